@@ -0,0 +1,157 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	opentracing "github.com/opentracing/opentracing-go"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/breaker"
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/tracing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/validation"
+)
+
+// middleware wraps an endpoint of a service like the daemon does
+type middleware func(service, method string, e endpoint.Endpoint) endpoint.Endpoint
+
+func newMiddleware(instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) middleware {
+	return func(service, method string, e endpoint.Endpoint) endpoint.Endpoint {
+		e = validation.Middleware()(e)
+		e = tracing.Middleware(tracer, service, method)(e)
+		e = instrumenting.Middleware(service, method)(e)
+		return logging.Middleware(logger, service, method)(e)
+	}
+}
+
+// guarded returns a middleware which guards the endpoints with b before wrapping them with m
+func (m middleware) guarded(b *breaker.Breaker) middleware {
+	return func(service, method string, e endpoint.Endpoint) endpoint.Endpoint {
+		return m(service, method, breaker.Middleware(b)(e))
+	}
+}
+
+// newBreaker returns the circuit breaker of a backend, it is nil if the breakers are disabled
+func newBreaker(c config.Breakers, backend string, logger log.Logger) *breaker.Breaker {
+	if !c.Enabled {
+		return nil
+	}
+	return breaker.New(backend, breaker.Settings{
+		Failures: uint32(c.Failures),
+		Timeout:  time.Duration(c.Timeout) * time.Second,
+	}, log.With(logger, "component", "breaker"))
+}
+
+func makeKMIServiceEndpoints(s kmi.Service, m middleware) kmi.Endpoints {
+	return kmi.Endpoints{
+		AddKMIEndpoint:    m("kmi", "AddKMI", kmi.MakeAddKMIEndpoint(s)),
+		RemoveKMIEndpoint: m("kmi", "RemoveKMI", kmi.MakeRemoveKMIEndpoint(s)),
+		GetKMIEndpoint:    m("kmi", "GetKMI", kmi.MakeGetKMIEndpoint(s)),
+		KMIEndpoint:       m("kmi", "KMI", kmi.MakeKMIEndpoint(s)),
+		UIsEndpoint:       m("kmi", "UIs", kmi.MakeUIsEndpoint(s)),
+	}
+}
+
+func makeRoutingServiceEndpoints(s routing.Service, c *routing.Collector, q *routing.QuotaEnforcer, m middleware) routing.Endpoints {
+	return routing.Endpoints{
+		CreateConfigEndpoint:          m("routing", "CreateConfig", routing.MakeCreateConfigEndpoint(s)),
+		EditConfigEndpoint:            m("routing", "EditConfig", routing.MakeEditConfigEndpoint(s)),
+		GetConfigEndpoint:             m("routing", "GetConfig", routing.MakeGetConfigEndpoint(s)),
+		RemoveConfigEndpoint:          m("routing", "RemoveConfig", routing.MakeRemoveConfigEndpoint(s)),
+		AddLocationEndpoint:           m("routing", "AddLocation", routing.MakeAddLocationEndpoint(s)),
+		RemoveLocationEndpoint:        m("routing", "RemoveLocation", routing.MakeRemoveLocationEndpoint(s)),
+		ChangeListenStatementEndpoint: m("routing", "ChangeListenStatement", routing.MakeChangeListenStatementEndpoint(s)),
+		AddServerNameEndpoint:         m("routing", "AddServerName", routing.MakeAddServerNameEndpoint(s)),
+		RemoveServerNameEndpoint:      m("routing", "RemoveServerName", routing.MakeRemoveServerNameEndpoint(s)),
+		ConfigurationsEndpoint:        m("routing", "Configurations", routing.MakeConfigurationsEndpoint(s)),
+		SetUpstreamEndpoint:           m("routing", "SetUpstream", routing.MakeSetUpstreamEndpoint(s)),
+		RemoveUpstreamEndpoint:        m("routing", "RemoveUpstream", routing.MakeRemoveUpstreamEndpoint(s)),
+		AddUpstreamMemberEndpoint:     m("routing", "AddUpstreamMember", routing.MakeAddUpstreamMemberEndpoint(s)),
+		RemoveUpstreamMemberEndpoint:  m("routing", "RemoveUpstreamMember", routing.MakeRemoveUpstreamMemberEndpoint(s)),
+		TrafficEndpoint:               m("routing", "Traffic", routing.MakeTrafficEndpoint(s)),
+		TrafficRateEndpoint:           m("routing", "TrafficRate", routing.MakeTrafficRateEndpoint(c)),
+		SetHTTPSPolicyEndpoint:        m("routing", "SetHTTPSPolicy", routing.MakeSetHTTPSPolicyEndpoint(s)),
+		SetErrorPageEndpoint:          m("routing", "SetErrorPage", routing.MakeSetErrorPageEndpoint(s)),
+		SetMaintenanceEndpoint:        m("routing", "SetMaintenance", routing.MakeSetMaintenanceEndpoint(s)),
+		SetProxyLimitsEndpoint:        m("routing", "SetProxyLimits", routing.MakeSetProxyLimitsEndpoint(s)),
+		SetSwitchEndpoint:             m("routing", "SetSwitch", routing.MakeSetSwitchEndpoint(s)),
+		RemoveSwitchEndpoint:          m("routing", "RemoveSwitch", routing.MakeRemoveSwitchEndpoint(s)),
+		QuotaEndpoint:                 m("routing", "Quota", routing.MakeQuotaEndpoint(q)),
+	}
+}
+
+func makeContainerServiceEndpoints(s container.Service, m middleware) container.Endpoints {
+	return container.Endpoints{
+		CreateContainerEndpoint: m("container", "CreateContainer", container.MakeCreateContainerEndpoint(s)),
+		RemoveContainerEndpoint: m("container", "RemoveContainer", container.MakeRemoveContainerEndpoint(s)),
+		InstancesEndpoint:       m("container", "Instances", container.MakeInstancesEndpoint(s)),
+		StopContainerEndpoint:   m("container", "StopContainer", container.MakeStopContainerEndpoint(s)),
+		ExecuteEndpoint:         m("container", "Execute", container.MakeExecuteEndpoint(s)),
+		GetEnvEndpoint:          m("container", "GetEnv", container.MakeGetEnvEndpoint(s)),
+		SetEnvEndpoint:          m("container", "SetEnv", container.MakeSetEnvEndpoint(s)),
+		IDForNameEndpoint:       m("container", "IDForName", container.MakeIDForNameEndpoint(s)),
+		GetContainerKMIEndpoint: m("container", "GetContainerKMI", container.MakeGetContainerKMIEndpoint(s)),
+		SetLinkEndpoint:         m("container", "SetLink", container.MakeSetLinkEndpoint(s)),
+		RemoveLinkEndpoint:      m("container", "RemoveLink", container.MakeRemoveLinkEndpoint(s)),
+		GetLinksEndpoint:        m("container", "GetLinks", container.MakeGetLinksEndpoint(s)),
+		ScaleInstanceEndpoint:   m("container", "ScaleInstance", container.MakeScaleInstanceEndpoint(s)),
+		CloneInstanceEndpoint:   m("container", "CloneInstance", container.MakeCloneInstanceEndpoint(s)),
+
+		CheckpointInstanceEndpoint: m("container", "CheckpointInstance", container.MakeCheckpointInstanceEndpoint(s)),
+		RestoreInstanceEndpoint:    m("container", "RestoreInstance", container.MakeRestoreInstanceEndpoint(s)),
+		CheckpointsEndpoint:        m("container", "Checkpoints", container.MakeCheckpointsEndpoint(s)),
+		RemoveCheckpointEndpoint:   m("container", "RemoveCheckpoint", container.MakeRemoveCheckpointEndpoint(s)),
+		PinInstanceEndpoint:        m("container", "PinInstance", container.MakePinInstanceEndpoint(s)),
+		SetPlacementEndpoint:       m("container", "SetPlacement", container.MakeSetPlacementEndpoint(s)),
+		ExportInstanceEndpoint:     m("container", "ExportInstance", container.MakeExportInstanceEndpoint(s)),
+	}
+}
+
+func makeFirewallServiceEndpoints(s firewall.Service, m middleware) firewall.Endpoints {
+	return firewall.Endpoints{
+		InitBridgeEndpoint:      m("firewall", "InitBridge", firewall.MakeInitBridgeEndpoint(s)),
+		AllowConnectionEndpoint: m("firewall", "AllowConnection", firewall.MakeAllowConnectionEndpoint(s)),
+		BlockConnectionEndpoint: m("firewall", "BlockConnection", firewall.MakeBlockConnectionEndpoint(s)),
+		AllowPortEndpoint:       m("firewall", "AllowPort", firewall.MakeAllowPortEndpoint(s)),
+		BlockPortEndpoint:       m("firewall", "BlockPort", firewall.MakeBlockPortEndpoint(s)),
+		RuleCountersEndpoint:    m("firewall", "RuleCounters", firewall.MakeRuleCountersEndpoint(s)),
+		ApplyPolicyEndpoint:     m("firewall", "ApplyPolicy", firewall.MakeApplyPolicyEndpoint(s)),
+		RemovePolicyEndpoint:    m("firewall", "RemovePolicy", firewall.MakeRemovePolicyEndpoint(s)),
+		DropsEndpoint:           m("firewall", "Drops", firewall.MakeDropsEndpoint(s)),
+		BanEndpoint:             m("firewall", "Ban", firewall.MakeBanEndpoint(s)),
+		UnbanEndpoint:           m("firewall", "Unban", firewall.MakeUnbanEndpoint(s)),
+	}
+}
+
+func makeNetworkServiceEndpoints(s network.Service, m middleware) network.Endpoints {
+	return network.Endpoints{
+		CreatePrimaryNetworkForContainerEndpoint: m("network", "CreatePrimaryNetworkForContainer", network.MakeCreatePrimaryNetworkForContainerEndpoint(s)),
+		CreateNetworkEndpoint:                    m("network", "CreateNetwork", network.MakeCreateNetworkEndpoint(s)),
+		RemoveNetworkByNameEndpoint:              m("network", "RemoveNetworkByName", network.MakeRemoveNetworkByNameEndpoint(s)),
+		AddContainerToNetworkEndpoint:            m("network", "AddContainerToNetwork", network.MakeAddContainerToNetworkEndpoint(s)),
+		RemoveContainerFromNetworkEndpoint:       m("network", "RemoveContainerFromNetwork", network.MakeRemoveContainerFromNetworkEndpoint(s)),
+		ExposePortToContainerEndpoint:            m("network", "ExposePortToContainer", network.MakeExposePortToContainerEndpoint(s)),
+		RemovePortFromContainerEndpoint:          m("network", "RemovePortFromContainer", network.MakeRemovePortFromContainerEndpoint(s)),
+		CreateBridgeEndpoint:                     m("network", "CreateBridge", network.MakeCreateBridgeEndpoint(s)),
+		RemoveBridgeEndpoint:                     m("network", "RemoveBridge", network.MakeRemoveBridgeEndpoint(s)),
+		AssignIPEndpoint:                         m("network", "AssignIP", network.MakeAssignIPEndpoint(s)),
+		ReleaseIPEndpoint:                        m("network", "ReleaseIP", network.MakeReleaseIPEndpoint(s)),
+		RequestPeeringEndpoint:                   m("network", "RequestPeering", network.MakeRequestPeeringEndpoint(s)),
+		ApprovePeeringEndpoint:                   m("network", "ApprovePeering", network.MakeApprovePeeringEndpoint(s)),
+		RevokePeeringEndpoint:                    m("network", "RevokePeering", network.MakeRevokePeeringEndpoint(s)),
+		PeeringsEndpoint:                         m("network", "Peerings", network.MakePeeringsEndpoint(s)),
+		RegisterNodeEndpoint:                     m("network", "RegisterNode", network.MakeRegisterNodeEndpoint(s)),
+		RemoveNodeEndpoint:                       m("network", "RemoveNode", network.MakeRemoveNodeEndpoint(s)),
+		NodesEndpoint:                            m("network", "Nodes", network.MakeNodesEndpoint(s)),
+		UsageEndpoint:                            m("network", "Usage", network.MakeUsageEndpoint(s)),
+		TotalUsageEndpoint:                       m("network", "TotalUsage", network.MakeTotalUsageEndpoint(s)),
+	}
+}
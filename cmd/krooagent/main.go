@@ -0,0 +1,378 @@
+// krooagent runs on the worker nodes of a cluster instead of a whole daemon. It serves the container,
+// firewall and network services of its node via gRPC, registers with the daemon of the control plane
+// by sending heartbeats and forwards the container events of its node to it.
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	"github.com/opencontainers/runc/libcontainer"
+	oldcontext "golang.org/x/net/context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/agent"
+	agentClient "github.com/kontainerooo/kontainer.ooo/pkg/agent/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/breaker"
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	containerPB "github.com/kontainerooo/kontainer.ooo/pkg/container/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/deadline"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
+	firewallPB "github.com/kontainerooo/kontainer.ooo/pkg/firewall/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kentheguru"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/lifecycle"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	networkPB "github.com/kontainerooo/kontainer.ooo/pkg/network/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/retry"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/tracing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/util"
+)
+
+func main() {
+	var (
+		configPath  string
+		checkConfig bool
+		dbWrapper   abstraction.DB
+	)
+
+	/* The agent reads the configuration of the daemon, settings it does not
+	 *  use are ignored. agent.controlPlane and agent.token are required. */
+	flag.StringVar(&configPath, "config", "", fmt.Sprintf("The configuration file, defaults to %s or the legacy %s.", config.DefaultPath, util.ConfigFileName))
+	flag.BoolVar(&checkConfig, "check-config", false, "Validate the configuration and exit.")
+	configFlags := config.NewFlags(flag.CommandLine)
+	flag.Parse()
+
+	cfg, err := config.Load(configPath, os.Environ(), configFlags)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err == nil && (cfg.Agent.ControlPlane == "" || cfg.Agent.Token == "") {
+		err = config.Errors{"agent: controlPlane and token are required"}
+	}
+	if checkConfig {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("configuration ok")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	conf := cfg.ConfigFile()
+	util.SetConfig(conf)
+
+	var logger log.Logger
+	{
+		logger, err = logging.NewLogger(os.Stdout, cfg.Log.Format)
+		if err != nil {
+			panic(err)
+		}
+		logger, err = logging.NewLevelLogger(logger, cfg.Log.Level, cfg.Log.Modules)
+		if err != nil {
+			panic(err)
+		}
+		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+		logger = log.With(logger, "caller", log.DefaultCaller)
+	}
+
+	level.Info(logger).Log("msg", "hello")
+	defer level.Info(logger).Log("msg", "goodbye")
+
+	tracer, tracerCloser := tracing.NewNoop()
+	if cfg.Tracing.Enabled {
+		tracer, tracerCloser, err = tracing.New(cfg.Tracing.ServiceName, cfg.Tracing.Agent, cfg.Tracing.Collector, cfg.Tracing.SampleRate)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	lc := lifecycle.NewManager(log.With(logger, "component", "lifecycle"))
+	lc.Add("tracer", lifecycle.Closer(tracerCloser))
+
+	metricsProvider := metrics.NewPrometheusProvider(metrics.Namespace)
+	m := newMiddleware(metrics.NewInstrumenting(metricsProvider), tracer, logger)
+	retryPolicy := retry.DefaultPolicy
+	retryPolicy.Metrics = retry.NewMetrics(metricsProvider)
+
+	if cfg.Database.Mock {
+		dbWrapper = testutils.NewMockDB()
+	} else {
+		// the database may still be starting, e.g. when the node boots together with the control plane
+		var db *gorm.DB
+		connectPolicy := retryPolicy
+		connectPolicy.Attempts = 10
+		err := connectPolicy.Do(context.Background(), "database.connect", func() (err error) {
+			db, err = gorm.Open(cfg.Database.Driver, cfg.Database.DSN)
+			return err
+		})
+		if err != nil {
+			panic(err)
+		}
+		lc.Add("database", lifecycle.Closer(db))
+		dbWrapper = abstraction.NewDB(db)
+	}
+	dbWrapper = breaker.NewDB(dbWrapper, newBreaker(cfg.Breakers, "database", logger))
+
+	node := cfg.Network.NodeName
+	if node == "" {
+		node, _ = os.Hostname()
+	}
+
+	address := cfg.Agent.Address
+	if address == "" {
+		address = cfg.Listen.GRPC
+	}
+
+	dialOption := grpc.WithInsecure()
+	if cfg.TLS.CertFile != "" {
+		dialOption = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))
+	}
+	conn, err := grpc.Dial(cfg.Agent.ControlPlane, dialOption, grpc.WithPerRPCCredentials(kentheguru.NewTokenCredentials(cfg.Agent.Token, cfg.TLS.CertFile != "")))
+	if err != nil {
+		panic(err)
+	}
+	lc.Add("control plane connection", lifecycle.Closer(conn))
+	controlPlane := agentClient.New(conn, logger)
+	if b := newBreaker(cfg.Breakers, "control plane", logger); b != nil {
+		controlPlane.HeartbeatEndpoint = breaker.Middleware(b)(controlPlane.HeartbeatEndpoint)
+		controlPlane.AgentsEndpoint = breaker.Middleware(b)(controlPlane.AgentsEndpoint)
+		controlPlane.RemoveAgentEndpoint = breaker.Middleware(b)(controlPlane.RemoveAgentEndpoint)
+		controlPlane.PublishEndpoint = breaker.Middleware(b)(controlPlane.PublishEndpoint)
+	}
+
+	// the events of the node only reach the control plane through the agent
+	bus := events.NewMemoryBus(node, 5*time.Second, log.With(logger, "component", "events"))
+	lc.Add("event bus", lifecycle.Closer(bus))
+
+	_, err = agent.Forward(node, controlPlane, bus, log.With(logger, "component", "agent"))
+	if err != nil {
+		panic(err)
+	}
+
+	// the KMI catalog and the router configurations are read from the database shared with the control plane
+	kmiService, err := kmi.NewService(dbWrapper)
+	if err != nil {
+		panic(err)
+	}
+	kmiEndpoints := makeKMIServiceEndpoints(kmiService, m)
+
+	routingService, err := routing.NewService(dbWrapper)
+	if err != nil {
+		panic(err)
+	}
+	collector := routing.NewCollector(routingService, "", log.With(logger, "service", "routing"))
+	// agents have no plans, the quota only reports the traffic
+	quotaEnforcer := routing.NewQuotaEnforcer(routingService, func(uint) (routing.Plan, error) {
+		return routing.Plan{}, nil
+	}, nil, nil, log.With(logger, "service", "routing"))
+	routingEndpoints := makeRoutingServiceEndpoints(routingService, collector, quotaEnforcer, m)
+
+	var firewallEndpoints *firewall.Endpoints
+	if cfg.IPTables.Enabled {
+		ipts, err := iptables.NewService(cfg.IPTables.Path, cfg.IPTables.RestorePath, dbWrapper)
+		if err != nil {
+			panic(err)
+		}
+
+		firewallOptions := firewall.Options{
+			LogDrops:   cfg.IPTables.LogDrops,
+			NFLogGroup: uint16(cfg.IPTables.NFLogGroup),
+		}
+		if firewallOptions.LogDrops != "" {
+			drops := firewall.NewDropCollector()
+			firewallOptions.Drops = drops
+			lc.Go("drop collector", func(stop <-chan struct{}) {
+				err := drops.Run(cfg.IPTables.DropLog, stop)
+				if err != nil {
+					level.Error(logger).Log("service", "firewall", "err", err)
+				}
+			})
+		}
+
+		firewallService, err := firewall.NewService(ipts, firewallOptions)
+		if err != nil {
+			panic(err)
+		}
+		fe := makeFirewallServiceEndpoints(firewallService, m)
+		firewallEndpoints = &fe
+	}
+
+	var networkEndpoints *network.Endpoints
+	if cfg.Network.Pool != "" {
+		pool, err := network.NewPool(cfg.Network.Pool, cfg.Network.Prefix)
+		if err != nil {
+			panic(err)
+		}
+
+		var overlay *network.Overlay
+		if cfg.Network.NodeName != "" {
+			overlay = &network.Overlay{
+				Node:    cfg.Network.NodeName,
+				Address: net.ParseIP(cfg.Network.NodeAddress),
+				Driver:  network.NewIPOverlayDriver(),
+			}
+		}
+
+		networkService, err := network.NewService(abstraction.NewDCLI(), dbWrapper, firewallEndpoints, pool, network.NewIPBridgeDriver(), overlay)
+		if err != nil {
+			panic(err)
+		}
+
+		if overlay != nil {
+			overlaySync := network.NewOverlaySync(networkService, log.With(logger, "service", "network"))
+			lc.Go("overlay sync", func(stop <-chan struct{}) {
+				overlaySync.Run(30*time.Second, stop)
+			})
+		}
+
+		ne := makeNetworkServiceEndpoints(networkService, m)
+		networkEndpoints = &ne
+	}
+
+	factory, err := libcontainer.New(cfg.Paths.Container, libcontainer.Cgroupfs, libcontainer.InitArgs(cfg.Paths.InitBinary, "init"), libcontainer.CriuPath(cfg.Checkpoints.CRIU))
+	if err != nil {
+		panic(err)
+	}
+
+	// the agent reads the secrets of the instances it runs from the secrets backend of the daemon
+	secrets, err := cfg.SecretBackend()
+	if err != nil {
+		panic(err)
+	}
+
+	containerService, err := container.NewService(factory, dbWrapper, &kmiEndpoints, &routingEndpoints, firewallEndpoints, bus, secrets, nil, logger)
+	if err != nil {
+		panic(err)
+	}
+	lc.Add("container checkpoints", containerService.CheckpointRunning)
+	containerEndpoints := makeContainerServiceEndpoints(containerService, m.guarded(newBreaker(cfg.Breakers, "container runtime", logger)))
+
+	rt := containerService.Runtime(context.Background())
+	heartbeat := agent.NewHeartbeat(controlPlane, agent.Agent{
+		Name:           node,
+		Address:        address,
+		Cgroup:         string(rt.Cgroup),
+		Runtime:        rt.Runtime,
+		RuntimeVersion: rt.RuntimeVersion,
+		Kernel:         rt.Kernel,
+		Storage:        rt.Storage,
+		CRIU:           rt.CRIU,
+		GPU:            rt.GPU,
+		Features:       rt.Features(),
+	}, func() (uint, error) {
+		return containerService.CountRunning(context.Background())
+	}, log.With(logger, "component", "agent"))
+	lc.Go("heartbeat", func(stop <-chan struct{}) {
+		heartbeat.Run(time.Duration(cfg.Agent.Heartbeat)*time.Second, stop)
+	})
+
+	errc := make(chan error)
+	err = startGRPCTransport(errc, logger, lc, cfg.Listen.GRPC, cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.Agent.Token, time.Duration(cfg.RequestTimeout)*time.Second, containerEndpoints, firewallEndpoints, networkEndpoints)
+	if err != nil {
+		panic(err)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		errc <- fmt.Errorf("%s", <-interrupt)
+	}()
+
+	level.Info(logger).Log("exit", <-errc)
+
+	err = lc.Shutdown(time.Duration(cfg.ShutdownTimeout) * time.Second)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+	}
+}
+
+// authorize rejects the calls which do not send the token of the agent, the control plane sends the
+// same token the agent authenticates with
+func authorize(token string) grpc.UnaryServerInterceptor {
+	expected := []byte("Bearer " + token)
+
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok || len(md["authorization"]) == 0 || subtle.ConstantTimeCompare([]byte(md["authorization"][0]), expected) != 1 {
+			return nil, grpc.Errorf(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// startGRPCTransport serves the services of the node via gRPC to callers sending token, the
+// connections are secured with TLS if a certificate is given. The calls are cancelled after timeout,
+// the deadline of the control plane is passed on by gRPC. The firewall and network services
+// are only served if they are configured. The server runs in the background until it is stopped by lc.
+func startGRPCTransport(errc chan error, logger log.Logger, lc *lifecycle.Manager, grpcAddr, certFile, keyFile, token string, timeout time.Duration, ce container.Endpoints, fe *firewall.Endpoints, ne *network.Endpoints) error {
+	logger = log.With(logger, "transport", "gRPC")
+	ctx := context.Background()
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(logging.UnaryServerInterceptor(), authorize(token), deadline.UnaryServerInterceptor(timeout), breaker.UnaryServerInterceptor()),
+	}
+	if certFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	ln, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return err
+	}
+
+	s := grpc.NewServer(opts...)
+
+	containerPB.RegisterContainerServiceServer(s, container.MakeGRPCServer(ctx, ce, logger))
+
+	if fe != nil {
+		firewallPB.RegisterFirewallServiceServer(s, firewall.MakeGRPCServer(ctx, *fe, logger))
+	}
+
+	if ne != nil {
+		networkPB.RegisterNetworkServiceServer(s, network.MakeGRPCServer(ctx, *ne, logger))
+	}
+
+	lc.Add("gRPC transport", lifecycle.GRPCServer(s))
+
+	level.Info(logger).Log("addr", grpcAddr)
+	go func() {
+		err := s.Serve(ln)
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return nil
+}
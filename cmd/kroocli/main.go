@@ -1,35 +1,71 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/abiosoft/ishell"
 	"github.com/go-kit/kit/log"
 	"github.com/kontainerooo/kontainer.ooo/pkg/cli"
-	"google.golang.org/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/client"
 )
 
 func main() {
 	var (
-		grpcAddr = ":8082"
+		profileName  string
+		profilesPath string
+		jsonOutput   bool
 	)
 
+	/* kroocli runs a single command if one is given as arguments, e.g.
+	 *  `kroocli -json container list`, otherwise an interactive shell is started. */
+	flag.StringVar(&profileName, "profile", "", "The profile to connect with, defaults to the current one.")
+	flag.StringVar(&profilesPath, "profiles", cli.DefaultProfilesPath(), "The file the profiles are stored in.")
+	flag.BoolVar(&jsonOutput, "json", false, "Print responses as JSON.")
+	flag.Parse()
+
+	profiles, err := cli.LoadProfiles(profilesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
+	profile, err := profiles.Get(profileName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
 	shell := ishell.New()
 	shell.SetHomeHistoryPath(".kroocli_history")
-	shell.Println("Kontainer.ooo interactive shell")
 
-	logger := log.NewLogfmtLogger(os.Stdout)
+	opts := profile.ClientOptions()
+	opts.Logger = log.NewLogfmtLogger(os.Stderr)
 
-	conn, err := grpc.Dial(grpcAddr, grpc.WithInsecure(), grpc.WithTimeout(time.Second))
+	c, err := client.Dial(opts)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v", err)
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
-	defer conn.Close()
+	defer c.Close()
 
-	cli.InitShell(shell, conn, logger)
+	cli.InitShell(shell, c, cli.Options{
+		JSON:         jsonOutput,
+		Profile:      profile,
+		Profiles:     profiles,
+		ProfilesPath: profilesPath,
+	})
 
+	if flag.NArg() > 0 {
+		err = shell.Process(flag.Args()...)
+		if err != nil {
+			c.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	shell.Println("Kontainer.ooo interactive shell")
 	shell.Run()
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/alert"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+)
+
+// instanceUsage returns the resource usage of the instances and replicas running on this node, the drops are
+// only counted if drops is set
+func instanceUsage(containers container.Service, drops *firewall.DropCollector) alert.UsageFunc {
+	return func() ([]alert.Usage, error) {
+		us, err := containers.Usage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		dropped := map[string]uint64{}
+		if drops != nil {
+			dropped = drops.Dropped()
+		}
+
+		usage := make([]alert.Usage, len(us))
+		for i, u := range us {
+			usage[i] = alert.Usage{
+				RefID:       u.RefID,
+				Instance:    u.ContainerName,
+				CPU:         u.CPU,
+				Memory:      u.Memory,
+				MemoryLimit: u.MemoryLimit,
+				Started:     u.Started,
+				Dropped:     dropped[u.IP],
+			}
+		}
+		return usage, nil
+	}
+}
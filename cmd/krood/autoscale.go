@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/autoscale"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
+
+// scaleUsage returns the CPU usage of the instances and replicas running on this node, replicas are
+// counted for the instance they replicate, replicas of instances which are not running are left out
+func scaleUsage(containers container.Service) autoscale.UsageFunc {
+	return func() ([]autoscale.Usage, error) {
+		us, err := containers.Usage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		names := make(map[string]string)
+		for _, u := range us {
+			names[u.ContainerID] = u.ContainerName
+		}
+
+		usage := []autoscale.Usage{}
+		for _, u := range us {
+			instance := u.ContainerName
+			if u.ReplicaOf != "" {
+				instance = names[u.ReplicaOf]
+			}
+			if instance == "" {
+				continue
+			}
+
+			usage = append(usage, autoscale.Usage{
+				RefID:     u.RefID,
+				Instance:  instance,
+				Container: u.ContainerID,
+				CPU:       u.CPU,
+				Started:   u.Started,
+			})
+		}
+		return usage, nil
+	}
+}
+
+// scaleRate returns the request rates of the routing configurations named like the instances,
+// the replicas of an instance are the members of the upstreams of its configuration
+func scaleRate(collector *routing.Collector) autoscale.RateFunc {
+	return func(refID uint, instance string) (float64, bool) {
+		return collector.Rate(refID, instance)
+	}
+}
+
+// instanceScaler scales the instances of the autoscaling policies with the container service
+type instanceScaler struct {
+	containers container.Service
+}
+
+func (s instanceScaler) instance(refID uint, name string) (container.Container, error) {
+	for _, c := range s.containers.Instances(context.Background(), refID) {
+		if c.ContainerName != name {
+			continue
+		}
+		if c.ReplicaOf != "" {
+			return container.Container{}, errors.New("a replica can not be scaled")
+		}
+		return c, nil
+	}
+	return container.Container{}, errors.New("instance does not exist")
+}
+
+func (s instanceScaler) Replicas(refID uint, instance string) (uint, error) {
+	c, err := s.instance(refID, instance)
+	if err != nil {
+		return 0, err
+	}
+	return c.Replicas, nil
+}
+
+func (s instanceScaler) Scale(refID uint, instance string, replicas uint) error {
+	c, err := s.instance(refID, instance)
+	if err != nil {
+		return err
+	}
+	return s.containers.ScaleInstance(context.Background(), refID, c.ContainerID, replicas)
+}
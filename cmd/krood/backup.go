@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/jinzhu/gorm"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/backup"
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+/* backupParts returns the parts of the installation in the order they are
+ *  restored, the database comes first so the firewall rules are replayed from
+ *  the restored tables. The state of libcontainer is not backed up, the
+ *  containers are created again from the restored volumes. Artifacts are only
+ *  part of the archive if they are kept on the node. */
+func backupParts(cfg config.Config, rules backup.RuleExporter) []backup.Part {
+	parts := []backup.Part{}
+	if !cfg.Database.Mock {
+		parts = append(parts, backup.Postgres(cfg.Database.DSN))
+	}
+	if cfg.IPTables.Enabled {
+		parts = append(parts, backup.Firewall(rules, cfg.IPTables.RestorePath))
+	}
+	if cfg.ManagedDatabases.Enabled {
+		parts = append(parts, backup.Directory("dumps", cfg.ManagedDatabases.DumpPath))
+	}
+	if cfg.Snapshots.Enabled {
+		parts = append(parts, backup.Directory("snapshots", cfg.Snapshots.LocalPath), backup.Directory("restores", cfg.Snapshots.RestorePath))
+	}
+	if cfg.Storage.Backend == storage.Local {
+		parts = append(parts, backup.Directory("artifacts", cfg.Storage.LocalPath))
+	}
+	if cfg.ACME.CertificatePath != "" {
+		parts = append(parts, backup.Directory("certificates", cfg.ACME.CertificatePath))
+	}
+	return append(parts,
+		backup.Directory("modules", cfg.Paths.Rootfs),
+		backup.Directory("volumes", cfg.Paths.Customer),
+	)
+}
+
+// fetchBackup copies the archive key of the backups in the artifact store into a temporary file
+func fetchBackup(cfg config.Config, key string) (string, error) {
+	store, err := artifactStore(cfg.Storage)
+	if err != nil {
+		return "", err
+	}
+
+	r, err := storage.Prefix(store, storage.Backups).Get(key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	f, err := ioutil.TempFile("", "kroo-backup")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// uploadBackup copies the archive in file into the backups of an s3 artifact store as node/<name of file>
+func uploadBackup(cfg config.Config, file, node string) (string, error) {
+	store, err := artifactStore(cfg.Storage)
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	key := path.Join(node, filepath.Base(file))
+	return key, storage.Prefix(store, storage.Backups).Put(key, f, info.Size())
+}
+
+// runBackup writes a backup to file, or restores it if restore is set. With an s3 artifact store
+// backups are uploaded too and storage:<node>/<archive> restores one of them
+func runBackup(cfg config.Config, file string, restore bool, logger log.Logger) error {
+	logger = log.With(logger, "component", "backup", "archive", file)
+
+	if restore {
+		if strings.HasPrefix(file, storage.PathPrefix) {
+			fetched, err := fetchBackup(cfg, strings.TrimPrefix(file, storage.PathPrefix))
+			if err != nil {
+				return err
+			}
+			defer os.Remove(fetched)
+			file = fetched
+		}
+
+		m, err := backup.Restore(file, backupParts(cfg, nil))
+		if err != nil {
+			return err
+		}
+		level.Info(logger).Log("msg", "restored", "node", m.Node, "created", m.Created, "files", len(m.Files))
+		return nil
+	}
+
+	var rules backup.RuleExporter
+	if cfg.IPTables.Enabled {
+		if cfg.Database.Mock {
+			return fmt.Errorf("the firewall rules of a mock database can't be backed up")
+		}
+
+		db, err := gorm.Open(cfg.Database.Driver, cfg.Database.DSN)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		rules, err = iptables.NewService(cfg.IPTables.Path, cfg.IPTables.RestorePath, abstraction.NewDB(db))
+		if err != nil {
+			return err
+		}
+	}
+
+	node := cfg.Network.NodeName
+	if node == "" {
+		node, _ = os.Hostname()
+	}
+
+	m, err := backup.Create(file, node, backupParts(cfg, rules))
+	if err != nil {
+		return err
+	}
+	level.Info(logger).Log("msg", "created", "parts", len(m.Parts), "files", len(m.Files))
+
+	if cfg.Storage.Backend == storage.S3 {
+		key, err := uploadBackup(cfg, file, node)
+		if err != nil {
+			return err
+		}
+		level.Info(logger).Log("msg", "uploaded", "key", storage.PathPrefix+key)
+	}
+	return nil
+}
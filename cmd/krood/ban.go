@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/ban"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
+
+/* banTargets returns the logs of the jails on this node. Container jails watch the standard output and error
+ *  of an instance and ban from its address, access jails watch the access log of a routing configuration and
+ *  ban from its listen address and port, which every configuration listening there shares. */
+func banTargets(containers container.Service, router routing.Service, root, node string) ban.TargetFunc {
+	return func(j ban.Jail) (ban.Target, bool, error) {
+		if j.Source == ban.Access {
+			r := routing.RouterConfig{}
+			err := router.GetRouterConfig(j.RefID, j.Target, &r)
+			if err != nil || r.AccessLog.Path == "" {
+				return ban.Target{}, false, nil
+			}
+
+			t := ban.Target{
+				Paths: []string{r.AccessLog.Path},
+			}
+			if r.ListenStatement != nil {
+				ip := net.ParseIP(string(r.ListenStatement.IPAddress))
+				if ip != nil && !ip.IsUnspecified() {
+					t.Destination = ip.String()
+				}
+				t.Port = r.ListenStatement.Port
+			}
+			return t, true, nil
+		}
+
+		for _, c := range containers.Instances(context.Background(), j.RefID) {
+			if c.ContainerName != j.Target || c.Node != node {
+				continue
+			}
+
+			dir := filepath.Join(root, fmt.Sprintf("%d", j.RefID), c.ContainerID)
+			ip, err := ioutil.ReadFile(filepath.Join(dir, ".ip"))
+			if os.IsNotExist(err) {
+				// the instance has no address in a bridge network its sources could be banned from
+				return ban.Target{}, false, nil
+			}
+			if err != nil {
+				return ban.Target{}, false, err
+			}
+			address := strings.TrimSpace(string(ip))
+			if i := strings.Index(address, "/"); i != -1 {
+				address = address[:i]
+			}
+
+			return ban.Target{
+				Paths:       []string{filepath.Join(dir, container.StdoutLog), filepath.Join(dir, container.StderrLog)},
+				Destination: address,
+			}, true, nil
+		}
+		return ban.Target{}, false, nil
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/billing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+)
+
+/* suspendInstances stops the instances and replicas of suspended users, they are not started
+ *  again once the user paid, so users decide which instances they need. */
+func suspendInstances(containers container.Service) billing.SuspendHook {
+	return func(refID uint, suspended bool) error {
+		if !suspended {
+			return nil
+		}
+
+		for _, c := range containers.Instances(context.Background(), refID) {
+			err := containers.StopContainer(context.Background(), refID, c.ContainerID)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/breaker"
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns"
+)
+
+// newBreaker returns the circuit breaker of a backend, it is nil if the breakers are disabled
+func newBreaker(c config.Breakers, backend string, logger log.Logger) *breaker.Breaker {
+	if !c.Enabled {
+		return nil
+	}
+	return breaker.New(backend, breaker.Settings{
+		Failures: uint32(c.Failures),
+		Timeout:  time.Duration(c.Timeout) * time.Second,
+	}, log.With(logger, "component", "breaker"))
+}
+
+// breakerProvider guards the calls to the API of a name server with a circuit breaker
+type breakerProvider struct {
+	dns.Provider
+	breaker *breaker.Breaker
+}
+
+func (p breakerProvider) SetRecords(name string, typ string, ttl uint, values []string) error {
+	return p.breaker.Execute(func() error {
+		return p.Provider.SetRecords(name, typ, ttl, values)
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog"
+)
+
+/* instanceLogs returns the log files of the instances and replicas of every user with a
+ *  directory below the customer path, the files of instances on other nodes do not exist. */
+func instanceLogs(containers container.Service, root string) containerlog.SourceFunc {
+	return func() ([]containerlog.Source, error) {
+		dirs, err := ioutil.ReadDir(root)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		sources := []containerlog.Source{}
+		for _, d := range dirs {
+			refID, err := strconv.ParseUint(d.Name(), 10, 32)
+			if err != nil || !d.IsDir() {
+				continue
+			}
+
+			for _, c := range containers.Instances(context.Background(), uint(refID)) {
+				dir := filepath.Join(root, fmt.Sprintf("%d", refID), c.ContainerID)
+				sources = append(sources, containerlog.Source{
+					RefID:    uint(refID),
+					Instance: c.ContainerName,
+					Stream:   containerlog.Stdout,
+					Path:     filepath.Join(dir, container.StdoutLog),
+				}, containerlog.Source{
+					RefID:    uint(refID),
+					Instance: c.ContainerName,
+					Stream:   containerlog.Stderr,
+					Path:     filepath.Join(dir, container.StderrLog),
+				})
+			}
+		}
+		return sources, nil
+	}
+}
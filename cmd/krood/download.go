@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/download"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+// newDownloadSigner returns the signer of the download URLs, it is nil if they are disabled
+func newDownloadSigner(c config.Downloads) *download.Signer {
+	if !c.Enabled {
+		return nil
+	}
+	return download.NewSigner([]byte(c.Secret), c.BaseURL, time.Duration(c.TTL)*time.Second)
+}
+
+// downloadURLs returns the download URLs of the artifacts of kind, it is nil if they are disabled
+func downloadURLs(s *download.Signer, kind download.Kind) download.URLs {
+	if s == nil {
+		return nil
+	}
+	return download.For(s, kind)
+}
+
+// containerExports returns where the archives of exported instances are kept, instances can not be
+// exported if download URLs are disabled
+func containerExports(s *download.Signer, artifacts storage.Store) *container.Exports {
+	if s == nil {
+		return nil
+	}
+	return &container.Exports{
+		Store: storage.Prefix(artifacts, storage.ContainerExports),
+		URLs:  downloadURLs(s, download.ContainerExports),
+	}
+}
+
+// signBackup prints the signed download URL of the backup <node>/<archive> of an s3 artifact store
+func signBackup(cfg config.Config, key string) error {
+	signer := newDownloadSigner(cfg.Downloads)
+	if signer == nil {
+		return fmt.Errorf("downloads are disabled")
+	}
+
+	store, err := artifactStore(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	r, err := storage.Prefix(store, storage.Backups).Get(key)
+	if err != nil {
+		return err
+	}
+	r.Close()
+
+	u, expires := downloadURLs(signer, download.Backups).URL(key, path.Base(key))
+	fmt.Println(u)
+	fmt.Printf("expires %s\n", expires.UTC().Format(time.RFC3339))
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/billing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/export"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/login"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshkey"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+)
+
+// exportAccount is the personal data of a user without the password hash and the salt
+type exportAccount struct {
+	ID       uint         `json:"id"`
+	Username string       `json:"username"`
+	Name     string       `json:"name"`
+	Surname  string       `json:"surname"`
+	Email    string       `json:"email"`
+	Phone    string       `json:"phone"`
+	Image    string       `json:"image"`
+	Address  user.Address `json:"address"`
+}
+
+// exportInstance is the metadata of an instance, its environment is left out since it holds the secrets
+// of the applications
+type exportInstance struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Module    string `json:"module"`
+	Image     string `json:"image"`
+	Node      string `json:"node"`
+	ReplicaOf string `json:"replicaOf,omitempty"`
+	Replicas  uint   `json:"replicas"`
+	Pinned    bool   `json:"pinned"`
+}
+
+// invoicePart exports the invoices and credit notes of a user as a list and as one pdf per document
+// named after its number
+type invoicePart struct {
+	billing billing.Service
+}
+
+func (p invoicePart) Name() string {
+	return "invoices"
+}
+
+func (p invoicePart) Export(ctx context.Context, refID uint, w *export.Writer) error {
+	invoices := []billing.Invoice{}
+	err := p.billing.Invoices(refID, &invoices)
+	if err != nil {
+		return err
+	}
+
+	err = w.JSON("invoices.json", invoices)
+	if err != nil {
+		return err
+	}
+
+	for _, i := range invoices {
+		pdf, err := p.billing.Document(refID, i.ID, billing.PDF)
+		if err != nil {
+			return err
+		}
+
+		name := i.Number
+		if name == "" {
+			name = fmt.Sprintf("%d", i.ID)
+		}
+		err = w.File(name+".pdf", pdf)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportParts returns the parts of the data exports of the users. invoices is nil if billing is disabled,
+// devices if the protection of the logins is disabled and recordings empty if the SSH gateway is disabled,
+// the recordings of the SSH sessions are only exported from the node writing the export.
+func exportParts(users user.Service, containers container.Service, keys sshkey.Service, invoices billing.Service, impersonations *impersonation.Sessions, devices *login.Guard, recordings string) []export.Part {
+	parts := []export.Part{
+		export.JSON("account", func(ctx context.Context, refID uint) (interface{}, error) {
+			u := &user.User{}
+			err := users.GetUser(refID, u)
+			if err != nil {
+				return nil, err
+			}
+
+			return exportAccount{
+				ID:       u.ID,
+				Username: u.Username,
+				Name:     u.Name,
+				Surname:  u.Surname,
+				Email:    u.Email,
+				Phone:    u.Phone,
+				Image:    u.Image,
+				Address:  u.Address,
+			}, nil
+		}),
+		export.JSON("instances", func(ctx context.Context, refID uint) (interface{}, error) {
+			instances := []exportInstance{}
+			for _, c := range containers.Instances(ctx, refID) {
+				instances = append(instances, exportInstance{
+					ID:        c.ContainerID,
+					Name:      c.ContainerName,
+					Module:    c.Module,
+					Image:     c.Image,
+					Node:      c.Node,
+					ReplicaOf: c.ReplicaOf,
+					Replicas:  c.Replicas,
+					Pinned:    c.Pinned,
+				})
+			}
+			return instances, nil
+		}),
+		export.JSON("ssh-keys", func(ctx context.Context, refID uint) (interface{}, error) {
+			ks := []sshkey.Key{}
+			err := keys.Keys(refID, &ks)
+			return ks, err
+		}),
+		export.JSON("audit", func(ctx context.Context, refID uint) (interface{}, error) {
+			all := []impersonation.Entry{}
+			err := impersonations.Trail(0, &all)
+			if err != nil {
+				return nil, err
+			}
+
+			entries := []impersonation.Entry{}
+			for _, e := range all {
+				if e.UserID == refID {
+					entries = append(entries, e)
+				}
+			}
+			return entries, nil
+		}),
+	}
+
+	if invoices != nil {
+		parts = append(parts, invoicePart{billing: invoices})
+	}
+	if devices != nil {
+		parts = append(parts, export.JSON("login-devices", func(ctx context.Context, refID uint) (interface{}, error) {
+			ds := []login.Device{}
+			err := devices.Devices(refID, &ds)
+			return ds, err
+		}))
+	}
+	if recordings != "" {
+		parts = append(parts, export.Directory("ssh-sessions", recordings))
+	}
+	return parts
+}
@@ -0,0 +1,66 @@
+package main
+
+// idempotentMethods are the gRPC methods which accept an idempotency key, they create or remove
+// resources and would do so twice if a client retried them after a network failure
+var idempotentMethods = []string{
+	"container.ContainerService/CreateContainer",
+	"container.ContainerService/RemoveContainer",
+	"container.ContainerService/CloneInstance",
+	"container.ContainerService/CheckpointInstance",
+	"container.ContainerService/RemoveCheckpoint",
+	"module.ModuleService/CreateContainerModule",
+	"kmi.KMIService/AddKMI",
+	"kmi.KMIService/RemoveKMI",
+	"firewall.FirewallService/AllowConnection",
+	"firewall.FirewallService/BlockConnection",
+	"firewall.FirewallService/AllowPort",
+	"firewall.FirewallService/BlockPort",
+	"firewall.FirewallService/ApplyPolicy",
+	"firewall.FirewallService/RemovePolicy",
+	"firewall.FirewallService/Ban",
+	"firewall.FirewallService/Unban",
+	"network.NetworkService/CreateNetwork",
+	"network.NetworkService/RemoveNetworkByName",
+	"network.NetworkService/ExposePortToContainer",
+	"network.NetworkService/RemovePortFromContainer",
+	"routing.RoutingService/CreateConfig",
+	"routing.RoutingService/RemoveConfig",
+	"dns.DNSService/CreateRecord",
+	"dns.DNSService/RemoveRecord",
+	"user.UserService/CreateUser",
+	"user.UserService/DeleteUser",
+	"webhook.WebhookService/CreateWebhook",
+	"webhook.WebhookService/RemoveWebhook",
+	"sshkey.SSHKeyService/AddKey",
+	"sshkey.SSHKeyService/RemoveKey",
+	"ports.PortService/AllocatePort",
+	"ports.PortService/ReservePort",
+	"ports.PortService/ReleasePort",
+	// CreateDatabase is left out, its response carries the password of the database which is not cached
+	"database.DatabaseService/RemoveDatabase",
+	"snapshot.SnapshotService/CreateSchedule",
+	"snapshot.SnapshotService/RemoveSchedule",
+	"snapshot.SnapshotService/RemoveSnapshot",
+	"snapshot.SnapshotService/RestoreSnapshot",
+	"cronjob.CronJobService/CreateJob",
+	"cronjob.CronJobService/RemoveJob",
+	"alert.AlertService/CreateRule",
+	"alert.AlertService/RemoveRule",
+	"ban.BanService/CreateJail",
+	"ban.BanService/RemoveJail",
+	"ban.BanService/Unban",
+	// CreatePeer and RotateKey are left out, their responses carry private keys which are not cached
+	"wireguard.WireGuardService/RemovePeer",
+	"billing.BillingService/CreateCreditNote",
+	"export.ExportService/ExportUserData",
+	"management.ManagementService/CreateUser",
+	"management.ManagementService/DeleteUser",
+	"management.ManagementService/CreateModule",
+	"management.ManagementService/DeleteModule",
+	"management.ManagementService/CreateInstance",
+	"management.ManagementService/DeleteInstance",
+	"management.ManagementService/CreateDomain",
+	"management.ManagementService/DeleteDomain",
+	"management.ManagementService/CreatePolicy",
+	"management.ManagementService/DeletePolicy",
+}
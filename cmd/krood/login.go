@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/login"
+)
+
+// newLoginGuard returns the protection of the logins, it is nil if it is disabled
+func newLoginGuard(db abstraction.DB, c config.Login, mails login.Notifier, logger log.Logger) (*login.Guard, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	var captcha login.Captcha
+	if c.CaptchaURL != "" {
+		captcha = login.NewSiteVerify(c.CaptchaURL, c.CaptchaSecret, &http.Client{Timeout: 10 * time.Second})
+	}
+
+	window := time.Duration(c.Window) * time.Second
+	lockout := time.Duration(c.Lockout) * time.Second
+	maxLockout := time.Duration(c.MaxLockout) * time.Second
+	return login.NewGuard(db, captcha, mails, login.Options{
+		Account: login.Policy{
+			Threshold: c.AccountThreshold,
+			Delay:     lockout,
+			MaxDelay:  maxLockout,
+			Window:    window,
+		},
+		Source: login.Policy{
+			Threshold: c.SourceThreshold,
+			Delay:     lockout,
+			MaxDelay:  maxLockout,
+			Window:    window,
+		},
+		CaptchaAfter: c.CaptchaAfter,
+		Notify:       c.Notify,
+	}, logger)
+}
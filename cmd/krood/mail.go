@@ -0,0 +1,59 @@
+package main
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+)
+
+// mailRecipients looks up the email addresses of the users
+type mailRecipients struct {
+	users user.Service
+}
+
+func (r mailRecipients) Email(refID uint) (string, error) {
+	u := &user.User{}
+	err := r.users.GetUser(refID, u)
+	return u.Email, err
+}
+
+// lazyMails passes notifications on to the mail service once it was created, they are dropped while
+// mails are disabled
+type lazyMails struct {
+	mails *mail.Service
+}
+
+func (m lazyMails) Notify(refID uint, template string, data interface{}) (uint, error) {
+	if *m.mails == nil {
+		return 0, nil
+	}
+	return (*m.mails).Notify(refID, template, data)
+}
+
+// mailProvider returns the provider configured in c, the sandbox keeps the last 100 messages
+func mailProvider(c config.Mail, logger log.Logger) mail.Provider {
+	if c.Sandbox {
+		return mail.NewSandbox(100, logger)
+	}
+
+	switch c.Provider {
+	case mail.SendGrid:
+		return mail.NewSendGridProvider(mail.APIOptions{
+			Endpoint: c.SendGrid.Endpoint,
+			APIKey:   c.SendGrid.APIKey,
+		})
+	case mail.Mailgun:
+		return mail.NewMailgunProvider(mail.APIOptions{
+			Endpoint: c.Mailgun.Endpoint,
+			APIKey:   c.Mailgun.APIKey,
+			Domain:   c.Mailgun.Domain,
+		})
+	}
+	return mail.NewSMTPProvider(mail.SMTPOptions{
+		Host:     c.SMTP.Host,
+		Port:     c.SMTP.Port,
+		Username: c.SMTP.Username,
+		Password: c.SMTP.Password,
+	})
+}
@@ -2,473 +2,4104 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/gorilla/websocket"
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	"github.com/opencontainers/runc/libcontainer"
+	opentracing "github.com/opentracing/opentracing-go"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/admin"
+	adminPB "github.com/kontainerooo/kontainer.ooo/pkg/admin/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/agent"
+	agentPB "github.com/kontainerooo/kontainer.ooo/pkg/agent/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/alert"
+	alertPB "github.com/kontainerooo/kontainer.ooo/pkg/alert/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/autoscale"
+	autoscalePB "github.com/kontainerooo/kontainer.ooo/pkg/autoscale/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ban"
+	banPB "github.com/kontainerooo/kontainer.ooo/pkg/ban/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/billing"
+	billingPB "github.com/kontainerooo/kontainer.ooo/pkg/billing/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/breaker"
+	"github.com/kontainerooo/kontainer.ooo/pkg/cache"
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
 	"github.com/kontainerooo/kontainer.ooo/pkg/container"
 	containerPB "github.com/kontainerooo/kontainer.ooo/pkg/container/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog"
+	containerlogPB "github.com/kontainerooo/kontainer.ooo/pkg/containerlog/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/cronjob"
+	cronjobPB "github.com/kontainerooo/kontainer.ooo/pkg/cronjob/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/database"
+	databasePB "github.com/kontainerooo/kontainer.ooo/pkg/database/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/deadline"
+	"github.com/kontainerooo/kontainer.ooo/pkg/deploy"
+	deployPB "github.com/kontainerooo/kontainer.ooo/pkg/deploy/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns"
+	dnsPB "github.com/kontainerooo/kontainer.ooo/pkg/dns/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/download"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/export"
+	exportPB "github.com/kontainerooo/kontainer.ooo/pkg/export/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/feature"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
+	firewallPB "github.com/kontainerooo/kontainer.ooo/pkg/firewall/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/gateway"
+	"github.com/kontainerooo/kontainer.ooo/pkg/health"
+	"github.com/kontainerooo/kontainer.ooo/pkg/idempotency"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kentheguru"
+	kentheguruPB "github.com/kontainerooo/kontainer.ooo/pkg/kentheguru/pb"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
 	kmiPB "github.com/kontainerooo/kontainer.ooo/pkg/kmi/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/leader"
+	"github.com/kontainerooo/kontainer.ooo/pkg/lifecycle"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/maintenance"
+	"github.com/kontainerooo/kontainer.ooo/pkg/management"
+	managementPB "github.com/kontainerooo/kontainer.ooo/pkg/management/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
 	"github.com/kontainerooo/kontainer.ooo/pkg/module"
 	modulePB "github.com/kontainerooo/kontainer.ooo/pkg/module/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	networkPB "github.com/kontainerooo/kontainer.ooo/pkg/network/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ports"
+	portsPB "github.com/kontainerooo/kontainer.ooo/pkg/ports/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ratelimit"
+	"github.com/kontainerooo/kontainer.ooo/pkg/registry"
+	"github.com/kontainerooo/kontainer.ooo/pkg/resolver"
+	resolverPB "github.com/kontainerooo/kontainer.ooo/pkg/resolver/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/retry"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing/acme"
 	routingPB "github.com/kontainerooo/kontainer.ooo/pkg/routing/pb"
+	routingTemplate "github.com/kontainerooo/kontainer.ooo/pkg/routing/template"
+	"github.com/kontainerooo/kontainer.ooo/pkg/site"
+	sitePB "github.com/kontainerooo/kontainer.ooo/pkg/site/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/snapshot"
+	snapshotPB "github.com/kontainerooo/kontainer.ooo/pkg/snapshot/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshgateway"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshkey"
+	sshkeyPB "github.com/kontainerooo/kontainer.ooo/pkg/sshkey/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
 	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/tracing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/usage"
+	usagePB "github.com/kontainerooo/kontainer.ooo/pkg/usage/pb"
 	"github.com/kontainerooo/kontainer.ooo/pkg/user"
 	userPB "github.com/kontainerooo/kontainer.ooo/pkg/user/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/util"
+	"github.com/kontainerooo/kontainer.ooo/pkg/validation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
+	"github.com/kontainerooo/kontainer.ooo/pkg/webhook"
+	webhookPB "github.com/kontainerooo/kontainer.ooo/pkg/webhook/pb"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+	"github.com/kontainerooo/kontainer.ooo/pkg/wireguard"
+	wireguardPB "github.com/kontainerooo/kontainer.ooo/pkg/wireguard/pb"
 )
 
 func main() {
 
 	var (
-		grpcAddr     = ":8082"
-		wsAddr       = ":8083"
-		wsAddrSecure = ":8084"
-		bcryptCost   = 15
-		isMock       bool
-		dbWrapper    abstraction.DB
-		initBinary   = "/var/go/bin/kroo-init"
+		configPath  string
+		checkConfig bool
+		backupFile  string
+		restoreFile string
+		rotate      bool
+		encrypt     string
+		signKey     string
+		isMock      bool
+		dbWrapper   abstraction.DB
 	)
 
-	/* The krood binary can now be given a flag called `--mock`. With this
-	 *  option the mock database is used. This is
-	 *  in order to simplify testing without a database
-	 *  connection. This might later be removed. */
+	/* Every setting can be given in the configuration file, as an environment
+	 *  variable or as a flag, e.g. `--database.dsn` or KROO_DATABASE_DSN.
+	 *  `--check-config` validates the configuration and exits. `--mock` is kept
+	 *  as a shorthand for `--database.mock`. */
+	flag.StringVar(&configPath, "config", "", fmt.Sprintf("The configuration file, defaults to %s or the legacy %s.", config.DefaultPath, util.ConfigFileName))
+	flag.BoolVar(&checkConfig, "check-config", false, "Validate the configuration and exit.")
+	flag.StringVar(&backupFile, "backup", "", "Write a backup of the installation to the given archive and exit.")
+	flag.StringVar(&restoreFile, "restore", "", "Restore the installation from the given archive, or storage:<node>/<archive> of an s3 artifact store, and exit, the daemon must not be running.")
+	flag.BoolVar(&rotate, "rotate-secrets", false, "Encrypt the secrets in the database with the current master key and exit.")
+	flag.StringVar(&encrypt, "encrypt-secret", "", "Print the given value encrypted with the current master key, e.g. for registry.password, and exit.")
+	flag.StringVar(&signKey, "sign-backup", "", "Print a signed download URL of the backup <node>/<archive> of an s3 artifact store and exit.")
 	flag.BoolVar(&isMock, "mock", false, "Determines if a mock DB should be used.")
+	configFlags := config.NewFlags(flag.CommandLine)
 	flag.Parse()
 
+	loadConfig := func() (config.Config, error) {
+		c, err := config.Load(configPath, os.Environ(), configFlags)
+		c.Database.Mock = c.Database.Mock || isMock
+		return c, err
+	}
+
+	cfg, err := loadConfig()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if checkConfig {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("configuration ok")
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid configuration:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	conf := cfg.ConfigFile()
+	util.SetConfig(conf)
+	reloader := config.NewReloader(cfg, loadConfig)
+
 	var logger log.Logger
+	var levelLogger *logging.LevelLogger
+	var errorRecorder *logging.Recorder
 	{
-		logger = log.NewLogfmtLogger(os.Stdout)
+		logger, err = logging.NewLogger(os.Stdout, cfg.Log.Format)
+		if err != nil {
+			panic(err)
+		}
+		// the recent errors are kept for the admin service
+		errorRecorder = logging.NewRecorder(logger, 100)
+		levelLogger, err = logging.NewLevelLogger(errorRecorder, cfg.Log.Level, cfg.Log.Modules)
+		if err != nil {
+			panic(err)
+		}
+		logger = levelLogger
 		logger = log.With(logger, "ts", log.DefaultTimestampUTC)
 		logger = log.With(logger, "caller", log.DefaultCaller)
 	}
-	logger.Log("msg", "hello")
-	defer logger.Log("msg", "goodbye")
+	if backupFile != "" || restoreFile != "" {
+		if backupFile != "" {
+			err = runBackup(cfg, backupFile, false, logger)
+		} else {
+			err = runBackup(cfg, restoreFile, true, logger)
+		}
+		if err != nil {
+			level.Error(logger).Log("component", "backup", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if rotate || encrypt != "" {
+		if rotate {
+			err = runRotation(cfg, logger)
+		} else {
+			err = encryptSecret(cfg, encrypt)
+		}
+		if err != nil {
+			level.Error(logger).Log("component", "secrets", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	settings, err := cfg.SecretStore()
+	if err != nil {
+		panic(err)
+	}
+	err = decryptSettings(&cfg, settings)
+	if err != nil {
+		panic(err)
+	}
+	if signKey != "" {
+		err = signBackup(cfg, signKey)
+		if err != nil {
+			level.Error(logger).Log("component", "backup", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+	secrets, err := cfg.SecretBackend()
+	if err != nil {
+		panic(err)
+	}
+
+	level.Info(logger).Log("msg", "hello")
+	defer level.Info(logger).Log("msg", "goodbye")
+
+	tracer, tracerCloser := tracing.NewNoop()
+	if cfg.Tracing.Enabled {
+		tracer, tracerCloser, err = tracing.New(cfg.Tracing.ServiceName, cfg.Tracing.Agent, cfg.Tracing.Collector, cfg.Tracing.SampleRate)
+		if err != nil {
+			panic(err)
+		}
+	}
+	opentracing.SetGlobalTracer(tracer)
 
-	if isMock {
+	/* Components register how they are stopped once they are started, they are
+	 *  stopped in reverse order so everything they depend on is still available. */
+	lc := lifecycle.NewManager(log.With(logger, "component", "lifecycle"))
+	lc.Add("tracer", lifecycle.Closer(tracerCloser))
+
+	// every dependency which can fail while the daemon is running registers a checker
+	healthRegistry := health.NewRegistry(5 * time.Second)
+
+	metricsProvider := metrics.NewPrometheusProvider(metrics.Namespace)
+	instrumenting := metrics.NewInstrumenting(metricsProvider)
+	sampler := metrics.NewSampler(metricsProvider, log.With(logger, "component", "metrics"))
+
+	// transient failures of the database, the artifact store and the certificate authority are retried
+	retryPolicy := retry.DefaultPolicy
+	retryPolicy.Metrics = retry.NewMetrics(metricsProvider)
+
+	/* The singleton background workers only run on the replica holding the lock,
+	 *  the mock database is not shared so the replica always holds it then. */
+	var locker leader.Locker
+	if cfg.Database.Mock {
 		dbWrapper = testutils.NewMockDB()
+		locker = leader.NewMemoryLock().Handle()
 	} else {
-		db, err := gorm.Open("postgres", "host=postgres database=postgres user=kroo password=kroo sslmode=disable")
+		// the database may still be starting, e.g. when the whole installation is started at once
+		var db *gorm.DB
+		connectPolicy := retryPolicy
+		connectPolicy.Attempts = 10
+		err := connectPolicy.Do(context.Background(), "database.connect", func() (err error) {
+			db, err = gorm.Open(cfg.Database.Driver, cfg.Database.DSN)
+			return err
+		})
 		if err != nil {
 			panic(err)
 		}
-		defer db.Close()
+		lc.Add("database", lifecycle.Closer(db))
+		healthRegistry.Register("database", func() error {
+			return db.DB().Ping()
+		})
 		dbWrapper = abstraction.NewDB(db)
+		locker = leader.NewPostgresLock(db.DB(), "krood")
+	}
+	elector := leader.NewElector(locker, log.With(logger, "component", "leader"))
+
+	dbWrapper = breaker.NewDB(dbWrapper, newBreaker(cfg.Breakers, "database", logger))
+	if cfg.Tracing.Enabled {
+		dbWrapper = tracing.NewDB(dbWrapper, tracer)
+	}
+
+	node := cfg.Network.NodeName
+	if node == "" {
+		node, _ = os.Hostname()
+	}
+
+	var bus events.Bus
+	eventLogger := log.With(logger, "component", "events")
+	if cfg.Events.Backend == "nats" {
+		bus, err = events.NewNATSBus(node, cfg.Events.URL, cfg.Events.Stream, eventLogger)
+		if err != nil {
+			panic(err)
+		}
+	} else {
+		bus = events.NewMemoryBus(node, 5*time.Second, eventLogger)
+	}
+	lc.Add("event bus", lifecycle.Closer(bus))
+
+	jobQueue, err := jobs.NewQueue(dbWrapper, log.With(logger, "component", "jobs"))
+	if err != nil {
+		panic(err)
+	}
+	jobQueue.SetMetrics(retryPolicy.Metrics)
+	jobQueue.Register(jobs.PruneJob, jobs.DefaultOptions, jobs.PruneHandler(jobQueue, 7*24*time.Hour))
+	// jobs enqueued on the other replicas are picked up by the leader when it polls the queue
+	elector.Go("job queue", func(stop <-chan struct{}) {
+		jobQueue.Run(time.Minute, stop)
+	})
+	elector.Go("job pruning", func(stop <-chan struct{}) {
+		jobQueue.Schedule(jobs.PruneJob, nil, 24*time.Hour, stop)
+	})
+	sampler.Gauge("dead_jobs", "Number of background jobs which failed on every attempt.", func() (float64, error) {
+		dead := []jobs.Job{}
+		err := jobQueue.Jobs(jobs.Dead, &dead)
+		return float64(len(dead)), err
+	})
+
+	var values cache.Cache
+	if cfg.Cache.Enabled {
+		values = cache.NewLRU(cfg.Cache.Size)
+		if cfg.Cache.Backend == "redis" {
+			values, err = cache.NewRedis(cfg.Cache.RedisURL)
+			if err != nil {
+				panic(err)
+			}
+		}
 	}
+	cacheInstrumenting := cache.NewInstrumenting(metricsProvider)
+	cacheTTL := time.Duration(cfg.Cache.TTL) * time.Second
 
 	var userService user.Service
-	userService, err := user.NewService(dbWrapper, bcryptCost)
+	userService, err = user.NewService(dbWrapper, cfg.BcryptCost)
 	if err != nil {
 		panic(err)
 	}
 	userService = user.NewTransactionBasedService(userService)
+	if values != nil {
+		userService = user.NewCachedService(userService, cacheInstrumenting.Cache("user", values), cacheTTL)
+	}
 
-	userEndpoints := makeUserServiceEndpoints(userService)
+	artifacts, err := artifactStore(cfg.Storage)
+	if err != nil {
+		panic(err)
+	}
+	downloads := newDownloadSigner(cfg.Downloads)
 
 	var kmiService kmi.Service
 	kmiService, err = kmi.NewService(dbWrapper)
 	if err != nil {
 		panic(err)
 	}
+	moduleAssets := storage.Prefix(artifacts, storage.Assets)
+	kmiService = kmi.NewAssetService(kmiService, moduleAssets)
+	kmiService = kmi.NewStorageService(kmiService, storage.Prefix(artifacts, storage.Bundles), retryPolicy)
+	if values != nil {
+		kmiService = kmi.NewCachedService(kmiService, cacheInstrumenting.Cache("kmi", values), cacheTTL)
+	}
 
-	kmiEndpoints := makeKMIServiceEndpoints(kmiService)
+	kmiEndpoints := makeKMIServiceEndpoints(kmiService, instrumenting, tracer, logger)
 
 	var routingService routing.Service
 	routingService, err = routing.NewService(dbWrapper)
 	if err != nil {
 		panic(err)
 	}
+	limitedRouting := routing.NewLimitedService(routingService, routing.PlanFunc(userPlan(dbWrapper)), cfg.Routing.Plans)
+	reloader.OnReload(func(old, new config.Config) error {
+		limitedRouting.SetPlans(new.Routing.Plans)
+		return nil
+	})
+	routingService = limitedRouting
+	if values != nil {
+		routingService = routing.NewCachedService(routingService, cacheInstrumenting.Cache("routing", values), cacheTTL)
+	}
+
+	router, err := routingTemplate.ParseRouter(conf.Router)
+	if err != nil {
+		panic(err)
+	}
+
+	if conf.RoutingTemplatePath != "" {
+		err = routingTemplate.Validate(router, conf.RoutingTemplatePath)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if router == routingTemplate.Nginx {
+		healthRegistry.Register("nginx", health.Command("nginx", "-t"))
+	}
+
+	collector := routing.NewCollector(routingService, conf.AnalyticsPath, log.With(logger, "service", "routing"))
+	if conf.AnalyticsPath != "" {
+		lc.Go("analytics collector", func(stop <-chan struct{}) {
+			collector.Run(10*time.Second, stop)
+		})
+	}
+
+	healthCheck := routing.NewHealthCheck(routingService, nil, 2*time.Second, log.With(logger, "service", "routing"))
+	elector.Go("health check", func(stop <-chan struct{}) {
+		healthCheck.Run(30*time.Second, stop)
+	})
+
+	var dnsProvider dns.Provider
+	if conf.PowerDNSURL != "" {
+		dnsProvider = &dns.PowerDNS{
+			URL:    conf.PowerDNSURL,
+			APIKey: conf.PowerDNSAPIKey,
+			Zone:   conf.BaseDomain,
+		}
+		if b := newBreaker(cfg.Breakers, "powerdns", logger); b != nil {
+			dnsProvider = breakerProvider{dnsProvider, b}
+		}
+	}
+
+	var dnsService dns.Service
+	dnsService, err = dns.NewService(dbWrapper, dnsProvider, nil, dns.Options{
+		Zone:   conf.BaseDomain,
+		IPv4:   conf.PublicIPv4,
+		IPv6:   conf.PublicIPv6,
+		Target: conf.DNSTarget,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = dns.Subscribe(dnsService, bus, log.With(logger, "service", "dns"))
+	if err != nil {
+		panic(err)
+	}
+
+	dnsEndpoints := makeDNSServiceEndpoints(dnsService, instrumenting, tracer, logger)
+
+	var firewallEndpoints *firewall.Endpoints
+	var drops *firewall.DropCollector
+	if conf.Firewall {
+		ipts, err := iptables.NewService(cfg.IPTables.Path, cfg.IPTables.RestorePath, dbWrapper)
+		if err != nil {
+			panic(err)
+		}
+
+		firewallOptions := firewall.Options{
+			LogDrops:   cfg.IPTables.LogDrops,
+			NFLogGroup: uint16(cfg.IPTables.NFLogGroup),
+		}
+		if firewallOptions.LogDrops != "" {
+			drops = firewall.NewDropCollector()
+			firewallOptions.Drops = drops
+			lc.Go("drop collector", func(stop <-chan struct{}) {
+				err := drops.Run(cfg.IPTables.DropLog, stop)
+				if err != nil {
+					level.Error(logger).Log("service", "firewall", "err", err)
+				}
+			})
+		}
+
+		firewallService, err := firewall.NewService(ipts, firewallOptions)
+		if err != nil {
+			panic(err)
+		}
+		firewallService = firewall.NewEventService(firewallService, bus, log.With(logger, "service", "firewall"))
+		healthRegistry.Register("iptables", health.Command(cfg.IPTables.Path, "-n", "-L", "INPUT"))
+
+		sampler.Gauge("firewall_rules", "Number of firewall rules.", func() (float64, error) {
+			n, err := ipts.CountRules()
+			return float64(n), err
+		})
+
+		fe := makeFirewallServiceEndpoints(firewallService, instrumenting, tracer, logger)
+		firewallEndpoints = &fe
+	}
+
+	var networkEndpoints *network.Endpoints
+	var adminNetwork admin.Network
+	var billingUsage billing.Usage
+	var managementPorts management.Ports
+	var bridgeNetworks network.Service
+	var networkTraffic routing.TrafficFunc
+	var throttler routing.Throttler
+	if conf.NetworkPool != "" {
+		pool, err := network.NewPool(conf.NetworkPool, conf.NetworkPrefix)
+		if err != nil {
+			panic(err)
+		}
+
+		var overlay *network.Overlay
+		if conf.NodeName != "" {
+			overlay = &network.Overlay{
+				Node:    conf.NodeName,
+				Address: net.ParseIP(conf.NodeAddress),
+				Driver:  network.NewIPOverlayDriver(),
+			}
+		}
+
+		networkService, err := network.NewService(abstraction.NewDCLI(), dbWrapper, firewallEndpoints, pool, network.NewIPBridgeDriver(), overlay)
+		if err != nil {
+			panic(err)
+		}
+
+		if overlay != nil {
+			overlaySync := network.NewOverlaySync(networkService, log.With(logger, "service", "network"))
+			lc.Go("overlay sync", func(stop <-chan struct{}) {
+				overlaySync.Run(30*time.Second, stop)
+			})
+		}
+
+		if conf.NetworkMetering {
+			counter, err := network.NewIPTablesCounter()
+			if err != nil {
+				panic(err)
+			}
+
+			meter := network.NewMeter(networkService, counter, log.With(logger, "service", "network"))
+			lc.Go("traffic meter", func(stop <-chan struct{}) {
+				meter.Run(10*time.Second, stop)
+			})
+			billingUsage = networkService
+			networkTraffic = func(refID uint, from time.Time, to time.Time) (uint64, error) {
+				b, err := networkService.TotalUsage(refID, from, to)
+				return b.In + b.Out, err
+			}
+		}
+
+		ne := makeNetworkServiceEndpoints(networkService, instrumenting, tracer, logger)
+		networkEndpoints = &ne
+		adminNetwork = networkService
+		managementPorts = networkService
+		bridgeNetworks = networkService
+		throttler = network.NewTCThrottler()
+
+		userService = user.NewHookedService(userService, func(id uint) error {
+			err := networkService.RemoveBridge(id)
+			if err == network.ErrBridgeNotExist {
+				return nil
+			}
+			return err
+		})
+	}
+
+	quotaEnforcer := routing.NewQuotaEnforcer(routingService, limitedRouting.Plan, networkTraffic, throttler, log.With(logger, "service", "routing"))
+	elector.Go("quota enforcer", func(stop <-chan struct{}) {
+		quotaEnforcer.Run(time.Minute, stop)
+	})
+
+	routingEndpoints := makeRoutingServiceEndpoints(routingService, collector, quotaEnforcer, instrumenting, tracer, logger)
+
+	userService = user.NewEventService(userService, bus, log.With(logger, "service", "user"))
+	userEndpoints := makeUserServiceEndpoints(userService, instrumenting, tracer, logger)
+
+	sampler.Gauge("users", "Number of users.", func() (float64, error) {
+		n, err := userService.CountUsers()
+		return float64(n), err
+	})
+
+	var issuer acme.Issuer
+	var certificates site.Certificates
+	if conf.ACMEEmail != "" {
+		certStore, err := acme.NewFileStore(conf.CertificatePath)
+		if err != nil {
+			panic(err)
+		}
+
+		acmeOptions := acme.DefaultOptions
+		acmeOptions.Reload = func() error {
+			return routingTemplate.Reload(router)
+		}
+
+		if conf.ACMEWildcard && dnsProvider != nil {
+			issuer, err = acme.NewDNSIssuer(conf.ACMEDirectory, conf.ACMEEmail, acme.Webroot, dnsProvider, conf.BaseDomain, certStore, retryPolicy)
+			acmeOptions.Wildcard = conf.BaseDomain
+		} else {
+			issuer, err = acme.NewIssuer(conf.ACMEDirectory, conf.ACMEEmail, acme.Webroot, certStore, retryPolicy)
+		}
+		if err != nil {
+			panic(err)
+		}
+
+		acmeService := acme.NewService(
+			routingService,
+			issuer,
+			acme.NewEventStore(certStore, bus, log.With(logger, "service", "acme")),
+			acme.NewDomainPolicy(conf.BaseDomain, dnsService.Verified),
+			acme.LogAlert(log.With(logger, "service", "acme")),
+			acmeOptions,
+		)
+
+		certificates = acmeService
+
+		renewal := &health.Result{}
+		healthRegistry.Register("acme", renewal.Check)
+
+		jobQueue.Register(acme.RenewJob, jobs.DefaultOptions, acme.RenewHandler(acmeService, renewal.Set))
+		elector.Go("certificate renewal", func(stop <-chan struct{}) {
+			jobQueue.Schedule(acme.RenewJob, nil, 12*time.Hour, stop)
+		})
+	}
+
+	/* Only the settings tagged as reloadable in the config package are applied,
+	 *  the handlers abort the reload before anything is changed if they fail. */
+	reloader.OnReload(func(old, new config.Config) error {
+		if (old.ACME.Email == "") != (new.ACME.Email == "") {
+			return errors.New("enabling or disabling certificates requires a restart")
+		}
 
-	routingEndpoints := makeRoutingServiceEndpoints(routingService)
+		if i, ok := issuer.(acme.ContactIssuer); ok {
+			i.SetEmail(new.ACME.Email)
+		}
+		return nil
+	})
+
+	reloader.OnReload(func(old, new config.Config) error {
+		util.SetConfig(new.ConfigFile())
+		err := levelLogger.SetLevel(new.Log.Level)
+		if err != nil {
+			return err
+		}
+		return levelLogger.SetModules(new.Log.Modules)
+	})
 
-	factory, err := libcontainer.New("/var/lib/kontainerooo/container", libcontainer.Cgroupfs, libcontainer.InitArgs(initBinary, "init"))
+	factory, err := libcontainer.New(cfg.Paths.Container, libcontainer.Cgroupfs, libcontainer.InitArgs(cfg.Paths.InitBinary, "init"), libcontainer.CriuPath(cfg.Checkpoints.CRIU))
 	if err != nil {
 		panic(err)
 	}
+	healthRegistry.Register("container runtime", health.Path(cfg.Paths.Container))
+	healthRegistry.Register("container init", health.Path(cfg.Paths.InitBinary))
 
 	var containerService container.Service
-	containerService, err = container.NewService(factory, dbWrapper, &kmiEndpoints, logger)
+	containerService, err = container.NewService(factory, dbWrapper, &kmiEndpoints, &routingEndpoints, nil, bus, secrets, containerExports(downloads, artifacts), logger)
+	if err != nil {
+		panic(err)
+	}
+	// the containers are saved before the database and the event bus are closed, if they should be restored
+	lc.Add("container checkpoints", containerService.CheckpointRunning)
+
+	containerServiceEndpoints := makeContainerServiceEndpoints(containerService, newBreaker(cfg.Breakers, "container runtime", logger), instrumenting, tracer, logger)
+
+	featureFlags, err := feature.NewFlags(dbWrapper, feature.PlanFunc(userPlan(dbWrapper)), log.With(logger, "component", "feature"))
+	if err != nil {
+		panic(err)
+	}
+	lc.Go("feature flags", func(stop <-chan struct{}) {
+		featureFlags.Run(time.Minute, stop)
+	})
+
+	// the windows are announced to the websocket clients once ken the guru is created
+	var kenTheGuruService kentheguru.Service
+	maintenanceMode, err := maintenance.NewMode(dbWrapper, maintenanceNotifier(&kenTheGuruService), log.With(logger, "component", "maintenance"))
+	if err != nil {
+		panic(err)
+	}
+	lc.Go("maintenance", func(stop <-chan struct{}) {
+		maintenanceMode.Run(10*time.Second, stop)
+	})
+
+	// the owners of the instances of drained nodes, impersonated users and users logging in from new devices
+	// are notified once the mail service is created
+	var mailService mail.Service
+	impersonations, err := impersonation.NewSessions(dbWrapper, lazyMails{&mailService}, log.With(logger, "component", "impersonation"))
+	if err != nil {
+		panic(err)
+	}
+
+	loginGuard, err := newLoginGuard(dbWrapper, cfg.Login, lazyMails{&mailService}, log.With(logger, "component", "login"))
+	if err != nil {
+		panic(err)
+	}
+
+	adminService, err := admin.NewService(dbWrapper, bus, errorRecorder, jobQueue, adminNetwork, featureFlags, maintenanceMode, impersonations, lazyMails{&mailService})
+	if err != nil {
+		panic(err)
+	}
+
+	// the container service learns whether this node is drained or cordoned once it subscribed to the node events
+	err = adminService.Announce(node)
+	if err != nil {
+		panic(err)
+	}
+
+	adminEndpoints := makeAdminServiceEndpoints(adminService, instrumenting, tracer, logger)
+
+	webhookService, err := webhook.NewService(dbWrapper, jobQueue, nil, secrets)
+	if err != nil {
+		panic(err)
+	}
+
+	jobQueue.Register(webhook.DeliverJob, webhook.DeliverOptions, webhook.DeliverHandler(webhookService))
+	_, err = webhook.Subscribe(webhookService, bus, log.With(logger, "service", "webhook"))
+	if err != nil {
+		panic(err)
+	}
+
+	webhookEndpoints := makeWebhookServiceEndpoints(webhookService, instrumenting, tracer, logger)
+
+	agentService, err := agent.NewService(dbWrapper, bus, time.Duration(cfg.Agent.Timeout)*time.Second)
+	if err != nil {
+		panic(err)
+	}
+
+	agentMonitor := agent.NewMonitor(agentService, log.With(logger, "service", "agent"))
+	elector.Go("agent monitor", func(stop <-chan struct{}) {
+		agentMonitor.Run(time.Duration(cfg.Agent.Heartbeat)*time.Second, stop)
+	})
+
+	if cfg.Agent.Reschedule > 0 {
+		rescheduler := admin.NewRescheduler(adminService, agentService, time.Duration(cfg.Agent.Reschedule)*time.Second, log.With(logger, "service", "admin"))
+		elector.Go("rescheduler", func(stop <-chan struct{}) {
+			rescheduler.Run(time.Duration(cfg.Agent.Heartbeat)*time.Second, stop)
+		})
+	}
+
+	agentEndpoints := makeAgentServiceEndpoints(agentService, instrumenting, tracer, logger)
+
+	sampler.Gauge("running_containers", "Number of running containers.", func() (float64, error) {
+		n, err := containerService.CountRunning(context.Background())
+		return float64(n), err
+	})
+	sampler.Gauge("leader", "Whether the replica runs the singleton background workers.", func() (float64, error) {
+		if elector.Leading() {
+			return 1, nil
+		}
+		return 0, nil
+	})
+	lc.Go("leader election", func(stop <-chan struct{}) {
+		elector.Run(5*time.Second, stop)
+	})
+	lc.Go("health checks", func(stop <-chan struct{}) {
+		healthRegistry.Run(30*time.Second, stop)
+	})
+
+	var moduleService module.Service
+	moduleService, err = module.NewService(&containerServiceEndpoints, &kmiEndpoints, logger)
+	if err != nil {
+		panic(err)
+	}
+
+	moduleServeEndpoints := makeModuleServiceEndpoints(moduleService, instrumenting, tracer, logger)
+
+	sshKeyService, err := sshkey.NewService(dbWrapper, sshKeyContainers{
+		containers: containerServiceEndpoints,
+		modules:    moduleServeEndpoints,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	_, err = sshkey.Subscribe(sshKeyService, bus, log.With(logger, "service", "sshkey"))
+	if err != nil {
+		panic(err)
+	}
+
+	sshKeyEndpoints := makeSSHKeyServiceEndpoints(sshKeyService, instrumenting, tracer, logger)
+
+	listeners := []string{cfg.Listen.GRPC, cfg.Listen.Websocket, cfg.Listen.WebsocketSecure, cfg.Listen.Gateway, cfg.Listen.Metrics}
+	if cfg.SSH.Enabled {
+		listeners = append(listeners, cfg.SSH.Address)
+	}
+	daemonPorts, err := ports.Listeners(listeners...)
+	if err != nil {
+		panic(err)
+	}
+
+	portService, err := ports.NewService(dbWrapper, ports.Range{
+		From: uint16(cfg.Ports.From),
+		To:   uint16(cfg.Ports.To),
+	}, daemonPorts, routerPorts{routing: routingService})
 	if err != nil {
 		panic(err)
 	}
 
-	containerServiceEndpoints := makeContainerServiceEndpoints(containerService)
+	portEndpoints := makePortServiceEndpoints(portService, instrumenting, tracer, logger)
+
+	var databaseEndpoints *database.Endpoints
+	var snapshotDatabases snapshot.Databases
+	if cfg.ManagedDatabases.Enabled {
+		m := cfg.ManagedDatabases
+		databaseService, err := database.NewService(dbWrapper, containerService, jobQueue, map[string]database.Provisioner{
+			database.SchemaMode: database.NewSchemaProvisioner(map[string]database.Server{
+				database.Postgres: {DSN: m.Postgres.DSN, Host: m.Postgres.Host, Port: uint(m.Postgres.Port)},
+				database.MySQL:    {DSN: m.MySQL.DSN, Host: m.MySQL.Host, Port: uint(m.MySQL.Port)},
+			}),
+			database.ContainerMode: database.NewContainerProvisioner(containerService, map[string]uint{
+				database.Postgres: uint(m.Postgres.KMI),
+				database.MySQL:    uint(m.MySQL.KMI),
+			}),
+		}, database.Options{
+			Mode:      m.Mode,
+			DumpPath:  m.DumpPath,
+			Retention: m.DumpRetention,
+			Secrets:   secrets,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		jobQueue.Register(database.DumpJob, database.DumpOptions, database.DumpHandler(databaseService))
+		elector.Go("database dumps", func(stop <-chan struct{}) {
+			jobQueue.Schedule(database.DumpJob, nil, time.Duration(m.DumpInterval)*time.Second, stop)
+		})
+
+		de := makeDatabaseServiceEndpoints(databaseService, instrumenting, tracer, logger)
+		databaseEndpoints = &de
+		snapshotDatabases = databaseService
+	}
+
+	if cfg.Mail.Enabled {
+		templates, err := mail.NewTemplates(cfg.Mail.TemplatePath)
+		if err != nil {
+			panic(err)
+		}
+
+		mailLogger := log.With(logger, "service", "mail")
+		mailService = mail.NewService(mailProvider(cfg.Mail, mailLogger), templates, mailRecipients{users: userService}, jobQueue, mail.Options{
+			From: cfg.Mail.From,
+		})
+		jobQueue.Register(mail.SendJob, mail.JobOptions, mail.SendHandler(mailService, mailLogger))
+	}
+
+	var (
+		billingWebhook   http.Handler
+		billingEndpoints *billing.Endpoints
+		billingService   billing.Service
+	)
+	if cfg.Billing.Enabled {
+		billingLogger := log.With(logger, "service", "billing")
+		provider := billing.NewStripeProvider(billing.StripeOptions{
+			Endpoint:      cfg.Billing.Stripe.Endpoint,
+			SecretKey:     cfg.Billing.Stripe.SecretKey,
+			WebhookSecret: cfg.Billing.Stripe.WebhookSecret,
+		})
+
+		billingService, err = billing.NewService(dbWrapper, provider, mailRecipients{users: userService}, billingUsage, storage.Prefix(artifacts, storage.Invoices), cfg.Billing.Plans, billing.Options{
+			Currency:    cfg.Billing.Currency,
+			GracePeriod: time.Duration(cfg.Billing.GracePeriod) * 24 * time.Hour,
+			Issuer:      cfg.Billing.Issuer,
+			Downloads:   downloadURLs(downloads, download.Invoices),
+		}, billingLogger, suspendInstances(containerService), billing.PublishHook(bus))
+		if err != nil {
+			panic(err)
+		}
+		reloader.OnReload(func(old, new config.Config) error {
+			billingService.SetPlans(new.Billing.Plans)
+			return nil
+		})
+
+		_, err = billing.Subscribe(billingService, bus, billing.PlanFunc(userPlan(dbWrapper)), billingLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		jobQueue.Register(billing.BillJob, jobs.DefaultOptions, billing.BillHandler(billingService))
+		jobQueue.Register(billing.EnforceJob, jobs.DefaultOptions, billing.EnforceHandler(billingService))
+		elector.Go("billing", func(stop <-chan struct{}) {
+			jobQueue.Schedule(billing.BillJob, nil, 24*time.Hour, stop)
+		})
+		elector.Go("billing enforcement", func(stop <-chan struct{}) {
+			jobQueue.Schedule(billing.EnforceJob, nil, time.Hour, stop)
+		})
+
+		billingWebhook = billing.Handler(billingService, provider, billingLogger)
+
+		be := makeBillingServiceEndpoints(billingService, instrumenting, tracer, logger)
+		billingEndpoints = &be
+	}
+
+	var snapshotEndpoints *snapshot.Endpoints
+	if cfg.Snapshots.Enabled {
+		stores := map[string]storage.Store{
+			storage.Local: storage.NewLocalStore(cfg.Snapshots.LocalPath),
+		}
+		if cfg.Snapshots.S3.Endpoint != "" {
+			stores[storage.S3] = storage.NewS3Store(s3Options(cfg.Snapshots.S3))
+		} else if cfg.Storage.Backend == storage.S3 {
+			stores[storage.S3] = storage.Prefix(artifacts, storage.Snapshots)
+		}
+
+		var snapshotService snapshot.Service
+		snapshotService, err = snapshot.NewService(dbWrapper, snapshotVolumes{containers: containerService, root: cfg.Paths.Customer}, snapshotDatabases, jobQueue, stores, snapshot.Options{
+			Node:        node,
+			RestorePath: cfg.Snapshots.RestorePath,
+			Retention:   uint(cfg.Snapshots.Retention),
+		})
+		if err != nil {
+			panic(err)
+		}
+		snapshotService = snapshot.NewEventService(snapshotService, bus, log.With(logger, "service", "snapshot"))
+
+		jobQueue.Register(snapshot.DueJob, jobs.DefaultOptions, snapshot.DueHandler(snapshotService))
+		jobQueue.Register(snapshot.SnapshotJob, snapshot.JobOptions, snapshot.SnapshotHandler(snapshotService))
+		jobQueue.Register(snapshot.RestoreJob, snapshot.JobOptions, snapshot.RestoreHandler(snapshotService))
+		elector.Go("snapshot schedules", func(stop <-chan struct{}) {
+			jobQueue.Schedule(snapshot.DueJob, nil, time.Minute, stop)
+		})
+
+		se := makeSnapshotServiceEndpoints(snapshotService, instrumenting, tracer, logger)
+		snapshotEndpoints = &se
+	}
+
+	var cronEndpoints *cronjob.Endpoints
+	if cfg.Cron.Enabled {
+		cronLogger := log.With(logger, "service", "cronjob")
+		var cronService cronjob.Service
+		cronService, err = cronjob.NewService(dbWrapper, containerService, cronjob.PlanFunc(userPlan(dbWrapper)), jobQueue, cfg.Cron.Plans, cronjob.Options{
+			History:   uint(cfg.Cron.History),
+			MaxOutput: cfg.Cron.MaxOutput,
+		})
+		if err != nil {
+			panic(err)
+		}
+		reloader.OnReload(func(old, new config.Config) error {
+			cronService.SetPlans(new.Cron.Plans)
+			return nil
+		})
+
+		var cronMails cronjob.Notifier
+		if mailService != nil {
+			cronMails = mailService
+		}
+		cronService = cronjob.NewEventService(cronService, bus, cronMails, cronLogger)
+
+		_, err = cronjob.Subscribe(cronService, bus, cronLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		jobQueue.Register(cronjob.DueJob, jobs.DefaultOptions, cronjob.DueHandler(cronService))
+		jobQueue.Register(cronjob.ExecuteJob, cronjob.JobOptions, cronjob.ExecuteHandler(cronService))
+		elector.Go("cron jobs", func(stop <-chan struct{}) {
+			jobQueue.Schedule(cronjob.DueJob, nil, time.Minute, stop)
+		})
+
+		ce := makeCronJobServiceEndpoints(cronService, instrumenting, tracer, logger)
+		cronEndpoints = &ce
+	}
+
+	var containerLogEndpoints *containerlog.Endpoints
+	if cfg.ContainerLogs.Enabled {
+		var containerLogService containerlog.Service
+		containerLogService, err = containerlog.NewService(dbWrapper, containerlog.PlanFunc(userPlan(dbWrapper)), cfg.ContainerLogs.Plans, containerlog.Options{
+			MaxLine: cfg.ContainerLogs.MaxLine,
+		})
+		if err != nil {
+			panic(err)
+		}
+		reloader.OnReload(func(old, new config.Config) error {
+			containerLogService.SetPlans(new.ContainerLogs.Plans)
+			return nil
+		})
+
+		if len(cfg.ContainerLogs.Sinks) > 0 {
+			logForwarder := containerlog.NewForwarder(metricsProvider, log.With(logger, "component", "log forwarder"))
+			for _, c := range cfg.ContainerLogs.Sinks {
+				sink, err := containerlog.NewSink(c, nil)
+				if err != nil {
+					panic(err)
+				}
+				logForwarder.Add(c, sink)
+			}
+			lc.Go("container log forwarder", logForwarder.Run)
+			containerLogService = containerlog.Forwarding(containerLogService, logForwarder)
+		}
+
+		// every node collects the logs of its own instances
+		logCollector := containerlog.NewCollector(containerLogService, instanceLogs(containerService, cfg.Paths.Customer), log.With(logger, "service", "containerlog"))
+		lc.Go("container log collector", func(stop <-chan struct{}) {
+			logCollector.Run(time.Duration(cfg.ContainerLogs.Interval)*time.Second, stop)
+		})
+
+		jobQueue.Register(containerlog.PruneJob, jobs.DefaultOptions, containerlog.PruneHandler(containerLogService))
+		elector.Go("container log retention", func(stop <-chan struct{}) {
+			jobQueue.Schedule(containerlog.PruneJob, nil, time.Hour, stop)
+		})
+
+		le := makeContainerLogServiceEndpoints(containerLogService, logCollector, instrumenting, tracer, logger)
+		containerLogEndpoints = &le
+	}
+
+	var alertEndpoints *alert.Endpoints
+	if cfg.Alerts.Enabled {
+		alertLogger := log.With(logger, "service", "alert")
+		var alertService alert.Service
+		alertService, err = alert.NewService(dbWrapper, alert.Options{
+			MaxRules: uint(cfg.Alerts.MaxRules),
+			History:  uint(cfg.Alerts.History),
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		var alertMails alert.Notifier
+		if mailService != nil {
+			alertMails = mailService
+		}
+		alertService = alert.NewEventService(alertService, bus, alertMails, alertLogger)
+
+		_, err = alert.Subscribe(alertService, bus, alertLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		// every node evaluates the rules for its own instances whenever the metrics are sampled
+		sampler.Gauge("alerts_firing", "Number of firing alerts.", alert.Gauge(alertService, alert.NewSampler(instanceUsage(containerService, drops)), alertLogger))
+
+		ae := makeAlertServiceEndpoints(alertService, instrumenting, tracer, logger)
+		alertEndpoints = &ae
+	}
+
+	var autoscaleEndpoints *autoscale.Endpoints
+	if cfg.Autoscaling.Enabled {
+		autoscaleLogger := log.With(logger, "service", "autoscale")
+		var autoscaleService autoscale.Service
+		autoscaleService, err = autoscale.NewService(dbWrapper, instanceScaler{containerService}, autoscale.Options{
+			MaxPolicies: uint(cfg.Autoscaling.MaxPolicies),
+			MaxReplicas: uint(cfg.Autoscaling.MaxReplicas),
+			MaxWindows:  uint(cfg.Autoscaling.MaxWindows),
+			History:     uint(cfg.Autoscaling.History),
+		})
+		if err != nil {
+			panic(err)
+		}
+		autoscaleService = autoscale.NewEventService(autoscaleService, bus, autoscaleLogger)
+
+		_, err = autoscale.Subscribe(autoscaleService, bus, autoscaleLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		// every node scales the instances it runs, the request rates are only known with the analytics of the router
+		var rate autoscale.RateFunc
+		if conf.AnalyticsPath != "" {
+			rate = scaleRate(collector)
+		}
+		autoscaler := autoscale.NewAutoscaler(autoscaleService, scaleUsage(containerService), rate, autoscaleLogger)
+		lc.Go("autoscaler", func(stop <-chan struct{}) {
+			autoscaler.Run(time.Duration(cfg.Autoscaling.Interval)*time.Second, stop)
+		})
+
+		// the windows apply to all instances, so they are scheduled once in the cluster
+		jobQueue.Register(autoscale.ScheduleJob, jobs.DefaultOptions, autoscale.ScheduleHandler(autoscaleService))
+		elector.Go("autoscaling windows", func(stop <-chan struct{}) {
+			jobQueue.Schedule(autoscale.ScheduleJob, nil, time.Minute, stop)
+		})
+
+		ae := makeAutoscaleServiceEndpoints(autoscaleService, instrumenting, tracer, logger)
+		autoscaleEndpoints = &ae
+	}
+
+	var banEndpoints *ban.Endpoints
+	if cfg.Bans.Enabled {
+		banOptions := ban.Options{
+			MaxJails:   uint(cfg.Bans.MaxJails),
+			MaxBanTime: uint(cfg.Bans.MaxBanTime),
+			Node:       node,
+		}
+		for _, n := range cfg.Bans.Ignore {
+			_, ignore, err := net.ParseCIDR(n)
+			if err != nil {
+				panic(err)
+			}
+			banOptions.Ignore = append(banOptions.Ignore, ignore)
+		}
+
+		var banService ban.Service
+		banService, err = ban.NewService(dbWrapper, firewallEndpoints, banOptions)
+		if err != nil {
+			panic(err)
+		}
+
+		// every node watches the logs of its own instances and router and bans in its own firewall
+		banWatcher := ban.NewWatcher(banService, banTargets(containerService, routingService, cfg.Paths.Customer, cfg.Network.NodeName), log.With(logger, "service", "ban"))
+		lc.Go("ban watcher", func(stop <-chan struct{}) {
+			banWatcher.Run(time.Duration(cfg.Bans.Interval)*time.Second, stop)
+		})
+
+		be := makeBanServiceEndpoints(banService, instrumenting, tracer, logger)
+		banEndpoints = &be
+	}
+
+	var wireguardEndpoints *wireguard.Endpoints
+	if cfg.WireGuard.Enabled {
+		wireguardLogger := log.With(logger, "service", "wireguard")
+		var publicKey string
+		publicKey, err = wireguard.LoadKey(cfg.WireGuard.PrivateKey)
+		if err != nil {
+			panic(err)
+		}
+		var pool *net.IPNet
+		_, pool, err = net.ParseCIDR(cfg.WireGuard.Pool)
+		if err != nil {
+			panic(err)
+		}
+
+		var wireguardService wireguard.Service
+		wireguardService, err = wireguard.NewService(dbWrapper, firewallEndpoints, wireguard.NewWGDevice(), wireguardNetwork{bridgeNetworks, containerService}, wireguard.Options{
+			Node:      node,
+			Interface: cfg.WireGuard.Interface,
+			Port:      uint16(cfg.WireGuard.Port),
+			Endpoint:  cfg.WireGuard.Endpoint,
+			Pool:      pool,
+			KeyFile:   cfg.WireGuard.PrivateKey,
+			PublicKey: publicKey,
+			MaxPeers:  uint(cfg.WireGuard.MaxPeers),
+			MaxKeyAge: time.Duration(cfg.WireGuard.MaxKeyAge) * 24 * time.Hour,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = wireguard.Subscribe(wireguardService, bus, wireguardLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		// every node serves the peers created on it over its own interface and firewall
+		wireguardSyncer := wireguard.NewSyncer(wireguardService, wireguardLogger)
+		lc.Go("wireguard sync", func(stop <-chan struct{}) {
+			wireguardSyncer.Run(time.Duration(cfg.WireGuard.Interval)*time.Second, stop)
+		})
+
+		we := makeWireGuardServiceEndpoints(wireguardService, instrumenting, tracer, logger)
+		wireguardEndpoints = &we
+	}
+
+	var resolverService resolver.Service
+	var resolverEndpoints *resolver.Endpoints
+	if cfg.Resolver.Enabled {
+		resolverService, err = resolver.NewService(dbWrapper, resolverNetwork{bridgeNetworks}, cfg.Resolver.Domain)
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = resolver.Subscribe(resolverService, bus, log.With(logger, "service", "resolver"))
+		if err != nil {
+			panic(err)
+		}
+
+		re := makeResolverServiceEndpoints(resolverService, instrumenting, tracer, logger)
+		resolverEndpoints = &re
+	}
+
+	var usageEndpoints *usage.Endpoints
+	if cfg.Usage.Enabled {
+		usageLogger := log.With(logger, "service", "usage")
+		var usageService usage.Service
+		usageService, err = usage.NewService(dbWrapper, usage.Options{
+			Raw:         time.Duration(cfg.Usage.RawRetention) * time.Hour,
+			FiveMinutes: time.Duration(cfg.Usage.FiveMinuteRetention) * 24 * time.Hour,
+			Hour:        time.Duration(cfg.Usage.HourRetention) * 24 * time.Hour,
+			Period:      time.Duration(cfg.Usage.RecommendationPeriod) * 24 * time.Hour,
+			Headroom:    cfg.Usage.Headroom,
+			CPULimit:    cfg.Limits.CPU,
+			CPUPrice:    cfg.Usage.CPUPrice,
+			MemoryPrice: cfg.Usage.MemoryPrice,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = usage.Subscribe(usageService, bus, usageLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		// every node records the usage of its own instances
+		usageCollector := usage.NewCollector(usageService, instanceStats(containerService), usageLogger)
+		lc.Go("usage collector", func(stop <-chan struct{}) {
+			usageCollector.Run(time.Duration(cfg.Usage.Interval)*time.Second, stop)
+		})
+
+		jobQueue.Register(usage.DownsampleJob, jobs.DefaultOptions, usage.DownsampleHandler(usageService))
+		elector.Go("usage downsampling", func(stop <-chan struct{}) {
+			jobQueue.Schedule(usage.DownsampleJob, nil, 5*time.Minute, stop)
+		})
+		jobQueue.Register(usage.RecommendJob, jobs.DefaultOptions, usage.RecommendHandler(usageService))
+		elector.Go("usage recommendations", func(stop <-chan struct{}) {
+			jobQueue.Schedule(usage.RecommendJob, nil, 7*24*time.Hour, stop)
+		})
+
+		ue := makeUsageServiceEndpoints(usageService, instrumenting, tracer, logger)
+		usageEndpoints = &ue
+	}
+
+	var siteEndpoints *site.Endpoints
+	if cfg.Sites.Enabled {
+		siteLogger := log.With(logger, "service", "site")
+		maxBundleSize, err := routing.ParseSize(cfg.Sites.MaxBundleSize)
+		if err != nil {
+			panic(err)
+		}
+
+		var siteService site.Service
+		siteService, err = site.NewService(dbWrapper, routingService, storage.Prefix(artifacts, storage.Sites), certificates, site.Options{
+			Root:    cfg.Sites.Root,
+			Address: abstraction.Inet(cfg.Sites.Address),
+			MaxSize: int64(maxBundleSize),
+			MaxAge:  uint(cfg.Sites.MaxAge),
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		_, err = site.Subscribe(siteService, bus, siteLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		// every node serves the sites from its own copy of the deployed versions
+		lc.Go("site sync", func(stop <-chan struct{}) {
+			t := time.NewTicker(time.Duration(cfg.Sites.Interval) * time.Second)
+			defer t.Stop()
+			for {
+				err := siteService.Sync()
+				if err != nil {
+					level.Error(siteLogger).Log("err", err)
+				}
+
+				select {
+				case <-t.C:
+				case <-stop:
+					return
+				}
+			}
+		})
+
+		se := makeSiteServiceEndpoints(siteService, instrumenting, tracer, logger)
+		siteEndpoints = &se
+	}
+
+	var (
+		deployService   deploy.Service
+		deployHooks     http.Handler
+		deployEndpoints *deploy.Endpoints
+	)
+	if cfg.Deploy.Enabled {
+		deployLogger := log.With(logger, "service", "deploy")
+		deployService, err = deploy.NewService(dbWrapper, containerService, jobQueue, map[string]deploy.Builder{
+			deploy.Dockerfile: deploy.NewDockerfileBuilder(cfg.Deploy.Docker),
+			deploy.Buildpack:  deploy.NewBuildpackBuilder(cfg.Deploy.Pack, cfg.Deploy.Buildpack, cfg.Deploy.Docker),
+		}, deploy.Options{
+			Root:    cfg.Deploy.Root,
+			Branch:  cfg.Deploy.Branch,
+			Keep:    uint(cfg.Deploy.Keep),
+			Secrets: secrets,
+		})
+		if err != nil {
+			panic(err)
+		}
+		jobQueue.Register(deploy.DeployJob, deploy.JobOptions, deploy.DeployHandler(deployService))
+		jobQueue.Register(deploy.PruneJob, jobs.DefaultOptions, deploy.PruneHandler(deployService))
+		elector.Go("deploy image retention", func(stop <-chan struct{}) {
+			jobQueue.Schedule(deploy.PruneJob, nil, 24*time.Hour, stop)
+		})
+
+		_, err = deploy.Subscribe(deployService, bus, deployLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		deployHooks = deploy.Handler(deployService, deployLogger)
+		de := makeDeployServiceEndpoints(deployService, instrumenting, tracer, logger)
+		deployEndpoints = &de
+	}
+
+	recordings := ""
+	if cfg.SSH.Enabled {
+		recordings = cfg.SSH.Recordings
+	}
+	exportService, err := export.NewService(dbWrapper, exportParts(userService, containerService, sshKeyService, billingService, impersonations, loginGuard, recordings), jobQueue, storage.Prefix(artifacts, storage.Exports), lazyMails{&mailService}, export.Options{
+		Downloads: downloadURLs(downloads, download.Exports),
+	}, log.With(logger, "service", "export"))
+	if err != nil {
+		panic(err)
+	}
+	jobQueue.Register(export.BuildJob, export.JobOptions, export.BuildHandler(exportService))
+	jobQueue.Register(export.ExpireJob, jobs.DefaultOptions, export.ExpireHandler(exportService))
+	elector.Go("export expiry", func(stop <-chan struct{}) {
+		jobQueue.Schedule(export.ExpireJob, nil, time.Hour, stop)
+	})
+	exportEndpoints := makeExportServiceEndpoints(exportService, instrumenting, tracer, logger)
+
+	var registryCache *registry.Cache
+	if cfg.Registry.Enabled {
+		registryLogger := log.With(logger, "service", "registry")
+		quota, err := routing.ParseSize(cfg.Registry.Quota)
+		if err != nil {
+			panic(err)
+		}
+
+		registryCache, err = registry.NewCache(registry.Options{
+			Upstream:    cfg.Registry.Upstream,
+			Username:    cfg.Registry.Username,
+			Password:    cfg.Registry.Password,
+			Secrets:     settings,
+			Root:        cfg.Registry.Root,
+			Quota:       int64(quota),
+			ManifestTTL: time.Duration(cfg.Registry.ManifestTTL) * time.Second,
+			Unused:      time.Duration(cfg.Registry.Unused) * time.Hour,
+		}, metricsProvider, registryLogger)
+		if err != nil {
+			panic(err)
+		}
+
+		sampler.Gauge("registry_cache_bytes", "Number of bytes the layers in the registry cache take up.", func() (float64, error) {
+			return float64(registryCache.Size()), nil
+		})
+
+		lc.Go("registry gc", func(stop <-chan struct{}) {
+			t := time.NewTicker(time.Duration(cfg.Registry.Interval) * time.Second)
+			defer t.Stop()
+			for {
+				select {
+				case <-t.C:
+				case <-stop:
+					return
+				}
+
+				removed, err := registryCache.GC()
+				if err != nil {
+					level.Error(registryLogger).Log("err", err)
+				} else if removed > 0 {
+					level.Info(registryLogger).Log("removed", removed, "size", registryCache.Size())
+				}
+			}
+		})
+	}
+
+	managementService, err := management.NewService(dbWrapper, management.Backends{
+		Users:     userService,
+		Modules:   kmiService,
+		Instances: containerService,
+		Domains:   dnsService,
+		Ports:     managementPorts,
+	})
+	if err != nil {
+		panic(err)
+	}
+	managementEndpoints := makeManagementServiceEndpoints(managementService, instrumenting, tracer, logger)
+
+	// the sampler is started once every gauge was added
+	lc.Go("metrics sampler", func(stop <-chan struct{}) {
+		sampler.Run(30*time.Second, stop)
+	})
+
+	errc := make(chan error)
+	ctx := context.Background()
+
+	kenTheGuruService = kentheguru.NewService(
+		// TODO: generate keys and load them from configuration file
+		"bubububububububububububububububu",
+		"bubububububububububububububububu",
+		"bu",
+		websocket.Upgrader{
+			EnableCompression: true,
+		},
+		ws.SSLConfig{
+			Addr: cfg.Listen.WebsocketSecure,
+			// TODO: generate certificate and key
+		},
+		userEndpoints, kmiEndpoints, containerServiceEndpoints, routingEndpoints, moduleServeEndpoints, dnsEndpoints,
+	)
+	kenTheGuruService.AddWebsocketMiddleware(ws.Before(maintenance.Websocket(maintenanceMode, readOnlyWebsocketMethods)))
+	for name, d := range deprecatedWebsocketProtocols {
+		kenTheGuruService.DeprecateWebsocketProtocol(name, d)
+	}
+	kenTheGuruService.SetRequestTimeout(time.Duration(cfg.RequestTimeout) * time.Second)
+	requestLimits, err := newRequestLimits(cfg)
+	if err != nil {
+		panic(err)
+	}
+	kenTheGuruService.SetRequestLimits(requestLimits)
+	if containerLogEndpoints != nil {
+		kenTheGuruService.AddWebsocketService(containerlog.MakeWebsocketService(*containerLogEndpoints))
+	}
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		store := ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Backend == "redis" {
+			store, err = ratelimit.NewRedisStore(cfg.RateLimit.RedisURL)
+			if err != nil {
+				panic(err)
+			}
+		}
+
+		limiter = ratelimit.NewLimiter(store, cfg.RateLimit.Plans, userPlan(dbWrapper), log.With(logger, "component", "ratelimit"))
+		kenTheGuruService.AddWebsocketMiddleware(ws.Before(ratelimit.Websocket(limiter)))
+		reloader.OnReload(func(old, new config.Config) error {
+			limiter.SetPlans(new.RateLimit.Plans)
+			return nil
+		})
+	}
+
+	idempotencyStore := idempotency.NewMemoryStore()
+	if cfg.Idempotency.Backend == "redis" {
+		idempotencyStore, err = idempotency.NewRedisStore(cfg.Idempotency.RedisURL)
+		if err != nil {
+			panic(err)
+		}
+	}
+	idempotencyInterceptor := idempotency.UnaryServerInterceptor(idempotencyStore, time.Duration(cfg.Idempotency.TTL)*time.Second, idempotentMethods, log.With(logger, "component", "idempotency"))
+
+	grpcServices := []grpcService{
+		func(s *grpc.Server, l log.Logger) {
+			userPB.RegisterUserServiceServer(s, user.MakeGRPCServer(ctx, userEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			kmiPB.RegisterKMIServiceServer(s, kmi.MakeGRPCServer(ctx, kmiEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			routingPB.RegisterRoutingServiceServer(s, routing.MakeGRPCServer(ctx, routingEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			containerPB.RegisterContainerServiceServer(s, container.MakeGRPCServer(ctx, containerServiceEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			modulePB.RegisterModuleServiceServer(s, module.MakeGRPCServer(ctx, moduleServeEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			dnsPB.RegisterDNSServiceServer(s, dns.MakeGRPCServer(ctx, dnsEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			adminPB.RegisterAdminServiceServer(s, admin.MakeGRPCServer(ctx, adminEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			webhookPB.RegisterWebhookServiceServer(s, webhook.MakeGRPCServer(ctx, webhookEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			sshkeyPB.RegisterSSHKeyServiceServer(s, sshkey.MakeGRPCServer(ctx, sshKeyEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			portsPB.RegisterPortServiceServer(s, ports.MakeGRPCServer(ctx, portEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			agentPB.RegisterAgentServiceServer(s, agent.MakeGRPCServer(ctx, agentEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			exportPB.RegisterExportServiceServer(s, export.MakeGRPCServer(ctx, exportEndpoints, l))
+		},
+		func(s *grpc.Server, l log.Logger) {
+			managementPB.RegisterManagementServiceServer(s, management.MakeGRPCServer(ctx, managementEndpoints, l))
+		},
+	}
+
+	// the optional services are only served if they are enabled
+	if firewallEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			firewallPB.RegisterFirewallServiceServer(s, firewall.MakeGRPCServer(ctx, *firewallEndpoints, l))
+		})
+	}
+	if databaseEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			databasePB.RegisterDatabaseServiceServer(s, database.MakeGRPCServer(ctx, *databaseEndpoints, l))
+		})
+	}
+	if snapshotEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			snapshotPB.RegisterSnapshotServiceServer(s, snapshot.MakeGRPCServer(ctx, *snapshotEndpoints, l))
+		})
+	}
+	if billingEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			billingPB.RegisterBillingServiceServer(s, billing.MakeGRPCServer(ctx, *billingEndpoints, l))
+		})
+	}
+	if cronEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			cronjobPB.RegisterCronJobServiceServer(s, cronjob.MakeGRPCServer(ctx, *cronEndpoints, l))
+		})
+	}
+	if containerLogEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			containerlogPB.RegisterContainerLogServiceServer(s, containerlog.MakeGRPCServer(ctx, *containerLogEndpoints, l))
+		})
+	}
+	if alertEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			alertPB.RegisterAlertServiceServer(s, alert.MakeGRPCServer(ctx, *alertEndpoints, l))
+		})
+	}
+	if autoscaleEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			autoscalePB.RegisterAutoscaleServiceServer(s, autoscale.MakeGRPCServer(ctx, *autoscaleEndpoints, l))
+		})
+	}
+	if banEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			banPB.RegisterBanServiceServer(s, ban.MakeGRPCServer(ctx, *banEndpoints, l))
+		})
+	}
+	if wireguardEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			wireguardPB.RegisterWireGuardServiceServer(s, wireguard.MakeGRPCServer(ctx, *wireguardEndpoints, l))
+		})
+	}
+	if resolverEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			resolverPB.RegisterResolverServiceServer(s, resolver.MakeGRPCServer(ctx, *resolverEndpoints, l))
+		})
+	}
+	if usageEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			usagePB.RegisterUsageServiceServer(s, usage.MakeGRPCServer(ctx, *usageEndpoints, l))
+		})
+	}
+	if siteEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			sitePB.RegisterSiteServiceServer(s, site.MakeGRPCServer(ctx, *siteEndpoints, l))
+		})
+	}
+	if deployEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			deployPB.RegisterDeployServiceServer(s, deploy.MakeGRPCServer(ctx, *deployEndpoints, l))
+		})
+	}
+	if networkEndpoints != nil {
+		grpcServices = append(grpcServices, func(s *grpc.Server, l log.Logger) {
+			networkPB.RegisterNetworkServiceServer(s, network.MakeGRPCServer(ctx, *networkEndpoints, l))
+		})
+	}
+
+	err = startGRPCTransport(errc, logger, lc, tracer, cfg.Listen.GRPC, conf.GRPCCertFile, conf.GRPCKeyFile, kenTheGuruService, time.Duration(cfg.RequestTimeout)*time.Second, requestLimits, limiter, maintenance.UnaryServerInterceptor(maintenanceMode, readOnlyMethods()), idempotencyInterceptor, grpcServices)
+	if err != nil {
+		panic(err)
+	}
+
+	dialOption := grpc.WithInsecure()
+	if conf.GRPCCertFile != "" {
+		// the connection never leaves this process, so the certificate does not need to be verified
+		dialOption = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}))
+	}
+
+	conn, err := grpc.Dial(cfg.Listen.GRPC, dialOption, grpc.WithTimeout(time.Second), grpc.WithUnaryInterceptor(tracing.UnaryClientInterceptor(tracer)))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v", err)
+		os.Exit(1)
+	}
+	lc.Add("gateway connection", lifecycle.Closer(conn))
+
+	if conf.GatewayAddr != "" {
+		var downloadHandler http.Handler
+		if downloads != nil {
+			downloadHandler = download.Handler(artifacts, downloads, log.With(logger, "component", "downloads"))
+		}
+
+		err = startGateway(errc, logger, lc, tracer, conf.GatewayAddr, conf.GRPCCertFile, conf.GRPCKeyFile, conn, requestLimits, billingWebhook, deployHooks, kmi.AssetHandler(moduleAssets, kmi.AssetOptions{
+			MaxAge:                time.Duration(cfg.ModuleUI.MaxAge) * time.Second,
+			ContentSecurityPolicy: cfg.ModuleUI.ContentSecurityPolicy,
+		}), downloadHandler)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	go kenTheGuruService.StartWebsocketTransport(errc, logger, cfg.Listen.Websocket)
+	lc.Add("websocket transport", kenTheGuruService.StopWebsocketTransport)
+
+	if cfg.Listen.Metrics != "" {
+		startMetrics(errc, logger, lc, cfg.Listen.Metrics, metricsProvider, health.Handler(healthRegistry))
+	}
+
+	if registryCache != nil {
+		level.Info(logger).Log("transport", "registry", "addr", cfg.Registry.Listen)
+		serveHTTP(errc, lc, "registry transport", &http.Server{
+			Addr:    cfg.Registry.Listen,
+			Handler: registryCache,
+		}, "", "")
+	}
+
+	if cfg.SSH.Enabled {
+		var receiver sshgateway.Receiver
+		if deployService != nil {
+			receiver = deployService
+		}
+		err = startSSHGateway(errc, logger, lc, cfg.SSH, sshKeyService, containerService, receiver)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if cfg.Resolver.Enabled {
+		err = startResolver(errc, logger, lc, cfg.Resolver, resolverService, resolverNetwork{bridgeNetworks})
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	kenTheGuruService.SetReloader(reloader)
+	kenTheGuruService.SetHealthReporter(healthRegistry)
+	kenTheGuruService.SetJobQueue(jobQueue)
+	kenTheGuruService.SetImpersonation(impersonations)
+	if loginGuard != nil {
+		kenTheGuruService.SetGuard(loginGuard)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go reloader.Watch(hup, log.With(logger, "component", "config"))
+	lc.Add("configuration reloader", func(context.Context) error {
+		signal.Stop(hup)
+		close(hup)
+		return nil
+	})
+
+	// Interrupt handler.
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		errc <- fmt.Errorf("%s", <-interrupt)
+	}()
+
+	level.Info(logger).Log("exit", <-errc)
+
+	// a second signal skips the rest of the shutdown
+	go func() {
+		level.Warn(logger).Log("msg", "shutdown aborted", "signal", <-interrupt)
+		os.Exit(1)
+	}()
+
+	err = lc.Shutdown(time.Duration(cfg.ShutdownTimeout) * time.Second)
+	if err != nil {
+		level.Error(logger).Log("err", err)
+	}
+}
+
+// grpcService registers the gRPC server of a service with s, its calls are logged with l
+type grpcService func(s *grpc.Server, l log.Logger)
+
+// startGRPCTransport serves every service via gRPC, calls are authorized by ktg, rejected by
+// inMaintenance during maintenance windows, limited by limiter if it is set and retries are answered
+// by idempotent, calls of deprecated methods announce their successor and calls taking longer than
+// timeout are cancelled. Calls rejected by an open circuit breaker fail with codes.Unavailable.
+// The connections are secured with TLS if a certificate is given.
+// The servers of the other services are registered by services.
+// The server runs in the background until it is stopped by lc.
+func startGRPCTransport(errc chan error, logger log.Logger, lc *lifecycle.Manager, tracer opentracing.Tracer, grpcAddr, certFile, keyFile string, ktg kentheguru.Service, timeout time.Duration, requestLimits payload.Limits, limiter *ratelimit.Limiter, inMaintenance, idempotent grpc.UnaryServerInterceptor, services []grpcService) error {
+	logger = log.With(logger, "transport", "gRPC")
+
+	interceptors := []grpc.UnaryServerInterceptor{logging.UnaryServerInterceptor(), tracing.UnaryServerInterceptor(tracer), payload.UnaryServerInterceptor(requestLimits), deadline.UnaryServerInterceptor(timeout), versioning.UnaryServerInterceptor(deprecatedMethods, logger), validation.UnaryServerInterceptor(), breaker.UnaryServerInterceptor(), ktg.Intercept, inMaintenance}
+	if limiter != nil {
+		interceptors = append(interceptors, ratelimit.UnaryServerInterceptor(limiter))
+	}
+	interceptors = append(interceptors, idempotent)
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.CustomCodec(payload.Codec{}),
+	}
+	if size := requestLimits.Largest(); size > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(size))
+	}
+	if certFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	ln, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer(opts...)
+
+	kentheguruPB.RegisterKenTheGuruServiceServer(s, ktg)
+	for _, register := range services {
+		register(s, logger)
+	}
+
+	lc.Add("gRPC transport", lifecycle.GRPCServer(s))
+
+	level.Info(logger).Log("addr", grpcAddr)
+	go func() {
+		err := s.Serve(ln)
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return nil
+}
+
+// serveHTTP serves srv in the background until it is stopped by lc, TLS is used if certFile is set
+func serveHTTP(errc chan error, lc *lifecycle.Manager, name string, srv *http.Server, certFile, keyFile string) {
+	lc.Add(name, lifecycle.HTTPServer(srv))
+
+	go func() {
+		var err error
+		if certFile != "" {
+			err = srv.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+
+		if err != http.ErrServerClosed {
+			errc <- err
+		}
+	}()
+}
+
+// startGateway serves the RESTful API, the requests are passed on to the gRPC server via conn.
+// The webhooks of the payment provider are passed to billingWebhook if billing is enabled, the webhooks
+// of GitHub and GitLab to deployHooks if deployments are enabled and the frontend assets of the modules
+// are served by moduleAssets. The artifacts are downloaded from downloads if signed download URLs are enabled.
+// Bodies may be twice as large as the requests, the JSON of bytes is base64 encoded.
+func startGateway(errc chan error, logger log.Logger, lc *lifecycle.Manager, tracer opentracing.Tracer, addr, certFile, keyFile string, conn *grpc.ClientConn, requestLimits payload.Limits, billingWebhook, deployHooks, moduleAssets, downloads http.Handler) error {
+	logger = log.With(logger, "transport", "gateway")
+
+	s, err := gateway.NewServer(conn, gateway.Routes, gateway.Versions, gateway.Info{
+		Title:   "kontainerooo",
+		Version: "v2",
+	}, logger)
+	if err != nil {
+		return err
+	}
+	s.MaxBodySize = 2 * int64(requestLimits.Largest())
+
+	mux := http.NewServeMux()
+	mux.Handle("/", s)
+	mux.Handle(kmi.AssetPath, moduleAssets)
+	if billingWebhook != nil {
+		mux.Handle(billing.WebhookPath, billingWebhook)
+	}
+	if deployHooks != nil {
+		mux.Handle(deploy.HookPath, deployHooks)
+	}
+	if downloads != nil {
+		mux.Handle(download.Path, downloads)
+	}
+
+	level.Info(logger).Log("addr", addr)
+	serveHTTP(errc, lc, "gateway transport", &http.Server{
+		Addr:    addr,
+		Handler: tracing.Handler(tracer, logging.Handler(mux)),
+	}, certFile, keyFile)
+	return nil
+}
+
+// startMetrics serves the metrics of p and the liveness and readiness served by h
+func startMetrics(errc chan error, logger log.Logger, lc *lifecycle.Manager, addr string, p metrics.Provider, h http.Handler) {
+	logger = log.With(logger, "transport", "metrics")
+
+	mux := http.NewServeMux()
+	mux.Handle(metrics.Path, p.Handler())
+	mux.Handle(health.LivePath, h)
+	mux.Handle(health.ReadyPath, h)
+
+	level.Info(logger).Log("addr", addr)
+	serveHTTP(errc, lc, "metrics transport", &http.Server{
+		Addr:    addr,
+		Handler: mux,
+	}, "", "")
+}
+
+// startSSHGateway bridges the SSH sessions of the users to their containers, they log in with the keys of keys.
+// Git pushes are passed to receiver if it is not nil.
+func startSSHGateway(errc chan error, logger log.Logger, lc *lifecycle.Manager, c config.SSH, keys sshgateway.KeyStore, containers sshgateway.Containers, receiver sshgateway.Receiver) error {
+	logger = log.With(logger, "transport", "ssh")
+
+	hostKey, err := sshgateway.HostKey(c.HostKey)
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", c.Address)
+	if err != nil {
+		return err
+	}
+
+	gateway := sshgateway.NewGateway(hostKey, keys, containers, c.Recordings, logger)
+	if receiver != nil {
+		gateway.SetReceiver(receiver)
+	}
+	level.Info(logger).Log("addr", c.Address)
+	go func() {
+		err := gateway.Serve(ln)
+		if err != sshgateway.ErrClosed {
+			errc <- err
+		}
+	}()
+	lc.Add("ssh gateway", lifecycle.Closer(gateway))
+	return nil
+}
+
+// startResolver answers the DNS queries of the containers, their sibling instances are resolved under the domain
+func startResolver(errc chan error, logger log.Logger, lc *lifecycle.Manager, c config.Resolver, s resolver.Service, nw resolver.Network) error {
+	logger = log.With(logger, "transport", "dns")
+
+	conn, err := net.ListenPacket("udp", c.Listen)
+	if err != nil {
+		return err
+	}
+
+	server := resolver.NewServer(s, nw, resolver.ServerOptions{
+		Domain:   c.Domain,
+		Upstream: c.Upstream,
+		TTL:      time.Duration(c.TTL) * time.Second,
+	}, logger)
+	level.Info(logger).Log("addr", c.Listen)
+	go func() {
+		err := server.Serve(conn)
+		if err != resolver.ErrClosed {
+			errc <- err
+		}
+	}()
+	lc.Add("resolver", lifecycle.Closer(server))
+	return nil
+}
+
+func makeUserServiceEndpoints(s user.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) user.Endpoints {
+	var createUserEndpoint endpoint.Endpoint
+	{
+		createUserEndpoint = user.MakeCreateUserEndpoint(s)
+		createUserEndpoint = validation.Middleware()(createUserEndpoint)
+		createUserEndpoint = tracing.Middleware(tracer, "user", "CreateUser")(createUserEndpoint)
+		createUserEndpoint = instrumenting.Middleware("user", "CreateUser")(createUserEndpoint)
+		createUserEndpoint = logging.Middleware(logger, "user", "CreateUser")(createUserEndpoint)
+	}
+
+	var editUserEndpoint endpoint.Endpoint
+	{
+		editUserEndpoint = user.MakeEditUserEndpoint(s)
+		editUserEndpoint = validation.Middleware()(editUserEndpoint)
+		editUserEndpoint = tracing.Middleware(tracer, "user", "EditUser")(editUserEndpoint)
+		editUserEndpoint = instrumenting.Middleware("user", "EditUser")(editUserEndpoint)
+		editUserEndpoint = logging.Middleware(logger, "user", "EditUser")(editUserEndpoint)
+	}
+
+	var changeUsernaemEndpoint endpoint.Endpoint
+	{
+		changeUsernaemEndpoint = user.MakeChangeUsernameEndpoint(s)
+		changeUsernaemEndpoint = validation.Middleware()(changeUsernaemEndpoint)
+		changeUsernaemEndpoint = tracing.Middleware(tracer, "user", "ChangeUsername")(changeUsernaemEndpoint)
+		changeUsernaemEndpoint = instrumenting.Middleware("user", "ChangeUsername")(changeUsernaemEndpoint)
+		changeUsernaemEndpoint = logging.Middleware(logger, "user", "ChangeUsername")(changeUsernaemEndpoint)
+	}
+
+	var deleteUserEndpoint endpoint.Endpoint
+	{
+		deleteUserEndpoint = user.MakeDeleteUserEndpoint(s)
+		deleteUserEndpoint = validation.Middleware()(deleteUserEndpoint)
+		deleteUserEndpoint = tracing.Middleware(tracer, "user", "DeleteUser")(deleteUserEndpoint)
+		deleteUserEndpoint = instrumenting.Middleware("user", "DeleteUser")(deleteUserEndpoint)
+		deleteUserEndpoint = logging.Middleware(logger, "user", "DeleteUser")(deleteUserEndpoint)
+	}
+
+	var resetPasswordEndpoint endpoint.Endpoint
+	{
+		resetPasswordEndpoint = user.MakeResetPasswordEndpoint(s)
+		resetPasswordEndpoint = validation.Middleware()(resetPasswordEndpoint)
+		resetPasswordEndpoint = tracing.Middleware(tracer, "user", "ResetPassword")(resetPasswordEndpoint)
+		resetPasswordEndpoint = instrumenting.Middleware("user", "ResetPassword")(resetPasswordEndpoint)
+		resetPasswordEndpoint = logging.Middleware(logger, "user", "ResetPassword")(resetPasswordEndpoint)
+	}
+
+	var getUserEndpoint endpoint.Endpoint
+	{
+		getUserEndpoint = user.MakeGetUserEndpoint(s)
+		getUserEndpoint = validation.Middleware()(getUserEndpoint)
+		getUserEndpoint = tracing.Middleware(tracer, "user", "GetUser")(getUserEndpoint)
+		getUserEndpoint = instrumenting.Middleware("user", "GetUser")(getUserEndpoint)
+		getUserEndpoint = logging.Middleware(logger, "user", "GetUser")(getUserEndpoint)
+	}
+
+	var checkLoginCredentialsEndpoint endpoint.Endpoint
+	{
+		checkLoginCredentialsEndpoint = user.MakeCheckLoginCredentialsEndpoint(s)
+		checkLoginCredentialsEndpoint = validation.Middleware()(checkLoginCredentialsEndpoint)
+		checkLoginCredentialsEndpoint = tracing.Middleware(tracer, "user", "CheckLoginCredentials")(checkLoginCredentialsEndpoint)
+		checkLoginCredentialsEndpoint = instrumenting.Middleware("user", "CheckLoginCredentials")(checkLoginCredentialsEndpoint)
+		checkLoginCredentialsEndpoint = logging.Middleware(logger, "user", "CheckLoginCredentials")(checkLoginCredentialsEndpoint)
+	}
+
+	return user.Endpoints{
+		CreateUserEndpoint:            createUserEndpoint,
+		EditUserEndpoint:              editUserEndpoint,
+		ChangeUsernameEndpoint:        changeUsernaemEndpoint,
+		DeleteUserEndpoint:            deleteUserEndpoint,
+		ResetPasswordEndpoint:         resetPasswordEndpoint,
+		GetUserEndpoint:               getUserEndpoint,
+		CheckLoginCredentialsEndpoint: checkLoginCredentialsEndpoint,
+	}
+}
+
+func makeKMIServiceEndpoints(s kmi.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) kmi.Endpoints {
+	var AddKMIEndpoint endpoint.Endpoint
+	{
+		AddKMIEndpoint = kmi.MakeAddKMIEndpoint(s)
+		AddKMIEndpoint = validation.Middleware()(AddKMIEndpoint)
+		AddKMIEndpoint = tracing.Middleware(tracer, "kmi", "AddKMI")(AddKMIEndpoint)
+		AddKMIEndpoint = instrumenting.Middleware("kmi", "AddKMI")(AddKMIEndpoint)
+		AddKMIEndpoint = logging.Middleware(logger, "kmi", "AddKMI")(AddKMIEndpoint)
+	}
+
+	var RemoveKMIEndpoint endpoint.Endpoint
+	{
+		RemoveKMIEndpoint = kmi.MakeRemoveKMIEndpoint(s)
+		RemoveKMIEndpoint = validation.Middleware()(RemoveKMIEndpoint)
+		RemoveKMIEndpoint = tracing.Middleware(tracer, "kmi", "RemoveKMI")(RemoveKMIEndpoint)
+		RemoveKMIEndpoint = instrumenting.Middleware("kmi", "RemoveKMI")(RemoveKMIEndpoint)
+		RemoveKMIEndpoint = logging.Middleware(logger, "kmi", "RemoveKMI")(RemoveKMIEndpoint)
+	}
+
+	var GetKMIEndpoint endpoint.Endpoint
+	{
+		GetKMIEndpoint = kmi.MakeGetKMIEndpoint(s)
+		GetKMIEndpoint = validation.Middleware()(GetKMIEndpoint)
+		GetKMIEndpoint = tracing.Middleware(tracer, "kmi", "GetKMI")(GetKMIEndpoint)
+		GetKMIEndpoint = instrumenting.Middleware("kmi", "GetKMI")(GetKMIEndpoint)
+		GetKMIEndpoint = logging.Middleware(logger, "kmi", "GetKMI")(GetKMIEndpoint)
+	}
+
+	var KMIEndpoint endpoint.Endpoint
+	{
+		KMIEndpoint = kmi.MakeKMIEndpoint(s)
+		KMIEndpoint = validation.Middleware()(KMIEndpoint)
+		KMIEndpoint = tracing.Middleware(tracer, "kmi", "KMI")(KMIEndpoint)
+		KMIEndpoint = instrumenting.Middleware("kmi", "KMI")(KMIEndpoint)
+		KMIEndpoint = logging.Middleware(logger, "kmi", "KMI")(KMIEndpoint)
+	}
+
+	var UIsEndpoint endpoint.Endpoint
+	{
+		UIsEndpoint = kmi.MakeUIsEndpoint(s)
+		UIsEndpoint = validation.Middleware()(UIsEndpoint)
+		UIsEndpoint = tracing.Middleware(tracer, "kmi", "UIs")(UIsEndpoint)
+		UIsEndpoint = instrumenting.Middleware("kmi", "UIs")(UIsEndpoint)
+		UIsEndpoint = logging.Middleware(logger, "kmi", "UIs")(UIsEndpoint)
+	}
+
+	return kmi.Endpoints{
+		AddKMIEndpoint:    AddKMIEndpoint,
+		RemoveKMIEndpoint: RemoveKMIEndpoint,
+		GetKMIEndpoint:    GetKMIEndpoint,
+		KMIEndpoint:       KMIEndpoint,
+		UIsEndpoint:       UIsEndpoint,
+	}
+}
+
+func makeContainerServiceEndpoints(s container.Service, b *breaker.Breaker, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) container.Endpoints {
+
+	var CreateContainerEndpoint endpoint.Endpoint
+	{
+		CreateContainerEndpoint = container.MakeCreateContainerEndpoint(s)
+		CreateContainerEndpoint = breaker.Middleware(b)(CreateContainerEndpoint)
+		CreateContainerEndpoint = validation.Middleware()(CreateContainerEndpoint)
+		CreateContainerEndpoint = tracing.Middleware(tracer, "container", "CreateContainer")(CreateContainerEndpoint)
+		CreateContainerEndpoint = instrumenting.Middleware("container", "CreateContainer")(CreateContainerEndpoint)
+		CreateContainerEndpoint = logging.Middleware(logger, "container", "CreateContainer")(CreateContainerEndpoint)
+	}
+	var RemoveContainerEndpoint endpoint.Endpoint
+	{
+		RemoveContainerEndpoint = container.MakeRemoveContainerEndpoint(s)
+		RemoveContainerEndpoint = breaker.Middleware(b)(RemoveContainerEndpoint)
+		RemoveContainerEndpoint = validation.Middleware()(RemoveContainerEndpoint)
+		RemoveContainerEndpoint = tracing.Middleware(tracer, "container", "RemoveContainer")(RemoveContainerEndpoint)
+		RemoveContainerEndpoint = instrumenting.Middleware("container", "RemoveContainer")(RemoveContainerEndpoint)
+		RemoveContainerEndpoint = logging.Middleware(logger, "container", "RemoveContainer")(RemoveContainerEndpoint)
+	}
+	var InstancesEndpoint endpoint.Endpoint
+	{
+		InstancesEndpoint = container.MakeInstancesEndpoint(s)
+		InstancesEndpoint = breaker.Middleware(b)(InstancesEndpoint)
+		InstancesEndpoint = validation.Middleware()(InstancesEndpoint)
+		InstancesEndpoint = tracing.Middleware(tracer, "container", "Instances")(InstancesEndpoint)
+		InstancesEndpoint = instrumenting.Middleware("container", "Instances")(InstancesEndpoint)
+		InstancesEndpoint = logging.Middleware(logger, "container", "Instances")(InstancesEndpoint)
+	}
+	var StopContainerEndpoint endpoint.Endpoint
+	{
+		StopContainerEndpoint = container.MakeStopContainerEndpoint(s)
+		StopContainerEndpoint = breaker.Middleware(b)(StopContainerEndpoint)
+		StopContainerEndpoint = validation.Middleware()(StopContainerEndpoint)
+		StopContainerEndpoint = tracing.Middleware(tracer, "container", "StopContainer")(StopContainerEndpoint)
+		StopContainerEndpoint = instrumenting.Middleware("container", "StopContainer")(StopContainerEndpoint)
+		StopContainerEndpoint = logging.Middleware(logger, "container", "StopContainer")(StopContainerEndpoint)
+	}
+	var ExecuteEndpoint endpoint.Endpoint
+	{
+		ExecuteEndpoint = container.MakeExecuteEndpoint(s)
+		ExecuteEndpoint = breaker.Middleware(b)(ExecuteEndpoint)
+		ExecuteEndpoint = validation.Middleware()(ExecuteEndpoint)
+		ExecuteEndpoint = tracing.Middleware(tracer, "container", "Execute")(ExecuteEndpoint)
+		ExecuteEndpoint = instrumenting.Middleware("container", "Execute")(ExecuteEndpoint)
+		ExecuteEndpoint = logging.Middleware(logger, "container", "Execute")(ExecuteEndpoint)
+	}
+	var GetEnvEndpoint endpoint.Endpoint
+	{
+		GetEnvEndpoint = container.MakeGetEnvEndpoint(s)
+		GetEnvEndpoint = breaker.Middleware(b)(GetEnvEndpoint)
+		GetEnvEndpoint = validation.Middleware()(GetEnvEndpoint)
+		GetEnvEndpoint = tracing.Middleware(tracer, "container", "GetEnv")(GetEnvEndpoint)
+		GetEnvEndpoint = instrumenting.Middleware("container", "GetEnv")(GetEnvEndpoint)
+		GetEnvEndpoint = logging.Middleware(logger, "container", "GetEnv")(GetEnvEndpoint)
+	}
+	var SetEnvEndpoint endpoint.Endpoint
+	{
+		SetEnvEndpoint = container.MakeSetEnvEndpoint(s)
+		SetEnvEndpoint = breaker.Middleware(b)(SetEnvEndpoint)
+		SetEnvEndpoint = validation.Middleware()(SetEnvEndpoint)
+		SetEnvEndpoint = tracing.Middleware(tracer, "container", "SetEnv")(SetEnvEndpoint)
+		SetEnvEndpoint = instrumenting.Middleware("container", "SetEnv")(SetEnvEndpoint)
+		SetEnvEndpoint = logging.Middleware(logger, "container", "SetEnv")(SetEnvEndpoint)
+	}
+	var IDForNameEndpoint endpoint.Endpoint
+	{
+		IDForNameEndpoint = container.MakeIDForNameEndpoint(s)
+		IDForNameEndpoint = breaker.Middleware(b)(IDForNameEndpoint)
+		IDForNameEndpoint = validation.Middleware()(IDForNameEndpoint)
+		IDForNameEndpoint = tracing.Middleware(tracer, "container", "IDForName")(IDForNameEndpoint)
+		IDForNameEndpoint = instrumenting.Middleware("container", "IDForName")(IDForNameEndpoint)
+		IDForNameEndpoint = logging.Middleware(logger, "container", "IDForName")(IDForNameEndpoint)
+	}
+	var GetContainerKMIEndpoint endpoint.Endpoint
+	{
+		GetContainerKMIEndpoint = container.MakeGetContainerKMIEndpoint(s)
+		GetContainerKMIEndpoint = breaker.Middleware(b)(GetContainerKMIEndpoint)
+		GetContainerKMIEndpoint = validation.Middleware()(GetContainerKMIEndpoint)
+		GetContainerKMIEndpoint = tracing.Middleware(tracer, "container", "GetContainerKMI")(GetContainerKMIEndpoint)
+		GetContainerKMIEndpoint = instrumenting.Middleware("container", "GetContainerKMI")(GetContainerKMIEndpoint)
+		GetContainerKMIEndpoint = logging.Middleware(logger, "container", "GetContainerKMI")(GetContainerKMIEndpoint)
+	}
+	var SetLinkEndpoint endpoint.Endpoint
+	{
+		SetLinkEndpoint = container.MakeSetLinkEndpoint(s)
+		SetLinkEndpoint = breaker.Middleware(b)(SetLinkEndpoint)
+		SetLinkEndpoint = validation.Middleware()(SetLinkEndpoint)
+		SetLinkEndpoint = tracing.Middleware(tracer, "container", "SetLink")(SetLinkEndpoint)
+		SetLinkEndpoint = instrumenting.Middleware("container", "SetLink")(SetLinkEndpoint)
+		SetLinkEndpoint = logging.Middleware(logger, "container", "SetLink")(SetLinkEndpoint)
+	}
+	var RemoveLinkEndpoint endpoint.Endpoint
+	{
+		RemoveLinkEndpoint = container.MakeRemoveLinkEndpoint(s)
+		RemoveLinkEndpoint = breaker.Middleware(b)(RemoveLinkEndpoint)
+		RemoveLinkEndpoint = validation.Middleware()(RemoveLinkEndpoint)
+		RemoveLinkEndpoint = tracing.Middleware(tracer, "container", "RemoveLink")(RemoveLinkEndpoint)
+		RemoveLinkEndpoint = instrumenting.Middleware("container", "RemoveLink")(RemoveLinkEndpoint)
+		RemoveLinkEndpoint = logging.Middleware(logger, "container", "RemoveLink")(RemoveLinkEndpoint)
+	}
+	var GetLinksEndpoint endpoint.Endpoint
+	{
+		GetLinksEndpoint = container.MakeGetLinksEndpoint(s)
+		GetLinksEndpoint = breaker.Middleware(b)(GetLinksEndpoint)
+		GetLinksEndpoint = validation.Middleware()(GetLinksEndpoint)
+		GetLinksEndpoint = tracing.Middleware(tracer, "container", "GetLinks")(GetLinksEndpoint)
+		GetLinksEndpoint = instrumenting.Middleware("container", "GetLinks")(GetLinksEndpoint)
+		GetLinksEndpoint = logging.Middleware(logger, "container", "GetLinks")(GetLinksEndpoint)
+	}
+	var ScaleInstanceEndpoint endpoint.Endpoint
+	{
+		ScaleInstanceEndpoint = container.MakeScaleInstanceEndpoint(s)
+		ScaleInstanceEndpoint = breaker.Middleware(b)(ScaleInstanceEndpoint)
+		ScaleInstanceEndpoint = validation.Middleware()(ScaleInstanceEndpoint)
+		ScaleInstanceEndpoint = tracing.Middleware(tracer, "container", "ScaleInstance")(ScaleInstanceEndpoint)
+		ScaleInstanceEndpoint = instrumenting.Middleware("container", "ScaleInstance")(ScaleInstanceEndpoint)
+		ScaleInstanceEndpoint = logging.Middleware(logger, "container", "ScaleInstance")(ScaleInstanceEndpoint)
+	}
+	var CloneInstanceEndpoint endpoint.Endpoint
+	{
+		CloneInstanceEndpoint = container.MakeCloneInstanceEndpoint(s)
+		CloneInstanceEndpoint = breaker.Middleware(b)(CloneInstanceEndpoint)
+		CloneInstanceEndpoint = validation.Middleware()(CloneInstanceEndpoint)
+		CloneInstanceEndpoint = tracing.Middleware(tracer, "container", "CloneInstance")(CloneInstanceEndpoint)
+		CloneInstanceEndpoint = instrumenting.Middleware("container", "CloneInstance")(CloneInstanceEndpoint)
+		CloneInstanceEndpoint = logging.Middleware(logger, "container", "CloneInstance")(CloneInstanceEndpoint)
+	}
+	var CheckpointInstanceEndpoint endpoint.Endpoint
+	{
+		CheckpointInstanceEndpoint = container.MakeCheckpointInstanceEndpoint(s)
+		CheckpointInstanceEndpoint = breaker.Middleware(b)(CheckpointInstanceEndpoint)
+		CheckpointInstanceEndpoint = validation.Middleware()(CheckpointInstanceEndpoint)
+		CheckpointInstanceEndpoint = tracing.Middleware(tracer, "container", "CheckpointInstance")(CheckpointInstanceEndpoint)
+		CheckpointInstanceEndpoint = instrumenting.Middleware("container", "CheckpointInstance")(CheckpointInstanceEndpoint)
+		CheckpointInstanceEndpoint = logging.Middleware(logger, "container", "CheckpointInstance")(CheckpointInstanceEndpoint)
+	}
+	var RestoreInstanceEndpoint endpoint.Endpoint
+	{
+		RestoreInstanceEndpoint = container.MakeRestoreInstanceEndpoint(s)
+		RestoreInstanceEndpoint = breaker.Middleware(b)(RestoreInstanceEndpoint)
+		RestoreInstanceEndpoint = validation.Middleware()(RestoreInstanceEndpoint)
+		RestoreInstanceEndpoint = tracing.Middleware(tracer, "container", "RestoreInstance")(RestoreInstanceEndpoint)
+		RestoreInstanceEndpoint = instrumenting.Middleware("container", "RestoreInstance")(RestoreInstanceEndpoint)
+		RestoreInstanceEndpoint = logging.Middleware(logger, "container", "RestoreInstance")(RestoreInstanceEndpoint)
+	}
+	var CheckpointsEndpoint endpoint.Endpoint
+	{
+		CheckpointsEndpoint = container.MakeCheckpointsEndpoint(s)
+		CheckpointsEndpoint = breaker.Middleware(b)(CheckpointsEndpoint)
+		CheckpointsEndpoint = validation.Middleware()(CheckpointsEndpoint)
+		CheckpointsEndpoint = tracing.Middleware(tracer, "container", "Checkpoints")(CheckpointsEndpoint)
+		CheckpointsEndpoint = instrumenting.Middleware("container", "Checkpoints")(CheckpointsEndpoint)
+		CheckpointsEndpoint = logging.Middleware(logger, "container", "Checkpoints")(CheckpointsEndpoint)
+	}
+	var RemoveCheckpointEndpoint endpoint.Endpoint
+	{
+		RemoveCheckpointEndpoint = container.MakeRemoveCheckpointEndpoint(s)
+		RemoveCheckpointEndpoint = breaker.Middleware(b)(RemoveCheckpointEndpoint)
+		RemoveCheckpointEndpoint = validation.Middleware()(RemoveCheckpointEndpoint)
+		RemoveCheckpointEndpoint = tracing.Middleware(tracer, "container", "RemoveCheckpoint")(RemoveCheckpointEndpoint)
+		RemoveCheckpointEndpoint = instrumenting.Middleware("container", "RemoveCheckpoint")(RemoveCheckpointEndpoint)
+		RemoveCheckpointEndpoint = logging.Middleware(logger, "container", "RemoveCheckpoint")(RemoveCheckpointEndpoint)
+	}
+	var PinInstanceEndpoint endpoint.Endpoint
+	{
+		PinInstanceEndpoint = container.MakePinInstanceEndpoint(s)
+		PinInstanceEndpoint = breaker.Middleware(b)(PinInstanceEndpoint)
+		PinInstanceEndpoint = validation.Middleware()(PinInstanceEndpoint)
+		PinInstanceEndpoint = tracing.Middleware(tracer, "container", "PinInstance")(PinInstanceEndpoint)
+		PinInstanceEndpoint = instrumenting.Middleware("container", "PinInstance")(PinInstanceEndpoint)
+		PinInstanceEndpoint = logging.Middleware(logger, "container", "PinInstance")(PinInstanceEndpoint)
+	}
+	var SetPlacementEndpoint endpoint.Endpoint
+	{
+		SetPlacementEndpoint = container.MakeSetPlacementEndpoint(s)
+		SetPlacementEndpoint = breaker.Middleware(b)(SetPlacementEndpoint)
+		SetPlacementEndpoint = validation.Middleware()(SetPlacementEndpoint)
+		SetPlacementEndpoint = tracing.Middleware(tracer, "container", "SetPlacement")(SetPlacementEndpoint)
+		SetPlacementEndpoint = instrumenting.Middleware("container", "SetPlacement")(SetPlacementEndpoint)
+		SetPlacementEndpoint = logging.Middleware(logger, "container", "SetPlacement")(SetPlacementEndpoint)
+	}
+	var ExportInstanceEndpoint endpoint.Endpoint
+	{
+		ExportInstanceEndpoint = container.MakeExportInstanceEndpoint(s)
+		ExportInstanceEndpoint = breaker.Middleware(b)(ExportInstanceEndpoint)
+		ExportInstanceEndpoint = validation.Middleware()(ExportInstanceEndpoint)
+		ExportInstanceEndpoint = tracing.Middleware(tracer, "container", "ExportInstance")(ExportInstanceEndpoint)
+		ExportInstanceEndpoint = instrumenting.Middleware("container", "ExportInstance")(ExportInstanceEndpoint)
+		ExportInstanceEndpoint = logging.Middleware(logger, "container", "ExportInstance")(ExportInstanceEndpoint)
+	}
+
+	return container.Endpoints{
+		CreateContainerEndpoint: CreateContainerEndpoint,
+		RemoveContainerEndpoint: RemoveContainerEndpoint,
+		InstancesEndpoint:       InstancesEndpoint,
+		StopContainerEndpoint:   StopContainerEndpoint,
+		ExecuteEndpoint:         ExecuteEndpoint,
+		GetEnvEndpoint:          GetEnvEndpoint,
+		SetEnvEndpoint:          SetEnvEndpoint,
+		IDForNameEndpoint:       IDForNameEndpoint,
+		GetContainerKMIEndpoint: GetContainerKMIEndpoint,
+		SetLinkEndpoint:         SetLinkEndpoint,
+		RemoveLinkEndpoint:      RemoveLinkEndpoint,
+		GetLinksEndpoint:        GetLinksEndpoint,
+		ScaleInstanceEndpoint:   ScaleInstanceEndpoint,
+		CloneInstanceEndpoint:   CloneInstanceEndpoint,
+
+		CheckpointInstanceEndpoint: CheckpointInstanceEndpoint,
+		RestoreInstanceEndpoint:    RestoreInstanceEndpoint,
+		CheckpointsEndpoint:        CheckpointsEndpoint,
+		RemoveCheckpointEndpoint:   RemoveCheckpointEndpoint,
+		PinInstanceEndpoint:        PinInstanceEndpoint,
+		SetPlacementEndpoint:       SetPlacementEndpoint,
+		ExportInstanceEndpoint:     ExportInstanceEndpoint,
+	}
+}
+
+func makeRoutingServiceEndpoints(s routing.Service, c *routing.Collector, q *routing.QuotaEnforcer, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) routing.Endpoints {
+	var CreateConfigEndpoint endpoint.Endpoint
+	{
+		CreateConfigEndpoint = routing.MakeCreateConfigEndpoint(s)
+		CreateConfigEndpoint = validation.Middleware()(CreateConfigEndpoint)
+		CreateConfigEndpoint = tracing.Middleware(tracer, "routing", "CreateConfig")(CreateConfigEndpoint)
+		CreateConfigEndpoint = instrumenting.Middleware("routing", "CreateConfig")(CreateConfigEndpoint)
+		CreateConfigEndpoint = logging.Middleware(logger, "routing", "CreateConfig")(CreateConfigEndpoint)
+	}
+
+	var EditConfigEndpoint endpoint.Endpoint
+	{
+		EditConfigEndpoint = routing.MakeEditConfigEndpoint(s)
+		EditConfigEndpoint = validation.Middleware()(EditConfigEndpoint)
+		EditConfigEndpoint = tracing.Middleware(tracer, "routing", "EditConfig")(EditConfigEndpoint)
+		EditConfigEndpoint = instrumenting.Middleware("routing", "EditConfig")(EditConfigEndpoint)
+		EditConfigEndpoint = logging.Middleware(logger, "routing", "EditConfig")(EditConfigEndpoint)
+	}
+
+	var GetConfigEndpoint endpoint.Endpoint
+	{
+		GetConfigEndpoint = routing.MakeGetConfigEndpoint(s)
+		GetConfigEndpoint = validation.Middleware()(GetConfigEndpoint)
+		GetConfigEndpoint = tracing.Middleware(tracer, "routing", "GetConfig")(GetConfigEndpoint)
+		GetConfigEndpoint = instrumenting.Middleware("routing", "GetConfig")(GetConfigEndpoint)
+		GetConfigEndpoint = logging.Middleware(logger, "routing", "GetConfig")(GetConfigEndpoint)
+	}
+
+	var RemoveConfigEndpoint endpoint.Endpoint
+	{
+		RemoveConfigEndpoint = routing.MakeRemoveConfigEndpoint(s)
+		RemoveConfigEndpoint = validation.Middleware()(RemoveConfigEndpoint)
+		RemoveConfigEndpoint = tracing.Middleware(tracer, "routing", "RemoveConfig")(RemoveConfigEndpoint)
+		RemoveConfigEndpoint = instrumenting.Middleware("routing", "RemoveConfig")(RemoveConfigEndpoint)
+		RemoveConfigEndpoint = logging.Middleware(logger, "routing", "RemoveConfig")(RemoveConfigEndpoint)
+	}
+
+	var AddLocationEndpoint endpoint.Endpoint
+	{
+		AddLocationEndpoint = routing.MakeAddLocationEndpoint(s)
+		AddLocationEndpoint = validation.Middleware()(AddLocationEndpoint)
+		AddLocationEndpoint = tracing.Middleware(tracer, "routing", "AddLocation")(AddLocationEndpoint)
+		AddLocationEndpoint = instrumenting.Middleware("routing", "AddLocation")(AddLocationEndpoint)
+		AddLocationEndpoint = logging.Middleware(logger, "routing", "AddLocation")(AddLocationEndpoint)
+	}
+
+	var RemoveLocationEndpoint endpoint.Endpoint
+	{
+		RemoveLocationEndpoint = routing.MakeRemoveLocationEndpoint(s)
+		RemoveLocationEndpoint = validation.Middleware()(RemoveLocationEndpoint)
+		RemoveLocationEndpoint = tracing.Middleware(tracer, "routing", "RemoveLocation")(RemoveLocationEndpoint)
+		RemoveLocationEndpoint = instrumenting.Middleware("routing", "RemoveLocation")(RemoveLocationEndpoint)
+		RemoveLocationEndpoint = logging.Middleware(logger, "routing", "RemoveLocation")(RemoveLocationEndpoint)
+	}
+
+	var ChangeListenStatementEndpoint endpoint.Endpoint
+	{
+		ChangeListenStatementEndpoint = routing.MakeChangeListenStatementEndpoint(s)
+		ChangeListenStatementEndpoint = validation.Middleware()(ChangeListenStatementEndpoint)
+		ChangeListenStatementEndpoint = tracing.Middleware(tracer, "routing", "ChangeListenStatement")(ChangeListenStatementEndpoint)
+		ChangeListenStatementEndpoint = instrumenting.Middleware("routing", "ChangeListenStatement")(ChangeListenStatementEndpoint)
+		ChangeListenStatementEndpoint = logging.Middleware(logger, "routing", "ChangeListenStatement")(ChangeListenStatementEndpoint)
+	}
+
+	var AddServerNameEndpoint endpoint.Endpoint
+	{
+		AddServerNameEndpoint = routing.MakeAddServerNameEndpoint(s)
+		AddServerNameEndpoint = validation.Middleware()(AddServerNameEndpoint)
+		AddServerNameEndpoint = tracing.Middleware(tracer, "routing", "AddServerName")(AddServerNameEndpoint)
+		AddServerNameEndpoint = instrumenting.Middleware("routing", "AddServerName")(AddServerNameEndpoint)
+		AddServerNameEndpoint = logging.Middleware(logger, "routing", "AddServerName")(AddServerNameEndpoint)
+	}
+
+	var RemoveServerNameEndpoint endpoint.Endpoint
+	{
+		RemoveServerNameEndpoint = routing.MakeRemoveServerNameEndpoint(s)
+		RemoveServerNameEndpoint = validation.Middleware()(RemoveServerNameEndpoint)
+		RemoveServerNameEndpoint = tracing.Middleware(tracer, "routing", "RemoveServerName")(RemoveServerNameEndpoint)
+		RemoveServerNameEndpoint = instrumenting.Middleware("routing", "RemoveServerName")(RemoveServerNameEndpoint)
+		RemoveServerNameEndpoint = logging.Middleware(logger, "routing", "RemoveServerName")(RemoveServerNameEndpoint)
+	}
+
+	var ConfigurationsEndpoint endpoint.Endpoint
+	{
+		ConfigurationsEndpoint = routing.MakeConfigurationsEndpoint(s)
+		ConfigurationsEndpoint = validation.Middleware()(ConfigurationsEndpoint)
+		ConfigurationsEndpoint = tracing.Middleware(tracer, "routing", "Configurations")(ConfigurationsEndpoint)
+		ConfigurationsEndpoint = instrumenting.Middleware("routing", "Configurations")(ConfigurationsEndpoint)
+		ConfigurationsEndpoint = logging.Middleware(logger, "routing", "Configurations")(ConfigurationsEndpoint)
+	}
+
+	var SetUpstreamEndpoint endpoint.Endpoint
+	{
+		SetUpstreamEndpoint = routing.MakeSetUpstreamEndpoint(s)
+		SetUpstreamEndpoint = validation.Middleware()(SetUpstreamEndpoint)
+		SetUpstreamEndpoint = tracing.Middleware(tracer, "routing", "SetUpstream")(SetUpstreamEndpoint)
+		SetUpstreamEndpoint = instrumenting.Middleware("routing", "SetUpstream")(SetUpstreamEndpoint)
+		SetUpstreamEndpoint = logging.Middleware(logger, "routing", "SetUpstream")(SetUpstreamEndpoint)
+	}
+
+	var RemoveUpstreamEndpoint endpoint.Endpoint
+	{
+		RemoveUpstreamEndpoint = routing.MakeRemoveUpstreamEndpoint(s)
+		RemoveUpstreamEndpoint = validation.Middleware()(RemoveUpstreamEndpoint)
+		RemoveUpstreamEndpoint = tracing.Middleware(tracer, "routing", "RemoveUpstream")(RemoveUpstreamEndpoint)
+		RemoveUpstreamEndpoint = instrumenting.Middleware("routing", "RemoveUpstream")(RemoveUpstreamEndpoint)
+		RemoveUpstreamEndpoint = logging.Middleware(logger, "routing", "RemoveUpstream")(RemoveUpstreamEndpoint)
+	}
+
+	var AddUpstreamMemberEndpoint endpoint.Endpoint
+	{
+		AddUpstreamMemberEndpoint = routing.MakeAddUpstreamMemberEndpoint(s)
+		AddUpstreamMemberEndpoint = validation.Middleware()(AddUpstreamMemberEndpoint)
+		AddUpstreamMemberEndpoint = tracing.Middleware(tracer, "routing", "AddUpstreamMember")(AddUpstreamMemberEndpoint)
+		AddUpstreamMemberEndpoint = instrumenting.Middleware("routing", "AddUpstreamMember")(AddUpstreamMemberEndpoint)
+		AddUpstreamMemberEndpoint = logging.Middleware(logger, "routing", "AddUpstreamMember")(AddUpstreamMemberEndpoint)
+	}
+
+	var RemoveUpstreamMemberEndpoint endpoint.Endpoint
+	{
+		RemoveUpstreamMemberEndpoint = routing.MakeRemoveUpstreamMemberEndpoint(s)
+		RemoveUpstreamMemberEndpoint = validation.Middleware()(RemoveUpstreamMemberEndpoint)
+		RemoveUpstreamMemberEndpoint = tracing.Middleware(tracer, "routing", "RemoveUpstreamMember")(RemoveUpstreamMemberEndpoint)
+		RemoveUpstreamMemberEndpoint = instrumenting.Middleware("routing", "RemoveUpstreamMember")(RemoveUpstreamMemberEndpoint)
+		RemoveUpstreamMemberEndpoint = logging.Middleware(logger, "routing", "RemoveUpstreamMember")(RemoveUpstreamMemberEndpoint)
+	}
+
+	var TrafficEndpoint endpoint.Endpoint
+	{
+		TrafficEndpoint = routing.MakeTrafficEndpoint(s)
+		TrafficEndpoint = validation.Middleware()(TrafficEndpoint)
+		TrafficEndpoint = tracing.Middleware(tracer, "routing", "Traffic")(TrafficEndpoint)
+		TrafficEndpoint = instrumenting.Middleware("routing", "Traffic")(TrafficEndpoint)
+		TrafficEndpoint = logging.Middleware(logger, "routing", "Traffic")(TrafficEndpoint)
+	}
+
+	var TrafficRateEndpoint endpoint.Endpoint
+	{
+		TrafficRateEndpoint = routing.MakeTrafficRateEndpoint(c)
+		TrafficRateEndpoint = validation.Middleware()(TrafficRateEndpoint)
+		TrafficRateEndpoint = tracing.Middleware(tracer, "routing", "TrafficRate")(TrafficRateEndpoint)
+		TrafficRateEndpoint = instrumenting.Middleware("routing", "TrafficRate")(TrafficRateEndpoint)
+		TrafficRateEndpoint = logging.Middleware(logger, "routing", "TrafficRate")(TrafficRateEndpoint)
+	}
+
+	var SetHTTPSPolicyEndpoint endpoint.Endpoint
+	{
+		SetHTTPSPolicyEndpoint = routing.MakeSetHTTPSPolicyEndpoint(s)
+		SetHTTPSPolicyEndpoint = validation.Middleware()(SetHTTPSPolicyEndpoint)
+		SetHTTPSPolicyEndpoint = tracing.Middleware(tracer, "routing", "SetHTTPSPolicy")(SetHTTPSPolicyEndpoint)
+		SetHTTPSPolicyEndpoint = instrumenting.Middleware("routing", "SetHTTPSPolicy")(SetHTTPSPolicyEndpoint)
+		SetHTTPSPolicyEndpoint = logging.Middleware(logger, "routing", "SetHTTPSPolicy")(SetHTTPSPolicyEndpoint)
+	}
+
+	var SetErrorPageEndpoint endpoint.Endpoint
+	{
+		SetErrorPageEndpoint = routing.MakeSetErrorPageEndpoint(s)
+		SetErrorPageEndpoint = validation.Middleware()(SetErrorPageEndpoint)
+		SetErrorPageEndpoint = tracing.Middleware(tracer, "routing", "SetErrorPage")(SetErrorPageEndpoint)
+		SetErrorPageEndpoint = instrumenting.Middleware("routing", "SetErrorPage")(SetErrorPageEndpoint)
+		SetErrorPageEndpoint = logging.Middleware(logger, "routing", "SetErrorPage")(SetErrorPageEndpoint)
+	}
+
+	var SetMaintenanceEndpoint endpoint.Endpoint
+	{
+		SetMaintenanceEndpoint = routing.MakeSetMaintenanceEndpoint(s)
+		SetMaintenanceEndpoint = validation.Middleware()(SetMaintenanceEndpoint)
+		SetMaintenanceEndpoint = tracing.Middleware(tracer, "routing", "SetMaintenance")(SetMaintenanceEndpoint)
+		SetMaintenanceEndpoint = instrumenting.Middleware("routing", "SetMaintenance")(SetMaintenanceEndpoint)
+		SetMaintenanceEndpoint = logging.Middleware(logger, "routing", "SetMaintenance")(SetMaintenanceEndpoint)
+	}
+
+	var SetProxyLimitsEndpoint endpoint.Endpoint
+	{
+		SetProxyLimitsEndpoint = routing.MakeSetProxyLimitsEndpoint(s)
+		SetProxyLimitsEndpoint = validation.Middleware()(SetProxyLimitsEndpoint)
+		SetProxyLimitsEndpoint = tracing.Middleware(tracer, "routing", "SetProxyLimits")(SetProxyLimitsEndpoint)
+		SetProxyLimitsEndpoint = instrumenting.Middleware("routing", "SetProxyLimits")(SetProxyLimitsEndpoint)
+		SetProxyLimitsEndpoint = logging.Middleware(logger, "routing", "SetProxyLimits")(SetProxyLimitsEndpoint)
+	}
+
+	var SetSwitchEndpoint endpoint.Endpoint
+	{
+		SetSwitchEndpoint = routing.MakeSetSwitchEndpoint(s)
+		SetSwitchEndpoint = validation.Middleware()(SetSwitchEndpoint)
+		SetSwitchEndpoint = tracing.Middleware(tracer, "routing", "SetSwitch")(SetSwitchEndpoint)
+		SetSwitchEndpoint = instrumenting.Middleware("routing", "SetSwitch")(SetSwitchEndpoint)
+		SetSwitchEndpoint = logging.Middleware(logger, "routing", "SetSwitch")(SetSwitchEndpoint)
+	}
+
+	var RemoveSwitchEndpoint endpoint.Endpoint
+	{
+		RemoveSwitchEndpoint = routing.MakeRemoveSwitchEndpoint(s)
+		RemoveSwitchEndpoint = validation.Middleware()(RemoveSwitchEndpoint)
+		RemoveSwitchEndpoint = tracing.Middleware(tracer, "routing", "RemoveSwitch")(RemoveSwitchEndpoint)
+		RemoveSwitchEndpoint = instrumenting.Middleware("routing", "RemoveSwitch")(RemoveSwitchEndpoint)
+		RemoveSwitchEndpoint = logging.Middleware(logger, "routing", "RemoveSwitch")(RemoveSwitchEndpoint)
+	}
+
+	var QuotaEndpoint endpoint.Endpoint
+	{
+		QuotaEndpoint = routing.MakeQuotaEndpoint(q)
+		QuotaEndpoint = validation.Middleware()(QuotaEndpoint)
+		QuotaEndpoint = tracing.Middleware(tracer, "routing", "Quota")(QuotaEndpoint)
+		QuotaEndpoint = instrumenting.Middleware("routing", "Quota")(QuotaEndpoint)
+		QuotaEndpoint = logging.Middleware(logger, "routing", "Quota")(QuotaEndpoint)
+	}
+
+	return routing.Endpoints{
+		CreateConfigEndpoint:          CreateConfigEndpoint,
+		EditConfigEndpoint:            EditConfigEndpoint,
+		GetConfigEndpoint:             GetConfigEndpoint,
+		RemoveConfigEndpoint:          RemoveConfigEndpoint,
+		AddLocationEndpoint:           AddLocationEndpoint,
+		RemoveLocationEndpoint:        RemoveLocationEndpoint,
+		ChangeListenStatementEndpoint: ChangeListenStatementEndpoint,
+		AddServerNameEndpoint:         AddServerNameEndpoint,
+		RemoveServerNameEndpoint:      RemoveServerNameEndpoint,
+		ConfigurationsEndpoint:        ConfigurationsEndpoint,
+		SetUpstreamEndpoint:           SetUpstreamEndpoint,
+		RemoveUpstreamEndpoint:        RemoveUpstreamEndpoint,
+		AddUpstreamMemberEndpoint:     AddUpstreamMemberEndpoint,
+		RemoveUpstreamMemberEndpoint:  RemoveUpstreamMemberEndpoint,
+		TrafficEndpoint:               TrafficEndpoint,
+		TrafficRateEndpoint:           TrafficRateEndpoint,
+		SetHTTPSPolicyEndpoint:        SetHTTPSPolicyEndpoint,
+		SetErrorPageEndpoint:          SetErrorPageEndpoint,
+		SetMaintenanceEndpoint:        SetMaintenanceEndpoint,
+		SetProxyLimitsEndpoint:        SetProxyLimitsEndpoint,
+		SetSwitchEndpoint:             SetSwitchEndpoint,
+		RemoveSwitchEndpoint:          RemoveSwitchEndpoint,
+		QuotaEndpoint:                 QuotaEndpoint,
+	}
+}
+
+func makeModuleServiceEndpoints(s module.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) module.Endpoints {
+
+	var CreateContainerModuleEndpoint endpoint.Endpoint
+	{
+		CreateContainerModuleEndpoint = module.MakeCreateContainerModuleEndpoint(s)
+		CreateContainerModuleEndpoint = validation.Middleware()(CreateContainerModuleEndpoint)
+		CreateContainerModuleEndpoint = tracing.Middleware(tracer, "module", "CreateContainerModule")(CreateContainerModuleEndpoint)
+		CreateContainerModuleEndpoint = instrumenting.Middleware("module", "CreateContainerModule")(CreateContainerModuleEndpoint)
+		CreateContainerModuleEndpoint = logging.Middleware(logger, "module", "CreateContainerModule")(CreateContainerModuleEndpoint)
+	}
+	var SetPublicKeyEndpoint endpoint.Endpoint
+	{
+		SetPublicKeyEndpoint = module.MakeSetPublicKeyEndpoint(s)
+		SetPublicKeyEndpoint = validation.Middleware()(SetPublicKeyEndpoint)
+		SetPublicKeyEndpoint = tracing.Middleware(tracer, "module", "SetPublicKey")(SetPublicKeyEndpoint)
+		SetPublicKeyEndpoint = instrumenting.Middleware("module", "SetPublicKey")(SetPublicKeyEndpoint)
+		SetPublicKeyEndpoint = logging.Middleware(logger, "module", "SetPublicKey")(SetPublicKeyEndpoint)
+	}
+	var RemoveFileEndpoint endpoint.Endpoint
+	{
+		RemoveFileEndpoint = module.MakeRemoveFileEndpoint(s)
+		RemoveFileEndpoint = validation.Middleware()(RemoveFileEndpoint)
+		RemoveFileEndpoint = tracing.Middleware(tracer, "module", "RemoveFile")(RemoveFileEndpoint)
+		RemoveFileEndpoint = instrumenting.Middleware("module", "RemoveFile")(RemoveFileEndpoint)
+		RemoveFileEndpoint = logging.Middleware(logger, "module", "RemoveFile")(RemoveFileEndpoint)
+	}
+	var RemoveDirectoryEndpoint endpoint.Endpoint
+	{
+		RemoveDirectoryEndpoint = module.MakeRemoveDirectoryEndpoint(s)
+		RemoveDirectoryEndpoint = validation.Middleware()(RemoveDirectoryEndpoint)
+		RemoveDirectoryEndpoint = tracing.Middleware(tracer, "module", "RemoveDirectory")(RemoveDirectoryEndpoint)
+		RemoveDirectoryEndpoint = instrumenting.Middleware("module", "RemoveDirectory")(RemoveDirectoryEndpoint)
+		RemoveDirectoryEndpoint = logging.Middleware(logger, "module", "RemoveDirectory")(RemoveDirectoryEndpoint)
+	}
+	var GetFilesEndpoint endpoint.Endpoint
+	{
+		GetFilesEndpoint = module.MakeGetFilesEndpoint(s)
+		GetFilesEndpoint = validation.Middleware()(GetFilesEndpoint)
+		GetFilesEndpoint = tracing.Middleware(tracer, "module", "GetFiles")(GetFilesEndpoint)
+		GetFilesEndpoint = instrumenting.Middleware("module", "GetFiles")(GetFilesEndpoint)
+		GetFilesEndpoint = logging.Middleware(logger, "module", "GetFiles")(GetFilesEndpoint)
+	}
+	var GetFileEndpoint endpoint.Endpoint
+	{
+		GetFileEndpoint = module.MakeGetFileEndpoint(s)
+		GetFileEndpoint = validation.Middleware()(GetFileEndpoint)
+		GetFileEndpoint = tracing.Middleware(tracer, "module", "GetFile")(GetFileEndpoint)
+		GetFileEndpoint = instrumenting.Middleware("module", "GetFile")(GetFileEndpoint)
+		GetFileEndpoint = logging.Middleware(logger, "module", "GetFile")(GetFileEndpoint)
+	}
+	var ListDirectoryEndpoint endpoint.Endpoint
+	{
+		ListDirectoryEndpoint = module.MakeListDirectoryEndpoint(s)
+		ListDirectoryEndpoint = validation.Middleware()(ListDirectoryEndpoint)
+		ListDirectoryEndpoint = tracing.Middleware(tracer, "module", "ListDirectory")(ListDirectoryEndpoint)
+		ListDirectoryEndpoint = instrumenting.Middleware("module", "ListDirectory")(ListDirectoryEndpoint)
+		ListDirectoryEndpoint = logging.Middleware(logger, "module", "ListDirectory")(ListDirectoryEndpoint)
+	}
+	var StatFileEndpoint endpoint.Endpoint
+	{
+		StatFileEndpoint = module.MakeStatFileEndpoint(s)
+		StatFileEndpoint = validation.Middleware()(StatFileEndpoint)
+		StatFileEndpoint = tracing.Middleware(tracer, "module", "StatFile")(StatFileEndpoint)
+		StatFileEndpoint = instrumenting.Middleware("module", "StatFile")(StatFileEndpoint)
+		StatFileEndpoint = logging.Middleware(logger, "module", "StatFile")(StatFileEndpoint)
+	}
+	var ReadFileEndpoint endpoint.Endpoint
+	{
+		ReadFileEndpoint = module.MakeReadFileEndpoint(s)
+		ReadFileEndpoint = validation.Middleware()(ReadFileEndpoint)
+		ReadFileEndpoint = tracing.Middleware(tracer, "module", "ReadFile")(ReadFileEndpoint)
+		ReadFileEndpoint = instrumenting.Middleware("module", "ReadFile")(ReadFileEndpoint)
+		ReadFileEndpoint = logging.Middleware(logger, "module", "ReadFile")(ReadFileEndpoint)
+	}
+	var UploadFileEndpoint endpoint.Endpoint
+	{
+		UploadFileEndpoint = module.MakeUploadFileEndpoint(s)
+		UploadFileEndpoint = validation.Middleware()(UploadFileEndpoint)
+		UploadFileEndpoint = tracing.Middleware(tracer, "module", "UploadFile")(UploadFileEndpoint)
+		UploadFileEndpoint = instrumenting.Middleware("module", "UploadFile")(UploadFileEndpoint)
+		UploadFileEndpoint = logging.Middleware(logger, "module", "UploadFile")(UploadFileEndpoint)
+	}
+	var GetModuleConfigEndpoint endpoint.Endpoint
+	{
+		GetModuleConfigEndpoint = module.MakeGetModuleConfigEndpoint(s)
+		GetModuleConfigEndpoint = validation.Middleware()(GetModuleConfigEndpoint)
+		GetModuleConfigEndpoint = tracing.Middleware(tracer, "module", "GetModuleConfig")(GetModuleConfigEndpoint)
+		GetModuleConfigEndpoint = instrumenting.Middleware("module", "GetModuleConfig")(GetModuleConfigEndpoint)
+		GetModuleConfigEndpoint = logging.Middleware(logger, "module", "GetModuleConfig")(GetModuleConfigEndpoint)
+	}
+	var SendCommandEndpoint endpoint.Endpoint
+	{
+		SendCommandEndpoint = module.MakeSendCommandEndpoint(s)
+		SendCommandEndpoint = validation.Middleware()(SendCommandEndpoint)
+		SendCommandEndpoint = tracing.Middleware(tracer, "module", "SendCommand")(SendCommandEndpoint)
+		SendCommandEndpoint = instrumenting.Middleware("module", "SendCommand")(SendCommandEndpoint)
+		SendCommandEndpoint = logging.Middleware(logger, "module", "SendCommand")(SendCommandEndpoint)
+	}
+	var SetEnvEndpoint endpoint.Endpoint
+	{
+		SetEnvEndpoint = module.MakeSetEnvEndpoint(s)
+		SetEnvEndpoint = validation.Middleware()(SetEnvEndpoint)
+		SetEnvEndpoint = tracing.Middleware(tracer, "module", "SetEnv")(SetEnvEndpoint)
+		SetEnvEndpoint = instrumenting.Middleware("module", "SetEnv")(SetEnvEndpoint)
+		SetEnvEndpoint = logging.Middleware(logger, "module", "SetEnv")(SetEnvEndpoint)
+	}
+	var GetEnvEndpoint endpoint.Endpoint
+	{
+		GetEnvEndpoint = module.MakeGetEnvEndpoint(s)
+		GetEnvEndpoint = validation.Middleware()(GetEnvEndpoint)
+		GetEnvEndpoint = tracing.Middleware(tracer, "module", "GetEnv")(GetEnvEndpoint)
+		GetEnvEndpoint = instrumenting.Middleware("module", "GetEnv")(GetEnvEndpoint)
+		GetEnvEndpoint = logging.Middleware(logger, "module", "GetEnv")(GetEnvEndpoint)
+	}
+	var SetLinkEndpoint endpoint.Endpoint
+	{
+		SetLinkEndpoint = module.MakeSetLinkEndpoint(s)
+		SetLinkEndpoint = validation.Middleware()(SetLinkEndpoint)
+		SetLinkEndpoint = tracing.Middleware(tracer, "module", "SetLink")(SetLinkEndpoint)
+		SetLinkEndpoint = instrumenting.Middleware("module", "SetLink")(SetLinkEndpoint)
+		SetLinkEndpoint = logging.Middleware(logger, "module", "SetLink")(SetLinkEndpoint)
+	}
+	var RemoveLinkEndpoint endpoint.Endpoint
+	{
+		RemoveLinkEndpoint = module.MakeRemoveLinkEndpoint(s)
+		RemoveLinkEndpoint = validation.Middleware()(RemoveLinkEndpoint)
+		RemoveLinkEndpoint = tracing.Middleware(tracer, "module", "RemoveLink")(RemoveLinkEndpoint)
+		RemoveLinkEndpoint = instrumenting.Middleware("module", "RemoveLink")(RemoveLinkEndpoint)
+		RemoveLinkEndpoint = logging.Middleware(logger, "module", "RemoveLink")(RemoveLinkEndpoint)
+	}
+	var GetModulesEndpoint endpoint.Endpoint
+	{
+		GetModulesEndpoint = module.MakeGetModulesEndpoint(s)
+		GetModulesEndpoint = validation.Middleware()(GetModulesEndpoint)
+		GetModulesEndpoint = tracing.Middleware(tracer, "module", "GetModules")(GetModulesEndpoint)
+		GetModulesEndpoint = instrumenting.Middleware("module", "GetModules")(GetModulesEndpoint)
+		GetModulesEndpoint = logging.Middleware(logger, "module", "GetModules")(GetModulesEndpoint)
+	}
+
+	return module.Endpoints{
+		CreateContainerModuleEndpoint: CreateContainerModuleEndpoint,
+		SetPublicKeyEndpoint:          SetPublicKeyEndpoint,
+		RemoveFileEndpoint:            RemoveFileEndpoint,
+		RemoveDirectoryEndpoint:       RemoveDirectoryEndpoint,
+		GetFilesEndpoint:              GetFilesEndpoint,
+		GetFileEndpoint:               GetFileEndpoint,
+		ListDirectoryEndpoint:         ListDirectoryEndpoint,
+		StatFileEndpoint:              StatFileEndpoint,
+		ReadFileEndpoint:              ReadFileEndpoint,
+		UploadFileEndpoint:            UploadFileEndpoint,
+		GetModuleConfigEndpoint:       GetModuleConfigEndpoint,
+		SendCommandEndpoint:           SendCommandEndpoint,
+		SetEnvEndpoint:                SetEnvEndpoint,
+		GetEnvEndpoint:                GetEnvEndpoint,
+		SetLinkEndpoint:               SetLinkEndpoint,
+		RemoveLinkEndpoint:            RemoveLinkEndpoint,
+		GetModulesEndpoint:            GetModulesEndpoint,
+	}
+}
+
+func makeDNSServiceEndpoints(s dns.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) dns.Endpoints {
+	var CreateRecordEndpoint endpoint.Endpoint
+	{
+		CreateRecordEndpoint = dns.MakeCreateRecordEndpoint(s)
+		CreateRecordEndpoint = validation.Middleware()(CreateRecordEndpoint)
+		CreateRecordEndpoint = tracing.Middleware(tracer, "dns", "CreateRecord")(CreateRecordEndpoint)
+		CreateRecordEndpoint = instrumenting.Middleware("dns", "CreateRecord")(CreateRecordEndpoint)
+		CreateRecordEndpoint = logging.Middleware(logger, "dns", "CreateRecord")(CreateRecordEndpoint)
+	}
+
+	var RemoveRecordEndpoint endpoint.Endpoint
+	{
+		RemoveRecordEndpoint = dns.MakeRemoveRecordEndpoint(s)
+		RemoveRecordEndpoint = validation.Middleware()(RemoveRecordEndpoint)
+		RemoveRecordEndpoint = tracing.Middleware(tracer, "dns", "RemoveRecord")(RemoveRecordEndpoint)
+		RemoveRecordEndpoint = instrumenting.Middleware("dns", "RemoveRecord")(RemoveRecordEndpoint)
+		RemoveRecordEndpoint = logging.Middleware(logger, "dns", "RemoveRecord")(RemoveRecordEndpoint)
+	}
+
+	var RecordsEndpoint endpoint.Endpoint
+	{
+		RecordsEndpoint = dns.MakeRecordsEndpoint(s)
+		RecordsEndpoint = validation.Middleware()(RecordsEndpoint)
+		RecordsEndpoint = tracing.Middleware(tracer, "dns", "Records")(RecordsEndpoint)
+		RecordsEndpoint = instrumenting.Middleware("dns", "Records")(RecordsEndpoint)
+		RecordsEndpoint = logging.Middleware(logger, "dns", "Records")(RecordsEndpoint)
+	}
+
+	var CreateInstanceRecordsEndpoint endpoint.Endpoint
+	{
+		CreateInstanceRecordsEndpoint = dns.MakeCreateInstanceRecordsEndpoint(s)
+		CreateInstanceRecordsEndpoint = validation.Middleware()(CreateInstanceRecordsEndpoint)
+		CreateInstanceRecordsEndpoint = tracing.Middleware(tracer, "dns", "CreateInstanceRecords")(CreateInstanceRecordsEndpoint)
+		CreateInstanceRecordsEndpoint = instrumenting.Middleware("dns", "CreateInstanceRecords")(CreateInstanceRecordsEndpoint)
+		CreateInstanceRecordsEndpoint = logging.Middleware(logger, "dns", "CreateInstanceRecords")(CreateInstanceRecordsEndpoint)
+	}
+
+	var RemoveInstanceRecordsEndpoint endpoint.Endpoint
+	{
+		RemoveInstanceRecordsEndpoint = dns.MakeRemoveInstanceRecordsEndpoint(s)
+		RemoveInstanceRecordsEndpoint = validation.Middleware()(RemoveInstanceRecordsEndpoint)
+		RemoveInstanceRecordsEndpoint = tracing.Middleware(tracer, "dns", "RemoveInstanceRecords")(RemoveInstanceRecordsEndpoint)
+		RemoveInstanceRecordsEndpoint = instrumenting.Middleware("dns", "RemoveInstanceRecords")(RemoveInstanceRecordsEndpoint)
+		RemoveInstanceRecordsEndpoint = logging.Middleware(logger, "dns", "RemoveInstanceRecords")(RemoveInstanceRecordsEndpoint)
+	}
+
+	var AddCustomDomainEndpoint endpoint.Endpoint
+	{
+		AddCustomDomainEndpoint = dns.MakeAddCustomDomainEndpoint(s)
+		AddCustomDomainEndpoint = validation.Middleware()(AddCustomDomainEndpoint)
+		AddCustomDomainEndpoint = tracing.Middleware(tracer, "dns", "AddCustomDomain")(AddCustomDomainEndpoint)
+		AddCustomDomainEndpoint = instrumenting.Middleware("dns", "AddCustomDomain")(AddCustomDomainEndpoint)
+		AddCustomDomainEndpoint = logging.Middleware(logger, "dns", "AddCustomDomain")(AddCustomDomainEndpoint)
+	}
+
+	var RemoveCustomDomainEndpoint endpoint.Endpoint
+	{
+		RemoveCustomDomainEndpoint = dns.MakeRemoveCustomDomainEndpoint(s)
+		RemoveCustomDomainEndpoint = validation.Middleware()(RemoveCustomDomainEndpoint)
+		RemoveCustomDomainEndpoint = tracing.Middleware(tracer, "dns", "RemoveCustomDomain")(RemoveCustomDomainEndpoint)
+		RemoveCustomDomainEndpoint = instrumenting.Middleware("dns", "RemoveCustomDomain")(RemoveCustomDomainEndpoint)
+		RemoveCustomDomainEndpoint = logging.Middleware(logger, "dns", "RemoveCustomDomain")(RemoveCustomDomainEndpoint)
+	}
+
+	var VerifyCustomDomainEndpoint endpoint.Endpoint
+	{
+		VerifyCustomDomainEndpoint = dns.MakeVerifyCustomDomainEndpoint(s)
+		VerifyCustomDomainEndpoint = validation.Middleware()(VerifyCustomDomainEndpoint)
+		VerifyCustomDomainEndpoint = tracing.Middleware(tracer, "dns", "VerifyCustomDomain")(VerifyCustomDomainEndpoint)
+		VerifyCustomDomainEndpoint = instrumenting.Middleware("dns", "VerifyCustomDomain")(VerifyCustomDomainEndpoint)
+		VerifyCustomDomainEndpoint = logging.Middleware(logger, "dns", "VerifyCustomDomain")(VerifyCustomDomainEndpoint)
+	}
+
+	var CustomDomainsEndpoint endpoint.Endpoint
+	{
+		CustomDomainsEndpoint = dns.MakeCustomDomainsEndpoint(s)
+		CustomDomainsEndpoint = validation.Middleware()(CustomDomainsEndpoint)
+		CustomDomainsEndpoint = tracing.Middleware(tracer, "dns", "CustomDomains")(CustomDomainsEndpoint)
+		CustomDomainsEndpoint = instrumenting.Middleware("dns", "CustomDomains")(CustomDomainsEndpoint)
+		CustomDomainsEndpoint = logging.Middleware(logger, "dns", "CustomDomains")(CustomDomainsEndpoint)
+	}
+
+	return dns.Endpoints{
+		CreateRecordEndpoint:          CreateRecordEndpoint,
+		RemoveRecordEndpoint:          RemoveRecordEndpoint,
+		RecordsEndpoint:               RecordsEndpoint,
+		CreateInstanceRecordsEndpoint: CreateInstanceRecordsEndpoint,
+		RemoveInstanceRecordsEndpoint: RemoveInstanceRecordsEndpoint,
+		AddCustomDomainEndpoint:       AddCustomDomainEndpoint,
+		RemoveCustomDomainEndpoint:    RemoveCustomDomainEndpoint,
+		VerifyCustomDomainEndpoint:    VerifyCustomDomainEndpoint,
+		CustomDomainsEndpoint:         CustomDomainsEndpoint,
+	}
+}
+
+func makeAdminServiceEndpoints(s admin.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) admin.Endpoints {
+	var UsersEndpoint endpoint.Endpoint
+	{
+		UsersEndpoint = admin.MakeUsersEndpoint(s)
+		UsersEndpoint = validation.Middleware()(UsersEndpoint)
+		UsersEndpoint = tracing.Middleware(tracer, "admin", "Users")(UsersEndpoint)
+		UsersEndpoint = instrumenting.Middleware("admin", "Users")(UsersEndpoint)
+		UsersEndpoint = logging.Middleware(logger, "admin", "Users")(UsersEndpoint)
+	}
+
+	var ContainersEndpoint endpoint.Endpoint
+	{
+		ContainersEndpoint = admin.MakeContainersEndpoint(s)
+		ContainersEndpoint = validation.Middleware()(ContainersEndpoint)
+		ContainersEndpoint = tracing.Middleware(tracer, "admin", "Containers")(ContainersEndpoint)
+		ContainersEndpoint = instrumenting.Middleware("admin", "Containers")(ContainersEndpoint)
+		ContainersEndpoint = logging.Middleware(logger, "admin", "Containers")(ContainersEndpoint)
+	}
+
+	var ErrorsEndpoint endpoint.Endpoint
+	{
+		ErrorsEndpoint = admin.MakeErrorsEndpoint(s)
+		ErrorsEndpoint = validation.Middleware()(ErrorsEndpoint)
+		ErrorsEndpoint = tracing.Middleware(tracer, "admin", "Errors")(ErrorsEndpoint)
+		ErrorsEndpoint = instrumenting.Middleware("admin", "Errors")(ErrorsEndpoint)
+		ErrorsEndpoint = logging.Middleware(logger, "admin", "Errors")(ErrorsEndpoint)
+	}
+
+	var QueueDepthEndpoint endpoint.Endpoint
+	{
+		QueueDepthEndpoint = admin.MakeQueueDepthEndpoint(s)
+		QueueDepthEndpoint = validation.Middleware()(QueueDepthEndpoint)
+		QueueDepthEndpoint = tracing.Middleware(tracer, "admin", "QueueDepth")(QueueDepthEndpoint)
+		QueueDepthEndpoint = instrumenting.Middleware("admin", "QueueDepth")(QueueDepthEndpoint)
+		QueueDepthEndpoint = logging.Middleware(logger, "admin", "QueueDepth")(QueueDepthEndpoint)
+	}
+
+	var StopContainerEndpoint endpoint.Endpoint
+	{
+		StopContainerEndpoint = admin.MakeStopContainerEndpoint(s)
+		StopContainerEndpoint = validation.Middleware()(StopContainerEndpoint)
+		StopContainerEndpoint = tracing.Middleware(tracer, "admin", "StopContainer")(StopContainerEndpoint)
+		StopContainerEndpoint = instrumenting.Middleware("admin", "StopContainer")(StopContainerEndpoint)
+		StopContainerEndpoint = logging.Middleware(logger, "admin", "StopContainer")(StopContainerEndpoint)
+	}
+
+	var ReassignNodeEndpoint endpoint.Endpoint
+	{
+		ReassignNodeEndpoint = admin.MakeReassignNodeEndpoint(s)
+		ReassignNodeEndpoint = validation.Middleware()(ReassignNodeEndpoint)
+		ReassignNodeEndpoint = tracing.Middleware(tracer, "admin", "ReassignNode")(ReassignNodeEndpoint)
+		ReassignNodeEndpoint = instrumenting.Middleware("admin", "ReassignNode")(ReassignNodeEndpoint)
+		ReassignNodeEndpoint = logging.Middleware(logger, "admin", "ReassignNode")(ReassignNodeEndpoint)
+	}
+
+	var DrainNodeEndpoint endpoint.Endpoint
+	{
+		DrainNodeEndpoint = admin.MakeDrainNodeEndpoint(s)
+		DrainNodeEndpoint = validation.Middleware()(DrainNodeEndpoint)
+		DrainNodeEndpoint = tracing.Middleware(tracer, "admin", "DrainNode")(DrainNodeEndpoint)
+		DrainNodeEndpoint = instrumenting.Middleware("admin", "DrainNode")(DrainNodeEndpoint)
+		DrainNodeEndpoint = logging.Middleware(logger, "admin", "DrainNode")(DrainNodeEndpoint)
+	}
+
+	var UndrainNodeEndpoint endpoint.Endpoint
+	{
+		UndrainNodeEndpoint = admin.MakeUndrainNodeEndpoint(s)
+		UndrainNodeEndpoint = validation.Middleware()(UndrainNodeEndpoint)
+		UndrainNodeEndpoint = tracing.Middleware(tracer, "admin", "UndrainNode")(UndrainNodeEndpoint)
+		UndrainNodeEndpoint = instrumenting.Middleware("admin", "UndrainNode")(UndrainNodeEndpoint)
+		UndrainNodeEndpoint = logging.Middleware(logger, "admin", "UndrainNode")(UndrainNodeEndpoint)
+	}
+
+	var CordonNodeEndpoint endpoint.Endpoint
+	{
+		CordonNodeEndpoint = admin.MakeCordonNodeEndpoint(s)
+		CordonNodeEndpoint = validation.Middleware()(CordonNodeEndpoint)
+		CordonNodeEndpoint = tracing.Middleware(tracer, "admin", "CordonNode")(CordonNodeEndpoint)
+		CordonNodeEndpoint = instrumenting.Middleware("admin", "CordonNode")(CordonNodeEndpoint)
+		CordonNodeEndpoint = logging.Middleware(logger, "admin", "CordonNode")(CordonNodeEndpoint)
+	}
+
+	var UncordonNodeEndpoint endpoint.Endpoint
+	{
+		UncordonNodeEndpoint = admin.MakeUncordonNodeEndpoint(s)
+		UncordonNodeEndpoint = validation.Middleware()(UncordonNodeEndpoint)
+		UncordonNodeEndpoint = tracing.Middleware(tracer, "admin", "UncordonNode")(UncordonNodeEndpoint)
+		UncordonNodeEndpoint = instrumenting.Middleware("admin", "UncordonNode")(UncordonNodeEndpoint)
+		UncordonNodeEndpoint = logging.Middleware(logger, "admin", "UncordonNode")(UncordonNodeEndpoint)
+	}
+	var LabelNodeEndpoint endpoint.Endpoint
+	{
+		LabelNodeEndpoint = admin.MakeLabelNodeEndpoint(s)
+		LabelNodeEndpoint = validation.Middleware()(LabelNodeEndpoint)
+		LabelNodeEndpoint = tracing.Middleware(tracer, "admin", "LabelNode")(LabelNodeEndpoint)
+		LabelNodeEndpoint = instrumenting.Middleware("admin", "LabelNode")(LabelNodeEndpoint)
+		LabelNodeEndpoint = logging.Middleware(logger, "admin", "LabelNode")(LabelNodeEndpoint)
+	}
+
+	var FlagsEndpoint endpoint.Endpoint
+	{
+		FlagsEndpoint = admin.MakeFlagsEndpoint(s)
+		FlagsEndpoint = validation.Middleware()(FlagsEndpoint)
+		FlagsEndpoint = tracing.Middleware(tracer, "admin", "Flags")(FlagsEndpoint)
+		FlagsEndpoint = instrumenting.Middleware("admin", "Flags")(FlagsEndpoint)
+		FlagsEndpoint = logging.Middleware(logger, "admin", "Flags")(FlagsEndpoint)
+	}
+
+	var SetFlagEndpoint endpoint.Endpoint
+	{
+		SetFlagEndpoint = admin.MakeSetFlagEndpoint(s)
+		SetFlagEndpoint = validation.Middleware()(SetFlagEndpoint)
+		SetFlagEndpoint = tracing.Middleware(tracer, "admin", "SetFlag")(SetFlagEndpoint)
+		SetFlagEndpoint = instrumenting.Middleware("admin", "SetFlag")(SetFlagEndpoint)
+		SetFlagEndpoint = logging.Middleware(logger, "admin", "SetFlag")(SetFlagEndpoint)
+	}
+
+	var RemoveFlagEndpoint endpoint.Endpoint
+	{
+		RemoveFlagEndpoint = admin.MakeRemoveFlagEndpoint(s)
+		RemoveFlagEndpoint = validation.Middleware()(RemoveFlagEndpoint)
+		RemoveFlagEndpoint = tracing.Middleware(tracer, "admin", "RemoveFlag")(RemoveFlagEndpoint)
+		RemoveFlagEndpoint = instrumenting.Middleware("admin", "RemoveFlag")(RemoveFlagEndpoint)
+		RemoveFlagEndpoint = logging.Middleware(logger, "admin", "RemoveFlag")(RemoveFlagEndpoint)
+	}
+
+	var MaintenanceEndpoint endpoint.Endpoint
+	{
+		MaintenanceEndpoint = admin.MakeMaintenanceEndpoint(s)
+		MaintenanceEndpoint = validation.Middleware()(MaintenanceEndpoint)
+		MaintenanceEndpoint = tracing.Middleware(tracer, "admin", "Maintenance")(MaintenanceEndpoint)
+		MaintenanceEndpoint = instrumenting.Middleware("admin", "Maintenance")(MaintenanceEndpoint)
+		MaintenanceEndpoint = logging.Middleware(logger, "admin", "Maintenance")(MaintenanceEndpoint)
+	}
+
+	var ScheduleMaintenanceEndpoint endpoint.Endpoint
+	{
+		ScheduleMaintenanceEndpoint = admin.MakeScheduleMaintenanceEndpoint(s)
+		ScheduleMaintenanceEndpoint = validation.Middleware()(ScheduleMaintenanceEndpoint)
+		ScheduleMaintenanceEndpoint = tracing.Middleware(tracer, "admin", "ScheduleMaintenance")(ScheduleMaintenanceEndpoint)
+		ScheduleMaintenanceEndpoint = instrumenting.Middleware("admin", "ScheduleMaintenance")(ScheduleMaintenanceEndpoint)
+		ScheduleMaintenanceEndpoint = logging.Middleware(logger, "admin", "ScheduleMaintenance")(ScheduleMaintenanceEndpoint)
+	}
+
+	var CancelMaintenanceEndpoint endpoint.Endpoint
+	{
+		CancelMaintenanceEndpoint = admin.MakeCancelMaintenanceEndpoint(s)
+		CancelMaintenanceEndpoint = validation.Middleware()(CancelMaintenanceEndpoint)
+		CancelMaintenanceEndpoint = tracing.Middleware(tracer, "admin", "CancelMaintenance")(CancelMaintenanceEndpoint)
+		CancelMaintenanceEndpoint = instrumenting.Middleware("admin", "CancelMaintenance")(CancelMaintenanceEndpoint)
+		CancelMaintenanceEndpoint = logging.Middleware(logger, "admin", "CancelMaintenance")(CancelMaintenanceEndpoint)
+	}
+
+	var ImpersonateEndpoint endpoint.Endpoint
+	{
+		ImpersonateEndpoint = admin.MakeImpersonateEndpoint(s)
+		ImpersonateEndpoint = validation.Middleware()(ImpersonateEndpoint)
+		ImpersonateEndpoint = tracing.Middleware(tracer, "admin", "Impersonate")(ImpersonateEndpoint)
+		ImpersonateEndpoint = instrumenting.Middleware("admin", "Impersonate")(ImpersonateEndpoint)
+		ImpersonateEndpoint = logging.Middleware(logger, "admin", "Impersonate")(ImpersonateEndpoint)
+	}
+
+	var StopImpersonationEndpoint endpoint.Endpoint
+	{
+		StopImpersonationEndpoint = admin.MakeStopImpersonationEndpoint(s)
+		StopImpersonationEndpoint = validation.Middleware()(StopImpersonationEndpoint)
+		StopImpersonationEndpoint = tracing.Middleware(tracer, "admin", "StopImpersonation")(StopImpersonationEndpoint)
+		StopImpersonationEndpoint = instrumenting.Middleware("admin", "StopImpersonation")(StopImpersonationEndpoint)
+		StopImpersonationEndpoint = logging.Middleware(logger, "admin", "StopImpersonation")(StopImpersonationEndpoint)
+	}
+
+	var ImpersonationsEndpoint endpoint.Endpoint
+	{
+		ImpersonationsEndpoint = admin.MakeImpersonationsEndpoint(s)
+		ImpersonationsEndpoint = validation.Middleware()(ImpersonationsEndpoint)
+		ImpersonationsEndpoint = tracing.Middleware(tracer, "admin", "Impersonations")(ImpersonationsEndpoint)
+		ImpersonationsEndpoint = instrumenting.Middleware("admin", "Impersonations")(ImpersonationsEndpoint)
+		ImpersonationsEndpoint = logging.Middleware(logger, "admin", "Impersonations")(ImpersonationsEndpoint)
+	}
+
+	var ImpersonationTrailEndpoint endpoint.Endpoint
+	{
+		ImpersonationTrailEndpoint = admin.MakeImpersonationTrailEndpoint(s)
+		ImpersonationTrailEndpoint = validation.Middleware()(ImpersonationTrailEndpoint)
+		ImpersonationTrailEndpoint = tracing.Middleware(tracer, "admin", "ImpersonationTrail")(ImpersonationTrailEndpoint)
+		ImpersonationTrailEndpoint = instrumenting.Middleware("admin", "ImpersonationTrail")(ImpersonationTrailEndpoint)
+		ImpersonationTrailEndpoint = logging.Middleware(logger, "admin", "ImpersonationTrail")(ImpersonationTrailEndpoint)
+	}
+
+	return admin.Endpoints{
+		UsersEndpoint:         UsersEndpoint,
+		ContainersEndpoint:    ContainersEndpoint,
+		ErrorsEndpoint:        ErrorsEndpoint,
+		QueueDepthEndpoint:    QueueDepthEndpoint,
+		StopContainerEndpoint: StopContainerEndpoint,
+		ReassignNodeEndpoint:  ReassignNodeEndpoint,
+		DrainNodeEndpoint:     DrainNodeEndpoint,
+		UndrainNodeEndpoint:   UndrainNodeEndpoint,
+		CordonNodeEndpoint:    CordonNodeEndpoint,
+		UncordonNodeEndpoint:  UncordonNodeEndpoint,
+		LabelNodeEndpoint:     LabelNodeEndpoint,
+		FlagsEndpoint:         FlagsEndpoint,
+		SetFlagEndpoint:       SetFlagEndpoint,
+		RemoveFlagEndpoint:    RemoveFlagEndpoint,
+
+		MaintenanceEndpoint:         MaintenanceEndpoint,
+		ScheduleMaintenanceEndpoint: ScheduleMaintenanceEndpoint,
+		CancelMaintenanceEndpoint:   CancelMaintenanceEndpoint,
+
+		ImpersonateEndpoint:        ImpersonateEndpoint,
+		StopImpersonationEndpoint:  StopImpersonationEndpoint,
+		ImpersonationsEndpoint:     ImpersonationsEndpoint,
+		ImpersonationTrailEndpoint: ImpersonationTrailEndpoint,
+	}
+}
+
+func makeAgentServiceEndpoints(s agent.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) agent.Endpoints {
+	var HeartbeatEndpoint endpoint.Endpoint
+	{
+		HeartbeatEndpoint = agent.MakeHeartbeatEndpoint(s)
+		HeartbeatEndpoint = validation.Middleware()(HeartbeatEndpoint)
+		HeartbeatEndpoint = tracing.Middleware(tracer, "agent", "Heartbeat")(HeartbeatEndpoint)
+		HeartbeatEndpoint = instrumenting.Middleware("agent", "Heartbeat")(HeartbeatEndpoint)
+		HeartbeatEndpoint = logging.Middleware(logger, "agent", "Heartbeat")(HeartbeatEndpoint)
+	}
+
+	var AgentsEndpoint endpoint.Endpoint
+	{
+		AgentsEndpoint = agent.MakeAgentsEndpoint(s)
+		AgentsEndpoint = validation.Middleware()(AgentsEndpoint)
+		AgentsEndpoint = tracing.Middleware(tracer, "agent", "Agents")(AgentsEndpoint)
+		AgentsEndpoint = instrumenting.Middleware("agent", "Agents")(AgentsEndpoint)
+		AgentsEndpoint = logging.Middleware(logger, "agent", "Agents")(AgentsEndpoint)
+	}
+
+	var RemoveAgentEndpoint endpoint.Endpoint
+	{
+		RemoveAgentEndpoint = agent.MakeRemoveAgentEndpoint(s)
+		RemoveAgentEndpoint = validation.Middleware()(RemoveAgentEndpoint)
+		RemoveAgentEndpoint = tracing.Middleware(tracer, "agent", "RemoveAgent")(RemoveAgentEndpoint)
+		RemoveAgentEndpoint = instrumenting.Middleware("agent", "RemoveAgent")(RemoveAgentEndpoint)
+		RemoveAgentEndpoint = logging.Middleware(logger, "agent", "RemoveAgent")(RemoveAgentEndpoint)
+	}
+
+	var PublishEndpoint endpoint.Endpoint
+	{
+		PublishEndpoint = agent.MakePublishEndpoint(s)
+		PublishEndpoint = validation.Middleware()(PublishEndpoint)
+		PublishEndpoint = tracing.Middleware(tracer, "agent", "Publish")(PublishEndpoint)
+		PublishEndpoint = instrumenting.Middleware("agent", "Publish")(PublishEndpoint)
+		PublishEndpoint = logging.Middleware(logger, "agent", "Publish")(PublishEndpoint)
+	}
+
+	return agent.Endpoints{
+		HeartbeatEndpoint:   HeartbeatEndpoint,
+		AgentsEndpoint:      AgentsEndpoint,
+		RemoveAgentEndpoint: RemoveAgentEndpoint,
+		PublishEndpoint:     PublishEndpoint,
+	}
+}
+
+func makeFirewallServiceEndpoints(s firewall.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) firewall.Endpoints {
+	var initBridgeEndpoint endpoint.Endpoint
+	{
+		initBridgeEndpoint = firewall.MakeInitBridgeEndpoint(s)
+		initBridgeEndpoint = validation.Middleware()(initBridgeEndpoint)
+		initBridgeEndpoint = tracing.Middleware(tracer, "firewall", "InitBridge")(initBridgeEndpoint)
+		initBridgeEndpoint = instrumenting.Middleware("firewall", "InitBridge")(initBridgeEndpoint)
+		initBridgeEndpoint = logging.Middleware(logger, "firewall", "InitBridge")(initBridgeEndpoint)
+	}
+
+	var allowConnectionEndpoint endpoint.Endpoint
+	{
+		allowConnectionEndpoint = firewall.MakeAllowConnectionEndpoint(s)
+		allowConnectionEndpoint = validation.Middleware()(allowConnectionEndpoint)
+		allowConnectionEndpoint = tracing.Middleware(tracer, "firewall", "AllowConnection")(allowConnectionEndpoint)
+		allowConnectionEndpoint = instrumenting.Middleware("firewall", "AllowConnection")(allowConnectionEndpoint)
+		allowConnectionEndpoint = logging.Middleware(logger, "firewall", "AllowConnection")(allowConnectionEndpoint)
+	}
+
+	var blockConnectionEndpoint endpoint.Endpoint
+	{
+		blockConnectionEndpoint = firewall.MakeBlockConnectionEndpoint(s)
+		blockConnectionEndpoint = validation.Middleware()(blockConnectionEndpoint)
+		blockConnectionEndpoint = tracing.Middleware(tracer, "firewall", "BlockConnection")(blockConnectionEndpoint)
+		blockConnectionEndpoint = instrumenting.Middleware("firewall", "BlockConnection")(blockConnectionEndpoint)
+		blockConnectionEndpoint = logging.Middleware(logger, "firewall", "BlockConnection")(blockConnectionEndpoint)
+	}
+
+	var allowPortEndpoint endpoint.Endpoint
+	{
+		allowPortEndpoint = firewall.MakeAllowPortEndpoint(s)
+		allowPortEndpoint = validation.Middleware()(allowPortEndpoint)
+		allowPortEndpoint = tracing.Middleware(tracer, "firewall", "AllowPort")(allowPortEndpoint)
+		allowPortEndpoint = instrumenting.Middleware("firewall", "AllowPort")(allowPortEndpoint)
+		allowPortEndpoint = logging.Middleware(logger, "firewall", "AllowPort")(allowPortEndpoint)
+	}
+
+	var blockPortEndpoint endpoint.Endpoint
+	{
+		blockPortEndpoint = firewall.MakeBlockPortEndpoint(s)
+		blockPortEndpoint = validation.Middleware()(blockPortEndpoint)
+		blockPortEndpoint = tracing.Middleware(tracer, "firewall", "BlockPort")(blockPortEndpoint)
+		blockPortEndpoint = instrumenting.Middleware("firewall", "BlockPort")(blockPortEndpoint)
+		blockPortEndpoint = logging.Middleware(logger, "firewall", "BlockPort")(blockPortEndpoint)
+	}
+
+	var ruleCountersEndpoint endpoint.Endpoint
+	{
+		ruleCountersEndpoint = firewall.MakeRuleCountersEndpoint(s)
+		ruleCountersEndpoint = validation.Middleware()(ruleCountersEndpoint)
+		ruleCountersEndpoint = tracing.Middleware(tracer, "firewall", "RuleCounters")(ruleCountersEndpoint)
+		ruleCountersEndpoint = instrumenting.Middleware("firewall", "RuleCounters")(ruleCountersEndpoint)
+		ruleCountersEndpoint = logging.Middleware(logger, "firewall", "RuleCounters")(ruleCountersEndpoint)
+	}
+
+	var applyPolicyEndpoint endpoint.Endpoint
+	{
+		applyPolicyEndpoint = firewall.MakeApplyPolicyEndpoint(s)
+		applyPolicyEndpoint = validation.Middleware()(applyPolicyEndpoint)
+		applyPolicyEndpoint = tracing.Middleware(tracer, "firewall", "ApplyPolicy")(applyPolicyEndpoint)
+		applyPolicyEndpoint = instrumenting.Middleware("firewall", "ApplyPolicy")(applyPolicyEndpoint)
+		applyPolicyEndpoint = logging.Middleware(logger, "firewall", "ApplyPolicy")(applyPolicyEndpoint)
+	}
+
+	var removePolicyEndpoint endpoint.Endpoint
+	{
+		removePolicyEndpoint = firewall.MakeRemovePolicyEndpoint(s)
+		removePolicyEndpoint = validation.Middleware()(removePolicyEndpoint)
+		removePolicyEndpoint = tracing.Middleware(tracer, "firewall", "RemovePolicy")(removePolicyEndpoint)
+		removePolicyEndpoint = instrumenting.Middleware("firewall", "RemovePolicy")(removePolicyEndpoint)
+		removePolicyEndpoint = logging.Middleware(logger, "firewall", "RemovePolicy")(removePolicyEndpoint)
+	}
+
+	var dropsEndpoint endpoint.Endpoint
+	{
+		dropsEndpoint = firewall.MakeDropsEndpoint(s)
+		dropsEndpoint = validation.Middleware()(dropsEndpoint)
+		dropsEndpoint = tracing.Middleware(tracer, "firewall", "Drops")(dropsEndpoint)
+		dropsEndpoint = instrumenting.Middleware("firewall", "Drops")(dropsEndpoint)
+		dropsEndpoint = logging.Middleware(logger, "firewall", "Drops")(dropsEndpoint)
+	}
+
+	var banEndpoint endpoint.Endpoint
+	{
+		banEndpoint = firewall.MakeBanEndpoint(s)
+		banEndpoint = validation.Middleware()(banEndpoint)
+		banEndpoint = tracing.Middleware(tracer, "firewall", "Ban")(banEndpoint)
+		banEndpoint = instrumenting.Middleware("firewall", "Ban")(banEndpoint)
+		banEndpoint = logging.Middleware(logger, "firewall", "Ban")(banEndpoint)
+	}
+
+	var unbanEndpoint endpoint.Endpoint
+	{
+		unbanEndpoint = firewall.MakeUnbanEndpoint(s)
+		unbanEndpoint = validation.Middleware()(unbanEndpoint)
+		unbanEndpoint = tracing.Middleware(tracer, "firewall", "Unban")(unbanEndpoint)
+		unbanEndpoint = instrumenting.Middleware("firewall", "Unban")(unbanEndpoint)
+		unbanEndpoint = logging.Middleware(logger, "firewall", "Unban")(unbanEndpoint)
+	}
+
+	return firewall.Endpoints{
+		InitBridgeEndpoint:      initBridgeEndpoint,
+		AllowConnectionEndpoint: allowConnectionEndpoint,
+		BlockConnectionEndpoint: blockConnectionEndpoint,
+		AllowPortEndpoint:       allowPortEndpoint,
+		BlockPortEndpoint:       blockPortEndpoint,
+		RuleCountersEndpoint:    ruleCountersEndpoint,
+		ApplyPolicyEndpoint:     applyPolicyEndpoint,
+		RemovePolicyEndpoint:    removePolicyEndpoint,
+		DropsEndpoint:           dropsEndpoint,
+		BanEndpoint:             banEndpoint,
+		UnbanEndpoint:           unbanEndpoint,
+	}
+}
+
+func makeNetworkServiceEndpoints(s network.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) network.Endpoints {
+	var createPrimaryNetworkForContainerEndpoint endpoint.Endpoint
+	{
+		createPrimaryNetworkForContainerEndpoint = network.MakeCreatePrimaryNetworkForContainerEndpoint(s)
+		createPrimaryNetworkForContainerEndpoint = validation.Middleware()(createPrimaryNetworkForContainerEndpoint)
+		createPrimaryNetworkForContainerEndpoint = tracing.Middleware(tracer, "network", "CreatePrimaryNetworkForContainer")(createPrimaryNetworkForContainerEndpoint)
+		createPrimaryNetworkForContainerEndpoint = instrumenting.Middleware("network", "CreatePrimaryNetworkForContainer")(createPrimaryNetworkForContainerEndpoint)
+		createPrimaryNetworkForContainerEndpoint = logging.Middleware(logger, "network", "CreatePrimaryNetworkForContainer")(createPrimaryNetworkForContainerEndpoint)
+	}
+
+	var createNetworkEndpoint endpoint.Endpoint
+	{
+		createNetworkEndpoint = network.MakeCreateNetworkEndpoint(s)
+		createNetworkEndpoint = validation.Middleware()(createNetworkEndpoint)
+		createNetworkEndpoint = tracing.Middleware(tracer, "network", "CreateNetwork")(createNetworkEndpoint)
+		createNetworkEndpoint = instrumenting.Middleware("network", "CreateNetwork")(createNetworkEndpoint)
+		createNetworkEndpoint = logging.Middleware(logger, "network", "CreateNetwork")(createNetworkEndpoint)
+	}
+
+	var removeNetworkByNameEndpoint endpoint.Endpoint
+	{
+		removeNetworkByNameEndpoint = network.MakeRemoveNetworkByNameEndpoint(s)
+		removeNetworkByNameEndpoint = validation.Middleware()(removeNetworkByNameEndpoint)
+		removeNetworkByNameEndpoint = tracing.Middleware(tracer, "network", "RemoveNetworkByName")(removeNetworkByNameEndpoint)
+		removeNetworkByNameEndpoint = instrumenting.Middleware("network", "RemoveNetworkByName")(removeNetworkByNameEndpoint)
+		removeNetworkByNameEndpoint = logging.Middleware(logger, "network", "RemoveNetworkByName")(removeNetworkByNameEndpoint)
+	}
+
+	var addContainerToNetworkEndpoint endpoint.Endpoint
+	{
+		addContainerToNetworkEndpoint = network.MakeAddContainerToNetworkEndpoint(s)
+		addContainerToNetworkEndpoint = validation.Middleware()(addContainerToNetworkEndpoint)
+		addContainerToNetworkEndpoint = tracing.Middleware(tracer, "network", "AddContainerToNetwork")(addContainerToNetworkEndpoint)
+		addContainerToNetworkEndpoint = instrumenting.Middleware("network", "AddContainerToNetwork")(addContainerToNetworkEndpoint)
+		addContainerToNetworkEndpoint = logging.Middleware(logger, "network", "AddContainerToNetwork")(addContainerToNetworkEndpoint)
+	}
+
+	var removeContainerFromNetworkEndpoint endpoint.Endpoint
+	{
+		removeContainerFromNetworkEndpoint = network.MakeRemoveContainerFromNetworkEndpoint(s)
+		removeContainerFromNetworkEndpoint = validation.Middleware()(removeContainerFromNetworkEndpoint)
+		removeContainerFromNetworkEndpoint = tracing.Middleware(tracer, "network", "RemoveContainerFromNetwork")(removeContainerFromNetworkEndpoint)
+		removeContainerFromNetworkEndpoint = instrumenting.Middleware("network", "RemoveContainerFromNetwork")(removeContainerFromNetworkEndpoint)
+		removeContainerFromNetworkEndpoint = logging.Middleware(logger, "network", "RemoveContainerFromNetwork")(removeContainerFromNetworkEndpoint)
+	}
+
+	var exposePortToContainerEndpoint endpoint.Endpoint
+	{
+		exposePortToContainerEndpoint = network.MakeExposePortToContainerEndpoint(s)
+		exposePortToContainerEndpoint = validation.Middleware()(exposePortToContainerEndpoint)
+		exposePortToContainerEndpoint = tracing.Middleware(tracer, "network", "ExposePortToContainer")(exposePortToContainerEndpoint)
+		exposePortToContainerEndpoint = instrumenting.Middleware("network", "ExposePortToContainer")(exposePortToContainerEndpoint)
+		exposePortToContainerEndpoint = logging.Middleware(logger, "network", "ExposePortToContainer")(exposePortToContainerEndpoint)
+	}
+
+	var removePortFromContainerEndpoint endpoint.Endpoint
+	{
+		removePortFromContainerEndpoint = network.MakeRemovePortFromContainerEndpoint(s)
+		removePortFromContainerEndpoint = validation.Middleware()(removePortFromContainerEndpoint)
+		removePortFromContainerEndpoint = tracing.Middleware(tracer, "network", "RemovePortFromContainer")(removePortFromContainerEndpoint)
+		removePortFromContainerEndpoint = instrumenting.Middleware("network", "RemovePortFromContainer")(removePortFromContainerEndpoint)
+		removePortFromContainerEndpoint = logging.Middleware(logger, "network", "RemovePortFromContainer")(removePortFromContainerEndpoint)
+	}
+
+	var createBridgeEndpoint endpoint.Endpoint
+	{
+		createBridgeEndpoint = network.MakeCreateBridgeEndpoint(s)
+		createBridgeEndpoint = validation.Middleware()(createBridgeEndpoint)
+		createBridgeEndpoint = tracing.Middleware(tracer, "network", "CreateBridge")(createBridgeEndpoint)
+		createBridgeEndpoint = instrumenting.Middleware("network", "CreateBridge")(createBridgeEndpoint)
+		createBridgeEndpoint = logging.Middleware(logger, "network", "CreateBridge")(createBridgeEndpoint)
+	}
+
+	var removeBridgeEndpoint endpoint.Endpoint
+	{
+		removeBridgeEndpoint = network.MakeRemoveBridgeEndpoint(s)
+		removeBridgeEndpoint = validation.Middleware()(removeBridgeEndpoint)
+		removeBridgeEndpoint = tracing.Middleware(tracer, "network", "RemoveBridge")(removeBridgeEndpoint)
+		removeBridgeEndpoint = instrumenting.Middleware("network", "RemoveBridge")(removeBridgeEndpoint)
+		removeBridgeEndpoint = logging.Middleware(logger, "network", "RemoveBridge")(removeBridgeEndpoint)
+	}
+
+	var assignIPEndpoint endpoint.Endpoint
+	{
+		assignIPEndpoint = network.MakeAssignIPEndpoint(s)
+		assignIPEndpoint = validation.Middleware()(assignIPEndpoint)
+		assignIPEndpoint = tracing.Middleware(tracer, "network", "AssignIP")(assignIPEndpoint)
+		assignIPEndpoint = instrumenting.Middleware("network", "AssignIP")(assignIPEndpoint)
+		assignIPEndpoint = logging.Middleware(logger, "network", "AssignIP")(assignIPEndpoint)
+	}
+
+	var releaseIPEndpoint endpoint.Endpoint
+	{
+		releaseIPEndpoint = network.MakeReleaseIPEndpoint(s)
+		releaseIPEndpoint = validation.Middleware()(releaseIPEndpoint)
+		releaseIPEndpoint = tracing.Middleware(tracer, "network", "ReleaseIP")(releaseIPEndpoint)
+		releaseIPEndpoint = instrumenting.Middleware("network", "ReleaseIP")(releaseIPEndpoint)
+		releaseIPEndpoint = logging.Middleware(logger, "network", "ReleaseIP")(releaseIPEndpoint)
+	}
+
+	var requestPeeringEndpoint endpoint.Endpoint
+	{
+		requestPeeringEndpoint = network.MakeRequestPeeringEndpoint(s)
+		requestPeeringEndpoint = validation.Middleware()(requestPeeringEndpoint)
+		requestPeeringEndpoint = tracing.Middleware(tracer, "network", "RequestPeering")(requestPeeringEndpoint)
+		requestPeeringEndpoint = instrumenting.Middleware("network", "RequestPeering")(requestPeeringEndpoint)
+		requestPeeringEndpoint = logging.Middleware(logger, "network", "RequestPeering")(requestPeeringEndpoint)
+	}
+
+	var approvePeeringEndpoint endpoint.Endpoint
+	{
+		approvePeeringEndpoint = network.MakeApprovePeeringEndpoint(s)
+		approvePeeringEndpoint = validation.Middleware()(approvePeeringEndpoint)
+		approvePeeringEndpoint = tracing.Middleware(tracer, "network", "ApprovePeering")(approvePeeringEndpoint)
+		approvePeeringEndpoint = instrumenting.Middleware("network", "ApprovePeering")(approvePeeringEndpoint)
+		approvePeeringEndpoint = logging.Middleware(logger, "network", "ApprovePeering")(approvePeeringEndpoint)
+	}
+
+	var revokePeeringEndpoint endpoint.Endpoint
+	{
+		revokePeeringEndpoint = network.MakeRevokePeeringEndpoint(s)
+		revokePeeringEndpoint = validation.Middleware()(revokePeeringEndpoint)
+		revokePeeringEndpoint = tracing.Middleware(tracer, "network", "RevokePeering")(revokePeeringEndpoint)
+		revokePeeringEndpoint = instrumenting.Middleware("network", "RevokePeering")(revokePeeringEndpoint)
+		revokePeeringEndpoint = logging.Middleware(logger, "network", "RevokePeering")(revokePeeringEndpoint)
+	}
+
+	var peeringsEndpoint endpoint.Endpoint
+	{
+		peeringsEndpoint = network.MakePeeringsEndpoint(s)
+		peeringsEndpoint = validation.Middleware()(peeringsEndpoint)
+		peeringsEndpoint = tracing.Middleware(tracer, "network", "Peerings")(peeringsEndpoint)
+		peeringsEndpoint = instrumenting.Middleware("network", "Peerings")(peeringsEndpoint)
+		peeringsEndpoint = logging.Middleware(logger, "network", "Peerings")(peeringsEndpoint)
+	}
+
+	var registerNodeEndpoint endpoint.Endpoint
+	{
+		registerNodeEndpoint = network.MakeRegisterNodeEndpoint(s)
+		registerNodeEndpoint = validation.Middleware()(registerNodeEndpoint)
+		registerNodeEndpoint = tracing.Middleware(tracer, "network", "RegisterNode")(registerNodeEndpoint)
+		registerNodeEndpoint = instrumenting.Middleware("network", "RegisterNode")(registerNodeEndpoint)
+		registerNodeEndpoint = logging.Middleware(logger, "network", "RegisterNode")(registerNodeEndpoint)
+	}
+
+	var removeNodeEndpoint endpoint.Endpoint
+	{
+		removeNodeEndpoint = network.MakeRemoveNodeEndpoint(s)
+		removeNodeEndpoint = validation.Middleware()(removeNodeEndpoint)
+		removeNodeEndpoint = tracing.Middleware(tracer, "network", "RemoveNode")(removeNodeEndpoint)
+		removeNodeEndpoint = instrumenting.Middleware("network", "RemoveNode")(removeNodeEndpoint)
+		removeNodeEndpoint = logging.Middleware(logger, "network", "RemoveNode")(removeNodeEndpoint)
+	}
+
+	var nodesEndpoint endpoint.Endpoint
+	{
+		nodesEndpoint = network.MakeNodesEndpoint(s)
+		nodesEndpoint = validation.Middleware()(nodesEndpoint)
+		nodesEndpoint = tracing.Middleware(tracer, "network", "Nodes")(nodesEndpoint)
+		nodesEndpoint = instrumenting.Middleware("network", "Nodes")(nodesEndpoint)
+		nodesEndpoint = logging.Middleware(logger, "network", "Nodes")(nodesEndpoint)
+	}
+
+	var usageEndpoint endpoint.Endpoint
+	{
+		usageEndpoint = network.MakeUsageEndpoint(s)
+		usageEndpoint = validation.Middleware()(usageEndpoint)
+		usageEndpoint = tracing.Middleware(tracer, "network", "Usage")(usageEndpoint)
+		usageEndpoint = instrumenting.Middleware("network", "Usage")(usageEndpoint)
+		usageEndpoint = logging.Middleware(logger, "network", "Usage")(usageEndpoint)
+	}
+
+	var totalUsageEndpoint endpoint.Endpoint
+	{
+		totalUsageEndpoint = network.MakeTotalUsageEndpoint(s)
+		totalUsageEndpoint = validation.Middleware()(totalUsageEndpoint)
+		totalUsageEndpoint = tracing.Middleware(tracer, "network", "TotalUsage")(totalUsageEndpoint)
+		totalUsageEndpoint = instrumenting.Middleware("network", "TotalUsage")(totalUsageEndpoint)
+		totalUsageEndpoint = logging.Middleware(logger, "network", "TotalUsage")(totalUsageEndpoint)
+	}
+
+	return network.Endpoints{
+		CreatePrimaryNetworkForContainerEndpoint: createPrimaryNetworkForContainerEndpoint,
+		CreateNetworkEndpoint:                    createNetworkEndpoint,
+		RemoveNetworkByNameEndpoint:              removeNetworkByNameEndpoint,
+		AddContainerToNetworkEndpoint:            addContainerToNetworkEndpoint,
+		RemoveContainerFromNetworkEndpoint:       removeContainerFromNetworkEndpoint,
+		ExposePortToContainerEndpoint:            exposePortToContainerEndpoint,
+		RemovePortFromContainerEndpoint:          removePortFromContainerEndpoint,
+		CreateBridgeEndpoint:                     createBridgeEndpoint,
+		RemoveBridgeEndpoint:                     removeBridgeEndpoint,
+		AssignIPEndpoint:                         assignIPEndpoint,
+		ReleaseIPEndpoint:                        releaseIPEndpoint,
+		RequestPeeringEndpoint:                   requestPeeringEndpoint,
+		ApprovePeeringEndpoint:                   approvePeeringEndpoint,
+		RevokePeeringEndpoint:                    revokePeeringEndpoint,
+		PeeringsEndpoint:                         peeringsEndpoint,
+		RegisterNodeEndpoint:                     registerNodeEndpoint,
+		RemoveNodeEndpoint:                       removeNodeEndpoint,
+		NodesEndpoint:                            nodesEndpoint,
+		UsageEndpoint:                            usageEndpoint,
+		TotalUsageEndpoint:                       totalUsageEndpoint,
+	}
+}
+
+func makeWebhookServiceEndpoints(s webhook.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) webhook.Endpoints {
+	var CreateWebhookEndpoint endpoint.Endpoint
+	{
+		CreateWebhookEndpoint = webhook.MakeCreateWebhookEndpoint(s)
+		CreateWebhookEndpoint = validation.Middleware()(CreateWebhookEndpoint)
+		CreateWebhookEndpoint = tracing.Middleware(tracer, "webhook", "CreateWebhook")(CreateWebhookEndpoint)
+		CreateWebhookEndpoint = instrumenting.Middleware("webhook", "CreateWebhook")(CreateWebhookEndpoint)
+		CreateWebhookEndpoint = logging.Middleware(logger, "webhook", "CreateWebhook")(CreateWebhookEndpoint)
+	}
+
+	var RemoveWebhookEndpoint endpoint.Endpoint
+	{
+		RemoveWebhookEndpoint = webhook.MakeRemoveWebhookEndpoint(s)
+		RemoveWebhookEndpoint = validation.Middleware()(RemoveWebhookEndpoint)
+		RemoveWebhookEndpoint = tracing.Middleware(tracer, "webhook", "RemoveWebhook")(RemoveWebhookEndpoint)
+		RemoveWebhookEndpoint = instrumenting.Middleware("webhook", "RemoveWebhook")(RemoveWebhookEndpoint)
+		RemoveWebhookEndpoint = logging.Middleware(logger, "webhook", "RemoveWebhook")(RemoveWebhookEndpoint)
+	}
+
+	var WebhooksEndpoint endpoint.Endpoint
+	{
+		WebhooksEndpoint = webhook.MakeWebhooksEndpoint(s)
+		WebhooksEndpoint = validation.Middleware()(WebhooksEndpoint)
+		WebhooksEndpoint = tracing.Middleware(tracer, "webhook", "Webhooks")(WebhooksEndpoint)
+		WebhooksEndpoint = instrumenting.Middleware("webhook", "Webhooks")(WebhooksEndpoint)
+		WebhooksEndpoint = logging.Middleware(logger, "webhook", "Webhooks")(WebhooksEndpoint)
+	}
+
+	var DeliveriesEndpoint endpoint.Endpoint
+	{
+		DeliveriesEndpoint = webhook.MakeDeliveriesEndpoint(s)
+		DeliveriesEndpoint = validation.Middleware()(DeliveriesEndpoint)
+		DeliveriesEndpoint = tracing.Middleware(tracer, "webhook", "Deliveries")(DeliveriesEndpoint)
+		DeliveriesEndpoint = instrumenting.Middleware("webhook", "Deliveries")(DeliveriesEndpoint)
+		DeliveriesEndpoint = logging.Middleware(logger, "webhook", "Deliveries")(DeliveriesEndpoint)
+	}
+
+	return webhook.Endpoints{
+		CreateWebhookEndpoint: CreateWebhookEndpoint,
+		RemoveWebhookEndpoint: RemoveWebhookEndpoint,
+		WebhooksEndpoint:      WebhooksEndpoint,
+		DeliveriesEndpoint:    DeliveriesEndpoint,
+	}
+}
+
+func makeSSHKeyServiceEndpoints(s sshkey.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) sshkey.Endpoints {
+	return sshkey.MakeEndpoints(s, serviceMiddleware("sshkey", instrumenting, tracer, logger))
+}
+
+func makePortServiceEndpoints(s ports.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) ports.Endpoints {
+	var AllocatePortEndpoint endpoint.Endpoint
+	{
+		AllocatePortEndpoint = ports.MakeAllocatePortEndpoint(s)
+		AllocatePortEndpoint = validation.Middleware()(AllocatePortEndpoint)
+		AllocatePortEndpoint = tracing.Middleware(tracer, "ports", "AllocatePort")(AllocatePortEndpoint)
+		AllocatePortEndpoint = instrumenting.Middleware("ports", "AllocatePort")(AllocatePortEndpoint)
+		AllocatePortEndpoint = logging.Middleware(logger, "ports", "AllocatePort")(AllocatePortEndpoint)
+	}
+
+	var ReservePortEndpoint endpoint.Endpoint
+	{
+		ReservePortEndpoint = ports.MakeReservePortEndpoint(s)
+		ReservePortEndpoint = validation.Middleware()(ReservePortEndpoint)
+		ReservePortEndpoint = tracing.Middleware(tracer, "ports", "ReservePort")(ReservePortEndpoint)
+		ReservePortEndpoint = instrumenting.Middleware("ports", "ReservePort")(ReservePortEndpoint)
+		ReservePortEndpoint = logging.Middleware(logger, "ports", "ReservePort")(ReservePortEndpoint)
+	}
+
+	var ReleasePortEndpoint endpoint.Endpoint
+	{
+		ReleasePortEndpoint = ports.MakeReleasePortEndpoint(s)
+		ReleasePortEndpoint = validation.Middleware()(ReleasePortEndpoint)
+		ReleasePortEndpoint = tracing.Middleware(tracer, "ports", "ReleasePort")(ReleasePortEndpoint)
+		ReleasePortEndpoint = instrumenting.Middleware("ports", "ReleasePort")(ReleasePortEndpoint)
+		ReleasePortEndpoint = logging.Middleware(logger, "ports", "ReleasePort")(ReleasePortEndpoint)
+	}
+
+	var ReservationsEndpoint endpoint.Endpoint
+	{
+		ReservationsEndpoint = ports.MakeReservationsEndpoint(s)
+		ReservationsEndpoint = validation.Middleware()(ReservationsEndpoint)
+		ReservationsEndpoint = tracing.Middleware(tracer, "ports", "Reservations")(ReservationsEndpoint)
+		ReservationsEndpoint = instrumenting.Middleware("ports", "Reservations")(ReservationsEndpoint)
+		ReservationsEndpoint = logging.Middleware(logger, "ports", "Reservations")(ReservationsEndpoint)
+	}
+
+	return ports.Endpoints{
+		AllocatePortEndpoint: AllocatePortEndpoint,
+		ReservePortEndpoint:  ReservePortEndpoint,
+		ReleasePortEndpoint:  ReleasePortEndpoint,
+		ReservationsEndpoint: ReservationsEndpoint,
+	}
+}
+
+func makeDatabaseServiceEndpoints(s database.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) database.Endpoints {
+	var CreateDatabaseEndpoint endpoint.Endpoint
+	{
+		CreateDatabaseEndpoint = database.MakeCreateDatabaseEndpoint(s)
+		CreateDatabaseEndpoint = validation.Middleware()(CreateDatabaseEndpoint)
+		CreateDatabaseEndpoint = tracing.Middleware(tracer, "database", "CreateDatabase")(CreateDatabaseEndpoint)
+		CreateDatabaseEndpoint = instrumenting.Middleware("database", "CreateDatabase")(CreateDatabaseEndpoint)
+		CreateDatabaseEndpoint = logging.Middleware(logger, "database", "CreateDatabase")(CreateDatabaseEndpoint)
+	}
+
+	var RemoveDatabaseEndpoint endpoint.Endpoint
+	{
+		RemoveDatabaseEndpoint = database.MakeRemoveDatabaseEndpoint(s)
+		RemoveDatabaseEndpoint = validation.Middleware()(RemoveDatabaseEndpoint)
+		RemoveDatabaseEndpoint = tracing.Middleware(tracer, "database", "RemoveDatabase")(RemoveDatabaseEndpoint)
+		RemoveDatabaseEndpoint = instrumenting.Middleware("database", "RemoveDatabase")(RemoveDatabaseEndpoint)
+		RemoveDatabaseEndpoint = logging.Middleware(logger, "database", "RemoveDatabase")(RemoveDatabaseEndpoint)
+	}
+
+	var DatabasesEndpoint endpoint.Endpoint
+	{
+		DatabasesEndpoint = database.MakeDatabasesEndpoint(s)
+		DatabasesEndpoint = validation.Middleware()(DatabasesEndpoint)
+		DatabasesEndpoint = tracing.Middleware(tracer, "database", "Databases")(DatabasesEndpoint)
+		DatabasesEndpoint = instrumenting.Middleware("database", "Databases")(DatabasesEndpoint)
+		DatabasesEndpoint = logging.Middleware(logger, "database", "Databases")(DatabasesEndpoint)
+	}
+
+	var LinkDatabaseEndpoint endpoint.Endpoint
+	{
+		LinkDatabaseEndpoint = database.MakeLinkDatabaseEndpoint(s)
+		LinkDatabaseEndpoint = validation.Middleware()(LinkDatabaseEndpoint)
+		LinkDatabaseEndpoint = tracing.Middleware(tracer, "database", "LinkDatabase")(LinkDatabaseEndpoint)
+		LinkDatabaseEndpoint = instrumenting.Middleware("database", "LinkDatabase")(LinkDatabaseEndpoint)
+		LinkDatabaseEndpoint = logging.Middleware(logger, "database", "LinkDatabase")(LinkDatabaseEndpoint)
+	}
+
+	var UnlinkDatabaseEndpoint endpoint.Endpoint
+	{
+		UnlinkDatabaseEndpoint = database.MakeUnlinkDatabaseEndpoint(s)
+		UnlinkDatabaseEndpoint = validation.Middleware()(UnlinkDatabaseEndpoint)
+		UnlinkDatabaseEndpoint = tracing.Middleware(tracer, "database", "UnlinkDatabase")(UnlinkDatabaseEndpoint)
+		UnlinkDatabaseEndpoint = instrumenting.Middleware("database", "UnlinkDatabase")(UnlinkDatabaseEndpoint)
+		UnlinkDatabaseEndpoint = logging.Middleware(logger, "database", "UnlinkDatabase")(UnlinkDatabaseEndpoint)
+	}
+
+	var DumpDatabaseEndpoint endpoint.Endpoint
+	{
+		DumpDatabaseEndpoint = database.MakeDumpDatabaseEndpoint(s)
+		DumpDatabaseEndpoint = validation.Middleware()(DumpDatabaseEndpoint)
+		DumpDatabaseEndpoint = tracing.Middleware(tracer, "database", "DumpDatabase")(DumpDatabaseEndpoint)
+		DumpDatabaseEndpoint = instrumenting.Middleware("database", "DumpDatabase")(DumpDatabaseEndpoint)
+		DumpDatabaseEndpoint = logging.Middleware(logger, "database", "DumpDatabase")(DumpDatabaseEndpoint)
+	}
+
+	var DumpsEndpoint endpoint.Endpoint
+	{
+		DumpsEndpoint = database.MakeDumpsEndpoint(s)
+		DumpsEndpoint = validation.Middleware()(DumpsEndpoint)
+		DumpsEndpoint = tracing.Middleware(tracer, "database", "Dumps")(DumpsEndpoint)
+		DumpsEndpoint = instrumenting.Middleware("database", "Dumps")(DumpsEndpoint)
+		DumpsEndpoint = logging.Middleware(logger, "database", "Dumps")(DumpsEndpoint)
+	}
 
-	var moduleService module.Service
-	moduleService, err = module.NewService(&containerServiceEndpoints, logger)
-	if err != nil {
-		panic(err)
+	return database.Endpoints{
+		CreateDatabaseEndpoint: CreateDatabaseEndpoint,
+		RemoveDatabaseEndpoint: RemoveDatabaseEndpoint,
+		DatabasesEndpoint:      DatabasesEndpoint,
+		LinkDatabaseEndpoint:   LinkDatabaseEndpoint,
+		UnlinkDatabaseEndpoint: UnlinkDatabaseEndpoint,
+		DumpDatabaseEndpoint:   DumpDatabaseEndpoint,
+		DumpsEndpoint:          DumpsEndpoint,
 	}
+}
 
-	moduleServeEndpoints := makeModuleServiceEndpoints(moduleService)
+func makeSnapshotServiceEndpoints(s snapshot.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) snapshot.Endpoints {
+	var CreateScheduleEndpoint endpoint.Endpoint
+	{
+		CreateScheduleEndpoint = snapshot.MakeCreateScheduleEndpoint(s)
+		CreateScheduleEndpoint = validation.Middleware()(CreateScheduleEndpoint)
+		CreateScheduleEndpoint = tracing.Middleware(tracer, "snapshot", "CreateSchedule")(CreateScheduleEndpoint)
+		CreateScheduleEndpoint = instrumenting.Middleware("snapshot", "CreateSchedule")(CreateScheduleEndpoint)
+		CreateScheduleEndpoint = logging.Middleware(logger, "snapshot", "CreateSchedule")(CreateScheduleEndpoint)
+	}
 
-	errc := make(chan error)
-	ctx := context.Background()
+	var RemoveScheduleEndpoint endpoint.Endpoint
+	{
+		RemoveScheduleEndpoint = snapshot.MakeRemoveScheduleEndpoint(s)
+		RemoveScheduleEndpoint = validation.Middleware()(RemoveScheduleEndpoint)
+		RemoveScheduleEndpoint = tracing.Middleware(tracer, "snapshot", "RemoveSchedule")(RemoveScheduleEndpoint)
+		RemoveScheduleEndpoint = instrumenting.Middleware("snapshot", "RemoveSchedule")(RemoveScheduleEndpoint)
+		RemoveScheduleEndpoint = logging.Middleware(logger, "snapshot", "RemoveSchedule")(RemoveScheduleEndpoint)
+	}
 
-	go startGRPCTransport(ctx, errc, logger, grpcAddr, userEndpoints, kmiEndpoints, routingEndpoints, containerServiceEndpoints, moduleServeEndpoints)
+	var SchedulesEndpoint endpoint.Endpoint
+	{
+		SchedulesEndpoint = snapshot.MakeSchedulesEndpoint(s)
+		SchedulesEndpoint = validation.Middleware()(SchedulesEndpoint)
+		SchedulesEndpoint = tracing.Middleware(tracer, "snapshot", "Schedules")(SchedulesEndpoint)
+		SchedulesEndpoint = instrumenting.Middleware("snapshot", "Schedules")(SchedulesEndpoint)
+		SchedulesEndpoint = logging.Middleware(logger, "snapshot", "Schedules")(SchedulesEndpoint)
+	}
 
-	conn, err := grpc.Dial(grpcAddr, grpc.WithInsecure(), grpc.WithTimeout(time.Second))
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v", err)
-		os.Exit(1)
+	var SnapshotsEndpoint endpoint.Endpoint
+	{
+		SnapshotsEndpoint = snapshot.MakeSnapshotsEndpoint(s)
+		SnapshotsEndpoint = validation.Middleware()(SnapshotsEndpoint)
+		SnapshotsEndpoint = tracing.Middleware(tracer, "snapshot", "Snapshots")(SnapshotsEndpoint)
+		SnapshotsEndpoint = instrumenting.Middleware("snapshot", "Snapshots")(SnapshotsEndpoint)
+		SnapshotsEndpoint = logging.Middleware(logger, "snapshot", "Snapshots")(SnapshotsEndpoint)
 	}
-	defer conn.Close()
 
-	kenTheGuruService := kentheguru.NewService(
-		// TODO: generate keys and load them from configuration file
-		"bubububububububububububububububu",
-		"bubububububububububububububububu",
-		"bu",
-		websocket.Upgrader{
-			EnableCompression: true,
-		},
-		ws.SSLConfig{
-			Addr: wsAddrSecure,
-			// TODO: generate certificate and key
-		},
-		userEndpoints, kmiEndpoints, containerServiceEndpoints, routingEndpoints, moduleServeEndpoints,
-	)
+	var RemoveSnapshotEndpoint endpoint.Endpoint
+	{
+		RemoveSnapshotEndpoint = snapshot.MakeRemoveSnapshotEndpoint(s)
+		RemoveSnapshotEndpoint = validation.Middleware()(RemoveSnapshotEndpoint)
+		RemoveSnapshotEndpoint = tracing.Middleware(tracer, "snapshot", "RemoveSnapshot")(RemoveSnapshotEndpoint)
+		RemoveSnapshotEndpoint = instrumenting.Middleware("snapshot", "RemoveSnapshot")(RemoveSnapshotEndpoint)
+		RemoveSnapshotEndpoint = logging.Middleware(logger, "snapshot", "RemoveSnapshot")(RemoveSnapshotEndpoint)
+	}
 
-	go kenTheGuruService.StartWebsocketTransport(errc, logger, wsAddr)
+	var TakeSnapshotEndpoint endpoint.Endpoint
+	{
+		TakeSnapshotEndpoint = snapshot.MakeTakeSnapshotEndpoint(s)
+		TakeSnapshotEndpoint = validation.Middleware()(TakeSnapshotEndpoint)
+		TakeSnapshotEndpoint = tracing.Middleware(tracer, "snapshot", "TakeSnapshot")(TakeSnapshotEndpoint)
+		TakeSnapshotEndpoint = instrumenting.Middleware("snapshot", "TakeSnapshot")(TakeSnapshotEndpoint)
+		TakeSnapshotEndpoint = logging.Middleware(logger, "snapshot", "TakeSnapshot")(TakeSnapshotEndpoint)
+	}
 
-	// Interrupt handler.
-	go func() {
-		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
-		errc <- fmt.Errorf("%s", <-c)
-	}()
+	var RestoreSnapshotEndpoint endpoint.Endpoint
+	{
+		RestoreSnapshotEndpoint = snapshot.MakeRestoreSnapshotEndpoint(s)
+		RestoreSnapshotEndpoint = validation.Middleware()(RestoreSnapshotEndpoint)
+		RestoreSnapshotEndpoint = tracing.Middleware(tracer, "snapshot", "RestoreSnapshot")(RestoreSnapshotEndpoint)
+		RestoreSnapshotEndpoint = instrumenting.Middleware("snapshot", "RestoreSnapshot")(RestoreSnapshotEndpoint)
+		RestoreSnapshotEndpoint = logging.Middleware(logger, "snapshot", "RestoreSnapshot")(RestoreSnapshotEndpoint)
+	}
 
-	logger.Log("exit", <-errc)
+	return snapshot.Endpoints{
+		CreateScheduleEndpoint:  CreateScheduleEndpoint,
+		RemoveScheduleEndpoint:  RemoveScheduleEndpoint,
+		SchedulesEndpoint:       SchedulesEndpoint,
+		SnapshotsEndpoint:       SnapshotsEndpoint,
+		RemoveSnapshotEndpoint:  RemoveSnapshotEndpoint,
+		TakeSnapshotEndpoint:    TakeSnapshotEndpoint,
+		RestoreSnapshotEndpoint: RestoreSnapshotEndpoint,
+	}
 }
 
-func startGRPCTransport(ctx context.Context, errc chan error, logger log.Logger, grpcAddr string, ue user.Endpoints, ke kmi.Endpoints, re routing.Endpoints, ce container.Endpoints, me module.Endpoints) {
-	logger = log.With(logger, "transport", "gRPC")
-
-	ln, err := net.Listen("tcp", grpcAddr)
-	if err != nil {
-		errc <- err
-		return
+func makeBillingServiceEndpoints(s billing.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) billing.Endpoints {
+	var AccountEndpoint endpoint.Endpoint
+	{
+		AccountEndpoint = billing.MakeAccountEndpoint(s)
+		AccountEndpoint = validation.Middleware()(AccountEndpoint)
+		AccountEndpoint = tracing.Middleware(tracer, "billing", "Account")(AccountEndpoint)
+		AccountEndpoint = instrumenting.Middleware("billing", "Account")(AccountEndpoint)
+		AccountEndpoint = logging.Middleware(logger, "billing", "Account")(AccountEndpoint)
 	}
-	s := grpc.NewServer()
 
-	userServer := user.MakeGRPCServer(ctx, ue, logger)
-	userPB.RegisterUserServiceServer(s, userServer)
-
-	kmiServer := kmi.MakeGRPCServer(ctx, ke, logger)
-	kmiPB.RegisterKMIServiceServer(s, kmiServer)
+	var InvoicesEndpoint endpoint.Endpoint
+	{
+		InvoicesEndpoint = billing.MakeInvoicesEndpoint(s)
+		InvoicesEndpoint = validation.Middleware()(InvoicesEndpoint)
+		InvoicesEndpoint = tracing.Middleware(tracer, "billing", "Invoices")(InvoicesEndpoint)
+		InvoicesEndpoint = instrumenting.Middleware("billing", "Invoices")(InvoicesEndpoint)
+		InvoicesEndpoint = logging.Middleware(logger, "billing", "Invoices")(InvoicesEndpoint)
+	}
 
-	routingServer := routing.MakeGRPCServer(ctx, re, logger)
-	routingPB.RegisterRoutingServiceServer(s, routingServer)
+	var DocumentEndpoint endpoint.Endpoint
+	{
+		DocumentEndpoint = billing.MakeDocumentEndpoint(s)
+		DocumentEndpoint = validation.Middleware()(DocumentEndpoint)
+		DocumentEndpoint = tracing.Middleware(tracer, "billing", "Document")(DocumentEndpoint)
+		DocumentEndpoint = instrumenting.Middleware("billing", "Document")(DocumentEndpoint)
+		DocumentEndpoint = logging.Middleware(logger, "billing", "Document")(DocumentEndpoint)
+	}
 
-	containerServer := container.MakeGRPCServer(ctx, ce, logger)
-	containerPB.RegisterContainerServiceServer(s, containerServer)
+	var DocumentURLEndpoint endpoint.Endpoint
+	{
+		DocumentURLEndpoint = billing.MakeDocumentURLEndpoint(s)
+		DocumentURLEndpoint = validation.Middleware()(DocumentURLEndpoint)
+		DocumentURLEndpoint = tracing.Middleware(tracer, "billing", "DocumentURL")(DocumentURLEndpoint)
+		DocumentURLEndpoint = instrumenting.Middleware("billing", "DocumentURL")(DocumentURLEndpoint)
+		DocumentURLEndpoint = logging.Middleware(logger, "billing", "DocumentURL")(DocumentURLEndpoint)
+	}
 
-	moduleServer := module.MakeGRPCServer(ctx, me, logger)
-	modulePB.RegisterModuleServiceServer(s, moduleServer)
+	var CreateCreditNoteEndpoint endpoint.Endpoint
+	{
+		CreateCreditNoteEndpoint = billing.MakeCreateCreditNoteEndpoint(s)
+		CreateCreditNoteEndpoint = validation.Middleware()(CreateCreditNoteEndpoint)
+		CreateCreditNoteEndpoint = tracing.Middleware(tracer, "billing", "CreateCreditNote")(CreateCreditNoteEndpoint)
+		CreateCreditNoteEndpoint = instrumenting.Middleware("billing", "CreateCreditNote")(CreateCreditNoteEndpoint)
+		CreateCreditNoteEndpoint = logging.Middleware(logger, "billing", "CreateCreditNote")(CreateCreditNoteEndpoint)
+	}
 
-	logger.Log("addr", grpcAddr)
-	errc <- s.Serve(ln)
+	return billing.Endpoints{
+		AccountEndpoint:          AccountEndpoint,
+		InvoicesEndpoint:         InvoicesEndpoint,
+		DocumentEndpoint:         DocumentEndpoint,
+		DocumentURLEndpoint:      DocumentURLEndpoint,
+		CreateCreditNoteEndpoint: CreateCreditNoteEndpoint,
+	}
 }
 
-func makeUserServiceEndpoints(s user.Service) user.Endpoints {
-	var createUserEndpoint endpoint.Endpoint
+func makeCronJobServiceEndpoints(s cronjob.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) cronjob.Endpoints {
+	var CreateJobEndpoint endpoint.Endpoint
 	{
-		createUserEndpoint = user.MakeCreateUserEndpoint(s)
+		CreateJobEndpoint = cronjob.MakeCreateJobEndpoint(s)
+		CreateJobEndpoint = validation.Middleware()(CreateJobEndpoint)
+		CreateJobEndpoint = tracing.Middleware(tracer, "cronjob", "CreateJob")(CreateJobEndpoint)
+		CreateJobEndpoint = instrumenting.Middleware("cronjob", "CreateJob")(CreateJobEndpoint)
+		CreateJobEndpoint = logging.Middleware(logger, "cronjob", "CreateJob")(CreateJobEndpoint)
 	}
 
-	var editUserEndpoint endpoint.Endpoint
+	var RemoveJobEndpoint endpoint.Endpoint
 	{
-		editUserEndpoint = user.MakeEditUserEndpoint(s)
+		RemoveJobEndpoint = cronjob.MakeRemoveJobEndpoint(s)
+		RemoveJobEndpoint = validation.Middleware()(RemoveJobEndpoint)
+		RemoveJobEndpoint = tracing.Middleware(tracer, "cronjob", "RemoveJob")(RemoveJobEndpoint)
+		RemoveJobEndpoint = instrumenting.Middleware("cronjob", "RemoveJob")(RemoveJobEndpoint)
+		RemoveJobEndpoint = logging.Middleware(logger, "cronjob", "RemoveJob")(RemoveJobEndpoint)
 	}
 
-	var changeUsernaemEndpoint endpoint.Endpoint
+	var JobsEndpoint endpoint.Endpoint
 	{
-		changeUsernaemEndpoint = user.MakeChangeUsernameEndpoint(s)
+		JobsEndpoint = cronjob.MakeJobsEndpoint(s)
+		JobsEndpoint = validation.Middleware()(JobsEndpoint)
+		JobsEndpoint = tracing.Middleware(tracer, "cronjob", "Jobs")(JobsEndpoint)
+		JobsEndpoint = instrumenting.Middleware("cronjob", "Jobs")(JobsEndpoint)
+		JobsEndpoint = logging.Middleware(logger, "cronjob", "Jobs")(JobsEndpoint)
 	}
 
-	var deleteUserEndpoint endpoint.Endpoint
+	var RunsEndpoint endpoint.Endpoint
 	{
-		deleteUserEndpoint = user.MakeDeleteUserEndpoint(s)
+		RunsEndpoint = cronjob.MakeRunsEndpoint(s)
+		RunsEndpoint = validation.Middleware()(RunsEndpoint)
+		RunsEndpoint = tracing.Middleware(tracer, "cronjob", "Runs")(RunsEndpoint)
+		RunsEndpoint = instrumenting.Middleware("cronjob", "Runs")(RunsEndpoint)
+		RunsEndpoint = logging.Middleware(logger, "cronjob", "Runs")(RunsEndpoint)
 	}
 
-	var resetPasswordEndpoint endpoint.Endpoint
+	var RunJobEndpoint endpoint.Endpoint
 	{
-		resetPasswordEndpoint = user.MakeResetPasswordEndpoint(s)
+		RunJobEndpoint = cronjob.MakeRunJobEndpoint(s)
+		RunJobEndpoint = validation.Middleware()(RunJobEndpoint)
+		RunJobEndpoint = tracing.Middleware(tracer, "cronjob", "RunJob")(RunJobEndpoint)
+		RunJobEndpoint = instrumenting.Middleware("cronjob", "RunJob")(RunJobEndpoint)
+		RunJobEndpoint = logging.Middleware(logger, "cronjob", "RunJob")(RunJobEndpoint)
 	}
 
-	var getUserEndpoint endpoint.Endpoint
+	return cronjob.Endpoints{
+		CreateJobEndpoint: CreateJobEndpoint,
+		RemoveJobEndpoint: RemoveJobEndpoint,
+		JobsEndpoint:      JobsEndpoint,
+		RunsEndpoint:      RunsEndpoint,
+		RunJobEndpoint:    RunJobEndpoint,
+	}
+}
+
+func makeContainerLogServiceEndpoints(s containerlog.Service, c *containerlog.Collector, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) containerlog.Endpoints {
+	var SearchEndpoint endpoint.Endpoint
 	{
-		getUserEndpoint = user.MakeGetUserEndpoint(s)
+		SearchEndpoint = containerlog.MakeSearchEndpoint(s)
+		SearchEndpoint = validation.Middleware()(SearchEndpoint)
+		SearchEndpoint = tracing.Middleware(tracer, "containerlog", "Search")(SearchEndpoint)
+		SearchEndpoint = instrumenting.Middleware("containerlog", "Search")(SearchEndpoint)
+		SearchEndpoint = logging.Middleware(logger, "containerlog", "Search")(SearchEndpoint)
 	}
 
-	var checkLoginCredentialsEndpoint endpoint.Endpoint
+	var TailEndpoint endpoint.Endpoint
 	{
-		checkLoginCredentialsEndpoint = user.MakeCheckLoginCredentialsEndpoint(s)
+		TailEndpoint = containerlog.MakeTailEndpoint(c)
+		TailEndpoint = validation.Middleware()(TailEndpoint)
+		TailEndpoint = tracing.Middleware(tracer, "containerlog", "Tail")(TailEndpoint)
+		TailEndpoint = instrumenting.Middleware("containerlog", "Tail")(TailEndpoint)
+		TailEndpoint = logging.Middleware(logger, "containerlog", "Tail")(TailEndpoint)
 	}
 
-	return user.Endpoints{
-		CreateUserEndpoint:            createUserEndpoint,
-		EditUserEndpoint:              editUserEndpoint,
-		ChangeUsernameEndpoint:        changeUsernaemEndpoint,
-		DeleteUserEndpoint:            deleteUserEndpoint,
-		ResetPasswordEndpoint:         resetPasswordEndpoint,
-		GetUserEndpoint:               getUserEndpoint,
-		CheckLoginCredentialsEndpoint: checkLoginCredentialsEndpoint,
+	return containerlog.Endpoints{
+		SearchEndpoint: SearchEndpoint,
+		TailEndpoint:   TailEndpoint,
 	}
 }
 
-func makeKMIServiceEndpoints(s kmi.Service) kmi.Endpoints {
-	var AddKMIEndpoint endpoint.Endpoint
+func makeAlertServiceEndpoints(s alert.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) alert.Endpoints {
+	var CreateRuleEndpoint endpoint.Endpoint
 	{
-		AddKMIEndpoint = kmi.MakeAddKMIEndpoint(s)
+		CreateRuleEndpoint = alert.MakeCreateRuleEndpoint(s)
+		CreateRuleEndpoint = validation.Middleware()(CreateRuleEndpoint)
+		CreateRuleEndpoint = tracing.Middleware(tracer, "alert", "CreateRule")(CreateRuleEndpoint)
+		CreateRuleEndpoint = instrumenting.Middleware("alert", "CreateRule")(CreateRuleEndpoint)
+		CreateRuleEndpoint = logging.Middleware(logger, "alert", "CreateRule")(CreateRuleEndpoint)
 	}
 
-	var RemoveKMIEndpoint endpoint.Endpoint
+	var RemoveRuleEndpoint endpoint.Endpoint
 	{
-		RemoveKMIEndpoint = kmi.MakeRemoveKMIEndpoint(s)
+		RemoveRuleEndpoint = alert.MakeRemoveRuleEndpoint(s)
+		RemoveRuleEndpoint = validation.Middleware()(RemoveRuleEndpoint)
+		RemoveRuleEndpoint = tracing.Middleware(tracer, "alert", "RemoveRule")(RemoveRuleEndpoint)
+		RemoveRuleEndpoint = instrumenting.Middleware("alert", "RemoveRule")(RemoveRuleEndpoint)
+		RemoveRuleEndpoint = logging.Middleware(logger, "alert", "RemoveRule")(RemoveRuleEndpoint)
 	}
 
-	var GetKMIEndpoint endpoint.Endpoint
+	var RulesEndpoint endpoint.Endpoint
 	{
-		GetKMIEndpoint = kmi.MakeGetKMIEndpoint(s)
+		RulesEndpoint = alert.MakeRulesEndpoint(s)
+		RulesEndpoint = validation.Middleware()(RulesEndpoint)
+		RulesEndpoint = tracing.Middleware(tracer, "alert", "Rules")(RulesEndpoint)
+		RulesEndpoint = instrumenting.Middleware("alert", "Rules")(RulesEndpoint)
+		RulesEndpoint = logging.Middleware(logger, "alert", "Rules")(RulesEndpoint)
 	}
 
-	var KMIEndpoint endpoint.Endpoint
+	var StatesEndpoint endpoint.Endpoint
 	{
-		KMIEndpoint = kmi.MakeKMIEndpoint(s)
+		StatesEndpoint = alert.MakeStatesEndpoint(s)
+		StatesEndpoint = validation.Middleware()(StatesEndpoint)
+		StatesEndpoint = tracing.Middleware(tracer, "alert", "States")(StatesEndpoint)
+		StatesEndpoint = instrumenting.Middleware("alert", "States")(StatesEndpoint)
+		StatesEndpoint = logging.Middleware(logger, "alert", "States")(StatesEndpoint)
 	}
 
-	return kmi.Endpoints{
-		AddKMIEndpoint:    AddKMIEndpoint,
-		RemoveKMIEndpoint: RemoveKMIEndpoint,
-		GetKMIEndpoint:    GetKMIEndpoint,
-		KMIEndpoint:       KMIEndpoint,
+	var AlertsEndpoint endpoint.Endpoint
+	{
+		AlertsEndpoint = alert.MakeAlertsEndpoint(s)
+		AlertsEndpoint = validation.Middleware()(AlertsEndpoint)
+		AlertsEndpoint = tracing.Middleware(tracer, "alert", "Alerts")(AlertsEndpoint)
+		AlertsEndpoint = instrumenting.Middleware("alert", "Alerts")(AlertsEndpoint)
+		AlertsEndpoint = logging.Middleware(logger, "alert", "Alerts")(AlertsEndpoint)
 	}
-}
 
-func makeContainerServiceEndpoints(s container.Service) container.Endpoints {
+	return alert.Endpoints{
+		CreateRuleEndpoint: CreateRuleEndpoint,
+		RemoveRuleEndpoint: RemoveRuleEndpoint,
+		RulesEndpoint:      RulesEndpoint,
+		StatesEndpoint:     StatesEndpoint,
+		AlertsEndpoint:     AlertsEndpoint,
+	}
+}
 
-	var CreateContainerEndpoint endpoint.Endpoint
+func makeAutoscaleServiceEndpoints(s autoscale.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) autoscale.Endpoints {
+	var SetPolicyEndpoint endpoint.Endpoint
 	{
-		CreateContainerEndpoint = container.MakeCreateContainerEndpoint(s)
+		SetPolicyEndpoint = autoscale.MakeSetPolicyEndpoint(s)
+		SetPolicyEndpoint = validation.Middleware()(SetPolicyEndpoint)
+		SetPolicyEndpoint = tracing.Middleware(tracer, "autoscale", "SetPolicy")(SetPolicyEndpoint)
+		SetPolicyEndpoint = instrumenting.Middleware("autoscale", "SetPolicy")(SetPolicyEndpoint)
+		SetPolicyEndpoint = logging.Middleware(logger, "autoscale", "SetPolicy")(SetPolicyEndpoint)
 	}
-	var RemoveContainerEndpoint endpoint.Endpoint
+
+	var RemovePolicyEndpoint endpoint.Endpoint
 	{
-		RemoveContainerEndpoint = container.MakeRemoveContainerEndpoint(s)
+		RemovePolicyEndpoint = autoscale.MakeRemovePolicyEndpoint(s)
+		RemovePolicyEndpoint = validation.Middleware()(RemovePolicyEndpoint)
+		RemovePolicyEndpoint = tracing.Middleware(tracer, "autoscale", "RemovePolicy")(RemovePolicyEndpoint)
+		RemovePolicyEndpoint = instrumenting.Middleware("autoscale", "RemovePolicy")(RemovePolicyEndpoint)
+		RemovePolicyEndpoint = logging.Middleware(logger, "autoscale", "RemovePolicy")(RemovePolicyEndpoint)
 	}
-	var InstancesEndpoint endpoint.Endpoint
+
+	var PoliciesEndpoint endpoint.Endpoint
 	{
-		InstancesEndpoint = container.MakeInstancesEndpoint(s)
+		PoliciesEndpoint = autoscale.MakePoliciesEndpoint(s)
+		PoliciesEndpoint = validation.Middleware()(PoliciesEndpoint)
+		PoliciesEndpoint = tracing.Middleware(tracer, "autoscale", "Policies")(PoliciesEndpoint)
+		PoliciesEndpoint = instrumenting.Middleware("autoscale", "Policies")(PoliciesEndpoint)
+		PoliciesEndpoint = logging.Middleware(logger, "autoscale", "Policies")(PoliciesEndpoint)
 	}
-	var StopContainerEndpoint endpoint.Endpoint
+
+	var CreateWindowEndpoint endpoint.Endpoint
 	{
-		StopContainerEndpoint = container.MakeStopContainerEndpoint(s)
+		CreateWindowEndpoint = autoscale.MakeCreateWindowEndpoint(s)
+		CreateWindowEndpoint = validation.Middleware()(CreateWindowEndpoint)
+		CreateWindowEndpoint = tracing.Middleware(tracer, "autoscale", "CreateWindow")(CreateWindowEndpoint)
+		CreateWindowEndpoint = instrumenting.Middleware("autoscale", "CreateWindow")(CreateWindowEndpoint)
+		CreateWindowEndpoint = logging.Middleware(logger, "autoscale", "CreateWindow")(CreateWindowEndpoint)
 	}
-	var ExecuteEndpoint endpoint.Endpoint
+
+	var RemoveWindowEndpoint endpoint.Endpoint
 	{
-		ExecuteEndpoint = container.MakeExecuteEndpoint(s)
+		RemoveWindowEndpoint = autoscale.MakeRemoveWindowEndpoint(s)
+		RemoveWindowEndpoint = validation.Middleware()(RemoveWindowEndpoint)
+		RemoveWindowEndpoint = tracing.Middleware(tracer, "autoscale", "RemoveWindow")(RemoveWindowEndpoint)
+		RemoveWindowEndpoint = instrumenting.Middleware("autoscale", "RemoveWindow")(RemoveWindowEndpoint)
+		RemoveWindowEndpoint = logging.Middleware(logger, "autoscale", "RemoveWindow")(RemoveWindowEndpoint)
 	}
-	var GetEnvEndpoint endpoint.Endpoint
+
+	var WindowsEndpoint endpoint.Endpoint
 	{
-		GetEnvEndpoint = container.MakeGetEnvEndpoint(s)
+		WindowsEndpoint = autoscale.MakeWindowsEndpoint(s)
+		WindowsEndpoint = validation.Middleware()(WindowsEndpoint)
+		WindowsEndpoint = tracing.Middleware(tracer, "autoscale", "Windows")(WindowsEndpoint)
+		WindowsEndpoint = instrumenting.Middleware("autoscale", "Windows")(WindowsEndpoint)
+		WindowsEndpoint = logging.Middleware(logger, "autoscale", "Windows")(WindowsEndpoint)
 	}
-	var SetEnvEndpoint endpoint.Endpoint
+
+	var EventsEndpoint endpoint.Endpoint
 	{
-		SetEnvEndpoint = container.MakeSetEnvEndpoint(s)
+		EventsEndpoint = autoscale.MakeEventsEndpoint(s)
+		EventsEndpoint = validation.Middleware()(EventsEndpoint)
+		EventsEndpoint = tracing.Middleware(tracer, "autoscale", "Events")(EventsEndpoint)
+		EventsEndpoint = instrumenting.Middleware("autoscale", "Events")(EventsEndpoint)
+		EventsEndpoint = logging.Middleware(logger, "autoscale", "Events")(EventsEndpoint)
 	}
-	var IDForNameEndpoint endpoint.Endpoint
+
+	return autoscale.Endpoints{
+		SetPolicyEndpoint:    SetPolicyEndpoint,
+		RemovePolicyEndpoint: RemovePolicyEndpoint,
+		PoliciesEndpoint:     PoliciesEndpoint,
+		CreateWindowEndpoint: CreateWindowEndpoint,
+		RemoveWindowEndpoint: RemoveWindowEndpoint,
+		WindowsEndpoint:      WindowsEndpoint,
+		EventsEndpoint:       EventsEndpoint,
+	}
+}
+
+func makeBanServiceEndpoints(s ban.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) ban.Endpoints {
+	var CreateJailEndpoint endpoint.Endpoint
 	{
-		IDForNameEndpoint = container.MakeIDForNameEndpoint(s)
+		CreateJailEndpoint = ban.MakeCreateJailEndpoint(s)
+		CreateJailEndpoint = validation.Middleware()(CreateJailEndpoint)
+		CreateJailEndpoint = tracing.Middleware(tracer, "ban", "CreateJail")(CreateJailEndpoint)
+		CreateJailEndpoint = instrumenting.Middleware("ban", "CreateJail")(CreateJailEndpoint)
+		CreateJailEndpoint = logging.Middleware(logger, "ban", "CreateJail")(CreateJailEndpoint)
 	}
-	var GetContainerKMIEndpoint endpoint.Endpoint
+
+	var RemoveJailEndpoint endpoint.Endpoint
 	{
-		GetContainerKMIEndpoint = container.MakeGetContainerKMIEndpoint(s)
+		RemoveJailEndpoint = ban.MakeRemoveJailEndpoint(s)
+		RemoveJailEndpoint = validation.Middleware()(RemoveJailEndpoint)
+		RemoveJailEndpoint = tracing.Middleware(tracer, "ban", "RemoveJail")(RemoveJailEndpoint)
+		RemoveJailEndpoint = instrumenting.Middleware("ban", "RemoveJail")(RemoveJailEndpoint)
+		RemoveJailEndpoint = logging.Middleware(logger, "ban", "RemoveJail")(RemoveJailEndpoint)
 	}
-	var SetLinkEndpoint endpoint.Endpoint
+
+	var JailsEndpoint endpoint.Endpoint
 	{
-		SetLinkEndpoint = container.MakeSetLinkEndpoint(s)
+		JailsEndpoint = ban.MakeJailsEndpoint(s)
+		JailsEndpoint = validation.Middleware()(JailsEndpoint)
+		JailsEndpoint = tracing.Middleware(tracer, "ban", "Jails")(JailsEndpoint)
+		JailsEndpoint = instrumenting.Middleware("ban", "Jails")(JailsEndpoint)
+		JailsEndpoint = logging.Middleware(logger, "ban", "Jails")(JailsEndpoint)
 	}
-	var RemoveLinkEndpoint endpoint.Endpoint
+
+	var BansEndpoint endpoint.Endpoint
 	{
-		RemoveLinkEndpoint = container.MakeRemoveLinkEndpoint(s)
+		BansEndpoint = ban.MakeBansEndpoint(s)
+		BansEndpoint = validation.Middleware()(BansEndpoint)
+		BansEndpoint = tracing.Middleware(tracer, "ban", "Bans")(BansEndpoint)
+		BansEndpoint = instrumenting.Middleware("ban", "Bans")(BansEndpoint)
+		BansEndpoint = logging.Middleware(logger, "ban", "Bans")(BansEndpoint)
 	}
-	var GetLinksEndpoint endpoint.Endpoint
+
+	var UnbanEndpoint endpoint.Endpoint
 	{
-		GetLinksEndpoint = container.MakeGetLinksEndpoint(s)
+		UnbanEndpoint = ban.MakeUnbanEndpoint(s)
+		UnbanEndpoint = validation.Middleware()(UnbanEndpoint)
+		UnbanEndpoint = tracing.Middleware(tracer, "ban", "Unban")(UnbanEndpoint)
+		UnbanEndpoint = instrumenting.Middleware("ban", "Unban")(UnbanEndpoint)
+		UnbanEndpoint = logging.Middleware(logger, "ban", "Unban")(UnbanEndpoint)
 	}
 
-	return container.Endpoints{
-		CreateContainerEndpoint: CreateContainerEndpoint,
-		RemoveContainerEndpoint: RemoveContainerEndpoint,
-		InstancesEndpoint:       InstancesEndpoint,
-		StopContainerEndpoint:   StopContainerEndpoint,
-		ExecuteEndpoint:         ExecuteEndpoint,
-		GetEnvEndpoint:          GetEnvEndpoint,
-		SetEnvEndpoint:          SetEnvEndpoint,
-		IDForNameEndpoint:       IDForNameEndpoint,
-		GetContainerKMIEndpoint: GetContainerKMIEndpoint,
-		SetLinkEndpoint:         SetLinkEndpoint,
-		RemoveLinkEndpoint:      RemoveLinkEndpoint,
-		GetLinksEndpoint:        GetLinksEndpoint,
+	return ban.Endpoints{
+		CreateJailEndpoint: CreateJailEndpoint,
+		RemoveJailEndpoint: RemoveJailEndpoint,
+		JailsEndpoint:      JailsEndpoint,
+		BansEndpoint:       BansEndpoint,
+		UnbanEndpoint:      UnbanEndpoint,
 	}
 }
 
-func makeRoutingServiceEndpoints(s routing.Service) routing.Endpoints {
-	var CreateConfigEndpoint endpoint.Endpoint
+func makeWireGuardServiceEndpoints(s wireguard.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) wireguard.Endpoints {
+	var CreatePeerEndpoint endpoint.Endpoint
 	{
-		CreateConfigEndpoint = routing.MakeCreateConfigEndpoint(s)
+		CreatePeerEndpoint = wireguard.MakeCreatePeerEndpoint(s)
+		CreatePeerEndpoint = validation.Middleware()(CreatePeerEndpoint)
+		CreatePeerEndpoint = tracing.Middleware(tracer, "wireguard", "CreatePeer")(CreatePeerEndpoint)
+		CreatePeerEndpoint = instrumenting.Middleware("wireguard", "CreatePeer")(CreatePeerEndpoint)
+		CreatePeerEndpoint = logging.Middleware(logger, "wireguard", "CreatePeer")(CreatePeerEndpoint)
 	}
 
-	var EditConfigEndpoint endpoint.Endpoint
+	var RemovePeerEndpoint endpoint.Endpoint
 	{
-		EditConfigEndpoint = routing.MakeEditConfigEndpoint(s)
+		RemovePeerEndpoint = wireguard.MakeRemovePeerEndpoint(s)
+		RemovePeerEndpoint = validation.Middleware()(RemovePeerEndpoint)
+		RemovePeerEndpoint = tracing.Middleware(tracer, "wireguard", "RemovePeer")(RemovePeerEndpoint)
+		RemovePeerEndpoint = instrumenting.Middleware("wireguard", "RemovePeer")(RemovePeerEndpoint)
+		RemovePeerEndpoint = logging.Middleware(logger, "wireguard", "RemovePeer")(RemovePeerEndpoint)
 	}
 
-	var GetConfigEndpoint endpoint.Endpoint
+	var PeersEndpoint endpoint.Endpoint
 	{
-		GetConfigEndpoint = routing.MakeGetConfigEndpoint(s)
+		PeersEndpoint = wireguard.MakePeersEndpoint(s)
+		PeersEndpoint = validation.Middleware()(PeersEndpoint)
+		PeersEndpoint = tracing.Middleware(tracer, "wireguard", "Peers")(PeersEndpoint)
+		PeersEndpoint = instrumenting.Middleware("wireguard", "Peers")(PeersEndpoint)
+		PeersEndpoint = logging.Middleware(logger, "wireguard", "Peers")(PeersEndpoint)
 	}
 
-	var RemoveConfigEndpoint endpoint.Endpoint
+	var RotateKeyEndpoint endpoint.Endpoint
 	{
-		RemoveConfigEndpoint = routing.MakeRemoveConfigEndpoint(s)
+		RotateKeyEndpoint = wireguard.MakeRotateKeyEndpoint(s)
+		RotateKeyEndpoint = validation.Middleware()(RotateKeyEndpoint)
+		RotateKeyEndpoint = tracing.Middleware(tracer, "wireguard", "RotateKey")(RotateKeyEndpoint)
+		RotateKeyEndpoint = instrumenting.Middleware("wireguard", "RotateKey")(RotateKeyEndpoint)
+		RotateKeyEndpoint = logging.Middleware(logger, "wireguard", "RotateKey")(RotateKeyEndpoint)
 	}
 
-	var AddLocationEndpoint endpoint.Endpoint
+	var SetRulesEndpoint endpoint.Endpoint
 	{
-		AddLocationEndpoint = routing.MakeAddLocationEndpoint(s)
+		SetRulesEndpoint = wireguard.MakeSetRulesEndpoint(s)
+		SetRulesEndpoint = validation.Middleware()(SetRulesEndpoint)
+		SetRulesEndpoint = tracing.Middleware(tracer, "wireguard", "SetRules")(SetRulesEndpoint)
+		SetRulesEndpoint = instrumenting.Middleware("wireguard", "SetRules")(SetRulesEndpoint)
+		SetRulesEndpoint = logging.Middleware(logger, "wireguard", "SetRules")(SetRulesEndpoint)
 	}
 
-	var RemoveLocationEndpoint endpoint.Endpoint
+	return wireguard.Endpoints{
+		CreatePeerEndpoint: CreatePeerEndpoint,
+		RemovePeerEndpoint: RemovePeerEndpoint,
+		PeersEndpoint:      PeersEndpoint,
+		RotateKeyEndpoint:  RotateKeyEndpoint,
+		SetRulesEndpoint:   SetRulesEndpoint,
+	}
+}
+
+func makeResolverServiceEndpoints(s resolver.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) resolver.Endpoints {
+	return resolver.MakeEndpoints(s, serviceMiddleware("resolver", instrumenting, tracer, logger))
+}
+
+func makeUsageServiceEndpoints(s usage.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) usage.Endpoints {
+	return usage.MakeEndpoints(s, serviceMiddleware("usage", instrumenting, tracer, logger))
+}
+
+func makeSiteServiceEndpoints(s site.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) site.Endpoints {
+	var CreateSiteEndpoint endpoint.Endpoint
 	{
-		RemoveLocationEndpoint = routing.MakeRemoveLocationEndpoint(s)
+		CreateSiteEndpoint = site.MakeCreateSiteEndpoint(s)
+		CreateSiteEndpoint = validation.Middleware()(CreateSiteEndpoint)
+		CreateSiteEndpoint = tracing.Middleware(tracer, "site", "CreateSite")(CreateSiteEndpoint)
+		CreateSiteEndpoint = instrumenting.Middleware("site", "CreateSite")(CreateSiteEndpoint)
+		CreateSiteEndpoint = logging.Middleware(logger, "site", "CreateSite")(CreateSiteEndpoint)
 	}
 
-	var ChangeListenStatementEndpoint endpoint.Endpoint
+	var EditSiteEndpoint endpoint.Endpoint
 	{
-		ChangeListenStatementEndpoint = routing.MakeChangeListenStatementEndpoint(s)
+		EditSiteEndpoint = site.MakeEditSiteEndpoint(s)
+		EditSiteEndpoint = validation.Middleware()(EditSiteEndpoint)
+		EditSiteEndpoint = tracing.Middleware(tracer, "site", "EditSite")(EditSiteEndpoint)
+		EditSiteEndpoint = instrumenting.Middleware("site", "EditSite")(EditSiteEndpoint)
+		EditSiteEndpoint = logging.Middleware(logger, "site", "EditSite")(EditSiteEndpoint)
 	}
 
-	var AddServerNameEndpoint endpoint.Endpoint
+	var GetSiteEndpoint endpoint.Endpoint
 	{
-		AddServerNameEndpoint = routing.MakeAddServerNameEndpoint(s)
+		GetSiteEndpoint = site.MakeGetSiteEndpoint(s)
+		GetSiteEndpoint = validation.Middleware()(GetSiteEndpoint)
+		GetSiteEndpoint = tracing.Middleware(tracer, "site", "GetSite")(GetSiteEndpoint)
+		GetSiteEndpoint = instrumenting.Middleware("site", "GetSite")(GetSiteEndpoint)
+		GetSiteEndpoint = logging.Middleware(logger, "site", "GetSite")(GetSiteEndpoint)
 	}
 
-	var RemoveServerNameEndpoint endpoint.Endpoint
+	var SitesEndpoint endpoint.Endpoint
 	{
-		RemoveServerNameEndpoint = routing.MakeRemoveServerNameEndpoint(s)
+		SitesEndpoint = site.MakeSitesEndpoint(s)
+		SitesEndpoint = validation.Middleware()(SitesEndpoint)
+		SitesEndpoint = tracing.Middleware(tracer, "site", "Sites")(SitesEndpoint)
+		SitesEndpoint = instrumenting.Middleware("site", "Sites")(SitesEndpoint)
+		SitesEndpoint = logging.Middleware(logger, "site", "Sites")(SitesEndpoint)
 	}
 
-	var ConfigurationsEndpoint endpoint.Endpoint
+	var RemoveSiteEndpoint endpoint.Endpoint
 	{
-		ConfigurationsEndpoint = routing.MakeConfigurationsEndpoint(s)
+		RemoveSiteEndpoint = site.MakeRemoveSiteEndpoint(s)
+		RemoveSiteEndpoint = validation.Middleware()(RemoveSiteEndpoint)
+		RemoveSiteEndpoint = tracing.Middleware(tracer, "site", "RemoveSite")(RemoveSiteEndpoint)
+		RemoveSiteEndpoint = instrumenting.Middleware("site", "RemoveSite")(RemoveSiteEndpoint)
+		RemoveSiteEndpoint = logging.Middleware(logger, "site", "RemoveSite")(RemoveSiteEndpoint)
 	}
 
-	return routing.Endpoints{
-		CreateConfigEndpoint:          CreateConfigEndpoint,
-		EditConfigEndpoint:            EditConfigEndpoint,
-		GetConfigEndpoint:             GetConfigEndpoint,
-		RemoveConfigEndpoint:          RemoveConfigEndpoint,
-		AddLocationEndpoint:           AddLocationEndpoint,
-		RemoveLocationEndpoint:        RemoveLocationEndpoint,
-		ChangeListenStatementEndpoint: ChangeListenStatementEndpoint,
-		AddServerNameEndpoint:         AddServerNameEndpoint,
-		RemoveServerNameEndpoint:      RemoveServerNameEndpoint,
-		ConfigurationsEndpoint:        ConfigurationsEndpoint,
+	var DeployEndpoint endpoint.Endpoint
+	{
+		DeployEndpoint = site.MakeDeployEndpoint(s)
+		DeployEndpoint = validation.Middleware()(DeployEndpoint)
+		DeployEndpoint = tracing.Middleware(tracer, "site", "Deploy")(DeployEndpoint)
+		DeployEndpoint = instrumenting.Middleware("site", "Deploy")(DeployEndpoint)
+		DeployEndpoint = logging.Middleware(logger, "site", "Deploy")(DeployEndpoint)
 	}
-}
 
-func makeModuleServiceEndpoints(s module.Service) module.Endpoints {
+	return site.Endpoints{
+		CreateSiteEndpoint: CreateSiteEndpoint,
+		EditSiteEndpoint:   EditSiteEndpoint,
+		GetSiteEndpoint:    GetSiteEndpoint,
+		SitesEndpoint:      SitesEndpoint,
+		RemoveSiteEndpoint: RemoveSiteEndpoint,
+		DeployEndpoint:     DeployEndpoint,
+	}
+}
 
-	var CreateContainerModuleEndpoint endpoint.Endpoint
+func makeDeployServiceEndpoints(s deploy.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) deploy.Endpoints {
+	var DeploymentsEndpoint endpoint.Endpoint
 	{
-		CreateContainerModuleEndpoint = module.MakeCreateContainerModuleEndpoint(s)
+		DeploymentsEndpoint = deploy.MakeDeploymentsEndpoint(s)
+		DeploymentsEndpoint = validation.Middleware()(DeploymentsEndpoint)
+		DeploymentsEndpoint = tracing.Middleware(tracer, "deploy", "Deployments")(DeploymentsEndpoint)
+		DeploymentsEndpoint = instrumenting.Middleware("deploy", "Deployments")(DeploymentsEndpoint)
+		DeploymentsEndpoint = logging.Middleware(logger, "deploy", "Deployments")(DeploymentsEndpoint)
 	}
-	var SetPublicKeyEndpoint endpoint.Endpoint
+
+	var LogEndpoint endpoint.Endpoint
 	{
-		SetPublicKeyEndpoint = module.MakeSetPublicKeyEndpoint(s)
+		LogEndpoint = deploy.MakeLogEndpoint(s)
+		LogEndpoint = validation.Middleware()(LogEndpoint)
+		LogEndpoint = tracing.Middleware(tracer, "deploy", "Log")(LogEndpoint)
+		LogEndpoint = instrumenting.Middleware("deploy", "Log")(LogEndpoint)
+		LogEndpoint = logging.Middleware(logger, "deploy", "Log")(LogEndpoint)
 	}
-	var RemoveFileEndpoint endpoint.Endpoint
+
+	var CreateHookEndpoint endpoint.Endpoint
 	{
-		RemoveFileEndpoint = module.MakeRemoveFileEndpoint(s)
+		CreateHookEndpoint = deploy.MakeCreateHookEndpoint(s)
+		CreateHookEndpoint = validation.Middleware()(CreateHookEndpoint)
+		CreateHookEndpoint = tracing.Middleware(tracer, "deploy", "CreateHook")(CreateHookEndpoint)
+		CreateHookEndpoint = instrumenting.Middleware("deploy", "CreateHook")(CreateHookEndpoint)
+		CreateHookEndpoint = logging.Middleware(logger, "deploy", "CreateHook")(CreateHookEndpoint)
 	}
-	var RemoveDirectoryEndpoint endpoint.Endpoint
+
+	var EditHookEndpoint endpoint.Endpoint
 	{
-		RemoveDirectoryEndpoint = module.MakeRemoveDirectoryEndpoint(s)
+		EditHookEndpoint = deploy.MakeEditHookEndpoint(s)
+		EditHookEndpoint = validation.Middleware()(EditHookEndpoint)
+		EditHookEndpoint = tracing.Middleware(tracer, "deploy", "EditHook")(EditHookEndpoint)
+		EditHookEndpoint = instrumenting.Middleware("deploy", "EditHook")(EditHookEndpoint)
+		EditHookEndpoint = logging.Middleware(logger, "deploy", "EditHook")(EditHookEndpoint)
 	}
-	var GetFilesEndpoint endpoint.Endpoint
+
+	var HooksEndpoint endpoint.Endpoint
 	{
-		GetFilesEndpoint = module.MakeGetFilesEndpoint(s)
+		HooksEndpoint = deploy.MakeHooksEndpoint(s)
+		HooksEndpoint = validation.Middleware()(HooksEndpoint)
+		HooksEndpoint = tracing.Middleware(tracer, "deploy", "Hooks")(HooksEndpoint)
+		HooksEndpoint = instrumenting.Middleware("deploy", "Hooks")(HooksEndpoint)
+		HooksEndpoint = logging.Middleware(logger, "deploy", "Hooks")(HooksEndpoint)
 	}
-	var GetFileEndpoint endpoint.Endpoint
+
+	var RemoveHookEndpoint endpoint.Endpoint
 	{
-		GetFileEndpoint = module.MakeGetFileEndpoint(s)
+		RemoveHookEndpoint = deploy.MakeRemoveHookEndpoint(s)
+		RemoveHookEndpoint = validation.Middleware()(RemoveHookEndpoint)
+		RemoveHookEndpoint = tracing.Middleware(tracer, "deploy", "RemoveHook")(RemoveHookEndpoint)
+		RemoveHookEndpoint = instrumenting.Middleware("deploy", "RemoveHook")(RemoveHookEndpoint)
+		RemoveHookEndpoint = logging.Middleware(logger, "deploy", "RemoveHook")(RemoveHookEndpoint)
 	}
-	var UploadFileEndpoint endpoint.Endpoint
+
+	var RollbackEndpoint endpoint.Endpoint
 	{
-		UploadFileEndpoint = module.MakeUploadFileEndpoint(s)
+		RollbackEndpoint = deploy.MakeRollbackEndpoint(s)
+		RollbackEndpoint = validation.Middleware()(RollbackEndpoint)
+		RollbackEndpoint = tracing.Middleware(tracer, "deploy", "Rollback")(RollbackEndpoint)
+		RollbackEndpoint = instrumenting.Middleware("deploy", "Rollback")(RollbackEndpoint)
+		RollbackEndpoint = logging.Middleware(logger, "deploy", "Rollback")(RollbackEndpoint)
 	}
-	var GetModuleConfigEndpoint endpoint.Endpoint
+
+	var GetRetentionEndpoint endpoint.Endpoint
 	{
-		GetModuleConfigEndpoint = module.MakeGetModuleConfigEndpoint(s)
+		GetRetentionEndpoint = deploy.MakeGetRetentionEndpoint(s)
+		GetRetentionEndpoint = validation.Middleware()(GetRetentionEndpoint)
+		GetRetentionEndpoint = tracing.Middleware(tracer, "deploy", "GetRetention")(GetRetentionEndpoint)
+		GetRetentionEndpoint = instrumenting.Middleware("deploy", "GetRetention")(GetRetentionEndpoint)
+		GetRetentionEndpoint = logging.Middleware(logger, "deploy", "GetRetention")(GetRetentionEndpoint)
 	}
-	var SendCommandEndpoint endpoint.Endpoint
+
+	var SetRetentionEndpoint endpoint.Endpoint
 	{
-		SendCommandEndpoint = module.MakeSendCommandEndpoint(s)
+		SetRetentionEndpoint = deploy.MakeSetRetentionEndpoint(s)
+		SetRetentionEndpoint = validation.Middleware()(SetRetentionEndpoint)
+		SetRetentionEndpoint = tracing.Middleware(tracer, "deploy", "SetRetention")(SetRetentionEndpoint)
+		SetRetentionEndpoint = instrumenting.Middleware("deploy", "SetRetention")(SetRetentionEndpoint)
+		SetRetentionEndpoint = logging.Middleware(logger, "deploy", "SetRetention")(SetRetentionEndpoint)
 	}
-	var SetEnvEndpoint endpoint.Endpoint
+
+	var PruneImagesEndpoint endpoint.Endpoint
 	{
-		SetEnvEndpoint = module.MakeSetEnvEndpoint(s)
+		PruneImagesEndpoint = deploy.MakePruneImagesEndpoint(s)
+		PruneImagesEndpoint = validation.Middleware()(PruneImagesEndpoint)
+		PruneImagesEndpoint = tracing.Middleware(tracer, "deploy", "PruneImages")(PruneImagesEndpoint)
+		PruneImagesEndpoint = instrumenting.Middleware("deploy", "PruneImages")(PruneImagesEndpoint)
+		PruneImagesEndpoint = logging.Middleware(logger, "deploy", "PruneImages")(PruneImagesEndpoint)
 	}
-	var GetEnvEndpoint endpoint.Endpoint
+
+	return deploy.Endpoints{
+		DeploymentsEndpoint:  DeploymentsEndpoint,
+		LogEndpoint:          LogEndpoint,
+		CreateHookEndpoint:   CreateHookEndpoint,
+		EditHookEndpoint:     EditHookEndpoint,
+		HooksEndpoint:        HooksEndpoint,
+		RemoveHookEndpoint:   RemoveHookEndpoint,
+		RollbackEndpoint:     RollbackEndpoint,
+		GetRetentionEndpoint: GetRetentionEndpoint,
+		SetRetentionEndpoint: SetRetentionEndpoint,
+		PruneImagesEndpoint:  PruneImagesEndpoint,
+	}
+}
+
+func makeExportServiceEndpoints(s export.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) export.Endpoints {
+	var ExportUserDataEndpoint endpoint.Endpoint
 	{
-		GetEnvEndpoint = module.MakeGetEnvEndpoint(s)
+		ExportUserDataEndpoint = export.MakeExportUserDataEndpoint(s)
+		ExportUserDataEndpoint = validation.Middleware()(ExportUserDataEndpoint)
+		ExportUserDataEndpoint = tracing.Middleware(tracer, "export", "ExportUserData")(ExportUserDataEndpoint)
+		ExportUserDataEndpoint = instrumenting.Middleware("export", "ExportUserData")(ExportUserDataEndpoint)
+		ExportUserDataEndpoint = logging.Middleware(logger, "export", "ExportUserData")(ExportUserDataEndpoint)
 	}
-	var SetLinkEndpoint endpoint.Endpoint
+
+	var ExportsEndpoint endpoint.Endpoint
 	{
-		SetLinkEndpoint = module.MakeSetLinkEndpoint(s)
+		ExportsEndpoint = export.MakeExportsEndpoint(s)
+		ExportsEndpoint = validation.Middleware()(ExportsEndpoint)
+		ExportsEndpoint = tracing.Middleware(tracer, "export", "Exports")(ExportsEndpoint)
+		ExportsEndpoint = instrumenting.Middleware("export", "Exports")(ExportsEndpoint)
+		ExportsEndpoint = logging.Middleware(logger, "export", "Exports")(ExportsEndpoint)
 	}
-	var RemoveLinkEndpoint endpoint.Endpoint
+
+	var DownloadEndpoint endpoint.Endpoint
 	{
-		RemoveLinkEndpoint = module.MakeRemoveLinkEndpoint(s)
+		DownloadEndpoint = export.MakeDownloadEndpoint(s)
+		DownloadEndpoint = validation.Middleware()(DownloadEndpoint)
+		DownloadEndpoint = tracing.Middleware(tracer, "export", "Download")(DownloadEndpoint)
+		DownloadEndpoint = instrumenting.Middleware("export", "Download")(DownloadEndpoint)
+		DownloadEndpoint = logging.Middleware(logger, "export", "Download")(DownloadEndpoint)
 	}
-	var GetModulesEndpoint endpoint.Endpoint
+
+	var DownloadURLEndpoint endpoint.Endpoint
 	{
-		GetModulesEndpoint = module.MakeGetModulesEndpoint(s)
+		DownloadURLEndpoint = export.MakeDownloadURLEndpoint(s)
+		DownloadURLEndpoint = validation.Middleware()(DownloadURLEndpoint)
+		DownloadURLEndpoint = tracing.Middleware(tracer, "export", "DownloadURL")(DownloadURLEndpoint)
+		DownloadURLEndpoint = instrumenting.Middleware("export", "DownloadURL")(DownloadURLEndpoint)
+		DownloadURLEndpoint = logging.Middleware(logger, "export", "DownloadURL")(DownloadURLEndpoint)
 	}
 
-	return module.Endpoints{
-		CreateContainerModuleEndpoint: CreateContainerModuleEndpoint,
-		SetPublicKeyEndpoint:          SetPublicKeyEndpoint,
-		RemoveFileEndpoint:            RemoveFileEndpoint,
-		RemoveDirectoryEndpoint:       RemoveDirectoryEndpoint,
-		GetFilesEndpoint:              GetFilesEndpoint,
-		GetFileEndpoint:               GetFileEndpoint,
-		UploadFileEndpoint:            UploadFileEndpoint,
-		GetModuleConfigEndpoint:       GetModuleConfigEndpoint,
-		SendCommandEndpoint:           SendCommandEndpoint,
-		SetEnvEndpoint:                SetEnvEndpoint,
-		GetEnvEndpoint:                GetEnvEndpoint,
-		SetLinkEndpoint:               SetLinkEndpoint,
-		RemoveLinkEndpoint:            RemoveLinkEndpoint,
-		GetModulesEndpoint:            GetModulesEndpoint,
+	return export.Endpoints{
+		ExportUserDataEndpoint: ExportUserDataEndpoint,
+		ExportsEndpoint:        ExportsEndpoint,
+		DownloadEndpoint:       DownloadEndpoint,
+		DownloadURLEndpoint:    DownloadURLEndpoint,
+	}
+}
+
+func makeManagementServiceEndpoints(s management.Service, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) management.Endpoints {
+	return management.MakeEndpoints(s, serviceMiddleware("management", instrumenting, tracer, logger))
+}
+
+// serviceMiddleware returns the middleware of the methods of a service generated by kroogen, see pkg/codegen
+func serviceMiddleware(service string, instrumenting *metrics.Instrumenting, tracer opentracing.Tracer, logger log.Logger) func(method string, validate bool) endpoint.Middleware {
+	return func(method string, validate bool) endpoint.Middleware {
+		return func(e endpoint.Endpoint) endpoint.Endpoint {
+			if validate {
+				e = validation.Middleware()(e)
+			}
+			e = tracing.Middleware(tracer, service, method)(e)
+			e = instrumenting.Middleware(service, method)(e)
+			return logging.Middleware(logger, service, method)(e)
+		}
 	}
 }
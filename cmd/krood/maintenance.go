@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/admin"
+	"github.com/kontainerooo/kontainer.ooo/pkg/gateway"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kentheguru"
+	kentheguruPB "github.com/kontainerooo/kontainer.ooo/pkg/kentheguru/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/maintenance"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+)
+
+// readOnlyWebsocketMethods are the websocket methods which keep working during maintenance windows
+var readOnlyWebsocketMethods = []string{
+	"KTG",
+	"USR/GET",
+	"KMI/GET",
+	"KMI/ALL",
+	"RTG/GET",
+	"RTG/CON",
+	"RTG/TRF",
+	"RTG/TRR",
+	"DNS/REC",
+	"DNS/CDS",
+	"MDL/GFS",
+	"MDL/GTF",
+	"MDL/GMC",
+	"MDL/GEV",
+	"MDL/GMS",
+	"CNT/ALL",
+	"CNT/GEV",
+	"CNT/IFN",
+	"CNT/GCK",
+	"CNT/GLI",
+	"LOG/SRC",
+	"LOG/TAL",
+}
+
+// readOnlyMethods returns the gRPC methods which keep working during maintenance windows, which are
+// the ones the gateway serves via GET, and the services admins need to end a window, agents need to
+// report and users to log in
+func readOnlyMethods() []string {
+	methods := []string{
+		"admin.AdminService",
+		"agent.AgentService",
+		"kentheguru.KenTheGuruService",
+	}
+	for _, r := range gateway.Routes {
+		if r.Method == "GET" {
+			methods = append(methods, strings.TrimPrefix(r.GRPCMethod, "/"))
+		}
+	}
+	return methods
+}
+
+// maintenanceNotifier passes the notices of the maintenance windows on to the websocket clients,
+// ktg is read when a notice is sent since the windows are loaded before ken the guru is started
+func maintenanceNotifier(ktg *kentheguru.Service) maintenance.Notifier {
+	return func(n maintenance.Notice) {
+		if *ktg == nil {
+			return
+		}
+		(*ktg).Broadcast(ws.ProtoIDFromString("MNT"), &kentheguruPB.MaintenanceNotice{
+			ID:      uint32(n.Window.ID),
+			Start:   admin.Unix(n.Window.Start),
+			End:     admin.Unix(n.Window.End),
+			Message: n.Window.Message,
+			State:   n.State,
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
+
+// deployMethod is the gRPC method the bundles of static sites are deployed with
+const deployMethod = "site.SiteService/Deploy"
+
+// newRequestLimits returns the sizes the requests may have, the deployment of sites may be as large as
+// their bundles unless it is configured
+func newRequestLimits(c config.Config) (payload.Limits, error) {
+	size, err := routing.ParseSize(c.Payloads.MaxSize)
+	if err != nil {
+		return payload.Limits{}, err
+	}
+
+	l := payload.Limits{
+		Max:     int(size),
+		Methods: make(map[string]int),
+	}
+	for method, s := range c.Payloads.Methods {
+		size, err := routing.ParseSize(s)
+		if err != nil {
+			return payload.Limits{}, err
+		}
+		l.Methods[method] = int(size)
+	}
+
+	if _, ok := l.Methods[deployMethod]; !ok && c.Sites.Enabled {
+		size, err := routing.ParseSize(c.Sites.MaxBundleSize)
+		if err != nil {
+			return payload.Limits{}, err
+		}
+		// the bundle is sent together with the name of the site
+		l.Methods[deployMethod] = int(size) + 1<<10
+	}
+	return l, nil
+}
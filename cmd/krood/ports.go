@@ -0,0 +1,40 @@
+package main
+
+import (
+	"github.com/kontainerooo/kontainer.ooo/pkg/ports"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
+
+/* routerPorts reports the ports the router listens on for the routing configurations. The router
+ *  serves the configurations of all users, so their ports belong to the system and are shared by
+ *  the configurations listening on them. */
+type routerPorts struct {
+	routing routing.Service
+}
+
+func (r routerPorts) Ports() ([]ports.Reservation, error) {
+	configs := []routing.RouterConfig{}
+	r.routing.Configurations(&configs)
+
+	used := []ports.Reservation{}
+	for _, c := range configs {
+		if c.ListenStatement == nil || c.ListenStatement.Port == 0 {
+			continue
+		}
+		used = append(used, ports.Reservation{
+			Port:     c.ListenStatement.Port,
+			Protocol: ports.TCP,
+			Owner:    "the router",
+		})
+
+		// plain http requests are redirected on a port of their own
+		if c.HTTPS != nil && c.HTTPS.Redirect != nil && c.HTTPS.Redirect.Port != 0 {
+			used = append(used, ports.Reservation{
+				Port:     c.HTTPS.Redirect.Port,
+				Protocol: ports.TCP,
+				Owner:    "the router",
+			})
+		}
+	}
+	return used, nil
+}
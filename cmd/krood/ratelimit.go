@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ratelimit"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+)
+
+// userPlan returns the rate limit plan of a user, which is the tier of its customer entry.
+// Admins are not limited and users who are no customers get the default plan.
+func userPlan(db abstraction.DB) ratelimit.PlanFunc {
+	return func(id uint) (string, error) {
+		u := user.User{}
+		err := db.First(&u, "ID = ?", id)
+		if err != nil && !db.IsNotFound(err) {
+			return "", err
+		}
+		if u.Admin {
+			return ratelimit.Unlimited, nil
+		}
+
+		c := user.Customer{}
+		err = db.First(&c, "user_id = ?", id)
+		if db.IsNotFound(err) {
+			return ratelimit.DefaultPlan, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(c.Tier), nil
+	}
+}
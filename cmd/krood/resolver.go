@@ -0,0 +1,40 @@
+package main
+
+import (
+	"net"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+)
+
+// resolverNetwork resolves the addresses of the containers and the users the addresses of the bridge networks belong to
+type resolverNetwork struct {
+	network network.Service
+}
+
+func (r resolverNetwork) Address(refID uint, containerID string) (net.IP, bool, error) {
+	as, err := r.network.Assignments()
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, a := range as {
+		if a.RefID == refID && a.ContainerID == containerID {
+			return a.IP.IP(), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (r resolverNetwork) Owner(ip net.IP) (uint, bool, error) {
+	as, err := r.network.Assignments()
+	if err != nil {
+		return 0, false, err
+	}
+
+	for _, a := range as {
+		if a.IP.IP().Equal(ip) {
+			return a.RefID, true, nil
+		}
+	}
+	return 0, false, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/jinzhu/gorm"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/database"
+	"github.com/kontainerooo/kontainer.ooo/pkg/deploy"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+	"github.com/kontainerooo/kontainer.ooo/pkg/webhook"
+)
+
+// encryptedSettings are the settings of the configuration which may be given encrypted
+func encryptedSettings(cfg config.Config) map[string]string {
+	return map[string]string{
+		"registry.password": cfg.Registry.Password,
+		"downloads.secret":  cfg.Downloads.Secret,
+	}
+}
+
+/* runRotation encrypts the secrets in the database again with the current
+ *  master key, or moves them into Vault with the vault backend. Secrets kept in
+ *  plaintext before are included. The master keys which were replaced may be
+ *  removed from the configuration afterwards, unless encrypted settings still
+ *  use them. */
+func runRotation(cfg config.Config, logger log.Logger) error {
+	logger = log.With(logger, "component", "secrets")
+
+	if !cfg.Secrets.Enabled {
+		return fmt.Errorf("secrets are disabled")
+	}
+	if cfg.Database.Mock {
+		return fmt.Errorf("the secrets of a mock database can't be rotated")
+	}
+
+	secrets, err := cfg.SecretBackend()
+	if err != nil {
+		return err
+	}
+
+	db, err := gorm.Open(cfg.Database.Driver, cfg.Database.DSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	dbWrapper := abstraction.NewDB(db)
+
+	for _, r := range []struct {
+		name   string
+		rotate func(abstraction.DB, secret.Backend) (int, error)
+	}{
+		{"webhooks", webhook.RotateSecrets},
+		{"deploy hooks", deploy.RotateSecrets},
+		{"instances", container.RotateSecrets},
+		{"databases", database.RotateSecrets},
+	} {
+		n, err := r.rotate(dbWrapper, secrets)
+		if err != nil {
+			return fmt.Errorf("%s: %v", r.name, err)
+		}
+		level.Info(logger).Log("msg", "rotated", "secrets", r.name, "count", n)
+	}
+
+	current := cfg.Secrets.Current
+	if cfg.Secrets.KMS == secret.AWS {
+		current = cfg.Secrets.AWS.KeyID
+	}
+	for name, v := range encryptedSettings(cfg) {
+		keyID, err := secret.KeyID(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+		if secret.IsEncrypted(v) && keyID != current {
+			level.Warn(logger).Log("msg", "the setting is encrypted with a previous master key, encrypt it again with -encrypt-secret", "setting", name, "key", keyID)
+		}
+	}
+	return nil
+}
+
+// decryptSettings replaces the encrypted settings of cfg with their plaintext, registry.password stays encrypted
+// since the registry cache keeps it sealed
+func decryptSettings(cfg *config.Config, secrets *secret.Store) error {
+	var err error
+	cfg.Downloads.Secret, err = secrets.Decrypt(cfg.Downloads.Secret)
+	if err != nil {
+		return fmt.Errorf("downloads.secret: %v", err)
+	}
+	return nil
+}
+
+// encryptSecret prints value encrypted with the current master key, for settings like registry.password
+func encryptSecret(cfg config.Config, value string) error {
+	secrets, err := cfg.SecretStore()
+	if err != nil {
+		return err
+	}
+	if secrets == nil {
+		return fmt.Errorf("no master keys are configured")
+	}
+
+	encrypted, err := secrets.Encrypt(value)
+	if err != nil {
+		return err
+	}
+	fmt.Println(encrypted)
+	return nil
+}
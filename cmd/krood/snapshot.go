@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+)
+
+/* snapshotVolumes returns the rootfs of a container as its volume, the containers of
+ *  a user are stored in a directory per user below the customer path. */
+type snapshotVolumes struct {
+	containers container.Service
+	root       string
+}
+
+func (v snapshotVolumes) Volume(refID uint, name string) (string, error) {
+	id, err := v.containers.IDForName(context.Background(), refID, name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(v.root, fmt.Sprintf("%d", refID), id, "rootfs"), nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/module"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshkey"
+)
+
+/* sshKeyContainers writes the keys of the users into their containers through the module
+ *  service, which places them as ssh.pub in the module directory of a container. Only
+ *  containers whose KMI declares the ssh interface get the keys. */
+type sshKeyContainers struct {
+	containers container.Endpoints
+	modules    module.Endpoints
+}
+
+func (s sshKeyContainers) SSHContainers(refID uint) ([]string, error) {
+	res, err := s.containers.InstancesEndpoint(context.Background(), container.InstancesRequest{
+		RefID: refID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, c := range res.(container.InstancesResponse).Containers {
+		if _, ok := c.KMI.Interfaces.ToStringMap()[sshkey.SSHInterface]; ok {
+			names = append(names, c.ContainerName)
+		}
+	}
+	return names, nil
+}
+
+func (s sshKeyContainers) SetKeys(refID uint, name string, authorizedKeys string) error {
+	res, err := s.modules.SetPublicKeyEndpoint(context.Background(), module.SetPublicKeyRequest{
+		RefID:         refID,
+		ContainerName: name,
+		Key:           authorizedKeys,
+	})
+	if err != nil {
+		return err
+	}
+	return res.(module.SetPublicKeyResponse).Error
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+// s3Options converts the settings of a bucket, the part size is configured in MiB
+func s3Options(c config.S3) storage.S3Options {
+	return storage.S3Options{
+		Endpoint:   c.Endpoint,
+		Region:     c.Region,
+		Bucket:     c.Bucket,
+		AccessKey:  c.AccessKey,
+		SecretKey:  c.SecretKey,
+		Encryption: c.Encryption,
+		KMSKeyID:   c.KMSKeyID,
+		PartSize:   int64(c.PartSize) << 20,
+	}
+}
+
+// artifactStore returns the store the artifacts of the installation are kept in
+func artifactStore(c config.Storage) (storage.Store, error) {
+	return storage.New(storage.Options{
+		Backend:   c.Backend,
+		LocalPath: c.LocalPath,
+		S3:        s3Options(c.S3),
+	})
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/usage"
+)
+
+// instanceStats returns the resource usage of the instances and replicas running on this node
+func instanceStats(containers container.Service) usage.StatsFunc {
+	return func() ([]usage.Stats, error) {
+		us, err := containers.Usage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+
+		stats := make([]usage.Stats, len(us))
+		for i, u := range us {
+			stats[i] = usage.Stats{
+				RefID:       u.RefID,
+				Instance:    u.ContainerName,
+				CPU:         u.CPU,
+				Memory:      u.Memory,
+				MemoryLimit: u.MemoryLimit,
+				Started:     u.Started,
+			}
+		}
+		return stats, nil
+	}
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
+)
+
+// deprecatedMethods are the gRPC methods which are replaced, their calls announce the successor so
+// clients migrate before the methods are removed. A method whose request or response changes
+// incompatibly is not changed in place, the new one is added next to it, e.g. in a service of the
+// package <service>.v2, and the old one is listed here.
+var deprecatedMethods = versioning.Methods{
+	"user.UserService/CheckLoginCredentials": {Successor: "kentheguru.KenTheGuruService/Authenticate"},
+}
+
+// deprecatedWebsocketProtocols are the websocket protocols which are replaced, the handshake of their
+// connections announces the successor
+var deprecatedWebsocketProtocols = map[string]versioning.Deprecation{}
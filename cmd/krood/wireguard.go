@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	"github.com/kontainerooo/kontainer.ooo/pkg/wireguard"
+)
+
+// wireguardNetwork resolves the bridge networks and the addresses of the instances the VPN peers connect to
+type wireguardNetwork struct {
+	network    network.Service
+	containers container.Service
+}
+
+func (w wireguardNetwork) Bridge(refID uint) (*net.IPNet, string, error) {
+	b, err := w.network.Bridge(refID)
+	if err == network.ErrBridgeNotExist {
+		return nil, "", wireguard.ErrNoNetwork
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, subnet, err := b.Subnet.ParseCIDR()
+	if err != nil {
+		return nil, "", err
+	}
+	return subnet, b.Name, nil
+}
+
+func (w wireguardNetwork) Address(refID uint, instance string) (net.IP, bool, error) {
+	for _, c := range w.containers.Instances(context.Background(), refID) {
+		if c.ContainerName != instance {
+			continue
+		}
+
+		as, err := w.network.Assignments()
+		if err != nil {
+			return nil, false, err
+		}
+		for _, a := range as {
+			if a.RefID == refID && a.ContainerID == c.ContainerID {
+				return a.IP.IP(), true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
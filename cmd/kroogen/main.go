@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/codegen"
+)
+
+// generate writes the generated files of the service of the proto file at source below root
+func generate(root, source string) error {
+	f, err := os.Open(filepath.Join(root, source))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := codegen.Parse(f)
+	if err != nil {
+		return fmt.Errorf("%s: %v", source, err)
+	}
+
+	files, err := codegen.Generate(s, source)
+	if err != nil {
+		return fmt.Errorf("%s: %v", source, err)
+	}
+
+	for _, file := range files {
+		path := filepath.Join(root, file.Path)
+		err = os.MkdirAll(filepath.Dir(path), 0755)
+		if err != nil {
+			return err
+		}
+
+		err = ioutil.WriteFile(path, file.Content, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func main() {
+	var root string
+
+	/* kroogen generates the transports of the services of the given proto files, e.g.
+	 *  `kroogen messages/management.proto`, see pkg/codegen for the directives. */
+	flag.StringVar(&root, "root", ".", "The root of the repository, the paths are relative to it.")
+	flag.Parse()
+
+	for _, source := range flag.Args() {
+		err := generate(root, source)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
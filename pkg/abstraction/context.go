@@ -0,0 +1,115 @@
+package abstraction
+
+import (
+	"context"
+)
+
+// contextDB runs the calls of a DB only while its context is not done, so the remaining queries of
+// an abandoned request fail with the error of the context instead of being run. The transactions
+// are left to the wrapped DB, a rollback after a failed call is always run.
+type contextDB struct {
+	DB
+	ctx context.Context
+}
+
+// Where runs the wrapped Where if the context is not done
+func (c *contextDB) Where(query interface{}, args ...interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.Where(query, args...)
+}
+
+// First runs the wrapped First if the context is not done
+func (c *contextDB) First(out interface{}, where ...interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.First(out, where...)
+}
+
+// Find runs the wrapped Find if the context is not done
+func (c *contextDB) Find(out interface{}, where ...interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.Find(out, where...)
+}
+
+// Create runs the wrapped Create if the context is not done
+func (c *contextDB) Create(value interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.Create(value)
+}
+
+// Delete runs the wrapped Delete if the context is not done
+func (c *contextDB) Delete(value interface{}, where ...interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.Delete(value, where...)
+}
+
+// Update runs the wrapped Update if the context is not done
+func (c *contextDB) Update(model interface{}, attrs ...interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.Update(model, attrs...)
+}
+
+// FindOrdered runs the wrapped FindOrdered if the context is not done
+func (c *contextDB) FindOrdered(out interface{}, order string, limit int, where ...interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.FindOrdered(out, order, limit, where...)
+}
+
+// Sum runs the wrapped Sum if the context is not done
+func (c *contextDB) Sum(model interface{}, column string, out *int64, where ...interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.Sum(model, column, out, where...)
+}
+
+// Pluck runs the wrapped Pluck if the context is not done
+func (c *contextDB) Pluck(model interface{}, column string, out interface{}, where ...interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.Pluck(model, column, out, where...)
+}
+
+// AppendToArray runs the wrapped AppendToArray if the context is not done
+func (c *contextDB) AppendToArray(query interface{}, target string, values interface{}) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.AppendToArray(query, target, values)
+}
+
+// RemoveFromArray runs the wrapped RemoveFromArray if the context is not done
+func (c *contextDB) RemoveFromArray(query interface{}, target string, index int) error {
+	if err := c.ctx.Err(); err != nil {
+		return err
+	}
+	return c.DB.RemoveFromArray(query, target, index)
+}
+
+// WithContext binds the wrapped DB to ctx instead
+func (c *contextDB) WithContext(ctx context.Context) DB {
+	return NewContextDB(c.DB, ctx)
+}
+
+// NewContextDB returns db bound to ctx: once ctx is done, e.g. because the client of a request
+// disconnected or its deadline passed, the calls fail with the error of ctx
+func NewContextDB(db DB, ctx context.Context) DB {
+	return &contextDB{
+		DB:  db,
+		ctx: ctx,
+	}
+}
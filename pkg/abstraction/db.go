@@ -7,6 +7,7 @@
 package abstraction
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
@@ -24,6 +25,9 @@ type DBAdapter interface {
 	GetValue() interface{}
 	GetAffectedRows() int64
 	IsNotFound(error) bool
+
+	// WithContext returns the DB bound to ctx, its calls fail once ctx is done
+	WithContext(ctx context.Context) DB
 }
 
 // DB is an Interface to abstract gorm Database Functions
@@ -36,6 +40,9 @@ type DB interface {
 
 	IsNotFound(error) bool
 
+	// WithContext returns the DB bound to ctx, its calls fail once ctx is done
+	WithContext(ctx context.Context) DB
+
 	AppendToArray(query interface{}, target string, values interface{}) error
 	RemoveFromArray(query interface{}, target string, index int) error
 
@@ -50,6 +57,15 @@ type DB interface {
 	Create(value interface{}) error
 	Delete(value interface{}, where ...interface{}) error
 	Update(model interface{}, attrs ...interface{}) error
+
+	// FindOrdered finds the records matching where sorted by order, at most limit of them if limit is positive
+	FindOrdered(out interface{}, order string, limit int, where ...interface{}) error
+
+	// Sum stores the sum of column over the records of model matching where in out, 0 if there are none
+	Sum(model interface{}, column string, out *int64, where ...interface{}) error
+
+	// Pluck stores column of the records of model matching where in the slice out
+	Pluck(model interface{}, column string, out interface{}, where ...interface{}) error
 }
 
 type dbWrapper struct {
@@ -69,6 +85,10 @@ func (w *dbWrapper) IsNotFound(err error) bool {
 	return err == gorm.ErrRecordNotFound
 }
 
+func (w *dbWrapper) WithContext(ctx context.Context) DB {
+	return NewContextDB(w, ctx)
+}
+
 func (w *dbWrapper) getTableName(v reflect.Value) string {
 	t := v.Type()
 
@@ -298,6 +318,38 @@ func (w *dbWrapper) Update(model interface{}, attrs ...interface{}) error {
 	return w.db.Model(model).Update(attrs...).Error
 }
 
+func (w *dbWrapper) current() *gorm.DB {
+	if w.tx != nil {
+		return w.tx
+	}
+	return w.db
+}
+
+// conditioned applies the inline conditions where to the query of model
+func conditioned(db *gorm.DB, model interface{}, where []interface{}) *gorm.DB {
+	db = db.Model(model)
+	if len(where) > 0 {
+		db = db.Where(where[0], where[1:]...)
+	}
+	return db
+}
+
+func (w *dbWrapper) FindOrdered(out interface{}, order string, limit int, where ...interface{}) error {
+	db := w.current().Order(order)
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+	return db.Find(out, where...).Error
+}
+
+func (w *dbWrapper) Sum(model interface{}, column string, out *int64, where ...interface{}) error {
+	return conditioned(w.current(), model, where).Select(fmt.Sprintf("COALESCE(SUM(%s), 0)", column)).Row().Scan(out)
+}
+
+func (w *dbWrapper) Pluck(model interface{}, column string, out interface{}, where ...interface{}) error {
+	return conditioned(w.current(), model, where).Pluck(column, out).Error
+}
+
 // NewDB returns an new Wrapper instance
 func NewDB(db *gorm.DB) DB {
 	return &dbWrapper{
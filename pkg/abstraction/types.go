@@ -4,8 +4,11 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // JSON is a json abstraction
@@ -19,7 +22,7 @@ func (j JSON) ToStringMap() map[string]string {
 		case string:
 			m[k] = v.(string)
 		case int:
-			m[k] = strconv.FormatInt(v.(int64), 10)
+			m[k] = strconv.Itoa(v.(int))
 		case float64:
 			m[k] = strconv.FormatFloat(v.(float64), 'f', -1, 64)
 		}
@@ -104,3 +107,27 @@ func NewInet(s string) (Inet, error) {
 
 	return "", errors.New("Not a valid IP Address")
 }
+
+// NewInetFromIP creates an Inet from an address and the subnet mask of n, if n is nil the Inet has no subnet mask
+func NewInetFromIP(ip net.IP, n *net.IPNet) Inet {
+	if n == nil {
+		return Inet(ip.String())
+	}
+
+	ones, _ := n.Mask.Size()
+	return Inet(fmt.Sprintf("%s/%d", ip, ones))
+}
+
+// IP returns the address of an Inet without its subnet mask, nil if it is not valid
+func (i Inet) IP() net.IP {
+	s := string(i)
+	if idx := strings.Index(s, "/"); idx != -1 {
+		s = s[:idx]
+	}
+	return net.ParseIP(s)
+}
+
+// ParseCIDR returns the address and network of an Inet with subnet mask
+func (i Inet) ParseCIDR() (net.IP, *net.IPNet, error) {
+	return net.ParseCIDR(string(i))
+}
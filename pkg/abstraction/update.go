@@ -0,0 +1,29 @@
+package abstraction
+
+// Updater is the part of a DB used by UpdateByID, the db adapters of the services implement it
+type Updater interface {
+	Begin()
+	Rollback()
+	Commit()
+	Where(query interface{}, args ...interface{}) error
+	Update(model interface{}, attrs ...interface{}) error
+}
+
+// UpdateByID stores the changed fields of the row id of model in a transaction of db,
+// zero values of changes are not stored
+func UpdateByID(db Updater, model interface{}, id uint, changes interface{}) error {
+	db.Begin()
+	err := db.Where("id = ?", id)
+	if err != nil {
+		db.Rollback()
+		return err
+	}
+
+	err = db.Update(model, changes)
+	if err != nil {
+		db.Rollback()
+		return err
+	}
+	db.Commit()
+	return nil
+}
@@ -0,0 +1,674 @@
+package admin_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/admin"
+	"github.com/kontainerooo/kontainer.ooo/pkg/agent"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/feature"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/maintenance"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockNetwork struct {
+	nodes []network.Node
+	usage map[uint]network.Bytes
+}
+
+func (n *mockNetwork) Nodes() ([]network.Node, error) {
+	return n.nodes, nil
+}
+
+func (n *mockNetwork) TotalUsage(refid uint, from time.Time, to time.Time) (network.Bytes, error) {
+	return n.usage[refid], nil
+}
+
+type recorder struct {
+	mtx    sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) handle(e events.Event) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) commands(topic string) []events.ContainerCommand {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	cs := []events.ContainerCommand{}
+	for _, e := range r.events {
+		if e.Topic != topic {
+			continue
+		}
+		c := events.ContainerCommand{}
+		e.Decode(&c)
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+func (r *recorder) nodes() []events.NodeEvent {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	ns := []events.NodeEvent{}
+	for _, e := range r.events {
+		if e.Topic != events.NodeChanged {
+			continue
+		}
+		n := events.NodeEvent{}
+		e.Decode(&n)
+		ns = append(ns, n)
+	}
+	return ns
+}
+
+func (r *recorder) incidents(topic string) []events.NodeIncident {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	is := []events.NodeIncident{}
+	for _, e := range r.events {
+		if e.Topic != topic {
+			continue
+		}
+		i := events.NodeIncident{}
+		e.Decode(&i)
+		is = append(is, i)
+	}
+	return is
+}
+
+type notifier struct {
+	mtx            sync.Mutex
+	mails          map[uint]mail.DrainData
+	failures       map[uint]mail.FailureData
+	impersonations map[uint]mail.ImpersonationData
+}
+
+func (n *notifier) Notify(refID uint, template string, data interface{}) (uint, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+	switch d := data.(type) {
+	case mail.DrainData:
+		n.mails[refID] = d
+	case mail.FailureData:
+		n.failures[refID] = d
+	case mail.ImpersonationData:
+		n.impersonations[refID] = d
+	}
+	return refID, nil
+}
+
+type mockAgents struct {
+	agents []agent.Agent
+}
+
+func (a *mockAgents) Agents(out *[]agent.Agent) error {
+	*out = append(*out, a.agents...)
+	return nil
+}
+
+var _ = Describe("Admin", func() {
+	var (
+		db     *testutils.MockDB
+		bus    events.Bus
+		rec    *recorder
+		errs   *logging.Recorder
+		queue  *jobs.Queue
+		net    *mockNetwork
+		flags  *feature.Flags
+		mode   *maintenance.Mode
+		imp    *impersonation.Sessions
+		mails  *notifier
+		s      admin.Service
+		logger = log.NewNopLogger()
+	)
+
+	BeforeEach(func() {
+		db = testutils.NewMockDB()
+		Expect(db.AutoMigrate(&admin.Instance{}, &user.User{})).To(Succeed())
+
+		for _, u := range []user.User{{ID: 1, Username: "alice"}, {ID: 2, Username: "bob"}} {
+			u := u
+			Expect(db.Create(&u)).To(Succeed())
+		}
+
+		for _, i := range []admin.Instance{
+			{RefID: 1, ContainerID: "a", ContainerName: "web", KMIID: 7, Replicas: 1, Node: "node1"},
+			{RefID: 1, ContainerID: "a1", ContainerName: "web-replica-0", ReplicaOf: "a", Node: "node1"},
+			{RefID: 1, ContainerID: "b", ContainerName: "db", KMIID: 8, Node: "node1", Pinned: true},
+			{RefID: 2, ContainerID: "c", ContainerName: "app", KMIID: 9, Node: "node2"},
+		} {
+			i := i
+			Expect(db.Create(&i)).To(Succeed())
+		}
+
+		bus = events.NewMemoryBus("node1", time.Millisecond, logger)
+		rec = &recorder{}
+		_, err := bus.Subscribe(events.ContainerCommands, "", rec.handle)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = bus.Subscribe(events.NodeChanged, "", rec.handle)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = bus.Subscribe(events.NodeFailed, "", rec.handle)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = bus.Subscribe(events.NodeRecovered, "", rec.handle)
+		Expect(err).NotTo(HaveOccurred())
+
+		errs = logging.NewRecorder(logger, 10)
+		queue, err = jobs.NewQueue(db, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		net = &mockNetwork{
+			nodes: []network.Node{{Name: "node1"}, {Name: "node2"}, {Name: "node3"}},
+			usage: map[uint]network.Bytes{
+				1: {In: 100, Out: 200},
+			},
+		}
+
+		flags, err = feature.NewFlags(db, nil, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		mode, err = maintenance.NewMode(db, nil, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		mails = &notifier{mails: map[uint]mail.DrainData{}, failures: map[uint]mail.FailureData{}, impersonations: map[uint]mail.ImpersonationData{}}
+		imp, err = impersonation.NewSessions(db, mails, logger)
+		Expect(err).NotTo(HaveOccurred())
+
+		s, err = admin.NewService(db, bus, errs, queue, net, flags, mode, imp, mails)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		bus.Close()
+	})
+
+	Describe("Users", func() {
+		It("Should return the usage of every user", func() {
+			users := []admin.UserUsage{}
+			Expect(s.Users(&users)).To(Succeed())
+			Expect(users).To(Equal([]admin.UserUsage{
+				{ID: 1, Username: "alice", Instances: 3, BytesIn: 100, BytesOut: 200},
+				{ID: 2, Username: "bob", Instances: 1},
+			}))
+		})
+	})
+
+	Describe("Containers", func() {
+		It("Should group the instances by node", func() {
+			nodes := []admin.NodeContainers{}
+			Expect(s.Containers(&nodes)).To(Succeed())
+			Expect(nodes).To(HaveLen(3))
+			Expect(nodes[0].Node).To(Equal("node1"))
+			Expect(nodes[0].Instances).To(HaveLen(3))
+			Expect(nodes[1].Node).To(Equal("node2"))
+			Expect(nodes[1].Instances).To(HaveLen(1))
+			Expect(nodes[2].Node).To(Equal("node3"))
+			Expect(nodes[2].Instances).To(BeEmpty())
+		})
+	})
+
+	Describe("Errors", func() {
+		It("Should return the recorded errors", func() {
+			level.Error(log.With(errs, "service", "routing")).Log("err", "failed")
+			entries := s.Errors(0)
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Module).To(Equal("routing"))
+			Expect(entries[0].Message).To(Equal("failed"))
+		})
+	})
+
+	Describe("QueueDepth", func() {
+		It("Should count the jobs per state", func() {
+			queue.Register("test", jobs.DefaultOptions, func(ctx context.Context, j jobs.Job) error {
+				return nil
+			})
+			_, err := queue.Enqueue("test", nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			depth := []admin.QueueDepth{}
+			Expect(s.QueueDepth(&depth)).To(Succeed())
+			Expect(depth).To(Equal([]admin.QueueDepth{
+				{State: jobs.Pending, Count: 1},
+				{State: jobs.Running},
+				{State: jobs.Succeeded},
+				{State: jobs.Dead},
+			}))
+		})
+	})
+
+	Describe("StopContainer", func() {
+		It("Should send the command to the node of the instance", func() {
+			Expect(s.StopContainer("c")).To(Succeed())
+			Eventually(func() []events.ContainerCommand {
+				return rec.commands(events.StopContainer)
+			}).Should(Equal([]events.ContainerCommand{{Node: "node2", RefID: 2, ContainerID: "c"}}))
+		})
+
+		It("Should return an error for unknown instances", func() {
+			Expect(s.StopContainer("x")).To(Equal(admin.ErrInstanceNotExist))
+		})
+	})
+
+	Describe("ReassignNode", func() {
+		It("Should move an instance to another node", func() {
+			Expect(s.ReassignNode("a", "node3")).To(Succeed())
+			Eventually(func() []events.ContainerCommand {
+				return rec.commands(events.MoveContainer)
+			}).Should(Equal([]events.ContainerCommand{{
+				Node:        "node1",
+				RefID:       1,
+				ContainerID: "a",
+				Name:        "web",
+				KMIID:       7,
+				Replicas:    1,
+				Target:      "node3",
+			}}))
+		})
+
+		It("Should reject invalid moves", func() {
+			Expect(s.ReassignNode("a1", "node3")).To(Equal(admin.ErrReplica))
+			Expect(s.ReassignNode("a", "node1")).To(Equal(admin.ErrSameNode))
+			Expect(s.ReassignNode("a", "node4")).To(Equal(admin.ErrNodeNotExist))
+
+			_, err := s.DrainNode("node3", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.ReassignNode("a", "node3")).To(Equal(admin.ErrNodeDrained))
+		})
+	})
+
+	Describe("DrainNode", func() {
+		It("Should move the instances to the least used nodes", func() {
+			moved, err := s.DrainNode("node1", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(moved).To(BeEquivalentTo(2))
+
+			Eventually(func() []events.ContainerCommand {
+				return rec.commands(events.MoveContainer)
+			}).Should(HaveLen(2))
+
+			targets := map[string]string{}
+			for _, c := range rec.commands(events.MoveContainer) {
+				targets[c.ContainerID] = c.Target
+			}
+			Expect(targets).To(Equal(map[string]string{"a": "node3", "b": "node2"}))
+			Eventually(rec.nodes).Should(Equal([]events.NodeEvent{{Name: "node1", Drained: true}}))
+
+			nodes := []admin.NodeContainers{}
+			Expect(s.Containers(&nodes)).To(Succeed())
+			Expect(nodes[0].Drained).To(BeTrue())
+
+			Expect(mails.mails).To(HaveLen(1))
+			Expect(mails.mails[1].Node).To(Equal("node1"))
+			Expect(mails.mails[1].Instances).To(ConsistOf("web", "db"))
+			Expect(mails.mails[1].Stopped).To(BeFalse())
+		})
+
+		It("Should stop the instances and their replicas", func() {
+			stopped, err := s.DrainNode("node1", true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stopped).To(BeEquivalentTo(3))
+
+			Eventually(func() []events.ContainerCommand {
+				return rec.commands(events.StopContainer)
+			}).Should(HaveLen(3))
+			Expect(rec.commands(events.MoveContainer)).To(BeEmpty())
+			Expect(mails.mails[1].Instances).To(ConsistOf("web", "web-replica-0", "db"))
+			Expect(mails.mails[1].Stopped).To(BeTrue())
+		})
+
+		It("Should stop the instances without another node", func() {
+			_, err := s.DrainNode("node2", false)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = s.DrainNode("node3", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			stopped, err := s.DrainNode("node1", true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stopped).To(BeEquivalentTo(3))
+		})
+
+		It("Should require another node", func() {
+			_, err := s.DrainNode("node2", false)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = s.DrainNode("node3", false)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.DrainNode("node1", false)
+			Expect(err).To(Equal(admin.ErrNoNode))
+			_, err = s.DrainNode("node4", false)
+			Expect(err).To(Equal(admin.ErrNodeNotExist))
+		})
+
+		It("Should undrain a node", func() {
+			Expect(s.UndrainNode("node3")).To(Equal(admin.ErrNotDrained))
+
+			_, err := s.DrainNode("node3", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.UndrainNode("node3")).To(Succeed())
+			Expect(s.ReassignNode("a", "node3")).To(Succeed())
+
+			Expect(s.Announce("node3")).To(Succeed())
+			Eventually(rec.nodes).Should(Equal([]events.NodeEvent{
+				{Name: "node3", Drained: true},
+				{Name: "node3"},
+				{Name: "node3"},
+			}))
+		})
+	})
+
+	Describe("CordonNode", func() {
+		It("Should stop placing instances on a node while they keep running on it", func() {
+			Expect(s.CordonNode("node2")).To(Succeed())
+			Expect(s.CordonNode("node4")).To(Equal(admin.ErrNodeNotExist))
+			Expect(s.ReassignNode("a", "node2")).To(Equal(admin.ErrNodeCordoned))
+			Expect(rec.commands(events.MoveContainer)).To(BeEmpty())
+			Expect(mails.mails).To(BeEmpty())
+
+			nodes := []admin.NodeContainers{}
+			Expect(s.Containers(&nodes)).To(Succeed())
+			Expect(nodes[1].Cordoned).To(BeTrue())
+			Expect(nodes[1].Drained).To(BeFalse())
+			Expect(nodes[1].Instances).To(HaveLen(1))
+
+			Expect(s.UndrainNode("node2")).To(Equal(admin.ErrNotDrained))
+			Expect(s.UncordonNode("node2")).To(Succeed())
+			Expect(s.UncordonNode("node2")).To(Equal(admin.ErrNotCordoned))
+			Eventually(rec.nodes).Should(Equal([]events.NodeEvent{
+				{Name: "node2", Cordoned: true},
+				{Name: "node2"},
+			}))
+		})
+
+		It("Should drain a cordoned node", func() {
+			Expect(s.CordonNode("node1")).To(Succeed())
+			moved, err := s.DrainNode("node1", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(moved).To(BeEquivalentTo(2))
+
+			Expect(s.CordonNode("node1")).To(Equal(admin.ErrNodeDrained))
+			Expect(s.UncordonNode("node1")).To(Equal(admin.ErrNotCordoned))
+
+			Expect(s.Announce("node1")).To(Succeed())
+			Eventually(rec.nodes).Should(Equal([]events.NodeEvent{
+				{Name: "node1", Cordoned: true},
+				{Name: "node1", Drained: true},
+				{Name: "node1", Drained: true},
+			}))
+		})
+	})
+
+	Describe("Placement", func() {
+		BeforeEach(func() {
+			for _, i := range []admin.Instance{
+				{RefID: 1, ContainerID: "a", ContainerName: "web", KMIID: 7, Replicas: 1, Node: "node1", Module: "web", Placement: kmi.Placement{Spread: true}},
+				{RefID: 1, ContainerID: "b", ContainerName: "db", KMIID: 8, Node: "node1", Module: "postgres", Placement: kmi.Placement{Labels: map[string]string{"ssd": "true"}}},
+				{RefID: 1, ContainerID: "d", ContainerName: "web-2", KMIID: 10, Node: "node3", Module: "web", Placement: kmi.Placement{Spread: true}},
+			} {
+				i := i
+				db.Delete(&admin.Instance{ContainerID: i.ContainerID})
+				Expect(db.Create(&i)).To(Succeed())
+			}
+		})
+
+		It("Should label nodes", func() {
+			Expect(s.LabelNode("node2", map[string]string{"ssd": "true", "zone": "a"})).To(Succeed())
+			Expect(s.LabelNode("node2", map[string]string{"zone": ""})).To(Succeed())
+			Expect(s.LabelNode("node4", map[string]string{"ssd": "true"})).To(Equal(admin.ErrNodeNotExist))
+			Expect(s.LabelNode("node2", map[string]string{"-ssd": "true"})).NotTo(Succeed())
+			Expect(s.LabelNode("node2", map[string]string{"kontainer.ooo/gpu": "true"})).To(MatchError("the label kontainer.ooo/gpu is reported by the node itself"))
+
+			nodes := []admin.NodeContainers{}
+			Expect(s.Containers(&nodes)).To(Succeed())
+			Expect(nodes[1].Labels).To(Equal(map[string]string{"ssd": "true"}))
+			Expect(nodes[2].Labels).To(BeEmpty())
+		})
+
+		It("Should move the instances to nodes satisfying their placement", func() {
+			_, err := s.DrainNode("node1", false)
+			Expect(err).To(BeAssignableToTypeOf(&kmi.PlacementError{}))
+			Expect(err.Error()).To(ContainSubstring("instance db"))
+
+			nodes := []admin.NodeContainers{}
+			Expect(s.Containers(&nodes)).To(Succeed())
+			Expect(nodes[0].Drained).To(BeFalse())
+
+			Expect(s.LabelNode("node2", map[string]string{"ssd": "true"})).To(Succeed())
+			moved, err := s.DrainNode("node1", false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(moved).To(BeEquivalentTo(2))
+
+			Eventually(func() []events.ContainerCommand {
+				return rec.commands(events.MoveContainer)
+			}).Should(HaveLen(2))
+
+			targets := map[string]string{}
+			for _, c := range rec.commands(events.MoveContainer) {
+				targets[c.ContainerID] = c.Target
+				if c.ContainerID == "a" {
+					Expect(c.Spread).To(BeTrue())
+				} else {
+					Expect(c.Labels).To(Equal(map[string]string{"ssd": "true"}))
+				}
+			}
+			Expect(targets).To(Equal(map[string]string{"a": "node2", "b": "node2"}))
+		})
+
+		It("Should only reassign an instance to a node satisfying its placement", func() {
+			Expect(s.ReassignNode("a", "node3")).To(BeAssignableToTypeOf(&kmi.PlacementError{}))
+			Expect(s.ReassignNode("b", "node3")).To(BeAssignableToTypeOf(&kmi.PlacementError{}))
+
+			Expect(s.LabelNode("node3", map[string]string{"ssd": "true"})).To(Succeed())
+			Expect(s.ReassignNode("b", "node3")).To(Succeed())
+			Expect(s.ReassignNode("a", "node2")).To(Succeed())
+		})
+
+		It("Should leave the instances of a failed node which fit on no other node", func() {
+			rescheduled, err := s.FailNode("node1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rescheduled).To(BeEquivalentTo(1))
+
+			Eventually(func() []events.ContainerCommand {
+				return rec.commands(events.PlaceContainer)
+			}).Should(HaveLen(1))
+			Expect(rec.commands(events.PlaceContainer)[0].Node).To(Equal("node2"))
+			Expect(mails.failures[1].Lost).To(ConsistOf("db"))
+		})
+	})
+
+	Describe("FailNode", func() {
+		It("Should reschedule the instances which are not pinned", func() {
+			rescheduled, err := s.FailNode("node1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rescheduled).To(BeEquivalentTo(1))
+
+			Eventually(func() []events.ContainerCommand {
+				return rec.commands(events.PlaceContainer)
+			}).Should(Equal([]events.ContainerCommand{{
+				Node:        "node3",
+				RefID:       1,
+				ContainerID: "a",
+				Name:        "web",
+				KMIID:       7,
+				Replicas:    1,
+				Lost:        true,
+			}}))
+			Eventually(func() []events.NodeIncident {
+				return rec.incidents(events.NodeFailed)
+			}).Should(Equal([]events.NodeIncident{{Name: "node1", Rescheduled: 1, Lost: 1}}))
+			Eventually(rec.nodes).Should(Equal([]events.NodeEvent{{Name: "node1", Drained: true}}))
+
+			Expect(mails.failures).To(HaveLen(1))
+			Expect(mails.failures[1].Node).To(Equal("node1"))
+			Expect(mails.failures[1].Rescheduled).To(ConsistOf("web"))
+			Expect(mails.failures[1].Lost).To(ConsistOf("db"))
+
+			nodes := []admin.NodeContainers{}
+			Expect(s.Containers(&nodes)).To(Succeed())
+			Expect(nodes[0].Failed).To(BeTrue())
+
+			_, err = s.FailNode("node1")
+			Expect(err).To(Equal(admin.ErrNodeFailed))
+			_, err = s.DrainNode("node1", false)
+			Expect(err).To(Equal(admin.ErrNodeFailed))
+			Expect(s.UndrainNode("node1")).To(Equal(admin.ErrNotDrained))
+		})
+
+		It("Should recover a failed node", func() {
+			Expect(s.RecoverNode("node2")).To(Equal(admin.ErrNotFailed))
+
+			_, err := s.FailNode("node2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.RecoverNode("node2")).To(Succeed())
+
+			Eventually(func() []events.NodeIncident {
+				return rec.incidents(events.NodeRecovered)
+			}).Should(Equal([]events.NodeIncident{{Name: "node2", Lost: 1}}))
+			Eventually(rec.nodes).Should(Equal([]events.NodeEvent{
+				{Name: "node2", Drained: true},
+				{Name: "node2"},
+			}))
+		})
+	})
+
+	Describe("Rescheduler", func() {
+		It("Should fail the nodes which missed their heartbeats and recover them", func() {
+			now := time.Now()
+			agents := &mockAgents{agents: []agent.Agent{
+				{Name: "node1", LastSeen: now.Add(-10 * time.Minute)},
+				{Name: "node2", LastSeen: now.Add(-time.Minute)},
+				{Name: "node3", Online: true, LastSeen: now},
+			}}
+			r := admin.NewRescheduler(s, agents, 5*time.Minute, logger)
+
+			Expect(r.Check(now)).To(Succeed())
+			Expect(r.Check(now)).To(Succeed())
+			Eventually(func() []events.NodeIncident {
+				return rec.incidents(events.NodeFailed)
+			}).Should(Equal([]events.NodeIncident{{Name: "node1", Rescheduled: 1, Lost: 1}}))
+
+			agents.agents[0].Online = true
+			Expect(r.Check(now)).To(Succeed())
+			Eventually(func() []events.NodeIncident {
+				return rec.incidents(events.NodeRecovered)
+			}).Should(HaveLen(1))
+		})
+	})
+
+	Describe("Flags", func() {
+		It("Should set, list and remove feature flags", func() {
+			Expect(s.SetFlag(&feature.Flag{Name: "scheduler", Percentage: 20})).To(Succeed())
+			Expect(s.SetFlag(&feature.Flag{Name: "nftables", Enabled: true})).To(Succeed())
+			Expect(flags.Enabled("nftables", 1)).To(BeTrue())
+
+			out := []feature.Flag{}
+			Expect(s.Flags(&out)).To(Succeed())
+			Expect(out).To(HaveLen(2))
+			Expect(out[0].Name).To(Equal("nftables"))
+
+			Expect(s.RemoveFlag("nftables")).To(Succeed())
+			Expect(flags.Enabled("nftables", 1)).To(BeFalse())
+			Expect(s.RemoveFlag("nftables")).To(Equal(feature.ErrFlagNotExist))
+		})
+
+		It("Should fail without feature flags", func() {
+			s, err := admin.NewService(db, bus, errs, queue, net, nil, nil, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			out := []feature.Flag{}
+			Expect(s.Flags(&out)).To(Succeed())
+			Expect(out).To(BeEmpty())
+			Expect(s.SetFlag(&feature.Flag{Name: "nftables"})).To(Equal(admin.ErrNoFlags))
+			Expect(s.RemoveFlag("nftables")).To(Equal(admin.ErrNoFlags))
+		})
+	})
+
+	Describe("Maintenance", func() {
+		It("Should schedule, list and cancel maintenance windows", func() {
+			w := &maintenance.Window{Start: time.Now().Add(time.Hour), End: time.Now().Add(2 * time.Hour), Message: "upgrade"}
+			Expect(s.ScheduleMaintenance(w)).To(Succeed())
+			Expect(w.ID).NotTo(BeZero())
+			_, active := mode.Active()
+			Expect(active).To(BeFalse())
+
+			out := []maintenance.Window{}
+			Expect(s.Maintenance(&out)).To(Succeed())
+			Expect(out).To(HaveLen(1))
+			Expect(out[0].Message).To(Equal("upgrade"))
+
+			Expect(s.CancelMaintenance(w.ID)).To(Succeed())
+			Expect(s.CancelMaintenance(w.ID)).To(Equal(maintenance.ErrWindowNotExist))
+		})
+
+		It("Should fail without a maintenance mode", func() {
+			s, err := admin.NewService(db, bus, errs, queue, net, nil, nil, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			out := []maintenance.Window{}
+			Expect(s.Maintenance(&out)).To(Succeed())
+			Expect(out).To(BeEmpty())
+			Expect(s.ScheduleMaintenance(&maintenance.Window{End: time.Now().Add(time.Hour)})).To(Equal(admin.ErrNoMaintenance))
+			Expect(s.CancelMaintenance(1)).To(Equal(admin.ErrNoMaintenance))
+		})
+	})
+
+	Describe("Impersonation", func() {
+		It("Should start, list, record and stop impersonation sessions", func() {
+			session := &impersonation.Session{AdminID: 3, UserID: 2, Reason: "ticket 42"}
+			Expect(s.Impersonate(session, time.Hour)).To(Succeed())
+			Expect(session.ID).NotTo(BeZero())
+			Expect(session.End.Sub(session.Start)).To(Equal(time.Hour))
+			Expect(mails.impersonations[2].Reason).To(Equal("ticket 42"))
+
+			out := []impersonation.Session{}
+			Expect(s.Impersonations(&out)).To(Succeed())
+			Expect(out).To(HaveLen(1))
+
+			Expect(s.StopImpersonation(session.ID)).To(Succeed())
+			Expect(s.StopImpersonation(session.ID)).To(Equal(impersonation.ErrSessionNotExist))
+
+			trail := []impersonation.Entry{}
+			Expect(s.ImpersonationTrail(session.ID, &trail)).To(Succeed())
+			Expect(trail).To(HaveLen(2))
+			Expect(trail[0].Method).To(Equal(impersonation.Started))
+			Expect(trail[1].Method).To(Equal(impersonation.Stopped))
+		})
+
+		It("Should not impersonate admins or unknown users", func() {
+			Expect(db.Create(&user.User{ID: 3, Username: "root", Config: user.Config{Admin: true}})).To(Succeed())
+			Expect(s.Impersonate(&impersonation.Session{AdminID: 3, UserID: 4, Reason: "ticket 42"}, 0)).To(Equal(admin.ErrUserNotExist))
+			Expect(s.Impersonate(&impersonation.Session{AdminID: 1, UserID: 3, Reason: "ticket 42"}, 0)).To(Equal(admin.ErrImpersonateAdmin))
+		})
+
+		It("Should fail without impersonation sessions", func() {
+			s, err := admin.NewService(db, bus, errs, queue, net, nil, nil, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			out := []impersonation.Session{}
+			Expect(s.Impersonations(&out)).To(Succeed())
+			Expect(out).To(BeEmpty())
+			Expect(s.Impersonate(&impersonation.Session{AdminID: 3, UserID: 2, Reason: "ticket 42"}, 0)).To(Equal(admin.ErrNoImpersonation))
+			Expect(s.StopImpersonation(1)).To(Equal(admin.ErrNoImpersonation))
+		})
+	})
+})
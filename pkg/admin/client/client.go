@@ -0,0 +1,740 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/admin"
+	"github.com/kontainerooo/kontainer.ooo/pkg/admin/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/feature"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/maintenance"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *admin.Endpoints {
+
+	var UsersEndpoint endpoint.Endpoint
+	{
+		UsersEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"Users",
+			EncodeGRPCUsersRequest,
+			DecodeGRPCUsersResponse,
+			pb.UsersResponse{},
+		).Endpoint()
+	}
+
+	var ContainersEndpoint endpoint.Endpoint
+	{
+		ContainersEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"Containers",
+			EncodeGRPCContainersRequest,
+			DecodeGRPCContainersResponse,
+			pb.ContainersResponse{},
+		).Endpoint()
+	}
+
+	var ErrorsEndpoint endpoint.Endpoint
+	{
+		ErrorsEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"Errors",
+			EncodeGRPCErrorsRequest,
+			DecodeGRPCErrorsResponse,
+			pb.ErrorsResponse{},
+		).Endpoint()
+	}
+
+	var QueueDepthEndpoint endpoint.Endpoint
+	{
+		QueueDepthEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"QueueDepth",
+			EncodeGRPCQueueDepthRequest,
+			DecodeGRPCQueueDepthResponse,
+			pb.QueueDepthResponse{},
+		).Endpoint()
+	}
+
+	var StopContainerEndpoint endpoint.Endpoint
+	{
+		StopContainerEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"StopContainer",
+			EncodeGRPCStopContainerRequest,
+			DecodeGRPCStopContainerResponse,
+			pb.StopContainerResponse{},
+		).Endpoint()
+	}
+
+	var ReassignNodeEndpoint endpoint.Endpoint
+	{
+		ReassignNodeEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"ReassignNode",
+			EncodeGRPCReassignNodeRequest,
+			DecodeGRPCReassignNodeResponse,
+			pb.ReassignNodeResponse{},
+		).Endpoint()
+	}
+
+	var DrainNodeEndpoint endpoint.Endpoint
+	{
+		DrainNodeEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"DrainNode",
+			EncodeGRPCDrainNodeRequest,
+			DecodeGRPCDrainNodeResponse,
+			pb.DrainNodeResponse{},
+		).Endpoint()
+	}
+
+	var UndrainNodeEndpoint endpoint.Endpoint
+	{
+		UndrainNodeEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"UndrainNode",
+			EncodeGRPCUndrainNodeRequest,
+			DecodeGRPCUndrainNodeResponse,
+			pb.UndrainNodeResponse{},
+		).Endpoint()
+	}
+
+	var CordonNodeEndpoint endpoint.Endpoint
+	{
+		CordonNodeEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"CordonNode",
+			EncodeGRPCCordonNodeRequest,
+			DecodeGRPCCordonNodeResponse,
+			pb.CordonNodeResponse{},
+		).Endpoint()
+	}
+
+	var UncordonNodeEndpoint endpoint.Endpoint
+	{
+		UncordonNodeEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"UncordonNode",
+			EncodeGRPCUncordonNodeRequest,
+			DecodeGRPCUncordonNodeResponse,
+			pb.UncordonNodeResponse{},
+		).Endpoint()
+	}
+
+	var LabelNodeEndpoint endpoint.Endpoint
+	{
+		LabelNodeEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"LabelNode",
+			EncodeGRPCLabelNodeRequest,
+			DecodeGRPCLabelNodeResponse,
+			pb.LabelNodeResponse{},
+		).Endpoint()
+	}
+
+	var FlagsEndpoint endpoint.Endpoint
+	{
+		FlagsEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"Flags",
+			EncodeGRPCFlagsRequest,
+			DecodeGRPCFlagsResponse,
+			pb.FlagsResponse{},
+		).Endpoint()
+	}
+
+	var SetFlagEndpoint endpoint.Endpoint
+	{
+		SetFlagEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"SetFlag",
+			EncodeGRPCSetFlagRequest,
+			DecodeGRPCSetFlagResponse,
+			pb.SetFlagResponse{},
+		).Endpoint()
+	}
+
+	var RemoveFlagEndpoint endpoint.Endpoint
+	{
+		RemoveFlagEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"RemoveFlag",
+			EncodeGRPCRemoveFlagRequest,
+			DecodeGRPCRemoveFlagResponse,
+			pb.RemoveFlagResponse{},
+		).Endpoint()
+	}
+
+	var MaintenanceEndpoint endpoint.Endpoint
+	{
+		MaintenanceEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"Maintenance",
+			EncodeGRPCMaintenanceRequest,
+			DecodeGRPCMaintenanceResponse,
+			pb.MaintenanceResponse{},
+		).Endpoint()
+	}
+
+	var ScheduleMaintenanceEndpoint endpoint.Endpoint
+	{
+		ScheduleMaintenanceEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"ScheduleMaintenance",
+			EncodeGRPCScheduleMaintenanceRequest,
+			DecodeGRPCScheduleMaintenanceResponse,
+			pb.ScheduleMaintenanceResponse{},
+		).Endpoint()
+	}
+
+	var CancelMaintenanceEndpoint endpoint.Endpoint
+	{
+		CancelMaintenanceEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"CancelMaintenance",
+			EncodeGRPCCancelMaintenanceRequest,
+			DecodeGRPCCancelMaintenanceResponse,
+			pb.CancelMaintenanceResponse{},
+		).Endpoint()
+	}
+
+	var ImpersonateEndpoint endpoint.Endpoint
+	{
+		ImpersonateEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"Impersonate",
+			EncodeGRPCImpersonateRequest,
+			DecodeGRPCImpersonateResponse,
+			pb.ImpersonateResponse{},
+		).Endpoint()
+	}
+
+	var StopImpersonationEndpoint endpoint.Endpoint
+	{
+		StopImpersonationEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"StopImpersonation",
+			EncodeGRPCStopImpersonationRequest,
+			DecodeGRPCStopImpersonationResponse,
+			pb.StopImpersonationResponse{},
+		).Endpoint()
+	}
+
+	var ImpersonationsEndpoint endpoint.Endpoint
+	{
+		ImpersonationsEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"Impersonations",
+			EncodeGRPCImpersonationsRequest,
+			DecodeGRPCImpersonationsResponse,
+			pb.ImpersonationsResponse{},
+		).Endpoint()
+	}
+
+	var ImpersonationTrailEndpoint endpoint.Endpoint
+	{
+		ImpersonationTrailEndpoint = grpctransport.NewClient(
+			conn,
+			"admin.AdminService",
+			"ImpersonationTrail",
+			EncodeGRPCImpersonationTrailRequest,
+			DecodeGRPCImpersonationTrailResponse,
+			pb.ImpersonationTrailResponse{},
+		).Endpoint()
+	}
+
+	return &admin.Endpoints{
+		UsersEndpoint:         UsersEndpoint,
+		ContainersEndpoint:    ContainersEndpoint,
+		ErrorsEndpoint:        ErrorsEndpoint,
+		QueueDepthEndpoint:    QueueDepthEndpoint,
+		StopContainerEndpoint: StopContainerEndpoint,
+		ReassignNodeEndpoint:  ReassignNodeEndpoint,
+		DrainNodeEndpoint:     DrainNodeEndpoint,
+		UndrainNodeEndpoint:   UndrainNodeEndpoint,
+		CordonNodeEndpoint:    CordonNodeEndpoint,
+		UncordonNodeEndpoint:  UncordonNodeEndpoint,
+		LabelNodeEndpoint:     LabelNodeEndpoint,
+		FlagsEndpoint:         FlagsEndpoint,
+		SetFlagEndpoint:       SetFlagEndpoint,
+		RemoveFlagEndpoint:    RemoveFlagEndpoint,
+
+		MaintenanceEndpoint:         MaintenanceEndpoint,
+		ScheduleMaintenanceEndpoint: ScheduleMaintenanceEndpoint,
+		CancelMaintenanceEndpoint:   CancelMaintenanceEndpoint,
+
+		ImpersonateEndpoint:        ImpersonateEndpoint,
+		StopImpersonationEndpoint:  StopImpersonationEndpoint,
+		ImpersonationsEndpoint:     ImpersonationsEndpoint,
+		ImpersonationTrailEndpoint: ImpersonationTrailEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCUsersRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain users request to a gRPC Users request.
+func EncodeGRPCUsersRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.UsersRequest)
+	return &pb.UsersRequest{
+		Page: paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCUsersResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Users response to a messages/admin.proto-domain users response.
+func DecodeGRPCUsersResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.UsersResponse)
+	users := make([]admin.UserUsage, len(response.Users))
+	for i, u := range response.Users {
+		users[i] = admin.ConvertPBUserUsage(u)
+	}
+
+	return &admin.UsersResponse{
+		Users: users,
+		Error: getError(response.Error),
+		Page:  paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCContainersRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain containers request to a gRPC Containers request.
+func EncodeGRPCContainersRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.ContainersRequest{}, nil
+}
+
+// DecodeGRPCContainersResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Containers response to a messages/admin.proto-domain containers response.
+func DecodeGRPCContainersResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ContainersResponse)
+	nodes := make([]admin.NodeContainers, len(response.Nodes))
+	for i, n := range response.Nodes {
+		nodes[i] = admin.ConvertPBNodeContainers(n)
+	}
+
+	return &admin.ContainersResponse{
+		Nodes: nodes,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCErrorsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain errors request to a gRPC Errors request.
+func EncodeGRPCErrorsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.ErrorsRequest)
+	return &pb.ErrorsRequest{
+		Limit: uint32(req.Limit),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCErrorsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Errors response to a messages/admin.proto-domain errors response.
+func DecodeGRPCErrorsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ErrorsResponse)
+	entries := make([]logging.Entry, len(response.Errors))
+	for i, e := range response.Errors {
+		entries[i] = logging.Entry{
+			Time:    time.Unix(e.Time, 0).UTC(),
+			Module:  e.Module,
+			Message: e.Message,
+			Record:  e.Record,
+		}
+	}
+
+	return &admin.ErrorsResponse{
+		Errors: entries,
+		Page:   paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCQueueDepthRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain queuedepth request to a gRPC QueueDepth request.
+func EncodeGRPCQueueDepthRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.QueueDepthRequest{}, nil
+}
+
+// DecodeGRPCQueueDepthResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC QueueDepth response to a messages/admin.proto-domain queuedepth response.
+func DecodeGRPCQueueDepthResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.QueueDepthResponse)
+	depth := make([]admin.QueueDepth, len(response.Depth))
+	for i, d := range response.Depth {
+		depth[i] = admin.QueueDepth{
+			State: d.State,
+			Count: uint(d.Count),
+		}
+	}
+
+	return &admin.QueueDepthResponse{
+		Depth: depth,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCStopContainerRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain stopcontainer request to a gRPC StopContainer request.
+func EncodeGRPCStopContainerRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.StopContainerRequest)
+	return &pb.StopContainerRequest{
+		ContainerID: req.ContainerID,
+	}, nil
+}
+
+// DecodeGRPCStopContainerResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC StopContainer response to a messages/admin.proto-domain stopcontainer response.
+func DecodeGRPCStopContainerResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.StopContainerResponse)
+	return &admin.StopContainerResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCReassignNodeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain reassignnode request to a gRPC ReassignNode request.
+func EncodeGRPCReassignNodeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.ReassignNodeRequest)
+	return &pb.ReassignNodeRequest{
+		ContainerID: req.ContainerID,
+		Node:        req.Node,
+	}, nil
+}
+
+// DecodeGRPCReassignNodeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ReassignNode response to a messages/admin.proto-domain reassignnode response.
+func DecodeGRPCReassignNodeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ReassignNodeResponse)
+	return &admin.ReassignNodeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDrainNodeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain drainnode request to a gRPC DrainNode request.
+func EncodeGRPCDrainNodeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.DrainNodeRequest)
+	return &pb.DrainNodeRequest{
+		Node: req.Node,
+		Stop: req.Stop,
+	}, nil
+}
+
+// DecodeGRPCDrainNodeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC DrainNode response to a messages/admin.proto-domain drainnode response.
+func DecodeGRPCDrainNodeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DrainNodeResponse)
+	return &admin.DrainNodeResponse{
+		Moved: uint(response.Moved),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCUndrainNodeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain undrainnode request to a gRPC UndrainNode request.
+func EncodeGRPCUndrainNodeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.UndrainNodeRequest)
+	return &pb.UndrainNodeRequest{
+		Node: req.Node,
+	}, nil
+}
+
+// DecodeGRPCUndrainNodeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC UndrainNode response to a messages/admin.proto-domain undrainnode response.
+func DecodeGRPCUndrainNodeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.UndrainNodeResponse)
+	return &admin.UndrainNodeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCCordonNodeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain cordonnode request to a gRPC CordonNode request.
+func EncodeGRPCCordonNodeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.CordonNodeRequest)
+	return &pb.CordonNodeRequest{
+		Node: req.Node,
+	}, nil
+}
+
+// DecodeGRPCCordonNodeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CordonNode response to a messages/admin.proto-domain cordonnode response.
+func DecodeGRPCCordonNodeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CordonNodeResponse)
+	return &admin.CordonNodeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCUncordonNodeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain uncordonnode request to a gRPC UncordonNode request.
+func EncodeGRPCUncordonNodeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.UncordonNodeRequest)
+	return &pb.UncordonNodeRequest{
+		Node: req.Node,
+	}, nil
+}
+
+// DecodeGRPCUncordonNodeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC UncordonNode response to a messages/admin.proto-domain uncordonnode response.
+func DecodeGRPCUncordonNodeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.UncordonNodeResponse)
+	return &admin.UncordonNodeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCFlagsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain flags request to a gRPC Flags request.
+func EncodeGRPCFlagsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.FlagsRequest{}, nil
+}
+
+// DecodeGRPCFlagsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Flags response to a messages/admin.proto-domain flags response.
+func DecodeGRPCFlagsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.FlagsResponse)
+	flags := make([]feature.Flag, len(response.Flags))
+	for i, f := range response.Flags {
+		flags[i] = admin.ConvertPBFlag(f)
+	}
+
+	return &admin.FlagsResponse{
+		Flags: flags,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetFlagRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain setflag request to a gRPC SetFlag request.
+func EncodeGRPCSetFlagRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.SetFlagRequest)
+	return &pb.SetFlagRequest{
+		Flag: admin.ConvertFlag(req.Flag),
+	}, nil
+}
+
+// DecodeGRPCSetFlagResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetFlag response to a messages/admin.proto-domain setflag response.
+func DecodeGRPCSetFlagResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetFlagResponse)
+	return &admin.SetFlagResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveFlagRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain removeflag request to a gRPC RemoveFlag request.
+func EncodeGRPCRemoveFlagRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.RemoveFlagRequest)
+	return &pb.RemoveFlagRequest{
+		Name: req.Name,
+	}, nil
+}
+
+// DecodeGRPCRemoveFlagResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveFlag response to a messages/admin.proto-domain removeflag response.
+func DecodeGRPCRemoveFlagResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveFlagResponse)
+	return &admin.RemoveFlagResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCMaintenanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain maintenance request to a gRPC Maintenance request.
+func EncodeGRPCMaintenanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.MaintenanceRequest{}, nil
+}
+
+// DecodeGRPCMaintenanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Maintenance response to a messages/admin.proto-domain maintenance response.
+func DecodeGRPCMaintenanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.MaintenanceResponse)
+	windows := make([]maintenance.Window, len(response.Windows))
+	for i, w := range response.Windows {
+		windows[i] = admin.ConvertPBMaintenanceWindow(w)
+	}
+
+	return &admin.MaintenanceResponse{
+		Windows: windows,
+		Error:   getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCScheduleMaintenanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain schedulemaintenance request to a gRPC ScheduleMaintenance request.
+func EncodeGRPCScheduleMaintenanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.ScheduleMaintenanceRequest)
+	return &pb.ScheduleMaintenanceRequest{
+		Start:   admin.Unix(req.Start),
+		End:     admin.Unix(req.End),
+		Message: req.Message,
+	}, nil
+}
+
+// DecodeGRPCScheduleMaintenanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ScheduleMaintenance response to a messages/admin.proto-domain schedulemaintenance response.
+func DecodeGRPCScheduleMaintenanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ScheduleMaintenanceResponse)
+	return &admin.ScheduleMaintenanceResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCCancelMaintenanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain cancelmaintenance request to a gRPC CancelMaintenance request.
+func EncodeGRPCCancelMaintenanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.CancelMaintenanceRequest)
+	return &pb.CancelMaintenanceRequest{
+		ID: uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCCancelMaintenanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CancelMaintenance response to a messages/admin.proto-domain cancelmaintenance response.
+func DecodeGRPCCancelMaintenanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CancelMaintenanceResponse)
+	return &admin.CancelMaintenanceResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCLabelNodeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain labelnode request to a gRPC LabelNode request.
+func EncodeGRPCLabelNodeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.LabelNodeRequest)
+	return &pb.LabelNodeRequest{
+		Node:   req.Node,
+		Labels: req.Labels,
+	}, nil
+}
+
+// DecodeGRPCLabelNodeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC LabelNode response to a messages/admin.proto-domain labelnode response.
+func DecodeGRPCLabelNodeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.LabelNodeResponse)
+	return &admin.LabelNodeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCImpersonateRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain impersonate request to a gRPC Impersonate request.
+func EncodeGRPCImpersonateRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.ImpersonateRequest)
+	return &pb.ImpersonateRequest{
+		UserID:   uint32(req.UserID),
+		Reason:   req.Reason,
+		Duration: int64(req.Duration / time.Second),
+	}, nil
+}
+
+// DecodeGRPCImpersonateResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Impersonate response to a messages/admin.proto-domain impersonate response.
+func DecodeGRPCImpersonateResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ImpersonateResponse)
+	return &admin.ImpersonateResponse{
+		Session: admin.ConvertPBImpersonationSession(response.Session),
+		Error:   getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCStopImpersonationRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain stopimpersonation request to a gRPC StopImpersonation request.
+func EncodeGRPCStopImpersonationRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.StopImpersonationRequest)
+	return &pb.StopImpersonationRequest{
+		ID: uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCStopImpersonationResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC StopImpersonation response to a messages/admin.proto-domain stopimpersonation response.
+func DecodeGRPCStopImpersonationResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.StopImpersonationResponse)
+	return &admin.StopImpersonationResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCImpersonationsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain impersonations request to a gRPC Impersonations request.
+func EncodeGRPCImpersonationsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.ImpersonationsRequest{}, nil
+}
+
+// DecodeGRPCImpersonationsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Impersonations response to a messages/admin.proto-domain impersonations response.
+func DecodeGRPCImpersonationsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ImpersonationsResponse)
+	sessions := make([]impersonation.Session, len(response.Sessions))
+	for i, s := range response.Sessions {
+		sessions[i] = admin.ConvertPBImpersonationSession(s)
+	}
+	return &admin.ImpersonationsResponse{
+		Sessions: sessions,
+		Error:    getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCImpersonationTrailRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain impersonationtrail request to a gRPC ImpersonationTrail request.
+func EncodeGRPCImpersonationTrailRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*admin.ImpersonationTrailRequest)
+	return &pb.ImpersonationTrailRequest{
+		ID:   uint32(req.ID),
+		Page: paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCImpersonationTrailResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ImpersonationTrail response to a messages/admin.proto-domain impersonationtrail response.
+func DecodeGRPCImpersonationTrailResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ImpersonationTrailResponse)
+	entries := make([]impersonation.Entry, len(response.Entries))
+	for i, e := range response.Entries {
+		entries[i] = admin.ConvertPBAuditEntry(e)
+	}
+	return &admin.ImpersonationTrailResponse{
+		Entries: entries,
+		Error:   getError(response.Error),
+		Page:    paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
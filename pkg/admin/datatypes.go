@@ -0,0 +1,85 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+)
+
+// UsageWindow is the period the traffic of users is summed up over
+const UsageWindow = 24 * time.Hour
+
+// UserUsage is a user with the resources they use
+type UserUsage struct {
+	ID        uint
+	Username  string
+	Admin     bool
+	Instances uint
+	// BytesIn and BytesOut are the traffic of the user's containers during the last UsageWindow
+	BytesIn  uint64
+	BytesOut uint64
+}
+
+// Instance is the part of a container's database entry operators see
+type Instance struct {
+	RefID         uint
+	ContainerID   string `gorm:"primary_key"`
+	ContainerName string
+	KMIID         uint
+	ReplicaOf     string
+	Replicas      uint
+	Node          string
+	// Pinned instances are not rescheduled once their node failed
+	Pinned bool
+	// Module is the name of the instance's KMI, the spread instances of a module are placed on different nodes
+	Module    string
+	Placement kmi.Placement `sql:"type:jsonb"`
+}
+
+// TableName sets Instance's database table name to the one of the container service
+func (Instance) TableName() string {
+	return "containers"
+}
+
+// NodeContainers are the instances running on a node, instances created before nodes were named
+// are listed under a node without a name
+type NodeContainers struct {
+	Node      string
+	Drained   bool
+	Cordoned  bool
+	Failed    bool
+	Labels    map[string]string
+	Instances []Instance
+}
+
+// QueueDepth is the number of background jobs in a state
+type QueueDepth struct {
+	State string
+	Count uint
+}
+
+// DrainedNode is a node instances are not placed on, the instances of a cordoned node stay on it
+// while those of a drained node were moved off or stopped and those of a failed node were rescheduled
+type DrainedNode struct {
+	Name     string `gorm:"primary_key"`
+	Since    time.Time
+	Cordoned bool
+	Failed   bool
+}
+
+// TableName sets DrainedNode's database table name
+func (DrainedNode) TableName() string {
+	return "admin_drained_nodes"
+}
+
+// NodeLabel is a label of a node like ssd=true, the container services of the nodes read them as well
+type NodeLabel struct {
+	Node  string `gorm:"primary_key"`
+	Key   string `gorm:"primary_key"`
+	Value string
+}
+
+// TableName sets NodeLabel's database table name
+func (NodeLabel) TableName() string {
+	return "node_labels"
+}
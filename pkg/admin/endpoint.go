@@ -0,0 +1,553 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/bart"
+	"github.com/kontainerooo/kontainer.ooo/pkg/feature"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/maintenance"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the admin service
+type Endpoints struct {
+	UsersEndpoint         endpoint.Endpoint
+	ContainersEndpoint    endpoint.Endpoint
+	ErrorsEndpoint        endpoint.Endpoint
+	QueueDepthEndpoint    endpoint.Endpoint
+	StopContainerEndpoint endpoint.Endpoint
+	ReassignNodeEndpoint  endpoint.Endpoint
+	DrainNodeEndpoint     endpoint.Endpoint
+	UndrainNodeEndpoint   endpoint.Endpoint
+	CordonNodeEndpoint    endpoint.Endpoint
+	UncordonNodeEndpoint  endpoint.Endpoint
+	LabelNodeEndpoint     endpoint.Endpoint
+	FlagsEndpoint         endpoint.Endpoint
+	SetFlagEndpoint       endpoint.Endpoint
+	RemoveFlagEndpoint    endpoint.Endpoint
+
+	MaintenanceEndpoint         endpoint.Endpoint
+	ScheduleMaintenanceEndpoint endpoint.Endpoint
+	CancelMaintenanceEndpoint   endpoint.Endpoint
+
+	ImpersonateEndpoint        endpoint.Endpoint
+	StopImpersonationEndpoint  endpoint.Endpoint
+	ImpersonationsEndpoint     endpoint.Endpoint
+	ImpersonationTrailEndpoint endpoint.Endpoint
+}
+
+// UsersRequest is the request struct for the UsersEndpoint
+type UsersRequest struct {
+	Page paging.Request
+}
+
+// UsersResponse is the response struct for the UsersEndpoint
+type UsersResponse struct {
+	Users []UserUsage
+	Error error
+	Page  paging.Response
+}
+
+// MakeUsersEndpoint creates a gokit endpoint which invokes Users
+func MakeUsersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UsersRequest)
+		users := []UserUsage{}
+		err := s.Users(&users)
+		if err != nil {
+			return UsersResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&users, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return UsersResponse{
+			Users: users,
+			Page:  page,
+		}, nil
+	}
+}
+
+// ContainersRequest is the request struct for the ContainersEndpoint
+type ContainersRequest struct{}
+
+// ContainersResponse is the response struct for the ContainersEndpoint
+type ContainersResponse struct {
+	Nodes []NodeContainers
+	Error error
+}
+
+// MakeContainersEndpoint creates a gokit endpoint which invokes Containers
+func MakeContainersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		nodes := []NodeContainers{}
+		err := s.Containers(&nodes)
+		return ContainersResponse{
+			Nodes: nodes,
+			Error: err,
+		}, nil
+	}
+}
+
+// ErrorsRequest is the request struct for the ErrorsEndpoint
+type ErrorsRequest struct {
+	Limit uint
+	Page  paging.Request
+}
+
+// ErrorsResponse is the response struct for the ErrorsEndpoint
+type ErrorsResponse struct {
+	Errors []logging.Entry
+	Page   paging.Response
+}
+
+// MakeErrorsEndpoint creates a gokit endpoint which invokes Errors
+func MakeErrorsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ErrorsRequest)
+		if req.Page.Sort == "" {
+			// the most recent errors come first unless another order is requested
+			req.Page.Sort = "-Time"
+		}
+
+		entries := s.Errors(req.Limit)
+		page, err := paging.Apply(&entries, "Record", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return ErrorsResponse{
+			Errors: entries,
+			Page:   page,
+		}, nil
+	}
+}
+
+// QueueDepthRequest is the request struct for the QueueDepthEndpoint
+type QueueDepthRequest struct{}
+
+// QueueDepthResponse is the response struct for the QueueDepthEndpoint
+type QueueDepthResponse struct {
+	Depth []QueueDepth
+	Error error
+}
+
+// MakeQueueDepthEndpoint creates a gokit endpoint which invokes QueueDepth
+func MakeQueueDepthEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		depth := []QueueDepth{}
+		err := s.QueueDepth(&depth)
+		return QueueDepthResponse{
+			Depth: depth,
+			Error: err,
+		}, nil
+	}
+}
+
+// StopContainerRequest is the request struct for the StopContainerEndpoint
+type StopContainerRequest struct {
+	ContainerID string `validate:"required"`
+}
+
+// StopContainerResponse is the response struct for the StopContainerEndpoint
+type StopContainerResponse struct {
+	Error error
+}
+
+// MakeStopContainerEndpoint creates a gokit endpoint which invokes StopContainer
+func MakeStopContainerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(StopContainerRequest)
+		err := s.StopContainer(req.ContainerID)
+		return StopContainerResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// ReassignNodeRequest is the request struct for the ReassignNodeEndpoint
+type ReassignNodeRequest struct {
+	ContainerID string `validate:"required"`
+	Node        string `validate:"required,name"`
+}
+
+// ReassignNodeResponse is the response struct for the ReassignNodeEndpoint
+type ReassignNodeResponse struct {
+	Error error
+}
+
+// MakeReassignNodeEndpoint creates a gokit endpoint which invokes ReassignNode
+func MakeReassignNodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ReassignNodeRequest)
+		err := s.ReassignNode(req.ContainerID, req.Node)
+		return ReassignNodeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// DrainNodeRequest is the request struct for the DrainNodeEndpoint
+type DrainNodeRequest struct {
+	Node string `validate:"required,name"`
+	Stop bool
+}
+
+// DrainNodeResponse is the response struct for the DrainNodeEndpoint
+type DrainNodeResponse struct {
+	Moved uint
+	Error error
+}
+
+// MakeDrainNodeEndpoint creates a gokit endpoint which invokes DrainNode
+func MakeDrainNodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DrainNodeRequest)
+		moved, err := s.DrainNode(req.Node, req.Stop)
+		return DrainNodeResponse{
+			Moved: moved,
+			Error: err,
+		}, nil
+	}
+}
+
+// UndrainNodeRequest is the request struct for the UndrainNodeEndpoint
+type UndrainNodeRequest struct {
+	Node string `validate:"required,name"`
+}
+
+// UndrainNodeResponse is the response struct for the UndrainNodeEndpoint
+type UndrainNodeResponse struct {
+	Error error
+}
+
+// MakeUndrainNodeEndpoint creates a gokit endpoint which invokes UndrainNode
+func MakeUndrainNodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UndrainNodeRequest)
+		err := s.UndrainNode(req.Node)
+		return UndrainNodeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// CordonNodeRequest is the request struct for the CordonNodeEndpoint
+type CordonNodeRequest struct {
+	Node string `validate:"required,name"`
+}
+
+// CordonNodeResponse is the response struct for the CordonNodeEndpoint
+type CordonNodeResponse struct {
+	Error error
+}
+
+// MakeCordonNodeEndpoint creates a gokit endpoint which invokes CordonNode
+func MakeCordonNodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CordonNodeRequest)
+		err := s.CordonNode(req.Node)
+		return CordonNodeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// UncordonNodeRequest is the request struct for the UncordonNodeEndpoint
+type UncordonNodeRequest struct {
+	Node string `validate:"required,name"`
+}
+
+// UncordonNodeResponse is the response struct for the UncordonNodeEndpoint
+type UncordonNodeResponse struct {
+	Error error
+}
+
+// MakeUncordonNodeEndpoint creates a gokit endpoint which invokes UncordonNode
+func MakeUncordonNodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UncordonNodeRequest)
+		err := s.UncordonNode(req.Node)
+		return UncordonNodeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// LabelNodeRequest is the request struct for the LabelNodeEndpoint
+type LabelNodeRequest struct {
+	Node   string `validate:"required,name"`
+	Labels map[string]string
+}
+
+// LabelNodeResponse is the response struct for the LabelNodeEndpoint
+type LabelNodeResponse struct {
+	Error error
+}
+
+// MakeLabelNodeEndpoint creates a gokit endpoint which invokes LabelNode
+func MakeLabelNodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(LabelNodeRequest)
+		err := s.LabelNode(req.Node, req.Labels)
+		return LabelNodeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// FlagsRequest is the request struct for the FlagsEndpoint
+type FlagsRequest struct{}
+
+// FlagsResponse is the response struct for the FlagsEndpoint
+type FlagsResponse struct {
+	Flags []feature.Flag
+	Error error
+}
+
+// MakeFlagsEndpoint creates a gokit endpoint which invokes Flags
+func MakeFlagsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		flags := []feature.Flag{}
+		err := s.Flags(&flags)
+		return FlagsResponse{
+			Flags: flags,
+			Error: err,
+		}, nil
+	}
+}
+
+// SetFlagRequest is the request struct for the SetFlagEndpoint
+type SetFlagRequest struct {
+	Flag feature.Flag
+}
+
+// SetFlagResponse is the response struct for the SetFlagEndpoint
+type SetFlagResponse struct {
+	Error error
+}
+
+// MakeSetFlagEndpoint creates a gokit endpoint which invokes SetFlag
+func MakeSetFlagEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetFlagRequest)
+		err := s.SetFlag(&req.Flag)
+		return SetFlagResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RemoveFlagRequest is the request struct for the RemoveFlagEndpoint
+type RemoveFlagRequest struct {
+	Name string `validate:"required,name"`
+}
+
+// RemoveFlagResponse is the response struct for the RemoveFlagEndpoint
+type RemoveFlagResponse struct {
+	Error error
+}
+
+// MakeRemoveFlagEndpoint creates a gokit endpoint which invokes RemoveFlag
+func MakeRemoveFlagEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveFlagRequest)
+		err := s.RemoveFlag(req.Name)
+		return RemoveFlagResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// MaintenanceRequest is the request struct for the MaintenanceEndpoint
+type MaintenanceRequest struct{}
+
+// MaintenanceResponse is the response struct for the MaintenanceEndpoint
+type MaintenanceResponse struct {
+	Windows []maintenance.Window
+	Error   error
+}
+
+// MakeMaintenanceEndpoint creates a gokit endpoint which invokes Maintenance
+func MakeMaintenanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		windows := []maintenance.Window{}
+		err := s.Maintenance(&windows)
+		return MaintenanceResponse{
+			Windows: windows,
+			Error:   err,
+		}, nil
+	}
+}
+
+// ScheduleMaintenanceRequest is the request struct for the ScheduleMaintenanceEndpoint
+type ScheduleMaintenanceRequest struct {
+	Start   time.Time
+	End     time.Time `validate:"required"`
+	Message string
+}
+
+// ScheduleMaintenanceResponse is the response struct for the ScheduleMaintenanceEndpoint
+type ScheduleMaintenanceResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeScheduleMaintenanceEndpoint creates a gokit endpoint which invokes ScheduleMaintenance
+func MakeScheduleMaintenanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ScheduleMaintenanceRequest)
+		w := &maintenance.Window{
+			Start:   req.Start,
+			End:     req.End,
+			Message: req.Message,
+		}
+		err := s.ScheduleMaintenance(w)
+		return ScheduleMaintenanceResponse{
+			ID:    w.ID,
+			Error: err,
+		}, nil
+	}
+}
+
+// CancelMaintenanceRequest is the request struct for the CancelMaintenanceEndpoint
+type CancelMaintenanceRequest struct {
+	ID uint `validate:"required"`
+}
+
+// CancelMaintenanceResponse is the response struct for the CancelMaintenanceEndpoint
+type CancelMaintenanceResponse struct {
+	Error error
+}
+
+// MakeCancelMaintenanceEndpoint creates a gokit endpoint which invokes CancelMaintenance
+func MakeCancelMaintenanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CancelMaintenanceRequest)
+		err := s.CancelMaintenance(req.ID)
+		return CancelMaintenanceResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// ImpersonateRequest is the request struct for the ImpersonateEndpoint, the admin is the caller
+type ImpersonateRequest struct {
+	UserID   uint   `validate:"required"`
+	Reason   string `validate:"required"`
+	Duration time.Duration
+}
+
+// ImpersonateResponse is the response struct for the ImpersonateEndpoint
+type ImpersonateResponse struct {
+	Session impersonation.Session
+	Error   error
+}
+
+// MakeImpersonateEndpoint creates a gokit endpoint which invokes Impersonate
+func MakeImpersonateEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ImpersonateRequest)
+		adminID, ok := bart.Caller(ctx)
+		if !ok {
+			return ImpersonateResponse{
+				Error: ErrUnknownAdmin,
+			}, nil
+		}
+
+		session := &impersonation.Session{
+			AdminID: adminID,
+			UserID:  req.UserID,
+			Reason:  req.Reason,
+		}
+		err := s.Impersonate(session, req.Duration)
+		return ImpersonateResponse{
+			Session: *session,
+			Error:   err,
+		}, nil
+	}
+}
+
+// StopImpersonationRequest is the request struct for the StopImpersonationEndpoint
+type StopImpersonationRequest struct {
+	ID uint `validate:"required"`
+}
+
+// StopImpersonationResponse is the response struct for the StopImpersonationEndpoint
+type StopImpersonationResponse struct {
+	Error error
+}
+
+// MakeStopImpersonationEndpoint creates a gokit endpoint which invokes StopImpersonation
+func MakeStopImpersonationEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(StopImpersonationRequest)
+		err := s.StopImpersonation(req.ID)
+		return StopImpersonationResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// ImpersonationsRequest is the request struct for the ImpersonationsEndpoint
+type ImpersonationsRequest struct{}
+
+// ImpersonationsResponse is the response struct for the ImpersonationsEndpoint
+type ImpersonationsResponse struct {
+	Sessions []impersonation.Session
+	Error    error
+}
+
+// MakeImpersonationsEndpoint creates a gokit endpoint which invokes Impersonations
+func MakeImpersonationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		sessions := []impersonation.Session{}
+		err := s.Impersonations(&sessions)
+		return ImpersonationsResponse{
+			Sessions: sessions,
+			Error:    err,
+		}, nil
+	}
+}
+
+// ImpersonationTrailRequest is the request struct for the ImpersonationTrailEndpoint
+type ImpersonationTrailRequest struct {
+	ID   uint
+	Page paging.Request
+}
+
+// ImpersonationTrailResponse is the response struct for the ImpersonationTrailEndpoint
+type ImpersonationTrailResponse struct {
+	Entries []impersonation.Entry
+	Error   error
+	Page    paging.Response
+}
+
+// MakeImpersonationTrailEndpoint creates a gokit endpoint which invokes ImpersonationTrail
+func MakeImpersonationTrailEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ImpersonationTrailRequest)
+		entries := []impersonation.Entry{}
+		err := s.ImpersonationTrail(req.ID, &entries)
+		if err != nil {
+			return ImpersonationTrailResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&entries, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return ImpersonationTrailResponse{
+			Entries: entries,
+			Page:    page,
+		}, nil
+	}
+}
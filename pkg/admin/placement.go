@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+)
+
+// nodeLabels returns the labels of the labeled nodes by their name
+func (s *service) nodeLabels() (map[string]map[string]string, error) {
+	ls := []NodeLabel{}
+	err := s.db.Find(&ls)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]map[string]string)
+	for _, l := range ls {
+		if labels[l.Node] == nil {
+			labels[l.Node] = make(map[string]string)
+		}
+		labels[l.Node][l.Key] = l.Value
+	}
+	return labels, nil
+}
+
+func (s *service) LabelNode(node string, labels map[string]string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.labelNode(node, labels)
+}
+
+func (s *service) labelNode(node string, labels map[string]string) error {
+	exists, err := s.nodeExists(node)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNodeNotExist
+	}
+
+	for k, v := range labels {
+		err = kmi.ValidateLabel(k, v)
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(k, kmi.RuntimeLabelPrefix) {
+			return fmt.Errorf("the label %s is reported by the node itself", k)
+		}
+	}
+
+	current, err := s.nodeLabels()
+	if err != nil {
+		return err
+	}
+
+	s.db.Begin()
+	for k, v := range labels {
+		if _, ok := current[node][k]; ok {
+			err = s.db.Delete(&NodeLabel{Node: node, Key: k})
+			if err != nil {
+				s.db.Rollback()
+				return err
+			}
+		}
+		if v == "" {
+			continue
+		}
+
+		err = s.db.Create(&NodeLabel{Node: node, Key: k, Value: v})
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+	}
+	s.db.Commit()
+	return nil
+}
+
+// placer tracks where instances are placed while they are moved off a node, so the spread instances
+// of a module stay on different nodes
+type placer struct {
+	labels map[string]map[string]string
+	// spread is set for the nodes running an instance of a module of a user
+	spread map[string]bool
+}
+
+func spreadKey(refID uint, module string, node string) string {
+	return fmt.Sprintf("%d/%s/%s", refID, module, node)
+}
+
+// placer returns a placer for the current labels and instances
+func (s *service) placer() (*placer, error) {
+	labels, err := s.nodeLabels()
+	if err != nil {
+		return nil, err
+	}
+
+	is, err := s.instances()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &placer{
+		labels: labels,
+		spread: make(map[string]bool),
+	}
+	for _, i := range is {
+		if i.ReplicaOf == "" {
+			p.spread[spreadKey(i.RefID, i.Module, i.Node)] = true
+		}
+	}
+	return p, nil
+}
+
+// fits returns a kmi.PlacementError if the placement of i does not allow it on node
+func (p *placer) fits(i Instance, node string) error {
+	if l := i.Placement.Missing(p.labels[node]); l != "" {
+		return &kmi.PlacementError{
+			Instance: i.ContainerName,
+			Node:     node,
+			Reason:   fmt.Sprintf("the node lacks the label %s", l),
+		}
+	}
+	if i.Placement.Spread && p.spread[spreadKey(i.RefID, i.Module, node)] {
+		return &kmi.PlacementError{
+			Instance: i.ContainerName,
+			Node:     node,
+			Reason:   fmt.Sprintf("the node runs another instance of %s, whose instances are spread across nodes", i.Module),
+		}
+	}
+	return nil
+}
+
+// target returns the least used of the nodes in load the placement of i allows and records i on it,
+// a kmi.PlacementError is returned if it allows none of them
+func (p *placer) target(i Instance, load map[string]uint) (string, error) {
+	allowed := make(map[string]uint)
+	for n, l := range load {
+		if p.fits(i, n) == nil {
+			allowed[n] = l
+		}
+	}
+	if len(allowed) == 0 {
+		reason := "no node accepts new instances"
+		if len(load) != 0 {
+			reason = fmt.Sprintf("no node accepting new instances satisfies its placement %s", i.Placement)
+		}
+		return "", &kmi.PlacementError{
+			Instance: i.ContainerName,
+			Reason:   reason,
+		}
+	}
+
+	target := leastUsed(allowed)
+	p.spread[spreadKey(i.RefID, i.Module, target)] = true
+	return target, nil
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/agent"
+)
+
+// Agents lists the agents of the worker nodes, it is satisfied by the agent service
+type Agents interface {
+	Agents(out *[]agent.Agent) error
+}
+
+// Rescheduler fails the nodes whose agent did not send a heartbeat for longer than after, so their instances
+// are placed on other nodes, and recovers them once they send heartbeats again. It runs on the control plane.
+type Rescheduler struct {
+	s      Service
+	agents Agents
+	after  time.Duration
+	logger log.Logger
+}
+
+// Check fails and recovers the nodes of the agents per their last heartbeat before now
+func (r *Rescheduler) Check(now time.Time) error {
+	as := []agent.Agent{}
+	err := r.agents.Agents(&as)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range as {
+		if a.Online {
+			err = r.s.RecoverNode(a.Name)
+			if err == nil {
+				level.Info(r.logger).Log("node", a.Name, "msg", "recovered")
+			} else if err != ErrNotFailed {
+				level.Error(r.logger).Log("node", a.Name, "err", err)
+			}
+			continue
+		}
+
+		if now.Sub(a.LastSeen) < r.after {
+			continue
+		}
+		n, err := r.s.FailNode(a.Name)
+		if err == ErrNodeFailed {
+			continue
+		}
+		if err != nil {
+			level.Error(r.logger).Log("node", a.Name, "rescheduled", n, "err", err)
+			continue
+		}
+		level.Warn(r.logger).Log("node", a.Name, "msg", "failed", "last_seen", a.LastSeen, "rescheduled", n)
+	}
+	return nil
+}
+
+// Run calls Check every interval until stop is closed
+func (r *Rescheduler) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case now := <-t.C:
+			err := r.Check(now)
+			if err != nil {
+				level.Error(r.logger).Log("err", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewRescheduler returns a Rescheduler failing the nodes of the agents which did not send a heartbeat for after
+func NewRescheduler(s Service, agents Agents, after time.Duration, logger log.Logger) *Rescheduler {
+	return &Rescheduler{
+		s:      s,
+		agents: agents,
+		after:  after,
+		logger: logger,
+	}
+}
@@ -0,0 +1,1095 @@
+// Package admin gives operators views of the whole platform and lets them move instances between nodes
+package admin
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/feature"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/maintenance"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+)
+
+var (
+	// ErrInstanceNotExist occurs if an instance does not exist
+	ErrInstanceNotExist = errors.New("instance does not exist")
+
+	// ErrReplica occurs if a replica should be moved, replicas are moved with their instance
+	ErrReplica = errors.New("replicas are moved with their instance")
+
+	// ErrNodeNotExist occurs if a node is not registered
+	ErrNodeNotExist = errors.New("node does not exist")
+
+	// ErrNodeDrained occurs if an instance should be moved to a drained node
+	ErrNodeDrained = errors.New("node is drained")
+
+	// ErrNotDrained occurs if a node which is not drained should be undrained
+	ErrNotDrained = errors.New("node is not drained")
+
+	// ErrNodeCordoned occurs if an instance should be moved to a cordoned node
+	ErrNodeCordoned = errors.New("node is cordoned")
+
+	// ErrNotCordoned occurs if a node which is not cordoned should be uncordoned
+	ErrNotCordoned = errors.New("node is not cordoned")
+
+	// ErrNodeFailed occurs if an instance should be moved to a failed node or a failed node is drained
+	ErrNodeFailed = errors.New("node failed")
+
+	// ErrNotFailed occurs if a node which did not fail should be recovered
+	ErrNotFailed = errors.New("node did not fail")
+
+	// ErrSameNode occurs if an instance should be moved to the node it runs on
+	ErrSameNode = errors.New("instance runs on the node already")
+
+	// ErrNoNode occurs if a node is drained without another node to move its instances to
+	ErrNoNode = errors.New("no node to move the instances to")
+
+	// ErrNoFlags occurs if feature flags are changed while the daemon does not store them
+	ErrNoFlags = errors.New("feature flags are not available")
+
+	// ErrNoMaintenance occurs if maintenance windows are changed while the daemon does not store them
+	ErrNoMaintenance = errors.New("maintenance windows are not available")
+
+	// ErrNoImpersonation occurs if a user is impersonated while the daemon does not store impersonation sessions
+	ErrNoImpersonation = errors.New("impersonation is not available")
+
+	// ErrUnknownAdmin occurs if a user is impersonated by a call which was not authenticated
+	ErrUnknownAdmin = errors.New("the impersonating admin is unknown")
+
+	// ErrUserNotExist occurs if a user who does not exist should be impersonated
+	ErrUserNotExist = errors.New("user does not exist")
+
+	// ErrImpersonateAdmin occurs if an admin should be impersonated, the calls made as them would not be limited
+	ErrImpersonateAdmin = errors.New("admins can't be impersonated")
+)
+
+// Service AdminService
+type Service interface {
+	// Users returns every user with the number of their instances and their traffic of the last UsageWindow
+	Users(u *[]UserUsage) error
+
+	// Containers returns the instances of all users grouped by the node they run on
+	Containers(n *[]NodeContainers) error
+
+	// Errors returns the most recent errors logged by the daemon, the newest first. All recorded errors
+	// are returned if limit is zero.
+	Errors(limit uint) []logging.Entry
+
+	// QueueDepth returns the number of background jobs in every state
+	QueueDepth(q *[]QueueDepth) error
+
+	// StopContainer stops a container on the node it runs on
+	StopContainer(containerID string) error
+
+	// ReassignNode moves an instance and its replicas to another node, the files of the instance are not moved
+	ReassignNode(containerID string, node string) error
+
+	// DrainNode moves every instance off a node, or stops them if stop is set, and rejects new instances on it.
+	// The owners of the instances are notified, it returns the number of moved or stopped instances.
+	DrainNode(node string, stop bool) (uint, error)
+
+	// UndrainNode accepts new instances on a drained node again
+	UndrainNode(node string) error
+
+	// CordonNode rejects new instances on a node, the instances running on it stay
+	CordonNode(node string) error
+
+	// UncordonNode accepts new instances on a cordoned node again
+	UncordonNode(node string) error
+
+	// FailNode marks a node which stopped sending heartbeats failed and places its instances which are not
+	// pinned on other nodes, the owners are notified. It returns the number of rescheduled instances.
+	FailNode(node string) (uint, error)
+
+	// RecoverNode accepts new instances on a failed node again once it sends heartbeats again
+	RecoverNode(node string) error
+
+	// LabelNode sets labels of a node, which the placement of modules requires, labels set to an empty value are removed.
+	// The labels prefixed with kmi.RuntimeLabelPrefix describe the container runtime of a node and are set by the node.
+	LabelNode(node string, labels map[string]string) error
+
+	// Announce publishes whether a node is drained or cordoned, so the services of a node learn about it after a restart
+	Announce(node string) error
+
+	// Flags returns every feature flag ordered by name
+	Flags(out *[]feature.Flag) error
+
+	// SetFlag stores a feature flag, the flag with the same name is replaced
+	SetFlag(f *feature.Flag) error
+
+	// RemoveFlag deletes a feature flag, the feature is turned off for every user
+	RemoveFlag(name string) error
+
+	// Maintenance returns the maintenance windows which did not end yet ordered by their start
+	Maintenance(out *[]maintenance.Window) error
+
+	// ScheduleMaintenance stores a maintenance window, while it is active only reading calls are accepted
+	ScheduleMaintenance(w *maintenance.Window) error
+
+	// CancelMaintenance removes a maintenance window, an active window ends right away
+	CancelMaintenance(id uint) error
+
+	// Impersonate starts a session of the admin AdminID in which they make calls as the user UserID, which is
+	// notified. The session lasts d, or impersonation.DefaultDuration if d is zero.
+	Impersonate(session *impersonation.Session, d time.Duration) error
+
+	// StopImpersonation ends an impersonation session right away
+	StopImpersonation(id uint) error
+
+	// Impersonations returns the impersonation sessions which did not end yet ordered by their start
+	Impersonations(out *[]impersonation.Session) error
+
+	// ImpersonationTrail returns the audit trail of an impersonation session, or of every session if id is zero
+	ImpersonationTrail(id uint, out *[]impersonation.Entry) error
+}
+
+// ErrorLog keeps the recent errors of the daemon
+type ErrorLog interface {
+	Entries(limit int) []logging.Entry
+}
+
+// JobQueue lists the background jobs of the daemon
+type JobQueue interface {
+	Jobs(state string, out *[]jobs.Job) error
+}
+
+// Notifier sends the emails of drained and failed nodes to the owners of their instances, it is satisfied by the mail service
+type Notifier interface {
+	Notify(refID uint, template string, data interface{}) (uint, error)
+}
+
+// Network lists the nodes and meters the traffic of users, it is the network service if the daemon runs one
+type Network interface {
+	Nodes() ([]network.Node, error)
+	TotalUsage(refid uint, from time.Time, to time.Time) (network.Bytes, error)
+}
+
+// FeatureFlags stores the feature flags, it is the feature.Flags of the daemon
+type FeatureFlags interface {
+	Flags(out *[]feature.Flag) error
+	Set(f *feature.Flag) error
+	Remove(name string) error
+}
+
+// MaintenanceMode stores the maintenance windows, it is the maintenance.Mode of the daemon
+type MaintenanceMode interface {
+	Windows(out *[]maintenance.Window) error
+	Schedule(w *maintenance.Window) error
+	Cancel(id uint) error
+}
+
+// Impersonations stores the impersonation sessions and their audit trail, it is the impersonation.Sessions of the daemon
+type Impersonations interface {
+	Start(session *impersonation.Session, d time.Duration) error
+	Stop(id uint) error
+	Active(out *[]impersonation.Session) error
+	Trail(id uint, out *[]impersonation.Entry) error
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	bus     events.Bus
+	errors  ErrorLog
+	queue   JobQueue
+	network Network
+	flags   FeatureFlags
+	mode    MaintenanceMode
+	imp     Impersonations
+	mails   Notifier
+	mtx     *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&DrainedNode{}, &NodeLabel{})
+}
+
+func (s *service) instances() ([]Instance, error) {
+	is := []Instance{}
+	err := s.db.Find(&is)
+	if err != nil {
+		return nil, err
+	}
+	return is, nil
+}
+
+func (s *service) getInstance(containerID string) (Instance, error) {
+	is, err := s.instances()
+	if err != nil {
+		return Instance{}, err
+	}
+
+	for _, i := range is {
+		if i.ContainerID == containerID {
+			return i, nil
+		}
+	}
+	return Instance{}, ErrInstanceNotExist
+}
+
+// drainedNodes returns the drained and cordoned nodes by their name
+func (s *service) drainedNodes() (map[string]DrainedNode, error) {
+	ds := []DrainedNode{}
+	err := s.db.Find(&ds)
+	if err != nil {
+		return nil, err
+	}
+
+	drained := make(map[string]DrainedNode)
+	for _, d := range ds {
+		drained[d.Name] = d
+	}
+	return drained, nil
+}
+
+// unschedulable returns the error of placing instances on the node d
+func unschedulable(d DrainedNode) error {
+	if d.Cordoned {
+		return ErrNodeCordoned
+	}
+	if d.Failed {
+		return ErrNodeFailed
+	}
+	return ErrNodeDrained
+}
+
+// leastUsed returns the node running the fewest instances, nodes running as many are chosen by their name
+func leastUsed(load map[string]uint) string {
+	target := ""
+	for n, l := range load {
+		if target == "" || l < load[target] || (l == load[target] && n < target) {
+			target = n
+		}
+	}
+	return target
+}
+
+func (s *service) nodes() ([]network.Node, error) {
+	if s.network == nil {
+		return nil, nil
+	}
+	return s.network.Nodes()
+}
+
+func (s *service) nodeExists(name string) (bool, error) {
+	nodes, err := s.nodes()
+	if err != nil {
+		return false, err
+	}
+
+	for _, n := range nodes {
+		if n.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *service) Users(u *[]UserUsage) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.users(u)
+}
+
+func (s *service) users(u *[]UserUsage) error {
+	us := []user.User{}
+	err := s.db.Find(&us)
+	if err != nil {
+		return err
+	}
+
+	is, err := s.instances()
+	if err != nil {
+		return err
+	}
+
+	count := make(map[uint]uint)
+	for _, i := range is {
+		count[i.RefID]++
+	}
+
+	to := time.Now()
+	from := to.Add(-UsageWindow)
+
+	usage := make([]UserUsage, 0, len(us))
+	for _, usr := range us {
+		uu := UserUsage{
+			ID:        usr.ID,
+			Username:  usr.Username,
+			Admin:     usr.Admin,
+			Instances: count[usr.ID],
+		}
+
+		if s.network != nil {
+			b, err := s.network.TotalUsage(usr.ID, from, to)
+			if err != nil {
+				return err
+			}
+			uu.BytesIn, uu.BytesOut = b.In, b.Out
+		}
+
+		usage = append(usage, uu)
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].ID < usage[j].ID
+	})
+	*u = usage
+	return nil
+}
+
+func (s *service) Containers(n *[]NodeContainers) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.containers(n)
+}
+
+func (s *service) containers(n *[]NodeContainers) error {
+	is, err := s.instances()
+	if err != nil {
+		return err
+	}
+
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return err
+	}
+
+	nodes, err := s.nodes()
+	if err != nil {
+		return err
+	}
+
+	labels, err := s.nodeLabels()
+	if err != nil {
+		return err
+	}
+
+	byNode := make(map[string]*NodeContainers)
+	get := func(name string) *NodeContainers {
+		nc, ok := byNode[name]
+		if !ok {
+			d, drain := drained[name]
+			nc = &NodeContainers{
+				Node:      name,
+				Drained:   drain && !d.Cordoned && !d.Failed,
+				Cordoned:  d.Cordoned,
+				Failed:    d.Failed,
+				Labels:    labels[name],
+				Instances: []Instance{},
+			}
+			byNode[name] = nc
+		}
+		return nc
+	}
+
+	// registered nodes are listed even if no instance runs on them
+	for _, node := range nodes {
+		get(node.Name)
+	}
+	for _, i := range is {
+		nc := get(i.Node)
+		nc.Instances = append(nc.Instances, i)
+	}
+
+	ncs := make([]NodeContainers, 0, len(byNode))
+	for _, nc := range byNode {
+		sort.Slice(nc.Instances, func(i, j int) bool {
+			return nc.Instances[i].ContainerID < nc.Instances[j].ContainerID
+		})
+		ncs = append(ncs, *nc)
+	}
+	sort.Slice(ncs, func(i, j int) bool {
+		return ncs[i].Node < ncs[j].Node
+	})
+
+	*n = ncs
+	return nil
+}
+
+func (s *service) Errors(limit uint) []logging.Entry {
+	if s.errors == nil {
+		return []logging.Entry{}
+	}
+	return s.errors.Entries(int(limit))
+}
+
+func (s *service) QueueDepth(q *[]QueueDepth) error {
+	depth := []QueueDepth{}
+	for _, state := range []string{jobs.Pending, jobs.Running, jobs.Succeeded, jobs.Dead} {
+		js := []jobs.Job{}
+		if s.queue != nil {
+			err := s.queue.Jobs(state, &js)
+			if err != nil {
+				return err
+			}
+		}
+
+		depth = append(depth, QueueDepth{
+			State: state,
+			Count: uint(len(js)),
+		})
+	}
+
+	*q = depth
+	return nil
+}
+
+func (s *service) StopContainer(containerID string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	i, err := s.getInstance(containerID)
+	if err != nil {
+		return err
+	}
+
+	return s.bus.Publish(events.StopContainer, events.ContainerCommand{
+		Node:        i.Node,
+		RefID:       i.RefID,
+		ContainerID: i.ContainerID,
+	})
+}
+
+func (s *service) ReassignNode(containerID string, node string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	i, err := s.getInstance(containerID)
+	if err != nil {
+		return err
+	}
+
+	if i.ReplicaOf != "" {
+		return ErrReplica
+	}
+
+	if i.Node == node {
+		return ErrSameNode
+	}
+
+	exists, err := s.nodeExists(node)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNodeNotExist
+	}
+
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return err
+	}
+	if d, ok := drained[node]; ok {
+		return unschedulable(d)
+	}
+
+	p, err := s.placer()
+	if err != nil {
+		return err
+	}
+	err = p.fits(i, node)
+	if err != nil {
+		return err
+	}
+
+	return s.move(i, node)
+}
+
+// move lets the node an instance runs on remove it and the target node create it again
+func (s *service) move(i Instance, node string) error {
+	return s.bus.Publish(events.MoveContainer, events.ContainerCommand{
+		Node:        i.Node,
+		RefID:       i.RefID,
+		ContainerID: i.ContainerID,
+		Name:        i.ContainerName,
+		KMIID:       i.KMIID,
+		Replicas:    i.Replicas,
+		Target:      node,
+		Spread:      i.Placement.Spread,
+		Labels:      i.Placement.Labels,
+	})
+}
+
+func (s *service) DrainNode(node string, stop bool) (uint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.drainNode(node, stop)
+}
+
+func (s *service) drainNode(node string, stop bool) (uint, error) {
+	nodes, err := s.nodes()
+	if err != nil {
+		return 0, err
+	}
+
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return 0, err
+	}
+
+	is, err := s.instances()
+	if err != nil {
+		return 0, err
+	}
+
+	// instances are moved to the nodes running the fewest instances
+	load := make(map[string]uint)
+	exists := false
+	for _, n := range nodes {
+		if n.Name == node {
+			exists = true
+			continue
+		}
+		if _, ok := drained[n.Name]; !ok {
+			load[n.Name] = 0
+		}
+	}
+	if !exists {
+		return 0, ErrNodeNotExist
+	}
+
+	// replicas are moved with their instance but stopped on their own
+	moving := []Instance{}
+	for _, i := range is {
+		if _, ok := load[i.Node]; ok {
+			load[i.Node]++
+		}
+		if i.Node == node && (stop || i.ReplicaOf == "") {
+			moving = append(moving, i)
+		}
+	}
+
+	if !stop && len(moving) != 0 && len(load) == 0 {
+		return 0, ErrNoNode
+	}
+
+	if drained[node].Failed {
+		return 0, ErrNodeFailed
+	}
+
+	// the targets are chosen before the node is drained, so it is not drained if an instance fits on no node
+	targets := make(map[string]string)
+	if !stop {
+		p, err := s.placer()
+		if err != nil {
+			return 0, err
+		}
+
+		for _, i := range moving {
+			target, err := p.target(i, load)
+			if err != nil {
+				return 0, err
+			}
+			targets[i.ContainerID] = target
+			load[target] += 1 + i.Replicas
+		}
+	}
+	if d, ok := drained[node]; !ok || d.Cordoned {
+		// a cordoned node is drained from now on
+		if ok {
+			err = s.db.Delete(&DrainedNode{Name: node})
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		err = s.db.Create(&DrainedNode{
+			Name:  node,
+			Since: time.Now(),
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = s.bus.Publish(events.NodeChanged, events.NodeEvent{
+		Name:    node,
+		Drained: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var moved uint
+	for _, i := range moving {
+		if stop {
+			err = s.bus.Publish(events.StopContainer, events.ContainerCommand{
+				Node:        i.Node,
+				RefID:       i.RefID,
+				ContainerID: i.ContainerID,
+			})
+			if err != nil {
+				return moved, err
+			}
+			moved++
+			continue
+		}
+
+		err = s.move(i, targets[i.ContainerID])
+		if err != nil {
+			return moved, err
+		}
+		moved++
+	}
+
+	return moved, s.notify(node, moving, stop)
+}
+
+// notify sends every owner of instances of a drained node an email listing the moved or stopped instances
+func (s *service) notify(node string, is []Instance, stopped bool) error {
+	if s.mails == nil {
+		return nil
+	}
+
+	owners := []uint{}
+	names := make(map[uint][]string)
+	for _, i := range is {
+		if _, ok := names[i.RefID]; !ok {
+			owners = append(owners, i.RefID)
+		}
+		names[i.RefID] = append(names[i.RefID], i.ContainerName)
+	}
+
+	now := time.Now()
+	for _, refID := range owners {
+		_, err := s.mails.Notify(refID, mail.NodeDrained, mail.DrainData{
+			Node:      node,
+			Instances: names[refID],
+			Stopped:   stopped,
+			Time:      now,
+		})
+		if err != nil && err != mail.ErrNoRecipient {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) UndrainNode(node string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return err
+	}
+	if d, ok := drained[node]; !ok || d.Cordoned || d.Failed {
+		return ErrNotDrained
+	}
+
+	return s.schedule(node)
+}
+
+func (s *service) CordonNode(node string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	exists, err := s.nodeExists(node)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNodeNotExist
+	}
+
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return err
+	}
+	d, ok := drained[node]
+	if ok && !d.Cordoned {
+		return unschedulable(d)
+	}
+	if !ok {
+		err = s.db.Create(&DrainedNode{
+			Name:     node,
+			Since:    time.Now(),
+			Cordoned: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.bus.Publish(events.NodeChanged, events.NodeEvent{
+		Name:     node,
+		Cordoned: true,
+	})
+}
+
+func (s *service) UncordonNode(node string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return err
+	}
+	if d, ok := drained[node]; !ok || !d.Cordoned {
+		return ErrNotCordoned
+	}
+
+	return s.schedule(node)
+}
+
+func (s *service) FailNode(node string) (uint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.failNode(node)
+}
+
+func (s *service) failNode(node string) (uint, error) {
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return 0, err
+	}
+	d, ok := drained[node]
+	if d.Failed {
+		return 0, ErrNodeFailed
+	}
+
+	nodes, err := s.nodes()
+	if err != nil {
+		return 0, err
+	}
+
+	is, err := s.instances()
+	if err != nil {
+		return 0, err
+	}
+
+	load := make(map[string]uint)
+	for _, n := range nodes {
+		if _, out := drained[n.Name]; n.Name != node && !out {
+			load[n.Name] = 0
+		}
+	}
+
+	lost := []Instance{}
+	for _, i := range is {
+		if _, ok := load[i.Node]; ok {
+			load[i.Node]++
+		}
+		if i.Node == node && i.ReplicaOf == "" {
+			lost = append(lost, i)
+		}
+	}
+
+	// a drained or cordoned node counts as failed until it is back
+	if ok {
+		err = s.db.Delete(&DrainedNode{Name: node})
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = s.db.Create(&DrainedNode{
+		Name:   node,
+		Since:  time.Now(),
+		Failed: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.bus.Publish(events.NodeChanged, events.NodeEvent{
+		Name:    node,
+		Drained: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	p, err := s.placer()
+	if err != nil {
+		return 0, err
+	}
+
+	// instances which are pinned or fit on no node are lost until the node is back
+	rescheduled, left := []Instance{}, []Instance{}
+	for _, i := range lost {
+		if i.Pinned {
+			left = append(left, i)
+			continue
+		}
+
+		target, err := p.target(i, load)
+		if err != nil {
+			left = append(left, i)
+			continue
+		}
+		err = s.bus.Publish(events.PlaceContainer, events.ContainerCommand{
+			Node:        target,
+			RefID:       i.RefID,
+			ContainerID: i.ContainerID,
+			Name:        i.ContainerName,
+			KMIID:       i.KMIID,
+			Replicas:    i.Replicas,
+			Lost:        true,
+			Spread:      i.Placement.Spread,
+			Labels:      i.Placement.Labels,
+		})
+		if err != nil {
+			return uint(len(rescheduled)), err
+		}
+		load[target] += 1 + i.Replicas
+		rescheduled = append(rescheduled, i)
+	}
+
+	err = s.bus.Publish(events.NodeFailed, events.NodeIncident{
+		Name:        node,
+		Rescheduled: uint(len(rescheduled)),
+		Lost:        uint(len(left)),
+	})
+	if err != nil {
+		return uint(len(rescheduled)), err
+	}
+
+	return uint(len(rescheduled)), s.notifyFailure(node, rescheduled, left)
+}
+
+// notifyFailure sends every owner of instances of a failed node an email listing the rescheduled and the lost instances
+func (s *service) notifyFailure(node string, rescheduled []Instance, lost []Instance) error {
+	if s.mails == nil {
+		return nil
+	}
+
+	owners := []uint{}
+	data := make(map[uint]*mail.FailureData)
+	now := time.Now()
+	get := func(refID uint) *mail.FailureData {
+		d, ok := data[refID]
+		if !ok {
+			d = &mail.FailureData{
+				Node: node,
+				Time: now,
+			}
+			data[refID] = d
+			owners = append(owners, refID)
+		}
+		return d
+	}
+	for _, i := range rescheduled {
+		d := get(i.RefID)
+		d.Rescheduled = append(d.Rescheduled, i.ContainerName)
+	}
+	for _, i := range lost {
+		d := get(i.RefID)
+		d.Lost = append(d.Lost, i.ContainerName)
+	}
+
+	for _, refID := range owners {
+		_, err := s.mails.Notify(refID, mail.NodeFailed, *data[refID])
+		if err != nil && err != mail.ErrNoRecipient {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) RecoverNode(node string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return err
+	}
+	if !drained[node].Failed {
+		return ErrNotFailed
+	}
+
+	is, err := s.instances()
+	if err != nil {
+		return err
+	}
+
+	var lost uint
+	for _, i := range is {
+		if i.Node == node && i.ReplicaOf == "" {
+			lost++
+		}
+	}
+
+	err = s.schedule(node)
+	if err != nil {
+		return err
+	}
+
+	return s.bus.Publish(events.NodeRecovered, events.NodeIncident{
+		Name: node,
+		Lost: lost,
+	})
+}
+
+// schedule places instances on a drained, cordoned or failed node again
+func (s *service) schedule(node string) error {
+	err := s.db.Delete(&DrainedNode{Name: node})
+	if err != nil {
+		return err
+	}
+
+	return s.bus.Publish(events.NodeChanged, events.NodeEvent{
+		Name: node,
+	})
+}
+
+func (s *service) Announce(node string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	drained, err := s.drainedNodes()
+	if err != nil {
+		return err
+	}
+
+	// failed nodes are drained until they are back
+	d, ok := drained[node]
+	return s.bus.Publish(events.NodeChanged, events.NodeEvent{
+		Name:     node,
+		Drained:  ok && !d.Cordoned,
+		Cordoned: d.Cordoned,
+	})
+}
+
+func (s *service) Flags(out *[]feature.Flag) error {
+	if s.flags == nil {
+		return nil
+	}
+	return s.flags.Flags(out)
+}
+
+func (s *service) SetFlag(f *feature.Flag) error {
+	if s.flags == nil {
+		return ErrNoFlags
+	}
+	return s.flags.Set(f)
+}
+
+func (s *service) RemoveFlag(name string) error {
+	if s.flags == nil {
+		return ErrNoFlags
+	}
+	return s.flags.Remove(name)
+}
+
+func (s *service) Maintenance(out *[]maintenance.Window) error {
+	if s.mode == nil {
+		return nil
+	}
+	return s.mode.Windows(out)
+}
+
+func (s *service) ScheduleMaintenance(w *maintenance.Window) error {
+	if s.mode == nil {
+		return ErrNoMaintenance
+	}
+	return s.mode.Schedule(w)
+}
+
+func (s *service) CancelMaintenance(id uint) error {
+	if s.mode == nil {
+		return ErrNoMaintenance
+	}
+	return s.mode.Cancel(id)
+}
+
+func (s *service) Impersonate(session *impersonation.Session, d time.Duration) error {
+	if s.imp == nil {
+		return ErrNoImpersonation
+	}
+
+	s.mtx.Lock()
+	us := []user.User{}
+	err := s.db.Find(&us)
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, u := range us {
+		if u.ID != session.UserID {
+			continue
+		}
+		if u.Admin {
+			return ErrImpersonateAdmin
+		}
+		return s.imp.Start(session, d)
+	}
+	return ErrUserNotExist
+}
+
+func (s *service) StopImpersonation(id uint) error {
+	if s.imp == nil {
+		return ErrNoImpersonation
+	}
+	return s.imp.Stop(id)
+}
+
+func (s *service) Impersonations(out *[]impersonation.Session) error {
+	if s.imp == nil {
+		return nil
+	}
+	return s.imp.Active(out)
+}
+
+func (s *service) ImpersonationTrail(id uint, out *[]impersonation.Entry) error {
+	if s.imp == nil {
+		return nil
+	}
+	return s.imp.Trail(id, out)
+}
+
+// NewService returns a new AdminService, n may be nil if the daemon does not run a network service,
+// f if it does not store feature flags, m if it has no maintenance mode, imp if it does not store
+// impersonation sessions and mails if it sends no emails
+func NewService(db dbAdapter, bus events.Bus, errs ErrorLog, q JobQueue, n Network, f FeatureFlags, m MaintenanceMode, imp Impersonations, mails Notifier) (Service, error) {
+	s := &service{
+		db:      db,
+		bus:     bus,
+		errors:  errs,
+		queue:   q,
+		network: n,
+		flags:   f,
+		mode:    m,
+		imp:     imp,
+		mails:   mails,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,1081 @@
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/admin/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/feature"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/maintenance"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/lib/pq"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC AdminServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.AdminServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		users: grpctransport.NewServer(
+			endpoints.UsersEndpoint,
+			DecodeGRPCUsersRequest,
+			EncodeGRPCUsersResponse,
+			options...,
+		),
+
+		containers: grpctransport.NewServer(
+			endpoints.ContainersEndpoint,
+			DecodeGRPCContainersRequest,
+			EncodeGRPCContainersResponse,
+			options...,
+		),
+
+		errors: grpctransport.NewServer(
+			endpoints.ErrorsEndpoint,
+			DecodeGRPCErrorsRequest,
+			EncodeGRPCErrorsResponse,
+			options...,
+		),
+
+		queueDepth: grpctransport.NewServer(
+			endpoints.QueueDepthEndpoint,
+			DecodeGRPCQueueDepthRequest,
+			EncodeGRPCQueueDepthResponse,
+			options...,
+		),
+
+		stopContainer: grpctransport.NewServer(
+			endpoints.StopContainerEndpoint,
+			DecodeGRPCStopContainerRequest,
+			EncodeGRPCStopContainerResponse,
+			options...,
+		),
+
+		reassignNode: grpctransport.NewServer(
+			endpoints.ReassignNodeEndpoint,
+			DecodeGRPCReassignNodeRequest,
+			EncodeGRPCReassignNodeResponse,
+			options...,
+		),
+
+		drainNode: grpctransport.NewServer(
+			endpoints.DrainNodeEndpoint,
+			DecodeGRPCDrainNodeRequest,
+			EncodeGRPCDrainNodeResponse,
+			options...,
+		),
+
+		undrainNode: grpctransport.NewServer(
+			endpoints.UndrainNodeEndpoint,
+			DecodeGRPCUndrainNodeRequest,
+			EncodeGRPCUndrainNodeResponse,
+			options...,
+		),
+
+		cordonNode: grpctransport.NewServer(
+			endpoints.CordonNodeEndpoint,
+			DecodeGRPCCordonNodeRequest,
+			EncodeGRPCCordonNodeResponse,
+			options...,
+		),
+
+		uncordonNode: grpctransport.NewServer(
+			endpoints.UncordonNodeEndpoint,
+			DecodeGRPCUncordonNodeRequest,
+			EncodeGRPCUncordonNodeResponse,
+			options...,
+		),
+
+		labelNode: grpctransport.NewServer(
+			endpoints.LabelNodeEndpoint,
+			DecodeGRPCLabelNodeRequest,
+			EncodeGRPCLabelNodeResponse,
+			options...,
+		),
+
+		flags: grpctransport.NewServer(
+			endpoints.FlagsEndpoint,
+			DecodeGRPCFlagsRequest,
+			EncodeGRPCFlagsResponse,
+			options...,
+		),
+
+		setFlag: grpctransport.NewServer(
+			endpoints.SetFlagEndpoint,
+			DecodeGRPCSetFlagRequest,
+			EncodeGRPCSetFlagResponse,
+			options...,
+		),
+
+		removeFlag: grpctransport.NewServer(
+			endpoints.RemoveFlagEndpoint,
+			DecodeGRPCRemoveFlagRequest,
+			EncodeGRPCRemoveFlagResponse,
+			options...,
+		),
+
+		maintenance: grpctransport.NewServer(
+			endpoints.MaintenanceEndpoint,
+			DecodeGRPCMaintenanceRequest,
+			EncodeGRPCMaintenanceResponse,
+			options...,
+		),
+
+		scheduleMaintenance: grpctransport.NewServer(
+			endpoints.ScheduleMaintenanceEndpoint,
+			DecodeGRPCScheduleMaintenanceRequest,
+			EncodeGRPCScheduleMaintenanceResponse,
+			options...,
+		),
+
+		cancelMaintenance: grpctransport.NewServer(
+			endpoints.CancelMaintenanceEndpoint,
+			DecodeGRPCCancelMaintenanceRequest,
+			EncodeGRPCCancelMaintenanceResponse,
+			options...,
+		),
+
+		impersonate: grpctransport.NewServer(
+			endpoints.ImpersonateEndpoint,
+			DecodeGRPCImpersonateRequest,
+			EncodeGRPCImpersonateResponse,
+			options...,
+		),
+
+		stopImpersonation: grpctransport.NewServer(
+			endpoints.StopImpersonationEndpoint,
+			DecodeGRPCStopImpersonationRequest,
+			EncodeGRPCStopImpersonationResponse,
+			options...,
+		),
+
+		impersonations: grpctransport.NewServer(
+			endpoints.ImpersonationsEndpoint,
+			DecodeGRPCImpersonationsRequest,
+			EncodeGRPCImpersonationsResponse,
+			options...,
+		),
+
+		impersonationTrail: grpctransport.NewServer(
+			endpoints.ImpersonationTrailEndpoint,
+			DecodeGRPCImpersonationTrailRequest,
+			EncodeGRPCImpersonationTrailResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	users         grpctransport.Handler
+	containers    grpctransport.Handler
+	errors        grpctransport.Handler
+	queueDepth    grpctransport.Handler
+	stopContainer grpctransport.Handler
+	reassignNode  grpctransport.Handler
+	drainNode     grpctransport.Handler
+	undrainNode   grpctransport.Handler
+	cordonNode    grpctransport.Handler
+	uncordonNode  grpctransport.Handler
+	labelNode     grpctransport.Handler
+	flags         grpctransport.Handler
+	setFlag       grpctransport.Handler
+	removeFlag    grpctransport.Handler
+
+	maintenance         grpctransport.Handler
+	scheduleMaintenance grpctransport.Handler
+	cancelMaintenance   grpctransport.Handler
+
+	impersonate        grpctransport.Handler
+	stopImpersonation  grpctransport.Handler
+	impersonations     grpctransport.Handler
+	impersonationTrail grpctransport.Handler
+}
+
+func (s *grpcServer) Users(ctx oldcontext.Context, req *pb.UsersRequest) (*pb.UsersResponse, error) {
+	_, res, err := s.users.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.UsersResponse), nil
+}
+
+func (s *grpcServer) Containers(ctx oldcontext.Context, req *pb.ContainersRequest) (*pb.ContainersResponse, error) {
+	_, res, err := s.containers.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ContainersResponse), nil
+}
+
+func (s *grpcServer) Errors(ctx oldcontext.Context, req *pb.ErrorsRequest) (*pb.ErrorsResponse, error) {
+	_, res, err := s.errors.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ErrorsResponse), nil
+}
+
+func (s *grpcServer) QueueDepth(ctx oldcontext.Context, req *pb.QueueDepthRequest) (*pb.QueueDepthResponse, error) {
+	_, res, err := s.queueDepth.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.QueueDepthResponse), nil
+}
+
+func (s *grpcServer) StopContainer(ctx oldcontext.Context, req *pb.StopContainerRequest) (*pb.StopContainerResponse, error) {
+	_, res, err := s.stopContainer.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.StopContainerResponse), nil
+}
+
+func (s *grpcServer) ReassignNode(ctx oldcontext.Context, req *pb.ReassignNodeRequest) (*pb.ReassignNodeResponse, error) {
+	_, res, err := s.reassignNode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ReassignNodeResponse), nil
+}
+
+func (s *grpcServer) DrainNode(ctx oldcontext.Context, req *pb.DrainNodeRequest) (*pb.DrainNodeResponse, error) {
+	_, res, err := s.drainNode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DrainNodeResponse), nil
+}
+
+func (s *grpcServer) UndrainNode(ctx oldcontext.Context, req *pb.UndrainNodeRequest) (*pb.UndrainNodeResponse, error) {
+	_, res, err := s.undrainNode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.UndrainNodeResponse), nil
+}
+
+func (s *grpcServer) CordonNode(ctx oldcontext.Context, req *pb.CordonNodeRequest) (*pb.CordonNodeResponse, error) {
+	_, res, err := s.cordonNode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CordonNodeResponse), nil
+}
+
+func (s *grpcServer) UncordonNode(ctx oldcontext.Context, req *pb.UncordonNodeRequest) (*pb.UncordonNodeResponse, error) {
+	_, res, err := s.uncordonNode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.UncordonNodeResponse), nil
+}
+
+func (s *grpcServer) LabelNode(ctx oldcontext.Context, req *pb.LabelNodeRequest) (*pb.LabelNodeResponse, error) {
+	_, res, err := s.labelNode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.LabelNodeResponse), nil
+}
+
+func (s *grpcServer) Flags(ctx oldcontext.Context, req *pb.FlagsRequest) (*pb.FlagsResponse, error) {
+	_, res, err := s.flags.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.FlagsResponse), nil
+}
+
+func (s *grpcServer) SetFlag(ctx oldcontext.Context, req *pb.SetFlagRequest) (*pb.SetFlagResponse, error) {
+	_, res, err := s.setFlag.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetFlagResponse), nil
+}
+
+func (s *grpcServer) RemoveFlag(ctx oldcontext.Context, req *pb.RemoveFlagRequest) (*pb.RemoveFlagResponse, error) {
+	_, res, err := s.removeFlag.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveFlagResponse), nil
+}
+
+func (s *grpcServer) Maintenance(ctx oldcontext.Context, req *pb.MaintenanceRequest) (*pb.MaintenanceResponse, error) {
+	_, res, err := s.maintenance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.MaintenanceResponse), nil
+}
+
+func (s *grpcServer) ScheduleMaintenance(ctx oldcontext.Context, req *pb.ScheduleMaintenanceRequest) (*pb.ScheduleMaintenanceResponse, error) {
+	_, res, err := s.scheduleMaintenance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ScheduleMaintenanceResponse), nil
+}
+
+func (s *grpcServer) CancelMaintenance(ctx oldcontext.Context, req *pb.CancelMaintenanceRequest) (*pb.CancelMaintenanceResponse, error) {
+	_, res, err := s.cancelMaintenance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CancelMaintenanceResponse), nil
+}
+
+func (s *grpcServer) Impersonate(ctx oldcontext.Context, req *pb.ImpersonateRequest) (*pb.ImpersonateResponse, error) {
+	_, res, err := s.impersonate.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ImpersonateResponse), nil
+}
+
+func (s *grpcServer) StopImpersonation(ctx oldcontext.Context, req *pb.StopImpersonationRequest) (*pb.StopImpersonationResponse, error) {
+	_, res, err := s.stopImpersonation.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.StopImpersonationResponse), nil
+}
+
+func (s *grpcServer) Impersonations(ctx oldcontext.Context, req *pb.ImpersonationsRequest) (*pb.ImpersonationsResponse, error) {
+	_, res, err := s.impersonations.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ImpersonationsResponse), nil
+}
+
+func (s *grpcServer) ImpersonationTrail(ctx oldcontext.Context, req *pb.ImpersonationTrailRequest) (*pb.ImpersonationTrailResponse, error) {
+	_, res, err := s.impersonationTrail.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ImpersonationTrailResponse), nil
+}
+
+// ConvertUserUsage converts a UserUsage to its protobuf representation
+func ConvertUserUsage(u UserUsage) *pb.UserUsage {
+	return &pb.UserUsage{
+		ID:        uint32(u.ID),
+		Username:  u.Username,
+		Admin:     u.Admin,
+		Instances: uint32(u.Instances),
+		BytesIn:   u.BytesIn,
+		BytesOut:  u.BytesOut,
+	}
+}
+
+// ConvertPBUserUsage converts a protobuf UserUsage to a UserUsage
+func ConvertPBUserUsage(u *pb.UserUsage) UserUsage {
+	if u == nil {
+		return UserUsage{}
+	}
+
+	return UserUsage{
+		ID:        uint(u.ID),
+		Username:  u.Username,
+		Admin:     u.Admin,
+		Instances: uint(u.Instances),
+		BytesIn:   u.BytesIn,
+		BytesOut:  u.BytesOut,
+	}
+}
+
+// ConvertNodeContainers converts a NodeContainers to its protobuf representation
+func ConvertNodeContainers(n NodeContainers) *pb.NodeContainers {
+	instances := make([]*pb.Instance, len(n.Instances))
+	for i, in := range n.Instances {
+		instances[i] = &pb.Instance{
+			RefID:       uint32(in.RefID),
+			ContainerID: in.ContainerID,
+			Name:        in.ContainerName,
+			ReplicaOf:   in.ReplicaOf,
+			Replicas:    uint32(in.Replicas),
+			Pinned:      in.Pinned,
+			Module:      in.Module,
+			Placement: &pb.Placement{
+				Spread: in.Placement.Spread,
+				Labels: in.Placement.Labels,
+			},
+		}
+	}
+
+	return &pb.NodeContainers{
+		Node:      n.Node,
+		Drained:   n.Drained,
+		Cordoned:  n.Cordoned,
+		Failed:    n.Failed,
+		Labels:    n.Labels,
+		Instances: instances,
+	}
+}
+
+// ConvertPBNodeContainers converts a protobuf NodeContainers to a NodeContainers
+func ConvertPBNodeContainers(n *pb.NodeContainers) NodeContainers {
+	if n == nil {
+		return NodeContainers{}
+	}
+
+	instances := make([]Instance, len(n.Instances))
+	for i, in := range n.Instances {
+		instances[i] = Instance{
+			RefID:         uint(in.RefID),
+			ContainerID:   in.ContainerID,
+			ContainerName: in.Name,
+			ReplicaOf:     in.ReplicaOf,
+			Replicas:      uint(in.Replicas),
+			Node:          n.Node,
+			Pinned:        in.Pinned,
+			Module:        in.Module,
+		}
+		if in.Placement != nil {
+			instances[i].Placement = kmi.Placement{
+				Spread: in.Placement.Spread,
+				Labels: in.Placement.Labels,
+			}
+		}
+	}
+
+	return NodeContainers{
+		Node:      n.Node,
+		Drained:   n.Drained,
+		Cordoned:  n.Cordoned,
+		Failed:    n.Failed,
+		Labels:    n.Labels,
+		Instances: instances,
+	}
+}
+
+// ConvertEntry converts a logging.Entry to its protobuf representation
+func ConvertEntry(e logging.Entry) *pb.ErrorEntry {
+	return &pb.ErrorEntry{
+		Time:    e.Time.Unix(),
+		Module:  e.Module,
+		Message: e.Message,
+		Record:  e.Record,
+	}
+}
+
+// ConvertFlag converts a feature.Flag to its protobuf representation
+func ConvertFlag(f feature.Flag) *pb.Flag {
+	users := make([]uint32, len(f.Users))
+	for i, u := range f.Users {
+		users[i] = uint32(u)
+	}
+
+	return &pb.Flag{
+		Name:        f.Name,
+		Description: f.Description,
+		Enabled:     f.Enabled,
+		Percentage:  uint32(f.Percentage),
+		Users:       users,
+		Plans:       f.Plans,
+	}
+}
+
+// ConvertPBFlag converts a protobuf Flag to a feature.Flag
+func ConvertPBFlag(f *pb.Flag) feature.Flag {
+	if f == nil {
+		return feature.Flag{}
+	}
+
+	users := make(pq.Int64Array, len(f.Users))
+	for i, u := range f.Users {
+		users[i] = int64(u)
+	}
+
+	return feature.Flag{
+		Name:        f.Name,
+		Description: f.Description,
+		Enabled:     f.Enabled,
+		Percentage:  uint(f.Percentage),
+		Users:       users,
+		Plans:       f.Plans,
+	}
+}
+
+// Unix returns the unix timestamp of t, it is zero if t is the zero time
+func Unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// FromUnix returns the time of a unix timestamp, it is the zero time if the timestamp is zero
+func FromUnix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
+
+// ConvertMaintenanceWindow converts a maintenance.Window to its protobuf representation
+func ConvertMaintenanceWindow(w maintenance.Window) *pb.MaintenanceWindow {
+	return &pb.MaintenanceWindow{
+		ID:      uint32(w.ID),
+		Start:   Unix(w.Start),
+		End:     Unix(w.End),
+		Message: w.Message,
+	}
+}
+
+// ConvertPBMaintenanceWindow converts a protobuf MaintenanceWindow to a maintenance.Window
+func ConvertPBMaintenanceWindow(w *pb.MaintenanceWindow) maintenance.Window {
+	if w == nil {
+		return maintenance.Window{}
+	}
+
+	return maintenance.Window{
+		ID:      uint(w.ID),
+		Start:   FromUnix(w.Start),
+		End:     FromUnix(w.End),
+		Message: w.Message,
+	}
+}
+
+// ConvertImpersonationSession converts an impersonation.Session to its protobuf representation
+func ConvertImpersonationSession(s impersonation.Session) *pb.ImpersonationSession {
+	return &pb.ImpersonationSession{
+		ID:      uint32(s.ID),
+		AdminID: uint32(s.AdminID),
+		UserID:  uint32(s.UserID),
+		Reason:  s.Reason,
+		Start:   Unix(s.Start),
+		End:     Unix(s.End),
+	}
+}
+
+// ConvertPBImpersonationSession converts a protobuf ImpersonationSession to an impersonation.Session
+func ConvertPBImpersonationSession(s *pb.ImpersonationSession) impersonation.Session {
+	if s == nil {
+		return impersonation.Session{}
+	}
+
+	return impersonation.Session{
+		ID:      uint(s.ID),
+		AdminID: uint(s.AdminID),
+		UserID:  uint(s.UserID),
+		Reason:  s.Reason,
+		Start:   FromUnix(s.Start),
+		End:     FromUnix(s.End),
+	}
+}
+
+// ConvertAuditEntry converts an impersonation.Entry to its protobuf representation
+func ConvertAuditEntry(e impersonation.Entry) *pb.AuditEntry {
+	return &pb.AuditEntry{
+		ID:        uint32(e.ID),
+		SessionID: uint32(e.SessionID),
+		AdminID:   uint32(e.AdminID),
+		UserID:    uint32(e.UserID),
+		Method:    e.Method,
+		Error:     e.Error,
+		Time:      Unix(e.Time),
+	}
+}
+
+// ConvertPBAuditEntry converts a protobuf AuditEntry to an impersonation.Entry
+func ConvertPBAuditEntry(e *pb.AuditEntry) impersonation.Entry {
+	return impersonation.Entry{
+		ID:        uint(e.ID),
+		SessionID: uint(e.SessionID),
+		AdminID:   uint(e.AdminID),
+		UserID:    uint(e.UserID),
+		Method:    e.Method,
+		Error:     e.Error,
+		Time:      FromUnix(e.Time),
+	}
+}
+
+// DecodeGRPCUsersRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Users request to a messages/admin.proto-domain users request.
+func DecodeGRPCUsersRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UsersRequest)
+	return UsersRequest{
+		Page: paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCUsersResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain users response to a gRPC Users response.
+func EncodeGRPCUsersResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(UsersResponse)
+	users := make([]*pb.UserUsage, len(res.Users))
+	for i, u := range res.Users {
+		users[i] = ConvertUserUsage(u)
+	}
+
+	gRPCRes := &pb.UsersResponse{
+		Users:    users,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCContainersRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Containers request to a messages/admin.proto-domain containers request.
+func DecodeGRPCContainersRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return ContainersRequest{}, nil
+}
+
+// EncodeGRPCContainersResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain containers response to a gRPC Containers response.
+func EncodeGRPCContainersResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ContainersResponse)
+	nodes := make([]*pb.NodeContainers, len(res.Nodes))
+	for i, n := range res.Nodes {
+		nodes[i] = ConvertNodeContainers(n)
+	}
+
+	gRPCRes := &pb.ContainersResponse{
+		Nodes: nodes,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCErrorsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Errors request to a messages/admin.proto-domain errors request.
+func DecodeGRPCErrorsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ErrorsRequest)
+	return ErrorsRequest{
+		Limit: uint(req.Limit),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCErrorsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain errors response to a gRPC Errors response.
+func EncodeGRPCErrorsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ErrorsResponse)
+	entries := make([]*pb.ErrorEntry, len(res.Errors))
+	for i, e := range res.Errors {
+		entries[i] = ConvertEntry(e)
+	}
+
+	return &pb.ErrorsResponse{
+		Errors:   entries,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}, nil
+}
+
+// DecodeGRPCQueueDepthRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC QueueDepth request to a messages/admin.proto-domain queuedepth request.
+func DecodeGRPCQueueDepthRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return QueueDepthRequest{}, nil
+}
+
+// EncodeGRPCQueueDepthResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain queuedepth response to a gRPC QueueDepth response.
+func EncodeGRPCQueueDepthResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(QueueDepthResponse)
+	depth := make([]*pb.QueueDepth, len(res.Depth))
+	for i, d := range res.Depth {
+		depth[i] = &pb.QueueDepth{
+			State: d.State,
+			Count: uint32(d.Count),
+		}
+	}
+
+	gRPCRes := &pb.QueueDepthResponse{
+		Depth: depth,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCStopContainerRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC StopContainer request to a messages/admin.proto-domain stopcontainer request.
+func DecodeGRPCStopContainerRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.StopContainerRequest)
+	return StopContainerRequest{
+		ContainerID: req.ContainerID,
+	}, nil
+}
+
+// EncodeGRPCStopContainerResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain stopcontainer response to a gRPC StopContainer response.
+func EncodeGRPCStopContainerResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(StopContainerResponse)
+	gRPCRes := &pb.StopContainerResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCReassignNodeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ReassignNode request to a messages/admin.proto-domain reassignnode request.
+func DecodeGRPCReassignNodeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ReassignNodeRequest)
+	return ReassignNodeRequest{
+		ContainerID: req.ContainerID,
+		Node:        req.Node,
+	}, nil
+}
+
+// EncodeGRPCReassignNodeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain reassignnode response to a gRPC ReassignNode response.
+func EncodeGRPCReassignNodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ReassignNodeResponse)
+	gRPCRes := &pb.ReassignNodeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDrainNodeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC DrainNode request to a messages/admin.proto-domain drainnode request.
+func DecodeGRPCDrainNodeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DrainNodeRequest)
+	return DrainNodeRequest{
+		Node: req.Node,
+		Stop: req.Stop,
+	}, nil
+}
+
+// EncodeGRPCDrainNodeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain drainnode response to a gRPC DrainNode response.
+func EncodeGRPCDrainNodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DrainNodeResponse)
+	gRPCRes := &pb.DrainNodeResponse{
+		Moved: uint32(res.Moved),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCUndrainNodeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC UndrainNode request to a messages/admin.proto-domain undrainnode request.
+func DecodeGRPCUndrainNodeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UndrainNodeRequest)
+	return UndrainNodeRequest{
+		Node: req.Node,
+	}, nil
+}
+
+// EncodeGRPCUndrainNodeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain undrainnode response to a gRPC UndrainNode response.
+func EncodeGRPCUndrainNodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(UndrainNodeResponse)
+	gRPCRes := &pb.UndrainNodeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCCordonNodeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CordonNode request to a messages/admin.proto-domain cordonnode request.
+func DecodeGRPCCordonNodeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CordonNodeRequest)
+	return CordonNodeRequest{
+		Node: req.Node,
+	}, nil
+}
+
+// EncodeGRPCCordonNodeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain cordonnode response to a gRPC CordonNode response.
+func EncodeGRPCCordonNodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CordonNodeResponse)
+	gRPCRes := &pb.CordonNodeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCUncordonNodeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC UncordonNode request to a messages/admin.proto-domain uncordonnode request.
+func DecodeGRPCUncordonNodeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UncordonNodeRequest)
+	return UncordonNodeRequest{
+		Node: req.Node,
+	}, nil
+}
+
+// EncodeGRPCUncordonNodeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain uncordonnode response to a gRPC UncordonNode response.
+func EncodeGRPCUncordonNodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(UncordonNodeResponse)
+	gRPCRes := &pb.UncordonNodeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCFlagsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Flags request to a messages/admin.proto-domain flags request.
+func DecodeGRPCFlagsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return FlagsRequest{}, nil
+}
+
+// EncodeGRPCFlagsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain flags response to a gRPC Flags response.
+func EncodeGRPCFlagsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(FlagsResponse)
+	flags := make([]*pb.Flag, len(res.Flags))
+	for i, f := range res.Flags {
+		flags[i] = ConvertFlag(f)
+	}
+
+	gRPCRes := &pb.FlagsResponse{
+		Flags: flags,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetFlagRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetFlag request to a messages/admin.proto-domain setflag request.
+func DecodeGRPCSetFlagRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetFlagRequest)
+	return SetFlagRequest{
+		Flag: ConvertPBFlag(req.Flag),
+	}, nil
+}
+
+// EncodeGRPCSetFlagResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain setflag response to a gRPC SetFlag response.
+func EncodeGRPCSetFlagResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetFlagResponse)
+	gRPCRes := &pb.SetFlagResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveFlagRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveFlag request to a messages/admin.proto-domain removeflag request.
+func DecodeGRPCRemoveFlagRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveFlagRequest)
+	return RemoveFlagRequest{
+		Name: req.Name,
+	}, nil
+}
+
+// EncodeGRPCRemoveFlagResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain removeflag response to a gRPC RemoveFlag response.
+func EncodeGRPCRemoveFlagResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveFlagResponse)
+	gRPCRes := &pb.RemoveFlagResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCMaintenanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Maintenance request to a messages/admin.proto-domain maintenance request.
+func DecodeGRPCMaintenanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return MaintenanceRequest{}, nil
+}
+
+// EncodeGRPCMaintenanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain maintenance response to a gRPC Maintenance response.
+func EncodeGRPCMaintenanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(MaintenanceResponse)
+	windows := make([]*pb.MaintenanceWindow, len(res.Windows))
+	for i, w := range res.Windows {
+		windows[i] = ConvertMaintenanceWindow(w)
+	}
+
+	gRPCRes := &pb.MaintenanceResponse{
+		Windows: windows,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCScheduleMaintenanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ScheduleMaintenance request to a messages/admin.proto-domain schedulemaintenance request.
+func DecodeGRPCScheduleMaintenanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ScheduleMaintenanceRequest)
+	return ScheduleMaintenanceRequest{
+		Start:   FromUnix(req.Start),
+		End:     FromUnix(req.End),
+		Message: req.Message,
+	}, nil
+}
+
+// EncodeGRPCScheduleMaintenanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain schedulemaintenance response to a gRPC ScheduleMaintenance response.
+func EncodeGRPCScheduleMaintenanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ScheduleMaintenanceResponse)
+	gRPCRes := &pb.ScheduleMaintenanceResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCCancelMaintenanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CancelMaintenance request to a messages/admin.proto-domain cancelmaintenance request.
+func DecodeGRPCCancelMaintenanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CancelMaintenanceRequest)
+	return CancelMaintenanceRequest{
+		ID: uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCCancelMaintenanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain cancelmaintenance response to a gRPC CancelMaintenance response.
+func EncodeGRPCCancelMaintenanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CancelMaintenanceResponse)
+	gRPCRes := &pb.CancelMaintenanceResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCLabelNodeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC LabelNode request to a messages/admin.proto-domain labelnode request.
+func DecodeGRPCLabelNodeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.LabelNodeRequest)
+	return LabelNodeRequest{
+		Node:   req.Node,
+		Labels: req.Labels,
+	}, nil
+}
+
+// EncodeGRPCLabelNodeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain labelnode response to a gRPC LabelNode response.
+func EncodeGRPCLabelNodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(LabelNodeResponse)
+	gRPCRes := &pb.LabelNodeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCImpersonateRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Impersonate request to a messages/admin.proto-domain impersonate request.
+func DecodeGRPCImpersonateRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ImpersonateRequest)
+	return ImpersonateRequest{
+		UserID:   uint(req.UserID),
+		Reason:   req.Reason,
+		Duration: time.Duration(req.Duration) * time.Second,
+	}, nil
+}
+
+// EncodeGRPCImpersonateResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain impersonate response to a gRPC Impersonate response.
+func EncodeGRPCImpersonateResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ImpersonateResponse)
+	gRPCRes := &pb.ImpersonateResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+		return gRPCRes, nil
+	}
+	gRPCRes.Session = ConvertImpersonationSession(res.Session)
+	return gRPCRes, nil
+}
+
+// DecodeGRPCStopImpersonationRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC StopImpersonation request to a messages/admin.proto-domain stopimpersonation request.
+func DecodeGRPCStopImpersonationRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.StopImpersonationRequest)
+	return StopImpersonationRequest{
+		ID: uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCStopImpersonationResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain stopimpersonation response to a gRPC StopImpersonation response.
+func EncodeGRPCStopImpersonationResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(StopImpersonationResponse)
+	gRPCRes := &pb.StopImpersonationResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCImpersonationsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Impersonations request to a messages/admin.proto-domain impersonations request.
+func DecodeGRPCImpersonationsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return ImpersonationsRequest{}, nil
+}
+
+// EncodeGRPCImpersonationsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain impersonations response to a gRPC Impersonations response.
+func EncodeGRPCImpersonationsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ImpersonationsResponse)
+	sessions := make([]*pb.ImpersonationSession, len(res.Sessions))
+	for i, s := range res.Sessions {
+		sessions[i] = ConvertImpersonationSession(s)
+	}
+
+	gRPCRes := &pb.ImpersonationsResponse{
+		Sessions: sessions,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCImpersonationTrailRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ImpersonationTrail request to a messages/admin.proto-domain impersonationtrail request.
+func DecodeGRPCImpersonationTrailRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ImpersonationTrailRequest)
+	return ImpersonationTrailRequest{
+		ID:   uint(req.ID),
+		Page: paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCImpersonationTrailResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/admin.proto-domain impersonationtrail response to a gRPC ImpersonationTrail response.
+func EncodeGRPCImpersonationTrailResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ImpersonationTrailResponse)
+	entries := make([]*pb.AuditEntry, len(res.Entries))
+	for i, e := range res.Entries {
+		entries[i] = ConvertAuditEntry(e)
+	}
+
+	gRPCRes := &pb.ImpersonationTrailResponse{
+		Entries:  entries,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
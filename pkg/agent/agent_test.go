@@ -0,0 +1,244 @@
+package agent_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/agent"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type recorder struct {
+	mtx    sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) handle(e events.Event) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) get() []events.Event {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]events.Event{}, r.events...)
+}
+
+// clientEndpoints calls s like the endpoints of a client, which take and return pointers
+func clientEndpoints(s agent.Service) *agent.Endpoints {
+	heartbeat := agent.MakeHeartbeatEndpoint(s)
+	publish := agent.MakePublishEndpoint(s)
+
+	return &agent.Endpoints{
+		HeartbeatEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			res, err := heartbeat(ctx, *request.(*agent.HeartbeatRequest))
+			r := res.(agent.HeartbeatResponse)
+			return &r, err
+		},
+		PublishEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			res, err := publish(ctx, *request.(*agent.PublishRequest))
+			r := res.(agent.PublishResponse)
+			return &r, err
+		},
+	}
+}
+
+var _ = Describe("Agent", func() {
+	var (
+		db     *testutils.MockDB
+		bus    events.Bus
+		rec    *recorder
+		s      agent.Service
+		logger = log.NewNopLogger()
+	)
+
+	BeforeEach(func() {
+		db = testutils.NewMockDB()
+		bus = events.NewMemoryBus("control", time.Millisecond, logger)
+		rec = &recorder{}
+		_, err := bus.Subscribe(">", "", rec.handle)
+		Expect(err).NotTo(HaveOccurred())
+
+		s, err = agent.NewService(db, bus, 50*time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		bus.Close()
+	})
+
+	Describe("NewService", func() {
+		It("Should return db errors", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := agent.NewService(db, nil, time.Second)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Heartbeat", func() {
+		It("Should register agents and announce them once", func() {
+			Expect(s.Heartbeat(&agent.Agent{Name: "node1", Address: "10.0.0.1:8082", Containers: 2})).To(Succeed())
+			Expect(s.Heartbeat(&agent.Agent{Name: "node1", Address: "10.0.0.1:8082", Containers: 3})).To(Succeed())
+
+			out := []agent.Agent{}
+			Expect(s.Agents(&out)).To(Succeed())
+			Expect(out).To(HaveLen(1))
+			Expect(out[0].Online).To(BeTrue())
+			Expect(out[0].Containers).To(BeEquivalentTo(3))
+
+			Eventually(rec.get).Should(HaveLen(1))
+			e := events.AgentEvent{}
+			Expect(rec.get()[0].Decode(&e)).To(Succeed())
+			Expect(e).To(Equal(events.AgentEvent{Name: "node1", Address: "10.0.0.1:8082", Online: true}))
+		})
+	})
+
+	Describe("Agents", func() {
+		It("Should order agents by name", func() {
+			Expect(s.Heartbeat(&agent.Agent{Name: "node2", Address: "10.0.0.2:8082"})).To(Succeed())
+			Expect(s.Heartbeat(&agent.Agent{Name: "node1", Address: "10.0.0.1:8082"})).To(Succeed())
+
+			out := []agent.Agent{}
+			Expect(s.Agents(&out)).To(Succeed())
+			Expect(out[0].Name).To(Equal("node1"))
+			Expect(out[1].Name).To(Equal("node2"))
+		})
+	})
+
+	Describe("Check", func() {
+		It("Should mark agents offline which missed their heartbeats", func() {
+			Expect(s.Heartbeat(&agent.Agent{Name: "node1", Address: "10.0.0.1:8082"})).To(Succeed())
+			Expect(s.Check()).To(Succeed())
+
+			out := []agent.Agent{}
+			Expect(s.Agents(&out)).To(Succeed())
+			Expect(out[0].Online).To(BeTrue())
+
+			time.Sleep(60 * time.Millisecond)
+			Expect(s.Check()).To(Succeed())
+			Expect(s.Check()).To(Succeed())
+
+			out = []agent.Agent{}
+			Expect(s.Agents(&out)).To(Succeed())
+			Expect(out[0].Online).To(BeFalse())
+			Eventually(rec.get).Should(HaveLen(2))
+
+			Expect(s.Heartbeat(&agent.Agent{Name: "node1", Address: "10.0.0.1:8082"})).To(Succeed())
+			Eventually(rec.get).Should(HaveLen(3))
+		})
+	})
+
+	Describe("RemoveAgent", func() {
+		It("Should unregister agents", func() {
+			Expect(s.RemoveAgent("node1")).To(Equal(agent.ErrAgentNotExist))
+
+			Expect(s.Heartbeat(&agent.Agent{Name: "node1", Address: "10.0.0.1:8082"})).To(Succeed())
+			Expect(s.RemoveAgent("node1")).To(Succeed())
+
+			out := []agent.Agent{}
+			Expect(s.Agents(&out)).To(Succeed())
+			Expect(out).To(BeEmpty())
+		})
+	})
+
+	Describe("Publish", func() {
+		BeforeEach(func() {
+			Expect(s.Heartbeat(&agent.Agent{Name: "node1", Address: "10.0.0.1:8082"})).To(Succeed())
+			Eventually(rec.get).Should(HaveLen(1))
+		})
+
+		It("Should only publish container events of registered agents", func() {
+			data := []byte(`{"refID":1,"containerID":"a","name":"web"}`)
+			Expect(s.Publish("node1", events.UserDeleted, data)).To(Equal(agent.ErrInvalidTopic))
+			Expect(s.Publish("node2", events.ContainerCreated, data)).To(Equal(agent.ErrAgentNotExist))
+			Expect(s.Publish("node1", events.ContainerCreated, []byte("{"))).NotTo(Succeed())
+
+			Expect(s.Publish("node1", events.ContainerCreated, data)).To(Succeed())
+			Eventually(rec.get).Should(HaveLen(2))
+			e := events.ContainerEvent{}
+			Expect(rec.get()[1].Topic).To(Equal(events.ContainerCreated))
+			Expect(rec.get()[1].Decode(&e)).To(Succeed())
+			Expect(e).To(Equal(events.ContainerEvent{RefID: 1, ContainerID: "a", Name: "web"}))
+		})
+
+		It("Should forward the container events of the bus of an agent", func() {
+			node := events.NewMemoryBus("node1", time.Millisecond, logger)
+			defer node.Close()
+			_, err := agent.Forward("node1", clientEndpoints(s), node, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(node.Publish(events.ContainerStopped, events.ContainerEvent{RefID: 1, ContainerID: "a"})).To(Succeed())
+			Expect(node.Publish(events.UserCreated, events.UserEvent{ID: 1})).To(Succeed())
+			Eventually(rec.get).Should(HaveLen(2))
+			Consistently(rec.get, 20*time.Millisecond).Should(HaveLen(2))
+			Expect(rec.get()[1].Topic).To(Equal(events.ContainerStopped))
+		})
+
+		It("Should deliver events again which could not be sent", func() {
+			failures := 2
+			e := clientEndpoints(s)
+			publish := e.PublishEndpoint
+			e.PublishEndpoint = func(ctx context.Context, request interface{}) (interface{}, error) {
+				if failures > 0 {
+					failures--
+					return nil, errors.New("unavailable")
+				}
+				return publish(ctx, request)
+			}
+
+			node := events.NewMemoryBus("node1", time.Millisecond, logger)
+			defer node.Close()
+			_, err := agent.Forward("node1", e, node, logger)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(node.Publish(events.ContainerRemoved, events.ContainerEvent{RefID: 1, ContainerID: "a"})).To(Succeed())
+			Eventually(rec.get).Should(HaveLen(2))
+		})
+	})
+
+	Describe("NewHeartbeat", func() {
+		It("Should register the agent through the endpoints", func() {
+			h := agent.NewHeartbeat(clientEndpoints(s), agent.Agent{
+				Name:     "node3",
+				Address:  "10.0.0.3:8082",
+				Cgroup:   "v2",
+				Runtime:  "runc",
+				Kernel:   "6.1.0-13-amd64",
+				CRIU:     "3.17.1",
+				Features: []string{"checkpoint"},
+			}, func() (uint, error) {
+				return 4, nil
+			}, logger)
+			Expect(h.Beat()).To(Succeed())
+
+			out := []agent.Agent{}
+			Expect(s.Agents(&out)).To(Succeed())
+			Expect(out).To(HaveLen(1))
+			Expect(out[0].Containers).To(BeEquivalentTo(4))
+			Expect(out[0].Cgroup).To(Equal("v2"))
+			Expect(out[0].Kernel).To(Equal("6.1.0-13-amd64"))
+			Expect(out[0].CRIU).To(Equal("3.17.1"))
+			Expect([]string(out[0].Features)).To(ConsistOf("checkpoint"))
+		})
+
+		It("Should return the errors of the control plane", func() {
+			e := &agent.Endpoints{
+				HeartbeatEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+					return &agent.HeartbeatResponse{Error: errors.New("denied")}, nil
+				},
+			}
+			h := agent.NewHeartbeat(e, agent.Agent{Name: "node3", Address: "10.0.0.3:8082", Cgroup: "v1"}, nil, logger)
+			Expect(h.Beat()).To(MatchError("denied"))
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/agent"
+	"github.com/kontainerooo/kontainer.ooo/pkg/agent/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *agent.Endpoints {
+
+	var HeartbeatEndpoint endpoint.Endpoint
+	{
+		HeartbeatEndpoint = grpctransport.NewClient(
+			conn,
+			"agent.AgentService",
+			"Heartbeat",
+			EncodeGRPCHeartbeatRequest,
+			DecodeGRPCHeartbeatResponse,
+			pb.HeartbeatResponse{},
+		).Endpoint()
+	}
+
+	var AgentsEndpoint endpoint.Endpoint
+	{
+		AgentsEndpoint = grpctransport.NewClient(
+			conn,
+			"agent.AgentService",
+			"Agents",
+			EncodeGRPCAgentsRequest,
+			DecodeGRPCAgentsResponse,
+			pb.AgentsResponse{},
+		).Endpoint()
+	}
+
+	var RemoveAgentEndpoint endpoint.Endpoint
+	{
+		RemoveAgentEndpoint = grpctransport.NewClient(
+			conn,
+			"agent.AgentService",
+			"RemoveAgent",
+			EncodeGRPCRemoveAgentRequest,
+			DecodeGRPCRemoveAgentResponse,
+			pb.RemoveAgentResponse{},
+		).Endpoint()
+	}
+
+	var PublishEndpoint endpoint.Endpoint
+	{
+		PublishEndpoint = grpctransport.NewClient(
+			conn,
+			"agent.AgentService",
+			"Publish",
+			EncodeGRPCPublishRequest,
+			DecodeGRPCPublishResponse,
+			pb.PublishResponse{},
+		).Endpoint()
+	}
+
+	return &agent.Endpoints{
+		HeartbeatEndpoint:   HeartbeatEndpoint,
+		AgentsEndpoint:      AgentsEndpoint,
+		RemoveAgentEndpoint: RemoveAgentEndpoint,
+		PublishEndpoint:     PublishEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCHeartbeatRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/agent.proto-domain heartbeat request to a gRPC Heartbeat request.
+func EncodeGRPCHeartbeatRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*agent.HeartbeatRequest)
+	return &pb.HeartbeatRequest{
+		Name:       req.Agent.Name,
+		Address:    req.Agent.Address,
+		Containers: uint32(req.Agent.Containers),
+		Cgroup:     req.Agent.Cgroup,
+		Runtime:    agent.ConvertRuntime(*req.Agent),
+	}, nil
+}
+
+// DecodeGRPCHeartbeatResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Heartbeat response to a messages/agent.proto-domain heartbeat response.
+func DecodeGRPCHeartbeatResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.HeartbeatResponse)
+	return &agent.HeartbeatResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCAgentsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/agent.proto-domain agents request to a gRPC Agents request.
+func EncodeGRPCAgentsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*agent.AgentsRequest)
+	return &pb.AgentsRequest{
+		Page: paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCAgentsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Agents response to a messages/agent.proto-domain agents response.
+func DecodeGRPCAgentsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.AgentsResponse)
+	agents := make([]agent.Agent, len(response.Agents))
+	for i, a := range response.Agents {
+		agents[i] = agent.ConvertPBAgent(a)
+	}
+
+	return &agent.AgentsResponse{
+		Agents: agents,
+		Error:  getError(response.Error),
+		Page:   paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCRemoveAgentRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/agent.proto-domain removeagent request to a gRPC RemoveAgent request.
+func EncodeGRPCRemoveAgentRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*agent.RemoveAgentRequest)
+	return &pb.RemoveAgentRequest{
+		Name: req.Name,
+	}, nil
+}
+
+// DecodeGRPCRemoveAgentResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveAgent response to a messages/agent.proto-domain removeagent response.
+func DecodeGRPCRemoveAgentResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveAgentResponse)
+	return &agent.RemoveAgentResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCPublishRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/agent.proto-domain publish request to a gRPC Publish request.
+func EncodeGRPCPublishRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*agent.PublishRequest)
+	return &pb.PublishRequest{
+		Name:  req.Name,
+		Topic: req.Topic,
+		Data:  req.Data,
+	}, nil
+}
+
+// DecodeGRPCPublishResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Publish response to a messages/agent.proto-domain publish response.
+func DecodeGRPCPublishResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.PublishResponse)
+	return &agent.PublishResponse{
+		Error: getError(response.Error),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Agent is a worker node running krooagent, which serves the container, firewall and network
+// services of the node
+type Agent struct {
+	Name string `gorm:"primary_key" validate:"required,name"`
+	// Address is the gRPC address the services of the node are served at
+	Address string `validate:"required"`
+	// Containers is the number of running instances reported with the last heartbeat
+	Containers uint
+	// Cgroup is the cgroup hierarchy the containers of the node are limited in, v1, hybrid or v2
+	Cgroup string
+	// Runtime is the runtime the containers of the node are run with and RuntimeVersion its version
+	Runtime        string
+	RuntimeVersion string
+	// Kernel is the release of the kernel of the node
+	Kernel string
+	// Storage is the filesystem the containers of the node are stored on
+	Storage string
+	// CRIU is the version of CRIU if checkpoints are supported on the node, GPU the version of its NVIDIA driver
+	CRIU string
+	GPU  string
+	// Features are the optional features the runtime of the node supports, like checkpoint or gpu
+	Features pq.StringArray `sql:"type:text[]"`
+	// Online is unset once the agent missed its heartbeats for the timeout of the control plane
+	Online   bool
+	LastSeen time.Time
+}
+
+// TableName sets Agent's database table name
+func (Agent) TableName() string {
+	return "agents"
+}
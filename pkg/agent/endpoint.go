@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the agent service
+type Endpoints struct {
+	HeartbeatEndpoint   endpoint.Endpoint
+	AgentsEndpoint      endpoint.Endpoint
+	RemoveAgentEndpoint endpoint.Endpoint
+	PublishEndpoint     endpoint.Endpoint
+}
+
+// HeartbeatRequest is the request struct for the HeartbeatEndpoint
+type HeartbeatRequest struct {
+	Agent *Agent `validate:"required"`
+}
+
+// HeartbeatResponse is the response struct for the HeartbeatEndpoint
+type HeartbeatResponse struct {
+	Error error
+}
+
+// MakeHeartbeatEndpoint creates a gokit endpoint which invokes Heartbeat
+func MakeHeartbeatEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(HeartbeatRequest)
+		err := s.Heartbeat(req.Agent)
+		return HeartbeatResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// AgentsRequest is the request struct for the AgentsEndpoint
+type AgentsRequest struct {
+	Page paging.Request
+}
+
+// AgentsResponse is the response struct for the AgentsEndpoint
+type AgentsResponse struct {
+	Agents []Agent
+	Error  error
+	Page   paging.Response
+}
+
+// MakeAgentsEndpoint creates a gokit endpoint which invokes Agents
+func MakeAgentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AgentsRequest)
+		agents := []Agent{}
+		err := s.Agents(&agents)
+		if err != nil {
+			return AgentsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&agents, "Name", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return AgentsResponse{
+			Agents: agents,
+			Page:   page,
+		}, nil
+	}
+}
+
+// RemoveAgentRequest is the request struct for the RemoveAgentEndpoint
+type RemoveAgentRequest struct {
+	Name string `validate:"required"`
+}
+
+// RemoveAgentResponse is the response struct for the RemoveAgentEndpoint
+type RemoveAgentResponse struct {
+	Error error
+}
+
+// MakeRemoveAgentEndpoint creates a gokit endpoint which invokes RemoveAgent
+func MakeRemoveAgentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveAgentRequest)
+		err := s.RemoveAgent(req.Name)
+		return RemoveAgentResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// PublishRequest is the request struct for the PublishEndpoint
+type PublishRequest struct {
+	Name  string `validate:"required"`
+	Topic string `validate:"required"`
+	Data  []byte
+}
+
+// PublishResponse is the response struct for the PublishEndpoint
+type PublishResponse struct {
+	Error error
+}
+
+// MakePublishEndpoint creates a gokit endpoint which invokes Publish
+func MakePublishEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PublishRequest)
+		err := s.Publish(req.Name, req.Topic, req.Data)
+		return PublishResponse{
+			Error: err,
+		}, nil
+	}
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// Monitor marks the agents which stopped sending heartbeats offline, it runs on the control plane
+type Monitor struct {
+	s      Service
+	logger log.Logger
+}
+
+// Run calls Check every interval until stop is closed
+func (m *Monitor) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			err := m.s.Check()
+			if err != nil {
+				level.Error(m.logger).Log("err", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewMonitor returns a Monitor for the agents of s
+func NewMonitor(s Service, logger log.Logger) *Monitor {
+	return &Monitor{
+		s:      s,
+		logger: logger,
+	}
+}
+
+// Heartbeat registers an agent with the control plane and renews the registration, it runs on the agent.
+// Containers returns the number of running instances which is reported with every heartbeat.
+type Heartbeat struct {
+	e          *Endpoints
+	agent      Agent
+	containers func() (uint, error)
+	logger     log.Logger
+}
+
+// Beat sends one heartbeat
+func (h *Heartbeat) Beat() error {
+	a := h.agent
+	if h.containers != nil {
+		n, err := h.containers()
+		if err != nil {
+			level.Warn(h.logger).Log("containers", "count", "err", err)
+		}
+		a.Containers = n
+	}
+
+	res, err := h.e.HeartbeatEndpoint(context.Background(), &HeartbeatRequest{
+		Agent: &a,
+	})
+	if err != nil {
+		return err
+	}
+	return res.(*HeartbeatResponse).Error
+}
+
+// Run sends a heartbeat right away and then every interval until stop is closed
+func (h *Heartbeat) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		err := h.Beat()
+		if err != nil {
+			level.Error(h.logger).Log("heartbeat", h.agent.Name, "err", err)
+		}
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewHeartbeat returns a Heartbeat of the agent a, which names the node, the address its services are served at
+// and its container runtime, sent to the control plane through e, which are the endpoints of a client
+func NewHeartbeat(e *Endpoints, a Agent, containers func() (uint, error), logger log.Logger) *Heartbeat {
+	return &Heartbeat{
+		e:          e,
+		agent:      a,
+		containers: containers,
+		logger:     logger,
+	}
+}
+
+// Forward publishes the container events of the bus of the agent name on the bus of the control plane,
+// e are the endpoints of a client. An event is delivered again if the control plane could not be reached,
+// events it rejects are dropped.
+func Forward(name string, e *Endpoints, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	sub, err := bus.Subscribe(events.ContainerEvents, "", func(ev events.Event) error {
+		res, err := e.PublishEndpoint(context.Background(), &PublishRequest{
+			Name:  name,
+			Topic: ev.Topic,
+			Data:  ev.Data,
+		})
+		if err != nil {
+			level.Error(logger).Log("event", ev.ID, "topic", ev.Topic, "err", err)
+			return err
+		}
+
+		err = res.(*PublishResponse).Error
+		if err != nil {
+			level.Error(logger).Log("event", ev.ID, "topic", ev.Topic, "err", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []events.Subscription{sub}, nil
+}
@@ -0,0 +1,223 @@
+// Package agent lets worker nodes run krooagent instead of a whole daemon. Agents serve the
+// container, firewall and network services of their node, register with the daemon of the control
+// plane by sending heartbeats and forward the container events of their node to it.
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+var (
+	// ErrAgentNotExist occurs if an agent is not registered
+	ErrAgentNotExist = errors.New("agent does not exist")
+
+	// ErrInvalidTopic occurs if an agent publishes an event which is not a container event
+	ErrInvalidTopic = errors.New("agents may only publish container events")
+)
+
+// Service AgentService
+type Service interface {
+	// Heartbeat registers an agent or renews its registration, the agent is online until it
+	// misses its heartbeats for the timeout
+	Heartbeat(a *Agent) error
+
+	// Agents returns every registered agent ordered by name
+	Agents(out *[]Agent) error
+
+	// RemoveAgent unregisters an agent, e.g. once its node was shut down for good
+	RemoveAgent(name string) error
+
+	// Publish publishes a container event observed on the agent name on the bus of the control plane
+	Publish(name, topic string, data []byte) error
+
+	// Check marks the agents whose last heartbeat is older than the timeout offline
+	Check() error
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	bus     events.Bus
+	timeout time.Duration
+	mtx     *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.AutoMigrate(&Agent{})
+}
+
+func (s *service) getAgent(name string) (Agent, error) {
+	a := Agent{}
+	err := s.db.First(&a, "name = ?", name)
+	if s.db.IsNotFound(err) {
+		return Agent{}, ErrAgentNotExist
+	}
+	if err != nil {
+		return Agent{}, err
+	}
+	return a, nil
+}
+
+// save replaces the stored agent, updates would skip the fields which are unset
+func (s *service) save(a *Agent) error {
+	s.db.Begin()
+	err := s.db.Delete(&Agent{Name: a.Name})
+	if err != nil && !s.db.IsNotFound(err) {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Create(a)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+	return nil
+}
+
+func (s *service) publish(a Agent) error {
+	if s.bus == nil {
+		return nil
+	}
+
+	return s.bus.Publish(events.AgentChanged, events.AgentEvent{
+		Name:    a.Name,
+		Address: a.Address,
+		Online:  a.Online,
+	})
+}
+
+func (s *service) Heartbeat(a *Agent) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	old, err := s.getAgent(a.Name)
+	if err != nil && err != ErrAgentNotExist {
+		return err
+	}
+
+	a.Online = true
+	a.LastSeen = time.Now()
+	err = s.save(a)
+	if err != nil {
+		return err
+	}
+
+	if old.Online && old.Address == a.Address {
+		return nil
+	}
+	return s.publish(*a)
+}
+
+func (s *service) Agents(out *[]Agent) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.FindOrdered(out, "name", 0)
+}
+
+func (s *service) RemoveAgent(name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	a, err := s.getAgent(name)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Delete(&Agent{Name: name})
+	if err != nil {
+		return err
+	}
+
+	if !a.Online {
+		return nil
+	}
+	a.Online = false
+	return s.publish(a)
+}
+
+func (s *service) Publish(name, topic string, data []byte) error {
+	if !events.Match(events.ContainerEvents, topic) {
+		return ErrInvalidTopic
+	}
+
+	e := events.ContainerEvent{}
+	err := json.Unmarshal(data, &e)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	_, err = s.getAgent(name)
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if s.bus == nil {
+		return nil
+	}
+	return s.bus.Publish(topic, e)
+}
+
+func (s *service) Check() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	stale := []Agent{}
+	err := s.db.Find(&stale, "online = ? AND last_seen <= ?", true, time.Now().Add(-s.timeout))
+	if err != nil {
+		return err
+	}
+
+	for _, a := range stale {
+		a.Online = false
+		err = s.save(&a)
+		if err != nil {
+			return err
+		}
+		err = s.publish(a)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewService creates an AgentService with necessary dependencies. Agents are offline once
+// they did not send a heartbeat for timeout, bus may be nil if nobody is notified.
+func NewService(db dbAdapter, bus events.Bus, timeout time.Duration) (Service, error) {
+	s := &service{
+		db:      db,
+		bus:     bus,
+		timeout: timeout,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,244 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/agent/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC AgentServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.AgentServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		heartbeat: grpctransport.NewServer(
+			endpoints.HeartbeatEndpoint,
+			DecodeGRPCHeartbeatRequest,
+			EncodeGRPCHeartbeatResponse,
+			options...,
+		),
+
+		agents: grpctransport.NewServer(
+			endpoints.AgentsEndpoint,
+			DecodeGRPCAgentsRequest,
+			EncodeGRPCAgentsResponse,
+			options...,
+		),
+
+		removeAgent: grpctransport.NewServer(
+			endpoints.RemoveAgentEndpoint,
+			DecodeGRPCRemoveAgentRequest,
+			EncodeGRPCRemoveAgentResponse,
+			options...,
+		),
+
+		publish: grpctransport.NewServer(
+			endpoints.PublishEndpoint,
+			DecodeGRPCPublishRequest,
+			EncodeGRPCPublishResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	heartbeat   grpctransport.Handler
+	agents      grpctransport.Handler
+	removeAgent grpctransport.Handler
+	publish     grpctransport.Handler
+}
+
+func (s *grpcServer) Heartbeat(ctx oldcontext.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	_, res, err := s.heartbeat.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.HeartbeatResponse), nil
+}
+
+func (s *grpcServer) Agents(ctx oldcontext.Context, req *pb.AgentsRequest) (*pb.AgentsResponse, error) {
+	_, res, err := s.agents.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.AgentsResponse), nil
+}
+
+func (s *grpcServer) RemoveAgent(ctx oldcontext.Context, req *pb.RemoveAgentRequest) (*pb.RemoveAgentResponse, error) {
+	_, res, err := s.removeAgent.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveAgentResponse), nil
+}
+
+func (s *grpcServer) Publish(ctx oldcontext.Context, req *pb.PublishRequest) (*pb.PublishResponse, error) {
+	_, res, err := s.publish.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.PublishResponse), nil
+}
+
+// ConvertAgent converts an Agent to its protobuf representation
+func ConvertAgent(a Agent) *pb.Agent {
+	return &pb.Agent{
+		Name:       a.Name,
+		Address:    a.Address,
+		Containers: uint32(a.Containers),
+		Cgroup:     a.Cgroup,
+		Runtime:    ConvertRuntime(a),
+		Online:     a.Online,
+		LastSeen:   a.LastSeen.Unix(),
+	}
+}
+
+// ConvertRuntime converts the runtime of an Agent to its protobuf representation
+func ConvertRuntime(a Agent) *pb.Runtime {
+	return &pb.Runtime{
+		Runtime:        a.Runtime,
+		RuntimeVersion: a.RuntimeVersion,
+		Kernel:         a.Kernel,
+		Storage:        a.Storage,
+		Criu:           a.CRIU,
+		Gpu:            a.GPU,
+		Features:       a.Features,
+	}
+}
+
+// SetPBRuntime sets the runtime of a to the protobuf runtime r
+func SetPBRuntime(a *Agent, r *pb.Runtime) {
+	if r == nil {
+		return
+	}
+
+	a.Runtime = r.Runtime
+	a.RuntimeVersion = r.RuntimeVersion
+	a.Kernel = r.Kernel
+	a.Storage = r.Storage
+	a.CRIU = r.Criu
+	a.GPU = r.Gpu
+	a.Features = r.Features
+}
+
+// ConvertPBAgent converts a protobuf Agent to an Agent
+func ConvertPBAgent(a *pb.Agent) Agent {
+	if a == nil {
+		return Agent{}
+	}
+
+	agent := Agent{
+		Name:       a.Name,
+		Address:    a.Address,
+		Containers: uint(a.Containers),
+		Cgroup:     a.Cgroup,
+		Online:     a.Online,
+		LastSeen:   time.Unix(a.LastSeen, 0).UTC(),
+	}
+	SetPBRuntime(&agent, a.Runtime)
+	return agent
+}
+
+// DecodeGRPCHeartbeatRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Heartbeat request to a messages/agent.proto-domain heartbeat request.
+func DecodeGRPCHeartbeatRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.HeartbeatRequest)
+	a := &Agent{
+		Name:       req.Name,
+		Address:    req.Address,
+		Containers: uint(req.Containers),
+		Cgroup:     req.Cgroup,
+	}
+	SetPBRuntime(a, req.Runtime)
+	return HeartbeatRequest{
+		Agent: a,
+	}, nil
+}
+
+// EncodeGRPCHeartbeatResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/agent.proto-domain heartbeat response to a gRPC Heartbeat response.
+func EncodeGRPCHeartbeatResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(HeartbeatResponse)
+	gRPCRes := &pb.HeartbeatResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCAgentsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Agents request to a messages/agent.proto-domain agents request.
+func DecodeGRPCAgentsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AgentsRequest)
+	return AgentsRequest{
+		Page: paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCAgentsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/agent.proto-domain agents response to a gRPC Agents response.
+func EncodeGRPCAgentsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(AgentsResponse)
+	agents := make([]*pb.Agent, len(res.Agents))
+	for i, a := range res.Agents {
+		agents[i] = ConvertAgent(a)
+	}
+
+	gRPCRes := &pb.AgentsResponse{
+		Agents:   agents,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveAgentRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveAgent request to a messages/agent.proto-domain removeagent request.
+func DecodeGRPCRemoveAgentRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveAgentRequest)
+	return RemoveAgentRequest{
+		Name: req.Name,
+	}, nil
+}
+
+// EncodeGRPCRemoveAgentResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/agent.proto-domain removeagent response to a gRPC RemoveAgent response.
+func EncodeGRPCRemoveAgentResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveAgentResponse)
+	gRPCRes := &pb.RemoveAgentResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCPublishRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Publish request to a messages/agent.proto-domain publish request.
+func DecodeGRPCPublishRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PublishRequest)
+	return PublishRequest{
+		Name:  req.Name,
+		Topic: req.Topic,
+		Data:  req.Data,
+	}, nil
+}
+
+// EncodeGRPCPublishResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/agent.proto-domain publish response to a gRPC Publish response.
+func EncodeGRPCPublishResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(PublishResponse)
+	gRPCRes := &pb.PublishResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
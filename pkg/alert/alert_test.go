@@ -0,0 +1,256 @@
+package alert_test
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/alert"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type notification struct {
+	refID    uint
+	template string
+	data     mail.AlertData
+}
+
+type mockMails struct {
+	sent []notification
+}
+
+func (m *mockMails) Notify(refID uint, template string, data interface{}) (uint, error) {
+	m.sent = append(m.sent, notification{refID: refID, template: template, data: data.(mail.AlertData)})
+	return uint(len(m.sent)), nil
+}
+
+type recorder struct {
+	mtx    sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) handle(e events.Event) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) topics() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	ts := []string{}
+	for _, e := range r.events {
+		ts = append(ts, e.Topic)
+	}
+	return ts
+}
+
+func states(as []alert.Alert) []string {
+	ss := []string{}
+	for _, a := range as {
+		ss = append(ss, a.Instance+" "+a.State)
+	}
+	return ss
+}
+
+var _ = Describe("Alert", func() {
+	var (
+		refID = uint(1)
+		s     alert.Service
+	)
+
+	BeforeEach(func() {
+		var err error
+		s, err = alert.NewService(testutils.NewMockDB(), alert.Options{
+			MaxRules: 2,
+			History:  3,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("Create Service", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := alert.NewService(db, alert.Options{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Rules", func() {
+		It("Should create and remove rules", func() {
+			r := &alert.Rule{Name: "cpu", Metric: alert.CPU, Threshold: 90, For: 300}
+			Ω(s.CreateRule(refID, r)).Should(Succeed())
+			Expect(r.ID).NotTo(BeZero())
+
+			Expect(s.CreateRule(refID, &alert.Rule{Name: "cpu", Metric: alert.CPU})).To(Equal(alert.ErrRuleExists))
+			Ω(s.CreateRule(refID, &alert.Rule{Name: "memory", Metric: alert.Memory})).Should(Succeed())
+			Expect(s.CreateRule(refID, &alert.Rule{Name: "restarts", Metric: alert.Restarts})).To(Equal(alert.ErrLimit))
+			Ω(s.CreateRule(2, &alert.Rule{Name: "cpu", Metric: alert.CPU})).Should(Succeed())
+
+			Expect(s.RemoveRule(2, r.ID)).To(Equal(alert.ErrRuleNotExist))
+			Ω(s.RemoveRule(refID, r.ID)).Should(Succeed())
+			rs := []alert.Rule{}
+			Ω(s.Rules(refID, &rs)).Should(Succeed())
+			Expect(rs).To(HaveLen(1))
+			Expect(rs[0].Name).To(Equal("memory"))
+		})
+
+		It("Should remove the rules of a removed instance", func() {
+			s.CreateRule(refID, &alert.Rule{Name: "web", Instance: "web", Metric: alert.CPU})
+			s.CreateRule(refID, &alert.Rule{Name: "all", Metric: alert.CPU})
+			s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 10}}, time.Now())
+
+			Ω(s.RemoveInstanceRules(refID, "web")).Should(Succeed())
+			rs := []alert.Rule{}
+			s.Rules(refID, &rs)
+			Expect(rs).To(HaveLen(1))
+			Expect(rs[0].Name).To(Equal("all"))
+			ss := []alert.State{}
+			s.States(refID, &ss)
+			Expect(ss).To(BeEmpty())
+		})
+	})
+
+	Describe("Evaluate", func() {
+		now := time.Now()
+
+		It("Should fire once the threshold was exceeded for the duration", func() {
+			s.CreateRule(refID, &alert.Rule{Name: "cpu", Metric: alert.CPU, Threshold: 90, For: 300})
+
+			as, err := s.Evaluate([]alert.Sample{
+				{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 95},
+				{RefID: refID, Instance: "db", Metric: alert.CPU, Value: 20},
+				{RefID: 2, Instance: "web", Metric: alert.CPU, Value: 99},
+			}, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(as).To(BeEmpty())
+			ss := []alert.State{}
+			s.States(refID, &ss)
+			Expect(ss).To(HaveLen(1))
+			Expect(ss[0].State).To(Equal(alert.Pending))
+
+			as, _ = s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 97}}, now.Add(4*time.Minute))
+			Expect(as).To(BeEmpty())
+
+			as, _ = s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 98}}, now.Add(5*time.Minute))
+			Expect(states(as)).To(Equal([]string{"web firing"}))
+			Expect(as[0].Value).To(Equal(98.0))
+
+			as, _ = s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 99}}, now.Add(6*time.Minute))
+			Expect(as).To(BeEmpty())
+
+			as, _ = s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 50}}, now.Add(7*time.Minute))
+			Expect(states(as)).To(Equal([]string{"web resolved"}))
+
+			all := []alert.Alert{}
+			Ω(s.Alerts(refID, &all)).Should(Succeed())
+			Expect(states(all)).To(Equal([]string{"web resolved", "web firing"}))
+			ss = []alert.State{}
+			s.States(refID, &ss)
+			Expect(ss).To(BeEmpty())
+		})
+
+		It("Should not fire if the threshold is not exceeded for the duration", func() {
+			s.CreateRule(refID, &alert.Rule{Name: "cpu", Instance: "web", Metric: alert.CPU, Threshold: 90, For: 300})
+
+			s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 95}}, now)
+			s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 50}}, now.Add(time.Minute))
+			as, _ := s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 95}}, now.Add(5*time.Minute))
+			Expect(as).To(BeEmpty())
+		})
+
+		It("Should keep the latest alerts", func() {
+			s.CreateRule(refID, &alert.Rule{Name: "restarts", Metric: alert.Restarts, Threshold: 3})
+
+			for i := 0; i < 3; i++ {
+				s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.Restarts, Value: 4}}, now)
+				s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.Restarts, Value: 0}}, now)
+			}
+
+			all := []alert.Alert{}
+			s.Alerts(refID, &all)
+			Expect(states(all)).To(Equal([]string{"web resolved", "web firing", "web resolved"}))
+		})
+	})
+
+	Describe("Sampler", func() {
+		It("Should derive the metrics from the usage", func() {
+			usage := []alert.Usage{
+				{RefID: refID, Instance: "web", CPU: 0, Memory: 50, MemoryLimit: 100, Started: "1"},
+			}
+			sm := alert.NewSampler(func() ([]alert.Usage, error) {
+				return usage, nil
+			})
+			now := time.Now()
+
+			samples, err := sm.Sample(now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(samples).To(ConsistOf(
+				alert.Sample{RefID: refID, Instance: "web", Metric: alert.Restarts, Value: 0},
+				alert.Sample{RefID: refID, Instance: "web", Metric: alert.Memory, Value: 50},
+			))
+
+			usage[0].CPU = uint64(15 * time.Second)
+			samples, _ = sm.Sample(now.Add(30 * time.Second))
+			Expect(samples).To(ContainElement(alert.Sample{RefID: refID, Instance: "web", Metric: alert.CPU, Value: 50}))
+
+			usage[0].Started = "2"
+			samples, _ = sm.Sample(now.Add(time.Minute))
+			Expect(samples).To(ContainElement(alert.Sample{RefID: refID, Instance: "web", Metric: alert.Restarts, Value: 1}))
+
+			samples, _ = sm.Sample(now.Add(2 * time.Hour))
+			Expect(samples).To(ContainElement(alert.Sample{RefID: refID, Instance: "web", Metric: alert.Restarts, Value: 0}))
+		})
+
+		It("Should derive the drops per minute", func() {
+			usage := []alert.Usage{
+				{RefID: refID, Instance: "web", Started: "1", Dropped: 10},
+			}
+			sm := alert.NewSampler(func() ([]alert.Usage, error) {
+				return usage, nil
+			})
+			now := time.Now()
+
+			samples, err := sm.Sample(now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(samples).To(ConsistOf(alert.Sample{RefID: refID, Instance: "web", Metric: alert.Restarts, Value: 0}))
+
+			usage[0].Dropped = 70
+			samples, _ = sm.Sample(now.Add(30 * time.Second))
+			Expect(samples).To(ContainElement(alert.Sample{RefID: refID, Instance: "web", Metric: alert.Drops, Value: 120}))
+		})
+	})
+
+	Describe("Events", func() {
+		It("Should publish the transitions and notify the user", func() {
+			logger := log.NewNopLogger()
+			bus := events.NewMemoryBus("node1", time.Millisecond, logger)
+			defer bus.Close()
+			rec := &recorder{}
+			bus.Subscribe(events.AlertEvents, "", rec.handle)
+
+			mails := &mockMails{}
+			s = alert.NewEventService(s, bus, mails, logger)
+			s.CreateRule(refID, &alert.Rule{Name: "quiet", Metric: alert.Memory, Threshold: 80})
+			s.CreateRule(refID, &alert.Rule{Name: "loud", Metric: alert.Memory, Threshold: 90, Notify: true})
+
+			now := time.Now()
+			s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.Memory, Value: 95}}, now)
+			s.Evaluate([]alert.Sample{{RefID: refID, Instance: "web", Metric: alert.Memory, Value: 10}}, now)
+
+			Eventually(rec.topics).Should(ConsistOf(events.AlertFiring, events.AlertFiring, events.AlertResolved, events.AlertResolved))
+			Expect(mails.sent).To(HaveLen(2))
+			Expect(mails.sent[0].template).To(Equal(mail.AlertFiring))
+			Expect(mails.sent[0].data.Rule).To(Equal("loud"))
+			Expect(mails.sent[0].data.Value).To(Equal(95.0))
+			Expect(mails.sent[1].template).To(Equal(mail.AlertResolved))
+		})
+	})
+})
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/alert"
+	"github.com/kontainerooo/kontainer.ooo/pkg/alert/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *alert.Endpoints {
+
+	var CreateRuleEndpoint endpoint.Endpoint
+	{
+		CreateRuleEndpoint = grpctransport.NewClient(
+			conn,
+			"alert.AlertService",
+			"CreateRule",
+			EncodeGRPCCreateRuleRequest,
+			DecodeGRPCCreateRuleResponse,
+			pb.CreateRuleResponse{},
+		).Endpoint()
+	}
+
+	var RemoveRuleEndpoint endpoint.Endpoint
+	{
+		RemoveRuleEndpoint = grpctransport.NewClient(
+			conn,
+			"alert.AlertService",
+			"RemoveRule",
+			EncodeGRPCRemoveRuleRequest,
+			DecodeGRPCRemoveRuleResponse,
+			pb.RemoveRuleResponse{},
+		).Endpoint()
+	}
+
+	var RulesEndpoint endpoint.Endpoint
+	{
+		RulesEndpoint = grpctransport.NewClient(
+			conn,
+			"alert.AlertService",
+			"Rules",
+			EncodeGRPCRulesRequest,
+			DecodeGRPCRulesResponse,
+			pb.RulesResponse{},
+		).Endpoint()
+	}
+
+	var StatesEndpoint endpoint.Endpoint
+	{
+		StatesEndpoint = grpctransport.NewClient(
+			conn,
+			"alert.AlertService",
+			"States",
+			EncodeGRPCStatesRequest,
+			DecodeGRPCStatesResponse,
+			pb.StatesResponse{},
+		).Endpoint()
+	}
+
+	var AlertsEndpoint endpoint.Endpoint
+	{
+		AlertsEndpoint = grpctransport.NewClient(
+			conn,
+			"alert.AlertService",
+			"Alerts",
+			EncodeGRPCAlertsRequest,
+			DecodeGRPCAlertsResponse,
+			pb.AlertsResponse{},
+		).Endpoint()
+	}
+
+	return &alert.Endpoints{
+		CreateRuleEndpoint: CreateRuleEndpoint,
+		RemoveRuleEndpoint: RemoveRuleEndpoint,
+		RulesEndpoint:      RulesEndpoint,
+		StatesEndpoint:     StatesEndpoint,
+		AlertsEndpoint:     AlertsEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreateRuleRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain createrule request to a gRPC CreateRule request.
+func EncodeGRPCCreateRuleRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*alert.CreateRuleRequest)
+	return &pb.CreateRuleRequest{
+		RefID:     uint32(req.RefID),
+		Name:      req.Rule.Name,
+		Instance:  req.Rule.Instance,
+		Metric:    req.Rule.Metric,
+		Threshold: req.Rule.Threshold,
+		For:       uint32(req.Rule.For),
+		Notify:    req.Rule.Notify,
+	}, nil
+}
+
+// DecodeGRPCCreateRuleResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateRule response to a messages/alert.proto-domain createrule response.
+func DecodeGRPCCreateRuleResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateRuleResponse)
+	return &alert.CreateRuleResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveRuleRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain removerule request to a gRPC RemoveRule request.
+func EncodeGRPCRemoveRuleRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*alert.RemoveRuleRequest)
+	return &pb.RemoveRuleRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveRuleResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveRule response to a messages/alert.proto-domain removerule response.
+func DecodeGRPCRemoveRuleResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveRuleResponse)
+	return &alert.RemoveRuleResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRulesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain rules request to a gRPC Rules request.
+func EncodeGRPCRulesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*alert.RulesRequest)
+	return &pb.RulesRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCRulesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Rules response to a messages/alert.proto-domain rules response.
+func DecodeGRPCRulesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RulesResponse)
+	rules := make([]alert.Rule, len(response.Rules))
+	for i, r := range response.Rules {
+		rules[i] = alert.ConvertPBRule(r)
+	}
+
+	return &alert.RulesResponse{
+		Rules: rules,
+		Error: getError(response.Error),
+		Page:  paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCStatesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain states request to a gRPC States request.
+func EncodeGRPCStatesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*alert.StatesRequest)
+	return &pb.StatesRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCStatesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC States response to a messages/alert.proto-domain states response.
+func DecodeGRPCStatesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.StatesResponse)
+	states := make([]alert.State, len(response.States))
+	for i, s := range response.States {
+		states[i] = alert.ConvertPBState(s)
+	}
+
+	return &alert.StatesResponse{
+		States: states,
+		Error:  getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCAlertsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain alerts request to a gRPC Alerts request.
+func EncodeGRPCAlertsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*alert.AlertsRequest)
+	return &pb.AlertsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCAlertsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Alerts response to a messages/alert.proto-domain alerts response.
+func DecodeGRPCAlertsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.AlertsResponse)
+	alerts := make([]alert.Alert, len(response.Alerts))
+	for i, a := range response.Alerts {
+		alerts[i] = alert.ConvertPBAlert(a)
+	}
+
+	return &alert.AlertsResponse{
+		Alerts: alerts,
+		Error:  getError(response.Error),
+		Page:   paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
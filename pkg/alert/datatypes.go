@@ -0,0 +1,91 @@
+package alert
+
+import (
+	"time"
+)
+
+// Rule fires an alert once a metric of an instance of a user exceeds a threshold for a duration
+type Rule struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	Name  string `validate:"required,name"`
+	// Instance is the name of the instance the rule applies to, it applies to every instance of the user if it is empty
+	Instance string `validate:"name"`
+	// Metric is cpu, memory, restarts or drops
+	Metric string `validate:"required,oneof=cpu|memory|restarts|drops"`
+	// Threshold is exceeded by greater values
+	Threshold float64
+	// For is the number of seconds the threshold has to be exceeded before the alert fires
+	For uint
+	// Notify sends an email to the user whenever the alert fires or is resolved
+	Notify    bool
+	CreatedAt time.Time
+}
+
+// TableName sets Rule's database table name
+func (Rule) TableName() string {
+	return "alert_rules"
+}
+
+// State is the state of a rule for an instance which exceeds its threshold, instances within the
+// threshold have no state
+type State struct {
+	ID       uint `gorm:"primary_key"`
+	RuleID   uint
+	RefID    uint
+	Instance string
+	// State is pending until the threshold was exceeded for the duration of the rule, then firing
+	State string
+	// Value is the last sampled value
+	Value float64
+	// Since is when the instance entered the state
+	Since time.Time
+}
+
+// TableName sets State's database table name
+func (State) TableName() string {
+	return "alert_states"
+}
+
+// Alert is a transition of a rule for an instance to firing or resolved
+type Alert struct {
+	ID        uint `gorm:"primary_key"`
+	RuleID    uint
+	RefID     uint
+	Rule      string
+	Instance  string
+	Metric    string
+	Threshold float64
+	// Value is the sampled value which caused the transition
+	Value     float64
+	State     string
+	CreatedAt time.Time
+}
+
+// TableName sets Alert's database table name
+func (Alert) TableName() string {
+	return "alerts"
+}
+
+// Sample is the value of a metric of an instance
+type Sample struct {
+	RefID    uint
+	Instance string
+	Metric   string
+	Value    float64
+}
+
+// Usage is the resource usage of a running instance
+type Usage struct {
+	RefID    uint
+	Instance string
+	// CPU is the CPU time the instance used since it was started in nanoseconds
+	CPU uint64
+	// Memory is the memory the instance uses and MemoryLimit the most it may use in bytes
+	Memory      uint64
+	MemoryLimit uint64
+	// Started identifies the start of the instance, it changes once the instance restarts
+	Started string
+	// Dropped is the number of packets of the instance the firewall dropped since the node started
+	Dropped uint64
+}
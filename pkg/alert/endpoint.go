@@ -0,0 +1,170 @@
+package alert
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the alert service
+type Endpoints struct {
+	CreateRuleEndpoint endpoint.Endpoint
+	RemoveRuleEndpoint endpoint.Endpoint
+	RulesEndpoint      endpoint.Endpoint
+	StatesEndpoint     endpoint.Endpoint
+	AlertsEndpoint     endpoint.Endpoint
+}
+
+// CreateRuleRequest is the request struct for the CreateRuleEndpoint
+type CreateRuleRequest struct {
+	RefID uint  `bart:"ref"`
+	Rule  *Rule `validate:"required"`
+}
+
+// CreateRuleResponse is the response struct for the CreateRuleEndpoint
+type CreateRuleResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeCreateRuleEndpoint creates a gokit endpoint which invokes CreateRule
+func MakeCreateRuleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateRuleRequest)
+		err := s.CreateRule(req.RefID, req.Rule)
+		if err != nil {
+			return CreateRuleResponse{
+				Error: err,
+			}, nil
+		}
+		return CreateRuleResponse{
+			ID: req.Rule.ID,
+		}, nil
+	}
+}
+
+// RemoveRuleRequest is the request struct for the RemoveRuleEndpoint
+type RemoveRuleRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemoveRuleResponse is the response struct for the RemoveRuleEndpoint
+type RemoveRuleResponse struct {
+	Error error
+}
+
+// MakeRemoveRuleEndpoint creates a gokit endpoint which invokes RemoveRule
+func MakeRemoveRuleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveRuleRequest)
+		err := s.RemoveRule(req.RefID, req.ID)
+		return RemoveRuleResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RulesRequest is the request struct for the RulesEndpoint
+type RulesRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// RulesResponse is the response struct for the RulesEndpoint
+type RulesResponse struct {
+	Rules []Rule
+	Error error
+	Page  paging.Response
+}
+
+// MakeRulesEndpoint creates a gokit endpoint which invokes Rules
+func MakeRulesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RulesRequest)
+		rules := []Rule{}
+		err := s.Rules(req.RefID, &rules)
+		if err != nil {
+			return RulesResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&rules, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return RulesResponse{
+			Rules: rules,
+			Page:  page,
+		}, nil
+	}
+}
+
+// StatesRequest is the request struct for the StatesEndpoint
+type StatesRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// StatesResponse is the response struct for the StatesEndpoint
+type StatesResponse struct {
+	States []State
+	Error  error
+}
+
+// MakeStatesEndpoint creates a gokit endpoint which invokes States
+func MakeStatesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(StatesRequest)
+		states := []State{}
+		// a user without a reference would get the states of every user
+		if req.RefID == 0 {
+			return StatesResponse{
+				States: states,
+			}, nil
+		}
+
+		err := s.States(req.RefID, &states)
+		return StatesResponse{
+			States: states,
+			Error:  err,
+		}, nil
+	}
+}
+
+// AlertsRequest is the request struct for the AlertsEndpoint
+type AlertsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// AlertsResponse is the response struct for the AlertsEndpoint
+type AlertsResponse struct {
+	Alerts []Alert
+	Error  error
+	Page   paging.Response
+}
+
+// MakeAlertsEndpoint creates a gokit endpoint which invokes Alerts
+func MakeAlertsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AlertsRequest)
+		alerts := []Alert{}
+		err := s.Alerts(req.RefID, &alerts)
+		if err != nil {
+			return AlertsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&alerts, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return AlertsResponse{
+			Alerts: alerts,
+			Page:   page,
+		}, nil
+	}
+}
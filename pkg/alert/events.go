@@ -0,0 +1,127 @@
+package alert
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+)
+
+// EventGroup is the group the alert services subscribe in, so every event is handled once
+const EventGroup = "alert"
+
+// Notifier sends the emails of the alerts, it is satisfied by the mail service
+type Notifier interface {
+	Notify(refID uint, template string, data interface{}) (uint, error)
+}
+
+type eventService struct {
+	Service
+	bus    events.Bus
+	mails  Notifier
+	logger log.Logger
+}
+
+// notify sends the email of a to its user if the rule of a asks for it
+func (s *eventService) notify(a Alert) {
+	if s.mails == nil {
+		return
+	}
+
+	rs := []Rule{}
+	err := s.Service.Rules(a.RefID, &rs)
+	if err != nil {
+		level.Error(s.logger).Log("alert", a.ID, "err", err)
+		return
+	}
+
+	for _, r := range rs {
+		if r.ID != a.RuleID || !r.Notify {
+			continue
+		}
+
+		template := mail.AlertFiring
+		if a.State == Resolved {
+			template = mail.AlertResolved
+		}
+
+		_, err = s.mails.Notify(a.RefID, template, mail.AlertData{
+			Rule:      a.Rule,
+			Instance:  a.Instance,
+			Metric:    a.Metric,
+			Threshold: a.Threshold,
+			Value:     a.Value,
+			Time:      a.CreatedAt,
+		})
+		if err != nil && err != mail.ErrNoRecipient {
+			level.Error(s.logger).Log("alert", a.ID, "user", a.RefID, "err", err)
+		}
+	}
+}
+
+func (s *eventService) Evaluate(samples []Sample, now time.Time) ([]Alert, error) {
+	as, err := s.Service.Evaluate(samples, now)
+	for _, a := range as {
+		topic := events.AlertFiring
+		if a.State == Resolved {
+			topic = events.AlertResolved
+		}
+
+		publish := s.bus.Publish(topic, events.AlertEvent{
+			RefID:     a.RefID,
+			RuleID:    a.RuleID,
+			AlertID:   a.ID,
+			Rule:      a.Rule,
+			Instance:  a.Instance,
+			Metric:    a.Metric,
+			Threshold: a.Threshold,
+			Value:     a.Value,
+		})
+		if publish != nil {
+			level.Error(s.logger).Log("topic", topic, "alert", a.ID, "err", publish)
+		}
+
+		s.notify(a)
+	}
+	return as, err
+}
+
+// NewEventService returns a Service which publishes an event and, for the rules asking for it, sends an
+// email to the user whenever an alert fires or is resolved. mails may be nil if no emails are sent,
+// failing to publish or to send is only logged.
+func NewEventService(s Service, bus events.Bus, mails Notifier, logger log.Logger) Service {
+	return &eventService{
+		Service: s,
+		bus:     bus,
+		mails:   mails,
+		logger:  logger,
+	}
+}
+
+// Subscribe removes the rules and states of an instance or a replica once it was removed
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	sub, err := bus.Subscribe(events.ContainerRemoved, EventGroup, func(e events.Event) error {
+		c := events.ContainerEvent{}
+		err := e.Decode(&c)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+		if c.Name == "" {
+			return nil
+		}
+
+		err = s.RemoveInstanceRules(c.RefID, c.Name)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", c.RefID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []events.Subscription{sub}, nil
+}
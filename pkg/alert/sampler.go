@@ -0,0 +1,141 @@
+package alert
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// restartWindow is the period the restarts of an instance are counted over
+const restartWindow = time.Hour
+
+// UsageFunc returns the resource usage of the instances running on this node
+type UsageFunc func() ([]Usage, error)
+
+type previous struct {
+	cpu      uint64
+	dropped  uint64
+	started  string
+	time     time.Time
+	restarts []time.Time
+}
+
+// Sampler turns the resource usage of the instances on this node into samples of the metrics. The CPU
+// usage, the drops and the restarts are derived from the previous usage, so it has to be sampled regularly.
+type Sampler struct {
+	usage UsageFunc
+	last  map[string]*previous
+	mtx   *sync.Mutex
+}
+
+// Sample returns the samples of the running instances, the CPU usage and the drops of an instance are sampled
+// from its second sample on
+func (s *Sampler) Sample(now time.Time) ([]Sample, error) {
+	us, err := s.usage()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	samples := []Sample{}
+	for _, u := range us {
+		key := fmt.Sprintf("%d/%s", u.RefID, u.Instance)
+		p, ok := s.last[key]
+		if ok && now.After(p.time) && u.Dropped >= p.dropped {
+			samples = append(samples, Sample{
+				RefID:    u.RefID,
+				Instance: u.Instance,
+				Metric:   Drops,
+				Value:    float64(u.Dropped-p.dropped) / now.Sub(p.time).Minutes(),
+			})
+		}
+
+		switch {
+		case !ok:
+			p = &previous{}
+			s.last[key] = p
+		case p.started != u.Started:
+			p.restarts = append(p.restarts, now)
+		case now.After(p.time) && u.CPU >= p.cpu:
+			samples = append(samples, Sample{
+				RefID:    u.RefID,
+				Instance: u.Instance,
+				Metric:   CPU,
+				Value:    float64(u.CPU-p.cpu) / float64(now.Sub(p.time).Nanoseconds()) * 100,
+			})
+		}
+		p.cpu, p.dropped, p.started, p.time = u.CPU, u.Dropped, u.Started, now
+
+		for len(p.restarts) > 0 && now.Sub(p.restarts[0]) > restartWindow {
+			p.restarts = p.restarts[1:]
+		}
+		samples = append(samples, Sample{
+			RefID:    u.RefID,
+			Instance: u.Instance,
+			Metric:   Restarts,
+			Value:    float64(len(p.restarts)),
+		})
+
+		if u.MemoryLimit > 0 {
+			samples = append(samples, Sample{
+				RefID:    u.RefID,
+				Instance: u.Instance,
+				Metric:   Memory,
+				Value:    float64(u.Memory) / float64(u.MemoryLimit) * 100,
+			})
+		}
+	}
+
+	// stopped instances are remembered for a while, so starting them again counts as a restart
+	for key, p := range s.last {
+		if now.Sub(p.time) > restartWindow {
+			delete(s.last, key)
+		}
+	}
+	return samples, nil
+}
+
+// NewSampler returns a Sampler of the usage returned by usage
+func NewSampler(usage UsageFunc) *Sampler {
+	return &Sampler{
+		usage: usage,
+		last:  make(map[string]*previous),
+		mtx:   &sync.Mutex{},
+	}
+}
+
+// Gauge returns the function of a gauge of the metrics sampler which evaluates the rules with the samples of
+// sm and returns the number of firing alerts of every node
+func Gauge(s Service, sm *Sampler, logger log.Logger) func() (float64, error) {
+	return func() (float64, error) {
+		now := time.Now()
+		samples, err := sm.Sample(now)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = s.Evaluate(samples, now)
+		if err != nil {
+			level.Error(logger).Log("err", err)
+		}
+
+		ss := []State{}
+		err = s.States(0, &ss)
+		if err != nil {
+			return 0, err
+		}
+
+		firing := 0
+		for _, st := range ss {
+			if st.State == Firing {
+				firing++
+			}
+		}
+		return float64(firing), nil
+	}
+}
@@ -0,0 +1,402 @@
+// Package alert evaluates the threshold rules users define on the resource usage of their instances
+// and records when the alerts of the rules fire and are resolved
+package alert
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Metrics the rules are evaluated on
+const (
+	// CPU is the percentage of a CPU core an instance used since the previous sample
+	CPU = "cpu"
+	// Memory is the memory an instance uses in percent of its limit
+	Memory = "memory"
+	// Restarts is the number of times an instance was restarted within the last hour
+	Restarts = "restarts"
+	// Drops is the number of packets per minute the firewall dropped for an instance since the previous sample
+	Drops = "drops"
+)
+
+// States of rules and alerts
+const (
+	// Pending is the state of instances exceeding the threshold for less than the duration of the rule
+	Pending = "pending"
+	// Firing is the state of instances exceeding the threshold for the duration of the rule
+	Firing = "firing"
+	// Resolved is the state of alerts of instances which are within the threshold again
+	Resolved = "resolved"
+)
+
+var (
+	// ErrRuleExists occurs if a user has a rule of the same name
+	ErrRuleExists = errors.New("rule exists already")
+
+	// ErrRuleNotExist occurs if a rule does not exist
+	ErrRuleNotExist = errors.New("rule does not exist")
+
+	// ErrLimit occurs if a user has as many rules as allowed
+	ErrLimit = errors.New("no more alert rules are allowed")
+)
+
+// Service AlertService
+type Service interface {
+	// CreateRule creates a rule of a user, it is evaluated with the next samples
+	CreateRule(refID uint, r *Rule) error
+
+	// RemoveRule removes a rule and its states, the alerts are kept
+	RemoveRule(refID uint, id uint) error
+
+	// RemoveInstanceRules removes the rules of an instance and the states of the instance
+	RemoveInstanceRules(refID uint, instance string) error
+
+	// Rules returns the rules of a user
+	Rules(refID uint, r *[]Rule) error
+
+	// States returns the instances of a user exceeding the thresholds of the rules, or of every user if refID is 0
+	States(refID uint, s *[]State) error
+
+	// Alerts returns the transitions of the rules of a user to firing and resolved, the latest first
+	Alerts(refID uint, a *[]Alert) error
+
+	// Evaluate updates the states of the rules with the samples and returns the alerts which fired or were
+	// resolved. Instances without samples keep their state, so every node evaluates the instances it runs.
+	Evaluate(samples []Sample, now time.Time) ([]Alert, error)
+}
+
+// Options configure the rules and alerts
+type Options struct {
+	// MaxRules is the number of rules a user may have
+	MaxRules uint
+	// History is the number of alerts kept per user
+	History uint
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	options Options
+	mtx     *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Rule{}, &State{}, &Alert{})
+}
+
+// rules returns the rules of the users refIDs
+func (s *service) rules(refIDs ...uint) ([]Rule, error) {
+	rs := []Rule{}
+	err := s.db.Find(&rs, "ref_id IN (?)", refIDs)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// states returns the states of a user, or of every user if refID is 0
+func (s *service) states(refID uint) ([]State, error) {
+	ss := []State{}
+	var err error
+	if refID == 0 {
+		err = s.db.Find(&ss)
+	} else {
+		err = s.db.Find(&ss, "ref_id = ?", refID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// alerts returns the alerts of a user, the latest first
+func (s *service) alerts(refID uint) ([]Alert, error) {
+	as := []Alert{}
+	err := s.db.FindOrdered(&as, "id DESC", 0, "ref_id = ?", refID)
+	if err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+func (s *service) CreateRule(refID uint, r *Rule) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	r.ID = 0
+	r.RefID = refID
+
+	rs, err := s.rules(refID)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range rs {
+		if o.Name == r.Name {
+			return ErrRuleExists
+		}
+	}
+	if uint(len(rs)) >= s.options.MaxRules {
+		return ErrLimit
+	}
+
+	r.CreatedAt = time.Now().UTC()
+	return s.db.Create(r)
+}
+
+// remove deletes the states of r for instance, or for every instance if it is empty, and r itself if instance is empty
+func (s *service) remove(r Rule, instance string) error {
+	if instance != "" {
+		return s.db.Delete(&State{}, "rule_id = ? AND instance = ?", r.ID, instance)
+	}
+
+	err := s.db.Delete(&State{}, "rule_id = ?", r.ID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&Rule{ID: r.ID})
+}
+
+func (s *service) RemoveRule(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	r := Rule{}
+	err := s.db.First(&r, "id = ? AND ref_id = ?", id, refID)
+	if s.db.IsNotFound(err) {
+		return ErrRuleNotExist
+	}
+	if err != nil {
+		return err
+	}
+	return s.remove(r, "")
+}
+
+func (s *service) RemoveInstanceRules(refID uint, instance string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rs := []Rule{}
+	err := s.db.Find(&rs, "ref_id = ? AND instance IN (?)", refID, []string{"", instance})
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rs {
+		// rules of every instance are kept, only the states of the removed instance are
+		if r.Instance == "" {
+			err = s.remove(r, instance)
+		} else if r.Instance == instance {
+			err = s.remove(r, "")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) Rules(refID uint, r *[]Rule) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.Find(r, "ref_id = ?", refID)
+}
+
+func (s *service) States(refID uint, st *[]State) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ss, err := s.states(refID)
+	if err != nil {
+		return err
+	}
+
+	*st = append(*st, ss...)
+	return nil
+}
+
+func (s *service) Alerts(refID uint, a *[]Alert) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	as, err := s.alerts(refID)
+	if err != nil {
+		return err
+	}
+
+	*a = append(*a, as...)
+	return nil
+}
+
+// transition records an alert of r for the instance of smp
+func (s *service) transition(r Rule, smp Sample, state string, now time.Time) (Alert, error) {
+	a := Alert{
+		RuleID:    r.ID,
+		RefID:     r.RefID,
+		Rule:      r.Name,
+		Instance:  smp.Instance,
+		Metric:    r.Metric,
+		Threshold: r.Threshold,
+		Value:     smp.Value,
+		State:     state,
+		CreatedAt: now.UTC(),
+	}
+	err := s.db.Create(&a)
+	if err != nil {
+		return Alert{}, err
+	}
+
+	// the oldest alert which is kept, the alerts of the user before it are removed
+	kept := []Alert{}
+	err = s.db.FindOrdered(&kept, "id DESC", int(s.options.History), "ref_id = ?", r.RefID)
+	if err != nil || uint(len(kept)) < s.options.History {
+		return a, err
+	}
+	err = s.db.Delete(&Alert{}, "ref_id = ? AND id < ?", r.RefID, kept[len(kept)-1].ID)
+	if err != nil {
+		return Alert{}, err
+	}
+	return a, nil
+}
+
+// evaluate updates the state of r for the instance of smp and returns the alert of a transition
+func (s *service) evaluate(r Rule, smp Sample, st *State, now time.Time) (*Alert, error) {
+	exceeded := smp.Value > r.Threshold
+	switch {
+	case !exceeded && st == nil:
+		return nil, nil
+	case !exceeded:
+		err := s.db.Delete(&State{ID: st.ID})
+		if err != nil || st.State != Firing {
+			return nil, err
+		}
+
+		a, err := s.transition(r, smp, Resolved, now)
+		return &a, err
+	case st == nil:
+		st = &State{
+			RuleID:   r.ID,
+			RefID:    r.RefID,
+			Instance: smp.Instance,
+			State:    Pending,
+			Value:    smp.Value,
+			Since:    now.UTC(),
+		}
+		if r.For == 0 {
+			st.State = Firing
+		}
+
+		err := s.db.Create(st)
+		if err != nil || st.State != Firing {
+			return nil, err
+		}
+
+		a, err := s.transition(r, smp, Firing, now)
+		return &a, err
+	case st.State == Pending && now.Sub(st.Since) >= time.Duration(r.For)*time.Second:
+		err := abstraction.UpdateByID(s.db, &State{}, st.ID, &State{State: Firing, Value: smp.Value, Since: now.UTC()})
+		if err != nil {
+			return nil, err
+		}
+
+		a, err := s.transition(r, smp, Firing, now)
+		return &a, err
+	}
+	return nil, abstraction.UpdateByID(s.db, &State{}, st.ID, &State{Value: smp.Value})
+}
+
+func (s *service) Evaluate(samples []Sample, now time.Time) ([]Alert, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	users := []uint{}
+	seen := make(map[uint]bool)
+	for _, smp := range samples {
+		if !seen[smp.RefID] {
+			seen[smp.RefID] = true
+			users = append(users, smp.RefID)
+		}
+	}
+	if len(users) == 0 {
+		return []Alert{}, nil
+	}
+
+	rs, err := s.rules(users...)
+	if err != nil {
+		return nil, err
+	}
+	ss := []State{}
+	err = s.db.Find(&ss, "ref_id IN (?)", users)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]*State)
+	for i, st := range ss {
+		states[fmt.Sprintf("%d/%s", st.RuleID, st.Instance)] = &ss[i]
+	}
+
+	as := []Alert{}
+	for _, smp := range samples {
+		for _, r := range rs {
+			if r.RefID != smp.RefID || r.Metric != smp.Metric || (r.Instance != "" && r.Instance != smp.Instance) {
+				continue
+			}
+
+			a, err := s.evaluate(r, smp, states[fmt.Sprintf("%d/%s", r.ID, smp.Instance)], now)
+			if err != nil {
+				return as, err
+			}
+			if a != nil {
+				as = append(as, *a)
+			}
+		}
+	}
+	return as, nil
+}
+
+// NewService creates an AlertService
+func NewService(db dbAdapter, o Options) (Service, error) {
+	if o.MaxRules == 0 {
+		o.MaxRules = 20
+	}
+	if o.History == 0 {
+		o.History = 100
+	}
+
+	s := &service{
+		db:      db,
+		options: o,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,332 @@
+package alert
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/alert/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC AlertServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.AlertServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createRule: grpctransport.NewServer(
+			endpoints.CreateRuleEndpoint,
+			DecodeGRPCCreateRuleRequest,
+			EncodeGRPCCreateRuleResponse,
+			options...,
+		),
+
+		removeRule: grpctransport.NewServer(
+			endpoints.RemoveRuleEndpoint,
+			DecodeGRPCRemoveRuleRequest,
+			EncodeGRPCRemoveRuleResponse,
+			options...,
+		),
+
+		rules: grpctransport.NewServer(
+			endpoints.RulesEndpoint,
+			DecodeGRPCRulesRequest,
+			EncodeGRPCRulesResponse,
+			options...,
+		),
+
+		states: grpctransport.NewServer(
+			endpoints.StatesEndpoint,
+			DecodeGRPCStatesRequest,
+			EncodeGRPCStatesResponse,
+			options...,
+		),
+
+		alerts: grpctransport.NewServer(
+			endpoints.AlertsEndpoint,
+			DecodeGRPCAlertsRequest,
+			EncodeGRPCAlertsResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createRule grpctransport.Handler
+	removeRule grpctransport.Handler
+	rules      grpctransport.Handler
+	states     grpctransport.Handler
+	alerts     grpctransport.Handler
+}
+
+func (s *grpcServer) CreateRule(ctx oldcontext.Context, req *pb.CreateRuleRequest) (*pb.CreateRuleResponse, error) {
+	_, res, err := s.createRule.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateRuleResponse), nil
+}
+
+func (s *grpcServer) RemoveRule(ctx oldcontext.Context, req *pb.RemoveRuleRequest) (*pb.RemoveRuleResponse, error) {
+	_, res, err := s.removeRule.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveRuleResponse), nil
+}
+
+func (s *grpcServer) Rules(ctx oldcontext.Context, req *pb.RulesRequest) (*pb.RulesResponse, error) {
+	_, res, err := s.rules.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RulesResponse), nil
+}
+
+func (s *grpcServer) States(ctx oldcontext.Context, req *pb.StatesRequest) (*pb.StatesResponse, error) {
+	_, res, err := s.states.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.StatesResponse), nil
+}
+
+func (s *grpcServer) Alerts(ctx oldcontext.Context, req *pb.AlertsRequest) (*pb.AlertsResponse, error) {
+	_, res, err := s.alerts.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.AlertsResponse), nil
+}
+
+// ConvertRule converts a Rule to its protobuf representation
+func ConvertRule(r Rule) *pb.Rule {
+	return &pb.Rule{
+		ID:        uint32(r.ID),
+		Name:      r.Name,
+		Instance:  r.Instance,
+		Metric:    r.Metric,
+		Threshold: r.Threshold,
+		For:       uint32(r.For),
+		Notify:    r.Notify,
+		CreatedAt: r.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBRule converts a protobuf Rule to a Rule
+func ConvertPBRule(r *pb.Rule) Rule {
+	if r == nil {
+		return Rule{}
+	}
+
+	return Rule{
+		ID:        uint(r.ID),
+		Name:      r.Name,
+		Instance:  r.Instance,
+		Metric:    r.Metric,
+		Threshold: r.Threshold,
+		For:       uint(r.For),
+		Notify:    r.Notify,
+		CreatedAt: time.Unix(r.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertState converts a State to its protobuf representation
+func ConvertState(s State) *pb.State {
+	return &pb.State{
+		RuleID:   uint32(s.RuleID),
+		Instance: s.Instance,
+		State:    s.State,
+		Value:    s.Value,
+		Since:    s.Since.Unix(),
+	}
+}
+
+// ConvertPBState converts a protobuf State to a State
+func ConvertPBState(s *pb.State) State {
+	if s == nil {
+		return State{}
+	}
+
+	return State{
+		RuleID:   uint(s.RuleID),
+		Instance: s.Instance,
+		State:    s.State,
+		Value:    s.Value,
+		Since:    time.Unix(s.Since, 0).UTC(),
+	}
+}
+
+// ConvertAlert converts an Alert to its protobuf representation
+func ConvertAlert(a Alert) *pb.Alert {
+	return &pb.Alert{
+		ID:        uint32(a.ID),
+		RuleID:    uint32(a.RuleID),
+		Rule:      a.Rule,
+		Instance:  a.Instance,
+		Metric:    a.Metric,
+		Threshold: a.Threshold,
+		Value:     a.Value,
+		State:     a.State,
+		CreatedAt: a.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBAlert converts a protobuf Alert to an Alert
+func ConvertPBAlert(a *pb.Alert) Alert {
+	if a == nil {
+		return Alert{}
+	}
+
+	return Alert{
+		ID:        uint(a.ID),
+		RuleID:    uint(a.RuleID),
+		Rule:      a.Rule,
+		Instance:  a.Instance,
+		Metric:    a.Metric,
+		Threshold: a.Threshold,
+		Value:     a.Value,
+		State:     a.State,
+		CreatedAt: time.Unix(a.CreatedAt, 0).UTC(),
+	}
+}
+
+// DecodeGRPCCreateRuleRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateRule request to a messages/alert.proto-domain createrule request.
+func DecodeGRPCCreateRuleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateRuleRequest)
+	return CreateRuleRequest{
+		RefID: uint(req.RefID),
+		Rule: &Rule{
+			Name:      req.Name,
+			Instance:  req.Instance,
+			Metric:    req.Metric,
+			Threshold: req.Threshold,
+			For:       uint(req.For),
+			Notify:    req.Notify,
+		},
+	}, nil
+}
+
+// EncodeGRPCCreateRuleResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain createrule response to a gRPC CreateRule response.
+func EncodeGRPCCreateRuleResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateRuleResponse)
+	gRPCRes := &pb.CreateRuleResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveRuleRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveRule request to a messages/alert.proto-domain removerule request.
+func DecodeGRPCRemoveRuleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveRuleRequest)
+	return RemoveRuleRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveRuleResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain removerule response to a gRPC RemoveRule response.
+func EncodeGRPCRemoveRuleResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveRuleResponse)
+	gRPCRes := &pb.RemoveRuleResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRulesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Rules request to a messages/alert.proto-domain rules request.
+func DecodeGRPCRulesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RulesRequest)
+	return RulesRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCRulesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain rules response to a gRPC Rules response.
+func EncodeGRPCRulesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RulesResponse)
+	rules := make([]*pb.Rule, len(res.Rules))
+	for i, r := range res.Rules {
+		rules[i] = ConvertRule(r)
+	}
+
+	gRPCRes := &pb.RulesResponse{
+		Rules:    rules,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCStatesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC States request to a messages/alert.proto-domain states request.
+func DecodeGRPCStatesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.StatesRequest)
+	return StatesRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCStatesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain states response to a gRPC States response.
+func EncodeGRPCStatesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(StatesResponse)
+	states := make([]*pb.State, len(res.States))
+	for i, s := range res.States {
+		states[i] = ConvertState(s)
+	}
+
+	gRPCRes := &pb.StatesResponse{
+		States: states,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCAlertsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Alerts request to a messages/alert.proto-domain alerts request.
+func DecodeGRPCAlertsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AlertsRequest)
+	return AlertsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCAlertsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/alert.proto-domain alerts response to a gRPC Alerts response.
+func EncodeGRPCAlertsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(AlertsResponse)
+	alerts := make([]*pb.Alert, len(res.Alerts))
+	for i, a := range res.Alerts {
+		alerts[i] = ConvertAlert(a)
+	}
+
+	gRPCRes := &pb.AlertsResponse{
+		Alerts:   alerts,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
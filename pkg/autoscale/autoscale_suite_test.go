@@ -0,0 +1,13 @@
+package autoscale_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAutoscale(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Autoscale Suite")
+}
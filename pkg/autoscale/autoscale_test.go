@@ -0,0 +1,378 @@
+package autoscale_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/autoscale"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockScaler struct {
+	replicas map[string]uint
+	fail     error
+}
+
+func (m *mockScaler) Replicas(refID uint, instance string) (uint, error) {
+	r, ok := m.replicas[instance]
+	if !ok {
+		return 0, errors.New("instance does not exist")
+	}
+	return r, nil
+}
+
+func (m *mockScaler) Scale(refID uint, instance string, replicas uint) error {
+	if m.fail != nil {
+		return m.fail
+	}
+	m.replicas[instance] = replicas
+	return nil
+}
+
+type recorder struct {
+	mtx    sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) handle(e events.Event) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) topics() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	ts := []string{}
+	for _, e := range r.events {
+		ts = append(ts, e.Topic)
+	}
+	return ts
+}
+
+var _ = Describe("Autoscale", func() {
+	var (
+		refID  = uint(1)
+		scaler *mockScaler
+		s      autoscale.Service
+	)
+
+	BeforeEach(func() {
+		scaler = &mockScaler{replicas: map[string]uint{"web": 0, "api": 0, "db": 0}}
+
+		var err error
+		s, err = autoscale.NewService(testutils.NewMockDB(), scaler, autoscale.Options{
+			MaxPolicies: 2,
+			MaxReplicas: 5,
+			History:     3,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("Create Service", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := autoscale.NewService(db, scaler, autoscale.Options{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Policies", func() {
+		It("Should set, replace and remove policies", func() {
+			p := &autoscale.Policy{Instance: "web", MaxReplicas: 3, CPU: 50}
+			Ω(s.SetPolicy(refID, p)).Should(Succeed())
+			Expect(p.ID).NotTo(BeZero())
+
+			Ω(s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MinReplicas: 1, MaxReplicas: 4, RequestRate: 100})).Should(Succeed())
+			Ω(s.SetPolicy(refID, &autoscale.Policy{Instance: "api", MaxReplicas: 1, CPU: 80})).Should(Succeed())
+			Expect(s.SetPolicy(refID, &autoscale.Policy{Instance: "db", MaxReplicas: 1, CPU: 80})).To(Equal(autoscale.ErrLimit))
+			Ω(s.SetPolicy(refID, &autoscale.Policy{Instance: "api", MaxReplicas: 2, CPU: 80})).Should(Succeed())
+
+			ps := []autoscale.Policy{}
+			Ω(s.Policies(refID, &ps)).Should(Succeed())
+			Expect(ps).To(HaveLen(2))
+			for _, o := range ps {
+				if o.Instance == "web" {
+					Expect(o.CPU).To(BeZero())
+					Expect(o.MaxReplicas).To(BeEquivalentTo(4))
+				}
+			}
+
+			Expect(s.RemovePolicy(2, "web")).To(Equal(autoscale.ErrPolicyNotExist))
+			Ω(s.RemovePolicy(refID, "web")).Should(Succeed())
+			ps = []autoscale.Policy{}
+			s.Policies(refID, &ps)
+			Expect(ps).To(HaveLen(1))
+			Expect(ps[0].Instance).To(Equal("api"))
+		})
+
+		It("Should check the policies", func() {
+			Expect(s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 3, CPU: -1, RequestRate: 10})).To(Equal(autoscale.ErrTarget))
+			Expect(s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MinReplicas: 2, MaxReplicas: 1, CPU: 50})).To(Equal(autoscale.ErrBounds))
+			Expect(s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 6, CPU: 50})).To(Equal(autoscale.ErrMaxReplicas))
+			Ω(s.SetPolicy(refID, &autoscale.Policy{Instance: "cache", MaxReplicas: 3, CPU: 50})).ShouldNot(Succeed())
+			Ω(s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MinReplicas: 1, MaxReplicas: 1})).Should(Succeed())
+		})
+	})
+
+	Describe("Windows", func() {
+		It("Should create and remove windows", func() {
+			w := &autoscale.Window{Instance: "web", Days: "1-5", Start: "09:00", End: "18:00", Replicas: 4}
+			Expect(s.CreateWindow(refID, w)).To(Equal(autoscale.ErrPolicyNotExist))
+
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MinReplicas: 1, MaxReplicas: 1})
+			Ω(s.CreateWindow(refID, w)).Should(Succeed())
+			Expect(w.ID).NotTo(BeZero())
+
+			for i := 0; i < 4; i++ {
+				Ω(s.CreateWindow(refID, &autoscale.Window{Instance: "web", Days: "*", Start: "22:00", End: "02:00", Replicas: 2})).Should(Succeed())
+			}
+			Expect(s.CreateWindow(refID, &autoscale.Window{Instance: "web", Days: "*", Start: "22:00", End: "02:00", Replicas: 2})).To(Equal(autoscale.ErrWindowLimit))
+
+			ws := []autoscale.Window{}
+			Ω(s.Windows(refID, &ws)).Should(Succeed())
+			Expect(ws).To(HaveLen(5))
+			Expect(ws[0].ID).To(Equal(w.ID))
+
+			Expect(s.RemoveWindow(2, w.ID)).To(Equal(autoscale.ErrWindowNotExist))
+			Ω(s.RemoveWindow(refID, w.ID)).Should(Succeed())
+			Ω(s.RemovePolicy(refID, "web")).Should(Succeed())
+			ws = []autoscale.Window{}
+			s.Windows(refID, &ws)
+			Expect(ws).To(BeEmpty())
+		})
+
+		It("Should check the windows", func() {
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 1})
+
+			for _, w := range []autoscale.Window{
+				{Instance: "web", Days: "1-5", Start: "9am", End: "18:00", Replicas: 4},
+				{Instance: "web", Days: "1-5", Start: "09:00", End: "24:00", Replicas: 4},
+				{Instance: "web", Days: "mon", Start: "09:00", End: "18:00", Replicas: 4},
+				{Instance: "web", Days: "1-5 *", Start: "09:00", End: "18:00", Replicas: 4},
+				{Instance: "web", Days: "1-5", Start: "09:00", End: "18:00", Timezone: "Mars/Olympus", Replicas: 4},
+				{Instance: "web", Days: "1-5", Start: "09:00", End: "18:00"},
+			} {
+				w := w
+				Expect(s.CreateWindow(refID, &w)).To(Equal(autoscale.ErrWindow))
+			}
+			Expect(s.CreateWindow(refID, &autoscale.Window{Instance: "web", Days: "1-5", Start: "09:00", End: "18:00", Replicas: 6})).To(Equal(autoscale.ErrMaxReplicas))
+		})
+
+		It("Should scale the instances by their windows", func() {
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MinReplicas: 1, MaxReplicas: 1, ScaleUpCooldown: 3600, ScaleDownCooldown: 3600})
+			Ω(s.CreateWindow(refID, &autoscale.Window{Instance: "web", Days: "1-5", Start: "09:00", End: "18:00", Timezone: "Europe/Berlin", Replicas: 4})).Should(Succeed())
+
+			// Monday, 19 October 2026, Berlin is two hours ahead of UTC
+			monday := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+			es, err := s.Schedule(monday.Add(6 * time.Hour))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].Reason).To(Equal(autoscale.Bounds))
+			Expect(scaler.replicas["web"]).To(BeEquivalentTo(1))
+
+			es, _ = s.Schedule(monday.Add(6*time.Hour + 59*time.Minute))
+			Expect(es).To(BeEmpty())
+
+			es, _ = s.Schedule(monday.Add(7 * time.Hour))
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(4))
+			Expect(es[0].Reason).To(Equal(autoscale.Schedule))
+
+			es, _ = s.Schedule(monday.Add(15*time.Hour + 59*time.Minute))
+			Expect(es).To(BeEmpty())
+
+			es, _ = s.Schedule(monday.Add(16 * time.Hour))
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(1))
+			Expect(es[0].Reason).To(Equal(autoscale.Bounds))
+
+			es, _ = s.Schedule(monday.AddDate(0, 0, 5).Add(10 * time.Hour))
+			Expect(es).To(BeEmpty())
+		})
+
+		It("Should keep windows spanning midnight open", func() {
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 1})
+			s.CreateWindow(refID, &autoscale.Window{Instance: "web", Days: "0", Start: "22:00", End: "02:00", Replicas: 2})
+
+			// Sunday, 18 October 2026
+			sunday := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+			es, _ := s.Schedule(sunday.Add(time.Hour))
+			Expect(es).To(BeEmpty())
+
+			es, _ = s.Schedule(sunday.Add(23 * time.Hour))
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(2))
+
+			es, _ = s.Schedule(sunday.Add(25 * time.Hour))
+			Expect(es).To(BeEmpty())
+
+			es, _ = s.Schedule(sunday.Add(26 * time.Hour))
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(1))
+		})
+
+		It("Should scale by the metrics above the replicas of a window", func() {
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 5, CPU: 50})
+			s.CreateWindow(refID, &autoscale.Window{Instance: "web", Days: "*", Start: "00:00", End: "00:00", Replicas: 2})
+			now := time.Now()
+
+			es, _ := s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 10}}, now)
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(2))
+			Expect(es[0].Reason).To(Equal(autoscale.Schedule))
+
+			es, _ = s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 240}}, now.Add(time.Minute))
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(4))
+			Expect(es[0].Reason).To(Equal(autoscale.CPU))
+		})
+	})
+
+	Describe("Evaluate", func() {
+		now := time.Now()
+
+		It("Should scale the replicas to the CPU target with cooldowns", func() {
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 3, CPU: 50, ScaleUpCooldown: 60, ScaleDownCooldown: 300})
+
+			es, err := s.Evaluate([]autoscale.Sample{
+				{RefID: refID, Instance: "web", CPU: 140},
+				{RefID: refID, Instance: "api", CPU: 400},
+				{RefID: 2, Instance: "web", CPU: 400},
+			}, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].From).To(BeEquivalentTo(0))
+			Expect(es[0].To).To(BeEquivalentTo(2))
+			Expect(es[0].Reason).To(Equal(autoscale.CPU))
+			Expect(es[0].Value).To(Equal(140.0))
+			Expect(scaler.replicas["web"]).To(BeEquivalentTo(2))
+
+			es, _ = s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 300}}, now.Add(30*time.Second))
+			Expect(es).To(BeEmpty())
+
+			es, _ = s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 300}}, now.Add(time.Minute))
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(3))
+			Expect(es[0].Reason).To(Equal(autoscale.Bounds))
+
+			es, _ = s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 40}}, now.Add(5*time.Minute))
+			Expect(es).To(BeEmpty())
+
+			es, _ = s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 40}}, now.Add(6*time.Minute))
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(0))
+			Expect(es[0].Value).To(Equal(10.0))
+
+			all := []autoscale.Event{}
+			Ω(s.Events(refID, &all)).Should(Succeed())
+			Expect(all).To(HaveLen(3))
+			Expect(all[0].To).To(BeEquivalentTo(0))
+		})
+
+		It("Should keep the replicas within the tolerance and the bounds", func() {
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MinReplicas: 1, MaxReplicas: 3, CPU: 50, ScaleUpCooldown: 600, ScaleDownCooldown: 600})
+
+			es, _ := s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 0}}, now)
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].Reason).To(Equal(autoscale.Bounds))
+			Expect(scaler.replicas["web"]).To(BeEquivalentTo(1))
+
+			es, _ = s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 109}}, now.Add(time.Hour))
+			Expect(es).To(BeEmpty())
+		})
+
+		It("Should scale by the metric demanding the most replicas", func() {
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 5, CPU: 50, RequestRate: 100})
+
+			es, _ := s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 60, RequestRate: 350, HasRequestRate: true}}, now)
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(3))
+			Expect(es[0].Reason).To(Equal(autoscale.Requests))
+
+			es, _ = s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 60}}, now.Add(time.Minute))
+			Expect(es).To(HaveLen(1))
+			Expect(es[0].To).To(BeEquivalentTo(1))
+			Expect(es[0].Reason).To(Equal(autoscale.CPU))
+		})
+
+		It("Should record failed scalings and keep the latest events", func() {
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 5, CPU: 50})
+			scaler.fail = errors.New("no capacity")
+
+			for i := 0; i < 4; i++ {
+				es, err := s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 200}}, now.Add(time.Duration(i)*time.Minute))
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(es).To(HaveLen(1))
+				Expect(es[0].Error).To(Equal("no capacity"))
+			}
+			Expect(scaler.replicas["web"]).To(BeZero())
+
+			all := []autoscale.Event{}
+			s.Events(refID, &all)
+			Expect(all).To(HaveLen(3))
+		})
+	})
+
+	Describe("Autoscaler", func() {
+		It("Should sample the instances together with their replicas", func() {
+			usage := []autoscale.Usage{
+				{RefID: refID, Instance: "web", Container: "a", Started: "1"},
+				{RefID: refID, Instance: "web", Container: "b", Started: "1"},
+				{RefID: refID, Instance: "db", Container: "c", Started: "1"},
+			}
+			a := autoscale.NewAutoscaler(s, func() ([]autoscale.Usage, error) {
+				return usage, nil
+			}, func(refID uint, instance string) (float64, bool) {
+				return 12, instance == "web"
+			}, log.NewNopLogger())
+			now := time.Now()
+
+			samples, err := a.Sample(now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(samples).To(BeEmpty())
+
+			usage[0].CPU = uint64(15 * time.Second)
+			usage[1].CPU = uint64(30 * time.Second)
+			usage = append(usage, autoscale.Usage{RefID: refID, Instance: "db", Container: "d", Started: "1"})
+			samples, _ = a.Sample(now.Add(30 * time.Second))
+			Expect(samples).To(ConsistOf(autoscale.Sample{RefID: refID, Instance: "web", CPU: 150, RequestRate: 12, HasRequestRate: true}))
+
+			usage[2].Started = "2"
+			samples, _ = a.Sample(now.Add(time.Minute))
+			Expect(samples).To(HaveLen(1))
+			samples, _ = a.Sample(now.Add(90 * time.Second))
+			Expect(samples).To(HaveLen(2))
+		})
+	})
+
+	Describe("Events", func() {
+		It("Should publish the scalings", func() {
+			logger := log.NewNopLogger()
+			bus := events.NewMemoryBus("node1", time.Millisecond, logger)
+			defer bus.Close()
+			rec := &recorder{}
+			bus.Subscribe(events.ScaleEvents, "", rec.handle)
+
+			s = autoscale.NewEventService(s, bus, logger)
+			s.SetPolicy(refID, &autoscale.Policy{Instance: "web", MaxReplicas: 5, CPU: 50})
+
+			now := time.Now()
+			s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 100}}, now)
+			scaler.fail = errors.New("no capacity")
+			s.Evaluate([]autoscale.Sample{{RefID: refID, Instance: "web", CPU: 500}}, now.Add(time.Minute))
+
+			Eventually(rec.topics).Should(Equal([]string{events.InstanceScaled, events.ScaleFailed}))
+		})
+	})
+})
@@ -0,0 +1,132 @@
+package autoscale
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// UsageFunc returns the CPU usage of the instances and replicas running on this node
+type UsageFunc func() ([]Usage, error)
+
+// RateFunc returns the number of requests per second the router recently passed to an instance of a user
+// and its replicas, it is false if the rate is unknown
+type RateFunc func(refID uint, instance string) (float64, bool)
+
+type previous struct {
+	cpu     uint64
+	started string
+	time    time.Time
+}
+
+// Autoscaler evaluates the policies of the instances on this node with their usage. The CPU usage is derived
+// from the previous usage of a container, so it has to evaluate regularly.
+type Autoscaler struct {
+	s      Service
+	usage  UsageFunc
+	rate   RateFunc
+	logger log.Logger
+
+	mtx  sync.Mutex
+	last map[string]*previous
+}
+
+// Sample returns the samples of the running instances. An instance is sampled once the CPU usage of every one
+// of its containers is known, i.e. from the second usage of its newest container on.
+func (a *Autoscaler) Sample(now time.Time) ([]Sample, error) {
+	us, err := a.usage()
+	if err != nil {
+		return nil, err
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	samples := make(map[series]*Sample)
+	complete := make(map[series]bool)
+	running := make(map[string]bool)
+	for _, u := range us {
+		key := series{u.RefID, u.Instance}
+		smp, ok := samples[key]
+		if !ok {
+			smp = &Sample{
+				RefID:    u.RefID,
+				Instance: u.Instance,
+			}
+			samples[key] = smp
+			complete[key] = true
+		}
+
+		running[u.Container] = true
+		p, ok := a.last[u.Container]
+		if ok && p.started == u.Started && now.After(p.time) && u.CPU >= p.cpu {
+			smp.CPU += float64(u.CPU-p.cpu) / float64(now.Sub(p.time).Nanoseconds()) * 100
+		} else {
+			complete[key] = false
+		}
+		a.last[u.Container] = &previous{u.CPU, u.Started, now}
+	}
+
+	// stopped containers are forgotten
+	for c := range a.last {
+		if !running[c] {
+			delete(a.last, c)
+		}
+	}
+
+	ss := []Sample{}
+	for key, smp := range samples {
+		if !complete[key] {
+			continue
+		}
+		if a.rate != nil {
+			smp.RequestRate, smp.HasRequestRate = a.rate(smp.RefID, smp.Instance)
+		}
+		ss = append(ss, *smp)
+	}
+	return ss, nil
+}
+
+// Evaluate samples the instances on this node and scales them by their policies
+func (a *Autoscaler) Evaluate(now time.Time) {
+	samples, err := a.Sample(now)
+	if err != nil {
+		level.Error(a.logger).Log("err", err)
+		return
+	}
+
+	_, err = a.s.Evaluate(samples, now)
+	if err != nil {
+		level.Error(a.logger).Log("err", err)
+	}
+}
+
+// Run calls Evaluate every interval until stop is closed
+func (a *Autoscaler) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		a.Evaluate(time.Now())
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewAutoscaler returns an Autoscaler evaluating the policies of s with the usage usage returns and the request
+// rates rate returns, rate may be nil if the request rates are unknown
+func NewAutoscaler(s Service, usage UsageFunc, rate RateFunc, logger log.Logger) *Autoscaler {
+	return &Autoscaler{
+		s:      s,
+		usage:  usage,
+		rate:   rate,
+		logger: logger,
+		last:   make(map[string]*previous),
+	}
+}
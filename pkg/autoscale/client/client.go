@@ -0,0 +1,287 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/autoscale"
+	"github.com/kontainerooo/kontainer.ooo/pkg/autoscale/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *autoscale.Endpoints {
+
+	var SetPolicyEndpoint endpoint.Endpoint
+	{
+		SetPolicyEndpoint = grpctransport.NewClient(
+			conn,
+			"autoscale.AutoscaleService",
+			"SetPolicy",
+			EncodeGRPCSetPolicyRequest,
+			DecodeGRPCSetPolicyResponse,
+			pb.SetPolicyResponse{},
+		).Endpoint()
+	}
+
+	var RemovePolicyEndpoint endpoint.Endpoint
+	{
+		RemovePolicyEndpoint = grpctransport.NewClient(
+			conn,
+			"autoscale.AutoscaleService",
+			"RemovePolicy",
+			EncodeGRPCRemovePolicyRequest,
+			DecodeGRPCRemovePolicyResponse,
+			pb.RemovePolicyResponse{},
+		).Endpoint()
+	}
+
+	var PoliciesEndpoint endpoint.Endpoint
+	{
+		PoliciesEndpoint = grpctransport.NewClient(
+			conn,
+			"autoscale.AutoscaleService",
+			"Policies",
+			EncodeGRPCPoliciesRequest,
+			DecodeGRPCPoliciesResponse,
+			pb.PoliciesResponse{},
+		).Endpoint()
+	}
+
+	var CreateWindowEndpoint endpoint.Endpoint
+	{
+		CreateWindowEndpoint = grpctransport.NewClient(
+			conn,
+			"autoscale.AutoscaleService",
+			"CreateWindow",
+			EncodeGRPCCreateWindowRequest,
+			DecodeGRPCCreateWindowResponse,
+			pb.CreateWindowResponse{},
+		).Endpoint()
+	}
+
+	var RemoveWindowEndpoint endpoint.Endpoint
+	{
+		RemoveWindowEndpoint = grpctransport.NewClient(
+			conn,
+			"autoscale.AutoscaleService",
+			"RemoveWindow",
+			EncodeGRPCRemoveWindowRequest,
+			DecodeGRPCRemoveWindowResponse,
+			pb.RemoveWindowResponse{},
+		).Endpoint()
+	}
+
+	var WindowsEndpoint endpoint.Endpoint
+	{
+		WindowsEndpoint = grpctransport.NewClient(
+			conn,
+			"autoscale.AutoscaleService",
+			"Windows",
+			EncodeGRPCWindowsRequest,
+			DecodeGRPCWindowsResponse,
+			pb.WindowsResponse{},
+		).Endpoint()
+	}
+
+	var EventsEndpoint endpoint.Endpoint
+	{
+		EventsEndpoint = grpctransport.NewClient(
+			conn,
+			"autoscale.AutoscaleService",
+			"Events",
+			EncodeGRPCEventsRequest,
+			DecodeGRPCEventsResponse,
+			pb.EventsResponse{},
+		).Endpoint()
+	}
+
+	return &autoscale.Endpoints{
+		SetPolicyEndpoint:    SetPolicyEndpoint,
+		RemovePolicyEndpoint: RemovePolicyEndpoint,
+		PoliciesEndpoint:     PoliciesEndpoint,
+		CreateWindowEndpoint: CreateWindowEndpoint,
+		RemoveWindowEndpoint: RemoveWindowEndpoint,
+		WindowsEndpoint:      WindowsEndpoint,
+		EventsEndpoint:       EventsEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCSetPolicyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain setpolicy request to a gRPC SetPolicy request.
+func EncodeGRPCSetPolicyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*autoscale.SetPolicyRequest)
+	return &pb.SetPolicyRequest{
+		RefID:             uint32(req.RefID),
+		Instance:          req.Policy.Instance,
+		MinReplicas:       uint32(req.Policy.MinReplicas),
+		MaxReplicas:       uint32(req.Policy.MaxReplicas),
+		Cpu:               req.Policy.CPU,
+		RequestRate:       req.Policy.RequestRate,
+		ScaleUpCooldown:   uint32(req.Policy.ScaleUpCooldown),
+		ScaleDownCooldown: uint32(req.Policy.ScaleDownCooldown),
+	}, nil
+}
+
+// DecodeGRPCSetPolicyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetPolicy response to a messages/autoscale.proto-domain setpolicy response.
+func DecodeGRPCSetPolicyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetPolicyResponse)
+	return &autoscale.SetPolicyResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemovePolicyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain removepolicy request to a gRPC RemovePolicy request.
+func EncodeGRPCRemovePolicyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*autoscale.RemovePolicyRequest)
+	return &pb.RemovePolicyRequest{
+		RefID:    uint32(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// DecodeGRPCRemovePolicyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemovePolicy response to a messages/autoscale.proto-domain removepolicy response.
+func DecodeGRPCRemovePolicyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemovePolicyResponse)
+	return &autoscale.RemovePolicyResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCPoliciesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain policies request to a gRPC Policies request.
+func EncodeGRPCPoliciesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*autoscale.PoliciesRequest)
+	return &pb.PoliciesRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCPoliciesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Policies response to a messages/autoscale.proto-domain policies response.
+func DecodeGRPCPoliciesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.PoliciesResponse)
+	policies := make([]autoscale.Policy, len(response.Policies))
+	for i, p := range response.Policies {
+		policies[i] = autoscale.ConvertPBPolicy(p)
+	}
+
+	return &autoscale.PoliciesResponse{
+		Policies: policies,
+		Error:    getError(response.Error),
+		Page:     paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCCreateWindowRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain createwindow request to a gRPC CreateWindow request.
+func EncodeGRPCCreateWindowRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*autoscale.CreateWindowRequest)
+	return &pb.CreateWindowRequest{
+		RefID:    uint32(req.RefID),
+		Instance: req.Window.Instance,
+		Days:     req.Window.Days,
+		Start:    req.Window.Start,
+		End:      req.Window.End,
+		Timezone: req.Window.Timezone,
+		Replicas: uint32(req.Window.Replicas),
+	}, nil
+}
+
+// DecodeGRPCCreateWindowResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateWindow response to a messages/autoscale.proto-domain createwindow response.
+func DecodeGRPCCreateWindowResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateWindowResponse)
+	return &autoscale.CreateWindowResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveWindowRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain removewindow request to a gRPC RemoveWindow request.
+func EncodeGRPCRemoveWindowRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*autoscale.RemoveWindowRequest)
+	return &pb.RemoveWindowRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveWindowResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveWindow response to a messages/autoscale.proto-domain removewindow response.
+func DecodeGRPCRemoveWindowResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveWindowResponse)
+	return &autoscale.RemoveWindowResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCWindowsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain windows request to a gRPC Windows request.
+func EncodeGRPCWindowsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*autoscale.WindowsRequest)
+	return &pb.WindowsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCWindowsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Windows response to a messages/autoscale.proto-domain windows response.
+func DecodeGRPCWindowsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.WindowsResponse)
+	windows := make([]autoscale.Window, len(response.Windows))
+	for i, w := range response.Windows {
+		windows[i] = autoscale.ConvertPBWindow(w)
+	}
+
+	return &autoscale.WindowsResponse{
+		Windows: windows,
+		Error:   getError(response.Error),
+		Page:    paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCEventsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain events request to a gRPC Events request.
+func EncodeGRPCEventsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*autoscale.EventsRequest)
+	return &pb.EventsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCEventsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Events response to a messages/autoscale.proto-domain events response.
+func DecodeGRPCEventsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.EventsResponse)
+	events := make([]autoscale.Event, len(response.Events))
+	for i, e := range response.Events {
+		events[i] = autoscale.ConvertPBEvent(e)
+	}
+
+	return &autoscale.EventsResponse{
+		Events: events,
+		Error:  getError(response.Error),
+		Page:   paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
@@ -0,0 +1,107 @@
+package autoscale
+
+import (
+	"time"
+)
+
+// Policy scales the replicas of an instance of a user between MinReplicas and MaxReplicas, so the instance and
+// its replicas stay close to the targets of their usage. A policy without targets keeps the replicas within
+// its bounds and the bounds of its windows.
+type Policy struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Instance is the name of the scaled instance, every instance has at most one policy
+	Instance string `validate:"required,name"`
+	// MinReplicas and MaxReplicas bound the number of replicas, the instance itself is not counted
+	MinReplicas uint
+	MaxReplicas uint
+	// CPU is the mean CPU usage in percent of a core the instance and its replicas should have, it is ignored if it is 0
+	CPU float64
+	// RequestRate is the number of requests per second the router should pass to the instance and each of its
+	// replicas, it is ignored if it is 0
+	RequestRate float64
+	// ScaleUpCooldown and ScaleDownCooldown are the number of seconds after a scaling of the instance before its
+	// replicas are increased or decreased again
+	ScaleUpCooldown   uint
+	ScaleDownCooldown uint
+	// ScaledAt is when the policy scaled the instance last, or tried to
+	ScaledAt  time.Time
+	CreatedAt time.Time
+}
+
+// TableName sets Policy's database table name
+func (Policy) TableName() string {
+	return "autoscale_policies"
+}
+
+// Event is a change of the replicas of an instance by its policy
+type Event struct {
+	ID       uint `gorm:"primary_key"`
+	RefID    uint
+	Instance string
+	// From is the number of replicas before and To after the scaling
+	From uint
+	To   uint
+	// Reason is the metric demanding the replicas, cpu or requests, bounds if they were out of the bounds of the policy,
+	// e.g. after a window closed, or schedule if they were below the replicas of an open window
+	Reason string
+	// Value is the mean usage of the metric per container which caused the scaling
+	Value float64
+	// Error is set if the replicas could not be changed
+	Error     string
+	CreatedAt time.Time
+}
+
+// TableName sets Event's database table name
+func (Event) TableName() string {
+	return "autoscale_events"
+}
+
+// Window raises the minimum of the replicas of the policy of an instance to Replicas during recurring times,
+// and its maximum as well if it is lower
+type Window struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Instance is the name of the instance, it has to have a policy
+	Instance string `validate:"required,name"`
+	// Days is the day of week field of a cron expression the window opens on, e.g. 1-5 for the weekdays or *
+	Days string `validate:"required"`
+	// Start and End are the times of day the window opens and closes, e.g. 09:00 and 18:00. A window which closes
+	// before it opens spans midnight, one which closes when it opens is open for a whole day.
+	Start string `validate:"required"`
+	End   string `validate:"required"`
+	// Timezone is the IANA time zone of the days and times, e.g. Europe/Berlin, they are in UTC if it is empty
+	Timezone  string
+	Replicas  uint
+	CreatedAt time.Time
+}
+
+// TableName sets Window's database table name
+func (Window) TableName() string {
+	return "autoscale_windows"
+}
+
+// Sample is the usage of an instance together with its replicas
+type Sample struct {
+	RefID    uint
+	Instance string
+	// CPU is the CPU usage of the instance and its replicas in percent of a core, summed up
+	CPU float64
+	// RequestRate is the number of requests per second the router passed to the instance and its replicas,
+	// it is only known if HasRequestRate is set
+	RequestRate    float64
+	HasRequestRate bool
+}
+
+// Usage is the CPU usage of a running instance or replica
+type Usage struct {
+	RefID uint
+	// Instance is the name of the instance, for replicas the name of the instance they replicate
+	Instance string
+	// Container is the id of the container of the instance or the replica
+	Container string
+	// CPU is the CPU time the container used since it was started in nanoseconds
+	CPU uint64
+	// Started identifies the start of the container, it changes once the container restarts
+	Started string
+}
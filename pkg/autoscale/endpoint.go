@@ -0,0 +1,227 @@
+package autoscale
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the autoscale service
+type Endpoints struct {
+	SetPolicyEndpoint    endpoint.Endpoint
+	RemovePolicyEndpoint endpoint.Endpoint
+	PoliciesEndpoint     endpoint.Endpoint
+	CreateWindowEndpoint endpoint.Endpoint
+	RemoveWindowEndpoint endpoint.Endpoint
+	WindowsEndpoint      endpoint.Endpoint
+	EventsEndpoint       endpoint.Endpoint
+}
+
+// SetPolicyRequest is the request struct for the SetPolicyEndpoint
+type SetPolicyRequest struct {
+	RefID  uint    `bart:"ref"`
+	Policy *Policy `validate:"required"`
+}
+
+// SetPolicyResponse is the response struct for the SetPolicyEndpoint
+type SetPolicyResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeSetPolicyEndpoint creates a gokit endpoint which invokes SetPolicy
+func MakeSetPolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetPolicyRequest)
+		err := s.SetPolicy(req.RefID, req.Policy)
+		if err != nil {
+			return SetPolicyResponse{
+				Error: err,
+			}, nil
+		}
+		return SetPolicyResponse{
+			ID: req.Policy.ID,
+		}, nil
+	}
+}
+
+// RemovePolicyRequest is the request struct for the RemovePolicyEndpoint
+type RemovePolicyRequest struct {
+	RefID    uint   `bart:"ref"`
+	Instance string `validate:"required,name"`
+}
+
+// RemovePolicyResponse is the response struct for the RemovePolicyEndpoint
+type RemovePolicyResponse struct {
+	Error error
+}
+
+// MakeRemovePolicyEndpoint creates a gokit endpoint which invokes RemovePolicy
+func MakeRemovePolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemovePolicyRequest)
+		err := s.RemovePolicy(req.RefID, req.Instance)
+		return RemovePolicyResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// PoliciesRequest is the request struct for the PoliciesEndpoint
+type PoliciesRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// PoliciesResponse is the response struct for the PoliciesEndpoint
+type PoliciesResponse struct {
+	Policies []Policy
+	Error    error
+	Page     paging.Response
+}
+
+// MakePoliciesEndpoint creates a gokit endpoint which invokes Policies
+func MakePoliciesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PoliciesRequest)
+		policies := []Policy{}
+		err := s.Policies(req.RefID, &policies)
+		if err != nil {
+			return PoliciesResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&policies, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return PoliciesResponse{
+			Policies: policies,
+			Page:     page,
+		}, nil
+	}
+}
+
+// CreateWindowRequest is the request struct for the CreateWindowEndpoint
+type CreateWindowRequest struct {
+	RefID  uint    `bart:"ref"`
+	Window *Window `validate:"required"`
+}
+
+// CreateWindowResponse is the response struct for the CreateWindowEndpoint
+type CreateWindowResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeCreateWindowEndpoint creates a gokit endpoint which invokes CreateWindow
+func MakeCreateWindowEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateWindowRequest)
+		err := s.CreateWindow(req.RefID, req.Window)
+		if err != nil {
+			return CreateWindowResponse{
+				Error: err,
+			}, nil
+		}
+		return CreateWindowResponse{
+			ID: req.Window.ID,
+		}, nil
+	}
+}
+
+// RemoveWindowRequest is the request struct for the RemoveWindowEndpoint
+type RemoveWindowRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint `validate:"required"`
+}
+
+// RemoveWindowResponse is the response struct for the RemoveWindowEndpoint
+type RemoveWindowResponse struct {
+	Error error
+}
+
+// MakeRemoveWindowEndpoint creates a gokit endpoint which invokes RemoveWindow
+func MakeRemoveWindowEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveWindowRequest)
+		err := s.RemoveWindow(req.RefID, req.ID)
+		return RemoveWindowResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// WindowsRequest is the request struct for the WindowsEndpoint
+type WindowsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// WindowsResponse is the response struct for the WindowsEndpoint
+type WindowsResponse struct {
+	Windows []Window
+	Error   error
+	Page    paging.Response
+}
+
+// MakeWindowsEndpoint creates a gokit endpoint which invokes Windows
+func MakeWindowsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(WindowsRequest)
+		windows := []Window{}
+		err := s.Windows(req.RefID, &windows)
+		if err != nil {
+			return WindowsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&windows, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return WindowsResponse{
+			Windows: windows,
+			Page:    page,
+		}, nil
+	}
+}
+
+// EventsRequest is the request struct for the EventsEndpoint
+type EventsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// EventsResponse is the response struct for the EventsEndpoint
+type EventsResponse struct {
+	Events []Event
+	Error  error
+	Page   paging.Response
+}
+
+// MakeEventsEndpoint creates a gokit endpoint which invokes Events
+func MakeEventsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(EventsRequest)
+		events := []Event{}
+		err := s.Events(req.RefID, &events)
+		if err != nil {
+			return EventsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&events, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return EventsResponse{
+			Events: events,
+			Page:   page,
+		}, nil
+	}
+}
@@ -0,0 +1,93 @@
+package autoscale
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// EventGroup is the group the autoscale services subscribe in, so every event is handled once
+const EventGroup = "autoscale"
+
+type eventService struct {
+	Service
+	bus    events.Bus
+	logger log.Logger
+}
+
+// publish publishes the events of the scalings
+func (s *eventService) publish(es []Event) {
+	for _, e := range es {
+		topic := events.InstanceScaled
+		if e.Error != "" {
+			topic = events.ScaleFailed
+		}
+
+		err := s.bus.Publish(topic, events.ScaleEvent{
+			RefID:    e.RefID,
+			EventID:  e.ID,
+			Instance: e.Instance,
+			From:     e.From,
+			To:       e.To,
+			Reason:   e.Reason,
+			Value:    e.Value,
+			Error:    e.Error,
+		})
+		if err != nil {
+			level.Error(s.logger).Log("topic", topic, "instance", e.Instance, "err", err)
+		}
+	}
+}
+
+func (s *eventService) Evaluate(samples []Sample, now time.Time) ([]Event, error) {
+	es, err := s.Service.Evaluate(samples, now)
+	s.publish(es)
+	return es, err
+}
+
+func (s *eventService) Schedule(now time.Time) ([]Event, error) {
+	es, err := s.Service.Schedule(now)
+	s.publish(es)
+	return es, err
+}
+
+// NewEventService returns a Service which publishes an event whenever a policy scaled an instance or failed to,
+// failing to publish is only logged
+func NewEventService(s Service, bus events.Bus, logger log.Logger) Service {
+	return &eventService{
+		Service: s,
+		bus:     bus,
+		logger:  logger,
+	}
+}
+
+// Subscribe removes the policy and the windows of an instance once it was removed
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	sub, err := bus.Subscribe(events.ContainerRemoved, EventGroup, func(e events.Event) error {
+		c := events.ContainerEvent{}
+		err := e.Decode(&c)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+		if c.Name == "" || c.Replica {
+			return nil
+		}
+
+		err = s.RemovePolicy(c.RefID, c.Name)
+		if err == ErrPolicyNotExist {
+			return nil
+		}
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", c.RefID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []events.Subscription{sub}, nil
+}
@@ -0,0 +1,20 @@
+package autoscale
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+// ScheduleJob is the type of the job scaling the instances whose replicas are out of the bounds of their
+// policy and its windows, e.g. since a window opened or closed
+const ScheduleJob = "autoscale.schedule"
+
+// ScheduleHandler returns the handler of ScheduleJob
+func ScheduleHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		_, err := s.Schedule(time.Now())
+		return err
+	}
+}
@@ -0,0 +1,547 @@
+// Package autoscale scales the replicas of the instances by the policies users set on them, between the bounds
+// of a policy and its windows and following the CPU usage and the request rate of the instances
+package autoscale
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Reasons of the scalings
+const (
+	// CPU is the mean CPU usage of the instance and its replicas in percent of a core
+	CPU = "cpu"
+	// Requests is the mean number of requests per second the router passed to the instance and its replicas
+	Requests = "requests"
+	// Bounds is the reason of scalings of instances whose replicas were out of the bounds of their policy
+	Bounds = "bounds"
+	// Schedule is the reason of scalings of instances whose replicas were below the replicas of an open window
+	Schedule = "schedule"
+)
+
+// Tolerance is the deviation of the usage from a target, relative to the target, within which the
+// replicas are not changed, so they do not flap around the target
+const Tolerance = 0.1
+
+var (
+	// ErrPolicyNotExist occurs if an instance has no policy
+	ErrPolicyNotExist = errors.New("policy does not exist")
+
+	// ErrLimit occurs if a user has as many policies as allowed
+	ErrLimit = errors.New("no more autoscaling policies are allowed")
+
+	// ErrBounds occurs if the minimum of the replicas of a policy is greater than its maximum
+	ErrBounds = errors.New("the minimum of the replicas is greater than the maximum")
+
+	// ErrMaxReplicas occurs if a policy would scale an instance to more replicas than allowed
+	ErrMaxReplicas = errors.New("the maximum of the replicas is too large")
+
+	// ErrTarget occurs if a policy has a negative CPU or request rate target
+	ErrTarget = errors.New("the cpu and request rate targets must not be negative")
+
+	// ErrWindowNotExist occurs if a window does not exist
+	ErrWindowNotExist = errors.New("window does not exist")
+
+	// ErrWindowLimit occurs if an instance has as many windows as allowed
+	ErrWindowLimit = errors.New("no more windows are allowed for this instance")
+
+	// ErrWindow occurs if the days, times or time zone of a window are invalid or it has no replicas
+	ErrWindow = errors.New("a window needs replicas, the days of a cron expression, times like 09:00 and a valid time zone")
+)
+
+// Scaler changes the replicas of the instances, it is satisfied by the container service through krood
+type Scaler interface {
+	// Replicas returns the number of replicas an instance of a user should have, it fails if the instance
+	// does not exist or can not be scaled
+	Replicas(refID uint, instance string) (uint, error)
+
+	// Scale creates or removes replicas of an instance of a user until it has the given number of replicas
+	Scale(refID uint, instance string, replicas uint) error
+}
+
+// Service AutoscaleService
+type Service interface {
+	// SetPolicy creates the policy of an instance of a user or replaces its current policy,
+	// it is applied with the next samples
+	SetPolicy(refID uint, p *Policy) error
+
+	// RemovePolicy removes the policy of an instance together with its windows, the instance keeps its replicas
+	RemovePolicy(refID uint, instance string) error
+
+	// Policies returns the policies of a user
+	Policies(refID uint, p *[]Policy) error
+
+	// CreateWindow adds a window to the policy of an instance of a user
+	CreateWindow(refID uint, w *Window) error
+
+	// RemoveWindow removes a window of a user
+	RemoveWindow(refID uint, id uint) error
+
+	// Windows returns the windows of a user
+	Windows(refID uint, w *[]Window) error
+
+	// Events returns the scalings of the instances of a user, the latest first
+	Events(refID uint, e *[]Event) error
+
+	// Evaluate scales the instances of the samples whose usage is off the targets of their policies and returns
+	// the events of the scalings. Instances without samples are not scaled, so every node evaluates the instances it runs.
+	Evaluate(samples []Sample, now time.Time) ([]Event, error)
+
+	// Schedule scales the instances of all policies whose replicas are out of the bounds of their policy and its
+	// windows at now and returns the events of the scalings
+	Schedule(now time.Time) ([]Event, error)
+}
+
+// Options configure the policies and events
+type Options struct {
+	// MaxPolicies is the number of policies a user may have
+	MaxPolicies uint
+	// MaxReplicas is the number of replicas a policy may scale an instance to
+	MaxReplicas uint
+	// MaxWindows is the number of windows an instance may have
+	MaxWindows uint
+	// History is the number of events kept per user
+	History uint
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	scaler  Scaler
+	options Options
+	mtx     *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Policy{}, &Window{}, &Event{})
+}
+
+// policy returns the policy of an instance of a user
+func (s *service) policy(refID uint, instance string) (Policy, error) {
+	p := Policy{}
+	err := s.db.First(&p, "ref_id = ? AND instance = ?", refID, instance)
+	if s.db.IsNotFound(err) {
+		return Policy{}, ErrPolicyNotExist
+	}
+	if err != nil {
+		return Policy{}, err
+	}
+	return p, nil
+}
+
+// windows returns the windows of an instance of a user
+func (s *service) windows(refID uint, instance string) ([]Window, error) {
+	ws := []Window{}
+	err := s.db.FindOrdered(&ws, "id", 0, "ref_id = ? AND instance = ?", refID, instance)
+	if err != nil {
+		return nil, err
+	}
+	return ws, nil
+}
+
+func (s *service) SetPolicy(refID uint, p *Policy) error {
+	switch {
+	case p.CPU < 0 || p.RequestRate < 0:
+		return ErrTarget
+	case p.MinReplicas > p.MaxReplicas:
+		return ErrBounds
+	case p.MaxReplicas > s.options.MaxReplicas:
+		return ErrMaxReplicas
+	}
+
+	_, err := s.scaler.Replicas(refID, p.Instance)
+	if err != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ps := []Policy{}
+	err = s.db.Find(&ps, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	p.ID = 0
+	p.RefID = refID
+	p.ScaledAt = time.Time{}
+	p.CreatedAt = time.Now().UTC()
+
+	var current *Policy
+	for i, o := range ps {
+		if o.Instance == p.Instance {
+			current = &ps[i]
+		}
+	}
+	if current == nil && uint(len(ps)) >= s.options.MaxPolicies {
+		return ErrLimit
+	}
+
+	// the policy is replaced as a whole, an update would skip the fields set to 0
+	s.db.Begin()
+	if current != nil {
+		err = s.db.Delete(&Policy{ID: current.ID})
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+		p.ScaledAt, p.CreatedAt = current.ScaledAt, current.CreatedAt
+	}
+
+	err = s.db.Create(p)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+	return nil
+}
+
+func (s *service) RemovePolicy(refID uint, instance string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, err := s.policy(refID, instance)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Delete(&Window{}, "ref_id = ? AND instance = ?", refID, instance)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&Policy{ID: p.ID})
+}
+
+func (s *service) Policies(refID uint, p *[]Policy) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.Find(p, "ref_id = ?", refID)
+}
+
+func (s *service) CreateWindow(refID uint, w *Window) error {
+	_, _, _, err := w.schedule()
+	if err != nil || w.Replicas == 0 {
+		return ErrWindow
+	}
+	if w.Replicas > s.options.MaxReplicas {
+		return ErrMaxReplicas
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	_, err = s.policy(refID, w.Instance)
+	if err != nil {
+		return err
+	}
+
+	ws, err := s.windows(refID, w.Instance)
+	if err != nil {
+		return err
+	}
+	if uint(len(ws)) >= s.options.MaxWindows {
+		return ErrWindowLimit
+	}
+
+	w.ID = 0
+	w.RefID = refID
+	w.CreatedAt = time.Now().UTC()
+	return s.db.Create(w)
+}
+
+func (s *service) RemoveWindow(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err := s.db.First(&Window{}, "id = ? AND ref_id = ?", id, refID)
+	if s.db.IsNotFound(err) {
+		return ErrWindowNotExist
+	}
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&Window{ID: id})
+}
+
+func (s *service) Windows(refID uint, w *[]Window) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.FindOrdered(w, "id", 0, "ref_id = ?", refID)
+}
+
+func (s *service) Events(refID uint, e *[]Event) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.FindOrdered(e, "id DESC", 0, "ref_id = ?", refID)
+}
+
+// want returns the number of replicas which each metric of p demands for the usage smp of the instance and its
+// current replicas, and the metric demanding the most, bounded by l. A metric within the tolerance of its target
+// demands the current replicas, without a sample the replicas are only bounded.
+func want(p Policy, l limits, smp *Sample, current uint) (uint, string, float64) {
+	containers := float64(current + 1)
+	replicas, reason, value := uint(0), "", 0.0
+
+	metric := func(name string, total, target float64) {
+		if target <= 0 {
+			return
+		}
+
+		n := current
+		if math.Abs(total/containers/target-1) > Tolerance {
+			n = uint(math.Max(math.Ceil(total/target), 1)) - 1
+		}
+		if reason == "" || n > replicas {
+			replicas, reason, value = n, name, total/containers
+		}
+	}
+	if smp != nil {
+		metric(CPU, smp.CPU, p.CPU)
+		if smp.HasRequestRate {
+			metric(Requests, smp.RequestRate, p.RequestRate)
+		}
+	}
+
+	if reason == "" {
+		replicas = current
+	}
+	if replicas < l.min {
+		replicas, reason = l.min, l.reason
+	}
+	if replicas > l.max {
+		replicas, reason = l.max, l.reason
+	}
+	return replicas, reason, value
+}
+
+// scale scales the instance of p if smp is off its targets or the replicas are out of the bounds of p and its
+// windows ws, and returns the event of the scaling, if any
+func (s *service) scale(p Policy, ws []Window, smp *Sample, now time.Time) (*Event, error) {
+	// instances which can not be scaled anymore, e.g. since they are being removed, are left out
+	current, err := s.scaler.Replicas(p.RefID, p.Instance)
+	if err != nil {
+		return nil, nil
+	}
+
+	l := bounds(p, ws, now)
+	replicas, reason, value := want(p, l, smp, current)
+	if replicas == current {
+		return nil, nil
+	}
+
+	// instances out of the bounds, e.g. after the policy changed or a window opened, are scaled regardless of the cooldowns
+	cooldown := p.ScaleDownCooldown
+	if replicas > current {
+		cooldown = p.ScaleUpCooldown
+	}
+	inBounds := current >= l.min && current <= l.max
+	if inBounds && now.Sub(p.ScaledAt) < time.Duration(cooldown)*time.Second {
+		return nil, nil
+	}
+
+	e := Event{
+		RefID:     p.RefID,
+		Instance:  p.Instance,
+		From:      current,
+		To:        replicas,
+		Reason:    reason,
+		Value:     value,
+		CreatedAt: now.UTC(),
+	}
+	scaleErr := s.scaler.Scale(p.RefID, p.Instance, replicas)
+	if scaleErr != nil {
+		e.Error = scaleErr.Error()
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// a failed scaling waits for the cooldown as well, so it is not retried with every sample
+	s.db.Begin()
+	err = s.db.Where("id = ?", p.ID)
+	if err != nil {
+		s.db.Rollback()
+		return nil, err
+	}
+	err = s.db.Update(&Policy{}, &Policy{ScaledAt: now.UTC()})
+	if err != nil {
+		s.db.Rollback()
+		return nil, err
+	}
+	s.db.Commit()
+
+	err = s.db.Create(&e)
+	if err != nil {
+		return nil, err
+	}
+
+	// the oldest event which is kept, the events of the user before it are removed
+	kept := []Event{}
+	err = s.db.FindOrdered(&kept, "id DESC", int(s.options.History), "ref_id = ?", p.RefID)
+	if err != nil {
+		return nil, err
+	}
+	if uint(len(kept)) == s.options.History {
+		err = s.db.Delete(&Event{}, "ref_id = ? AND id < ?", p.RefID, kept[len(kept)-1].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &e, nil
+}
+
+// load returns the policies of the users refIDs, or of every user if refIDs is nil, and their windows by instance
+func (s *service) load(refIDs []uint) ([]Policy, map[series][]Window, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	where := []interface{}{}
+	if refIDs != nil {
+		where = []interface{}{"ref_id IN (?)", refIDs}
+	}
+
+	ps := []Policy{}
+	err := s.db.Find(&ps, where...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	all := []Window{}
+	err = s.db.FindOrdered(&all, "id", 0, where...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ws := make(map[series][]Window)
+	for _, w := range all {
+		key := series{w.RefID, w.Instance}
+		ws[key] = append(ws[key], w)
+	}
+	return ps, ws, nil
+}
+
+func (s *service) Evaluate(samples []Sample, now time.Time) ([]Event, error) {
+	users := []uint{}
+	seen := make(map[uint]bool)
+	for _, smp := range samples {
+		if !seen[smp.RefID] {
+			seen[smp.RefID] = true
+			users = append(users, smp.RefID)
+		}
+	}
+	if len(users) == 0 {
+		return []Event{}, nil
+	}
+
+	ps, ws, err := s.load(users)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make(map[series]Policy)
+	for _, p := range ps {
+		policies[series{p.RefID, p.Instance}] = p
+	}
+
+	// the mutex is not held while scaling, creating the replicas may take a while
+	es := []Event{}
+	for _, smp := range samples {
+		key := series{smp.RefID, smp.Instance}
+		p, ok := policies[key]
+		if !ok {
+			continue
+		}
+
+		smp := smp
+		e, err := s.scale(p, ws[key], &smp, now)
+		if err != nil {
+			return es, err
+		}
+		if e != nil {
+			es = append(es, *e)
+		}
+	}
+	return es, nil
+}
+
+func (s *service) Schedule(now time.Time) ([]Event, error) {
+	// the windows are evaluated here, so every policy is a candidate
+	ps, ws, err := s.load(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	es := []Event{}
+	for _, p := range ps {
+		e, err := s.scale(p, ws[series{p.RefID, p.Instance}], nil, now)
+		if err != nil {
+			return es, err
+		}
+		if e != nil {
+			es = append(es, *e)
+		}
+	}
+	return es, nil
+}
+
+type series struct {
+	refID    uint
+	instance string
+}
+
+// NewService creates an AutoscaleService scaling the instances with scaler
+func NewService(db dbAdapter, scaler Scaler, o Options) (Service, error) {
+	if o.MaxPolicies == 0 {
+		o.MaxPolicies = 10
+	}
+	if o.MaxReplicas == 0 {
+		o.MaxReplicas = 10
+	}
+	if o.MaxWindows == 0 {
+		o.MaxWindows = 5
+	}
+	if o.History == 0 {
+		o.History = 100
+	}
+
+	s := &service{
+		db:      db,
+		scaler:  scaler,
+		options: o,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
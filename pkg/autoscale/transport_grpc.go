@@ -0,0 +1,442 @@
+package autoscale
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/autoscale/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC AutoscaleServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.AutoscaleServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		setPolicy: grpctransport.NewServer(
+			endpoints.SetPolicyEndpoint,
+			DecodeGRPCSetPolicyRequest,
+			EncodeGRPCSetPolicyResponse,
+			options...,
+		),
+
+		removePolicy: grpctransport.NewServer(
+			endpoints.RemovePolicyEndpoint,
+			DecodeGRPCRemovePolicyRequest,
+			EncodeGRPCRemovePolicyResponse,
+			options...,
+		),
+
+		policies: grpctransport.NewServer(
+			endpoints.PoliciesEndpoint,
+			DecodeGRPCPoliciesRequest,
+			EncodeGRPCPoliciesResponse,
+			options...,
+		),
+
+		createWindow: grpctransport.NewServer(
+			endpoints.CreateWindowEndpoint,
+			DecodeGRPCCreateWindowRequest,
+			EncodeGRPCCreateWindowResponse,
+			options...,
+		),
+
+		removeWindow: grpctransport.NewServer(
+			endpoints.RemoveWindowEndpoint,
+			DecodeGRPCRemoveWindowRequest,
+			EncodeGRPCRemoveWindowResponse,
+			options...,
+		),
+
+		windows: grpctransport.NewServer(
+			endpoints.WindowsEndpoint,
+			DecodeGRPCWindowsRequest,
+			EncodeGRPCWindowsResponse,
+			options...,
+		),
+
+		events: grpctransport.NewServer(
+			endpoints.EventsEndpoint,
+			DecodeGRPCEventsRequest,
+			EncodeGRPCEventsResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	setPolicy    grpctransport.Handler
+	removePolicy grpctransport.Handler
+	policies     grpctransport.Handler
+	createWindow grpctransport.Handler
+	removeWindow grpctransport.Handler
+	windows      grpctransport.Handler
+	events       grpctransport.Handler
+}
+
+func (s *grpcServer) SetPolicy(ctx oldcontext.Context, req *pb.SetPolicyRequest) (*pb.SetPolicyResponse, error) {
+	_, res, err := s.setPolicy.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetPolicyResponse), nil
+}
+
+func (s *grpcServer) RemovePolicy(ctx oldcontext.Context, req *pb.RemovePolicyRequest) (*pb.RemovePolicyResponse, error) {
+	_, res, err := s.removePolicy.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemovePolicyResponse), nil
+}
+
+func (s *grpcServer) Policies(ctx oldcontext.Context, req *pb.PoliciesRequest) (*pb.PoliciesResponse, error) {
+	_, res, err := s.policies.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.PoliciesResponse), nil
+}
+
+func (s *grpcServer) CreateWindow(ctx oldcontext.Context, req *pb.CreateWindowRequest) (*pb.CreateWindowResponse, error) {
+	_, res, err := s.createWindow.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateWindowResponse), nil
+}
+
+func (s *grpcServer) RemoveWindow(ctx oldcontext.Context, req *pb.RemoveWindowRequest) (*pb.RemoveWindowResponse, error) {
+	_, res, err := s.removeWindow.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveWindowResponse), nil
+}
+
+func (s *grpcServer) Windows(ctx oldcontext.Context, req *pb.WindowsRequest) (*pb.WindowsResponse, error) {
+	_, res, err := s.windows.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.WindowsResponse), nil
+}
+
+func (s *grpcServer) Events(ctx oldcontext.Context, req *pb.EventsRequest) (*pb.EventsResponse, error) {
+	_, res, err := s.events.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.EventsResponse), nil
+}
+
+// unix returns the unix timestamp of t, or 0 if t is zero
+func unix(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// fromUnix returns the time of a unix timestamp, or the zero time if it is 0
+func fromUnix(t int64) time.Time {
+	if t == 0 {
+		return time.Time{}
+	}
+	return time.Unix(t, 0).UTC()
+}
+
+// ConvertPolicy converts a Policy to its protobuf representation
+func ConvertPolicy(p Policy) *pb.Policy {
+	return &pb.Policy{
+		ID:                uint32(p.ID),
+		Instance:          p.Instance,
+		MinReplicas:       uint32(p.MinReplicas),
+		MaxReplicas:       uint32(p.MaxReplicas),
+		Cpu:               p.CPU,
+		RequestRate:       p.RequestRate,
+		ScaleUpCooldown:   uint32(p.ScaleUpCooldown),
+		ScaleDownCooldown: uint32(p.ScaleDownCooldown),
+		ScaledAt:          unix(p.ScaledAt),
+		CreatedAt:         p.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBPolicy converts a protobuf Policy to a Policy
+func ConvertPBPolicy(p *pb.Policy) Policy {
+	if p == nil {
+		return Policy{}
+	}
+
+	return Policy{
+		ID:                uint(p.ID),
+		Instance:          p.Instance,
+		MinReplicas:       uint(p.MinReplicas),
+		MaxReplicas:       uint(p.MaxReplicas),
+		CPU:               p.Cpu,
+		RequestRate:       p.RequestRate,
+		ScaleUpCooldown:   uint(p.ScaleUpCooldown),
+		ScaleDownCooldown: uint(p.ScaleDownCooldown),
+		ScaledAt:          fromUnix(p.ScaledAt),
+		CreatedAt:         time.Unix(p.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertWindow converts a Window to its protobuf representation
+func ConvertWindow(w Window) *pb.Window {
+	return &pb.Window{
+		ID:        uint32(w.ID),
+		Instance:  w.Instance,
+		Days:      w.Days,
+		Start:     w.Start,
+		End:       w.End,
+		Timezone:  w.Timezone,
+		Replicas:  uint32(w.Replicas),
+		CreatedAt: w.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBWindow converts a protobuf Window to a Window
+func ConvertPBWindow(w *pb.Window) Window {
+	if w == nil {
+		return Window{}
+	}
+
+	return Window{
+		ID:        uint(w.ID),
+		Instance:  w.Instance,
+		Days:      w.Days,
+		Start:     w.Start,
+		End:       w.End,
+		Timezone:  w.Timezone,
+		Replicas:  uint(w.Replicas),
+		CreatedAt: time.Unix(w.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertEvent converts an Event to its protobuf representation
+func ConvertEvent(e Event) *pb.Event {
+	return &pb.Event{
+		ID:        uint32(e.ID),
+		Instance:  e.Instance,
+		From:      uint32(e.From),
+		To:        uint32(e.To),
+		Reason:    e.Reason,
+		Value:     e.Value,
+		Error:     e.Error,
+		CreatedAt: e.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBEvent converts a protobuf Event to an Event
+func ConvertPBEvent(e *pb.Event) Event {
+	if e == nil {
+		return Event{}
+	}
+
+	return Event{
+		ID:        uint(e.ID),
+		Instance:  e.Instance,
+		From:      uint(e.From),
+		To:        uint(e.To),
+		Reason:    e.Reason,
+		Value:     e.Value,
+		Error:     e.Error,
+		CreatedAt: time.Unix(e.CreatedAt, 0).UTC(),
+	}
+}
+
+// DecodeGRPCSetPolicyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetPolicy request to a messages/autoscale.proto-domain setpolicy request.
+func DecodeGRPCSetPolicyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetPolicyRequest)
+	return SetPolicyRequest{
+		RefID: uint(req.RefID),
+		Policy: &Policy{
+			Instance:          req.Instance,
+			MinReplicas:       uint(req.MinReplicas),
+			MaxReplicas:       uint(req.MaxReplicas),
+			CPU:               req.Cpu,
+			RequestRate:       req.RequestRate,
+			ScaleUpCooldown:   uint(req.ScaleUpCooldown),
+			ScaleDownCooldown: uint(req.ScaleDownCooldown),
+		},
+	}, nil
+}
+
+// EncodeGRPCSetPolicyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain setpolicy response to a gRPC SetPolicy response.
+func EncodeGRPCSetPolicyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetPolicyResponse)
+	gRPCRes := &pb.SetPolicyResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemovePolicyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemovePolicy request to a messages/autoscale.proto-domain removepolicy request.
+func DecodeGRPCRemovePolicyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemovePolicyRequest)
+	return RemovePolicyRequest{
+		RefID:    uint(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// EncodeGRPCRemovePolicyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain removepolicy response to a gRPC RemovePolicy response.
+func EncodeGRPCRemovePolicyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemovePolicyResponse)
+	gRPCRes := &pb.RemovePolicyResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCPoliciesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Policies request to a messages/autoscale.proto-domain policies request.
+func DecodeGRPCPoliciesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PoliciesRequest)
+	return PoliciesRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCPoliciesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain policies response to a gRPC Policies response.
+func EncodeGRPCPoliciesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(PoliciesResponse)
+	policies := make([]*pb.Policy, len(res.Policies))
+	for i, p := range res.Policies {
+		policies[i] = ConvertPolicy(p)
+	}
+
+	gRPCRes := &pb.PoliciesResponse{
+		Policies: policies,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCCreateWindowRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateWindow request to a messages/autoscale.proto-domain createwindow request.
+func DecodeGRPCCreateWindowRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateWindowRequest)
+	return CreateWindowRequest{
+		RefID: uint(req.RefID),
+		Window: &Window{
+			Instance: req.Instance,
+			Days:     req.Days,
+			Start:    req.Start,
+			End:      req.End,
+			Timezone: req.Timezone,
+			Replicas: uint(req.Replicas),
+		},
+	}, nil
+}
+
+// EncodeGRPCCreateWindowResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain createwindow response to a gRPC CreateWindow response.
+func EncodeGRPCCreateWindowResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateWindowResponse)
+	gRPCRes := &pb.CreateWindowResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveWindowRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveWindow request to a messages/autoscale.proto-domain removewindow request.
+func DecodeGRPCRemoveWindowRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveWindowRequest)
+	return RemoveWindowRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveWindowResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain removewindow response to a gRPC RemoveWindow response.
+func EncodeGRPCRemoveWindowResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveWindowResponse)
+	gRPCRes := &pb.RemoveWindowResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCWindowsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Windows request to a messages/autoscale.proto-domain windows request.
+func DecodeGRPCWindowsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.WindowsRequest)
+	return WindowsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCWindowsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain windows response to a gRPC Windows response.
+func EncodeGRPCWindowsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(WindowsResponse)
+	windows := make([]*pb.Window, len(res.Windows))
+	for i, w := range res.Windows {
+		windows[i] = ConvertWindow(w)
+	}
+
+	gRPCRes := &pb.WindowsResponse{
+		Windows:  windows,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCEventsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Events request to a messages/autoscale.proto-domain events request.
+func DecodeGRPCEventsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.EventsRequest)
+	return EventsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCEventsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/autoscale.proto-domain events response to a gRPC Events response.
+func EncodeGRPCEventsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(EventsResponse)
+	events := make([]*pb.Event, len(res.Events))
+	for i, e := range res.Events {
+		events[i] = ConvertEvent(e)
+	}
+
+	gRPCRes := &pb.EventsResponse{
+		Events:   events,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,74 @@
+package autoscale
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/snapshot"
+)
+
+// schedule returns the cron expression of the times w opens, how long it stays open and the location of its
+// days and times
+func (w Window) schedule() (snapshot.Cron, time.Duration, *time.Location, error) {
+	start, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return snapshot.Cron{}, 0, nil, ErrWindow
+	}
+	end, err := time.Parse("15:04", w.End)
+	if err != nil {
+		return snapshot.Cron{}, 0, nil, ErrWindow
+	}
+
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return snapshot.Cron{}, 0, nil, ErrWindow
+	}
+
+	c, err := snapshot.ParseCron(fmt.Sprintf("%d %d * * %s", start.Minute(), start.Hour(), w.Days))
+	if err != nil {
+		return snapshot.Cron{}, 0, nil, ErrWindow
+	}
+
+	d := end.Sub(start)
+	if d <= 0 {
+		d += 24 * time.Hour
+	}
+	return c, d, loc, nil
+}
+
+// open returns whether w is open at now, i.e. it opened within its duration before now
+func (w Window) open(now time.Time) bool {
+	c, d, loc, err := w.schedule()
+	if err != nil {
+		return false
+	}
+
+	// cron expressions match in UTC, so the wall clock of now in the time zone of w is taken as UTC
+	l := now.In(loc)
+	t := time.Date(l.Year(), l.Month(), l.Day(), l.Hour(), l.Minute(), l.Second(), 0, time.UTC)
+
+	opened := c.Next(t.Add(-d))
+	return !opened.IsZero() && !opened.After(t)
+}
+
+// limits are the bounds of the replicas of a policy at a time
+type limits struct {
+	min, max uint
+	// reason is the reason of scalings to the bounds, schedule if a window raised them
+	reason string
+}
+
+// bounds returns the bounds of the replicas of p at now, ws are the windows of its instance. Of several open
+// windows the one with the most replicas applies.
+func bounds(p Policy, ws []Window, now time.Time) limits {
+	l := limits{p.MinReplicas, p.MaxReplicas, Bounds}
+	for _, w := range ws {
+		if w.Replicas > l.min && w.open(now) {
+			l.min, l.reason = w.Replicas, Schedule
+		}
+	}
+	if l.max < l.min {
+		l.max = l.min
+	}
+	return l
+}
@@ -0,0 +1,401 @@
+// Package backup exports the state of a kontainer.ooo installation into a single archive and restores it
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Version is the version of the archive format
+const Version = 1
+
+// ManifestName is the name of the manifest, it is the last entry of every archive
+const ManifestName = "manifest.json"
+
+var (
+	// ErrNoManifest is returned if an archive does not end with a manifest
+	ErrNoManifest = errors.New("archive has no manifest")
+
+	// ErrVersion is returned if an archive was written in an unknown format
+	ErrVersion = errors.New("unsupported archive version")
+
+	// ErrChecksum is returned if a file of an archive does not match the manifest
+	ErrChecksum = errors.New("checksum mismatch")
+
+	// ErrInvalidName is returned for entry names which would leave their part
+	ErrInvalidName = errors.New("invalid entry name")
+)
+
+// File is the checksum of a regular file in an archive
+type File struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes the contents of an archive
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	Node    string    `json:"node"`
+	Parts   []string  `json:"parts"`
+	Files   []File    `json:"files"`
+}
+
+// Entry is a file, directory or symlink of a part
+type Entry struct {
+	// Name is relative to the part
+	Name    string
+	Mode    os.FileMode
+	Link    string
+	ModTime time.Time
+}
+
+// Part is one component of the installation which is backed up, like the database
+type Part interface {
+	// Name is the directory of the part in the archive
+	Name() string
+
+	// Backup adds the entries of the part to the archive
+	Backup(w *Writer) error
+
+	// Restore is called for every entry of the part in the order they were added,
+	// r holds the content of regular files
+	Restore(e Entry, r io.Reader) error
+}
+
+// Writer writes the entries of the parts into an archive and keeps their checksums
+type Writer struct {
+	gz       *gzip.Writer
+	tw       *tar.Writer
+	prefix   string
+	manifest Manifest
+}
+
+// NewWriter creates a Writer for an archive written to w
+func NewWriter(w io.Writer, node string) *Writer {
+	gz := gzip.NewWriter(w)
+	return &Writer{
+		gz: gz,
+		tw: tar.NewWriter(gz),
+		manifest: Manifest{
+			Version: Version,
+			Created: time.Now().UTC(),
+			Node:    node,
+		},
+	}
+}
+
+func (w *Writer) header(e Entry, typ byte, size int64) (*tar.Header, error) {
+	if typ == tar.TypeSymlink && e.Link == "" {
+		return nil, fmt.Errorf("%s: symlink without target", e.Name)
+	}
+
+	modTime := e.ModTime
+	if modTime.IsZero() {
+		modTime = w.manifest.Created
+	}
+
+	return &tar.Header{
+		Name:     path.Join(w.prefix, cleanName(e.Name)),
+		Mode:     int64(e.Mode.Perm()),
+		Size:     size,
+		Typeflag: typ,
+		Linkname: e.Link,
+		ModTime:  modTime,
+	}, nil
+}
+
+// AddFile adds a regular file of the given size, r has to hold exactly size bytes
+func (w *Writer) AddFile(e Entry, size int64, r io.Reader) error {
+	h, err := w.header(e, tar.TypeReg, size)
+	if err != nil {
+		return err
+	}
+
+	err = w.tw.WriteHeader(h)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w.tw, sum), io.LimitReader(r, size))
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("%s: file changed during backup", h.Name)
+	}
+
+	w.manifest.Files = append(w.manifest.Files, File{
+		Name:   h.Name,
+		Size:   size,
+		SHA256: hex.EncodeToString(sum.Sum(nil)),
+	})
+	return nil
+}
+
+// AddDir adds a directory
+func (w *Writer) AddDir(e Entry) error {
+	h, err := w.header(e, tar.TypeDir, 0)
+	if err != nil {
+		return err
+	}
+	return w.tw.WriteHeader(h)
+}
+
+// AddSymlink adds a symlink pointing to e.Link
+func (w *Writer) AddSymlink(e Entry) error {
+	h, err := w.header(e, tar.TypeSymlink, 0)
+	if err != nil {
+		return err
+	}
+	return w.tw.WriteHeader(h)
+}
+
+// Close writes the manifest and closes the archive, the underlying writer is not closed
+func (w *Writer) Close() error {
+	data, err := json.MarshalIndent(w.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = w.tw.WriteHeader(&tar.Header{
+		Name:     ManifestName,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+		ModTime:  w.manifest.Created,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = w.tw.Write(data)
+	if err != nil {
+		return err
+	}
+
+	err = w.tw.Close()
+	if err != nil {
+		return err
+	}
+	return w.gz.Close()
+}
+
+// cleanName makes name relative and removes every .. which would leave the root
+func cleanName(name string) string {
+	name = path.Clean("/" + name)[1:]
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// Create writes an archive of parts to file, the archive is written next to it
+// and only renamed once it is complete
+func Create(file, node string, parts []Part) (Manifest, error) {
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer os.Remove(tmp)
+	defer f.Close()
+
+	w := NewWriter(f, node)
+	for _, p := range parts {
+		if p.Name() == "" || strings.ContainsAny(p.Name(), "/.") {
+			return Manifest{}, fmt.Errorf("invalid part name %q", p.Name())
+		}
+
+		w.prefix = p.Name()
+		w.manifest.Parts = append(w.manifest.Parts, p.Name())
+		err = p.Backup(w)
+		if err != nil {
+			return Manifest{}, fmt.Errorf("%s: %v", p.Name(), err)
+		}
+	}
+
+	err = w.Close()
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	return w.manifest, os.Rename(tmp, file)
+}
+
+// walk calls fn for every entry of the archive in r except the manifest, which is returned
+func walk(r io.Reader, fn func(h *tar.Header, r io.Reader) error) (Manifest, error) {
+	m := Manifest{}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return m, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	seen := false
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+
+		if seen {
+			return m, fmt.Errorf("%s: entry after the manifest", h.Name)
+		}
+
+		if h.Name == ManifestName {
+			seen = true
+			err = json.NewDecoder(tr).Decode(&m)
+			if err != nil {
+				return m, fmt.Errorf("invalid manifest: %v", err)
+			}
+			continue
+		}
+
+		err = fn(h, tr)
+		if err != nil {
+			return m, err
+		}
+	}
+
+	if !seen {
+		return m, ErrNoManifest
+	}
+	if m.Version != Version {
+		return m, ErrVersion
+	}
+	return m, nil
+}
+
+// Verify checks the files of the archive in file against the checksums of its manifest
+func Verify(file string) (Manifest, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return Manifest{}, err
+	}
+	defer f.Close()
+
+	sums := make(map[string]File)
+	m, err := walk(f, func(h *tar.Header, r io.Reader) error {
+		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeRegA {
+			return nil
+		}
+
+		sum := sha256.New()
+		n, err := io.Copy(sum, r)
+		if err != nil {
+			return err
+		}
+
+		sums[h.Name] = File{
+			Name:   h.Name,
+			Size:   n,
+			SHA256: hex.EncodeToString(sum.Sum(nil)),
+		}
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+
+	if len(sums) != len(m.Files) {
+		return m, ErrChecksum
+	}
+	for _, file := range m.Files {
+		if sums[file.Name] != file {
+			return m, fmt.Errorf("%s: %v", file.Name, ErrChecksum)
+		}
+	}
+	return m, nil
+}
+
+// Restore verifies the archive in file and replays it onto parts, every part of
+// the archive needs to be given
+func Restore(file string, parts []Part) (Manifest, error) {
+	m, err := Verify(file)
+	if err != nil {
+		return m, err
+	}
+
+	byName := make(map[string]Part)
+	for _, p := range parts {
+		byName[p.Name()] = p
+	}
+	for _, name := range m.Parts {
+		if byName[name] == nil {
+			return m, fmt.Errorf("%s: part is not configured", name)
+		}
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return m, err
+	}
+	defer f.Close()
+
+	_, err = walk(f, func(h *tar.Header, r io.Reader) error {
+		name := strings.SplitN(h.Name, "/", 2)
+		p := byName[name[0]]
+		if p == nil {
+			return fmt.Errorf("%s: %v", h.Name, ErrInvalidName)
+		}
+
+		e := Entry{
+			Name:    ".",
+			Mode:    os.FileMode(h.Mode).Perm(),
+			Link:    h.Linkname,
+			ModTime: h.ModTime,
+		}
+		if len(name) == 2 {
+			e.Name = cleanName(name[1])
+		}
+
+		switch h.Typeflag {
+		case tar.TypeDir:
+			e.Mode |= os.ModeDir
+		case tar.TypeSymlink:
+			e.Mode |= os.ModeSymlink
+		case tar.TypeReg, tar.TypeRegA:
+		default:
+			return fmt.Errorf("%s: unsupported entry type", h.Name)
+		}
+
+		err := p.Restore(e, r)
+		if err != nil {
+			return fmt.Errorf("%s: %v", p.Name(), err)
+		}
+
+		// parts may ignore the content, it still has to be read
+		_, err = io.Copy(ioutil.Discard, r)
+		return err
+	})
+	return m, err
+}
@@ -0,0 +1,192 @@
+package backup_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/backup"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type rules string
+
+func (r rules) ExportRules() (string, error) {
+	return string(r), nil
+}
+
+// rewrite replaces the content of the file name in an archive without updating the manifest
+func rewrite(file, name, content string) {
+	f, _ := os.Open(file)
+	gz, _ := gzip.NewReader(f)
+	tr := tar.NewReader(gz)
+
+	buf := &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		data, _ := ioutil.ReadAll(tr)
+		if h.Name == name {
+			data = []byte(content)
+			h.Size = int64(len(data))
+		}
+		tw.WriteHeader(h)
+		tw.Write(data)
+	}
+	tw.Close()
+	gw.Close()
+	f.Close()
+	ioutil.WriteFile(file, buf.Bytes(), 0600)
+}
+
+var _ = Describe("Backup", func() {
+	var (
+		dir     string
+		archive string
+	)
+
+	BeforeEach(func() {
+		dir, _ = ioutil.TempDir("", "kroo-backup")
+		archive = filepath.Join(dir, "backup.tar.gz")
+
+		src := filepath.Join(dir, "src")
+		os.MkdirAll(filepath.Join(src, "1", "2"), 0755)
+		ioutil.WriteFile(filepath.Join(src, "1", "2", "data"), []byte("data"), 0640)
+		ioutil.WriteFile(filepath.Join(src, "top"), []byte("top"), 0600)
+		os.Symlink("top", filepath.Join(src, "link"))
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		backup.ExecCommand = exec.Command
+	})
+
+	Describe("Directory", func() {
+		It("Should restore files, directories and symlinks", func() {
+			m, err := backup.Create(archive, "node1", []backup.Part{backup.Directory("volumes", filepath.Join(dir, "src"))})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.Node).To(Equal("node1"))
+			Expect(m.Parts).To(Equal([]string{"volumes"}))
+			Expect(m.Files).To(HaveLen(2))
+
+			dst := filepath.Join(dir, "dst")
+			_, err = backup.Restore(archive, []backup.Part{backup.Directory("volumes", dst)})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			data, err := ioutil.ReadFile(filepath.Join(dst, "1", "2", "data"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(string(data)).To(Equal("data"))
+
+			info, _ := os.Stat(filepath.Join(dst, "1", "2", "data"))
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0640)))
+
+			link, err := os.Readlink(filepath.Join(dst, "link"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(link).To(Equal("top"))
+		})
+
+		It("Should back up nothing if the directory does not exist", func() {
+			m, err := backup.Create(archive, "node1", []backup.Part{backup.Directory("modules", filepath.Join(dir, "missing"))})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.Files).To(BeEmpty())
+		})
+
+		It("Should not write through restored symlinks", func() {
+			w := &bytes.Buffer{}
+			bw := backup.NewWriter(w, "node1")
+			bw.AddSymlink(backup.Entry{Name: "volumes/1", Link: dir})
+			bw.AddFile(backup.Entry{Name: "volumes/1/escaped", Mode: 0600}, 1, strings.NewReader("x"))
+			bw.Close()
+			ioutil.WriteFile(archive, w.Bytes(), 0600)
+
+			_, err := backup.Restore(archive, []backup.Part{backup.Directory("volumes", filepath.Join(dir, "dst"))})
+			Ω(err).Should(HaveOccurred())
+			_, err = os.Stat(filepath.Join(dir, "escaped"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Verify", func() {
+		It("Should detect modified files", func() {
+			backup.Create(archive, "node1", []backup.Part{backup.Directory("volumes", filepath.Join(dir, "src"))})
+			_, err := backup.Verify(archive)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			rewrite(archive, "volumes/top", "changed")
+			_, err = backup.Verify(archive)
+			Ω(err).Should(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(backup.ErrChecksum.Error()))
+
+			_, err = backup.Restore(archive, []backup.Part{backup.Directory("volumes", filepath.Join(dir, "dst"))})
+			Ω(err).Should(HaveOccurred())
+			_, err = os.Stat(filepath.Join(dir, "dst"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("Should require a manifest", func() {
+			buf := &bytes.Buffer{}
+			gw := gzip.NewWriter(buf)
+			tar.NewWriter(gw).Close()
+			gw.Close()
+			ioutil.WriteFile(archive, buf.Bytes(), 0600)
+
+			_, err := backup.Verify(archive)
+			Ω(err).Should(Equal(backup.ErrNoManifest))
+		})
+	})
+
+	Describe("Restore", func() {
+		It("Should require every part of the archive", func() {
+			backup.Create(archive, "node1", []backup.Part{backup.Directory("volumes", filepath.Join(dir, "src"))})
+			_, err := backup.Restore(archive, []backup.Part{backup.Directory("modules", filepath.Join(dir, "dst"))})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Postgres and Firewall", func() {
+		It("Should dump and replay the database and rules", func() {
+			restored := filepath.Join(dir, "restored")
+			commands := []string{}
+			backup.ExecCommand = func(name string, args ...string) *exec.Cmd {
+				commands = append(commands, name+" "+strings.Join(args, " "))
+				switch name {
+				case "pg_dump":
+					return exec.Command("echo", "CREATE TABLE users ();")
+				default:
+					return exec.Command("sh", "-c", "cat >> "+restored)
+				}
+			}
+
+			parts := []backup.Part{
+				backup.Postgres("host=postgres database=kroo"),
+				backup.Firewall(rules("-A INPUT -j ACCEPT\n"), "iptables-restore"),
+			}
+			m, err := backup.Create(archive, "node1", parts)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.Parts).To(Equal([]string{"database", "firewall"}))
+
+			_, err = backup.Restore(archive, parts)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			data, _ := ioutil.ReadFile(restored)
+			Expect(string(data)).To(Equal("CREATE TABLE users ();\n-A INPUT -j ACCEPT\n"))
+			Expect(commands).To(Equal([]string{
+				"pg_dump --dbname=host=postgres dbname=kroo --clean --if-exists --no-owner",
+				"psql --dbname=host=postgres dbname=kroo --set=ON_ERROR_STOP=1 --quiet --file=-",
+				"iptables-restore -c",
+			}))
+		})
+	})
+})
@@ -0,0 +1,266 @@
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ExecCommand is a wrapper around exec.Command used for testing
+var ExecCommand = exec.Command
+
+type directory struct {
+	name string
+	root string
+}
+
+func (d *directory) Name() string {
+	return d.name
+}
+
+func (d *directory) Backup(w *Writer) error {
+	_, err := os.Stat(d.root)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(d.root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(d.root, file)
+		if err != nil {
+			return err
+		}
+
+		e := Entry{
+			Name:    filepath.ToSlash(name),
+			Mode:    info.Mode(),
+			ModTime: info.ModTime(),
+		}
+
+		switch {
+		case info.IsDir():
+			return w.AddDir(e)
+		case info.Mode()&os.ModeSymlink != 0:
+			e.Link, err = os.Readlink(file)
+			if err != nil {
+				return err
+			}
+			return w.AddSymlink(e)
+		case info.Mode().IsRegular():
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			return w.AddFile(e, info.Size(), f)
+		}
+
+		// sockets, devices and pipes can't be restored
+		return nil
+	})
+}
+
+// path returns the path of name below the root, it fails if a parent of it is a
+// symlink so a restored link can't be used to write outside of the root
+func (d *directory) path(name string) (string, error) {
+	p := d.root
+	parts := strings.Split(cleanName(name), "/")
+	for i, part := range parts {
+		if part == "." {
+			continue
+		}
+
+		p = filepath.Join(p, part)
+		if i == len(parts)-1 {
+			break
+		}
+
+		info, err := os.Lstat(p)
+		if err != nil {
+			return "", err
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("%s: %v", name, ErrInvalidName)
+		}
+	}
+	return p, nil
+}
+
+func (d *directory) Restore(e Entry, r io.Reader) error {
+	p, err := d.path(e.Name)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case e.Mode.IsDir():
+		err = os.MkdirAll(p, e.Mode.Perm())
+		if err != nil {
+			return err
+		}
+		err = os.Chmod(p, e.Mode.Perm())
+	case e.Mode&os.ModeSymlink != 0:
+		os.Remove(p)
+		err = os.Symlink(e.Link, p)
+	default:
+		os.Remove(p)
+		var f *os.File
+		f, err = os.OpenFile(p, os.O_CREATE|os.O_EXCL|os.O_WRONLY, e.Mode.Perm())
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, r)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil || e.Mode&os.ModeSymlink != 0 {
+		return err
+	}
+	return os.Chtimes(p, e.ModTime, e.ModTime)
+}
+
+// Directory backs up everything below root, files which already exist are
+// overwritten on restore but files missing in the archive are kept
+func Directory(name, root string) Part {
+	return &directory{
+		name: name,
+		root: root,
+	}
+}
+
+// DumpName is the name of the database dump in the database part
+const DumpName = "dump.sql"
+
+type postgres struct {
+	dsn string
+}
+
+func (p *postgres) Name() string {
+	return "database"
+}
+
+func (p *postgres) Backup(w *Writer) error {
+	tmp, err := ioutil.TempFile("", "kroo-dump")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	stderr := &bytes.Buffer{}
+	cmd := ExecCommand("pg_dump", "--dbname="+conninfo(p.dsn), "--clean", "--if-exists", "--no-owner")
+	cmd.Stdout = tmp
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("pg_dump: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	info, err := tmp.Stat()
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	return w.AddFile(Entry{Name: DumpName, Mode: 0600}, info.Size(), tmp)
+}
+
+func (p *postgres) Restore(e Entry, r io.Reader) error {
+	if e.Name != DumpName {
+		return nil
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := ExecCommand("psql", "--dbname="+conninfo(p.dsn), "--set=ON_ERROR_STOP=1", "--quiet", "--file=-")
+	cmd.Stdin = r
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("psql: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// conninfo converts the keyword dsn used for gorm into one libpq accepts,
+// lib/pq takes database as an alias for dbname
+func conninfo(dsn string) string {
+	fields := strings.Fields(dsn)
+	for i, f := range fields {
+		if strings.HasPrefix(f, "database=") {
+			fields[i] = "dbname=" + strings.TrimPrefix(f, "database=")
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+// Postgres backs up the database dsn points to with pg_dump and restores it with psql,
+// the dump drops every object before it is recreated
+func Postgres(dsn string) Part {
+	return &postgres{
+		dsn: dsn,
+	}
+}
+
+// RulesName is the name of the exported rules in the firewall part
+const RulesName = "rules"
+
+// RuleExporter returns the firewall rules in the format read by iptables-restore
+type RuleExporter interface {
+	ExportRules() (string, error)
+}
+
+type firewall struct {
+	rules       RuleExporter
+	restorePath string
+}
+
+func (f *firewall) Name() string {
+	return "firewall"
+}
+
+func (f *firewall) Backup(w *Writer) error {
+	rules, err := f.rules.ExportRules()
+	if err != nil {
+		return err
+	}
+	return w.AddFile(Entry{Name: RulesName, Mode: 0600}, int64(len(rules)), strings.NewReader(rules))
+}
+
+func (f *firewall) Restore(e Entry, r io.Reader) error {
+	if e.Name != RulesName {
+		return nil
+	}
+
+	stderr := &bytes.Buffer{}
+	cmd := ExecCommand(f.restorePath, "-c")
+	cmd.Stdin = r
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", f.restorePath, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// Firewall backs up the rules of the firewall service and applies them with
+// iptables-restore, rules may be nil if the part is only restored
+func Firewall(rules RuleExporter, restorePath string) Part {
+	return &firewall{
+		rules:       rules,
+		restorePath: restorePath,
+	}
+}
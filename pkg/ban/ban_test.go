@@ -0,0 +1,271 @@
+package ban_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ban"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// mockFirewall records the rules the ban and unban endpoints were called for
+type mockFirewall struct {
+	rules map[string]int
+}
+
+func (m *mockFirewall) endpoints() *firewall.Endpoints {
+	return &firewall.Endpoints{
+		BanEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(firewall.BanRequest)
+			m.rules[string(req.Src)+">"+string(req.Dst)]++
+			return firewall.BanResponse{}, nil
+		},
+		UnbanEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(firewall.UnbanRequest)
+			m.rules[string(req.Src)+">"+string(req.Dst)]--
+			return firewall.UnbanResponse{}, nil
+		},
+	}
+}
+
+var _ = Describe("Ban", func() {
+	var (
+		refID = uint(1)
+		now   = time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+		fw    *mockFirewall
+		db    *testutils.MockDB
+		s     ban.Service
+	)
+
+	newService := func(node string) ban.Service {
+		_, private, _ := net.ParseCIDR("10.0.0.0/8")
+		s, err := ban.NewService(db, fw.endpoints(), ban.Options{
+			MaxJails:   2,
+			MaxBanTime: 7200,
+			Node:       node,
+			Ignore:     []*net.IPNet{private},
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		return s
+	}
+
+	BeforeEach(func() {
+		fw = &mockFirewall{rules: make(map[string]int)}
+		db = testutils.NewMockDB()
+		s = newService("node1")
+	})
+
+	Describe("Create Service", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := ban.NewService(db, nil, ban.Options{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Compile", func() {
+		It("Should match the address of the source", func() {
+			re, err := ban.Compile("auth")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(re.FindStringSubmatch(`203.0.113.7 - - [01/Jun/2017:12:00:00 +0000] "GET /admin HTTP/1.1" 401 12 "-" "curl"`)).To(ContainElement("203.0.113.7"))
+			Expect(re.MatchString(`203.0.113.7 - - [01/Jun/2017:12:00:00 +0000] "GET / HTTP/1.1" 200 12 "-" "curl"`)).To(BeFalse())
+		})
+
+		It("Should require the host placeholder", func() {
+			_, err := ban.Compile("Failed password")
+			Expect(err).To(Equal(ban.ErrFilter))
+
+			_, err = ban.Compile("(<HOST>")
+			Expect(err).To(Equal(ban.ErrFilter))
+		})
+	})
+
+	Describe("Jails", func() {
+		It("Should create jails with defaults", func() {
+			j := &ban.Jail{Name: "web", Source: ban.Access, Target: "web", Filter: "auth", BanTime: 86400}
+			Ω(s.CreateJail(refID, j)).Should(Succeed())
+			Expect(j.MaxRetry).To(BeEquivalentTo(5))
+			Expect(j.FindTime).To(BeEquivalentTo(600))
+			Expect(j.BanTime).To(BeEquivalentTo(7200))
+
+			js := []ban.Jail{}
+			Ω(s.Jails(refID, &js)).Should(Succeed())
+			Expect(js).To(HaveLen(1))
+
+			js = []ban.Jail{}
+			Ω(s.Jails(2, &js)).Should(Succeed())
+			Expect(js).To(BeEmpty())
+		})
+
+		It("Should refuse invalid filters, duplicate names and too many jails", func() {
+			Expect(s.CreateJail(refID, &ban.Jail{Name: "a", Filter: "failed"})).To(Equal(ban.ErrFilter))
+			Ω(s.CreateJail(refID, &ban.Jail{Name: "a", Filter: "sshd"})).Should(Succeed())
+			Expect(s.CreateJail(refID, &ban.Jail{Name: "a", Filter: "sshd"})).To(Equal(ban.ErrJailExists))
+			Ω(s.CreateJail(refID, &ban.Jail{Name: "b", Filter: "sshd"})).Should(Succeed())
+			Expect(s.CreateJail(refID, &ban.Jail{Name: "c", Filter: "sshd"})).To(Equal(ban.ErrLimit))
+		})
+
+		It("Should lift the bans of a removed jail", func() {
+			j := &ban.Jail{Name: "ssh", Filter: "sshd"}
+			Ω(s.CreateJail(refID, j)).Should(Succeed())
+			_, err := s.Ban(*j, "203.0.113.7", ban.Target{Destination: "10.1.0.2"}, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>10.1.0.2", 1))
+
+			Ω(s.RemoveJail(refID, j.ID)).Should(Succeed())
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>10.1.0.2", 0))
+			Expect(s.RemoveJail(refID, j.ID)).To(Equal(ban.ErrJailNotExist))
+		})
+	})
+
+	Describe("Bans", func() {
+		var j *ban.Jail
+
+		BeforeEach(func() {
+			j = &ban.Jail{Name: "ssh", Filter: "sshd", BanTime: 60}
+			Ω(s.CreateJail(refID, j)).Should(Succeed())
+		})
+
+		It("Should ban a source until the ban time passed", func() {
+			b, err := s.Ban(*j, "203.0.113.7", ban.Target{Destination: "10.1.0.2", Port: 22}, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(b.Until).To(Equal(now.Add(time.Minute)))
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>10.1.0.2", 1))
+
+			_, err = s.Ban(*j, "203.0.113.7", ban.Target{Destination: "10.1.0.2", Port: 22}, now)
+			Expect(err).To(Equal(ban.ErrBanExists))
+
+			Ω(s.Expire(now.Add(30 * time.Second))).Should(Succeed())
+			bs := []ban.Ban{}
+			Ω(s.Bans(refID, &bs)).Should(Succeed())
+			Expect(bs).To(HaveLen(1))
+
+			Ω(s.Expire(now.Add(time.Minute))).Should(Succeed())
+			bs = []ban.Ban{}
+			Ω(s.Bans(refID, &bs)).Should(Succeed())
+			Expect(bs).To(BeEmpty())
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>10.1.0.2", 0))
+		})
+
+		It("Should never ban ignored sources", func() {
+			for _, address := range []string{"127.0.0.1", "10.2.3.4", "2001:db8::1", "example"} {
+				_, err := s.Ban(*j, address, ban.Target{}, now)
+				Expect(err).To(Equal(ban.ErrIgnored))
+			}
+			Expect(fw.rules).To(BeEmpty())
+		})
+
+		It("Should share the rule of bans dropping the same packets", func() {
+			other := &ban.Jail{Name: "web", Filter: "notfound", BanTime: 120}
+			Ω(s.CreateJail(refID, other)).Should(Succeed())
+
+			first, err := s.Ban(*j, "203.0.113.7", ban.Target{}, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = s.Ban(*other, "203.0.113.7", ban.Target{}, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>", 1))
+
+			Ω(s.Unban(refID, first.ID)).Should(Succeed())
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>", 1))
+
+			Ω(s.Expire(now.Add(2 * time.Minute))).Should(Succeed())
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>", 0))
+		})
+
+		It("Should only lift the bans of other users and nodes through the expiry", func() {
+			// the mock database drops its tables when they are migrated, so the node is started first
+			db = testutils.NewMockDB()
+			other := newService("node2")
+			s = newService("node1")
+			Ω(s.CreateJail(refID, j)).Should(Succeed())
+
+			b, err := s.Ban(*j, "203.0.113.7", ban.Target{}, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s.Unban(2, b.ID)).To(Equal(ban.ErrBanNotExist))
+
+			// another node lets the ban expire, the node which created it lifts it on its next expiry
+			Ω(other.Unban(refID, b.ID)).Should(Succeed())
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>", 1))
+
+			Ω(s.Expire(time.Now())).Should(Succeed())
+			Expect(fw.rules).To(HaveKeyWithValue("203.0.113.7>", 0))
+		})
+	})
+
+	Describe("Watcher", func() {
+		var (
+			dir string
+			j   *ban.Jail
+			w   *ban.Watcher
+		)
+
+		appendLines := func(lines ...string) {
+			f, err := os.OpenFile(filepath.Join(dir, "access.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer f.Close()
+			for _, line := range lines {
+				_, err = f.WriteString(line + "\n")
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+		}
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "ban")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			j = &ban.Jail{Name: "web", Source: ban.Access, Target: "web", Filter: "notfound", MaxRetry: 3, FindTime: 60}
+			Ω(s.CreateJail(refID, j)).Should(Succeed())
+
+			w = ban.NewWatcher(s, func(ban.Jail) (ban.Target, bool, error) {
+				return ban.Target{Paths: []string{filepath.Join(dir, "access.log")}, Port: 443}, true, nil
+			}, log.NewNopLogger())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		notFound := `203.0.113.7 - - [01/Jun/2017:12:00:00 +0000] "GET /wp-login.php HTTP/1.1" 404 12 "-" "curl"`
+
+		It("Should ban sources matched too often within the find time", func() {
+			appendLines(notFound, notFound, notFound)
+			w.Watch(now)
+
+			appendLines(notFound, notFound)
+			w.Watch(now)
+			bs := []ban.Ban{}
+			Ω(s.Bans(refID, &bs)).Should(Succeed())
+			Expect(bs).To(BeEmpty())
+
+			appendLines(notFound)
+			w.Watch(now.Add(30 * time.Second))
+			Ω(s.Bans(refID, &bs)).Should(Succeed())
+			Expect(bs).To(HaveLen(1))
+			Expect(bs[0].Address).To(Equal("203.0.113.7"))
+			Expect(bs[0].Port).To(BeEquivalentTo(443))
+		})
+
+		It("Should forget matches older than the find time", func() {
+			w.Watch(now)
+			appendLines(notFound, notFound)
+			w.Watch(now)
+
+			appendLines(notFound)
+			w.Watch(now.Add(2 * time.Minute))
+			bs := []ban.Ban{}
+			Ω(s.Bans(refID, &bs)).Should(Succeed())
+			Expect(bs).To(BeEmpty())
+		})
+	})
+})
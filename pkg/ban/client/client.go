@@ -0,0 +1,210 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/ban"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ban/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *ban.Endpoints {
+
+	var CreateJailEndpoint endpoint.Endpoint
+	{
+		CreateJailEndpoint = grpctransport.NewClient(
+			conn,
+			"ban.BanService",
+			"CreateJail",
+			EncodeGRPCCreateJailRequest,
+			DecodeGRPCCreateJailResponse,
+			pb.CreateJailResponse{},
+		).Endpoint()
+	}
+
+	var RemoveJailEndpoint endpoint.Endpoint
+	{
+		RemoveJailEndpoint = grpctransport.NewClient(
+			conn,
+			"ban.BanService",
+			"RemoveJail",
+			EncodeGRPCRemoveJailRequest,
+			DecodeGRPCRemoveJailResponse,
+			pb.RemoveJailResponse{},
+		).Endpoint()
+	}
+
+	var JailsEndpoint endpoint.Endpoint
+	{
+		JailsEndpoint = grpctransport.NewClient(
+			conn,
+			"ban.BanService",
+			"Jails",
+			EncodeGRPCJailsRequest,
+			DecodeGRPCJailsResponse,
+			pb.JailsResponse{},
+		).Endpoint()
+	}
+
+	var BansEndpoint endpoint.Endpoint
+	{
+		BansEndpoint = grpctransport.NewClient(
+			conn,
+			"ban.BanService",
+			"Bans",
+			EncodeGRPCBansRequest,
+			DecodeGRPCBansResponse,
+			pb.BansResponse{},
+		).Endpoint()
+	}
+
+	var UnbanEndpoint endpoint.Endpoint
+	{
+		UnbanEndpoint = grpctransport.NewClient(
+			conn,
+			"ban.BanService",
+			"Unban",
+			EncodeGRPCUnbanRequest,
+			DecodeGRPCUnbanResponse,
+			pb.UnbanResponse{},
+		).Endpoint()
+	}
+
+	return &ban.Endpoints{
+		CreateJailEndpoint: CreateJailEndpoint,
+		RemoveJailEndpoint: RemoveJailEndpoint,
+		JailsEndpoint:      JailsEndpoint,
+		BansEndpoint:       BansEndpoint,
+		UnbanEndpoint:      UnbanEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreateJailRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain createjail request to a gRPC CreateJail request.
+func EncodeGRPCCreateJailRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ban.CreateJailRequest)
+	return &pb.CreateJailRequest{
+		RefID:    uint32(req.RefID),
+		Name:     req.Jail.Name,
+		Source:   req.Jail.Source,
+		Target:   req.Jail.Target,
+		Filter:   req.Jail.Filter,
+		MaxRetry: uint32(req.Jail.MaxRetry),
+		FindTime: uint32(req.Jail.FindTime),
+		BanTime:  uint32(req.Jail.BanTime),
+	}, nil
+}
+
+// DecodeGRPCCreateJailResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateJail response to a messages/ban.proto-domain createjail response.
+func DecodeGRPCCreateJailResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateJailResponse)
+	return &ban.CreateJailResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveJailRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain removejail request to a gRPC RemoveJail request.
+func EncodeGRPCRemoveJailRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ban.RemoveJailRequest)
+	return &pb.RemoveJailRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveJailResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveJail response to a messages/ban.proto-domain removejail response.
+func DecodeGRPCRemoveJailResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveJailResponse)
+	return &ban.RemoveJailResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCJailsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain jails request to a gRPC Jails request.
+func EncodeGRPCJailsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ban.JailsRequest)
+	return &pb.JailsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCJailsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Jails response to a messages/ban.proto-domain jails response.
+func DecodeGRPCJailsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.JailsResponse)
+	jails := make([]ban.Jail, len(response.Jails))
+	for i, j := range response.Jails {
+		jails[i] = ban.ConvertPBJail(j)
+	}
+
+	return &ban.JailsResponse{
+		Jails: jails,
+		Error: getError(response.Error),
+		Page:  paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCBansRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain bans request to a gRPC Bans request.
+func EncodeGRPCBansRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ban.BansRequest)
+	return &pb.BansRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCBansResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Bans response to a messages/ban.proto-domain bans response.
+func DecodeGRPCBansResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.BansResponse)
+	bans := make([]ban.Ban, len(response.Bans))
+	for i, b := range response.Bans {
+		bans[i] = ban.ConvertPBBan(b)
+	}
+
+	return &ban.BansResponse{
+		Bans:  bans,
+		Error: getError(response.Error),
+		Page:  paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCUnbanRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain unban request to a gRPC Unban request.
+func EncodeGRPCUnbanRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ban.UnbanRequest)
+	return &pb.UnbanRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCUnbanResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Unban response to a messages/ban.proto-domain unban response.
+func DecodeGRPCUnbanResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.UnbanResponse)
+	return &ban.UnbanResponse{
+		Error: getError(response.Error),
+	}, nil
+}
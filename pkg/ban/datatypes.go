@@ -0,0 +1,60 @@
+package ban
+
+import (
+	"time"
+)
+
+// Jail bans the sources matching the filter of a jail MaxRetry times within FindTime seconds in a log of a user
+// for BanTime seconds
+type Jail struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	Name  string `validate:"required,name"`
+	// Source is container to watch the standard output and error of the instance Target or access to watch
+	// the access log of the routing configuration Target
+	Source string `validate:"required,oneof=container|access"`
+	Target string `validate:"required,name"`
+	// Filter is a preset (auth, notfound or sshd) or a regular expression whose <HOST> placeholder matches the
+	// address of the source
+	Filter    string `validate:"required"`
+	MaxRetry  uint
+	FindTime  uint
+	BanTime   uint
+	CreatedAt time.Time
+}
+
+// TableName sets Jail's database table name
+func (Jail) TableName() string {
+	return "ban_jails"
+}
+
+// Ban is a source banned by a jail
+type Ban struct {
+	ID     uint `gorm:"primary_key"`
+	JailID uint
+	RefID  uint
+	Jail   string
+	// Address is the banned source
+	Address string
+	// Destination and Port are the address and the tcp port the packets of the source are dropped to,
+	// every packet of the source is dropped if they are empty
+	Destination string
+	Port        uint16
+	// Node is the name of the node whose firewall drops the packets
+	Node      string
+	CreatedAt time.Time
+	// Until is when the ban expires
+	Until time.Time
+}
+
+// TableName sets Ban's database table name
+func (Ban) TableName() string {
+	return "bans"
+}
+
+// Target is the logs a jail watches on this node and what the bans of the jail drop the packets to
+type Target struct {
+	Paths       []string
+	Destination string
+	Port        uint16
+}
@@ -0,0 +1,167 @@
+package ban
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the ban service
+type Endpoints struct {
+	CreateJailEndpoint endpoint.Endpoint
+	RemoveJailEndpoint endpoint.Endpoint
+	JailsEndpoint      endpoint.Endpoint
+	BansEndpoint       endpoint.Endpoint
+	UnbanEndpoint      endpoint.Endpoint
+}
+
+// CreateJailRequest is the request struct for the CreateJailEndpoint
+type CreateJailRequest struct {
+	RefID uint  `bart:"ref"`
+	Jail  *Jail `validate:"required"`
+}
+
+// CreateJailResponse is the response struct for the CreateJailEndpoint
+type CreateJailResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeCreateJailEndpoint creates a gokit endpoint which invokes CreateJail
+func MakeCreateJailEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateJailRequest)
+		err := s.CreateJail(req.RefID, req.Jail)
+		if err != nil {
+			return CreateJailResponse{
+				Error: err,
+			}, nil
+		}
+		return CreateJailResponse{
+			ID: req.Jail.ID,
+		}, nil
+	}
+}
+
+// RemoveJailRequest is the request struct for the RemoveJailEndpoint
+type RemoveJailRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemoveJailResponse is the response struct for the RemoveJailEndpoint
+type RemoveJailResponse struct {
+	Error error
+}
+
+// MakeRemoveJailEndpoint creates a gokit endpoint which invokes RemoveJail
+func MakeRemoveJailEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveJailRequest)
+		err := s.RemoveJail(req.RefID, req.ID)
+		return RemoveJailResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// JailsRequest is the request struct for the JailsEndpoint
+type JailsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// JailsResponse is the response struct for the JailsEndpoint
+type JailsResponse struct {
+	Jails []Jail
+	Error error
+	Page  paging.Response
+}
+
+// MakeJailsEndpoint creates a gokit endpoint which invokes Jails
+func MakeJailsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(JailsRequest)
+		jails := []Jail{}
+		// a user without a reference would get the jails of every user
+		if req.RefID != 0 {
+			err := s.Jails(req.RefID, &jails)
+			if err != nil {
+				return JailsResponse{
+					Error: err,
+				}, nil
+			}
+		}
+
+		page, err := paging.Apply(&jails, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return JailsResponse{
+			Jails: jails,
+			Page:  page,
+		}, nil
+	}
+}
+
+// BansRequest is the request struct for the BansEndpoint
+type BansRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// BansResponse is the response struct for the BansEndpoint
+type BansResponse struct {
+	Bans  []Ban
+	Error error
+	Page  paging.Response
+}
+
+// MakeBansEndpoint creates a gokit endpoint which invokes Bans
+func MakeBansEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(BansRequest)
+		bans := []Ban{}
+		// a user without a reference would get the bans of every user
+		if req.RefID != 0 {
+			err := s.Bans(req.RefID, &bans)
+			if err != nil {
+				return BansResponse{
+					Error: err,
+				}, nil
+			}
+		}
+
+		page, err := paging.Apply(&bans, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return BansResponse{
+			Bans: bans,
+			Page: page,
+		}, nil
+	}
+}
+
+// UnbanRequest is the request struct for the UnbanEndpoint
+type UnbanRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// UnbanResponse is the response struct for the UnbanEndpoint
+type UnbanResponse struct {
+	Error error
+}
+
+// MakeUnbanEndpoint creates a gokit endpoint which invokes Unban
+func MakeUnbanEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UnbanRequest)
+		err := s.Unban(req.RefID, req.ID)
+		return UnbanResponse{
+			Error: err,
+		}, nil
+	}
+}
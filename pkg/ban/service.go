@@ -0,0 +1,443 @@
+// Package ban watches the logs of the instances and of the routing configurations of the users who opted in
+// for abuse like authentication failures and bans the offending sources in the firewall for a while
+package ban
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+)
+
+// Sources of the logs jails watch
+const (
+	// Container is the standard output and error of an instance
+	Container = "container"
+	// Access is the access log of a routing configuration
+	Access = "access"
+)
+
+// HostPlaceholder is replaced by the expression matching the source address in the filters of the jails
+const HostPlaceholder = "<HOST>"
+
+// hostExpr matches an IPv4 address, the firewall does not ban IPv6 addresses
+const hostExpr = `(?P<host>(?:[0-9]{1,3}\.){3}[0-9]{1,3})`
+
+// Presets are the filters which are available by name, the access presets expect the combined log format of nginx
+var Presets = map[string]string{
+	"auth":     `^<HOST> \S+ \S+ \[[^\]]*\] "[^"]*" 40[13] `,
+	"notfound": `^<HOST> \S+ \S+ \[[^\]]*\] "[^"]*" 404 `,
+	"sshd":     `(?:Failed password|Invalid user) .*from <HOST>`,
+}
+
+var (
+	// ErrJailExists occurs if a user has a jail of the same name
+	ErrJailExists = errors.New("jail exists already")
+
+	// ErrJailNotExist occurs if a jail does not exist
+	ErrJailNotExist = errors.New("jail does not exist")
+
+	// ErrBanExists occurs if a jail banned a source already
+	ErrBanExists = errors.New("source is banned already")
+
+	// ErrBanNotExist occurs if a ban does not exist
+	ErrBanNotExist = errors.New("ban does not exist")
+
+	// ErrLimit occurs if a user has as many jails as allowed
+	ErrLimit = errors.New("no more jails are allowed")
+
+	// ErrFilter occurs if the filter of a jail is no regular expression with the <HOST> placeholder
+	ErrFilter = errors.New("filter has to be a preset or a regular expression containing <HOST>")
+
+	// ErrIgnored occurs if a source may not be banned
+	ErrIgnored = errors.New("source is never banned")
+
+	// ErrNoFirewall occurs if a source is banned on a node without a firewall
+	ErrNoFirewall = errors.New("firewall is not enabled")
+)
+
+// Service BanService
+type Service interface {
+	// CreateJail creates a jail of a user, its log is watched from the next line on
+	CreateJail(refID uint, j *Jail) error
+
+	// RemoveJail removes a jail and lifts its bans
+	RemoveJail(refID uint, id uint) error
+
+	// Jails returns the jails of a user, or of every user if refID is 0
+	Jails(refID uint, j *[]Jail) error
+
+	// Bans returns the bans of the jails of a user, the latest first
+	Bans(refID uint, b *[]Ban) error
+
+	// Unban lifts a ban before it expires
+	Unban(refID uint, id uint) error
+
+	// Ban bans the source address of a jail on this node until the ban time of the jail passed
+	Ban(j Jail, address string, t Target, now time.Time) (Ban, error)
+
+	// Expire lifts the bans of this node which expired
+	Expire(now time.Time) error
+}
+
+// Compile returns the regular expression of the filter of a jail
+func Compile(filter string) (*regexp.Regexp, error) {
+	if preset, ok := Presets[filter]; ok {
+		filter = preset
+	}
+	if !strings.Contains(filter, HostPlaceholder) {
+		return nil, ErrFilter
+	}
+
+	re, err := regexp.Compile(strings.Replace(filter, HostPlaceholder, hostExpr, 1))
+	if err != nil {
+		return nil, ErrFilter
+	}
+	return re, nil
+}
+
+// Options configure the jails and bans
+type Options struct {
+	// MaxJails is the number of jails a user may have
+	MaxJails uint
+	// MaxBanTime is the longest a source may be banned in seconds
+	MaxBanTime uint
+	// Node is the name of this node
+	Node string
+	// Ignore are the networks whose addresses are never banned
+	Ignore []*net.IPNet
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db       dbAdapter
+	firewall *firewall.Endpoints
+	options  Options
+	mtx      *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Jail{}, &Ban{})
+}
+
+// jails returns the jails of a user, or of every user if refID is 0
+func (s *service) jails(refID uint) ([]Jail, error) {
+	js := []Jail{}
+	var err error
+	if refID == 0 {
+		err = s.db.Find(&js)
+	} else {
+		err = s.db.Find(&js, "ref_id = ?", refID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return js, nil
+}
+
+// bans returns the bans of a user, or of every user if refID is 0, the latest first
+func (s *service) bans(refID uint) ([]Ban, error) {
+	bs := []Ban{}
+	var err error
+	if refID == 0 {
+		err = s.db.FindOrdered(&bs, "id DESC", 0)
+	} else {
+		err = s.db.FindOrdered(&bs, "id DESC", 0, "ref_id = ?", refID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (s *service) CreateJail(refID uint, j *Jail) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	j.ID = 0
+	j.RefID = refID
+
+	_, err := Compile(j.Filter)
+	if err != nil {
+		return err
+	}
+
+	if j.MaxRetry == 0 {
+		j.MaxRetry = 5
+	}
+	if j.FindTime == 0 {
+		j.FindTime = 600
+	}
+	if j.BanTime == 0 {
+		j.BanTime = 3600
+	}
+	if j.BanTime > s.options.MaxBanTime {
+		j.BanTime = s.options.MaxBanTime
+	}
+
+	js, err := s.jails(refID)
+	if err != nil {
+		return err
+	}
+	for _, o := range js {
+		if o.Name == j.Name {
+			return ErrJailExists
+		}
+	}
+	if uint(len(js)) >= s.options.MaxJails {
+		return ErrLimit
+	}
+
+	j.CreatedAt = time.Now().UTC()
+	return s.db.Create(j)
+}
+
+// firewallRequest calls a firewall endpoint with the source, destination and port of b
+func (s *service) firewallRequest(b Ban, unban bool) error {
+	if s.firewall == nil {
+		return ErrNoFirewall
+	}
+
+	if unban {
+		res, err := s.firewall.UnbanEndpoint(context.Background(), firewall.UnbanRequest{
+			Src:  abstraction.Inet(b.Address),
+			Dst:  abstraction.Inet(b.Destination),
+			Port: b.Port,
+		})
+		if err != nil {
+			return err
+		}
+		return res.(firewall.UnbanResponse).Error
+	}
+
+	res, err := s.firewall.BanEndpoint(context.Background(), firewall.BanRequest{
+		Src:  abstraction.Inet(b.Address),
+		Dst:  abstraction.Inet(b.Destination),
+		Port: b.Port,
+	})
+	if err != nil {
+		return err
+	}
+	return res.(firewall.BanResponse).Error
+}
+
+// shared returns whether another ban drops the same packets as b on this node
+func (s *service) shared(b Ban) (bool, error) {
+	err := s.db.First(&Ban{}, "id <> ? AND address = ? AND destination = ? AND port = ? AND node = ?",
+		b.ID, b.Address, b.Destination, b.Port, s.options.Node)
+	if s.db.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// lift removes a ban of this node and its rule unless another ban shares it. Bans of other nodes
+// expire at once instead, so the node which created them removes them on its next Expire.
+func (s *service) lift(b Ban, now time.Time) error {
+	if b.Node != s.options.Node {
+		return abstraction.UpdateByID(s.db, &Ban{}, b.ID, &Ban{Until: now.UTC()})
+	}
+
+	shared, err := s.shared(b)
+	if err != nil {
+		return err
+	}
+	if !shared {
+		err = s.firewallRequest(b, true)
+		if err != nil {
+			return err
+		}
+	}
+	return s.db.Delete(&Ban{ID: b.ID})
+}
+
+func (s *service) RemoveJail(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err := s.db.First(&Jail{}, "id = ? AND ref_id = ?", id, refID)
+	if s.db.IsNotFound(err) {
+		return ErrJailNotExist
+	}
+	if err != nil {
+		return err
+	}
+
+	bs := []Ban{}
+	err = s.db.Find(&bs, "jail_id = ?", id)
+	if err != nil {
+		return err
+	}
+	for _, b := range bs {
+		err = s.lift(b, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return s.db.Delete(&Jail{ID: id})
+}
+
+func (s *service) Jails(refID uint, j *[]Jail) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	js, err := s.jails(refID)
+	if err != nil {
+		return err
+	}
+
+	*j = append(*j, js...)
+	return nil
+}
+
+func (s *service) Bans(refID uint, b *[]Ban) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	bs, err := s.bans(refID)
+	if err != nil {
+		return err
+	}
+
+	*b = append(*b, bs...)
+	return nil
+}
+
+func (s *service) Unban(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	b := Ban{}
+	err := s.db.First(&b, "id = ? AND ref_id = ?", id, refID)
+	if s.db.IsNotFound(err) {
+		return ErrBanNotExist
+	}
+	if err != nil {
+		return err
+	}
+	return s.lift(b, time.Now())
+}
+
+// ignored returns whether address may not be banned
+func (s *service) ignored(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil || ip.To4() == nil || ip.IsLoopback() || ip.IsUnspecified() {
+		return true
+	}
+
+	for _, n := range s.options.Ignore {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) Ban(j Jail, address string, t Target, now time.Time) (Ban, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.ignored(address) {
+		return Ban{}, ErrIgnored
+	}
+
+	err := s.db.First(&Ban{}, "jail_id = ? AND address = ? AND node = ?", j.ID, address, s.options.Node)
+	if err == nil {
+		return Ban{}, ErrBanExists
+	}
+	if !s.db.IsNotFound(err) {
+		return Ban{}, err
+	}
+
+	b := Ban{
+		JailID:      j.ID,
+		RefID:       j.RefID,
+		Jail:        j.Name,
+		Address:     address,
+		Destination: t.Destination,
+		Port:        t.Port,
+		Node:        s.options.Node,
+		CreatedAt:   now.UTC(),
+		Until:       now.Add(time.Duration(j.BanTime) * time.Second).UTC(),
+	}
+
+	// bans of other jails may drop the same packets, they share the rule
+	shared, err := s.shared(b)
+	if err != nil {
+		return Ban{}, err
+	}
+	if !shared {
+		err = s.firewallRequest(b, false)
+		if err != nil {
+			return Ban{}, err
+		}
+	}
+	return b, s.db.Create(&b)
+}
+
+func (s *service) Expire(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	bs := []Ban{}
+	err := s.db.Find(&bs, "node = ? AND until <= ?", s.options.Node, now)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range bs {
+		err = s.lift(b, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewService creates a BanService, fw is the firewall of this node which may be nil if it is not enabled
+func NewService(db dbAdapter, fw *firewall.Endpoints, o Options) (Service, error) {
+	if o.MaxJails == 0 {
+		o.MaxJails = 10
+	}
+	if o.MaxBanTime == 0 {
+		o.MaxBanTime = 7 * 24 * 3600
+	}
+
+	s := &service{
+		db:       db,
+		firewall: fw,
+		options:  o,
+		mtx:      &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
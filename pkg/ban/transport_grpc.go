@@ -0,0 +1,303 @@
+package ban
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ban/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC BanServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.BanServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createJail: grpctransport.NewServer(
+			endpoints.CreateJailEndpoint,
+			DecodeGRPCCreateJailRequest,
+			EncodeGRPCCreateJailResponse,
+			options...,
+		),
+
+		removeJail: grpctransport.NewServer(
+			endpoints.RemoveJailEndpoint,
+			DecodeGRPCRemoveJailRequest,
+			EncodeGRPCRemoveJailResponse,
+			options...,
+		),
+
+		jails: grpctransport.NewServer(
+			endpoints.JailsEndpoint,
+			DecodeGRPCJailsRequest,
+			EncodeGRPCJailsResponse,
+			options...,
+		),
+
+		bans: grpctransport.NewServer(
+			endpoints.BansEndpoint,
+			DecodeGRPCBansRequest,
+			EncodeGRPCBansResponse,
+			options...,
+		),
+
+		unban: grpctransport.NewServer(
+			endpoints.UnbanEndpoint,
+			DecodeGRPCUnbanRequest,
+			EncodeGRPCUnbanResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createJail grpctransport.Handler
+	removeJail grpctransport.Handler
+	jails      grpctransport.Handler
+	bans       grpctransport.Handler
+	unban      grpctransport.Handler
+}
+
+func (s *grpcServer) CreateJail(ctx oldcontext.Context, req *pb.CreateJailRequest) (*pb.CreateJailResponse, error) {
+	_, res, err := s.createJail.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateJailResponse), nil
+}
+
+func (s *grpcServer) RemoveJail(ctx oldcontext.Context, req *pb.RemoveJailRequest) (*pb.RemoveJailResponse, error) {
+	_, res, err := s.removeJail.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveJailResponse), nil
+}
+
+func (s *grpcServer) Jails(ctx oldcontext.Context, req *pb.JailsRequest) (*pb.JailsResponse, error) {
+	_, res, err := s.jails.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.JailsResponse), nil
+}
+
+func (s *grpcServer) Bans(ctx oldcontext.Context, req *pb.BansRequest) (*pb.BansResponse, error) {
+	_, res, err := s.bans.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.BansResponse), nil
+}
+
+func (s *grpcServer) Unban(ctx oldcontext.Context, req *pb.UnbanRequest) (*pb.UnbanResponse, error) {
+	_, res, err := s.unban.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.UnbanResponse), nil
+}
+
+// ConvertJail converts a Jail to its protobuf representation
+func ConvertJail(j Jail) *pb.Jail {
+	return &pb.Jail{
+		ID:        uint32(j.ID),
+		Name:      j.Name,
+		Source:    j.Source,
+		Target:    j.Target,
+		Filter:    j.Filter,
+		MaxRetry:  uint32(j.MaxRetry),
+		FindTime:  uint32(j.FindTime),
+		BanTime:   uint32(j.BanTime),
+		CreatedAt: j.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBJail converts a protobuf Jail to a Jail
+func ConvertPBJail(j *pb.Jail) Jail {
+	if j == nil {
+		return Jail{}
+	}
+
+	return Jail{
+		ID:        uint(j.ID),
+		Name:      j.Name,
+		Source:    j.Source,
+		Target:    j.Target,
+		Filter:    j.Filter,
+		MaxRetry:  uint(j.MaxRetry),
+		FindTime:  uint(j.FindTime),
+		BanTime:   uint(j.BanTime),
+		CreatedAt: time.Unix(j.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertBan converts a Ban to its protobuf representation
+func ConvertBan(b Ban) *pb.Ban {
+	return &pb.Ban{
+		ID:          uint32(b.ID),
+		JailID:      uint32(b.JailID),
+		Jail:        b.Jail,
+		Address:     b.Address,
+		Destination: b.Destination,
+		Port:        uint32(b.Port),
+		Node:        b.Node,
+		CreatedAt:   b.CreatedAt.Unix(),
+		Until:       b.Until.Unix(),
+	}
+}
+
+// ConvertPBBan converts a protobuf Ban to a Ban
+func ConvertPBBan(b *pb.Ban) Ban {
+	if b == nil {
+		return Ban{}
+	}
+
+	return Ban{
+		ID:          uint(b.ID),
+		JailID:      uint(b.JailID),
+		Jail:        b.Jail,
+		Address:     b.Address,
+		Destination: b.Destination,
+		Port:        uint16(b.Port),
+		Node:        b.Node,
+		CreatedAt:   time.Unix(b.CreatedAt, 0).UTC(),
+		Until:       time.Unix(b.Until, 0).UTC(),
+	}
+}
+
+// DecodeGRPCCreateJailRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateJail request to a messages/ban.proto-domain createjail request.
+func DecodeGRPCCreateJailRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateJailRequest)
+	return CreateJailRequest{
+		RefID: uint(req.RefID),
+		Jail: &Jail{
+			Name:     req.Name,
+			Source:   req.Source,
+			Target:   req.Target,
+			Filter:   req.Filter,
+			MaxRetry: uint(req.MaxRetry),
+			FindTime: uint(req.FindTime),
+			BanTime:  uint(req.BanTime),
+		},
+	}, nil
+}
+
+// EncodeGRPCCreateJailResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain createjail response to a gRPC CreateJail response.
+func EncodeGRPCCreateJailResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateJailResponse)
+	gRPCRes := &pb.CreateJailResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveJailRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveJail request to a messages/ban.proto-domain removejail request.
+func DecodeGRPCRemoveJailRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveJailRequest)
+	return RemoveJailRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveJailResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain removejail response to a gRPC RemoveJail response.
+func EncodeGRPCRemoveJailResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveJailResponse)
+	gRPCRes := &pb.RemoveJailResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCJailsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Jails request to a messages/ban.proto-domain jails request.
+func DecodeGRPCJailsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.JailsRequest)
+	return JailsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCJailsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain jails response to a gRPC Jails response.
+func EncodeGRPCJailsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(JailsResponse)
+	jails := make([]*pb.Jail, len(res.Jails))
+	for i, j := range res.Jails {
+		jails[i] = ConvertJail(j)
+	}
+
+	gRPCRes := &pb.JailsResponse{
+		Jails:    jails,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCBansRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Bans request to a messages/ban.proto-domain bans request.
+func DecodeGRPCBansRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.BansRequest)
+	return BansRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCBansResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain bans response to a gRPC Bans response.
+func EncodeGRPCBansResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(BansResponse)
+	bans := make([]*pb.Ban, len(res.Bans))
+	for i, b := range res.Bans {
+		bans[i] = ConvertBan(b)
+	}
+
+	gRPCRes := &pb.BansResponse{
+		Bans:     bans,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCUnbanRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Unban request to a messages/ban.proto-domain unban request.
+func DecodeGRPCUnbanRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UnbanRequest)
+	return UnbanRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCUnbanResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ban.proto-domain unban response to a gRPC Unban response.
+func EncodeGRPCUnbanResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(UnbanResponse)
+	gRPCRes := &pb.UnbanResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,256 @@
+package ban
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// TargetFunc returns the target of a jail on this node, ok is false if the instance or routing configuration
+// the jail watches does not exist on this node
+type TargetFunc func(j Jail) (t Target, ok bool, err error)
+
+type watchKey struct {
+	jail uint
+	path string
+}
+
+type hitKey struct {
+	jail    uint
+	address string
+}
+
+// Watcher matches the lines appended to the logs of the jails against their filters and bans the
+// sources which were matched too often
+type Watcher struct {
+	s       Service
+	targets TargetFunc
+	logger  log.Logger
+
+	mtx     sync.Mutex
+	offsets map[watchKey]int64
+	hits    map[hitKey][]time.Time
+	filters map[uint]*regexp.Regexp
+}
+
+// read returns the complete lines which were appended to a log of a jail since the last call.
+// Logs are read from their end when a jail watches them the first time, so lines written before the jail
+// was created or before a restart do not count, logs which shrank because they were rotated are read
+// from their start.
+func (w *Watcher) read(key watchKey) ([]string, error) {
+	offset, known := w.offsets[key]
+
+	f, err := os.Open(key.path)
+	if os.IsNotExist(err) {
+		w.offsets[key] = 0
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if !known {
+		w.offsets[key] = info.Size()
+		return nil, nil
+	}
+
+	if info.Size() < offset {
+		offset = 0
+	}
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			// an incomplete line is read again once it was written completely
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		offset += int64(len(line))
+		lines = append(lines, strings.TrimRight(line, "\r\n"))
+	}
+
+	w.offsets[key] = offset
+	return lines, nil
+}
+
+// filter returns the compiled filter of a jail, jails cannot be changed so it is compiled once
+func (w *Watcher) filter(j Jail) (*regexp.Regexp, error) {
+	re, ok := w.filters[j.ID]
+	if ok {
+		return re, nil
+	}
+
+	re, err := Compile(j.Filter)
+	if err != nil {
+		return nil, err
+	}
+	w.filters[j.ID] = re
+	return re, nil
+}
+
+// host returns the address the filter matched in line
+func host(re *regexp.Regexp, line string) (string, bool) {
+	m := re.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+
+	for i, name := range re.SubexpNames() {
+		if name == "host" {
+			return m[i], true
+		}
+	}
+	return "", false
+}
+
+// hit records a match of a source and returns whether the source was matched MaxRetry times within FindTime
+func (w *Watcher) hit(j Jail, address string, now time.Time) bool {
+	key := hitKey{j.ID, address}
+	since := now.Add(-time.Duration(j.FindTime) * time.Second)
+
+	hits := []time.Time{now}
+	for _, t := range w.hits[key] {
+		if t.After(since) {
+			hits = append(hits, t)
+		}
+	}
+	w.hits[key] = hits
+
+	return uint(len(hits)) >= j.MaxRetry
+}
+
+// watch reads the new lines of the logs of a jail and bans the sources its filter matched too often
+func (w *Watcher) watch(j Jail, active map[watchKey]bool, now time.Time) {
+	re, err := w.filter(j)
+	if err != nil {
+		level.Error(w.logger).Log("jail", j.ID, "err", err)
+		return
+	}
+
+	t, ok, err := w.targets(j)
+	if err != nil {
+		level.Error(w.logger).Log("jail", j.ID, "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	for _, path := range t.Paths {
+		key := watchKey{j.ID, path}
+		active[key] = true
+
+		lines, err := w.read(key)
+		if err != nil {
+			level.Error(w.logger).Log("jail", j.ID, "log", path, "err", err)
+			continue
+		}
+
+		for _, line := range lines {
+			address, ok := host(re, line)
+			if !ok || !w.hit(j, address, now) {
+				continue
+			}
+
+			delete(w.hits, hitKey{j.ID, address})
+			_, err = w.s.Ban(j, address, t, now)
+			if err != nil && err != ErrBanExists && err != ErrIgnored {
+				level.Error(w.logger).Log("jail", j.ID, "address", address, "err", err)
+			}
+		}
+	}
+}
+
+// Watch reads the new lines of the logs of every jail, bans the offending sources and lifts the expired bans
+func (w *Watcher) Watch(now time.Time) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	js := []Jail{}
+	err := w.s.Jails(0, &js)
+	if err != nil {
+		level.Error(w.logger).Log("err", err)
+		return
+	}
+
+	jails := make(map[uint]Jail)
+	active := make(map[watchKey]bool)
+	for _, j := range js {
+		jails[j.ID] = j
+		w.watch(j, active, now)
+	}
+
+	// the offsets of removed jails and instances and the matches which are too old to count are forgotten
+	for key := range w.offsets {
+		if !active[key] {
+			delete(w.offsets, key)
+		}
+	}
+	for key, hits := range w.hits {
+		j, ok := jails[key.jail]
+		if !ok || hits[0].Before(now.Add(-time.Duration(j.FindTime)*time.Second)) {
+			delete(w.hits, key)
+		}
+	}
+	for id := range w.filters {
+		if _, ok := jails[id]; !ok {
+			delete(w.filters, id)
+		}
+	}
+
+	err = w.s.Expire(now)
+	if err != nil {
+		level.Error(w.logger).Log("err", err)
+	}
+}
+
+// Run calls Watch every interval until stop is closed
+func (w *Watcher) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		w.Watch(time.Now())
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewWatcher returns a Watcher for the jails of s whose logs targets returns
+func NewWatcher(s Service, targets TargetFunc, logger log.Logger) *Watcher {
+	return &Watcher{
+		s:       s,
+		targets: targets,
+		logger:  logger,
+		offsets: make(map[watchKey]int64),
+		hits:    make(map[hitKey][]time.Time),
+		filters: make(map[uint]*regexp.Regexp),
+	}
+}
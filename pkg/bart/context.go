@@ -0,0 +1,16 @@
+package bart
+
+import "context"
+
+type callerKey struct{}
+
+// ContextWithCaller returns a copy of ctx carrying the id of the authenticated user making a call
+func ContextWithCaller(ctx context.Context, id uint) context.Context {
+	return context.WithValue(ctx, callerKey{}, id)
+}
+
+// Caller returns the id of the user making a call, ok is false if the call is not authenticated
+func Caller(ctx context.Context) (id uint, ok bool) {
+	id, ok = ctx.Value(callerKey{}).(uint)
+	return id, ok
+}
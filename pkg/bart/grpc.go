@@ -0,0 +1,262 @@
+package bart
+
+import (
+	"reflect"
+)
+
+// ownerCheck reports whether the user id owns the gRPC request req, so it may be called by them
+type ownerCheck func(req interface{}, id uint) bool
+
+// uintField returns an ownerCheck comparing the unsigned integer field of a request found by following
+// the field names of path, requests without the field are owned by nobody
+func uintField(path ...string) ownerCheck {
+	return func(req interface{}, id uint) bool {
+		val := reflect.ValueOf(req)
+		for _, name := range path {
+			val = reflect.Indirect(val)
+			if val.Kind() != reflect.Struct {
+				return false
+			}
+
+			val = val.FieldByName(name)
+			if !val.IsValid() {
+				return false
+			}
+		}
+
+		switch val.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return val.Uint() == uint64(id)
+		}
+		return false
+	}
+}
+
+var (
+	// refID checks the RefID of a request, the id of the user most requests concern
+	refID = uintField("RefID")
+
+	// userID checks the ID of the requests of the user service
+	userID = uintField("ID")
+
+	// configRefID checks the RefID of the router configuration of a request
+	configRefID = uintField("Config", "RefID")
+)
+
+// anyUser is the ownerCheck of the methods every user may call, they concern no data of a single user
+func anyUser(req interface{}, id uint) bool {
+	return true
+}
+
+// grpcOwner are the ownerChecks of the gRPC methods users may call, every other method may only be
+// called by admins. A method has to be added here once users should call it, with the check of the
+// field naming the user its request concerns.
+var grpcOwner = map[string]ownerCheck{
+	"alert.AlertService/Alerts":     refID,
+	"alert.AlertService/CreateRule": refID,
+	"alert.AlertService/RemoveRule": refID,
+	"alert.AlertService/Rules":      refID,
+	"alert.AlertService/States":     refID,
+
+	"autoscale.AutoscaleService/CreateWindow": refID,
+	"autoscale.AutoscaleService/Events":       refID,
+	"autoscale.AutoscaleService/Policies":     refID,
+	"autoscale.AutoscaleService/RemovePolicy": refID,
+	"autoscale.AutoscaleService/RemoveWindow": refID,
+	"autoscale.AutoscaleService/SetPolicy":    refID,
+	"autoscale.AutoscaleService/Windows":      refID,
+
+	"ban.BanService/Bans":       refID,
+	"ban.BanService/CreateJail": refID,
+	"ban.BanService/Jails":      refID,
+	"ban.BanService/RemoveJail": refID,
+	"ban.BanService/Unban":      refID,
+
+	"billing.BillingService/Account":     refID,
+	"billing.BillingService/Document":    refID,
+	"billing.BillingService/DocumentURL": refID,
+	"billing.BillingService/Invoices":    refID,
+
+	"container.ContainerService/CheckpointInstance": refID,
+	"container.ContainerService/Checkpoints":        refID,
+	"container.ContainerService/CloneInstance":      refID,
+	"container.ContainerService/CreateContainer":    refID,
+	"container.ContainerService/Execute":            refID,
+	"container.ContainerService/ExportInstance":     refID,
+	"container.ContainerService/GetEnv":             refID,
+	"container.ContainerService/GetLinks":           refID,
+	"container.ContainerService/IDForName":          refID,
+	"container.ContainerService/Instances":          refID,
+	"container.ContainerService/PinInstance":        refID,
+	"container.ContainerService/RemoveCheckpoint":   refID,
+	"container.ContainerService/RemoveContainer":    refID,
+	"container.ContainerService/RemoveLink":         refID,
+	"container.ContainerService/RestoreInstance":    refID,
+	"container.ContainerService/ScaleInstance":      refID,
+	"container.ContainerService/SetEnv":             refID,
+	"container.ContainerService/SetLink":            refID,
+	"container.ContainerService/SetPlacement":       refID,
+	"container.ContainerService/StopContainer":      refID,
+
+	"containerlog.ContainerLogService/Search": refID,
+
+	"cronjob.CronJobService/CreateJob": refID,
+	"cronjob.CronJobService/Jobs":      refID,
+	"cronjob.CronJobService/RemoveJob": refID,
+	"cronjob.CronJobService/RunJob":    refID,
+	"cronjob.CronJobService/Runs":      refID,
+
+	"database.DatabaseService/CreateDatabase": refID,
+	"database.DatabaseService/Databases":      refID,
+	"database.DatabaseService/DumpDatabase":   refID,
+	"database.DatabaseService/Dumps":          refID,
+	"database.DatabaseService/LinkDatabase":   refID,
+	"database.DatabaseService/RemoveDatabase": refID,
+	"database.DatabaseService/UnlinkDatabase": refID,
+
+	"deploy.DeployService/CreateHook":   refID,
+	"deploy.DeployService/Deployments":  refID,
+	"deploy.DeployService/EditHook":     refID,
+	"deploy.DeployService/GetRetention": refID,
+	"deploy.DeployService/Hooks":        refID,
+	"deploy.DeployService/Log":          refID,
+	"deploy.DeployService/PruneImages":  refID,
+	"deploy.DeployService/RemoveHook":   refID,
+	"deploy.DeployService/Rollback":     refID,
+	"deploy.DeployService/SetRetention": refID,
+
+	"dns.DNSService/AddCustomDomain":       refID,
+	"dns.DNSService/CreateInstanceRecords": refID,
+	"dns.DNSService/CreateRecord":          refID,
+	"dns.DNSService/CustomDomains":         refID,
+	"dns.DNSService/Records":               refID,
+	"dns.DNSService/RemoveCustomDomain":    refID,
+	"dns.DNSService/RemoveInstanceRecords": refID,
+	"dns.DNSService/RemoveRecord":          refID,
+	"dns.DNSService/VerifyCustomDomain":    refID,
+
+	"export.ExportService/Download":       refID,
+	"export.ExportService/DownloadURL":    refID,
+	"export.ExportService/ExportUserData": refID,
+	"export.ExportService/Exports":        refID,
+
+	"kmi.KMIService/GetKMI": anyUser,
+	"kmi.KMIService/KMI":    anyUser,
+	"kmi.KMIService/UIs":    anyUser,
+
+	"management.ManagementService/CreateDomain":   refID,
+	"management.ManagementService/CreateInstance": refID,
+	"management.ManagementService/CreatePolicy":   refID,
+	"management.ManagementService/DeleteDomain":   refID,
+	"management.ManagementService/DeleteInstance": refID,
+	"management.ManagementService/DeletePolicy":   refID,
+	"management.ManagementService/GetDomain":      refID,
+	"management.ManagementService/GetInstance":    refID,
+	"management.ManagementService/GetPolicy":      refID,
+	"management.ManagementService/UpdateInstance": refID,
+
+	"module.ModuleService/CreateContainerModule": refID,
+	"module.ModuleService/GetEnv":                refID,
+	"module.ModuleService/GetFile":               refID,
+	"module.ModuleService/GetFiles":              refID,
+	"module.ModuleService/GetModuleConfig":       refID,
+	"module.ModuleService/GetModules":            refID,
+	"module.ModuleService/ListDirectory":         refID,
+	"module.ModuleService/ReadFile":              refID,
+	"module.ModuleService/RemoveDirectory":       refID,
+	"module.ModuleService/RemoveFile":            refID,
+	"module.ModuleService/RemoveLink":            refID,
+	"module.ModuleService/SendCommand":           refID,
+	"module.ModuleService/SetEnv":                refID,
+	"module.ModuleService/SetLink":               refID,
+	"module.ModuleService/SetPublicKey":          refID,
+	"module.ModuleService/StatFile":              refID,
+	"module.ModuleService/UploadFile":            refID,
+
+	"network.NetworkService/AddContainerToNetwork":            refID,
+	"network.NetworkService/ApprovePeering":                   refID,
+	"network.NetworkService/AssignIP":                         refID,
+	"network.NetworkService/CreateBridge":                     refID,
+	"network.NetworkService/CreateNetwork":                    refID,
+	"network.NetworkService/CreatePrimaryNetworkForContainer": refID,
+	"network.NetworkService/ExposePortToContainer":            refID,
+	"network.NetworkService/Peerings":                         refID,
+	"network.NetworkService/ReleaseIP":                        refID,
+	"network.NetworkService/RemoveBridge":                     refID,
+	"network.NetworkService/RemoveContainerFromNetwork":       refID,
+	"network.NetworkService/RemoveNetworkByName":              refID,
+	"network.NetworkService/RemovePortFromContainer":          refID,
+	"network.NetworkService/RequestPeering":                   refID,
+	"network.NetworkService/RevokePeering":                    refID,
+	"network.NetworkService/TotalUsage":                       refID,
+	"network.NetworkService/Usage":                            refID,
+
+	"ports.PortService/AllocatePort": refID,
+	"ports.PortService/ReleasePort":  refID,
+	"ports.PortService/Reservations": refID,
+	"ports.PortService/ReservePort":  refID,
+
+	"resolver.ResolverService/Lookup":  refID,
+	"resolver.ResolverService/Records": refID,
+
+	"routing.RoutingService/AddLocation":           refID,
+	"routing.RoutingService/AddServerName":         refID,
+	"routing.RoutingService/AddUpstreamMember":     refID,
+	"routing.RoutingService/ChangeListenStatement": refID,
+	"routing.RoutingService/CreateConfig":          configRefID,
+	"routing.RoutingService/EditConfig":            refID,
+	"routing.RoutingService/GetConfig":             refID,
+	"routing.RoutingService/Quota":                 refID,
+	"routing.RoutingService/RemoveConfig":          refID,
+	"routing.RoutingService/RemoveLocation":        refID,
+	"routing.RoutingService/RemoveServerName":      refID,
+	"routing.RoutingService/RemoveSwitch":          refID,
+	"routing.RoutingService/RemoveUpstream":        refID,
+	"routing.RoutingService/RemoveUpstreamMember":  refID,
+	"routing.RoutingService/SetErrorPage":          refID,
+	"routing.RoutingService/SetHTTPSPolicy":        refID,
+	"routing.RoutingService/SetMaintenance":        refID,
+	"routing.RoutingService/SetProxyLimits":        refID,
+	"routing.RoutingService/SetSwitch":             refID,
+	"routing.RoutingService/SetUpstream":           refID,
+	"routing.RoutingService/Traffic":               refID,
+
+	"site.SiteService/CreateSite": refID,
+	"site.SiteService/Deploy":     refID,
+	"site.SiteService/EditSite":   refID,
+	"site.SiteService/GetSite":    refID,
+	"site.SiteService/RemoveSite": refID,
+	"site.SiteService/Sites":      refID,
+
+	"snapshot.SnapshotService/CreateSchedule":  refID,
+	"snapshot.SnapshotService/RemoveSchedule":  refID,
+	"snapshot.SnapshotService/RemoveSnapshot":  refID,
+	"snapshot.SnapshotService/RestoreSnapshot": refID,
+	"snapshot.SnapshotService/Schedules":       refID,
+	"snapshot.SnapshotService/Snapshots":       refID,
+	"snapshot.SnapshotService/TakeSnapshot":    refID,
+
+	"sshkey.SSHKeyService/AddKey":    refID,
+	"sshkey.SSHKeyService/Keys":      refID,
+	"sshkey.SSHKeyService/RemoveKey": refID,
+
+	"usage.UsageService/Query":           refID,
+	"usage.UsageService/Recommendations": refID,
+
+	"user.UserService/ChangeUsername": userID,
+	"user.UserService/DeleteUser":     userID,
+	"user.UserService/EditUser":       userID,
+	"user.UserService/GetUser":        userID,
+	"user.UserService/ResetPassword":  anyUser,
+
+	"webhook.WebhookService/CreateWebhook": refID,
+	"webhook.WebhookService/Deliveries":    refID,
+	"webhook.WebhookService/RemoveWebhook": refID,
+	"webhook.WebhookService/Webhooks":      refID,
+
+	"wireguard.WireGuardService/CreatePeer": refID,
+	"wireguard.WireGuardService/Peers":      refID,
+	"wireguard.WireGuardService/RemovePeer": refID,
+	"wireguard.WireGuardService/RotateKey":  refID,
+	"wireguard.WireGuardService/SetRules":   refID,
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"reflect"
 	"regexp"
+	"strings"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	pb "github.com/kontainerooo/kontainer.ooo/pkg/kentheguru/pb"
@@ -15,6 +16,23 @@ import (
 
 var refRegexp = regexp.MustCompile("ref")
 
+// ownerOnly are the gRPC methods and websocket endpoints, as service and method id, which expose the
+// files inside of the containers of a user, so admins may only call them for their own containers
+var ownerOnly = map[string]bool{
+	"module.ModuleService/ListDirectory": true,
+	"module.ModuleService/StatFile":      true,
+	"module.ModuleService/ReadFile":      true,
+	"MDL/LSD":                            true,
+	"MDL/STF":                            true,
+	"MDL/RDF":                            true,
+}
+
+// OwnerOnly reports whether the gRPC method fullMethod exposes the files inside of the containers of a user,
+// so nobody but their owner may call it, not even an admin impersonating the owner
+func OwnerOnly(fullMethod string) bool {
+	return ownerOnly[strings.TrimPrefix(fullMethod, "/")]
+}
+
 // Bus is a permission management system
 type Bus interface {
 	// GetOff should be used as a websocket before middleware
@@ -25,30 +43,30 @@ type Bus interface {
 
 	// LostAndFound should be used as a websocket before middleware
 	LostAndFound(ws.ProtoID, ws.ProtoID, *ws.MiddlewareData, interface{}) error
+
+	// CheckGRPC should be used by a gRPC interceptor to check if the user id may call fullMethod with req
+	CheckGRPC(fullMethod string, req interface{}, id uint) error
 }
 
 type bus struct {
 	ue         user.Endpoints
 	signingKey []byte
-	admins     map[uint]bool
 	tiers      map[uint]string
 	fieldID    map[string]int
 }
 
+// IsAdmin looks the user up for every check, so a changed admin flag applies at once, the user
+// service caches the lookups
 func (b *bus) IsAdmin(id uint) bool {
-	admin, ok := b.admins[id]
-	if !ok {
-		res, err := b.ue.GetUserEndpoint(context.Background(), user.GetUserRequest{
-			ID: id,
-		})
-		if err != nil {
-			return false
-		}
-		b.admins[id] = res.(user.GetUserResponse).User.Admin
-		admin = b.admins[id]
+	res, err := b.ue.GetUserEndpoint(context.Background(), user.GetUserRequest{
+		ID: id,
+	})
+	if err != nil {
+		return false
 	}
 
-	return admin
+	r := res.(user.GetUserResponse)
+	return r.Error == nil && r.User != nil && r.User.Admin
 }
 
 func (b *bus) CheckServiceAccess(srv, me string, id uint) error {
@@ -105,6 +123,25 @@ func (b *bus) CheckID(srv, me string, data interface{}, id uint) error {
 	return nil
 }
 
+// CheckGRPC lets admins call every method but the ownerOnly ones of other users, users may only call
+// the methods of grpcOwner with requests they own
+func (b *bus) CheckGRPC(fullMethod string, req interface{}, id uint) error {
+	method := strings.TrimPrefix(fullMethod, "/")
+	if !ownerOnly[method] && b.IsAdmin(id) {
+		return nil
+	}
+
+	owns, ok := grpcOwner[method]
+	if !ok {
+		return errors.New("not allowed")
+	}
+
+	if !owns(req, id) {
+		return errors.New("wrong id")
+	}
+	return nil
+}
+
 func (b *bus) GetOff(srv, me ws.ProtoID, data *ws.MiddlewareData, session interface{}) error {
 	service := srv.String()
 	method := me.String()
@@ -129,7 +166,7 @@ func (b *bus) GetOff(srv, me ws.ProtoID, data *ws.MiddlewareData, session interf
 	}
 	id := uint(id64)
 
-	if b.IsAdmin(id) {
+	if !ownerOnly[service+"/"+method] && b.IsAdmin(id) {
 		return nil
 	}
 
@@ -232,7 +269,6 @@ func NewBus(signingKey string, ue user.Endpoints) Bus {
 	return &bus{
 		ue:         ue,
 		signingKey: []byte(signingKey),
-		admins:     make(map[uint]bool),
 		tiers:      make(map[uint]string),
 		fieldID:    make(map[string]int),
 	}
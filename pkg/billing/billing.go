@@ -0,0 +1,545 @@
+// Package billing charges the users for their plans and traffic through a payment provider like Stripe,
+// issues their monthly invoices and suspends the users whose payments failed for longer than a grace period
+package billing
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/download"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+// States of an account
+const (
+	Active    = "active"
+	PastDue   = "past_due"
+	Suspended = "suspended"
+	Canceled  = "canceled"
+)
+
+// Kinds of line items
+const (
+	PlanItem    = "plan"
+	TrafficItem = "traffic"
+)
+
+// gib is the number of bytes of the traffic charged per unit
+const gib = 1 << 30
+
+var (
+	// ErrPlanNotExist occurs if a user subscribes to a plan which is not configured
+	ErrPlanNotExist = errors.New("plan does not exist")
+
+	// ErrAccountNotExist occurs if a user has no billing account
+	ErrAccountNotExist = errors.New("account does not exist")
+
+	// ErrSubscribed occurs if a user with an active subscription subscribes again
+	ErrSubscribed = errors.New("account is subscribed already")
+)
+
+// Service BillingService
+type Service interface {
+	// SetPlans replaces the configured plans, subscriptions to removed plans are kept
+	SetPlans(plans map[string]Plan)
+
+	// Subscribe creates the account of a user at the provider and subscribes it to a plan,
+	// a canceled account is subscribed again
+	Subscribe(refID uint, plan string) error
+
+	// Cancel cancels the subscription of a user, the line items are kept
+	Cancel(refID uint) error
+
+	// Account returns the account of a user
+	Account(refID uint) (Account, error)
+
+	// LineItems returns the line items of a user for the month of period, or for every month if period is zero
+	LineItems(refID uint, period time.Time, items *[]LineItem) error
+
+	// Bill records the line items of every subscribed account for the month before now, adds the
+	// usage based items to the next invoice of the provider and issues the invoice of the month,
+	// months which were billed are skipped
+	Bill(now time.Time) error
+
+	// Invoices returns the invoices and credit notes of a user, the latest first
+	Invoices(refID uint, invoices *[]Invoice) error
+
+	// Document returns the pdf or json document of an invoice or credit note of a user
+	Document(refID uint, id uint, format string) ([]byte, error)
+
+	// DocumentURL returns the signed URL the pdf or json document of an invoice or credit note of a user
+	// can be downloaded from and when it expires
+	DocumentURL(refID uint, id uint, format string) (string, time.Time, error)
+
+	// CreateCreditNote corrects an invoice of a user by crediting amount, or the rest of its total if amount
+	// is 0, to the customer at the provider, the credit is deducted from the next payments of the user
+	CreateCreditNote(refID uint, invoiceID uint, amount uint64, reason string) (Invoice, error)
+
+	// HandleEvent updates the account of the customer of an event of the provider
+	HandleEvent(e Event) error
+
+	// Enforce suspends the accounts which are past due for longer than the grace period
+	Enforce(now time.Time) error
+}
+
+// Emails returns the email addresses of the users, it is satisfied by the recipients of the mail service
+type Emails interface {
+	Email(refID uint) (string, error)
+}
+
+// Usage returns the traffic of the users, it is satisfied by the network service
+type Usage interface {
+	TotalUsage(refid uint, from time.Time, to time.Time) (network.Bytes, error)
+}
+
+// SuspendHook is called once an account was suspended or resumed, e.g. to stop the instances of the user
+type SuspendHook func(refID uint, suspended bool) error
+
+// Options configure the billing
+type Options struct {
+	// Currency is the ISO code of the currency of the plans, it defaults to eur
+	Currency string
+	// GracePeriod is how long an account may be past due before it is suspended
+	GracePeriod time.Duration
+	// Issuer is the name and address printed on the invoices, one line each
+	Issuer string
+	// Downloads hands out the download URLs of the documents, there are none if it is nil
+	Downloads download.URLs
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Sum(interface{}, string, *int64, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db       dbAdapter
+	provider Provider
+	emails   Emails
+	usage    Usage
+	store    storage.Store
+	options  Options
+	hooks    []SuspendHook
+	logger   log.Logger
+	plans    map[string]Plan
+	mtx      *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.AutoMigrate(&Account{}, &LineItem{}, &Invoice{})
+}
+
+func (s *service) SetPlans(plans map[string]Plan) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.plans = plans
+}
+
+func (s *service) account(refID uint) (Account, error) {
+	a := Account{}
+	err := s.db.First(&a, "ref_id = ?", refID)
+	if s.db.IsNotFound(err) {
+		return Account{}, ErrAccountNotExist
+	}
+	if err != nil {
+		return Account{}, err
+	}
+	return a, nil
+}
+
+func (s *service) Subscribe(refID uint, plan string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, ok := s.plans[plan]
+	if !ok {
+		return ErrPlanNotExist
+	}
+
+	a, err := s.account(refID)
+	if err == ErrAccountNotExist {
+		email, err := s.emails.Email(refID)
+		if err != nil {
+			return err
+		}
+
+		customer, err := s.provider.CreateCustomer(refID, email)
+		if err != nil {
+			return err
+		}
+
+		a = Account{
+			RefID:      refID,
+			CustomerID: customer,
+			Status:     Canceled,
+			CreatedAt:  time.Now().UTC(),
+		}
+		err = s.db.Create(&a)
+		if err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if a.Status != Canceled {
+		return ErrSubscribed
+	}
+
+	subscription, err := s.provider.Subscribe(a.CustomerID, p)
+	if err != nil {
+		return err
+	}
+
+	return abstraction.UpdateByID(s.db, &Account{}, a.ID, &Account{
+		Plan:           plan,
+		SubscriptionID: subscription,
+		Status:         Active,
+	})
+}
+
+func (s *service) Cancel(refID uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	a, err := s.account(refID)
+	if err != nil {
+		return err
+	}
+	if a.Status == Canceled {
+		return nil
+	}
+
+	err = s.provider.Cancel(a.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	return abstraction.UpdateByID(s.db, &Account{}, a.ID, &Account{Status: Canceled})
+}
+
+func (s *service) Account(refID uint) (Account, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.account(refID)
+}
+
+// lineItems returns the line items of a user, or of every user if refID is 0, for the month of period,
+// or of every month if period is zero, ordered by their creation
+func (s *service) lineItems(refID uint, period time.Time) ([]LineItem, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if refID != 0 {
+		conditions = append(conditions, "ref_id = ?")
+		args = append(args, refID)
+	}
+	if !period.IsZero() {
+		conditions = append(conditions, "period = ?")
+		args = append(args, Month(period))
+	}
+
+	where := []interface{}{}
+	if len(conditions) != 0 {
+		where = append([]interface{}{strings.Join(conditions, " AND ")}, args...)
+	}
+
+	items := []LineItem{}
+	err := s.db.FindOrdered(&items, "id", 0, where...)
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *service) LineItems(refID uint, period time.Time, items *[]LineItem) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	is, err := s.lineItems(refID, period)
+	if err != nil {
+		return err
+	}
+
+	*items = append(*items, is...)
+	return nil
+}
+
+// Month returns the first day of the month of t in UTC
+func Month(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// items returns the line items of an account for the month starting at period
+func (s *service) items(a Account, p Plan, period time.Time) ([]LineItem, error) {
+	items := []LineItem{{
+		RefID:       a.RefID,
+		Period:      period,
+		Kind:        PlanItem,
+		Description: fmt.Sprintf("Plan %s %s", a.Plan, period.Format("January 2006")),
+		Quantity:    1,
+		UnitAmount:  p.Price,
+		Amount:      p.Price,
+	}}
+
+	if s.usage == nil || p.TrafficPrice == 0 {
+		return items, nil
+	}
+
+	total, err := s.usage.TotalUsage(a.RefID, period, period.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+
+	// started GiB are charged
+	traffic := (total.In + total.Out + gib - 1) / gib
+	if traffic <= p.IncludedTraffic {
+		return items, nil
+	}
+
+	exceeding := traffic - p.IncludedTraffic
+	return append(items, LineItem{
+		RefID:       a.RefID,
+		Period:      period,
+		Kind:        TrafficItem,
+		Description: fmt.Sprintf("Traffic %s, %d GiB exceeding the %d GiB included", period.Format("January 2006"), exceeding, p.IncludedTraffic),
+		Quantity:    exceeding,
+		UnitAmount:  p.TrafficPrice,
+		Amount:      exceeding * p.TrafficPrice,
+	}), nil
+}
+
+// bill records the items of a for the month starting at period, items which exist already are skipped
+func (s *service) bill(a Account, period time.Time, billed map[string]bool) error {
+	p, ok := s.plans[a.Plan]
+	if !ok {
+		return fmt.Errorf("account of user %d: %v: %s", a.RefID, ErrPlanNotExist, a.Plan)
+	}
+
+	items, err := s.items(a, p, period)
+	if err != nil {
+		return err
+	}
+
+	for n := range items {
+		i := &items[n]
+		if billed[i.Kind] {
+			continue
+		}
+
+		i.Currency = s.options.Currency
+		i.CreatedAt = time.Now().UTC()
+		err = s.db.Create(i)
+		if err != nil {
+			return err
+		}
+
+		// the plan is paid by the subscription
+		if i.Kind == PlanItem {
+			continue
+		}
+
+		id, err := s.provider.AddInvoiceItem(a.CustomerID, *i)
+		if err != nil {
+			return err
+		}
+
+		err = abstraction.UpdateByID(s.db, &LineItem{}, i.ID, &LineItem{ProviderID: id})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) Bill(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	period := Month(now).AddDate(0, -1, 0)
+
+	// the accounts which are not canceled and existed within the month
+	as := []Account{}
+	err := s.db.Find(&as, "status <> ? AND created_at <= ?", Canceled, period.AddDate(0, 1, 0))
+	if err != nil {
+		return err
+	}
+
+	items, err := s.lineItems(0, period)
+	if err != nil {
+		return err
+	}
+
+	billed := make(map[uint]map[string]bool)
+	for _, i := range items {
+		if billed[i.RefID] == nil {
+			billed[i.RefID] = make(map[string]bool)
+		}
+		billed[i.RefID][i.Kind] = true
+	}
+
+	invoices := []Invoice{}
+	err = s.db.Find(&invoices, "kind = ? AND period = ?", InvoiceKind, period)
+	if err != nil {
+		return err
+	}
+
+	issued := make(map[uint]bool)
+	for _, i := range invoices {
+		issued[i.RefID] = true
+	}
+
+	var failed error
+	for _, a := range as {
+		err = s.bill(a, period, billed[a.RefID])
+		if err == nil && !issued[a.RefID] {
+			err = s.issue(a, period)
+		}
+		if err != nil {
+			level.Error(s.logger).Log("user", a.RefID, "period", period.Format("2006-01"), "err", err)
+			failed = err
+		}
+	}
+	return failed
+}
+
+// customer returns the account of a customer of the provider
+func (s *service) customer(id string) (Account, error) {
+	a := Account{}
+	err := s.db.First(&a, "customer_id = ?", id)
+	if s.db.IsNotFound(err) {
+		return Account{}, ErrAccountNotExist
+	}
+	if err != nil {
+		return Account{}, err
+	}
+	return a, nil
+}
+
+// suspend calls the hooks of an account which was suspended or resumed
+func (s *service) suspend(refID uint, suspended bool) error {
+	for _, hook := range s.hooks {
+		err := hook(refID, suspended)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) HandleEvent(e Event) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	a, err := s.customer(e.CustomerID)
+	if err == ErrAccountNotExist {
+		// the customer was not created by this installation
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch e.Type {
+	case PaymentFailed:
+		if a.Status != Active {
+			return nil
+		}
+		if e.Time.IsZero() {
+			e.Time = time.Now()
+		}
+		return abstraction.UpdateByID(s.db, &Account{}, a.ID, &Account{Status: PastDue, PastDueSince: e.Time.UTC()})
+	case PaymentSucceeded:
+		if a.Status != PastDue && a.Status != Suspended {
+			return nil
+		}
+		if a.Status == Suspended {
+			err = s.suspend(a.RefID, false)
+			if err != nil {
+				return err
+			}
+		}
+		return abstraction.UpdateByID(s.db, &Account{}, a.ID, &Account{Status: Active})
+	case SubscriptionCanceled:
+		if a.SubscriptionID != e.SubscriptionID || a.Status == Canceled {
+			return nil
+		}
+		return abstraction.UpdateByID(s.db, &Account{}, a.ID, &Account{Status: Canceled})
+	}
+	return nil
+}
+
+func (s *service) Enforce(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// the accounts which are past due for the grace period
+	as := []Account{}
+	err := s.db.Find(&as, "status = ? AND past_due_since <= ?", PastDue, now.Add(-s.options.GracePeriod))
+	if err != nil {
+		return err
+	}
+
+	var failed error
+	for _, a := range as {
+		// the account stays past due if a hook fails, so the suspension is tried again
+		err = s.suspend(a.RefID, true)
+		if err == nil {
+			err = abstraction.UpdateByID(s.db, &Account{}, a.ID, &Account{Status: Suspended})
+		}
+		if err != nil {
+			level.Error(s.logger).Log("user", a.RefID, "err", err)
+			failed = err
+		}
+	}
+	return failed
+}
+
+// NewService returns a new billing service charging the users through p, usage is optional and
+// traffic is not charged without it. The documents of the invoices are kept in store.
+// The hooks are called once an account was suspended or resumed.
+func NewService(db dbAdapter, p Provider, emails Emails, usage Usage, store storage.Store, plans map[string]Plan, o Options, logger log.Logger, hooks ...SuspendHook) (Service, error) {
+	if o.Currency == "" {
+		o.Currency = "eur"
+	}
+
+	s := &service{
+		db:       db,
+		provider: p,
+		emails:   emails,
+		usage:    usage,
+		store:    store,
+		options:  o,
+		hooks:    hooks,
+		logger:   logger,
+		plans:    plans,
+		mtx:      &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
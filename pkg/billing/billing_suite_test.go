@@ -0,0 +1,13 @@
+package billing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestBilling(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Billing Suite")
+}
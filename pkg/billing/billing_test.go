@@ -0,0 +1,520 @@
+package billing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/billing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/download"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockProvider struct {
+	customers     map[uint]string
+	subscriptions []string
+	canceled      []string
+	items         []billing.LineItem
+	credits       []billing.Invoice
+	err           error
+}
+
+func (p *mockProvider) CreateCustomer(refID uint, email string) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	id := fmt.Sprintf("cus_%d", refID)
+	p.customers[refID] = email
+	return id, nil
+}
+
+func (p *mockProvider) Subscribe(customerID string, plan billing.Plan) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	id := fmt.Sprintf("sub_%d", len(p.subscriptions)+1)
+	p.subscriptions = append(p.subscriptions, id)
+	return id, nil
+}
+
+func (p *mockProvider) Cancel(subscriptionID string) error {
+	p.canceled = append(p.canceled, subscriptionID)
+	return p.err
+}
+
+func (p *mockProvider) AddInvoiceItem(customerID string, i billing.LineItem) (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	p.items = append(p.items, i)
+	return fmt.Sprintf("ii_%d", i.ID), nil
+}
+
+func (p *mockProvider) Credit(customerID string, creditNote billing.Invoice) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.credits = append(p.credits, creditNote)
+	return nil
+}
+
+func (p *mockProvider) Event(payload []byte, signature string) (billing.Event, error) {
+	return billing.Event{}, errors.New("not implemented")
+}
+
+type mockEmails map[uint]string
+
+func (e mockEmails) Email(refID uint) (string, error) {
+	email, ok := e[refID]
+	if !ok {
+		return "", errors.New("user does not exist")
+	}
+	return email, nil
+}
+
+type mockUsage map[uint]network.Bytes
+
+func (u mockUsage) TotalUsage(refid uint, from time.Time, to time.Time) (network.Bytes, error) {
+	return u[refid], nil
+}
+
+var plans = map[string]billing.Plan{
+	"1": {
+		Price:           900,
+		StripePrice:     "price_small",
+		IncludedTraffic: 10,
+		TrafficPrice:    5,
+	},
+}
+
+var _ = Describe("Billing", func() {
+	var (
+		s         billing.Service
+		provider  *mockProvider
+		usage     mockUsage
+		store     storage.Store
+		artifacts storage.Store
+		dir       string
+		hooked    []string
+		signer    *download.Signer
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "kroo-billing")
+		Expect(err).NotTo(HaveOccurred())
+		artifacts = storage.NewLocalStore(dir)
+		store = storage.Prefix(artifacts, storage.Invoices)
+
+		provider = &mockProvider{customers: make(map[uint]string)}
+		usage = mockUsage{}
+		hooked = nil
+		signer = download.NewSigner([]byte("secret"), "https://kroo.example.com", time.Hour)
+		s, err = billing.NewService(testutils.NewMockDB(), provider, mockEmails{1: "a@kontainer.ooo", 2: "b@kontainer.ooo"}, usage, store, plans, billing.Options{
+			GracePeriod: 72 * time.Hour,
+			Issuer:      "kontainer.ooo\nExample Street 1",
+			Downloads:   download.For(signer, download.Invoices),
+		}, log.NewNopLogger(), func(refID uint, suspended bool) error {
+			hooked = append(hooked, fmt.Sprintf("%d %t", refID, suspended))
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Create Service", func() {
+		It("Should fail if the tables can not be created", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := billing.NewService(db, provider, mockEmails{}, nil, store, plans, billing.Options{}, log.NewNopLogger())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Subscribe", func() {
+		It("Should create a customer and subscribe it", func() {
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			Expect(provider.customers[1]).To(Equal("a@kontainer.ooo"))
+
+			a, err := s.Account(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.CustomerID).To(Equal("cus_1"))
+			Expect(a.SubscriptionID).To(Equal("sub_1"))
+			Expect(a.Plan).To(Equal("1"))
+			Expect(a.Status).To(Equal(billing.Active))
+		})
+
+		It("Should reject unknown plans and second subscriptions", func() {
+			Expect(s.Subscribe(1, "2")).To(Equal(billing.ErrPlanNotExist))
+
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			Expect(s.Subscribe(1, "1")).To(Equal(billing.ErrSubscribed))
+		})
+
+		It("Should subscribe canceled accounts again", func() {
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			Expect(s.Cancel(1)).To(Succeed())
+			Expect(provider.canceled).To(Equal([]string{"sub_1"}))
+
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			a, _ := s.Account(1)
+			Expect(a.SubscriptionID).To(Equal("sub_2"))
+			Expect(a.Status).To(Equal(billing.Active))
+		})
+
+		It("Should not create an account if the provider fails", func() {
+			provider.err = errors.New("stripe is down")
+			Expect(s.Subscribe(1, "1")).NotTo(Succeed())
+
+			_, err := s.Account(1)
+			Expect(err).To(Equal(billing.ErrAccountNotExist))
+		})
+	})
+
+	Describe("Bill", func() {
+		// accounts are billed from the month they were created in
+		now := time.Now()
+
+		It("Should record the plan and the exceeding traffic of the last month", func() {
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			Expect(s.Subscribe(2, "1")).To(Succeed())
+			usage[1] = network.Bytes{In: 12 << 30, Out: 1}
+
+			Expect(s.Bill(billing.Month(now).AddDate(0, 1, 0))).To(Succeed())
+
+			items := []billing.LineItem{}
+			Expect(s.LineItems(1, now, &items)).To(Succeed())
+			Expect(items).To(HaveLen(2))
+			Expect(items[0].Kind).To(Equal(billing.PlanItem))
+			Expect(items[0].Amount).To(Equal(uint64(900)))
+			Expect(items[0].Currency).To(Equal("eur"))
+			Expect(items[1].Kind).To(Equal(billing.TrafficItem))
+			Expect(items[1].Quantity).To(Equal(uint64(3)))
+			Expect(items[1].Amount).To(Equal(uint64(15)))
+
+			Expect(provider.items).To(HaveLen(1))
+			Expect(provider.items[0].Amount).To(Equal(uint64(15)))
+
+			items = []billing.LineItem{}
+			Expect(s.LineItems(2, time.Time{}, &items)).To(Succeed())
+			Expect(items).To(HaveLen(1))
+		})
+
+		It("Should skip months which were billed", func() {
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			usage[1] = network.Bytes{In: 20 << 30}
+
+			later := billing.Month(now).AddDate(0, 1, 0)
+			Expect(s.Bill(later)).To(Succeed())
+			Expect(s.Bill(later.Add(time.Hour))).To(Succeed())
+
+			items := []billing.LineItem{}
+			Expect(s.LineItems(1, time.Time{}, &items)).To(Succeed())
+			Expect(items).To(HaveLen(2))
+			Expect(provider.items).To(HaveLen(1))
+		})
+
+		It("Should not bill accounts created after the month", func() {
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			Expect(s.Bill(now)).To(Succeed())
+
+			items := []billing.LineItem{}
+			Expect(s.LineItems(1, time.Time{}, &items)).To(Succeed())
+			Expect(items).To(BeEmpty())
+		})
+	})
+
+	Describe("Invoices", func() {
+		now := time.Now()
+		later := billing.Month(now).AddDate(0, 1, 0)
+
+		BeforeEach(func() {
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			usage[1] = network.Bytes{In: 12 << 30}
+			Expect(s.Bill(later)).To(Succeed())
+		})
+
+		It("Should issue an invoice of the billed month once", func() {
+			Expect(s.Bill(later.Add(time.Hour))).To(Succeed())
+
+			invoices := []billing.Invoice{}
+			Expect(s.Invoices(1, &invoices)).To(Succeed())
+			Expect(invoices).To(HaveLen(1))
+			Expect(invoices[0].Kind).To(Equal(billing.InvoiceKind))
+			Expect(invoices[0].Number).To(Equal(fmt.Sprintf("INV-%06d", invoices[0].ID)))
+			Expect(invoices[0].Period).To(Equal(billing.Month(now)))
+			Expect(invoices[0].Total).To(Equal(uint64(910)))
+
+			invoices = []billing.Invoice{}
+			Expect(s.Invoices(2, &invoices)).To(Succeed())
+			Expect(invoices).To(BeEmpty())
+		})
+
+		It("Should keep the documents of the invoices", func() {
+			invoices := []billing.Invoice{}
+			Expect(s.Invoices(1, &invoices)).To(Succeed())
+
+			j, err := s.Document(1, invoices[0].ID, billing.JSON)
+			Expect(err).NotTo(HaveOccurred())
+			d := billing.Document{}
+			Expect(json.Unmarshal(j, &d)).To(Succeed())
+			Expect(d.Number).To(Equal(invoices[0].Number))
+			Expect(d.Email).To(Equal("a@kontainer.ooo"))
+			Expect(d.Items).To(HaveLen(2))
+			Expect(d.Total).To(Equal(uint64(910)))
+
+			pdf, err := s.Document(1, invoices[0].ID, billing.PDF)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(pdf)).To(HavePrefix("%PDF-1.4"))
+			Expect(string(pdf)).To(ContainSubstring("9.10 EUR"))
+			Expect(string(pdf)).To(HaveSuffix("%%EOF\n"))
+
+			_, err = s.Document(1, invoices[0].ID, "docx")
+			Expect(err).To(Equal(billing.ErrFormat))
+
+			_, err = s.Document(2, invoices[0].ID, billing.PDF)
+			Expect(err).To(Equal(billing.ErrInvoiceNotExist))
+		})
+
+		It("Should return signed URLs of the documents", func() {
+			invoices := []billing.Invoice{}
+			Expect(s.Invoices(1, &invoices)).To(Succeed())
+
+			u, expires, err := s.DocumentURL(1, invoices[0].ID, billing.PDF)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expires).To(BeTemporally(">", time.Now()))
+
+			w := httptest.NewRecorder()
+			download.Handler(artifacts, signer, log.NewNopLogger()).ServeHTTP(w, httptest.NewRequest("GET", strings.TrimPrefix(u, "https://kroo.example.com"), nil))
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Header().Get("Content-Disposition")).To(ContainSubstring(invoices[0].Number + ".pdf"))
+			Expect(w.Body.String()).To(HavePrefix("%PDF-1.4"))
+
+			_, _, err = s.DocumentURL(2, invoices[0].ID, billing.PDF)
+			Expect(err).To(Equal(billing.ErrInvoiceNotExist))
+
+			other, err := billing.NewService(testutils.NewMockDB(), provider, mockEmails{}, usage, store, plans, billing.Options{}, log.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+			_, _, err = other.DocumentURL(1, invoices[0].ID, billing.PDF)
+			Expect(err).To(Equal(billing.ErrNoDownloadURLs))
+		})
+
+		It("Should correct invoices with credit notes up to their total", func() {
+			invoices := []billing.Invoice{}
+			Expect(s.Invoices(1, &invoices)).To(Succeed())
+			id := invoices[0].ID
+
+			c, err := s.CreateCreditNote(1, id, 400, "downtime")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Kind).To(Equal(billing.CreditNoteKind))
+			Expect(c.Number).To(HavePrefix("CN-"))
+			Expect(c.CorrectsID).To(Equal(id))
+			Expect(provider.credits).To(HaveLen(1))
+			Expect(provider.credits[0].Total).To(Equal(uint64(400)))
+
+			_, err = s.CreateCreditNote(1, id, 600, "too much")
+			Expect(err).To(Equal(billing.ErrCreditExceeds))
+
+			c, err = s.CreateCreditNote(1, id, 0, "refund")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Total).To(Equal(uint64(510)))
+
+			_, err = s.CreateCreditNote(1, id, 0, "refund")
+			Expect(err).To(Equal(billing.ErrCreditExceeds))
+
+			_, err = s.CreateCreditNote(1, c.ID, 0, "credit note")
+			Expect(err).To(Equal(billing.ErrInvoiceNotExist))
+
+			invoices = []billing.Invoice{}
+			Expect(s.Invoices(1, &invoices)).To(Succeed())
+			Expect(invoices).To(HaveLen(3))
+			Expect(invoices[0].ID).To(Equal(c.ID))
+		})
+
+		It("Should not keep credit notes the provider failed to credit", func() {
+			invoices := []billing.Invoice{}
+			Expect(s.Invoices(1, &invoices)).To(Succeed())
+
+			provider.err = errors.New("stripe is down")
+			_, err := s.CreateCreditNote(1, invoices[0].ID, 0, "refund")
+			Expect(err).To(HaveOccurred())
+
+			invoices = []billing.Invoice{}
+			Expect(s.Invoices(1, &invoices)).To(Succeed())
+			Expect(invoices).To(HaveLen(1))
+		})
+	})
+
+	Describe("Payments", func() {
+		BeforeEach(func() {
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+		})
+
+		failed := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+		It("Should suspend accounts past the grace period and resume them once they paid", func() {
+			Expect(s.HandleEvent(billing.Event{Type: billing.PaymentFailed, CustomerID: "cus_1", Time: failed})).To(Succeed())
+			a, _ := s.Account(1)
+			Expect(a.Status).To(Equal(billing.PastDue))
+
+			Expect(s.Enforce(failed.Add(71 * time.Hour))).To(Succeed())
+			Expect(hooked).To(BeEmpty())
+
+			Expect(s.Enforce(failed.Add(73 * time.Hour))).To(Succeed())
+			Expect(hooked).To(Equal([]string{"1 true"}))
+			a, _ = s.Account(1)
+			Expect(a.Status).To(Equal(billing.Suspended))
+
+			Expect(s.HandleEvent(billing.Event{Type: billing.PaymentSucceeded, CustomerID: "cus_1"})).To(Succeed())
+			Expect(hooked).To(Equal([]string{"1 true", "1 false"}))
+			a, _ = s.Account(1)
+			Expect(a.Status).To(Equal(billing.Active))
+		})
+
+		It("Should not suspend accounts which paid within the grace period", func() {
+			Expect(s.HandleEvent(billing.Event{Type: billing.PaymentFailed, CustomerID: "cus_1", Time: failed})).To(Succeed())
+			Expect(s.HandleEvent(billing.Event{Type: billing.PaymentSucceeded, CustomerID: "cus_1"})).To(Succeed())
+
+			Expect(s.Enforce(failed.Add(100 * time.Hour))).To(Succeed())
+			Expect(hooked).To(BeEmpty())
+		})
+
+		It("Should cancel accounts whose subscription ended", func() {
+			Expect(s.HandleEvent(billing.Event{Type: billing.SubscriptionCanceled, CustomerID: "cus_1", SubscriptionID: "sub_1"})).To(Succeed())
+			a, _ := s.Account(1)
+			Expect(a.Status).To(Equal(billing.Canceled))
+		})
+
+		It("Should ignore unknown customers", func() {
+			Expect(s.HandleEvent(billing.Event{Type: billing.PaymentFailed, CustomerID: "cus_other"})).To(Succeed())
+		})
+	})
+
+	Describe("Stripe", func() {
+		var (
+			server   *httptest.Server
+			requests []*http.Request
+			forms    []url.Values
+			p        billing.Provider
+		)
+
+		BeforeEach(func() {
+			requests, forms = nil, nil
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				form, _ := url.ParseQuery(string(body))
+				requests = append(requests, r)
+				forms = append(forms, form)
+
+				if r.Header.Get("Authorization") != "Bearer sk_test" {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte(`{"error":{"message":"Invalid API Key provided"}}`))
+					return
+				}
+				w.Write([]byte(`{"id":"obj_1"}`))
+			}))
+			p = billing.NewStripeProvider(billing.StripeOptions{
+				Endpoint:      server.URL,
+				SecretKey:     "sk_test",
+				WebhookSecret: "whsec_test",
+			})
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Should create customers, subscriptions and invoice items", func() {
+			id, err := p.CreateCustomer(3, "c@kontainer.ooo")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(id).To(Equal("obj_1"))
+			Expect(requests[0].URL.Path).To(Equal("/v1/customers"))
+			Expect(requests[0].Header.Get("Idempotency-Key")).To(Equal("kroo-customer-3"))
+			Expect(forms[0].Get("email")).To(Equal("c@kontainer.ooo"))
+
+			_, err = p.Subscribe("cus_3", plans["1"])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(forms[1].Get("items[0][price]")).To(Equal("price_small"))
+
+			_, err = p.AddInvoiceItem("cus_3", billing.LineItem{ID: 7, Amount: 15, Currency: "eur", Description: "Traffic"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests[2].URL.Path).To(Equal("/v1/invoiceitems"))
+			Expect(requests[2].Header.Get("Idempotency-Key")).To(Equal("kroo-item-7"))
+			Expect(forms[2].Get("amount")).To(Equal("15"))
+
+			Expect(p.Cancel("sub_3")).To(Succeed())
+			Expect(requests[3].Method).To(Equal("DELETE"))
+			Expect(requests[3].URL.Path).To(Equal("/v1/subscriptions/sub_3"))
+
+			Expect(p.Credit("cus_3", billing.Invoice{ID: 9, Number: "CN-000009", Total: 400, Currency: "eur"})).To(Succeed())
+			Expect(requests[4].URL.Path).To(Equal("/v1/customers/cus_3/balance_transactions"))
+			Expect(requests[4].Header.Get("Idempotency-Key")).To(Equal("kroo-credit-9"))
+			Expect(forms[4].Get("amount")).To(Equal("-400"))
+		})
+
+		It("Should return the errors of the API", func() {
+			p = billing.NewStripeProvider(billing.StripeOptions{Endpoint: server.URL, SecretKey: "sk_wrong"})
+			_, err := p.CreateCustomer(3, "c@kontainer.ooo")
+			Expect(err).To(MatchError(ContainSubstring("Invalid API Key provided")))
+		})
+
+		It("Should verify the signature of webhooks", func() {
+			payload := []byte(`{"id":"evt_1","type":"invoice.payment_failed","created":1772366400,"data":{"object":{"id":"in_1","object":"invoice","customer":"cus_1","subscription":"sub_1"}}}`)
+
+			e, err := p.Event(payload, billing.SignStripe("whsec_test", payload, time.Now()))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(e.Type).To(Equal(billing.PaymentFailed))
+			Expect(e.CustomerID).To(Equal("cus_1"))
+			Expect(e.InvoiceID).To(Equal("in_1"))
+			Expect(e.Time).To(Equal(time.Unix(1772366400, 0).UTC()))
+
+			_, err = p.Event(payload, billing.SignStripe("whsec_other", payload, time.Now()))
+			Expect(err).To(Equal(billing.ErrSignature))
+
+			_, err = p.Event(payload, billing.SignStripe("whsec_test", payload, time.Now().Add(-time.Hour)))
+			Expect(err).To(Equal(billing.ErrSignature))
+
+			_, err = p.Event(payload, "")
+			Expect(err).To(Equal(billing.ErrSignature))
+		})
+	})
+
+	Describe("Handler", func() {
+		It("Should handle the events with a valid signature", func() {
+			Expect(s.Subscribe(1, "1")).To(Succeed())
+			p := billing.NewStripeProvider(billing.StripeOptions{WebhookSecret: "whsec_test"})
+			h := billing.Handler(s, p, log.NewNopLogger())
+
+			payload := `{"id":"evt_1","type":"invoice.payment_failed","created":1772366400,"data":{"object":{"id":"in_1","object":"invoice","customer":"cus_1"}}}`
+			req := httptest.NewRequest("POST", billing.WebhookPath, strings.NewReader(payload))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusBadRequest))
+
+			req = httptest.NewRequest("POST", billing.WebhookPath, strings.NewReader(payload))
+			req.Header.Set(billing.SignatureHeader, billing.SignStripe("whsec_test", []byte(payload), time.Now()))
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			Expect(rec.Code).To(Equal(http.StatusNoContent))
+
+			a, _ := s.Account(1)
+			Expect(a.Status).To(Equal(billing.PastDue))
+		})
+	})
+})
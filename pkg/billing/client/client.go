@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/billing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/billing/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *billing.Endpoints {
+
+	var AccountEndpoint endpoint.Endpoint
+	{
+		AccountEndpoint = grpctransport.NewClient(
+			conn,
+			"billing.BillingService",
+			"Account",
+			EncodeGRPCAccountRequest,
+			DecodeGRPCAccountResponse,
+			pb.AccountResponse{},
+		).Endpoint()
+	}
+
+	var InvoicesEndpoint endpoint.Endpoint
+	{
+		InvoicesEndpoint = grpctransport.NewClient(
+			conn,
+			"billing.BillingService",
+			"Invoices",
+			EncodeGRPCInvoicesRequest,
+			DecodeGRPCInvoicesResponse,
+			pb.InvoicesResponse{},
+		).Endpoint()
+	}
+
+	var DocumentEndpoint endpoint.Endpoint
+	{
+		DocumentEndpoint = grpctransport.NewClient(
+			conn,
+			"billing.BillingService",
+			"Document",
+			EncodeGRPCDocumentRequest,
+			DecodeGRPCDocumentResponse,
+			pb.DocumentResponse{},
+		).Endpoint()
+	}
+
+	var DocumentURLEndpoint endpoint.Endpoint
+	{
+		DocumentURLEndpoint = grpctransport.NewClient(
+			conn,
+			"billing.BillingService",
+			"DocumentURL",
+			EncodeGRPCDocumentRequest,
+			DecodeGRPCDocumentURLResponse,
+			pb.DocumentURLResponse{},
+		).Endpoint()
+	}
+
+	var CreateCreditNoteEndpoint endpoint.Endpoint
+	{
+		CreateCreditNoteEndpoint = grpctransport.NewClient(
+			conn,
+			"billing.BillingService",
+			"CreateCreditNote",
+			EncodeGRPCCreateCreditNoteRequest,
+			DecodeGRPCCreateCreditNoteResponse,
+			pb.CreateCreditNoteResponse{},
+		).Endpoint()
+	}
+
+	return &billing.Endpoints{
+		AccountEndpoint:          AccountEndpoint,
+		InvoicesEndpoint:         InvoicesEndpoint,
+		DocumentEndpoint:         DocumentEndpoint,
+		DocumentURLEndpoint:      DocumentURLEndpoint,
+		CreateCreditNoteEndpoint: CreateCreditNoteEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCAccountRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain account request to a gRPC Account request.
+func EncodeGRPCAccountRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*billing.AccountRequest)
+	return &pb.AccountRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCAccountResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Account response to a messages/billing.proto-domain account response.
+func DecodeGRPCAccountResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.AccountResponse)
+	return &billing.AccountResponse{
+		Account: billing.ConvertPBAccount(response.Account),
+		Error:   getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCInvoicesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain invoices request to a gRPC Invoices request.
+func EncodeGRPCInvoicesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*billing.InvoicesRequest)
+	return &pb.InvoicesRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCInvoicesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Invoices response to a messages/billing.proto-domain invoices response.
+func DecodeGRPCInvoicesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.InvoicesResponse)
+	invoices := make([]billing.Invoice, len(response.Invoices))
+	for i, invoice := range response.Invoices {
+		invoices[i] = billing.ConvertPBInvoice(invoice)
+	}
+
+	return &billing.InvoicesResponse{
+		Invoices: invoices,
+		Error:    getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDocumentRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain document request to a gRPC Document request.
+func EncodeGRPCDocumentRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*billing.DocumentRequest)
+	return &pb.DocumentRequest{
+		RefID:  uint32(req.RefID),
+		ID:     uint32(req.ID),
+		Format: req.Format,
+	}, nil
+}
+
+// DecodeGRPCDocumentResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Document response to a messages/billing.proto-domain document response.
+func DecodeGRPCDocumentResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DocumentResponse)
+	return &billing.DocumentResponse{
+		Content:     response.Content,
+		ContentType: response.ContentType,
+		Error:       getError(response.Error),
+	}, nil
+}
+
+// DecodeGRPCDocumentURLResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC DocumentURL response to a messages/billing.proto-domain documenturl response.
+func DecodeGRPCDocumentURLResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DocumentURLResponse)
+	res := &billing.DocumentURLResponse{
+		URL:   response.Url,
+		Error: getError(response.Error),
+	}
+	if response.ExpiresAt != 0 {
+		res.ExpiresAt = time.Unix(response.ExpiresAt, 0).UTC()
+	}
+	return res, nil
+}
+
+// EncodeGRPCCreateCreditNoteRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain createcreditnote request to a gRPC CreateCreditNote request.
+func EncodeGRPCCreateCreditNoteRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*billing.CreateCreditNoteRequest)
+	return &pb.CreateCreditNoteRequest{
+		RefID:     uint32(req.RefID),
+		InvoiceID: uint32(req.InvoiceID),
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+	}, nil
+}
+
+// DecodeGRPCCreateCreditNoteResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateCreditNote response to a messages/billing.proto-domain createcreditnote response.
+func DecodeGRPCCreateCreditNoteResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateCreditNoteResponse)
+	return &billing.CreateCreditNoteResponse{
+		CreditNote: billing.ConvertPBInvoice(response.CreditNote),
+		Error:      getError(response.Error),
+	}, nil
+}
@@ -0,0 +1,82 @@
+package billing
+
+import (
+	"time"
+)
+
+// Plan is the price of a plan, amounts are in the smallest unit of the currency, e.g. cents
+type Plan struct {
+	// Price is charged per month by the subscription of the plan
+	Price uint64 `yaml:"price"`
+	// StripePrice is the id of the recurring price of the plan in Stripe, e.g. price_1Hh1...
+	StripePrice string `yaml:"stripePrice"`
+	// IncludedTraffic is the traffic in GiB per month included in the price
+	IncludedTraffic uint64 `yaml:"includedTraffic"`
+	// TrafficPrice is charged for every GiB exceeding the included traffic
+	TrafficPrice uint64 `yaml:"trafficPrice"`
+}
+
+// Account is the billing account of a user with the customer and subscription of the provider
+type Account struct {
+	ID             uint `gorm:"primary_key"`
+	RefID          uint
+	Plan           string
+	CustomerID     string
+	SubscriptionID string
+	Status         string
+	// PastDueSince is when the payment failed which made the account past due
+	PastDueSince time.Time
+	CreatedAt    time.Time
+}
+
+// TableName sets Account's database table name
+func (Account) TableName() string {
+	return "billing_accounts"
+}
+
+// LineItem is a charge of a user for a month
+type LineItem struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Period is the first day of the month the item is charged for in UTC
+	Period time.Time
+	// Kind is plan or traffic
+	Kind        string
+	Description string
+	Quantity    uint64
+	UnitAmount  uint64
+	Amount      uint64
+	Currency    string
+	// ProviderID is the id of the invoice item at the provider, items paid by the subscription have none
+	ProviderID string
+	CreatedAt  time.Time
+}
+
+// TableName sets LineItem's database table name
+func (LineItem) TableName() string {
+	return "billing_line_items"
+}
+
+// Invoice is a monthly invoice of a user or a credit note correcting one, its documents are kept in the store
+type Invoice struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Number is unique per installation, e.g. INV-000042, or CN-000043 for credit notes
+	Number string
+	// Kind is invoice or credit_note
+	Kind string
+	// Period is the first day of the month an invoice is for in UTC
+	Period time.Time
+	// CorrectsID is the invoice corrected by a credit note
+	CorrectsID uint
+	Total      uint64
+	Currency   string
+	// Reason is why a credit note was issued
+	Reason    string
+	CreatedAt time.Time
+}
+
+// TableName sets Invoice's database table name
+func (Invoice) TableName() string {
+	return "billing_invoices"
+}
@@ -0,0 +1,155 @@
+package billing
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints is a struct which collects all endpoints for the billing service
+type Endpoints struct {
+	AccountEndpoint          endpoint.Endpoint
+	InvoicesEndpoint         endpoint.Endpoint
+	DocumentEndpoint         endpoint.Endpoint
+	DocumentURLEndpoint      endpoint.Endpoint
+	CreateCreditNoteEndpoint endpoint.Endpoint
+}
+
+// AccountRequest is the request struct for the AccountEndpoint
+type AccountRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// AccountResponse is the response struct for the AccountEndpoint
+type AccountResponse struct {
+	Account Account
+	Error   error
+}
+
+// MakeAccountEndpoint creates a gokit endpoint which invokes Account
+func MakeAccountEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AccountRequest)
+		a, err := s.Account(req.RefID)
+		return AccountResponse{
+			Account: a,
+			Error:   err,
+		}, nil
+	}
+}
+
+// InvoicesRequest is the request struct for the InvoicesEndpoint
+type InvoicesRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// InvoicesResponse is the response struct for the InvoicesEndpoint
+type InvoicesResponse struct {
+	Invoices []Invoice
+	Error    error
+}
+
+// MakeInvoicesEndpoint creates a gokit endpoint which invokes Invoices
+func MakeInvoicesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(InvoicesRequest)
+		invoices := []Invoice{}
+		err := s.Invoices(req.RefID, &invoices)
+		return InvoicesResponse{
+			Invoices: invoices,
+			Error:    err,
+		}, nil
+	}
+}
+
+// DocumentRequest is the request struct for the DocumentEndpoint
+type DocumentRequest struct {
+	RefID  uint `bart:"ref"`
+	ID     uint
+	Format string
+}
+
+// DocumentResponse is the response struct for the DocumentEndpoint
+type DocumentResponse struct {
+	Content     []byte
+	ContentType string
+	Error       error
+}
+
+// MakeDocumentEndpoint creates a gokit endpoint which invokes Document, the format defaults to pdf
+func MakeDocumentEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DocumentRequest)
+		if req.Format == "" {
+			req.Format = PDF
+		}
+
+		content, err := s.Document(req.RefID, req.ID, req.Format)
+		if err != nil {
+			return DocumentResponse{
+				Error: err,
+			}, nil
+		}
+
+		contentType := "application/pdf"
+		if req.Format == JSON {
+			contentType = "application/json"
+		}
+		return DocumentResponse{
+			Content:     content,
+			ContentType: contentType,
+		}, nil
+	}
+}
+
+// DocumentURLResponse is the response struct for the DocumentURLEndpoint
+type DocumentURLResponse struct {
+	URL       string
+	ExpiresAt time.Time
+	Error     error
+}
+
+// MakeDocumentURLEndpoint creates a gokit endpoint which invokes DocumentURL, it takes a DocumentRequest
+// and the format defaults to pdf
+func MakeDocumentURLEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DocumentRequest)
+		if req.Format == "" {
+			req.Format = PDF
+		}
+
+		u, expires, err := s.DocumentURL(req.RefID, req.ID, req.Format)
+		return DocumentURLResponse{
+			URL:       u,
+			ExpiresAt: expires,
+			Error:     err,
+		}, nil
+	}
+}
+
+// CreateCreditNoteRequest is the request struct for the CreateCreditNoteEndpoint
+type CreateCreditNoteRequest struct {
+	RefID     uint `bart:"ref"`
+	InvoiceID uint `validate:"required"`
+	Amount    uint64
+	Reason    string `validate:"required"`
+}
+
+// CreateCreditNoteResponse is the response struct for the CreateCreditNoteEndpoint
+type CreateCreditNoteResponse struct {
+	CreditNote Invoice
+	Error      error
+}
+
+// MakeCreateCreditNoteEndpoint creates a gokit endpoint which invokes CreateCreditNote
+func MakeCreateCreditNoteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateCreditNoteRequest)
+		c, err := s.CreateCreditNote(req.RefID, req.InvoiceID, req.Amount, req.Reason)
+		return CreateCreditNoteResponse{
+			CreditNote: c,
+			Error:      err,
+		}, nil
+	}
+}
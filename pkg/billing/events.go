@@ -0,0 +1,77 @@
+package billing
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// EventGroup is the group the billing services subscribe in, so every event is handled once
+const EventGroup = "billing"
+
+// PlanFunc returns the name of the plan of a user
+type PlanFunc func(refID uint) (string, error)
+
+// Subscribe subscribes new users to their plan and cancels the subscription of deleted users.
+// Users whose plan has no price, like admins, are not subscribed.
+func Subscribe(s Service, bus events.Bus, planOf PlanFunc, logger log.Logger) ([]events.Subscription, error) {
+	created, err := bus.Subscribe(events.UserCreated, EventGroup, func(e events.Event) error {
+		u := events.UserEvent{}
+		err := e.Decode(&u)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+
+		plan, err := planOf(u.ID)
+		if err != nil {
+			return err
+		}
+
+		err = s.Subscribe(u.ID, plan)
+		if err == ErrPlanNotExist || err == ErrSubscribed {
+			return nil
+		}
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", u.ID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := bus.Subscribe(events.UserDeleted, EventGroup, func(e events.Event) error {
+		u := events.UserEvent{}
+		err := e.Decode(&u)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+
+		err = s.Cancel(u.ID)
+		if err == ErrAccountNotExist {
+			return nil
+		}
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", u.ID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		created.Unsubscribe()
+		return nil, err
+	}
+
+	return []events.Subscription{created, deleted}, nil
+}
+
+// PublishHook returns a SuspendHook publishing AccountSuspended and AccountResumed
+func PublishHook(bus events.Bus) SuspendHook {
+	return func(refID uint, suspended bool) error {
+		if suspended {
+			return bus.Publish(events.AccountSuspended, events.AccountEvent{RefID: refID})
+		}
+		return bus.Publish(events.AccountResumed, events.AccountEvent{RefID: refID})
+	}
+}
@@ -0,0 +1,48 @@
+package billing
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// WebhookPath is the path the webhooks of the provider are served at by the gateway
+const WebhookPath = "/v1/billing/webhook"
+
+// maxPayload limits the body of a webhook, the events of Stripe are far smaller
+const maxPayload = 1 << 16
+
+// Handler returns the handler of the webhooks of p. Events with an invalid signature are rejected
+// with 400, events which could not be handled with 500, so the provider delivers them again.
+func Handler(s Service, p Provider, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPayload))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		e, err := p.Event(payload, r.Header.Get(SignatureHeader))
+		if err != nil {
+			level.Warn(logger).Log("remote", r.RemoteAddr, "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.HandleEvent(e)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "type", e.Type, "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
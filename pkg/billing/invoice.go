@@ -0,0 +1,367 @@
+package billing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Kinds of invoices
+const (
+	InvoiceKind    = "invoice"
+	CreditNoteKind = "credit_note"
+)
+
+// Formats of the documents of the invoices
+const (
+	PDF  = "pdf"
+	JSON = "json"
+)
+
+var (
+	// ErrInvoiceNotExist occurs if a user has no invoice with an id
+	ErrInvoiceNotExist = errors.New("invoice does not exist")
+
+	// ErrFormat occurs if a document is requested in a format other than pdf or json
+	ErrFormat = errors.New("format is not supported")
+
+	// ErrCreditExceeds occurs if the credit notes of an invoice would exceed its total
+	ErrCreditExceeds = errors.New("credit exceeds the invoice")
+
+	// ErrNoDownloadURLs occurs if a download URL is requested but the installation does not hand them out
+	ErrNoDownloadURLs = errors.New("download URLs are disabled")
+)
+
+// DocumentItem is a line of an invoice document
+type DocumentItem struct {
+	Description string `json:"description"`
+	Quantity    uint64 `json:"quantity"`
+	UnitAmount  uint64 `json:"unitAmount"`
+	Amount      uint64 `json:"amount"`
+}
+
+// Document is the machine readable content of an invoice or credit note, the amounts are in the
+// smallest unit of the currency
+type Document struct {
+	Number   string         `json:"number"`
+	Kind     string         `json:"kind"`
+	Issued   time.Time      `json:"issued"`
+	Period   string         `json:"period,omitempty"`
+	Corrects string         `json:"corrects,omitempty"`
+	Reason   string         `json:"reason,omitempty"`
+	Issuer   string         `json:"issuer,omitempty"`
+	User     uint           `json:"user"`
+	Email    string         `json:"email"`
+	Items    []DocumentItem `json:"items"`
+	Total    uint64         `json:"total"`
+	Currency string         `json:"currency"`
+}
+
+// invoices returns the invoices and credit notes of a user, or of every user if refID is 0, the latest first
+func (s *service) invoices(refID uint) ([]Invoice, error) {
+	invoices := []Invoice{}
+	var err error
+	if refID == 0 {
+		err = s.db.FindOrdered(&invoices, "id DESC", 0)
+	} else {
+		err = s.db.FindOrdered(&invoices, "id DESC", 0, "ref_id = ?", refID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+func (s *service) invoice(refID uint, id uint) (Invoice, error) {
+	i := Invoice{}
+	err := s.db.First(&i, "id = ? AND ref_id = ?", id, refID)
+	if s.db.IsNotFound(err) {
+		return Invoice{}, ErrInvoiceNotExist
+	}
+	if err != nil {
+		return Invoice{}, err
+	}
+	return i, nil
+}
+
+func (s *service) Invoices(refID uint, invoices *[]Invoice) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	is, err := s.invoices(refID)
+	if err != nil {
+		return err
+	}
+
+	*invoices = append(*invoices, is...)
+	return nil
+}
+
+// documentKey returns the key of a document of an invoice in the store
+func documentKey(i Invoice, format string) string {
+	return fmt.Sprintf("%d/%s.%s", i.RefID, i.Number, format)
+}
+
+func (s *service) Document(refID uint, id uint, format string) ([]byte, error) {
+	if format != PDF && format != JSON {
+		return nil, ErrFormat
+	}
+
+	s.mtx.Lock()
+	i, err := s.invoice(refID, id)
+	s.mtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := s.store.Get(documentKey(i, format))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+func (s *service) DocumentURL(refID uint, id uint, format string) (string, time.Time, error) {
+	if format != PDF && format != JSON {
+		return "", time.Time{}, ErrFormat
+	}
+	if s.options.Downloads == nil {
+		return "", time.Time{}, ErrNoDownloadURLs
+	}
+
+	s.mtx.Lock()
+	i, err := s.invoice(refID, id)
+	s.mtx.Unlock()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	u, expires := s.options.Downloads.URL(documentKey(i, format), i.Number+"."+format)
+	return u, expires, nil
+}
+
+// formatAmount formats an amount in the smallest unit of a currency with two decimals, e.g. 9.15 EUR
+func formatAmount(amount uint64, currency string) string {
+	return fmt.Sprintf("%d.%02d %s", amount/100, amount%100, strings.ToUpper(currency))
+}
+
+// lines returns the text of the pdf of a document
+func (d Document) lines() []string {
+	title := "Invoice"
+	if d.Kind == CreditNoteKind {
+		title = "Credit note"
+	}
+
+	lines := []string{}
+	if d.Issuer != "" {
+		lines = append(lines, strings.Split(d.Issuer, "\n")...)
+		lines = append(lines, "")
+	}
+	lines = append(lines,
+		fmt.Sprintf("%s %s", title, d.Number),
+		"",
+		fmt.Sprintf("Issued:   %s", d.Issued.Format("2006-01-02")),
+	)
+	if d.Period != "" {
+		lines = append(lines, fmt.Sprintf("Period:   %s", d.Period))
+	}
+	if d.Corrects != "" {
+		lines = append(lines, fmt.Sprintf("Corrects: %s", d.Corrects))
+	}
+	if d.Reason != "" {
+		lines = append(lines, fmt.Sprintf("Reason:   %s", d.Reason))
+	}
+	lines = append(lines,
+		fmt.Sprintf("Customer: #%d %s", d.User, d.Email),
+		"",
+		fmt.Sprintf("%-50s %8s %14s %14s", "Description", "Quantity", "Unit price", "Amount"),
+		strings.Repeat("-", 89),
+	)
+	for _, i := range d.Items {
+		lines = append(lines, fmt.Sprintf("%-50.50s %8d %14s %14s", i.Description, i.Quantity, formatAmount(i.UnitAmount, d.Currency), formatAmount(i.Amount, d.Currency)))
+	}
+	return append(lines,
+		strings.Repeat("-", 89),
+		fmt.Sprintf("%-74s %14s", "Total", formatAmount(d.Total, d.Currency)),
+	)
+}
+
+// storeDocuments renders the documents of an invoice and puts them into the store
+func (s *service) storeDocuments(i Invoice, d Document) error {
+	j, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	documents := map[string][]byte{
+		JSON: j,
+		PDF:  renderPDF(d.lines()),
+	}
+	for format, content := range documents {
+		err = s.store.Put(documentKey(i, format), bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createInvoice stores an invoice, numbers it by its id and stores its documents, the invoice is removed
+// again if its documents can not be stored
+func (s *service) createInvoice(i *Invoice, d Document) error {
+	prefix := "INV"
+	if i.Kind == CreditNoteKind {
+		prefix = "CN"
+	}
+
+	i.CreatedAt = time.Now().UTC()
+	err := s.db.Create(i)
+	if err != nil {
+		return err
+	}
+
+	i.Number = fmt.Sprintf("%s-%06d", prefix, i.ID)
+	err = abstraction.UpdateByID(s.db, &Invoice{}, i.ID, &Invoice{Number: i.Number})
+	if err == nil {
+		d.Number, d.Issued = i.Number, i.CreatedAt
+		err = s.storeDocuments(*i, d)
+	}
+	if err != nil {
+		s.deleteInvoice(*i)
+		return err
+	}
+	return nil
+}
+
+// deleteInvoice removes an invoice which could not be completed and its documents
+func (s *service) deleteInvoice(i Invoice) {
+	for _, format := range []string{PDF, JSON} {
+		s.store.Delete(documentKey(i, format))
+	}
+	s.db.Delete(&Invoice{ID: i.ID})
+}
+
+// issue creates the invoice of an account for the month starting at period from its line items
+func (s *service) issue(a Account, period time.Time) error {
+	items, err := s.lineItems(a.RefID, period)
+	if err != nil {
+		return err
+	}
+
+	email, err := s.emails.Email(a.RefID)
+	if err != nil {
+		return err
+	}
+
+	i := Invoice{
+		RefID:    a.RefID,
+		Kind:     InvoiceKind,
+		Period:   period,
+		Currency: s.options.Currency,
+	}
+	d := Document{
+		Kind:     InvoiceKind,
+		Period:   period.Format("January 2006"),
+		Issuer:   s.options.Issuer,
+		User:     a.RefID,
+		Email:    email,
+		Items:    []DocumentItem{},
+		Currency: s.options.Currency,
+	}
+	for _, item := range items {
+		d.Items = append(d.Items, DocumentItem{
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			UnitAmount:  item.UnitAmount,
+			Amount:      item.Amount,
+		})
+		i.Total += item.Amount
+	}
+	d.Total = i.Total
+
+	return s.createInvoice(&i, d)
+}
+
+func (s *service) CreateCreditNote(refID uint, invoiceID uint, amount uint64, reason string) (Invoice, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	corrected, err := s.invoice(refID, invoiceID)
+	if err != nil {
+		return Invoice{}, err
+	}
+	if corrected.Kind != InvoiceKind {
+		return Invoice{}, ErrInvoiceNotExist
+	}
+
+	// the total of the credit notes issued for the invoice so far
+	var credited int64
+	err = s.db.Sum(&Invoice{}, "total", &credited, "corrects_id = ? AND kind = ?", invoiceID, CreditNoteKind)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	remaining := corrected.Total - uint64(credited)
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount == 0 || amount > remaining {
+		return Invoice{}, ErrCreditExceeds
+	}
+
+	a, err := s.account(refID)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	email, err := s.emails.Email(refID)
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	description := fmt.Sprintf("Credit for invoice %s", corrected.Number)
+	c := Invoice{
+		RefID:      refID,
+		Kind:       CreditNoteKind,
+		Period:     corrected.Period,
+		CorrectsID: corrected.ID,
+		Total:      amount,
+		Currency:   corrected.Currency,
+		Reason:     reason,
+	}
+	err = s.createInvoice(&c, Document{
+		Kind:     CreditNoteKind,
+		Corrects: corrected.Number,
+		Reason:   reason,
+		Issuer:   s.options.Issuer,
+		User:     refID,
+		Email:    email,
+		Items: []DocumentItem{{
+			Description: description,
+			Quantity:    1,
+			UnitAmount:  amount,
+			Amount:      amount,
+		}},
+		Total:    amount,
+		Currency: corrected.Currency,
+	})
+	if err != nil {
+		return Invoice{}, err
+	}
+
+	// the credit note is only kept if the provider credited the customer
+	err = s.provider.Credit(a.CustomerID, c)
+	if err != nil {
+		s.deleteInvoice(c)
+		return Invoice{}, err
+	}
+	return c, nil
+}
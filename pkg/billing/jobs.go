@@ -0,0 +1,29 @@
+package billing
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+const (
+	// BillJob is the type of the job billing the month before it runs
+	BillJob = "billing.bill"
+	// EnforceJob is the type of the job suspending the accounts past their grace period
+	EnforceJob = "billing.enforce"
+)
+
+// BillHandler returns the handler of BillJob, a failed billing can be retried since billed items are skipped
+func BillHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		return s.Bill(time.Now())
+	}
+}
+
+// EnforceHandler returns the handler of EnforceJob
+func EnforceHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		return s.Enforce(time.Now())
+	}
+}
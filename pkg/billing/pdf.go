@@ -0,0 +1,90 @@
+package billing
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 pages in points with a margin of 50 points
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	pageMargin   = 50
+	fontSize     = 10
+	lineHeight   = 14
+	linesPerPage = (pageHeight - 2*pageMargin) / lineHeight
+)
+
+// pdfText escapes a line for a string of a content stream, characters which are not in the
+// WinAnsiEncoding of the standard fonts are replaced by ?
+func pdfText(line string) string {
+	b := strings.Builder{}
+	for _, r := range line {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case r == '€':
+			b.WriteString("\\200")
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// renderPDF renders lines of text in a monospaced standard font on as many A4 pages as needed,
+// which keeps the columns of the documents aligned without embedding a font
+func renderPDF(lines []string) []byte {
+	var pages [][]string
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// 1 is the catalog, 2 the page tree, 3 the font, followed by a page and its content per page
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+	}
+	kids := []string{}
+	for _, page := range pages {
+		content := bytes.Buffer{}
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", fontSize, lineHeight, pageMargin, pageHeight-pageMargin)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) '\n", pdfText(line))
+		}
+		content.WriteString("ET")
+
+		id := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", id))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, id+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	out := bytes.Buffer{}
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for n, o := range objects {
+		offsets[n] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", n+1, o)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
@@ -0,0 +1,279 @@
+package billing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of the events of the provider handled by the service
+const (
+	// PaymentFailed occurs if the payment of an invoice failed
+	PaymentFailed = "invoice.payment_failed"
+	// PaymentSucceeded occurs once an invoice was paid
+	PaymentSucceeded = "invoice.paid"
+	// SubscriptionCanceled occurs once a subscription ended, e.g. since every retry of a payment failed
+	SubscriptionCanceled = "customer.subscription.deleted"
+)
+
+// ErrSignature occurs if the signature of a webhook of the provider is invalid or too old
+var ErrSignature = errors.New("invalid signature")
+
+// Event is an event of the provider about a customer
+type Event struct {
+	ID             string
+	Type           string
+	Time           time.Time
+	CustomerID     string
+	SubscriptionID string
+	// InvoiceID is set for the invoice events
+	InvoiceID string
+}
+
+// Provider manages the customers, subscriptions and invoices at a payment provider
+type Provider interface {
+	// CreateCustomer creates a customer for a user and returns its id
+	CreateCustomer(refID uint, email string) (string, error)
+
+	// Subscribe subscribes a customer to a plan and returns the id of the subscription
+	Subscribe(customerID string, p Plan) (string, error)
+
+	// Cancel cancels a subscription immediately
+	Cancel(subscriptionID string) error
+
+	// AddInvoiceItem adds an item to the next invoice of a customer and returns its id
+	AddInvoiceItem(customerID string, i LineItem) (string, error)
+
+	// Credit credits an amount to the balance of a customer, which is deducted from its next invoices
+	Credit(customerID string, creditNote Invoice) error
+
+	// Event verifies the signature of a webhook of the provider and returns its event
+	Event(payload []byte, signature string) (Event, error)
+}
+
+// StripeEndpoint is the URL of the Stripe API
+const StripeEndpoint = "https://api.stripe.com"
+
+// SignatureHeader is the header of the signature of the webhooks of Stripe
+const SignatureHeader = "Stripe-Signature"
+
+// signatureTolerance is how old the timestamp of a webhook may be, older ones may be replayed
+const signatureTolerance = 5 * time.Minute
+
+// StripeOptions configure the Stripe account
+type StripeOptions struct {
+	// Endpoint defaults to StripeEndpoint
+	Endpoint  string
+	SecretKey string
+	// WebhookSecret is the signing secret of the webhook endpoint, e.g. whsec_...
+	WebhookSecret string
+}
+
+type stripeProvider struct {
+	options StripeOptions
+	client  *http.Client
+	now     func() time.Time
+}
+
+type stripeObject struct {
+	ID string `json:"id"`
+}
+
+type stripeError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// request sends a form encoded request to the API and decodes the response into out, idempotencyKey
+// makes retries of a request return the result of the first one
+func (p *stripeProvider) request(method, path string, form url.Values, idempotencyKey string, out interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(p.options.Endpoint, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.options.SecretKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		e := stripeError{}
+		json.NewDecoder(io.LimitReader(res.Body, 4096)).Decode(&e)
+		return fmt.Errorf("stripe: %s: %s", res.Status, e.Error.Message)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (p *stripeProvider) CreateCustomer(refID uint, email string) (string, error) {
+	c := stripeObject{}
+	err := p.request("POST", "/v1/customers", url.Values{
+		"email":          {email},
+		"metadata[user]": {strconv.FormatUint(uint64(refID), 10)},
+	}, fmt.Sprintf("kroo-customer-%d", refID), &c)
+	return c.ID, err
+}
+
+func (p *stripeProvider) Subscribe(customerID string, plan Plan) (string, error) {
+	s := stripeObject{}
+	err := p.request("POST", "/v1/subscriptions", url.Values{
+		"customer":        {customerID},
+		"items[0][price]": {plan.StripePrice},
+	}, "", &s)
+	return s.ID, err
+}
+
+func (p *stripeProvider) Cancel(subscriptionID string) error {
+	return p.request("DELETE", "/v1/subscriptions/"+url.PathEscape(subscriptionID), nil, "", &stripeObject{})
+}
+
+func (p *stripeProvider) AddInvoiceItem(customerID string, i LineItem) (string, error) {
+	item := stripeObject{}
+	err := p.request("POST", "/v1/invoiceitems", url.Values{
+		"customer":           {customerID},
+		"amount":             {strconv.FormatUint(i.Amount, 10)},
+		"currency":           {i.Currency},
+		"description":        {i.Description},
+		"metadata[lineItem]": {strconv.FormatUint(uint64(i.ID), 10)},
+	}, fmt.Sprintf("kroo-item-%d", i.ID), &item)
+	return item.ID, err
+}
+
+func (p *stripeProvider) Credit(customerID string, creditNote Invoice) error {
+	return p.request("POST", "/v1/customers/"+url.PathEscape(customerID)+"/balance_transactions", url.Values{
+		"amount":               {"-" + strconv.FormatUint(creditNote.Total, 10)},
+		"currency":             {creditNote.Currency},
+		"description":          {creditNote.Number},
+		"metadata[creditNote]": {strconv.FormatUint(uint64(creditNote.ID), 10)},
+	}, fmt.Sprintf("kroo-credit-%d", creditNote.ID), &stripeObject{})
+}
+
+type stripeEvent struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Created int64  `json:"created"`
+	Data    struct {
+		Object struct {
+			ID           string `json:"id"`
+			Object       string `json:"object"`
+			Customer     string `json:"customer"`
+			Subscription string `json:"subscription"`
+		} `json:"object"`
+	} `json:"data"`
+}
+
+// verify checks a signature header of the form t=<timestamp>,v1=<hex HMAC-SHA256 of timestamp.payload>,
+// which may contain several v1 signatures while the secret is rolled
+func (p *stripeProvider) verify(payload []byte, header string) error {
+	var (
+		timestamp  string
+		signatures []string
+	)
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	t, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	if age := p.now().Sub(time.Unix(t, 0)); age > signatureTolerance || age < -signatureTolerance {
+		return ErrSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.options.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := mac.Sum(nil)
+
+	for _, s := range signatures {
+		sig, err := hex.DecodeString(s)
+		if err == nil && hmac.Equal(sig, expected) {
+			return nil
+		}
+	}
+	return ErrSignature
+}
+
+func (p *stripeProvider) Event(payload []byte, signature string) (Event, error) {
+	err := p.verify(payload, signature)
+	if err != nil {
+		return Event{}, err
+	}
+
+	e := stripeEvent{}
+	err = json.Unmarshal(payload, &e)
+	if err != nil {
+		return Event{}, err
+	}
+
+	event := Event{
+		ID:             e.ID,
+		Type:           e.Type,
+		Time:           time.Unix(e.Created, 0).UTC(),
+		CustomerID:     e.Data.Object.Customer,
+		SubscriptionID: e.Data.Object.Subscription,
+	}
+	switch e.Data.Object.Object {
+	case "invoice":
+		event.InvoiceID = e.Data.Object.ID
+	case "subscription":
+		event.SubscriptionID = e.Data.Object.ID
+	}
+	return event, nil
+}
+
+// SignStripe returns a signature header of payload as sent by Stripe
+func SignStripe(secret string, payload []byte, t time.Time) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// NewStripeProvider returns a Provider using the Stripe API
+func NewStripeProvider(o StripeOptions) Provider {
+	if o.Endpoint == "" {
+		o.Endpoint = StripeEndpoint
+	}
+
+	return &stripeProvider{
+		options: o,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		now: time.Now,
+	}
+}
@@ -0,0 +1,281 @@
+package billing
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/billing/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC BillingServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.BillingServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		account: grpctransport.NewServer(
+			endpoints.AccountEndpoint,
+			DecodeGRPCAccountRequest,
+			EncodeGRPCAccountResponse,
+			options...,
+		),
+
+		invoices: grpctransport.NewServer(
+			endpoints.InvoicesEndpoint,
+			DecodeGRPCInvoicesRequest,
+			EncodeGRPCInvoicesResponse,
+			options...,
+		),
+
+		document: grpctransport.NewServer(
+			endpoints.DocumentEndpoint,
+			DecodeGRPCDocumentRequest,
+			EncodeGRPCDocumentResponse,
+			options...,
+		),
+
+		documentURL: grpctransport.NewServer(
+			endpoints.DocumentURLEndpoint,
+			DecodeGRPCDocumentRequest,
+			EncodeGRPCDocumentURLResponse,
+			options...,
+		),
+
+		createCreditNote: grpctransport.NewServer(
+			endpoints.CreateCreditNoteEndpoint,
+			DecodeGRPCCreateCreditNoteRequest,
+			EncodeGRPCCreateCreditNoteResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	account          grpctransport.Handler
+	invoices         grpctransport.Handler
+	document         grpctransport.Handler
+	documentURL      grpctransport.Handler
+	createCreditNote grpctransport.Handler
+}
+
+func (s *grpcServer) Account(ctx oldcontext.Context, req *pb.AccountRequest) (*pb.AccountResponse, error) {
+	_, res, err := s.account.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.AccountResponse), nil
+}
+
+func (s *grpcServer) Invoices(ctx oldcontext.Context, req *pb.InvoicesRequest) (*pb.InvoicesResponse, error) {
+	_, res, err := s.invoices.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.InvoicesResponse), nil
+}
+
+func (s *grpcServer) Document(ctx oldcontext.Context, req *pb.DocumentRequest) (*pb.DocumentResponse, error) {
+	_, res, err := s.document.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DocumentResponse), nil
+}
+
+func (s *grpcServer) DocumentURL(ctx oldcontext.Context, req *pb.DocumentRequest) (*pb.DocumentURLResponse, error) {
+	_, res, err := s.documentURL.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DocumentURLResponse), nil
+}
+
+func (s *grpcServer) CreateCreditNote(ctx oldcontext.Context, req *pb.CreateCreditNoteRequest) (*pb.CreateCreditNoteResponse, error) {
+	_, res, err := s.createCreditNote.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateCreditNoteResponse), nil
+}
+
+// ConvertAccount converts an Account to its protobuf representation
+func ConvertAccount(a Account) *pb.Account {
+	account := &pb.Account{
+		Plan:      a.Plan,
+		Status:    a.Status,
+		CreatedAt: a.CreatedAt.Unix(),
+	}
+	if !a.PastDueSince.IsZero() {
+		account.PastDueSince = a.PastDueSince.Unix()
+	}
+	return account
+}
+
+// ConvertPBAccount converts a protobuf Account to an Account
+func ConvertPBAccount(a *pb.Account) Account {
+	if a == nil {
+		return Account{}
+	}
+
+	account := Account{
+		Plan:      a.Plan,
+		Status:    a.Status,
+		CreatedAt: time.Unix(a.CreatedAt, 0).UTC(),
+	}
+	if a.PastDueSince != 0 {
+		account.PastDueSince = time.Unix(a.PastDueSince, 0).UTC()
+	}
+	return account
+}
+
+// ConvertInvoice converts an Invoice to its protobuf representation
+func ConvertInvoice(i Invoice) *pb.Invoice {
+	return &pb.Invoice{
+		ID:         uint32(i.ID),
+		Number:     i.Number,
+		Kind:       i.Kind,
+		Period:     i.Period.Unix(),
+		CorrectsID: uint32(i.CorrectsID),
+		Total:      i.Total,
+		Currency:   i.Currency,
+		Reason:     i.Reason,
+		CreatedAt:  i.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBInvoice converts a protobuf Invoice to an Invoice
+func ConvertPBInvoice(i *pb.Invoice) Invoice {
+	if i == nil {
+		return Invoice{}
+	}
+
+	return Invoice{
+		ID:         uint(i.ID),
+		Number:     i.Number,
+		Kind:       i.Kind,
+		Period:     time.Unix(i.Period, 0).UTC(),
+		CorrectsID: uint(i.CorrectsID),
+		Total:      i.Total,
+		Currency:   i.Currency,
+		Reason:     i.Reason,
+		CreatedAt:  time.Unix(i.CreatedAt, 0).UTC(),
+	}
+}
+
+// DecodeGRPCAccountRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Account request to a messages/billing.proto-domain account request.
+func DecodeGRPCAccountRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AccountRequest)
+	return AccountRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCAccountResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain account response to a gRPC Account response.
+func EncodeGRPCAccountResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(AccountResponse)
+	gRPCRes := &pb.AccountResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+		return gRPCRes, nil
+	}
+	gRPCRes.Account = ConvertAccount(res.Account)
+	return gRPCRes, nil
+}
+
+// DecodeGRPCInvoicesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Invoices request to a messages/billing.proto-domain invoices request.
+func DecodeGRPCInvoicesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.InvoicesRequest)
+	return InvoicesRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCInvoicesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain invoices response to a gRPC Invoices response.
+func EncodeGRPCInvoicesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(InvoicesResponse)
+	invoices := make([]*pb.Invoice, len(res.Invoices))
+	for i, invoice := range res.Invoices {
+		invoices[i] = ConvertInvoice(invoice)
+	}
+
+	gRPCRes := &pb.InvoicesResponse{
+		Invoices: invoices,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDocumentRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Document request to a messages/billing.proto-domain document request.
+func DecodeGRPCDocumentRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DocumentRequest)
+	return DocumentRequest{
+		RefID:  uint(req.RefID),
+		ID:     uint(req.ID),
+		Format: req.Format,
+	}, nil
+}
+
+// EncodeGRPCDocumentResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain document response to a gRPC Document response.
+func EncodeGRPCDocumentResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DocumentResponse)
+	gRPCRes := &pb.DocumentResponse{
+		Content:     res.Content,
+		ContentType: res.ContentType,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// EncodeGRPCDocumentURLResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain documenturl response to a gRPC DocumentURL response.
+func EncodeGRPCDocumentURLResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DocumentURLResponse)
+	if res.Error != nil {
+		return &pb.DocumentURLResponse{
+			Error: res.Error.Error(),
+		}, nil
+	}
+	return &pb.DocumentURLResponse{
+		Url:       res.URL,
+		ExpiresAt: res.ExpiresAt.Unix(),
+	}, nil
+}
+
+// DecodeGRPCCreateCreditNoteRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateCreditNote request to a messages/billing.proto-domain createcreditnote request.
+func DecodeGRPCCreateCreditNoteRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateCreditNoteRequest)
+	return CreateCreditNoteRequest{
+		RefID:     uint(req.RefID),
+		InvoiceID: uint(req.InvoiceID),
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+	}, nil
+}
+
+// EncodeGRPCCreateCreditNoteResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/billing.proto-domain createcreditnote response to a gRPC CreateCreditNote response.
+func EncodeGRPCCreateCreditNoteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateCreditNoteResponse)
+	gRPCRes := &pb.CreateCreditNoteResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+		return gRPCRes, nil
+	}
+	gRPCRes.CreditNote = ConvertInvoice(res.CreditNote)
+	return gRPCRes, nil
+}
@@ -0,0 +1,100 @@
+// Package breaker stops calling backends which became unavailable, like a database refusing connections
+// or a container runtime which hangs. Once a backend failed a number of calls in a row its breaker opens
+// and the calls fail fast with an Error until a trial call after the timeout succeeded again, so requests
+// do not pile up waiting for it.
+package breaker
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/sony/gobreaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Error is returned for the calls to a backend whose breaker is open
+type Error struct {
+	Backend string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("backend unavailable: %s is not responding, retry later", e.Backend)
+}
+
+// Settings configure when a breaker opens. It opens after Failures failed calls in a row and lets
+// a trial call through after Timeout.
+type Settings struct {
+	Failures uint32
+	Timeout  time.Duration
+}
+
+// Breaker guards the calls to a single backend, a nil Breaker lets every call through
+type Breaker struct {
+	backend string
+	cb      *gobreaker.CircuitBreaker
+}
+
+// unavailable reports whether err shows that a backend is not reachable or not responding, other
+// errors like missing records or invalid arguments do not count as failures of the backend
+func unavailable(err error) bool {
+	if err == context.DeadlineExceeded || err == driver.ErrBadConn {
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	switch grpc.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// Execute calls f unless the breaker is open, a failure of f counts against the backend if it
+// shows that the backend is unavailable
+func (b *Breaker) Execute(f func() error) error {
+	if b == nil {
+		return f()
+	}
+
+	var err error
+	_, cbErr := b.cb.Execute(func() (interface{}, error) {
+		err = f()
+		return nil, err
+	})
+	if cbErr == gobreaker.ErrOpenState || cbErr == gobreaker.ErrTooManyRequests {
+		return &Error{b.backend}
+	}
+	return err
+}
+
+// New returns a Breaker for the backend, its state changes are logged
+func New(backend string, s Settings, logger log.Logger) *Breaker {
+	return &Breaker{
+		backend: backend,
+		cb: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:        backend,
+			MaxRequests: 1,
+			Timeout:     s.Timeout,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= s.Failures
+			},
+			OnStateChange: func(name string, from, to gobreaker.State) {
+				l := level.Info(logger)
+				if to == gobreaker.StateOpen {
+					l = level.Warn(logger)
+				}
+				l.Log("backend", name, "from", from.String(), "to", to.String())
+			},
+			IsSuccessful: func(err error) bool {
+				return err == nil || !unavailable(err)
+			},
+		}),
+	}
+}
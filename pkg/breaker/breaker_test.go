@@ -0,0 +1,153 @@
+package breaker_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/breaker"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type response struct {
+	Error error
+}
+
+var _ = Describe("Breaker", func() {
+	var b *breaker.Breaker
+
+	BeforeEach(func() {
+		b = breaker.New("docker", breaker.Settings{Failures: 2, Timeout: 50 * time.Millisecond}, log.NewNopLogger())
+	})
+
+	fail := func(err error) func() error {
+		return func() error {
+			return err
+		}
+	}
+
+	Describe("Execute", func() {
+		It("Should open after the configured number of failures in a row", func() {
+			Expect(b.Execute(fail(driver.ErrBadConn))).To(Equal(driver.ErrBadConn))
+			Expect(b.Execute(fail(driver.ErrBadConn))).To(Equal(driver.ErrBadConn))
+
+			called := false
+			err := b.Execute(func() error {
+				called = true
+				return nil
+			})
+			Expect(called).To(BeFalse())
+			Expect(err).To(Equal(&breaker.Error{Backend: "docker"}))
+		})
+
+		It("Should not count errors which do not show that the backend is unavailable", func() {
+			for i := 0; i < 5; i++ {
+				Expect(b.Execute(fail(errors.New("not found")))).To(HaveOccurred())
+			}
+			Expect(b.Execute(fail(nil))).To(Succeed())
+		})
+
+		It("Should close again once a trial call succeeded", func() {
+			b.Execute(fail(context.DeadlineExceeded))
+			b.Execute(fail(context.DeadlineExceeded))
+			Expect(b.Execute(fail(nil))).To(BeAssignableToTypeOf(&breaker.Error{}))
+
+			time.Sleep(60 * time.Millisecond)
+			Expect(b.Execute(fail(nil))).To(Succeed())
+			Expect(b.Execute(fail(nil))).To(Succeed())
+		})
+
+		It("Should let every call through if the breaker is nil", func() {
+			var nb *breaker.Breaker
+			for i := 0; i < 5; i++ {
+				Expect(nb.Execute(fail(driver.ErrBadConn))).To(Equal(driver.ErrBadConn))
+			}
+		})
+	})
+
+	Describe("Middleware", func() {
+		It("Should count the errors of the responses and reject calls once open", func() {
+			e := breaker.Middleware(b)(func(ctx context.Context, request interface{}) (interface{}, error) {
+				return response{grpc.Errorf(codes.Unavailable, "connection refused")}, nil
+			})
+
+			for i := 0; i < 2; i++ {
+				res, err := e(context.Background(), nil)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(res.(response).Error).To(HaveOccurred())
+			}
+
+			res, err := e(context.Background(), nil)
+			Expect(res).To(BeNil())
+			Expect(err).To(Equal(&breaker.Error{Backend: "docker"}))
+		})
+
+		It("Should count calls which outlived their deadline", func() {
+			e := breaker.Middleware(b)(func(ctx context.Context, request interface{}) (interface{}, error) {
+				<-ctx.Done()
+				return response{}, nil
+			})
+
+			for i := 0; i < 2; i++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+				_, err := e(ctx, nil)
+				cancel()
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			_, err := e(context.Background(), nil)
+			Expect(err).To(BeAssignableToTypeOf(&breaker.Error{}))
+		})
+
+		It("Should not count calls cancelled by their client", func() {
+			e := breaker.Middleware(b)(func(ctx context.Context, request interface{}) (interface{}, error) {
+				return response{}, nil
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			for i := 0; i < 3; i++ {
+				_, err := e(ctx, nil)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+	})
+
+	Describe("UnaryServerInterceptor", func() {
+		It("Should return rejected calls with codes.Unavailable", func() {
+			info := &grpc.UnaryServerInfo{FullMethod: "/container.ContainerService/Execute"}
+			_, err := breaker.UnaryServerInterceptor()(context.Background(), nil, info, func(ctx oldcontext.Context, req interface{}) (interface{}, error) {
+				return nil, &breaker.Error{Backend: "docker"}
+			})
+			Expect(grpc.Code(err)).To(Equal(codes.Unavailable))
+		})
+	})
+
+	Describe("DB", func() {
+		It("Should fail fast while the breaker is open", func() {
+			b.Execute(fail(driver.ErrBadConn))
+			b.Execute(fail(driver.ErrBadConn))
+
+			db := breaker.NewDB(testutils.NewMockDB(), b)
+			err := db.Create(&user.User{ID: 1, Username: "kroo"})
+			Expect(err).To(BeAssignableToTypeOf(&breaker.Error{}))
+
+			err = db.WithContext(context.Background()).Find(&[]user.User{})
+			Expect(err).To(BeAssignableToTypeOf(&breaker.Error{}))
+		})
+
+		It("Should return the database if the breaker is nil", func() {
+			mock := testutils.NewMockDB()
+			Expect(breaker.NewDB(mock, nil)).To(BeIdenticalTo(mock))
+		})
+	})
+})
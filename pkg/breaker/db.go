@@ -0,0 +1,103 @@
+package breaker
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// db guards the operations of a database with a Breaker
+type db struct {
+	abstraction.DB
+	breaker *Breaker
+}
+
+func (d *db) AppendToArray(query interface{}, target string, values interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.AppendToArray(query, target, values)
+	})
+}
+
+func (d *db) RemoveFromArray(query interface{}, target string, index int) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.RemoveFromArray(query, target, index)
+	})
+}
+
+func (d *db) AutoMigrate(values ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.AutoMigrate(values...)
+	})
+}
+
+func (d *db) Where(query interface{}, args ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.Where(query, args...)
+	})
+}
+
+func (d *db) First(out interface{}, where ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.First(out, where...)
+	})
+}
+
+func (d *db) Find(out interface{}, where ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.Find(out, where...)
+	})
+}
+
+func (d *db) Create(value interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.Create(value)
+	})
+}
+
+func (d *db) Delete(value interface{}, where ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.Delete(value, where...)
+	})
+}
+
+func (d *db) Update(value interface{}, attrs ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.Update(value, attrs...)
+	})
+}
+
+func (d *db) FindOrdered(out interface{}, order string, limit int, where ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.FindOrdered(out, order, limit, where...)
+	})
+}
+
+func (d *db) Sum(model interface{}, column string, out *int64, where ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.Sum(model, column, out, where...)
+	})
+}
+
+func (d *db) Pluck(model interface{}, column string, out interface{}, where ...interface{}) error {
+	return d.breaker.Execute(func() error {
+		return d.DB.Pluck(model, column, out, where...)
+	})
+}
+
+func (d *db) WithContext(ctx context.Context) abstraction.DB {
+	return abstraction.NewContextDB(&db{
+		DB:      d.DB.WithContext(ctx),
+		breaker: d.breaker,
+	}, ctx)
+}
+
+// NewDB returns a DB whose operations fail fast with an Error while b is open, next is returned if b is nil
+func NewDB(next abstraction.DB, b *Breaker) abstraction.DB {
+	if b == nil {
+		return next
+	}
+	return &db{
+		DB:      next,
+		breaker: b,
+	}
+}
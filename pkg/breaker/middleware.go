@@ -0,0 +1,74 @@
+package breaker
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/go-kit/kit/endpoint"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// responseError returns the error of an endpoint call, the services return most errors in the Error field of their responses
+func responseError(response interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(response))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	f := v.FieldByName("Error")
+	if !f.IsValid() || f.Type() != errorType || f.IsNil() {
+		return nil
+	}
+	return f.Interface().(error)
+}
+
+// Middleware returns a middleware guarding an endpoint with b, calls which fail because the backend is
+// unavailable or outlive the deadline of their context count against it. Calls rejected by an open breaker
+// return an Error.
+func Middleware(b *Breaker) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		if b == nil {
+			return next
+		}
+
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			var (
+				called   bool
+				response interface{}
+				err      error
+			)
+			rejected := b.Execute(func() error {
+				called = true
+				response, err = next(ctx, request)
+				if e := responseError(response, err); e != nil {
+					return e
+				}
+				return ctx.Err()
+			})
+			if !called {
+				return nil, rejected
+			}
+			return response, err
+		}
+	}
+}
+
+// UnaryServerInterceptor returns calls rejected by an open breaker with codes.Unavailable, so clients
+// and the gateway know to retry them later
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		res, err := handler(ctx, req)
+		if e, ok := err.(*Error); ok {
+			return nil, grpc.Errorf(codes.Unavailable, "%v", e)
+		}
+		return res, err
+	}
+}
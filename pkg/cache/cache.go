@@ -0,0 +1,121 @@
+// Package cache keeps the results of frequent reads, e.g. of the KMI catalog, the users or the router
+// configurations, so they do not hit the database every time. Services invalidate the values they
+// cache when they change them, the TTL bounds how long a value can be outdated otherwise.
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSize is the default number of values kept by an in-memory cache
+	DefaultSize = 10000
+
+	// DefaultTTL is the default time a value is kept
+	DefaultTTL = 5 * time.Minute
+)
+
+// Cache keeps values by key, values are encoded as JSON so every backend returns copies of them
+type Cache interface {
+	// Get decodes the value of key into v, it reports whether the key was found
+	Get(key string, v interface{}) (bool, error)
+
+	// Set stores v for key for ttl
+	Set(key string, v interface{}, ttl time.Duration) error
+
+	// Delete removes the values of keys
+	Delete(keys ...string) error
+}
+
+type entry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+type lru struct {
+	mtx     sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func (c *lru) Get(key string, v interface{}) (bool, error) {
+	c.mtx.Lock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.mtx.Unlock()
+		return false, nil
+	}
+
+	e := el.Value.(*entry)
+	if time.Now().After(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		c.mtx.Unlock()
+		return false, nil
+	}
+	c.order.MoveToFront(el)
+	value := e.value
+	c.mtx.Unlock()
+
+	return true, json.Unmarshal(value, v)
+}
+
+func (c *lru) Set(key string, v interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	e := &entry{
+		key:     key,
+		value:   value,
+		expires: time.Now().Add(ttl),
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(e)
+	if c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*entry).key)
+	}
+	return nil
+}
+
+func (c *lru) Delete(keys ...string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.entries[key]; ok {
+			c.order.Remove(el)
+			delete(c.entries, key)
+		}
+	}
+	return nil
+}
+
+// NewLRU returns a Cache keeping at most size values in memory, the least recently used value is
+// removed if it is full. The values are not shared between nodes.
+func NewLRU(size int) Cache {
+	if size <= 0 {
+		size = DefaultSize
+	}
+	return &lru{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
@@ -0,0 +1,134 @@
+package cache_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/cache"
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type value struct {
+	Name  string
+	Ports []int
+}
+
+var _ = Describe("Cache", func() {
+	Describe("LRU", func() {
+		It("Should return copies of the values", func() {
+			c := cache.NewLRU(10)
+			v := value{Name: "web", Ports: []int{80}}
+			Ω(c.Set("a", v, time.Minute)).Should(Succeed())
+			v.Ports[0] = 8080
+
+			out := value{}
+			found, err := c.Get("a", &out)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(out).To(Equal(value{Name: "web", Ports: []int{80}}))
+
+			found, err = c.Get("b", &out)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("Should remove the least recently used value if it is full", func() {
+			c := cache.NewLRU(2)
+			c.Set("a", 1, time.Minute)
+			c.Set("b", 2, time.Minute)
+
+			var i int
+			found, _ := c.Get("a", &i)
+			Expect(found).To(BeTrue())
+
+			c.Set("c", 3, time.Minute)
+			found, _ = c.Get("b", &i)
+			Expect(found).To(BeFalse())
+			found, _ = c.Get("a", &i)
+			Expect(found).To(BeTrue())
+			found, _ = c.Get("c", &i)
+			Expect(found).To(BeTrue())
+		})
+
+		It("Should expire values", func() {
+			c := cache.NewLRU(2)
+			c.Set("a", 1, -time.Second)
+
+			var i int
+			found, _ := c.Get("a", &i)
+			Expect(found).To(BeFalse())
+		})
+
+		It("Should delete values", func() {
+			c := cache.NewLRU(2)
+			c.Set("a", 1, time.Minute)
+			c.Set("b", 2, time.Minute)
+			Ω(c.Delete("a", "b", "c")).Should(Succeed())
+
+			var i int
+			found, _ := c.Get("a", &i)
+			Expect(found).To(BeFalse())
+			found, _ = c.Get("b", &i)
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("Redis", func() {
+		It("Should reject invalid urls", func() {
+			_, err := cache.NewRedis("http://localhost")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should keep values in Redis", func() {
+			url, commands := testutils.FakeRedis("+OK\r\n", "+OK\r\n", "+OK\r\n", "$27\r\n{\"Name\":\"web\",\"Ports\":[80]}\r\n", "$-1\r\n", ":1\r\n")
+			c, err := cache.NewRedis(url)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(c.Set("a", value{Name: "web", Ports: []int{80}}, time.Minute)).Should(Succeed())
+
+			out := value{}
+			found, err := c.Get("a", &out)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(out).To(Equal(value{Name: "web", Ports: []int{80}}))
+
+			found, err = c.Get("b", &out)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			Ω(c.Delete("a", "b")).Should(Succeed())
+
+			<-commands
+			<-commands
+			Expect(<-commands).To(Equal([]string{"SET", cache.RedisKeyPrefix + "a", `{"Name":"web","Ports":[80]}`, "PX", "60000"}))
+			Expect(<-commands).To(Equal([]string{"GET", cache.RedisKeyPrefix + "a"}))
+			Expect(<-commands).To(Equal([]string{"GET", cache.RedisKeyPrefix + "b"}))
+			Expect(<-commands).To(Equal([]string{"DEL", cache.RedisKeyPrefix + "a", cache.RedisKeyPrefix + "b"}))
+		})
+	})
+
+	Describe("Instrumenting", func() {
+		It("Should count hits and misses", func() {
+			p := metrics.NewPrometheusProvider(metrics.Namespace)
+			c := cache.NewInstrumenting(p).Cache("kmi", cache.NewLRU(10))
+
+			var i int
+			c.Set("a", 1, time.Minute)
+			c.Get("a", &i)
+			c.Get("a", &i)
+			c.Get("b", &i)
+
+			rec := httptest.NewRecorder()
+			p.Handler().ServeHTTP(rec, httptest.NewRequest("GET", metrics.Path, nil))
+			body, err := ioutil.ReadAll(rec.Body)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(string(body)).To(ContainSubstring(`kontainerooo_cache_hits_total{cache="kmi"} 2`))
+			Expect(string(body)).To(ContainSubstring(`kontainerooo_cache_misses_total{cache="kmi"} 1`))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	kmetrics "github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+)
+
+// Instrumenting records the hits, misses and errors of caches
+type Instrumenting struct {
+	hits   metrics.Counter
+	misses metrics.Counter
+	errors metrics.Counter
+}
+
+type instrumentedCache struct {
+	Cache
+	name string
+	i    *Instrumenting
+}
+
+func (c *instrumentedCache) Get(key string, v interface{}) (bool, error) {
+	found, err := c.Cache.Get(key, v)
+	switch {
+	case err != nil:
+		c.i.errors.With("cache", c.name).Add(1)
+	case found:
+		c.i.hits.With("cache", c.name).Add(1)
+	default:
+		c.i.misses.With("cache", c.name).Add(1)
+	}
+	return found, err
+}
+
+func (c *instrumentedCache) Set(key string, v interface{}, ttl time.Duration) error {
+	err := c.Cache.Set(key, v, ttl)
+	if err != nil {
+		c.i.errors.With("cache", c.name).Add(1)
+	}
+	return err
+}
+
+func (c *instrumentedCache) Delete(keys ...string) error {
+	err := c.Cache.Delete(keys...)
+	if err != nil {
+		c.i.errors.With("cache", c.name).Add(1)
+	}
+	return err
+}
+
+// Cache returns c recording its calls with the label name, e.g. kmi
+func (i *Instrumenting) Cache(name string, c Cache) Cache {
+	return &instrumentedCache{
+		Cache: c,
+		name:  name,
+		i:     i,
+	}
+}
+
+// NewInstrumenting returns an Instrumenting whose metrics are created by p
+func NewInstrumenting(p kmetrics.Provider) *Instrumenting {
+	return &Instrumenting{
+		hits:   p.NewCounter("cache_hits_total", "Number of values found in the caches.", "cache"),
+		misses: p.NewCounter("cache_misses_total", "Number of values not found in the caches.", "cache"),
+		errors: p.NewCounter("cache_errors_total", "Number of failed cache operations.", "cache"),
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/redis"
+)
+
+// RedisKeyPrefix is prepended to the keys of the values in Redis
+const RedisKeyPrefix = "kroo:cache:"
+
+type redisCache struct {
+	client *redis.Client
+}
+
+func (c *redisCache) Get(key string, v interface{}) (bool, error) {
+	reply, err := c.client.Do("GET", RedisKeyPrefix+key)
+	if err != nil {
+		return false, err
+	}
+
+	value, ok := reply.(string)
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal([]byte(value), v)
+}
+
+func (c *redisCache) Set(key string, v interface{}, ttl time.Duration) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	ms := strconv.FormatInt(int64(ttl/time.Millisecond), 10)
+	_, err = c.client.Do("SET", RedisKeyPrefix+key, string(value), "PX", ms)
+	return err
+}
+
+func (c *redisCache) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	args := []string{"DEL"}
+	for _, key := range keys {
+		args = append(args, RedisKeyPrefix+key)
+	}
+	_, err := c.client.Do(args...)
+	return err
+}
+
+// NewRedis returns a Cache keeping the values in the Redis server at rawurl, which has the form
+// redis://[:password@]host[:port][/db]. The values are shared by every node using the server, so
+// a value invalidated on one node is invalidated on all of them.
+func NewRedis(rawurl string) (Cache, error) {
+	c, err := redis.NewClient(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return &redisCache{
+		client: c,
+	}, nil
+}
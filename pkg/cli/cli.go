@@ -2,626 +2,4593 @@
 package cli
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
-	"github.com/go-kit/kit/log"
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	adminPB "github.com/kontainerooo/kontainer.ooo/pkg/admin/pb"
+	alertPB "github.com/kontainerooo/kontainer.ooo/pkg/alert/pb"
+	autoscalePB "github.com/kontainerooo/kontainer.ooo/pkg/autoscale/pb"
+	banPB "github.com/kontainerooo/kontainer.ooo/pkg/ban/pb"
+	billingPB "github.com/kontainerooo/kontainer.ooo/pkg/billing/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/client"
 	"github.com/kontainerooo/kontainer.ooo/pkg/container"
-	containerClient "github.com/kontainerooo/kontainer.ooo/pkg/container/client"
+	containerlogPB "github.com/kontainerooo/kontainer.ooo/pkg/containerlog/pb"
+	cronjobPB "github.com/kontainerooo/kontainer.ooo/pkg/cronjob/pb"
+	databasePB "github.com/kontainerooo/kontainer.ooo/pkg/database/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/deploy"
+	deployPB "github.com/kontainerooo/kontainer.ooo/pkg/deploy/pb"
+	exportPB "github.com/kontainerooo/kontainer.ooo/pkg/export/pb"
+	ktgPB "github.com/kontainerooo/kontainer.ooo/pkg/kentheguru/pb"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
-	kmiClient "github.com/kontainerooo/kontainer.ooo/pkg/kmi/client"
 	"github.com/kontainerooo/kontainer.ooo/pkg/module"
-	moduleClient "github.com/kontainerooo/kontainer.ooo/pkg/module/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	resolverPB "github.com/kontainerooo/kontainer.ooo/pkg/resolver/pb"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
-	routingClient "github.com/kontainerooo/kontainer.ooo/pkg/routing/client"
+	sitePB "github.com/kontainerooo/kontainer.ooo/pkg/site/pb"
+	snapshotPB "github.com/kontainerooo/kontainer.ooo/pkg/snapshot/pb"
+	sshkeyPB "github.com/kontainerooo/kontainer.ooo/pkg/sshkey/pb"
+	usagePB "github.com/kontainerooo/kontainer.ooo/pkg/usage/pb"
 	"github.com/kontainerooo/kontainer.ooo/pkg/user"
-	userClient "github.com/kontainerooo/kontainer.ooo/pkg/user/client"
+	webhookPB "github.com/kontainerooo/kontainer.ooo/pkg/webhook/pb"
+	wireguardPB "github.com/kontainerooo/kontainer.ooo/pkg/wireguard/pb"
 
 	"github.com/abiosoft/ishell"
-	"google.golang.org/grpc"
 )
 
+// Options configure the commands of a shell
+type Options struct {
+	// JSON prints responses as JSON instead of a human readable form
+	JSON bool
+
+	// Profile is the profile the shell is connected with
+	Profile *Profile
+
+	// Profiles are saved to ProfilesPath after logging in or changing a profile
+	Profiles     *Profiles
+	ProfilesPath string
+}
+
+type session struct {
+	opts     Options
+	client   *client.Client
+	ktg      ktgPB.KenTheGuruServiceClient
+	admin    adminPB.AdminServiceClient
+	webhook  webhookPB.WebhookServiceClient
+	sshKey   sshkeyPB.SSHKeyServiceClient
+	db       databasePB.DatabaseServiceClient
+	snapshot snapshotPB.SnapshotServiceClient
+	billing  billingPB.BillingServiceClient
+	cron     cronjobPB.CronJobServiceClient
+	logs     containerlogPB.ContainerLogServiceClient
+	alert    alertPB.AlertServiceClient
+	scale    autoscalePB.AutoscaleServiceClient
+	ban      banPB.BanServiceClient
+	vpn      wireguardPB.WireGuardServiceClient
+	resolver resolverPB.ResolverServiceClient
+	usage    usagePB.UsageServiceClient
+	site     sitePB.SiteServiceClient
+	deploy   deployPB.DeployServiceClient
+	export   exportPB.ExportServiceClient
+
+	// impersonated is the user the calls are made as while the client impersonates them
+	impersonated uint32
+}
+
 // InitShell adds all available kontaineroo commands to an ishell instance
-func InitShell(sh *ishell.Shell, conn *grpc.ClientConn, logger log.Logger) {
+func InitShell(sh *ishell.Shell, c *client.Client, opts Options) {
+	conn := c.Conn()
+	s := &session{
+		opts:     opts,
+		client:   c,
+		ktg:      ktgPB.NewKenTheGuruServiceClient(conn),
+		admin:    adminPB.NewAdminServiceClient(conn),
+		webhook:  webhookPB.NewWebhookServiceClient(conn),
+		sshKey:   sshkeyPB.NewSSHKeyServiceClient(conn),
+		db:       databasePB.NewDatabaseServiceClient(conn),
+		snapshot: snapshotPB.NewSnapshotServiceClient(conn),
+		billing:  billingPB.NewBillingServiceClient(conn),
+		cron:     cronjobPB.NewCronJobServiceClient(conn),
+		logs:     containerlogPB.NewContainerLogServiceClient(conn),
+		alert:    alertPB.NewAlertServiceClient(conn),
+		scale:    autoscalePB.NewAutoscaleServiceClient(conn),
+		ban:      banPB.NewBanServiceClient(conn),
+		vpn:      wireguardPB.NewWireGuardServiceClient(conn),
+		resolver: resolverPB.NewResolverServiceClient(conn),
+		usage:    usagePB.NewUsageServiceClient(conn),
+		site:     sitePB.NewSiteServiceClient(conn),
+		deploy:   deployPB.NewDeployServiceClient(conn),
+		export:   exportPB.NewExportServiceClient(conn),
+	}
+
 	sh.AddCmd(&ishell.Cmd{
 		Name: "login",
-		Help: "authenticate yourself",
-		Func: func(c *ishell.Context) {
-			c.Println("not yet implemented")
-		},
+		Help: "authenticate yourself, usage: login [username]",
+		Func: s.login,
 	})
 
-	kmiClient := kmiClient.New(conn, logger)
-	sh.AddCmd(kmiCommands(sh, kmiClient))
-
-	moduleClient := moduleClient.New(conn, logger)
-	sh.AddCmd(moduleCommands(sh, moduleClient))
-
-	containerClient := containerClient.New(conn, logger)
-	sh.AddCmd(containerCommands(sh, containerClient))
-
-	userClient := userClient.New(conn, logger)
-	sh.AddCmd(userCommands(sh, userClient))
+	sh.AddCmd(&ishell.Cmd{
+		Name: "logout",
+		Help: "forget the token of the current profile",
+		Func: s.logout,
+	})
 
-	routingClient := routingClient.New(conn, logger)
-	sh.AddCmd(routingCommands(sh, routingClient))
-}
+	sh.AddCmd(&ishell.Cmd{
+		Name: "reload",
+		Help: "reload the configuration of the daemon, only admins may do this",
+		Func: s.reload,
+	})
 
-func fillRequestStruct(c *ishell.Context, value *reflect.Value, typ reflect.Type) {
-	for i := 0; i < typ.NumField(); i++ {
-		typeField := typ.Field(i)
-		valField := value.Field(i)
-		name := strings.Title(typeField.Name)
+	sh.AddCmd(&ishell.Cmd{
+		Name: "health",
+		Help: "show the health of the dependencies of the daemon, only admins may do this",
+		Func: s.health,
+	})
 
-		if valField.Kind() == reflect.Ptr {
-			valField = valField.Elem()
-		}
+	sh.AddCmd(s.jobCommands())
 
-		if valField.Kind() == reflect.Struct {
-			c.Println(name)
-			fillRequestStruct(c, &valField, valField.Type())
-			continue
-		}
+	sh.AddCmd(s.adminCommands())
 
-		for {
-			c.Print(name, ": ")
-			value := c.ReadLine()
-			switch valField.Kind() {
-			case reflect.String:
-				if valField.Type() == reflect.TypeOf(abstraction.Inet("")) {
-					valField.Set(reflect.ValueOf(abstraction.Inet(value)))
-				}
-				valField.SetString(value)
-			case reflect.Uint:
-				num, err := strconv.ParseUint(value, 10, 32)
-				if err != nil {
-					c.Println(err.Error())
-					continue
-				}
-				valField.SetUint(num)
-			case reflect.Int:
-				num, err := strconv.ParseInt(value, 10, 32)
-				if err != nil {
-					c.Println(err.Error())
-					continue
-				}
-				valField.SetInt(num)
-			case reflect.Bool:
-				bul, err := strconv.ParseBool(value)
-				if err != nil {
-					c.Println(err.Error())
-					continue
-				}
-				valField.SetBool(bul)
-			case reflect.Array:
-				if valField.Len() > 1 {
-					el := valField.Index(0)
-					switch el.Kind() {
-					case reflect.Uint8:
-						str := ""
-						for i := 0; i < valField.Len(); i++ {
-							str = fmt.Sprintf("%s%c", str, el.Uint())
-						}
-					}
-				}
-			}
-			break
-		}
-	}
-}
+	sh.AddCmd(s.flagCommands())
 
-func printResult(c *ishell.Context, res reflect.Value, resType reflect.Type) {
-	c.Println(strings.Title(resType.Name()))
-	for i := 0; i < resType.NumField(); i++ {
-		field := resType.Field(i)
-		val := res.Field(i)
-		name := strings.Title(field.Name)
+	sh.AddCmd(s.maintenanceCommands())
 
-		if name == "Error" {
-			continue
-		}
+	sh.AddCmd(s.impersonationCommands())
 
-		if val.Kind() == reflect.Ptr {
-			val = val.Elem()
-		}
+	sh.AddCmd(s.webhookCommands())
 
-		if val.Kind() == reflect.Struct {
-			printResult(c, val, val.Type())
-			continue
-		}
+	sh.AddCmd(s.keyCommands())
 
-		c.Println(name, ": ", val.Interface())
-	}
-}
+	sh.AddCmd(s.databaseCommands())
 
-func createCommand(name, help string, endpoint endpoint.Endpoint, req, resType interface{}) *ishell.Cmd {
-	return &ishell.Cmd{
-		Name: name,
-		Help: help,
-		Func: func(c *ishell.Context) {
-			reqValPtr := reflect.ValueOf(req)
-			reqVal := reqValPtr.Elem()
-			reqType := reqVal.Type()
-			fillRequestStruct(c, &reqVal, reqType)
+	sh.AddCmd(s.snapshotCommands())
 
-			res, err := endpoint(context.Background(), reqValPtr.Interface())
-			if err != nil {
-				c.Println(err)
-				return
-			}
+	sh.AddCmd(s.cronCommands())
 
-			resVal := reflect.ValueOf(res).Elem()
-			resValType := resVal.Type()
+	sh.AddCmd(s.alertCommands())
 
-			if reflect.ValueOf(resType).Elem().Type() != resValType {
-				c.Println("response malformed")
-				return
-			}
+	sh.AddCmd(s.autoscaleCommands())
 
-			errVal := resVal.FieldByName("Error")
-			if errVal != reflect.ValueOf(nil) && errVal.Interface() != nil {
-				c.Println("Error: ", errVal)
-				return
-			}
+	sh.AddCmd(s.banCommands())
 
-			c.Println("Success!")
-			printResult(c, resVal, resValType)
-		},
-	}
-}
+	sh.AddCmd(s.vpnCommands())
 
-func kmiCommands(sh *ishell.Shell, kmiClient *kmi.Endpoints) *ishell.Cmd {
-	kmiCmd := &ishell.Cmd{
-		Name: "kmi",
-		Help: "all KMI Service commands",
-	}
+	sh.AddCmd(s.internalDNSCommands())
 
-	kmiCmd.AddCmd(createCommand(
-		"add",
-		"adds a kmi",
-		kmiClient.AddKMIEndpoint,
-		&kmi.AddKMIRequest{},
-		&kmi.AddKMIResponse{}),
-	)
+	sh.AddCmd(s.siteCommands())
+	sh.AddCmd(s.deploymentCommands())
 
-	kmiCmd.AddCmd(createCommand(
-		"remove",
-		"removes a kmi by id",
-		kmiClient.RemoveKMIEndpoint,
-		&kmi.RemoveKMIRequest{},
-		&kmi.RemoveKMIResponse{}),
-	)
+	sh.AddCmd(&ishell.Cmd{
+		Name: "usage",
+		Help: "show the CPU and memory usage of an instance, by default of the last hour, usage: usage <instance> [duration]",
+		Func: s.showUsage,
+	})
 
-	kmiCmd.AddCmd(createCommand(
-		"get",
-		"requests information for a specific kmi",
-		kmiClient.GetKMIEndpoint,
-		&kmi.GetKMIRequest{},
-		&kmi.GetKMIResponse{}),
-	)
+	sh.AddCmd(&ishell.Cmd{
+		Name: "recommendations",
+		Help: "show the CPU and memory limits suggested for your instances from their usage and what they would save, usage: recommendations",
+		Func: s.showRecommendations,
+	})
 
-	kmiCmd.AddCmd(createCommand(
-		"all",
-		"requests information for all kmis",
-		kmiClient.KMIEndpoint,
-		&kmi.KMIRequest{},
-		&kmi.KMIResponse{}),
-	)
+	sh.AddCmd(&ishell.Cmd{
+		Name: "logs",
+		Help: "show the last lines an instance wrote to its standard output and error, optionally only the lines containing every given word, usage: logs <instance> [words]",
+		Func: s.searchLogs,
+	})
 
-	return kmiCmd
-}
+	sh.AddCmd(s.invoiceCommands())
 
-func moduleCommands(sh *ishell.Shell, moduleClient *module.Endpoints) *ishell.Cmd {
-	moduleCmd := &ishell.Cmd{
-		Name: "module",
-		Help: "all Module Service commands",
-	}
+	sh.AddCmd(s.exportCommands())
 
-	moduleCmd.AddCmd(createCommand(
-		"create",
-		"create container module",
-		moduleClient.CreateContainerModuleEndpoint,
-		&module.CreateContainerModuleRequest{},
-		&module.CreateContainerModuleResponse{}),
-	)
+	sh.AddCmd(s.profileCommands())
 
-	setCmd := &ishell.Cmd{
-		Name: "set",
-	}
+	sh.AddCmd(s.kmiCommands(sh, c.KMIs))
 
-	setCmd.AddCmd(createCommand(
-		"publickey",
-		"set publickey",
-		moduleClient.SetPublicKeyEndpoint,
-		&module.SetPublicKeyRequest{},
-		&module.SetPublicKeyResponse{}),
-	)
+	sh.AddCmd(s.moduleCommands(sh, c.Modules))
 
-	setCmd.AddCmd(createCommand(
-		"env",
-		"set environment",
-		moduleClient.SetEnvEndpoint,
-		&module.SetEnvRequest{},
-		&module.SetEnvResponse{}),
-	)
+	sh.AddCmd(s.containerCommands(sh, c.Containers))
 
-	setCmd.AddCmd(createCommand(
-		"link",
-		"set link",
-		moduleClient.SetLinkEndpoint,
-		&module.SetLinkRequest{},
-		&module.SetLinkResponse{}),
-	)
+	sh.AddCmd(s.userCommands(sh, c.Users))
 
-	moduleCmd.AddCmd(setCmd)
+	sh.AddCmd(s.routingCommands(sh, c.Routing))
+}
 
-	getCmd := &ishell.Cmd{
-		Name: "get",
+// fail reports an error, it makes a non-interactive call exit with a non-zero code
+func (s *session) fail(c *ishell.Context, err error) {
+	if s.opts.JSON {
+		data, _ := json.Marshal(map[string]string{
+			"error": err.Error(),
+		})
+		c.Println(string(data))
+	} else {
+		c.Println("Error: ", err)
 	}
+	c.Err(err)
+}
 
-	getCmd.AddCmd(createCommand(
-		"file",
-		"get file",
-		moduleClient.GetFileEndpoint,
-		&module.GetFileRequest{},
-		&module.GetFileResponse{}),
-	)
+func (s *session) saveProfiles(c *ishell.Context) {
+	if s.opts.Profiles == nil || s.opts.ProfilesPath == "" {
+		return
+	}
 
-	getCmd.AddCmd(createCommand(
-		"files",
-		"get files",
-		moduleClient.GetFilesEndpoint,
-		&module.GetFilesRequest{},
-		&module.GetFilesResponse{}),
-	)
+	err := s.opts.Profiles.Save(s.opts.ProfilesPath)
+	if err != nil {
+		s.fail(c, err)
+	}
+}
 
-	getCmd.AddCmd(createCommand(
-		"env",
-		"get environment",
-		moduleClient.GetEnvEndpoint,
-		&module.GetEnvRequest{},
-		&module.GetEnvResponse{}),
-	)
+func (s *session) login(c *ishell.Context) {
+	var username string
+	if len(c.Args) > 0 {
+		username = c.Args[0]
+	} else {
+		c.Print("Username: ")
+		username = c.ReadLine()
+	}
 
-	getCmd.AddCmd(createCommand(
-		"moduleconf",
-		"get module config",
-		moduleClient.GetModuleConfigEndpoint,
-		&module.GetModuleConfigRequest{},
-		&module.GetModuleConfigResponse{}),
-	)
+	c.Print("Password: ")
+	password := c.ReadPassword()
 
-	getCmd.AddCmd(createCommand(
-		"modules",
-		"get all modules",
-		moduleClient.GetModulesEndpoint,
-		&module.GetModulesRequest{},
-		&module.GetModulesResponse{},
-	))
+	id, err := s.client.Login(context.Background(), username, password)
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
 
-	moduleCmd.AddCmd(getCmd)
+	s.opts.Profile.Username = username
+	s.opts.Profile.ID = id
+	s.opts.Profile.Token = s.client.Token()
+	s.saveProfiles(c)
 
-	moduleCmd.AddCmd(createCommand(
-		"uploadfile",
-		"upload file",
-		moduleClient.UploadFileEndpoint,
-		&module.UploadFileRequest{},
-		&module.UploadFileResponse{}),
-	)
+	if !s.opts.JSON {
+		c.Println("Logged in as", username)
+	}
+}
 
-	moduleCmd.AddCmd(createCommand(
-		"sendcmd",
-		"send command",
-		moduleClient.SendCommandEndpoint,
-		&module.SendCommandRequest{},
-		&module.SendCommandResponse{}),
-	)
+func (s *session) logout(c *ishell.Context) {
+	s.opts.Profile.Username = ""
+	s.opts.Profile.ID = 0
+	s.opts.Profile.Token = ""
+	s.client.SetToken("")
+	s.saveProfiles(c)
+}
 
-	removeCmd := &ishell.Cmd{
-		Name: "remove",
+func (s *session) reload(c *ishell.Context) {
+	res, err := s.ktg.ReloadConfiguration(context.Background(), &ktgPB.ReloadConfigurationRequest{})
+	if err == nil && res.Error != "" {
+		err = errors.New(res.Error)
+	}
+	if err != nil {
+		s.fail(c, err)
+		return
 	}
 
-	removeCmd.AddCmd(createCommand(
-		"file",
-		"remove file",
-		moduleClient.RemoveFileEndpoint,
-		&module.RemoveFileRequest{},
-		&module.RemoveFileResponse{}),
-	)
+	if s.opts.JSON {
+		data, _ := json.MarshalIndent(res, "", "  ")
+		c.Println(string(data))
+		return
+	}
 
-	removeCmd.AddCmd(createCommand(
-		"dir",
-		"remove directory",
-		moduleClient.RemoveDirectoryEndpoint,
-		&module.RemoveDirectoryRequest{},
-		&module.RemoveDirectoryResponse{},
-	))
+	for _, setting := range res.Changed {
+		c.Println("changed", setting)
+	}
+	for _, setting := range res.Ignored {
+		c.Println("restart to apply", setting)
+	}
+}
 
-	removeCmd.AddCmd(createCommand(
-		"link",
-		"remove link",
-		moduleClient.RemoveLinkEndpoint, &module.RemoveLinkRequest{},
-		&module.RemoveLinkResponse{},
-	))
+func (s *session) health(c *ishell.Context) {
+	res, err := s.ktg.Health(context.Background(), &ktgPB.HealthRequest{})
+	if err == nil && res.Error != "" {
+		err = errors.New(res.Error)
+	}
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
 
-	moduleCmd.AddCmd(removeCmd)
+	if s.opts.JSON {
+		data, _ := json.MarshalIndent(res, "", "  ")
+		c.Println(string(data))
+	} else {
+		for _, check := range res.Checks {
+			if check.Healthy {
+				c.Println(check.Name, "ok")
+				continue
+			}
+			c.Println(check.Name, "failing:", check.Error)
+		}
+	}
 
-	return moduleCmd
+	// the failing checks are printed already, only the exit code has to reflect them
+	if !res.Live || !res.Ready {
+		c.Err(errors.New("the daemon is not ready"))
+	}
 }
 
-func containerCommands(sh *ishell.Shell, containerClient *container.Endpoints) *ishell.Cmd {
-	containerCmd := &ishell.Cmd{
-		Name: "container",
+func (s *session) jobCommands() *ishell.Cmd {
+	jobCmd := &ishell.Cmd{
+		Name: "jobs",
+		Help: "manage the background jobs of the daemon, only admins may do this",
 	}
 
-	containerCmd.AddCmd(createCommand(
-		"create",
-		"creates a container",
-		containerClient.CreateContainerEndpoint,
-		&container.CreateContainerRequest{},
-		&container.CreateContainerResponse{},
-	))
+	jobCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list the jobs, usage: jobs list [pending|running|succeeded|dead]",
+		Func: func(c *ishell.Context) {
+			req := &ktgPB.JobsRequest{}
+			if len(c.Args) > 0 {
+				req.State = c.Args[0]
+			}
 
-	containerCmd.AddCmd(createCommand(
-		"remove",
-		"removes a container",
+			res, err := s.ktg.Jobs(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.opts.JSON {
+				data, _ := json.MarshalIndent(res, "", "  ")
+				c.Println(string(data))
+				return
+			}
+
+			for _, j := range res.Jobs {
+				line := fmt.Sprintf("%d %s %s %d/%d", j.ID, j.Type, j.State, j.Attempts, j.Limit)
+				if j.LastError != "" {
+					line += " " + j.LastError
+				}
+				c.Println(line)
+			}
+		},
+	})
+
+	jobCmd.AddCmd(&ishell.Cmd{
+		Name: "requeue",
+		Help: "run a dead or succeeded job again, usage: jobs requeue <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: jobs requeue <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.ktg.RequeueJob(context.Background(), &ktgPB.RequeueJobRequest{
+				ID: uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	return jobCmd
+}
+
+// print prints a response as JSON if requested and reports whether it was printed
+func (s *session) print(c *ishell.Context, res interface{}) bool {
+	if !s.opts.JSON {
+		return false
+	}
+
+	data, _ := json.MarshalIndent(res, "", "  ")
+	c.Println(string(data))
+	return true
+}
+
+func (s *session) adminCommands() *ishell.Cmd {
+	adminCmd := &ishell.Cmd{
+		Name: "admin",
+		Help: "inspect and operate the whole platform, only admins may do this",
+	}
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "users",
+		Help: "list all users with their instances and the traffic of the last day",
+		Func: func(c *ishell.Context) {
+			res, err := s.admin.Users(context.Background(), &adminPB.UsersRequest{})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, u := range res.Users {
+				c.Printf("%d %s instances=%d in=%d out=%d\n", u.ID, u.Username, u.Instances, u.BytesIn, u.BytesOut)
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "containers",
+		Help: "list the instances of all users per node",
+		Func: func(c *ishell.Context) {
+			res, err := s.admin.Containers(context.Background(), &adminPB.ContainersRequest{})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, n := range res.Nodes {
+				node := n.Node
+				if node == "" {
+					node = "(unnamed)"
+				}
+				if n.Drained {
+					node += " drained"
+				}
+				if n.Cordoned {
+					node += " cordoned"
+				}
+				if n.Failed {
+					node += " failed"
+				}
+				labels := []string{}
+				for k, v := range n.Labels {
+					labels = append(labels, k+"="+v)
+				}
+				sort.Strings(labels)
+				c.Println(node, strings.Join(labels, ","))
+				for _, i := range n.Instances {
+					line := []interface{}{" ", i.ContainerID, i.RefID, i.Name}
+					if i.Pinned {
+						line = append(line, "pinned")
+					}
+					if i.Placement != nil && (i.Placement.Spread || len(i.Placement.Labels) != 0) {
+						line = append(line, kmi.Placement{
+							Spread: i.Placement.Spread,
+							Labels: i.Placement.Labels,
+						}.String())
+					}
+					c.Println(line...)
+				}
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "errors",
+		Help: "list the recent errors of the daemon, usage: admin errors [limit]",
+		Func: func(c *ishell.Context) {
+			req := &adminPB.ErrorsRequest{}
+			if len(c.Args) > 0 {
+				limit, err := strconv.ParseUint(c.Args[0], 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+				req.Limit = uint32(limit)
+			}
+
+			res, err := s.admin.Errors(context.Background(), req)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, e := range res.Errors {
+				c.Println(e.Record)
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "queue",
+		Help: "count the background jobs per state",
+		Func: func(c *ishell.Context) {
+			res, err := s.admin.QueueDepth(context.Background(), &adminPB.QueueDepthRequest{})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, d := range res.Depth {
+				c.Println(d.State, d.Count)
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "stop",
+		Help: "stop a container of any user, usage: admin stop <container id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: admin stop <container id>"))
+				return
+			}
+
+			res, err := s.admin.StopContainer(context.Background(), &adminPB.StopContainerRequest{
+				ContainerID: c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "reassign",
+		Help: "move an instance to another node, its files are not moved, usage: admin reassign <container id> <node>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 2 {
+				s.fail(c, errors.New("usage: admin reassign <container id> <node>"))
+				return
+			}
+
+			res, err := s.admin.ReassignNode(context.Background(), &adminPB.ReassignNodeRequest{
+				ContainerID: c.Args[0],
+				Node:        c.Args[1],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "drain",
+		Help: "move every instance off a node, or stop them, notify their owners and stop placing instances on it, usage: admin drain <node> [stop]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 1 || len(c.Args) > 2 || (len(c.Args) == 2 && c.Args[1] != "stop") {
+				s.fail(c, errors.New("usage: admin drain <node> [stop]"))
+				return
+			}
+
+			stop := len(c.Args) == 2
+			res, err := s.admin.DrainNode(context.Background(), &adminPB.DrainNodeRequest{
+				Node: c.Args[0],
+				Stop: stop,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			if stop {
+				c.Println("stopping", res.Moved, "instances")
+			} else {
+				c.Println("moving", res.Moved, "instances")
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "undrain",
+		Help: "place instances on a drained node again, usage: admin undrain <node>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: admin undrain <node>"))
+				return
+			}
+
+			res, err := s.admin.UndrainNode(context.Background(), &adminPB.UndrainNodeRequest{
+				Node: c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "cordon",
+		Help: "stop placing instances on a node while its instances keep running, usage: admin cordon <node>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: admin cordon <node>"))
+				return
+			}
+
+			res, err := s.admin.CordonNode(context.Background(), &adminPB.CordonNodeRequest{
+				Node: c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "uncordon",
+		Help: "place instances on a cordoned node again, usage: admin uncordon <node>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: admin uncordon <node>"))
+				return
+			}
+
+			res, err := s.admin.UncordonNode(context.Background(), &adminPB.UncordonNodeRequest{
+				Node: c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	adminCmd.AddCmd(&ishell.Cmd{
+		Name: "label",
+		Help: "set labels of a node the placement of modules requires, key= removes a label, usage: admin label <node> <key=value>...",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 {
+				s.fail(c, errors.New("usage: admin label <node> <key=value>..."))
+				return
+			}
+
+			labels := make(map[string]string)
+			for _, l := range c.Args[1:] {
+				kv := strings.SplitN(l, "=", 2)
+				if len(kv) != 2 {
+					s.fail(c, fmt.Errorf("label %s is not key=value", l))
+					return
+				}
+				labels[kv[0]] = kv[1]
+			}
+
+			res, err := s.admin.LabelNode(context.Background(), &adminPB.LabelNodeRequest{
+				Node:   c.Args[0],
+				Labels: labels,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	return adminCmd
+}
+
+// parseFlag builds a feature flag from the arguments of flags set
+func parseFlag(args []string) (*adminPB.Flag, error) {
+	f := &adminPB.Flag{
+		Name: args[0],
+	}
+
+	for _, a := range args[1:] {
+		switch {
+		case a == "on":
+			f.Enabled = true
+		case strings.HasPrefix(a, "user="):
+			id, err := strconv.ParseUint(strings.TrimPrefix(a, "user="), 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			f.Users = append(f.Users, uint32(id))
+		case strings.HasPrefix(a, "plan="):
+			f.Plans = append(f.Plans, strings.TrimPrefix(a, "plan="))
+		case strings.HasSuffix(a, "%"):
+			p, err := strconv.ParseUint(strings.TrimSuffix(a, "%"), 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			f.Percentage = uint32(p)
+		default:
+			return nil, fmt.Errorf("unknown argument %s", a)
+		}
+	}
+	return f, nil
+}
+
+func (s *session) flagCommands() *ishell.Cmd {
+	flagCmd := &ishell.Cmd{
+		Name: "flags",
+		Help: "turn features on for some users only, only admins may do this",
+	}
+
+	flagCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list the feature flags",
+		Func: func(c *ishell.Context) {
+			res, err := s.admin.Flags(context.Background(), &adminPB.FlagsRequest{})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, f := range res.Flags {
+				c.Printf("%s on=%t percentage=%d users=%v plans=%v\n", f.Name, f.Enabled, f.Percentage, f.Users, f.Plans)
+			}
+		},
+	})
+
+	flagCmd.AddCmd(&ishell.Cmd{
+		Name: "set",
+		Help: "turn a feature on for everyone, a percentage, users or plans, it is off for everyone else, usage: flags set <name> [on] [<percentage>%] [user=<id>]... [plan=<plan>]...",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 1 {
+				s.fail(c, errors.New("usage: flags set <name> [on] [<percentage>%] [user=<id>]... [plan=<plan>]..."))
+				return
+			}
+
+			f, err := parseFlag(c.Args)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.admin.SetFlag(context.Background(), &adminPB.SetFlagRequest{
+				Flag: f,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	flagCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "turn a feature off for everyone, usage: flags remove <name>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: flags remove <name>"))
+				return
+			}
+
+			res, err := s.admin.RemoveFlag(context.Background(), &adminPB.RemoveFlagRequest{
+				Name: c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	return flagCmd
+}
+
+func (s *session) maintenanceCommands() *ishell.Cmd {
+	maintenanceCmd := &ishell.Cmd{
+		Name: "maintenance",
+		Help: "schedule maintenance windows in which only reading calls are accepted, only admins may do this",
+	}
+
+	maintenanceCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list the upcoming and active maintenance windows",
+		Func: func(c *ishell.Context) {
+			res, err := s.admin.Maintenance(context.Background(), &adminPB.MaintenanceRequest{})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, w := range res.Windows {
+				start := time.Unix(w.Start, 0).Format(time.RFC3339)
+				end := time.Unix(w.End, 0).Format(time.RFC3339)
+				c.Printf("%d %s - %s %s\n", w.ID, start, end, w.Message)
+			}
+		},
+	})
+
+	maintenanceCmd.AddCmd(&ishell.Cmd{
+		Name: "schedule",
+		Help: "schedule a maintenance window, start is a RFC 3339 time or now, usage: maintenance schedule <start> <duration> [message]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 {
+				s.fail(c, errors.New("usage: maintenance schedule <start> <duration> [message]"))
+				return
+			}
+
+			start := time.Now()
+			if c.Args[0] != "now" {
+				var err error
+				start, err = time.Parse(time.RFC3339, c.Args[0])
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+			}
+
+			d, err := time.ParseDuration(c.Args[1])
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.admin.ScheduleMaintenance(context.Background(), &adminPB.ScheduleMaintenanceRequest{
+				Start:   start.Unix(),
+				End:     start.Add(d).Unix(),
+				Message: strings.Join(c.Args[2:], " "),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("scheduled maintenance window", res.ID)
+			}
+		},
+	})
+
+	maintenanceCmd.AddCmd(&ishell.Cmd{
+		Name: "cancel",
+		Help: "cancel a maintenance window, an active window ends right away, usage: maintenance cancel <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: maintenance cancel <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.admin.CancelMaintenance(context.Background(), &adminPB.CancelMaintenanceRequest{
+				ID: uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	return maintenanceCmd
+}
+
+func (s *session) impersonationCommands() *ishell.Cmd {
+	impersonateCmd := &ishell.Cmd{
+		Name: "impersonate",
+		Help: "act as a user for support purposes, the user is notified and every call is recorded, only admins may do this",
+	}
+
+	impersonateCmd.AddCmd(&ishell.Cmd{
+		Name: "start",
+		Help: "start a session and make the further calls as the user, it lasts 30 minutes by default and at most 4 hours, usage: impersonate start <user id> <duration> <reason>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 3 {
+				s.fail(c, errors.New("usage: impersonate start <user id> <duration> <reason>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			d, err := time.ParseDuration(c.Args[1])
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			s.client.Impersonate(0)
+			res, err := s.admin.Impersonate(context.Background(), &adminPB.ImpersonateRequest{
+				UserID:   uint32(id),
+				Reason:   strings.Join(c.Args[2:], " "),
+				Duration: int64(d / time.Second),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			s.client.Impersonate(uint(res.Session.ID))
+			s.impersonated = res.Session.UserID
+			if !s.print(c, res) {
+				c.Printf("impersonating user %d in session %d until %s\n", res.Session.UserID, res.Session.ID, time.Unix(res.Session.End, 0).Format(time.RFC3339))
+			}
+		},
+	})
+
+	impersonateCmd.AddCmd(&ishell.Cmd{
+		Name: "use",
+		Help: "make the further calls in a session which was started before, usage: impersonate use <session id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: impersonate use <session id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			s.client.Impersonate(0)
+			res, err := s.admin.Impersonations(context.Background(), &adminPB.ImpersonationsRequest{})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			for _, session := range res.Sessions {
+				if uint64(session.ID) == id {
+					s.client.Impersonate(uint(id))
+					s.impersonated = session.UserID
+					return
+				}
+			}
+			s.fail(c, errors.New("the session does not exist or ended"))
+		},
+	})
+
+	impersonateCmd.AddCmd(&ishell.Cmd{
+		Name: "stop",
+		Help: "end a session right away, the current one by default, usage: impersonate stop [session id]",
+		Func: func(c *ishell.Context) {
+			id := uint64(s.client.Impersonating())
+			if len(c.Args) == 1 {
+				var err error
+				id, err = strconv.ParseUint(c.Args[0], 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+			}
+			if id == 0 {
+				s.fail(c, errors.New("usage: impersonate stop [session id]"))
+				return
+			}
+
+			// the call is made as the admin again
+			s.client.Impersonate(0)
+			s.impersonated = 0
+			res, err := s.admin.StopImpersonation(context.Background(), &adminPB.StopImpersonationRequest{
+				ID: uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	impersonateCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list the sessions which did not end yet",
+		Func: func(c *ishell.Context) {
+			res, err := s.admin.Impersonations(context.Background(), &adminPB.ImpersonationsRequest{})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, session := range res.Sessions {
+				end := time.Unix(session.End, 0).Format(time.RFC3339)
+				c.Printf("%d admin %d as user %d until %s: %s\n", session.ID, session.AdminID, session.UserID, end, session.Reason)
+			}
+		},
+	})
+
+	impersonateCmd.AddCmd(&ishell.Cmd{
+		Name: "trail",
+		Help: "show the audit trail of a session or of every session, usage: impersonate trail [session id]",
+		Func: func(c *ishell.Context) {
+			var id uint64
+			if len(c.Args) == 1 {
+				var err error
+				id, err = strconv.ParseUint(c.Args[0], 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+			}
+
+			res, err := s.admin.ImpersonationTrail(context.Background(), &adminPB.ImpersonationTrailRequest{
+				ID: uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, e := range res.Entries {
+				t := time.Unix(e.Time, 0).Format(time.RFC3339)
+				c.Printf("%s session %d admin %d as user %d: %s", t, e.SessionID, e.AdminID, e.UserID, e.Method)
+				if e.Error != "" {
+					c.Printf(" failed: %s", e.Error)
+				}
+				c.Println()
+			}
+		},
+	})
+
+	return impersonateCmd
+}
+
+// webhookOwner returns the user whose webhooks a command manages and its remaining arguments,
+// a leading -platform selects the webhooks of the platform
+func (s *session) webhookOwner(c *ishell.Context) (uint32, []string) {
+	if len(c.Args) > 0 && c.Args[0] == "-platform" {
+		return 0, c.Args[1:]
+	}
+
+	return s.refID(), c.Args
+}
+
+// refID returns the ID of the user of the current profile
+func (s *session) refID() uint32 {
+	if s.impersonated != 0 && s.client.Impersonating() != 0 {
+		return s.impersonated
+	}
+	if s.opts.Profile != nil {
+		return uint32(s.opts.Profile.ID)
+	}
+	return 0
+}
+
+func (s *session) webhookCommands() *ishell.Cmd {
+	webhookCmd := &ishell.Cmd{
+		Name: "webhook",
+		Help: "manage the webhooks events are posted to, -platform manages the ones of the platform, only admins may do this",
+	}
+
+	webhookCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your webhooks, usage: webhook list [-platform]",
+		Func: func(c *ishell.Context) {
+			refID, _ := s.webhookOwner(c)
+			res, err := s.webhook.Webhooks(context.Background(), &webhookPB.WebhooksRequest{
+				RefID: refID,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, w := range res.Webhooks {
+				c.Println(w.ID, w.Url, strings.Join(w.Events, ","))
+			}
+		},
+	})
+
+	webhookCmd.AddCmd(&ishell.Cmd{
+		Name: "create",
+		Help: "post events to an url, the secret signing them is printed, usage: webhook create [-platform] <url> <event>...",
+		Func: func(c *ishell.Context) {
+			refID, args := s.webhookOwner(c)
+			if len(args) < 2 {
+				s.fail(c, errors.New("usage: webhook create [-platform] <url> <event>..."))
+				return
+			}
+
+			res, err := s.webhook.CreateWebhook(context.Background(), &webhookPB.CreateWebhookRequest{
+				RefID:  refID,
+				Url:    args[0],
+				Events: args[1:],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("created webhook", res.ID, "with secret", res.Secret)
+			}
+		},
+	})
+
+	webhookCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a webhook, usage: webhook remove [-platform] <id>",
+		Func: func(c *ishell.Context) {
+			refID, args := s.webhookOwner(c)
+			if len(args) != 1 {
+				s.fail(c, errors.New("usage: webhook remove [-platform] <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.webhook.RemoveWebhook(context.Background(), &webhookPB.RemoveWebhookRequest{
+				RefID: refID,
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	webhookCmd.AddCmd(&ishell.Cmd{
+		Name: "deliveries",
+		Help: "list the recent deliveries of a webhook, usage: webhook deliveries [-platform] <id>",
+		Func: func(c *ishell.Context) {
+			refID, args := s.webhookOwner(c)
+			if len(args) != 1 {
+				s.fail(c, errors.New("usage: webhook deliveries [-platform] <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.webhook.Deliveries(context.Background(), &webhookPB.DeliveriesRequest{
+				RefID: refID,
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, d := range res.Deliveries {
+				c.Printf("%s %s attempt=%d status=%d %dms %s\n", d.EventID, d.Topic, d.Attempt, d.StatusCode, d.Duration, d.Error)
+			}
+		},
+	})
+
+	return webhookCmd
+}
+
+func (s *session) keyCommands() *ishell.Cmd {
+	keyCmd := &ishell.Cmd{
+		Name: "key",
+		Help: "manage the SSH keys you log in to your containers with",
+	}
+
+	keyCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your keys, usage: key list",
+		Func: func(c *ishell.Context) {
+			res, err := s.sshKey.Keys(context.Background(), &sshkeyPB.KeysRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, k := range res.Keys {
+				c.Println(k.ID, k.Type, k.Fingerprint, k.Name)
+			}
+		},
+	})
+
+	keyCmd.AddCmd(&ishell.Cmd{
+		Name: "add",
+		Help: "add a public key, the name defaults to its comment, usage: key add <file> [name]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 1 || len(c.Args) > 2 {
+				s.fail(c, errors.New("usage: key add <file> [name]"))
+				return
+			}
+
+			b, err := ioutil.ReadFile(c.Args[0])
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			req := &sshkeyPB.AddKeyRequest{
+				RefID: s.refID(),
+				Key:   strings.TrimSpace(string(b)),
+			}
+			if len(c.Args) == 2 {
+				req.Name = c.Args[1]
+			}
+
+			res, err := s.sshKey.AddKey(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("added key", res.ID, res.Fingerprint)
+			}
+		},
+	})
+
+	keyCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a key, usage: key remove <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: key remove <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.sshKey.RemoveKey(context.Background(), &sshkeyPB.RemoveKeyRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	return keyCmd
+}
+
+func (s *session) profileCommands() *ishell.Cmd {
+	profileCmd := &ishell.Cmd{
+		Name: "profile",
+		Help: "manage the installations the cli connects to",
+	}
+
+	profileCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list all profiles",
+		Func: func(c *ishell.Context) {
+			if s.opts.Profiles == nil {
+				return
+			}
+
+			if s.opts.JSON {
+				data, _ := json.MarshalIndent(s.opts.Profiles, "", "  ")
+				c.Println(string(data))
+				return
+			}
+
+			for name, p := range s.opts.Profiles.Profiles {
+				current := " "
+				if name == s.opts.Profiles.Current {
+					current = "*"
+				}
+				c.Println(current, name, p.Address, p.Username)
+			}
+		},
+	})
+
+	profileCmd.AddCmd(&ishell.Cmd{
+		Name: "add",
+		Help: "add a profile, usage: profile add <name> <address> [tls] [ca file]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 || s.opts.Profiles == nil {
+				s.fail(c, errors.New("usage: profile add <name> <address> [tls] [ca file]"))
+				return
+			}
+
+			p := &Profile{
+				Address: c.Args[1],
+			}
+			if len(c.Args) > 2 {
+				tls, err := strconv.ParseBool(c.Args[2])
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+				p.TLS = tls
+			}
+			if len(c.Args) > 3 {
+				p.CAFile = c.Args[3]
+			}
+
+			s.opts.Profiles.Profiles[c.Args[0]] = p
+			s.saveProfiles(c)
+		},
+	})
+
+	profileCmd.AddCmd(&ishell.Cmd{
+		Name: "use",
+		Help: "use a profile from the next start on, usage: profile use <name>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 1 || s.opts.Profiles == nil {
+				s.fail(c, errors.New("usage: profile use <name>"))
+				return
+			}
+
+			_, err := s.opts.Profiles.Get(c.Args[0])
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			s.opts.Profiles.Current = c.Args[0]
+			s.saveProfiles(c)
+		},
+	})
+
+	profileCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a profile, usage: profile remove <name>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 1 || s.opts.Profiles == nil {
+				s.fail(c, errors.New("usage: profile remove <name>"))
+				return
+			}
+
+			delete(s.opts.Profiles.Profiles, c.Args[0])
+			s.saveProfiles(c)
+		},
+	})
+
+	return profileCmd
+}
+
+// setField parses value into a request field
+func setField(valField reflect.Value, value string) error {
+	switch valField.Kind() {
+	case reflect.String:
+		if valField.Type() == reflect.TypeOf(abstraction.Inet("")) {
+			valField.Set(reflect.ValueOf(abstraction.Inet(value)))
+		}
+		valField.SetString(value)
+	case reflect.Uint:
+		num, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return err
+		}
+		valField.SetUint(num)
+	case reflect.Int:
+		num, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return err
+		}
+		valField.SetInt(num)
+	case reflect.Bool:
+		bul, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		valField.SetBool(bul)
+	case reflect.Map:
+		// maps of strings are given as key=value,key=value
+		if valField.Type() != reflect.TypeOf(map[string]string{}) {
+			return nil
+		}
+		m := make(map[string]string)
+		for _, kv := range strings.Split(value, ",") {
+			if kv == "" {
+				continue
+			}
+			p := strings.SplitN(kv, "=", 2)
+			if len(p) != 2 {
+				return fmt.Errorf("%s is not key=value", kv)
+			}
+			m[p[0]] = p[1]
+		}
+		valField.Set(reflect.ValueOf(m))
+	case reflect.Array:
+		if valField.Len() > 1 {
+			el := valField.Index(0)
+			switch el.Kind() {
+			case reflect.Uint8:
+				str := ""
+				for i := 0; i < valField.Len(); i++ {
+					str = fmt.Sprintf("%s%c", str, el.Uint())
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isRefID reports whether a field is the id of the user a request concerns, it is filled from the profile
+func (s *session) isRefID(field reflect.StructField) bool {
+	return field.Name == "RefID" && s.opts.Profile != nil && s.opts.Profile.ID != 0
+}
+
+func (s *session) fillRequestStruct(c *ishell.Context, value *reflect.Value, typ reflect.Type) {
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		valField := value.Field(i)
+		name := strings.Title(typeField.Name)
+
+		if s.isRefID(typeField) {
+			valField.SetUint(uint64(s.opts.Profile.ID))
+			continue
+		}
+
+		if valField.Kind() == reflect.Ptr {
+			valField = valField.Elem()
+		}
+
+		if valField.Kind() == reflect.Struct {
+			c.Println(name)
+			s.fillRequestStruct(c, &valField, valField.Type())
+			continue
+		}
+
+		for {
+			c.Print(name, ": ")
+			err := setField(valField, c.ReadLine())
+			if err != nil {
+				c.Println(err.Error())
+				continue
+			}
+			break
+		}
+	}
+}
+
+// applyArgs sets the fields of a request from arguments of the form field=value,
+// fields of nested structs are set with field.nested=value
+func (s *session) applyArgs(value reflect.Value, args []string) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		if s.isRefID(typ.Field(i)) {
+			value.Field(i).SetUint(uint64(s.opts.Profile.ID))
+		}
+	}
+
+	for _, arg := range args {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("argument %s is not of the form field=value", arg)
+		}
+
+		val := value
+		path := strings.Split(kv[0], ".")
+		for j, name := range path {
+			field := val.FieldByNameFunc(func(n string) bool {
+				return strings.EqualFold(n, name)
+			})
+			if !field.IsValid() {
+				return fmt.Errorf("unknown field %s", kv[0])
+			}
+
+			if field.Kind() == reflect.Ptr {
+				if field.IsNil() {
+					field.Set(reflect.New(field.Type().Elem()))
+				}
+				field = field.Elem()
+			}
+
+			if j < len(path)-1 {
+				if field.Kind() != reflect.Struct {
+					return fmt.Errorf("unknown field %s", kv[0])
+				}
+				val = field
+				continue
+			}
+
+			err := setField(field, kv[1])
+			if err != nil {
+				return fmt.Errorf("%s: %v", kv[0], err)
+			}
+		}
+	}
+	return nil
+}
+
+// withAliases adds aliases to a command
+func withAliases(cmd *ishell.Cmd, aliases ...string) *ishell.Cmd {
+	cmd.Aliases = append(cmd.Aliases, aliases...)
+	return cmd
+}
+
+func printResult(c *ishell.Context, res reflect.Value, resType reflect.Type) {
+	c.Println(strings.Title(resType.Name()))
+	for i := 0; i < resType.NumField(); i++ {
+		field := resType.Field(i)
+		val := res.Field(i)
+		name := strings.Title(field.Name)
+
+		if name == "Error" {
+			continue
+		}
+
+		if val.Kind() == reflect.Ptr {
+			val = val.Elem()
+		}
+
+		if val.Kind() == reflect.Struct {
+			printResult(c, val, val.Type())
+			continue
+		}
+
+		c.Println(name, ": ", val.Interface())
+	}
+}
+
+// createCommand returns a command calling endpoint, the request is read from the arguments
+// of the command or, if there are none, interactively
+func (s *session) createCommand(name, help string, endpoint endpoint.Endpoint, req, resType interface{}) *ishell.Cmd {
+	return &ishell.Cmd{
+		Name: name,
+		Help: help,
+		Func: func(c *ishell.Context) {
+			// every call starts with a copy of the request template
+			tmpl := reflect.ValueOf(req).Elem()
+			reqValPtr := reflect.New(tmpl.Type())
+			reqVal := reqValPtr.Elem()
+			reqVal.Set(tmpl)
+			reqType := reqVal.Type()
+
+			if len(c.Args) > 0 {
+				err := s.applyArgs(reqVal, c.Args)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+			} else {
+				s.fillRequestStruct(c, &reqVal, reqType)
+			}
+
+			res, err := endpoint(context.Background(), reqValPtr.Interface())
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			resVal := reflect.ValueOf(res).Elem()
+			resValType := resVal.Type()
+
+			if reflect.ValueOf(resType).Elem().Type() != resValType {
+				s.fail(c, errors.New("response malformed"))
+				return
+			}
+
+			errVal := resVal.FieldByName("Error")
+			if errVal != reflect.ValueOf(nil) && errVal.Interface() != nil {
+				s.fail(c, fmt.Errorf("%v", errVal.Interface()))
+				return
+			}
+
+			if s.opts.JSON {
+				data, err := json.MarshalIndent(resVal.Interface(), "", "  ")
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+				c.Println(string(data))
+				return
+			}
+
+			c.Println("Success!")
+			printResult(c, resVal, resValType)
+		},
+	}
+}
+
+func (s *session) kmiCommands(sh *ishell.Shell, kmiClient *kmi.Endpoints) *ishell.Cmd {
+	kmiCmd := &ishell.Cmd{
+		Name: "kmi",
+		Help: "all KMI Service commands",
+	}
+
+	kmiCmd.AddCmd(s.createCommand(
+		"add",
+		"adds a kmi",
+		kmiClient.AddKMIEndpoint,
+		&kmi.AddKMIRequest{},
+		&kmi.AddKMIResponse{}),
+	)
+
+	kmiCmd.AddCmd(s.createCommand(
+		"remove",
+		"removes a kmi by id",
+		kmiClient.RemoveKMIEndpoint,
+		&kmi.RemoveKMIRequest{},
+		&kmi.RemoveKMIResponse{}),
+	)
+
+	kmiCmd.AddCmd(s.createCommand(
+		"get",
+		"requests information for a specific kmi",
+		kmiClient.GetKMIEndpoint,
+		&kmi.GetKMIRequest{},
+		&kmi.GetKMIResponse{}),
+	)
+
+	kmiCmd.AddCmd(s.createCommand(
+		"all",
+		"requests information for all kmis",
+		kmiClient.KMIEndpoint,
+		&kmi.KMIRequest{},
+		&kmi.KMIResponse{}),
+	)
+
+	return kmiCmd
+}
+
+func (s *session) moduleCommands(sh *ishell.Shell, moduleClient *module.Endpoints) *ishell.Cmd {
+	moduleCmd := &ishell.Cmd{
+		Name: "module",
+		Help: "all Module Service commands",
+	}
+
+	moduleCmd.AddCmd(withAliases(s.createCommand(
+		"create",
+		"create container module",
+		moduleClient.CreateContainerModuleEndpoint,
+		&module.CreateContainerModuleRequest{},
+		&module.CreateContainerModuleResponse{}),
+		"install",
+	))
+
+	setCmd := &ishell.Cmd{
+		Name: "set",
+	}
+
+	setCmd.AddCmd(s.createCommand(
+		"publickey",
+		"set publickey",
+		moduleClient.SetPublicKeyEndpoint,
+		&module.SetPublicKeyRequest{},
+		&module.SetPublicKeyResponse{}),
+	)
+
+	setCmd.AddCmd(s.createCommand(
+		"env",
+		"set environment",
+		moduleClient.SetEnvEndpoint,
+		&module.SetEnvRequest{},
+		&module.SetEnvResponse{}),
+	)
+
+	setCmd.AddCmd(s.createCommand(
+		"link",
+		"set link",
+		moduleClient.SetLinkEndpoint,
+		&module.SetLinkRequest{},
+		&module.SetLinkResponse{}),
+	)
+
+	moduleCmd.AddCmd(setCmd)
+
+	getCmd := &ishell.Cmd{
+		Name: "get",
+	}
+
+	getCmd.AddCmd(s.createCommand(
+		"file",
+		"get file",
+		moduleClient.GetFileEndpoint,
+		&module.GetFileRequest{},
+		&module.GetFileResponse{}),
+	)
+
+	getCmd.AddCmd(s.createCommand(
+		"files",
+		"get files",
+		moduleClient.GetFilesEndpoint,
+		&module.GetFilesRequest{},
+		&module.GetFilesResponse{}),
+	)
+
+	getCmd.AddCmd(s.createCommand(
+		"env",
+		"get environment",
+		moduleClient.GetEnvEndpoint,
+		&module.GetEnvRequest{},
+		&module.GetEnvResponse{}),
+	)
+
+	getCmd.AddCmd(s.createCommand(
+		"moduleconf",
+		"get module config",
+		moduleClient.GetModuleConfigEndpoint,
+		&module.GetModuleConfigRequest{},
+		&module.GetModuleConfigResponse{}),
+	)
+
+	getCmd.AddCmd(s.createCommand(
+		"modules",
+		"get all modules",
+		moduleClient.GetModulesEndpoint,
+		&module.GetModulesRequest{},
+		&module.GetModulesResponse{},
+	))
+
+	moduleCmd.AddCmd(getCmd)
+
+	moduleCmd.AddCmd(s.createCommand(
+		"uploadfile",
+		"upload file",
+		moduleClient.UploadFileEndpoint,
+		&module.UploadFileRequest{},
+		&module.UploadFileResponse{}),
+	)
+
+	moduleCmd.AddCmd(s.createCommand(
+		"sendcmd",
+		"send command",
+		moduleClient.SendCommandEndpoint,
+		&module.SendCommandRequest{},
+		&module.SendCommandResponse{}),
+	)
+
+	removeCmd := &ishell.Cmd{
+		Name: "remove",
+	}
+
+	removeCmd.AddCmd(s.createCommand(
+		"file",
+		"remove file",
+		moduleClient.RemoveFileEndpoint,
+		&module.RemoveFileRequest{},
+		&module.RemoveFileResponse{}),
+	)
+
+	removeCmd.AddCmd(s.createCommand(
+		"dir",
+		"remove directory",
+		moduleClient.RemoveDirectoryEndpoint,
+		&module.RemoveDirectoryRequest{},
+		&module.RemoveDirectoryResponse{},
+	))
+
+	removeCmd.AddCmd(s.createCommand(
+		"link",
+		"remove link",
+		moduleClient.RemoveLinkEndpoint, &module.RemoveLinkRequest{},
+		&module.RemoveLinkResponse{},
+	))
+
+	moduleCmd.AddCmd(removeCmd)
+
+	return moduleCmd
+}
+
+func (s *session) containerCommands(sh *ishell.Shell, containerClient *container.Endpoints) *ishell.Cmd {
+	containerCmd := &ishell.Cmd{
+		Name: "container",
+	}
+
+	containerCmd.AddCmd(s.createCommand(
+		"create",
+		"creates a container",
+		containerClient.CreateContainerEndpoint,
+		&container.CreateContainerRequest{},
+		&container.CreateContainerResponse{},
+	))
+
+	containerCmd.AddCmd(s.createCommand(
+		"remove",
+		"removes a container",
 		containerClient.RemoveContainerEndpoint,
 		&container.RemoveContainerRequest{},
 		&container.RemoveContainerResponse{},
 	))
 
-	containerCmd.AddCmd(createCommand(
-		"execute",
-		"execute a command inside a container",
-		containerClient.ExecuteEndpoint,
-		&container.ExecuteRequest{},
-		&container.ExecuteResponse{},
-	))
+	containerCmd.AddCmd(withAliases(s.createCommand(
+		"execute",
+		"execute a command inside a container",
+		containerClient.ExecuteEndpoint,
+		&container.ExecuteRequest{},
+		&container.ExecuteResponse{},
+	), "exec"))
+
+	containerCmd.AddCmd(s.createCommand(
+		"stop",
+		"stop a container",
+		containerClient.StopContainerEndpoint,
+		&container.StopContainerRequest{},
+		&container.StopContainerResponse{},
+	))
+
+	containerCmd.AddCmd(withAliases(s.createCommand(
+		"instances",
+		"all container instances",
+		containerClient.InstancesEndpoint,
+		&container.InstancesRequest{},
+		&container.InstancesResponse{},
+	), "list"))
+
+	containerCmd.AddCmd(s.createCommand(
+		"id",
+		"get id for container name",
+		containerClient.IDForNameEndpoint,
+		&container.IDForNameRequest{},
+		&container.IDForNameResponse{},
+	))
+
+	containerCmd.AddCmd(s.createCommand(
+		"kmi",
+		"get kmi of a container",
+		containerClient.GetContainerKMIEndpoint,
+		&container.GetContainerKMIRequest{},
+		&container.GetContainerKMIResponse{},
+	))
+
+	containerCmd.AddCmd(s.createCommand(
+		"pin",
+		"pin a container to its node so it is not rescheduled if the node fails",
+		containerClient.PinInstanceEndpoint,
+		&container.PinInstanceRequest{},
+		&container.PinInstanceResponse{},
+	))
+
+	containerCmd.AddCmd(s.createCommand(
+		"placement",
+		"spread a container from the other instances of its module or require node labels",
+		containerClient.SetPlacementEndpoint,
+		&container.SetPlacementRequest{},
+		&container.SetPlacementResponse{},
+	))
+
+	containerCmd.AddCmd(s.createCommand(
+		"export",
+		"export the volume of a container and print a signed URL the archive can be downloaded from until it expires",
+		containerClient.ExportInstanceEndpoint,
+		&container.ExportInstanceRequest{},
+		&container.ExportInstanceResponse{},
+	))
+
+	linkCmd := &ishell.Cmd{
+		Name: "link",
+	}
+
+	linkCmd.AddCmd(s.createCommand(
+		"set",
+		"set a link",
+		containerClient.SetLinkEndpoint,
+		&container.SetLinkRequest{},
+		&container.SetLinkResponse{},
+	))
+
+	linkCmd.AddCmd(s.createCommand(
+		"remove",
+		"remove a link",
+		containerClient.RemoveLinkEndpoint,
+		&container.RemoveLinkRequest{},
+		&container.RemoveLinkResponse{},
+	))
+
+	linkCmd.AddCmd(s.createCommand(
+		"get",
+		"get links of a container",
+		containerClient.GetLinksEndpoint,
+		&container.GetLinksRequest{},
+		&container.GetLinksResponse{},
+	))
+
+	containerCmd.AddCmd(linkCmd)
+
+	return containerCmd
+}
+
+func (s *session) userCommands(sh *ishell.Shell, userClient *user.Endpoints) *ishell.Cmd {
+	userCmd := &ishell.Cmd{
+		Name: "user",
+		Help: "all User Service commands",
+	}
+
+	userCmd.AddCmd(s.createCommand(
+		"create",
+		"create user",
+		userClient.CreateUserEndpoint,
+		&user.CreateUserRequest{
+			Cfg: &user.Config{
+				Address: user.Address{},
+			},
+		},
+		&user.CreateUserResponse{},
+	))
+
+	userCmd.AddCmd(s.createCommand(
+		"edit",
+		"edit user",
+		userClient.EditUserEndpoint,
+		&user.EditUserRequest{},
+		&user.EditUserResponse{},
+	))
+
+	userCmd.AddCmd(s.createCommand(
+		"delete",
+		"delete user",
+		userClient.DeleteUserEndpoint,
+		&user.DeleteUserRequest{},
+		&user.DeleteUserResponse{},
+	))
+
+	userCmd.AddCmd(s.createCommand(
+		"get",
+		"get user",
+		userClient.GetUserEndpoint,
+		&user.GetUserRequest{},
+		&user.GetUserResponse{},
+	))
+
+	userCmd.AddCmd(s.createCommand(
+		"changeusername",
+		"change username",
+		userClient.ChangeUsernameEndpoint,
+		&user.ChangeUsernameRequest{},
+		&user.ChangeUsernameResponse{},
+	))
+
+	userCmd.AddCmd(s.createCommand(
+		"resetpassword",
+		"reset the password of a user",
+		userClient.ResetPasswordEndpoint,
+		&user.ResetPasswordRequest{},
+		&user.ResetPasswordResponse{},
+	))
+
+	userCmd.AddCmd(s.createCommand(
+		"checkcredentials",
+		"check login credentials",
+		userClient.CheckLoginCredentialsEndpoint,
+		&user.CheckLoginCredentialsRequest{},
+		&user.CheckLoginCredentialsResponse{},
+	))
+
+	return userCmd
+}
+
+func (s *session) routingCommands(sh *ishell.Shell, routingClient *routing.Endpoints) *ishell.Cmd {
+	routingCmd := &ishell.Cmd{
+		Name: "routing",
+		Help: "all routing service commands",
+	}
+
+	ls := &routing.ListenStatement{}
+	log := routing.Log{}
+	ssl := routing.SSLSettings{}
+	loc := &routing.LocationRule{}
+	rc := &routing.RouterConfig{
+		ListenStatement: ls,
+		AccessLog:       log,
+		ErrorLog:        log,
+		SSLSettings:     ssl,
+		LocationRules:   routing.LocationRules{loc},
+	}
+
+	routingCmd.AddCmd(s.createCommand(
+		"createconf",
+		"Create a router config",
+		routingClient.CreateConfigEndpoint,
+		&routing.CreateConfigRequest{
+			Config: rc,
+		},
+		&routing.CreateConfigResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"editconf",
+		"Edit a router config",
+		routingClient.EditConfigEndpoint,
+		&routing.EditConfigRequest{
+			Config: rc,
+		},
+		&routing.EditConfigResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"getconf",
+		"Get a router config",
+		routingClient.GetConfigEndpoint,
+		&routing.GetConfigRequest{},
+		&routing.GetConfigResponse{},
+	))
+
+	removeCmd := &ishell.Cmd{
+		Name: "remove",
+	}
+
+	removeCmd.AddCmd(s.createCommand(
+		"conf",
+		"Remove a router config",
+		routingClient.RemoveConfigEndpoint,
+		&routing.RemoveConfigRequest{},
+		&routing.RemoveConfigResponse{},
+	))
+
+	removeCmd.AddCmd(s.createCommand(
+		"loc",
+		"Remove a location",
+		routingClient.RemoveLocationEndpoint,
+		&routing.RemoveLocationRequest{},
+		&routing.RemoveLocationResponse{},
+	))
+
+	removeCmd.AddCmd(s.createCommand(
+		"sn",
+		"Remove a server name",
+		routingClient.RemoveServerNameEndpoint,
+		&routing.RemoveServerNameRequest{},
+		&routing.RemoveServerNameResponse{},
+	))
+
+	routingCmd.AddCmd(removeCmd)
+
+	addCmd := &ishell.Cmd{
+		Name: "Add",
+	}
+
+	addCmd.AddCmd(s.createCommand(
+		"loc",
+		"Add a location",
+		routingClient.AddLocationEndpoint,
+		&routing.AddLocationRequest{},
+		&routing.AddLocationResponse{},
+	))
+
+	addCmd.AddCmd(s.createCommand(
+		"sn",
+		"Add a server name",
+		routingClient.AddServerNameEndpoint,
+		&routing.AddServerNameRequest{},
+		&routing.AddServerNameResponse{},
+	))
+
+	routingCmd.AddCmd(addCmd)
+
+	routingCmd.AddCmd(s.createCommand(
+		"changels",
+		"Change a listen statement",
+		routingClient.ChangeListenStatementEndpoint,
+		&routing.ChangeListenStatementRequest{},
+		&routing.ChangeListenStatementResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"https",
+		"Set the https redirect and HSTS policy",
+		routingClient.SetHTTPSPolicyEndpoint,
+		&routing.SetHTTPSPolicyRequest{},
+		&routing.SetHTTPSPolicyResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"errorpage",
+		"Set the custom error page for a status code",
+		routingClient.SetErrorPageEndpoint,
+		&routing.SetErrorPageRequest{},
+		&routing.SetErrorPageResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"maintenance",
+		"Put an instance into maintenance mode or out of it",
+		routingClient.SetMaintenanceEndpoint,
+		&routing.SetMaintenanceRequest{},
+		&routing.SetMaintenanceResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"limits",
+		"Set the client max body size and timeouts of a location",
+		routingClient.SetProxyLimitsEndpoint,
+		&routing.SetProxyLimitsRequest{},
+		&routing.SetProxyLimitsResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"switch",
+		"Point a blue/green switch at an upstream",
+		routingClient.SetSwitchEndpoint,
+		&routing.SetSwitchRequest{},
+		&routing.SetSwitchResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"rmswitch",
+		"Remove a blue/green switch",
+		routingClient.RemoveSwitchEndpoint,
+		&routing.RemoveSwitchRequest{},
+		&routing.RemoveSwitchResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"quota",
+		"Get the traffic and the traffic quota of the current month",
+		routingClient.QuotaEndpoint,
+		&routing.QuotaRequest{},
+		&routing.QuotaResponse{},
+	))
+
+	routingCmd.AddCmd(s.createCommand(
+		"all",
+		"Get all configurations",
+		routingClient.ConfigurationsEndpoint,
+		&routing.ConfigurationsRequest{},
+		&routing.ConfigurationsResponse{},
+	))
+
+	return routingCmd
+}
+
+func (s *session) databaseCommands() *ishell.Cmd {
+	databaseCmd := &ishell.Cmd{
+		Name: "database",
+		Help: "manage your MySQL and PostgreSQL databases",
+	}
+
+	databaseCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your databases with their credentials, usage: database list",
+		Func: func(c *ishell.Context) {
+			res, err := s.db.Databases(context.Background(), &databasePB.DatabasesRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, d := range res.Databases {
+				c.Println(d.Name, d.Engine, d.Mode, d.Schema, d.User, d.Password, d.Host, d.Port)
+			}
+		},
+	})
+
+	databaseCmd.AddCmd(&ishell.Cmd{
+		Name: "create",
+		Help: "provision a database, usage: database create <name> <postgres|mysql> [schema|container]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 || len(c.Args) > 3 {
+				s.fail(c, errors.New("usage: database create <name> <postgres|mysql> [schema|container]"))
+				return
+			}
+
+			req := &databasePB.CreateDatabaseRequest{
+				RefID:  s.refID(),
+				Name:   c.Args[0],
+				Engine: c.Args[1],
+			}
+			if len(c.Args) == 3 {
+				req.Mode = c.Args[2]
+			}
+
+			res, err := s.db.CreateDatabase(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("created database", res.Database.Schema, "with user", res.Database.User, "and password", res.Database.Password)
+			}
+		},
+	})
+
+	databaseCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "drop a database, its dumps are kept, usage: database remove <name>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: database remove <name>"))
+				return
+			}
+
+			res, err := s.db.RemoveDatabase(context.Background(), &databasePB.RemoveDatabaseRequest{
+				RefID: s.refID(),
+				Name:  c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	link := func(unlink bool) func(c *ishell.Context) {
+		return func(c *ishell.Context) {
+			if len(c.Args) != 2 {
+				s.fail(c, errors.New("usage: database link|unlink <name> <container>"))
+				return
+			}
+
+			req := &databasePB.LinkDatabaseRequest{
+				RefID:         s.refID(),
+				Name:          c.Args[0],
+				ContainerName: c.Args[1],
+			}
+			call := s.db.LinkDatabase
+			if unlink {
+				call = s.db.UnlinkDatabase
+			}
+
+			res, err := call(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		}
+	}
+
+	databaseCmd.AddCmd(&ishell.Cmd{
+		Name: "link",
+		Help: "inject the connection info of a database into an instance, usage: database link <name> <container>",
+		Func: link(false),
+	})
+
+	databaseCmd.AddCmd(&ishell.Cmd{
+		Name: "unlink",
+		Help: "remove the connection info of a database from an instance, usage: database unlink <name> <container>",
+		Func: link(true),
+	})
+
+	databaseCmd.AddCmd(&ishell.Cmd{
+		Name: "dump",
+		Help: "dump a database in the background, usage: database dump <name>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: database dump <name>"))
+				return
+			}
+
+			res, err := s.db.DumpDatabase(context.Background(), &databasePB.DumpDatabaseRequest{
+				RefID: s.refID(),
+				Name:  c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("dumping in job", res.JobID)
+			}
+		},
+	})
+
+	databaseCmd.AddCmd(&ishell.Cmd{
+		Name: "dumps",
+		Help: "list the dumps of a database, usage: database dumps <name>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: database dumps <name>"))
+				return
+			}
+
+			res, err := s.db.Dumps(context.Background(), &databasePB.DumpsRequest{
+				RefID: s.refID(),
+				Name:  c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, d := range res.Dumps {
+				c.Println(time.Unix(d.CreatedAt, 0).UTC().Format(time.RFC3339), d.File, d.Size)
+			}
+		},
+	})
+
+	return databaseCmd
+}
+
+func (s *session) snapshotCommands() *ishell.Cmd {
+	snapshotCmd := &ishell.Cmd{
+		Name: "snapshot",
+		Help: "schedule snapshots of your volumes and databases",
+	}
+
+	snapshotCmd.AddCmd(&ishell.Cmd{
+		Name: "schedules",
+		Help: "list your snapshot schedules, usage: snapshot schedules",
+		Func: func(c *ishell.Context) {
+			res, err := s.snapshot.Schedules(context.Background(), &snapshotPB.SchedulesRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, sc := range res.Schedules {
+				c.Println(sc.ID, sc.Name, sc.Kind, sc.Source, sc.Cron, sc.Retention, sc.Target)
+			}
+		},
+	})
+
+	snapshotCmd.AddCmd(&ishell.Cmd{
+		Name: "schedule",
+		Help: "schedule snapshots, quote the cron expression, usage: snapshot schedule <name> <volume|database> <source> <cron> [retention] [local|s3]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 4 || len(c.Args) > 6 {
+				s.fail(c, errors.New("usage: snapshot schedule <name> <volume|database> <source> <cron> [retention] [local|s3]"))
+				return
+			}
+
+			req := &snapshotPB.CreateScheduleRequest{
+				RefID:  s.refID(),
+				Name:   c.Args[0],
+				Kind:   c.Args[1],
+				Source: c.Args[2],
+				Cron:   c.Args[3],
+			}
+			if len(c.Args) > 4 {
+				retention, err := strconv.ParseUint(c.Args[4], 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+				req.Retention = uint32(retention)
+			}
+			if len(c.Args) > 5 {
+				req.Target = c.Args[5]
+			}
+
+			res, err := s.snapshot.CreateSchedule(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("created schedule", res.ID)
+			}
+		},
+	})
+
+	snapshotCmd.AddCmd(&ishell.Cmd{
+		Name: "unschedule",
+		Help: "remove a schedule, its snapshots are kept, usage: snapshot unschedule <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: snapshot unschedule <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.snapshot.RemoveSchedule(context.Background(), &snapshotPB.RemoveScheduleRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	snapshotCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your snapshots, latest first, usage: snapshot list [scheduleID]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) > 1 {
+				s.fail(c, errors.New("usage: snapshot list [scheduleID]"))
+				return
+			}
+
+			req := &snapshotPB.SnapshotsRequest{
+				RefID: s.refID(),
+			}
+			if len(c.Args) == 1 {
+				id, err := strconv.ParseUint(c.Args[0], 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+				req.ScheduleID = uint32(id)
+			}
+
+			res, err := s.snapshot.Snapshots(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, sn := range res.Snapshots {
+				c.Println(sn.ID, time.Unix(sn.CreatedAt, 0).UTC().Format(time.RFC3339), sn.Kind, sn.Source, sn.State, sn.Target, sn.Key, sn.Size, sn.Error)
+			}
+		},
+	})
+
+	snapshotCmd.AddCmd(&ishell.Cmd{
+		Name: "take",
+		Help: "take a snapshot of a schedule now, usage: snapshot take <scheduleID>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: snapshot take <scheduleID>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.snapshot.TakeSnapshot(context.Background(), &snapshotPB.TakeSnapshotRequest{
+				RefID:      s.refID(),
+				ScheduleID: uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("taking snapshot in job", res.JobID)
+			}
+		},
+	})
+
+	snapshotCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a snapshot, usage: snapshot remove <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: snapshot remove <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.snapshot.RemoveSnapshot(context.Background(), &snapshotPB.RemoveSnapshotRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	snapshotCmd.AddCmd(&ishell.Cmd{
+		Name: "restore",
+		Help: "restore a volume snapshot into a new volume, usage: snapshot restore <id> <volume>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 2 {
+				s.fail(c, errors.New("usage: snapshot restore <id> <volume>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.snapshot.RestoreSnapshot(context.Background(), &snapshotPB.RestoreSnapshotRequest{
+				RefID:  s.refID(),
+				ID:     uint32(id),
+				Volume: c.Args[1],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("restoring in job", res.JobID)
+			}
+		},
+	})
+
+	return snapshotCmd
+}
+
+// logLines is the number of lines shown by the logs command
+const logLines = 100
+
+func (s *session) searchLogs(c *ishell.Context) {
+	if len(c.Args) < 1 {
+		s.fail(c, errors.New("usage: logs <instance> [words]"))
+		return
+	}
+
+	res, err := s.logs.Search(context.Background(), &containerlogPB.SearchRequest{
+		RefID:    s.refID(),
+		Instance: c.Args[0],
+		Text:     strings.Join(c.Args[1:], " "),
+		Page: paging.EncodePage(paging.Request{
+			Limit: logLines,
+			Sort:  "-id",
+		}),
+	})
+	if err == nil && res.Error != "" {
+		err = errors.New(res.Error)
+	}
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+
+	if s.print(c, res) {
+		return
+	}
+	// the latest lines are returned first
+	for i := len(res.Entries) - 1; i >= 0; i-- {
+		e := res.Entries[i]
+		c.Println(time.Unix(e.Time, 0).UTC().Format(time.RFC3339), e.Stream, e.Line)
+	}
+}
+
+func (s *session) showUsage(c *ishell.Context) {
+	if len(c.Args) < 1 || len(c.Args) > 2 {
+		s.fail(c, errors.New("usage: usage <instance> [duration]"))
+		return
+	}
+
+	req := &usagePB.QueryRequest{
+		RefID:    s.refID(),
+		Instance: c.Args[0],
+	}
+	if len(c.Args) == 2 {
+		d, err := time.ParseDuration(c.Args[1])
+		if err != nil {
+			s.fail(c, err)
+			return
+		}
+		req.From = time.Now().Add(-d).Unix()
+	}
+
+	res, err := s.usage.Query(context.Background(), req)
+	if err == nil && res.Error != "" {
+		err = errors.New(res.Error)
+	}
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+
+	if s.print(c, res) {
+		return
+	}
+	c.Println("resolution", res.Resolution)
+	for _, p := range res.Points {
+		c.Println(time.Unix(p.Time, 0).UTC().Format(time.RFC3339), fmt.Sprintf("%.1f%%", p.Cpu), fmt.Sprintf("%.1f%%", p.CpuMax), p.Memory, p.MemoryMax, p.MemoryLimit)
+	}
+}
+
+func (s *session) showRecommendations(c *ishell.Context) {
+	res, err := s.usage.Recommendations(context.Background(), &usagePB.RecommendationsRequest{
+		RefID: s.refID(),
+	})
+	if err == nil && res.Error != "" {
+		err = errors.New(res.Error)
+	}
+	if err != nil {
+		s.fail(c, err)
+		return
+	}
+
+	if s.print(c, res) {
+		return
+	}
+	for _, r := range res.Recommendations {
+		c.Println(r.Instance, "cpu", r.CpuLimit, "->", r.SuggestedCpu, "memory", r.MemoryLimit, "->", r.SuggestedMemory, "savings", r.Savings)
+	}
+}
+
+func (s *session) cronCommands() *ishell.Cmd {
+	cronCmd := &ishell.Cmd{
+		Name: "cron",
+		Help: "run commands inside your instances on a schedule",
+	}
+
+	cronCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your cron jobs, usage: cron list",
+		Func: func(c *ishell.Context) {
+			res, err := s.cron.Jobs(context.Background(), &cronjobPB.JobsRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, j := range res.Jobs {
+				c.Println(j.ID, j.Name, j.Instance, j.Cron, j.Command, j.Notify)
+			}
+		},
+	})
+
+	cronCmd.AddCmd(&ishell.Cmd{
+		Name: "create",
+		Help: "run a command inside an instance on a schedule, quote the cron expression and the command, add notify to get an email for failed runs, usage: cron create <name> <instance> <cron> <command> [notify]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 4 || len(c.Args) > 5 || (len(c.Args) == 5 && c.Args[4] != "notify") {
+				s.fail(c, errors.New("usage: cron create <name> <instance> <cron> <command> [notify]"))
+				return
+			}
+
+			res, err := s.cron.CreateJob(context.Background(), &cronjobPB.CreateJobRequest{
+				RefID:    s.refID(),
+				Name:     c.Args[0],
+				Instance: c.Args[1],
+				Cron:     c.Args[2],
+				Command:  c.Args[3],
+				Notify:   len(c.Args) == 5,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("created cron job", res.ID)
+			}
+		},
+	})
+
+	cronCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a cron job and its runs, usage: cron remove <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: cron remove <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.cron.RemoveJob(context.Background(), &cronjobPB.RemoveJobRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	cronCmd.AddCmd(&ishell.Cmd{
+		Name: "run",
+		Help: "run a cron job now, usage: cron run <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: cron run <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.cron.RunJob(context.Background(), &cronjobPB.RunJobRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("running in job", res.BackgroundJobID)
+			}
+		},
+	})
+
+	cronCmd.AddCmd(&ishell.Cmd{
+		Name: "runs",
+		Help: "show the last runs of a cron job with their output, latest first, usage: cron runs <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: cron runs <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.cron.Runs(context.Background(), &cronjobPB.RunsRequest{
+				RefID: s.refID(),
+				JobID: uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, r := range res.Runs {
+				c.Println(r.ID, time.Unix(r.CreatedAt, 0).UTC().Format(time.RFC3339), r.State, r.Error)
+				if r.Output != "" {
+					c.Println(strings.TrimRight(r.Output, "\n"))
+				}
+			}
+		},
+	})
+
+	return cronCmd
+}
+
+func (s *session) alertCommands() *ishell.Cmd {
+	alertCmd := &ishell.Cmd{
+		Name: "alert",
+		Help: "get alerted about the resource usage of your instances",
+	}
+
+	alertCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your alert rules, usage: alert list",
+		Func: func(c *ishell.Context) {
+			res, err := s.alert.Rules(context.Background(), &alertPB.RulesRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, r := range res.Rules {
+				c.Println(r.ID, r.Name, r.Instance, r.Metric, r.Threshold, r.For, r.Notify)
+			}
+		},
+	})
+
+	alertCmd.AddCmd(&ishell.Cmd{
+		Name: "create",
+		Help: "alert once a metric of an instance, or of every instance for *, exceeds a threshold for a number of seconds, the metric is cpu, memory, restarts or drops, add notify to get an email, usage: alert create <name> <instance|*> <metric> <threshold> [for] [notify]",
+		Func: func(c *ishell.Context) {
+			args := c.Args
+			notify := len(args) > 0 && args[len(args)-1] == "notify"
+			if notify {
+				args = args[:len(args)-1]
+			}
+			if len(args) < 4 || len(args) > 5 {
+				s.fail(c, errors.New("usage: alert create <name> <instance|*> <metric> <threshold> [for] [notify]"))
+				return
+			}
+
+			threshold, err := strconv.ParseFloat(args[3], 64)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			var duration uint64
+			if len(args) == 5 {
+				duration, err = strconv.ParseUint(args[4], 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+			}
+
+			instance := args[1]
+			if instance == "*" {
+				instance = ""
+			}
+
+			res, err := s.alert.CreateRule(context.Background(), &alertPB.CreateRuleRequest{
+				RefID:     s.refID(),
+				Name:      args[0],
+				Instance:  instance,
+				Metric:    args[2],
+				Threshold: threshold,
+				For:       uint32(duration),
+				Notify:    notify,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("created alert rule", res.ID)
+			}
+		},
+	})
+
+	alertCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove an alert rule, usage: alert remove <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: alert remove <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.alert.RemoveRule(context.Background(), &alertPB.RemoveRuleRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	alertCmd.AddCmd(&ishell.Cmd{
+		Name: "states",
+		Help: "show the pending and firing alerts of your instances, usage: alert states",
+		Func: func(c *ishell.Context) {
+			res, err := s.alert.States(context.Background(), &alertPB.StatesRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, st := range res.States {
+				c.Println(st.RuleID, st.Instance, st.State, st.Value, time.Unix(st.Since, 0).UTC().Format(time.RFC3339))
+			}
+		},
+	})
+
+	alertCmd.AddCmd(&ishell.Cmd{
+		Name: "history",
+		Help: "show the alerts which fired or were resolved, latest first, usage: alert history",
+		Func: func(c *ishell.Context) {
+			res, err := s.alert.Alerts(context.Background(), &alertPB.AlertsRequest{
+				RefID: s.refID(),
+				Page: paging.EncodePage(paging.Request{
+					Sort: "-id",
+				}),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, a := range res.Alerts {
+				c.Println(time.Unix(a.CreatedAt, 0).UTC().Format(time.RFC3339), a.State, a.Rule, a.Instance, a.Metric, a.Value, a.Threshold)
+			}
+		},
+	})
+
+	return alertCmd
+}
+
+func (s *session) autoscaleCommands() *ishell.Cmd {
+	autoscaleCmd := &ishell.Cmd{
+		Name: "autoscale",
+		Help: "scale the replicas of your instances by their usage",
+	}
+
+	autoscaleCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your autoscaling policies, usage: autoscale list",
+		Func: func(c *ishell.Context) {
+			res, err := s.scale.Policies(context.Background(), &autoscalePB.PoliciesRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, p := range res.Policies {
+				c.Println(p.Instance, p.MinReplicas, p.MaxReplicas, "cpu", p.Cpu, "requests", p.RequestRate, "cooldowns", p.ScaleUpCooldown, p.ScaleDownCooldown)
+			}
+		},
+	})
+
+	autoscaleCmd.AddCmd(&ishell.Cmd{
+		Name: "set",
+		Help: "scale the replicas of an instance between min and max so it and each replica use cpu percent of a core or get requests per second, without targets the replicas are only kept within the bounds and the windows, up and down are the seconds to wait after a scaling, usage: autoscale set <instance> <min> <max> [cpu=<percent>] [requests=<rate>] [up=<seconds>] [down=<seconds>]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 4 {
+				s.fail(c, errors.New("usage: autoscale set <instance> <min> <max> [cpu=<percent>] [requests=<rate>] [up=<seconds>] [down=<seconds>]"))
+				return
+			}
+
+			bounds := [2]uint64{}
+			for i := range bounds {
+				n, err := strconv.ParseUint(c.Args[i+1], 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+				bounds[i] = n
+			}
+
+			req := &autoscalePB.SetPolicyRequest{
+				RefID:       s.refID(),
+				Instance:    c.Args[0],
+				MinReplicas: uint32(bounds[0]),
+				MaxReplicas: uint32(bounds[1]),
+			}
+			for _, arg := range c.Args[3:] {
+				kv := strings.SplitN(arg, "=", 2)
+				if len(kv) != 2 {
+					s.fail(c, fmt.Errorf("argument %s is not of the form key=value", arg))
+					return
+				}
+
+				var err error
+				switch kv[0] {
+				case "cpu":
+					req.Cpu, err = strconv.ParseFloat(kv[1], 64)
+				case "requests":
+					req.RequestRate, err = strconv.ParseFloat(kv[1], 64)
+				case "up", "down":
+					var n uint64
+					n, err = strconv.ParseUint(kv[1], 10, 32)
+					if kv[0] == "up" {
+						req.ScaleUpCooldown = uint32(n)
+					} else {
+						req.ScaleDownCooldown = uint32(n)
+					}
+				default:
+					err = fmt.Errorf("unknown argument %s", kv[0])
+				}
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+			}
+
+			res, err := s.scale.SetPolicy(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("set autoscaling policy", res.ID)
+			}
+		},
+	})
+
+	autoscaleCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "stop scaling an instance, it keeps its replicas, usage: autoscale remove <instance>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: autoscale remove <instance>"))
+				return
+			}
+
+			res, err := s.scale.RemovePolicy(context.Background(), &autoscalePB.RemovePolicyRequest{
+				RefID:    s.refID(),
+				Instance: c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	windowCmd := &ishell.Cmd{
+		Name: "window",
+		Help: "keep a minimum of replicas of your instances during recurring times",
+	}
+
+	windowCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list the windows of your autoscaling policies, usage: autoscale window list",
+		Func: func(c *ishell.Context) {
+			res, err := s.scale.Windows(context.Background(), &autoscalePB.WindowsRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, w := range res.Windows {
+				zone := w.Timezone
+				if zone == "" {
+					zone = "UTC"
+				}
+				c.Println(w.ID, w.Instance, w.Replicas, w.Days, w.Start+"-"+w.End, zone)
+			}
+		},
+	})
+
+	windowCmd.AddCmd(&ishell.Cmd{
+		Name: "create",
+		Help: "keep at least replicas replicas of an instance with an autoscaling policy from start to end on the days of a cron expression, e.g. 4 1-5 09:00 18:00 Europe/Berlin, usage: autoscale window create <instance> <replicas> <days> <start> <end> [timezone]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 5 && len(c.Args) != 6 {
+				s.fail(c, errors.New("usage: autoscale window create <instance> <replicas> <days> <start> <end> [timezone]"))
+				return
+			}
+
+			replicas, err := strconv.ParseUint(c.Args[1], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			req := &autoscalePB.CreateWindowRequest{
+				RefID:    s.refID(),
+				Instance: c.Args[0],
+				Replicas: uint32(replicas),
+				Days:     c.Args[2],
+				Start:    c.Args[3],
+				End:      c.Args[4],
+			}
+			if len(c.Args) == 6 {
+				req.Timezone = c.Args[5]
+			}
+
+			res, err := s.scale.CreateWindow(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("created window", res.ID)
+			}
+		},
+	})
+
+	windowCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a window of an autoscaling policy, usage: autoscale window remove <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: autoscale window remove <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.scale.RemoveWindow(context.Background(), &autoscalePB.RemoveWindowRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	autoscaleCmd.AddCmd(windowCmd)
+
+	autoscaleCmd.AddCmd(&ishell.Cmd{
+		Name: "history",
+		Help: "show the scalings of your instances, latest first, usage: autoscale history",
+		Func: func(c *ishell.Context) {
+			res, err := s.scale.Events(context.Background(), &autoscalePB.EventsRequest{
+				RefID: s.refID(),
+				Page: paging.EncodePage(paging.Request{
+					Sort: "-id",
+				}),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, e := range res.Events {
+				c.Println(time.Unix(e.CreatedAt, 0).UTC().Format(time.RFC3339), e.Instance, e.From, "->", e.To, e.Reason, e.Value, e.Error)
+			}
+		},
+	})
+
+	return autoscaleCmd
+}
+
+func (s *session) banCommands() *ishell.Cmd {
+	banCmd := &ishell.Cmd{
+		Name: "ban",
+		Help: "ban the sources which abuse your instances for a while",
+	}
+
+	banCmd.AddCmd(&ishell.Cmd{
+		Name: "jails",
+		Help: "list your jails, usage: ban jails",
+		Func: func(c *ishell.Context) {
+			res, err := s.ban.Jails(context.Background(), &banPB.JailsRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, j := range res.Jails {
+				c.Println(j.ID, j.Name, j.Source, j.Target, j.Filter, j.MaxRetry, j.FindTime, j.BanTime)
+			}
+		},
+	})
+
+	banCmd.AddCmd(&ishell.Cmd{
+		Name: "create",
+		Help: "ban the sources which match a filter maxretry times within findtime seconds in the log of an instance (container) or routing configuration (access) for bantime seconds, the filter is auth, notfound, sshd or a regular expression containing <HOST>, usage: ban create <name> <container|access> <target> <filter> [maxretry] [findtime] [bantime]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 4 || len(c.Args) > 7 {
+				s.fail(c, errors.New("usage: ban create <name> <container|access> <target> <filter> [maxretry] [findtime] [bantime]"))
+				return
+			}
+
+			limits := make([]uint32, 3)
+			for i, arg := range c.Args[4:] {
+				n, err := strconv.ParseUint(arg, 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+				limits[i] = uint32(n)
+			}
+
+			res, err := s.ban.CreateJail(context.Background(), &banPB.CreateJailRequest{
+				RefID:    s.refID(),
+				Name:     c.Args[0],
+				Source:   c.Args[1],
+				Target:   c.Args[2],
+				Filter:   c.Args[3],
+				MaxRetry: limits[0],
+				FindTime: limits[1],
+				BanTime:  limits[2],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("created jail", res.ID)
+			}
+		},
+	})
+
+	banCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a jail and lift its bans, usage: ban remove <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: ban remove <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.ban.RemoveJail(context.Background(), &banPB.RemoveJailRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	banCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "show the sources your jails banned, latest first, usage: ban list",
+		Func: func(c *ishell.Context) {
+			res, err := s.ban.Bans(context.Background(), &banPB.BansRequest{
+				RefID: s.refID(),
+				Page: paging.EncodePage(paging.Request{
+					Sort: "-id",
+				}),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, b := range res.Bans {
+				c.Println(b.ID, b.Jail, b.Address, b.Destination, b.Port, b.Node, time.Unix(b.Until, 0).UTC().Format(time.RFC3339))
+			}
+		},
+	})
+
+	banCmd.AddCmd(&ishell.Cmd{
+		Name: "lift",
+		Help: "lift a ban before it expires, usage: ban lift <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: ban lift <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.ban.Unban(context.Background(), &banPB.UnbanRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	return banCmd
+}
+
+func (s *session) vpnCommands() *ishell.Cmd {
+	vpnCmd := &ishell.Cmd{
+		Name: "vpn",
+		Help: "connect your devices to the private network of your instances with WireGuard",
+	}
+
+	vpnCmd.AddCmd(&ishell.Cmd{
+		Name: "peers",
+		Help: "list your peers, usage: vpn peers",
+		Func: func(c *ishell.Context) {
+			res, err := s.vpn.Peers(context.Background(), &wireguardPB.PeersRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, p := range res.Peers {
+				c.Println(p.ID, p.Name, p.Address, p.Node, p.Status, strings.Join(p.Rules, ","), time.Unix(p.KeyCreatedAt, 0).UTC().Format(time.RFC3339))
+			}
+		},
+	})
+
+	vpnCmd.AddCmd(&ishell.Cmd{
+		Name: "create",
+		Help: "create a peer and save its WireGuard configuration to a file, the rules are the instances it may reach (* for all, the default), usage: vpn create <name> <file> [rule...]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 {
+				s.fail(c, errors.New("usage: vpn create <name> <file> [rule...]"))
+				return
+			}
+
+			res, err := s.vpn.CreatePeer(context.Background(), &wireguardPB.CreatePeerRequest{
+				RefID: s.refID(),
+				Name:  c.Args[0],
+				Rules: c.Args[2:],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err == nil {
+				err = ioutil.WriteFile(c.Args[1], []byte(res.Config), 0600)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			c.Println("created peer", res.ID)
+		},
+	})
+
+	vpnCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a peer, usage: vpn remove <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: vpn remove <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.vpn.RemovePeer(context.Background(), &wireguardPB.RemovePeerRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	vpnCmd.AddCmd(&ishell.Cmd{
+		Name: "rotate",
+		Help: "replace the key of a peer and save its new configuration to a file, usage: vpn rotate <id> <file>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 2 {
+				s.fail(c, errors.New("usage: vpn rotate <id> <file>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.vpn.RotateKey(context.Background(), &wireguardPB.RotateKeyRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err == nil {
+				err = ioutil.WriteFile(c.Args[1], []byte(res.Config), 0600)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	vpnCmd.AddCmd(&ishell.Cmd{
+		Name: "rules",
+		Help: "set the instances a peer may reach, * for all, usage: vpn rules <id> <rule...>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 {
+				s.fail(c, errors.New("usage: vpn rules <id> <rule...>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.vpn.SetRules(context.Background(), &wireguardPB.SetRulesRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+				Rules: c.Args[1:],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
+
+	return vpnCmd
+}
+
+func (s *session) internalDNSCommands() *ishell.Cmd {
+	dnsCmd := &ishell.Cmd{
+		Name: "internal-dns",
+		Help: "inspect the names your instances resolve each other by in your private network",
+	}
+
+	dnsCmd.AddCmd(&ishell.Cmd{
+		Name: "records",
+		Help: "list the names of your instances, usage: internal-dns records",
+		Func: func(c *ishell.Context) {
+			res, err := s.resolver.Records(context.Background(), &resolverPB.RecordsRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, r := range res.Records {
+				c.Println(r.Name, r.ContainerID)
+			}
+		},
+	})
+
+	dnsCmd.AddCmd(&ishell.Cmd{
+		Name: "lookup",
+		Help: "resolve a name like your containers do, usage: internal-dns lookup <name>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: internal-dns lookup <name>"))
+				return
+			}
+
+			res, err := s.resolver.Lookup(context.Background(), &resolverPB.LookupRequest{
+				RefID: s.refID(),
+				Name:  c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			addresses := strings.Join(res.Addresses, ",")
+			if addresses == "" {
+				addresses = "no address, the container is not attached to the network"
+			}
+			c.Println(res.Name, res.ContainerID, addresses)
+		},
+	})
+
+	return dnsCmd
+}
+
+// siteSettings parses the domains, tls and max-age=<seconds> arguments of the site commands
+func siteSettings(args []string) (domains []string, maxAge uint32, tls bool, err error) {
+	for _, a := range args {
+		switch {
+		case a == "tls":
+			tls = true
+		case strings.HasPrefix(a, "max-age="):
+			age, err := strconv.ParseUint(strings.TrimPrefix(a, "max-age="), 10, 32)
+			if err != nil {
+				return nil, 0, false, err
+			}
+			maxAge = uint32(age)
+		default:
+			domains = append(domains, a)
+		}
+	}
+	return domains, maxAge, tls, nil
+}
+
+// siteBundle returns the content of an archive or a gzip compressed tar archive of the files of a directory
+func siteBundle(p string) ([]byte, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return ioutil.ReadFile(p)
+	}
+
+	b := &bytes.Buffer{}
+	gz := gzip.NewWriter(b)
+	tw := tar.NewWriter(gz)
+	err = filepath.Walk(p, func(file string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		name, err := filepath.Rel(p, file)
+		if err != nil {
+			return err
+		}
+		h, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		h.Name = filepath.ToSlash(name)
+
+		err = tw.WriteHeader(h)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(content)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = gz.Close()
+	if err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (s *session) printSite(c *ishell.Context, site *sitePB.Site) {
+	if site == nil {
+		return
+	}
+
+	deployed := "never deployed"
+	if site.Version != 0 {
+		deployed = fmt.Sprintf("version %d, %d bytes, deployed %s", site.Version, site.Size, time.Unix(site.DeployedAt, 0).Format(time.RFC3339))
+	}
+	scheme := "http"
+	if site.Tls {
+		scheme = "https"
+	}
+	c.Println(site.Name, scheme, strings.Join(site.Domains, ","), fmt.Sprintf("max-age=%d", site.MaxAge), deployed)
+}
+
+func (s *session) siteCommands() *ishell.Cmd {
+	siteCmd := &ishell.Cmd{
+		Name: "sites",
+		Help: "host static sites without a container",
+	}
+
+	siteCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your sites, usage: sites list",
+		Func: func(c *ishell.Context) {
+			res, err := s.site.Sites(context.Background(), &sitePB.SitesRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, site := range res.Sites {
+				s.printSite(c, site)
+			}
+		},
+	})
 
-	containerCmd.AddCmd(createCommand(
-		"stop",
-		"stop a container",
-		containerClient.StopContainerEndpoint,
-		&container.StopContainerRequest{},
-		&container.StopContainerResponse{},
-	))
+	siteCmd.AddCmd(&ishell.Cmd{
+		Name: "create",
+		Help: "create a site served under its domains, over https with tls, usage: sites create <name> <domain>... [tls] [max-age=<seconds>]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 {
+				s.fail(c, errors.New("usage: sites create <name> <domain>... [tls] [max-age=<seconds>]"))
+				return
+			}
 
-	containerCmd.AddCmd(createCommand(
-		"instances",
-		"all container instances",
-		containerClient.InstancesEndpoint,
-		&container.InstancesRequest{},
-		&container.InstancesResponse{},
-	))
+			domains, maxAge, tls, err := siteSettings(c.Args[1:])
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	containerCmd.AddCmd(createCommand(
-		"id",
-		"get id for container name",
-		containerClient.IDForNameEndpoint,
-		&container.IDForNameRequest{},
-		&container.IDForNameResponse{},
-	))
+			res, err := s.site.CreateSite(context.Background(), &sitePB.CreateSiteRequest{
+				RefID:   s.refID(),
+				Name:    c.Args[0],
+				Domains: domains,
+				MaxAge:  maxAge,
+				Tls:     tls,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	containerCmd.AddCmd(createCommand(
-		"kmi",
-		"get kmi of a container",
-		containerClient.GetContainerKMIEndpoint,
-		&container.GetContainerKMIRequest{},
-		&container.GetContainerKMIResponse{},
-	))
+			if !s.print(c, res) {
+				s.printSite(c, res.Site)
+			}
+		},
+	})
 
-	linkCmd := &ishell.Cmd{
-		Name: "link",
+	siteCmd.AddCmd(&ishell.Cmd{
+		Name: "edit",
+		Help: "replace the domains, https and the max age of a site, usage: sites edit <name> <domain>... [tls] [max-age=<seconds>]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 {
+				s.fail(c, errors.New("usage: sites edit <name> <domain>... [tls] [max-age=<seconds>]"))
+				return
+			}
+
+			domains, maxAge, tls, err := siteSettings(c.Args[1:])
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.site.EditSite(context.Background(), &sitePB.EditSiteRequest{
+				RefID:   s.refID(),
+				Name:    c.Args[0],
+				Domains: domains,
+				MaxAge:  maxAge,
+				Tls:     tls,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				s.printSite(c, res.Site)
+			}
+		},
+	})
+
+	siteCmd.AddCmd(&ishell.Cmd{
+		Name: "deploy",
+		Help: "deploy the files of a directory or a .tar.gz archive to a site, usage: sites deploy <name> <directory|archive>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 2 {
+				s.fail(c, errors.New("usage: sites deploy <name> <directory|archive>"))
+				return
+			}
+
+			bundle, err := siteBundle(c.Args[1])
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.site.Deploy(context.Background(), &sitePB.DeployRequest{
+				RefID:  s.refID(),
+				Name:   c.Args[0],
+				Bundle: bundle,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("deployed version", res.Version)
+			}
+		},
+	})
+
+	siteCmd.AddCmd(&ishell.Cmd{
+		Name: "remove",
+		Help: "remove a site with its files and certificate, usage: sites remove <name>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: sites remove <name>"))
+				return
+			}
+
+			res, err := s.site.RemoveSite(context.Background(), &sitePB.RemoveSiteRequest{
+				RefID: s.refID(),
+				Name:  c.Args[0],
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("removed site", c.Args[0])
+			}
+		},
+	})
+
+	return siteCmd
+}
+
+func (s *session) deploymentCommands() *ishell.Cmd {
+	deploymentCmd := &ishell.Cmd{
+		Name: "deployments",
+		Help: "inspect the deployments of git pushes to your instances, push with git push <instance>@<host>:<instance>.git",
 	}
 
-	linkCmd.AddCmd(createCommand(
-		"set",
-		"set a link",
-		containerClient.SetLinkEndpoint,
-		&container.SetLinkRequest{},
-		&container.SetLinkResponse{},
-	))
+	deploymentCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your deployments, the latest first, usage: deployments list [instance]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) > 1 {
+				s.fail(c, errors.New("usage: deployments list [instance]"))
+				return
+			}
 
-	linkCmd.AddCmd(createCommand(
-		"remove",
-		"remove a link",
-		containerClient.RemoveLinkEndpoint,
-		&container.RemoveLinkRequest{},
-		&container.RemoveLinkResponse{},
-	))
+			req := &deployPB.DeploymentsRequest{
+				RefID: s.refID(),
+			}
+			if len(c.Args) == 1 {
+				req.Instance = c.Args[0]
+			}
+
+			res, err := s.deploy.Deployments(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, d := range res.Deployments {
+				trigger := fmt.Sprintf("push=%d", d.Deployer)
+				switch {
+				case d.Rollback != 0:
+					trigger = fmt.Sprintf("rollback=%d", d.Rollback)
+				case d.Hook != 0:
+					trigger = fmt.Sprintf("hook=%d", d.Hook)
+				}
+				c.Println(d.ID, time.Unix(d.CreatedAt, 0).UTC().Format(time.RFC3339), d.Instance, d.Commit, trigger, d.Builder, d.State, d.Digest, d.Config, d.Error)
+			}
+		},
+	})
+
+	deploymentCmd.AddCmd(&ishell.Cmd{
+		Name: "log",
+		Help: "show the build log of a deployment, usage: deployments log <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: deployments log <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.deploy.Log(context.Background(), &deployPB.LogRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Print(string(res.Log))
+			}
+		},
+	})
+
+	deploymentCmd.AddCmd(&ishell.Cmd{
+		Name: "rollback",
+		Help: "deploy the image and the environment of a succeeded deployment of an instance again, usage: deployments rollback <instance> <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 2 {
+				s.fail(c, errors.New("usage: deployments rollback <instance> <id>"))
+				return
+			}
+
+			id, err := strconv.ParseUint(c.Args[1], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			res, err := s.deploy.Rollback(context.Background(), &deployPB.RollbackRequest{
+				RefID:    s.refID(),
+				Instance: c.Args[0],
+				ID:       uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("rolling back", res.Deployment.Instance, "to deployment", id, "with deployment", res.Deployment.ID)
+			}
+		},
+	})
+
+	deploymentCmd.AddCmd(&ishell.Cmd{
+		Name: "retention",
+		Help: "show or set how many images are kept per instance and for how many days superseded ones are kept (0 until they exceed keep), usage: deployments retention [keep] [max age]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) > 2 {
+				s.fail(c, errors.New("usage: deployments retention [keep] [max age]"))
+				return
+			}
+
+			if len(c.Args) == 0 {
+				res, err := s.deploy.GetRetention(context.Background(), &deployPB.GetRetentionRequest{
+					RefID: s.refID(),
+				})
+				if err == nil && res.Error != "" {
+					err = errors.New(res.Error)
+				}
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+
+				if !s.print(c, res) {
+					c.Println("keep", res.Retention.Keep, "max age", res.Retention.MaxAge)
+				}
+				return
+			}
+
+			retention := &deployPB.Retention{}
+			for i, arg := range c.Args {
+				n, err := strconv.ParseUint(arg, 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+				if i == 0 {
+					retention.Keep = uint32(n)
+				} else {
+					retention.MaxAge = uint32(n)
+				}
+			}
+
+			res, err := s.deploy.SetRetention(context.Background(), &deployPB.SetRetentionRequest{
+				RefID:     s.refID(),
+				Retention: retention,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("keeping", retention.Keep, "images per instance")
+			}
+		},
+	})
+
+	deploymentCmd.AddCmd(&ishell.Cmd{
+		Name: "prune",
+		Help: "remove the images exceeding your retention, dry-run only lists them, usage: deployments prune [dry-run]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) > 1 || (len(c.Args) == 1 && c.Args[0] != "dry-run") {
+				s.fail(c, errors.New("usage: deployments prune [dry-run]"))
+				return
+			}
+
+			res, err := s.deploy.PruneImages(context.Background(), &deployPB.PruneImagesRequest{
+				RefID:  s.refID(),
+				DryRun: len(c.Args) == 1,
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			verb := "removed"
+			if len(c.Args) == 1 {
+				verb = "would remove"
+			}
+			for _, d := range res.Deployments {
+				c.Println(verb, "the image of deployment", d.ID, "of", d.Instance, d.Digest)
+			}
+		},
+	})
+
+	deploymentCmd.AddCmd(&ishell.Cmd{
+		Name: "hooks",
+		Help: "list your hooks deploying instances from GitHub or GitLab, usage: deployments hooks",
+		Func: func(c *ishell.Context) {
+			res, err := s.deploy.Hooks(context.Background(), &deployPB.HooksRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, h := range res.Hooks {
+				state := "enabled"
+				if !h.Enabled {
+					state = "disabled"
+				}
+				c.Println(h.ID, h.Instance, h.Provider, h.Repository, h.Branch, state)
+			}
+		},
+	})
+
+	deploymentCmd.AddCmd(&ishell.Cmd{
+		Name: "hook",
+		Help: "deploy an instance from the pushes to a branch of a repository, usage: deployments hook <instance> <github|gitlab> <repository url> [branch]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 3 || len(c.Args) > 4 {
+				s.fail(c, errors.New("usage: deployments hook <instance> <github|gitlab> <repository url> [branch]"))
+				return
+			}
+
+			req := &deployPB.CreateHookRequest{
+				RefID:      s.refID(),
+				Instance:   c.Args[0],
+				Provider:   c.Args[1],
+				Repository: c.Args[2],
+			}
+			if len(c.Args) == 4 {
+				req.Branch = c.Args[3]
+			}
+
+			res, err := s.deploy.CreateHook(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			c.Println("created hook", res.Hook.ID, "deploying", res.Hook.Branch, "to", res.Hook.Instance)
+			c.Printf("add a webhook for push events to %s with the url https://<gateway>%s%d and the secret %s\n", res.Hook.Repository, deploy.HookPath, res.Hook.ID, res.Hook.Secret)
+		},
+	})
+
+	for _, enabled := range []bool{true, false} {
+		enabled := enabled
+		name := "enable"
+		if !enabled {
+			name = "disable"
+		}
+
+		deploymentCmd.AddCmd(&ishell.Cmd{
+			Name: name,
+			Help: fmt.Sprintf("%s the deployments of a hook, usage: deployments %s <hook>", name, name),
+			Func: func(c *ishell.Context) {
+				if len(c.Args) != 1 {
+					s.fail(c, fmt.Errorf("usage: deployments %s <hook>", name))
+					return
+				}
+
+				id, err := strconv.ParseUint(c.Args[0], 10, 32)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+
+				res, err := s.deploy.EditHook(context.Background(), &deployPB.EditHookRequest{
+					RefID:   s.refID(),
+					ID:      uint32(id),
+					Enabled: enabled,
+				})
+				if err == nil && res.Error != "" {
+					err = errors.New(res.Error)
+				}
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+
+				if !s.print(c, res) {
+					c.Printf("%sd hook %d\n", name, id)
+				}
+			},
+		})
+	}
+
+	deploymentCmd.AddCmd(&ishell.Cmd{
+		Name: "rmhook",
+		Help: "remove a hook, its deployments are kept, usage: deployments rmhook <hook>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: deployments rmhook <hook>"))
+				return
+			}
 
-	linkCmd.AddCmd(createCommand(
-		"get",
-		"get links of a module",
-		containerClient.GetContainerKMIEndpoint,
-		&container.RemoveLinkRequest{},
-		&container.RemoveLinkResponse{},
-	))
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	containerCmd.AddCmd(linkCmd)
+			res, err := s.deploy.RemoveHook(context.Background(), &deployPB.RemoveHookRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	return containerCmd
+			if !s.print(c, res) {
+				c.Println("removed hook", id)
+			}
+		},
+	})
+
+	return deploymentCmd
 }
 
-func userCommands(sh *ishell.Shell, userClient *user.Endpoints) *ishell.Cmd {
-	userCmd := &ishell.Cmd{
-		Name: "user",
-		Help: "all User Service commands",
+func (s *session) invoiceCommands() *ishell.Cmd {
+	invoiceCmd := &ishell.Cmd{
+		Name: "invoice",
+		Help: "list and download your invoices",
 	}
 
-	userCmd.AddCmd(createCommand(
-		"create",
-		"create user",
-		userClient.CreateUserEndpoint,
-		&user.CreateUserRequest{
-			Cfg: &user.Config{
-				Address: user.Address{},
-			},
+	invoiceCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your invoices and credit notes, usage: invoice list",
+		Func: func(c *ishell.Context) {
+			res, err := s.billing.Invoices(context.Background(), &billingPB.InvoicesRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if s.print(c, res) {
+				return
+			}
+			for _, i := range res.Invoices {
+				c.Println(i.ID, i.Number, time.Unix(i.CreatedAt, 0).UTC().Format("2006-01-02"), i.Kind, fmt.Sprintf("%d.%02d", i.Total/100, i.Total%100), strings.ToUpper(i.Currency))
+			}
 		},
-		&user.CreateUserResponse{},
-	))
+	})
 
-	userCmd.AddCmd(createCommand(
-		"edit",
-		"edit user",
-		userClient.EditUserEndpoint,
-		&user.EditUserRequest{},
-		&user.EditUserResponse{},
-	))
+	invoiceCmd.AddCmd(&ishell.Cmd{
+		Name: "download",
+		Help: "save an invoice to a file, usage: invoice download <id> <file> [pdf|json]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 2 || len(c.Args) > 3 {
+				s.fail(c, errors.New("usage: invoice download <id> <file> [pdf|json]"))
+				return
+			}
 
-	userCmd.AddCmd(createCommand(
-		"delete",
-		"delete user",
-		userClient.DeleteUserEndpoint,
-		&user.DeleteUserRequest{},
-		&user.DeleteUserResponse{},
-	))
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	userCmd.AddCmd(createCommand(
-		"get",
-		"get user",
-		userClient.GetUserEndpoint,
-		&user.GetUserRequest{},
-		&user.GetUserResponse{},
-	))
+			req := &billingPB.DocumentRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			}
+			if len(c.Args) > 2 {
+				req.Format = c.Args[2]
+			}
 
-	userCmd.AddCmd(createCommand(
-		"changeusername",
-		"change username",
-		userClient.ChangeUsernameEndpoint,
-		&user.ChangeUsernameRequest{},
-		&user.ChangeUsernameResponse{},
-	))
+			res, err := s.billing.Document(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err == nil {
+				err = ioutil.WriteFile(c.Args[1], res.Content, 0600)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
 
-	userCmd.AddCmd(createCommand(
-		"checkcredentials",
-		"check login credentials",
-		userClient.CheckLoginCredentialsEndpoint,
-		&user.CheckLoginCredentialsRequest{},
-		&user.CheckLoginCredentialsResponse{},
-	))
+	invoiceCmd.AddCmd(&ishell.Cmd{
+		Name: "link",
+		Help: "print a signed URL an invoice can be downloaded from until it expires, usage: invoice link <id> [pdf|json]",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 1 || len(c.Args) > 2 {
+				s.fail(c, errors.New("usage: invoice link <id> [pdf|json]"))
+				return
+			}
 
-	return userCmd
-}
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-func routingCommands(sh *ishell.Shell, routingClient *routing.Endpoints) *ishell.Cmd {
-	routingCmd := &ishell.Cmd{
-		Name: "routing",
-		Help: "all routing service commands",
-	}
+			req := &billingPB.DocumentRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			}
+			if len(c.Args) > 1 {
+				req.Format = c.Args[1]
+			}
 
-	ls := &routing.ListenStatement{}
-	log := routing.Log{}
-	ssl := routing.SSLSettings{}
-	loc := &routing.LocationRule{}
-	rc := &routing.RouterConfig{
-		ListenStatement: ls,
-		AccessLog:       log,
-		ErrorLog:        log,
-		SSLSettings:     ssl,
-		LocationRules:   routing.LocationRules{loc},
-	}
+			res, err := s.billing.DocumentURL(context.Background(), req)
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	routingCmd.AddCmd(createCommand(
-		"createconf",
-		"Create a router config",
-		routingClient.CreateConfigEndpoint,
-		&routing.CreateConfigRequest{
-			Config: rc,
+			if !s.print(c, res) {
+				c.Println(res.Url)
+				c.Println("expires", time.Unix(res.ExpiresAt, 0).UTC().Format("2006-01-02 15:04"))
+			}
 		},
-		&routing.CreateConfigResponse{},
-	))
+	})
 
-	routingCmd.AddCmd(createCommand(
-		"editconf",
-		"Edit a router config",
-		routingClient.EditConfigEndpoint,
-		&routing.EditConfigRequest{
-			Config: rc,
+	invoiceCmd.AddCmd(&ishell.Cmd{
+		Name: "credit",
+		Help: "correct an invoice of a user with a credit note in cents, only admins may do this, usage: invoice credit <user> <invoice> <amount|all> <reason>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) < 4 {
+				s.fail(c, errors.New("usage: invoice credit <user> <invoice> <amount|all> <reason>"))
+				return
+			}
+
+			refID, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			invoiceID, err := strconv.ParseUint(c.Args[1], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			var amount uint64
+			if c.Args[2] != "all" {
+				amount, err = strconv.ParseUint(c.Args[2], 10, 64)
+				if err != nil {
+					s.fail(c, err)
+					return
+				}
+			}
+
+			res, err := s.billing.CreateCreditNote(context.Background(), &billingPB.CreateCreditNoteRequest{
+				RefID:     uint32(refID),
+				InvoiceID: uint32(invoiceID),
+				Amount:    amount,
+				Reason:    strings.Join(c.Args[3:], " "),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
+
+			if !s.print(c, res) {
+				c.Println("created credit note", res.CreditNote.Number)
+			}
 		},
-		&routing.EditConfigResponse{},
-	))
+	})
 
-	routingCmd.AddCmd(createCommand(
-		"getconf",
-		"Get a router config",
-		routingClient.GetConfigEndpoint,
-		&routing.GetConfigRequest{},
-		&routing.GetConfigResponse{},
-	))
+	return invoiceCmd
+}
 
-	removeCmd := &ishell.Cmd{
-		Name: "remove",
+func (s *session) exportCommands() *ishell.Cmd {
+	exportCmd := &ishell.Cmd{
+		Name: "export",
+		Help: "export your personal data into an archive",
 	}
 
-	removeCmd.AddCmd(createCommand(
-		"conf",
-		"Remove a router config",
-		routingClient.RemoveConfigEndpoint,
-		&routing.RemoveConfigRequest{},
-		&routing.RemoveConfigResponse{},
-	))
+	exportCmd.AddCmd(&ishell.Cmd{
+		Name: "request",
+		Help: "export your personal data in the background, you are notified once it is done, usage: export request",
+		Func: func(c *ishell.Context) {
+			res, err := s.export.ExportUserData(context.Background(), &exportPB.ExportUserDataRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	removeCmd.AddCmd(createCommand(
-		"loc",
-		"Remove a location",
-		routingClient.RemoveLocationEndpoint,
-		&routing.RemoveLocationRequest{},
-		&routing.RemoveLocationResponse{},
-	))
+			if !s.print(c, res) {
+				c.Println("exporting your data in export", res.Export.ID)
+			}
+		},
+	})
 
-	removeCmd.AddCmd(createCommand(
-		"sn",
-		"Remove a server name",
-		routingClient.RemoveServerNameEndpoint,
-		&routing.RemoveServerNameRequest{},
-		&routing.RemoveServerNameResponse{},
-	))
+	exportCmd.AddCmd(&ishell.Cmd{
+		Name: "list",
+		Help: "list your data exports, latest first, usage: export list",
+		Func: func(c *ishell.Context) {
+			res, err := s.export.Exports(context.Background(), &exportPB.ExportsRequest{
+				RefID: s.refID(),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	routingCmd.AddCmd(removeCmd)
+			if s.print(c, res) {
+				return
+			}
+			for _, e := range res.Exports {
+				created := time.Unix(e.CreatedAt, 0).UTC().Format("2006-01-02 15:04")
+				switch {
+				case e.Error != "":
+					c.Println(e.ID, created, e.State, e.Error)
+				case e.ExpiresAt != 0:
+					c.Println(e.ID, created, e.State, e.Size, "expires", time.Unix(e.ExpiresAt, 0).UTC().Format("2006-01-02 15:04"))
+				default:
+					c.Println(e.ID, created, e.State)
+				}
+			}
+		},
+	})
 
-	addCmd := &ishell.Cmd{
-		Name: "Add",
-	}
+	exportCmd.AddCmd(&ishell.Cmd{
+		Name: "download",
+		Help: "save the archive of a data export to a file, usage: export download <id> <file>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 2 {
+				s.fail(c, errors.New("usage: export download <id> <file>"))
+				return
+			}
 
-	addCmd.AddCmd(createCommand(
-		"loc",
-		"Add a location",
-		routingClient.AddLocationEndpoint,
-		&routing.AddLocationRequest{},
-		&routing.AddLocationResponse{},
-	))
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	addCmd.AddCmd(createCommand(
-		"sn",
-		"Add a server name",
-		routingClient.AddServerNameEndpoint,
-		&routing.AddServerNameRequest{},
-		&routing.AddServerNameResponse{},
-	))
+			res, err := s.export.Download(context.Background(), &exportPB.DownloadRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err == nil {
+				err = ioutil.WriteFile(c.Args[1], res.Content, 0600)
+			}
+			if err != nil {
+				s.fail(c, err)
+			}
+		},
+	})
 
-	routingCmd.AddCmd(addCmd)
+	exportCmd.AddCmd(&ishell.Cmd{
+		Name: "link",
+		Help: "print a signed URL the archive of a data export can be downloaded from until it expires, usage: export link <id>",
+		Func: func(c *ishell.Context) {
+			if len(c.Args) != 1 {
+				s.fail(c, errors.New("usage: export link <id>"))
+				return
+			}
 
-	routingCmd.AddCmd(createCommand(
-		"changels",
-		"Change a listen statement",
-		routingClient.ChangeListenStatementEndpoint,
-		&routing.ChangeListenStatementRequest{},
-		&routing.ChangeListenStatementResponse{},
-	))
+			id, err := strconv.ParseUint(c.Args[0], 10, 32)
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	routingCmd.AddCmd(createCommand(
-		"all",
-		"Get all configurations",
-		routingClient.ConfigurationsEndpoint,
-		&routing.ConfigurationsRequest{},
-		&routing.ConfigurationsResponse{},
-	))
+			res, err := s.export.DownloadURL(context.Background(), &exportPB.DownloadURLRequest{
+				RefID: s.refID(),
+				ID:    uint32(id),
+			})
+			if err == nil && res.Error != "" {
+				err = errors.New(res.Error)
+			}
+			if err != nil {
+				s.fail(c, err)
+				return
+			}
 
-	return routingCmd
+			if !s.print(c, res) {
+				c.Println(res.Url)
+				c.Println("expires", time.Unix(res.ExpiresAt, 0).UTC().Format("2006-01-02 15:04"))
+			}
+		},
+	})
+
+	return exportCmd
 }
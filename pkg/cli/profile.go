@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/client"
+)
+
+// DefaultProfile is the name of the profile created if none exist, it connects to a local daemon
+const DefaultProfile = "local"
+
+// Profile describes a kontainerooo installation and the user logged in to it
+type Profile struct {
+	Address string
+	TLS     bool
+
+	// CAFile is the certificate authority the server is verified with, the system roots are used if it is empty
+	CAFile string
+
+	Username string
+	ID       uint
+	Token    string
+}
+
+// Profiles are the stored profiles of the cli
+type Profiles struct {
+	Current  string
+	Profiles map[string]*Profile
+}
+
+// DefaultProfilesPath returns the path the profiles are stored at by default
+func DefaultProfilesPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".kroocli.json")
+}
+
+// LoadProfiles reads the profiles stored at path, if the file does not exist the default profile is returned
+func LoadProfiles(path string) (*Profiles, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Profiles{
+			Current: DefaultProfile,
+			Profiles: map[string]*Profile{
+				DefaultProfile: {
+					Address: ":8082",
+				},
+			},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Profiles{}
+	err = json.Unmarshal(data, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Profiles == nil {
+		p.Profiles = make(map[string]*Profile)
+	}
+	return p, nil
+}
+
+// Save stores the profiles at path, the file is only readable by the user since it contains tokens
+func (p *Profiles) Save(path string) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+// Get returns the profile name, or the current one if name is empty
+func (p *Profiles) Get(name string) (*Profile, error) {
+	if name == "" {
+		name = p.Current
+	}
+
+	profile, ok := p.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %s does not exist", name)
+	}
+	return profile, nil
+}
+
+// ClientOptions returns the options to connect to the daemon of a profile
+func (p *Profile) ClientOptions() client.Options {
+	return client.Options{
+		Address: p.Address,
+		TLS:     p.TLS,
+		CAFile:  p.CAFile,
+		Token:   p.Token,
+	}
+}
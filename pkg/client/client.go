@@ -0,0 +1,261 @@
+// Package client is the Go SDK of kontainerooo for third-party integrations and kroocli. A Client
+// holds one gRPC connection to a daemon, sends the token of the user with every call, retries calls
+// failing with transient errors and iterates over the pages of list calls. The REST gateway serves
+// the same calls, see pkg/gateway.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	containerClient "github.com/kontainerooo/kontainer.ooo/pkg/container/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	ktgPB "github.com/kontainerooo/kontainer.ooo/pkg/kentheguru/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	kmiClient "github.com/kontainerooo/kontainer.ooo/pkg/kmi/client"
+	managementClient "github.com/kontainerooo/kontainer.ooo/pkg/management/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/module"
+	moduleClient "github.com/kontainerooo/kontainer.ooo/pkg/module/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	routingClient "github.com/kontainerooo/kontainer.ooo/pkg/routing/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+	userClient "github.com/kontainerooo/kontainer.ooo/pkg/user/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
+)
+
+// ErrTokenMalformed occurs if a token is no JSON web token
+var ErrTokenMalformed = errors.New("token malformed")
+
+// Options configure how a Client connects to a daemon
+type Options struct {
+	// Address is the gRPC address of the daemon, e.g. example.com:8082
+	Address string
+
+	// TLS connects with TLS, the server is verified with the certificate authority CAFile or the
+	// system roots if it is empty
+	TLS    bool
+	CAFile string
+
+	// Token authenticates the calls, it is replaced by Login
+	Token string
+
+	// Retries is how often a call failing with a transient error is retried, 3 by default and none
+	// if it is negative
+	Retries int
+
+	// Backoff is the delay before the first retry, it doubles with every further retry up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Logger logs the errors of the calls and the first call of every deprecated method, nothing is
+	// logged by default
+	Logger log.Logger
+}
+
+// DefaultOptions are used for the unset options
+var DefaultOptions = Options{
+	Retries:    3,
+	Backoff:    200 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+}
+
+// Client is a client of a daemon, it is safe for concurrent use
+type Client struct {
+	conn *grpc.ClientConn
+	ktg  ktgPB.KenTheGuruServiceClient
+
+	mtx   sync.RWMutex
+	token string
+	// session is the impersonation session the calls are made in, there is none if it is zero
+	session uint
+
+	// the typed endpoints of the services, the requests and responses are the ones of their packages
+	Users      *user.Endpoints
+	KMIs       *kmi.Endpoints
+	Containers *container.Endpoints
+	Modules    *module.Endpoints
+	Routing    *routing.Endpoints
+
+	// Management is the stable management API for infrastructure as code tools
+	Management *managementClient.Client
+}
+
+// tokenCredentials send the token of a Client with every call, the token is read on each call so
+// that logging in takes effect on an open connection
+type tokenCredentials struct {
+	c   *Client
+	tls bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx oldcontext.Context, uri ...string) (map[string]string, error) {
+	token := t.c.Token()
+	if token == "" {
+		return map[string]string{}, nil
+	}
+
+	md := map[string]string{
+		"authorization": "Bearer " + token,
+	}
+	if session := t.c.Impersonating(); session != 0 {
+		md[impersonation.MetadataKey] = strconv.FormatUint(uint64(session), 10)
+	}
+	return md, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.tls
+}
+
+// Dial connects to the daemon at o.Address, the connection is established in the background
+func Dial(o Options) (*Client, error) {
+	if o.Retries == 0 {
+		o.Retries = DefaultOptions.Retries
+	}
+	if o.Backoff == 0 {
+		o.Backoff = DefaultOptions.Backoff
+	}
+	if o.MaxBackoff == 0 {
+		o.MaxBackoff = DefaultOptions.MaxBackoff
+	}
+	if o.Logger == nil {
+		o.Logger = log.NewNopLogger()
+	}
+
+	c := &Client{
+		token: o.Token,
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithPerRPCCredentials(tokenCredentials{c, o.TLS}),
+		grpc.WithChainUnaryInterceptor(UnaryClientInterceptor(o.Retries, o.Backoff, o.MaxBackoff), versioning.UnaryClientInterceptor(o.Logger)),
+	}
+
+	if o.TLS {
+		creds := credentials.NewTLS(&tls.Config{})
+		if o.CAFile != "" {
+			var err error
+			creds, err = credentials.NewClientTLSFromFile(o.CAFile, "")
+			if err != nil {
+				return nil, err
+			}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(creds))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+
+	conn, err := grpc.Dial(o.Address, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	c.conn = conn
+	c.ktg = ktgPB.NewKenTheGuruServiceClient(conn)
+	c.Users = userClient.New(conn, o.Logger)
+	c.KMIs = kmiClient.New(conn, o.Logger)
+	c.Containers = containerClient.New(conn, o.Logger)
+	c.Modules = moduleClient.New(conn, o.Logger)
+	c.Routing = routingClient.New(conn, o.Logger)
+	c.Management = managementClient.NewClient(conn, o.Logger)
+	return c, nil
+}
+
+// Conn returns the connection of c, e.g. to create the gRPC clients of the pb packages
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conn
+}
+
+// Close closes the connection of c
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Token returns the token the calls are authenticated with
+func (c *Client) Token() string {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.token
+}
+
+// SetToken sets the token the calls are authenticated with, an empty token logs out. Impersonating stops.
+func (c *Client) SetToken(token string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.token = token
+	c.session = 0
+}
+
+// Impersonate makes the further calls of an admin as the user of the impersonation session id, they are
+// recorded in the audit trail of the session. Impersonating stops if id is zero.
+func (c *Client) Impersonate(id uint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.session = id
+}
+
+// Impersonating returns the impersonation session the calls are made in, it is zero if there is none
+func (c *Client) Impersonating() uint {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.session
+}
+
+// Login authenticates the further calls as the user and returns the id of the user
+func (c *Client) Login(ctx context.Context, username, password string) (uint, error) {
+	res, err := c.ktg.Authenticate(ctx, &ktgPB.AuthenticationRequest{
+		Username: username,
+		Password: password,
+	})
+	if err == nil && res.Error != "" {
+		err = errors.New(res.Error)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := TokenID(res.Token)
+	if err != nil {
+		return 0, err
+	}
+
+	c.SetToken(res.Token)
+	return id, nil
+}
+
+// TokenID returns the id of the user a token was issued to, the token is not verified since
+// only the daemon knows the signing key
+func TokenID(token string) (uint, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrTokenMalformed
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, err
+	}
+
+	claims := struct {
+		Data struct {
+			ID uint
+		}
+	}{}
+	err = json.Unmarshal(data, &claims)
+	if err != nil {
+		return 0, err
+	}
+	return claims.Data.ID, nil
+}
@@ -0,0 +1,141 @@
+package client_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/idempotency"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Client", func() {
+	Describe("Iterator", func() {
+		pages := map[string][]interface{}{
+			"":  {1, 2},
+			"a": {},
+			"b": {3},
+		}
+		next := map[string]string{
+			"":  "a",
+			"a": "b",
+		}
+
+		f := func(ctx context.Context, r paging.Request) ([]interface{}, paging.Response, error) {
+			return pages[r.Cursor], paging.Response{NextCursor: next[r.Cursor], Total: 3}, nil
+		}
+
+		It("Should return the items of every page", func() {
+			it := client.NewIterator(f, paging.Request{})
+			items := []interface{}{}
+			for it.Next(context.Background()) {
+				items = append(items, it.Value())
+			}
+			Ω(it.Err()).ShouldNot(HaveOccurred())
+			Expect(items).To(Equal([]interface{}{1, 2, 3}))
+			Expect(it.Total()).To(Equal(uint(3)))
+		})
+
+		It("Should continue the cursor of the request", func() {
+			it := client.NewIterator(f, paging.Request{Cursor: "b"})
+			Expect(it.Next(context.Background())).To(BeTrue())
+			Expect(it.Value()).To(Equal(3))
+			Expect(it.Next(context.Background())).To(BeFalse())
+		})
+
+		It("Should stop at the first error", func() {
+			calls := 0
+			it := client.NewIterator(func(ctx context.Context, r paging.Request) ([]interface{}, paging.Response, error) {
+				calls++
+				return nil, paging.Response{}, errors.New("failure")
+			}, paging.Request{})
+			Expect(it.Next(context.Background())).To(BeFalse())
+			Expect(it.Next(context.Background())).To(BeFalse())
+			Expect(it.Err()).To(MatchError("failure"))
+			Expect(calls).To(Equal(1))
+		})
+	})
+
+	Describe("Retries", func() {
+		var (
+			keys     []string
+			failures []error
+		)
+
+		invoker := func(ctx oldcontext.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			keys = append(keys, md[idempotency.Header]...)
+			if len(failures) == 0 {
+				return nil
+			}
+			err := failures[0]
+			failures = failures[1:]
+			return err
+		}
+
+		BeforeEach(func() {
+			keys = []string{}
+		})
+
+		It("Should retry transient errors with the same idempotency key", func() {
+			failures = []error{
+				grpc.Errorf(codes.Unavailable, "maintenance"),
+				grpc.Errorf(codes.ResourceExhausted, "rate limit"),
+			}
+			err := client.UnaryClientInterceptor(3, time.Millisecond, time.Millisecond)(context.Background(), "/kmi.KMIService/AddKMI", nil, nil, nil, invoker)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(keys).To(HaveLen(3))
+			Expect(keys[1]).To(Equal(keys[0]))
+			Expect(keys[2]).To(Equal(keys[0]))
+		})
+
+		It("Should give up after the retries", func() {
+			failures = []error{
+				grpc.Errorf(codes.Unavailable, "down"),
+				grpc.Errorf(codes.Unavailable, "down"),
+			}
+			err := client.UnaryClientInterceptor(1, time.Millisecond, time.Millisecond)(context.Background(), "/kmi.KMIService/AddKMI", nil, nil, nil, invoker)
+			Expect(grpc.Code(err)).To(Equal(codes.Unavailable))
+			Expect(keys).To(HaveLen(2))
+		})
+
+		It("Should not retry other errors", func() {
+			failures = []error{grpc.Errorf(codes.InvalidArgument, "invalid")}
+			err := client.UnaryClientInterceptor(3, time.Millisecond, time.Millisecond)(context.Background(), "/kmi.KMIService/AddKMI", nil, nil, nil, invoker)
+			Expect(grpc.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(keys).To(HaveLen(1))
+		})
+
+		It("Should keep the idempotency key of the caller", func() {
+			failures = []error{}
+			ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs(idempotency.Header, "key"))
+			err := client.UnaryClientInterceptor(3, time.Millisecond, time.Millisecond)(ctx, "/kmi.KMIService/AddKMI", nil, nil, nil, invoker)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(keys).To(Equal([]string{"key"}))
+		})
+	})
+
+	Describe("TokenID", func() {
+		It("Should return the id of the user of a token", func() {
+			claims := base64.RawURLEncoding.EncodeToString([]byte(`{"Data":{"ID":42}}`))
+			id, err := client.TokenID("header." + claims + ".signature")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(id).To(Equal(uint(42)))
+		})
+
+		It("Should reject malformed tokens", func() {
+			_, err := client.TokenID("token")
+			Expect(err).To(Equal(client.ErrTokenMalformed))
+		})
+	})
+})
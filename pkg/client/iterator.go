@@ -0,0 +1,145 @@
+package client
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// PageFunc returns the items of the page r of a list and the page info
+type PageFunc func(ctx context.Context, r paging.Request) ([]interface{}, paging.Response, error)
+
+// Iterator iterates over the items of every page of a list, the next page is requested once the
+// items of the current one were returned
+//
+//	it := client.NewIterator(f, paging.Request{Sort: "name"})
+//	for it.Next(ctx) {
+//		item := it.Value()
+//	}
+//	if it.Err() != nil {
+//		...
+//	}
+type Iterator struct {
+	f     PageFunc
+	r     paging.Request
+	items []interface{}
+	value interface{}
+	total uint
+	done  bool
+	err   error
+}
+
+// NewIterator returns an Iterator over the pages f returns starting at r, r's cursor is continued
+func NewIterator(f PageFunc, r paging.Request) *Iterator {
+	return &Iterator{
+		f: f,
+		r: r,
+	}
+}
+
+// Next advances to the next item and returns whether there is one, it returns false after the last
+// item or an error
+func (it *Iterator) Next(ctx context.Context) bool {
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		items, page, err := it.f(ctx, it.r)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.items = items
+		it.total = page.Total
+		it.r.Cursor = page.NextCursor
+		it.done = page.NextCursor == ""
+	}
+
+	it.value = it.items[0]
+	it.items = it.items[1:]
+	return true
+}
+
+// Value returns the current item
+func (it *Iterator) Value() interface{} {
+	return it.value
+}
+
+// Total returns the number of items matching the filter as of the last requested page
+func (it *Iterator) Total() uint {
+	return it.total
+}
+
+// Err returns the error which stopped the iteration
+func (it *Iterator) Err() error {
+	return it.err
+}
+
+// InstanceIterator iterates over the instances of a user
+type InstanceIterator struct {
+	*Iterator
+}
+
+// Instance returns the current instance
+func (it InstanceIterator) Instance() container.Container {
+	return it.Value().(container.Container)
+}
+
+// Instances returns an iterator over the instances of a user starting at r
+func (c *Client) Instances(refID uint, r paging.Request) InstanceIterator {
+	return InstanceIterator{NewIterator(func(ctx context.Context, r paging.Request) ([]interface{}, paging.Response, error) {
+		res, err := c.Containers.InstancesEndpoint(ctx, &container.InstancesRequest{
+			RefID: refID,
+			Page:  r,
+		})
+		if err != nil {
+			return nil, paging.Response{}, err
+		}
+
+		page := res.(*container.InstancesResponse)
+		items := make([]interface{}, len(page.Containers))
+		for i, c := range page.Containers {
+			items[i] = c
+		}
+		return items, page.Page, nil
+	}, r)}
+}
+
+// KMDIIterator iterates over the displaying information of the kontainer modules
+type KMDIIterator struct {
+	*Iterator
+}
+
+// KMDI returns the displaying information of the current module
+func (it KMDIIterator) KMDI() kmi.KMDI {
+	return it.Value().(kmi.KMDI)
+}
+
+// KMDIs returns an iterator over the displaying information of the kontainer modules starting at r
+func (c *Client) KMDIs(r paging.Request) KMDIIterator {
+	return KMDIIterator{NewIterator(func(ctx context.Context, r paging.Request) ([]interface{}, paging.Response, error) {
+		res, err := c.KMIs.KMIEndpoint(ctx, &kmi.KMIRequest{
+			Page: r,
+		})
+		if err != nil {
+			return nil, paging.Response{}, err
+		}
+
+		page := res.(*kmi.KMIResponse)
+		if page.Error != nil {
+			return nil, paging.Response{}, page.Error
+		}
+
+		items := []interface{}{}
+		if page.KMDI != nil {
+			for _, k := range *page.KMDI {
+				items = append(items, k)
+			}
+		}
+		return items, page.Page, nil
+	}, r)}
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/idempotency"
+)
+
+// retryable are the codes of transient errors: the daemon is unreachable or in maintenance, the rate
+// limit of the user is exceeded or an earlier attempt of the call is still handled
+var retryable = map[codes.Code]bool{
+	codes.Unavailable:       true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+}
+
+// newKey returns a random idempotency key
+func newKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// UnaryClientInterceptor retries calls failing with a transient error up to retries times, the delay
+// before a retry starts at backoff and doubles up to maxBackoff. Every attempt of a call is sent with
+// the same idempotency key, so calls creating or removing resources are not handled twice. Calls
+// whose context carries an idempotency key already keep it.
+func UnaryClientInterceptor(retries int, backoff, maxBackoff time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx oldcontext.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+		if len(md[idempotency.Header]) == 0 {
+			md[idempotency.Header] = []string{newKey()}
+		}
+		ctx = metadata.NewOutgoingContext(ctx, md)
+
+		delay := backoff
+		for attempt := 0; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || !retryable[grpc.Code(err)] || attempt >= retries {
+				return err
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return err
+			}
+
+			delay *= 2
+			if delay > maxBackoff {
+				delay = maxBackoff
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package codegen_test
+
+import (
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/codegen"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const contract = `syntax = "proto3";
+package kmi;
+option go_package = "pb";
+
+// KMIService manages the kontainer modules
+// kroo:ws kmiService KMI
+service KMIService {
+  // AddKMI adds a module
+  // kroo:validate
+  // kroo:ws ADD
+  // kroo:http POST /v1/kmis Add a "module"
+  rpc AddKMI (AddKMIRequest) returns (AddKMIResponse);
+  // kroo:ws REM
+  rpc RemoveKMI (RemoveKMIRequest) returns (RemoveKMIResponse);
+  rpc UIs (UIsRequest) returns (UIsResponse);
+}
+`
+
+// paths returns the paths of files
+func paths(files []codegen.File) []string {
+	ps := []string{}
+	for _, f := range files {
+		ps = append(ps, f.Path)
+	}
+	return ps
+}
+
+var _ = Describe("Codegen", func() {
+	Describe("Parse", func() {
+		It("Should read the service and the directives", func() {
+			s, err := codegen.Parse(strings.NewReader(contract))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s.Package).To(Equal("kmi"))
+			Expect(s.Name).To(Equal("KMIService"))
+			Expect(s.WSName).To(Equal("kmiService"))
+			Expect(s.WSID).To(Equal("KMI"))
+			Expect(s.Methods).To(Equal([]codegen.Method{
+				{Name: "AddKMI", Request: "AddKMIRequest", Response: "AddKMIResponse", Validate: true, WS: "ADD", HTTP: &codegen.HTTP{Method: "POST", Path: "/v1/kmis", Summary: `Add a "module"`}},
+				{Name: "RemoveKMI", Request: "RemoveKMIRequest", Response: "RemoveKMIResponse", WS: "REM"},
+				{Name: "UIs", Request: "UIsRequest", Response: "UIsResponse"},
+			}))
+		})
+
+		It("Should reject unknown directives", func() {
+			_, err := codegen.Parse(strings.NewReader("service S {\n// kroo:grpc\nrpc M (A) returns (B);\n}"))
+			Expect(err).To(MatchError(ContainSubstring("line 3")))
+		})
+
+		It("Should reject websocket methods of services which are not served via websocket", func() {
+			_, err := codegen.Parse(strings.NewReader("service S {\n// kroo:ws GET\nrpc M (A) returns (B);\n}"))
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should require a service", func() {
+			_, err := codegen.Parse(strings.NewReader("package kmi;\nmessage A {}\n"))
+			Expect(err).To(Equal(codegen.ErrNoService))
+		})
+	})
+
+	Describe("Generate", func() {
+		It("Should generate the transports of the service", func() {
+			s, _ := codegen.Parse(strings.NewReader(contract))
+			files, err := codegen.Generate(s, "messages/kmi.proto")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(paths(files)).To(Equal([]string{
+				"pkg/kmi/endpoint_gen.go",
+				"pkg/kmi/transport_grpc_gen.go",
+				"pkg/kmi/transport_ws_gen.go",
+				"pkg/kmi/client/client_gen.go",
+				"pkg/gateway/routes_kmi_gen.go",
+			}))
+
+			for _, f := range files {
+				Expect(string(f.Content)).To(HavePrefix("// Code generated by kroogen from messages/kmi.proto. DO NOT EDIT."))
+			}
+
+			Expect(string(files[0].Content)).To(ContainSubstring(`AddKMIEndpoint:    mw("AddKMI", true)(MakeAddKMIEndpoint(s)),`))
+			Expect(string(files[1].Content)).To(ContainSubstring("func (s *grpcServer) UIs(ctx oldcontext.Context, req *pb.UIsRequest) (*pb.UIsResponse, error) {"))
+			Expect(string(files[2].Content)).To(ContainSubstring("func DecodeWSRemoveKMIRequest("))
+			Expect(string(files[2].Content)).NotTo(ContainSubstring("UIsRequest"))
+			Expect(string(files[3].Content)).To(ContainSubstring(`"kmi.KMIService",`))
+			Expect(string(files[4].Content)).To(ContainSubstring(`{"POST", "/v1/kmis", "/kmi.KMIService/AddKMI", &kmiPB.AddKMIRequest{}, &kmiPB.AddKMIResponse{}, "Add a \"module\""},`))
+		})
+
+		It("Should only generate the transports the service is served by", func() {
+			s, _ := codegen.Parse(strings.NewReader("package user;\nservice UserService {\nrpc GetUser (GetUserRequest) returns (GetUserResponse);\n}"))
+			files, err := codegen.Generate(s, "messages/user.proto")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(paths(files)).To(Equal([]string{
+				"pkg/user/endpoint_gen.go",
+				"pkg/user/transport_grpc_gen.go",
+				"pkg/user/client/client_gen.go",
+			}))
+		})
+	})
+})
@@ -0,0 +1,264 @@
+package codegen
+
+import (
+	"bytes"
+	"go/format"
+	"strings"
+	"text/template"
+)
+
+// ImportPath is the import path of the packages of the services
+const ImportPath = "github.com/kontainerooo/kontainer.ooo/pkg/"
+
+// File is a generated file
+type File struct {
+	// Path is relative to the root of the repository
+	Path    string
+	Content []byte
+}
+
+var funcs = template.FuncMap{
+	"lower": func(s string) string {
+		return strings.ToLower(s[:1]) + s[1:]
+	},
+}
+
+var header = `// Code generated by kroogen from {{.Source}}. DO NOT EDIT.
+`
+
+var endpointsTemplate = template.Must(template.New("endpoints").Funcs(funcs).Parse(header + `
+package {{.Package}}
+
+import (
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints is a struct which collects all endpoints for the {{.Package}} service
+type Endpoints struct {
+{{- range .Methods}}
+	{{.Name}}Endpoint endpoint.Endpoint
+{{- end}}
+}
+
+// MakeEndpoints creates the endpoints of s, every endpoint is wrapped with the middleware mw returns
+// for its method, validate is set for the methods whose requests have to be validated
+func MakeEndpoints(s Service, mw func(method string, validate bool) endpoint.Middleware) Endpoints {
+	return Endpoints{
+{{- range .Methods}}
+		{{.Name}}Endpoint: mw("{{.Name}}", {{.Validate}})(Make{{.Name}}Endpoint(s)),
+{{- end}}
+	}
+}
+`))
+
+var grpcTemplate = template.Must(template.New("grpc").Funcs(funcs).Parse(header + `
+package {{.Package}}
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"{{.ImportPath}}{{.Package}}/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC {{.Name}}Server
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.{{.Name}}Server {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+{{- range .Methods}}
+		{{lower .Name}}: grpctransport.NewServer(
+			endpoints.{{.Name}}Endpoint,
+			DecodeGRPC{{.Request}},
+			EncodeGRPC{{.Response}},
+			options...,
+		),
+{{end}}	}
+}
+
+type grpcServer struct {
+{{- range .Methods}}
+	{{lower .Name}} grpctransport.Handler
+{{- end}}
+}
+{{range .Methods}}
+func (s *grpcServer) {{.Name}}(ctx oldcontext.Context, req *pb.{{.Request}}) (*pb.{{.Response}}, error) {
+	_, res, err := s.{{lower .Name}}.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.{{.Response}}), nil
+}
+{{end}}`))
+
+var wsTemplate = template.Must(template.New("ws").Funcs(funcs).Parse(header + `
+package {{.Package}}
+
+import (
+	"context"
+
+	"{{.ImportPath}}{{.Package}}/pb"
+	"{{.ImportPath}}payload"
+	ws "{{.ImportPath}}websocket"
+)
+
+// MakeWebsocketService makes a set of {{.Package}} Endpoints available as a websocket Service
+func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
+	service, _ := ws.NewServiceDescription("{{.WSName}}", ws.ProtoIDFromString("{{.WSID}}"))
+{{range .Methods}}{{if .WS}}
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"{{.Name}}",
+		ws.ProtoIDFromString("{{.WS}}"),
+		endpoints.{{.Name}}Endpoint,
+		DecodeWS{{.Request}},
+		EncodeGRPC{{.Response}},
+	))
+{{end}}{{end}}
+	return service
+}
+{{range .WSRequests}}
+// DecodeWS{{.}} is a websocket.DecodeRequestFunc that converts a
+// WS {{.}} to a messages/{{$.Package}}.proto-domain request.
+func DecodeWS{{.}}(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.{{.}}{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPC{{.}}(ctx, req)
+}
+{{end}}`))
+
+var clientTemplate = template.Must(template.New("client").Funcs(funcs).Parse(header + `
+package client
+
+import (
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"{{.ImportPath}}{{.Package}}"
+	"{{.ImportPath}}{{.Package}}/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *{{.Package}}.Endpoints {
+{{- range .Methods}}
+
+	var {{.Name}}Endpoint endpoint.Endpoint
+	{
+		{{.Name}}Endpoint = grpctransport.NewClient(
+			conn,
+			"{{$.Package}}.{{$.Name}}",
+			"{{.Name}}",
+			EncodeGRPC{{.Request}},
+			DecodeGRPC{{.Response}},
+			pb.{{.Response}}{},
+		).Endpoint()
+	}
+{{- end}}
+
+	return &{{.Package}}.Endpoints{
+{{- range .Methods}}
+		{{.Name}}Endpoint: {{.Name}}Endpoint,
+{{- end}}
+	}
+}
+`))
+
+var routesTemplate = template.Must(template.New("routes").Funcs(funcs).Parse(header + `
+package gateway
+
+import (
+	{{.Package}}PB "{{.ImportPath}}{{.Package}}/pb"
+)
+
+// the routes of the {{.Package}} service are served after the hand-written ones
+func init() {
+	Routes = append(Routes, []Route{
+{{- range .Methods}}{{if .HTTP}}
+		{ {{- printf "%q" .HTTP.Method}}, {{printf "%q" .HTTP.Path}}, "/{{$.Package}}.{{$.Name}}/{{.Name}}", &{{$.Package}}PB.{{.Request}}{}, &{{$.Package}}PB.{{.Response}}{}, {{printf "%q" .HTTP.Summary -}} },
+{{- end}}{{end}}
+	}...)
+}
+`))
+
+// data is passed to the templates
+type data struct {
+	*Service
+	Source     string
+	ImportPath string
+}
+
+// WSRequests returns the request messages of the methods served via websocket, each once
+func (d data) WSRequests() []string {
+	seen := make(map[string]bool)
+	requests := []string{}
+	for _, m := range d.Methods {
+		if m.WS != "" && !seen[m.Request] {
+			seen[m.Request] = true
+			requests = append(requests, m.Request)
+		}
+	}
+	return requests
+}
+
+// hasHTTP returns whether a method of s is served via the REST gateway
+func (s *Service) hasHTTP() bool {
+	for _, m := range s.Methods {
+		if m.HTTP != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// render executes t with d and formats the result
+func render(t *template.Template, d data) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := t.Execute(buf, d)
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// Generate returns the generated files of s, source is the path of its proto file. The endpoints
+// struct, the gRPC server and the client are always generated, the websocket service and the REST
+// routes only if the service has methods served by them.
+func Generate(s *Service, source string) ([]File, error) {
+	d := data{s, source, ImportPath}
+	dir := "pkg/" + s.Package + "/"
+
+	targets := []struct {
+		path string
+		t    *template.Template
+		ok   bool
+	}{
+		{dir + "endpoint_gen.go", endpointsTemplate, true},
+		{dir + "transport_grpc_gen.go", grpcTemplate, true},
+		{dir + "transport_ws_gen.go", wsTemplate, len(d.WSRequests()) > 0},
+		{dir + "client/client_gen.go", clientTemplate, true},
+		{"pkg/gateway/routes_" + s.Package + "_gen.go", routesTemplate, s.hasHTTP()},
+	}
+
+	files := []File{}
+	for _, t := range targets {
+		if !t.ok {
+			continue
+		}
+
+		content, err := render(t.t, d)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{t.path, content})
+	}
+	return files, nil
+}
@@ -0,0 +1,159 @@
+// Package codegen generates the transports of a service from its contract in messages/<service>.proto.
+// The encoders and decoders between the messages and the domain types stay hand-written, everything
+// wiring them to the endpoints is generated, so the gRPC server, the websocket service, the client and
+// the REST routes can't drift from the contract. Directives in the comments above the service and its
+// methods select the generated parts:
+//
+//	// kroo:ws <name> <protocol id>            above the service, serves it via websocket as name
+//	// kroo:ws <protocol id>                   above a method, serves it via websocket
+//	// kroo:validate                           above a method, validates its requests
+//	// kroo:http <method> <path> <summary>     above a method, serves it via the REST gateway
+//
+// Only the contracts listed in GENERATED_PROTOS of the Makefile are generated. The transports of
+// the services missing from that list are still hand-written.
+package codegen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// directive is the prefix of the comments holding directives
+const directive = "kroo:"
+
+var (
+	packageExp = regexp.MustCompile(`^package\s+(\w+)\s*;`)
+	serviceExp = regexp.MustCompile(`^service\s+(\w+)\s*\{`)
+	rpcExp     = regexp.MustCompile(`^rpc\s+(\w+)\s*\(\s*(\w+)\s*\)\s*returns\s*\(\s*(\w+)\s*\)`)
+
+	// ErrNoService occurs if a file does not define a service
+	ErrNoService = errors.New("the file does not define a service")
+)
+
+// HTTP is the REST route of a method
+type HTTP struct {
+	Method  string
+	Path    string
+	Summary string
+}
+
+// Method is a method of a service
+type Method struct {
+	Name     string
+	Request  string
+	Response string
+
+	// Validate is set if the requests are validated
+	Validate bool
+
+	// WS is the protocol id the method is served with via websocket, it is not served if it is empty
+	WS string
+
+	// HTTP is the REST route of the method, it is not served if it is nil
+	HTTP *HTTP
+}
+
+// Service is the service of a proto file
+type Service struct {
+	// Package is the proto package, it is the name of the Go package as well
+	Package string
+	Name    string
+
+	// WSName and WSID are the name and the protocol id of the websocket service, the service is
+	// not served via websocket if WSID is empty
+	WSName string
+	WSID   string
+
+	Methods []Method
+}
+
+// parseError returns the error of line n
+func parseError(n int, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", n, fmt.Sprintf(format, args...))
+}
+
+// Parse reads the service of a proto file, only one service per file is supported
+func Parse(r io.Reader) (*Service, error) {
+	s := &Service{}
+	found := false
+	directives := []string{}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "//") {
+			comment := strings.TrimSpace(strings.TrimPrefix(line, "//"))
+			if strings.HasPrefix(comment, directive) {
+				directives = append(directives, strings.TrimPrefix(comment, directive))
+			}
+			continue
+		}
+
+		if m := packageExp.FindStringSubmatch(line); m != nil {
+			s.Package = m[1]
+		}
+
+		if m := serviceExp.FindStringSubmatch(line); m != nil {
+			if found {
+				return nil, parseError(n, "only one service per file is supported")
+			}
+			found = true
+			s.Name = m[1]
+
+			for _, d := range directives {
+				fields := strings.Fields(d)
+				if len(fields) != 3 || fields[0] != "ws" {
+					return nil, parseError(n, "unknown service directive %q", d)
+				}
+				s.WSName, s.WSID = fields[1], fields[2]
+			}
+		}
+
+		if m := rpcExp.FindStringSubmatch(line); m != nil {
+			method := Method{
+				Name:     m[1],
+				Request:  m[2],
+				Response: m[3],
+			}
+
+			for _, d := range directives {
+				fields := strings.Fields(d)
+				switch {
+				case len(fields) == 1 && fields[0] == "validate":
+					method.Validate = true
+				case len(fields) == 2 && fields[0] == "ws":
+					if s.WSID == "" {
+						return nil, parseError(n, "%s is served via websocket but its service is not", method.Name)
+					}
+					method.WS = fields[1]
+				case len(fields) >= 3 && fields[0] == "http":
+					method.HTTP = &HTTP{
+						Method:  strings.ToUpper(fields[1]),
+						Path:    fields[2],
+						Summary: strings.Join(fields[3:], " "),
+					}
+				default:
+					return nil, parseError(n, "unknown method directive %q", d)
+				}
+			}
+
+			s.Methods = append(s.Methods, method)
+		}
+
+		// directives belong to the next service or method
+		directives = directives[:0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, ErrNoService
+	}
+	return s, nil
+}
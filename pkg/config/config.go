@@ -0,0 +1,1128 @@
+// Package config loads the settings of the daemon from a YAML file, environment variables and flags
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/billing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog"
+	"github.com/kontainerooo/kontainer.ooo/pkg/cronjob"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ratelimit"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+	"github.com/kontainerooo/kontainer.ooo/pkg/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// DefaultPath is the path the configuration file is read from if no other path is given
+const DefaultPath = "/var/lib/kontainerooo/config.yml"
+
+// EnvPrefix is the prefix of the environment variables overriding settings,
+// database.dsn for example is overridden by KROO_DATABASE_DSN
+const EnvPrefix = "KROO"
+
+// Log configures the logging of the daemon
+type Log struct {
+	// Level is the minimum level of logged messages, one of debug, info, warn and error
+	Level string `yaml:"level" reload:"true"`
+
+	// Modules overrides the level per module, e.g. "routing=debug,network=warn". Modules are named by the
+	// service, component or transport field of their records.
+	Modules string `yaml:"modules" reload:"true"`
+
+	// Format of the records, either logfmt or json
+	Format string `yaml:"format"`
+}
+
+// Database configures the database connection
+type Database struct {
+	// Mock uses an in memory database instead of connecting to one
+	Mock   bool   `yaml:"mock"`
+	Driver string `yaml:"driver"`
+	DSN    string `yaml:"dsn"`
+}
+
+// Listen are the addresses the transports listen on, the gateway and metrics are disabled if their address is empty
+type Listen struct {
+	GRPC            string `yaml:"grpc"`
+	Websocket       string `yaml:"websocket"`
+	WebsocketSecure string `yaml:"websocketSecure"`
+	Gateway         string `yaml:"gateway"`
+	// Metrics also serves the liveness and readiness of the daemon
+	Metrics string `yaml:"metrics"`
+}
+
+// TLS secures the gRPC and gateway transports if a certificate is given
+type TLS struct {
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+}
+
+// Paths are the directories and binaries used by the container service
+type Paths struct {
+	Rootfs               string `yaml:"rootfs"`
+	Customer             string `yaml:"customer"`
+	NetNS                string `yaml:"netns"`
+	StandardPathVariable string `yaml:"standardPathVariable"`
+	Container            string `yaml:"container"`
+	InitBinary           string `yaml:"initBinary"`
+}
+
+// IPTables configures the firewall service, it is only started if enabled
+type IPTables struct {
+	Enabled     bool   `yaml:"enabled"`
+	Path        string `yaml:"path"`
+	RestorePath string `yaml:"restorePath"`
+	// LogDrops logs the packets dropped by the deny rules, either to the kernel log (log) or to an
+	// NFLOG group (nflog), the drops are read from DropLog to count them per instance
+	LogDrops   string `yaml:"logDrops"`
+	NFLogGroup int    `yaml:"nflogGroup"`
+	DropLog    string `yaml:"dropLog"`
+}
+
+// Routing configures the router the routing service writes configurations for
+type Routing struct {
+	Router        string `yaml:"router"`
+	TemplatePath  string `yaml:"templatePath" reload:"true"`
+	AnalyticsPath string `yaml:"analyticsPath"`
+	// Plans limit the client max body size and timeouts users set for their locations and the monthly traffic
+	// of the users like the plans of the cron jobs. Plans can only be given in the configuration file.
+	Plans map[string]routing.Plan `yaml:"plans" reload:"true"`
+}
+
+// ACME configures the certificates issued for routed domains, it is disabled if no email is given
+type ACME struct {
+	Email           string `yaml:"email" reload:"true"`
+	Directory       string `yaml:"directory"`
+	Wildcard        bool   `yaml:"wildcard"`
+	CertificatePath string `yaml:"certificatePath"`
+}
+
+// DNS configures the records created for instances
+type DNS struct {
+	BaseDomain     string `yaml:"baseDomain"`
+	PublicIPv4     string `yaml:"publicIPv4"`
+	PublicIPv6     string `yaml:"publicIPv6"`
+	Target         string `yaml:"target"`
+	PowerDNSURL    string `yaml:"powerDNSURL"`
+	PowerDNSAPIKey string `yaml:"powerDNSAPIKey"`
+}
+
+// Network configures the bridges of users, the network service is only started if a pool is given
+type Network struct {
+	Pool        string `yaml:"pool"`
+	Prefix      int    `yaml:"prefix"`
+	NodeName    string `yaml:"nodeName"`
+	NodeAddress string `yaml:"nodeAddress"`
+	Metering    bool   `yaml:"metering"`
+}
+
+// Tracing configures the reporting of traces to Jaeger, the collector is used instead of the agent if it is set
+type Tracing struct {
+	Enabled     bool    `yaml:"enabled"`
+	ServiceName string  `yaml:"serviceName"`
+	Agent       string  `yaml:"agent"`
+	Collector   string  `yaml:"collector"`
+	SampleRate  float64 `yaml:"sampleRate"`
+}
+
+// Events configures the bus the services publish their events on. The memory backend only delivers
+// events within the daemon, nodes of a cluster share their events over the nats backend.
+type Events struct {
+	Backend string `yaml:"backend"`
+	URL     string `yaml:"url"`
+	// Stream is the JetStream stream the events are kept in until every subscriber handled them
+	Stream string `yaml:"stream"`
+}
+
+// RateLimit configures how many requests users may make. The plan of a user is the tier of its
+// customer entry, e.g. "1", users without one get the "default" plan and admins are not limited.
+// The memory backend limits the requests to each node, nodes share the limits over the redis backend.
+// Plans can only be given in the configuration file.
+type RateLimit struct {
+	Enabled  bool                      `yaml:"enabled"`
+	Backend  string                    `yaml:"backend"`
+	RedisURL string                    `yaml:"redisURL"`
+	Plans    map[string]ratelimit.Plan `yaml:"plans" reload:"true"`
+}
+
+// Idempotency configures how the results of calls with an idempotency key are kept. The memory backend
+// only recognizes retries sent to the same node, nodes share the keys over the redis backend.
+type Idempotency struct {
+	Backend  string `yaml:"backend"`
+	RedisURL string `yaml:"redisURL"`
+	// TTL is the number of seconds the result of a call is returned to retries with the same key
+	TTL int `yaml:"ttl"`
+}
+
+// Cache configures how the KMI catalog, the users and the router configurations are cached. The memory
+// backend keeps them on each node, where changes made on another node only show up once they expire,
+// nodes share them over the redis backend.
+type Cache struct {
+	Enabled  bool   `yaml:"enabled"`
+	Backend  string `yaml:"backend"`
+	RedisURL string `yaml:"redisURL"`
+	// Size is the maximum number of values kept by the memory backend
+	Size int `yaml:"size"`
+	// TTL is the number of seconds a value is kept
+	TTL int `yaml:"ttl"`
+}
+
+// Agent configures krooagent, which serves the container, firewall and network services of a worker
+// node on listen.grpc and reports to the daemon of the control plane. The daemon marks agents offline
+// which did not send a heartbeat for Timeout seconds and reschedules the instances of their node
+// once they did not send one for Reschedule seconds, 0 leaves the instances on failed nodes.
+type Agent struct {
+	ControlPlane string `yaml:"controlPlane"`
+	// Token is the token of an admin issued by Authenticate, the agent sends it with its calls to the
+	// control plane and only accepts calls which send it as well
+	Token string `yaml:"token"`
+	// Address is the address the control plane reaches the agent at, listen.grpc is used if it is empty
+	Address string `yaml:"address"`
+	// Heartbeat is the number of seconds between the heartbeats of the agent
+	Heartbeat  int `yaml:"heartbeat"`
+	Timeout    int `yaml:"timeout"`
+	Reschedule int `yaml:"reschedule"`
+}
+
+// SSH configures the gateway bridging SSH sessions of the users to their containers
+type SSH struct {
+	Enabled bool   `yaml:"enabled"`
+	Address string `yaml:"address"`
+	// HostKey is the private key of the gateway, an ed25519 key is generated if the file does not exist
+	HostKey string `yaml:"hostKey"`
+	// Recordings is the directory the sessions are recorded in
+	Recordings string `yaml:"recordings"`
+}
+
+// DatabaseServer is a shared server the managed databases of an engine are created on
+type DatabaseServer struct {
+	// DSN connects as a user which may create databases and users, no databases are created on the server if it is empty
+	DSN string `yaml:"dsn"`
+	// Host and Port are the address the instances of the users connect to
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// KMI is the KMI the database containers of the engine are created from, 0 disables them
+	KMI int `yaml:"kmi"`
+}
+
+// ManagedDatabases configures the MySQL and PostgreSQL databases provisioned for the users, either on
+// the shared servers or in containers of the users. Every database is dumped to DumpPath every
+// DumpInterval seconds and its latest DumpRetention dumps are kept.
+type ManagedDatabases struct {
+	Enabled bool `yaml:"enabled"`
+	// Mode is the mode of databases created without one, schema or container
+	Mode          string         `yaml:"mode"`
+	Postgres      DatabaseServer `yaml:"postgres"`
+	MySQL         DatabaseServer `yaml:"mysql"`
+	DumpPath      string         `yaml:"dumpPath"`
+	DumpInterval  int            `yaml:"dumpInterval"`
+	DumpRetention int            `yaml:"dumpRetention"`
+}
+
+// S3 is a bucket of an S3 compatible object storage
+type S3 struct {
+	// Endpoint is the URL of the object storage, e.g. https://s3.eu-central-1.amazonaws.com, no bucket is used if it is empty
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"accessKey"`
+	SecretKey string `yaml:"secretKey"`
+	// Encryption is the server-side encryption of new objects, AES256, aws:kms or empty for none
+	Encryption string `yaml:"encryption"`
+	// KMSKeyID is the key used with aws:kms, the default key of the bucket is used if it is empty
+	KMSKeyID string `yaml:"kmsKeyID"`
+	// PartSize is the size in MiB of the parts large objects are uploaded in, at least 5
+	PartSize int `yaml:"partSize"`
+}
+
+// Storage configures where the artifacts of the installation, like module bundles and backups, are kept
+type Storage struct {
+	// Backend is local or s3
+	Backend   string `yaml:"backend"`
+	LocalPath string `yaml:"localPath"`
+	S3        S3     `yaml:"s3"`
+}
+
+// Snapshots configures the snapshot schedules of the users, snapshots are kept in LocalPath or
+// the S3 bucket, which defaults to the one of an s3 storage, and volumes are restored into RestorePath
+type Snapshots struct {
+	Enabled     bool   `yaml:"enabled"`
+	LocalPath   string `yaml:"localPath"`
+	S3          S3     `yaml:"s3"`
+	RestorePath string `yaml:"restorePath"`
+	// Retention is the number of snapshots kept by schedules without one
+	Retention int `yaml:"retention"`
+}
+
+// SMTP is the SMTP server emails are sent to
+type SMTP struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// MailAPI is the account of an email API, the endpoint defaults to the one of the provider
+type MailAPI struct {
+	Endpoint string `yaml:"endpoint"`
+	APIKey   string `yaml:"apiKey"`
+	// Domain is the sending domain of Mailgun
+	Domain string `yaml:"domain"`
+}
+
+// Mail configures the emails sent to the users
+type Mail struct {
+	Enabled bool `yaml:"enabled"`
+	// Provider is smtp, sendgrid or mailgun
+	Provider string `yaml:"provider"`
+	// Sandbox logs and keeps the messages instead of sending them
+	Sandbox bool   `yaml:"sandbox"`
+	From    string `yaml:"from"`
+	// TemplatePath is a directory with templates replacing the built-in ones, it is optional
+	TemplatePath string  `yaml:"templatePath"`
+	SMTP         SMTP    `yaml:"smtp"`
+	SendGrid     MailAPI `yaml:"sendgrid"`
+	Mailgun      MailAPI `yaml:"mailgun"`
+}
+
+// Stripe is the Stripe account the users are charged through, the endpoint defaults to the one of Stripe
+type Stripe struct {
+	Endpoint  string `yaml:"endpoint"`
+	SecretKey string `yaml:"secretKey"`
+	// WebhookSecret is the signing secret of the webhook endpoint at /v1/billing/webhook of the gateway
+	WebhookSecret string `yaml:"webhookSecret"`
+}
+
+// Billing configures the charges of the users. The plan of a user is the tier of its customer entry
+// like for the rate limits, users whose plan has no price are not billed.
+// Plans can only be given in the configuration file.
+type Billing struct {
+	Enabled  bool   `yaml:"enabled"`
+	Currency string `yaml:"currency"`
+	// GracePeriod is the number of days an account may be past due before its instances are stopped
+	GracePeriod int `yaml:"gracePeriod"`
+	// Issuer is the name and address of the operator printed on the invoices, one line each
+	Issuer string                  `yaml:"issuer"`
+	Stripe Stripe                  `yaml:"stripe"`
+	Plans  map[string]billing.Plan `yaml:"plans" reload:"true"`
+}
+
+// ModuleUI configures how the frontend assets of the modules are served by the gateway
+type ModuleUI struct {
+	// MaxAge is the number of seconds browsers may cache the assets
+	MaxAge int `yaml:"maxAge"`
+	// ContentSecurityPolicy is sent with every asset, it defaults to only allowing the assets of the installation
+	ContentSecurityPolicy string `yaml:"contentSecurityPolicy"`
+}
+
+// Cron configures the cron jobs users run inside their instances. The plan of a user is the tier of its
+// customer entry like for the rate limits, users whose plan is not configured get the "default" plan and
+// admins are not limited. Plans can only be given in the configuration file.
+type Cron struct {
+	Enabled bool `yaml:"enabled"`
+	// History is the number of runs kept per job
+	History int `yaml:"history"`
+	// MaxOutput is the number of bytes of the output kept per run, the end of longer outputs is kept
+	MaxOutput int                     `yaml:"maxOutput"`
+	Plans     map[string]cronjob.Plan `yaml:"plans" reload:"true"`
+}
+
+// ContainerLogs configures the collection of the lines the instances write to their standard output and error.
+// The plans limit the retention and size of the lines per user like the plans of the cron jobs. The sinks
+// get the lines of every user or of the given users, e.g. for the syslog of the installation.
+type ContainerLogs struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is the number of seconds between the collections of new lines
+	Interval int `yaml:"interval"`
+	// MaxLine is the number of bytes kept per line
+	MaxLine int                          `yaml:"maxLine"`
+	Plans   map[string]containerlog.Plan `yaml:"plans" reload:"true"`
+	// Sinks are the syslog, Loki and Elasticsearch servers the lines are forwarded to
+	Sinks []containerlog.SinkConfig `yaml:"sinks"`
+}
+
+// Alerts configures the threshold rules users define on the resource usage of their instances. Every node
+// evaluates the rules for the instances it runs whenever the metrics are sampled.
+type Alerts struct {
+	Enabled bool `yaml:"enabled"`
+	// MaxRules is the number of rules a user may have
+	MaxRules int `yaml:"maxRules"`
+	// History is the number of alerts kept per user
+	History int `yaml:"history"`
+}
+
+// Autoscaling configures the policies users set to scale the replicas of their instances by their usage. Every node
+// evaluates the policies of the instances it runs, scaling by the request rates requires the analytics of the router.
+// The windows of the policies are applied every minute by the job queue.
+type Autoscaling struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is the number of seconds between the evaluations
+	Interval int `yaml:"interval"`
+	// MaxPolicies is the number of policies a user may have
+	MaxPolicies int `yaml:"maxPolicies"`
+	// MaxReplicas is the number of replicas a policy may scale an instance to
+	MaxReplicas int `yaml:"maxReplicas"`
+	// MaxWindows is the number of windows the policy of an instance may have
+	MaxWindows int `yaml:"maxWindows"`
+	// History is the number of scale events kept per user
+	History int `yaml:"history"`
+}
+
+// Bans configures the jails users create to ban the sources which abuse their instances, e.g. by failing to
+// authenticate. Every node watches the logs of its instances and routing configurations and bans in its firewall.
+type Bans struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is the number of seconds between the reads of the new lines of the logs
+	Interval int `yaml:"interval"`
+	// MaxJails is the number of jails a user may have
+	MaxJails int `yaml:"maxJails"`
+	// MaxBanTime is the number of seconds a source may be banned at most
+	MaxBanTime int `yaml:"maxBanTime"`
+	// Ignore are the networks in CIDR notation whose addresses are never banned, e.g. those of the administrators
+	Ignore []string `yaml:"ignore"`
+}
+
+// WireGuard configures the VPN access of the users to their bridge networks. Every node serves the peers created
+// on it over its interface and allows them to reach the instances of their rules in its firewall.
+type WireGuard struct {
+	Enabled bool `yaml:"enabled"`
+	// Interface is the name of the WireGuard interface, Port the UDP port it listens on
+	Interface string `yaml:"interface"`
+	Port      int    `yaml:"port"`
+	// Endpoint is the host name or address of this node the peers connect to
+	Endpoint string `yaml:"endpoint"`
+	// Pool is the network in CIDR notation the addresses inside of the tunnels are assigned from
+	Pool string `yaml:"pool"`
+	// PrivateKey is the private key of the interface, it is generated if the file does not exist
+	PrivateKey string `yaml:"privateKey"`
+	// MaxPeers is the number of peers a user may have
+	MaxPeers int `yaml:"maxPeers"`
+	// MaxKeyAge is the number of days a key is valid before it has to be rotated, keys never expire if it is 0
+	MaxKeyAge int `yaml:"maxKeyAge"`
+	// Interval is the number of seconds between the syncs of the interface and the firewall with the peers
+	Interval int `yaml:"interval"`
+}
+
+// Resolver configures the DNS resolver the containers resolve their sibling instances with, e.g. db.internal.
+// Every node answers on Listen, which includes the gateways of the bridge networks, and resolves the names in the
+// network of the user the querying container belongs to.
+type Resolver struct {
+	Enabled bool `yaml:"enabled"`
+	// Listen is the UDP address the resolver listens on
+	Listen string `yaml:"listen"`
+	// Domain is the domain the instances are resolved under
+	Domain string `yaml:"domain"`
+	// Upstream is the resolver other names are forwarded to as host:port, they are refused if it is empty
+	Upstream string `yaml:"upstream"`
+	// TTL is the number of seconds the answers may be cached
+	TTL int `yaml:"ttl"`
+}
+
+// Usage configures the history of the resource usage of the instances. Every node samples its instances,
+// the samples are rolled up into five minute and hourly points which are kept longer. The limits suggested
+// for the instances are derived from their hourly points every week.
+type Usage struct {
+	Enabled bool `yaml:"enabled"`
+	// Interval is the number of seconds between the samples
+	Interval int `yaml:"interval"`
+	// RawRetention is the number of hours the samples are kept
+	RawRetention int `yaml:"rawRetention"`
+	// FiveMinuteRetention and HourRetention are the number of days the rollups are kept
+	FiveMinuteRetention int `yaml:"fiveMinuteRetention"`
+	HourRetention       int `yaml:"hourRetention"`
+	// RecommendationPeriod is the number of days of usage the suggested limits are derived from, Headroom
+	// the share of the peak usage added to them
+	RecommendationPeriod int     `yaml:"recommendationPeriod"`
+	Headroom             float64 `yaml:"headroom"`
+	// CPUPrice and MemoryPrice are the monthly prices of a core and a GiB of memory in the smallest unit of the
+	// billing currency, the savings of the suggested limits are priced with them
+	CPUPrice    uint64 `yaml:"cpuPrice"`
+	MemoryPrice uint64 `yaml:"memoryPrice"`
+}
+
+// Sites configures the static sites. Their bundles are kept in the artifact store, every node extracts the
+// deployed versions below Root and the router serves them, so it requires nginx.
+type Sites struct {
+	Enabled bool `yaml:"enabled"`
+	// Root is the directory the sites are extracted to, the router has to be able to read it
+	Root string `yaml:"root"`
+	// Address is the address the router serves the sites on
+	Address string `yaml:"address"`
+	// MaxBundleSize is the size a bundle and the files it contains may have, e.g. 100m
+	MaxBundleSize string `yaml:"maxBundleSize"`
+	// MaxAge is the number of seconds browsers may cache the assets of a site which does not set it
+	MaxAge int `yaml:"maxAge"`
+	// Interval is the number of seconds between the syncs of the extracted sites with the deployed versions
+	Interval int `yaml:"interval"`
+}
+
+// Deploy configures the deployments of the instances from git pushes to the SSH gateway. A push of Branch
+// is built into an image with its Dockerfile or with the buildpacks of Buildpack and the instance is updated
+// with it, the latest Keep images of every instance are kept for redeploys.
+type Deploy struct {
+	Enabled bool `yaml:"enabled"`
+	// Root is the directory the repositories, images and build logs are kept in
+	Root   string `yaml:"root"`
+	Branch string `yaml:"branch"`
+	Keep   int    `yaml:"keep"`
+	// Docker and Pack are the docker and pack binaries
+	Docker string `yaml:"docker"`
+	Pack   string `yaml:"pack"`
+	// Buildpack is the builder image providing the buildpacks
+	Buildpack string `yaml:"buildpack"`
+}
+
+// Registry configures the pull-through cache of a Docker registry served on Listen. The docker daemons building the
+// deployments pull through it once it is one of the registry-mirrors of their daemon.json. Pulls are not
+// authenticated, so Listen should only be reachable by the nodes.
+type Registry struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"`
+	// Upstream is the registry pulled through, Username and Password are its credentials for private images
+	Upstream string `yaml:"upstream"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// Root is the directory the layers and manifests are kept in
+	Root string `yaml:"root"`
+	// Quota is the size the layers may take up, e.g. 20g, the least recently pulled ones are removed beyond it
+	Quota string `yaml:"quota"`
+	// ManifestTTL is the number of seconds the manifests of tags are served without asking Upstream
+	ManifestTTL int `yaml:"manifestTTL"`
+	// Unused is the number of hours layers and manifests are kept after they were pulled the last time
+	Unused int `yaml:"unused"`
+	// Interval is the number of seconds between the removals of the unused layers
+	Interval int `yaml:"interval"`
+}
+
+// Checkpoints configures the checkpoints of containers with CRIU. They are only available on nodes whose
+// criu check passes, other nodes reject checkpoints and restores.
+type Checkpoints struct {
+	Enabled bool `yaml:"enabled"`
+	// CRIU is the criu binary used by the container runtime
+	CRIU string `yaml:"criu"`
+	// Restart checkpoints the running containers of the node when the daemon shuts down and restores them
+	// once it started again, instead of leaving them to be killed with it
+	Restart bool `yaml:"restart"`
+}
+
+// Limits are the resources every container may use, they are set in the v1 hierarchies or the unified
+// hierarchy of cgroup v2, whichever the node runs. Empty or zero values are unlimited.
+type Limits struct {
+	// Memory is the memory of a container, e.g. 512m, and Swap the swap it may use on top of it
+	Memory string `yaml:"memory"`
+	Swap   string `yaml:"swap"`
+	// CPU is the number of cores, e.g. 0.5 for half of a core
+	CPU float64 `yaml:"cpu"`
+	// Pids is the number of processes and threads
+	Pids int64 `yaml:"pids"`
+}
+
+// Capacity is the part of a node the limits of its containers may add up to. Reserved CPU cores and memory are
+// kept for the host and the platform daemons, the rest may be committed overcommit times, e.g. a cpuOvercommit of 4
+// hands 4 cores of limits out per core. Containers which would exceed it are refused with insufficient capacity.
+type Capacity struct {
+	ReservedCPU      float64 `yaml:"reservedCPU"`
+	ReservedMemory   string  `yaml:"reservedMemory"`
+	CPUOvercommit    float64 `yaml:"cpuOvercommit"`
+	MemoryOvercommit float64 `yaml:"memoryOvercommit"`
+}
+
+// Breakers configures the circuit breakers guarding the database, the container runtime and the external
+// services. A breaker opens after Failures calls in a row failed because its backend was unavailable or did
+// not respond in time, calls then fail fast until a trial call after Timeout seconds succeeded.
+type Breakers struct {
+	Enabled  bool `yaml:"enabled"`
+	Failures int  `yaml:"failures"`
+	Timeout  int  `yaml:"timeout"`
+}
+
+// Login protects the logins against guessing passwords. An account is locked out for Lockout seconds once its
+// logins failed AccountThreshold times within Window seconds and a source address once its logins failed
+// SourceThreshold times, the lockout is doubled with every further failure up to MaxLockout seconds. Failures
+// are counted on each node. Once an account or address failed CaptchaAfter times its logins have to solve a
+// CAPTCHA, which is verified at CaptchaURL, the siteverify endpoint of reCAPTCHA, hCaptcha or Turnstile. No
+// CAPTCHA is required if CaptchaURL is empty. Notify emails users who log in from a network or device they
+// did not use before.
+type Login struct {
+	Enabled          bool   `yaml:"enabled"`
+	AccountThreshold int    `yaml:"accountThreshold"`
+	SourceThreshold  int    `yaml:"sourceThreshold"`
+	Window           int    `yaml:"window"`
+	Lockout          int    `yaml:"lockout"`
+	MaxLockout       int    `yaml:"maxLockout"`
+	CaptchaAfter     int    `yaml:"captchaAfter"`
+	CaptchaURL       string `yaml:"captchaURL"`
+	CaptchaSecret    string `yaml:"captchaSecret"`
+	Notify           bool   `yaml:"notify"`
+}
+
+// Payloads limits the size of the requests, e.g. 4m. MaxSize is the size of the requests of every gRPC method
+// and websocket endpoint not in Methods, which are named like site.SiteService/Deploy for gRPC or CNT/CRE for
+// the websocket transport. The deployment of sites may be as large as sites.maxBundleSize unless it is set.
+type Payloads struct {
+	MaxSize string            `yaml:"maxSize"`
+	Methods map[string]string `yaml:"methods"`
+}
+
+// Downloads hands out signed URLs large artifacts, like backups, invoices and data exports, can be downloaded
+// from at /v1/downloads/ of the gateway instead of via the APIs. BaseURL is the public URL of the gateway, the
+// URLs expire after TTL seconds. Secret signs them, it has to have at least 32 characters and can be encrypted
+// with krood -encrypt-secret.
+type Downloads struct {
+	Enabled bool   `yaml:"enabled"`
+	BaseURL string `yaml:"baseURL"`
+	Secret  string `yaml:"secret"`
+	TTL     int    `yaml:"ttl"`
+}
+
+// Ports is the range host ports are allocated from for the users
+type Ports struct {
+	From int `yaml:"from"`
+	To   int `yaml:"to"`
+}
+
+// Secrets configures how the secrets of the users are kept, like the secrets of webhooks and deploy hooks and the
+// secrets in the environments of instances. The store backend encrypts them for the database: every secret has its
+// own data key, which is encrypted with the master key Current of Keys, or with KeyID of AWS KMS if KMS is aws. The
+// vault backend keeps them in HashiCorp Vault instead. krood -rotate-secrets encrypts the secrets again with the
+// current key, or moves them into Vault, so keys which are replaced can be removed afterwards.
+type Secrets struct {
+	Enabled bool `yaml:"enabled"`
+	// Backend is store or vault, the master keys are only used to decrypt the secrets stored before with vault
+	Backend string `yaml:"backend"`
+	// KMS is local or aws
+	KMS string `yaml:"kms"`
+	// Keys are the base64 encoded master keys of 32 bytes by their id, they can only be set in the configuration file
+	Keys    map[string]string `yaml:"keys"`
+	Current string            `yaml:"current"`
+	AWS     AWSKMS            `yaml:"aws"`
+	Vault   Vault             `yaml:"vault"`
+}
+
+// store reports whether the master keys of the store are configured, the vault backend does not need them
+func (s Secrets) store() bool {
+	return s.Backend == "store" || s.KMS == "aws" || s.Current != ""
+}
+
+// AWSKMS is a symmetric key of AWS KMS
+type AWSKMS struct {
+	// Endpoint is the URL of the KMS, https://kms.<region>.amazonaws.com is used if it is empty
+	Endpoint  string `yaml:"endpoint"`
+	Region    string `yaml:"region"`
+	AccessKey string `yaml:"accessKey"`
+	SecretKey string `yaml:"secretKey"`
+	// KeyID is the id, ARN or alias of the key
+	KeyID string `yaml:"keyID"`
+}
+
+// Vault is the KV version 2 secrets engine of HashiCorp Vault the secrets are kept in with the vault backend,
+// the secrets of every user are kept below <mount>/<prefix>/users/<refID>
+type Vault struct {
+	Address   string `yaml:"address"`
+	Namespace string `yaml:"namespace"`
+	Mount     string `yaml:"mount"`
+	Prefix    string `yaml:"prefix"`
+	// Token authenticates the daemon, it logs in with the RoleID and SecretID of the AppRole auth method mounted
+	// at AppRole otherwise
+	Token    string `yaml:"token"`
+	RoleID   string `yaml:"roleID"`
+	SecretID string `yaml:"secretID"`
+	AppRole  string `yaml:"appRole"`
+}
+
+// Config are all settings of the daemon, settings tagged with reload can be changed without restarting it
+type Config struct {
+	Log         Log         `yaml:"log"`
+	Database    Database    `yaml:"database"`
+	Listen      Listen      `yaml:"listen"`
+	TLS         TLS         `yaml:"tls"`
+	Paths       Paths       `yaml:"paths"`
+	IPTables    IPTables    `yaml:"iptables"`
+	Routing     Routing     `yaml:"routing"`
+	ACME        ACME        `yaml:"acme"`
+	DNS         DNS         `yaml:"dns"`
+	Network     Network     `yaml:"network"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Events      Events      `yaml:"events"`
+	RateLimit   RateLimit   `yaml:"rateLimit"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Cache       Cache       `yaml:"cache"`
+	Agent       Agent       `yaml:"agent"`
+	SSH         SSH         `yaml:"ssh"`
+
+	ManagedDatabases ManagedDatabases `yaml:"managedDatabases"`
+	Snapshots        Snapshots        `yaml:"snapshots"`
+	Storage          Storage          `yaml:"storage"`
+	Mail             Mail             `yaml:"mail"`
+	Billing          Billing          `yaml:"billing"`
+	ModuleUI         ModuleUI         `yaml:"moduleUI"`
+	Cron             Cron             `yaml:"cron"`
+	ContainerLogs    ContainerLogs    `yaml:"containerLogs"`
+	Alerts           Alerts           `yaml:"alerts"`
+	Autoscaling      Autoscaling      `yaml:"autoscaling"`
+	Bans             Bans             `yaml:"bans"`
+	WireGuard        WireGuard        `yaml:"wireguard"`
+	Resolver         Resolver         `yaml:"resolver"`
+	Usage            Usage            `yaml:"usage"`
+	Sites            Sites            `yaml:"sites"`
+	Deploy           Deploy           `yaml:"deploy"`
+	Registry         Registry         `yaml:"registry"`
+	Checkpoints      Checkpoints      `yaml:"checkpoints"`
+	Limits           Limits           `yaml:"limits"`
+	Capacity         Capacity         `yaml:"capacity"`
+	Breakers         Breakers         `yaml:"breakers"`
+	Login            Login            `yaml:"login"`
+	Payloads         Payloads         `yaml:"payloads"`
+	Downloads        Downloads        `yaml:"downloads"`
+	Ports            Ports            `yaml:"ports"`
+	Secrets          Secrets          `yaml:"secrets"`
+	BcryptCost       int              `yaml:"bcryptCost"`
+
+	// ShutdownTimeout is the number of seconds the daemon waits for requests and workers to finish when it is stopped
+	ShutdownTimeout int `yaml:"shutdownTimeout"`
+
+	// RequestTimeout is the number of seconds a gRPC or websocket request may take before it is cancelled
+	// with the database and container operations it started, there is no deadline if it is 0
+	RequestTimeout int `yaml:"requestTimeout"`
+}
+
+// Default returns the configuration used for settings which are not set otherwise
+func Default() Config {
+	return Config{
+		Log: Log{
+			Level:  "info",
+			Format: "logfmt",
+		},
+		Database: Database{
+			Driver: "postgres",
+			DSN:    "host=postgres database=postgres user=kroo password=kroo sslmode=disable",
+		},
+		Listen: Listen{
+			GRPC:            ":8082",
+			Websocket:       ":8083",
+			WebsocketSecure: ":8084",
+			Metrics:         ":8086",
+		},
+		Paths: Paths{
+			Container:  "/var/lib/kontainerooo/container",
+			InitBinary: "/var/go/bin/kroo-init",
+		},
+		IPTables: IPTables{
+			Path:        "iptables",
+			RestorePath: "iptables-restore",
+			DropLog:     "/dev/kmsg",
+		},
+		Routing: Routing{
+			Router: "nginx",
+		},
+		Network: Network{
+			Prefix: 24,
+		},
+		Tracing: Tracing{
+			ServiceName: "krood",
+			Agent:       "localhost:6831",
+			SampleRate:  1,
+		},
+		Events: Events{
+			Backend: "memory",
+			URL:     "nats://localhost:4222",
+			Stream:  "kroo",
+		},
+		RateLimit: RateLimit{
+			Backend:  "memory",
+			RedisURL: "redis://localhost:6379",
+		},
+		Idempotency: Idempotency{
+			Backend:  "memory",
+			RedisURL: "redis://localhost:6379",
+			TTL:      86400,
+		},
+		Cache: Cache{
+			Enabled:  true,
+			Backend:  "memory",
+			RedisURL: "redis://localhost:6379",
+			Size:     10000,
+			TTL:      300,
+		},
+		Agent: Agent{
+			Heartbeat:  10,
+			Timeout:    30,
+			Reschedule: 300,
+		},
+		SSH: SSH{
+			Address:    ":2222",
+			HostKey:    "/var/lib/kontainerooo/ssh/host_key",
+			Recordings: "/var/lib/kontainerooo/ssh/recordings",
+		},
+		ManagedDatabases: ManagedDatabases{
+			Mode: "schema",
+			Postgres: DatabaseServer{
+				Port: 5432,
+			},
+			MySQL: DatabaseServer{
+				Port: 3306,
+			},
+			DumpPath:      "/var/lib/kontainerooo/dumps",
+			DumpInterval:  24 * 60 * 60,
+			DumpRetention: 7,
+		},
+		Snapshots: Snapshots{
+			LocalPath:   "/var/lib/kontainerooo/snapshots",
+			RestorePath: "/var/lib/kontainerooo/volumes",
+			Retention:   7,
+		},
+		Storage: Storage{
+			Backend:   "local",
+			LocalPath: "/var/lib/kontainerooo/artifacts",
+			S3: S3{
+				PartSize: 16,
+			},
+		},
+		Mail: Mail{
+			Provider: "smtp",
+			SMTP: SMTP{
+				Port: 587,
+			},
+		},
+		Billing: Billing{
+			Currency:    "eur",
+			GracePeriod: 14,
+		},
+		ModuleUI: ModuleUI{
+			MaxAge: 3600,
+		},
+		Cron: Cron{
+			History:   20,
+			MaxOutput: 64 * 1024,
+		},
+		ContainerLogs: ContainerLogs{
+			Interval: 5,
+			MaxLine:  16 * 1024,
+		},
+		Alerts: Alerts{
+			MaxRules: 20,
+			History:  100,
+		},
+		Autoscaling: Autoscaling{
+			Interval:    30,
+			MaxPolicies: 10,
+			MaxReplicas: 10,
+			MaxWindows:  5,
+			History:     100,
+		},
+		Bans: Bans{
+			Interval:   5,
+			MaxJails:   10,
+			MaxBanTime: 7 * 24 * 3600,
+		},
+		WireGuard: WireGuard{
+			Interface:  "kroowg0",
+			Port:       51820,
+			Pool:       "10.99.0.0/16",
+			PrivateKey: "/var/lib/kontainerooo/wireguard/private.key",
+			MaxPeers:   5,
+			MaxKeyAge:  90,
+			Interval:   10,
+		},
+		Resolver: Resolver{
+			Listen: ":53",
+			Domain: "internal",
+			TTL:    5,
+		},
+		Usage: Usage{
+			Interval:             60,
+			RawRetention:         24,
+			FiveMinuteRetention:  7,
+			HourRetention:        90,
+			RecommendationPeriod: 14,
+			Headroom:             0.2,
+		},
+		Sites: Sites{
+			Root:          "/var/lib/kontainerooo/sites",
+			Address:       "0.0.0.0",
+			MaxBundleSize: "100m",
+			MaxAge:        3600,
+			Interval:      60,
+		},
+		Deploy: Deploy{
+			Root:      "/var/lib/kontainerooo/deploy",
+			Branch:    "master",
+			Keep:      3,
+			Docker:    "docker",
+			Pack:      "pack",
+			Buildpack: "heroku/builder:22",
+		},
+		Registry: Registry{
+			Listen:      ":5000",
+			Upstream:    "https://registry-1.docker.io",
+			Root:        "/var/lib/kontainerooo/registry",
+			Quota:       "20g",
+			ManifestTTL: 300,
+			Unused:      168,
+			Interval:    3600,
+		},
+		Checkpoints: Checkpoints{
+			CRIU: "criu",
+		},
+		Capacity: Capacity{
+			ReservedCPU:      0.5,
+			ReservedMemory:   "512m",
+			CPUOvercommit:    4,
+			MemoryOvercommit: 1,
+		},
+		Breakers: Breakers{
+			Enabled:  true,
+			Failures: 5,
+			Timeout:  30,
+		},
+		Login: Login{
+			Enabled:          true,
+			AccountThreshold: 5,
+			SourceThreshold:  20,
+			Window:           900,
+			Lockout:          60,
+			MaxLockout:       3600,
+			CaptchaAfter:     3,
+			Notify:           true,
+		},
+		Payloads: Payloads{
+			MaxSize: "4m",
+		},
+		Downloads: Downloads{
+			TTL: 3600,
+		},
+		Ports: Ports{
+			From: 20000,
+			To:   29999,
+		},
+		Secrets: Secrets{
+			Backend: "store",
+			KMS:     "local",
+			Vault: Vault{
+				Mount:   "secret",
+				Prefix:  "kroo",
+				AppRole: "approle",
+			},
+		},
+		BcryptCost:      15,
+		ShutdownTimeout: 30,
+		RequestTimeout:  300,
+	}
+}
+
+// FromConfigFile returns the configuration of a legacy JSON config file
+func FromConfigFile(f util.ConfigFile) Config {
+	c := Default()
+	c.Paths.Rootfs = f.RootfsPath
+	c.Paths.Customer = f.CustomerPath
+	c.Paths.NetNS = f.NetNSPath
+	c.Paths.StandardPathVariable = f.StandardPathVariable
+	c.Routing.AnalyticsPath = f.AnalyticsPath
+	c.Routing.TemplatePath = f.RoutingTemplatePath
+	if f.Router != "" {
+		c.Routing.Router = f.Router
+	}
+	c.ACME.Email = f.ACMEEmail
+	c.ACME.Directory = f.ACMEDirectory
+	c.ACME.Wildcard = f.ACMEWildcard
+	c.ACME.CertificatePath = f.CertificatePath
+	c.DNS.BaseDomain = f.BaseDomain
+	c.DNS.PublicIPv4 = f.PublicIPv4
+	c.DNS.PublicIPv6 = f.PublicIPv6
+	c.DNS.Target = f.DNSTarget
+	c.DNS.PowerDNSURL = f.PowerDNSURL
+	c.DNS.PowerDNSAPIKey = f.PowerDNSAPIKey
+	c.Network.Pool = f.NetworkPool
+	if f.NetworkPrefix != 0 {
+		c.Network.Prefix = f.NetworkPrefix
+	}
+	c.Network.NodeName = f.NodeName
+	c.Network.NodeAddress = f.NodeAddress
+	c.Network.Metering = f.NetworkMetering
+	c.IPTables.Enabled = f.Firewall
+	c.Checkpoints.Enabled = f.Checkpoints
+	if f.CRIUPath != "" {
+		c.Checkpoints.CRIU = f.CRIUPath
+	}
+	c.Checkpoints.Restart = f.CheckpointRestart
+	if f.MemoryLimit > 0 {
+		c.Limits.Memory = strconv.FormatInt(f.MemoryLimit, 10)
+	}
+	if f.SwapLimit > 0 {
+		c.Limits.Swap = strconv.FormatInt(f.SwapLimit, 10)
+	}
+	c.Limits.CPU = f.CPULimit
+	c.Limits.Pids = f.PidsLimit
+	if f.ReservedCPU > 0 {
+		c.Capacity.ReservedCPU = f.ReservedCPU
+	}
+	if f.ReservedMemory > 0 {
+		c.Capacity.ReservedMemory = strconv.FormatInt(f.ReservedMemory, 10)
+	}
+	if f.CPUOvercommit > 0 {
+		c.Capacity.CPUOvercommit = f.CPUOvercommit
+	}
+	if f.MemoryOvercommit > 0 {
+		c.Capacity.MemoryOvercommit = f.MemoryOvercommit
+	}
+	c.TLS.CertFile = f.GRPCCertFile
+	c.TLS.KeyFile = f.GRPCKeyFile
+	c.Listen.Gateway = f.GatewayAddr
+	return c
+}
+
+// size returns the bytes of a size validated by Validate, empty sizes are 0
+func size(s string) int64 {
+	n, err := routing.ParseSize(s)
+	if err != nil {
+		return 0
+	}
+	return int64(n)
+}
+
+// ConfigFile returns the configuration in the form of the legacy config file, which is still read by some services
+func (c Config) ConfigFile() util.ConfigFile {
+	return util.ConfigFile{
+		RootfsPath:           c.Paths.Rootfs,
+		CustomerPath:         c.Paths.Customer,
+		NetNSPath:            c.Paths.NetNS,
+		StandardPathVariable: c.Paths.StandardPathVariable,
+		BaseDomain:           c.DNS.BaseDomain,
+		ACMEDirectory:        c.ACME.Directory,
+		ACMEEmail:            c.ACME.Email,
+		ACMEWildcard:         c.ACME.Wildcard,
+		CertificatePath:      c.ACME.CertificatePath,
+		AnalyticsPath:        c.Routing.AnalyticsPath,
+		RoutingTemplatePath:  c.Routing.TemplatePath,
+		Router:               c.Routing.Router,
+		PublicIPv4:           c.DNS.PublicIPv4,
+		PublicIPv6:           c.DNS.PublicIPv6,
+		DNSTarget:            c.DNS.Target,
+		PowerDNSURL:          c.DNS.PowerDNSURL,
+		PowerDNSAPIKey:       c.DNS.PowerDNSAPIKey,
+		NetworkPool:          c.Network.Pool,
+		NetworkPrefix:        c.Network.Prefix,
+		NodeName:             c.Network.NodeName,
+		NodeAddress:          c.Network.NodeAddress,
+		NetworkMetering:      c.Network.Metering,
+		Firewall:             c.IPTables.Enabled,
+		Checkpoints:          c.Checkpoints.Enabled,
+		CRIUPath:             c.Checkpoints.CRIU,
+		CheckpointRestart:    c.Checkpoints.Restart,
+		MemoryLimit:          size(c.Limits.Memory),
+		SwapLimit:            size(c.Limits.Swap),
+		CPULimit:             c.Limits.CPU,
+		PidsLimit:            c.Limits.Pids,
+		ReservedCPU:          c.Capacity.ReservedCPU,
+		ReservedMemory:       size(c.Capacity.ReservedMemory),
+		CPUOvercommit:        c.Capacity.CPUOvercommit,
+		MemoryOvercommit:     c.Capacity.MemoryOvercommit,
+		GRPCCertFile:         c.TLS.CertFile,
+		GRPCKeyFile:          c.TLS.KeyFile,
+		GatewayAddr:          c.Listen.Gateway,
+	}
+}
+
+// SecretStore returns the store encrypting the secrets kept in the database, like the encrypted settings, it is nil
+// if secrets are disabled or the vault backend is used without master keys
+func (c Config) SecretStore() (*secret.Store, error) {
+	if !c.Secrets.Enabled || !c.Secrets.store() {
+		return nil, nil
+	}
+
+	return secret.New(secret.Options{
+		KMS:     c.Secrets.KMS,
+		Keys:    c.Secrets.Keys,
+		Current: c.Secrets.Current,
+		AWS: secret.AWSOptions{
+			Endpoint:  c.Secrets.AWS.Endpoint,
+			Region:    c.Secrets.AWS.Region,
+			AccessKey: c.Secrets.AWS.AccessKey,
+			SecretKey: c.Secrets.AWS.SecretKey,
+			KeyID:     c.Secrets.AWS.KeyID,
+		},
+	})
+}
+
+// SecretBackend returns the backend keeping the secrets of the users, secret.Plaintext if secrets are disabled
+func (c Config) SecretBackend() (secret.Backend, error) {
+	if !c.Secrets.Enabled {
+		return secret.Plaintext, nil
+	}
+
+	store, err := c.SecretStore()
+	if err != nil || c.Secrets.Backend != "vault" {
+		return store, err
+	}
+
+	return secret.NewVault(secret.VaultOptions{
+		Address:   c.Secrets.Vault.Address,
+		Namespace: c.Secrets.Vault.Namespace,
+		Mount:     c.Secrets.Vault.Mount,
+		Prefix:    c.Secrets.Vault.Prefix,
+		Token:     c.Secrets.Vault.Token,
+		RoleID:    c.Secrets.Vault.RoleID,
+		SecretID:  c.Secrets.Vault.SecretID,
+		AppRole:   c.Secrets.Vault.AppRole,
+	}, store, nil), nil
+}
+
+// ReadFile reads the settings of a YAML file into the configuration, files ending in .json are read
+// as legacy config files and replace the whole configuration
+func (c *Config) ReadFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if filepath.Ext(path) == ".json" {
+		f := util.ConfigFile{}
+		err = json.Unmarshal(data, &f)
+		if err != nil {
+			return err
+		}
+		*c = FromConfigFile(f)
+		return nil
+	}
+
+	return yaml.UnmarshalStrict(data, c)
+}
+
+// Load returns the default configuration overridden by the file at path, the environment and the flags
+// which were set. If path is empty DefaultPath or the legacy config file are read if they exist.
+func Load(path string, environ []string, flags Flags) (Config, error) {
+	c := Default()
+
+	if path == "" {
+		for _, p := range []string{DefaultPath, util.ConfigFileName} {
+			if _, err := os.Stat(p); err == nil {
+				path = p
+				break
+			}
+		}
+	}
+
+	if path != "" {
+		err := c.ReadFile(path)
+		if err != nil {
+			return c, err
+		}
+	}
+
+	err := c.ApplyEnv(environ)
+	if err != nil {
+		return c, err
+	}
+
+	err = c.ApplyFlags(flags)
+	if err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
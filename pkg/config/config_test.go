@@ -0,0 +1,798 @@
+package config_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/config"
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Config", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "config")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		return path
+	}
+
+	Describe("Load", func() {
+		It("Should return the defaults", func() {
+			c, err := config.Load(write("empty.yml", ""), nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c).To(Equal(config.Default()))
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("Should read a YAML file", func() {
+			path := write("config.yml", "database:\n  dsn: host=db\nlisten:\n  grpc: :9000\nnetwork:\n  pool: 10.0.0.0/16\n")
+			c, err := config.Load(path, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Database.DSN).To(Equal("host=db"))
+			Expect(c.Database.Driver).To(Equal("postgres"))
+			Expect(c.Listen.GRPC).To(Equal(":9000"))
+			Expect(c.Network.Pool).To(Equal("10.0.0.0/16"))
+			Expect(c.Network.Prefix).To(Equal(24))
+		})
+
+		It("Should reject unknown settings", func() {
+			_, err := config.Load(write("config.yml", "database:\n  dns: host=db\n"), nil, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should read a legacy JSON file", func() {
+			path := write("config.json", `{"Router": "traefik", "NetworkPool": "10.0.0.0/16", "GatewayAddr": ":8085"}`)
+			c, err := config.Load(path, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Routing.Router).To(Equal("traefik"))
+			Expect(c.Network.Pool).To(Equal("10.0.0.0/16"))
+			Expect(c.Network.Prefix).To(Equal(24))
+			Expect(c.Listen.Gateway).To(Equal(":8085"))
+			Expect(c.ConfigFile().Router).To(Equal("traefik"))
+		})
+
+		It("Should override settings with the environment", func() {
+			path := write("config.yml", "database:\n  dsn: host=db\n")
+			c, err := config.Load(path, []string{
+				"KROO_DATABASE_DSN=host=env",
+				"KROO_DATABASE_MOCK=true",
+				"KROO_BCRYPTCOST=10",
+				"KROO_LISTEN_WEBSOCKETSECURE=",
+			}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Database.DSN).To(Equal("host=env"))
+			Expect(c.Database.Mock).To(BeTrue())
+			Expect(c.BcryptCost).To(Equal(10))
+			Expect(c.Listen.WebsocketSecure).To(BeEmpty())
+		})
+
+		It("Should return an error for malformed environment variables", func() {
+			_, err := config.Load(write("empty.yml", ""), []string{"KROO_NETWORK_PREFIX=abc"}, nil)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should override settings with flags which were set", func() {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags := config.NewFlags(fs)
+			Expect(fs.Parse([]string{"-database.dsn", "host=flag", "-iptables.enabled", "-network.prefix=26"})).To(Succeed())
+
+			c, err := config.Load(write("empty.yml", ""), []string{"KROO_DATABASE_DSN=host=env", "KROO_LISTEN_GRPC=:9000"}, flags)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Database.DSN).To(Equal("host=flag"))
+			Expect(c.IPTables.Enabled).To(BeTrue())
+			Expect(c.Network.Prefix).To(Equal(26))
+			Expect(c.Listen.GRPC).To(Equal(":9000"))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("Should report every invalid setting", func() {
+			c := config.Default()
+			c.Log.Modules = "routing"
+			c.Log.Format = "xml"
+			c.Database.DSN = ""
+			c.Listen.GRPC = "8082"
+			c.TLS.CertFile = "cert.pem"
+			c.BcryptCost = 2
+			c.Routing.Router = "apache"
+			c.Network.Pool = "10.0.0.0/16"
+			c.Network.Prefix = 8
+
+			err := c.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(config.Errors{}))
+			Expect(err.(config.Errors)).To(HaveLen(8))
+		})
+
+		It("Should not require a database if it is mocked", func() {
+			c := config.Default()
+			c.Database.Mock = true
+			c.Database.DSN = ""
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("Should require a pool for the overlay", func() {
+			c := config.Default()
+			c.Network.NodeName = "node1"
+			c.Network.NodeAddress = "192.168.0.1"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Network.Pool = "10.0.0.0/16"
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("Should require a server for the nats event bus", func() {
+			c := config.Default()
+			c.Events.Backend = "nats"
+			c.Events.URL = ""
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Events.URL = "nats://nats:4222"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Events.Stream = "kroo.events"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should read and check the rate limit plans", func() {
+			c, err := config.Load(write("config.yml", "rateLimit:\n  enabled: true\n  plans:\n    default:\n      rate: 5\n      burst: 10\n      methods:\n        CNT/CRE:\n          rate: 0.1\n"), nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.RateLimit.Plans["default"].Burst).To(Equal(10))
+			Expect(c.RateLimit.Plans["default"].Methods["CNT/CRE"].Rate).To(Equal(0.1))
+			Expect(c.Validate()).To(Succeed())
+
+			c.RateLimit.Backend = "memcached"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the idempotency settings", func() {
+			c := config.Default()
+			c.Idempotency.Backend = "redis"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Idempotency.TTL = 0
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the request timeout", func() {
+			c := config.Default()
+			c.RequestTimeout = 0
+			Expect(c.Validate()).To(Succeed())
+
+			c.RequestTimeout = -1
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the breaker settings", func() {
+			c := config.Default()
+			c.Breakers.Failures = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Breakers.Enabled = false
+			Expect(c.Validate()).To(Succeed())
+
+			c = config.Default()
+			c.Breakers.Timeout = 0
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the drop log settings", func() {
+			c := config.Default()
+			c.IPTables.LogDrops = "nflog"
+			c.IPTables.NFLogGroup = 5
+			Expect(c.Validate()).To(Succeed())
+
+			c.IPTables.LogDrops = "syslog"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c = config.Default()
+			c.IPTables.NFLogGroup = 70000
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c = config.Default()
+			c.IPTables.LogDrops = "log"
+			c.IPTables.DropLog = ""
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the port range", func() {
+			c := config.Default()
+			c.Ports.From = 80
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c = config.Default()
+			c.Ports.From, c.Ports.To = 30000, 20000
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c = config.Default()
+			c.Ports.To = 70000
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the secrets settings", func() {
+			c := config.Default()
+			c.Registry.Password = secret.Prefix + "a.b.c"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Secrets.Enabled = true
+			c.Secrets.Keys = map[string]string{"a": base64.StdEncoding.EncodeToString(make([]byte, secret.DataKeySize))}
+			c.Secrets.Current = "a"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Secrets.Current = "b"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Secrets.KMS = "aws"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Secrets.AWS = config.AWSKMS{Region: "eu-central-1", AccessKey: "access", SecretKey: "secret", KeyID: "alias/kroo"}
+			Expect(c.Validate()).To(Succeed())
+
+			c.Secrets.Keys["b"] = "c2hvcnQ="
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the vault settings", func() {
+			c := config.Default()
+			c.Secrets.Enabled = true
+			c.Secrets.Backend = "vault"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Secrets.Vault.Address = "https://vault:8200"
+			c.Secrets.Vault.RoleID = "role"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Secrets.Vault.SecretID = "id"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Registry.Password = secret.Prefix + "a.b.c"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Secrets.Keys = map[string]string{"a": base64.StdEncoding.EncodeToString(make([]byte, secret.DataKeySize))}
+			c.Secrets.Current = "a"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Secrets.Backend = "consul"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the cache settings", func() {
+			c := config.Default()
+			c.Cache.Size = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Cache.Backend = "redis"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Cache.TTL = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Cache.Enabled = false
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("Should check the agent settings", func() {
+			c := config.Default()
+			c.Agent.ControlPlane = "krood"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Agent.ControlPlane = "krood:8082"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Agent.Reschedule = 10
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Agent.Reschedule = 0
+			Expect(c.Validate()).To(Succeed())
+
+			c.Agent.Timeout = c.Agent.Heartbeat
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the SSH settings", func() {
+			c := config.Default()
+			c.SSH.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.SSH.Address = "2222"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.SSH.Address = ":2222"
+			c.SSH.Recordings = ""
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.SSH.Enabled = false
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("Should check the managed database settings", func() {
+			c := config.Default()
+			c.ManagedDatabases.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.ManagedDatabases.Postgres.DSN = "host=localhost user=postgres"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.ManagedDatabases.Postgres.Host = "10.0.0.2"
+			Expect(c.Validate()).To(Succeed())
+
+			c.ManagedDatabases.Mode = "cluster"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.ManagedDatabases.Mode = "container"
+			c.ManagedDatabases.DumpRetention = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.ManagedDatabases.Enabled = false
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("Should check the cron settings", func() {
+			c := config.Default()
+			c.Cron.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Cron.History = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Cron.History = 5
+			c.Cron.MaxOutput = -1
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the container log settings", func() {
+			c := config.Default()
+			c.ContainerLogs.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.ContainerLogs.Interval = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.ContainerLogs.Interval = 5
+			c.ContainerLogs.MaxLine = -1
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.ContainerLogs.MaxLine = 1024
+			c.ContainerLogs.Sinks = []containerlog.SinkConfig{
+				{Name: "syslog", Type: containerlog.Syslog, URL: "udp://10.0.0.1:514"},
+				{Name: "loki", Type: containerlog.Loki, URL: "https://loki.example.com", Users: []uint{1}},
+			}
+			Expect(c.Validate()).To(Succeed())
+
+			c.ContainerLogs.Sinks[1].Name = "syslog"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.ContainerLogs.Sinks[1].Name = "loki"
+			c.ContainerLogs.Sinks[0].URL = "http://10.0.0.1:514"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.ContainerLogs.Sinks[0].URL = "udp://10.0.0.1:514"
+			c.ContainerLogs.Sinks[1].Type = "kafka"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the alert settings", func() {
+			c := config.Default()
+			c.Alerts.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Alerts.MaxRules = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Alerts.MaxRules = 5
+			c.Alerts.History = -1
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the autoscaling settings", func() {
+			c := config.Default()
+			c.Autoscaling.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Autoscaling.Interval = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Autoscaling.Interval = 30
+			c.Autoscaling.MaxReplicas = -1
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Autoscaling.MaxReplicas = 10
+			c.Autoscaling.MaxWindows = 0
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the ban settings", func() {
+			c := config.Default()
+			c.Bans.Enabled = true
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.IPTables.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Bans.Ignore = []string{"10.0.0.0/8", "192.168.1.1"}
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Bans.Ignore = []string{"10.0.0.0/8"}
+			c.Bans.MaxBanTime = 0
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the login settings", func() {
+			c := config.Default()
+			c.Login.MaxLockout = 30
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Login.MaxLockout = 3600
+			c.Login.CaptchaURL = "https://hcaptcha.com/siteverify"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Login.CaptchaSecret = "0x0000"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Login.Enabled = false
+			c.Login.Window = 0
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("Should check the sizes of the requests", func() {
+			c := config.Default()
+			c.Payloads.MaxSize = "0"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Payloads.MaxSize = "1m"
+			c.Payloads.Methods = map[string]string{"site.SiteService/Deploy": "200m", "CNT/CRE": "4x"}
+			Expect(c.Validate()).NotTo(Succeed())
+
+			delete(c.Payloads.Methods, "CNT/CRE")
+			Expect(c.Validate()).To(Succeed())
+		})
+
+		It("Should check the download settings", func() {
+			c := config.Default()
+			c.Downloads.Enabled = true
+			c.Downloads.BaseURL = "https://kroo.example.com"
+			c.Downloads.Secret = "too short"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Downloads.Secret = "0123456789abcdef0123456789abcdef"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Listen.Gateway = ":8085"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Downloads.BaseURL = "kroo.example.com"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the wireguard settings", func() {
+			c := config.Default()
+			c.WireGuard.Enabled = true
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.IPTables.Enabled = true
+			c.Network.Pool = "10.0.0.0/16"
+			c.WireGuard.Endpoint = "vpn.kontainer.ooo"
+			Expect(c.Validate()).To(Succeed())
+
+			c.WireGuard.Pool = "10.99.0.1"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.WireGuard.Pool = "10.0.128.0/24"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.WireGuard.Pool = "10.99.0.0/16"
+			c.WireGuard.Port = 70000
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the resolver settings", func() {
+			c := config.Default()
+			c.Resolver.Enabled = true
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Network.Pool = "10.0.0.0/16"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Resolver.Upstream = "1.1.1.1"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Resolver.Upstream = "1.1.1.1:53"
+			c.Resolver.Domain = "internal."
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the usage settings", func() {
+			c := config.Default()
+			c.Usage.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Usage.Interval = 0
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Usage.Interval = 60
+			c.Usage.HourRetention = -1
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Usage.HourRetention = 7
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Usage.HourRetention = 90
+			c.Usage.Headroom = -0.1
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the site settings", func() {
+			c := config.Default()
+			c.Sites.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Sites.MaxBundleSize = "0"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Sites.MaxBundleSize = "100m"
+			c.Routing.Router = "traefik"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the deploy settings", func() {
+			c := config.Default()
+			c.Deploy.Enabled = true
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.SSH.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Deploy.Keep = 0
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the registry settings", func() {
+			c := config.Default()
+			c.Registry.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Registry.Upstream = "registry-1.docker.io"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Registry.Upstream = "https://registry-1.docker.io"
+			c.Registry.Quota = "lots"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the checkpoint settings", func() {
+			c := config.Default()
+			c.Checkpoints.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Checkpoints.CRIU = ""
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the resource limits", func() {
+			c := config.Default()
+			c.Limits.Memory = "512m"
+			c.Limits.Swap = "128m"
+			c.Limits.CPU = 0.5
+			Expect(c.Validate()).To(Succeed())
+			Expect(c.ConfigFile().MemoryLimit).To(BeEquivalentTo(512 << 20))
+
+			c.Limits.Memory = "lots"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Limits.Memory = ""
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the capacity policy", func() {
+			c := config.Default()
+			Expect(c.Validate()).To(Succeed())
+			Expect(c.ConfigFile().ReservedMemory).To(BeEquivalentTo(512 << 20))
+
+			c.Capacity.MemoryOvercommit = 0.5
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Capacity.MemoryOvercommit = 1
+			c.Capacity.ReservedMemory = "half"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the snapshot settings", func() {
+			c := config.Default()
+			c.Snapshots.Enabled = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Snapshots.S3.Endpoint = "https://s3.eu-central-1.amazonaws.com"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Snapshots.S3 = config.S3{
+				Endpoint:  "https://s3.eu-central-1.amazonaws.com",
+				Region:    "eu-central-1",
+				Bucket:    "snapshots",
+				AccessKey: "AKID",
+				SecretKey: "secret",
+			}
+			Expect(c.Validate()).To(Succeed())
+
+			c.Snapshots.S3.Endpoint = "s3.eu-central-1.amazonaws.com"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Snapshots.S3.Endpoint = ""
+			c.Snapshots.Retention = 0
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the mail settings", func() {
+			c := config.Default()
+			c.Mail.Enabled = true
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Mail.From = "kontainer.ooo <noreply@kontainer.ooo>"
+			c.Mail.Sandbox = true
+			Expect(c.Validate()).To(Succeed())
+
+			c.Mail.Sandbox = false
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Mail.SMTP.Host = "mail.kontainer.ooo"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Mail.Provider = "mailgun"
+			c.Mail.Mailgun.APIKey = "key"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Mail.Mailgun.Domain = "mg.kontainer.ooo"
+			c.Mail.Mailgun.Endpoint = "https://api.eu.mailgun.net"
+			Expect(c.Validate()).To(Succeed())
+
+			c.Mail.Provider = "sendgrid"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Mail.Provider = "postfix"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Mail.Provider = "smtp"
+			c.Mail.TemplatePath = "/does/not/exist"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should read and check the billing settings", func() {
+			c, err := config.Load(write("config.yml", "billing:\n  enabled: true\n  stripe:\n    secretKey: sk_live\n  plans:\n    \"1\":\n      price: 900\n      trafficPrice: 5\n"), nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Billing.Plans["1"].Price).To(Equal(uint64(900)))
+			Expect(c.Billing.GracePeriod).To(Equal(14))
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Billing.Stripe.WebhookSecret = "whsec"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			p := c.Billing.Plans["1"]
+			p.StripePrice = "price_1"
+			c.Billing.Plans["1"] = p
+			Expect(c.Validate()).To(Succeed())
+
+			c.Billing.Currency = "euro"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should check the storage settings", func() {
+			c := config.Default()
+			c.Storage.LocalPath = ""
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Storage.Backend = "s3"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Storage.S3 = config.S3{
+				Endpoint:   "https://s3.eu-central-1.amazonaws.com",
+				Region:     "eu-central-1",
+				Bucket:     "artifacts",
+				AccessKey:  "AKID",
+				SecretKey:  "secret",
+				Encryption: "aws:kms",
+				KMSKeyID:   "key1",
+			}
+			Expect(c.Validate()).To(Succeed())
+
+			c.Storage.S3.Encryption = "AES256"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Storage.S3.KMSKeyID = ""
+			c.Storage.S3.PartSize = 4
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Storage.S3.PartSize = 0
+			c.Storage.S3.Encryption = "des"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.Storage.Backend = "ftp"
+			Expect(c.Validate()).NotTo(Succeed())
+		})
+
+		It("Should require a base domain for certificates", func() {
+			c := config.Default()
+			c.ACME.Email = "admin@kontainer.ooo"
+			c.ACME.CertificatePath = "/var/lib/kontainerooo/certificates"
+			Expect(c.Validate()).NotTo(Succeed())
+
+			c.DNS.BaseDomain = "kontainer.ooo"
+			Expect(c.Validate()).To(Succeed())
+		})
+	})
+	Describe("Reload", func() {
+		It("Should declare which settings are reloadable", func() {
+			Expect(config.Reloadable()).To(ConsistOf("log.level", "log.modules", "routing.templatePath", "acme.email", "rateLimit.plans"))
+		})
+
+		It("Should only update reloadable settings", func() {
+			c, n := config.Default(), config.Default()
+			n.Log.Level = "debug"
+			n.Database.DSN = "host=db"
+
+			changed, ignored := c.Update(n)
+			Expect(changed).To(Equal([]string{"log.level"}))
+			Expect(ignored).To(Equal([]string{"database.dsn"}))
+			Expect(c.Log.Level).To(Equal("debug"))
+			Expect(c.Database.DSN).To(Equal(config.Default().Database.DSN))
+		})
+
+		It("Should apply changed settings", func() {
+			path := write("config.yml", "log:\n  level: info\n")
+			r := config.NewReloader(config.Default(), func() (config.Config, error) {
+				return config.Load(path, nil, nil)
+			})
+
+			var level string
+			r.OnReload(func(old, new config.Config) error {
+				level = new.Log.Level
+				return nil
+			})
+
+			write("config.yml", "log:\n  level: warn\nbcryptCost: 10\n")
+			changed, ignored, err := r.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(Equal([]string{"log.level"}))
+			Expect(ignored).To(Equal([]string{"bcryptCost"}))
+			Expect(level).To(Equal("warn"))
+			Expect(r.Config().Log.Level).To(Equal("warn"))
+			Expect(r.Config().BcryptCost).To(Equal(15))
+		})
+
+		It("Should keep the configuration if the reload fails", func() {
+			path := write("config.yml", "log:\n  level: loud\n")
+			r := config.NewReloader(config.Default(), func() (config.Config, error) {
+				return config.Load(path, nil, nil)
+			})
+
+			_, _, err := r.Reload()
+			Expect(err).To(HaveOccurred())
+
+			write("config.yml", "log:\n  level: debug\n")
+			r.OnReload(func(old, new config.Config) error {
+				return errors.New("fail")
+			})
+
+			_, _, err = r.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(r.Config().Log.Level).To(Equal("info"))
+		})
+	})
+})
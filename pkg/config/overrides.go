@@ -0,0 +1,173 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// setting is a single value of the configuration
+type setting struct {
+	// path are the yaml names of the sections and the setting, e.g. [database dsn]
+	path  []string
+	value reflect.Value
+
+	// reloadable settings can be changed while the daemon is running
+	reloadable bool
+}
+
+// name returns the name of the flag of a setting
+func (s setting) name() string {
+	return strings.Join(s.path, ".")
+}
+
+// env returns the name of the environment variable of a setting
+func (s setting) env() string {
+	return strings.ToUpper(strings.Join(append([]string{EnvPrefix}, s.path...), "_"))
+}
+
+// set parses v into the setting
+func (s setting) set(v string) error {
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(v)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", s.name(), err)
+		}
+		s.value.SetBool(b)
+	case reflect.Int:
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%s: %v", s.name(), err)
+		}
+		s.value.SetInt(int64(i))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %v", s.name(), err)
+		}
+		s.value.SetFloat(f)
+	default:
+		return fmt.Errorf("%s: unsupported type %s", s.name(), s.value.Type())
+	}
+	return nil
+}
+
+// settings returns every setting of c
+func (c *Config) settings() []setting {
+	ss := []setting{}
+
+	var walk func(v reflect.Value, path []string)
+	walk = func(v reflect.Value, path []string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+			p := append(append([]string{}, path...), name)
+
+			if v.Field(i).Kind() == reflect.Struct {
+				walk(v.Field(i), p)
+				continue
+			}
+
+			ss = append(ss, setting{
+				path:       p,
+				value:      v.Field(i),
+				reloadable: t.Field(i).Tag.Get("reload") == "true",
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), nil)
+
+	return ss
+}
+
+// ApplyEnv overrides settings with the environment variables of environ, which is in the form of os.Environ
+func (c *Config) ApplyEnv(environ []string) error {
+	env := make(map[string]string)
+	for _, e := range environ {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) == 2 {
+			env[kv[0]] = kv[1]
+		}
+	}
+
+	for _, s := range c.settings() {
+		v, ok := env[s.env()]
+		if !ok {
+			continue
+		}
+
+		err := s.set(v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flagValue stores the value of a flag until it is applied to a configuration
+type flagValue struct {
+	kind  reflect.Kind
+	value string
+	set   bool
+}
+
+func (f *flagValue) String() string {
+	return f.value
+}
+
+func (f *flagValue) Set(v string) error {
+	f.value = v
+	f.set = true
+	return nil
+}
+
+// IsBoolFlag allows boolean settings to be given without a value
+func (f *flagValue) IsBoolFlag() bool {
+	return f.kind == reflect.Bool
+}
+
+// Flags are the command line overrides of the settings, they are named like the settings in the file, e.g. -database.dsn
+type Flags map[string]*flagValue
+
+// NewFlags registers a flag for every setting on fs
+func NewFlags(fs *flag.FlagSet) Flags {
+	flags := make(Flags)
+
+	d := Default()
+	for _, s := range d.settings() {
+		// maps like the rate limit plans can only be set in the configuration file
+		if s.value.Kind() == reflect.Map {
+			continue
+		}
+
+		f := &flagValue{
+			kind: s.value.Kind(),
+		}
+		flags[s.name()] = f
+
+		usage := fmt.Sprintf("Overrides %s, the default is %v (environment variable %s).", s.name(), s.value.Interface(), s.env())
+		fs.Var(f, s.name(), usage)
+	}
+	return flags
+}
+
+// ApplyFlags overrides settings with the flags which were set
+func (c *Config) ApplyFlags(flags Flags) error {
+	for _, s := range c.settings() {
+		f, ok := flags[s.name()]
+		if !ok || !f.set {
+			continue
+		}
+
+		err := s.set(f.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
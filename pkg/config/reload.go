@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Reloadable returns the names of the settings which can be changed while the daemon is running
+func Reloadable() []string {
+	names := []string{}
+
+	c := Default()
+	for _, s := range c.settings() {
+		if s.reloadable {
+			names = append(names, s.name())
+		}
+	}
+	return names
+}
+
+// Update copies the reloadable settings of n which differ into c. It returns the names of the
+// changed settings and of the settings which differ but can not be changed without a restart.
+func (c *Config) Update(n Config) (changed []string, ignored []string) {
+	changed, ignored = []string{}, []string{}
+
+	ns := n.settings()
+	for i, s := range c.settings() {
+		if reflect.DeepEqual(s.value.Interface(), ns[i].value.Interface()) {
+			continue
+		}
+
+		if !s.reloadable {
+			ignored = append(ignored, s.name())
+			continue
+		}
+
+		s.value.Set(ns[i].value)
+		changed = append(changed, s.name())
+	}
+	return changed, ignored
+}
+
+// ReloadFunc applies a reloaded configuration, it should check new before changing anything
+// since the reload is aborted on the first error
+type ReloadFunc func(old, new Config) error
+
+// Reloader reloads the configuration while the daemon is running
+type Reloader struct {
+	mtx      sync.Mutex
+	config   Config
+	load     func() (Config, error)
+	handlers []ReloadFunc
+}
+
+// Config returns the current configuration
+func (r *Reloader) Config() Config {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.config
+}
+
+// OnReload registers a function called with every configuration whose reloadable settings changed
+func (r *Reloader) OnReload(f ReloadFunc) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.handlers = append(r.handlers, f)
+}
+
+// Reload loads the configuration and applies the reloadable settings which changed. Changed settings
+// which are not reloadable are returned as ignored, they take effect when the daemon is restarted.
+func (r *Reloader) Reload() (changed []string, ignored []string, err error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	n, err := r.load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = n.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := r.config
+	changed, ignored = c.Update(n)
+	if len(changed) == 0 {
+		return changed, ignored, nil
+	}
+
+	for _, h := range r.handlers {
+		err = h(r.config, c)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	r.config = c
+	return changed, ignored, nil
+}
+
+// Watch reloads the configuration whenever a signal is received, it returns once signals is closed
+func (r *Reloader) Watch(signals <-chan os.Signal, logger log.Logger) {
+	for range signals {
+		changed, ignored, err := r.Reload()
+		if err != nil {
+			level.Error(logger).Log("msg", "reloading configuration failed", "err", err)
+			continue
+		}
+
+		level.Info(logger).Log("msg", "configuration reloaded", "changed", len(changed))
+		for _, s := range ignored {
+			level.Warn(logger).Log("msg", "setting changed, restart to apply", "setting", s)
+		}
+	}
+}
+
+// NewReloader returns a Reloader starting with the configuration c, load should return the configuration
+// the same way it was loaded initially
+func NewReloader(c Config, load func() (Config, error)) *Reloader {
+	return &Reloader{
+		config:   c,
+		load:     load,
+		handlers: []ReloadFunc{},
+	}
+}
@@ -0,0 +1,914 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	routingTemplate "github.com/kontainerooo/kontainer.ooo/pkg/routing/template"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+// Errors are the problems found while validating a configuration
+type Errors []string
+
+func (e Errors) Error() string {
+	return strings.Join(e, "\n")
+}
+
+// add records a problem with a setting
+func (e *Errors) add(setting, format string, a ...interface{}) {
+	*e = append(*e, fmt.Sprintf("%s: %s", setting, fmt.Sprintf(format, a...)))
+}
+
+// address checks that addr is a listen address, empty addresses are only allowed if the setting is optional
+func (e *Errors) address(setting, addr string, optional bool) {
+	if addr == "" {
+		if !optional {
+			e.add(setting, "is required")
+		}
+		return
+	}
+
+	_, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		e.add(setting, "%v", err)
+	}
+}
+
+// file checks that path exists
+func (e *Errors) file(setting, path string) {
+	_, err := os.Stat(path)
+	if err != nil {
+		e.add(setting, "%v", err)
+	}
+}
+
+// s3 checks the settings of a bucket
+func (e *Errors) s3(setting string, s3 S3) {
+	u, err := url.Parse(s3.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		e.add(setting+".endpoint", "%s is not a valid http or https URL", s3.Endpoint)
+	}
+	if s3.Region == "" || s3.Bucket == "" || s3.AccessKey == "" || s3.SecretKey == "" {
+		e.add(setting, "region, bucket, accessKey and secretKey are required with an endpoint")
+	}
+	switch s3.Encryption {
+	case "", "AES256":
+		if s3.KMSKeyID != "" {
+			e.add(setting+".kmsKeyID", "is only used with the aws:kms encryption")
+		}
+	case "aws:kms":
+	default:
+		e.add(setting+".encryption", "%q is neither AES256 nor aws:kms", s3.Encryption)
+	}
+	if s3.PartSize != 0 && s3.PartSize < 5 {
+		e.add(setting+".partSize", "%d is less than 5 MiB", s3.PartSize)
+	}
+}
+
+// secretStore checks the master keys of the secret store
+func (e *Errors) secretStore(s Secrets) {
+	_, err := secret.NewKeyring(s.Keys, "")
+	if err != nil {
+		e.add("secrets.keys", "%v", err)
+	}
+	switch s.KMS {
+	case "local":
+		if _, ok := s.Keys[s.Current]; !ok {
+			e.add("secrets.current", "%q is not one of the keys", s.Current)
+		}
+	case "aws":
+		if s.AWS.Endpoint != "" {
+			u, err := url.Parse(s.AWS.Endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				e.add("secrets.aws.endpoint", "%s is not a valid http or https URL", s.AWS.Endpoint)
+			}
+		}
+		if s.AWS.Region == "" || s.AWS.AccessKey == "" || s.AWS.SecretKey == "" || s.AWS.KeyID == "" {
+			e.add("secrets.aws", "region, accessKey, secretKey and keyID are required")
+		}
+	default:
+		e.add("secrets.kms", "%q is neither local nor aws", s.KMS)
+	}
+}
+
+// payloads checks the sizes requests may have
+func (e *Errors) payloads(p Payloads) {
+	size, err := routing.ParseSize(p.MaxSize)
+	if err != nil {
+		e.add("payloads.maxSize", "%v", err)
+	} else if size == 0 {
+		e.add("payloads.maxSize", "has to be positive")
+	}
+
+	for method, s := range p.Methods {
+		if method == "" {
+			e.add("payloads.methods", "method names may not be empty")
+		}
+		size, err := routing.ParseSize(s)
+		if err != nil {
+			e.add("payloads.methods."+method, "%v", err)
+		} else if size == 0 {
+			e.add("payloads.methods."+method, "has to be positive")
+		}
+	}
+}
+
+// downloads checks the settings of the signed download URLs
+func (e *Errors) downloads(d Downloads, gateway string) {
+	if gateway == "" {
+		e.add("downloads.enabled", "requires listen.gateway")
+	}
+	u, err := url.Parse(d.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		e.add("downloads.baseURL", "%q is not a valid http or https URL", d.BaseURL)
+	}
+	if len(d.Secret) < 32 {
+		e.add("downloads.secret", "has to have at least 32 characters")
+	}
+	if d.TTL < 1 {
+		e.add("downloads.ttl", "%d is not a positive number of seconds", d.TTL)
+	}
+}
+
+// login checks the settings of the protection of the logins
+func (e *Errors) login(l Login) {
+	if l.AccountThreshold < 1 {
+		e.add("login.accountThreshold", "%d is not a positive number of failures", l.AccountThreshold)
+	}
+	if l.SourceThreshold < 1 {
+		e.add("login.sourceThreshold", "%d is not a positive number of failures", l.SourceThreshold)
+	}
+	if l.Window < 1 {
+		e.add("login.window", "%d is not a positive number of seconds", l.Window)
+	}
+	if l.Lockout < 1 {
+		e.add("login.lockout", "%d is not a positive number of seconds", l.Lockout)
+	}
+	if l.MaxLockout < l.Lockout {
+		e.add("login.maxLockout", "has to be at least the lockout")
+	}
+	if l.CaptchaURL == "" {
+		return
+	}
+
+	u, err := url.Parse(l.CaptchaURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		e.add("login.captchaURL", "%s is not a valid http or https URL", l.CaptchaURL)
+	}
+	if l.CaptchaSecret == "" {
+		e.add("login.captchaSecret", "is required with a captcha URL")
+	}
+	if l.CaptchaAfter < 1 {
+		e.add("login.captchaAfter", "%d is not a positive number of failures", l.CaptchaAfter)
+	}
+}
+
+// vault checks the settings of the vault secrets backend
+func (e *Errors) vault(v Vault) {
+	u, err := url.Parse(v.Address)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		e.add("secrets.vault.address", "%s is not a valid http or https URL", v.Address)
+	}
+	if v.Mount == "" {
+		e.add("secrets.vault.mount", "is required")
+	}
+	if v.Token == "" && (v.RoleID == "" || v.SecretID == "" || v.AppRole == "") {
+		e.add("secrets.vault", "token or roleID, secretID and appRole are required")
+	}
+}
+
+// mail checks the settings of the emails, the provider is not checked in sandbox mode
+func (e *Errors) mail(m Mail) {
+	if _, err := mail.ParseAddress(m.From); err != nil {
+		e.add("mail.from", "%q is not an email address: %v", m.From, err)
+	}
+	if m.TemplatePath != "" {
+		e.file("mail.templatePath", m.TemplatePath)
+	}
+	if m.Sandbox {
+		return
+	}
+
+	api := func(setting string, a MailAPI) {
+		if a.APIKey == "" {
+			e.add(setting+".apiKey", "is required by the %s provider", m.Provider)
+		}
+		if a.Endpoint != "" {
+			u, err := url.Parse(a.Endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				e.add(setting+".endpoint", "%s is not a valid http or https URL", a.Endpoint)
+			}
+		}
+	}
+
+	switch m.Provider {
+	case "smtp":
+		if m.SMTP.Host == "" {
+			e.add("mail.smtp.host", "is required by the smtp provider")
+		}
+		if m.SMTP.Port < 1 || m.SMTP.Port > 65535 {
+			e.add("mail.smtp.port", "%d is not a port", m.SMTP.Port)
+		}
+	case "sendgrid":
+		api("mail.sendgrid", m.SendGrid)
+	case "mailgun":
+		api("mail.mailgun", m.Mailgun)
+		if m.Mailgun.Domain == "" {
+			e.add("mail.mailgun.domain", "is required by the mailgun provider")
+		}
+	default:
+		e.add("mail.provider", "%q is neither smtp, sendgrid nor mailgun", m.Provider)
+	}
+}
+
+// billing checks the account and the plans of the billing
+func (e *Errors) billing(b Billing) {
+	if len(b.Currency) != 3 {
+		e.add("billing.currency", "%q is not an ISO currency code", b.Currency)
+	}
+	if b.GracePeriod < 0 {
+		e.add("billing.gracePeriod", "can't be negative")
+	}
+	if b.Stripe.SecretKey == "" {
+		e.add("billing.stripe.secretKey", "is required")
+	}
+	if b.Stripe.WebhookSecret == "" {
+		e.add("billing.stripe.webhookSecret", "is required")
+	}
+	if b.Stripe.Endpoint != "" {
+		u, err := url.Parse(b.Stripe.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			e.add("billing.stripe.endpoint", "%s is not a valid http or https URL", b.Stripe.Endpoint)
+		}
+	}
+
+	for name, p := range b.Plans {
+		if p.StripePrice == "" {
+			e.add("billing.plans."+name+".stripePrice", "is required")
+		}
+	}
+}
+
+// logSinks checks the sinks container logs are forwarded to, their names label the delivery metrics
+func (e *Errors) logSinks(sinks []containerlog.SinkConfig) {
+	names := make(map[string]bool)
+	for i, s := range sinks {
+		setting := fmt.Sprintf("containerLogs.sinks[%d]", i)
+		if s.Name == "" {
+			e.add(setting+".name", "is required")
+		} else if names[s.Name] {
+			e.add(setting+".name", "%q is used by another sink", s.Name)
+		}
+		names[s.Name] = true
+
+		if s.Buffer < 0 {
+			e.add(setting+".buffer", "can't be negative")
+		}
+
+		u, err := url.Parse(s.URL)
+		switch s.Type {
+		case containerlog.Syslog:
+			if err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") || u.Host == "" {
+				e.add(setting+".url", "%s is not a valid udp or tcp address", s.URL)
+			}
+		case containerlog.Loki, containerlog.Elasticsearch:
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				e.add(setting+".url", "%s is not a valid http or https URL", s.URL)
+			}
+		default:
+			e.add(setting+".type", "%q is neither syslog, loki nor elasticsearch", s.Type)
+		}
+	}
+}
+
+// Validate checks the configuration for settings the daemon can not start with, the returned error is of type Errors
+func (c Config) Validate() error {
+	e := Errors{}
+
+	_, err := logging.ParseLevel(c.Log.Level)
+	if err != nil {
+		e.add("log.level", "%v", err)
+	}
+	_, err = logging.ParseModules(c.Log.Modules)
+	if err != nil {
+		e.add("log.modules", "%v", err)
+	}
+	if c.Log.Format != "logfmt" && c.Log.Format != "json" {
+		e.add("log.format", "%s is neither logfmt nor json", c.Log.Format)
+	}
+
+	if !c.Database.Mock {
+		if c.Database.Driver != "postgres" {
+			e.add("database.driver", "%s is not supported", c.Database.Driver)
+		}
+		if c.Database.DSN == "" {
+			e.add("database.dsn", "is required")
+		}
+	}
+
+	e.address("listen.grpc", c.Listen.GRPC, false)
+	e.address("listen.websocket", c.Listen.Websocket, false)
+	e.address("listen.websocketSecure", c.Listen.WebsocketSecure, true)
+	e.address("listen.gateway", c.Listen.Gateway, true)
+	e.address("listen.metrics", c.Listen.Metrics, true)
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		e.add("tls", "certFile and keyFile have to be set together")
+	} else if c.TLS.CertFile != "" {
+		e.file("tls.certFile", c.TLS.CertFile)
+		e.file("tls.keyFile", c.TLS.KeyFile)
+	}
+
+	e.address("agent.controlPlane", c.Agent.ControlPlane, true)
+	e.address("agent.address", c.Agent.Address, true)
+	if c.Agent.Heartbeat < 1 {
+		e.add("agent.heartbeat", "%d is not a positive number of seconds", c.Agent.Heartbeat)
+	} else if c.Agent.Timeout <= c.Agent.Heartbeat {
+		e.add("agent.timeout", "%d has to be longer than the heartbeat", c.Agent.Timeout)
+	}
+	if c.Agent.Reschedule != 0 && c.Agent.Reschedule < c.Agent.Timeout {
+		e.add("agent.reschedule", "%d may not be shorter than the timeout", c.Agent.Reschedule)
+	}
+
+	if c.SSH.Enabled {
+		e.address("ssh.address", c.SSH.Address, false)
+		if c.SSH.HostKey == "" {
+			e.add("ssh.hostKey", "is required if the SSH gateway is enabled")
+		}
+		if c.SSH.Recordings == "" {
+			e.add("ssh.recordings", "is required if the SSH gateway is enabled")
+		}
+	}
+
+	if c.ManagedDatabases.Enabled {
+		m := c.ManagedDatabases
+		if m.Mode != "schema" && m.Mode != "container" {
+			e.add("managedDatabases.mode", "%s is neither schema nor container", m.Mode)
+		}
+		for _, engine := range []string{"postgres", "mysql"} {
+			s := m.Postgres
+			if engine == "mysql" {
+				s = m.MySQL
+			}
+			if s.DSN != "" && s.Host == "" {
+				e.add("managedDatabases."+engine+".host", "is required if the server is configured")
+			}
+			if s.Port <= 0 || s.Port > 65535 {
+				e.add("managedDatabases."+engine+".port", "%d is not a valid port", s.Port)
+			}
+			if s.KMI < 0 {
+				e.add("managedDatabases."+engine+".kmi", "must not be negative")
+			}
+		}
+		if m.DumpPath == "" {
+			e.add("managedDatabases.dumpPath", "is required if managed databases are enabled")
+		}
+		if m.DumpInterval <= 0 {
+			e.add("managedDatabases.dumpInterval", "has to be positive")
+		}
+		if m.DumpRetention <= 0 {
+			e.add("managedDatabases.dumpRetention", "has to be positive")
+		}
+	}
+
+	if c.Snapshots.Enabled {
+		if c.Snapshots.LocalPath == "" {
+			e.add("snapshots.localPath", "is required if snapshots are enabled")
+		}
+		if c.Snapshots.RestorePath == "" {
+			e.add("snapshots.restorePath", "is required if snapshots are enabled")
+		}
+		if c.Snapshots.Retention <= 0 {
+			e.add("snapshots.retention", "has to be positive")
+		}
+		if c.Snapshots.S3.Endpoint != "" {
+			e.s3("snapshots.s3", c.Snapshots.S3)
+		}
+	}
+
+	if c.Cron.Enabled {
+		if c.Cron.History <= 0 {
+			e.add("cron.history", "has to be positive")
+		}
+		if c.Cron.MaxOutput <= 0 {
+			e.add("cron.maxOutput", "has to be positive")
+		}
+	}
+
+	if c.ContainerLogs.Enabled {
+		if c.ContainerLogs.Interval <= 0 {
+			e.add("containerLogs.interval", "has to be positive")
+		}
+		if c.ContainerLogs.MaxLine <= 0 {
+			e.add("containerLogs.maxLine", "has to be positive")
+		}
+		e.logSinks(c.ContainerLogs.Sinks)
+	}
+
+	if c.Alerts.Enabled {
+		if c.Alerts.MaxRules <= 0 {
+			e.add("alerts.maxRules", "has to be positive")
+		}
+		if c.Alerts.History <= 0 {
+			e.add("alerts.history", "has to be positive")
+		}
+	}
+
+	if c.Autoscaling.Enabled {
+		if c.Autoscaling.Interval <= 0 {
+			e.add("autoscaling.interval", "has to be positive")
+		}
+		if c.Autoscaling.MaxPolicies <= 0 {
+			e.add("autoscaling.maxPolicies", "has to be positive")
+		}
+		if c.Autoscaling.MaxReplicas <= 0 {
+			e.add("autoscaling.maxReplicas", "has to be positive")
+		}
+		if c.Autoscaling.MaxWindows <= 0 {
+			e.add("autoscaling.maxWindows", "has to be positive")
+		}
+		if c.Autoscaling.History <= 0 {
+			e.add("autoscaling.history", "has to be positive")
+		}
+	}
+
+	if c.Bans.Enabled {
+		if !c.IPTables.Enabled {
+			e.add("bans.enabled", "requires the firewall")
+		}
+		if c.Bans.Interval <= 0 {
+			e.add("bans.interval", "has to be positive")
+		}
+		if c.Bans.MaxJails <= 0 {
+			e.add("bans.maxJails", "has to be positive")
+		}
+		if c.Bans.MaxBanTime <= 0 {
+			e.add("bans.maxBanTime", "has to be positive")
+		}
+		for _, n := range c.Bans.Ignore {
+			_, _, err := net.ParseCIDR(n)
+			if err != nil {
+				e.add("bans.ignore", "%v", err)
+			}
+		}
+	}
+
+	if c.WireGuard.Enabled {
+		if !c.IPTables.Enabled {
+			e.add("wireguard.enabled", "requires the firewall")
+		}
+		if c.Network.Pool == "" {
+			e.add("wireguard.enabled", "requires a network pool")
+		}
+		if c.WireGuard.Interface == "" {
+			e.add("wireguard.interface", "is required")
+		}
+		if c.WireGuard.Port <= 0 || c.WireGuard.Port > 65535 {
+			e.add("wireguard.port", "%d is not a valid port", c.WireGuard.Port)
+		}
+		if c.WireGuard.Endpoint == "" {
+			e.add("wireguard.endpoint", "is required")
+		}
+		_, pool, err := net.ParseCIDR(c.WireGuard.Pool)
+		if err != nil {
+			e.add("wireguard.pool", "%v", err)
+		} else if _, bridges, err := net.ParseCIDR(c.Network.Pool); err == nil && (pool.Contains(bridges.IP) || bridges.Contains(pool.IP)) {
+			e.add("wireguard.pool", "overlaps the network pool")
+		}
+		if c.WireGuard.PrivateKey == "" {
+			e.add("wireguard.privateKey", "is required")
+		}
+		if c.WireGuard.MaxPeers <= 0 {
+			e.add("wireguard.maxPeers", "has to be positive")
+		}
+		if c.WireGuard.MaxKeyAge < 0 {
+			e.add("wireguard.maxKeyAge", "may not be negative")
+		}
+		if c.WireGuard.Interval <= 0 {
+			e.add("wireguard.interval", "has to be positive")
+		}
+	}
+
+	if c.Resolver.Enabled {
+		if c.Network.Pool == "" {
+			e.add("resolver.enabled", "requires a network pool")
+		}
+		_, err := net.ResolveUDPAddr("udp", c.Resolver.Listen)
+		if c.Resolver.Listen == "" || err != nil {
+			e.add("resolver.listen", "%q is not a valid address", c.Resolver.Listen)
+		}
+		if c.Resolver.Domain == "" || strings.Trim(c.Resolver.Domain, ".") != c.Resolver.Domain {
+			e.add("resolver.domain", "%q is not a valid domain", c.Resolver.Domain)
+		}
+		if c.Resolver.Upstream != "" {
+			_, _, err = net.SplitHostPort(c.Resolver.Upstream)
+			if err != nil {
+				e.add("resolver.upstream", "%v", err)
+			}
+		}
+		if c.Resolver.TTL < 0 {
+			e.add("resolver.ttl", "may not be negative")
+		}
+	}
+
+	if c.Usage.Enabled {
+		if c.Usage.Interval <= 0 {
+			e.add("usage.interval", "has to be positive")
+		}
+		if c.Usage.RawRetention <= 0 {
+			e.add("usage.rawRetention", "has to be positive")
+		}
+		if c.Usage.FiveMinuteRetention <= 0 {
+			e.add("usage.fiveMinuteRetention", "has to be positive")
+		}
+		if c.Usage.HourRetention <= 0 {
+			e.add("usage.hourRetention", "has to be positive")
+		}
+		if c.Usage.RecommendationPeriod <= 0 {
+			e.add("usage.recommendationPeriod", "has to be positive")
+		}
+		if c.Usage.RecommendationPeriod > c.Usage.HourRetention {
+			e.add("usage.recommendationPeriod", "has to be at most usage.hourRetention")
+		}
+		if c.Usage.Headroom < 0 {
+			e.add("usage.headroom", "must not be negative")
+		}
+	}
+
+	if c.Mail.Enabled {
+		e.mail(c.Mail)
+	}
+
+	if c.Billing.Enabled {
+		e.billing(c.Billing)
+	}
+
+	switch c.Storage.Backend {
+	case "local":
+		if c.Storage.LocalPath == "" {
+			e.add("storage.localPath", "is required by the local backend")
+		}
+	case "s3":
+		e.s3("storage.s3", c.Storage.S3)
+	default:
+		e.add("storage.backend", "%q is neither local nor s3", c.Storage.Backend)
+	}
+
+	if c.BcryptCost < 4 || c.BcryptCost > 31 {
+		e.add("bcryptCost", "%d is not between 4 and 31", c.BcryptCost)
+	}
+
+	if c.ShutdownTimeout < 1 {
+		e.add("shutdownTimeout", "%d is not a positive number of seconds", c.ShutdownTimeout)
+	}
+
+	if c.RequestTimeout < 0 {
+		e.add("requestTimeout", "%d is a negative number of seconds", c.RequestTimeout)
+	}
+
+	if c.Breakers.Enabled {
+		if c.Breakers.Failures < 1 {
+			e.add("breakers.failures", "%d is not a positive number of calls", c.Breakers.Failures)
+		}
+		if c.Breakers.Timeout < 1 {
+			e.add("breakers.timeout", "%d is not a positive number of seconds", c.Breakers.Timeout)
+		}
+	}
+
+	if c.Login.Enabled {
+		e.login(c.Login)
+	}
+
+	e.payloads(c.Payloads)
+
+	if c.Downloads.Enabled {
+		e.downloads(c.Downloads, c.Listen.Gateway)
+	}
+
+	if c.Ports.From < 1024 || c.Ports.To > 65535 || c.Ports.From > c.Ports.To {
+		e.add("ports", "%d-%d is not a range of unprivileged ports", c.Ports.From, c.Ports.To)
+	}
+
+	if c.Secrets.Enabled {
+		switch c.Secrets.Backend {
+		case "store":
+		case "vault":
+			e.vault(c.Secrets.Vault)
+		default:
+			e.add("secrets.backend", "%q is neither store nor vault", c.Secrets.Backend)
+		}
+		if c.Secrets.store() {
+			e.secretStore(c.Secrets)
+		}
+	}
+	if secret.IsEncrypted(c.Registry.Password) && !(c.Secrets.Enabled && c.Secrets.store()) {
+		e.add("registry.password", "is encrypted but no master keys are configured")
+	}
+	if secret.IsEncrypted(c.Downloads.Secret) && !(c.Secrets.Enabled && c.Secrets.store()) {
+		e.add("downloads.secret", "is encrypted but no master keys are configured")
+	}
+
+	if c.IPTables.Enabled {
+		if c.IPTables.Path == "" {
+			e.add("iptables.path", "is required if the firewall is enabled")
+		}
+		if c.IPTables.RestorePath == "" {
+			e.add("iptables.restorePath", "is required if the firewall is enabled")
+		}
+	}
+	switch c.IPTables.LogDrops {
+	case "", "log", "nflog":
+	default:
+		e.add("iptables.logDrops", "must be log or nflog, got %s", c.IPTables.LogDrops)
+	}
+	if c.IPTables.NFLogGroup < 0 || c.IPTables.NFLogGroup > 65535 {
+		e.add("iptables.nflogGroup", "must be between 0 and 65535")
+	}
+	if c.IPTables.LogDrops != "" && c.IPTables.DropLog == "" {
+		e.add("iptables.dropLog", "is required if drops are logged")
+	}
+
+	router, err := routingTemplate.ParseRouter(c.Routing.Router)
+	if err != nil {
+		e.add("routing.router", "%v", err)
+	} else if c.Routing.TemplatePath != "" {
+		err = routingTemplate.Validate(router, c.Routing.TemplatePath)
+		if err != nil {
+			e.add("routing.templatePath", "%v", err)
+		}
+	}
+
+	for name, p := range c.Routing.Plans {
+		if p.MaxBodySize != "" {
+			size, err := routing.ParseSize(p.MaxBodySize)
+			if err != nil {
+				e.add("routing.plans."+name+".maxBodySize", "%v", err)
+			} else if size == 0 {
+				e.add("routing.plans."+name+".maxBodySize", "has to be positive, leave it empty to not limit it")
+			}
+		}
+
+		if p.MonthlyTraffic != "" {
+			if _, err := routing.ParseSize(p.MonthlyTraffic); err != nil {
+				e.add("routing.plans."+name+".monthlyTraffic", "%v", err)
+			}
+		}
+
+		switch p.Exhausted {
+		case "", routing.QuotaPage:
+		case routing.QuotaThrottle:
+			if p.ThrottleRate == "" {
+				e.add("routing.plans."+name+".throttleRate", "is required to throttle the traffic")
+			}
+		default:
+			e.add("routing.plans."+name+".exhausted", "has to be %s or %s", routing.QuotaPage, routing.QuotaThrottle)
+		}
+	}
+
+	if c.Sites.Enabled {
+		if err == nil && router != routingTemplate.Nginx {
+			e.add("sites.enabled", "requires nginx as router")
+		}
+		if c.Sites.Root == "" {
+			e.add("sites.root", "is required")
+		}
+		if net.ParseIP(c.Sites.Address) == nil {
+			e.add("sites.address", "%q is not an ip address", c.Sites.Address)
+		}
+		size, err := routing.ParseSize(c.Sites.MaxBundleSize)
+		if err != nil {
+			e.add("sites.maxBundleSize", "%v", err)
+		} else if size == 0 {
+			e.add("sites.maxBundleSize", "has to be positive")
+		}
+		if c.Sites.MaxAge < 0 {
+			e.add("sites.maxAge", "may not be negative")
+		}
+		if c.Sites.Interval <= 0 {
+			e.add("sites.interval", "has to be positive")
+		}
+	}
+
+	if c.Deploy.Enabled {
+		if !c.SSH.Enabled {
+			e.add("deploy.enabled", "requires the SSH gateway")
+		}
+		if c.Deploy.Root == "" {
+			e.add("deploy.root", "is required")
+		}
+		if c.Deploy.Branch == "" {
+			e.add("deploy.branch", "is required")
+		}
+		if c.Deploy.Keep < 1 {
+			e.add("deploy.keep", "has to be at least 1")
+		}
+		if c.Deploy.Docker == "" {
+			e.add("deploy.docker", "is required")
+		}
+	}
+
+	if c.Registry.Enabled {
+		e.address("registry.listen", c.Registry.Listen, false)
+		u, err := url.Parse(c.Registry.Upstream)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			e.add("registry.upstream", "%s is not a valid http or https URL", c.Registry.Upstream)
+		}
+		if c.Registry.Root == "" {
+			e.add("registry.root", "is required")
+		}
+		size, err := routing.ParseSize(c.Registry.Quota)
+		if err != nil {
+			e.add("registry.quota", "%v", err)
+		} else if size == 0 {
+			e.add("registry.quota", "has to be positive")
+		}
+		if c.Registry.ManifestTTL < 0 {
+			e.add("registry.manifestTTL", "may not be negative")
+		}
+		if c.Registry.Unused <= 0 {
+			e.add("registry.unused", "has to be positive")
+		}
+		if c.Registry.Interval <= 0 {
+			e.add("registry.interval", "has to be positive")
+		}
+	}
+
+	if c.Checkpoints.Enabled && c.Checkpoints.CRIU == "" {
+		e.add("checkpoints.criu", "is required")
+	}
+
+	for name, size := range map[string]string{"limits.memory": c.Limits.Memory, "limits.swap": c.Limits.Swap} {
+		if size == "" {
+			continue
+		}
+		_, err := routing.ParseSize(size)
+		if err != nil {
+			e.add(name, "%v", err)
+		}
+	}
+	if c.Limits.Swap != "" && c.Limits.Memory == "" {
+		e.add("limits.swap", "requires limits.memory")
+	}
+	if c.Limits.CPU < 0 {
+		e.add("limits.cpu", "may not be negative")
+	}
+	if c.Limits.Pids < 0 {
+		e.add("limits.pids", "may not be negative")
+	}
+
+	if c.Capacity.ReservedCPU < 0 {
+		e.add("capacity.reservedCPU", "may not be negative")
+	}
+	if c.Capacity.ReservedMemory != "" {
+		_, err := routing.ParseSize(c.Capacity.ReservedMemory)
+		if err != nil {
+			e.add("capacity.reservedMemory", "%v", err)
+		}
+	}
+	if c.Capacity.CPUOvercommit < 1 {
+		e.add("capacity.cpuOvercommit", "has to be at least 1")
+	}
+	if c.Capacity.MemoryOvercommit < 1 {
+		e.add("capacity.memoryOvercommit", "has to be at least 1")
+	}
+
+	if c.ACME.Email != "" {
+		if c.DNS.BaseDomain == "" {
+			e.add("dns.baseDomain", "is required if certificates are issued")
+		}
+		if c.ACME.CertificatePath == "" {
+			e.add("acme.certificatePath", "is required if certificates are issued")
+		}
+		if c.ACME.Wildcard && c.DNS.PowerDNSURL == "" {
+			e.add("dns.powerDNSURL", "is required for wildcard certificates")
+		}
+	}
+
+	if c.DNS.PublicIPv4 != "" {
+		ip := net.ParseIP(c.DNS.PublicIPv4)
+		if ip == nil || ip.To4() == nil {
+			e.add("dns.publicIPv4", "%s is not an IPv4 address", c.DNS.PublicIPv4)
+		}
+	}
+	if c.DNS.PublicIPv6 != "" {
+		ip := net.ParseIP(c.DNS.PublicIPv6)
+		if ip == nil || ip.To4() != nil {
+			e.add("dns.publicIPv6", "%s is not an IPv6 address", c.DNS.PublicIPv6)
+		}
+	}
+
+	if c.Network.Pool != "" {
+		_, pool, err := net.ParseCIDR(c.Network.Pool)
+		if err != nil {
+			e.add("network.pool", "%v", err)
+		} else {
+			ones, _ := pool.Mask.Size()
+			if c.Network.Prefix < ones || c.Network.Prefix > 30 {
+				e.add("network.prefix", "%d is not between %d and 30", c.Network.Prefix, ones)
+			}
+		}
+	} else {
+		if c.Network.NodeName != "" {
+			e.add("network.nodeName", "requires a network pool")
+		}
+		if c.Network.Metering {
+			e.add("network.metering", "requires a network pool")
+		}
+	}
+
+	if c.Network.NodeName != "" && net.ParseIP(c.Network.NodeAddress) == nil {
+		e.add("network.nodeAddress", "%q is not an IP address", c.Network.NodeAddress)
+	}
+
+	if c.Tracing.Enabled {
+		if c.Tracing.ServiceName == "" {
+			e.add("tracing.serviceName", "is required if tracing is enabled")
+		}
+		if c.Tracing.Collector == "" {
+			e.address("tracing.agent", c.Tracing.Agent, false)
+		}
+		if c.Tracing.SampleRate < 0 || c.Tracing.SampleRate > 1 {
+			e.add("tracing.sampleRate", "%v is not between 0 and 1", c.Tracing.SampleRate)
+		}
+	}
+
+	switch c.Events.Backend {
+	case "memory":
+	case "nats":
+		if c.Events.URL == "" {
+			e.add("events.url", "is required for the nats backend")
+		}
+		if c.Events.Stream == "" || strings.ContainsAny(c.Events.Stream, ".*> ") {
+			e.add("events.stream", "%q is not a valid stream name", c.Events.Stream)
+		}
+	default:
+		e.add("events.backend", "%s is neither memory nor nats", c.Events.Backend)
+	}
+
+	if c.RateLimit.Enabled {
+		switch c.RateLimit.Backend {
+		case "memory":
+		case "redis":
+			if c.RateLimit.RedisURL == "" {
+				e.add("rateLimit.redisURL", "is required for the redis backend")
+			}
+		default:
+			e.add("rateLimit.backend", "%s is neither memory nor redis", c.RateLimit.Backend)
+		}
+
+		for name, p := range c.RateLimit.Plans {
+			if p.Rate < 0 || p.Burst < 0 {
+				e.add("rateLimit.plans."+name, "rate and burst can't be negative")
+			}
+			for method, l := range p.Methods {
+				if l.Rate < 0 || l.Burst < 0 {
+					e.add("rateLimit.plans."+name+".methods."+method, "rate and burst can't be negative")
+				}
+			}
+		}
+	}
+
+	switch c.Idempotency.Backend {
+	case "memory":
+	case "redis":
+		if c.Idempotency.RedisURL == "" {
+			e.add("idempotency.redisURL", "is required for the redis backend")
+		}
+	default:
+		e.add("idempotency.backend", "%s is neither memory nor redis", c.Idempotency.Backend)
+	}
+	if c.Idempotency.TTL <= 0 {
+		e.add("idempotency.ttl", "has to be positive")
+	}
+
+	if c.Cache.Enabled {
+		switch c.Cache.Backend {
+		case "memory":
+			if c.Cache.Size <= 0 {
+				e.add("cache.size", "has to be positive")
+			}
+		case "redis":
+			if c.Cache.RedisURL == "" {
+				e.add("cache.redisURL", "is required for the redis backend")
+			}
+		default:
+			e.add("cache.backend", "%s is neither memory nor redis", c.Cache.Backend)
+		}
+		if c.Cache.TTL <= 0 {
+			e.add("cache.ttl", "has to be positive")
+		}
+	}
+
+	if len(e) > 0 {
+		return e
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package container
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Capacity are the CPU cores and the memory in bytes of a node
+type Capacity struct {
+	CPU    float64
+	Memory int64
+}
+
+// CapacityPolicy is the part of a node handed to containers. The reserved resources are kept for the host and the
+// platform daemons, the limits of the containers may exceed the rest by the overcommit ratios, e.g. 4 for CPU
+// since containers rarely use all of their cores at once.
+type CapacityPolicy struct {
+	ReservedCPU      float64
+	ReservedMemory   int64
+	CPUOvercommit    float64
+	MemoryOvercommit float64
+}
+
+// Allocatable returns the resources of a node with the capacity host the limits of its containers may add up to
+func (p CapacityPolicy) Allocatable(host Capacity) Capacity {
+	ratio := func(r float64) float64 {
+		if r <= 0 {
+			return 1
+		}
+		return r
+	}
+
+	a := Capacity{
+		CPU:    (host.CPU - p.ReservedCPU) * ratio(p.CPUOvercommit),
+		Memory: int64(float64(host.Memory-p.ReservedMemory) * ratio(p.MemoryOvercommit)),
+	}
+	if a.CPU < 0 {
+		a.CPU = 0
+	}
+	if a.Memory < 0 {
+		a.Memory = 0
+	}
+	return a
+}
+
+// CapacityError is returned if a container would exceed the allocatable resources of a node
+type CapacityError struct {
+	Node     string
+	Resource string
+	// Committed are the limits of the containers on the node, Requested the limit of the new container
+	// and Allocatable what the policy of the node allows
+	Committed   string
+	Requested   string
+	Allocatable string
+}
+
+func (e *CapacityError) Error() string {
+	return fmt.Sprintf("insufficient capacity: node %s has %s of %s %s committed, %s more were requested",
+		e.Node, e.Committed, e.Allocatable, e.Resource, e.Requested)
+}
+
+// admit returns a CapacityError if a further container with the limits l exceeds the allocatable resources a of the
+// node named node, whose containers count of them are placed on. Unlimited resources are not accounted for.
+func admit(node string, a Capacity, l Limits, count int) error {
+	n := float64(count)
+	if l.CPU > 0 && (n+1)*l.CPU > a.CPU {
+		return &CapacityError{
+			Node:        node,
+			Resource:    "cpu",
+			Committed:   strconv.FormatFloat(n*l.CPU, 'f', -1, 64),
+			Requested:   strconv.FormatFloat(l.CPU, 'f', -1, 64),
+			Allocatable: strconv.FormatFloat(a.CPU, 'f', -1, 64),
+		}
+	}
+	if l.Memory > 0 && (n+1)*float64(l.Memory) > float64(a.Memory) {
+		return &CapacityError{
+			Node:        node,
+			Resource:    "memory",
+			Committed:   strconv.FormatInt(int64(count)*l.Memory, 10),
+			Requested:   strconv.FormatInt(l.Memory, 10),
+			Allocatable: strconv.FormatInt(a.Memory, 10),
+		}
+	}
+	return nil
+}
+
+// HostCapacity returns the CPU cores and the memory of this host, the memory is read from meminfo, e.g. /proc/meminfo
+func HostCapacity(meminfo string) (Capacity, error) {
+	f, err := os.Open(meminfo)
+	if err != nil {
+		return Capacity{}, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		// MemTotal:       16318220 kB
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return Capacity{}, err
+		}
+		return Capacity{
+			CPU:    float64(runtime.NumCPU()),
+			Memory: kb << 10,
+		}, nil
+	}
+	if err := sc.Err(); err != nil {
+		return Capacity{}, err
+	}
+	return Capacity{}, errors.New("MemTotal is missing in " + meminfo)
+}
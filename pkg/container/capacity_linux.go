@@ -0,0 +1,25 @@
+// +build linux
+
+package container
+
+// checkCapacity refuses a further container if the limits of the containers on this node would exceed its
+// allocatable resources, nodes whose capacity is unknown accept every container
+func (s *service) checkCapacity() error {
+	if s.host == (Capacity{}) {
+		return nil
+	}
+
+	cs := []Container{}
+	err := s.db.Find(&cs)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, c := range cs {
+		if c.Node == s.config.NodeName {
+			count++
+		}
+	}
+	return admit(s.config.NodeName, s.policy.Allocatable(s.host), s.limits, count)
+}
@@ -0,0 +1,62 @@
+// +build integration,linux
+
+package container_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Capacity", func() {
+	Describe("Allocatable", func() {
+		host := container.Capacity{CPU: 4, Memory: 8 << 30}
+
+		It("Should keep the reserved resources and overcommit the rest", func() {
+			p := container.CapacityPolicy{
+				ReservedCPU:      1,
+				ReservedMemory:   1 << 30,
+				CPUOvercommit:    4,
+				MemoryOvercommit: 1.5,
+			}
+			Ω(p.Allocatable(host)).Should(Equal(container.Capacity{CPU: 12, Memory: 21 << 29}))
+		})
+
+		It("Should not overcommit without a ratio", func() {
+			Ω(container.CapacityPolicy{}.Allocatable(host)).Should(Equal(host))
+		})
+
+		It("Should not allocate below zero", func() {
+			p := container.CapacityPolicy{ReservedCPU: 8, ReservedMemory: 16 << 30}
+			Ω(p.Allocatable(host)).Should(Equal(container.Capacity{}))
+		})
+	})
+
+	Describe("HostCapacity", func() {
+		It("Should read the memory of the host", func() {
+			f, err := ioutil.TempFile("", "meminfo")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer os.Remove(f.Name())
+			f.WriteString("MemTotal:       16318220 kB\nMemFree:         1234567 kB\n")
+			f.Close()
+
+			c, err := container.HostCapacity(f.Name())
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(c.Memory).Should(BeEquivalentTo(16318220 << 10))
+			Ω(c.CPU).Should(BeNumerically(">", 0))
+		})
+
+		It("Should fail without MemTotal", func() {
+			f, err := ioutil.TempFile("", "meminfo")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer os.Remove(f.Name())
+			f.Close()
+
+			_, err = container.HostCapacity(f.Name())
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
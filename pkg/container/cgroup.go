@@ -0,0 +1,131 @@
+package container
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// CgroupMode is the cgroup hierarchy the containers of a node are limited in
+type CgroupMode string
+
+// The cgroup hierarchies of a node
+const (
+	// CgroupV1 mounts a hierarchy per controller
+	CgroupV1 CgroupMode = "v1"
+	// CgroupHybrid mounts the unified hierarchy without controllers beside the v1 hierarchies, so the
+	// limits are set in the v1 hierarchies
+	CgroupHybrid CgroupMode = "hybrid"
+	// CgroupV2 mounts the unified hierarchy only
+	CgroupV2 CgroupMode = "v2"
+)
+
+// CgroupRoot is the directory the cgroup hierarchies of a node are mounted in
+const CgroupRoot = "/sys/fs/cgroup"
+
+// cpuPeriod is the period in microseconds CPU quotas are measured in, the kernel does not accept
+// quotas below minCPUQuota
+const (
+	cpuPeriod   = 100000
+	minCPUQuota = 1000
+)
+
+// DetectCgroupMode returns the cgroup hierarchy mounted at root
+func DetectCgroupMode(root string) CgroupMode {
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		return CgroupV2
+	}
+	if _, err := os.Stat(filepath.Join(root, "unified", "cgroup.controllers")); err == nil {
+		return CgroupHybrid
+	}
+	return CgroupV1
+}
+
+// cpuQuota returns the CPU time in microseconds the container may use per cpuPeriod, 0 if it is unlimited
+func (l Limits) cpuQuota() int64 {
+	if l.CPU <= 0 {
+		return 0
+	}
+
+	q := int64(l.CPU * cpuPeriod)
+	if q < minCPUQuota {
+		q = minCPUQuota
+	}
+	return q
+}
+
+// UnifiedFiles returns the values of the interface files of a cgroup v2 enforcing the limits. Unlike
+// memory.memsw.limit_in_bytes of v1, which limits memory and swap together, memory.swap.max limits the swap alone.
+func (l Limits) UnifiedFiles() map[string]string {
+	max := func(n int64) string {
+		if n <= 0 {
+			return "max"
+		}
+		return strconv.FormatInt(n, 10)
+	}
+
+	files := map[string]string{
+		"memory.max":      max(l.Memory),
+		"memory.swap.max": "max",
+		"cpu.max":         fmt.Sprintf("%s %d", max(l.cpuQuota()), cpuPeriod),
+		"pids.max":        max(l.Pids),
+	}
+	if l.Memory > 0 {
+		files["memory.swap.max"] = strconv.FormatInt(l.Swap, 10)
+	}
+	return files
+}
+
+// controllers are the controllers of the unified hierarchy the limits are set with
+var controllers = []string{"cpu", "memory", "pids"}
+
+// readCgroupValue reads a single number of an interface file, max is returned as 0
+func readCgroupValue(dir string, file string) (uint64, error) {
+	b, err := ioutil.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return 0, err
+	}
+
+	v := strings.TrimSpace(string(b))
+	if v == "max" {
+		return 0, nil
+	}
+	return strconv.ParseUint(v, 10, 64)
+}
+
+// unifiedUsage reads the CPU time in nanoseconds, the memory and the memory limit in bytes of the
+// cgroup v2 dir, the limit is 0 if the memory is not limited
+func unifiedUsage(dir string) (cpu uint64, memory uint64, limit uint64, err error) {
+	memory, err = readCgroupValue(dir, "memory.current")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	limit, err = readCgroupValue(dir, "memory.max")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && fields[0] == "usage_usec" {
+			usec, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, 0, 0, err
+			}
+			cpu = usec * 1000
+		}
+	}
+	return cpu, memory, limit, sc.Err()
+}
@@ -0,0 +1,98 @@
+// +build linux
+
+package container
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// resources returns the cgroup resources of a container. The limits are translated for the v1 hierarchies
+// only, on v2 nodes they are written to the unified hierarchy by applyUnified once the container started.
+func (s *service) resources() *configs.Resources {
+	r := &configs.Resources{
+		MemorySwappiness: nil,
+		AllowAllDevices:  nil,
+		AllowedDevices:   configs.DefaultAllowedDevices,
+	}
+	if s.cgroup == CgroupV2 {
+		return r
+	}
+
+	l := s.limits
+	if l.Memory > 0 {
+		r.Memory = l.Memory
+		// memory.memsw.limit_in_bytes limits memory and swap together
+		r.MemorySwap = l.Memory + l.Swap
+	}
+	if q := l.cpuQuota(); q > 0 {
+		r.CpuPeriod = cpuPeriod
+		r.CpuQuota = q
+	}
+	if l.Pids > 0 {
+		r.PidsLimit = l.Pids
+	}
+	return r
+}
+
+// applyUnified limits a started container in the unified hierarchy of a v2 node. The cgroup is created
+// below Parent if the container runtime did not create it, and the init process of the container is moved into it.
+func (s *service) applyUnified(c libcontainer.Container, parent string) error {
+	if s.cgroup != CgroupV2 {
+		return nil
+	}
+
+	state, err := c.State()
+	if err != nil {
+		return err
+	}
+
+	dir := state.CgroupPaths[""]
+	if dir == "" {
+		dir = filepath.Join(CgroupRoot, parent, c.ID())
+	}
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	// the controllers have to be enabled in every ancestor, from the root down, for the interface files to exist
+	ancestors := []string{}
+	for p := filepath.Dir(dir); strings.HasPrefix(p, CgroupRoot); p = filepath.Dir(p) {
+		ancestors = append([]string{p}, ancestors...)
+		if p == CgroupRoot {
+			break
+		}
+	}
+	enable := "+" + strings.Join(controllers, " +")
+	for _, p := range ancestors {
+		err = ioutil.WriteFile(filepath.Join(p, "cgroup.subtree_control"), []byte(enable), 0644)
+		if err != nil {
+			return fmt.Errorf("enabling the controllers of %s: %v", p, err)
+		}
+	}
+
+	for file, value := range s.limits.UnifiedFiles() {
+		err = ioutil.WriteFile(filepath.Join(dir, file), []byte(value), 0644)
+		if err != nil {
+			return fmt.Errorf("writing %s: %v", file, err)
+		}
+	}
+
+	if state.InitProcessPid == 0 {
+		return nil
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(fmt.Sprint(state.InitProcessPid)), 0644)
+	if pe, ok := err.(*os.PathError); ok && pe.Err == syscall.ESRCH {
+		// the init process exited already
+		return nil
+	}
+	return err
+}
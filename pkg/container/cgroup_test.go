@@ -0,0 +1,65 @@
+// +build integration,linux
+
+package container_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Cgroups", func() {
+	Describe("DetectCgroupMode", func() {
+		var root string
+
+		BeforeEach(func() {
+			var err error
+			root, err = ioutil.TempDir("", "cgroup")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(root)
+		})
+
+		It("Should detect the hierarchies mounted at the root", func() {
+			Ω(container.DetectCgroupMode(root)).Should(Equal(container.CgroupV1))
+
+			Ω(os.Mkdir(filepath.Join(root, "unified"), 0755)).Should(Succeed())
+			Ω(ioutil.WriteFile(filepath.Join(root, "unified", "cgroup.controllers"), nil, 0644)).Should(Succeed())
+			Ω(container.DetectCgroupMode(root)).Should(Equal(container.CgroupHybrid))
+
+			Ω(ioutil.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory pids"), 0644)).Should(Succeed())
+			Ω(container.DetectCgroupMode(root)).Should(Equal(container.CgroupV2))
+		})
+	})
+
+	Describe("UnifiedFiles", func() {
+		It("Should translate the limits for cgroup v2", func() {
+			l := container.Limits{Memory: 512 << 20, Swap: 128 << 20, CPU: 0.5, Pids: 100}
+			Ω(l.UnifiedFiles()).Should(Equal(map[string]string{
+				"memory.max":      "536870912",
+				"memory.swap.max": "134217728",
+				"cpu.max":         "50000 100000",
+				"pids.max":        "100",
+			}))
+		})
+
+		It("Should not limit zero values", func() {
+			Ω(container.Limits{}.UnifiedFiles()).Should(Equal(map[string]string{
+				"memory.max":      "max",
+				"memory.swap.max": "max",
+				"cpu.max":         "max 100000",
+				"pids.max":        "max",
+			}))
+		})
+
+		It("Should raise CPU quotas to the minimum of the kernel", func() {
+			Ω(container.Limits{CPU: 0.001}.UnifiedFiles()["cpu.max"]).Should(Equal("1000 100000"))
+		})
+	})
+})
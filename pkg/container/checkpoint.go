@@ -0,0 +1,307 @@
+// +build linux
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/opencontainers/runc/libcontainer"
+	"golang.org/x/net/context"
+)
+
+// ErrCheckpointUnsupported is returned if a container should be checkpointed or restored on a node
+// without checkpoints enabled or whose kernel or CRIU installation does not support them
+var ErrCheckpointUnsupported = errors.New("checkpoints are not supported on this node")
+
+// ErrCheckpointNotFound is returned if an instance has no checkpoint with the given name
+var ErrCheckpointNotFound = errors.New("checkpoint not found")
+
+// checkCRIU reports whether checkpoints are enabled and criu check passes, i.e. the kernel has
+// every feature CRIU needs to checkpoint and restore processes
+func (s *service) checkCRIU() bool {
+	if !s.config.Checkpoints {
+		return false
+	}
+
+	out, err := exec.Command(s.config.CRIUPath, "check").CombinedOutput()
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "checkpoints are disabled, criu check failed", "output", string(out), "err", err)
+		return false
+	}
+	return true
+}
+
+// checkpointPath returns the directory the images of the checkpoint name of an instance are stored in
+func (s *service) checkpointPath(refID uint, id string, name string) string {
+	return path.Join(s.config.CustomerPath, fmt.Sprintf("%d", refID), id, "checkpoints", name)
+}
+
+func (s *service) criuOpts(refID uint, id string, name string, leaveRunning bool) *libcontainer.CriuOpts {
+	return &libcontainer.CriuOpts{
+		ImagesDirectory: s.checkpointPath(refID, id, name),
+		LeaveRunning:    leaveRunning,
+		// connections and locks of databases and servers are restored with their processes
+		TcpEstablished: true,
+		FileLocks:      true,
+	}
+}
+
+func (s *service) checkpoints(refID uint, id string) ([]Checkpoint, error) {
+	cps := []Checkpoint{}
+	err := s.db.FindOrdered(&cps, "created_at DESC", 0, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return nil, err
+	}
+	return cps, nil
+}
+
+func (s *service) checkpoint(refID uint, id string, name string) (Checkpoint, error) {
+	cp := Checkpoint{}
+	err := s.db.First(&cp, "ref_id = ? AND container_id = ? AND name = ?", refID, id, name)
+	if s.db.IsNotFound(err) {
+		return Checkpoint{}, ErrCheckpointNotFound
+	}
+	if err != nil {
+		return Checkpoint{}, err
+	}
+	return cp, nil
+}
+
+func (s *service) CheckpointInstance(ctx context.Context, refID uint, id string, name string, leaveRunning bool) (Checkpoint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).checkpointInstance(refID, id, name, leaveRunning)
+}
+
+func (s *service) checkpointInstance(refID uint, id string, name string, leaveRunning bool) (Checkpoint, error) {
+	if !s.checkpointing {
+		return Checkpoint{}, ErrCheckpointUnsupported
+	}
+
+	c := Container{}
+	err := s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	container, err := s.libcnt.Load(id)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	// a checkpoint is replaced by a newer one of the same name
+	err = s.removeCheckpoint(refID, id, name)
+	if err != nil && err != ErrCheckpointNotFound {
+		return Checkpoint{}, err
+	}
+
+	dir := s.checkpointPath(refID, id, name)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	err = container.Checkpoint(s.criuOpts(refID, id, name, leaveRunning))
+	if err != nil {
+		os.RemoveAll(dir)
+		return Checkpoint{}, err
+	}
+
+	cp := Checkpoint{
+		RefID:       refID,
+		ContainerID: id,
+		Name:        name,
+	}
+	err = s.db.Create(&cp)
+	if err != nil {
+		return Checkpoint{}, err
+	}
+
+	if !leaveRunning {
+		s.publish(events.ContainerStopped, events.ContainerEvent{
+			RefID:       refID,
+			ContainerID: id,
+		})
+	}
+	return cp, nil
+}
+
+func (s *service) RestoreInstance(ctx context.Context, refID uint, id string, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).restoreInstance(refID, id, name)
+}
+
+func (s *service) restoreInstance(refID uint, id string, name string) error {
+	if !s.checkpointing {
+		return ErrCheckpointUnsupported
+	}
+
+	_, err := s.checkpoint(refID, id, name)
+	if err != nil {
+		return err
+	}
+
+	container, err := s.libcnt.Load(id)
+	if err != nil {
+		return err
+	}
+
+	status, err := container.Status()
+	if err != nil {
+		return err
+	}
+	if status != libcontainer.Stopped {
+		return fmt.Errorf("instance %s has to be stopped to be restored, it is %s", id, status)
+	}
+
+	instancePath := path.Join(s.config.CustomerPath, fmt.Sprintf("%d", refID), id)
+	stdout, err := os.OpenFile(path.Join(instancePath, StdoutLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	defer stdout.Close()
+
+	stderr, err := os.OpenFile(path.Join(instancePath, StderrLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	defer stderr.Close()
+
+	return container.Restore(&libcontainer.Process{
+		Stdout: stdout,
+		Stderr: stderr,
+	}, s.criuOpts(refID, id, name, false))
+}
+
+func (s *service) Checkpoints(ctx context.Context, refID uint, id string) ([]Checkpoint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).checkpoints(refID, id)
+}
+
+func (s *service) RemoveCheckpoint(ctx context.Context, refID uint, id string, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).removeCheckpoint(refID, id, name)
+}
+
+func (s *service) removeCheckpoint(refID uint, id string, name string) error {
+	cp, err := s.checkpoint(refID, id, name)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(s.checkpointPath(refID, id, name))
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&Checkpoint{ID: cp.ID})
+}
+
+// removeCheckpoints removes the checkpoints of a removed instance from the database, their images are
+// removed with the directory of the instance
+func (s *service) removeCheckpoints(refID uint, id string) error {
+	cps, err := s.checkpoints(refID, id)
+	if err != nil {
+		return err
+	}
+
+	for _, cp := range cps {
+		err = s.db.Delete(&Checkpoint{ID: cp.ID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) CheckpointRunning(ctx context.Context) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).checkpointRunning()
+}
+
+// checkpointRunning saves the running containers of this node as RestartCheckpoint and stops them.
+// Containers which fail to be checkpointed are logged and left to be killed with the daemon.
+func (s *service) checkpointRunning() error {
+	if !s.checkpointing || !s.config.CheckpointRestart {
+		return nil
+	}
+
+	cs := []Container{}
+	err := s.db.Find(&cs)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cs {
+		if c.Node != s.config.NodeName {
+			continue
+		}
+
+		container, err := s.libcnt.Load(c.ContainerID)
+		if err != nil {
+			continue
+		}
+		status, err := container.Status()
+		if err != nil || status != libcontainer.Running {
+			continue
+		}
+
+		_, err = s.checkpointInstance(c.RefID, c.ContainerID, RestartCheckpoint, false)
+		if err != nil {
+			level.Error(s.logger).Log("checkpoint", RestartCheckpoint, "instance", c.ContainerID, "err", err)
+		}
+	}
+	return nil
+}
+
+// restoreRunning restores the containers which were saved as RestartCheckpoint when the daemon shut down,
+// the checkpoints are removed afterwards since the state of a restored container moves on
+func (s *service) restoreRunning() {
+	if !s.checkpointing || !s.config.CheckpointRestart {
+		return
+	}
+
+	all := []Checkpoint{}
+	err := s.db.Find(&all)
+	if err != nil {
+		level.Error(s.logger).Log("err", err)
+		return
+	}
+
+	for _, cp := range all {
+		if cp.Name != RestartCheckpoint {
+			continue
+		}
+
+		c := Container{}
+		err = s.db.First(&c, "container_id = ?", cp.ContainerID)
+		if err != nil || c.Node != s.config.NodeName {
+			continue
+		}
+
+		err = s.restoreInstance(cp.RefID, cp.ContainerID, cp.Name)
+		if err != nil {
+			level.Error(s.logger).Log("restore", cp.Name, "instance", cp.ContainerID, "err", err)
+			continue
+		}
+
+		err = s.removeCheckpoint(cp.RefID, cp.ContainerID, cp.Name)
+		if err != nil {
+			level.Error(s.logger).Log("checkpoint", cp.Name, "instance", cp.ContainerID, "err", err)
+		}
+	}
+}
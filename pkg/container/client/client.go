@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
@@ -14,6 +15,7 @@ import (
 
 	containerPB "github.com/kontainerooo/kontainer.ooo/pkg/container/pb"
 	kmiClient "github.com/kontainerooo/kontainer.ooo/pkg/kmi/client"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 )
 
 // New creates a set of endpoints based on a gRPC connection
@@ -162,6 +164,114 @@ func New(conn *grpc.ClientConn, logger log.Logger) *container.Endpoints {
 		).Endpoint()
 	}
 
+	var ScaleInstanceEndpoint endpoint.Endpoint
+	{
+		ScaleInstanceEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"ScaleInstance",
+			EncodeGRPCScaleInstanceRequest,
+			DecodeGRPCScaleInstanceResponse,
+			containerPB.ScaleInstanceResponse{},
+		).Endpoint()
+	}
+
+	var CloneInstanceEndpoint endpoint.Endpoint
+	{
+		CloneInstanceEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"CloneInstance",
+			EncodeGRPCCloneInstanceRequest,
+			DecodeGRPCCloneInstanceResponse,
+			containerPB.CloneInstanceResponse{},
+		).Endpoint()
+	}
+
+	var CheckpointInstanceEndpoint endpoint.Endpoint
+	{
+		CheckpointInstanceEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"CheckpointInstance",
+			EncodeGRPCCheckpointInstanceRequest,
+			DecodeGRPCCheckpointInstanceResponse,
+			containerPB.CheckpointInstanceResponse{},
+		).Endpoint()
+	}
+
+	var RestoreInstanceEndpoint endpoint.Endpoint
+	{
+		RestoreInstanceEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"RestoreInstance",
+			EncodeGRPCRestoreInstanceRequest,
+			DecodeGRPCRestoreInstanceResponse,
+			containerPB.RestoreInstanceResponse{},
+		).Endpoint()
+	}
+
+	var CheckpointsEndpoint endpoint.Endpoint
+	{
+		CheckpointsEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"Checkpoints",
+			EncodeGRPCCheckpointsRequest,
+			DecodeGRPCCheckpointsResponse,
+			containerPB.CheckpointsResponse{},
+		).Endpoint()
+	}
+
+	var RemoveCheckpointEndpoint endpoint.Endpoint
+	{
+		RemoveCheckpointEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"RemoveCheckpoint",
+			EncodeGRPCRemoveCheckpointRequest,
+			DecodeGRPCRemoveCheckpointResponse,
+			containerPB.RemoveCheckpointResponse{},
+		).Endpoint()
+	}
+
+	var PinInstanceEndpoint endpoint.Endpoint
+	{
+		PinInstanceEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"PinInstance",
+			EncodeGRPCPinInstanceRequest,
+			DecodeGRPCPinInstanceResponse,
+			containerPB.PinInstanceResponse{},
+		).Endpoint()
+	}
+
+	var SetPlacementEndpoint endpoint.Endpoint
+	{
+		SetPlacementEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"SetPlacement",
+			EncodeGRPCSetPlacementRequest,
+			DecodeGRPCSetPlacementResponse,
+			containerPB.SetPlacementResponse{},
+		).Endpoint()
+	}
+
+	var ExportInstanceEndpoint endpoint.Endpoint
+	{
+		ExportInstanceEndpoint = grpctransport.NewClient(
+			conn,
+			"container.ContainerService",
+			"ExportInstance",
+			EncodeGRPCExportInstanceRequest,
+			DecodeGRPCExportInstanceResponse,
+			containerPB.ExportInstanceResponse{},
+		).Endpoint()
+	}
+
 	return &container.Endpoints{
 		CreateContainerEndpoint: CreateContainerEndpoint,
 		RemoveContainerEndpoint: RemoveContainerEndpoint,
@@ -175,6 +285,16 @@ func New(conn *grpc.ClientConn, logger log.Logger) *container.Endpoints {
 		SetLinkEndpoint:         SetLinkEndpoint,
 		RemoveLinkEndpoint:      RemoveLinkEndpoint,
 		GetLinksEndpoint:        GetLinksEndpoint,
+		ScaleInstanceEndpoint:   ScaleInstanceEndpoint,
+		CloneInstanceEndpoint:   CloneInstanceEndpoint,
+
+		CheckpointInstanceEndpoint: CheckpointInstanceEndpoint,
+		RestoreInstanceEndpoint:    RestoreInstanceEndpoint,
+		CheckpointsEndpoint:        CheckpointsEndpoint,
+		RemoveCheckpointEndpoint:   RemoveCheckpointEndpoint,
+		PinInstanceEndpoint:        PinInstanceEndpoint,
+		SetPlacementEndpoint:       SetPlacementEndpoint,
+		ExportInstanceEndpoint:     ExportInstanceEndpoint,
 	}
 }
 
@@ -195,6 +315,8 @@ func pbContainersToContainers(pbc []*containerPB.Container) []container.Containe
 			KMI: container.CKMI{
 				KMI: *kmiClient.ConvertKMI(c.Kmi),
 			},
+			ReplicaOf: c.ReplicaOf,
+			Replicas:  uint(c.Replicas),
 		})
 	}
 
@@ -247,6 +369,7 @@ func EncodeGRPCInstancesRequest(_ context.Context, request interface{}) (interfa
 	req := request.(*container.InstancesRequest)
 	return &containerPB.InstancesRequest{
 		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
 	}, nil
 }
 
@@ -256,6 +379,7 @@ func DecodeGRPCInstancesResponse(_ context.Context, grpcResponse interface{}) (i
 	response := grpcResponse.(*containerPB.InstancesResponse)
 	return &container.InstancesResponse{
 		Containers: pbContainersToContainers(response.Instances),
+		Page:       paging.DecodePageInfo(response.PageInfo),
 	}, nil
 }
 
@@ -450,3 +574,207 @@ func DecodeGRPCGetLinksResponse(_ context.Context, grpcResponse interface{}) (in
 		Links: arrayMap,
 	}, nil
 }
+
+// EncodeGRPCScaleInstanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain scaleinstance request to a gRPC ScaleInstance request.
+func EncodeGRPCScaleInstanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.ScaleInstanceRequest)
+	return &containerPB.ScaleInstanceRequest{
+		RefID:    uint32(req.RefID),
+		ID:       req.ID,
+		Replicas: uint32(req.Replicas),
+	}, nil
+}
+
+// DecodeGRPCScaleInstanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ScaleInstance response to a messages/container.proto-domain scaleinstance response.
+func DecodeGRPCScaleInstanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.ScaleInstanceResponse)
+	return &container.ScaleInstanceResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCCloneInstanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain cloneinstance request to a gRPC CloneInstance request.
+func EncodeGRPCCloneInstanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.CloneInstanceRequest)
+	return &containerPB.CloneInstanceRequest{
+		RefID: uint32(req.RefID),
+		ID:    req.ID,
+		Name:  req.Name,
+		Env:   req.Env,
+	}, nil
+}
+
+// DecodeGRPCCloneInstanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CloneInstance response to a messages/container.proto-domain cloneinstance response.
+func DecodeGRPCCloneInstanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.CloneInstanceResponse)
+	return &container.CloneInstanceResponse{
+		ID:      response.ID,
+		Secrets: response.Secrets,
+		Error:   getError(response.Error),
+	}, nil
+}
+
+func pbCheckpointToCheckpoint(cp *containerPB.Checkpoint) container.Checkpoint {
+	if cp == nil {
+		return container.Checkpoint{}
+	}
+	return container.Checkpoint{
+		Name:      cp.Name,
+		CreatedAt: time.Unix(cp.CreatedAt, 0).UTC(),
+	}
+}
+
+// EncodeGRPCCheckpointInstanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain checkpointinstance request to a gRPC CheckpointInstance request.
+func EncodeGRPCCheckpointInstanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.CheckpointInstanceRequest)
+	return &containerPB.CheckpointInstanceRequest{
+		RefID:        uint32(req.RefID),
+		ID:           req.ID,
+		Name:         req.Name,
+		LeaveRunning: req.LeaveRunning,
+	}, nil
+}
+
+// DecodeGRPCCheckpointInstanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CheckpointInstance response to a messages/container.proto-domain checkpointinstance response.
+func DecodeGRPCCheckpointInstanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.CheckpointInstanceResponse)
+	return &container.CheckpointInstanceResponse{
+		Checkpoint: pbCheckpointToCheckpoint(response.Checkpoint),
+		Error:      getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRestoreInstanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain restoreinstance request to a gRPC RestoreInstance request.
+func EncodeGRPCRestoreInstanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.RestoreInstanceRequest)
+	return &containerPB.RestoreInstanceRequest{
+		RefID: uint32(req.RefID),
+		ID:    req.ID,
+		Name:  req.Name,
+	}, nil
+}
+
+// DecodeGRPCRestoreInstanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RestoreInstance response to a messages/container.proto-domain restoreinstance response.
+func DecodeGRPCRestoreInstanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.RestoreInstanceResponse)
+	return &container.RestoreInstanceResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCCheckpointsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain checkpoints request to a gRPC Checkpoints request.
+func EncodeGRPCCheckpointsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.CheckpointsRequest)
+	return &containerPB.CheckpointsRequest{
+		RefID: uint32(req.RefID),
+		ID:    req.ID,
+	}, nil
+}
+
+// DecodeGRPCCheckpointsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Checkpoints response to a messages/container.proto-domain checkpoints response.
+func DecodeGRPCCheckpointsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.CheckpointsResponse)
+	cps := []container.Checkpoint{}
+	for _, cp := range response.Checkpoints {
+		cps = append(cps, pbCheckpointToCheckpoint(cp))
+	}
+	return &container.CheckpointsResponse{
+		Checkpoints: cps,
+		Error:       getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveCheckpointRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain removecheckpoint request to a gRPC RemoveCheckpoint request.
+func EncodeGRPCRemoveCheckpointRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.RemoveCheckpointRequest)
+	return &containerPB.RemoveCheckpointRequest{
+		RefID: uint32(req.RefID),
+		ID:    req.ID,
+		Name:  req.Name,
+	}, nil
+}
+
+// DecodeGRPCRemoveCheckpointResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveCheckpoint response to a messages/container.proto-domain removecheckpoint response.
+func DecodeGRPCRemoveCheckpointResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.RemoveCheckpointResponse)
+	return &container.RemoveCheckpointResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCPinInstanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain pininstance request to a gRPC PinInstance request.
+func EncodeGRPCPinInstanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.PinInstanceRequest)
+	return &containerPB.PinInstanceRequest{
+		RefID:  uint32(req.RefID),
+		ID:     req.ID,
+		Pinned: req.Pinned,
+	}, nil
+}
+
+// DecodeGRPCPinInstanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC PinInstance response to a messages/container.proto-domain pininstance response.
+func DecodeGRPCPinInstanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.PinInstanceResponse)
+	return &container.PinInstanceResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetPlacementRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain setplacement request to a gRPC SetPlacement request.
+func EncodeGRPCSetPlacementRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.SetPlacementRequest)
+	return &containerPB.SetPlacementRequest{
+		RefID:  uint32(req.RefID),
+		ID:     req.ID,
+		Spread: req.Spread,
+		Labels: req.Labels,
+	}, nil
+}
+
+// DecodeGRPCSetPlacementResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetPlacement response to a messages/container.proto-domain setplacement response.
+func DecodeGRPCSetPlacementResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.SetPlacementResponse)
+	return &container.SetPlacementResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCExportInstanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain exportinstance request to a gRPC ExportInstance request.
+func EncodeGRPCExportInstanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*container.ExportInstanceRequest)
+	return &containerPB.ExportInstanceRequest{
+		RefID: uint32(req.RefID),
+		ID:    req.ID,
+	}, nil
+}
+
+// DecodeGRPCExportInstanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ExportInstance response to a messages/container.proto-domain exportinstance response.
+func DecodeGRPCExportInstanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*containerPB.ExportInstanceResponse)
+	res := &container.ExportInstanceResponse{
+		URL:   response.Url,
+		Error: getError(response.Error),
+	}
+	if response.ExpiresAt != 0 {
+		res.ExpiresAt = time.Unix(response.ExpiresAt, 0).UTC()
+	}
+	return res, nil
+}
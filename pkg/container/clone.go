@@ -0,0 +1,211 @@
+// +build linux
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/backup"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"golang.org/x/net/context"
+)
+
+// volumePart is the name of the rootfs in the snapshots clones are restored from and instances are exported to
+const volumePart = "volume"
+
+func (s *service) CloneInstance(ctx context.Context, refID uint, id string, name string, env map[string]string) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).cloneInstance(refID, id, name, env)
+}
+
+func (s *service) cloneInstance(refID uint, id string, name string, env map[string]string) (string, error) {
+	err := s.schedulable()
+	if err != nil {
+		return "", err
+	}
+
+	c := Container{}
+	err = s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return "", err
+	}
+
+	if c.ReplicaOf != "" {
+		return "", errors.New("a replica can not be cloned")
+	}
+
+	if _, err := s.idForName(refID, name); err == nil {
+		return "", fmt.Errorf("an instance named %s exists already", name)
+	}
+
+	ckmi, err := s.getCKMI(id)
+	if err != nil {
+		return "", err
+	}
+
+	cloneEnv, err := cloneEnvironment(ckmi.KMI, ckmi.Environment.ToStringMap(), env)
+	if err != nil {
+		return "", err
+	}
+	ckmi.Environment = abstraction.NewJSONFromMap(cloneEnv)
+
+	cloneID, err := s.createInstance(refID, ckmi.KMI, ckmi.Links, name, "", c.Image, c.Placement)
+	if err != nil {
+		return "", err
+	}
+
+	clone := Container{
+		RefID:         refID,
+		ContainerID:   cloneID,
+		ContainerName: name,
+	}
+	err = s.copyVolume(c, clone)
+	if err == nil && s.firewall != nil {
+		ip := s.ip(refID, cloneID)
+		if ip != "" {
+			err = s.linkFirewall(ckmi, refID, abstraction.Inet(ip), true)
+		}
+	}
+	if err == nil {
+		err = s.routeClone(c, clone)
+	}
+	if err != nil {
+		rerr := s.removeContainer(refID, cloneID)
+		if rerr != nil {
+			level.Error(s.logger).Log("clone", name, "err", rerr)
+		}
+		return "", err
+	}
+
+	s.publish(events.ContainerCreated, events.ContainerEvent{
+		RefID:       refID,
+		ContainerID: cloneID,
+		Name:        name,
+	})
+	return cloneID, nil
+}
+
+// cloneEnvironment copies the environment of an instance and applies env to it. The values of the
+// secrets flagged by k are not copied, every secret of the instance needs a value in env.
+func cloneEnvironment(k kmi.KMI, src map[string]string, env map[string]string) (map[string]string, error) {
+	clone := make(map[string]string)
+	missing := []string{}
+	for name, v := range src {
+		if !k.IsSecret(name) {
+			clone[name] = v
+			continue
+		}
+
+		if _, ok := env[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, &SecretsError{Keys: missing}
+	}
+
+	for k, v := range env {
+		clone[k] = v
+	}
+	return clone, nil
+}
+
+// copyVolume snapshots the rootfs of c and restores it over the rootfs of the clone, so the clone
+// starts with the data of the instance at the time it was cloned
+func (s *service) copyVolume(c Container, clone Container) error {
+	rootfs := func(c Container) string {
+		return path.Join(s.config.CustomerPath, fmt.Sprintf("%d", c.RefID), c.ContainerID, "rootfs")
+	}
+
+	dir, err := ioutil.TempDir("", "clone")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "snapshot")
+	_, err = backup.Create(file, s.config.NodeName, []backup.Part{backup.Directory(volumePart, rootfs(c))})
+	if err != nil {
+		return err
+	}
+
+	_, err = backup.Restore(file, []backup.Part{backup.Directory(volumePart, rootfs(clone))})
+	return err
+}
+
+// routeClone copies the routing configuration of c for the clone and serves it at its staging domain.
+// The upstream members of c are replaced by the clone, the ones of its replicas are dropped.
+func (s *service) routeClone(c Container, clone Container) error {
+	if s.routing == nil {
+		return nil
+	}
+
+	res, err := s.routing.GetConfigEndpoint(s.ctx, routing.GetConfigRequest{
+		IDRequest: routing.IDRequest{RefID: c.RefID, Name: c.ContainerName},
+	})
+	if err != nil || res.(routing.GetConfigResponse).Error != nil {
+		// the instance is not routed
+		return nil
+	}
+
+	conf := res.(routing.GetConfigResponse).Config
+	conf.Name = clone.ContainerName
+	conf.ServerName = []string{StagingDomain(clone.ContainerName, s.config.BaseDomain)}
+
+	srcIP := s.ip(c.RefID, c.ContainerID)
+	ip := s.ip(clone.RefID, clone.ContainerID)
+	address := func(a string) string {
+		if srcIP == "" || ip == "" {
+			return a
+		}
+		return strings.Replace(a, srcIP+":", ip+":", -1)
+	}
+
+	upstreams := routing.Upstreams{}
+	for _, u := range conf.Upstreams {
+		members := []*routing.UpstreamMember{}
+		for _, m := range u.Members {
+			if ip == "" || srcIP == "" || !strings.HasPrefix(m.Address, srcIP+":") {
+				continue
+			}
+			member := *m
+			member.Address = address(m.Address)
+			members = append(members, &member)
+		}
+		upstreams = append(upstreams, &routing.Upstream{
+			Name:      u.Name,
+			Algorithm: u.Algorithm,
+			Members:   members,
+		})
+	}
+	conf.Upstreams = upstreams
+
+	for _, l := range conf.LocationRules {
+		if l.Proxy != nil {
+			l.Proxy.Upstream = address(l.Proxy.Upstream)
+		}
+	}
+
+	res, err = s.routing.CreateConfigEndpoint(s.ctx, routing.CreateConfigRequest{
+		Config: &conf,
+	})
+	if err != nil {
+		return err
+	}
+	return res.(routing.CreateConfigResponse).Error
+}
@@ -0,0 +1,238 @@
+// +build integration,linux
+
+package container_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/util"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opencontainers/runc/libcontainer"
+	_ "github.com/opencontainers/runc/libcontainer/nsenter"
+)
+
+/* The integration tests create real containers on the host, so they have to run as root with
+ *  go test -tags integration ./pkg/container/
+ *  KROO_INTEGRATION_ROOTFS is a directory holding the rootfs.tar (gzipped, with /bin/sh and /bin/echo)
+ *  the containers are created from and KROO_INTEGRATION_NETNS the path of the netns binary.
+ *  The test binary is the init binary of the containers, like kroo-init. */
+
+func init() {
+	if len(os.Args) > 1 && os.Args[1] == "init" {
+		runtime.GOMAXPROCS(1)
+		runtime.LockOSThread()
+		factory, err := libcontainer.New("")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := factory.StartInitialization(); err != nil {
+			log.Fatal(err)
+		}
+		panic("--this line should have never been executed, congratulations--")
+	}
+}
+
+// kmiEndpoints returns the KMI endpoints the container service fetches the templates from
+func kmiEndpoints(kmis map[uint]kmi.KMI) *kmi.Endpoints {
+	return &kmi.Endpoints{
+		GetKMIEndpoint: func(ctx context.Context, req interface{}) (interface{}, error) {
+			k, ok := kmis[req.(kmi.GetKMIRequest).ID]
+			if !ok {
+				return kmi.GetKMIResponse{Error: errors.New("KMI not found")}, nil
+			}
+			return kmi.GetKMIResponse{KMI: &k}, nil
+		},
+	}
+}
+
+var _ = Describe("Container Integration", func() {
+	const refID uint = 1
+
+	ctx := context.Background()
+
+	var (
+		dir     string
+		factory libcontainer.Factory
+		service container.Service
+	)
+
+	BeforeEach(func() {
+		rootfs, netns := os.Getenv("KROO_INTEGRATION_ROOTFS"), os.Getenv("KROO_INTEGRATION_NETNS")
+		if rootfs == "" || netns == "" {
+			Skip("KROO_INTEGRATION_ROOTFS and KROO_INTEGRATION_NETNS are not set")
+		}
+		if os.Geteuid() != 0 {
+			Skip("containers can only be created as root")
+		}
+
+		var err error
+		dir, err = ioutil.TempDir("", "kroo-integration")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		util.SetConfig(util.ConfigFile{
+			RootfsPath:           rootfs,
+			CustomerPath:         path.Join(dir, "customers"),
+			NetNSPath:            netns,
+			StandardPathVariable: "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+			NodeName:             "integration",
+		})
+
+		factory, err = libcontainer.New(path.Join(dir, "containers"), libcontainer.Cgroupfs, libcontainer.InitArgs(os.Args[0], "init"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		ke := kmiEndpoints(map[uint]kmi.KMI{
+			1: kmi.KMI{
+				ID:              1,
+				Name:            "integration",
+				ProvisionScript: "echo provisioned > /provisioned",
+				Environment: abstraction.NewJSONFromMap(map[string]string{
+					"GREETING":    "hello",
+					"DB_PASSWORD": "production",
+				}),
+			},
+		})
+
+		service, err = container.NewService(factory, testutils.NewMockDB(), ke, nil, nil, nil, nil, nil, kitlog.NewNopLogger())
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if dir != "" {
+			os.RemoveAll(dir)
+		}
+	})
+
+	It("Should create a container from the KMI", func() {
+		id, err := service.CreateContainer(ctx, refID, 1, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(id).ShouldNot(BeEmpty())
+
+		_, err = factory.Load(id)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		instances := service.Instances(ctx, refID)
+		Ω(instances).Should(HaveLen(1))
+		Ω(instances[0].ContainerID).Should(Equal(id))
+		Ω(instances[0].ContainerName).Should(Equal("web"))
+
+		found, err := service.IDForName(ctx, refID, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(found).Should(Equal(id))
+	})
+
+	It("Should provision the root filesystem", func() {
+		id, err := service.CreateContainer(ctx, refID, 1, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		out, err := service.Execute(ctx, refID, id, "cat /provisioned", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(strings.TrimSpace(out)).Should(Equal("provisioned"))
+	})
+
+	It("Should execute commands with the environment of the KMI", func() {
+		id, err := service.CreateContainer(ctx, refID, 1, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		out, err := service.Execute(ctx, refID, id, "echo $GREETING $NAME", map[string]string{
+			"NAME": "world",
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(strings.TrimSpace(out)).Should(Equal("hello world"))
+
+		value, err := service.GetEnv(ctx, refID, id, "GREETING")
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(value).Should(Equal("hello"))
+	})
+
+	It("Should kill a command once its request is cancelled", func() {
+		id, err := service.CreateContainer(ctx, refID, 1, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		short, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		_, err = service.Execute(short, refID, id, "sleep 10", nil)
+		Ω(err).Should(Equal(context.DeadlineExceeded))
+	})
+
+	It("Should stop a container", func() {
+		id, err := service.CreateContainer(ctx, refID, 1, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(service.StopContainer(ctx, refID, id)).Should(Succeed())
+
+		c, err := factory.Load(id)
+		Ω(err).ShouldNot(HaveOccurred())
+		status, err := c.Status()
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(status).ShouldNot(Equal(libcontainer.Running))
+
+		n, err := service.CountRunning(ctx)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(n).Should(BeZero())
+	})
+
+	It("Should remove a container", func() {
+		id, err := service.CreateContainer(ctx, refID, 1, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		Ω(service.RemoveContainer(ctx, refID, id)).Should(Succeed())
+		Ω(service.Instances(ctx, refID)).Should(BeEmpty())
+	})
+
+	It("Should clone an instance with its volume but without its secrets", func() {
+		id, err := service.CreateContainer(ctx, refID, 1, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+		_, err = service.Execute(ctx, refID, id, "echo data > /data", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = service.CloneInstance(ctx, refID, id, "web-staging", nil)
+		Ω(err).Should(BeAssignableToTypeOf(&container.SecretsError{}))
+		Ω(err.(*container.SecretsError).Keys).Should(Equal([]string{"DB_PASSWORD"}))
+
+		clone, err := service.CloneInstance(ctx, refID, id, "web-staging", map[string]string{
+			"DB_PASSWORD": "staging",
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(clone).ShouldNot(Equal(id))
+
+		out, err := service.Execute(ctx, refID, clone, "cat /data; echo $GREETING $DB_PASSWORD", nil)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(strings.TrimSpace(out)).Should(Equal("data\nhello staging"))
+		Ω(service.Instances(ctx, refID)).Should(HaveLen(2))
+	})
+
+	It("Should reject checkpoints unless they are enabled", func() {
+		id, err := service.CreateContainer(ctx, refID, 1, "web")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = service.CheckpointInstance(ctx, refID, id, "before-upgrade", true)
+		Ω(err).Should(Equal(container.ErrCheckpointUnsupported))
+		Ω(service.RestoreInstance(ctx, refID, id, "before-upgrade")).Should(Equal(container.ErrCheckpointUnsupported))
+
+		cps, err := service.Checkpoints(ctx, refID, id)
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(cps).Should(BeEmpty())
+	})
+
+	It("Should fail for unknown KMI", func() {
+		_, err := service.CreateContainer(ctx, refID, 2, "web")
+		Ω(err).Should(HaveOccurred())
+		Ω(service.Instances(ctx, refID)).Should(BeEmpty())
+	})
+})
@@ -1,8 +1,20 @@
 package container
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/download"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+// Files in the directory of an instance the standard output and error of its processes are appended to
+const (
+	StdoutLog = "stdout.log"
+	StderrLog = "stderr.log"
 )
 
 // Container is the DB entry for each container module with its
@@ -13,8 +25,95 @@ type Container struct {
 	ContainerName string
 	KMIID         uint
 	KMI           CKMI
+	ReplicaOf     string
+	Replicas      uint
+	// Image is the rootfs archive the containers of the instance are created from, they are created
+	// from the base rootfs and provisioned by their KMI if it is empty
+	Image string
+	// Node is the name of the node the container runs on
+	Node string
+	// Pinned instances stay on their node, they are not placed on another node once it failed,
+	// e.g. since their files only exist there
+	Pinned bool
+	// Module is the name of the KMI the instance was created from, Placement the placement of the KMI
+	// together with the one the user set
+	Module    string
+	Placement kmi.Placement `sql:"type:jsonb"`
+}
+
+// NodeLabel is a label of a node set by the operators of the admin service
+type NodeLabel struct {
+	Node  string `gorm:"primary_key"`
+	Key   string `gorm:"primary_key"`
+	Value string
+}
+
+// TableName sets the database name for node labels
+func (NodeLabel) TableName() string {
+	return "node_labels"
+}
+
+// LostContainer is a container of a failed node whose instance was placed on another node,
+// the failed node removes it once it is back
+type LostContainer struct {
+	RefID       uint
+	ContainerID string `gorm:"primary_key"`
+	Node        string
+}
+
+// TableName sets the database name for lost containers
+func (LostContainer) TableName() string {
+	return "container_lost"
+}
+
+// Usage is the resource usage of a running container
+type Usage struct {
+	RefID         uint
+	ContainerID   string
+	ContainerName string
+	// ReplicaOf is the id of the instance if the container is one of its replicas
+	ReplicaOf string
+	// IP is the address of the container in its bridge network
+	IP string
+	// CPU is the CPU time the container used since it was started in nanoseconds
+	CPU uint64
+	// Memory is the memory the container uses and MemoryLimit the most it may use in bytes
+	Memory      uint64
+	MemoryLimit uint64
+	// Started identifies the start of the init process of the container, it changes once the container restarts
+	Started string
 }
 
+// Limits are the resources every container of a node may use, zero values are unlimited
+type Limits struct {
+	// Memory is the memory in bytes and Swap the swap in bytes a container may use on top of it
+	Memory int64
+	Swap   int64
+	// CPU is the number of cores, e.g. 0.5 for half of a core
+	CPU float64
+	// Pids is the number of processes and threads
+	Pids int64
+}
+
+// Checkpoint is a CRIU image of the processes of a container the container can be restored from,
+// it is stored in the checkpoints directory of the instance
+type Checkpoint struct {
+	ID          uint `gorm:"primary_key"`
+	RefID       uint
+	ContainerID string
+	Name        string
+	CreatedAt   time.Time
+}
+
+// TableName sets the database name for checkpoint
+func (Checkpoint) TableName() string {
+	return "container_checkpoints"
+}
+
+// RestartCheckpoint is the name of the checkpoints the running containers of a node are saved as when the
+// daemon shuts down, the containers are restored from them once it started again
+const RestartCheckpoint = "restart"
+
 // CKMI is the database representation for the kmi of a specific instance
 type CKMI struct {
 	kmi.KMI
@@ -25,3 +124,25 @@ type CKMI struct {
 func (CKMI) TableName() string {
 	return "container_kmis"
 }
+
+// Exports keeps the archives of the volumes of exported instances and hands out their download URLs
+type Exports struct {
+	// Store keeps the archives, e.g. storage.Prefix(artifacts, storage.ContainerExports)
+	Store storage.Store
+	// URLs hands out the signed download URLs of the objects of Store
+	URLs download.URLs
+}
+
+// SecretsError is returned if an instance is cloned without a value for each of its secrets
+type SecretsError struct {
+	Keys []string
+}
+
+func (e *SecretsError) Error() string {
+	return fmt.Sprintf("values for the secrets %s are required", strings.Join(e.Keys, ", "))
+}
+
+// StagingDomain returns the domain the clone of an instance named name is served at
+func StagingDomain(name string, baseDomain string) string {
+	return fmt.Sprintf("%s.staging.%s", name, baseDomain)
+}
@@ -2,9 +2,11 @@ package container
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 )
 
 // Endpoints is a struct which collects all endpoints for the container service
@@ -23,14 +25,24 @@ type Endpoints struct {
 
 	RemoveLinkEndpoint endpoint.Endpoint
 
-	GetLinksEndpoint endpoint.Endpoint
+	GetLinksEndpoint      endpoint.Endpoint
+	ScaleInstanceEndpoint endpoint.Endpoint
+	CloneInstanceEndpoint endpoint.Endpoint
+
+	CheckpointInstanceEndpoint endpoint.Endpoint
+	RestoreInstanceEndpoint    endpoint.Endpoint
+	CheckpointsEndpoint        endpoint.Endpoint
+	RemoveCheckpointEndpoint   endpoint.Endpoint
+	PinInstanceEndpoint        endpoint.Endpoint
+	SetPlacementEndpoint       endpoint.Endpoint
+	ExportInstanceEndpoint     endpoint.Endpoint
 }
 
 // CreateContainerRequest is the request struct for the CreateContainerEndpoint
 type CreateContainerRequest struct {
-	RefID uint `bart:"ref"`
-	KmiID uint
-	Name  string
+	RefID uint   `bart:"ref"`
+	KmiID uint   `validate:"required"`
+	Name  string `validate:"required,name"`
 }
 
 // CreateContainerResponse is the response struct for the CreateContainerEndpoint
@@ -43,7 +55,7 @@ type CreateContainerResponse struct {
 func MakeCreateContainerEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(CreateContainerRequest)
-		id, err := s.CreateContainer(req.RefID, req.KmiID, req.Name)
+		id, err := s.CreateContainer(ctx, req.RefID, req.KmiID, req.Name)
 		return CreateContainerResponse{
 			ID:    id,
 			Error: err,
@@ -53,8 +65,8 @@ func MakeCreateContainerEndpoint(s Service) endpoint.Endpoint {
 
 // RemoveContainerRequest is the request struct for the RemoveContainerEndpoint
 type RemoveContainerRequest struct {
-	RefID uint `bart:"ref"`
-	ID    string
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
 }
 
 // RemoveContainerResponse is the response struct for the RemoveContainerEndpoint
@@ -66,7 +78,7 @@ type RemoveContainerResponse struct {
 func MakeRemoveContainerEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(RemoveContainerRequest)
-		err := s.RemoveContainer(req.RefID, req.ID)
+		err := s.RemoveContainer(ctx, req.RefID, req.ID)
 		return RemoveContainerResponse{
 			Error: err,
 		}, nil
@@ -76,28 +88,35 @@ func MakeRemoveContainerEndpoint(s Service) endpoint.Endpoint {
 // InstancesRequest is the request struct for the InstancesEndpoint
 type InstancesRequest struct {
 	RefID uint `bart:"ref"`
+	Page  paging.Request
 }
 
 // InstancesResponse is the response struct for the InstancesEndpoint
 type InstancesResponse struct {
 	Containers []Container
+	Page       paging.Response
 }
 
 // MakeInstancesEndpoint creates a gokit endpoint which invokes Instances
 func MakeInstancesEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(InstancesRequest)
-		cnt := s.Instances(req.RefID)
+		cnt := s.Instances(ctx, req.RefID)
+		page, err := paging.Apply(&cnt, "ContainerID", req.Page)
+		if err != nil {
+			return nil, err
+		}
 		return InstancesResponse{
 			Containers: cnt,
+			Page:       page,
 		}, nil
 	}
 }
 
 // StopContainerRequest is the request struct for the StopContainerEndpoint
 type StopContainerRequest struct {
-	RefID uint `bart:"ref"`
-	ID    string
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
 }
 
 // StopContainerResponse is the response struct for the StopContainerEndpoint
@@ -109,7 +128,7 @@ type StopContainerResponse struct {
 func MakeStopContainerEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(StopContainerRequest)
-		err := s.StopContainer(req.RefID, req.ID)
+		err := s.StopContainer(ctx, req.RefID, req.ID)
 		return StopContainerResponse{
 			Error: err,
 		}, nil
@@ -118,9 +137,9 @@ func MakeStopContainerEndpoint(s Service) endpoint.Endpoint {
 
 // ExecuteRequest is the request struct for the ExecuteEndpoint
 type ExecuteRequest struct {
-	RefID uint `bart:"ref"`
-	ID    string
-	CMD   string
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
+	CMD   string `validate:"required"`
 	Env   map[string]string
 }
 
@@ -134,7 +153,7 @@ type ExecuteResponse struct {
 func MakeExecuteEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(ExecuteRequest)
-		res, err := s.Execute(req.RefID, req.ID, req.CMD, req.Env)
+		res, err := s.Execute(ctx, req.RefID, req.ID, req.CMD, req.Env)
 		return ExecuteResponse{
 			Response: res,
 			Error:    err,
@@ -144,9 +163,9 @@ func MakeExecuteEndpoint(s Service) endpoint.Endpoint {
 
 // GetEnvRequest is the request struct for the GetEnvEndpoint
 type GetEnvRequest struct {
-	RefID uint `bart:"ref"`
-	ID    string
-	Key   string
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
+	Key   string `validate:"required"`
 }
 
 // GetEnvResponse is the response struct for the GetEnvEndpoint
@@ -159,7 +178,7 @@ type GetEnvResponse struct {
 func MakeGetEnvEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetEnvRequest)
-		res, err := s.GetEnv(req.RefID, req.ID, req.Key)
+		res, err := s.GetEnv(ctx, req.RefID, req.ID, req.Key)
 		return GetEnvResponse{
 			Value: res,
 			Error: err,
@@ -169,9 +188,9 @@ func MakeGetEnvEndpoint(s Service) endpoint.Endpoint {
 
 // SetEnvRequest is the request struct for the SetEnvEndpoint
 type SetEnvRequest struct {
-	RefID uint `bart:"ref"`
-	ID    string
-	Key   string
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
+	Key   string `validate:"required"`
 	Value string
 }
 
@@ -184,7 +203,7 @@ type SetEnvResponse struct {
 func MakeSetEnvEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(SetEnvRequest)
-		err := s.SetEnv(req.RefID, req.ID, req.Key, req.Value)
+		err := s.SetEnv(ctx, req.RefID, req.ID, req.Key, req.Value)
 		return SetEnvResponse{
 			Error: err,
 		}, nil
@@ -193,8 +212,8 @@ func MakeSetEnvEndpoint(s Service) endpoint.Endpoint {
 
 // IDForNameRequest is the request struct for the IDForNameEndpoint
 type IDForNameRequest struct {
-	RefID uint `bart:"ref"`
-	Name  string
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required,name"`
 }
 
 // IDForNameResponse is the response struct for the IDForNameEndpoint
@@ -207,7 +226,7 @@ type IDForNameResponse struct {
 func MakeIDForNameEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(IDForNameRequest)
-		id, err := s.IDForName(req.RefID, req.Name)
+		id, err := s.IDForName(ctx, req.RefID, req.Name)
 		return IDForNameResponse{
 			ID:    id,
 			Error: err,
@@ -230,7 +249,7 @@ type GetContainerKMIResponse struct {
 func MakeGetContainerKMIEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetContainerKMIRequest)
-		containerKMI, err := s.GetContainerKMI(req.ContainerID)
+		containerKMI, err := s.GetContainerKMI(ctx, req.ContainerID)
 		return GetContainerKMIResponse{
 			ContainerKMI: containerKMI,
 			Error:        err,
@@ -256,7 +275,7 @@ type SetLinkResponse struct {
 func MakeSetLinkEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(SetLinkRequest)
-		err := s.SetLink(req.RefID, req.ContainerID, req.LinkID, req.LinkName, req.LinkInterface)
+		err := s.SetLink(ctx, req.RefID, req.ContainerID, req.LinkID, req.LinkName, req.LinkInterface)
 		return SetLinkResponse{
 			Error: err,
 		}, nil
@@ -281,7 +300,7 @@ type RemoveLinkResponse struct {
 func MakeRemoveLinkEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(RemoveLinkRequest)
-		err := s.RemoveLink(req.RefID, req.ContainerID, req.LinkID, req.LinkName, req.LinkInterface)
+		err := s.RemoveLink(ctx, req.RefID, req.ContainerID, req.LinkID, req.LinkName, req.LinkInterface)
 		return RemoveLinkResponse{
 			Error: err,
 		}, nil
@@ -304,10 +323,237 @@ type GetLinksResponse struct {
 func MakeGetLinksEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetLinksRequest)
-		links, err := s.GetLinks(req.RefID, req.ContainerID)
+		links, err := s.GetLinks(ctx, req.RefID, req.ContainerID)
 		return GetLinksResponse{
 			Links: links,
 			Error: err,
 		}, nil
 	}
 }
+
+// ScaleInstanceRequest is the request struct for the ScaleInstanceEndpoint
+type ScaleInstanceRequest struct {
+	RefID    uint   `bart:"ref"`
+	ID       string `validate:"required"`
+	Replicas uint
+}
+
+// ScaleInstanceResponse is the response struct for the ScaleInstanceEndpoint
+type ScaleInstanceResponse struct {
+	Error error
+}
+
+// MakeScaleInstanceEndpoint creates a gokit endpoint which invokes ScaleInstance
+func MakeScaleInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ScaleInstanceRequest)
+		err := s.ScaleInstance(ctx, req.RefID, req.ID, req.Replicas)
+		return ScaleInstanceResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// CloneInstanceRequest is the request struct for the CloneInstanceEndpoint
+type CloneInstanceRequest struct {
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
+	Name  string `validate:"required,name"`
+	Env   map[string]string
+}
+
+// CloneInstanceResponse is the response struct for the CloneInstanceEndpoint, Secrets are
+// the secrets which need a value if the clone failed because of them
+type CloneInstanceResponse struct {
+	ID      string
+	Secrets []string
+	Error   error
+}
+
+// MakeCloneInstanceEndpoint creates a gokit endpoint which invokes CloneInstance
+func MakeCloneInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CloneInstanceRequest)
+		id, err := s.CloneInstance(ctx, req.RefID, req.ID, req.Name, req.Env)
+		res := CloneInstanceResponse{
+			ID:    id,
+			Error: err,
+		}
+		if e, ok := err.(*SecretsError); ok {
+			res.Secrets = e.Keys
+		}
+		return res, nil
+	}
+}
+
+// CheckpointInstanceRequest is the request struct for the CheckpointInstanceEndpoint
+type CheckpointInstanceRequest struct {
+	RefID        uint   `bart:"ref"`
+	ID           string `validate:"required"`
+	Name         string `validate:"required,name"`
+	LeaveRunning bool
+}
+
+// CheckpointInstanceResponse is the response struct for the CheckpointInstanceEndpoint
+type CheckpointInstanceResponse struct {
+	Checkpoint Checkpoint
+	Error      error
+}
+
+// MakeCheckpointInstanceEndpoint creates a gokit endpoint which invokes CheckpointInstance
+func MakeCheckpointInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CheckpointInstanceRequest)
+		cp, err := s.CheckpointInstance(ctx, req.RefID, req.ID, req.Name, req.LeaveRunning)
+		return CheckpointInstanceResponse{
+			Checkpoint: cp,
+			Error:      err,
+		}, nil
+	}
+}
+
+// RestoreInstanceRequest is the request struct for the RestoreInstanceEndpoint
+type RestoreInstanceRequest struct {
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
+	Name  string `validate:"required,name"`
+}
+
+// RestoreInstanceResponse is the response struct for the RestoreInstanceEndpoint
+type RestoreInstanceResponse struct {
+	Error error
+}
+
+// MakeRestoreInstanceEndpoint creates a gokit endpoint which invokes RestoreInstance
+func MakeRestoreInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RestoreInstanceRequest)
+		err := s.RestoreInstance(ctx, req.RefID, req.ID, req.Name)
+		return RestoreInstanceResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// CheckpointsRequest is the request struct for the CheckpointsEndpoint
+type CheckpointsRequest struct {
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
+}
+
+// CheckpointsResponse is the response struct for the CheckpointsEndpoint
+type CheckpointsResponse struct {
+	Checkpoints []Checkpoint
+	Error       error
+}
+
+// MakeCheckpointsEndpoint creates a gokit endpoint which invokes Checkpoints
+func MakeCheckpointsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CheckpointsRequest)
+		cps, err := s.Checkpoints(ctx, req.RefID, req.ID)
+		return CheckpointsResponse{
+			Checkpoints: cps,
+			Error:       err,
+		}, nil
+	}
+}
+
+// RemoveCheckpointRequest is the request struct for the RemoveCheckpointEndpoint
+type RemoveCheckpointRequest struct {
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
+	Name  string `validate:"required"`
+}
+
+// RemoveCheckpointResponse is the response struct for the RemoveCheckpointEndpoint
+type RemoveCheckpointResponse struct {
+	Error error
+}
+
+// MakeRemoveCheckpointEndpoint creates a gokit endpoint which invokes RemoveCheckpoint
+func MakeRemoveCheckpointEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveCheckpointRequest)
+		err := s.RemoveCheckpoint(ctx, req.RefID, req.ID, req.Name)
+		return RemoveCheckpointResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// PinInstanceRequest is the request struct for the PinInstanceEndpoint
+type PinInstanceRequest struct {
+	RefID  uint   `bart:"ref"`
+	ID     string `validate:"required"`
+	Pinned bool
+}
+
+// PinInstanceResponse is the response struct for the PinInstanceEndpoint
+type PinInstanceResponse struct {
+	Error error
+}
+
+// MakePinInstanceEndpoint creates a gokit endpoint which invokes PinInstance
+func MakePinInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PinInstanceRequest)
+		err := s.PinInstance(ctx, req.RefID, req.ID, req.Pinned)
+		return PinInstanceResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// SetPlacementRequest is the request struct for the SetPlacementEndpoint
+type SetPlacementRequest struct {
+	RefID  uint   `bart:"ref"`
+	ID     string `validate:"required"`
+	Spread bool
+	Labels map[string]string
+}
+
+// SetPlacementResponse is the response struct for the SetPlacementEndpoint
+type SetPlacementResponse struct {
+	Error error
+}
+
+// MakeSetPlacementEndpoint creates a gokit endpoint which invokes SetPlacement
+func MakeSetPlacementEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetPlacementRequest)
+		err := s.SetPlacement(ctx, req.RefID, req.ID, kmi.Placement{
+			Spread: req.Spread,
+			Labels: req.Labels,
+		})
+		return SetPlacementResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// ExportInstanceRequest is the request struct for the ExportInstanceEndpoint
+type ExportInstanceRequest struct {
+	RefID uint   `bart:"ref"`
+	ID    string `validate:"required"`
+}
+
+// ExportInstanceResponse is the response struct for the ExportInstanceEndpoint
+type ExportInstanceResponse struct {
+	URL       string
+	ExpiresAt time.Time
+	Error     error
+}
+
+// MakeExportInstanceEndpoint creates a gokit endpoint which invokes ExportInstance
+func MakeExportInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ExportInstanceRequest)
+		u, expires, err := s.ExportInstance(ctx, req.RefID, req.ID)
+		return ExportInstanceResponse{
+			URL:       u,
+			ExpiresAt: expires,
+			Error:     err,
+		}, nil
+	}
+}
@@ -0,0 +1,171 @@
+// +build linux
+
+package container
+
+import (
+	"errors"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+)
+
+// ErrNodeDrained is returned if an instance should be created on a drained node
+var ErrNodeDrained = errors.New("node is drained")
+
+// ErrNodeCordoned is returned if an instance should be created on a cordoned node
+var ErrNodeCordoned = errors.New("node is cordoned")
+
+// schedulable returns an error if new instances may not be placed on this node
+func (s *service) schedulable() error {
+	if s.drained {
+		return ErrNodeDrained
+	}
+	if s.cordoned {
+		return ErrNodeCordoned
+	}
+	return nil
+}
+
+// publish publishes an event of a container, errors are only logged since the
+// container itself changed regardless of whether other services learn about it
+func (s *service) publish(topic string, e events.ContainerEvent) {
+	if s.events == nil {
+		return
+	}
+
+	err := s.events.Publish(topic, e)
+	if err != nil {
+		level.Error(s.logger).Log("topic", topic, "container", e.ContainerID, "err", err)
+	}
+}
+
+// subscribe handles the commands operators send to the instances of this node. Every node receives
+// every command without a group, so commands published while a node is down are not delivered to it.
+func (s *service) subscribe() error {
+	if s.events == nil {
+		return nil
+	}
+
+	commands, err := s.events.Subscribe(events.ContainerCommands, "", s.handleCommand)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.events.Subscribe(events.NodeChanged, "", s.handleNodeChanged)
+	if err != nil {
+		commands.Unsubscribe()
+		return err
+	}
+	return nil
+}
+
+// handleNodeChanged rejects the creation of instances while this node is drained or cordoned
+func (s *service) handleNodeChanged(e events.Event) error {
+	n := events.NodeEvent{}
+	err := e.Decode(&n)
+	if err != nil {
+		level.Error(s.logger).Log("event", e.ID, "err", err)
+		return nil
+	}
+	if n.Name != s.config.NodeName {
+		return nil
+	}
+
+	s.mtx.Lock()
+	s.drained = n.Drained
+	s.cordoned = n.Cordoned
+	s.mtx.Unlock()
+
+	level.Info(s.logger).Log("node", n.Name, "drained", n.Drained, "cordoned", n.Cordoned)
+	return nil
+}
+
+// handleCommand runs a command addressed to this node. A failed command is logged instead of being
+// delivered again, since it would most likely fail again and operators see its outcome in the admin views.
+func (s *service) handleCommand(e events.Event) error {
+	c := events.ContainerCommand{}
+	err := e.Decode(&c)
+	if err != nil {
+		level.Error(s.logger).Log("event", e.ID, "err", err)
+		return nil
+	}
+	if c.Node != s.config.NodeName {
+		return nil
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	switch e.Topic {
+	case events.StopContainer:
+		err = s.stopContainer(c.RefID, c.ContainerID)
+	case events.MoveContainer:
+		err = s.move(c)
+	case events.PlaceContainer:
+		err = s.place(c)
+	default:
+		return nil
+	}
+
+	if err != nil {
+		level.Error(s.logger).Log("command", e.Topic, "container", c.ContainerID, "err", err)
+	}
+	return nil
+}
+
+// move removes an instance and its replicas and lets the target node create it again.
+// Only the instance is moved, the files of its container are not migrated.
+func (s *service) move(c events.ContainerCommand) error {
+	err := s.removeContainer(c.RefID, c.ContainerID)
+	if err != nil {
+		return err
+	}
+
+	c.Node = c.Target
+	return s.events.Publish(events.PlaceContainer, c)
+}
+
+// place creates an instance which was removed from another node with the KMI it was created with. An instance
+// lost with its failed node is forgotten once it was created, so it stays listed on the failed node otherwise.
+func (s *service) place(c events.ContainerCommand) error {
+	err := s.schedulable()
+	if err != nil {
+		return err
+	}
+
+	ckmi := CKMI{}
+	err = s.db.First(&ckmi, "id = ?", c.KMIID)
+	if err != nil {
+		return err
+	}
+
+	// instances moved before their placement was recorded get the placement of their module
+	p, err := ckmi.KMI.Placement.Merge(kmi.Placement{Spread: c.Spread, Labels: c.Labels})
+	if err != nil {
+		return err
+	}
+
+	id, err := s.createInstance(c.RefID, ckmi.KMI, ckmi.Links, c.Name, "", "", p)
+	if err != nil {
+		return err
+	}
+
+	if c.Lost {
+		err = s.forget(c.ContainerID)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.publish(events.ContainerCreated, events.ContainerEvent{
+		RefID:       c.RefID,
+		ContainerID: id,
+		Name:        c.Name,
+	})
+
+	if c.Replicas == 0 {
+		return nil
+	}
+	return s.scaleInstance(c.RefID, id, c.Replicas)
+}
@@ -0,0 +1,84 @@
+// +build linux
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/backup"
+	"golang.org/x/net/context"
+)
+
+// ErrExportsDisabled is returned if an instance is exported but the installation keeps no container exports
+var ErrExportsDisabled = errors.New("container exports are disabled")
+
+// exportKey is the key of the archive of an instance, every export replaces the previous one
+func exportKey(c Container) string {
+	return fmt.Sprintf("%d/%s.tar.gz", c.RefID, c.ContainerID)
+}
+
+func (s *service) ExportInstance(ctx context.Context, refID uint, id string) (string, time.Time, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).exportInstance(refID, id)
+}
+
+func (s *service) exportInstance(refID uint, id string) (string, time.Time, error) {
+	if s.exports == nil {
+		return "", time.Time{}, ErrExportsDisabled
+	}
+
+	c := Container{}
+	err := s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "volume.tar.gz")
+	rootfs := path.Join(s.config.CustomerPath, fmt.Sprintf("%d", c.RefID), c.ContainerID, "rootfs")
+	_, err = backup.Create(file, s.config.NodeName, []backup.Part{backup.Directory(volumePart, rootfs)})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	err = s.exports.Store.Put(exportKey(c), f, info.Size())
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	u, expires := s.exports.URLs.URL(exportKey(c), c.ContainerName+".tar.gz")
+	return u, expires, nil
+}
+
+// removeExport removes the archive of a removed instance from the store
+func (s *service) removeExport(c Container) error {
+	if s.exports == nil {
+		return nil
+	}
+
+	return s.exports.Store.Delete(exportKey(c))
+}
@@ -0,0 +1,138 @@
+// +build linux
+
+package container
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"golang.org/x/net/context"
+)
+
+// labels returns the labels operators set for node
+func (s *service) labels(node string) (map[string]string, error) {
+	ls := []NodeLabel{}
+	err := s.db.Find(&ls)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string)
+	for _, l := range ls {
+		if l.Node == node {
+			labels[l.Key] = l.Value
+		}
+	}
+	return labels, nil
+}
+
+// fits returns a kmi.PlacementError if the instance name of a user with the placement p may not run on node,
+// since the node lacks a label or runs another instance of the spread module
+func (s *service) fits(node string, refID uint, name string, module string, p kmi.Placement) error {
+	labels, err := s.labels(node)
+	if err != nil {
+		return err
+	}
+
+	if l := p.Missing(labels); l != "" {
+		return &kmi.PlacementError{
+			Instance: name,
+			Node:     node,
+			Reason:   fmt.Sprintf("the node lacks the label %s", l),
+		}
+	}
+
+	if !p.Spread {
+		return nil
+	}
+
+	cs := []Container{}
+	err = s.db.Find(&cs, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cs {
+		if c.RefID == refID && c.Node == node && c.Module == module && c.ReplicaOf == "" && c.ContainerName != name {
+			return &kmi.PlacementError{
+				Instance: name,
+				Node:     node,
+				Reason:   fmt.Sprintf("the node runs %s, another instance of %s, whose instances are spread across nodes", c.ContainerName, module),
+			}
+		}
+	}
+	return nil
+}
+
+// checkPlacement returns a kmi.PlacementError if an instance with the placement p may not be created on this node
+func (s *service) checkPlacement(refID uint, name string, module string, p kmi.Placement) error {
+	return s.fits(s.config.NodeName, refID, name, module, p)
+}
+
+func (s *service) SetPlacement(ctx context.Context, refID uint, id string, p kmi.Placement) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).setPlacement(refID, id, p)
+}
+
+func (s *service) setPlacement(refID uint, id string, p kmi.Placement) error {
+	err := p.Validate()
+	if err != nil {
+		return err
+	}
+
+	c := Container{}
+	err = s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return err
+	}
+
+	if c.ReplicaOf != "" {
+		return fmt.Errorf("replicas have the placement of their instance %s", c.ReplicaOf)
+	}
+
+	ckmi, err := s.getCKMI(id)
+	if err != nil {
+		return err
+	}
+
+	// the placement of the module can not be lifted by the user
+	placement, err := ckmi.KMI.Placement.Merge(p)
+	if err != nil {
+		return err
+	}
+
+	if placement.Spread && c.Replicas > 0 {
+		return errors.New("the replicas of an instance run on its node, scale it to 0 before spreading it")
+	}
+
+	// the instance has to satisfy its placement where it runs, otherwise it has to be moved first
+	err = s.fits(c.Node, refID, c.ContainerName, ckmi.KMI.Name, placement)
+	if err != nil {
+		return err
+	}
+
+	s.db.Begin()
+	err = s.db.Where("container_id = ?", id)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Update(&Container{}, "placement", placement)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	// instances created before their module was recorded get it now, so they are spread from their siblings
+	err = s.db.Update(&Container{}, "module", ckmi.KMI.Name)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+	return nil
+}
@@ -0,0 +1,117 @@
+// +build linux
+
+package container
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+)
+
+// firewallPolicy converts the firewall policy of a kmi, internal ports are not part of it since they are
+// never published and stay reachable through links only
+func firewallPolicy(p kmi.FirewallPolicy) firewall.Policy {
+	public := make([]firewall.PublicPort, len(p.Public))
+	for i, port := range p.Public {
+		public[i] = firewall.PublicPort{
+			Port:     port.Port,
+			Protocol: port.Protocol,
+		}
+	}
+
+	return firewall.Policy{
+		Public: public,
+		Egress: p.Egress,
+	}
+}
+
+// hasPolicy returns whether the firewall policy of a kmi needs any rules
+func hasPolicy(p kmi.FirewallPolicy) bool {
+	return len(p.Public) != 0 || (p.Egress != "" && p.Egress != firewall.EgressOpen)
+}
+
+// checkPublicPorts returns an error if a public port of p is published by another instance of this node already
+func (s *service) checkPublicPorts(p kmi.FirewallPolicy) error {
+	if len(p.Public) == 0 {
+		return nil
+	}
+
+	cs := []Container{}
+	err := s.db.Find(&cs)
+	if err != nil {
+		return err
+	}
+
+	published := make(map[kmi.PolicyPort]bool)
+	for _, c := range cs {
+		if c.ReplicaOf != "" || c.Node != s.config.NodeName {
+			continue
+		}
+
+		ckmi := CKMI{}
+		err = s.db.First(&ckmi, "id = ?", c.KMIID)
+		if err != nil {
+			return err
+		}
+		for _, port := range ckmi.Firewall.Public {
+			published[port] = true
+		}
+	}
+
+	for _, port := range p.Public {
+		if published[port] {
+			return fmt.Errorf("port %d/%s is published by another instance already", port.Port, port.Protocol)
+		}
+	}
+	return nil
+}
+
+// applyPolicy creates the firewall rules of the policy of the kmi of an instance
+func (s *service) applyPolicy(refID uint, id string, p kmi.FirewallPolicy) error {
+	if s.firewall == nil || !hasPolicy(p) {
+		return nil
+	}
+
+	ip := s.ip(refID, id)
+	if ip == "" {
+		return errors.New("instance has no ip address")
+	}
+
+	res, err := s.firewall.ApplyPolicyEndpoint(s.ctx, firewall.ApplyPolicyRequest{
+		IP:      abstraction.Inet(ip),
+		Network: BridgeNetwork,
+		Policy:  firewallPolicy(p),
+	})
+	if err != nil {
+		return err
+	}
+	return res.(firewall.ApplyPolicyResponse).Error
+}
+
+// removePolicy reverts applyPolicy, errors are only logged since the instance is removed anyway
+func (s *service) removePolicy(refID uint, id string, p kmi.FirewallPolicy) {
+	if s.firewall == nil || !hasPolicy(p) {
+		return
+	}
+
+	ip := s.ip(refID, id)
+	if ip == "" {
+		return
+	}
+
+	res, err := s.firewall.RemovePolicyEndpoint(s.ctx, firewall.RemovePolicyRequest{
+		IP:      abstraction.Inet(ip),
+		Network: BridgeNetwork,
+		Policy:  firewallPolicy(p),
+	})
+	if err == nil {
+		err = res.(firewall.RemovePolicyResponse).Error
+	}
+	if err != nil {
+		level.Error(s.logger).Log("instance", id, "err", err)
+	}
+}
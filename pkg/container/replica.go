@@ -0,0 +1,374 @@
+// +build linux
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"golang.org/x/net/context"
+)
+
+// BridgeNetwork is the network interface containers are attached to by netns
+const BridgeNetwork = "netns0"
+
+// ReplicaName returns the container name of the n-th replica of an instance
+func ReplicaName(name string, n int) string {
+	return fmt.Sprintf("%s-replica-%d", name, n)
+}
+
+func (s *service) ScaleInstance(ctx context.Context, refID uint, id string, replicas uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).scaleInstance(refID, id, replicas)
+}
+
+func (s *service) scaleInstance(refID uint, id string, replicas uint) error {
+	c := Container{}
+	err := s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return err
+	}
+
+	if c.ReplicaOf != "" {
+		return errors.New("a replica can not be scaled")
+	}
+
+	if c.Placement.Spread && replicas > 0 {
+		return &kmi.PlacementError{
+			Instance: c.ContainerName,
+			Node:     c.Node,
+			Reason:   fmt.Sprintf("its replicas would run on its node while the instances of %s are spread across nodes, create another instance on another node instead", c.Module),
+		}
+	}
+
+	s.db.Begin()
+	err = s.db.Where("container_id = ?", id)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	// replicas is updated as a column, a struct update would skip a count of 0
+	err = s.db.Update(&Container{}, "replicas", replicas)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+
+	c.Replicas = replicas
+	return s.reconcile(c)
+}
+
+func (s *service) replicasOf(id string) ([]Container, error) {
+	cs := []Container{}
+	err := s.db.Find(&cs, "replica_of = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// reconcile creates or removes replicas of an instance until their number matches c.Replicas.
+// Replicas whose container does not exist anymore, e.g. after a daemon restart, are recreated.
+func (s *service) reconcile(c Container) error {
+	replicas, err := s.replicasOf(c.ContainerID)
+	if err != nil {
+		return err
+	}
+
+	running := []Container{}
+	for _, r := range replicas {
+		_, err = s.libcnt.Load(r.ContainerID)
+		if err != nil {
+			s.unregisterReplica(c, r)
+			err = s.db.Delete(&Container{ContainerID: r.ContainerID})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		running = append(running, r)
+	}
+
+	for uint(len(running)) > c.Replicas {
+		r := running[len(running)-1]
+		s.unregisterReplica(c, r)
+
+		err = s.removeContainer(r.RefID, r.ContainerID)
+		if err != nil {
+			return err
+		}
+		running = running[:len(running)-1]
+	}
+
+	if uint(len(running)) == c.Replicas {
+		return nil
+	}
+
+	ckmi, err := s.getCKMI(c.ContainerID)
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	for _, r := range running {
+		used[r.ContainerName] = true
+	}
+
+	for n := 1; uint(len(running)) < c.Replicas; n++ {
+		name := ReplicaName(c.ContainerName, n)
+		if used[name] {
+			continue
+		}
+
+		if _, err := s.idForName(c.RefID, name); err == nil {
+			continue
+		}
+
+		id, err := s.createInstance(c.RefID, ckmi.KMI, ckmi.Links, name, c.ContainerID, c.Image, c.Placement)
+		if err != nil {
+			return err
+		}
+
+		r := Container{
+			RefID:         c.RefID,
+			ContainerID:   id,
+			ContainerName: name,
+			ReplicaOf:     c.ContainerID,
+		}
+		err = s.registerReplica(c, r)
+		if err != nil {
+			level.Error(s.logger).Log("replica", name, "err", err)
+		}
+		running = append(running, r)
+	}
+
+	return nil
+}
+
+// reconcileReplicas brings the replicas of all instances to their desired count
+func (s *service) reconcileReplicas() {
+	cs := []Container{}
+	err := s.db.Find(&cs, "replicas > 0")
+	if err != nil {
+		level.Error(s.logger).Log("err", err)
+		return
+	}
+
+	for _, c := range cs {
+		err = s.reconcile(c)
+		if err != nil {
+			level.Error(s.logger).Log("instance", c.ContainerID, "err", err)
+		}
+	}
+}
+
+// interfaces returns the ports of the interfaces of a KMI by their name
+func (s *service) interfaces(ckmi CKMI) map[string]uint16 {
+	ports := make(map[string]uint16)
+	for k, v := range ckmi.Interfaces.ToStringMap() {
+		p, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			continue
+		}
+		ports[k] = uint16(p)
+	}
+	return ports
+}
+
+func (s *service) ip(refID uint, containerID string) string {
+	ip := s.getContainerIP(refID, containerID)
+	if i := strings.Index(ip, "/"); i != -1 {
+		ip = ip[:i]
+	}
+	return strings.TrimSpace(ip)
+}
+
+// registerReplica adds a replica as member to the upstreams of the instance's routing
+// configuration, one upstream per interface, and allows it to reach the instance's links.
+func (s *service) registerReplica(c Container, r Container) error {
+	ip := s.ip(r.RefID, r.ContainerID)
+	if ip == "" {
+		return errors.New("replica has no ip address")
+	}
+
+	ckmi, err := s.getCKMI(c.ContainerID)
+	if err != nil {
+		return err
+	}
+
+	if s.routing != nil {
+		res, err := s.routing.GetConfigEndpoint(s.ctx, routing.GetConfigRequest{
+			IDRequest: routing.IDRequest{RefID: c.RefID, Name: c.ContainerName},
+		})
+		if err == nil && res.(routing.GetConfigResponse).Error == nil {
+			conf := res.(routing.GetConfigResponse).Config
+			primaryIP := s.ip(c.RefID, c.ContainerID)
+			for iface, port := range s.interfaces(ckmi) {
+				primary := ""
+				if primaryIP != "" {
+					primary = fmt.Sprintf("%s:%d", primaryIP, port)
+				}
+
+				err = s.addUpstreamMember(conf, iface, primary, fmt.Sprintf("%s:%d", ip, port))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if s.firewall != nil {
+		return s.linkFirewall(ckmi, c.RefID, abstraction.Inet(ip), true)
+	}
+
+	return nil
+}
+
+// addUpstreamMember adds address to an upstream of conf. A missing upstream is created
+// with the primary instance's address as its first member.
+func (s *service) addUpstreamMember(conf routing.RouterConfig, upstream string, primary string, address string) error {
+	if conf.Upstreams.Get(upstream) == -1 {
+		members := []*routing.UpstreamMember{}
+		if primary != "" {
+			members = append(members, &routing.UpstreamMember{
+				Address: primary,
+			})
+		}
+
+		res, err := s.routing.SetUpstreamEndpoint(s.ctx, routing.SetUpstreamRequest{
+			IDRequest: routing.IDRequest{RefID: conf.RefID, Name: conf.Name},
+			Upstream: &routing.Upstream{
+				Name:    upstream,
+				Members: members,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if res.(routing.SetUpstreamResponse).Error != nil {
+			return res.(routing.SetUpstreamResponse).Error
+		}
+	}
+
+	res, err := s.routing.AddUpstreamMemberEndpoint(s.ctx, routing.AddUpstreamMemberRequest{
+		IDRequest: routing.IDRequest{RefID: conf.RefID, Name: conf.Name},
+		Upstream:  upstream,
+		Member: &routing.UpstreamMember{
+			Address: address,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return res.(routing.AddUpstreamMemberResponse).Error
+}
+
+// unregisterReplica reverts registerReplica, errors are only logged since the replica is removed anyway
+func (s *service) unregisterReplica(c Container, r Container) {
+	ip := s.ip(r.RefID, r.ContainerID)
+	if ip == "" {
+		return
+	}
+
+	ckmi, err := s.getCKMI(c.ContainerID)
+	if err != nil {
+		level.Error(s.logger).Log("replica", r.ContainerName, "err", err)
+		return
+	}
+
+	if s.routing != nil {
+		for iface, port := range s.interfaces(ckmi) {
+			res, err := s.routing.RemoveUpstreamMemberEndpoint(s.ctx, routing.RemoveUpstreamMemberRequest{
+				IDRequest: routing.IDRequest{RefID: c.RefID, Name: c.ContainerName},
+				Upstream:  iface,
+				Address:   fmt.Sprintf("%s:%d", ip, port),
+			})
+			if err == nil {
+				err = res.(routing.RemoveUpstreamMemberResponse).Error
+			}
+			if err != nil {
+				level.Error(s.logger).Log("replica", r.ContainerName, "err", err)
+			}
+		}
+	}
+
+	if s.firewall != nil {
+		err = s.linkFirewall(ckmi, c.RefID, abstraction.Inet(ip), false)
+		if err != nil {
+			level.Error(s.logger).Log("replica", r.ContainerName, "err", err)
+		}
+	}
+}
+
+// linkFirewall allows or blocks the traffic from ip to every linked interface of ckmi
+func (s *service) linkFirewall(ckmi CKMI, refID uint, ip abstraction.Inet, allow bool) error {
+	for link, ifaces := range ckmi.Links.ToStringArrayMap() {
+		linkID, err := s.idForName(refID, link)
+		if err != nil {
+			continue
+		}
+
+		linkIP := s.ip(refID, linkID)
+		if linkIP == "" {
+			continue
+		}
+
+		linkCKMI, err := s.getCKMI(linkID)
+		if err != nil {
+			continue
+		}
+		ports := s.interfaces(linkCKMI)
+
+		for _, iface := range ifaces {
+			port, ok := ports[iface]
+			if !ok {
+				continue
+			}
+
+			if allow {
+				res, err := s.firewall.AllowPortEndpoint(s.ctx, firewall.AllowPortRequest{
+					SrcIP:      ip,
+					SrcNetwork: BridgeNetwork,
+					DstIP:      abstraction.Inet(linkIP),
+					DstNetwork: BridgeNetwork,
+					Port:       port,
+					Protocol:   "tcp",
+				})
+				if err == nil {
+					err = res.(firewall.AllowPortResponse).Error
+				}
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			res, err := s.firewall.BlockPortEndpoint(s.ctx, firewall.BlockPortRequest{
+				SrcIP:      ip,
+				SrcNetwork: BridgeNetwork,
+				DstIP:      abstraction.Inet(linkIP),
+				DstNetwork: BridgeNetwork,
+				Port:       port,
+				Protocol:   "tcp",
+			})
+			if err == nil {
+				err = res.(firewall.BlockPortResponse).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// +build linux
+
+package container
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/go-kit/kit/log/level"
+	"golang.org/x/net/context"
+)
+
+func (s *service) PinInstance(ctx context.Context, refID uint, id string, pinned bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).pinInstance(refID, id, pinned)
+}
+
+func (s *service) pinInstance(refID uint, id string, pinned bool) error {
+	c := Container{}
+	err := s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return err
+	}
+
+	if c.ReplicaOf != "" {
+		return fmt.Errorf("replicas are pinned with their instance %s", c.ReplicaOf)
+	}
+
+	s.db.Begin()
+	err = s.db.Where("container_id = ?", id)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	// pinned is updated as a column, a struct update would skip false
+	err = s.db.Update(&Container{}, "pinned", pinned)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+	return nil
+}
+
+// forget removes the database entries of an instance and its replicas which were lost with their failed node
+// before the instance is placed on this node, they are recorded as LostContainer so the failed node removes their
+// containers once it is back. ContainerRemoved is not published since the instance is placed again under its
+// name, so the state other services keep for it stays.
+func (s *service) forget(id string) error {
+	c := Container{}
+	err := s.db.First(&c, "container_id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	replicas, err := s.replicasOf(id)
+	if err != nil {
+		return err
+	}
+
+	for _, l := range append(replicas, c) {
+		err = s.db.Create(&LostContainer{
+			RefID:       l.RefID,
+			ContainerID: l.ContainerID,
+			Node:        l.Node,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = s.db.Delete(&Container{ContainerID: l.ContainerID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeLost removes the containers of this node which were placed on other nodes while it failed,
+// they would serve outdated instances next to their replacements otherwise
+func (s *service) removeLost() {
+	lost := []LostContainer{}
+	err := s.db.Find(&lost, "node = ?", s.config.NodeName)
+	if err != nil {
+		level.Error(s.logger).Log("err", err)
+		return
+	}
+
+	for _, l := range lost {
+		container, err := s.libcnt.Load(l.ContainerID)
+		if err == nil {
+			container.Signal(os.Kill, true)
+			err = container.Destroy()
+			if err != nil {
+				level.Error(s.logger).Log("lost", l.ContainerID, "err", err)
+				continue
+			}
+		}
+
+		err = os.RemoveAll(path.Join(s.config.CustomerPath, fmt.Sprintf("%d", l.RefID), l.ContainerID))
+		if err == nil {
+			err = s.removeCheckpoints(l.RefID, l.ContainerID)
+		}
+		if err == nil {
+			err = s.db.Delete(&LostContainer{ContainerID: l.ContainerID})
+		}
+		if err != nil {
+			level.Error(s.logger).Log("lost", l.ContainerID, "err", err)
+			continue
+		}
+		level.Info(s.logger).Log("lost", l.ContainerID, "msg", "removed")
+	}
+}
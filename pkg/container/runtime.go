@@ -0,0 +1,117 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+)
+
+// The optional features of a container runtime, modules requiring one are placed on nodes supporting it
+// through the node label kmi.RuntimeLabelPrefix + feature, like kontainer.ooo/gpu=true
+const (
+	// FeatureCheckpoint is supported if checkpoints are enabled and CRIU works on a node
+	FeatureCheckpoint = "checkpoint"
+	// FeatureGPU is supported if a node has NVIDIA GPUs with their driver loaded
+	FeatureGPU = "gpu"
+)
+
+// Runtime describes the container runtime of a node and the features it supports
+type Runtime struct {
+	// Runtime is the runtime containers are run with and RuntimeVersion its version, which is empty
+	// if the binary was built without module information
+	Runtime        string
+	RuntimeVersion string
+	// Kernel is the release of the kernel of the node
+	Kernel string
+	Cgroup CgroupMode
+	// Storage is the filesystem the containers of the node are stored on
+	Storage string
+	// CRIU is the version of CRIU if checkpoints are supported
+	CRIU string
+	// GPU is the version of the NVIDIA driver if the node has GPUs
+	GPU string
+}
+
+// Features returns the optional features the runtime supports
+func (r Runtime) Features() []string {
+	f := []string{}
+	if r.CRIU != "" {
+		f = append(f, FeatureCheckpoint)
+	}
+	if r.GPU != "" {
+		f = append(f, FeatureGPU)
+	}
+	return f
+}
+
+// Labels returns the node labels describing the runtime, the features it supports are set to true
+func (r Runtime) Labels() map[string]string {
+	l := map[string]string{
+		"runtime":         r.Runtime,
+		"runtime-version": r.RuntimeVersion,
+		"kernel":          r.Kernel,
+		"cgroup":          string(r.Cgroup),
+		"storage":         r.Storage,
+		"criu":            r.CRIU,
+		"gpu-driver":      r.GPU,
+	}
+	for _, f := range r.Features() {
+		l[f] = "true"
+	}
+
+	labels := make(map[string]string)
+	for k, v := range l {
+		// values which can not be labels, like kernel releases of custom builds, are not reported
+		if v == "" || kmi.ValidateLabel(kmi.RuntimeLabelPrefix+k, v) != nil {
+			continue
+		}
+		labels[kmi.RuntimeLabelPrefix+k] = v
+	}
+	return labels
+}
+
+// filesystems names the filesystems containers are commonly stored on by their magic number
+var filesystems = map[int64]string{
+	0xef53:     "ext4",
+	0x58465342: "xfs",
+	0x9123683e: "btrfs",
+	0x794c7630: "overlay",
+	0x2fc12fc1: "zfs",
+	0x01021994: "tmpfs",
+	0x6969:     "nfs",
+}
+
+// filesystem returns the name of the filesystem with the magic number magic
+func filesystem(magic int64) string {
+	if name, ok := filesystems[magic]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", magic)
+}
+
+// parseCRIUVersion returns the version criu --version prints, i.e. 3.17.1 of "Version: 3.17.1"
+func parseCRIUVersion(out string) string {
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "Version:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "Version:"))
+		}
+	}
+	return ""
+}
+
+// parseNVIDIADriver returns the driver version of the content of /proc/driver/nvidia/version, i.e. 535.54.03 of
+// "NVRM version: NVIDIA UNIX x86_64 Kernel Module  535.54.03  Tue Jun  6 22:20:39 UTC 2023"
+func parseNVIDIADriver(version string) string {
+	line := strings.SplitN(version, "\n", 2)[0]
+	i := strings.Index(line, "Kernel Module")
+	if i < 0 {
+		return ""
+	}
+
+	fields := strings.Fields(line[i+len("Kernel Module"):])
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
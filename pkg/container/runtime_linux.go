@@ -0,0 +1,93 @@
+// +build linux
+
+package container
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"runtime/debug"
+	"strings"
+	"syscall"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"golang.org/x/net/context"
+)
+
+// runcModule is the module of libcontainer, which runs the containers of every node
+const runcModule = "github.com/opencontainers/runc"
+
+// DetectRuntime returns the runtime of this node, whose containers are limited in the hierarchy cgroup and stored in dir.
+// criu is the path of the CRIU binary if checkpoints work on this node, empty otherwise.
+func DetectRuntime(cgroup CgroupMode, criu string, dir string) Runtime {
+	r := Runtime{
+		Runtime: "runc",
+		Cgroup:  cgroup,
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, dep := range info.Deps {
+			if dep.Path == runcModule {
+				r.RuntimeVersion = dep.Version
+			}
+		}
+	}
+
+	if release, err := ioutil.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		r.Kernel = strings.TrimSpace(string(release))
+	}
+
+	fs := syscall.Statfs_t{}
+	if err := syscall.Statfs(dir, &fs); err == nil {
+		r.Storage = filesystem(int64(fs.Type))
+	}
+
+	if criu != "" {
+		if out, err := exec.Command(criu, "--version").Output(); err == nil {
+			r.CRIU = parseCRIUVersion(string(out))
+		}
+	}
+
+	if version, err := ioutil.ReadFile("/proc/driver/nvidia/version"); err == nil {
+		r.GPU = parseNVIDIADriver(string(version))
+	}
+
+	return r
+}
+
+func (s *service) Runtime(ctx context.Context) Runtime {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.runtime
+}
+
+// labelRuntime replaces the runtime labels of this node with those of its current runtime
+func (s *service) labelRuntime() error {
+	current, err := s.labels(s.config.NodeName)
+	if err != nil {
+		return err
+	}
+
+	s.db.Begin()
+	for k := range current {
+		if !strings.HasPrefix(k, kmi.RuntimeLabelPrefix) {
+			continue
+		}
+
+		err = s.db.Delete(&NodeLabel{Node: s.config.NodeName, Key: k})
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+	}
+
+	for k, v := range s.runtime.Labels() {
+		err = s.db.Create(&NodeLabel{Node: s.config.NodeName, Key: k, Value: v})
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+	}
+	s.db.Commit()
+	return nil
+}
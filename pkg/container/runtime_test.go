@@ -0,0 +1,46 @@
+// +build integration,linux
+
+package container_test
+
+import (
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Runtime", func() {
+	Describe("Labels", func() {
+		It("Should label the runtime and its features", func() {
+			r := container.Runtime{
+				Runtime: "runc",
+				Kernel:  "6.1.0-13-amd64",
+				Cgroup:  container.CgroupV2,
+				Storage: "ext4",
+				CRIU:    "3.17.1",
+			}
+			Ω(r.Features()).Should(Equal([]string{container.FeatureCheckpoint}))
+			Ω(r.Labels()).Should(Equal(map[string]string{
+				"kontainer.ooo/runtime":    "runc",
+				"kontainer.ooo/kernel":     "6.1.0-13-amd64",
+				"kontainer.ooo/cgroup":     "v2",
+				"kontainer.ooo/storage":    "ext4",
+				"kontainer.ooo/criu":       "3.17.1",
+				"kontainer.ooo/checkpoint": "true",
+			}))
+		})
+
+		It("Should leave out values which can not be labels", func() {
+			r := container.Runtime{
+				Runtime: "runc",
+				Kernel:  "6.1.0+custom",
+				GPU:     "535.54.03",
+			}
+			Ω(r.Features()).Should(Equal([]string{container.FeatureGPU}))
+			Ω(r.Labels()).Should(Equal(map[string]string{
+				"kontainer.ooo/runtime":    "runc",
+				"kontainer.ooo/gpu-driver": "535.54.03",
+				"kontainer.ooo/gpu":        "true",
+			}))
+		})
+	})
+})
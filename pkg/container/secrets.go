@@ -0,0 +1,127 @@
+package container
+
+import (
+	"fmt"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+// sealEnvironment returns env with the values of the variables flagged as secrets by k kept by secrets for the user
+// refID, values which are sealed already are kept
+func sealEnvironment(secrets secret.Backend, refID uint, k kmi.KMI, env abstraction.JSON) (abstraction.JSON, error) {
+	if env == nil {
+		return env, nil
+	}
+
+	values := env.ToStringMap()
+	sealed := make(abstraction.JSON)
+	for name, v := range env {
+		sealed[name] = v
+		value, ok := values[name]
+		if !k.IsSecret(name) || !ok || secret.IsSealed(value) {
+			continue
+		}
+
+		encrypted, err := secrets.Put(refID, "environment", value)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %v", name, err)
+		}
+		sealed[name] = encrypted
+	}
+	return sealed, nil
+}
+
+// openEnvironment returns env with the values of the secrets of the user refID, every sealed value is opened
+// so secrets sealed before a module stopped flagging them are not handed to the instance sealed
+func openEnvironment(secrets secret.Backend, refID uint, env abstraction.JSON) (abstraction.JSON, error) {
+	if env == nil {
+		return env, nil
+	}
+
+	opened := make(abstraction.JSON)
+	for k, v := range env {
+		opened[k] = v
+		value, ok := v.(string)
+		if !ok || !secret.IsSealed(value) {
+			continue
+		}
+
+		plaintext, err := secrets.Get(refID, value)
+		if err != nil {
+			return nil, fmt.Errorf("variable %s: %v", k, err)
+		}
+		opened[k] = plaintext
+	}
+	return opened, nil
+}
+
+// RotateSecrets migrates the secrets in the environments of all instances to the current master key or backend of
+// secrets, including those flagged by their module but kept in plaintext before. It returns the number of
+// environments which changed.
+func RotateSecrets(db abstraction.DB, secrets secret.Backend) (int, error) {
+	cs := []Container{}
+	err := db.Find(&cs)
+	if err != nil {
+		return 0, err
+	}
+
+	owners := make(map[uint]uint)
+	for _, c := range cs {
+		owners[c.KMIID] = c.RefID
+	}
+
+	ckmis := []CKMI{}
+	err = db.Find(&ckmis)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, c := range ckmis {
+		refID, ok := owners[c.ID]
+		if !ok {
+			continue
+		}
+
+		values := c.Environment.ToStringMap()
+		env := make(abstraction.JSON)
+		changed := false
+		for k, v := range c.Environment {
+			env[k] = v
+			value, ok := values[k]
+			if !ok || !(c.IsSecret(k) || secret.IsSealed(value)) {
+				continue
+			}
+
+			encrypted, again, err := secrets.Migrate(refID, "environment", value)
+			if err != nil {
+				return rotated, fmt.Errorf("instance kmi %d, variable %s: %v", c.ID, k, err)
+			}
+			if again {
+				env[k] = encrypted
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		db.Begin()
+		err = db.Where("id = ?", c.ID)
+		if err != nil {
+			db.Rollback()
+			return rotated, err
+		}
+
+		err = db.Update(&CKMI{}, &CKMI{KMI: kmi.KMI{Environment: env}})
+		if err != nil {
+			db.Rollback()
+			return rotated, err
+		}
+		db.Commit()
+		rotated++
+	}
+	return rotated, nil
+}
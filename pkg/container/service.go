@@ -18,8 +18,13 @@ import (
 	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
 	"github.com/kontainerooo/kontainer.ooo/pkg/util"
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -29,41 +34,106 @@ import (
 // Service Container Service
 type Service interface {
 	// CreateContainer instanciates a container for a User with the id refID and returns its id
-	CreateContainer(refID uint, kmiID uint, name string) (id string, err error)
+	CreateContainer(ctx context.Context, refID uint, kmiID uint, name string) (id string, err error)
 
 	// RemoveContainer is used to remove a container instance by id
-	RemoveContainer(refID uint, id string) error
+	RemoveContainer(ctx context.Context, refID uint, id string) error
 
 	// Instances returns a list of container instances of a user by id
-	Instances(refID uint) []Container
+	Instances(ctx context.Context, refID uint) []Container
 
 	// StopContainer stops a container
-	StopContainer(refID uint, id string) error
+	StopContainer(ctx context.Context, refID uint, id string) error
 
 	// Execute executes a command in a given container
-	Execute(refID uint, id string, cmd string, env map[string]string) (string, error)
+	Execute(ctx context.Context, refID uint, id string, cmd string, env map[string]string) (string, error)
+
+	// Attach runs cmd in a given container with its standard streams connected to stdin, stdout and stderr
+	// and returns the exit code of cmd, other calls are not blocked while cmd runs
+	Attach(ctx context.Context, refID uint, id string, cmd []string, env map[string]string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
 
 	// GetEnv returns the value to a given environment variable setting. Returns the whole
 	// environment as string if key is empty
-	GetEnv(refID uint, id string, key string) (string, error)
+	GetEnv(ctx context.Context, refID uint, id string, key string) (string, error)
 
 	// SetEnv sets an environment variable for the container
-	SetEnv(refID uint, id string, key string, value string) error
+	SetEnv(ctx context.Context, refID uint, id string, key string, value string) error
+
+	// Environment returns the environment of an instance
+	Environment(ctx context.Context, refID uint, id string) (map[string]string, error)
+
+	// SetEnvironment replaces the environment of an instance
+	SetEnvironment(ctx context.Context, refID uint, id string, env map[string]string) error
+
+	// ImagesInUse returns the rootfs archives the containers are created from
+	ImagesInUse(ctx context.Context) ([]string, error)
 
 	// IDForName returs the containerID for a given container name and user
-	IDForName(refID uint, name string) (string, error)
+	IDForName(ctx context.Context, refID uint, name string) (string, error)
 
 	// GetContainerKMI returns the KMI for a given container
-	GetContainerKMI(containerID string) (kmi.KMI, error)
+	GetContainerKMI(ctx context.Context, containerID string) (kmi.KMI, error)
 
 	// SetLink links a container's interface into a container
-	SetLink(refID uint, containerID string, linkID string, linkName string, linkInterface string) error
+	SetLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error
 
 	// RemoveLink links a container's interface into a container
-	RemoveLink(refID uint, containerID string, linkID string, linkName string, linkInterface string) error
+	RemoveLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error
 
 	// GetLinks returns all links a container has
-	GetLinks(refID uint, containerID string) (map[string][]string, error)
+	GetLinks(ctx context.Context, refID uint, containerID string) (map[string][]string, error)
+
+	// ScaleInstance creates or removes replicas of a container instance until it has the given number of
+	// replicas. Replicas are registered with the routing and firewall services.
+	ScaleInstance(ctx context.Context, refID uint, id string, replicas uint) error
+
+	// CloneInstance creates a staging copy of an instance named name from its kmi, links, environment and a
+	// snapshot of its volume and serves it at its StagingDomain. The values of secrets are not copied, a
+	// SecretsError names the secrets which need a value in env.
+	CloneInstance(ctx context.Context, refID uint, id string, name string, env map[string]string) (string, error)
+
+	// UpdateInstance replaces the containers of an instance and its replicas with containers created from
+	// the gzip compressed rootfs archive image without downtime. The instance keeps its ID and address.
+	UpdateInstance(ctx context.Context, refID uint, id string, image string) error
+
+	// PinInstance pins an instance to its node or unpins it, pinned instances are not placed on another
+	// node once their node failed
+	PinInstance(ctx context.Context, refID uint, id string, pinned bool) error
+
+	// ExportInstance writes an archive of the volume of an instance to the artifact store, replacing its
+	// previous export, and returns the signed URL it is downloaded from and when the URL expires
+	ExportInstance(ctx context.Context, refID uint, id string) (string, time.Time, error)
+
+	// SetPlacement constrains the nodes an instance is placed on in addition to the placement of its module.
+	// It returns a kmi.PlacementError if the node of the instance does not satisfy it.
+	SetPlacement(ctx context.Context, refID uint, id string, p kmi.Placement) error
+
+	// CheckpointInstance saves the processes of the container of an instance as the checkpoint name with CRIU,
+	// replacing an older checkpoint of that name. The container keeps running with leaveRunning and is stopped
+	// otherwise. It fails with ErrCheckpointUnsupported unless checkpoints are enabled and CRIU works on this node.
+	CheckpointInstance(ctx context.Context, refID uint, id string, name string, leaveRunning bool) (Checkpoint, error)
+
+	// RestoreInstance restores the stopped container of an instance from its checkpoint name
+	RestoreInstance(ctx context.Context, refID uint, id string, name string) error
+
+	// Checkpoints returns the checkpoints of an instance, the latest first
+	Checkpoints(ctx context.Context, refID uint, id string) ([]Checkpoint, error)
+
+	// RemoveCheckpoint removes a checkpoint of an instance
+	RemoveCheckpoint(ctx context.Context, refID uint, id string, name string) error
+
+	// CheckpointRunning saves the running containers of this node as RestartCheckpoint and stops them if they
+	// should be restored once the daemon started again, it is called when the daemon shuts down
+	CheckpointRunning(ctx context.Context) error
+
+	// CountRunning returns the number of containers which are running
+	CountRunning(ctx context.Context) (uint, error)
+
+	// Runtime returns the container runtime of this node and the features it supports
+	Runtime(ctx context.Context) Runtime
+
+	// Usage returns the resource usage of the containers running on this node
+	Usage(ctx context.Context) ([]Usage, error)
 }
 
 type dbAdapter interface {
@@ -71,6 +141,7 @@ type dbAdapter interface {
 	AutoMigrate(...interface{}) error
 	Where(interface{}, ...interface{}) error
 	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
 	First(interface{}, ...interface{}) error
 	Create(interface{}) error
 	Delete(interface{}, ...interface{}) error
@@ -81,9 +152,33 @@ type service struct {
 	db        dbAdapter
 	libcnt    libcontainer.Factory
 	kmiClient *kmi.Endpoints
-	logger    log.Logger
-	config    util.ConfigFile
-	mtx       *sync.Mutex
+	routing   *routing.Endpoints
+	firewall  *firewall.Endpoints
+	events    events.Bus
+	// secrets keeps the values of the secrets in the environments of the instances
+	secrets secret.Backend
+	// exports keeps the archives of exported instances, instances can not be exported if it is nil
+	exports *Exports
+	logger  log.Logger
+	config  util.ConfigFile
+	mtx     *sync.Mutex
+	// ctx is the context of the request a bound copy of the service handles, the calls to the
+	// database and the other services are made with it
+	ctx context.Context
+	// drained is set while operators move the instances off this node, cordoned while the instances
+	// stay on it but new ones are not placed on it
+	drained  bool
+	cordoned bool
+	// checkpointing is set if checkpoints are enabled and CRIU works on this node
+	checkpointing bool
+	// runtime describes the container runtime of this node, it is reported as the runtime labels of the node
+	runtime Runtime
+	// cgroup is the cgroup hierarchy of this node and limits the resources of every container
+	cgroup CgroupMode
+	limits Limits
+	// host is the capacity of this node, the limits of its containers add up to at most the part policy allows
+	host   Capacity
+	policy CapacityPolicy
 }
 
 const (
@@ -99,8 +194,17 @@ func (s *service) InitializeDatabases() error {
 	return s.initializeDatabases()
 }
 
+// bind returns a copy of s handling the request of ctx, its database calls fail and its calls to the
+// other services are cancelled once ctx is done. It is called while the service is locked.
+func (s *service) bind(ctx context.Context) *service {
+	b := *s
+	b.ctx = ctx
+	b.db = s.db.WithContext(ctx)
+	return &b
+}
+
 func (s *service) initializeDatabases() error {
-	return s.db.AutoMigrate(&CKMI{}, &Container{})
+	return s.db.AutoMigrate(&CKMI{}, &Container{}, &Checkpoint{}, &LostContainer{}, &NodeLabel{})
 }
 
 func (s *service) checkAndCreate(path string) error {
@@ -151,26 +255,127 @@ func (s *service) initPaths() error {
 	return nil
 }
 
-func (s *service) CreateContainer(refID uint, kmiID uint, name string) (id string, err error) {
+func (s *service) CreateContainer(ctx context.Context, refID uint, kmiID uint, name string) (id string, err error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.createContainer(refID, kmiID, name)
+	return s.bind(ctx).createContainer(refID, kmiID, name)
 }
 
 func (s *service) createContainer(refID uint, kmiID uint, name string) (id string, err error) {
+	err = s.schedulable()
+	if err != nil {
+		return "", err
+	}
+
 	kmi, err := s.getTemplateKMI(kmiID)
 	if err != nil {
 		return "", err
 	}
 
-	// Compute the container id - consisting of userID + imagename + timestamp
+	err = s.checkPublicPorts(kmi.Firewall)
+	if err != nil {
+		return "", err
+	}
+
+	id, err = s.createInstance(refID, kmi, abstraction.NewJSONFromMap(make(map[string]string)), name, "", "", kmi.Placement)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.applyPolicy(refID, id, kmi.Firewall)
+	if err != nil {
+		s.removeContainer(refID, id)
+		return "", err
+	}
+
+	s.publish(events.ContainerCreated, events.ContainerEvent{
+		RefID:       refID,
+		ContainerID: id,
+		Name:        name,
+	})
+	return id, nil
+}
+
+// createInstance creates a container from kmi, or from the rootfs archive image if it is not empty
+func (s *service) createInstance(refID uint, kmi kmi.KMI, links abstraction.JSON, name string, replicaOf string, image string, p kmi.Placement) (id string, err error) {
+	// replicas run on the node of their instance, which satisfies the placement already
+	if replicaOf == "" {
+		err = s.checkPlacement(refID, name, kmi.Name, p)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = s.checkCapacity()
+	if err != nil {
+		return "", err
+	}
+
+	// instances moved from other nodes and replicas share the sealed secrets of their original,
+	// the container is provisioned with their values
+	env, err := sealEnvironment(s.secrets, refID, kmi, kmi.Environment)
+	if err != nil {
+		return "", err
+	}
+	kmi.Environment, err = openEnvironment(s.secrets, refID, env)
+	if err != nil {
+		return "", err
+	}
+
+	// Compute the container id - consisting of userID + imagename + name + timestamp,
+	// the name keeps replicas created within the same second apart
 	h := md5.New()
-	io.WriteString(h, fmt.Sprintf("%d%d%s", refID, kmi.ID, time.Now().Format("20060102150405")))
+	io.WriteString(h, fmt.Sprintf("%d%d%s%s", refID, kmi.ID, name, time.Now().Format("20060102150405")))
 	containerID := fmt.Sprintf("%x", h.Sum(nil))
 
-	s.initRootfs(refID, kmi.ProvisionScript, containerID, kmi)
+	s.initRootfs(refID, kmi.ProvisionScript, containerID, kmi, image)
 
+	err = s.start(refID, containerID, name)
+	if err != nil {
+		return "", err
+	}
+
+	c := Container{
+		RefID:         refID,
+		ContainerName: name,
+		ContainerID:   containerID,
+		ReplicaOf:     replicaOf,
+		Image:         image,
+		Node:          s.config.NodeName,
+		Module:        kmi.Name,
+		Placement:     p,
+	}
+
+	ckmi := CKMI{
+		KMI:   kmi,
+		Links: links,
+	}
+	ckmi.ID = 0
+	ckmi.Environment = env
+
+	s.db.Begin()
+
+	err = s.db.Create(&ckmi)
+	if err != nil {
+		s.db.Rollback()
+		return "", err
+	}
+
+	c.KMIID = ckmi.ID
+
+	err = s.db.Create(&c)
+	if err != nil {
+		s.db.Rollback()
+		return "", err
+	}
+
+	s.db.Commit()
+	return containerID, nil
+}
+
+// start creates the libcontainer container of an instance whose rootfs is initialized and runs it
+func (s *service) start(refID uint, containerID string, name string) error {
 	netnsCmd := configs.NewCommandHook(configs.Command{
 		Path: s.config.NetNSPath,
 		Args: []string{"netns", "-ipfile", path.Join(s.config.CustomerPath, fmt.Sprintf("%d", refID), containerID, ".ip")},
@@ -233,13 +438,9 @@ func (s *service) createContainer(refID uint, kmiID uint, name string) (id strin
 		},
 		Routes: nil,
 		Cgroups: &configs.Cgroup{
-			Name:   containerID,
-			Parent: "system",
-			Resources: &configs.Resources{
-				MemorySwappiness: nil,
-				AllowAllDevices:  nil,
-				AllowedDevices:   configs.DefaultAllowedDevices,
-			},
+			Name:      containerID,
+			Parent:    "system",
+			Resources: s.resources(),
 		},
 		Rootless: false,
 		Hostname: name,
@@ -250,63 +451,83 @@ func (s *service) createContainer(refID uint, kmiID uint, name string) (id strin
 		},
 	})
 	if err != nil {
-		return "", err
-	}
-
-	p := &libcontainer.Process{
-		Args: []string{"/bin/echo", "Done!"},
-	}
-
-	if err = cu.Run(p); err != nil {
-		return "", err
+		return err
 	}
 
-	_, err = p.Wait()
+	// the output of the instance is collected from its log files
+	instancePath := path.Join(s.config.CustomerPath, fmt.Sprintf("%d", refID), containerID)
+	stdout, err := os.OpenFile(path.Join(instancePath, StdoutLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
 	if err != nil {
-		return "", err
+		return err
 	}
+	defer stdout.Close()
 
-	c := Container{
-		RefID:         refID,
-		ContainerName: name,
-		ContainerID:   containerID,
+	stderr, err := os.OpenFile(path.Join(instancePath, StderrLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
 	}
+	defer stderr.Close()
 
-	ckmi := CKMI{
-		KMI:   kmi,
-		Links: abstraction.NewJSONFromMap(make(map[string]string)),
+	p := &libcontainer.Process{
+		Args:   []string{"/bin/echo", "Done!"},
+		Stdout: stdout,
+		Stderr: stderr,
 	}
-	ckmi.ID = 0
-
-	s.db.Begin()
 
-	err = s.db.Create(&ckmi)
-	if err != nil {
-		s.db.Rollback()
-		return "", err
+	if err = cu.Run(p); err != nil {
+		return err
 	}
 
-	c.KMIID = ckmi.ID
-
-	err = s.db.Create(&c)
+	err = s.applyUnified(cu, "system")
 	if err != nil {
-		s.db.Rollback()
-		return "", err
+		cu.Signal(os.Kill, true)
+		return err
 	}
 
-	s.db.Commit()
-	return containerID, nil
+	_, err = p.Wait()
+	return err
 }
 
-func (s *service) RemoveContainer(refID uint, id string) error {
+func (s *service) RemoveContainer(ctx context.Context, refID uint, id string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.removeContainer(refID, id)
+	return s.bind(ctx).removeContainer(refID, id)
 }
 
 func (s *service) removeContainer(refID uint, id string) error {
-	err := s.stopContainer(refID, id)
+	replicas, err := s.replicasOf(id)
+	if err != nil {
+		return err
+	}
+
+	if len(replicas) != 0 {
+		c := Container{}
+		err = s.db.First(&c, "container_id = ?", id)
+		if err != nil {
+			return err
+		}
+
+		for _, r := range replicas {
+			s.unregisterReplica(c, r)
+			err = s.removeContainer(r.RefID, r.ContainerID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	instance := Container{}
+	found := s.db.First(&instance, "container_id = ?", id) == nil
+
+	if found && instance.ReplicaOf == "" {
+		ckmi, err := s.getCKMI(id)
+		if err == nil {
+			s.removePolicy(refID, id, ckmi.Firewall)
+		}
+	}
+
+	err = s.stopContainer(refID, id)
 	if err != nil {
 		return err
 	}
@@ -325,14 +546,32 @@ func (s *service) removeContainer(refID uint, id string) error {
 		return err
 	}
 
+	err = s.removeCheckpoints(refID, id)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		err = s.removeExport(instance)
+		if err != nil {
+			return err
+		}
+
+		s.publish(events.ContainerRemoved, events.ContainerEvent{
+			RefID:       refID,
+			ContainerID: id,
+			Name:        instance.ContainerName,
+			Replica:     instance.ReplicaOf != "",
+		})
+	}
 	return nil
 }
 
-func (s *service) Instances(refID uint) []Container {
+func (s *service) Instances(ctx context.Context, refID uint) []Container {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.instances(refID)
+	return s.bind(ctx).instances(refID)
 }
 
 func (s *service) instances(refID uint) []Container {
@@ -357,11 +596,11 @@ func (s *service) instances(refID uint) []Container {
 	return cs
 }
 
-func (s *service) StopContainer(refID uint, id string) error {
+func (s *service) StopContainer(ctx context.Context, refID uint, id string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.stopContainer(refID, id)
+	return s.bind(ctx).stopContainer(refID, id)
 }
 
 func (s *service) stopContainer(refID uint, id string) error {
@@ -375,14 +614,18 @@ func (s *service) stopContainer(refID uint, id string) error {
 		return err
 	}
 
+	s.publish(events.ContainerStopped, events.ContainerEvent{
+		RefID:       refID,
+		ContainerID: id,
+	})
 	return nil
 }
 
-func (s *service) Execute(refID uint, id string, cmd string, env map[string]string) (string, error) {
+func (s *service) Execute(ctx context.Context, refID uint, id string, cmd string, env map[string]string) (string, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.execute(refID, id, cmd, env)
+	return s.bind(ctx).execute(refID, id, cmd, env)
 }
 
 func (s *service) execute(refID uint, id string, cmd string, env map[string]string) (string, error) {
@@ -440,14 +683,81 @@ func (s *service) execute(refID uint, id string, cmd string, env map[string]stri
 		return buf.String(), nil
 	case <-time.After(time.Second * 30):
 		return "Timeout:" + buf.String(), nil
+	case <-s.ctx.Done():
+		p.Signal(os.Kill)
+		return "", s.ctx.Err()
+	}
+}
+
+func (s *service) Attach(ctx context.Context, refID uint, id string, cmd []string, env map[string]string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	container, execEnv, err := s.bind(ctx).attachEnvironment(refID, id, env)
+	if err != nil {
+		return -1, err
+	}
+
+	envString := []string{}
+	for k, v := range execEnv {
+		key := strings.Replace(k, " ", "_", -1)
+		envString = append(envString, fmt.Sprintf("%s=%s", key, v))
+	}
+
+	p := &libcontainer.Process{
+		Args:   cmd,
+		Env:    envString,
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: stderr,
+	}
+
+	err = container.Run(p)
+	if err != nil {
+		return -1, err
+	}
+
+	// cmd is killed if the client goes away before it exits
+	exited := make(chan struct{})
+	defer close(exited)
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.Signal(os.Kill)
+		case <-exited:
+		}
+	}()
+
+	state, err := p.Wait()
+	if ctx.Err() != nil {
+		return -1, ctx.Err()
+	}
+	if state == nil {
+		return -1, err
+	}
+	return state.Sys().(syscall.WaitStatus).ExitStatus(), nil
+}
+
+// attachEnvironment loads the container and its environment for Attach while the service is locked
+func (s *service) attachEnvironment(refID uint, id string, env map[string]string) (libcontainer.Container, map[string]string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	container, err := s.libcnt.Load(id)
+	if err != nil {
+		return nil, nil, err
 	}
+
+	cKMI, err := s.getCKMI(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return container, s.createEnvironmentMap(refID, cKMI, env), nil
 }
 
-func (s *service) GetEnv(refID uint, id string, key string) (string, error) {
+func (s *service) GetEnv(ctx context.Context, refID uint, id string, key string) (string, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.getEnv(refID, id, key)
+	return s.bind(ctx).getEnv(refID, id, key)
 }
 
 func (s *service) getEnv(refID uint, id string, key string) (string, error) {
@@ -477,15 +787,15 @@ func (s *service) getEnv(refID uint, id string, key string) (string, error) {
 	return val, nil
 }
 
-func (s *service) SetEnv(refID uint, id string, key string, value string) error {
+func (s *service) SetEnv(ctx context.Context, refID uint, id string, key string, value string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.setEnv(refID, id, key, value)
+	return s.bind(ctx).setEnv(refID, id, key, value)
 }
 
 func (s *service) setEnv(refID uint, id string, key string, value string) error {
-	cKMI, err := s.getContainerKMI(id)
+	instance, cKMI, err := s.getSealedCKMI(id)
 	if err != nil {
 		return err
 	}
@@ -494,12 +804,18 @@ func (s *service) setEnv(refID uint, id string, key string, value string) error
 
 	env[key] = value
 
+	// only the new value is sealed, the other secrets stay sealed
+	sealed, err := sealEnvironment(s.secrets, instance.RefID, cKMI.KMI, abstraction.NewJSONFromMap(env))
+	if err != nil {
+		return err
+	}
+
 	s.db.Begin()
 	c := &Container{
 		ContainerID: id,
 		KMI: CKMI{
 			KMI: kmi.KMI{
-				Environment: abstraction.NewJSONFromMap(env),
+				Environment: sealed,
 			},
 		},
 	}
@@ -515,11 +831,186 @@ func (s *service) setEnv(refID uint, id string, key string, value string) error
 	return nil
 }
 
-func (s *service) IDForName(refID uint, name string) (string, error) {
+func (s *service) Environment(ctx context.Context, refID uint, id string) (map[string]string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).environment(refID, id)
+}
+
+func (s *service) environment(refID uint, id string) (map[string]string, error) {
+	c := Container{}
+	err := s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	cKMI, err := s.getCKMI(id)
+	if err != nil {
+		return nil, err
+	}
+	return cKMI.Environment.ToStringMap(), nil
+}
+
+func (s *service) SetEnvironment(ctx context.Context, refID uint, id string, env map[string]string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.idForName(refID, name)
+	return s.bind(ctx).setEnvironment(refID, id, env)
+}
+
+func (s *service) setEnvironment(refID uint, id string, env map[string]string) error {
+	c := Container{}
+	err := s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return err
+	}
+
+	cKMI := CKMI{}
+	err = s.db.First(&cKMI, "id = ?", c.KMIID)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := sealEnvironment(s.secrets, c.RefID, cKMI.KMI, abstraction.NewJSONFromMap(env))
+	if err != nil {
+		return err
+	}
+
+	s.db.Begin()
+	err = s.db.Where("id = ?", c.KMIID)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	// the whole environment is replaced, so variables which are not in env are removed
+	err = s.db.Update(&CKMI{}, "environment", sealed)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+	return nil
+}
+
+func (s *service) ImagesInUse(ctx context.Context) ([]string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).imagesInUse()
+}
+
+func (s *service) imagesInUse() ([]string, error) {
+	cs := []Container{}
+	err := s.db.Find(&cs)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	images := []string{}
+	for _, c := range cs {
+		if c.Image != "" && !seen[c.Image] {
+			seen[c.Image] = true
+			images = append(images, c.Image)
+		}
+	}
+	return images, nil
+}
+
+func (s *service) CountRunning(ctx context.Context) (uint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).countRunning()
+}
+
+func (s *service) countRunning() (uint, error) {
+	cs := []Container{}
+	err := s.db.Find(&cs)
+	if err != nil {
+		return 0, err
+	}
+
+	var running uint
+	for _, c := range cs {
+		container, err := s.libcnt.Load(c.ContainerID)
+		if err != nil {
+			continue
+		}
+
+		status, err := container.Status()
+		if err == nil && status == libcontainer.Running {
+			running++
+		}
+	}
+	return running, nil
+}
+
+func (s *service) Usage(ctx context.Context) ([]Usage, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	cs := []Container{}
+	err := s.db.WithContext(ctx).Find(&cs)
+	if err != nil {
+		return nil, err
+	}
+
+	us := []Usage{}
+	for _, c := range cs {
+		container, err := s.libcnt.Load(c.ContainerID)
+		if err != nil {
+			continue
+		}
+
+		// containers which stopped in the meantime are left out
+		status, err := container.Status()
+		if err != nil || status != libcontainer.Running {
+			continue
+		}
+		state, err := container.State()
+		if err != nil {
+			continue
+		}
+		stats, err := container.Stats()
+		if err != nil || stats.CgroupStats == nil {
+			continue
+		}
+
+		u := Usage{
+			RefID:         c.RefID,
+			ContainerID:   c.ContainerID,
+			ContainerName: c.ContainerName,
+			ReplicaOf:     c.ReplicaOf,
+			IP:            s.ip(c.RefID, c.ContainerID),
+			CPU:           stats.CgroupStats.CpuStats.CpuUsage.TotalUsage,
+			Memory:        stats.CgroupStats.MemoryStats.Usage.Usage,
+			MemoryLimit:   stats.CgroupStats.MemoryStats.Usage.Limit,
+			Started:       fmt.Sprint(state.InitProcessStartTime),
+		}
+		// the v1 statistics of the runtime are empty for the unified hierarchy
+		if s.cgroup == CgroupV2 {
+			dir := state.CgroupPaths[""]
+			if dir == "" {
+				dir = path.Join(CgroupRoot, "system", c.ContainerID)
+			}
+			u.CPU, u.Memory, u.MemoryLimit, err = unifiedUsage(dir)
+			if err != nil {
+				continue
+			}
+		}
+		us = append(us, u)
+	}
+	return us, nil
+}
+
+func (s *service) IDForName(ctx context.Context, refID uint, name string) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).idForName(refID, name)
 }
 
 func (s *service) idForName(refID uint, name string) (string, error) {
@@ -616,7 +1107,7 @@ func (s *service) getTemplateKMI(kmiID uint) (kmi.KMI, error) {
 		return kmi.KMI{}, errors.New("No KMI client")
 	}
 
-	kmiResponse, err := s.kmiClient.GetKMIEndpoint(context.Background(), kmi.GetKMIRequest{
+	kmiResponse, err := s.kmiClient.GetKMIEndpoint(s.ctx, kmi.GetKMIRequest{
 		ID: kmiID,
 	})
 	if err != nil {
@@ -631,11 +1122,11 @@ func (s *service) getTemplateKMI(kmiID uint) (kmi.KMI, error) {
 	return *kmi, nil
 }
 
-func (s *service) GetContainerKMI(containerID string) (kmi.KMI, error) {
+func (s *service) GetContainerKMI(ctx context.Context, containerID string) (kmi.KMI, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.getContainerKMI(containerID)
+	return s.bind(ctx).getContainerKMI(containerID)
 }
 
 func (s *service) getContainerKMI(containerID string) (kmi.KMI, error) {
@@ -648,20 +1139,34 @@ func (s *service) getContainerKMI(containerID string) (kmi.KMI, error) {
 	return kmi.KMI(cKMI.KMI), nil
 }
 
+// getCKMI returns the KMI of an instance with the values of the secrets in its environment
 func (s *service) getCKMI(containerID string) (CKMI, error) {
+	c, cKMI, err := s.getSealedCKMI(containerID)
+	if err != nil {
+		return CKMI{}, err
+	}
+
+	cKMI.Environment, err = openEnvironment(s.secrets, c.RefID, cKMI.Environment)
+	if err != nil {
+		return CKMI{}, err
+	}
+	return cKMI, nil
+}
+
+// getSealedCKMI returns an instance and its KMI as it is stored, with the secrets in its environment sealed
+func (s *service) getSealedCKMI(containerID string) (Container, CKMI, error) {
 	c := Container{}
 	err := s.db.First(&c, "container_id = ?", containerID)
 	if err != nil {
-		return CKMI{}, err
+		return Container{}, CKMI{}, err
 	}
 
 	cKMI := CKMI{}
 	err = s.db.First(&cKMI, "id = ?", c.KMIID)
 	if err != nil {
-		return CKMI{}, err
+		return Container{}, CKMI{}, err
 	}
-
-	return cKMI, nil
+	return c, cKMI, nil
 }
 
 func (s *service) getContainerIP(refID uint, containerID string) string {
@@ -674,15 +1179,15 @@ func (s *service) getContainerIP(refID uint, containerID string) string {
 	return fmt.Sprintf("%s", ip)
 }
 
-func (s *service) SetLink(refID uint, containerID string, linkID string, linkName string, linkInterface string) error {
+func (s *service) SetLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.setLink(refID, containerID, linkID, linkName, linkInterface)
+	return s.bind(ctx).setLink(refID, containerID, linkID, linkName, linkInterface)
 }
 
 func (s *service) setLink(refID uint, containerID string, linkID string, linkName string, linkInterface string) error {
-	containerKMI, err := s.getCKMI(containerID)
+	_, containerKMI, err := s.getSealedCKMI(containerID)
 	if err != nil {
 		return err
 	}
@@ -723,11 +1228,11 @@ func (s *service) setLink(refID uint, containerID string, linkID string, linkNam
 	return nil
 }
 
-func (s *service) RemoveLink(refID uint, containerID string, linkID string, linkName string, linkInterface string) error {
+func (s *service) RemoveLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.removeLink(refID, containerID, linkID, linkName, linkInterface)
+	return s.bind(ctx).removeLink(refID, containerID, linkID, linkName, linkInterface)
 }
 
 func (s *service) removeLink(refID uint, containerID string, linkID string, linkName string, linkInterface string) error {
@@ -773,11 +1278,11 @@ func (s *service) removeLink(refID uint, containerID string, linkID string, link
 	return nil
 }
 
-func (s *service) GetLinks(refID uint, containerID string) (map[string][]string, error) {
+func (s *service) GetLinks(ctx context.Context, refID uint, containerID string) (map[string][]string, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.getLinks(refID, containerID)
+	return s.bind(ctx).getLinks(refID, containerID)
 }
 
 func (s *service) getLinks(refID uint, containerID string) (map[string][]string, error) {
@@ -791,8 +1296,13 @@ func (s *service) getLinks(refID uint, containerID string) (map[string][]string,
 	return links, nil
 }
 
-func (s *service) initRootfs(refID uint, provisionScript string, id string, cKMI kmi.KMI) error {
+// initRootfs unpacks the base rootfs and provisions it with the KMI, the rootfs archives built for
+// an instance are complete and are only unpacked
+func (s *service) initRootfs(refID uint, provisionScript string, id string, cKMI kmi.KMI, image string) error {
 	imagePath := path.Join(s.config.RootfsPath, "rootfs.tar")
+	if image != "" {
+		imagePath = image
+	}
 	_, err := os.Stat(imagePath)
 	if err != nil && !os.IsNotExist(err) {
 		return err
@@ -815,6 +1325,10 @@ func (s *service) initRootfs(refID uint, provisionScript string, id string, cKMI
 		return err
 	}
 
+	if image != "" {
+		return nil
+	}
+
 	err = s.provisionRootfs(cKMI, mPath, provisionScript)
 	if err != nil {
 		return err
@@ -881,9 +1395,17 @@ func (s *service) provisionRootfs(cKMI kmi.KMI, rfsPath string, provisionScript
 	}
 
 	// TODO: make clean up more... clean
+	finished := make(chan struct{})
+	defer close(finished)
 	go func() error {
-		time.Sleep(120 * time.Second)
-		s.logger.Log("Timed out. Destroying provision container")
+		select {
+		case <-finished:
+			return nil
+		case <-time.After(120 * time.Second):
+			level.Warn(s.logger).Log("msg", "provisioning timed out, destroying the provision container")
+		case <-s.ctx.Done():
+			level.Warn(s.logger).Log("msg", "provisioning cancelled, destroying the provision container", "err", s.ctx.Err())
+		}
 		container.Signal(os.Kill, true)
 		container.Destroy()
 		return nil
@@ -893,6 +1415,9 @@ func (s *service) provisionRootfs(cKMI kmi.KMI, rfsPath string, provisionScript
 	if err != nil {
 		return err
 	}
+	if s.ctx.Err() != nil {
+		return s.ctx.Err()
+	}
 
 	// Write output to log file
 	err = ioutil.WriteFile(path.Join(rfsPath, "../provision.log"), b, 0644)
@@ -934,20 +1459,43 @@ func (s *service) untar(dst string, src string) error {
 	return nil
 }
 
-// NewService creates a new container service with necessary dependencies
-func NewService(lc libcontainer.Factory, db dbAdapter, ke *kmi.Endpoints, l log.Logger) (Service, error) {
+// NewService creates a new container service with necessary dependencies,
+// re and fe may be nil if replicas should not be registered with routing or firewall, bus if no events should be published,
+// secrets if the secrets in the environments of the instances are kept in plaintext and exports if instances can not be exported
+func NewService(lc libcontainer.Factory, db dbAdapter, ke *kmi.Endpoints, re *routing.Endpoints, fe *firewall.Endpoints, bus events.Bus, secrets secret.Backend, exports *Exports, l log.Logger) (Service, error) {
 	conf, err := util.GetConfig()
 	if err != nil {
 		return &service{}, err
 	}
+	if secrets == nil {
+		secrets = secret.Plaintext
+	}
 
 	s := &service{
 		libcnt:    lc,
 		db:        db,
 		kmiClient: ke,
+		routing:   re,
+		firewall:  fe,
+		events:    bus,
+		secrets:   secrets,
+		exports:   exports,
 		logger:    l,
 		config:    conf,
 		mtx:       &sync.Mutex{},
+		ctx:       context.Background(),
+		limits: Limits{
+			Memory: conf.MemoryLimit,
+			Swap:   conf.SwapLimit,
+			CPU:    conf.CPULimit,
+			Pids:   conf.PidsLimit,
+		},
+		policy: CapacityPolicy{
+			ReservedCPU:      conf.ReservedCPU,
+			ReservedMemory:   conf.ReservedMemory,
+			CPUOvercommit:    conf.CPUOvercommit,
+			MemoryOvercommit: conf.MemoryOvercommit,
+		},
 	}
 
 	err = s.initializeDatabases()
@@ -960,5 +1508,38 @@ func NewService(lc libcontainer.Factory, db dbAdapter, ke *kmi.Endpoints, l log.
 		return s, err
 	}
 
+	s.cgroup = DetectCgroupMode(CgroupRoot)
+	level.Info(s.logger).Log("cgroup", s.cgroup)
+
+	s.host, err = HostCapacity("/proc/meminfo")
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "the capacity of the node is unknown, containers are placed regardless of it", "err", err)
+	} else {
+		a := s.policy.Allocatable(s.host)
+		level.Info(s.logger).Log("cpu", s.host.CPU, "memory", s.host.Memory, "allocatable_cpu", a.CPU, "allocatable_memory", a.Memory)
+	}
+
+	s.removeLost()
+	s.checkpointing = s.checkCRIU()
+
+	criu := ""
+	if s.checkpointing {
+		criu = s.config.CRIUPath
+	}
+	s.runtime = DetectRuntime(s.cgroup, criu, s.config.CustomerPath)
+	level.Info(s.logger).Log("runtime", s.runtime.Runtime, "version", s.runtime.RuntimeVersion, "kernel", s.runtime.Kernel, "storage", s.runtime.Storage, "features", strings.Join(s.runtime.Features(), ","))
+	err = s.labelRuntime()
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "the runtime labels of the node are outdated, modules requiring them may be misplaced", "err", err)
+	}
+
+	s.restoreRunning()
+	s.reconcileReplicas()
+
+	err = s.subscribe()
+	if err != nil {
+		return s, err
+	}
+
 	return s, nil
 }
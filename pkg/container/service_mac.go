@@ -2,44 +2,115 @@
 
 package container
 
-import "github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+import (
+	"io"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"golang.org/x/net/context"
+)
 
 // Service Container Service
 type Service interface {
 	// CreateContainer instanciates a container for a User with the id refID and returns its id
-	CreateContainer(refID uint, kmiID uint, name string) (id string, err error)
+	CreateContainer(ctx context.Context, refID uint, kmiID uint, name string) (id string, err error)
 
 	// RemoveContainer is used to remove a container instance by id
-	RemoveContainer(refID uint, id string) error
+	RemoveContainer(ctx context.Context, refID uint, id string) error
 
 	// Instances returns a list of container instances of a user by id
-	Instances(refID uint) []Container
+	Instances(ctx context.Context, refID uint) []Container
 
 	// StopContainer stops a container
-	StopContainer(refID uint, id string) error
+	StopContainer(ctx context.Context, refID uint, id string) error
 
 	// Execute executes a command in a given container
-	Execute(refID uint, id string, cmd string, env map[string]string) (string, error)
+	Execute(ctx context.Context, refID uint, id string, cmd string, env map[string]string) (string, error)
+
+	// Attach runs cmd in a given container with its standard streams connected to stdin, stdout and stderr
+	// and returns the exit code of cmd, other calls are not blocked while cmd runs
+	Attach(ctx context.Context, refID uint, id string, cmd []string, env map[string]string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
 
 	// GetEnv returns the value to a given environment variable setting. Returns the whole
 	// environment as string if key is empty
-	GetEnv(refID uint, id string, key string) (string, error)
+	GetEnv(ctx context.Context, refID uint, id string, key string) (string, error)
 
 	// SetEnv sets an environment variable for the container
-	SetEnv(refID uint, id string, key string, value string) error
+	SetEnv(ctx context.Context, refID uint, id string, key string, value string) error
+
+	// Environment returns the environment of an instance
+	Environment(ctx context.Context, refID uint, id string) (map[string]string, error)
+
+	// SetEnvironment replaces the environment of an instance
+	SetEnvironment(ctx context.Context, refID uint, id string, env map[string]string) error
+
+	// ImagesInUse returns the rootfs archives the containers are created from
+	ImagesInUse(ctx context.Context) ([]string, error)
 
 	// IDForName returs the containerID for a given container name and user
-	IDForName(refID uint, name string) (string, error)
+	IDForName(ctx context.Context, refID uint, name string) (string, error)
 
 	// GetContainerKMI returns the KMI for a given container
-	GetContainerKMI(containerID string) (kmi.KMI, error)
+	GetContainerKMI(ctx context.Context, containerID string) (kmi.KMI, error)
 
 	// SetLink links a container's interface into a container
-	SetLink(refID uint, containerID string, linkID string, linkName string, linkInterface string) error
+	SetLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error
 
 	// RemoveLink links a container's interface into a container
-	RemoveLink(refID uint, containerID string, linkID string, linkName string, linkInterface string) error
+	RemoveLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error
 
 	// GetLinks returns all links a container has
-	GetLinks(refID uint, containerID string) (map[string][]string, error)
+	GetLinks(ctx context.Context, refID uint, containerID string) (map[string][]string, error)
+
+	// ScaleInstance creates or removes replicas of a container instance until it has the given number of
+	// replicas. Replicas are registered with the routing and firewall services.
+	ScaleInstance(ctx context.Context, refID uint, id string, replicas uint) error
+
+	// CloneInstance creates a staging copy of an instance named name from its kmi, links, environment and a
+	// snapshot of its volume and serves it at its StagingDomain. The values of secrets are not copied, a
+	// SecretsError names the secrets which need a value in env.
+	CloneInstance(ctx context.Context, refID uint, id string, name string, env map[string]string) (string, error)
+
+	// UpdateInstance replaces the containers of an instance and its replicas with containers created from
+	// the gzip compressed rootfs archive image without downtime. The instance keeps its ID and address.
+	UpdateInstance(ctx context.Context, refID uint, id string, image string) error
+
+	// PinInstance pins an instance to its node or unpins it, pinned instances are not placed on another
+	// node once their node failed
+	PinInstance(ctx context.Context, refID uint, id string, pinned bool) error
+
+	// ExportInstance writes an archive of the volume of an instance to the artifact store, replacing its
+	// previous export, and returns the signed URL it is downloaded from and when the URL expires
+	ExportInstance(ctx context.Context, refID uint, id string) (string, time.Time, error)
+
+	// SetPlacement constrains the nodes an instance is placed on in addition to the placement of its module.
+	// It returns a kmi.PlacementError if the node of the instance does not satisfy it.
+	SetPlacement(ctx context.Context, refID uint, id string, p kmi.Placement) error
+
+	// CheckpointInstance saves the processes of the container of an instance as the checkpoint name with CRIU,
+	// replacing an older checkpoint of that name. The container keeps running with leaveRunning and is stopped
+	// otherwise. It fails with ErrCheckpointUnsupported unless checkpoints are enabled and CRIU works on this node.
+	CheckpointInstance(ctx context.Context, refID uint, id string, name string, leaveRunning bool) (Checkpoint, error)
+
+	// RestoreInstance restores the stopped container of an instance from its checkpoint name
+	RestoreInstance(ctx context.Context, refID uint, id string, name string) error
+
+	// Checkpoints returns the checkpoints of an instance, the latest first
+	Checkpoints(ctx context.Context, refID uint, id string) ([]Checkpoint, error)
+
+	// RemoveCheckpoint removes a checkpoint of an instance
+	RemoveCheckpoint(ctx context.Context, refID uint, id string, name string) error
+
+	// CheckpointRunning saves the running containers of this node as RestartCheckpoint and stops them if they
+	// should be restored once the daemon started again, it is called when the daemon shuts down
+	CheckpointRunning(ctx context.Context) error
+
+	// CountRunning returns the number of containers which are running
+	CountRunning(ctx context.Context) (uint, error)
+
+	// Runtime returns the container runtime of this node and the features it supports
+	Runtime(ctx context.Context) Runtime
+
+	// Usage returns the resource usage of the containers running on this node
+	Usage(ctx context.Context) ([]Usage, error)
 }
@@ -8,6 +8,7 @@ import (
 	grpctransport "github.com/go-kit/kit/transport/grpc"
 	"github.com/kontainerooo/kontainer.ooo/pkg/container/pb"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 	oldcontext "golang.org/x/net/context"
 )
 
@@ -91,6 +92,69 @@ func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger)
 			EncodeGRPCGetLinksResponse,
 			options...,
 		),
+
+		scaleinstance: grpctransport.NewServer(
+			endpoints.ScaleInstanceEndpoint,
+			DecodeGRPCScaleInstanceRequest,
+			EncodeGRPCScaleInstanceResponse,
+			options...,
+		),
+
+		cloneinstance: grpctransport.NewServer(
+			endpoints.CloneInstanceEndpoint,
+			DecodeGRPCCloneInstanceRequest,
+			EncodeGRPCCloneInstanceResponse,
+			options...,
+		),
+
+		checkpointinstance: grpctransport.NewServer(
+			endpoints.CheckpointInstanceEndpoint,
+			DecodeGRPCCheckpointInstanceRequest,
+			EncodeGRPCCheckpointInstanceResponse,
+			options...,
+		),
+
+		restoreinstance: grpctransport.NewServer(
+			endpoints.RestoreInstanceEndpoint,
+			DecodeGRPCRestoreInstanceRequest,
+			EncodeGRPCRestoreInstanceResponse,
+			options...,
+		),
+
+		checkpoints: grpctransport.NewServer(
+			endpoints.CheckpointsEndpoint,
+			DecodeGRPCCheckpointsRequest,
+			EncodeGRPCCheckpointsResponse,
+			options...,
+		),
+
+		removecheckpoint: grpctransport.NewServer(
+			endpoints.RemoveCheckpointEndpoint,
+			DecodeGRPCRemoveCheckpointRequest,
+			EncodeGRPCRemoveCheckpointResponse,
+			options...,
+		),
+
+		pininstance: grpctransport.NewServer(
+			endpoints.PinInstanceEndpoint,
+			DecodeGRPCPinInstanceRequest,
+			EncodeGRPCPinInstanceResponse,
+			options...,
+		),
+
+		setplacement: grpctransport.NewServer(
+			endpoints.SetPlacementEndpoint,
+			DecodeGRPCSetPlacementRequest,
+			EncodeGRPCSetPlacementResponse,
+			options...,
+		),
+
+		exportinstance: grpctransport.NewServer(
+			endpoints.ExportInstanceEndpoint,
+			DecodeGRPCExportInstanceRequest,
+			EncodeGRPCExportInstanceResponse,
+			options...,
+		),
 	}
 }
 
@@ -108,6 +172,16 @@ type grpcServer struct {
 	setlink         grpctransport.Handler
 	removelink      grpctransport.Handler
 	getlinks        grpctransport.Handler
+	scaleinstance   grpctransport.Handler
+	cloneinstance   grpctransport.Handler
+
+	checkpointinstance grpctransport.Handler
+	restoreinstance    grpctransport.Handler
+	checkpoints        grpctransport.Handler
+	removecheckpoint   grpctransport.Handler
+	pininstance        grpctransport.Handler
+	setplacement       grpctransport.Handler
+	exportinstance     grpctransport.Handler
 }
 
 func (s *grpcServer) CreateContainer(ctx oldcontext.Context, req *pb.CreateContainerRequest) (*pb.CreateContainerResponse, error) {
@@ -206,6 +280,78 @@ func (s *grpcServer) GetLinks(ctx oldcontext.Context, req *pb.GetLinksRequest) (
 	return res.(*pb.GetLinksResponse), nil
 }
 
+func (s *grpcServer) ScaleInstance(ctx oldcontext.Context, req *pb.ScaleInstanceRequest) (*pb.ScaleInstanceResponse, error) {
+	_, res, err := s.scaleinstance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ScaleInstanceResponse), nil
+}
+
+func (s *grpcServer) CloneInstance(ctx oldcontext.Context, req *pb.CloneInstanceRequest) (*pb.CloneInstanceResponse, error) {
+	_, res, err := s.cloneinstance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CloneInstanceResponse), nil
+}
+
+func (s *grpcServer) CheckpointInstance(ctx oldcontext.Context, req *pb.CheckpointInstanceRequest) (*pb.CheckpointInstanceResponse, error) {
+	_, res, err := s.checkpointinstance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CheckpointInstanceResponse), nil
+}
+
+func (s *grpcServer) RestoreInstance(ctx oldcontext.Context, req *pb.RestoreInstanceRequest) (*pb.RestoreInstanceResponse, error) {
+	_, res, err := s.restoreinstance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RestoreInstanceResponse), nil
+}
+
+func (s *grpcServer) Checkpoints(ctx oldcontext.Context, req *pb.CheckpointsRequest) (*pb.CheckpointsResponse, error) {
+	_, res, err := s.checkpoints.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CheckpointsResponse), nil
+}
+
+func (s *grpcServer) RemoveCheckpoint(ctx oldcontext.Context, req *pb.RemoveCheckpointRequest) (*pb.RemoveCheckpointResponse, error) {
+	_, res, err := s.removecheckpoint.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveCheckpointResponse), nil
+}
+
+func (s *grpcServer) PinInstance(ctx oldcontext.Context, req *pb.PinInstanceRequest) (*pb.PinInstanceResponse, error) {
+	_, res, err := s.pininstance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.PinInstanceResponse), nil
+}
+
+func (s *grpcServer) SetPlacement(ctx oldcontext.Context, req *pb.SetPlacementRequest) (*pb.SetPlacementResponse, error) {
+	_, res, err := s.setplacement.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetPlacementResponse), nil
+}
+
+func (s *grpcServer) ExportInstance(ctx oldcontext.Context, req *pb.ExportInstanceRequest) (*pb.ExportInstanceResponse, error) {
+	_, res, err := s.exportinstance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ExportInstanceResponse), nil
+}
+
 // DecodeGRPCCreateContainerRequest is a transport/grpc.DecodeRequestFunc that converts a
 // gRPC CreateContainer request to a messages/container.proto-domain createcontainer request.
 func DecodeGRPCCreateContainerRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
@@ -233,6 +379,7 @@ func DecodeGRPCInstancesRequest(_ context.Context, grpcReq interface{}) (interfa
 	req := grpcReq.(*pb.InstancesRequest)
 	return InstancesRequest{
 		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
 	}, nil
 }
 
@@ -371,11 +518,14 @@ func EncodeGRPCInstancesResponse(_ context.Context, response interface{}) (inter
 			ContainerName: v.ContainerName,
 			Kmi:           kmi.ConvertPBKMI(&kmiWrapper),
 			RefID:         uint32(v.RefID),
+			ReplicaOf:     v.ReplicaOf,
+			Replicas:      uint32(v.Replicas),
 		}
 		cts = append(cts, c)
 	}
 	gRPCRes := &pb.InstancesResponse{
 		Instances: cts,
+		PageInfo:  paging.EncodePageInfo(res.Page),
 	}
 	return gRPCRes, nil
 }
@@ -493,3 +643,221 @@ func EncodeGRPCGetLinksResponse(_ context.Context, response interface{}) (interf
 	}
 	return gRPCRes, nil
 }
+
+// DecodeGRPCScaleInstanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ScaleInstance request to a messages/container.proto-domain scaleinstance request.
+func DecodeGRPCScaleInstanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ScaleInstanceRequest)
+	return ScaleInstanceRequest{
+		RefID:    uint(req.RefID),
+		ID:       req.ID,
+		Replicas: uint(req.Replicas),
+	}, nil
+}
+
+// EncodeGRPCScaleInstanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain scaleinstance response to a gRPC ScaleInstance response.
+func EncodeGRPCScaleInstanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ScaleInstanceResponse)
+	gRPCRes := &pb.ScaleInstanceResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCCloneInstanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CloneInstance request to a messages/container.proto-domain cloneinstance request.
+func DecodeGRPCCloneInstanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CloneInstanceRequest)
+	return CloneInstanceRequest{
+		RefID: uint(req.RefID),
+		ID:    req.ID,
+		Name:  req.Name,
+		Env:   req.Env,
+	}, nil
+}
+
+// EncodeGRPCCloneInstanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain cloneinstance response to a gRPC CloneInstance response.
+func EncodeGRPCCloneInstanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CloneInstanceResponse)
+	gRPCRes := &pb.CloneInstanceResponse{
+		ID:      res.ID,
+		Secrets: res.Secrets,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+func checkpointToPB(cp Checkpoint) *pb.Checkpoint {
+	return &pb.Checkpoint{
+		Name:      cp.Name,
+		CreatedAt: cp.CreatedAt.Unix(),
+	}
+}
+
+// DecodeGRPCCheckpointInstanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CheckpointInstance request to a messages/container.proto-domain checkpointinstance request.
+func DecodeGRPCCheckpointInstanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CheckpointInstanceRequest)
+	return CheckpointInstanceRequest{
+		RefID:        uint(req.RefID),
+		ID:           req.ID,
+		Name:         req.Name,
+		LeaveRunning: req.LeaveRunning,
+	}, nil
+}
+
+// EncodeGRPCCheckpointInstanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain checkpointinstance response to a gRPC CheckpointInstance response.
+func EncodeGRPCCheckpointInstanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CheckpointInstanceResponse)
+	gRPCRes := &pb.CheckpointInstanceResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	} else {
+		gRPCRes.Checkpoint = checkpointToPB(res.Checkpoint)
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRestoreInstanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RestoreInstance request to a messages/container.proto-domain restoreinstance request.
+func DecodeGRPCRestoreInstanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RestoreInstanceRequest)
+	return RestoreInstanceRequest{
+		RefID: uint(req.RefID),
+		ID:    req.ID,
+		Name:  req.Name,
+	}, nil
+}
+
+// EncodeGRPCRestoreInstanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain restoreinstance response to a gRPC RestoreInstance response.
+func EncodeGRPCRestoreInstanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RestoreInstanceResponse)
+	gRPCRes := &pb.RestoreInstanceResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCCheckpointsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Checkpoints request to a messages/container.proto-domain checkpoints request.
+func DecodeGRPCCheckpointsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CheckpointsRequest)
+	return CheckpointsRequest{
+		RefID: uint(req.RefID),
+		ID:    req.ID,
+	}, nil
+}
+
+// EncodeGRPCCheckpointsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain checkpoints response to a gRPC Checkpoints response.
+func EncodeGRPCCheckpointsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CheckpointsResponse)
+	gRPCRes := &pb.CheckpointsResponse{}
+	for _, cp := range res.Checkpoints {
+		gRPCRes.Checkpoints = append(gRPCRes.Checkpoints, checkpointToPB(cp))
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveCheckpointRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveCheckpoint request to a messages/container.proto-domain removecheckpoint request.
+func DecodeGRPCRemoveCheckpointRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveCheckpointRequest)
+	return RemoveCheckpointRequest{
+		RefID: uint(req.RefID),
+		ID:    req.ID,
+		Name:  req.Name,
+	}, nil
+}
+
+// EncodeGRPCRemoveCheckpointResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain removecheckpoint response to a gRPC RemoveCheckpoint response.
+func EncodeGRPCRemoveCheckpointResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveCheckpointResponse)
+	gRPCRes := &pb.RemoveCheckpointResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCPinInstanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC PinInstance request to a messages/container.proto-domain pininstance request.
+func DecodeGRPCPinInstanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PinInstanceRequest)
+	return PinInstanceRequest{
+		RefID:  uint(req.RefID),
+		ID:     req.ID,
+		Pinned: req.Pinned,
+	}, nil
+}
+
+// EncodeGRPCPinInstanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain pininstance response to a gRPC PinInstance response.
+func EncodeGRPCPinInstanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(PinInstanceResponse)
+	gRPCRes := &pb.PinInstanceResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetPlacementRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetPlacement request to a messages/container.proto-domain setplacement request.
+func DecodeGRPCSetPlacementRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetPlacementRequest)
+	return SetPlacementRequest{
+		RefID:  uint(req.RefID),
+		ID:     req.ID,
+		Spread: req.Spread,
+		Labels: req.Labels,
+	}, nil
+}
+
+// EncodeGRPCSetPlacementResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain setplacement response to a gRPC SetPlacement response.
+func EncodeGRPCSetPlacementResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetPlacementResponse)
+	gRPCRes := &pb.SetPlacementResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCExportInstanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ExportInstance request to a messages/container.proto-domain exportinstance request.
+func DecodeGRPCExportInstanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ExportInstanceRequest)
+	return ExportInstanceRequest{
+		RefID: uint(req.RefID),
+		ID:    req.ID,
+	}, nil
+}
+
+// EncodeGRPCExportInstanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/container.proto-domain exportinstance response to a gRPC ExportInstance response.
+func EncodeGRPCExportInstanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ExportInstanceResponse)
+	if res.Error != nil {
+		return &pb.ExportInstanceResponse{
+			Error: res.Error.Error(),
+		}, nil
+	}
+	return &pb.ExportInstanceResponse{
+		Url:       res.URL,
+		ExpiresAt: res.ExpiresAt.Unix(),
+	}, nil
+}
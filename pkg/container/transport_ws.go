@@ -3,8 +3,8 @@ package container
 import (
 	"context"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/kontainerooo/kontainer.ooo/pkg/container/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
 )
 
@@ -108,6 +108,22 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 		EncodeGRPCGetLinksResponse,
 	))
 
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"ScaleInstance",
+		ws.ProtoIDFromString("SCI"),
+		endpoints.ScaleInstanceEndpoint,
+		DecodeWSScaleInstanceRequest,
+		EncodeGRPCScaleInstanceResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"CloneInstance",
+		ws.ProtoIDFromString("CLI"),
+		endpoints.CloneInstanceEndpoint,
+		DecodeWSCloneInstanceRequest,
+		EncodeGRPCCloneInstanceResponse,
+	))
+
 	return service
 }
 
@@ -115,7 +131,7 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 // WS CreateContainer request to a container.proto-domain createcontainer request.
 func DecodeWSCreateContainerRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.CreateContainerRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +143,7 @@ func DecodeWSCreateContainerRequest(ctx context.Context, data interface{}) (inte
 // WS RemoveContainer request to a container.proto-domain removecontainer request.
 func DecodeWSRemoveContainerRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveContainerRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +155,7 @@ func DecodeWSRemoveContainerRequest(ctx context.Context, data interface{}) (inte
 // WS Instances request to a container.proto-domain instances request.
 func DecodeWSInstancesRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.InstancesRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +167,7 @@ func DecodeWSInstancesRequest(ctx context.Context, data interface{}) (interface{
 // WS StopContainer request to a container.proto-domain stopcontainer request.
 func DecodeWSStopContainerRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.StopContainerRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +179,7 @@ func DecodeWSStopContainerRequest(ctx context.Context, data interface{}) (interf
 // WS Execute request to a container.proto-domain execute request.
 func DecodeWSExecuteRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.ExecuteRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +191,7 @@ func DecodeWSExecuteRequest(ctx context.Context, data interface{}) (interface{},
 // WS GetEnv request to a container.proto-domain getenv request.
 func DecodeWSGetEnvRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetEnvRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -187,7 +203,7 @@ func DecodeWSGetEnvRequest(ctx context.Context, data interface{}) (interface{},
 // WS SetEnv request to a container.proto-domain setenv request.
 func DecodeWSSetEnvRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.SetEnvRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -199,7 +215,7 @@ func DecodeWSSetEnvRequest(ctx context.Context, data interface{}) (interface{},
 // WS IDForName request to a container.proto-domain idforname request.
 func DecodeWSIDForNameRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.IDForNameRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -211,7 +227,7 @@ func DecodeWSIDForNameRequest(ctx context.Context, data interface{}) (interface{
 // WS GetContainerKMI request to a container.proto-domain getcontainerkmi request.
 func DecodeWSGetContainerKMIRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetContainerKMIRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +239,7 @@ func DecodeWSGetContainerKMIRequest(ctx context.Context, data interface{}) (inte
 // WS SetLink request to a container.proto-domain setlink request.
 func DecodeWSSetLinkRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.SetLinkRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -235,7 +251,7 @@ func DecodeWSSetLinkRequest(ctx context.Context, data interface{}) (interface{},
 // WS RemoveLink request to a container.proto-domain removelink request.
 func DecodeWSRemoveLinkRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveLinkRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -247,10 +263,34 @@ func DecodeWSRemoveLinkRequest(ctx context.Context, data interface{}) (interface
 // WS GetLinks request to a container.proto-domain getlinks request.
 func DecodeWSGetLinksRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetLinksRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
 
 	return DecodeGRPCGetLinksRequest(ctx, req)
 }
+
+// DecodeWSScaleInstanceRequest is a websocket.DecodeRequestFunc that converts a
+// WS ScaleInstance request to a messages/container.proto-domain scaleinstance request.
+func DecodeWSScaleInstanceRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.ScaleInstanceRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCScaleInstanceRequest(ctx, req)
+}
+
+// DecodeWSCloneInstanceRequest is a websocket.DecodeRequestFunc that converts a
+// WS CloneInstance request to a messages/container.proto-domain cloneinstance request.
+func DecodeWSCloneInstanceRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.CloneInstanceRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCCloneInstanceRequest(ctx, req)
+}
@@ -0,0 +1,149 @@
+// +build linux
+
+package container
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/go-kit/kit/log/level"
+	"golang.org/x/net/context"
+)
+
+// SurgeName returns the container name of the replica serving an instance while it is updated
+func SurgeName(name string) string {
+	return fmt.Sprintf("%s-surge", name)
+}
+
+func (s *service) UpdateInstance(ctx context.Context, refID uint, id string, image string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.bind(ctx).updateInstance(refID, id, image)
+}
+
+// updateInstance registers a surge replica created from image first, so the instance keeps serving
+// while its replicas and then the instance itself are recreated from image one after another
+func (s *service) updateInstance(refID uint, id string, image string) error {
+	if s.drained {
+		return ErrNodeDrained
+	}
+
+	c := Container{}
+	err := s.db.First(&c, "ref_id = ? AND container_id = ?", refID, id)
+	if err != nil {
+		return err
+	}
+
+	if c.ReplicaOf != "" {
+		return errors.New("a replica can not be updated")
+	}
+
+	_, err = os.Stat(image)
+	if err != nil {
+		return err
+	}
+
+	ckmi, err := s.getCKMI(id)
+	if err != nil {
+		return err
+	}
+
+	surgeID, err := s.createInstance(refID, ckmi.KMI, ckmi.Links, SurgeName(c.ContainerName), id, image, c.Placement)
+	if err != nil {
+		return err
+	}
+	surge := Container{
+		RefID:         refID,
+		ContainerID:   surgeID,
+		ContainerName: SurgeName(c.ContainerName),
+		ReplicaOf:     id,
+	}
+
+	err = s.registerReplica(c, surge)
+	if err != nil {
+		s.removeContainer(refID, surgeID)
+		return err
+	}
+	defer func() {
+		s.unregisterReplica(c, surge)
+		err := s.removeContainer(refID, surgeID)
+		if err != nil {
+			level.Error(s.logger).Log("replica", surge.ContainerName, "err", err)
+		}
+	}()
+
+	replicas, err := s.replicasOf(id)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range replicas {
+		if r.ContainerID == surgeID {
+			continue
+		}
+
+		s.unregisterReplica(c, r)
+		err = s.removeContainer(r.RefID, r.ContainerID)
+		if err != nil {
+			return err
+		}
+
+		r.ContainerID, err = s.createInstance(refID, ckmi.KMI, ckmi.Links, r.ContainerName, id, image, c.Placement)
+		if err != nil {
+			return err
+		}
+
+		err = s.registerReplica(c, r)
+		if err != nil {
+			level.Error(s.logger).Log("replica", r.ContainerName, "err", err)
+		}
+	}
+
+	err = s.recreate(c, ckmi, image)
+	if err != nil {
+		return err
+	}
+
+	s.db.Begin()
+	err = s.db.Where("container_id = ?", id)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Update(&Container{}, "image", image)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+	return nil
+}
+
+// recreate replaces the container of an instance with one created from image. The directory of the
+// instance is kept, so the instance keeps its address and the routing does not need to change.
+func (s *service) recreate(c Container, ckmi CKMI, image string) error {
+	container, err := s.libcnt.Load(c.ContainerID)
+	if err == nil {
+		container.Signal(os.Kill, true)
+		err = container.Destroy()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.RemoveAll(path.Join(s.config.CustomerPath, fmt.Sprintf("%d", c.RefID), c.ContainerID, "rootfs"))
+	if err != nil {
+		return err
+	}
+
+	err = s.initRootfs(c.RefID, ckmi.ProvisionScript, c.ContainerID, ckmi.KMI, image)
+	if err != nil {
+		return err
+	}
+
+	return s.start(c.RefID, c.ContainerID, c.ContainerName)
+}
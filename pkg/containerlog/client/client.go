@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog"
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection, tailing is only available via websocket
+func New(conn *grpc.ClientConn, logger log.Logger) *containerlog.Endpoints {
+
+	var SearchEndpoint endpoint.Endpoint
+	{
+		SearchEndpoint = grpctransport.NewClient(
+			conn,
+			"containerlog.ContainerLogService",
+			"Search",
+			EncodeGRPCSearchRequest,
+			DecodeGRPCSearchResponse,
+			pb.SearchResponse{},
+		).Endpoint()
+	}
+
+	return &containerlog.Endpoints{
+		SearchEndpoint: SearchEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCSearchRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/containerlog.proto-domain search request to a gRPC Search request.
+func EncodeGRPCSearchRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*containerlog.SearchRequest)
+	gRPCReq := &pb.SearchRequest{
+		RefID:    uint32(req.RefID),
+		Instance: req.Query.Instance,
+		Stream:   req.Query.Stream,
+		Text:     req.Query.Text,
+		Page:     paging.EncodePage(req.Page),
+	}
+	if !req.Query.From.IsZero() {
+		gRPCReq.From = req.Query.From.Unix()
+	}
+	if !req.Query.To.IsZero() {
+		gRPCReq.To = req.Query.To.Unix()
+	}
+	return gRPCReq, nil
+}
+
+// DecodeGRPCSearchResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Search response to a messages/containerlog.proto-domain search response.
+func DecodeGRPCSearchResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SearchResponse)
+	return &containerlog.SearchResponse{
+		Entries: containerlog.ConvertPBEntries(response.Entries),
+		Error:   getError(response.Error),
+		Page:    paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
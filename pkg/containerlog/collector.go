@@ -0,0 +1,225 @@
+package containerlog
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// SourceFunc returns the log files of the instances running on this node
+type SourceFunc func() ([]Source, error)
+
+// subscriberBuffer is the number of batches of lines a subscriber may fall behind before batches are dropped
+const subscriberBuffer = 16
+
+type instanceKey struct {
+	refID    uint
+	instance string
+}
+
+// Collector reads the lines the instances append to their log files, stores them and
+// publishes them to the subscribers tailing an instance
+type Collector struct {
+	s       Service
+	sources SourceFunc
+	logger  log.Logger
+
+	mtx         sync.Mutex
+	collected   bool
+	offsets     map[string]int64
+	subscribers map[instanceKey][]chan []Entry
+}
+
+// read returns the complete lines which were appended to a log since the last call.
+// Logs which already exist on the first Collect are read from their end, so a restart does not
+// store lines twice, logs of instances created later and logs which shrank because they were
+// rotated are read from their start.
+func (c *Collector) read(path string) ([]string, error) {
+	offset, known := c.offsets[path]
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		c.offsets[path] = 0
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if !known && !c.collected {
+		c.offsets[path] = info.Size()
+		return nil, nil
+	}
+
+	if info.Size() < offset {
+		offset = 0
+	}
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			// an incomplete line is read again once the instance finished writing it
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		offset += int64(len(line))
+		lines = append(lines, strings.TrimRight(line, "\r\n"))
+	}
+
+	c.offsets[path] = offset
+	return lines, nil
+}
+
+// Collect reads the new lines of all instances, stores them and publishes them to the subscribers
+func (c *Collector) Collect(now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	sources, err := c.sources()
+	if err != nil {
+		level.Error(c.logger).Log("err", err)
+		return
+	}
+
+	active := make(map[string]bool)
+	entries := []Entry{}
+	for _, src := range sources {
+		active[src.Path] = true
+
+		lines, err := c.read(src.Path)
+		if err != nil {
+			level.Error(c.logger).Log("log", src.Path, "err", err)
+			continue
+		}
+
+		for _, line := range lines {
+			entries = append(entries, Entry{
+				RefID:    src.RefID,
+				Instance: src.Instance,
+				Stream:   src.Stream,
+				Line:     line,
+				Time:     now.UTC(),
+			})
+		}
+	}
+
+	c.collected = true
+
+	// the offsets of removed instances are forgotten
+	for path := range c.offsets {
+		if !active[path] {
+			delete(c.offsets, path)
+		}
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	err = c.s.Append(entries)
+	if err != nil {
+		level.Error(c.logger).Log("lines", len(entries), "err", err)
+	}
+	c.publish(entries)
+}
+
+func (c *Collector) publish(entries []Entry) {
+	batches := make(map[instanceKey][]Entry)
+	for _, e := range entries {
+		key := instanceKey{e.RefID, e.Instance}
+		if len(c.subscribers[key]) != 0 {
+			batches[key] = append(batches[key], e)
+		}
+	}
+
+	for key, batch := range batches {
+		for _, s := range c.subscribers[key] {
+			// a subscriber which fell too far behind misses the lines instead of blocking the collection
+			select {
+			case s <- batch:
+			default:
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel which receives the new lines of an instance on every Collect
+// and a function to cancel the subscription
+func (c *Collector) Subscribe(refID uint, instance string) (<-chan []Entry, func()) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	key := instanceKey{refID, instance}
+	s := make(chan []Entry, subscriberBuffer)
+	c.subscribers[key] = append(c.subscribers[key], s)
+
+	var once sync.Once
+	return s, func() {
+		once.Do(func() {
+			c.mtx.Lock()
+			defer c.mtx.Unlock()
+
+			subscribers := c.subscribers[key]
+			for i, sub := range subscribers {
+				if sub == s {
+					c.subscribers[key] = append(subscribers[:i], subscribers[i+1:]...)
+					break
+				}
+			}
+			if len(c.subscribers[key]) == 0 {
+				delete(c.subscribers, key)
+			}
+			close(s)
+		})
+	}
+}
+
+// Run calls Collect every interval until stop is closed, the new lines are collected once more before it returns
+func (c *Collector) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		c.Collect(time.Now())
+
+		select {
+		case <-t.C:
+		case <-stop:
+			c.Collect(time.Now())
+			return
+		}
+	}
+}
+
+// NewCollector returns a Collector for the log files sources returns
+func NewCollector(s Service, sources SourceFunc, logger log.Logger) *Collector {
+	return &Collector{
+		s:           s,
+		sources:     sources,
+		logger:      logger,
+		offsets:     make(map[string]int64),
+		subscribers: make(map[instanceKey][]chan []Entry),
+	}
+}
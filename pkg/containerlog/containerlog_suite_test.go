@@ -0,0 +1,13 @@
+package containerlog_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestContainerLog(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ContainerLog Suite")
+}
@@ -0,0 +1,398 @@
+package containerlog_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog"
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func lines(es []containerlog.Entry) []string {
+	ls := []string{}
+	for _, e := range es {
+		ls = append(ls, e.Line)
+	}
+	return ls
+}
+
+type mockSink struct {
+	mtx   sync.Mutex
+	fails int
+	sent  []containerlog.Entry
+}
+
+func (m *mockSink) Send(entries []containerlog.Entry) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.fails > 0 {
+		m.fails--
+		return errors.New("unavailable")
+	}
+	m.sent = append(m.sent, entries...)
+	return nil
+}
+
+func (m *mockSink) lines() []string {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	return lines(m.sent)
+}
+
+var _ = Describe("ContainerLog", func() {
+	var (
+		plan string
+		s    containerlog.Service
+	)
+
+	planOf := func(refID uint) (string, error) {
+		return plan, nil
+	}
+
+	BeforeEach(func() {
+		plan = containerlog.DefaultPlan
+
+		var err error
+		s, err = containerlog.NewService(testutils.NewMockDB(), planOf, map[string]containerlog.Plan{
+			containerlog.DefaultPlan: {Retention: 7, MaxSize: 16},
+		}, containerlog.Options{
+			MaxLine: 8,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("Create Service", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := containerlog.NewService(db, planOf, nil, containerlog.Options{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Search", func() {
+		now := time.Now().UTC()
+
+		BeforeEach(func() {
+			Ω(s.Append([]containerlog.Entry{
+				{RefID: 1, Instance: "web", Stream: containerlog.Stdout, Line: "GET /", Time: now.Add(-time.Hour)},
+				{RefID: 1, Instance: "web", Stream: containerlog.Stderr, Line: "Error", Time: now},
+				{RefID: 1, Instance: "db", Stream: containerlog.Stdout, Line: "ready", Time: now},
+				{RefID: 2, Instance: "web", Stream: containerlog.Stdout, Line: "GET /a", Time: now},
+			})).Should(Succeed())
+		})
+
+		It("Should return the lines of a user, the latest first", func() {
+			es := []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"ready", "Error", "GET /"}))
+		})
+
+		It("Should filter the lines", func() {
+			es := []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{Instance: "web"}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"Error", "GET /"}))
+
+			es = []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{Stream: containerlog.Stderr}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"Error"}))
+
+			es = []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{Text: "error"}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"Error"}))
+
+			es = []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{From: now.Add(-2 * time.Hour), To: now}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"GET /"}))
+		})
+
+		It("Should return an error if the time range ends before it starts", func() {
+			err := s.Search(1, containerlog.Query{From: now, To: now.Add(-time.Minute)}, &[]containerlog.Entry{})
+			Expect(err).To(Equal(containerlog.ErrTimeRange))
+		})
+	})
+
+	Describe("Limits", func() {
+		It("Should cut long lines", func() {
+			Ω(s.Append([]containerlog.Entry{
+				{RefID: 1, Instance: "web", Line: "0123456789"},
+				{RefID: 1, Instance: "web", Line: "aaaGrüße"},
+			})).Should(Succeed())
+
+			es := []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"aaaGrü", "01234567"}))
+		})
+
+		It("Should remove the oldest lines exceeding the size", func() {
+			Ω(s.Append([]containerlog.Entry{
+				{RefID: 1, Instance: "web", Line: "first"},
+				{RefID: 1, Instance: "web", Line: "second"},
+				{RefID: 1, Instance: "web", Line: "third"},
+				{RefID: 2, Instance: "web", Line: "other"},
+			})).Should(Succeed())
+			Ω(s.Append([]containerlog.Entry{
+				{RefID: 1, Instance: "web", Line: "fourth"},
+			})).Should(Succeed())
+
+			es := []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"fourth", "third"}))
+
+			es = []containerlog.Entry{}
+			Ω(s.Search(2, containerlog.Query{}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"other"}))
+
+			plan = containerlog.Unlimited
+			Ω(s.Append([]containerlog.Entry{
+				{RefID: 1, Instance: "web", Line: "fifth"},
+				{RefID: 1, Instance: "web", Line: "sixth"},
+			})).Should(Succeed())
+			es = []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{}, &es)).Should(Succeed())
+			Expect(es).To(HaveLen(4))
+		})
+
+		It("Should remove the lines older than the retention", func() {
+			now := time.Now().UTC()
+			Ω(s.Append([]containerlog.Entry{
+				{RefID: 1, Instance: "web", Line: "old", Time: now.AddDate(0, 0, -8)},
+				{RefID: 1, Instance: "web", Line: "new", Time: now.AddDate(0, 0, -6)},
+			})).Should(Succeed())
+
+			s.SetPlans(map[string]containerlog.Plan{"gold": {Retention: 30}})
+			plan = "gold"
+			Ω(s.Append([]containerlog.Entry{
+				{RefID: 2, Instance: "web", Line: "old", Time: now.AddDate(0, 0, -8)},
+			})).Should(Succeed())
+
+			Ω(s.Prune(now)).Should(Succeed())
+			es := []containerlog.Entry{}
+			Ω(s.Search(2, containerlog.Query{}, &es)).Should(Succeed())
+			Expect(es).To(HaveLen(1))
+
+			plan = containerlog.DefaultPlan
+			s.SetPlans(map[string]containerlog.Plan{containerlog.DefaultPlan: {Retention: 7}})
+			Ω(s.Prune(now)).Should(Succeed())
+			es = []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"new"}))
+		})
+	})
+
+	Describe("Collector", func() {
+		var (
+			dir     string
+			sources []containerlog.Source
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "containerlog")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			sources = []containerlog.Source{
+				{RefID: 1, Instance: "web", Stream: containerlog.Stdout, Path: filepath.Join(dir, "stdout.log")},
+				{RefID: 1, Instance: "web", Stream: containerlog.Stderr, Path: filepath.Join(dir, "stderr.log")},
+			}
+			s.SetPlans(nil)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		appendLog := func(name, data string) {
+			f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer f.Close()
+			_, err = f.WriteString(data)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		It("Should store and publish the new lines", func() {
+			appendLog("stdout.log", "before\n")
+			c := containerlog.NewCollector(s, func() ([]containerlog.Source, error) {
+				return sources, nil
+			}, log.NewNopLogger())
+			tail, cancel := c.Subscribe(1, "web")
+			defer cancel()
+			c.Collect(time.Now())
+
+			appendLog("stdout.log", "one\ntwo\nthr")
+			appendLog("stderr.log", "oops\n")
+			c.Collect(time.Now())
+
+			Expect(lines(<-tail)).To(Equal([]string{"one", "two", "oops"}))
+			es := []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{Stream: containerlog.Stdout}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"two", "one"}))
+
+			appendLog("stdout.log", "ee\n")
+			c.Collect(time.Now())
+			Expect(lines(<-tail)).To(Equal([]string{"three"}))
+		})
+
+		It("Should stop publishing once a subscription is cancelled", func() {
+			c := containerlog.NewCollector(s, func() ([]containerlog.Source, error) {
+				return sources, nil
+			}, log.NewNopLogger())
+			tail, cancel := c.Subscribe(1, "web")
+			c.Collect(time.Now())
+
+			cancel()
+			appendLog("stdout.log", "one\n")
+			c.Collect(time.Now())
+
+			_, open := <-tail
+			Expect(open).To(BeFalse())
+		})
+	})
+
+	Describe("Forwarder", func() {
+		var (
+			f    *containerlog.Forwarder
+			stop chan struct{}
+		)
+
+		BeforeEach(func() {
+			f = containerlog.NewForwarder(metrics.NewDiscardProvider(), log.NewNopLogger())
+			stop = make(chan struct{})
+		})
+
+		AfterEach(func() {
+			close(stop)
+		})
+
+		run := func() {
+			go f.Run(stop)
+		}
+
+		It("Should forward the appended lines of the users of a sink", func() {
+			all, one := &mockSink{}, &mockSink{}
+			f.Add(containerlog.SinkConfig{Name: "all"}, all)
+			f.Add(containerlog.SinkConfig{Name: "one", Users: []uint{2}}, one)
+			run()
+
+			fs := containerlog.Forwarding(s, f)
+			Ω(fs.Append([]containerlog.Entry{
+				{RefID: 1, Instance: "web", Line: "one"},
+				{RefID: 2, Instance: "web", Line: "two"},
+			})).Should(Succeed())
+
+			Eventually(all.lines).Should(Equal([]string{"one", "two"}))
+			Eventually(one.lines).Should(Equal([]string{"two"}))
+			es := []containerlog.Entry{}
+			Ω(s.Search(1, containerlog.Query{}, &es)).Should(Succeed())
+			Expect(lines(es)).To(Equal([]string{"one"}))
+		})
+
+		It("Should retry failed deliveries and drop the oldest lines exceeding the buffer", func() {
+			sink := &mockSink{fails: 1}
+			f.Add(containerlog.SinkConfig{Name: "flaky", Buffer: 2}, sink)
+			f.Forward([]containerlog.Entry{
+				{RefID: 1, Line: "one"},
+				{RefID: 1, Line: "two"},
+				{RefID: 1, Line: "three"},
+			})
+			run()
+
+			Eventually(sink.lines, 3*time.Second).Should(Equal([]string{"two", "three"}))
+		})
+	})
+
+	Describe("Sinks", func() {
+		entries := []containerlog.Entry{
+			{RefID: 1, Instance: "web", Stream: containerlog.Stdout, Line: "GET /", Time: time.Unix(10, 0)},
+			{RefID: 1, Instance: "web", Stream: containerlog.Stderr, Line: "Error", Time: time.Unix(11, 0)},
+		}
+
+		It("Should push the lines to Loki", func() {
+			var body struct {
+				Streams []struct {
+					Stream map[string]string `json:"stream"`
+					Values [][2]string       `json:"values"`
+				} `json:"streams"`
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.URL.Path).To(Equal("/loki/api/v1/push"))
+				Ω(json.NewDecoder(r.Body).Decode(&body)).Should(Succeed())
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+
+			sink, err := containerlog.NewSink(containerlog.SinkConfig{Type: containerlog.Loki, URL: server.URL}, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(sink.Send(entries)).Should(Succeed())
+
+			Expect(body.Streams).To(HaveLen(2))
+			Expect(body.Streams[1].Stream).To(Equal(map[string]string{"user": "1", "instance": "web", "stream": "stderr"}))
+			Expect(body.Streams[1].Values).To(Equal([][2]string{{"11000000000", "Error"}}))
+		})
+
+		It("Should index the lines in Elasticsearch", func() {
+			var (
+				index    string
+				rejected bool
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.URL.Path).To(Equal("/_bulk"))
+				b, err := ioutil.ReadAll(r.Body)
+				Ω(err).ShouldNot(HaveOccurred())
+				ls := strings.Split(strings.TrimSpace(string(b)), "\n")
+				Expect(ls).To(HaveLen(4))
+				index = ls[0]
+				json.NewEncoder(w).Encode(map[string]bool{"errors": rejected})
+			}))
+			defer server.Close()
+
+			sink, err := containerlog.NewSink(containerlog.SinkConfig{Type: containerlog.Elasticsearch, URL: server.URL, Index: "logs"}, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(sink.Send(entries)).Should(Succeed())
+			Expect(index).To(Equal(`{"index":{"_index":"logs"}}`))
+
+			rejected = true
+			Ω(sink.Send(entries)).ShouldNot(Succeed())
+		})
+
+		It("Should send the lines to syslog", func() {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer conn.Close()
+
+			sink, err := containerlog.NewSink(containerlog.SinkConfig{Type: containerlog.Syslog, URL: "udp://" + conn.LocalAddr().String()}, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(sink.Send(entries[1:])).Should(Succeed())
+
+			b := make([]byte, 1024)
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := conn.ReadFrom(b)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(string(b[:n])).To(HavePrefix("<11>1 1970-01-01T00:00:11Z "))
+			Expect(string(b[:n])).To(HaveSuffix(" web 1 stderr - Error"))
+		})
+
+		It("Should return an error for unknown types", func() {
+			_, err := containerlog.NewSink(containerlog.SinkConfig{Type: "kafka"}, nil)
+			Expect(err).To(Equal(containerlog.ErrSinkType))
+		})
+	})
+})
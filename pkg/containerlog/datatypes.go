@@ -0,0 +1,51 @@
+package containerlog
+
+import (
+	"time"
+)
+
+// Plan limits the logs kept for the users of a plan
+type Plan struct {
+	// Retention is the number of days lines are kept, 0 keeps them until the size is exceeded
+	Retention uint `yaml:"retention"`
+	// MaxSize is the number of bytes of lines kept per user, the oldest lines are removed first.
+	// 0 does not limit the size.
+	MaxSize uint64 `yaml:"maxSize"`
+}
+
+// Entry is a line an instance wrote to its standard output or error
+type Entry struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Instance is the name of the instance which wrote the line
+	Instance string
+	// Stream is Stdout or Stderr
+	Stream string
+	Line   string
+	// Time is when the line was collected
+	Time time.Time
+}
+
+// TableName sets Entry's database table name
+func (Entry) TableName() string {
+	return "container_logs"
+}
+
+// Query selects the lines of a user, zero values match every line
+type Query struct {
+	Instance string
+	Stream   string
+	// Text are words every line has to contain, regardless of their case
+	Text string
+	// From and To select the lines collected in [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// Source is the log file of a stream of an instance
+type Source struct {
+	RefID    uint
+	Instance string
+	Stream   string
+	Path     string
+}
@@ -0,0 +1,94 @@
+package containerlog
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+)
+
+// Endpoints is a struct which collects all endpoints for the container log service
+type Endpoints struct {
+	SearchEndpoint endpoint.Endpoint
+	TailEndpoint   endpoint.Endpoint
+}
+
+// SearchRequest is the request struct for the SearchEndpoint
+type SearchRequest struct {
+	RefID uint `bart:"ref"`
+	Query Query
+	Page  paging.Request
+}
+
+// SearchResponse is the response struct for the SearchEndpoint
+type SearchResponse struct {
+	Entries []Entry
+	Error   error
+	Page    paging.Response
+}
+
+// MakeSearchEndpoint creates a gokit endpoint which invokes Search
+func MakeSearchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SearchRequest)
+		entries := []Entry{}
+		err := s.Search(req.RefID, req.Query, &entries)
+		if err != nil {
+			return SearchResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&entries, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return SearchResponse{
+			Entries: entries,
+			Page:    page,
+		}, nil
+	}
+}
+
+// TailRequest is the request struct for the TailEndpoint
+type TailRequest struct {
+	RefID    uint   `bart:"ref"`
+	Instance string `validate:"required,name"`
+}
+
+// TailResponse is the response struct for the TailEndpoint
+type TailResponse struct {
+	Entries []Entry
+	Error   error
+}
+
+// MakeTailEndpoint creates a gokit endpoint which streams the new lines a Collector
+// reads for an instance, it can only be used with the websocket transport
+func MakeTailEndpoint(c *Collector) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(TailRequest)
+		entries, cancel := c.Subscribe(req.RefID, req.Instance)
+
+		responses := make(chan interface{})
+		stop := make(chan struct{})
+		go func() {
+			defer close(responses)
+			for e := range entries {
+				select {
+				case responses <- TailResponse{Entries: e}:
+				case <-stop:
+					return
+				}
+			}
+		}()
+
+		return &ws.Stream{
+			C: responses,
+			Stop: func() {
+				close(stop)
+				cancel()
+			},
+		}, nil
+	}
+}
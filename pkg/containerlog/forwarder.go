@@ -0,0 +1,214 @@
+package containerlog
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/metrics"
+	kmetrics "github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+)
+
+const (
+	// DefaultBuffer is the number of lines kept per sink if its configuration has no buffer
+	DefaultBuffer = 10000
+	// BatchSize is the maximum number of lines sent to a sink at once
+	BatchSize = 500
+
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+type output struct {
+	name    string
+	sink    Sink
+	users   map[uint]bool
+	buffer  int
+	entries []Entry
+	wake    chan struct{}
+	mtx     *sync.Mutex
+}
+
+// Forwarder ships the collected lines to sinks. Every sink has a buffer and a worker of its own,
+// so an unavailable sink neither blocks the collection nor the other sinks
+type Forwarder struct {
+	outputs   []*output
+	logger    log.Logger
+	forwarded metrics.Counter
+	failed    metrics.Counter
+	dropped   metrics.Counter
+	pending   metrics.Gauge
+}
+
+// Add forwards the lines matching the users of c to s, it has to be called before Run
+func (f *Forwarder) Add(c SinkConfig, s Sink) {
+	o := &output{
+		name:   c.Name,
+		sink:   s,
+		users:  make(map[uint]bool),
+		buffer: c.Buffer,
+		wake:   make(chan struct{}, 1),
+		mtx:    &sync.Mutex{},
+	}
+	if o.buffer <= 0 {
+		o.buffer = DefaultBuffer
+	}
+	for _, u := range c.Users {
+		o.users[u] = true
+	}
+	f.outputs = append(f.outputs, o)
+}
+
+// push appends entries to the buffer of o, the oldest lines exceeding the buffer are dropped
+func (f *Forwarder) push(o *output, entries []Entry, front bool) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	if front {
+		o.entries = append(entries, o.entries...)
+	} else {
+		o.entries = append(o.entries, entries...)
+	}
+
+	if n := len(o.entries) - o.buffer; n > 0 {
+		o.entries = o.entries[n:]
+		f.dropped.With("sink", o.name).Add(float64(n))
+	}
+	f.pending.With("sink", o.name).Set(float64(len(o.entries)))
+}
+
+// take removes the next batch from the buffer of o
+func (f *Forwarder) take(o *output) []Entry {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+
+	n := len(o.entries)
+	if n > BatchSize {
+		n = BatchSize
+	}
+	batch := make([]Entry, n)
+	copy(batch, o.entries)
+	o.entries = o.entries[n:]
+	f.pending.With("sink", o.name).Set(float64(len(o.entries)))
+	return batch
+}
+
+// Forward queues the lines for the sinks, it never blocks
+func (f *Forwarder) Forward(entries []Entry) {
+	for _, o := range f.outputs {
+		es := []Entry{}
+		for _, e := range entries {
+			if len(o.users) == 0 || o.users[e.RefID] {
+				es = append(es, e)
+			}
+		}
+		if len(es) == 0 {
+			continue
+		}
+
+		f.push(o, es, false)
+		select {
+		case o.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// flush sends the buffer of o in batches, a batch which could not be sent is put back
+func (f *Forwarder) flush(o *output) error {
+	for {
+		batch := f.take(o)
+		if len(batch) == 0 {
+			return nil
+		}
+
+		err := o.sink.Send(batch)
+		if err != nil {
+			f.failed.With("sink", o.name).Add(1)
+			f.push(o, batch, true)
+			return err
+		}
+		f.forwarded.With("sink", o.name).Add(float64(len(batch)))
+	}
+}
+
+// work sends the lines queued for o until stop is closed, failed sends are retried with a growing backoff
+func (f *Forwarder) work(o *output, stop <-chan struct{}) {
+	backoff := time.Duration(0)
+	for {
+		var (
+			wake  <-chan struct{}
+			retry <-chan time.Time
+		)
+		if backoff == 0 {
+			wake = o.wake
+		} else {
+			retry = time.After(backoff)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-wake:
+		case <-retry:
+		}
+
+		err := f.flush(o)
+		if err == nil {
+			backoff = 0
+			continue
+		}
+
+		backoff *= 2
+		if backoff < minBackoff {
+			backoff = minBackoff
+		}
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+		f.logger.Log("sink", o.name, "err", err, "retry", backoff)
+	}
+}
+
+// Run sends the forwarded lines to the sinks until stop is closed
+func (f *Forwarder) Run(stop <-chan struct{}) {
+	wg := &sync.WaitGroup{}
+	for _, o := range f.outputs {
+		wg.Add(1)
+		go func(o *output) {
+			defer wg.Done()
+			f.work(o, stop)
+		}(o)
+	}
+	wg.Wait()
+}
+
+// NewForwarder returns a Forwarder without sinks whose metrics are created by p
+func NewForwarder(p kmetrics.Provider, logger log.Logger) *Forwarder {
+	return &Forwarder{
+		logger:    logger,
+		forwarded: p.NewCounter("log_lines_forwarded_total", "Number of container log lines delivered to the sinks.", "sink"),
+		failed:    p.NewCounter("log_forward_errors_total", "Number of failed deliveries of container log lines.", "sink"),
+		dropped:   p.NewCounter("log_lines_dropped_total", "Number of container log lines dropped because the buffer of a sink was full.", "sink"),
+		pending:   p.NewGauge("log_lines_pending", "Number of container log lines waiting for delivery.", "sink"),
+	}
+}
+
+type forwardingService struct {
+	Service
+	f *Forwarder
+}
+
+func (s *forwardingService) Append(entries []Entry) error {
+	err := s.Service.Append(entries)
+	s.f.Forward(entries)
+	return err
+}
+
+// Forwarding returns s forwarding the appended lines to f, they are forwarded even if they could not be stored
+func Forwarding(s Service, f *Forwarder) Service {
+	return &forwardingService{
+		Service: s,
+		f:       f,
+	}
+}
@@ -0,0 +1,18 @@
+package containerlog
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+// PruneJob is the type of the job removing the lines which are older than the retention of their users
+const PruneJob = "logs.prune"
+
+// PruneHandler returns the handler of PruneJob
+func PruneHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		return s.Prune(time.Now())
+	}
+}
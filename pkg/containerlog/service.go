@@ -0,0 +1,311 @@
+// Package containerlog collects the lines the instances of the users write to their standard output
+// and error, keeps them within the retention and size of the plan of the user and searches them
+package containerlog
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Streams of an instance
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+)
+
+// Plans of users which are not limited by the configured plans
+const (
+	// DefaultPlan is used for users whose plan is not configured
+	DefaultPlan = "default"
+	// Unlimited is the plan of users who are never limited, like admins
+	Unlimited = "unlimited"
+)
+
+// ErrTimeRange occurs if the end of a query is before its start
+var ErrTimeRange = errors.New("the end of the time range is before its start")
+
+// Service ContainerLogService
+type Service interface {
+	// SetPlans replaces the configured plans, they are applied on the next Append and Prune
+	SetPlans(plans map[string]Plan)
+
+	// Append stores the lines of the instances and removes the oldest lines of the users exceeding their size
+	Append(entries []Entry) error
+
+	// Search returns the lines of a user matching q, the latest first
+	Search(refID uint, q Query, e *[]Entry) error
+
+	// Prune removes the lines which are older than the retention of their users
+	Prune(now time.Time) error
+}
+
+// PlanFunc returns the name of the plan of a user
+type PlanFunc func(refID uint) (string, error)
+
+// Options configure the stored lines
+type Options struct {
+	// MaxLine is the number of bytes kept per line, the start of longer lines is kept
+	MaxLine int
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Sum(interface{}, string, *int64, ...interface{}) error
+	Pluck(interface{}, string, interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	planOf  PlanFunc
+	plans   map[string]Plan
+	options Options
+	mtx     *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Entry{})
+}
+
+func (s *service) SetPlans(plans map[string]Plan) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.plans = plans
+}
+
+// plan returns the plan of a user, users are not limited if neither their plan nor the default plan is configured
+func (s *service) plan(refID uint) (Plan, error) {
+	name, err := s.planOf(refID)
+	if err != nil {
+		return Plan{}, err
+	}
+	if name == Unlimited {
+		return Plan{}, nil
+	}
+
+	p, ok := s.plans[name]
+	if !ok {
+		p = s.plans[DefaultPlan]
+	}
+	return p, nil
+}
+
+// batch is the number of lines read at once while looking for the lines to remove
+const batch = 256
+
+// cut returns the start of line within the maximum length, it does not end within a character
+func (s *service) cut(line string) string {
+	if len(line) <= s.options.MaxLine {
+		return line
+	}
+
+	n := s.options.MaxLine
+	for n > 0 && !utf8.RuneStart(line[n]) {
+		n--
+	}
+	return line[:n]
+}
+
+func (s *service) Append(entries []Entry) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	users := []uint{}
+	seen := make(map[uint]bool)
+	for i := range entries {
+		e := entries[i]
+		e.ID = 0
+		e.Line = s.cut(e.Line)
+		if e.Time.IsZero() {
+			e.Time = time.Now().UTC()
+		}
+
+		err := s.db.Create(&e)
+		if err != nil {
+			return err
+		}
+
+		if !seen[e.RefID] {
+			seen[e.RefID] = true
+			users = append(users, e.RefID)
+		}
+	}
+
+	for _, refID := range users {
+		err := s.limit(refID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// limit removes the oldest lines of a user until they fit the size of the plan
+func (s *service) limit(refID uint) error {
+	p, err := s.plan(refID)
+	if err != nil {
+		return err
+	}
+	if p.MaxSize == 0 {
+		return nil
+	}
+
+	size := int64(0)
+	err = s.db.Sum(&Entry{}, "octet_length(line)", &size, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+	if uint64(size) <= p.MaxSize {
+		return nil
+	}
+
+	// the newest line to remove, every older line of the user is removed as well
+	excess := uint64(size) - p.MaxSize
+	last := uint(0)
+	for excess > 0 {
+		es := []Entry{}
+		err = s.db.FindOrdered(&es, "id", batch, "ref_id = ? AND id > ?", refID, last)
+		if err != nil {
+			return err
+		}
+		if len(es) == 0 {
+			break
+		}
+
+		for _, e := range es {
+			last = e.ID
+			if uint64(len(e.Line)) >= excess {
+				excess = 0
+				break
+			}
+			excess -= uint64(len(e.Line))
+		}
+	}
+
+	return s.db.Delete(&Entry{}, "ref_id = ? AND id <= ?", refID, last)
+}
+
+// matches reports whether line contains every word of text, regardless of their case
+func matches(line, text string) bool {
+	line = strings.ToLower(line)
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		if !strings.Contains(line, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *service) Search(refID uint, q Query, e *[]Entry) error {
+	if !q.To.IsZero() && q.To.Before(q.From) {
+		return ErrTimeRange
+	}
+
+	conditions := []string{"ref_id = ?"}
+	args := []interface{}{refID}
+	if q.Instance != "" {
+		conditions = append(conditions, "instance = ?")
+		args = append(args, q.Instance)
+	}
+	if q.Stream != "" {
+		conditions = append(conditions, "stream = ?")
+		args = append(args, q.Stream)
+	}
+	if !q.From.IsZero() {
+		conditions = append(conditions, "\"time\" >= ?")
+		args = append(args, q.From)
+	}
+	if !q.To.IsZero() {
+		conditions = append(conditions, "\"time\" < ?")
+		args = append(args, q.To)
+	}
+	where := append([]interface{}{strings.Join(conditions, " AND ")}, args...)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	es := []Entry{}
+	err := s.db.FindOrdered(&es, "id DESC", 0, where...)
+	if err != nil {
+		return err
+	}
+
+	for _, o := range es {
+		if matches(o.Line, q.Text) {
+			*e = append(*e, o)
+		}
+	}
+	return nil
+}
+
+func (s *service) Prune(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	users := []uint{}
+	err := s.db.Pluck(&Entry{}, "DISTINCT ref_id", &users)
+	if err != nil {
+		return err
+	}
+
+	// the users are grouped by their retention, the lines of each group are removed at once
+	retention := make(map[uint][]uint)
+	for _, refID := range users {
+		p, err := s.plan(refID)
+		if err != nil {
+			return err
+		}
+		if p.Retention != 0 {
+			retention[p.Retention] = append(retention[p.Retention], refID)
+		}
+	}
+
+	for days, refIDs := range retention {
+		err = s.db.Delete(&Entry{}, "ref_id IN (?) AND \"time\" < ?", refIDs, now.AddDate(0, 0, -int(days)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewService creates a ContainerLogService, the plans are looked up by planOf and users whose plan is
+// not configured get the DefaultPlan
+func NewService(db dbAdapter, planOf PlanFunc, plans map[string]Plan, o Options) (Service, error) {
+	if o.MaxLine == 0 {
+		o.MaxLine = 16 * 1024
+	}
+
+	s := &service{
+		db:      db,
+		planOf:  planOf,
+		plans:   plans,
+		options: o,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,291 @@
+package containerlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Types of sinks
+const (
+	Syslog        = "syslog"
+	Loki          = "loki"
+	Elasticsearch = "elasticsearch"
+)
+
+// DefaultIndex is the Elasticsearch index lines are written to if a sink has none
+const DefaultIndex = "kontainerooo-logs"
+
+// ErrSinkType occurs if a sink has an unknown type
+var ErrSinkType = errors.New("unknown sink type")
+
+// SinkConfig configures a system the collected lines are forwarded to
+type SinkConfig struct {
+	Name string `yaml:"name"`
+	// Type is syslog, loki or elasticsearch
+	Type string `yaml:"type"`
+	// URL is udp://host:port or tcp://host:port for syslog and the base URL of Loki or Elasticsearch
+	URL string `yaml:"url"`
+	// Username and Password authenticate the requests to Loki and Elasticsearch
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// Index is the Elasticsearch index, DefaultIndex is used if it is empty
+	Index string `yaml:"index"`
+	// Users are the IDs of the users whose lines are forwarded, the lines of every user are forwarded if it is empty
+	Users []uint `yaml:"users"`
+	// Buffer is the number of lines kept while the sink is unavailable, the oldest lines are dropped first
+	Buffer int `yaml:"buffer"`
+}
+
+// Sink delivers lines to an external log system
+type Sink interface {
+	Send(entries []Entry) error
+}
+
+type syslogSink struct {
+	network  string
+	addr     string
+	hostname string
+	timeout  time.Duration
+	conn     net.Conn
+}
+
+// format returns e as a RFC 5424 message of the facility user, stderr lines have the severity error
+func (s *syslogSink) format(e Entry) string {
+	pri := 1*8 + 6
+	if e.Stream == Stderr {
+		pri = 1*8 + 3
+	}
+
+	app := e.Instance
+	if app == "" {
+		app = "-"
+	}
+	if len(app) > 48 {
+		app = app[:48]
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s - %s", pri, e.Time.UTC().Format(time.RFC3339Nano), s.hostname, app, e.RefID, e.Stream, e.Line)
+}
+
+func (s *syslogSink) Send(entries []Entry) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.addr, s.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	for _, e := range entries {
+		msg := s.format(e)
+		if s.network == "tcp" {
+			// messages are framed by octet counting over TCP (RFC 6587)
+			msg = fmt.Sprintf("%d %s", len(msg), msg)
+		}
+
+		s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		_, err := io.WriteString(s.conn, msg)
+		if err != nil {
+			// the connection is dialed again on the next send
+			s.conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+	return nil
+}
+
+type httpSink struct {
+	client   *http.Client
+	url      string
+	username string
+	password string
+	body     func(entries []Entry) (contentType string, body []byte, err error)
+	check    func(body []byte) error
+}
+
+func (s *httpSink) Send(entries []Entry) error {
+	contentType, body, err := s.body(entries)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.username != "" {
+		req.SetBasicAuth(s.username, s.password)
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	if s.check != nil {
+		return s.check(b)
+	}
+	return nil
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// lokiBody groups the lines by user, instance and stream, which are the labels of the Loki streams
+func lokiBody(entries []Entry) (string, []byte, error) {
+	streams := []*lokiStream{}
+	index := make(map[string]*lokiStream)
+	for _, e := range entries {
+		key := fmt.Sprintf("%d/%s/%s", e.RefID, e.Instance, e.Stream)
+		st, ok := index[key]
+		if !ok {
+			st = &lokiStream{
+				Stream: map[string]string{
+					"user":     strconv.FormatUint(uint64(e.RefID), 10),
+					"instance": e.Instance,
+					"stream":   e.Stream,
+				},
+			}
+			index[key] = st
+			streams = append(streams, st)
+		}
+		st.Values = append(st.Values, [2]string{strconv.FormatInt(e.Time.UnixNano(), 10), e.Line})
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"streams": streams,
+	})
+	return "application/json", b, err
+}
+
+type elasticsearchDocument struct {
+	Timestamp time.Time `json:"@timestamp"`
+	User      uint      `json:"user"`
+	Instance  string    `json:"instance"`
+	Stream    string    `json:"stream"`
+	Message   string    `json:"message"`
+}
+
+// elasticsearchBody returns a bulk request indexing every line as a document of index
+func elasticsearchBody(index string) func(entries []Entry) (string, []byte, error) {
+	return func(entries []Entry) (string, []byte, error) {
+		buf := &bytes.Buffer{}
+		enc := json.NewEncoder(buf)
+		for _, e := range entries {
+			err := enc.Encode(map[string]interface{}{
+				"index": map[string]string{"_index": index},
+			})
+			if err != nil {
+				return "", nil, err
+			}
+
+			err = enc.Encode(elasticsearchDocument{
+				Timestamp: e.Time.UTC(),
+				User:      e.RefID,
+				Instance:  e.Instance,
+				Stream:    e.Stream,
+				Message:   e.Line,
+			})
+			if err != nil {
+				return "", nil, err
+			}
+		}
+		return "application/x-ndjson", buf.Bytes(), nil
+	}
+}
+
+// elasticsearchCheck returns an error if a document of a bulk request was not indexed
+func elasticsearchCheck(body []byte) error {
+	res := struct {
+		Errors bool `json:"errors"`
+	}{}
+	err := json.Unmarshal(body, &res)
+	if err != nil {
+		return err
+	}
+	if res.Errors {
+		return errors.New("elasticsearch rejected documents of the bulk request")
+	}
+	return nil
+}
+
+// NewSink returns the Sink of a configuration, client is used for the HTTP based sinks
+func NewSink(c SinkConfig, client *http.Client) (Sink, error) {
+	if client == nil {
+		client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.Type {
+	case Syslog:
+		if u.Scheme != "udp" && u.Scheme != "tcp" {
+			return nil, fmt.Errorf("syslog sink %s has to use udp or tcp", c.Name)
+		}
+
+		hostname, err := os.Hostname()
+		if err != nil || hostname == "" {
+			hostname = "-"
+		}
+		timeout := client.Timeout
+		if timeout == 0 {
+			timeout = 10 * time.Second
+		}
+		return &syslogSink{
+			network:  u.Scheme,
+			addr:     u.Host,
+			hostname: hostname,
+			timeout:  timeout,
+		}, nil
+	case Loki:
+		return &httpSink{
+			client:   client,
+			url:      strings.TrimRight(c.URL, "/") + "/loki/api/v1/push",
+			username: c.Username,
+			password: c.Password,
+			body:     lokiBody,
+		}, nil
+	case Elasticsearch:
+		index := c.Index
+		if index == "" {
+			index = DefaultIndex
+		}
+		return &httpSink{
+			client:   client,
+			url:      strings.TrimRight(c.URL, "/") + "/_bulk",
+			username: c.Username,
+			password: c.Password,
+			body:     elasticsearchBody(index),
+			check:    elasticsearchCheck,
+		}, nil
+	}
+	return nil, ErrSinkType
+}
@@ -0,0 +1,108 @@
+package containerlog
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC ContainerLogServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.ContainerLogServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		search: grpctransport.NewServer(
+			endpoints.SearchEndpoint,
+			DecodeGRPCSearchRequest,
+			EncodeGRPCSearchResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	search grpctransport.Handler
+}
+
+func (s *grpcServer) Search(ctx oldcontext.Context, req *pb.SearchRequest) (*pb.SearchResponse, error) {
+	_, res, err := s.search.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SearchResponse), nil
+}
+
+// ConvertEntries converts Entries to their protobuf representation
+func ConvertEntries(es []Entry) []*pb.Entry {
+	entries := make([]*pb.Entry, len(es))
+	for i, e := range es {
+		entries[i] = &pb.Entry{
+			ID:       uint32(e.ID),
+			Instance: e.Instance,
+			Stream:   e.Stream,
+			Line:     e.Line,
+			Time:     e.Time.Unix(),
+		}
+	}
+	return entries
+}
+
+// ConvertPBEntries converts protobuf Entries to Entries
+func ConvertPBEntries(es []*pb.Entry) []Entry {
+	entries := make([]Entry, len(es))
+	for i, e := range es {
+		entries[i] = Entry{
+			ID:       uint(e.ID),
+			Instance: e.Instance,
+			Stream:   e.Stream,
+			Line:     e.Line,
+			Time:     time.Unix(e.Time, 0).UTC(),
+		}
+	}
+	return entries
+}
+
+// DecodeGRPCSearchRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Search request to a messages/containerlog.proto-domain search request.
+func DecodeGRPCSearchRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SearchRequest)
+	q := Query{
+		Instance: req.Instance,
+		Stream:   req.Stream,
+		Text:     req.Text,
+	}
+	// an unset bound does not limit the time range
+	if req.From != 0 {
+		q.From = time.Unix(req.From, 0)
+	}
+	if req.To != 0 {
+		q.To = time.Unix(req.To, 0)
+	}
+
+	return SearchRequest{
+		RefID: uint(req.RefID),
+		Query: q,
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCSearchResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/containerlog.proto-domain search response to a gRPC Search response.
+func EncodeGRPCSearchResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SearchResponse)
+	gRPCRes := &pb.SearchResponse{
+		Entries:  ConvertEntries(res.Entries),
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
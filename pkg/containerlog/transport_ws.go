@@ -0,0 +1,72 @@
+package containerlog
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/containerlog/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+)
+
+// MakeWebsocketService makes a set of container log Endpoints available as a websocket Service
+func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
+	service, _ := ws.NewServiceDescription("containerLogService", ws.ProtoIDFromString("LOG"))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"Search",
+		ws.ProtoIDFromString("SRC"),
+		endpoints.SearchEndpoint,
+		DecodeWSSearchRequest,
+		EncodeGRPCSearchResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"Tail",
+		ws.ProtoIDFromString("TAL"),
+		endpoints.TailEndpoint,
+		DecodeWSTailRequest,
+		EncodeWSTailResponse,
+	))
+
+	return service
+}
+
+// DecodeWSSearchRequest is a websocket.DecodeRequestFunc that converts a
+// WS Search request to a messages/containerlog.proto-domain search request.
+func DecodeWSSearchRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.SearchRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCSearchRequest(ctx, req)
+}
+
+// DecodeWSTailRequest is a websocket.DecodeRequestFunc that converts a
+// WS Tail request to a messages/containerlog.proto-domain tail request.
+func DecodeWSTailRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.TailRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return TailRequest{
+		RefID:    uint(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// EncodeWSTailResponse is a websocket.EncodeResponseFunc that converts a
+// messages/containerlog.proto-domain tail response to a WS Tail response.
+func EncodeWSTailResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(TailResponse)
+	wsRes := &pb.TailResponse{
+		Entries: ConvertEntries(res.Entries),
+	}
+	if res.Error != nil {
+		wsRes.Error = res.Error.Error()
+	}
+	return wsRes, nil
+}
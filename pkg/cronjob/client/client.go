@@ -0,0 +1,208 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/cronjob"
+	"github.com/kontainerooo/kontainer.ooo/pkg/cronjob/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *cronjob.Endpoints {
+
+	var CreateJobEndpoint endpoint.Endpoint
+	{
+		CreateJobEndpoint = grpctransport.NewClient(
+			conn,
+			"cronjob.CronJobService",
+			"CreateJob",
+			EncodeGRPCCreateJobRequest,
+			DecodeGRPCCreateJobResponse,
+			pb.CreateJobResponse{},
+		).Endpoint()
+	}
+
+	var RemoveJobEndpoint endpoint.Endpoint
+	{
+		RemoveJobEndpoint = grpctransport.NewClient(
+			conn,
+			"cronjob.CronJobService",
+			"RemoveJob",
+			EncodeGRPCRemoveJobRequest,
+			DecodeGRPCRemoveJobResponse,
+			pb.RemoveJobResponse{},
+		).Endpoint()
+	}
+
+	var JobsEndpoint endpoint.Endpoint
+	{
+		JobsEndpoint = grpctransport.NewClient(
+			conn,
+			"cronjob.CronJobService",
+			"Jobs",
+			EncodeGRPCJobsRequest,
+			DecodeGRPCJobsResponse,
+			pb.JobsResponse{},
+		).Endpoint()
+	}
+
+	var RunsEndpoint endpoint.Endpoint
+	{
+		RunsEndpoint = grpctransport.NewClient(
+			conn,
+			"cronjob.CronJobService",
+			"Runs",
+			EncodeGRPCRunsRequest,
+			DecodeGRPCRunsResponse,
+			pb.RunsResponse{},
+		).Endpoint()
+	}
+
+	var RunJobEndpoint endpoint.Endpoint
+	{
+		RunJobEndpoint = grpctransport.NewClient(
+			conn,
+			"cronjob.CronJobService",
+			"RunJob",
+			EncodeGRPCRunJobRequest,
+			DecodeGRPCRunJobResponse,
+			pb.RunJobResponse{},
+		).Endpoint()
+	}
+
+	return &cronjob.Endpoints{
+		CreateJobEndpoint: CreateJobEndpoint,
+		RemoveJobEndpoint: RemoveJobEndpoint,
+		JobsEndpoint:      JobsEndpoint,
+		RunsEndpoint:      RunsEndpoint,
+		RunJobEndpoint:    RunJobEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreateJobRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain createjob request to a gRPC CreateJob request.
+func EncodeGRPCCreateJobRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*cronjob.CreateJobRequest)
+	return &pb.CreateJobRequest{
+		RefID:    uint32(req.RefID),
+		Name:     req.Job.Name,
+		Instance: req.Job.Instance,
+		Command:  req.Job.Command,
+		Cron:     req.Job.Cron,
+		Notify:   req.Job.Notify,
+	}, nil
+}
+
+// DecodeGRPCCreateJobResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateJob response to a messages/cronjob.proto-domain createjob response.
+func DecodeGRPCCreateJobResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateJobResponse)
+	return &cronjob.CreateJobResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveJobRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain removejob request to a gRPC RemoveJob request.
+func EncodeGRPCRemoveJobRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*cronjob.RemoveJobRequest)
+	return &pb.RemoveJobRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveJobResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveJob response to a messages/cronjob.proto-domain removejob response.
+func DecodeGRPCRemoveJobResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveJobResponse)
+	return &cronjob.RemoveJobResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCJobsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain jobs request to a gRPC Jobs request.
+func EncodeGRPCJobsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*cronjob.JobsRequest)
+	return &pb.JobsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCJobsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Jobs response to a messages/cronjob.proto-domain jobs response.
+func DecodeGRPCJobsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.JobsResponse)
+	jobs := make([]cronjob.Job, len(response.Jobs))
+	for i, j := range response.Jobs {
+		jobs[i] = cronjob.ConvertPBJob(j)
+	}
+
+	return &cronjob.JobsResponse{
+		Jobs:  jobs,
+		Error: getError(response.Error),
+		Page:  paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCRunsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain runs request to a gRPC Runs request.
+func EncodeGRPCRunsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*cronjob.RunsRequest)
+	return &pb.RunsRequest{
+		RefID: uint32(req.RefID),
+		JobID: uint32(req.JobID),
+	}, nil
+}
+
+// DecodeGRPCRunsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Runs response to a messages/cronjob.proto-domain runs response.
+func DecodeGRPCRunsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RunsResponse)
+	runs := make([]cronjob.Run, len(response.Runs))
+	for i, r := range response.Runs {
+		runs[i] = cronjob.ConvertPBRun(r)
+	}
+
+	return &cronjob.RunsResponse{
+		Runs:  runs,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRunJobRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain runjob request to a gRPC RunJob request.
+func EncodeGRPCRunJobRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*cronjob.RunJobRequest)
+	return &pb.RunJobRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRunJobResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RunJob response to a messages/cronjob.proto-domain runjob response.
+func DecodeGRPCRunJobResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RunJobResponse)
+	return &cronjob.RunJobResponse{
+		BackgroundJobID: uint(response.BackgroundJobID),
+		Error:           getError(response.Error),
+	}, nil
+}
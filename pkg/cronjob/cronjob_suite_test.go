@@ -0,0 +1,13 @@
+package cronjob_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestCronJob(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "CronJob Suite")
+}
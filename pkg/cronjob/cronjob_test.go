@@ -0,0 +1,317 @@
+package cronjob_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/cronjob"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type execution struct {
+	id  string
+	cmd string
+}
+
+type mockContainers struct {
+	ids        map[string]string
+	output     string
+	err        error
+	executions []execution
+}
+
+func (c *mockContainers) IDForName(ctx context.Context, refID uint, name string) (string, error) {
+	id, ok := c.ids[name]
+	if !ok {
+		return "", errors.New("container does not exist")
+	}
+	return id, nil
+}
+
+func (c *mockContainers) Execute(ctx context.Context, refID uint, id string, cmd string, env map[string]string) (string, error) {
+	c.executions = append(c.executions, execution{id: id, cmd: cmd})
+	return c.output, c.err
+}
+
+type job struct {
+	typ     string
+	payload string
+}
+
+type mockQueue struct {
+	jobs []job
+}
+
+func (q *mockQueue) Enqueue(typ string, payload interface{}) (uint, error) {
+	b, _ := json.Marshal(payload)
+	q.jobs = append(q.jobs, job{typ: typ, payload: string(b)})
+	return uint(len(q.jobs)), nil
+}
+
+type notification struct {
+	refID    uint
+	template string
+	data     mail.CronData
+}
+
+type mockMails struct {
+	sent []notification
+}
+
+func (m *mockMails) Notify(refID uint, template string, data interface{}) (uint, error) {
+	m.sent = append(m.sent, notification{refID: refID, template: template, data: data.(mail.CronData)})
+	return uint(len(m.sent)), nil
+}
+
+type recorder struct {
+	mtx    sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) handle(e events.Event) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) count() int {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return len(r.events)
+}
+
+var _ = Describe("CronJob", func() {
+	var (
+		refID      = uint(1)
+		plan       string
+		containers *mockContainers
+		queue      *mockQueue
+		s          cronjob.Service
+	)
+
+	planOf := func(refID uint) (string, error) {
+		return plan, nil
+	}
+
+	BeforeEach(func() {
+		plan = cronjob.DefaultPlan
+		containers = &mockContainers{ids: map[string]string{"web": "abc"}, output: "done\n"}
+		queue = &mockQueue{}
+
+		var err error
+		s, err = cronjob.NewService(testutils.NewMockDB(), containers, planOf, queue, map[string]cronjob.Plan{
+			cronjob.DefaultPlan: {MaxJobs: 2},
+		}, cronjob.Options{
+			History:   2,
+			MaxOutput: 8,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("Create Service", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := cronjob.NewService(db, containers, planOf, queue, nil, cronjob.Options{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("CreateJob", func() {
+		It("Should create a job", func() {
+			j := &cronjob.Job{Name: "cleanup", Instance: "web", Command: "rm -rf /tmp/cache", Cron: "*/15 * * * *"}
+			Ω(s.CreateJob(refID, j)).Should(Succeed())
+			Expect(j.ID).ToNot(BeZero())
+			Expect(j.LastRun).To(Equal(j.CreatedAt))
+
+			js := []cronjob.Job{}
+			Ω(s.Jobs(refID, &js)).Should(Succeed())
+			Expect(js).To(HaveLen(1))
+			Expect(js[0].Command).To(Equal("rm -rf /tmp/cache"))
+
+			js = []cronjob.Job{}
+			Ω(s.Jobs(2, &js)).Should(Succeed())
+			Expect(js).To(BeEmpty())
+		})
+
+		It("Should check the job", func() {
+			err := s.CreateJob(refID, &cronjob.Job{Name: "cleanup", Instance: "web", Command: "true", Cron: "* * *"})
+			Expect(err).To(HaveOccurred())
+
+			err = s.CreateJob(refID, &cronjob.Job{Name: "cleanup", Instance: "db", Command: "true", Cron: "@hourly"})
+			Expect(err).To(Equal(cronjob.ErrInstanceNotExist))
+
+			Ω(s.CreateJob(refID, &cronjob.Job{Name: "cleanup", Instance: "web", Command: "true", Cron: "@hourly"})).Should(Succeed())
+			err = s.CreateJob(refID, &cronjob.Job{Name: "cleanup", Instance: "web", Command: "true", Cron: "@daily"})
+			Expect(err).To(Equal(cronjob.ErrJobExists))
+		})
+
+		It("Should limit the jobs by the plan of the user", func() {
+			for _, name := range []string{"a", "b"} {
+				Ω(s.CreateJob(refID, &cronjob.Job{Name: name, Instance: "web", Command: "true", Cron: "@hourly"})).Should(Succeed())
+			}
+			err := s.CreateJob(refID, &cronjob.Job{Name: "c", Instance: "web", Command: "true", Cron: "@hourly"})
+			Expect(err).To(Equal(cronjob.ErrLimit))
+
+			plan = cronjob.Unlimited
+			Ω(s.CreateJob(refID, &cronjob.Job{Name: "c", Instance: "web", Command: "true", Cron: "@hourly"})).Should(Succeed())
+
+			plan = "1"
+			s.SetPlans(map[string]cronjob.Plan{"1": {MaxJobs: 4}})
+			Ω(s.CreateJob(refID, &cronjob.Job{Name: "d", Instance: "web", Command: "true", Cron: "@hourly"})).Should(Succeed())
+			err = s.CreateJob(refID, &cronjob.Job{Name: "e", Instance: "web", Command: "true", Cron: "@hourly"})
+			Expect(err).To(Equal(cronjob.ErrLimit))
+		})
+	})
+
+	Describe("Runs", func() {
+		var j *cronjob.Job
+
+		BeforeEach(func() {
+			j = &cronjob.Job{Name: "cleanup", Instance: "web", Command: "rm -rf /tmp/cache", Cron: "@hourly"}
+			Ω(s.CreateJob(refID, j)).Should(Succeed())
+		})
+
+		It("Should run the command inside the instance", func() {
+			r, err := s.Run(j.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(r.State).To(Equal(cronjob.Succeeded))
+			Expect(r.Output).To(Equal("done\n"))
+			Expect(containers.executions).To(Equal([]execution{{id: "abc", cmd: "rm -rf /tmp/cache"}}))
+
+			rs := []cronjob.Run{}
+			Ω(s.Runs(refID, j.ID, &rs)).Should(Succeed())
+			Expect(rs).To(HaveLen(1))
+			Expect(rs[0].State).To(Equal(cronjob.Succeeded))
+			Expect(rs[0].FinishedAt.IsZero()).To(BeFalse())
+		})
+
+		It("Should record failed runs and keep the end of long outputs", func() {
+			containers.output = "removing /tmp/cache/a\n"
+			containers.err = errors.New("exit status 1")
+
+			r, err := s.Run(j.ID)
+			Expect(err).To(Equal(containers.err))
+			Expect(r.State).To(Equal(cronjob.Failed))
+			Expect(r.Error).To(Equal("exit status 1"))
+			Expect(r.Output).To(Equal("cache/a\n"))
+
+			containers.output, containers.err = "Timeout:still running", nil
+			r, err = s.Run(j.ID)
+			Expect(err).To(Equal(cronjob.ErrTimeout))
+			Expect(r.Output).To(Equal(" running"))
+		})
+
+		It("Should keep the history of the job", func() {
+			for i := 0; i < 3; i++ {
+				s.Run(j.ID)
+			}
+
+			rs := []cronjob.Run{}
+			Ω(s.Runs(refID, j.ID, &rs)).Should(Succeed())
+			Expect(rs).To(HaveLen(2))
+			Expect(rs[0].ID).To(BeNumerically(">", rs[1].ID))
+
+			Expect(s.Runs(2, j.ID, &rs)).To(Equal(cronjob.ErrJobNotExist))
+		})
+
+		It("Should enqueue the jobs which are due", func() {
+			Ω(s.Due(time.Now())).Should(Succeed())
+			Expect(queue.jobs).To(BeEmpty())
+
+			Ω(s.Due(time.Now().Add(time.Hour))).Should(Succeed())
+			Expect(queue.jobs).To(HaveLen(1))
+			Expect(queue.jobs[0].typ).To(Equal(cronjob.ExecuteJob))
+
+			Ω(s.Due(time.Now().Add(time.Hour))).Should(Succeed())
+			Expect(queue.jobs).To(HaveLen(1))
+
+			id, err := s.RunJob(refID, j.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(id).To(Equal(uint(2)))
+			_, err = s.RunJob(2, j.ID)
+			Expect(err).To(Equal(cronjob.ErrJobNotExist))
+		})
+
+		It("Should run the enqueued jobs", func() {
+			handler := cronjob.ExecuteHandler(s)
+			Ω(handler(context.Background(), jobs.Job{Payload: fmt.Sprintf(`{"jobID":%d}`, j.ID)})).Should(Succeed())
+			Expect(containers.executions).To(HaveLen(1))
+
+			Ω(handler(context.Background(), jobs.Job{Payload: `{"jobID":42}`})).Should(Succeed())
+		})
+
+		It("Should remove the runs with the job", func() {
+			s.Run(j.ID)
+			Ω(s.RemoveJob(refID, j.ID)).Should(Succeed())
+
+			rs := []cronjob.Run{}
+			Ω(s.Runs(refID, 0, &rs)).Should(Succeed())
+			Expect(rs).To(BeEmpty())
+			Expect(s.RemoveJob(refID, j.ID)).To(Equal(cronjob.ErrJobNotExist))
+		})
+
+		It("Should remove the jobs of an instance", func() {
+			containers.ids["db"] = "def"
+			Ω(s.CreateJob(refID, &cronjob.Job{Name: "vacuum", Instance: "db", Command: "vacuumdb", Cron: "@daily"})).Should(Succeed())
+
+			Ω(s.RemoveInstanceJobs(refID, "web")).Should(Succeed())
+			js := []cronjob.Job{}
+			s.Jobs(refID, &js)
+			Expect(js).To(HaveLen(1))
+			Expect(js[0].Name).To(Equal("vacuum"))
+		})
+	})
+
+	Describe("Events", func() {
+		It("Should publish failed runs and notify the user", func() {
+			logger := log.NewNopLogger()
+			bus := events.NewMemoryBus("node1", time.Millisecond, logger)
+			defer bus.Close()
+			rec := &recorder{}
+			bus.Subscribe(events.CronEvents, "", rec.handle)
+
+			mails := &mockMails{}
+			s = cronjob.NewEventService(s, bus, mails, logger)
+			quiet := &cronjob.Job{Name: "quiet", Instance: "web", Command: "false", Cron: "@hourly"}
+			loud := &cronjob.Job{Name: "loud", Instance: "web", Command: "false", Cron: "@hourly", Notify: true}
+			s.CreateJob(refID, quiet)
+			s.CreateJob(refID, loud)
+
+			s.Run(quiet.ID)
+			containers.err = errors.New("exit status 1")
+			s.Run(quiet.ID)
+			r, _ := s.Run(loud.ID)
+
+			Eventually(rec.count).Should(Equal(2))
+			e := events.CronEvent{}
+			rec.events[1].Decode(&e)
+			Expect(e).To(Equal(events.CronEvent{
+				RefID:    refID,
+				JobID:    loud.ID,
+				RunID:    r.ID,
+				Name:     "loud",
+				Instance: "web",
+				Error:    "exit status 1",
+			}))
+
+			Expect(mails.sent).To(HaveLen(1))
+			Expect(mails.sent[0].refID).To(Equal(refID))
+			Expect(mails.sent[0].template).To(Equal(mail.CronFailed))
+			Expect(mails.sent[0].data.Job).To(Equal("loud"))
+			Expect(mails.sent[0].data.Output).To(Equal("done\n"))
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package cronjob
+
+import (
+	"time"
+)
+
+// Plan limits the cron jobs of the users of a plan
+type Plan struct {
+	// MaxJobs is the number of cron jobs a user may have, 0 allows none
+	MaxJobs uint `yaml:"maxJobs"`
+}
+
+// Job runs a command inside a running instance of a user on a schedule
+type Job struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	Name  string `validate:"required,name"`
+	// Instance is the name of the instance the command is run in
+	Instance string `validate:"required,name"`
+	// Command is run by /bin/sh -c with the environment of the instance
+	Command string `validate:"required"`
+	// Cron is the five field cron expression of the runs in UTC, e.g. */15 * * * *
+	Cron string `validate:"required"`
+	// Notify sends an email to the user for every failed run
+	Notify    bool
+	LastRun   time.Time
+	CreatedAt time.Time
+}
+
+// TableName sets Job's database table name
+func (Job) TableName() string {
+	return "cron_jobs"
+}
+
+// Run is an execution of a job
+type Run struct {
+	ID    uint `gorm:"primary_key"`
+	JobID uint
+	RefID uint
+	State string
+	// Output is the standard output of the command, it is cut to the end if it exceeds the limit of the options
+	Output string
+	Error  string
+	// CreatedAt is when the run was started, FinishedAt when it succeeded or failed
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// TableName sets Run's database table name
+func (Run) TableName() string {
+	return "cron_runs"
+}
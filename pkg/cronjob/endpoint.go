@@ -0,0 +1,153 @@
+package cronjob
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the cron job service
+type Endpoints struct {
+	CreateJobEndpoint endpoint.Endpoint
+	RemoveJobEndpoint endpoint.Endpoint
+	JobsEndpoint      endpoint.Endpoint
+	RunsEndpoint      endpoint.Endpoint
+	RunJobEndpoint    endpoint.Endpoint
+}
+
+// CreateJobRequest is the request struct for the CreateJobEndpoint
+type CreateJobRequest struct {
+	RefID uint `bart:"ref"`
+	Job   *Job `validate:"required"`
+}
+
+// CreateJobResponse is the response struct for the CreateJobEndpoint
+type CreateJobResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeCreateJobEndpoint creates a gokit endpoint which invokes CreateJob
+func MakeCreateJobEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateJobRequest)
+		err := s.CreateJob(req.RefID, req.Job)
+		if err != nil {
+			return CreateJobResponse{
+				Error: err,
+			}, nil
+		}
+		return CreateJobResponse{
+			ID: req.Job.ID,
+		}, nil
+	}
+}
+
+// RemoveJobRequest is the request struct for the RemoveJobEndpoint
+type RemoveJobRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemoveJobResponse is the response struct for the RemoveJobEndpoint
+type RemoveJobResponse struct {
+	Error error
+}
+
+// MakeRemoveJobEndpoint creates a gokit endpoint which invokes RemoveJob
+func MakeRemoveJobEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveJobRequest)
+		err := s.RemoveJob(req.RefID, req.ID)
+		return RemoveJobResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// JobsRequest is the request struct for the JobsEndpoint
+type JobsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// JobsResponse is the response struct for the JobsEndpoint
+type JobsResponse struct {
+	Jobs  []Job
+	Error error
+	Page  paging.Response
+}
+
+// MakeJobsEndpoint creates a gokit endpoint which invokes Jobs
+func MakeJobsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(JobsRequest)
+		jobs := []Job{}
+		err := s.Jobs(req.RefID, &jobs)
+		if err != nil {
+			return JobsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&jobs, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return JobsResponse{
+			Jobs: jobs,
+			Page: page,
+		}, nil
+	}
+}
+
+// RunsRequest is the request struct for the RunsEndpoint
+type RunsRequest struct {
+	RefID uint `bart:"ref"`
+	JobID uint
+}
+
+// RunsResponse is the response struct for the RunsEndpoint
+type RunsResponse struct {
+	Runs  []Run
+	Error error
+}
+
+// MakeRunsEndpoint creates a gokit endpoint which invokes Runs
+func MakeRunsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RunsRequest)
+		runs := []Run{}
+		err := s.Runs(req.RefID, req.JobID, &runs)
+		return RunsResponse{
+			Runs:  runs,
+			Error: err,
+		}, nil
+	}
+}
+
+// RunJobRequest is the request struct for the RunJobEndpoint
+type RunJobRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RunJobResponse is the response struct for the RunJobEndpoint
+type RunJobResponse struct {
+	// BackgroundJobID is the ID of the job of the queue running the cron job
+	BackgroundJobID uint
+	Error           error
+}
+
+// MakeRunJobEndpoint creates a gokit endpoint which invokes RunJob
+func MakeRunJobEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RunJobRequest)
+		id, err := s.RunJob(req.RefID, req.ID)
+		return RunJobResponse{
+			BackgroundJobID: id,
+			Error:           err,
+		}, nil
+	}
+}
@@ -0,0 +1,116 @@
+package cronjob
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+)
+
+// EventGroup is the group the cron services subscribe in, so every event is handled once
+const EventGroup = "cronjob"
+
+// Notifier sends the emails of failed runs, it is satisfied by the mail service
+type Notifier interface {
+	Notify(refID uint, template string, data interface{}) (uint, error)
+}
+
+type eventService struct {
+	Service
+	bus    events.Bus
+	mails  Notifier
+	logger log.Logger
+}
+
+// notify sends mail.CronFailed to the user of j if j asks for it
+func (s *eventService) notify(j Job, r Run) {
+	if s.mails == nil || !j.Notify {
+		return
+	}
+
+	_, err := s.mails.Notify(j.RefID, mail.CronFailed, mail.CronData{
+		Job:      j.Name,
+		Instance: j.Instance,
+		Command:  j.Command,
+		Error:    r.Error,
+		Output:   r.Output,
+		Time:     r.CreatedAt,
+	})
+	if err != nil && err != mail.ErrNoRecipient {
+		level.Error(s.logger).Log("job", j.ID, "user", j.RefID, "err", err)
+	}
+}
+
+func (s *eventService) Run(jobID uint) (Run, error) {
+	r, err := s.Service.Run(jobID)
+	if r.ID == 0 || r.State != Failed {
+		return r, err
+	}
+
+	js := []Job{}
+	lookup := s.Service.Jobs(r.RefID, &js)
+	if lookup != nil {
+		level.Error(s.logger).Log("job", jobID, "err", lookup)
+		return r, err
+	}
+
+	for _, j := range js {
+		if j.ID != jobID {
+			continue
+		}
+
+		e := events.CronEvent{
+			RefID:    r.RefID,
+			JobID:    j.ID,
+			RunID:    r.ID,
+			Name:     j.Name,
+			Instance: j.Instance,
+			Error:    r.Error,
+		}
+		publish := s.bus.Publish(events.CronFailed, e)
+		if publish != nil {
+			level.Error(s.logger).Log("topic", events.CronFailed, "run", r.ID, "err", publish)
+		}
+
+		s.notify(j, r)
+	}
+	return r, err
+}
+
+// NewEventService returns a Service which publishes an event and, for the jobs asking for it, sends an
+// email to the user once a run failed. mails may be nil if no emails are sent, failing to publish or
+// to send is only logged.
+func NewEventService(s Service, bus events.Bus, mails Notifier, logger log.Logger) Service {
+	return &eventService{
+		Service: s,
+		bus:     bus,
+		mails:   mails,
+		logger:  logger,
+	}
+}
+
+// Subscribe removes the jobs of an instance once it was removed, the jobs of replicas are kept
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	sub, err := bus.Subscribe(events.ContainerRemoved, EventGroup, func(e events.Event) error {
+		c := events.ContainerEvent{}
+		err := e.Decode(&c)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+		if c.Replica || c.Name == "" {
+			return nil
+		}
+
+		err = s.RemoveInstanceJobs(c.RefID, c.Name)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", c.RefID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []events.Subscription{sub}, nil
+}
@@ -0,0 +1,50 @@
+package cronjob
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+const (
+	// DueJob is the type of the job enqueueing the runs of the cron jobs which are due
+	DueJob = "cron.due"
+	// ExecuteJob is the type of the job running the command of a cron job
+	ExecuteJob = "cron.execute"
+)
+
+// JobOptions run a few commands at a time, failed runs are not retried but run again at the next
+// time of their job, so every failure is only reported once
+var JobOptions = jobs.Options{
+	Workers:     4,
+	MaxAttempts: 1,
+}
+
+type executePayload struct {
+	JobID uint `json:"jobID"`
+}
+
+// DueHandler returns the handler of DueJob
+func DueHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		return s.Due(time.Now())
+	}
+}
+
+// ExecuteHandler returns the handler of ExecuteJob, jobs removed in the meantime are skipped
+func ExecuteHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		p := executePayload{}
+		err := j.Decode(&p)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Run(p.JobID)
+		if err == ErrJobNotExist {
+			return nil
+		}
+		return err
+	}
+}
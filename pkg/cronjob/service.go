@@ -0,0 +1,481 @@
+// Package cronjob runs the commands users schedule inside their running instances, keeps the output
+// of the last runs and limits the number of jobs per plan
+package cronjob
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/snapshot"
+)
+
+// States of a run
+const (
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+// Plans of users which are not limited by the configured plans
+const (
+	// DefaultPlan is used for users whose plan is not configured
+	DefaultPlan = "default"
+	// Unlimited is the plan of users who are never limited, like admins
+	Unlimited = "unlimited"
+)
+
+// timeoutPrefix is put in front of the output of commands which did not finish in time by the container service
+const timeoutPrefix = "Timeout:"
+
+var (
+	// ErrInstanceNotExist occurs if a job is created for an instance the user does not have
+	ErrInstanceNotExist = errors.New("instance does not exist")
+
+	// ErrJobExists occurs if a user has a job of the same name
+	ErrJobExists = errors.New("job exists already")
+
+	// ErrJobNotExist occurs if a job does not exist
+	ErrJobNotExist = errors.New("job does not exist")
+
+	// ErrLimit occurs if a user has as many jobs as the plan allows
+	ErrLimit = errors.New("the plan allows no more cron jobs")
+
+	// ErrTimeout is the error of runs whose command did not finish in time
+	ErrTimeout = errors.New("command timed out")
+)
+
+// Service CronJobService
+type Service interface {
+	// SetPlans replaces the configured plans, jobs exceeding a new limit are kept
+	SetPlans(plans map[string]Plan)
+
+	// CreateJob creates a job of a user, it is run the next time its cron expression matches
+	CreateJob(refID uint, j *Job) error
+
+	// RemoveJob removes a job and its runs
+	RemoveJob(refID uint, id uint) error
+
+	// RemoveInstanceJobs removes the jobs of an instance and their runs
+	RemoveInstanceJobs(refID uint, instance string) error
+
+	// Jobs returns the jobs of a user
+	Jobs(refID uint, j *[]Job) error
+
+	// Runs returns the runs of a job, or of every job if jobID is 0, the latest first
+	Runs(refID uint, jobID uint, r *[]Run) error
+
+	// RunJob runs a job in the background and returns the ID of the background job
+	RunJob(refID uint, id uint) (uint, error)
+
+	// Due enqueues a run of every job whose cron expression matched since its last run
+	Due(now time.Time) error
+
+	// Run runs the command of a job in its instance and removes the runs exceeding the history.
+	// The run is returned even if it failed, it has no ID if it was never started.
+	Run(jobID uint) (Run, error)
+}
+
+// Containers executes the commands, it is satisfied by the container service
+type Containers interface {
+	IDForName(ctx context.Context, refID uint, name string) (string, error)
+	Execute(ctx context.Context, refID uint, id string, cmd string, env map[string]string) (string, error)
+}
+
+// PlanFunc returns the name of the plan of a user
+type PlanFunc func(refID uint) (string, error)
+
+// Queue runs the jobs in the background, it is the job queue of the daemon
+type Queue interface {
+	Enqueue(typ string, payload interface{}) (uint, error)
+}
+
+// Options configure the runs
+type Options struct {
+	// History is the number of runs kept per job
+	History uint
+	// MaxOutput is the number of bytes of the output kept per run
+	MaxOutput int
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db         dbAdapter
+	containers Containers
+	planOf     PlanFunc
+	queue      Queue
+	plans      map[string]Plan
+	options    Options
+	mtx        *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Job{}, &Run{})
+}
+
+func (s *service) SetPlans(plans map[string]Plan) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.plans = plans
+}
+
+// limit returns the number of jobs the plan of a user allows, users are not limited if neither their
+// plan nor the default plan is configured
+func (s *service) limit(refID uint) (uint, bool, error) {
+	name, err := s.planOf(refID)
+	if err != nil {
+		return 0, false, err
+	}
+	if name == Unlimited {
+		return 0, false, nil
+	}
+
+	p, ok := s.plans[name]
+	if !ok {
+		p, ok = s.plans[DefaultPlan]
+	}
+	return p.MaxJobs, ok, nil
+}
+
+// job returns the job id of a user, or of any user if refID is 0
+func (s *service) job(refID uint, id uint) (Job, error) {
+	j := Job{}
+	var err error
+	if refID == 0 {
+		err = s.db.First(&j, "id = ?", id)
+	} else {
+		err = s.db.First(&j, "id = ? AND ref_id = ?", id, refID)
+	}
+	if s.db.IsNotFound(err) {
+		return Job{}, ErrJobNotExist
+	}
+	if err != nil {
+		return Job{}, err
+	}
+	return j, nil
+}
+
+// runs returns the runs matching the non-zero arguments, the latest first
+func (s *service) runs(refID uint, jobID uint) ([]Run, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if refID != 0 {
+		conditions = append(conditions, "ref_id = ?")
+		args = append(args, refID)
+	}
+	if jobID != 0 {
+		conditions = append(conditions, "job_id = ?")
+		args = append(args, jobID)
+	}
+
+	where := []interface{}{}
+	if len(conditions) != 0 {
+		where = append([]interface{}{strings.Join(conditions, " AND ")}, args...)
+	}
+
+	rs := []Run{}
+	err := s.db.FindOrdered(&rs, "id DESC", 0, where...)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (s *service) CreateJob(refID uint, j *Job) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.createJob(refID, j)
+}
+
+func (s *service) createJob(refID uint, j *Job) error {
+	j.ID = 0
+	j.RefID = refID
+
+	_, err := snapshot.ParseCron(j.Cron)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.containers.IDForName(context.Background(), refID, j.Instance)
+	if err != nil {
+		return ErrInstanceNotExist
+	}
+
+	js := []Job{}
+	err = s.db.Find(&js, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+	for _, o := range js {
+		if o.Name == j.Name {
+			return ErrJobExists
+		}
+	}
+	count := uint(len(js))
+
+	max, limited, err := s.limit(refID)
+	if err != nil {
+		return err
+	}
+	if limited && count >= max {
+		return ErrLimit
+	}
+
+	// the first run is the next time the expression matches
+	j.CreatedAt = time.Now().UTC()
+	j.LastRun = j.CreatedAt
+	return s.db.Create(j)
+}
+
+func (s *service) RemoveJob(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	j, err := s.job(refID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.remove(j)
+}
+
+// remove deletes the runs of j and j itself
+func (s *service) remove(j Job) error {
+	err := s.db.Delete(&Run{}, "job_id = ?", j.ID)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&Job{ID: j.ID})
+}
+
+func (s *service) RemoveInstanceJobs(refID uint, instance string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	js := []Job{}
+	err := s.db.Find(&js, "ref_id = ? AND instance = ?", refID, instance)
+	if err != nil {
+		return err
+	}
+
+	for _, j := range js {
+		err = s.remove(j)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) Jobs(refID uint, j *[]Job) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.Find(j, "ref_id = ?", refID)
+}
+
+func (s *service) Runs(refID uint, jobID uint, r *[]Run) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if jobID != 0 {
+		_, err := s.job(refID, jobID)
+		if err != nil {
+			return err
+		}
+	}
+
+	rs, err := s.runs(refID, jobID)
+	if err != nil {
+		return err
+	}
+
+	*r = append(*r, rs...)
+	return nil
+}
+
+func (s *service) RunJob(refID uint, id uint) (uint, error) {
+	s.mtx.Lock()
+	j, err := s.job(refID, id)
+	s.mtx.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	return s.queue.Enqueue(ExecuteJob, executePayload{
+		JobID: j.ID,
+	})
+}
+
+func (s *service) Due(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// the cron expressions are evaluated here, so every job is a candidate
+	js := []Job{}
+	err := s.db.Find(&js)
+	if err != nil {
+		return err
+	}
+
+	for _, j := range js {
+		c, err := snapshot.ParseCron(j.Cron)
+		if err != nil {
+			continue
+		}
+
+		next := c.Next(j.LastRun)
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+
+		_, err = s.queue.Enqueue(ExecuteJob, executePayload{
+			JobID: j.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = abstraction.UpdateByID(s.db, &Job{}, j.ID, &Job{LastRun: now.UTC()})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execute runs the command of j in its instance and returns the output
+func (s *service) execute(j Job) (string, error) {
+	id, err := s.containers.IDForName(context.Background(), j.RefID, j.Instance)
+	if err != nil {
+		return "", ErrInstanceNotExist
+	}
+
+	output, err := s.containers.Execute(context.Background(), j.RefID, id, j.Command, nil)
+	if err != nil {
+		return output, err
+	}
+	if strings.HasPrefix(output, timeoutPrefix) {
+		return strings.TrimPrefix(output, timeoutPrefix), ErrTimeout
+	}
+	return output, nil
+}
+
+// Run does not lock the service while the command is running
+func (s *service) Run(jobID uint) (Run, error) {
+	s.mtx.Lock()
+	j, err := s.job(0, jobID)
+	if err != nil {
+		s.mtx.Unlock()
+		return Run{}, err
+	}
+
+	r := Run{
+		JobID:     j.ID,
+		RefID:     j.RefID,
+		State:     Running,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = s.db.Create(&r)
+	s.mtx.Unlock()
+	if err != nil {
+		return Run{}, err
+	}
+
+	output, failed := s.execute(j)
+	if len(output) > s.options.MaxOutput {
+		output = output[len(output)-s.options.MaxOutput:]
+		// the output is stored as text, so it must not start within a character
+		for len(output) > 0 && !utf8.RuneStart(output[0]) {
+			output = output[1:]
+		}
+	}
+
+	changes := &Run{
+		State:      Succeeded,
+		Output:     output,
+		FinishedAt: time.Now().UTC(),
+	}
+	if failed != nil {
+		changes.State = Failed
+		changes.Error = failed.Error()
+	}
+	r.State, r.Output, r.Error, r.FinishedAt = changes.State, changes.Output, changes.Error, changes.FinishedAt
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err = abstraction.UpdateByID(s.db, &Run{}, r.ID, changes)
+	if err != nil {
+		return r, err
+	}
+
+	err = s.prune(j)
+	if failed != nil {
+		return r, failed
+	}
+	return r, err
+}
+
+// prune removes the finished runs of j exceeding the history
+func (s *service) prune(j Job) error {
+	// the oldest finished run which is kept, the finished ones before it are removed
+	kept := []Run{}
+	err := s.db.FindOrdered(&kept, "id DESC", int(s.options.History), "job_id = ? AND state <> ?", j.ID, Running)
+	if err != nil || uint(len(kept)) < s.options.History {
+		return err
+	}
+	return s.db.Delete(&Run{}, "job_id = ? AND state <> ? AND id < ?", j.ID, Running, kept[len(kept)-1].ID)
+}
+
+// NewService creates a CronJobService, the plans are looked up by planOf and users whose plan is
+// not configured get the DefaultPlan
+func NewService(db dbAdapter, containers Containers, planOf PlanFunc, q Queue, plans map[string]Plan, o Options) (Service, error) {
+	if o.History == 0 {
+		o.History = 20
+	}
+	if o.MaxOutput == 0 {
+		o.MaxOutput = 64 * 1024
+	}
+
+	s := &service{
+		db:         db,
+		containers: containers,
+		planOf:     planOf,
+		queue:      q,
+		plans:      plans,
+		options:    o,
+		mtx:        &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,302 @@
+package cronjob
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/cronjob/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC CronJobServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.CronJobServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createJob: grpctransport.NewServer(
+			endpoints.CreateJobEndpoint,
+			DecodeGRPCCreateJobRequest,
+			EncodeGRPCCreateJobResponse,
+			options...,
+		),
+
+		removeJob: grpctransport.NewServer(
+			endpoints.RemoveJobEndpoint,
+			DecodeGRPCRemoveJobRequest,
+			EncodeGRPCRemoveJobResponse,
+			options...,
+		),
+
+		jobs: grpctransport.NewServer(
+			endpoints.JobsEndpoint,
+			DecodeGRPCJobsRequest,
+			EncodeGRPCJobsResponse,
+			options...,
+		),
+
+		runs: grpctransport.NewServer(
+			endpoints.RunsEndpoint,
+			DecodeGRPCRunsRequest,
+			EncodeGRPCRunsResponse,
+			options...,
+		),
+
+		runJob: grpctransport.NewServer(
+			endpoints.RunJobEndpoint,
+			DecodeGRPCRunJobRequest,
+			EncodeGRPCRunJobResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createJob grpctransport.Handler
+	removeJob grpctransport.Handler
+	jobs      grpctransport.Handler
+	runs      grpctransport.Handler
+	runJob    grpctransport.Handler
+}
+
+func (s *grpcServer) CreateJob(ctx oldcontext.Context, req *pb.CreateJobRequest) (*pb.CreateJobResponse, error) {
+	_, res, err := s.createJob.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateJobResponse), nil
+}
+
+func (s *grpcServer) RemoveJob(ctx oldcontext.Context, req *pb.RemoveJobRequest) (*pb.RemoveJobResponse, error) {
+	_, res, err := s.removeJob.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveJobResponse), nil
+}
+
+func (s *grpcServer) Jobs(ctx oldcontext.Context, req *pb.JobsRequest) (*pb.JobsResponse, error) {
+	_, res, err := s.jobs.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.JobsResponse), nil
+}
+
+func (s *grpcServer) Runs(ctx oldcontext.Context, req *pb.RunsRequest) (*pb.RunsResponse, error) {
+	_, res, err := s.runs.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RunsResponse), nil
+}
+
+func (s *grpcServer) RunJob(ctx oldcontext.Context, req *pb.RunJobRequest) (*pb.RunJobResponse, error) {
+	_, res, err := s.runJob.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RunJobResponse), nil
+}
+
+// ConvertJob converts a Job to its protobuf representation
+func ConvertJob(j Job) *pb.Job {
+	return &pb.Job{
+		ID:        uint32(j.ID),
+		Name:      j.Name,
+		Instance:  j.Instance,
+		Command:   j.Command,
+		Cron:      j.Cron,
+		Notify:    j.Notify,
+		LastRun:   j.LastRun.Unix(),
+		CreatedAt: j.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBJob converts a protobuf Job to a Job
+func ConvertPBJob(j *pb.Job) Job {
+	if j == nil {
+		return Job{}
+	}
+
+	return Job{
+		ID:        uint(j.ID),
+		Name:      j.Name,
+		Instance:  j.Instance,
+		Command:   j.Command,
+		Cron:      j.Cron,
+		Notify:    j.Notify,
+		LastRun:   time.Unix(j.LastRun, 0).UTC(),
+		CreatedAt: time.Unix(j.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertRun converts a Run to its protobuf representation
+func ConvertRun(r Run) *pb.Run {
+	run := &pb.Run{
+		ID:        uint32(r.ID),
+		JobID:     uint32(r.JobID),
+		State:     r.State,
+		Output:    r.Output,
+		Error:     r.Error,
+		CreatedAt: r.CreatedAt.Unix(),
+	}
+	if !r.FinishedAt.IsZero() {
+		run.FinishedAt = r.FinishedAt.Unix()
+	}
+	return run
+}
+
+// ConvertPBRun converts a protobuf Run to a Run
+func ConvertPBRun(r *pb.Run) Run {
+	if r == nil {
+		return Run{}
+	}
+
+	run := Run{
+		ID:        uint(r.ID),
+		JobID:     uint(r.JobID),
+		State:     r.State,
+		Output:    r.Output,
+		Error:     r.Error,
+		CreatedAt: time.Unix(r.CreatedAt, 0).UTC(),
+	}
+	if r.FinishedAt != 0 {
+		run.FinishedAt = time.Unix(r.FinishedAt, 0).UTC()
+	}
+	return run
+}
+
+// DecodeGRPCCreateJobRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateJob request to a messages/cronjob.proto-domain createjob request.
+func DecodeGRPCCreateJobRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateJobRequest)
+	return CreateJobRequest{
+		RefID: uint(req.RefID),
+		Job: &Job{
+			Name:     req.Name,
+			Instance: req.Instance,
+			Command:  req.Command,
+			Cron:     req.Cron,
+			Notify:   req.Notify,
+		},
+	}, nil
+}
+
+// EncodeGRPCCreateJobResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain createjob response to a gRPC CreateJob response.
+func EncodeGRPCCreateJobResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateJobResponse)
+	gRPCRes := &pb.CreateJobResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveJobRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveJob request to a messages/cronjob.proto-domain removejob request.
+func DecodeGRPCRemoveJobRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveJobRequest)
+	return RemoveJobRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveJobResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain removejob response to a gRPC RemoveJob response.
+func EncodeGRPCRemoveJobResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveJobResponse)
+	gRPCRes := &pb.RemoveJobResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCJobsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Jobs request to a messages/cronjob.proto-domain jobs request.
+func DecodeGRPCJobsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.JobsRequest)
+	return JobsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCJobsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain jobs response to a gRPC Jobs response.
+func EncodeGRPCJobsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(JobsResponse)
+	jobs := make([]*pb.Job, len(res.Jobs))
+	for i, j := range res.Jobs {
+		jobs[i] = ConvertJob(j)
+	}
+
+	gRPCRes := &pb.JobsResponse{
+		Jobs:     jobs,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRunsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Runs request to a messages/cronjob.proto-domain runs request.
+func DecodeGRPCRunsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RunsRequest)
+	return RunsRequest{
+		RefID: uint(req.RefID),
+		JobID: uint(req.JobID),
+	}, nil
+}
+
+// EncodeGRPCRunsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain runs response to a gRPC Runs response.
+func EncodeGRPCRunsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RunsResponse)
+	runs := make([]*pb.Run, len(res.Runs))
+	for i, r := range res.Runs {
+		runs[i] = ConvertRun(r)
+	}
+
+	gRPCRes := &pb.RunsResponse{
+		Runs: runs,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRunJobRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RunJob request to a messages/cronjob.proto-domain runjob request.
+func DecodeGRPCRunJobRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RunJobRequest)
+	return RunJobRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRunJobResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/cronjob.proto-domain runjob response to a gRPC RunJob response.
+func EncodeGRPCRunJobResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RunJobResponse)
+	gRPCRes := &pb.RunJobResponse{
+		BackgroundJobID: uint32(res.BackgroundJobID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
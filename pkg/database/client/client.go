@@ -0,0 +1,252 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/database"
+	"github.com/kontainerooo/kontainer.ooo/pkg/database/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *database.Endpoints {
+
+	var CreateDatabaseEndpoint endpoint.Endpoint
+	{
+		CreateDatabaseEndpoint = grpctransport.NewClient(
+			conn,
+			"database.DatabaseService",
+			"CreateDatabase",
+			EncodeGRPCCreateDatabaseRequest,
+			DecodeGRPCCreateDatabaseResponse,
+			pb.CreateDatabaseResponse{},
+		).Endpoint()
+	}
+
+	var RemoveDatabaseEndpoint endpoint.Endpoint
+	{
+		RemoveDatabaseEndpoint = grpctransport.NewClient(
+			conn,
+			"database.DatabaseService",
+			"RemoveDatabase",
+			EncodeGRPCRemoveDatabaseRequest,
+			DecodeGRPCRemoveDatabaseResponse,
+			pb.RemoveDatabaseResponse{},
+		).Endpoint()
+	}
+
+	var DatabasesEndpoint endpoint.Endpoint
+	{
+		DatabasesEndpoint = grpctransport.NewClient(
+			conn,
+			"database.DatabaseService",
+			"Databases",
+			EncodeGRPCDatabasesRequest,
+			DecodeGRPCDatabasesResponse,
+			pb.DatabasesResponse{},
+		).Endpoint()
+	}
+
+	var LinkDatabaseEndpoint endpoint.Endpoint
+	{
+		LinkDatabaseEndpoint = grpctransport.NewClient(
+			conn,
+			"database.DatabaseService",
+			"LinkDatabase",
+			EncodeGRPCLinkDatabaseRequest,
+			DecodeGRPCLinkDatabaseResponse,
+			pb.LinkDatabaseResponse{},
+		).Endpoint()
+	}
+
+	var UnlinkDatabaseEndpoint endpoint.Endpoint
+	{
+		UnlinkDatabaseEndpoint = grpctransport.NewClient(
+			conn,
+			"database.DatabaseService",
+			"UnlinkDatabase",
+			EncodeGRPCLinkDatabaseRequest,
+			DecodeGRPCLinkDatabaseResponse,
+			pb.LinkDatabaseResponse{},
+		).Endpoint()
+	}
+
+	var DumpDatabaseEndpoint endpoint.Endpoint
+	{
+		DumpDatabaseEndpoint = grpctransport.NewClient(
+			conn,
+			"database.DatabaseService",
+			"DumpDatabase",
+			EncodeGRPCDumpDatabaseRequest,
+			DecodeGRPCDumpDatabaseResponse,
+			pb.DumpDatabaseResponse{},
+		).Endpoint()
+	}
+
+	var DumpsEndpoint endpoint.Endpoint
+	{
+		DumpsEndpoint = grpctransport.NewClient(
+			conn,
+			"database.DatabaseService",
+			"Dumps",
+			EncodeGRPCDumpsRequest,
+			DecodeGRPCDumpsResponse,
+			pb.DumpsResponse{},
+		).Endpoint()
+	}
+
+	return &database.Endpoints{
+		CreateDatabaseEndpoint: CreateDatabaseEndpoint,
+		RemoveDatabaseEndpoint: RemoveDatabaseEndpoint,
+		DatabasesEndpoint:      DatabasesEndpoint,
+		LinkDatabaseEndpoint:   LinkDatabaseEndpoint,
+		UnlinkDatabaseEndpoint: UnlinkDatabaseEndpoint,
+		DumpDatabaseEndpoint:   DumpDatabaseEndpoint,
+		DumpsEndpoint:          DumpsEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreateDatabaseRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain createdatabase request to a gRPC CreateDatabase request.
+func EncodeGRPCCreateDatabaseRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*database.CreateDatabaseRequest)
+	return &pb.CreateDatabaseRequest{
+		RefID:  uint32(req.RefID),
+		Name:   req.Database.Name,
+		Engine: req.Database.Engine,
+		Mode:   req.Database.Mode,
+	}, nil
+}
+
+// DecodeGRPCCreateDatabaseResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateDatabase response to a messages/database.proto-domain createdatabase response.
+func DecodeGRPCCreateDatabaseResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateDatabaseResponse)
+	return &database.CreateDatabaseResponse{
+		Database: database.ConvertPBDatabase(response.Database),
+		Error:    getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveDatabaseRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain removedatabase request to a gRPC RemoveDatabase request.
+func EncodeGRPCRemoveDatabaseRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*database.RemoveDatabaseRequest)
+	return &pb.RemoveDatabaseRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// DecodeGRPCRemoveDatabaseResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveDatabase response to a messages/database.proto-domain removedatabase response.
+func DecodeGRPCRemoveDatabaseResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveDatabaseResponse)
+	return &database.RemoveDatabaseResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDatabasesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain databases request to a gRPC Databases request.
+func EncodeGRPCDatabasesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*database.DatabasesRequest)
+	return &pb.DatabasesRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCDatabasesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Databases response to a messages/database.proto-domain databases response.
+func DecodeGRPCDatabasesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DatabasesResponse)
+	databases := make([]database.Database, len(response.Databases))
+	for i, d := range response.Databases {
+		databases[i] = database.ConvertPBDatabase(d)
+	}
+
+	return &database.DatabasesResponse{
+		Databases: databases,
+		Error:     getError(response.Error),
+		Page:      paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCLinkDatabaseRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain linkdatabase request to a gRPC LinkDatabase or UnlinkDatabase request.
+func EncodeGRPCLinkDatabaseRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*database.LinkDatabaseRequest)
+	return &pb.LinkDatabaseRequest{
+		RefID:         uint32(req.RefID),
+		Name:          req.Name,
+		ContainerName: req.ContainerName,
+	}, nil
+}
+
+// DecodeGRPCLinkDatabaseResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC LinkDatabase or UnlinkDatabase response to a messages/database.proto-domain linkdatabase response.
+func DecodeGRPCLinkDatabaseResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.LinkDatabaseResponse)
+	return &database.LinkDatabaseResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDumpDatabaseRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain dumpdatabase request to a gRPC DumpDatabase request.
+func EncodeGRPCDumpDatabaseRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*database.DumpDatabaseRequest)
+	return &pb.DumpDatabaseRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// DecodeGRPCDumpDatabaseResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC DumpDatabase response to a messages/database.proto-domain dumpdatabase response.
+func DecodeGRPCDumpDatabaseResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DumpDatabaseResponse)
+	return &database.DumpDatabaseResponse{
+		JobID: uint(response.JobID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDumpsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain dumps request to a gRPC Dumps request.
+func EncodeGRPCDumpsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*database.DumpsRequest)
+	return &pb.DumpsRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// DecodeGRPCDumpsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Dumps response to a messages/database.proto-domain dumps response.
+func DecodeGRPCDumpsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DumpsResponse)
+	dumps := make([]database.Dump, len(response.Dumps))
+	for i, d := range response.Dumps {
+		dumps[i] = database.ConvertPBDump(d)
+	}
+
+	return &database.DumpsResponse{
+		Dumps: dumps,
+		Error: getError(response.Error),
+	}, nil
+}
@@ -0,0 +1,13 @@
+package database_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDatabase(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Database Suite")
+}
@@ -0,0 +1,490 @@
+package database_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/database"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockProvisioner struct {
+	err     error
+	created []string
+	dropped []string
+}
+
+func (p *mockProvisioner) Create(d *database.Database) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.created = append(p.created, d.Schema)
+	d.Host = "db.example.com"
+	d.Port = 5432
+	return nil
+}
+
+func (p *mockProvisioner) Drop(d database.Database) error {
+	p.dropped = append(p.dropped, d.Schema)
+	return nil
+}
+
+func (p *mockProvisioner) Dump(d database.Database, w io.Writer) error {
+	if p.err != nil {
+		return p.err
+	}
+	_, err := fmt.Fprintf(w, "dump of %s", d.Schema)
+	return err
+}
+
+type mockQueue struct {
+	payloads []string
+}
+
+func (q *mockQueue) Enqueue(typ string, payload interface{}) (uint, error) {
+	b, _ := json.Marshal(payload)
+	q.payloads = append(q.payloads, string(b))
+	return uint(len(q.payloads)), nil
+}
+
+type mockContainers struct {
+	ids      map[string]string
+	env      map[string]map[string]string
+	links    []string
+	executed []string
+	attached [][]string
+	removed  []string
+	kmi      kmi.KMI
+}
+
+func (c *mockContainers) CreateContainer(ctx context.Context, refID uint, kmiID uint, name string) (string, error) {
+	id := fmt.Sprintf("%d-%s", refID, name)
+	c.ids[name] = id
+	return id, nil
+}
+
+func (c *mockContainers) RemoveContainer(ctx context.Context, refID uint, id string) error {
+	c.removed = append(c.removed, id)
+	return nil
+}
+
+func (c *mockContainers) IDForName(ctx context.Context, refID uint, name string) (string, error) {
+	id, ok := c.ids[name]
+	if !ok {
+		return "", errors.New("container does not exist")
+	}
+	return id, nil
+}
+
+func (c *mockContainers) GetContainerKMI(ctx context.Context, containerID string) (kmi.KMI, error) {
+	return c.kmi, nil
+}
+
+func (c *mockContainers) Execute(ctx context.Context, refID uint, id string, cmd string, env map[string]string) (string, error) {
+	c.executed = append(c.executed, cmd)
+	return "", nil
+}
+
+func (c *mockContainers) Attach(ctx context.Context, refID uint, id string, cmd []string, env map[string]string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	c.attached = append(c.attached, cmd)
+	fmt.Fprint(stdout, "dump")
+	return 0, nil
+}
+
+func (c *mockContainers) SetEnv(ctx context.Context, refID uint, id string, key string, value string) error {
+	if c.env[id] == nil {
+		c.env[id] = make(map[string]string)
+	}
+	if value == "" {
+		delete(c.env[id], key)
+	} else {
+		c.env[id][key] = value
+	}
+	return nil
+}
+
+func (c *mockContainers) SetLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error {
+	c.links = append(c.links, fmt.Sprintf("%s>%s:%s", linkName, containerID, linkInterface))
+	return nil
+}
+
+func (c *mockContainers) RemoveLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error {
+	link := fmt.Sprintf("%s>%s:%s", linkName, containerID, linkInterface)
+	for i, l := range c.links {
+		if l == link {
+			c.links = append(c.links[:i], c.links[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func readDump(path string) string {
+	f, err := os.Open(path)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	Ω(err).ShouldNot(HaveOccurred())
+	b, err := ioutil.ReadAll(gz)
+	Ω(err).ShouldNot(HaveOccurred())
+	return string(b)
+}
+
+var _ = Describe("Database", func() {
+	var (
+		refID       = uint(1)
+		provisioner *mockProvisioner
+		containers  *mockContainers
+		queue       *mockQueue
+		dir         string
+		secrets     *secret.Store
+		db          *testutils.MockDB
+		s           database.Service
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "kroo-database")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		provisioner = &mockProvisioner{}
+		containers = &mockContainers{
+			ids: map[string]string{"web": "1-web"},
+			env: make(map[string]map[string]string),
+		}
+		queue = &mockQueue{}
+
+		keys, err := secret.NewKeyring(map[string]string{"a": base64.StdEncoding.EncodeToString(make([]byte, secret.DataKeySize))}, "a")
+		Ω(err).ShouldNot(HaveOccurred())
+		secrets = secret.NewStore(keys)
+
+		db = testutils.NewMockDB()
+		s, _ = database.NewService(db, containers, queue, map[string]database.Provisioner{
+			database.SchemaMode: provisioner,
+		}, database.Options{
+			DumpPath:  dir,
+			Retention: 2,
+			Secrets:   secrets,
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Create Service", func() {
+		It("Should create service", func() {
+			s, err := database.NewService(testutils.NewMockDB(), containers, queue, nil, database.Options{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s).ToNot(BeZero())
+		})
+
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := database.NewService(db, containers, queue, nil, database.Options{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("CreateDatabase", func() {
+		It("Should provision a database with generated credentials", func() {
+			d := &database.Database{Name: "app", Engine: database.Postgres}
+			err := s.CreateDatabase(refID, d)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(d.ID).ToNot(BeZero())
+			Expect(d.Mode).To(Equal(database.SchemaMode))
+			Expect(d.Schema).To(HavePrefix("kroo_"))
+			Expect(d.User).To(Equal(d.Schema))
+			Expect(len(d.User)).To(BeNumerically("<=", 32))
+			Expect(d.Password).To(HaveLen(32))
+			Expect(d.Host).To(Equal("db.example.com"))
+			Expect(provisioner.created).To(ConsistOf(d.Schema))
+
+			ds := []database.Database{}
+			Ω(s.Databases(refID, &ds)).Should(Succeed())
+			Expect(ds).To(HaveLen(1))
+			Expect(ds[0].Password).To(Equal(d.Password))
+
+			ds = []database.Database{}
+			Ω(s.Databases(2, &ds)).Should(Succeed())
+			Expect(ds).To(BeEmpty())
+		})
+
+		It("Should keep the passwords of the databases encrypted", func() {
+			d := &database.Database{Name: "app", Engine: database.Postgres}
+			Ω(s.CreateDatabase(refID, d)).Should(Succeed())
+
+			stored := []database.Database{}
+			Ω(db.Find(&stored)).Should(Succeed())
+			Ω(stored).Should(HaveLen(1))
+			Ω(secret.IsEncrypted(stored[0].Password)).Should(BeTrue())
+			Ω(secrets.Decrypt(stored[0].Password)).Should(Equal(d.Password))
+
+			keys, err := secret.NewKeyring(map[string]string{
+				"a": base64.StdEncoding.EncodeToString(make([]byte, secret.DataKeySize)),
+				"b": base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, secret.DataKeySize)),
+			}, "b")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(database.RotateSecrets(db, secret.NewStore(keys))).Should(Equal(1))
+
+			stored = []database.Database{}
+			Ω(db.Find(&stored)).Should(Succeed())
+			Ω(secret.KeyID(stored[0].Password)).Should(Equal("b"))
+			Ω(secret.NewStore(keys).Decrypt(stored[0].Password)).Should(Equal(d.Password))
+			Ω(database.RotateSecrets(db, secret.NewStore(keys))).Should(Equal(0))
+		})
+
+		It("Should reject duplicate names of a user", func() {
+			Ω(s.CreateDatabase(refID, &database.Database{Name: "app", Engine: database.Postgres})).Should(Succeed())
+
+			err := s.CreateDatabase(refID, &database.Database{Name: "app", Engine: database.MySQL})
+			Ω(err).Should(Equal(database.ErrDatabaseExists))
+
+			Ω(s.CreateDatabase(2, &database.Database{Name: "app", Engine: database.MySQL})).Should(Succeed())
+		})
+
+		It("Should validate the engine and mode", func() {
+			err := s.CreateDatabase(refID, &database.Database{Name: "app", Engine: "sqlite"})
+			Ω(err).Should(Equal(database.ErrEngine))
+
+			err = s.CreateDatabase(refID, &database.Database{Name: "app", Engine: database.MySQL, Mode: database.ContainerMode})
+			Ω(err).Should(Equal(database.ErrMode))
+		})
+
+		It("Should not store databases which could not be provisioned", func() {
+			provisioner.err = errors.New("server unavailable")
+			err := s.CreateDatabase(refID, &database.Database{Name: "app", Engine: database.Postgres})
+			Ω(err).Should(HaveOccurred())
+
+			ds := []database.Database{}
+			s.Databases(refID, &ds)
+			Expect(ds).To(BeEmpty())
+		})
+	})
+
+	Describe("LinkDatabase", func() {
+		It("Should inject the connection info into the instance", func() {
+			d := &database.Database{Name: "app-db", Engine: database.Postgres}
+			s.CreateDatabase(refID, d)
+
+			err := s.LinkDatabase(refID, "app-db", "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(containers.env["1-web"]).To(Equal(map[string]string{
+				"KROO_DB_APP_DB_ENGINE":   database.Postgres,
+				"KROO_DB_APP_DB_DATABASE": d.Schema,
+				"KROO_DB_APP_DB_USER":     d.User,
+				"KROO_DB_APP_DB_PASSWORD": d.Password,
+				"KROO_DB_APP_DB_HOST":     "db.example.com",
+				"KROO_DB_APP_DB_PORT":     "5432",
+			}))
+
+			err = s.LinkDatabase(refID, "app-db", "web")
+			Ω(err).Should(Equal(database.ErrLinkExists))
+
+			err = s.LinkDatabase(refID, "app-db", "api")
+			Ω(err).Should(HaveOccurred())
+
+			err = s.LinkDatabase(2, "app-db", "web")
+			Ω(err).Should(Equal(database.ErrDatabaseNotExist))
+		})
+
+		It("Should remove the connection info from the instance", func() {
+			s.CreateDatabase(refID, &database.Database{Name: "app", Engine: database.MySQL})
+			s.LinkDatabase(refID, "app", "web")
+
+			Ω(s.UnlinkDatabase(refID, "app", "web")).Should(Succeed())
+			Expect(containers.env["1-web"]).To(BeEmpty())
+
+			err := s.UnlinkDatabase(refID, "app", "web")
+			Ω(err).Should(Equal(database.ErrLinkNotExist))
+		})
+	})
+
+	Describe("RemoveDatabase", func() {
+		It("Should unlink and drop the database", func() {
+			d := &database.Database{Name: "app", Engine: database.Postgres}
+			s.CreateDatabase(refID, d)
+			s.LinkDatabase(refID, "app", "web")
+
+			err := s.RemoveDatabase(2, "app")
+			Ω(err).Should(Equal(database.ErrDatabaseNotExist))
+
+			Ω(s.RemoveDatabase(refID, "app")).Should(Succeed())
+			Expect(provisioner.dropped).To(ConsistOf(d.Schema))
+			Expect(containers.env["1-web"]).To(BeEmpty())
+
+			ds := []database.Database{}
+			s.Databases(refID, &ds)
+			Expect(ds).To(BeEmpty())
+
+			Ω(s.CreateDatabase(refID, &database.Database{Name: "app", Engine: database.Postgres})).Should(Succeed())
+			Ω(s.LinkDatabase(refID, "app", "web")).Should(Succeed())
+		})
+	})
+
+	Describe("Dump", func() {
+		It("Should enqueue dumps of a database", func() {
+			d := &database.Database{Name: "app", Engine: database.Postgres}
+			s.CreateDatabase(refID, d)
+
+			id, err := s.DumpDatabase(refID, "app")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(id).To(BeEquivalentTo(1))
+			Expect(queue.payloads).To(ConsistOf(fmt.Sprintf(`{"databaseID":%d}`, d.ID)))
+
+			_, err = s.DumpDatabase(2, "app")
+			Ω(err).Should(Equal(database.ErrDatabaseNotExist))
+		})
+
+		It("Should write gzipped dumps and keep the latest ones", func() {
+			d := &database.Database{Name: "app", Engine: database.Postgres}
+			s.CreateDatabase(refID, d)
+			other := &database.Database{Name: "app-2", Engine: database.Postgres}
+			s.CreateDatabase(refID, other)
+
+			userDir := filepath.Join(dir, "1")
+			os.MkdirAll(userDir, 0700)
+			for _, f := range []string{"app-20170101T000000.sql.gz", "app-20170102T000000.sql.gz", "app-2-20170101T000000.sql.gz"} {
+				Ω(ioutil.WriteFile(filepath.Join(userDir, f), nil, 0600)).Should(Succeed())
+			}
+
+			handler := database.DumpHandler(s)
+			Ω(handler(context.Background(), jobs.Job{Payload: fmt.Sprintf(`{"databaseID":%d}`, d.ID)})).Should(Succeed())
+
+			ds := []database.Dump{}
+			Ω(s.Dumps(refID, "app", &ds)).Should(Succeed())
+			Expect(ds).To(HaveLen(2))
+			Expect(ds[1].File).To(Equal("app-20170102T000000.sql.gz"))
+			Expect(readDump(filepath.Join(userDir, ds[0].File))).To(Equal("dump of " + d.Schema))
+
+			ds = []database.Dump{}
+			Ω(s.Dumps(refID, "app-2", &ds)).Should(Succeed())
+			Expect(ds).To(HaveLen(1))
+
+			Ω(handler(context.Background(), jobs.Job{})).Should(Succeed())
+			ds = []database.Dump{}
+			s.Dumps(refID, "app-2", &ds)
+			Expect(ds).To(HaveLen(2))
+		})
+
+		It("Should keep the previous dumps if a dump fails", func() {
+			s.CreateDatabase(refID, &database.Database{Name: "app", Engine: database.Postgres})
+			Ω(s.Dump(0)).Should(Succeed())
+
+			provisioner.err = errors.New("connection refused")
+			Ω(s.Dump(0)).ShouldNot(Succeed())
+
+			ds := []database.Dump{}
+			s.Dumps(refID, "app", &ds)
+			Expect(ds).To(HaveLen(1))
+
+			files, _ := ioutil.ReadDir(filepath.Join(dir, "1"))
+			Expect(files).To(HaveLen(1))
+		})
+
+		It("Should export a database", func() {
+			d := &database.Database{Name: "app", Engine: database.Postgres}
+			s.CreateDatabase(refID, d)
+
+			buf := &bytes.Buffer{}
+			Ω(s.Export(refID, "app", buf)).Should(Succeed())
+			Expect(buf.String()).To(Equal("dump of " + d.Schema))
+
+			Expect(s.Export(refID, "missing", buf)).To(Equal(database.ErrDatabaseNotExist))
+		})
+	})
+
+	Describe("Statements", func() {
+		d := database.Database{Schema: "kroo_1", User: "kroo_1", Password: "secret"}
+
+		It("Should create and drop postgres databases", func() {
+			d.Engine = database.Postgres
+			Expect(database.CreateStatements(d)).To(Equal([]string{
+				`CREATE ROLE "kroo_1" LOGIN PASSWORD 'secret'`,
+				`CREATE DATABASE "kroo_1" OWNER "kroo_1"`,
+			}))
+			Expect(database.DropStatements(d)).To(Equal([]string{
+				`DROP DATABASE IF EXISTS "kroo_1"`,
+				`DROP ROLE IF EXISTS "kroo_1"`,
+			}))
+		})
+
+		It("Should create and drop mysql databases", func() {
+			d.Engine = database.MySQL
+			Expect(database.CreateStatements(d)).To(Equal([]string{
+				"CREATE DATABASE `kroo_1`",
+				"CREATE USER 'kroo_1'@'%' IDENTIFIED BY 'secret'",
+				"GRANT ALL PRIVILEGES ON `kroo_1`.* TO 'kroo_1'@'%'",
+			}))
+			Expect(database.DropStatements(d)).To(Equal([]string{
+				"DROP DATABASE IF EXISTS `kroo_1`",
+				"DROP USER IF EXISTS 'kroo_1'@'%'",
+			}))
+		})
+	})
+
+	Describe("Container provisioner", func() {
+		BeforeEach(func() {
+			containers.kmi = kmi.KMI{
+				Interfaces: abstraction.NewJSONFromMap(map[string]string{"mysql": "3306"}),
+				Commands:   abstraction.NewJSONFromMap(map[string]string{database.ProvisionCommand: "/provision.sh"}),
+			}
+			s, _ = database.NewService(testutils.NewMockDB(), containers, queue, map[string]database.Provisioner{
+				database.ContainerMode: database.NewContainerProvisioner(containers, map[string]uint{database.MySQL: 3}),
+			}, database.Options{
+				Mode:     database.ContainerMode,
+				DumpPath: dir,
+			})
+		})
+
+		It("Should provision databases in containers of the users", func() {
+			d := &database.Database{Name: "db", Engine: database.MySQL}
+			Ω(s.CreateDatabase(refID, d)).Should(Succeed())
+			Expect(d.Mode).To(Equal(database.ContainerMode))
+			Expect(d.Port).To(BeEquivalentTo(3306))
+			Expect(containers.env["1-db"]).To(HaveKeyWithValue("KROO_DB_PASSWORD", d.Password))
+			Expect(containers.executed).To(ConsistOf("/provision.sh"))
+
+			err := s.CreateDatabase(refID, &database.Database{Name: "pg", Engine: database.Postgres})
+			Ω(err).Should(Equal(database.ErrNoServer))
+		})
+
+		It("Should link the containers into the instances", func() {
+			s.CreateDatabase(refID, &database.Database{Name: "db", Engine: database.MySQL})
+			Ω(s.LinkDatabase(refID, "db", "web")).Should(Succeed())
+			Expect(containers.links).To(ConsistOf("db>1-web:mysql"))
+			Expect(containers.env["1-web"]).ToNot(HaveKey("KROO_DB_DB_HOST"))
+
+			Ω(s.RemoveDatabase(refID, "db")).Should(Succeed())
+			Expect(containers.links).To(BeEmpty())
+			Expect(containers.removed).To(ConsistOf("1-db"))
+		})
+
+		It("Should dump the databases in their containers", func() {
+			s.CreateDatabase(refID, &database.Database{Name: "db", Engine: database.MySQL})
+			Ω(s.Dump(0)).Should(Succeed())
+			Expect(containers.attached).To(HaveLen(1))
+			Expect(containers.attached[0][0]).To(Equal("mysqldump"))
+			Expect(strings.Join(containers.attached[0], " ")).To(ContainSubstring("--port=3306"))
+		})
+	})
+})
@@ -0,0 +1,49 @@
+package database
+
+import (
+	"time"
+)
+
+// Database is a managed database of a user
+type Database struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Name is the name the user refers to the database with, the database and its user on the server are named Schema
+	Name   string `validate:"required,name"`
+	Engine string `validate:"required"`
+	// Mode is how the database was provisioned, as schema on a shared server or in a container of the user
+	Mode     string
+	Schema   string
+	User     string
+	Password string
+	// Host and Port are the address of a shared server, the address of a container is injected as link
+	Host      string
+	Port      uint
+	Container string
+	CreatedAt time.Time
+}
+
+// TableName sets Database's database table name
+func (Database) TableName() string {
+	return "databases"
+}
+
+// Link is an instance of a user the connection info of a database is injected into
+type Link struct {
+	ID            uint `gorm:"primary_key"`
+	DatabaseID    uint
+	RefID         uint
+	ContainerName string
+}
+
+// TableName sets Link's database table name
+func (Link) TableName() string {
+	return "database_links"
+}
+
+// Dump is a dump of a database in the dump directory
+type Dump struct {
+	File      string
+	Size      int64
+	CreatedAt time.Time
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+// DumpJob is the type of the job dumping a database, or every database if it has no payload
+const DumpJob = "database.dump"
+
+// DumpOptions run one dump at a time and retry it for about an hour
+var DumpOptions = jobs.Options{
+	Workers:     1,
+	MaxAttempts: 4,
+	Backoff:     5 * time.Minute,
+	MaxBackoff:  30 * time.Minute,
+}
+
+// dumpTime is the format of the time in the names of the dumps
+const dumpTime = "20060102T150405"
+
+// dumpExt is the extension of the dumps, they are gzipped SQL
+const dumpExt = ".sql.gz"
+
+type dumpPayload struct {
+	DatabaseID uint `json:"databaseID"`
+}
+
+// DumpHandler returns the handler of DumpJob
+func DumpHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		p := dumpPayload{}
+		if j.Payload != "" {
+			err := j.Decode(&p)
+			if err != nil {
+				return err
+			}
+		}
+
+		return s.Dump(p.DatabaseID)
+	}
+}
+
+// parseDump returns the time of a dump file of the database name
+func parseDump(file, name string) (time.Time, bool) {
+	prefix := name + "-"
+	if !strings.HasPrefix(file, prefix) || !strings.HasSuffix(file, dumpExt) {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(dumpTime, strings.TrimSuffix(strings.TrimPrefix(file, prefix), dumpExt))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// dumps returns the dumps of d in dir/<refID>/<name>-<time>.sql.gz, the latest first
+func dumps(dir string, d Database) ([]Dump, error) {
+	infos, err := ioutil.ReadDir(filepath.Join(dir, fmt.Sprintf("%d", d.RefID)))
+	if os.IsNotExist(err) {
+		return []Dump{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ds := []Dump{}
+	for _, info := range infos {
+		t, ok := parseDump(info.Name(), d.Name)
+		if !ok || info.IsDir() {
+			continue
+		}
+
+		ds = append(ds, Dump{
+			File:      info.Name(),
+			Size:      info.Size(),
+			CreatedAt: t,
+		})
+	}
+
+	sort.Slice(ds, func(i, j int) bool {
+		return ds[i].CreatedAt.After(ds[j].CreatedAt)
+	})
+	return ds, nil
+}
+
+// dump writes a dump of d to dir and removes its oldest dumps until retention are left.
+// The dump is written to a temporary file first, so a failed dump does not replace a previous one.
+func dump(dir string, retention int, d Database, p Provisioner) error {
+	userDir := filepath.Join(dir, fmt.Sprintf("%d", d.RefID))
+	err := os.MkdirAll(userDir, 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(userDir, ".dump")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	gz := gzip.NewWriter(tmp)
+	err = p.Dump(d, gz)
+	if err != nil {
+		return err
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s%s", d.Name, time.Now().UTC().Format(dumpTime), dumpExt)
+	err = os.Rename(tmp.Name(), filepath.Join(userDir, name))
+	if err != nil {
+		return err
+	}
+
+	ds, err := dumps(dir, d)
+	if err != nil {
+		return err
+	}
+	for i := retention; i < len(ds); i++ {
+		err = os.Remove(filepath.Join(userDir, ds[i].File))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
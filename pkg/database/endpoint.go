@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the database service
+type Endpoints struct {
+	CreateDatabaseEndpoint endpoint.Endpoint
+	RemoveDatabaseEndpoint endpoint.Endpoint
+	DatabasesEndpoint      endpoint.Endpoint
+	LinkDatabaseEndpoint   endpoint.Endpoint
+	UnlinkDatabaseEndpoint endpoint.Endpoint
+	DumpDatabaseEndpoint   endpoint.Endpoint
+	DumpsEndpoint          endpoint.Endpoint
+}
+
+// CreateDatabaseRequest is the request struct for the CreateDatabaseEndpoint
+type CreateDatabaseRequest struct {
+	RefID    uint      `bart:"ref"`
+	Database *Database `validate:"required"`
+}
+
+// CreateDatabaseResponse is the response struct for the CreateDatabaseEndpoint
+type CreateDatabaseResponse struct {
+	Database Database
+	Error    error
+}
+
+// MakeCreateDatabaseEndpoint creates a gokit endpoint which invokes CreateDatabase
+func MakeCreateDatabaseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateDatabaseRequest)
+		err := s.CreateDatabase(req.RefID, req.Database)
+		if err != nil {
+			return CreateDatabaseResponse{
+				Error: err,
+			}, nil
+		}
+		return CreateDatabaseResponse{
+			Database: *req.Database,
+		}, nil
+	}
+}
+
+// RemoveDatabaseRequest is the request struct for the RemoveDatabaseEndpoint
+type RemoveDatabaseRequest struct {
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required,name"`
+}
+
+// RemoveDatabaseResponse is the response struct for the RemoveDatabaseEndpoint
+type RemoveDatabaseResponse struct {
+	Error error
+}
+
+// MakeRemoveDatabaseEndpoint creates a gokit endpoint which invokes RemoveDatabase
+func MakeRemoveDatabaseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveDatabaseRequest)
+		err := s.RemoveDatabase(req.RefID, req.Name)
+		return RemoveDatabaseResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// DatabasesRequest is the request struct for the DatabasesEndpoint
+type DatabasesRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// DatabasesResponse is the response struct for the DatabasesEndpoint
+type DatabasesResponse struct {
+	Databases []Database
+	Error     error
+	Page      paging.Response
+}
+
+// MakeDatabasesEndpoint creates a gokit endpoint which invokes Databases
+func MakeDatabasesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DatabasesRequest)
+		databases := []Database{}
+		err := s.Databases(req.RefID, &databases)
+		if err != nil {
+			return DatabasesResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&databases, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return DatabasesResponse{
+			Databases: databases,
+			Page:      page,
+		}, nil
+	}
+}
+
+// LinkDatabaseRequest is the request struct for the LinkDatabaseEndpoint and UnlinkDatabaseEndpoint
+type LinkDatabaseRequest struct {
+	RefID         uint   `bart:"ref"`
+	Name          string `validate:"required,name"`
+	ContainerName string `validate:"required,name"`
+}
+
+// LinkDatabaseResponse is the response struct for the LinkDatabaseEndpoint and UnlinkDatabaseEndpoint
+type LinkDatabaseResponse struct {
+	Error error
+}
+
+// MakeLinkDatabaseEndpoint creates a gokit endpoint which invokes LinkDatabase
+func MakeLinkDatabaseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(LinkDatabaseRequest)
+		err := s.LinkDatabase(req.RefID, req.Name, req.ContainerName)
+		return LinkDatabaseResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// MakeUnlinkDatabaseEndpoint creates a gokit endpoint which invokes UnlinkDatabase
+func MakeUnlinkDatabaseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(LinkDatabaseRequest)
+		err := s.UnlinkDatabase(req.RefID, req.Name, req.ContainerName)
+		return LinkDatabaseResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// DumpDatabaseRequest is the request struct for the DumpDatabaseEndpoint
+type DumpDatabaseRequest struct {
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required,name"`
+}
+
+// DumpDatabaseResponse is the response struct for the DumpDatabaseEndpoint
+type DumpDatabaseResponse struct {
+	JobID uint
+	Error error
+}
+
+// MakeDumpDatabaseEndpoint creates a gokit endpoint which invokes DumpDatabase
+func MakeDumpDatabaseEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DumpDatabaseRequest)
+		id, err := s.DumpDatabase(req.RefID, req.Name)
+		return DumpDatabaseResponse{
+			JobID: id,
+			Error: err,
+		}, nil
+	}
+}
+
+// DumpsRequest is the request struct for the DumpsEndpoint
+type DumpsRequest struct {
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required,name"`
+}
+
+// DumpsResponse is the response struct for the DumpsEndpoint
+type DumpsResponse struct {
+	Dumps []Dump
+	Error error
+}
+
+// MakeDumpsEndpoint creates a gokit endpoint which invokes Dumps
+func MakeDumpsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DumpsRequest)
+		dumps := []Dump{}
+		err := s.Dumps(req.RefID, req.Name, &dumps)
+		return DumpsResponse{
+			Dumps: dumps,
+			Error: err,
+		}, nil
+	}
+}
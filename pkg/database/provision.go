@@ -0,0 +1,308 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+
+	// drivers of the shared servers
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+const (
+	// Postgres is the engine of PostgreSQL databases
+	Postgres = "postgres"
+	// MySQL is the engine of MySQL databases
+	MySQL = "mysql"
+)
+
+const (
+	// SchemaMode provisions a database and its user on a shared server
+	SchemaMode = "schema"
+	// ContainerMode provisions a database in a container of the user
+	ContainerMode = "container"
+)
+
+// ProvisionCommand is the command of the KMIs of database containers which creates the database and its user
+// from the KROO_DB_DATABASE, KROO_DB_USER and KROO_DB_PASSWORD variables and starts the server
+const ProvisionCommand = "provision"
+
+// ErrNoServer occurs if no server or KMI is configured for an engine
+var ErrNoServer = errors.New("engine is not available")
+
+// ExecCommand creates the commands the shared servers are dumped with, tests replace it
+var ExecCommand = exec.Command
+
+// Provisioner creates and drops the databases of a mode
+type Provisioner interface {
+	// Create creates d.Schema with the user d.User and d.Password, it sets the address of d
+	Create(d *Database) error
+
+	// Drop drops the database and user of d
+	Drop(d Database) error
+
+	// Dump writes a SQL dump of d to w
+	Dump(d Database, w io.Writer) error
+}
+
+// Containers creates the containers of the databases and injects their connection info,
+// it is satisfied by the container service
+type Containers interface {
+	CreateContainer(ctx context.Context, refID uint, kmiID uint, name string) (string, error)
+	RemoveContainer(ctx context.Context, refID uint, id string) error
+	IDForName(ctx context.Context, refID uint, name string) (string, error)
+	GetContainerKMI(ctx context.Context, containerID string) (kmi.KMI, error)
+	Execute(ctx context.Context, refID uint, id string, cmd string, env map[string]string) (string, error)
+	Attach(ctx context.Context, refID uint, id string, cmd []string, env map[string]string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+	SetEnv(ctx context.Context, refID uint, id string, key string, value string) error
+	SetLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error
+	RemoveLink(ctx context.Context, refID uint, containerID string, linkID string, linkName string, linkInterface string) error
+}
+
+// Server is a shared database server of an engine
+type Server struct {
+	// DSN connects to the server as a user which may create databases and users
+	DSN string
+	// Host and Port are the address the instances connect to
+	Host string
+	Port uint
+}
+
+// CreateStatements returns the statements creating the database and user of d.
+// The names and password are generated, so they are quoted but not escaped.
+func CreateStatements(d Database) []string {
+	if d.Engine == MySQL {
+		return []string{
+			fmt.Sprintf("CREATE DATABASE `%s`", d.Schema),
+			fmt.Sprintf("CREATE USER '%s'@'%%' IDENTIFIED BY '%s'", d.User, d.Password),
+			fmt.Sprintf("GRANT ALL PRIVILEGES ON `%s`.* TO '%s'@'%%'", d.Schema, d.User),
+		}
+	}
+	return []string{
+		fmt.Sprintf(`CREATE ROLE "%s" LOGIN PASSWORD '%s'`, d.User, d.Password),
+		fmt.Sprintf(`CREATE DATABASE "%s" OWNER "%s"`, d.Schema, d.User),
+	}
+}
+
+// DropStatements returns the statements dropping the database and user of d
+func DropStatements(d Database) []string {
+	if d.Engine == MySQL {
+		return []string{
+			fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", d.Schema),
+			fmt.Sprintf("DROP USER IF EXISTS '%s'@'%%'", d.User),
+		}
+	}
+	return []string{
+		fmt.Sprintf(`DROP DATABASE IF EXISTS "%s"`, d.Schema),
+		fmt.Sprintf(`DROP ROLE IF EXISTS "%s"`, d.User),
+	}
+}
+
+// DumpCommand returns the command dumping d and its environment, host is the address of the server
+func DumpCommand(d Database, host string) ([]string, map[string]string) {
+	port := strconv.FormatUint(uint64(d.Port), 10)
+	if d.Engine == MySQL {
+		cmd := []string{"mysqldump", "--single-transaction", "--host=" + host, "--user=" + d.User}
+		if d.Port != 0 {
+			cmd = append(cmd, "--port="+port)
+		}
+		return append(cmd, d.Schema), map[string]string{
+			"MYSQL_PWD": d.Password,
+		}
+	}
+
+	env := map[string]string{
+		"PGHOST":     host,
+		"PGUSER":     d.User,
+		"PGPASSWORD": d.Password,
+	}
+	if d.Port != 0 {
+		env["PGPORT"] = port
+	}
+	return []string{"pg_dump", "--clean", "--if-exists", "--no-owner", d.Schema}, env
+}
+
+type schemaProvisioner struct {
+	servers map[string]Server
+	mtx     sync.Mutex
+	conns   map[string]*sql.DB
+}
+
+func (p *schemaProvisioner) conn(engine string) (*sql.DB, Server, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	server, ok := p.servers[engine]
+	if !ok || server.DSN == "" {
+		return nil, Server{}, ErrNoServer
+	}
+
+	db, ok := p.conns[engine]
+	if ok {
+		return db, server, nil
+	}
+
+	db, err := sql.Open(engine, server.DSN)
+	if err != nil {
+		return nil, Server{}, err
+	}
+	p.conns[engine] = db
+	return db, server, nil
+}
+
+func (p *schemaProvisioner) exec(engine string, stmts []string) error {
+	db, _, err := p.conn(engine)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range stmts {
+		_, err = db.Exec(stmt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *schemaProvisioner) Create(d *Database) error {
+	_, server, err := p.conn(d.Engine)
+	if err != nil {
+		return err
+	}
+
+	err = p.exec(d.Engine, CreateStatements(*d))
+	if err != nil {
+		// a partly created database would block creating it again
+		p.exec(d.Engine, DropStatements(*d))
+		return err
+	}
+
+	d.Host = server.Host
+	d.Port = server.Port
+	return nil
+}
+
+func (p *schemaProvisioner) Drop(d Database) error {
+	return p.exec(d.Engine, DropStatements(d))
+}
+
+func (p *schemaProvisioner) Dump(d Database, w io.Writer) error {
+	args, env := DumpCommand(d, d.Host)
+
+	stderr := &bytes.Buffer{}
+	cmd := ExecCommand(args[0], args[1:]...)
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
+	}
+	cmd.Stdout = w
+	cmd.Stderr = stderr
+	err := cmd.Run()
+	if err != nil {
+		return fmt.Errorf("%s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// NewSchemaProvisioner returns a Provisioner creating the databases on the servers of their engines
+func NewSchemaProvisioner(servers map[string]Server) Provisioner {
+	return &schemaProvisioner{
+		servers: servers,
+		conns:   make(map[string]*sql.DB),
+	}
+}
+
+type containerProvisioner struct {
+	containers Containers
+	kmis       map[string]uint
+}
+
+func (p *containerProvisioner) Create(d *Database) error {
+	kmiID, ok := p.kmis[d.Engine]
+	if !ok || kmiID == 0 {
+		return ErrNoServer
+	}
+
+	id, err := p.containers.CreateContainer(context.Background(), d.RefID, kmiID, d.Name)
+	if err != nil {
+		return err
+	}
+
+	err = p.provision(d, id)
+	if err != nil {
+		p.containers.RemoveContainer(context.Background(), d.RefID, id)
+		return err
+	}
+
+	d.Container = id
+	return nil
+}
+
+// provision passes the credentials to the container and runs the ProvisionCommand of its KMI
+func (p *containerProvisioner) provision(d *Database, id string) error {
+	env := map[string]string{
+		"KROO_DB_DATABASE": d.Schema,
+		"KROO_DB_USER":     d.User,
+		"KROO_DB_PASSWORD": d.Password,
+	}
+	for k, v := range env {
+		err := p.containers.SetEnv(context.Background(), d.RefID, id, k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	k, err := p.containers.GetContainerKMI(context.Background(), id)
+	if err != nil {
+		return err
+	}
+
+	port, err := strconv.ParseUint(k.Interfaces.ToStringMap()[d.Engine], 10, 32)
+	if err == nil {
+		d.Port = uint(port)
+	}
+
+	cmd, ok := k.Commands.ToStringMap()[ProvisionCommand]
+	if !ok {
+		return nil
+	}
+	_, err = p.containers.Execute(context.Background(), d.RefID, id, cmd, nil)
+	return err
+}
+
+func (p *containerProvisioner) Drop(d Database) error {
+	return p.containers.RemoveContainer(context.Background(), d.RefID, d.Container)
+}
+
+func (p *containerProvisioner) Dump(d Database, w io.Writer) error {
+	args, env := DumpCommand(d, "127.0.0.1")
+
+	stderr := &bytes.Buffer{}
+	code, err := p.containers.Attach(context.Background(), d.RefID, d.Container, args, env, nil, w, stderr)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return fmt.Errorf("%s exited with %d: %s", args[0], code, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// NewContainerProvisioner returns a Provisioner creating the databases in containers of the users,
+// the containers of an engine are created from the KMI kmis[engine] and named like the database
+func NewContainerProvisioner(containers Containers, kmis map[string]uint) Provisioner {
+	return &containerProvisioner{
+		containers: containers,
+		kmis:       kmis,
+	}
+}
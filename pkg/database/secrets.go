@@ -0,0 +1,36 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+// RotateSecrets migrates the password of every database to the current master key or backend of secrets, including
+// those kept in plaintext before. It returns the number of passwords which changed.
+func RotateSecrets(db abstraction.DB, secrets secret.Backend) (int, error) {
+	ds := []Database{}
+	err := db.Find(&ds)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, d := range ds {
+		encrypted, changed, err := secrets.Migrate(d.RefID, "databases", d.Password)
+		if err != nil {
+			return rotated, fmt.Errorf("database %d: %v", d.ID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		err = abstraction.UpdateByID(db, &Database{}, d.ID, &Database{Password: encrypted})
+		if err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+	return rotated, nil
+}
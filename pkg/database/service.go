@@ -0,0 +1,526 @@
+// Package database provisions managed MySQL and PostgreSQL databases for the users, either on shared servers
+// or in containers of the users, injects their connection info into linked instances and dumps them regularly
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+var (
+	// ErrEngine occurs if the engine of a database is neither postgres nor mysql
+	ErrEngine = errors.New("engine has to be postgres or mysql")
+
+	// ErrMode occurs if databases can not be provisioned in the mode of a database
+	ErrMode = errors.New("mode is not available")
+
+	// ErrDatabaseExists occurs if a user has a database of the same name
+	ErrDatabaseExists = errors.New("database exists already")
+
+	// ErrDatabaseNotExist occurs if a database does not exist
+	ErrDatabaseNotExist = errors.New("database does not exist")
+
+	// ErrLinkExists occurs if a database is linked into an instance already
+	ErrLinkExists = errors.New("database is linked into the instance already")
+
+	// ErrLinkNotExist occurs if a database is not linked into an instance
+	ErrLinkNotExist = errors.New("database is not linked into the instance")
+)
+
+// Service DatabaseService
+type Service interface {
+	// CreateDatabase provisions a database with generated credentials, the mode defaults to the one of the options
+	CreateDatabase(refID uint, d *Database) error
+
+	// RemoveDatabase unlinks a database from its instances and drops it, its dumps are kept
+	RemoveDatabase(refID uint, name string) error
+
+	// Databases returns the databases of a user
+	Databases(refID uint, d *[]Database) error
+
+	// LinkDatabase injects the connection info of a database into an instance as KROO_DB_<NAME>_* variables
+	LinkDatabase(refID uint, name string, containerName string) error
+
+	// UnlinkDatabase removes the connection info of a database from an instance
+	UnlinkDatabase(refID uint, name string, containerName string) error
+
+	// DumpDatabase dumps a database in the background and returns the ID of the job
+	DumpDatabase(refID uint, name string) (uint, error)
+
+	// Dumps returns the dumps of a database, the latest first
+	Dumps(refID uint, name string, d *[]Dump) error
+
+	// Dump dumps the database id, or every database if id is 0, and removes the dumps exceeding the retention
+	Dump(id uint) error
+
+	// Export writes a SQL dump of a database to w
+	Export(refID uint, name string, w io.Writer) error
+}
+
+// Queue runs the dumps in the background, it is the job queue of the daemon
+type Queue interface {
+	Enqueue(typ string, payload interface{}) (uint, error)
+}
+
+// Options configure where the databases are provisioned and dumped
+type Options struct {
+	// Mode is the mode of databases created without one
+	Mode string
+	// DumpPath is the directory the dumps are written to, in a directory per user
+	DumpPath string
+	// Retention is the number of dumps kept per database
+	Retention int
+	// Secrets keeps the passwords of the databases, they are kept in plaintext if it is nil
+	Secrets secret.Backend
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db           dbAdapter
+	containers   Containers
+	queue        Queue
+	provisioners map[string]Provisioner
+	options      Options
+	mtx          *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Database{}, &Link{})
+}
+
+// EnvName returns the prefix of the variables of a database in linked instances
+func EnvName(name string) string {
+	return "KROO_DB_" + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
+
+func generate(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *service) database(refID uint, name string) (Database, error) {
+	d := Database{}
+	err := s.db.First(&d, "ref_id = ? AND name = ?", refID, name)
+	if s.db.IsNotFound(err) {
+		return Database{}, ErrDatabaseNotExist
+	}
+	if err != nil {
+		return Database{}, err
+	}
+	return d, nil
+}
+
+func (s *service) links(databaseID uint) ([]Link, error) {
+	ls := []Link{}
+	err := s.db.Find(&ls, "database_id = ?", databaseID)
+	if err != nil {
+		return nil, err
+	}
+	return ls, nil
+}
+
+// link returns the link of a database to the instance containerName, ok is false if they are not linked
+func (s *service) link(databaseID uint, containerName string) (l Link, ok bool, err error) {
+	err = s.db.First(&l, "database_id = ? AND container_name = ?", databaseID, containerName)
+	if s.db.IsNotFound(err) {
+		return Link{}, false, nil
+	}
+	return l, err == nil, err
+}
+
+// open returns d with the plaintext of its password
+func (s *service) open(d Database) (Database, error) {
+	var err error
+	d.Password, err = s.options.Secrets.Get(d.RefID, d.Password)
+	return d, err
+}
+
+func (s *service) CreateDatabase(refID uint, d *Database) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.createDatabase(refID, d)
+}
+
+func (s *service) createDatabase(refID uint, d *Database) error {
+	if d.Engine != Postgres && d.Engine != MySQL {
+		return ErrEngine
+	}
+
+	mode := d.Mode
+	if mode == "" {
+		mode = s.options.Mode
+	}
+	p, ok := s.provisioners[mode]
+	if !ok {
+		return ErrMode
+	}
+
+	_, err := s.database(refID, d.Name)
+	if err == nil {
+		return ErrDatabaseExists
+	}
+	if err != ErrDatabaseNotExist {
+		return err
+	}
+
+	id, err := generate(6)
+	if err != nil {
+		return err
+	}
+	password, err := generate(16)
+	if err != nil {
+		return err
+	}
+
+	// the name of the user is limited to 32 characters by MySQL
+	created := &Database{
+		RefID:     refID,
+		Name:      d.Name,
+		Engine:    d.Engine,
+		Mode:      mode,
+		Schema:    "kroo_" + id,
+		User:      "kroo_" + id,
+		Password:  password,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = p.Create(created)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := s.options.Secrets.Put(refID, "databases", password)
+	if err != nil {
+		p.Drop(*created)
+		return err
+	}
+
+	stored := *created
+	stored.Password = sealed
+	err = s.db.Create(&stored)
+	if err != nil {
+		p.Drop(*created)
+		s.options.Secrets.Delete(refID, sealed)
+		return err
+	}
+
+	*d = stored
+	d.Password = password
+	return nil
+}
+
+func (s *service) RemoveDatabase(refID uint, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeDatabase(refID, name)
+}
+
+func (s *service) removeDatabase(refID uint, name string) error {
+	d, err := s.database(refID, name)
+	if err != nil {
+		return err
+	}
+
+	ls, err := s.links(d.ID)
+	if err != nil {
+		return err
+	}
+	for _, l := range ls {
+		err = s.unlink(d, l)
+		if err != nil {
+			return err
+		}
+	}
+
+	p, ok := s.provisioners[d.Mode]
+	if !ok {
+		return ErrMode
+	}
+	err = p.Drop(d)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Delete(&Database{ID: d.ID})
+	if err != nil {
+		return err
+	}
+	return s.options.Secrets.Delete(refID, d.Password)
+}
+
+func (s *service) Databases(refID uint, d *[]Database) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ds := []Database{}
+	err := s.db.Find(&ds, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	for _, stored := range ds {
+		opened, err := s.open(stored)
+		if err != nil {
+			return err
+		}
+		*d = append(*d, opened)
+	}
+	return nil
+}
+
+// env returns the connection info of d, the address of a database container is injected by its link
+func env(d Database) map[string]string {
+	prefix := EnvName(d.Name)
+	e := map[string]string{
+		prefix + "_ENGINE":   d.Engine,
+		prefix + "_DATABASE": d.Schema,
+		prefix + "_USER":     d.User,
+		prefix + "_PASSWORD": d.Password,
+	}
+	if d.Mode == SchemaMode {
+		e[prefix+"_HOST"] = d.Host
+		e[prefix+"_PORT"] = fmt.Sprintf("%d", d.Port)
+	}
+	return e
+}
+
+func (s *service) LinkDatabase(refID uint, name string, containerName string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.linkDatabase(refID, name, containerName)
+}
+
+func (s *service) linkDatabase(refID uint, name string, containerName string) error {
+	d, err := s.database(refID, name)
+	if err != nil {
+		return err
+	}
+
+	_, linked, err := s.link(d.ID, containerName)
+	if err != nil {
+		return err
+	}
+	if linked {
+		return ErrLinkExists
+	}
+
+	d, err = s.open(d)
+	if err != nil {
+		return err
+	}
+
+	id, err := s.containers.IDForName(context.Background(), refID, containerName)
+	if err != nil {
+		return err
+	}
+
+	if d.Container != "" {
+		err = s.containers.SetLink(context.Background(), refID, id, d.Container, d.Name, d.Engine)
+		if err != nil {
+			return err
+		}
+	}
+
+	for k, v := range env(d) {
+		err = s.containers.SetEnv(context.Background(), refID, id, k, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.Create(&Link{
+		DatabaseID:    d.ID,
+		RefID:         refID,
+		ContainerName: containerName,
+	})
+}
+
+func (s *service) UnlinkDatabase(refID uint, name string, containerName string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	d, err := s.database(refID, name)
+	if err != nil {
+		return err
+	}
+
+	l, linked, err := s.link(d.ID, containerName)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return ErrLinkNotExist
+	}
+	return s.unlink(d, l)
+}
+
+// unlink clears the variables of d in the instance of l, an instance which was removed meanwhile is skipped
+func (s *service) unlink(d Database, l Link) error {
+	id, err := s.containers.IDForName(context.Background(), d.RefID, l.ContainerName)
+	if err == nil && id != "" {
+		if d.Container != "" {
+			err = s.containers.RemoveLink(context.Background(), d.RefID, id, d.Container, d.Name, d.Engine)
+			if err != nil {
+				return err
+			}
+		}
+
+		for k := range env(d) {
+			err = s.containers.SetEnv(context.Background(), d.RefID, id, k, "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return s.db.Delete(&Link{ID: l.ID})
+}
+
+func (s *service) DumpDatabase(refID uint, name string) (uint, error) {
+	s.mtx.Lock()
+	d, err := s.database(refID, name)
+	s.mtx.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	return s.queue.Enqueue(DumpJob, dumpPayload{
+		DatabaseID: d.ID,
+	})
+}
+
+func (s *service) Dumps(refID uint, name string, d *[]Dump) error {
+	s.mtx.Lock()
+	db, err := s.database(refID, name)
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	ds, err := dumps(s.options.DumpPath, db)
+	if err != nil {
+		return err
+	}
+
+	*d = append(*d, ds...)
+	return nil
+}
+
+// Dump does not lock the service while the databases are dumped
+func (s *service) Dump(id uint) error {
+	ds := []Database{}
+	var err error
+	s.mtx.Lock()
+	if id == 0 {
+		err = s.db.Find(&ds)
+	} else {
+		err = s.db.Find(&ds, "id = ?", id)
+	}
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var failed error
+	for _, d := range ds {
+		p, ok := s.provisioners[d.Mode]
+		if !ok {
+			failed = ErrMode
+			continue
+		}
+
+		d, err = s.open(d)
+		if err != nil {
+			failed = fmt.Errorf("database %d: %v", d.ID, err)
+			continue
+		}
+
+		err = dump(s.options.DumpPath, s.options.Retention, d, p)
+		if err != nil {
+			failed = fmt.Errorf("database %d: %v", d.ID, err)
+		}
+	}
+	return failed
+}
+
+// Export does not lock the service while the database is dumped
+func (s *service) Export(refID uint, name string, w io.Writer) error {
+	s.mtx.Lock()
+	d, err := s.database(refID, name)
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	p, ok := s.provisioners[d.Mode]
+	if !ok {
+		return ErrMode
+	}
+
+	d, err = s.open(d)
+	if err != nil {
+		return err
+	}
+	return p.Dump(d, w)
+}
+
+// NewService creates a DatabaseService, provisioners maps the modes to the Provisioners of the databases
+func NewService(db dbAdapter, containers Containers, q Queue, provisioners map[string]Provisioner, o Options) (Service, error) {
+	if o.Mode == "" {
+		o.Mode = SchemaMode
+	}
+	if o.Retention <= 0 {
+		o.Retention = 7
+	}
+	if o.Secrets == nil {
+		o.Secrets = secret.Plaintext
+	}
+
+	s := &service{
+		db:           db,
+		containers:   containers,
+		queue:        q,
+		provisioners: provisioners,
+		options:      o,
+		mtx:          &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
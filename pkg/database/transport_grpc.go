@@ -0,0 +1,344 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/database/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC DatabaseServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.DatabaseServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createDatabase: grpctransport.NewServer(
+			endpoints.CreateDatabaseEndpoint,
+			DecodeGRPCCreateDatabaseRequest,
+			EncodeGRPCCreateDatabaseResponse,
+			options...,
+		),
+
+		removeDatabase: grpctransport.NewServer(
+			endpoints.RemoveDatabaseEndpoint,
+			DecodeGRPCRemoveDatabaseRequest,
+			EncodeGRPCRemoveDatabaseResponse,
+			options...,
+		),
+
+		databases: grpctransport.NewServer(
+			endpoints.DatabasesEndpoint,
+			DecodeGRPCDatabasesRequest,
+			EncodeGRPCDatabasesResponse,
+			options...,
+		),
+
+		linkDatabase: grpctransport.NewServer(
+			endpoints.LinkDatabaseEndpoint,
+			DecodeGRPCLinkDatabaseRequest,
+			EncodeGRPCLinkDatabaseResponse,
+			options...,
+		),
+
+		unlinkDatabase: grpctransport.NewServer(
+			endpoints.UnlinkDatabaseEndpoint,
+			DecodeGRPCLinkDatabaseRequest,
+			EncodeGRPCLinkDatabaseResponse,
+			options...,
+		),
+
+		dumpDatabase: grpctransport.NewServer(
+			endpoints.DumpDatabaseEndpoint,
+			DecodeGRPCDumpDatabaseRequest,
+			EncodeGRPCDumpDatabaseResponse,
+			options...,
+		),
+
+		dumps: grpctransport.NewServer(
+			endpoints.DumpsEndpoint,
+			DecodeGRPCDumpsRequest,
+			EncodeGRPCDumpsResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createDatabase grpctransport.Handler
+	removeDatabase grpctransport.Handler
+	databases      grpctransport.Handler
+	linkDatabase   grpctransport.Handler
+	unlinkDatabase grpctransport.Handler
+	dumpDatabase   grpctransport.Handler
+	dumps          grpctransport.Handler
+}
+
+func (s *grpcServer) CreateDatabase(ctx oldcontext.Context, req *pb.CreateDatabaseRequest) (*pb.CreateDatabaseResponse, error) {
+	_, res, err := s.createDatabase.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateDatabaseResponse), nil
+}
+
+func (s *grpcServer) RemoveDatabase(ctx oldcontext.Context, req *pb.RemoveDatabaseRequest) (*pb.RemoveDatabaseResponse, error) {
+	_, res, err := s.removeDatabase.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveDatabaseResponse), nil
+}
+
+func (s *grpcServer) Databases(ctx oldcontext.Context, req *pb.DatabasesRequest) (*pb.DatabasesResponse, error) {
+	_, res, err := s.databases.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DatabasesResponse), nil
+}
+
+func (s *grpcServer) LinkDatabase(ctx oldcontext.Context, req *pb.LinkDatabaseRequest) (*pb.LinkDatabaseResponse, error) {
+	_, res, err := s.linkDatabase.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.LinkDatabaseResponse), nil
+}
+
+func (s *grpcServer) UnlinkDatabase(ctx oldcontext.Context, req *pb.LinkDatabaseRequest) (*pb.LinkDatabaseResponse, error) {
+	_, res, err := s.unlinkDatabase.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.LinkDatabaseResponse), nil
+}
+
+func (s *grpcServer) DumpDatabase(ctx oldcontext.Context, req *pb.DumpDatabaseRequest) (*pb.DumpDatabaseResponse, error) {
+	_, res, err := s.dumpDatabase.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DumpDatabaseResponse), nil
+}
+
+func (s *grpcServer) Dumps(ctx oldcontext.Context, req *pb.DumpsRequest) (*pb.DumpsResponse, error) {
+	_, res, err := s.dumps.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DumpsResponse), nil
+}
+
+// ConvertDatabase converts a Database to its protobuf representation
+func ConvertDatabase(d Database) *pb.Database {
+	return &pb.Database{
+		ID:        uint32(d.ID),
+		Name:      d.Name,
+		Engine:    d.Engine,
+		Mode:      d.Mode,
+		Schema:    d.Schema,
+		User:      d.User,
+		Password:  d.Password,
+		Host:      d.Host,
+		Port:      uint32(d.Port),
+		CreatedAt: d.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBDatabase converts a protobuf Database to a Database
+func ConvertPBDatabase(d *pb.Database) Database {
+	if d == nil {
+		return Database{}
+	}
+
+	return Database{
+		ID:        uint(d.ID),
+		Name:      d.Name,
+		Engine:    d.Engine,
+		Mode:      d.Mode,
+		Schema:    d.Schema,
+		User:      d.User,
+		Password:  d.Password,
+		Host:      d.Host,
+		Port:      uint(d.Port),
+		CreatedAt: time.Unix(d.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertDump converts a Dump to its protobuf representation
+func ConvertDump(d Dump) *pb.Dump {
+	return &pb.Dump{
+		File:      d.File,
+		Size:      d.Size,
+		CreatedAt: d.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBDump converts a protobuf Dump to a Dump
+func ConvertPBDump(d *pb.Dump) Dump {
+	if d == nil {
+		return Dump{}
+	}
+
+	return Dump{
+		File:      d.File,
+		Size:      d.Size,
+		CreatedAt: time.Unix(d.CreatedAt, 0).UTC(),
+	}
+}
+
+// DecodeGRPCCreateDatabaseRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateDatabase request to a messages/database.proto-domain createdatabase request.
+func DecodeGRPCCreateDatabaseRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateDatabaseRequest)
+	return CreateDatabaseRequest{
+		RefID: uint(req.RefID),
+		Database: &Database{
+			Name:   req.Name,
+			Engine: req.Engine,
+			Mode:   req.Mode,
+		},
+	}, nil
+}
+
+// EncodeGRPCCreateDatabaseResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain createdatabase response to a gRPC CreateDatabase response.
+func EncodeGRPCCreateDatabaseResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateDatabaseResponse)
+	gRPCRes := &pb.CreateDatabaseResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	} else {
+		gRPCRes.Database = ConvertDatabase(res.Database)
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveDatabaseRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveDatabase request to a messages/database.proto-domain removedatabase request.
+func DecodeGRPCRemoveDatabaseRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveDatabaseRequest)
+	return RemoveDatabaseRequest{
+		RefID: uint(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// EncodeGRPCRemoveDatabaseResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain removedatabase response to a gRPC RemoveDatabase response.
+func EncodeGRPCRemoveDatabaseResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveDatabaseResponse)
+	gRPCRes := &pb.RemoveDatabaseResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDatabasesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Databases request to a messages/database.proto-domain databases request.
+func DecodeGRPCDatabasesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DatabasesRequest)
+	return DatabasesRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCDatabasesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain databases response to a gRPC Databases response.
+func EncodeGRPCDatabasesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DatabasesResponse)
+	databases := make([]*pb.Database, len(res.Databases))
+	for i, d := range res.Databases {
+		databases[i] = ConvertDatabase(d)
+	}
+
+	gRPCRes := &pb.DatabasesResponse{
+		Databases: databases,
+		PageInfo:  paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCLinkDatabaseRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC LinkDatabase or UnlinkDatabase request to a messages/database.proto-domain linkdatabase request.
+func DecodeGRPCLinkDatabaseRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.LinkDatabaseRequest)
+	return LinkDatabaseRequest{
+		RefID:         uint(req.RefID),
+		Name:          req.Name,
+		ContainerName: req.ContainerName,
+	}, nil
+}
+
+// EncodeGRPCLinkDatabaseResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain linkdatabase response to a gRPC LinkDatabase or UnlinkDatabase response.
+func EncodeGRPCLinkDatabaseResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(LinkDatabaseResponse)
+	gRPCRes := &pb.LinkDatabaseResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDumpDatabaseRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC DumpDatabase request to a messages/database.proto-domain dumpdatabase request.
+func DecodeGRPCDumpDatabaseRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DumpDatabaseRequest)
+	return DumpDatabaseRequest{
+		RefID: uint(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// EncodeGRPCDumpDatabaseResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain dumpdatabase response to a gRPC DumpDatabase response.
+func EncodeGRPCDumpDatabaseResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DumpDatabaseResponse)
+	gRPCRes := &pb.DumpDatabaseResponse{
+		JobID: uint32(res.JobID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDumpsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Dumps request to a messages/database.proto-domain dumps request.
+func DecodeGRPCDumpsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DumpsRequest)
+	return DumpsRequest{
+		RefID: uint(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// EncodeGRPCDumpsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/database.proto-domain dumps response to a gRPC Dumps response.
+func EncodeGRPCDumpsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DumpsResponse)
+	dumps := make([]*pb.Dump, len(res.Dumps))
+	for i, d := range res.Dumps {
+		dumps[i] = ConvertDump(d)
+	}
+
+	gRPCRes := &pb.DumpsResponse{
+		Dumps: dumps,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
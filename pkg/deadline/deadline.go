@@ -0,0 +1,26 @@
+// Package deadline bounds the time the calls of the gRPC transport may take, so the database and
+// container operations of calls whose clients gave up stop instead of consuming resources. gRPC
+// cancels the context of a call once its client disconnected or its own deadline passed.
+package deadline
+
+import (
+	"context"
+	"time"
+
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor cancels the context of every call after timeout, an earlier deadline set by
+// the client is kept. The calls have no deadline of the server if timeout is 0.
+func UnaryServerInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
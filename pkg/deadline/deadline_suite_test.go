@@ -0,0 +1,13 @@
+package deadline_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestDeadline(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Deadline Suite")
+}
@@ -0,0 +1,58 @@
+package deadline_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/deadline"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deadline", func() {
+	info := &grpc.UnaryServerInfo{FullMethod: "/container.ContainerService/Execute"}
+
+	call := func(ctx context.Context, timeout time.Duration) (time.Time, bool) {
+		var (
+			d  time.Time
+			ok bool
+		)
+		deadline.UnaryServerInterceptor(timeout)(ctx, nil, info, func(ctx oldcontext.Context, req interface{}) (interface{}, error) {
+			d, ok = ctx.Deadline()
+			return nil, nil
+		})
+		return d, ok
+	}
+
+	It("Should set the deadline of a call", func() {
+		d, ok := call(context.Background(), time.Minute)
+		Expect(ok).To(BeTrue())
+		Expect(d).To(BeTemporally("~", time.Now().Add(time.Minute), time.Second))
+	})
+
+	It("Should keep an earlier deadline of the client", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		d, ok := call(ctx, time.Minute)
+		Expect(ok).To(BeTrue())
+		Expect(d).To(BeTemporally("~", time.Now().Add(time.Second), 500*time.Millisecond))
+	})
+
+	It("Should not set a deadline if the timeout is 0", func() {
+		_, ok := call(context.Background(), 0)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("Should cancel the context once the call returned", func() {
+		var ctx context.Context
+		deadline.UnaryServerInterceptor(time.Minute)(context.Background(), nil, info, func(c oldcontext.Context, req interface{}) (interface{}, error) {
+			ctx = c
+			return nil, nil
+		})
+		Expect(ctx.Err()).To(Equal(context.Canceled))
+	})
+})
@@ -0,0 +1,119 @@
+package deploy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Builders of the images
+const (
+	// Dockerfile builds sources with a Dockerfile in their root
+	Dockerfile = "dockerfile"
+	// Buildpack builds the other sources with cloud native buildpacks
+	Buildpack = "buildpack"
+)
+
+// Builder builds the image of a source
+type Builder interface {
+	// Build builds the source in the directory src and writes the root filesystem of the image as gzip
+	// compressed tar archive to image, the output of the build is written to log
+	Build(ctx context.Context, src string, image string, log io.Writer) error
+}
+
+// Detect returns the builder of the source in the directory src
+func Detect(src string) string {
+	_, err := os.Stat(filepath.Join(src, "Dockerfile"))
+	if err == nil {
+		return Dockerfile
+	}
+	return Buildpack
+}
+
+// run runs cmd with its output written to log
+func run(ctx context.Context, log io.Writer, cmd string, args ...string) error {
+	c := exec.CommandContext(ctx, cmd, args...)
+	c.Stdout = log
+	c.Stderr = log
+	err := c.Run()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v", cmd, args[0], err)
+	}
+	return nil
+}
+
+type dockerBuilder struct {
+	docker string
+	// build returns the command building the image tag from the source in src
+	build func(src string, tag string) []string
+}
+
+func (b dockerBuilder) Build(ctx context.Context, src string, image string, log io.Writer) error {
+	tag := fmt.Sprintf("kroo-build-%s", strings.TrimSuffix(filepath.Base(image), ".tar.gz"))
+
+	args := b.build(src, tag)
+	err := run(ctx, log, args[0], args[1:]...)
+	if err != nil {
+		return err
+	}
+	defer run(context.Background(), log, b.docker, "rmi", "-f", tag)
+
+	out := &bytes.Buffer{}
+	create := exec.CommandContext(ctx, b.docker, "create", tag)
+	create.Stdout = out
+	create.Stderr = log
+	err = create.Run()
+	if err != nil {
+		return fmt.Errorf("%s create: %v", b.docker, err)
+	}
+	id := strings.TrimSpace(out.String())
+	defer run(context.Background(), log, b.docker, "rm", "-f", id)
+
+	f, err := os.OpenFile(image, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	export := exec.CommandContext(ctx, b.docker, "export", id)
+	export.Stdout = gz
+	export.Stderr = log
+	err = export.Run()
+	if err != nil {
+		return fmt.Errorf("%s export: %v", b.docker, err)
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// NewDockerfileBuilder returns a Builder building the Dockerfile of a source with the docker binary docker
+func NewDockerfileBuilder(docker string) Builder {
+	return dockerBuilder{
+		docker: docker,
+		build: func(src string, tag string) []string {
+			return []string{docker, "build", "--tag", tag, src}
+		},
+	}
+}
+
+// NewBuildpackBuilder returns a Builder building a source with the pack binary pack and the buildpacks of the
+// builder image builder, the image is exported with the docker binary docker
+func NewBuildpackBuilder(pack string, builder string, docker string) Builder {
+	return dockerBuilder{
+		docker: docker,
+		build: func(src string, tag string) []string {
+			return []string{pack, "build", tag, "--path", src, "--builder", builder, "--pull-policy", "if-not-present"}
+		},
+	}
+}
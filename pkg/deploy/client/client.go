@@ -0,0 +1,373 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/deploy"
+	"github.com/kontainerooo/kontainer.ooo/pkg/deploy/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *deploy.Endpoints {
+
+	var DeploymentsEndpoint endpoint.Endpoint
+	{
+		DeploymentsEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"Deployments",
+			EncodeGRPCDeploymentsRequest,
+			DecodeGRPCDeploymentsResponse,
+			pb.DeploymentsResponse{},
+		).Endpoint()
+	}
+
+	var LogEndpoint endpoint.Endpoint
+	{
+		LogEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"Log",
+			EncodeGRPCLogRequest,
+			DecodeGRPCLogResponse,
+			pb.LogResponse{},
+		).Endpoint()
+	}
+
+	var CreateHookEndpoint endpoint.Endpoint
+	{
+		CreateHookEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"CreateHook",
+			EncodeGRPCCreateHookRequest,
+			DecodeGRPCCreateHookResponse,
+			pb.CreateHookResponse{},
+		).Endpoint()
+	}
+
+	var EditHookEndpoint endpoint.Endpoint
+	{
+		EditHookEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"EditHook",
+			EncodeGRPCEditHookRequest,
+			DecodeGRPCEditHookResponse,
+			pb.EditHookResponse{},
+		).Endpoint()
+	}
+
+	var HooksEndpoint endpoint.Endpoint
+	{
+		HooksEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"Hooks",
+			EncodeGRPCHooksRequest,
+			DecodeGRPCHooksResponse,
+			pb.HooksResponse{},
+		).Endpoint()
+	}
+
+	var RemoveHookEndpoint endpoint.Endpoint
+	{
+		RemoveHookEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"RemoveHook",
+			EncodeGRPCRemoveHookRequest,
+			DecodeGRPCRemoveHookResponse,
+			pb.RemoveHookResponse{},
+		).Endpoint()
+	}
+
+	var RollbackEndpoint endpoint.Endpoint
+	{
+		RollbackEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"Rollback",
+			EncodeGRPCRollbackRequest,
+			DecodeGRPCRollbackResponse,
+			pb.RollbackResponse{},
+		).Endpoint()
+	}
+
+	var GetRetentionEndpoint endpoint.Endpoint
+	{
+		GetRetentionEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"GetRetention",
+			EncodeGRPCGetRetentionRequest,
+			DecodeGRPCGetRetentionResponse,
+			pb.GetRetentionResponse{},
+		).Endpoint()
+	}
+
+	var SetRetentionEndpoint endpoint.Endpoint
+	{
+		SetRetentionEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"SetRetention",
+			EncodeGRPCSetRetentionRequest,
+			DecodeGRPCSetRetentionResponse,
+			pb.SetRetentionResponse{},
+		).Endpoint()
+	}
+
+	var PruneImagesEndpoint endpoint.Endpoint
+	{
+		PruneImagesEndpoint = grpctransport.NewClient(
+			conn,
+			"deploy.DeployService",
+			"PruneImages",
+			EncodeGRPCPruneImagesRequest,
+			DecodeGRPCPruneImagesResponse,
+			pb.PruneImagesResponse{},
+		).Endpoint()
+	}
+
+	return &deploy.Endpoints{
+		DeploymentsEndpoint:  DeploymentsEndpoint,
+		LogEndpoint:          LogEndpoint,
+		CreateHookEndpoint:   CreateHookEndpoint,
+		EditHookEndpoint:     EditHookEndpoint,
+		HooksEndpoint:        HooksEndpoint,
+		RemoveHookEndpoint:   RemoveHookEndpoint,
+		RollbackEndpoint:     RollbackEndpoint,
+		GetRetentionEndpoint: GetRetentionEndpoint,
+		SetRetentionEndpoint: SetRetentionEndpoint,
+		PruneImagesEndpoint:  PruneImagesEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCDeploymentsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain deployments request to a gRPC Deployments request.
+func EncodeGRPCDeploymentsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.DeploymentsRequest)
+	return &pb.DeploymentsRequest{
+		RefID:    uint32(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// DecodeGRPCDeploymentsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Deployments response to a messages/deploy.proto-domain deployments response.
+func DecodeGRPCDeploymentsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DeploymentsResponse)
+	deployments := make([]deploy.Deployment, len(response.Deployments))
+	for i, d := range response.Deployments {
+		deployments[i] = deploy.ConvertPBDeployment(d)
+	}
+
+	return &deploy.DeploymentsResponse{
+		Deployments: deployments,
+		Error:       getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCLogRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain log request to a gRPC Log request.
+func EncodeGRPCLogRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.LogRequest)
+	return &pb.LogRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCLogResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Log response to a messages/deploy.proto-domain log response.
+func DecodeGRPCLogResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.LogResponse)
+	return &deploy.LogResponse{
+		Log:   response.Log,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCCreateHookRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain createhook request to a gRPC CreateHook request.
+func EncodeGRPCCreateHookRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.CreateHookRequest)
+	return &pb.CreateHookRequest{
+		RefID:      uint32(req.RefID),
+		Instance:   req.Instance,
+		Provider:   req.Provider,
+		Repository: req.Repository,
+		Branch:     req.Branch,
+	}, nil
+}
+
+// DecodeGRPCCreateHookResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateHook response to a messages/deploy.proto-domain createhook response.
+func DecodeGRPCCreateHookResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateHookResponse)
+	return &deploy.CreateHookResponse{
+		Hook:  deploy.ConvertPBHook(response.Hook),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCEditHookRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain edithook request to a gRPC EditHook request.
+func EncodeGRPCEditHookRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.EditHookRequest)
+	return &pb.EditHookRequest{
+		RefID:   uint32(req.RefID),
+		ID:      uint32(req.ID),
+		Enabled: req.Enabled,
+	}, nil
+}
+
+// DecodeGRPCEditHookResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC EditHook response to a messages/deploy.proto-domain edithook response.
+func DecodeGRPCEditHookResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.EditHookResponse)
+	return &deploy.EditHookResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCHooksRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain hooks request to a gRPC Hooks request.
+func EncodeGRPCHooksRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.HooksRequest)
+	return &pb.HooksRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCHooksResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Hooks response to a messages/deploy.proto-domain hooks response.
+func DecodeGRPCHooksResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.HooksResponse)
+	hooks := make([]deploy.Hook, len(response.Hooks))
+	for i, h := range response.Hooks {
+		hooks[i] = deploy.ConvertPBHook(h)
+	}
+
+	return &deploy.HooksResponse{
+		Hooks: hooks,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveHookRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain removehook request to a gRPC RemoveHook request.
+func EncodeGRPCRemoveHookRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.RemoveHookRequest)
+	return &pb.RemoveHookRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveHookResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveHook response to a messages/deploy.proto-domain removehook response.
+func DecodeGRPCRemoveHookResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveHookResponse)
+	return &deploy.RemoveHookResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRollbackRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain rollback request to a gRPC Rollback request.
+func EncodeGRPCRollbackRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.RollbackRequest)
+	return &pb.RollbackRequest{
+		RefID:    uint32(req.RefID),
+		Instance: req.Instance,
+		ID:       uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRollbackResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Rollback response to a messages/deploy.proto-domain rollback response.
+func DecodeGRPCRollbackResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RollbackResponse)
+	return &deploy.RollbackResponse{
+		Deployment: deploy.ConvertPBDeployment(response.Deployment),
+		Error:      getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCGetRetentionRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain get retention request to a gRPC GetRetention request.
+func EncodeGRPCGetRetentionRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.GetRetentionRequest)
+	return &pb.GetRetentionRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCGetRetentionResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC GetRetention response to a messages/deploy.proto-domain get retention response.
+func DecodeGRPCGetRetentionResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.GetRetentionResponse)
+	return &deploy.GetRetentionResponse{
+		Retention: deploy.ConvertPBRetention(response.Retention),
+		Error:     getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetRetentionRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain set retention request to a gRPC SetRetention request.
+func EncodeGRPCSetRetentionRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.SetRetentionRequest)
+	return &pb.SetRetentionRequest{
+		RefID:     uint32(req.RefID),
+		Retention: deploy.ConvertRetention(req.Retention),
+	}, nil
+}
+
+// DecodeGRPCSetRetentionResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetRetention response to a messages/deploy.proto-domain set retention response.
+func DecodeGRPCSetRetentionResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetRetentionResponse)
+	return &deploy.SetRetentionResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCPruneImagesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain prune images request to a gRPC PruneImages request.
+func EncodeGRPCPruneImagesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*deploy.PruneImagesRequest)
+	return &pb.PruneImagesRequest{
+		RefID:  uint32(req.RefID),
+		DryRun: req.DryRun,
+	}, nil
+}
+
+// DecodeGRPCPruneImagesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC PruneImages response to a messages/deploy.proto-domain prune images response.
+func DecodeGRPCPruneImagesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.PruneImagesResponse)
+	deployments := make([]deploy.Deployment, len(response.Deployments))
+	for i, d := range response.Deployments {
+		deployments[i] = deploy.ConvertPBDeployment(d)
+	}
+
+	return &deploy.PruneImagesResponse{
+		Deployments: deployments,
+		Error:       getError(response.Error),
+	}, nil
+}
@@ -0,0 +1,87 @@
+package deploy
+
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Deployment is a build of a commit pushed to the repository of an instance and the update of the
+// instance with the built image
+type Deployment struct {
+	ID       uint `gorm:"primary_key"`
+	RefID    uint
+	Instance string
+	// Commit is the pushed commit which is built
+	Commit string
+	// Hook is the hook which triggered the deployment, it is 0 for git pushes to the instance
+	Hook uint
+	// Deployer is the user who pushed the commit or rolled back, it is 0 for deployments triggered by hooks
+	Deployer uint
+	// Rollback is the deployment whose image and environment are deployed again by a rollback
+	Rollback uint
+	// Builder is the builder which built the image, dockerfile or buildpack
+	Builder string
+	// Image is the rootfs archive built by a succeeded deployment, Pruned is set once it was removed
+	Image  string
+	Pruned bool
+	// Digest is the sha256 digest of the image
+	Digest string
+	// Environment is the environment of the instance the image was deployed with, Config is its sha256 digest
+	Environment abstraction.JSON `sql:"type:jsonb"`
+	Config      string
+	// State is pending, building, deploying, succeeded or failed, Error is why a deployment failed
+	State string
+	Error string
+	// CreatedAt is when the commit was pushed, FinishedAt when the deployment succeeded or failed
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// TableName sets Deployment's database table name
+func (Deployment) TableName() string {
+	return "deployments"
+}
+
+// Finished reports whether the deployment succeeded or failed
+func (d Deployment) Finished() bool {
+	return d.State == Succeeded || d.State == Failed
+}
+
+// Hook deploys an instance from the pushes to a branch of a repository hosted on GitHub or GitLab
+type Hook struct {
+	ID       uint `gorm:"primary_key"`
+	RefID    uint
+	Instance string
+	// Provider is the provider hosting the repository, github or gitlab
+	Provider string
+	// Repository is the URL the repository is fetched from, it contains the credentials of private repositories
+	Repository string
+	Branch     string
+	// Secret signs the webhooks of GitHub and is the token of the webhooks of GitLab
+	Secret string
+	// State is enabled or disabled, the pushes of disabled hooks are not deployed
+	State     string
+	CreatedAt time.Time
+}
+
+// TableName sets Hook's database table name
+func (Hook) TableName() string {
+	return "deploy_hooks"
+}
+
+// Retention decides how long the images built for the instances of a user are kept, the images are
+// pruned after every deployment and by the prune job
+type Retention struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Keep is the number of images of the succeeded deployments kept per instance
+	Keep uint
+	// MaxAge is the number of days the images of superseded deployments are kept, 0 keeps them until Keep is exceeded
+	MaxAge uint
+}
+
+// TableName sets Retention's database table name
+func (Retention) TableName() string {
+	return "deploy_retentions"
+}
@@ -0,0 +1,644 @@
+package deploy_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/deploy"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// mockContainers records the content of the images the instance web of user 1 is updated with,
+// the instance uses the latest image and the images of inUse
+type mockContainers struct {
+	mtx     sync.Mutex
+	images  []string
+	env     map[string]string
+	current string
+	inUse   []string
+}
+
+func (c *mockContainers) IDForName(ctx context.Context, refID uint, name string) (string, error) {
+	if refID == 1 && name == "web" {
+		return "c1", nil
+	}
+	return "", errors.New("container does not exist")
+}
+
+func (c *mockContainers) UpdateInstance(ctx context.Context, refID uint, id string, image string) error {
+	b, err := ioutil.ReadFile(image)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.images = append(c.images, string(b))
+	c.current = image
+	return nil
+}
+
+func (c *mockContainers) ImagesInUse(ctx context.Context) ([]string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]string{c.current}, c.inUse...), nil
+}
+
+func (c *mockContainers) Environment(ctx context.Context, refID uint, id string) (map[string]string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	env := map[string]string{}
+	for k, v := range c.env {
+		env[k] = v
+	}
+	return env, nil
+}
+
+func (c *mockContainers) SetEnvironment(ctx context.Context, refID uint, id string, env map[string]string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.env = env
+	return nil
+}
+
+func (c *mockContainers) deployed() []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return append([]string{}, c.images...)
+}
+
+// mockBuilder builds images which contain the index.html of the source
+type mockBuilder struct {
+	err error
+}
+
+func (b *mockBuilder) Build(ctx context.Context, src string, image string, log io.Writer) error {
+	fmt.Fprintln(log, "building")
+	if b.err != nil {
+		return b.err
+	}
+
+	index, err := ioutil.ReadFile(filepath.Join(src, "index.html"))
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(image, index, 0600)
+}
+
+// mockQueue runs the deployments right away
+type mockQueue struct {
+	s deploy.Service
+}
+
+func (q *mockQueue) Enqueue(typ string, payload interface{}) (uint, error) {
+	b, _ := json.Marshal(payload)
+	p := struct {
+		DeploymentID uint `json:"deploymentID"`
+	}{}
+	json.Unmarshal(b, &p)
+
+	go q.s.Deploy(context.Background(), p.DeploymentID)
+	return 1, nil
+}
+
+func git(dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=kroo", "-c", "user.email=kroo@example.com"}, args...)...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	Ω(err).ShouldNot(HaveOccurred(), string(out))
+}
+
+func commit(dir string, files map[string]string) {
+	for name, content := range files {
+		Ω(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)).Should(Succeed())
+		git(dir, "add", name)
+	}
+	git(dir, "commit", "--quiet", "--message", "update")
+}
+
+// push pushes the master branch of src to the instance name of user 1. git runs a script as receive-pack
+// which connects it to Receive through two named pipes.
+func push(s deploy.Service, src string, name string) (int, string, error) {
+	dir, err := ioutil.TempDir("", "deploy-push")
+	Ω(err).ShouldNot(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	Ω(syscall.Mkfifo(in, 0600)).Should(Succeed())
+	Ω(syscall.Mkfifo(out, 0600)).Should(Succeed())
+
+	script := filepath.Join(dir, "receive-pack")
+	Ω(ioutil.WriteFile(script, []byte(fmt.Sprintf("#!/bin/sh\ncat %s &\nexec cat > %s\n", out, in)), 0700)).Should(Succeed())
+
+	pushed := make(chan struct{})
+	go func() {
+		defer close(pushed)
+		cmd := exec.Command("git", "push", "--receive-pack="+script, filepath.Join(dir, "remote.git"), "master")
+		cmd.Dir = src
+		cmd.Run()
+	}()
+
+	r, err := os.OpenFile(in, os.O_RDONLY, 0)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer r.Close()
+	w, err := os.OpenFile(out, os.O_WRONLY, 0)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	stderr := &bytes.Buffer{}
+	code, err := s.Receive(context.Background(), 1, name, r, w, stderr)
+	w.Close()
+	<-pushed
+	return code, stderr.String(), err
+}
+
+// githubDelivery returns a push to the branch master of repository delivered by GitHub, signed with secret
+func githubDelivery(secret, repository, commit string) deploy.Delivery {
+	payload := []byte(fmt.Sprintf(`{"ref":"refs/heads/master","after":"%s","repository":{"clone_url":"%s"}}`, commit, repository))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return deploy.Delivery{
+		Event:     "push",
+		Signature: "sha256=" + hex.EncodeToString(mac.Sum(nil)),
+		Payload:   payload,
+	}
+}
+
+func head(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	Ω(err).ShouldNot(HaveOccurred())
+	return strings.TrimSpace(string(out))
+}
+
+var _ = Describe("Deploy", func() {
+	var (
+		root       string
+		src        string
+		containers *mockContainers
+		builder    *mockBuilder
+		keep       uint
+		secrets    *secret.Store
+		db         *testutils.MockDB
+		s          deploy.Service
+	)
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "deploy")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		src = filepath.Join(root, "src")
+		Ω(os.Mkdir(src, 0755)).Should(Succeed())
+		git(src, "init", "--quiet")
+		git(src, "symbolic-ref", "HEAD", "refs/heads/master")
+		keep = 1
+
+		keys, err := secret.NewKeyring(map[string]string{"a": base64.StdEncoding.EncodeToString(make([]byte, secret.DataKeySize))}, "a")
+		Ω(err).ShouldNot(HaveOccurred())
+		secrets = secret.NewStore(keys)
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		db = testutils.NewMockDB()
+		containers = &mockContainers{env: map[string]string{"GREETING": "hello"}}
+		builder = &mockBuilder{}
+		queue := &mockQueue{}
+		s, err = deploy.NewService(db, containers, queue, map[string]deploy.Builder{
+			deploy.Dockerfile: builder,
+			deploy.Buildpack:  builder,
+		}, deploy.Options{
+			Root:    filepath.Join(root, "deploy"),
+			Branch:  "master",
+			Keep:    keep,
+			Secrets: secrets,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		queue.s = s
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(root)
+	})
+
+	deployments := func() []deploy.Deployment {
+		ds := []deploy.Deployment{}
+		Ω(s.Deployments(1, "web", &ds)).Should(Succeed())
+		return ds
+	}
+
+	Describe("Receive", func() {
+		It("Should build and deploy pushes of the deploy branch", func() {
+			commit(src, map[string]string{"index.html": "v1"})
+
+			code, log, err := push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(Equal(0))
+			Ω(log).Should(ContainSubstring("with the buildpack builder"))
+			Ω(log).Should(ContainSubstring("building"))
+			Ω(log).Should(ContainSubstring("Deployed"))
+			Ω(containers.deployed()).Should(Equal([]string{"v1"}))
+
+			ds := deployments()
+			Ω(ds).Should(HaveLen(1))
+			Ω(ds[0].State).Should(Equal(deploy.Succeeded))
+			Ω(ds[0].Builder).Should(Equal(deploy.Buildpack))
+			Ω(ds[0].Commit).Should(HaveLen(40))
+
+			stored, err := s.Log(1, ds[0].ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(log).Should(ContainSubstring(string(stored)))
+		})
+
+		It("Should build sources with a Dockerfile with the dockerfile builder", func() {
+			commit(src, map[string]string{"index.html": "v1", "Dockerfile": "FROM scratch"})
+
+			code, log, err := push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(Equal(0))
+			Ω(log).Should(ContainSubstring("with the dockerfile builder"))
+			Ω(deployments()[0].Builder).Should(Equal(deploy.Dockerfile))
+		})
+
+		It("Should report failed builds to the pusher", func() {
+			builder.err = errors.New("no buildpack detected")
+			commit(src, map[string]string{"index.html": "v1"})
+
+			code, log, err := push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(Equal(1))
+			Ω(log).Should(ContainSubstring("no buildpack detected"))
+			Ω(containers.deployed()).Should(BeEmpty())
+
+			ds := deployments()
+			Ω(ds[0].State).Should(Equal(deploy.Failed))
+			Ω(ds[0].Error).Should(Equal("no buildpack detected"))
+		})
+
+		It("Should keep the latest images", func() {
+			commit(src, map[string]string{"index.html": "v1"})
+			code, _, err := push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(Equal(0))
+
+			commit(src, map[string]string{"index.html": "v2"})
+			code, _, err = push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(code).Should(Equal(0))
+			Ω(containers.deployed()).Should(Equal([]string{"v1", "v2"}))
+
+			ds := deployments()
+			Ω(ds).Should(HaveLen(2))
+			Ω(ds[0].Pruned).Should(BeFalse())
+			Ω(ds[0].Image).Should(BeAnExistingFile())
+			Ω(ds[1].Pruned).Should(BeTrue())
+			Ω(ds[1].Image).ShouldNot(BeAnExistingFile())
+		})
+
+		It("Should refuse pushes to unknown instances", func() {
+			_, err := s.Receive(context.Background(), 1, "db", &bytes.Buffer{}, ioutil.Discard, ioutil.Discard)
+			Ω(err).Should(Equal(deploy.ErrInstanceNotExist))
+		})
+	})
+
+	Describe("Hooks", func() {
+		var (
+			server     *httptest.Server
+			repository string
+		)
+
+		BeforeEach(func() {
+			commit(src, map[string]string{"index.html": "v1"})
+
+			// the source is served like by a provider with git's smart http protocol
+			git, err := exec.LookPath("git")
+			Ω(err).ShouldNot(HaveOccurred())
+			server = httptest.NewServer(&cgi.Handler{
+				Path: git,
+				Args: []string{"http-backend"},
+				Env:  []string{"GIT_PROJECT_ROOT=" + root, "GIT_HTTP_EXPORT_ALL=1"},
+			})
+			repository = server.URL + "/src/.git"
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		createHook := func(provider string) deploy.Hook {
+			h := deploy.Hook{
+				Instance:   "web",
+				Provider:   provider,
+				Repository: repository,
+			}
+			Ω(s.CreateHook(context.Background(), 1, &h)).Should(Succeed())
+			return h
+		}
+
+		finished := func(id uint) deploy.Deployment {
+			d := deploy.Deployment{}
+			Eventually(func() bool {
+				for _, deployment := range deployments() {
+					if deployment.ID == id {
+						d = deployment
+					}
+				}
+				return d.Finished()
+			}, 10*time.Second).Should(BeTrue())
+			return d
+		}
+
+		It("Should check hooks", func() {
+			h := deploy.Hook{Instance: "db", Provider: deploy.GitHub, Repository: repository}
+			Ω(s.CreateHook(context.Background(), 1, &h)).Should(Equal(deploy.ErrInstanceNotExist))
+
+			h = deploy.Hook{Instance: "web", Provider: "bitbucket", Repository: repository}
+			Ω(s.CreateHook(context.Background(), 1, &h)).Should(Equal(deploy.ErrInvalidProvider))
+
+			h = deploy.Hook{Instance: "web", Provider: deploy.GitHub, Repository: "git@github.com:kontainerooo/web.git"}
+			Ω(s.CreateHook(context.Background(), 1, &h)).Should(Equal(deploy.ErrInvalidRepository))
+		})
+
+		It("Should deploy pushes announced by GitHub", func() {
+			h := createHook(deploy.GitHub)
+			Ω(h.Branch).Should(Equal("master"))
+			Ω(h.Secret).ShouldNot(BeEmpty())
+
+			id, err := s.Trigger(h.ID, githubDelivery(h.Secret, repository, head(src)))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(id).ShouldNot(BeZero())
+
+			d := finished(id)
+			Ω(d.State).Should(Equal(deploy.Succeeded))
+			Ω(d.Hook).Should(Equal(h.ID))
+			Ω(d.Commit).Should(Equal(head(src)))
+			Ω(containers.deployed()).Should(Equal([]string{"v1"}))
+
+			log, err := s.Log(1, id)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(log)).Should(ContainSubstring("Fetching master of " + repository))
+
+			By("ignoring deliveries of deployed commits again")
+			id, err = s.Trigger(h.ID, githubDelivery(h.Secret, repository, head(src)))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(id).Should(BeZero())
+		})
+
+		It("Should deploy pushes announced by GitLab", func() {
+			h := createHook(deploy.GitLab)
+
+			id, err := s.Trigger(h.ID, deploy.Delivery{
+				Event:     "Push Hook",
+				Signature: h.Secret,
+				Payload:   []byte(fmt.Sprintf(`{"ref":"refs/heads/master","after":"%s","project":{"git_http_url":"%s"}}`, head(src), repository)),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(finished(id).State).Should(Equal(deploy.Succeeded))
+		})
+
+		It("Should reject deliveries which are not signed with the secret of the hook", func() {
+			h := createHook(deploy.GitHub)
+			_, err := s.Trigger(h.ID, githubDelivery("secret", repository, head(src)))
+			Ω(err).Should(Equal(deploy.ErrSignature))
+		})
+
+		It("Should reject pushes to other repositories", func() {
+			h := createHook(deploy.GitHub)
+			_, err := s.Trigger(h.ID, githubDelivery(h.Secret, "https://github.com/kontainerooo/web.git", head(src)))
+			Ω(err).Should(Equal(deploy.ErrRepositoryMismatch))
+		})
+
+		It("Should not deploy pushes of disabled hooks", func() {
+			h := createHook(deploy.GitHub)
+			Ω(s.EditHook(1, h.ID, false)).Should(Succeed())
+
+			id, err := s.Trigger(h.ID, githubDelivery(h.Secret, repository, head(src)))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(id).Should(BeZero())
+
+			hs := []deploy.Hook{}
+			Ω(s.Hooks(1, &hs)).Should(Succeed())
+			Ω(hs).Should(HaveLen(1))
+			Ω(hs[0].State).Should(Equal(deploy.Disabled))
+			Ω(hs[0].Secret).Should(BeEmpty())
+
+			Ω(s.EditHook(1, h.ID, true)).Should(Succeed())
+			id, err = s.Trigger(h.ID, githubDelivery(h.Secret, repository, head(src)))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(id).ShouldNot(BeZero())
+			finished(id)
+		})
+
+		It("Should keep the secrets of the hooks encrypted", func() {
+			h := createHook(deploy.GitHub)
+
+			stored := []deploy.Hook{}
+			Ω(db.Find(&stored)).Should(Succeed())
+			Ω(stored).Should(HaveLen(1))
+			Ω(secret.IsEncrypted(stored[0].Secret)).Should(BeTrue())
+			Ω(secrets.Decrypt(stored[0].Secret)).Should(Equal(h.Secret))
+
+			keys, err := secret.NewKeyring(map[string]string{
+				"a": base64.StdEncoding.EncodeToString(make([]byte, secret.DataKeySize)),
+				"b": base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, secret.DataKeySize)),
+			}, "b")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(deploy.RotateSecrets(db, secret.NewStore(keys))).Should(Equal(1))
+
+			stored = []deploy.Hook{}
+			Ω(db.Find(&stored)).Should(Succeed())
+			Ω(secret.KeyID(stored[0].Secret)).Should(Equal("b"))
+			Ω(secret.NewStore(keys).Decrypt(stored[0].Secret)).Should(Equal(h.Secret))
+			Ω(deploy.RotateSecrets(db, secret.NewStore(keys))).Should(Equal(0))
+		})
+
+		It("Should answer the webhooks", func() {
+			h := createHook(deploy.GitHub)
+			handler := deploy.Handler(s, log.NewNopLogger())
+
+			deliver := func(path string, event string, d deploy.Delivery) int {
+				req := httptest.NewRequest("POST", path, bytes.NewReader(d.Payload))
+				req.Header.Set("X-GitHub-Event", event)
+				req.Header.Set("X-Hub-Signature-256", d.Signature)
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, req)
+				return rec.Code
+			}
+
+			ping := githubDelivery(h.Secret, repository, head(src))
+			Ω(deliver(fmt.Sprintf("%s%d", deploy.HookPath, h.ID), "ping", ping)).Should(Equal(http.StatusNoContent))
+			Ω(deliver(deploy.HookPath+"99", "push", ping)).Should(Equal(http.StatusNotFound))
+			Ω(deliver(fmt.Sprintf("%s%d", deploy.HookPath, h.ID), "push", githubDelivery("secret", repository, head(src)))).Should(Equal(http.StatusBadRequest))
+			Ω(deliver(fmt.Sprintf("%s%d", deploy.HookPath, h.ID), "push", ping)).Should(Equal(http.StatusAccepted))
+			finished(deployments()[0].ID)
+		})
+	})
+
+	Describe("Rollback", func() {
+		BeforeEach(func() {
+			keep = 2
+		})
+
+		It("Should record the image and the environment of deployments", func() {
+			commit(src, map[string]string{"index.html": "v1"})
+			_, _, err := push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			d := deployments()[0]
+			Ω(d.Deployer).Should(Equal(uint(1)))
+			Ω(d.Digest).Should(HavePrefix("sha256:"))
+			Ω(d.Config).Should(HavePrefix("sha256:"))
+			Ω(d.Environment.ToStringMap()).Should(Equal(map[string]string{"GREETING": "hello"}))
+		})
+
+		It("Should deploy the image and the environment of an earlier deployment again", func() {
+			commit(src, map[string]string{"index.html": "v1"})
+			_, _, err := push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			containers.SetEnvironment(context.Background(), 1, "c1", map[string]string{"GREETING": "hi"})
+			commit(src, map[string]string{"index.html": "v2"})
+			_, _, err = push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			ds := deployments()
+			Ω(ds[0].Config).ShouldNot(Equal(ds[1].Config))
+
+			d, err := s.RollbackDeployment(context.Background(), 1, "web", ds[1].ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(d.Rollback).Should(Equal(ds[1].ID))
+			Ω(d.Commit).Should(Equal(ds[1].Commit))
+
+			Eventually(func() bool {
+				return deployments()[0].Finished()
+			}, 10*time.Second).Should(BeTrue())
+
+			rollback := deployments()[0]
+			Ω(rollback.State).Should(Equal(deploy.Succeeded))
+			Ω(rollback.Digest).Should(Equal(ds[1].Digest))
+			Ω(rollback.Config).Should(Equal(ds[1].Config))
+			Ω(containers.deployed()).Should(Equal([]string{"v1", "v2", "v1"}))
+			Ω(containers.env).Should(Equal(map[string]string{"GREETING": "hello"}))
+
+			By("keeping the image of the rollback when the deployment it rolled back to is pruned")
+			Ω(deployments()[2].Pruned).Should(BeTrue())
+			Ω(rollback.Image).Should(BeAnExistingFile())
+
+			log, err := s.Log(1, rollback.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(log)).Should(ContainSubstring(fmt.Sprintf("Rolling back to deployment %d", ds[1].ID)))
+		})
+
+		It("Should only roll back to succeeded deployments of the instance", func() {
+			builder.err = errors.New("no buildpack detected")
+			commit(src, map[string]string{"index.html": "v1"})
+			_, _, err := push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			id := deployments()[0].ID
+			_, err = s.RollbackDeployment(context.Background(), 1, "web", id)
+			Ω(err).Should(Equal(deploy.ErrNotRollbackable))
+
+			_, err = s.RollbackDeployment(context.Background(), 1, "db", id)
+			Ω(err).Should(Equal(deploy.ErrDeploymentNotExist))
+		})
+	})
+
+	Describe("Retention", func() {
+		BeforeEach(func() {
+			keep = 3
+		})
+
+		pushes := func(versions ...string) []deploy.Deployment {
+			for _, v := range versions {
+				commit(src, map[string]string{"index.html": v})
+				code, _, err := push(s, src, "web")
+				Ω(err).ShouldNot(HaveOccurred())
+				Ω(code).Should(Equal(0))
+			}
+			return deployments()
+		}
+
+		It("Should keep the images of the retention of the user", func() {
+			r, err := s.GetRetention(1)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(r.Keep).Should(Equal(uint(3)))
+
+			Ω(s.SetRetention(1, deploy.Retention{Keep: 0})).Should(Equal(deploy.ErrInvalidRetention))
+			Ω(s.SetRetention(1, deploy.Retention{Keep: 1, MaxAge: 7})).Should(Succeed())
+			Ω(s.SetRetention(1, deploy.Retention{Keep: 1})).Should(Succeed())
+			r, err = s.GetRetention(1)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(r).Should(Equal(deploy.Retention{ID: r.ID, RefID: 1, Keep: 1}))
+
+			ds := pushes("v1", "v2")
+			Ω(ds[0].Pruned).Should(BeFalse())
+			Ω(ds[1].Pruned).Should(BeTrue())
+			Ω(ds[1].Image).ShouldNot(BeAnExistingFile())
+		})
+
+		It("Should preview and remove the images older than the max age which are not in use", func() {
+			ds := pushes("v1", "v2", "v3")
+			Ω(s.SetRetention(1, deploy.Retention{Keep: 3, MaxAge: 7})).Should(Succeed())
+
+			for _, d := range ds {
+				db.Where("id = ?", d.ID)
+				db.Update(&deploy.Deployment{}, &deploy.Deployment{FinishedAt: time.Now().UTC().AddDate(0, 0, -8)})
+			}
+			containers.inUse = []string{ds[1].Image}
+
+			preview, err := s.PruneImages(context.Background(), 1, true)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(preview).Should(HaveLen(1))
+			Ω(preview[0].ID).Should(Equal(ds[2].ID))
+			Ω(ds[2].Image).Should(BeAnExistingFile())
+
+			pruned, err := s.PruneImages(context.Background(), 1, false)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(pruned).Should(HaveLen(1))
+			Ω(ds[2].Image).ShouldNot(BeAnExistingFile())
+			Ω(ds[1].Image).Should(BeAnExistingFile())
+			Ω(deployments()[2].Pruned).Should(BeTrue())
+
+			By("protecting the image the instance runs")
+			Ω(ds[0].Image).Should(BeAnExistingFile())
+		})
+	})
+
+	Describe("Remove", func() {
+		It("Should remove the repository and the deployments of an instance", func() {
+			commit(src, map[string]string{"index.html": "v1"})
+			_, _, err := push(s, src, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			image := deployments()[0].Image
+
+			Ω(s.RemoveRepository(1, "web")).Should(Succeed())
+			Ω(deployments()).Should(BeEmpty())
+			Ω(image).ShouldNot(BeAnExistingFile())
+			Ω(filepath.Join(root, "deploy", "repositories", "1", "web.git")).ShouldNot(BeADirectory())
+		})
+	})
+})
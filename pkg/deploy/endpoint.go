@@ -0,0 +1,268 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints is a struct which collects all endpoints for the deploy service
+type Endpoints struct {
+	DeploymentsEndpoint  endpoint.Endpoint
+	LogEndpoint          endpoint.Endpoint
+	CreateHookEndpoint   endpoint.Endpoint
+	EditHookEndpoint     endpoint.Endpoint
+	HooksEndpoint        endpoint.Endpoint
+	RemoveHookEndpoint   endpoint.Endpoint
+	RollbackEndpoint     endpoint.Endpoint
+	GetRetentionEndpoint endpoint.Endpoint
+	SetRetentionEndpoint endpoint.Endpoint
+	PruneImagesEndpoint  endpoint.Endpoint
+}
+
+// DeploymentsRequest is the request struct for the DeploymentsEndpoint
+type DeploymentsRequest struct {
+	RefID uint `bart:"ref"`
+	// Instance limits the deployments to the ones of an instance
+	Instance string
+}
+
+// DeploymentsResponse is the response struct for the DeploymentsEndpoint
+type DeploymentsResponse struct {
+	Deployments []Deployment
+	Error       error
+}
+
+// MakeDeploymentsEndpoint creates a gokit endpoint which invokes Deployments
+func MakeDeploymentsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DeploymentsRequest)
+		deployments := []Deployment{}
+		err := s.Deployments(req.RefID, req.Instance, &deployments)
+		return DeploymentsResponse{
+			Deployments: deployments,
+			Error:       err,
+		}, nil
+	}
+}
+
+// LogRequest is the request struct for the LogEndpoint
+type LogRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint `validate:"required"`
+}
+
+// LogResponse is the response struct for the LogEndpoint
+type LogResponse struct {
+	Log   []byte
+	Error error
+}
+
+// MakeLogEndpoint creates a gokit endpoint which invokes Log
+func MakeLogEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(LogRequest)
+		log, err := s.Log(req.RefID, req.ID)
+		return LogResponse{
+			Log:   log,
+			Error: err,
+		}, nil
+	}
+}
+
+// CreateHookRequest is the request struct for the CreateHookEndpoint
+type CreateHookRequest struct {
+	RefID      uint   `bart:"ref"`
+	Instance   string `validate:"required"`
+	Provider   string `validate:"required"`
+	Repository string `validate:"required"`
+	Branch     string
+}
+
+// CreateHookResponse is the response struct for the CreateHookEndpoint
+type CreateHookResponse struct {
+	Hook  Hook
+	Error error
+}
+
+// MakeCreateHookEndpoint creates a gokit endpoint which invokes CreateHook
+func MakeCreateHookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateHookRequest)
+		h := &Hook{
+			Instance:   req.Instance,
+			Provider:   req.Provider,
+			Repository: req.Repository,
+			Branch:     req.Branch,
+		}
+		err := s.CreateHook(ctx, req.RefID, h)
+		return CreateHookResponse{
+			Hook:  *h,
+			Error: err,
+		}, nil
+	}
+}
+
+// EditHookRequest is the request struct for the EditHookEndpoint
+type EditHookRequest struct {
+	RefID   uint `bart:"ref"`
+	ID      uint `validate:"required"`
+	Enabled bool
+}
+
+// EditHookResponse is the response struct for the EditHookEndpoint
+type EditHookResponse struct {
+	Error error
+}
+
+// MakeEditHookEndpoint creates a gokit endpoint which invokes EditHook
+func MakeEditHookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(EditHookRequest)
+		err := s.EditHook(req.RefID, req.ID, req.Enabled)
+		return EditHookResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// HooksRequest is the request struct for the HooksEndpoint
+type HooksRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// HooksResponse is the response struct for the HooksEndpoint
+type HooksResponse struct {
+	Hooks []Hook
+	Error error
+}
+
+// MakeHooksEndpoint creates a gokit endpoint which invokes Hooks
+func MakeHooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(HooksRequest)
+		hooks := []Hook{}
+		err := s.Hooks(req.RefID, &hooks)
+		return HooksResponse{
+			Hooks: hooks,
+			Error: err,
+		}, nil
+	}
+}
+
+// RemoveHookRequest is the request struct for the RemoveHookEndpoint
+type RemoveHookRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint `validate:"required"`
+}
+
+// RemoveHookResponse is the response struct for the RemoveHookEndpoint
+type RemoveHookResponse struct {
+	Error error
+}
+
+// MakeRemoveHookEndpoint creates a gokit endpoint which invokes RemoveHook
+func MakeRemoveHookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveHookRequest)
+		err := s.RemoveHook(req.RefID, req.ID)
+		return RemoveHookResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RollbackRequest is the request struct for the RollbackEndpoint
+type RollbackRequest struct {
+	RefID    uint   `bart:"ref"`
+	Instance string `validate:"required"`
+	ID       uint   `validate:"required"`
+}
+
+// RollbackResponse is the response struct for the RollbackEndpoint
+type RollbackResponse struct {
+	Deployment Deployment
+	Error      error
+}
+
+// MakeRollbackEndpoint creates a gokit endpoint which invokes RollbackDeployment
+func MakeRollbackEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RollbackRequest)
+		d, err := s.RollbackDeployment(ctx, req.RefID, req.Instance, req.ID)
+		return RollbackResponse{
+			Deployment: d,
+			Error:      err,
+		}, nil
+	}
+}
+
+// GetRetentionRequest is the request struct for the GetRetentionEndpoint
+type GetRetentionRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// GetRetentionResponse is the response struct for the GetRetentionEndpoint
+type GetRetentionResponse struct {
+	Retention Retention
+	Error     error
+}
+
+// MakeGetRetentionEndpoint creates a gokit endpoint which invokes GetRetention
+func MakeGetRetentionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetRetentionRequest)
+		r, err := s.GetRetention(req.RefID)
+		return GetRetentionResponse{
+			Retention: r,
+			Error:     err,
+		}, nil
+	}
+}
+
+// SetRetentionRequest is the request struct for the SetRetentionEndpoint
+type SetRetentionRequest struct {
+	RefID     uint `bart:"ref"`
+	Retention Retention
+}
+
+// SetRetentionResponse is the response struct for the SetRetentionEndpoint
+type SetRetentionResponse struct {
+	Error error
+}
+
+// MakeSetRetentionEndpoint creates a gokit endpoint which invokes SetRetention
+func MakeSetRetentionEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetRetentionRequest)
+		err := s.SetRetention(req.RefID, req.Retention)
+		return SetRetentionResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// PruneImagesRequest is the request struct for the PruneImagesEndpoint
+type PruneImagesRequest struct {
+	RefID uint `bart:"ref"`
+	// DryRun only returns the deployments whose images would be removed
+	DryRun bool
+}
+
+// PruneImagesResponse is the response struct for the PruneImagesEndpoint
+type PruneImagesResponse struct {
+	Deployments []Deployment
+	Error       error
+}
+
+// MakePruneImagesEndpoint creates a gokit endpoint which invokes PruneImages
+func MakePruneImagesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PruneImagesRequest)
+		deployments, err := s.PruneImages(ctx, req.RefID, req.DryRun)
+		return PruneImagesResponse{
+			Deployments: deployments,
+			Error:       err,
+		}, nil
+	}
+}
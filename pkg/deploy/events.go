@@ -0,0 +1,55 @@
+package deploy
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// EventGroup is the group the deploy services subscribe in, so every event is handled once
+const EventGroup = "deploy"
+
+// Subscribe removes the repository of an instance once it was removed and the repositories of deleted users
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	removed, err := bus.Subscribe(events.ContainerRemoved, EventGroup, func(e events.Event) error {
+		c := events.ContainerEvent{}
+		err := e.Decode(&c)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+		if c.Name == "" || c.Replica {
+			return nil
+		}
+
+		err = s.RemoveRepository(c.RefID, c.Name)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", c.RefID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := bus.Subscribe(events.UserDeleted, EventGroup, func(e events.Event) error {
+		u := events.UserEvent{}
+		err := e.Decode(&u)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+
+		err = s.RemoveRepositories(u.ID)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", u.ID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		removed.Unsubscribe()
+		return nil, err
+	}
+
+	return []events.Subscription{removed, deleted}, nil
+}
@@ -0,0 +1,76 @@
+package deploy
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// HookPath is the path the webhooks of the hooks are served at by the gateway, followed by the ID of the hook
+const HookPath = "/v1/deploy/hooks/"
+
+// maxPayload limits the body of a webhook, pushes with many commits are larger than most events
+const maxPayload = 1 << 22
+
+// Handler returns the handler of the webhooks of GitHub and GitLab. Webhooks with an invalid signature
+// are rejected with 400, webhooks which could not be handled with 500, so the provider delivers them
+// again. Webhooks triggering a deployment are answered with 202, the others with 204.
+func Handler(s Service, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, err := strconv.ParseUint(strings.TrimPrefix(r.URL.Path, HookPath), 10, 32)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		payload, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPayload))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		d := Delivery{
+			Payload: payload,
+		}
+		if event := r.Header.Get("X-GitHub-Event"); event != "" {
+			d.Event = event
+			d.Signature = r.Header.Get("X-Hub-Signature-256")
+		} else {
+			d.Event = r.Header.Get("X-Gitlab-Event")
+			d.Signature = r.Header.Get("X-Gitlab-Token")
+		}
+
+		deployment, err := s.Trigger(uint(id), d)
+		switch err {
+		case nil:
+		case ErrHookNotExist:
+			http.NotFound(w, r)
+			return
+		case ErrSignature, ErrInvalidPayload, ErrRepositoryMismatch:
+			level.Warn(logger).Log("hook", id, "remote", r.RemoteAddr, "err", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		default:
+			level.Error(logger).Log("hook", id, "event", d.Event, "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if deployment == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		level.Info(logger).Log("hook", id, "deployment", deployment)
+		w.WriteHeader(http.StatusAccepted)
+	})
+}
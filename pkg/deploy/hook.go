@@ -0,0 +1,415 @@
+package deploy
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Providers hosting the repositories of hooks
+const (
+	GitHub = "github"
+	GitLab = "gitlab"
+)
+
+// States of a hook
+const (
+	Enabled  = "enabled"
+	Disabled = "disabled"
+)
+
+// deletedCommit is the commit a deleted branch is pushed to
+const deletedCommit = "0000000000000000000000000000000000000000"
+
+var (
+	// ErrHookNotExist occurs if a hook does not exist
+	ErrHookNotExist = errors.New("hook does not exist")
+
+	// ErrInvalidProvider occurs if a hook is created for a provider which is not supported
+	ErrInvalidProvider = errors.New("provider has to be github or gitlab")
+
+	// ErrInvalidRepository occurs if the repository of a hook is not an http or https URL
+	ErrInvalidRepository = errors.New("repository has to be an http or https URL")
+
+	// ErrSignature occurs if the signature or the token of a webhook is invalid
+	ErrSignature = errors.New("invalid signature")
+
+	// ErrInvalidPayload occurs if the payload of a push webhook can not be decoded
+	ErrInvalidPayload = errors.New("invalid payload")
+
+	// ErrRepositoryMismatch occurs if a webhook announces a push to another repository than the one of its hook
+	ErrRepositoryMismatch = errors.New("push is not of the repository of the hook")
+)
+
+// Delivery is a webhook of a provider
+type Delivery struct {
+	// Event is the event of the webhook, e.g. push for GitHub or Push Hook for GitLab
+	Event string
+	// Signature is the signature of the payload for GitHub or the token for GitLab
+	Signature string
+	Payload   []byte
+}
+
+// push is a push announced by a webhook
+type push struct {
+	Branch string
+	Commit string
+	// Repositories are the URLs the repository is known under
+	Repositories []string
+}
+
+// verify checks the signature of a delivery for the hook h with its decrypted secret key
+func verify(h Hook, key string, d Delivery) error {
+	switch h.Provider {
+	case GitHub:
+		mac := hmac.New(sha256.New, []byte(key))
+		mac.Write(d.Payload)
+		if hmac.Equal([]byte(d.Signature), []byte("sha256="+hex.EncodeToString(mac.Sum(nil)))) {
+			return nil
+		}
+	case GitLab:
+		if subtle.ConstantTimeCompare([]byte(d.Signature), []byte(key)) == 1 {
+			return nil
+		}
+	}
+	return ErrSignature
+}
+
+// parsePush returns the push announced by a delivery of provider, ok is false for the other events
+func parsePush(provider string, d Delivery) (p push, ok bool, err error) {
+	payload := struct {
+		Ref        string `json:"ref"`
+		After      string `json:"after"`
+		Repository struct {
+			CloneURL string `json:"clone_url"`
+			HTMLURL  string `json:"html_url"`
+		} `json:"repository"`
+		Project struct {
+			HTTPURL string `json:"git_http_url"`
+			WebURL  string `json:"web_url"`
+		} `json:"project"`
+	}{}
+
+	switch {
+	case provider == GitHub && d.Event == "push":
+	case provider == GitLab && d.Event == "Push Hook":
+	default:
+		return p, false, nil
+	}
+
+	err = json.Unmarshal(d.Payload, &payload)
+	if err != nil {
+		return p, false, ErrInvalidPayload
+	}
+
+	if !strings.HasPrefix(payload.Ref, "refs/heads/") || payload.After == "" || payload.After == deletedCommit {
+		return p, false, nil
+	}
+
+	p = push{
+		Branch: strings.TrimPrefix(payload.Ref, "refs/heads/"),
+		Commit: payload.After,
+	}
+	if provider == GitHub {
+		p.Repositories = []string{payload.Repository.CloneURL, payload.Repository.HTMLURL}
+	} else {
+		p.Repositories = []string{payload.Project.HTTPURL, payload.Project.WebURL}
+	}
+	return p, true, nil
+}
+
+// normalizeRepository returns the host and the path of a repository URL without its credentials,
+// a trailing slash and the .git suffix
+func normalizeRepository(repository string) string {
+	u, err := url.Parse(repository)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host + strings.TrimSuffix(strings.TrimSuffix(u.Path, "/"), ".git"))
+}
+
+// redact returns a repository URL without its credentials
+func redact(repository string) string {
+	u, err := url.Parse(repository)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	return u.String()
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *service) hooks(refID uint, name string) ([]Hook, error) {
+	hs := []Hook{}
+	err := s.db.FindOrdered(&hs, "id", 0, conditions(refID, name)...)
+	if err != nil {
+		return nil, err
+	}
+	return hs, nil
+}
+
+// hook returns the hook id of a user, or of any user if refID is 0
+func (s *service) hook(refID uint, id uint) (Hook, error) {
+	h := Hook{}
+	var err error
+	if refID == 0 {
+		err = s.db.First(&h, "id = ?", id)
+	} else {
+		err = s.db.First(&h, "id = ? AND ref_id = ?", id, refID)
+	}
+	if s.db.IsNotFound(err) {
+		return Hook{}, ErrHookNotExist
+	}
+	if err != nil {
+		return Hook{}, err
+	}
+	return h, nil
+}
+
+// CreateHook does not lock the service while it checks the instance
+func (s *service) CreateHook(ctx context.Context, refID uint, h *Hook) error {
+	id, err := s.containers.IDForName(ctx, refID, h.Instance)
+	if err != nil || id == "" {
+		return ErrInstanceNotExist
+	}
+
+	if h.Provider != GitHub && h.Provider != GitLab {
+		return ErrInvalidProvider
+	}
+
+	u, err := url.Parse(h.Repository)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidRepository
+	}
+
+	if h.Branch == "" {
+		h.Branch = s.options.Branch
+	}
+
+	sec, err := newSecret()
+	if err != nil {
+		return err
+	}
+
+	encrypted, err := s.options.Secrets.Put(refID, "hooks", sec)
+	if err != nil {
+		return err
+	}
+
+	hook := &Hook{
+		RefID:      refID,
+		Instance:   h.Instance,
+		Provider:   h.Provider,
+		Repository: h.Repository,
+		Branch:     h.Branch,
+		Secret:     encrypted,
+		State:      Enabled,
+		CreatedAt:  time.Now().UTC(),
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err = s.db.Create(hook)
+	if err != nil {
+		return err
+	}
+
+	*h = *hook
+	h.Secret = sec
+	return nil
+}
+
+func (s *service) EditHook(refID uint, id uint, enabled bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	_, err := s.hook(refID, id)
+	if err != nil {
+		return err
+	}
+
+	state := Disabled
+	if enabled {
+		state = Enabled
+	}
+
+	s.db.Begin()
+	err = s.db.Where("id = ?", id)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Update(&Hook{}, &Hook{State: state})
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+	return nil
+}
+
+func (s *service) Hooks(refID uint, h *[]Hook) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	hs, err := s.hooks(refID, "")
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range hs {
+		hook.Secret = ""
+		hook.Repository = redact(hook.Repository)
+		*h = append(*h, hook)
+	}
+	return nil
+}
+
+func (s *service) RemoveHook(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	h, err := s.hook(refID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Delete(&Hook{ID: id})
+	if err != nil {
+		return err
+	}
+	return s.options.Secrets.Delete(refID, h.Secret)
+}
+
+func (s *service) Trigger(id uint, d Delivery) (uint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	h, err := s.hook(0, id)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err := s.options.Secrets.Get(h.RefID, h.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	err = verify(h, key, d)
+	if err != nil {
+		return 0, err
+	}
+
+	if h.State != Enabled {
+		return 0, nil
+	}
+
+	p, ok, err := parsePush(h.Provider, d)
+	if err != nil || !ok || p.Branch != h.Branch {
+		return 0, err
+	}
+
+	matches := false
+	for _, r := range p.Repositories {
+		if r != "" && normalizeRepository(r) == normalizeRepository(h.Repository) {
+			matches = true
+		}
+	}
+	if !matches {
+		return 0, ErrRepositoryMismatch
+	}
+
+	// providers deliver webhooks again if they did not get an answer in time
+	ds, err := s.deployments(h.RefID, h.Instance)
+	if err != nil {
+		return 0, err
+	}
+	for _, deployment := range ds {
+		if deployment.Commit == p.Commit && deployment.State != Failed {
+			return 0, nil
+		}
+	}
+
+	deployment := Deployment{
+		RefID:     h.RefID,
+		Instance:  h.Instance,
+		Commit:    p.Commit,
+		Hook:      h.ID,
+		State:     Pending,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = s.db.Create(&deployment)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.queue.Enqueue(DeployJob, deployPayload{
+		DeploymentID: deployment.ID,
+	})
+	if err != nil {
+		abstraction.UpdateByID(s.db, &Deployment{}, deployment.ID, &Deployment{
+			State:      Failed,
+			Error:      err.Error(),
+			FinishedAt: time.Now().UTC(),
+		})
+		return 0, err
+	}
+	return deployment.ID, nil
+}
+
+// fetch fetches the branch of the hook which triggered d into the repository of its instance,
+// deployments of git pushes are already in the repository
+func (s *service) fetch(ctx context.Context, d Deployment, log io.Writer) error {
+	if d.Hook == 0 {
+		return nil
+	}
+
+	s.mtx.Lock()
+	h, err := s.hook(d.RefID, d.Hook)
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	repo, err := s.initRepository(ctx, d.RefID, d.Instance)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(log, "-----> Fetching %s of %s\n", h.Branch, redact(h.Repository))
+	return run(ctx, log, "git", "--git-dir", repo, "fetch", "--quiet", "--no-tags", h.Repository, fmt.Sprintf("+refs/heads/%s:refs/heads/%s", h.Branch, s.options.Branch))
+}
+
+// initRepository creates the repository of an instance if it does not exist yet and returns it
+func (s *service) initRepository(ctx context.Context, refID uint, name string) (string, error) {
+	repo := s.repository(refID, name)
+	_, err := os.Stat(repo)
+	if os.IsNotExist(err) {
+		err = run(ctx, ioutil.Discard, "git", "init", "--quiet", "--bare", repo)
+	}
+	return repo, err
+}
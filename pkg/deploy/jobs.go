@@ -0,0 +1,50 @@
+package deploy
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+// DeployJob is the type of the job building and deploying a deployment
+const DeployJob = "deploy.build"
+
+// PruneJob is the type of the job removing the images exceeding the retention of their users
+const PruneJob = "deploy.prune"
+
+// JobOptions run one deployment at a time, so the deployments of an instance are applied in the order
+// they were pushed. Failed deployments are not retried, they are pushed again.
+var JobOptions = jobs.Options{
+	Workers:     1,
+	MaxAttempts: 1,
+}
+
+type deployPayload struct {
+	DeploymentID uint `json:"deploymentID"`
+}
+
+// DeployHandler returns the handler of DeployJob. Deployments which failed to build or to update their
+// instance are not errors of the job, they are reported to the pusher and in the deployment.
+func DeployHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		p := deployPayload{}
+		err := j.Decode(&p)
+		if err != nil {
+			return err
+		}
+
+		d, err := s.Deploy(ctx, p.DeploymentID)
+		if err == ErrDeploymentNotExist || d.State == Failed {
+			return nil
+		}
+		return err
+	}
+}
+
+// PruneHandler returns the handler of PruneJob
+func PruneHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		_, err := s.PruneImages(ctx, 0, false)
+		return err
+	}
+}
@@ -0,0 +1,177 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// ErrInvalidRetention occurs if a retention does not keep the image of at least one deployment per instance
+var ErrInvalidRetention = errors.New("at least one image has to be kept per instance")
+
+// expired returns the deployments of an instance, the latest first, whose images exceed the retention r. The
+// images of superseded deployments are kept up to r.Keep and for r.MaxAge days, the image of the latest succeeded
+// deployment and the images used by containers are kept regardless.
+func expired(ds []Deployment, r Retention, inUse map[string]bool, now time.Time) []Deployment {
+	maxAge := time.Duration(r.MaxAge) * 24 * time.Hour
+
+	kept := uint(0)
+	latest := true
+	e := []Deployment{}
+	for _, d := range ds {
+		if d.Image == "" || d.Pruned {
+			continue
+		}
+
+		switch {
+		case latest:
+			latest = false
+			kept++
+		case inUse[d.Image]:
+		case kept < r.Keep && (r.MaxAge == 0 || now.Sub(d.FinishedAt) < maxAge):
+			kept++
+		default:
+			e = append(e, d)
+		}
+	}
+	return e
+}
+
+// retentions returns the retentions of a user, or of every user if refID is 0, by user
+func (s *service) retentions(refID uint) (map[uint]Retention, error) {
+	all := []Retention{}
+	err := s.db.Find(&all, conditions(refID, "")...)
+	if err != nil {
+		return nil, err
+	}
+
+	rs := make(map[uint]Retention)
+	for _, r := range all {
+		rs[r.RefID] = r
+	}
+	return rs, nil
+}
+
+// retention returns the retention of a user, users without one keep the latest Keep images
+func (s *service) retention(rs map[uint]Retention, refID uint) Retention {
+	r, ok := rs[refID]
+	if !ok {
+		r = Retention{
+			RefID: refID,
+			Keep:  s.options.Keep,
+		}
+	}
+	return r
+}
+
+// imagesInUse returns the images containers are created from, it does not lock the service
+func (s *service) imagesInUse(ctx context.Context) (map[string]bool, error) {
+	images, err := s.containers.ImagesInUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inUse := make(map[string]bool)
+	for _, image := range images {
+		inUse[image] = true
+	}
+	return inUse, nil
+}
+
+// prune removes the images of the instance name, or of every instance if it is empty, exceeding the retention of
+// their user and returns their deployments, the latest first. The images are only returned with dryRun.
+func (s *service) prune(refID uint, name string, inUse map[string]bool, dryRun bool) ([]Deployment, error) {
+	ds, err := s.deployments(refID, name)
+	if err != nil {
+		return nil, err
+	}
+
+	rs, err := s.retentions(refID)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make(map[string][]Deployment)
+	for _, d := range ds {
+		key := fmt.Sprintf("%d/%s", d.RefID, d.Instance)
+		instances[key] = append(instances[key], d)
+	}
+
+	now := time.Now().UTC()
+	pruned := make(map[uint]bool)
+	for _, instance := range instances {
+		for _, d := range expired(instance, s.retention(rs, instance[0].RefID), inUse, now) {
+			pruned[d.ID] = true
+		}
+	}
+
+	p := []Deployment{}
+	for _, d := range ds {
+		if !pruned[d.ID] {
+			continue
+		}
+
+		if !dryRun {
+			err = os.Remove(d.Image)
+			if err != nil && !os.IsNotExist(err) {
+				return p, err
+			}
+
+			err = abstraction.UpdateByID(s.db, &Deployment{}, d.ID, &Deployment{Pruned: true})
+			if err != nil {
+				return p, err
+			}
+			d.Pruned = true
+		}
+		p = append(p, d)
+	}
+	return p, nil
+}
+
+func (s *service) PruneImages(ctx context.Context, refID uint, dryRun bool) ([]Deployment, error) {
+	inUse, err := s.imagesInUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.prune(refID, "", inUse, dryRun)
+}
+
+func (s *service) GetRetention(refID uint) (Retention, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rs, err := s.retentions(refID)
+	if err != nil {
+		return Retention{}, err
+	}
+	return s.retention(rs, refID), nil
+}
+
+// SetRetention replaces the retention of a user, it is removed and created again since MaxAge may be reset to 0
+func (s *service) SetRetention(refID uint, r Retention) error {
+	if r.Keep < 1 {
+		return ErrInvalidRetention
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err := s.db.Delete(&Retention{}, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Create(&Retention{
+		RefID:  refID,
+		Keep:   r.Keep,
+		MaxAge: r.MaxAge,
+	})
+}
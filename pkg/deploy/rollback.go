@@ -0,0 +1,170 @@
+package deploy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/bart"
+)
+
+// imageDigest returns the sha256 digest of an image
+func imageDigest(image string) (string, error) {
+	f, err := os.Open(image)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// configDigest returns the sha256 digest of an environment, the variables are encoded in the order of their names
+func configDigest(env map[string]string) string {
+	b, _ := json.Marshal(env)
+	h := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+// copyImage copies the image src to dst, it is linked if both are on the same file system
+func copyImage(src string, dst string) error {
+	err := os.MkdirAll(filepath.Dir(dst), 0700)
+	if err != nil {
+		return err
+	}
+
+	err = os.Link(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// restore returns the builder, a copy of the image and the environment of the deployment a rollback rolls
+// back to. The image is copied, so it is pruned with the rollback and not with the deployment it was built by.
+func (s *service) restore(d Deployment, log io.Writer) (string, string, map[string]string, error) {
+	s.mtx.Lock()
+	target, err := s.deployment(d.RefID, d.Rollback)
+	s.mtx.Unlock()
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if target.State != Succeeded || target.Pruned {
+		return "", "", nil, ErrNotRollbackable
+	}
+	fmt.Fprintf(log, "-----> Rolling back to deployment %d of %s\n", target.ID, short(target.Commit))
+
+	image := s.imageFile(d)
+	err = copyImage(target.Image, image)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return target.Builder, image, target.Environment.ToStringMap(), nil
+}
+
+// updateInstance updates the instance of d with image and returns the environment it was updated with. The
+// environment of the instance is replaced with env unless it is nil, it is restored if the update failed.
+func (s *service) updateInstance(ctx context.Context, d Deployment, image string, env map[string]string) (map[string]string, error) {
+	id, err := s.containers.IDForName(ctx, d.RefID, d.Instance)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.containers.Environment(ctx, d.RefID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if env == nil {
+		return current, s.containers.UpdateInstance(ctx, d.RefID, id, image)
+	}
+
+	err = s.containers.SetEnvironment(ctx, d.RefID, id, env)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.containers.UpdateInstance(ctx, d.RefID, id, image)
+	if err != nil {
+		s.containers.SetEnvironment(ctx, d.RefID, id, current)
+		return nil, err
+	}
+	return env, nil
+}
+
+func (s *service) RollbackDeployment(ctx context.Context, refID uint, name string, id uint) (Deployment, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	target, err := s.deployment(refID, id)
+	if err != nil || target.Instance != name {
+		return Deployment{}, ErrDeploymentNotExist
+	}
+
+	if target.State != Succeeded || target.Pruned {
+		return Deployment{}, ErrNotRollbackable
+	}
+
+	deployer, ok := bart.Caller(ctx)
+	if !ok {
+		deployer = refID
+	}
+
+	d := Deployment{
+		RefID:     refID,
+		Instance:  name,
+		Commit:    target.Commit,
+		Deployer:  deployer,
+		Rollback:  target.ID,
+		State:     Pending,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = s.db.Create(&d)
+	if err != nil {
+		return Deployment{}, err
+	}
+
+	_, err = s.queue.Enqueue(DeployJob, deployPayload{
+		DeploymentID: d.ID,
+	})
+	if err != nil {
+		abstraction.UpdateByID(s.db, &Deployment{}, d.ID, &Deployment{
+			State:      Failed,
+			Error:      err.Error(),
+			FinishedAt: time.Now().UTC(),
+		})
+		return Deployment{}, err
+	}
+	return d, nil
+}
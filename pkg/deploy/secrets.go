@@ -0,0 +1,45 @@
+package deploy
+
+import (
+	"fmt"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+// RotateSecrets migrates the secret of every hook to the current master key or backend of secrets, including
+// those kept in plaintext before. It returns the number of secrets which changed.
+func RotateSecrets(db abstraction.DB, secrets secret.Backend) (int, error) {
+	hs := []Hook{}
+	err := db.Find(&hs)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, h := range hs {
+		encrypted, changed, err := secrets.Migrate(h.RefID, "hooks", h.Secret)
+		if err != nil {
+			return rotated, fmt.Errorf("hook %d: %v", h.ID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		db.Begin()
+		err = db.Where("id = ?", h.ID)
+		if err != nil {
+			db.Rollback()
+			return rotated, err
+		}
+
+		err = db.Update(&Hook{}, &Hook{Secret: encrypted})
+		if err != nil {
+			db.Rollback()
+			return rotated, err
+		}
+		db.Commit()
+		rotated++
+	}
+	return rotated, nil
+}
@@ -0,0 +1,658 @@
+// Package deploy gives every instance a git remote. Pushes of its deploy branch are built into an image,
+// with the Dockerfile of the source or with buildpacks, in a background job and the instance is updated
+// with the image without downtime. The log of the build and the update is streamed back to the pusher.
+// Instances can also be deployed from repositories on GitHub or GitLab, whose webhooks trigger the
+// deployments of the pushes to a branch.
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+// States of a deployment
+const (
+	// Pending deployments wait for the job building them
+	Pending = "pending"
+	// Building deployments build the image of their commit
+	Building = "building"
+	// Deploying deployments update their instance with the built image
+	Deploying = "deploying"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+// followInterval is the interval the log of a deployment is streamed to the pusher in
+const followInterval = 500 * time.Millisecond
+
+var (
+	// ErrInstanceNotExist occurs if a user pushes to an instance which does not exist
+	ErrInstanceNotExist = errors.New("instance does not exist")
+
+	// ErrDeploymentNotExist occurs if a deployment does not exist
+	ErrDeploymentNotExist = errors.New("deployment does not exist")
+
+	// ErrNotRollbackable occurs if an instance is rolled back to a deployment which did not succeed or
+	// whose image was pruned
+	ErrNotRollbackable = errors.New("only succeeded deployments whose image is kept can be rolled back to")
+)
+
+// Service DeployService
+type Service interface {
+	// Receive receives a git push to the repository of the instance name with git receive-pack. If the
+	// deploy branch was updated, a deployment of its head is enqueued and its log is written to stderr
+	// until it finished. It returns the exit code of the push.
+	Receive(ctx context.Context, refID uint, name string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+
+	// Deployments returns the deployments of the instance name, or of every instance if it is empty, the latest first
+	Deployments(refID uint, name string, d *[]Deployment) error
+
+	// Log returns the log of the build and the update of a deployment
+	Log(refID uint, id uint) ([]byte, error)
+
+	// Deploy builds the image of a deployment and updates its instance with it. The deployment is
+	// returned even if it failed.
+	Deploy(ctx context.Context, id uint) (Deployment, error)
+
+	// CreateHook creates a hook deploying the instance of h from its repository and returns it with the secret
+	// of its webhooks, the branch defaults to the deploy branch
+	CreateHook(ctx context.Context, refID uint, h *Hook) error
+
+	// EditHook enables or disables a hook
+	EditHook(refID uint, id uint, enabled bool) error
+
+	// Hooks returns the hooks of a user without their secrets
+	Hooks(refID uint, h *[]Hook) error
+
+	// RemoveHook removes a hook, its deployments are kept
+	RemoveHook(refID uint, id uint) error
+
+	// Trigger verifies a webhook delivered for the hook id and enqueues a deployment of the pushed commit if
+	// the hook is enabled and it announces a push to the branch of the hook. It returns the deployment, which
+	// is 0 if the webhook does not trigger one.
+	Trigger(id uint, d Delivery) (uint, error)
+
+	// RollbackDeployment enqueues a deployment of the image and the environment of the deployment id of the
+	// instance name and returns it
+	RollbackDeployment(ctx context.Context, refID uint, name string, id uint) (Deployment, error)
+
+	// GetRetention returns the retention of the images of a user
+	GetRetention(refID uint) (Retention, error)
+
+	// SetRetention sets the retention of the images of a user
+	SetRetention(refID uint, r Retention) error
+
+	// PruneImages removes the images of a user, or of every user if refID is 0, exceeding their retention and
+	// returns the deployments they were built by. With dryRun the images are only returned.
+	PruneImages(ctx context.Context, refID uint, dryRun bool) ([]Deployment, error)
+
+	// RemoveRepository removes the repository, the hooks, the deployments and the images of an instance
+	RemoveRepository(refID uint, name string) error
+
+	// RemoveRepositories removes the repositories, the hooks, the deployments, the images and the retention of a user
+	RemoveRepositories(refID uint) error
+}
+
+// Containers updates the instances with the built images, it is satisfied by the container service
+type Containers interface {
+	IDForName(ctx context.Context, refID uint, name string) (string, error)
+	UpdateInstance(ctx context.Context, refID uint, id string, image string) error
+	Environment(ctx context.Context, refID uint, id string) (map[string]string, error)
+	SetEnvironment(ctx context.Context, refID uint, id string, env map[string]string) error
+	ImagesInUse(ctx context.Context) ([]string, error)
+}
+
+// Queue runs the deployments in the background, it is the job queue of the daemon
+type Queue interface {
+	Enqueue(typ string, payload interface{}) (uint, error)
+}
+
+// Options configure the deployments
+type Options struct {
+	// Root is the directory the repositories, the images and the logs of the deployments are kept in
+	Root string
+	// Branch is the branch whose pushes are deployed
+	Branch string
+	// Keep is the number of images of the succeeded deployments kept per instance of the users without a
+	// retention, it is at least 1
+	Keep uint
+	// Secrets keeps the secrets of the hooks, they are kept in plaintext if it is nil
+	Secrets secret.Backend
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db         dbAdapter
+	containers Containers
+	queue      Queue
+	builders   map[string]Builder
+	options    Options
+	mtx        *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Deployment{}, &Hook{}, &Retention{})
+}
+
+// repository returns the bare repository of an instance
+func (s *service) repository(refID uint, name string) string {
+	return filepath.Join(s.options.Root, "repositories", fmt.Sprintf("%d", refID), name+".git")
+}
+
+// imageFile returns the rootfs archive a deployment is built into
+func (s *service) imageFile(d Deployment) string {
+	return filepath.Join(s.options.Root, "images", fmt.Sprintf("%d", d.RefID), d.Instance, fmt.Sprintf("%d.tar.gz", d.ID))
+}
+
+func (s *service) logFile(id uint) string {
+	return filepath.Join(s.options.Root, "logs", fmt.Sprintf("%d.log", id))
+}
+
+// deployments returns the deployments matching the non-zero arguments, the latest first
+// conditions returns the inline conditions selecting the rows of the instance name of a user, zero values
+// select the rows of every user or instance
+func conditions(refID uint, name string) []interface{} {
+	cs := []string{}
+	args := []interface{}{}
+	if refID != 0 {
+		cs = append(cs, "ref_id = ?")
+		args = append(args, refID)
+	}
+	if name != "" {
+		cs = append(cs, "instance = ?")
+		args = append(args, name)
+	}
+
+	if len(cs) == 0 {
+		return nil
+	}
+	return append([]interface{}{strings.Join(cs, " AND ")}, args...)
+}
+
+func (s *service) deployments(refID uint, name string) ([]Deployment, error) {
+	ds := []Deployment{}
+	err := s.db.FindOrdered(&ds, "id DESC", 0, conditions(refID, name)...)
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// deployment returns the deployment id of a user, or of any user if refID is 0
+func (s *service) deployment(refID uint, id uint) (Deployment, error) {
+	d := Deployment{}
+	var err error
+	if refID == 0 {
+		err = s.db.First(&d, "id = ?", id)
+	} else {
+		err = s.db.First(&d, "id = ? AND ref_id = ?", id, refID)
+	}
+	if s.db.IsNotFound(err) {
+		return Deployment{}, ErrDeploymentNotExist
+	}
+	if err != nil {
+		return Deployment{}, err
+	}
+	return d, nil
+}
+
+// head returns the commit the deploy branch of repo points to, it is empty if the branch does not exist
+func (s *service) head(repo string) string {
+	out, err := exec.Command("git", "--git-dir", repo, "rev-parse", "-q", "--verify", "refs/heads/"+s.options.Branch).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// short abbreviates a commit
+func short(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// receivePack runs git receive-pack in repo. It reads from a pipe, so it does not wait for the pusher to
+// close its input once it exited, which exec.Cmd does for other readers.
+func receivePack(ctx context.Context, repo string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	defer w.Close()
+	go func() {
+		io.Copy(w, stdin)
+		w.Close()
+	}()
+
+	cmd := exec.CommandContext(ctx, "git", "receive-pack", repo)
+	cmd.Stdin = r
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err = cmd.Run()
+	if exit, ok := err.(*exec.ExitError); ok {
+		return exit.Sys().(syscall.WaitStatus).ExitStatus(), nil
+	}
+	return 0, err
+}
+
+// Receive does not lock the service while the push is received and the deployment is followed
+func (s *service) Receive(ctx context.Context, refID uint, name string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	id, err := s.containers.IDForName(ctx, refID, name)
+	if err != nil || id == "" {
+		return 0, ErrInstanceNotExist
+	}
+
+	repo, err := s.initRepository(ctx, refID, name)
+	if err != nil {
+		return 0, err
+	}
+
+	before := s.head(repo)
+	code, err := receivePack(ctx, repo, stdin, stdout, stderr)
+	if err != nil || code != 0 {
+		return code, err
+	}
+
+	after := s.head(repo)
+	if after == "" || after == before {
+		fmt.Fprintf(stderr, "-----> %s was not updated, nothing to deploy\n", s.options.Branch)
+		return 0, nil
+	}
+
+	s.mtx.Lock()
+	d := Deployment{
+		RefID:     refID,
+		Instance:  name,
+		Commit:    after,
+		Deployer:  refID,
+		State:     Pending,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = s.db.Create(&d)
+	s.mtx.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	fmt.Fprintf(stderr, "-----> Deploying %s to %s as deployment %d\n", short(after), name, d.ID)
+	_, err = s.queue.Enqueue(DeployJob, deployPayload{
+		DeploymentID: d.ID,
+	})
+	if err != nil {
+		s.mtx.Lock()
+		abstraction.UpdateByID(s.db, &Deployment{}, d.ID, &Deployment{
+			State:      Failed,
+			Error:      err.Error(),
+			FinishedAt: time.Now().UTC(),
+		})
+		s.mtx.Unlock()
+		return 0, err
+	}
+
+	return s.follow(ctx, d.ID, stderr)
+}
+
+// follow writes the log of the deployment id to w until it finished and returns the exit code of the push.
+// The deployment goes on if the pusher goes away.
+func (s *service) follow(ctx context.Context, id uint, w io.Writer) (int, error) {
+	offset := int64(0)
+	for {
+		s.mtx.Lock()
+		d, err := s.deployment(0, id)
+		s.mtx.Unlock()
+		if err != nil {
+			return 0, err
+		}
+
+		// the log is complete once the deployment finished
+		offset = s.copyLog(id, offset, w)
+		if d.Finished() {
+			if d.State == Failed {
+				return 1, nil
+			}
+			return 0, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(followInterval):
+		}
+	}
+}
+
+// copyLog copies the log of the deployment id from offset to w and returns the offset of its end
+func (s *service) copyLog(id uint, offset int64, w io.Writer) int64 {
+	f, err := os.Open(s.logFile(id))
+	if err != nil {
+		return offset
+	}
+	defer f.Close()
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return offset
+	}
+
+	n, _ := io.Copy(w, f)
+	return offset + n
+}
+
+func (s *service) Deployments(refID uint, name string, d *[]Deployment) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ds, err := s.deployments(refID, name)
+	if err != nil {
+		return err
+	}
+
+	*d = append(*d, ds...)
+	return nil
+}
+
+func (s *service) Log(refID uint, id uint) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	_, err := s.deployment(refID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadFile(s.logFile(id))
+	if os.IsNotExist(err) {
+		return []byte{}, nil
+	}
+	return b, err
+}
+
+// build checks out the commit of d and builds its image, it returns the builder and the image
+func (s *service) build(ctx context.Context, d Deployment, log io.Writer) (string, string, error) {
+	dir, err := ioutil.TempDir("", "kroo-deploy")
+	if err != nil {
+		return "", "", err
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "src")
+	err = os.Mkdir(src, 0755)
+	if err != nil {
+		return "", "", err
+	}
+
+	archive := filepath.Join(dir, "src.tar")
+	err = run(ctx, log, "git", "--git-dir", s.repository(d.RefID, d.Instance), "archive", "--format=tar", "--output", archive, d.Commit)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = run(ctx, log, "tar", "-xf", archive, "-C", src)
+	if err != nil {
+		return "", "", err
+	}
+
+	name := Detect(src)
+	builder, ok := s.builders[name]
+	if !ok {
+		return "", "", fmt.Errorf("the %s builder is not available", name)
+	}
+	fmt.Fprintf(log, "-----> Building %s with the %s builder\n", short(d.Commit), name)
+
+	image := s.imageFile(d)
+	err = os.MkdirAll(filepath.Dir(image), 0700)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = builder.Build(ctx, src, image, log)
+	if err != nil {
+		os.Remove(image)
+		return "", "", err
+	}
+	return name, image, nil
+}
+
+// Deploy does not lock the service while the image is built and the instance is updated
+func (s *service) Deploy(ctx context.Context, id uint) (Deployment, error) {
+	s.mtx.Lock()
+	d, err := s.deployment(0, id)
+	if err == nil {
+		err = abstraction.UpdateByID(s.db, &Deployment{}, id, &Deployment{State: Building})
+	}
+	s.mtx.Unlock()
+	if err != nil {
+		return d, err
+	}
+	d.State = Building
+
+	log, err := os.OpenFile(s.logFile(id), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return d, err
+	}
+	defer log.Close()
+
+	var (
+		builder, image string
+		env            map[string]string
+		failed         error
+	)
+	if d.Rollback != 0 {
+		builder, image, env, failed = s.restore(d, log)
+	} else {
+		failed = s.fetch(ctx, d, log)
+		if failed == nil {
+			builder, image, failed = s.build(ctx, d, log)
+		}
+	}
+	if failed == nil {
+		s.mtx.Lock()
+		failed = abstraction.UpdateByID(s.db, &Deployment{}, id, &Deployment{State: Deploying, Builder: builder})
+		s.mtx.Unlock()
+	}
+
+	var digest string
+	if failed == nil {
+		digest, failed = imageDigest(image)
+	}
+
+	if failed == nil {
+		fmt.Fprintf(log, "-----> Updating %s\n", d.Instance)
+		env, failed = s.updateInstance(ctx, d, image, env)
+		if failed != nil {
+			os.Remove(image)
+		}
+	}
+
+	changes := &Deployment{
+		State:      Succeeded,
+		Builder:    builder,
+		FinishedAt: time.Now().UTC(),
+	}
+	if failed != nil {
+		changes.State = Failed
+		changes.Error = failed.Error()
+		fmt.Fprintf(log, "!      %s\n", failed)
+	} else {
+		changes.Image = image
+		changes.Digest = digest
+		changes.Environment = abstraction.NewJSONFromMap(env)
+		changes.Config = configDigest(env)
+		fmt.Fprintf(log, "-----> Deployed %s to %s\n", short(d.Commit), d.Instance)
+	}
+	d.State, d.Builder, d.Image, d.Error, d.FinishedAt = changes.State, changes.Builder, changes.Image, changes.Error, changes.FinishedAt
+	d.Digest, d.Environment, d.Config = changes.Digest, changes.Environment, changes.Config
+
+	inUse, inUseErr := s.imagesInUse(ctx)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err = abstraction.UpdateByID(s.db, &Deployment{}, id, changes)
+	if err != nil {
+		return d, err
+	}
+
+	// the replicas of the instance are created from the image of the latest deployment, so it is always kept
+	err = inUseErr
+	if err == nil {
+		_, err = s.prune(d.RefID, d.Instance, inUse, false)
+	}
+	if failed != nil {
+		return d, failed
+	}
+	return d, err
+}
+
+func (s *service) RemoveRepository(refID uint, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeRepository(refID, name)
+}
+
+func (s *service) removeRepository(refID uint, name string) error {
+	hs, err := s.hooks(refID, name)
+	if err != nil {
+		return err
+	}
+
+	for _, h := range hs {
+		err = s.db.Delete(&Hook{ID: h.ID})
+		if err != nil {
+			return err
+		}
+
+		err = s.options.Secrets.Delete(refID, h.Secret)
+		if err != nil {
+			return err
+		}
+	}
+
+	ds, err := s.deployments(refID, name)
+	if err != nil {
+		return err
+	}
+
+	for _, d := range ds {
+		os.Remove(s.logFile(d.ID))
+		err = s.db.Delete(&Deployment{ID: d.ID})
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.RemoveAll(filepath.Join(s.options.Root, "images", fmt.Sprintf("%d", refID), name))
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(s.repository(refID, name))
+}
+
+func (s *service) RemoveRepositories(refID uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ds, err := s.deployments(refID, "")
+	if err != nil {
+		return err
+	}
+
+	hs, err := s.hooks(refID, "")
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for _, d := range ds {
+		names[d.Instance] = true
+	}
+	for _, h := range hs {
+		names[h.Instance] = true
+	}
+	for name := range names {
+		err = s.removeRepository(refID, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.db.Delete(&Retention{}, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	// instances which were pushed to but never deployed only have a repository
+	err = os.RemoveAll(filepath.Join(s.options.Root, "repositories", fmt.Sprintf("%d", refID)))
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(s.options.Root, "images", fmt.Sprintf("%d", refID)))
+}
+
+// NewService creates a DeployService building the images with builders, keyed by Dockerfile and Buildpack
+func NewService(db dbAdapter, containers Containers, queue Queue, builders map[string]Builder, o Options) (Service, error) {
+	if o.Keep < 1 {
+		o.Keep = 1
+	}
+	if o.Secrets == nil {
+		o.Secrets = secret.Plaintext
+	}
+
+	s := &service{
+		db:         db,
+		containers: containers,
+		queue:      queue,
+		builders:   builders,
+		options:    o,
+		mtx:        &sync.Mutex{},
+	}
+
+	for _, dir := range []string{"repositories", "images", "logs"} {
+		err := os.MkdirAll(filepath.Join(o.Root, dir), 0700)
+		if err != nil {
+			return s, err
+		}
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return s, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,528 @@
+package deploy
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/deploy/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC DeployServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.DeployServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		deployments: grpctransport.NewServer(
+			endpoints.DeploymentsEndpoint,
+			DecodeGRPCDeploymentsRequest,
+			EncodeGRPCDeploymentsResponse,
+			options...,
+		),
+
+		log: grpctransport.NewServer(
+			endpoints.LogEndpoint,
+			DecodeGRPCLogRequest,
+			EncodeGRPCLogResponse,
+			options...,
+		),
+
+		createHook: grpctransport.NewServer(
+			endpoints.CreateHookEndpoint,
+			DecodeGRPCCreateHookRequest,
+			EncodeGRPCCreateHookResponse,
+			options...,
+		),
+
+		editHook: grpctransport.NewServer(
+			endpoints.EditHookEndpoint,
+			DecodeGRPCEditHookRequest,
+			EncodeGRPCEditHookResponse,
+			options...,
+		),
+
+		hooks: grpctransport.NewServer(
+			endpoints.HooksEndpoint,
+			DecodeGRPCHooksRequest,
+			EncodeGRPCHooksResponse,
+			options...,
+		),
+
+		removeHook: grpctransport.NewServer(
+			endpoints.RemoveHookEndpoint,
+			DecodeGRPCRemoveHookRequest,
+			EncodeGRPCRemoveHookResponse,
+			options...,
+		),
+
+		rollback: grpctransport.NewServer(
+			endpoints.RollbackEndpoint,
+			DecodeGRPCRollbackRequest,
+			EncodeGRPCRollbackResponse,
+			options...,
+		),
+
+		getRetention: grpctransport.NewServer(
+			endpoints.GetRetentionEndpoint,
+			DecodeGRPCGetRetentionRequest,
+			EncodeGRPCGetRetentionResponse,
+			options...,
+		),
+
+		setRetention: grpctransport.NewServer(
+			endpoints.SetRetentionEndpoint,
+			DecodeGRPCSetRetentionRequest,
+			EncodeGRPCSetRetentionResponse,
+			options...,
+		),
+
+		pruneImages: grpctransport.NewServer(
+			endpoints.PruneImagesEndpoint,
+			DecodeGRPCPruneImagesRequest,
+			EncodeGRPCPruneImagesResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	deployments  grpctransport.Handler
+	log          grpctransport.Handler
+	createHook   grpctransport.Handler
+	editHook     grpctransport.Handler
+	hooks        grpctransport.Handler
+	removeHook   grpctransport.Handler
+	rollback     grpctransport.Handler
+	getRetention grpctransport.Handler
+	setRetention grpctransport.Handler
+	pruneImages  grpctransport.Handler
+}
+
+func (s *grpcServer) Deployments(ctx oldcontext.Context, req *pb.DeploymentsRequest) (*pb.DeploymentsResponse, error) {
+	_, res, err := s.deployments.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DeploymentsResponse), nil
+}
+
+func (s *grpcServer) Log(ctx oldcontext.Context, req *pb.LogRequest) (*pb.LogResponse, error) {
+	_, res, err := s.log.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.LogResponse), nil
+}
+
+func (s *grpcServer) CreateHook(ctx oldcontext.Context, req *pb.CreateHookRequest) (*pb.CreateHookResponse, error) {
+	_, res, err := s.createHook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateHookResponse), nil
+}
+
+func (s *grpcServer) EditHook(ctx oldcontext.Context, req *pb.EditHookRequest) (*pb.EditHookResponse, error) {
+	_, res, err := s.editHook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.EditHookResponse), nil
+}
+
+func (s *grpcServer) Hooks(ctx oldcontext.Context, req *pb.HooksRequest) (*pb.HooksResponse, error) {
+	_, res, err := s.hooks.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.HooksResponse), nil
+}
+
+func (s *grpcServer) RemoveHook(ctx oldcontext.Context, req *pb.RemoveHookRequest) (*pb.RemoveHookResponse, error) {
+	_, res, err := s.removeHook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveHookResponse), nil
+}
+
+func (s *grpcServer) Rollback(ctx oldcontext.Context, req *pb.RollbackRequest) (*pb.RollbackResponse, error) {
+	_, res, err := s.rollback.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RollbackResponse), nil
+}
+
+func (s *grpcServer) GetRetention(ctx oldcontext.Context, req *pb.GetRetentionRequest) (*pb.GetRetentionResponse, error) {
+	_, res, err := s.getRetention.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.GetRetentionResponse), nil
+}
+
+func (s *grpcServer) SetRetention(ctx oldcontext.Context, req *pb.SetRetentionRequest) (*pb.SetRetentionResponse, error) {
+	_, res, err := s.setRetention.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetRetentionResponse), nil
+}
+
+func (s *grpcServer) PruneImages(ctx oldcontext.Context, req *pb.PruneImagesRequest) (*pb.PruneImagesResponse, error) {
+	_, res, err := s.pruneImages.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.PruneImagesResponse), nil
+}
+
+// ConvertDeployment converts a Deployment to its protobuf representation
+func ConvertDeployment(d Deployment) *pb.Deployment {
+	deployment := &pb.Deployment{
+		ID:        uint32(d.ID),
+		Instance:  d.Instance,
+		Commit:    d.Commit,
+		Builder:   d.Builder,
+		State:     d.State,
+		Error:     d.Error,
+		CreatedAt: d.CreatedAt.Unix(),
+		Hook:      uint32(d.Hook),
+		Deployer:  uint32(d.Deployer),
+		Rollback:  uint32(d.Rollback),
+		Digest:    d.Digest,
+		Config:    d.Config,
+	}
+	if !d.FinishedAt.IsZero() {
+		deployment.FinishedAt = d.FinishedAt.Unix()
+	}
+	return deployment
+}
+
+// ConvertPBDeployment converts a protobuf Deployment to a Deployment
+func ConvertPBDeployment(d *pb.Deployment) Deployment {
+	if d == nil {
+		return Deployment{}
+	}
+
+	deployment := Deployment{
+		ID:        uint(d.ID),
+		Instance:  d.Instance,
+		Commit:    d.Commit,
+		Builder:   d.Builder,
+		State:     d.State,
+		Error:     d.Error,
+		CreatedAt: time.Unix(d.CreatedAt, 0).UTC(),
+		Hook:      uint(d.Hook),
+		Deployer:  uint(d.Deployer),
+		Rollback:  uint(d.Rollback),
+		Digest:    d.Digest,
+		Config:    d.Config,
+	}
+	if d.FinishedAt != 0 {
+		deployment.FinishedAt = time.Unix(d.FinishedAt, 0).UTC()
+	}
+	return deployment
+}
+
+// ConvertHook converts a Hook to its protobuf representation
+func ConvertHook(h Hook) *pb.Hook {
+	return &pb.Hook{
+		ID:         uint32(h.ID),
+		Instance:   h.Instance,
+		Provider:   h.Provider,
+		Repository: h.Repository,
+		Branch:     h.Branch,
+		Secret:     h.Secret,
+		Enabled:    h.State == Enabled,
+		CreatedAt:  h.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBHook converts a protobuf Hook to a Hook
+func ConvertPBHook(h *pb.Hook) Hook {
+	if h == nil {
+		return Hook{}
+	}
+
+	hook := Hook{
+		ID:         uint(h.ID),
+		Instance:   h.Instance,
+		Provider:   h.Provider,
+		Repository: h.Repository,
+		Branch:     h.Branch,
+		Secret:     h.Secret,
+		State:      Disabled,
+		CreatedAt:  time.Unix(h.CreatedAt, 0).UTC(),
+	}
+	if h.Enabled {
+		hook.State = Enabled
+	}
+	return hook
+}
+
+// DecodeGRPCDeploymentsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Deployments request to a messages/deploy.proto-domain deployments request.
+func DecodeGRPCDeploymentsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DeploymentsRequest)
+	return DeploymentsRequest{
+		RefID:    uint(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// EncodeGRPCDeploymentsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain deployments response to a gRPC Deployments response.
+func EncodeGRPCDeploymentsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DeploymentsResponse)
+	deployments := make([]*pb.Deployment, len(res.Deployments))
+	for i, d := range res.Deployments {
+		deployments[i] = ConvertDeployment(d)
+	}
+
+	gRPCRes := &pb.DeploymentsResponse{
+		Deployments: deployments,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCLogRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Log request to a messages/deploy.proto-domain log request.
+func DecodeGRPCLogRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.LogRequest)
+	return LogRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCLogResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain log response to a gRPC Log response.
+func EncodeGRPCLogResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(LogResponse)
+	gRPCRes := &pb.LogResponse{
+		Log: res.Log,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCCreateHookRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateHook request to a messages/deploy.proto-domain createhook request.
+func DecodeGRPCCreateHookRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateHookRequest)
+	return CreateHookRequest{
+		RefID:      uint(req.RefID),
+		Instance:   req.Instance,
+		Provider:   req.Provider,
+		Repository: req.Repository,
+		Branch:     req.Branch,
+	}, nil
+}
+
+// EncodeGRPCCreateHookResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain createhook response to a gRPC CreateHook response.
+func EncodeGRPCCreateHookResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateHookResponse)
+	gRPCRes := &pb.CreateHookResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	} else {
+		gRPCRes.Hook = ConvertHook(res.Hook)
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCEditHookRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC EditHook request to a messages/deploy.proto-domain edithook request.
+func DecodeGRPCEditHookRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.EditHookRequest)
+	return EditHookRequest{
+		RefID:   uint(req.RefID),
+		ID:      uint(req.ID),
+		Enabled: req.Enabled,
+	}, nil
+}
+
+// EncodeGRPCEditHookResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain edithook response to a gRPC EditHook response.
+func EncodeGRPCEditHookResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(EditHookResponse)
+	gRPCRes := &pb.EditHookResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCHooksRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Hooks request to a messages/deploy.proto-domain hooks request.
+func DecodeGRPCHooksRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.HooksRequest)
+	return HooksRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCHooksResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain hooks response to a gRPC Hooks response.
+func EncodeGRPCHooksResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(HooksResponse)
+	hooks := make([]*pb.Hook, len(res.Hooks))
+	for i, h := range res.Hooks {
+		hooks[i] = ConvertHook(h)
+	}
+
+	gRPCRes := &pb.HooksResponse{
+		Hooks: hooks,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveHookRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveHook request to a messages/deploy.proto-domain removehook request.
+func DecodeGRPCRemoveHookRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveHookRequest)
+	return RemoveHookRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveHookResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain removehook response to a gRPC RemoveHook response.
+func EncodeGRPCRemoveHookResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveHookResponse)
+	gRPCRes := &pb.RemoveHookResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRollbackRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Rollback request to a messages/deploy.proto-domain rollback request.
+func DecodeGRPCRollbackRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RollbackRequest)
+	return RollbackRequest{
+		RefID:    uint(req.RefID),
+		Instance: req.Instance,
+		ID:       uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRollbackResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain rollback response to a gRPC Rollback response.
+func EncodeGRPCRollbackResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RollbackResponse)
+	gRPCRes := &pb.RollbackResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	} else {
+		gRPCRes.Deployment = ConvertDeployment(res.Deployment)
+	}
+	return gRPCRes, nil
+}
+
+// ConvertRetention converts a Retention to a protobuf Retention
+func ConvertRetention(r Retention) *pb.Retention {
+	return &pb.Retention{
+		Keep:   uint32(r.Keep),
+		MaxAge: uint32(r.MaxAge),
+	}
+}
+
+// ConvertPBRetention converts a protobuf Retention to a Retention
+func ConvertPBRetention(r *pb.Retention) Retention {
+	if r == nil {
+		return Retention{}
+	}
+	return Retention{
+		Keep:   uint(r.Keep),
+		MaxAge: uint(r.MaxAge),
+	}
+}
+
+// DecodeGRPCGetRetentionRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC GetRetention request to a messages/deploy.proto-domain get retention request.
+func DecodeGRPCGetRetentionRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetRetentionRequest)
+	return GetRetentionRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCGetRetentionResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain get retention response to a gRPC GetRetention response.
+func EncodeGRPCGetRetentionResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(GetRetentionResponse)
+	gRPCRes := &pb.GetRetentionResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	} else {
+		gRPCRes.Retention = ConvertRetention(res.Retention)
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetRetentionRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetRetention request to a messages/deploy.proto-domain set retention request.
+func DecodeGRPCSetRetentionRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetRetentionRequest)
+	return SetRetentionRequest{
+		RefID:     uint(req.RefID),
+		Retention: ConvertPBRetention(req.Retention),
+	}, nil
+}
+
+// EncodeGRPCSetRetentionResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain set retention response to a gRPC SetRetention response.
+func EncodeGRPCSetRetentionResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetRetentionResponse)
+	gRPCRes := &pb.SetRetentionResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCPruneImagesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC PruneImages request to a messages/deploy.proto-domain prune images request.
+func DecodeGRPCPruneImagesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PruneImagesRequest)
+	return PruneImagesRequest{
+		RefID:  uint(req.RefID),
+		DryRun: req.DryRun,
+	}, nil
+}
+
+// EncodeGRPCPruneImagesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/deploy.proto-domain prune images response to a gRPC PruneImages response.
+func EncodeGRPCPruneImagesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(PruneImagesResponse)
+	deployments := make([]*pb.Deployment, len(res.Deployments))
+	for i, d := range res.Deployments {
+		deployments[i] = ConvertDeployment(d)
+	}
+
+	gRPCRes := &pb.PruneImagesResponse{
+		Deployments: deployments,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
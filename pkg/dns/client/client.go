@@ -0,0 +1,333 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns"
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *dns.Endpoints {
+
+	var CreateRecordEndpoint endpoint.Endpoint
+	{
+		CreateRecordEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"CreateRecord",
+			EncodeGRPCCreateRecordRequest,
+			DecodeGRPCCreateRecordResponse,
+			pb.CreateRecordResponse{},
+		).Endpoint()
+	}
+
+	var RemoveRecordEndpoint endpoint.Endpoint
+	{
+		RemoveRecordEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"RemoveRecord",
+			EncodeGRPCRemoveRecordRequest,
+			DecodeGRPCRemoveRecordResponse,
+			pb.RemoveRecordResponse{},
+		).Endpoint()
+	}
+
+	var RecordsEndpoint endpoint.Endpoint
+	{
+		RecordsEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"Records",
+			EncodeGRPCRecordsRequest,
+			DecodeGRPCRecordsResponse,
+			pb.RecordsResponse{},
+		).Endpoint()
+	}
+
+	var CreateInstanceRecordsEndpoint endpoint.Endpoint
+	{
+		CreateInstanceRecordsEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"CreateInstanceRecords",
+			EncodeGRPCCreateInstanceRecordsRequest,
+			DecodeGRPCCreateInstanceRecordsResponse,
+			pb.CreateInstanceRecordsResponse{},
+		).Endpoint()
+	}
+
+	var RemoveInstanceRecordsEndpoint endpoint.Endpoint
+	{
+		RemoveInstanceRecordsEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"RemoveInstanceRecords",
+			EncodeGRPCRemoveInstanceRecordsRequest,
+			DecodeGRPCRemoveInstanceRecordsResponse,
+			pb.RemoveInstanceRecordsResponse{},
+		).Endpoint()
+	}
+
+	var AddCustomDomainEndpoint endpoint.Endpoint
+	{
+		AddCustomDomainEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"AddCustomDomain",
+			EncodeGRPCAddCustomDomainRequest,
+			DecodeGRPCAddCustomDomainResponse,
+			pb.AddCustomDomainResponse{},
+		).Endpoint()
+	}
+
+	var RemoveCustomDomainEndpoint endpoint.Endpoint
+	{
+		RemoveCustomDomainEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"RemoveCustomDomain",
+			EncodeGRPCRemoveCustomDomainRequest,
+			DecodeGRPCRemoveCustomDomainResponse,
+			pb.RemoveCustomDomainResponse{},
+		).Endpoint()
+	}
+
+	var VerifyCustomDomainEndpoint endpoint.Endpoint
+	{
+		VerifyCustomDomainEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"VerifyCustomDomain",
+			EncodeGRPCVerifyCustomDomainRequest,
+			DecodeGRPCVerifyCustomDomainResponse,
+			pb.VerifyCustomDomainResponse{},
+		).Endpoint()
+	}
+
+	var CustomDomainsEndpoint endpoint.Endpoint
+	{
+		CustomDomainsEndpoint = grpctransport.NewClient(
+			conn,
+			"dns.DNSService",
+			"CustomDomains",
+			EncodeGRPCCustomDomainsRequest,
+			DecodeGRPCCustomDomainsResponse,
+			pb.CustomDomainsResponse{},
+		).Endpoint()
+	}
+
+	return &dns.Endpoints{
+		CreateRecordEndpoint:          CreateRecordEndpoint,
+		RemoveRecordEndpoint:          RemoveRecordEndpoint,
+		RecordsEndpoint:               RecordsEndpoint,
+		CreateInstanceRecordsEndpoint: CreateInstanceRecordsEndpoint,
+		RemoveInstanceRecordsEndpoint: RemoveInstanceRecordsEndpoint,
+		AddCustomDomainEndpoint:       AddCustomDomainEndpoint,
+		RemoveCustomDomainEndpoint:    RemoveCustomDomainEndpoint,
+		VerifyCustomDomainEndpoint:    VerifyCustomDomainEndpoint,
+		CustomDomainsEndpoint:         CustomDomainsEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreateRecordRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain createrecord request to a gRPC CreateRecord request.
+func EncodeGRPCCreateRecordRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.CreateRecordRequest)
+	return &pb.CreateRecordRequest{
+		RefID:  uint32(req.RefID),
+		Record: dns.ConvertRecord(req.Record),
+	}, nil
+}
+
+// DecodeGRPCCreateRecordResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateRecord response to a messages/dns.proto-domain createrecord response.
+func DecodeGRPCCreateRecordResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateRecordResponse)
+	return &dns.CreateRecordResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveRecordRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain removerecord request to a gRPC RemoveRecord request.
+func EncodeGRPCRemoveRecordRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.RemoveRecordRequest)
+	return &pb.RemoveRecordRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveRecordResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveRecord response to a messages/dns.proto-domain removerecord response.
+func DecodeGRPCRemoveRecordResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveRecordResponse)
+	return &dns.RemoveRecordResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRecordsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain records request to a gRPC Records request.
+func EncodeGRPCRecordsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.RecordsRequest)
+	return &pb.RecordsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCRecordsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Records response to a messages/dns.proto-domain records response.
+func DecodeGRPCRecordsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RecordsResponse)
+	records := make([]dns.Record, len(response.Records))
+	for i, r := range response.Records {
+		records[i] = *dns.ConvertPBRecord(r)
+	}
+
+	return &dns.RecordsResponse{
+		Records: records,
+		Error:   getError(response.Error),
+		Page:    paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCCreateInstanceRecordsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain createinstancerecords request to a gRPC CreateInstanceRecords request.
+func EncodeGRPCCreateInstanceRecordsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.CreateInstanceRecordsRequest)
+	return &pb.CreateInstanceRecordsRequest{
+		RefID:    uint32(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// DecodeGRPCCreateInstanceRecordsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateInstanceRecords response to a messages/dns.proto-domain createinstancerecords response.
+func DecodeGRPCCreateInstanceRecordsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateInstanceRecordsResponse)
+	return &dns.CreateInstanceRecordsResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveInstanceRecordsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain removeinstancerecords request to a gRPC RemoveInstanceRecords request.
+func EncodeGRPCRemoveInstanceRecordsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.RemoveInstanceRecordsRequest)
+	return &pb.RemoveInstanceRecordsRequest{
+		RefID:    uint32(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// DecodeGRPCRemoveInstanceRecordsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveInstanceRecords response to a messages/dns.proto-domain removeinstancerecords response.
+func DecodeGRPCRemoveInstanceRecordsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveInstanceRecordsResponse)
+	return &dns.RemoveInstanceRecordsResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCAddCustomDomainRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain addcustomdomain request to a gRPC AddCustomDomain request.
+func EncodeGRPCAddCustomDomainRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.AddCustomDomainRequest)
+	return &pb.AddCustomDomainRequest{
+		RefID:  uint32(req.RefID),
+		Domain: req.Domain,
+	}, nil
+}
+
+// DecodeGRPCAddCustomDomainResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC AddCustomDomain response to a messages/dns.proto-domain addcustomdomain response.
+func DecodeGRPCAddCustomDomainResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.AddCustomDomainResponse)
+	return &dns.AddCustomDomainResponse{
+		Verification: dns.ConvertPBVerification(response.Verification),
+		Error:        getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveCustomDomainRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain removecustomdomain request to a gRPC RemoveCustomDomain request.
+func EncodeGRPCRemoveCustomDomainRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.RemoveCustomDomainRequest)
+	return &pb.RemoveCustomDomainRequest{
+		RefID:  uint32(req.RefID),
+		Domain: req.Domain,
+	}, nil
+}
+
+// DecodeGRPCRemoveCustomDomainResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveCustomDomain response to a messages/dns.proto-domain removecustomdomain response.
+func DecodeGRPCRemoveCustomDomainResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveCustomDomainResponse)
+	return &dns.RemoveCustomDomainResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCVerifyCustomDomainRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain verifycustomdomain request to a gRPC VerifyCustomDomain request.
+func EncodeGRPCVerifyCustomDomainRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.VerifyCustomDomainRequest)
+	return &pb.VerifyCustomDomainRequest{
+		RefID:  uint32(req.RefID),
+		Domain: req.Domain,
+	}, nil
+}
+
+// DecodeGRPCVerifyCustomDomainResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC VerifyCustomDomain response to a messages/dns.proto-domain verifycustomdomain response.
+func DecodeGRPCVerifyCustomDomainResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.VerifyCustomDomainResponse)
+	return &dns.VerifyCustomDomainResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCCustomDomainsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain customdomains request to a gRPC CustomDomains request.
+func EncodeGRPCCustomDomainsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*dns.CustomDomainsRequest)
+	return &pb.CustomDomainsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCCustomDomainsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CustomDomains response to a messages/dns.proto-domain customdomains response.
+func DecodeGRPCCustomDomainsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CustomDomainsResponse)
+	domains := make([]dns.Verification, len(response.Domains))
+	for i, v := range response.Domains {
+		domains[i] = dns.ConvertPBVerification(v)
+	}
+
+	return &dns.CustomDomainsResponse{
+		Domains: domains,
+		Error:   getError(response.Error),
+		Page:    paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
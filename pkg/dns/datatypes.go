@@ -0,0 +1,57 @@
+package dns
+
+import "fmt"
+
+// The record types which can be managed
+const (
+	A     = "A"
+	AAAA  = "AAAA"
+	CNAME = "CNAME"
+	TXT   = "TXT"
+)
+
+// VerificationPrefix is prepended to a custom domain to get the name of its verification record
+const VerificationPrefix = "_kontainerooo"
+
+// Record is a resource record of the zone
+type Record struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Name is the fully qualified domain name of the record without a trailing dot
+	Name  string `validate:"required"`
+	Type  string `validate:"required,oneof=A|AAAA|CNAME|TXT"`
+	Value string `validate:"required"`
+	TTL   uint
+	// Instance is the name of the instance the record was created for, it is empty
+	// for records created by the user
+	Instance string
+}
+
+// TableName sets Record's database table name
+func (Record) TableName() string {
+	return "dns_records"
+}
+
+// Verification is a custom domain a user wants to use for their configurations,
+// it is verified once the domain serves the token as TXT record
+type Verification struct {
+	Domain   string `gorm:"primary_key"`
+	RefID    uint
+	Token    string
+	Verified bool
+}
+
+// TableName sets Verification's database table name
+func (Verification) TableName() string {
+	return "dns_verifications"
+}
+
+// VerificationName returns the name of the TXT record which has to contain the verification token of domain
+func VerificationName(domain string) string {
+	return fmt.Sprintf("%s.%s", VerificationPrefix, domain)
+}
+
+// InstanceDomain returns the subdomain of zone assigned to an instance of a user
+func InstanceDomain(refID uint, instance string, zone string) string {
+	return fmt.Sprintf("%s-%d.%s", instance, refID, zone)
+}
@@ -0,0 +1,314 @@
+package dns_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockProvider struct {
+	records map[string][]string
+	err     error
+}
+
+func (p *mockProvider) SetRecords(name string, typ string, ttl uint, values []string) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	if len(values) == 0 {
+		delete(p.records, typ+" "+name)
+		return nil
+	}
+	p.records[typ+" "+name] = values
+	return nil
+}
+
+var _ = Describe("DNS", func() {
+	var (
+		refID    = uint(1)
+		provider *mockProvider
+		txt      map[string][]string
+		s        dns.Service
+		opts     = dns.Options{
+			Zone: "kontainer.ooo",
+			IPv4: "203.0.113.1",
+			IPv6: "2001:db8::1",
+		}
+	)
+
+	lookup := func(name string) ([]string, error) {
+		values, ok := txt[name]
+		if !ok {
+			return nil, errors.New("no such host")
+		}
+		return values, nil
+	}
+
+	BeforeEach(func() {
+		provider = &mockProvider{
+			records: make(map[string][]string),
+		}
+		txt = make(map[string][]string)
+		s, _ = dns.NewService(testutils.NewMockDB(), provider, lookup, opts)
+	})
+
+	Describe("Create Service", func() {
+		It("Should create service", func() {
+			s, err := dns.NewService(testutils.NewMockDB(), nil, nil, opts)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s).ToNot(BeZero())
+		})
+
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := dns.NewService(db, nil, nil, opts)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Records", func() {
+		It("Should create and publish a record", func() {
+			r := &dns.Record{Name: "WWW.kontainer.ooo.", Type: "a", Value: "203.0.113.2"}
+			err := s.CreateRecord(refID, r)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(r.ID).ToNot(BeZero())
+			Expect(r.TTL).To(BeEquivalentTo(300))
+			Expect(provider.records["A www.kontainer.ooo"]).To(ConsistOf("203.0.113.2"))
+
+			rs := []dns.Record{}
+			err = s.Records(refID, &rs)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(rs).To(HaveLen(1))
+			Expect(rs[0].Name).To(BeEquivalentTo("www.kontainer.ooo"))
+		})
+
+		It("Should reject names outside of the zone", func() {
+			err := s.CreateRecord(refID, &dns.Record{Name: "www.example.com", Type: dns.A, Value: "203.0.113.2"})
+			Expect(err).To(BeEquivalentTo(dns.ErrInvalidName))
+		})
+
+		It("Should reject values not matching the type", func() {
+			err := s.CreateRecord(refID, &dns.Record{Name: "www.kontainer.ooo", Type: dns.A, Value: "2001:db8::2"})
+			Expect(err).To(BeEquivalentTo(dns.ErrInvalidValue))
+
+			err = s.CreateRecord(refID, &dns.Record{Name: "www.kontainer.ooo", Type: dns.AAAA, Value: "203.0.113.2"})
+			Expect(err).To(BeEquivalentTo(dns.ErrInvalidValue))
+
+			err = s.CreateRecord(refID, &dns.Record{Name: "www.kontainer.ooo", Type: dns.CNAME, Value: "not a host"})
+			Expect(err).To(BeEquivalentTo(dns.ErrInvalidValue))
+
+			err = s.CreateRecord(refID, &dns.Record{Name: "www.kontainer.ooo", Type: "MX", Value: "mail.kontainer.ooo"})
+			Expect(err).To(BeEquivalentTo(dns.ErrInvalidType))
+		})
+
+		It("Should not share a CNAME's name", func() {
+			err := s.CreateRecord(refID, &dns.Record{Name: "www.kontainer.ooo", Type: dns.CNAME, Value: "example.com"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = s.CreateRecord(refID, &dns.Record{Name: "www.kontainer.ooo", Type: dns.TXT, Value: "hello"})
+			Expect(err).To(BeEquivalentTo(dns.ErrCNAMEConflict))
+		})
+
+		It("Should not use a name of another user", func() {
+			err := s.CreateRecord(refID, &dns.Record{Name: "www.kontainer.ooo", Type: dns.A, Value: "203.0.113.2"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = s.CreateRecord(refID+1, &dns.Record{Name: "www.kontainer.ooo", Type: dns.A, Value: "203.0.113.3"})
+			Expect(err).To(BeEquivalentTo(dns.ErrNameTaken))
+		})
+
+		It("Should not create records with verification names", func() {
+			err := s.CreateRecord(refID, &dns.Record{Name: "abc._kontainerooo.kontainer.ooo", Type: dns.TXT, Value: "abc", Instance: "_kontainerooo"})
+			Expect(err).To(BeEquivalentTo(dns.ErrInvalidName))
+		})
+
+		It("Should not keep a record the provider rejected", func() {
+			provider.err = errors.New("provider failure")
+			err := s.CreateRecord(refID, &dns.Record{Name: "www.kontainer.ooo", Type: dns.A, Value: "203.0.113.2"})
+			Ω(err).Should(HaveOccurred())
+
+			rs := []dns.Record{}
+			s.Records(refID, &rs)
+			Expect(rs).To(BeEmpty())
+		})
+
+		It("Should remove a record", func() {
+			r := &dns.Record{Name: "www.kontainer.ooo", Type: dns.A, Value: "203.0.113.2"}
+			s.CreateRecord(refID, r)
+
+			err := s.RemoveRecord(refID+1, r.ID)
+			Expect(err).To(BeEquivalentTo(dns.ErrRecordNotExist))
+
+			err = s.RemoveRecord(refID, r.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(provider.records).ToNot(HaveKey("A www.kontainer.ooo"))
+		})
+	})
+
+	Describe("Instance Records", func() {
+		It("Should create and remove the records of an instance", func() {
+			err := s.CreateInstanceRecords(refID, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			name := dns.InstanceDomain(refID, "web", "kontainer.ooo")
+			Expect(name).To(BeEquivalentTo("web-1.kontainer.ooo"))
+			Expect(provider.records["A "+name]).To(ConsistOf(opts.IPv4))
+			Expect(provider.records["AAAA "+name]).To(ConsistOf(opts.IPv6))
+
+			err = s.RemoveInstanceRecords(refID, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(provider.records).To(BeEmpty())
+		})
+
+		It("Should alias instances to the target", func() {
+			o := opts
+			o.Target = "router.kontainer.ooo"
+			s, _ = dns.NewService(testutils.NewMockDB(), provider, lookup, o)
+
+			err := s.CreateInstanceRecords(refID, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(provider.records["CNAME web-1.kontainer.ooo"]).To(ConsistOf("router.kontainer.ooo"))
+		})
+
+		It("Should follow the events of containers", func() {
+			bus := events.NewMemoryBus("", time.Millisecond, log.NewNopLogger())
+			defer bus.Close()
+
+			_, err := dns.Subscribe(s, bus, log.NewNopLogger())
+			Ω(err).ShouldNot(HaveOccurred())
+
+			records := func() []dns.Record {
+				rs := []dns.Record{}
+				s.Records(refID, &rs)
+				return rs
+			}
+
+			bus.Publish(events.ContainerCreated, events.ContainerEvent{RefID: refID, Name: "web"})
+			Eventually(records).Should(HaveLen(2))
+
+			bus.Publish(events.ContainerRemoved, events.ContainerEvent{RefID: refID, Name: "web", Replica: true})
+			Consistently(records, 20*time.Millisecond).Should(HaveLen(2))
+
+			bus.Publish(events.ContainerRemoved, events.ContainerEvent{RefID: refID, Name: "web"})
+			Eventually(records).Should(BeEmpty())
+
+			bus.Publish(events.ContainerCreated, events.ContainerEvent{RefID: refID, Name: "web"})
+			bus.Publish(events.ContainerRemoved, events.ContainerEvent{RefID: refID, Name: "web"})
+			bus.Publish(events.ContainerCreated, events.ContainerEvent{RefID: refID, Name: "web"})
+			Eventually(records).Should(HaveLen(2))
+			Consistently(records, 20*time.Millisecond).Should(HaveLen(2))
+		})
+	})
+
+	Describe("Custom Domains", func() {
+		It("Should publish a verification record", func() {
+			v := dns.Verification{}
+			err := s.AddCustomDomain(refID, "Example.com", &v)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(v.Domain).To(BeEquivalentTo("example.com"))
+			Expect(v.Token).ToNot(BeEmpty())
+			Expect(provider.records["TXT "+v.Token+"._kontainerooo.kontainer.ooo"]).To(ConsistOf(v.Token))
+		})
+
+		It("Should not add a domain of another user", func() {
+			v := dns.Verification{}
+			s.AddCustomDomain(refID, "example.com", &v)
+
+			err := s.AddCustomDomain(refID+1, "example.com", &v)
+			Expect(err).To(BeEquivalentTo(dns.ErrDomainTaken))
+		})
+
+		It("Should not add subdomains of the zone", func() {
+			v := dns.Verification{}
+			err := s.AddCustomDomain(refID, "www.kontainer.ooo", &v)
+			Expect(err).To(BeEquivalentTo(dns.ErrInvalidName))
+		})
+
+		It("Should verify a domain serving its token", func() {
+			v := dns.Verification{}
+			s.AddCustomDomain(refID, "example.com", &v)
+
+			err := s.VerifyCustomDomain(refID, "example.com")
+			Ω(err).Should(HaveOccurred())
+			Expect(s.Verified(refID, "example.com")).To(BeFalse())
+
+			txt[dns.VerificationName("example.com")] = []string{"other", v.Token}
+			err = s.VerifyCustomDomain(refID, "example.com")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s.Verified(refID, "example.com")).To(BeTrue())
+			Expect(s.Verified(refID+1, "example.com")).To(BeFalse())
+		})
+
+		It("Should remove a domain and its verification record", func() {
+			v := dns.Verification{}
+			s.AddCustomDomain(refID, "example.com", &v)
+
+			err := s.RemoveCustomDomain(refID, "example.com")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(provider.records).To(BeEmpty())
+
+			vs := []dns.Verification{}
+			s.CustomDomains(refID, &vs)
+			Expect(vs).To(BeEmpty())
+		})
+	})
+
+	Describe("PowerDNS", func() {
+		It("Should patch the rrset of a name", func() {
+			var (
+				path string
+				key  string
+				body map[string][]map[string]interface{}
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				key = r.Header.Get("X-API-Key")
+				data, _ := ioutil.ReadAll(r.Body)
+				json.Unmarshal(data, &body)
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			p := &dns.PowerDNS{URL: srv.URL, APIKey: "secret", Zone: "kontainer.ooo"}
+			err := p.SetRecords("www.kontainer.ooo", dns.CNAME, 60, []string{"example.com"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(path).To(BeEquivalentTo("/api/v1/servers/localhost/zones/kontainer.ooo."))
+			Expect(key).To(BeEquivalentTo("secret"))
+
+			rrset := body["rrsets"][0]
+			Expect(rrset["name"]).To(BeEquivalentTo("www.kontainer.ooo."))
+			Expect(rrset["changetype"]).To(BeEquivalentTo("REPLACE"))
+			Expect(rrset["records"]).To(ConsistOf(HaveKeyWithValue("content", "example.com.")))
+
+			err = p.SetRecords("www.kontainer.ooo", dns.CNAME, 60, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(body["rrsets"][0]["changetype"]).To(BeEquivalentTo("DELETE"))
+		})
+
+		It("Should return the error of the API", func() {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"error": "bad rrset"}`))
+			}))
+			defer srv.Close()
+
+			p := &dns.PowerDNS{URL: srv.URL, Zone: "kontainer.ooo"}
+			err := p.SetRecords("www.kontainer.ooo", dns.A, 60, []string{"203.0.113.2"})
+			Ω(err).Should(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("bad rrset"))
+		})
+	})
+})
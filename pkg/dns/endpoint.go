@@ -0,0 +1,256 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the dns service
+type Endpoints struct {
+	CreateRecordEndpoint          endpoint.Endpoint
+	RemoveRecordEndpoint          endpoint.Endpoint
+	RecordsEndpoint               endpoint.Endpoint
+	CreateInstanceRecordsEndpoint endpoint.Endpoint
+	RemoveInstanceRecordsEndpoint endpoint.Endpoint
+	AddCustomDomainEndpoint       endpoint.Endpoint
+	RemoveCustomDomainEndpoint    endpoint.Endpoint
+	VerifyCustomDomainEndpoint    endpoint.Endpoint
+	CustomDomainsEndpoint         endpoint.Endpoint
+}
+
+// CreateRecordRequest is the request struct for the CreateRecordEndpoint
+type CreateRecordRequest struct {
+	RefID  uint    `bart:"ref"`
+	Record *Record `validate:"required"`
+}
+
+// CreateRecordResponse is the response struct for the CreateRecordEndpoint
+type CreateRecordResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeCreateRecordEndpoint creates a gokit endpoint which invokes CreateRecord
+func MakeCreateRecordEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateRecordRequest)
+		err := s.CreateRecord(req.RefID, req.Record)
+		if err != nil {
+			return CreateRecordResponse{
+				Error: err,
+			}, nil
+		}
+		return CreateRecordResponse{
+			ID: req.Record.ID,
+		}, nil
+	}
+}
+
+// RemoveRecordRequest is the request struct for the RemoveRecordEndpoint
+type RemoveRecordRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemoveRecordResponse is the response struct for the RemoveRecordEndpoint
+type RemoveRecordResponse struct {
+	Error error
+}
+
+// MakeRemoveRecordEndpoint creates a gokit endpoint which invokes RemoveRecord
+func MakeRemoveRecordEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveRecordRequest)
+		err := s.RemoveRecord(req.RefID, req.ID)
+		return RemoveRecordResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RecordsRequest is the request struct for the RecordsEndpoint
+type RecordsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// RecordsResponse is the response struct for the RecordsEndpoint
+type RecordsResponse struct {
+	Records []Record
+	Error   error
+	Page    paging.Response
+}
+
+// MakeRecordsEndpoint creates a gokit endpoint which invokes Records
+func MakeRecordsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RecordsRequest)
+		records := []Record{}
+		err := s.Records(req.RefID, &records)
+		if err != nil {
+			return RecordsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&records, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return RecordsResponse{
+			Records: records,
+			Page:    page,
+		}, nil
+	}
+}
+
+// CreateInstanceRecordsRequest is the request struct for the CreateInstanceRecordsEndpoint
+type CreateInstanceRecordsRequest struct {
+	RefID    uint   `bart:"ref"`
+	Instance string `validate:"required,name"`
+}
+
+// CreateInstanceRecordsResponse is the response struct for the CreateInstanceRecordsEndpoint
+type CreateInstanceRecordsResponse struct {
+	Error error
+}
+
+// MakeCreateInstanceRecordsEndpoint creates a gokit endpoint which invokes CreateInstanceRecords
+func MakeCreateInstanceRecordsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateInstanceRecordsRequest)
+		err := s.CreateInstanceRecords(req.RefID, req.Instance)
+		return CreateInstanceRecordsResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RemoveInstanceRecordsRequest is the request struct for the RemoveInstanceRecordsEndpoint
+type RemoveInstanceRecordsRequest struct {
+	RefID    uint   `bart:"ref"`
+	Instance string `validate:"required,name"`
+}
+
+// RemoveInstanceRecordsResponse is the response struct for the RemoveInstanceRecordsEndpoint
+type RemoveInstanceRecordsResponse struct {
+	Error error
+}
+
+// MakeRemoveInstanceRecordsEndpoint creates a gokit endpoint which invokes RemoveInstanceRecords
+func MakeRemoveInstanceRecordsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveInstanceRecordsRequest)
+		err := s.RemoveInstanceRecords(req.RefID, req.Instance)
+		return RemoveInstanceRecordsResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// AddCustomDomainRequest is the request struct for the AddCustomDomainEndpoint
+type AddCustomDomainRequest struct {
+	RefID  uint   `bart:"ref"`
+	Domain string `validate:"required,domain"`
+}
+
+// AddCustomDomainResponse is the response struct for the AddCustomDomainEndpoint
+type AddCustomDomainResponse struct {
+	Verification Verification
+	Error        error
+}
+
+// MakeAddCustomDomainEndpoint creates a gokit endpoint which invokes AddCustomDomain
+func MakeAddCustomDomainEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AddCustomDomainRequest)
+		v := Verification{}
+		err := s.AddCustomDomain(req.RefID, req.Domain, &v)
+		return AddCustomDomainResponse{
+			Verification: v,
+			Error:        err,
+		}, nil
+	}
+}
+
+// RemoveCustomDomainRequest is the request struct for the RemoveCustomDomainEndpoint
+type RemoveCustomDomainRequest struct {
+	RefID  uint   `bart:"ref"`
+	Domain string `validate:"required,domain"`
+}
+
+// RemoveCustomDomainResponse is the response struct for the RemoveCustomDomainEndpoint
+type RemoveCustomDomainResponse struct {
+	Error error
+}
+
+// MakeRemoveCustomDomainEndpoint creates a gokit endpoint which invokes RemoveCustomDomain
+func MakeRemoveCustomDomainEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveCustomDomainRequest)
+		err := s.RemoveCustomDomain(req.RefID, req.Domain)
+		return RemoveCustomDomainResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// VerifyCustomDomainRequest is the request struct for the VerifyCustomDomainEndpoint
+type VerifyCustomDomainRequest struct {
+	RefID  uint   `bart:"ref"`
+	Domain string `validate:"required,domain"`
+}
+
+// VerifyCustomDomainResponse is the response struct for the VerifyCustomDomainEndpoint
+type VerifyCustomDomainResponse struct {
+	Error error
+}
+
+// MakeVerifyCustomDomainEndpoint creates a gokit endpoint which invokes VerifyCustomDomain
+func MakeVerifyCustomDomainEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(VerifyCustomDomainRequest)
+		err := s.VerifyCustomDomain(req.RefID, req.Domain)
+		return VerifyCustomDomainResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// CustomDomainsRequest is the request struct for the CustomDomainsEndpoint
+type CustomDomainsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// CustomDomainsResponse is the response struct for the CustomDomainsEndpoint
+type CustomDomainsResponse struct {
+	Domains []Verification
+	Error   error
+	Page    paging.Response
+}
+
+// MakeCustomDomainsEndpoint creates a gokit endpoint which invokes CustomDomains
+func MakeCustomDomainsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CustomDomainsRequest)
+		domains := []Verification{}
+		err := s.CustomDomains(req.RefID, &domains)
+		if err != nil {
+			return CustomDomainsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&domains, "Domain", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return CustomDomainsResponse{
+			Domains: domains,
+			Page:    page,
+		}, nil
+	}
+}
@@ -0,0 +1,65 @@
+package dns
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// EventGroup is the group the dns services of all nodes subscribe to container events with,
+// so the records of an instance are only changed once
+const EventGroup = "dns"
+
+// permanent reports whether an error would occur again if the event was delivered again
+func permanent(err error) bool {
+	switch err {
+	case ErrInvalidName, ErrInvalidType, ErrInvalidValue, ErrNameTaken, ErrCNAMEConflict, ErrRecordNotExist:
+		return true
+	}
+	return false
+}
+
+// instanceHandler returns a handler creating or removing the records of the instance of a container event,
+// replicas share the records of the instance they replicate
+func instanceHandler(s Service, logger log.Logger) events.Handler {
+	return func(e events.Event) error {
+		var f func(refID uint, instance string) error
+		switch e.Topic {
+		case events.ContainerCreated:
+			f = s.CreateInstanceRecords
+		case events.ContainerRemoved:
+			f = s.RemoveInstanceRecords
+		default:
+			return nil
+		}
+
+		c := events.ContainerEvent{}
+		err := e.Decode(&c)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+		if c.Replica {
+			return nil
+		}
+
+		err = f(c.RefID, c.Name)
+		if err != nil && permanent(err) {
+			level.Error(logger).Log("instance", c.Name, "err", err)
+			return nil
+		}
+		return err
+	}
+}
+
+// Subscribe creates and removes the records of instances once their containers are created or removed.
+// Both topics are handled by one subscription, so the records of an instance which is moved between
+// nodes are removed before they are created again.
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	sub, err := bus.Subscribe(events.ContainerEvents, EventGroup, instanceHandler(s, logger))
+	if err != nil {
+		return nil, err
+	}
+
+	return []events.Subscription{sub}, nil
+}
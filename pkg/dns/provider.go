@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Provider publishes the records of the zone to an authoritative name server
+type Provider interface {
+	// SetRecords replaces all records of a name and type with values, no values removes them
+	SetRecords(name string, typ string, ttl uint, values []string) error
+}
+
+// NopProvider only keeps the records in the database, it is used if no name server is configured
+type NopProvider struct{}
+
+// SetRecords does nothing
+func (NopProvider) SetRecords(string, string, uint, []string) error {
+	return nil
+}
+
+// PowerDNS publishes records using the HTTP API of a PowerDNS authoritative server
+type PowerDNS struct {
+	// URL is the address of the API, e.g. http://127.0.0.1:8081
+	URL    string
+	APIKey string
+	// Server is the id of the server, PowerDNS only knows localhost
+	Server string
+	Zone   string
+	Client *http.Client
+}
+
+type powerDNSRecord struct {
+	Content  string `json:"content"`
+	Disabled bool   `json:"disabled"`
+}
+
+type powerDNSRRSet struct {
+	Name       string           `json:"name"`
+	Type       string           `json:"type"`
+	TTL        uint             `json:"ttl,omitempty"`
+	ChangeType string           `json:"changetype"`
+	Records    []powerDNSRecord `json:"records"`
+}
+
+// canonical appends the trailing dot PowerDNS expects for names
+func canonical(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// content converts a value to the presentation format of its type
+func content(typ string, value string) string {
+	switch typ {
+	case CNAME:
+		return canonical(value)
+	case TXT:
+		return fmt.Sprintf("%q", value)
+	}
+	return value
+}
+
+// SetRecords patches the rrset of name and type in the zone
+func (p *PowerDNS) SetRecords(name string, typ string, ttl uint, values []string) error {
+	rrset := powerDNSRRSet{
+		Name:       canonical(name),
+		Type:       typ,
+		ChangeType: "DELETE",
+		Records:    []powerDNSRecord{},
+	}
+
+	if len(values) != 0 {
+		rrset.ChangeType = "REPLACE"
+		rrset.TTL = ttl
+		for _, v := range values {
+			rrset.Records = append(rrset.Records, powerDNSRecord{
+				Content: content(typ, v),
+			})
+		}
+	}
+
+	body, err := json.Marshal(map[string][]powerDNSRRSet{
+		"rrsets": []powerDNSRRSet{rrset},
+	})
+	if err != nil {
+		return err
+	}
+
+	server := p.Server
+	if server == "" {
+		server = "localhost"
+	}
+
+	url := fmt.Sprintf("%s/api/v1/servers/%s/zones/%s", strings.TrimSuffix(p.URL, "/"), server, canonical(p.Zone))
+	req, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-API-Key", p.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode/100 != 2 {
+		e := struct {
+			Error string `json:"error"`
+		}{}
+		json.NewDecoder(res.Body).Decode(&e)
+		return fmt.Errorf("powerdns: %s %s: %s %s", typ, name, res.Status, e.Error)
+	}
+	return nil
+}
@@ -0,0 +1,612 @@
+// Package dns manages the records of the zone instances and custom domains are served under
+package dns
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+var (
+	// ErrInvalidName occurs if a record name is not part of the zone or reserved
+	ErrInvalidName = errors.New("name is not part of the zone")
+
+	// ErrInvalidType occurs if a record type is not supported
+	ErrInvalidType = errors.New("record type is not supported")
+
+	// ErrInvalidValue occurs if a record value does not match its type
+	ErrInvalidValue = errors.New("value is not valid for the record type")
+
+	// ErrNameTaken occurs if a name is already used by another user
+	ErrNameTaken = errors.New("name is used by another user")
+
+	// ErrCNAMEConflict occurs if a CNAME record would share its name with another record
+	ErrCNAMEConflict = errors.New("a CNAME record can not share its name with other records")
+
+	// ErrRecordNotExist occurs if a record does not exist
+	ErrRecordNotExist = errors.New("record does not exist")
+
+	// ErrDomainTaken occurs if a custom domain was already added by another user
+	ErrDomainTaken = errors.New("domain was added by another user")
+
+	// ErrDomainNotExist occurs if a custom domain was not added
+	ErrDomainNotExist = errors.New("domain was not added")
+
+	// ErrNotVerified occurs if the verification record of a custom domain does not contain its token
+	ErrNotVerified = errors.New("verification record does not contain the token")
+)
+
+// Service DNSService
+type Service interface {
+	// CreateRecord adds a record to the zone
+	CreateRecord(refID uint, r *Record) error
+
+	// RemoveRecord removes a record of a user from the zone
+	RemoveRecord(refID uint, id uint) error
+
+	// Records returns the records of a user
+	Records(refID uint, r *[]Record) error
+
+	// CreateInstanceRecords points the subdomain of an instance to the router
+	CreateInstanceRecords(refID uint, instance string) error
+
+	// RemoveInstanceRecords removes the records of an instance's subdomain
+	RemoveInstanceRecords(refID uint, instance string) error
+
+	// AddCustomDomain starts the verification of a custom domain and publishes its verification record
+	AddCustomDomain(refID uint, domain string, v *Verification) error
+
+	// RemoveCustomDomain removes a custom domain and its verification record
+	RemoveCustomDomain(refID uint, domain string) error
+
+	// VerifyCustomDomain checks whether a custom domain serves its verification token
+	VerifyCustomDomain(refID uint, domain string) error
+
+	// CustomDomains returns the custom domains of a user
+	CustomDomains(refID uint, v *[]Verification) error
+
+	// Verified returns whether a custom domain was verified for a user
+	Verified(refID uint, domain string) bool
+}
+
+// LookupFunc returns the TXT records of a name
+type LookupFunc func(name string) ([]string, error)
+
+// Options configures the zone and where instance subdomains point to
+type Options struct {
+	// Zone is the domain instances get their subdomains of, e.g. kontainer.ooo
+	Zone string
+
+	// IPv4 and IPv6 are the public addresses of the router
+	IPv4 string
+	IPv6 string
+
+	// Target is a host name instance subdomains are aliased to instead of the addresses
+	Target string
+
+	// TTL is used for records without a TTL
+	TTL uint
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db       dbAdapter
+	provider Provider
+	lookup   LookupFunc
+	opts     Options
+	mtx      *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Record{}, &Verification{})
+}
+
+// normalize lower cases a name and removes its trailing dot
+func normalize(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// validHost checks whether name is a valid host name
+func validHost(name string) bool {
+	if name == "" || len(name) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// inZone checks whether name is the zone or one of its subdomains
+func (s *service) inZone(name string) bool {
+	return name == s.opts.Zone || strings.HasSuffix(name, "."+s.opts.Zone)
+}
+
+// verificationTarget returns the name of the TXT record a custom domain's verification record is aliased to
+func (s *service) verificationTarget(token string) string {
+	return fmt.Sprintf("%s.%s.%s", token, VerificationPrefix, s.opts.Zone)
+}
+
+func (s *service) validate(r *Record) error {
+	if !validHost(r.Name) || !s.inZone(r.Name) {
+		return ErrInvalidName
+	}
+
+	switch r.Type {
+	case A:
+		ip := net.ParseIP(r.Value)
+		if ip == nil || ip.To4() == nil {
+			return ErrInvalidValue
+		}
+	case AAAA:
+		ip := net.ParseIP(r.Value)
+		if ip == nil || ip.To4() != nil {
+			return ErrInvalidValue
+		}
+	case CNAME:
+		r.Value = normalize(r.Value)
+		if !validHost(r.Value) {
+			return ErrInvalidValue
+		}
+	case TXT:
+		if r.Value == "" || len(r.Value) > 255 {
+			return ErrInvalidValue
+		}
+	default:
+		return ErrInvalidType
+	}
+
+	existing, err := s.recordsByName(r.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range existing {
+		if e.RefID != r.RefID {
+			return ErrNameTaken
+		}
+		if e.Type == CNAME || r.Type == CNAME {
+			return ErrCNAMEConflict
+		}
+	}
+	return nil
+}
+
+// recordsByName returns every record of a name, the rows are filtered again
+// since the conditions are not applied by every dbAdapter
+func (s *service) recordsByName(name string) ([]Record, error) {
+	rs := []Record{}
+	err := s.db.Find(&rs, "name = ?", name)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := []Record{}
+	for _, r := range rs {
+		if r.Name == name {
+			byName = append(byName, r)
+		}
+	}
+	return byName, nil
+}
+
+// publish sends the current values of a name and type to the provider
+func (s *service) publish(name string, typ string) error {
+	rs, err := s.recordsByName(name)
+	if err != nil {
+		return err
+	}
+
+	ttl := uint(0)
+	values := []string{}
+	for _, r := range rs {
+		if r.Type != typ {
+			continue
+		}
+		values = append(values, r.Value)
+		if ttl == 0 || r.TTL < ttl {
+			ttl = r.TTL
+		}
+	}
+
+	return s.provider.SetRecords(name, typ, ttl, values)
+}
+
+func (s *service) CreateRecord(refID uint, r *Record) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// records created by the user never belong to an instance
+	r.Instance = ""
+	return s.createRecord(refID, r)
+}
+
+func (s *service) createRecord(refID uint, r *Record) error {
+	r.Name = normalize(r.Name)
+	if strings.HasSuffix(r.Name, fmt.Sprintf(".%s.%s", VerificationPrefix, s.opts.Zone)) && r.Instance == "" {
+		return ErrInvalidName
+	}
+
+	r.ID = 0
+	r.RefID = refID
+	r.Type = strings.ToUpper(r.Type)
+	if r.TTL == 0 {
+		r.TTL = s.opts.TTL
+	}
+
+	err := s.validate(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Create(r)
+	if err != nil {
+		return err
+	}
+
+	err = s.publish(r.Name, r.Type)
+	if err != nil {
+		s.db.Delete(&Record{ID: r.ID})
+		return err
+	}
+	return nil
+}
+
+func (s *service) RemoveRecord(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeRecord(refID, id)
+}
+
+func (s *service) removeRecord(refID uint, id uint) error {
+	r := Record{}
+	err := s.db.Where("id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.First(&r, "id = ?", id)
+	if err != nil || r.ID != id || r.RefID != refID {
+		return ErrRecordNotExist
+	}
+
+	err = s.db.Delete(&Record{ID: id})
+	if err != nil {
+		return err
+	}
+
+	return s.publish(r.Name, r.Type)
+}
+
+func (s *service) Records(refID uint, r *[]Record) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.records(refID, r)
+}
+
+func (s *service) records(refID uint, r *[]Record) error {
+	rs := []Record{}
+	err := s.db.Find(&rs, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	*r = []Record{}
+	for _, record := range rs {
+		if record.RefID == refID {
+			*r = append(*r, record)
+		}
+	}
+	return nil
+}
+
+func (s *service) CreateInstanceRecords(refID uint, instance string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.createInstanceRecords(refID, instance)
+}
+
+func (s *service) createInstanceRecords(refID uint, instance string) error {
+	if s.opts.Zone == "" {
+		return nil
+	}
+
+	name := InstanceDomain(refID, normalize(instance), s.opts.Zone)
+	records := []Record{}
+	if s.opts.Target != "" {
+		records = append(records, Record{Type: CNAME, Value: s.opts.Target})
+	} else {
+		if s.opts.IPv4 != "" {
+			records = append(records, Record{Type: A, Value: s.opts.IPv4})
+		}
+		if s.opts.IPv6 != "" {
+			records = append(records, Record{Type: AAAA, Value: s.opts.IPv6})
+		}
+	}
+
+	created := []uint{}
+	for _, r := range records {
+		r.Name = name
+		r.Instance = instance
+		err := s.createRecord(refID, &r)
+		if err != nil {
+			for _, id := range created {
+				s.removeRecord(refID, id)
+			}
+			return err
+		}
+		created = append(created, r.ID)
+	}
+	return nil
+}
+
+func (s *service) RemoveInstanceRecords(refID uint, instance string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeInstanceRecords(refID, instance)
+}
+
+func (s *service) removeInstanceRecords(refID uint, instance string) error {
+	rs := []Record{}
+	err := s.records(refID, &rs)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rs {
+		if r.Instance != instance {
+			continue
+		}
+
+		err = s.removeRecord(refID, r.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verification returns the verification of a custom domain
+func (s *service) verification(domain string) (Verification, error) {
+	v := Verification{}
+	err := s.db.Where("domain = ?", domain)
+	if err != nil {
+		return v, err
+	}
+
+	err = s.db.First(&v, "domain = ?", domain)
+	if err != nil || v.Domain != domain {
+		return Verification{}, ErrDomainNotExist
+	}
+	return v, nil
+}
+
+func (s *service) AddCustomDomain(refID uint, domain string, v *Verification) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.addCustomDomain(refID, domain, v)
+}
+
+func (s *service) addCustomDomain(refID uint, domain string, v *Verification) error {
+	domain = normalize(domain)
+	if !validHost(domain) || s.inZone(domain) {
+		return ErrInvalidName
+	}
+
+	existing, err := s.verification(domain)
+	if err == nil {
+		if existing.RefID != refID {
+			return ErrDomainTaken
+		}
+		*v = existing
+		return nil
+	}
+
+	b := make([]byte, 16)
+	_, err = rand.Read(b)
+	if err != nil {
+		return err
+	}
+
+	*v = Verification{
+		Domain: domain,
+		RefID:  refID,
+		Token:  hex.EncodeToString(b),
+	}
+
+	err = s.db.Create(v)
+	if err != nil {
+		return err
+	}
+
+	// the user aliases the verification name of their domain to this record,
+	// so the token never has to be copied into a foreign zone
+	if s.opts.Zone != "" {
+		err = s.createRecord(refID, &Record{
+			Name:     s.verificationTarget(v.Token),
+			Type:     TXT,
+			Value:    v.Token,
+			Instance: VerificationPrefix,
+		})
+		if err != nil {
+			s.db.Delete(&Verification{Domain: domain})
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) RemoveCustomDomain(refID uint, domain string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeCustomDomain(refID, domain)
+}
+
+func (s *service) removeCustomDomain(refID uint, domain string) error {
+	domain = normalize(domain)
+	v, err := s.verification(domain)
+	if err != nil || v.RefID != refID {
+		return ErrDomainNotExist
+	}
+
+	rs, err := s.recordsByName(s.verificationTarget(v.Token))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rs {
+		err = s.removeRecord(refID, r.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.Delete(&Verification{Domain: domain})
+}
+
+func (s *service) VerifyCustomDomain(refID uint, domain string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.verifyCustomDomain(refID, domain)
+}
+
+func (s *service) verifyCustomDomain(refID uint, domain string) error {
+	domain = normalize(domain)
+	v, err := s.verification(domain)
+	if err != nil || v.RefID != refID {
+		return ErrDomainNotExist
+	}
+
+	if v.Verified {
+		return nil
+	}
+
+	txts, err := s.lookup(VerificationName(domain))
+	if err != nil {
+		return err
+	}
+
+	for _, txt := range txts {
+		if strings.TrimSpace(txt) != v.Token {
+			continue
+		}
+
+		s.db.Begin()
+		err = s.db.Where("domain = ?", domain)
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+
+		err = s.db.Update(&Verification{}, &Verification{Verified: true})
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+		s.db.Commit()
+		return nil
+	}
+	return ErrNotVerified
+}
+
+func (s *service) CustomDomains(refID uint, v *[]Verification) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.customDomains(refID, v)
+}
+
+func (s *service) customDomains(refID uint, v *[]Verification) error {
+	vs := []Verification{}
+	err := s.db.Find(&vs, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	*v = []Verification{}
+	for _, verification := range vs {
+		if verification.RefID == refID {
+			*v = append(*v, verification)
+		}
+	}
+	return nil
+}
+
+func (s *service) Verified(refID uint, domain string) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	v, err := s.verification(normalize(domain))
+	return err == nil && v.RefID == refID && v.Verified
+}
+
+// NewService creates a DNSService with necessary dependencies, records are published using p
+// and custom domains are verified using lookup, which defaults to net.LookupTXT
+func NewService(db dbAdapter, p Provider, lookup LookupFunc, o Options) (Service, error) {
+	if p == nil {
+		p = NopProvider{}
+	}
+
+	if lookup == nil {
+		lookup = net.LookupTXT
+	}
+
+	o.Zone = normalize(o.Zone)
+	o.Target = normalize(o.Target)
+	if o.TTL == 0 {
+		o.TTL = 300
+	}
+
+	s := &service{
+		db:       db,
+		provider: p,
+		lookup:   lookup,
+		opts:     o,
+		mtx:      &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
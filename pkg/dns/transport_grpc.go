@@ -0,0 +1,430 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC DNSServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.DNSServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createRecord: grpctransport.NewServer(
+			endpoints.CreateRecordEndpoint,
+			DecodeGRPCCreateRecordRequest,
+			EncodeGRPCCreateRecordResponse,
+			options...,
+		),
+
+		removeRecord: grpctransport.NewServer(
+			endpoints.RemoveRecordEndpoint,
+			DecodeGRPCRemoveRecordRequest,
+			EncodeGRPCRemoveRecordResponse,
+			options...,
+		),
+
+		records: grpctransport.NewServer(
+			endpoints.RecordsEndpoint,
+			DecodeGRPCRecordsRequest,
+			EncodeGRPCRecordsResponse,
+			options...,
+		),
+
+		createInstanceRecords: grpctransport.NewServer(
+			endpoints.CreateInstanceRecordsEndpoint,
+			DecodeGRPCCreateInstanceRecordsRequest,
+			EncodeGRPCCreateInstanceRecordsResponse,
+			options...,
+		),
+
+		removeInstanceRecords: grpctransport.NewServer(
+			endpoints.RemoveInstanceRecordsEndpoint,
+			DecodeGRPCRemoveInstanceRecordsRequest,
+			EncodeGRPCRemoveInstanceRecordsResponse,
+			options...,
+		),
+
+		addCustomDomain: grpctransport.NewServer(
+			endpoints.AddCustomDomainEndpoint,
+			DecodeGRPCAddCustomDomainRequest,
+			EncodeGRPCAddCustomDomainResponse,
+			options...,
+		),
+
+		removeCustomDomain: grpctransport.NewServer(
+			endpoints.RemoveCustomDomainEndpoint,
+			DecodeGRPCRemoveCustomDomainRequest,
+			EncodeGRPCRemoveCustomDomainResponse,
+			options...,
+		),
+
+		verifyCustomDomain: grpctransport.NewServer(
+			endpoints.VerifyCustomDomainEndpoint,
+			DecodeGRPCVerifyCustomDomainRequest,
+			EncodeGRPCVerifyCustomDomainResponse,
+			options...,
+		),
+
+		customDomains: grpctransport.NewServer(
+			endpoints.CustomDomainsEndpoint,
+			DecodeGRPCCustomDomainsRequest,
+			EncodeGRPCCustomDomainsResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createRecord          grpctransport.Handler
+	removeRecord          grpctransport.Handler
+	records               grpctransport.Handler
+	createInstanceRecords grpctransport.Handler
+	removeInstanceRecords grpctransport.Handler
+	addCustomDomain       grpctransport.Handler
+	removeCustomDomain    grpctransport.Handler
+	verifyCustomDomain    grpctransport.Handler
+	customDomains         grpctransport.Handler
+}
+
+func (s *grpcServer) CreateRecord(ctx oldcontext.Context, req *pb.CreateRecordRequest) (*pb.CreateRecordResponse, error) {
+	_, res, err := s.createRecord.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateRecordResponse), nil
+}
+
+func (s *grpcServer) RemoveRecord(ctx oldcontext.Context, req *pb.RemoveRecordRequest) (*pb.RemoveRecordResponse, error) {
+	_, res, err := s.removeRecord.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveRecordResponse), nil
+}
+
+func (s *grpcServer) Records(ctx oldcontext.Context, req *pb.RecordsRequest) (*pb.RecordsResponse, error) {
+	_, res, err := s.records.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RecordsResponse), nil
+}
+
+func (s *grpcServer) CreateInstanceRecords(ctx oldcontext.Context, req *pb.CreateInstanceRecordsRequest) (*pb.CreateInstanceRecordsResponse, error) {
+	_, res, err := s.createInstanceRecords.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateInstanceRecordsResponse), nil
+}
+
+func (s *grpcServer) RemoveInstanceRecords(ctx oldcontext.Context, req *pb.RemoveInstanceRecordsRequest) (*pb.RemoveInstanceRecordsResponse, error) {
+	_, res, err := s.removeInstanceRecords.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveInstanceRecordsResponse), nil
+}
+
+func (s *grpcServer) AddCustomDomain(ctx oldcontext.Context, req *pb.AddCustomDomainRequest) (*pb.AddCustomDomainResponse, error) {
+	_, res, err := s.addCustomDomain.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.AddCustomDomainResponse), nil
+}
+
+func (s *grpcServer) RemoveCustomDomain(ctx oldcontext.Context, req *pb.RemoveCustomDomainRequest) (*pb.RemoveCustomDomainResponse, error) {
+	_, res, err := s.removeCustomDomain.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveCustomDomainResponse), nil
+}
+
+func (s *grpcServer) VerifyCustomDomain(ctx oldcontext.Context, req *pb.VerifyCustomDomainRequest) (*pb.VerifyCustomDomainResponse, error) {
+	_, res, err := s.verifyCustomDomain.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.VerifyCustomDomainResponse), nil
+}
+
+func (s *grpcServer) CustomDomains(ctx oldcontext.Context, req *pb.CustomDomainsRequest) (*pb.CustomDomainsResponse, error) {
+	_, res, err := s.customDomains.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CustomDomainsResponse), nil
+}
+
+// ConvertRecord converts a Record to its protobuf representation
+func ConvertRecord(r *Record) *pb.Record {
+	if r == nil {
+		return nil
+	}
+
+	return &pb.Record{
+		ID:       uint32(r.ID),
+		Name:     r.Name,
+		Type:     r.Type,
+		Value:    r.Value,
+		TTL:      uint32(r.TTL),
+		Instance: r.Instance,
+	}
+}
+
+// ConvertPBRecord converts a protobuf Record to a Record
+func ConvertPBRecord(r *pb.Record) *Record {
+	if r == nil {
+		return &Record{}
+	}
+
+	return &Record{
+		ID:       uint(r.ID),
+		Name:     r.Name,
+		Type:     r.Type,
+		Value:    r.Value,
+		TTL:      uint(r.TTL),
+		Instance: r.Instance,
+	}
+}
+
+// ConvertVerification converts a Verification to its protobuf representation
+func ConvertVerification(v *Verification) *pb.Verification {
+	return &pb.Verification{
+		Domain:   v.Domain,
+		Token:    v.Token,
+		Verified: v.Verified,
+	}
+}
+
+// ConvertPBVerification converts a protobuf Verification to a Verification
+func ConvertPBVerification(v *pb.Verification) Verification {
+	if v == nil {
+		return Verification{}
+	}
+
+	return Verification{
+		Domain:   v.Domain,
+		Token:    v.Token,
+		Verified: v.Verified,
+	}
+}
+
+// DecodeGRPCCreateRecordRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateRecord request to a messages/dns.proto-domain createrecord request.
+func DecodeGRPCCreateRecordRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateRecordRequest)
+	return CreateRecordRequest{
+		RefID:  uint(req.RefID),
+		Record: ConvertPBRecord(req.Record),
+	}, nil
+}
+
+// EncodeGRPCCreateRecordResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain createrecord response to a gRPC CreateRecord response.
+func EncodeGRPCCreateRecordResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateRecordResponse)
+	gRPCRes := &pb.CreateRecordResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveRecordRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveRecord request to a messages/dns.proto-domain removerecord request.
+func DecodeGRPCRemoveRecordRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveRecordRequest)
+	return RemoveRecordRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveRecordResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain removerecord response to a gRPC RemoveRecord response.
+func EncodeGRPCRemoveRecordResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveRecordResponse)
+	gRPCRes := &pb.RemoveRecordResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRecordsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Records request to a messages/dns.proto-domain records request.
+func DecodeGRPCRecordsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RecordsRequest)
+	return RecordsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCRecordsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain records response to a gRPC Records response.
+func EncodeGRPCRecordsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RecordsResponse)
+	records := make([]*pb.Record, len(res.Records))
+	for i, r := range res.Records {
+		records[i] = ConvertRecord(&r)
+	}
+
+	gRPCRes := &pb.RecordsResponse{
+		Records:  records,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCCreateInstanceRecordsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateInstanceRecords request to a messages/dns.proto-domain createinstancerecords request.
+func DecodeGRPCCreateInstanceRecordsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateInstanceRecordsRequest)
+	return CreateInstanceRecordsRequest{
+		RefID:    uint(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// EncodeGRPCCreateInstanceRecordsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain createinstancerecords response to a gRPC CreateInstanceRecords response.
+func EncodeGRPCCreateInstanceRecordsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateInstanceRecordsResponse)
+	gRPCRes := &pb.CreateInstanceRecordsResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveInstanceRecordsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveInstanceRecords request to a messages/dns.proto-domain removeinstancerecords request.
+func DecodeGRPCRemoveInstanceRecordsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveInstanceRecordsRequest)
+	return RemoveInstanceRecordsRequest{
+		RefID:    uint(req.RefID),
+		Instance: req.Instance,
+	}, nil
+}
+
+// EncodeGRPCRemoveInstanceRecordsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain removeinstancerecords response to a gRPC RemoveInstanceRecords response.
+func EncodeGRPCRemoveInstanceRecordsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveInstanceRecordsResponse)
+	gRPCRes := &pb.RemoveInstanceRecordsResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCAddCustomDomainRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC AddCustomDomain request to a messages/dns.proto-domain addcustomdomain request.
+func DecodeGRPCAddCustomDomainRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AddCustomDomainRequest)
+	return AddCustomDomainRequest{
+		RefID:  uint(req.RefID),
+		Domain: req.Domain,
+	}, nil
+}
+
+// EncodeGRPCAddCustomDomainResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain addcustomdomain response to a gRPC AddCustomDomain response.
+func EncodeGRPCAddCustomDomainResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(AddCustomDomainResponse)
+	gRPCRes := &pb.AddCustomDomainResponse{
+		Verification: ConvertVerification(&res.Verification),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveCustomDomainRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveCustomDomain request to a messages/dns.proto-domain removecustomdomain request.
+func DecodeGRPCRemoveCustomDomainRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveCustomDomainRequest)
+	return RemoveCustomDomainRequest{
+		RefID:  uint(req.RefID),
+		Domain: req.Domain,
+	}, nil
+}
+
+// EncodeGRPCRemoveCustomDomainResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain removecustomdomain response to a gRPC RemoveCustomDomain response.
+func EncodeGRPCRemoveCustomDomainResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveCustomDomainResponse)
+	gRPCRes := &pb.RemoveCustomDomainResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCVerifyCustomDomainRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC VerifyCustomDomain request to a messages/dns.proto-domain verifycustomdomain request.
+func DecodeGRPCVerifyCustomDomainRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.VerifyCustomDomainRequest)
+	return VerifyCustomDomainRequest{
+		RefID:  uint(req.RefID),
+		Domain: req.Domain,
+	}, nil
+}
+
+// EncodeGRPCVerifyCustomDomainResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain verifycustomdomain response to a gRPC VerifyCustomDomain response.
+func EncodeGRPCVerifyCustomDomainResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(VerifyCustomDomainResponse)
+	gRPCRes := &pb.VerifyCustomDomainResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCCustomDomainsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CustomDomains request to a messages/dns.proto-domain customdomains request.
+func DecodeGRPCCustomDomainsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CustomDomainsRequest)
+	return CustomDomainsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCCustomDomainsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/dns.proto-domain customdomains response to a gRPC CustomDomains response.
+func EncodeGRPCCustomDomainsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CustomDomainsResponse)
+	domains := make([]*pb.Verification, len(res.Domains))
+	for i, v := range res.Domains {
+		domains[i] = ConvertVerification(&v)
+	}
+
+	gRPCRes := &pb.CustomDomainsResponse{
+		Domains:  domains,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,156 @@
+package dns
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+)
+
+// MakeWebsocketService makes a set of dns Endpoints available as a websocket Service
+func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
+	service, _ := ws.NewServiceDescription("dnsService", ws.ProtoIDFromString("DNS"))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"CreateRecord",
+		ws.ProtoIDFromString("CRR"),
+		endpoints.CreateRecordEndpoint,
+		DecodeWSCreateRecordRequest,
+		EncodeGRPCCreateRecordResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"RemoveRecord",
+		ws.ProtoIDFromString("RMR"),
+		endpoints.RemoveRecordEndpoint,
+		DecodeWSRemoveRecordRequest,
+		EncodeGRPCRemoveRecordResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"Records",
+		ws.ProtoIDFromString("REC"),
+		endpoints.RecordsEndpoint,
+		DecodeWSRecordsRequest,
+		EncodeGRPCRecordsResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"AddCustomDomain",
+		ws.ProtoIDFromString("ACD"),
+		endpoints.AddCustomDomainEndpoint,
+		DecodeWSAddCustomDomainRequest,
+		EncodeGRPCAddCustomDomainResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"RemoveCustomDomain",
+		ws.ProtoIDFromString("RCD"),
+		endpoints.RemoveCustomDomainEndpoint,
+		DecodeWSRemoveCustomDomainRequest,
+		EncodeGRPCRemoveCustomDomainResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"VerifyCustomDomain",
+		ws.ProtoIDFromString("VCD"),
+		endpoints.VerifyCustomDomainEndpoint,
+		DecodeWSVerifyCustomDomainRequest,
+		EncodeGRPCVerifyCustomDomainResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"CustomDomains",
+		ws.ProtoIDFromString("CDS"),
+		endpoints.CustomDomainsEndpoint,
+		DecodeWSCustomDomainsRequest,
+		EncodeGRPCCustomDomainsResponse,
+	))
+
+	return service
+}
+
+// DecodeWSCreateRecordRequest is a websocket.DecodeRequestFunc that converts a
+// WS CreateRecord request to a messages/dns.proto-domain createrecord request.
+func DecodeWSCreateRecordRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.CreateRecordRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCCreateRecordRequest(ctx, req)
+}
+
+// DecodeWSRemoveRecordRequest is a websocket.DecodeRequestFunc that converts a
+// WS RemoveRecord request to a messages/dns.proto-domain removerecord request.
+func DecodeWSRemoveRecordRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.RemoveRecordRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCRemoveRecordRequest(ctx, req)
+}
+
+// DecodeWSRecordsRequest is a websocket.DecodeRequestFunc that converts a
+// WS Records request to a messages/dns.proto-domain records request.
+func DecodeWSRecordsRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.RecordsRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCRecordsRequest(ctx, req)
+}
+
+// DecodeWSAddCustomDomainRequest is a websocket.DecodeRequestFunc that converts a
+// WS AddCustomDomain request to a messages/dns.proto-domain addcustomdomain request.
+func DecodeWSAddCustomDomainRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.AddCustomDomainRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCAddCustomDomainRequest(ctx, req)
+}
+
+// DecodeWSRemoveCustomDomainRequest is a websocket.DecodeRequestFunc that converts a
+// WS RemoveCustomDomain request to a messages/dns.proto-domain removecustomdomain request.
+func DecodeWSRemoveCustomDomainRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.RemoveCustomDomainRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCRemoveCustomDomainRequest(ctx, req)
+}
+
+// DecodeWSVerifyCustomDomainRequest is a websocket.DecodeRequestFunc that converts a
+// WS VerifyCustomDomain request to a messages/dns.proto-domain verifycustomdomain request.
+func DecodeWSVerifyCustomDomainRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.VerifyCustomDomainRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCVerifyCustomDomainRequest(ctx, req)
+}
+
+// DecodeWSCustomDomainsRequest is a websocket.DecodeRequestFunc that converts a
+// WS CustomDomains request to a messages/dns.proto-domain customdomains request.
+func DecodeWSCustomDomainsRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.CustomDomainsRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCCustomDomainsRequest(ctx, req)
+}
@@ -0,0 +1,207 @@
+// Package download serves large artifacts of the artifact store, like backups, invoices, data exports and
+// container exports, via plain HTTP instead of the gRPC and websocket APIs. The URLs of the objects are only
+// valid until they expire and are signed with HMAC-SHA256, so they can be handed out without any other credentials.
+package download
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+// Path is the path the downloads are served below, it is followed by the key of the object
+const Path = "/v1/downloads/"
+
+var (
+	// ErrExpired occurs if a URL is used after it expired
+	ErrExpired = errors.New("download URL expired")
+
+	// ErrSignature occurs if the signature of a URL does not match its object, name and expiry
+	ErrSignature = errors.New("invalid signature")
+)
+
+// Kind is a kind of artifact downloaded at signed URLs, it is the prefix of its objects in the artifact store
+type Kind string
+
+const (
+	// Backups are the backup archives of the nodes
+	Backups Kind = storage.Backups
+	// Invoices are the invoices and credit notes of the users
+	Invoices Kind = storage.Invoices
+	// Exports are the archives of the personal data of the users
+	Exports Kind = storage.Exports
+	// ContainerExports are the archives of the volumes of exported instances
+	ContainerExports Kind = storage.ContainerExports
+)
+
+// Kinds are the kinds of artifacts Handler serves, the other objects of the artifact store are never downloaded
+var Kinds = []Kind{Backups, Invoices, Exports, ContainerExports}
+
+// downloadable reports whether the object key is an artifact of one of Kinds
+func downloadable(key string) bool {
+	kind := Kind(strings.SplitN(key, "/", 2)[0])
+	for _, k := range Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// URLs hands out the download URLs of the objects of a store, it is satisfied by *Signer
+type URLs interface {
+	// URL returns the URL of the object key downloaded as name and when it expires
+	URL(key, name string) (string, time.Time)
+}
+
+// Signer signs and checks the download URLs of the objects of the artifact store
+type Signer struct {
+	secret []byte
+	base   string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewSigner returns a Signer for the URLs below baseURL, the public URL of the server of Handler,
+// which are valid for ttl
+func NewSigner(secret []byte, baseURL string, ttl time.Duration) *Signer {
+	return &Signer{
+		secret: secret,
+		base:   strings.TrimSuffix(baseURL, "/"),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// signature returns the hex encoded HMAC of an object downloaded as name until expires
+func (s *Signer) signature(key, name string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	io.WriteString(mac, key+"\n"+name+"\n"+strconv.FormatInt(expires, 10))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL returns the URL of the object key downloaded as name and when it expires
+func (s *Signer) URL(key, name string) (string, time.Time) {
+	expires := s.now().Add(s.ttl).Truncate(time.Second)
+
+	q := url.Values{}
+	q.Set("name", name)
+	q.Set("expires", strconv.FormatInt(expires.Unix(), 10))
+	q.Set("signature", s.signature(key, name, expires.Unix()))
+
+	u := url.URL{Path: Path + key, RawQuery: q.Encode()}
+	return s.base + u.String(), expires
+}
+
+// Verify checks the signature and the expiry of the download of the object key as name
+func (s *Signer) Verify(key, name, expires, signature string) error {
+	e, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.signature(key, name, e))) {
+		return ErrSignature
+	}
+	if s.now().Unix() > e {
+		return ErrExpired
+	}
+	return nil
+}
+
+type prefixURLs struct {
+	urls   URLs
+	prefix string
+}
+
+func (p *prefixURLs) URL(key, name string) (string, time.Time) {
+	return p.urls.URL(path.Join(p.prefix, key), name)
+}
+
+// Prefix returns the URLs of the objects of storage.Prefix(store, prefix) if u hands out the ones of store
+func Prefix(u URLs, prefix string) URLs {
+	return &prefixURLs{
+		urls:   u,
+		prefix: prefix,
+	}
+}
+
+// For returns the URLs of the artifacts of kind if u hands out the ones of the artifact store
+func For(u URLs, kind Kind) URLs {
+	return Prefix(u, string(kind))
+}
+
+// Handler serves the artifacts of Kinds kept in store at the URLs signed by s. Expired URLs are answered
+// with 410, URLs with an invalid signature with 403 and the other objects of store with 404.
+func Handler(store storage.Store, s *Signer, logger log.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, Path)
+		q := r.URL.Query()
+		name := q.Get("name")
+
+		err := s.Verify(key, name, q.Get("expires"), q.Get("signature"))
+		switch err {
+		case nil:
+		case ErrExpired:
+			http.Error(w, err.Error(), http.StatusGone)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+
+		if !downloadable(key) {
+			http.NotFound(w, r)
+			return
+		}
+
+		obj, err := store.Get(key)
+		if err == storage.ErrObjectNotExist {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			level.Error(logger).Log("key", key, "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer obj.Close()
+
+		if name == "" {
+			name = key
+		}
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(name)}))
+		w.Header().Set("Cache-Control", "private, no-store")
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		_, err = io.Copy(w, obj)
+		if err != nil {
+			level.Warn(logger).Log("key", key, "err", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package download_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/download"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Download", func() {
+	var (
+		dir   string
+		store storage.Store
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "kroo-download")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		store = storage.NewLocalStore(dir)
+		Ω(store.Put("exports/7/1.tar.gz", strings.NewReader("archive"), 7)).Should(Succeed())
+		Ω(store.Put("bundles/php.kmi", strings.NewReader("bundle"), 6)).Should(Succeed())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	get := func(h http.Handler, rawURL string) *httptest.ResponseRecorder {
+		u, err := url.Parse(rawURL)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", u.RequestURI(), nil))
+		return w
+	}
+
+	It("Should serve objects at signed URLs", func() {
+		s := download.NewSigner([]byte("secret"), "https://kroo.example.com/", time.Hour)
+		u, expires := download.Prefix(s, storage.Exports).URL("7/1.tar.gz", "export-1.tar.gz")
+		Expect(u).To(HavePrefix("https://kroo.example.com/v1/downloads/exports/7/1.tar.gz?"))
+		Expect(expires).To(BeTemporally("~", time.Now().Add(time.Hour), time.Second))
+
+		w := get(download.Handler(store, s, log.NewNopLogger()), u)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("archive"))
+		Expect(w.Header().Get("Content-Disposition")).To(Equal(`attachment; filename=export-1.tar.gz`))
+	})
+
+	It("Should serve the archives of exported instances", func() {
+		Ω(store.Put("container-exports/7/abc.tar.gz", strings.NewReader("volume"), 6)).Should(Succeed())
+
+		s := download.NewSigner([]byte("secret"), "", time.Hour)
+		u, _ := download.For(s, download.ContainerExports).URL("7/abc.tar.gz", "web.tar.gz")
+		Expect(u).To(HavePrefix("/v1/downloads/container-exports/7/abc.tar.gz?"))
+
+		w := get(download.Handler(store, s, log.NewNopLogger()), u)
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("volume"))
+	})
+
+	It("Should not serve objects of other kinds", func() {
+		s := download.NewSigner([]byte("secret"), "", time.Hour)
+		u, _ := s.URL("bundles/php.kmi", "php.kmi")
+
+		w := get(download.Handler(store, s, log.NewNopLogger()), u)
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("Should reject tampered URLs", func() {
+		s := download.NewSigner([]byte("secret"), "", time.Hour)
+		h := download.Handler(store, s, log.NewNopLogger())
+		u, _ := s.URL("exports/7/1.tar.gz", "export.tar.gz")
+
+		for _, tampered := range []string{
+			strings.Replace(u, "exports/7", "exports/8", 1),
+			strings.Replace(u, "name=export", "name=other", 1),
+			strings.Replace(u, "expires=", "expires=1", 1),
+			strings.Replace(u, "signature=", "signature=0", 1),
+		} {
+			Expect(get(h, tampered).Code).To(Equal(http.StatusForbidden))
+		}
+
+		other, _ := download.NewSigner([]byte("other"), "", time.Hour).URL("exports/7/1.tar.gz", "export.tar.gz")
+		Expect(get(h, other).Code).To(Equal(http.StatusForbidden))
+	})
+
+	It("Should reject expired URLs", func() {
+		s := download.NewSigner([]byte("secret"), "", -time.Minute)
+		u, _ := s.URL("exports/7/1.tar.gz", "export.tar.gz")
+
+		w := get(download.Handler(store, s, log.NewNopLogger()), u)
+		Expect(w.Code).To(Equal(http.StatusGone))
+	})
+
+	It("Should answer 404 for missing objects", func() {
+		s := download.NewSigner([]byte("secret"), "", time.Hour)
+		u, _ := s.URL("exports/7/2.tar.gz", "export.tar.gz")
+
+		w := get(download.Handler(store, s, log.NewNopLogger()), u)
+		Expect(w.Code).To(Equal(http.StatusNotFound))
+	})
+})
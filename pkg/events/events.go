@@ -0,0 +1,280 @@
+// Package events distributes the events of the services over a publish/subscribe bus,
+// so services react to each other without depending on one another
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrClosed is returned if an event is published or subscribed to on a closed bus
+var ErrClosed = errors.New("event bus closed")
+
+// Topics of the events published by the services
+const (
+	// ContainerEvents matches every container topic
+	ContainerEvents = "container.*"
+	// ContainerCreated is published with a ContainerEvent once an instance was created
+	ContainerCreated = "container.created"
+	// ContainerStopped is published with a ContainerEvent once an instance was stopped
+	ContainerStopped = "container.stopped"
+	// ContainerRemoved is published with a ContainerEvent once an instance was removed
+	ContainerRemoved = "container.removed"
+
+	// UserCreated is published with a UserEvent once a user was created
+	UserCreated = "user.created"
+	// UserDeleted is published with a UserEvent once a user was deleted
+	UserDeleted = "user.deleted"
+
+	// FirewallChanged is published with a FirewallEvent once a firewall rule was changed
+	FirewallChanged = "firewall.changed"
+
+	// CertificateEvents matches every certificate topic
+	CertificateEvents = "certificate.*"
+	// CertificateIssued is published with a CertificateEvent once the first certificate of a configuration was stored
+	CertificateIssued = "certificate.issued"
+	// CertificateRenewed is published with a CertificateEvent once a certificate replaced an earlier one
+	CertificateRenewed = "certificate.renewed"
+
+	// SnapshotEvents matches every snapshot topic
+	SnapshotEvents = "snapshot.*"
+	// SnapshotSucceeded is published with a SnapshotEvent once a scheduled snapshot was stored
+	SnapshotSucceeded = "snapshot.succeeded"
+	// SnapshotFailed is published with a SnapshotEvent once a snapshot or the restore of one failed
+	SnapshotFailed = "snapshot.failed"
+	// SnapshotRestored is published with a SnapshotEvent once a snapshot was restored into a new volume
+	SnapshotRestored = "snapshot.restored"
+
+	// CronEvents matches every cron topic
+	CronEvents = "cron.*"
+	// CronFailed is published with a CronEvent once a run of a cron job failed, successful runs are
+	// only kept in the history of the job
+	CronFailed = "cron.failed"
+
+	// AlertEvents matches every alert topic
+	AlertEvents = "alert.*"
+	// AlertFiring is published with an AlertEvent once an instance exceeded the threshold of an alert rule
+	// for its duration
+	AlertFiring = "alert.firing"
+	// AlertResolved is published with an AlertEvent once an instance of a firing alert is within the threshold again
+	AlertResolved = "alert.resolved"
+
+	// ScaleEvents matches every scale topic
+	ScaleEvents = "scale.*"
+	// InstanceScaled is published with a ScaleEvent once an autoscaling policy changed the replicas of an instance
+	InstanceScaled = "scale.succeeded"
+	// ScaleFailed is published with a ScaleEvent once an autoscaling policy failed to change the replicas of an instance
+	ScaleFailed = "scale.failed"
+
+	// ContainerCommands matches the commands operators send to the container services of the nodes
+	ContainerCommands = "command.container.>"
+	// StopContainer is published with a ContainerCommand to stop an instance
+	StopContainer = "command.container.stop"
+	// MoveContainer is published with a ContainerCommand to remove an instance from its node
+	// and place it on the target node
+	MoveContainer = "command.container.move"
+	// PlaceContainer is published with a ContainerCommand to create an instance which was removed from another node
+	PlaceContainer = "command.container.place"
+
+	// NodeChanged is published with a NodeEvent once a node was drained, cordoned, undrained or uncordoned
+	NodeChanged = "node.changed"
+	// NodeFailed is published with a NodeIncident once a node missed its heartbeats for long enough that its
+	// instances were rescheduled
+	NodeFailed = "node.failed"
+	// NodeRecovered is published with a NodeIncident once a failed node sends heartbeats again
+	NodeRecovered = "node.recovered"
+
+	// AgentChanged is published with an AgentEvent once an agent registered, missed its heartbeats or was removed
+	AgentChanged = "agent.changed"
+
+	// AccountEvents matches every billing account topic
+	AccountEvents = "account.*"
+	// AccountSuspended is published with an AccountEvent once a user was suspended for failed payments
+	AccountSuspended = "account.suspended"
+	// AccountResumed is published with an AccountEvent once a suspended user paid
+	AccountResumed = "account.resumed"
+)
+
+// ContainerEvent is the payload of the container topics
+type ContainerEvent struct {
+	RefID       uint   `json:"refID"`
+	ContainerID string `json:"containerID"`
+	Name        string `json:"name"`
+	// Replica is set if the container is a replica of another container
+	Replica bool `json:"replica,omitempty"`
+}
+
+// UserEvent is the payload of the user topics
+type UserEvent struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+// FirewallEvent is the payload of FirewallChanged, Action names the changed rule,
+// e.g. allow_connection or block_port
+type FirewallEvent struct {
+	Action   string `json:"action"`
+	SrcIP    string `json:"srcIP,omitempty"`
+	SrcNw    string `json:"srcNw,omitempty"`
+	DstIP    string `json:"dstIP,omitempty"`
+	DstNw    string `json:"dstNw,omitempty"`
+	Port     uint16 `json:"port,omitempty"`
+	Protocol string `json:"protocol,omitempty"`
+}
+
+// CertificateEvent is the payload of the certificate topics, the wildcard certificate has the RefID 0
+type CertificateEvent struct {
+	RefID    uint      `json:"refID"`
+	Name     string    `json:"name"`
+	Domains  []string  `json:"domains"`
+	NotAfter time.Time `json:"notAfter"`
+}
+
+// SnapshotEvent is the payload of the snapshot topics, Volume is set for restores
+type SnapshotEvent struct {
+	RefID      uint   `json:"refID"`
+	ScheduleID uint   `json:"scheduleID"`
+	SnapshotID uint   `json:"snapshotID,omitempty"`
+	Kind       string `json:"kind"`
+	Source     string `json:"source"`
+	Volume     string `json:"volume,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CronEvent is the payload of the cron topics
+type CronEvent struct {
+	RefID    uint   `json:"refID"`
+	JobID    uint   `json:"jobID"`
+	RunID    uint   `json:"runID"`
+	Name     string `json:"name"`
+	Instance string `json:"instance"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AlertEvent is the payload of the alert topics
+type AlertEvent struct {
+	RefID     uint    `json:"refID"`
+	RuleID    uint    `json:"ruleID"`
+	AlertID   uint    `json:"alertID"`
+	Rule      string  `json:"rule"`
+	Instance  string  `json:"instance"`
+	Metric    string  `json:"metric"`
+	Threshold float64 `json:"threshold"`
+	Value     float64 `json:"value"`
+}
+
+// ScaleEvent is the payload of the scale topics, Reason is the metric or the bounds the replicas were scaled for
+type ScaleEvent struct {
+	RefID    uint    `json:"refID"`
+	EventID  uint    `json:"eventID"`
+	Instance string  `json:"instance"`
+	From     uint    `json:"from"`
+	To       uint    `json:"to"`
+	Reason   string  `json:"reason"`
+	Value    float64 `json:"value"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// ContainerCommand is the payload of the container commands, it is handled by the node named Node
+type ContainerCommand struct {
+	Node        string `json:"node"`
+	RefID       uint   `json:"refID"`
+	ContainerID string `json:"containerID"`
+	Name        string `json:"name,omitempty"`
+	// KMIID is the id of the instance's KMI, which is kept after the instance was removed
+	KMIID    uint `json:"kmiID,omitempty"`
+	Replicas uint `json:"replicas,omitempty"`
+	// Target is the node an instance is moved to
+	Target string `json:"target,omitempty"`
+	// Lost is set if the instance was lost with its failed node, the node it is placed on forgets it first
+	Lost bool `json:"lost,omitempty"`
+	// Spread and Labels are the placement of the instance, which it keeps on the node it is placed on
+	Spread bool              `json:"spread,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NodeEvent is the payload of NodeChanged
+type NodeEvent struct {
+	Name     string `json:"name"`
+	Drained  bool   `json:"drained"`
+	Cordoned bool   `json:"cordoned,omitempty"`
+}
+
+// NodeIncident is the payload of NodeFailed and NodeRecovered, Rescheduled is the number of instances
+// placed on other nodes and Lost the number of pinned instances left on the failed node
+type NodeIncident struct {
+	Name        string `json:"name"`
+	Rescheduled uint   `json:"rescheduled,omitempty"`
+	Lost        uint   `json:"lost,omitempty"`
+}
+
+// AgentEvent is the payload of AgentChanged
+type AgentEvent struct {
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+	Online  bool   `json:"online"`
+}
+
+// AccountEvent is the payload of the account topics
+type AccountEvent struct {
+	RefID uint `json:"refID"`
+}
+
+// Event is a message published on a topic
+type Event struct {
+	ID    string    `json:"id"`
+	Topic string    `json:"topic"`
+	Time  time.Time `json:"time"`
+	// Node is the name of the node which published the event
+	Node string          `json:"node,omitempty"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the payload of the event into v
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+// Handler handles an event, if it returns an error the event is delivered again later
+type Handler func(Event) error
+
+// Subscription is a subscription to a topic
+type Subscription interface {
+	// Unsubscribe stops the delivery of events, events which are not handled yet are dropped
+	Unsubscribe() error
+}
+
+// Bus delivers the published events at least once to every subscriber of their topic
+type Bus interface {
+	// Publish publishes data marshalled as JSON on topic
+	Publish(topic string, data interface{}) error
+
+	// Subscribe calls h for every event published on a topic matching topic. Topics are dot separated
+	// tokens, a * matches one token and a trailing > matches the remaining ones. If group is not empty,
+	// every event is only delivered to one of the subscribers of the group, also across nodes.
+	Subscribe(topic, group string, h Handler) (Subscription, error)
+
+	// Close ends all subscriptions
+	Close() error
+}
+
+// Match reports whether topic matches the pattern of a subscription
+func Match(pattern, topic string) bool {
+	p := strings.Split(pattern, ".")
+	t := strings.Split(topic, ".")
+
+	for i, token := range p {
+		if token == ">" {
+			return i == len(p)-1 && len(t) > i
+		}
+		if i >= len(t) {
+			return false
+		}
+		if token != "*" && token != t[i] {
+			return false
+		}
+	}
+	return len(p) == len(t)
+}
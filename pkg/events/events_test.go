@@ -0,0 +1,149 @@
+package events_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Events", func() {
+	Describe("Match", func() {
+		It("Should match topics", func() {
+			Expect(events.Match("container.created", "container.created")).To(BeTrue())
+			Expect(events.Match("container.*", "container.removed")).To(BeTrue())
+			Expect(events.Match("*.created", "user.created")).To(BeTrue())
+			Expect(events.Match(">", "user.created")).To(BeTrue())
+			Expect(events.Match("container.>", "container.created")).To(BeTrue())
+
+			Expect(events.Match("container.created", "container.removed")).To(BeFalse())
+			Expect(events.Match("container.*", "container")).To(BeFalse())
+			Expect(events.Match("container.*", "container.created.node")).To(BeFalse())
+			Expect(events.Match("container.>", "container")).To(BeFalse())
+			Expect(events.Match("container.created.node", "container.created")).To(BeFalse())
+		})
+	})
+
+	Describe("Memory bus", func() {
+		var bus events.Bus
+
+		BeforeEach(func() {
+			bus = events.NewMemoryBus("node1", 10*time.Millisecond, log.NewNopLogger())
+		})
+
+		AfterEach(func() {
+			bus.Close()
+		})
+
+		collect := func() (events.Handler, func() []events.Event) {
+			var (
+				mtx      sync.Mutex
+				received []events.Event
+			)
+			return func(e events.Event) error {
+					mtx.Lock()
+					defer mtx.Unlock()
+					received = append(received, e)
+					return nil
+				}, func() []events.Event {
+					mtx.Lock()
+					defer mtx.Unlock()
+					return append([]events.Event{}, received...)
+				}
+		}
+
+		It("Should deliver events to every matching subscriber", func() {
+			h1, received1 := collect()
+			h2, received2 := collect()
+			_, err := bus.Subscribe(events.ContainerCreated, "", h1)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = bus.Subscribe("container.*", "", h2)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(bus.Publish(events.ContainerCreated, events.ContainerEvent{RefID: 1, Name: "web"})).To(Succeed())
+			Expect(bus.Publish(events.ContainerRemoved, events.ContainerEvent{RefID: 1, Name: "web"})).To(Succeed())
+			Expect(bus.Publish(events.UserCreated, events.UserEvent{ID: 1})).To(Succeed())
+
+			Eventually(received2).Should(HaveLen(2))
+			Expect(received1()).To(HaveLen(1))
+
+			e := received1()[0]
+			Expect(e.ID).NotTo(BeEmpty())
+			Expect(e.Topic).To(Equal(events.ContainerCreated))
+			Expect(e.Node).To(Equal("node1"))
+
+			c := events.ContainerEvent{}
+			Expect(e.Decode(&c)).To(Succeed())
+			Expect(c).To(Equal(events.ContainerEvent{RefID: 1, Name: "web"}))
+		})
+
+		It("Should deliver events once per group", func() {
+			h1, received1 := collect()
+			h2, received2 := collect()
+			h3, received3 := collect()
+			bus.Subscribe(events.UserCreated, "dns", h1)
+			bus.Subscribe(events.UserCreated, "dns", h2)
+			bus.Subscribe(events.UserCreated, "", h3)
+
+			for i := uint(0); i < 4; i++ {
+				Expect(bus.Publish(events.UserCreated, events.UserEvent{ID: i})).To(Succeed())
+			}
+
+			Eventually(received3).Should(HaveLen(4))
+			Eventually(func() int { return len(received1()) + len(received2()) }).Should(Equal(4))
+			Expect(received1()).To(HaveLen(2))
+			Expect(received2()).To(HaveLen(2))
+		})
+
+		It("Should redeliver events whose handler failed in order", func() {
+			var (
+				mtx   sync.Mutex
+				ids   []uint
+				fails = 2
+			)
+			bus.Subscribe(events.UserDeleted, "", func(e events.Event) error {
+				mtx.Lock()
+				defer mtx.Unlock()
+				u := events.UserEvent{}
+				e.Decode(&u)
+				if u.ID == 1 && fails > 0 {
+					fails--
+					return errors.New("unavailable")
+				}
+				ids = append(ids, u.ID)
+				return nil
+			})
+
+			bus.Publish(events.UserDeleted, events.UserEvent{ID: 1})
+			bus.Publish(events.UserDeleted, events.UserEvent{ID: 2})
+
+			Eventually(func() []uint {
+				mtx.Lock()
+				defer mtx.Unlock()
+				return append([]uint{}, ids...)
+			}).Should(Equal([]uint{1, 2}))
+		})
+
+		It("Should stop delivering events to cancelled subscriptions", func() {
+			h, received := collect()
+			sub, err := bus.Subscribe(events.FirewallChanged, "", h)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sub.Unsubscribe()).To(Succeed())
+
+			Expect(bus.Publish(events.FirewallChanged, events.FirewallEvent{Action: "allow_port"})).To(Succeed())
+			Consistently(received, 50*time.Millisecond).Should(BeEmpty())
+		})
+
+		It("Should be unusable once closed", func() {
+			Expect(bus.Close()).To(Succeed())
+			Expect(bus.Publish(events.UserCreated, events.UserEvent{})).To(Equal(events.ErrClosed))
+			_, err := bus.Subscribe(events.UserCreated, "", func(events.Event) error { return nil })
+			Expect(err).To(Equal(events.ErrClosed))
+		})
+	})
+})
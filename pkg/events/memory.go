@@ -0,0 +1,209 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+)
+
+type memorySubscription struct {
+	bus     *memoryBus
+	pattern string
+	group   string
+	h       Handler
+
+	mtx    sync.Mutex
+	cond   *sync.Cond
+	queue  []Event
+	closed bool
+	done   chan struct{}
+}
+
+func (s *memorySubscription) push(e Event) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return
+	}
+	s.queue = append(s.queue, e)
+	s.cond.Signal()
+}
+
+// next blocks until an event is queued, it returns false once the subscription is closed
+func (s *memorySubscription) next() (Event, bool) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for len(s.queue) == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if s.closed {
+		return Event{}, false
+	}
+	return s.queue[0], true
+}
+
+func (s *memorySubscription) pop() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if len(s.queue) > 0 {
+		s.queue = s.queue[1:]
+	}
+}
+
+// run delivers the queued events in order, an event whose handler failed is delivered
+// again after the retry delay before any later event
+func (s *memorySubscription) run() {
+	for {
+		e, ok := s.next()
+		if !ok {
+			return
+		}
+
+		err := s.h(e)
+		if err == nil {
+			s.pop()
+			continue
+		}
+
+		level.Warn(s.bus.logger).Log("topic", e.Topic, "event", e.ID, "group", s.group, "err", err)
+		select {
+		case <-time.After(s.bus.retry):
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *memorySubscription) close() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.queue = nil
+	close(s.done)
+	s.cond.Broadcast()
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.bus.remove(s)
+	s.close()
+	return nil
+}
+
+type memoryBus struct {
+	node   string
+	retry  time.Duration
+	logger log.Logger
+
+	mtx    sync.Mutex
+	subs   []*memorySubscription
+	next   map[string]int
+	closed bool
+}
+
+func (b *memoryBus) Publish(topic string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	e := Event{
+		ID:    logging.NewRequestID(),
+		Topic: topic,
+		Time:  time.Now().UTC(),
+		Node:  b.node,
+		Data:  raw,
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+
+	groups := make(map[string][]*memorySubscription)
+	for _, s := range b.subs {
+		if !Match(s.pattern, topic) {
+			continue
+		}
+		if s.group == "" {
+			s.push(e)
+			continue
+		}
+		groups[s.group] = append(groups[s.group], s)
+	}
+
+	// every group receives the event once, its members take turns
+	for group, members := range groups {
+		i := b.next[group] % len(members)
+		b.next[group] = i + 1
+		members[i].push(e)
+	}
+
+	return nil
+}
+
+func (b *memoryBus) Subscribe(topic, group string, h Handler) (Subscription, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	s := &memorySubscription{
+		bus:     b,
+		pattern: topic,
+		group:   group,
+		h:       h,
+		done:    make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mtx)
+	b.subs = append(b.subs, s)
+
+	go s.run()
+	return s, nil
+}
+
+func (b *memoryBus) remove(s *memorySubscription) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for i, sub := range b.subs {
+		if sub == s {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (b *memoryBus) Close() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+
+	for _, s := range b.subs {
+		s.close()
+	}
+	b.subs = nil
+	return nil
+}
+
+// NewMemoryBus returns a Bus delivering events within the process, events whose handler failed
+// are delivered again after retry. Events which were not handled when the bus is closed are lost,
+// so the delivery is only at least once as long as the process runs.
+func NewMemoryBus(node string, retry time.Duration, logger log.Logger) Bus {
+	return &memoryBus{
+		node:   node,
+		retry:  retry,
+		logger: logger,
+		next:   make(map[string]int),
+	}
+}
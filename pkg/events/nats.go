@@ -0,0 +1,136 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	nats "github.com/nats-io/nats.go"
+)
+
+type natsSubscription struct {
+	sub *nats.Subscription
+}
+
+func (s natsSubscription) Unsubscribe() error {
+	return s.sub.Unsubscribe()
+}
+
+// natsBus publishes the events to a JetStream stream, plain NATS subjects would drop the
+// events of subscribers which are not connected, JetStream keeps them until they are acknowledged
+type natsBus struct {
+	node   string
+	stream string
+	nc     *nats.Conn
+	js     nats.JetStreamContext
+	logger log.Logger
+}
+
+func (b *natsBus) subject(topic string) string {
+	return b.stream + "." + topic
+}
+
+// durable returns the name of the consumer shared by the members of a group on every node
+func durable(group, topic string) string {
+	return strings.NewReplacer(".", "_", "*", "any", ">", "all").Replace(group + "_" + topic)
+}
+
+func (b *natsBus) Publish(topic string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	msg, err := json.Marshal(Event{
+		ID:    logging.NewRequestID(),
+		Topic: topic,
+		Time:  time.Now().UTC(),
+		Node:  b.node,
+		Data:  raw,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = b.js.Publish(b.subject(topic), msg)
+	return err
+}
+
+func (b *natsBus) Subscribe(topic, group string, h Handler) (Subscription, error) {
+	cb := func(m *nats.Msg) {
+		e := Event{}
+		err := json.Unmarshal(m.Data, &e)
+		if err != nil {
+			// a malformed event would fail on every delivery
+			level.Error(b.logger).Log("subject", m.Subject, "err", err)
+			m.Term()
+			return
+		}
+
+		err = h(e)
+		if err != nil {
+			level.Warn(b.logger).Log("topic", e.Topic, "event", e.ID, "group", group, "err", err)
+			m.Nak()
+			return
+		}
+		m.Ack()
+	}
+
+	var (
+		sub *nats.Subscription
+		err error
+	)
+	if group == "" {
+		sub, err = b.js.Subscribe(b.subject(topic), cb, nats.ManualAck(), nats.DeliverNew())
+	} else {
+		name := durable(group, topic)
+		sub, err = b.js.QueueSubscribe(b.subject(topic), name, cb, nats.ManualAck(), nats.Durable(name))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return natsSubscription{sub}, nil
+}
+
+func (b *natsBus) Close() error {
+	return b.nc.Drain()
+}
+
+// NewNATSBus returns a Bus connected to the NATS server at url, the events are kept in the
+// JetStream stream named stream, which is created if it does not exist
+func NewNATSBus(node, url, stream string, logger log.Logger) (Bus, error) {
+	nc, err := nats.Connect(url, nats.Name(node), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	_, err = js.StreamInfo(stream)
+	if err != nil {
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:     stream,
+			Subjects: []string{stream + ".>"},
+		})
+		if err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+
+	return &natsBus{
+		node:   node,
+		stream: stream,
+		nc:     nc,
+		js:     js,
+		logger: logger,
+	}, nil
+}
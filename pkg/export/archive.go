@@ -0,0 +1,206 @@
+package export
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// manifestFile is the name of the manifest at the root of an archive
+const manifestFile = "manifest.json"
+
+// Manifest describes an archive, Parts are the directories of the parts in the order they were written
+type Manifest struct {
+	User    uint      `json:"user"`
+	Created time.Time `json:"created"`
+	Parts   []string  `json:"parts"`
+}
+
+// Part is one kind of personal data of a user, like the account or the invoices
+type Part interface {
+	// Name is the directory of the part in the archive
+	Name() string
+
+	// Export adds the files of the part for the user refID to the archive
+	Export(ctx context.Context, refID uint, w *Writer) error
+}
+
+// Writer adds the files of a part to an archive
+type Writer struct {
+	tw   *tar.Writer
+	dir  string
+	time time.Time
+}
+
+// checkName makes sure name is a relative slash separated path inside of the directory of the part
+func checkName(name string) error {
+	if name == "" || strings.HasPrefix(name, "/") || path.Clean(name) != name || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("invalid file name %q", name)
+	}
+	return nil
+}
+
+// Copy adds the file name with the size bytes read from r
+func (w *Writer) Copy(name string, r io.Reader, size int64) error {
+	err := checkName(name)
+	if err != nil {
+		return err
+	}
+
+	err = w.tw.WriteHeader(&tar.Header{
+		Name:     path.Join(w.dir, name),
+		Mode:     0644,
+		Size:     size,
+		ModTime:  w.time,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = io.CopyN(w.tw, r, size)
+	return err
+}
+
+// File adds the file name with content
+func (w *Writer) File(name string, content []byte) error {
+	return w.Copy(name, bytes.NewReader(content), int64(len(content)))
+}
+
+// JSON adds the file name with the indented JSON encoding of v
+func (w *Writer) JSON(name string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return w.File(name, append(b, '\n'))
+}
+
+type jsonPart struct {
+	name    string
+	collect func(ctx context.Context, refID uint) (interface{}, error)
+}
+
+func (p jsonPart) Name() string {
+	return p.name
+}
+
+func (p jsonPart) Export(ctx context.Context, refID uint, w *Writer) error {
+	v, err := p.collect(ctx, refID)
+	if err != nil {
+		return err
+	}
+	return w.JSON(p.name+".json", v)
+}
+
+// JSON returns a Part writing the value returned by collect into <name>/<name>.json
+func JSON(name string, collect func(ctx context.Context, refID uint) (interface{}, error)) Part {
+	return jsonPart{
+		name:    name,
+		collect: collect,
+	}
+}
+
+type directoryPart struct {
+	name string
+	root string
+}
+
+func (p directoryPart) Name() string {
+	return p.name
+}
+
+func (p directoryPart) Export(ctx context.Context, refID uint, w *Writer) error {
+	dir := filepath.Join(p.root, fmt.Sprintf("%d", refID))
+	return filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && file == dir {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return w.Copy(filepath.ToSlash(rel), f, info.Size())
+	})
+}
+
+// Directory returns a Part adding the regular files below the directory of the user in root, e.g. root/42,
+// a user without a directory has no files
+func Directory(name, root string) Part {
+	return directoryPart{
+		name: name,
+		root: root,
+	}
+}
+
+// Archive writes the gzipped tar archive of the parts of the user refID to w, every part is written into
+// its own directory next to the manifest
+func Archive(ctx context.Context, refID uint, parts []Part, w io.Writer) (Manifest, error) {
+	m := Manifest{
+		User:    refID,
+		Created: time.Now().UTC(),
+		Parts:   []string{},
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, p := range parts {
+		err := ctx.Err()
+		if err != nil {
+			return m, err
+		}
+
+		err = checkName(p.Name())
+		if err != nil {
+			return m, err
+		}
+
+		err = p.Export(ctx, refID, &Writer{
+			tw:   tw,
+			dir:  p.Name(),
+			time: m.Created,
+		})
+		if err != nil {
+			return m, fmt.Errorf("%s: %s", p.Name(), err)
+		}
+		m.Parts = append(m.Parts, p.Name())
+	}
+
+	manifest := &Writer{
+		tw:   tw,
+		time: m.Created,
+	}
+	err := manifest.JSON(manifestFile, m)
+	if err != nil {
+		return m, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return m, err
+	}
+	return m, gz.Close()
+}
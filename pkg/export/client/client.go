@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/export"
+	"github.com/kontainerooo/kontainer.ooo/pkg/export/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *export.Endpoints {
+
+	var ExportUserDataEndpoint endpoint.Endpoint
+	{
+		ExportUserDataEndpoint = grpctransport.NewClient(
+			conn,
+			"export.ExportService",
+			"ExportUserData",
+			EncodeGRPCExportUserDataRequest,
+			DecodeGRPCExportUserDataResponse,
+			pb.ExportUserDataResponse{},
+		).Endpoint()
+	}
+
+	var ExportsEndpoint endpoint.Endpoint
+	{
+		ExportsEndpoint = grpctransport.NewClient(
+			conn,
+			"export.ExportService",
+			"Exports",
+			EncodeGRPCExportsRequest,
+			DecodeGRPCExportsResponse,
+			pb.ExportsResponse{},
+		).Endpoint()
+	}
+
+	var DownloadEndpoint endpoint.Endpoint
+	{
+		DownloadEndpoint = grpctransport.NewClient(
+			conn,
+			"export.ExportService",
+			"Download",
+			EncodeGRPCDownloadRequest,
+			DecodeGRPCDownloadResponse,
+			pb.DownloadResponse{},
+		).Endpoint()
+	}
+
+	var DownloadURLEndpoint endpoint.Endpoint
+	{
+		DownloadURLEndpoint = grpctransport.NewClient(
+			conn,
+			"export.ExportService",
+			"DownloadURL",
+			EncodeGRPCDownloadURLRequest,
+			DecodeGRPCDownloadURLResponse,
+			pb.DownloadURLResponse{},
+		).Endpoint()
+	}
+
+	return &export.Endpoints{
+		ExportUserDataEndpoint: ExportUserDataEndpoint,
+		ExportsEndpoint:        ExportsEndpoint,
+		DownloadEndpoint:       DownloadEndpoint,
+		DownloadURLEndpoint:    DownloadURLEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCExportUserDataRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/export.proto-domain exportuserdata request to a gRPC ExportUserData request.
+func EncodeGRPCExportUserDataRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*export.ExportUserDataRequest)
+	return &pb.ExportUserDataRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCExportUserDataResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ExportUserData response to a messages/export.proto-domain exportuserdata response.
+func DecodeGRPCExportUserDataResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ExportUserDataResponse)
+	return &export.ExportUserDataResponse{
+		Export: export.ConvertPBExport(response.Export),
+		Error:  getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCExportsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/export.proto-domain exports request to a gRPC Exports request.
+func EncodeGRPCExportsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*export.ExportsRequest)
+	return &pb.ExportsRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCExportsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Exports response to a messages/export.proto-domain exports response.
+func DecodeGRPCExportsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ExportsResponse)
+	exports := make([]export.Export, len(response.Exports))
+	for i, e := range response.Exports {
+		exports[i] = export.ConvertPBExport(e)
+	}
+
+	return &export.ExportsResponse{
+		Exports: exports,
+		Error:   getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDownloadRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/export.proto-domain download request to a gRPC Download request.
+func EncodeGRPCDownloadRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*export.DownloadRequest)
+	return &pb.DownloadRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCDownloadResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Download response to a messages/export.proto-domain download response.
+func DecodeGRPCDownloadResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DownloadResponse)
+	return &export.DownloadResponse{
+		Content:     response.Content,
+		ContentType: response.ContentType,
+		Error:       getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDownloadURLRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/export.proto-domain downloadurl request to a gRPC DownloadURL request.
+func EncodeGRPCDownloadURLRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*export.DownloadURLRequest)
+	return &pb.DownloadURLRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCDownloadURLResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC DownloadURL response to a messages/export.proto-domain downloadurl response.
+func DecodeGRPCDownloadURLResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DownloadURLResponse)
+	res := &export.DownloadURLResponse{
+		URL:   response.Url,
+		Error: getError(response.Error),
+	}
+	if response.ExpiresAt != 0 {
+		res.ExpiresAt = time.Unix(response.ExpiresAt, 0).UTC()
+	}
+	return res, nil
+}
@@ -0,0 +1,26 @@
+package export
+
+import (
+	"time"
+)
+
+// Export is an archive of the personal data of a user
+type Export struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Key is the name of the archive in the store
+	Key   string
+	Size  int64
+	State string
+	Error string
+	// CreatedAt is when the export was requested, FinishedAt when it succeeded or failed and
+	// ExpiresAt when it is removed together with its archive
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	ExpiresAt  time.Time
+}
+
+// TableName sets Export's database table name
+func (Export) TableName() string {
+	return "exports"
+}
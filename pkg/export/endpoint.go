@@ -0,0 +1,122 @@
+package export
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// ContentType is the content type of the archives
+const ContentType = "application/gzip"
+
+// Endpoints is a struct which collects all endpoints for the export service
+type Endpoints struct {
+	ExportUserDataEndpoint endpoint.Endpoint
+	ExportsEndpoint        endpoint.Endpoint
+	DownloadEndpoint       endpoint.Endpoint
+	DownloadURLEndpoint    endpoint.Endpoint
+}
+
+// ExportUserDataRequest is the request struct for the ExportUserDataEndpoint
+type ExportUserDataRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// ExportUserDataResponse is the response struct for the ExportUserDataEndpoint
+type ExportUserDataResponse struct {
+	Export Export
+	Error  error
+}
+
+// MakeExportUserDataEndpoint creates a gokit endpoint which invokes ExportUserData
+func MakeExportUserDataEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ExportUserDataRequest)
+		e, err := s.ExportUserData(req.RefID)
+		return ExportUserDataResponse{
+			Export: e,
+			Error:  err,
+		}, nil
+	}
+}
+
+// ExportsRequest is the request struct for the ExportsEndpoint
+type ExportsRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// ExportsResponse is the response struct for the ExportsEndpoint
+type ExportsResponse struct {
+	Exports []Export
+	Error   error
+}
+
+// MakeExportsEndpoint creates a gokit endpoint which invokes Exports
+func MakeExportsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ExportsRequest)
+		exports := []Export{}
+		err := s.Exports(req.RefID, &exports)
+		return ExportsResponse{
+			Exports: exports,
+			Error:   err,
+		}, nil
+	}
+}
+
+// DownloadRequest is the request struct for the DownloadEndpoint
+type DownloadRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint `validate:"required"`
+}
+
+// DownloadResponse is the response struct for the DownloadEndpoint
+type DownloadResponse struct {
+	Content     []byte
+	ContentType string
+	Error       error
+}
+
+// MakeDownloadEndpoint creates a gokit endpoint which invokes Download
+func MakeDownloadEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DownloadRequest)
+		content, err := s.Download(req.RefID, req.ID)
+		if err != nil {
+			return DownloadResponse{
+				Error: err,
+			}, nil
+		}
+		return DownloadResponse{
+			Content:     content,
+			ContentType: ContentType,
+		}, nil
+	}
+}
+
+// DownloadURLRequest is the request struct for the DownloadURLEndpoint
+type DownloadURLRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint `validate:"required"`
+}
+
+// DownloadURLResponse is the response struct for the DownloadURLEndpoint
+type DownloadURLResponse struct {
+	URL       string
+	ExpiresAt time.Time
+	Error     error
+}
+
+// MakeDownloadURLEndpoint creates a gokit endpoint which invokes DownloadURL
+func MakeDownloadURLEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DownloadURLRequest)
+		u, expires, err := s.DownloadURL(req.RefID, req.ID)
+		return DownloadURLResponse{
+			URL:       u,
+			ExpiresAt: expires,
+			Error:     err,
+		}, nil
+	}
+}
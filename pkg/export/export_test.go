@@ -0,0 +1,356 @@
+package export_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/download"
+	"github.com/kontainerooo/kontainer.ooo/pkg/export"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type job struct {
+	typ     string
+	payload string
+}
+
+type mockQueue struct {
+	jobs []job
+}
+
+func (q *mockQueue) Enqueue(typ string, payload interface{}) (uint, error) {
+	b, _ := json.Marshal(payload)
+	q.jobs = append(q.jobs, job{typ: typ, payload: string(b)})
+	return uint(len(q.jobs)), nil
+}
+
+type notification struct {
+	refID    uint
+	template string
+	data     mail.ExportData
+}
+
+type mockNotifier struct {
+	sent []notification
+}
+
+func (n *mockNotifier) Notify(refID uint, template string, data interface{}) (uint, error) {
+	n.sent = append(n.sent, notification{refID: refID, template: template, data: data.(mail.ExportData)})
+	return uint(len(n.sent)), nil
+}
+
+type failingPart struct{}
+
+func (failingPart) Name() string {
+	return "broken"
+}
+
+func (failingPart) Export(ctx context.Context, refID uint, w *export.Writer) error {
+	return errors.New("backend unavailable")
+}
+
+// files returns the content of the files of a gzipped tar archive by their names
+func files(archive []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	Ω(err).ShouldNot(HaveOccurred())
+
+	fs := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		Ω(err).ShouldNot(HaveOccurred())
+
+		b, err := ioutil.ReadAll(tr)
+		Ω(err).ShouldNot(HaveOccurred())
+		fs[hdr.Name] = string(b)
+	}
+	return fs
+}
+
+var _ = Describe("Export", func() {
+	account := export.JSON("account", func(ctx context.Context, refID uint) (interface{}, error) {
+		return map[string]interface{}{"id": refID, "email": "user@kontainer.ooo"}, nil
+	})
+
+	Describe("Archive", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "kroo-export")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("Should write every part into its directory next to the manifest", func() {
+			os.MkdirAll(filepath.Join(dir, "1", "web"), 0755)
+			ioutil.WriteFile(filepath.Join(dir, "1", "web", "session.jsonl"), []byte("{}"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "2.jsonl"), []byte("other"), 0644)
+
+			buf := &bytes.Buffer{}
+			m, err := export.Archive(context.Background(), 1, []export.Part{account, export.Directory("sessions", dir)}, buf)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.User).To(Equal(uint(1)))
+			Expect(m.Parts).To(Equal([]string{"account", "sessions"}))
+
+			fs := files(buf.Bytes())
+			Expect(fs).To(HaveLen(3))
+			Expect(fs["account/account.json"]).To(ContainSubstring(`"email": "user@kontainer.ooo"`))
+			Expect(fs["sessions/web/session.jsonl"]).To(Equal("{}"))
+
+			manifest := export.Manifest{}
+			Ω(json.Unmarshal([]byte(fs["manifest.json"]), &manifest)).Should(Succeed())
+			Expect(manifest.Parts).To(Equal(m.Parts))
+		})
+
+		It("Should export no files of users without a directory", func() {
+			buf := &bytes.Buffer{}
+			_, err := export.Archive(context.Background(), 3, []export.Part{export.Directory("sessions", dir)}, buf)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(files(buf.Bytes())).To(HaveLen(1))
+		})
+
+		It("Should fail with the part that failed", func() {
+			_, err := export.Archive(context.Background(), 1, []export.Part{account, failingPart{}}, ioutil.Discard)
+			Expect(err).To(MatchError("broken: backend unavailable"))
+		})
+
+		It("Should refuse files outside of the directory of a part", func() {
+			escape := export.JSON("../account", func(ctx context.Context, refID uint) (interface{}, error) {
+				return nil, nil
+			})
+			_, err := export.Archive(context.Background(), 1, []export.Part{escape}, ioutil.Discard)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Service", func() {
+		var (
+			refID     = uint(1)
+			dir       string
+			queue     *mockQueue
+			mails     *mockNotifier
+			store     storage.Store
+			artifacts storage.Store
+			parts     []export.Part
+			s         export.Service
+			create    func() export.Service
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "kroo-export")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			queue = &mockQueue{}
+			mails = &mockNotifier{}
+			artifacts = storage.NewLocalStore(filepath.Join(dir, "store"))
+			store = storage.Prefix(artifacts, storage.Exports)
+			parts = []export.Part{account}
+			create = func() export.Service {
+				s, err := export.NewService(testutils.NewMockDB(), parts, queue, store, mails, export.Options{Retention: time.Hour}, log.NewNopLogger())
+				Ω(err).ShouldNot(HaveOccurred())
+				return s
+			}
+			s = create()
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		Describe("Create Service", func() {
+			It("Should return db error", func() {
+				db := testutils.NewMockDB()
+				db.SetError(1)
+				_, err := export.NewService(db, parts, queue, store, nil, export.Options{}, log.NewNopLogger())
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+
+		Describe("ExportUserData", func() {
+			It("Should enqueue the export", func() {
+				e, err := s.ExportUserData(refID)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(e.ID).ToNot(BeZero())
+				Expect(e.State).To(Equal(export.Running))
+				Expect(queue.jobs).To(HaveLen(1))
+				Expect(queue.jobs[0].typ).To(Equal(export.BuildJob))
+			})
+
+			It("Should allow one running export per user", func() {
+				_, err := s.ExportUserData(refID)
+				Ω(err).ShouldNot(HaveOccurred())
+				_, err = s.ExportUserData(refID)
+				Expect(err).To(Equal(export.ErrExportRunning))
+
+				_, err = s.ExportUserData(2)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+		})
+
+		Describe("Build", func() {
+			It("Should store the archive and notify the user", func() {
+				e, _ := s.ExportUserData(refID)
+
+				built, err := s.Build(context.Background(), e.ID)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(built.State).To(Equal(export.Succeeded))
+				Expect(built.Size).ToNot(BeZero())
+				Expect(built.ExpiresAt).To(Equal(built.FinishedAt.Add(time.Hour)))
+
+				Expect(mails.sent).To(HaveLen(1))
+				Expect(mails.sent[0].refID).To(Equal(refID))
+				Expect(mails.sent[0].template).To(Equal(mail.ExportReady))
+				Expect(mails.sent[0].data.ID).To(Equal(e.ID))
+
+				archive, err := s.Download(refID, e.ID)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(int64(len(archive))).To(Equal(built.Size))
+				Expect(files(archive)).To(HaveKey("account/account.json"))
+
+				es := []export.Export{}
+				Ω(s.Exports(refID, &es)).Should(Succeed())
+				Expect(es).To(HaveLen(1))
+				Expect(es[0].State).To(Equal(export.Succeeded))
+			})
+
+			It("Should mark failed exports and notify the user", func() {
+				parts = []export.Part{account, failingPart{}}
+				s = create()
+				e, _ := s.ExportUserData(refID)
+
+				built, err := s.Build(context.Background(), e.ID)
+				Ω(err).Should(HaveOccurred())
+				Expect(built.State).To(Equal(export.Failed))
+				Expect(built.Error).To(Equal("broken: backend unavailable"))
+				Expect(mails.sent).To(HaveLen(1))
+				Expect(mails.sent[0].template).To(Equal(mail.ExportFailed))
+
+				_, err = s.Download(refID, e.ID)
+				Expect(err).To(Equal(export.ErrNotReady))
+
+				_, err = s.ExportUserData(refID)
+				Ω(err).ShouldNot(HaveOccurred())
+			})
+
+			It("Should leave exports running if the queue stopped", func() {
+				e, _ := s.ExportUserData(refID)
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				_, err := s.Build(ctx, e.ID)
+				Ω(err).Should(HaveOccurred())
+				Expect(mails.sent).To(BeEmpty())
+
+				es := []export.Export{}
+				s.Exports(refID, &es)
+				Expect(es[0].State).To(Equal(export.Running))
+			})
+
+			It("Should skip exports which were built already", func() {
+				e, _ := s.ExportUserData(refID)
+				s.Build(context.Background(), e.ID)
+				s.Build(context.Background(), e.ID)
+				Expect(mails.sent).To(HaveLen(1))
+			})
+		})
+
+		Describe("Download", func() {
+			It("Should only return the exports of the user", func() {
+				e, _ := s.ExportUserData(refID)
+				s.Build(context.Background(), e.ID)
+
+				_, err := s.Download(2, e.ID)
+				Expect(err).To(Equal(export.ErrExportNotExist))
+			})
+
+			It("Should not return running exports", func() {
+				e, _ := s.ExportUserData(refID)
+				_, err := s.Download(refID, e.ID)
+				Expect(err).To(Equal(export.ErrNotReady))
+			})
+		})
+
+		Describe("DownloadURL", func() {
+			It("Should fail without download URLs", func() {
+				e, _ := s.ExportUserData(refID)
+				s.Build(context.Background(), e.ID)
+
+				_, _, err := s.DownloadURL(refID, e.ID)
+				Expect(err).To(Equal(export.ErrNoDownloadURLs))
+			})
+
+			It("Should return a signed URL of the archive", func() {
+				signer := download.NewSigner([]byte("secret"), "https://kroo.example.com", time.Hour)
+				s, err := export.NewService(testutils.NewMockDB(), parts, queue, store, mails, export.Options{Downloads: download.For(signer, download.Exports)}, log.NewNopLogger())
+				Ω(err).ShouldNot(HaveOccurred())
+
+				e, _ := s.ExportUserData(refID)
+				_, _, err = s.DownloadURL(refID, e.ID)
+				Expect(err).To(Equal(export.ErrNotReady))
+
+				s.Build(context.Background(), e.ID)
+				_, _, err = s.DownloadURL(2, e.ID)
+				Expect(err).To(Equal(export.ErrExportNotExist))
+
+				u, expires, err := s.DownloadURL(refID, e.ID)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(expires).To(BeTemporally(">", time.Now()))
+
+				w := httptest.NewRecorder()
+				download.Handler(artifacts, signer, log.NewNopLogger()).ServeHTTP(w, httptest.NewRequest("GET", strings.TrimPrefix(u, "https://kroo.example.com"), nil))
+				Expect(w.Code).To(Equal(http.StatusOK))
+
+				archive, _ := s.Download(refID, e.ID)
+				Expect(w.Body.Bytes()).To(Equal(archive))
+			})
+		})
+
+		Describe("Expire", func() {
+			It("Should remove expired exports and their archives", func() {
+				e, _ := s.ExportUserData(refID)
+				built, _ := s.Build(context.Background(), e.ID)
+				running, _ := s.ExportUserData(refID)
+
+				Ω(s.Expire(time.Now())).Should(Succeed())
+				es := []export.Export{}
+				s.Exports(refID, &es)
+				Expect(es).To(HaveLen(2))
+
+				Ω(s.Expire(built.ExpiresAt)).Should(Succeed())
+				es = []export.Export{}
+				s.Exports(refID, &es)
+				Expect(es).To(HaveLen(1))
+				Expect(es[0].ID).To(Equal(running.ID))
+
+				_, err := store.Get(built.Key)
+				Expect(err).To(Equal(storage.ErrObjectNotExist))
+			})
+		})
+	})
+})
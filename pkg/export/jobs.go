@@ -0,0 +1,50 @@
+package export
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+const (
+	// BuildJob is the type of the job writing the archive of an export
+	BuildJob = "export.build"
+	// ExpireJob is the type of the job removing the expired exports
+	ExpireJob = "export.expire"
+)
+
+// JobOptions write one export at a time, failed exports are not retried since the user is notified
+// of the failure and can request a new export
+var JobOptions = jobs.Options{
+	Workers:     1,
+	MaxAttempts: 1,
+}
+
+type buildPayload struct {
+	ExportID uint `json:"exportID"`
+}
+
+// BuildHandler returns the handler of BuildJob, exports which expired in the meantime are skipped
+func BuildHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		p := buildPayload{}
+		err := j.Decode(&p)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Build(ctx, p.ExportID)
+		if err == ErrExportNotExist {
+			return nil
+		}
+		return err
+	}
+}
+
+// ExpireHandler returns the handler of ExpireJob
+func ExpireHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		return s.Expire(time.Now())
+	}
+}
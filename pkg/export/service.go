@@ -0,0 +1,378 @@
+// Package export assembles the personal data of a user, like the account, the metadata of the instances,
+// the invoices and the audit trail, into an archive the user can download. Exports are written in the
+// background by the job queue, the user is notified once an export is done and its archive is removed
+// after the retention of the installation.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/download"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+// States of an export
+const (
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+var (
+	// ErrExportNotExist occurs if an export does not exist or expired
+	ErrExportNotExist = errors.New("export does not exist")
+
+	// ErrExportRunning occurs if a user requests an export while another one is running
+	ErrExportRunning = errors.New("an export is running already")
+
+	// ErrNotReady occurs if an export which is running or failed is downloaded
+	ErrNotReady = errors.New("only succeeded exports can be downloaded")
+
+	// ErrNoDownloadURLs occurs if a download URL is requested but the installation does not hand them out
+	ErrNoDownloadURLs = errors.New("download URLs are disabled")
+)
+
+// Service ExportService
+type Service interface {
+	// ExportUserData starts an export of the personal data of a user in the background and returns it,
+	// a user has at most one running export
+	ExportUserData(refID uint) (Export, error)
+
+	// Exports returns the exports of a user, the latest first
+	Exports(refID uint, e *[]Export) error
+
+	// Download returns the archive of a succeeded export
+	Download(refID uint, id uint) ([]byte, error)
+
+	// DownloadURL returns the signed URL the archive of a succeeded export can be downloaded from
+	// and when it expires
+	DownloadURL(refID uint, id uint) (string, time.Time, error)
+
+	// Build writes the archive of a running export to the store and notifies the user once it succeeded
+	// or failed. The export is returned even if it failed.
+	Build(ctx context.Context, id uint) (Export, error)
+
+	// Expire removes the exports which expired at now together with their archives
+	Expire(now time.Time) error
+}
+
+// Queue writes the exports in the background, it is the job queue of the daemon
+type Queue interface {
+	Enqueue(typ string, payload interface{}) (uint, error)
+}
+
+// Notifier sends the email telling a user that an export is done, it is satisfied by the mail service
+type Notifier interface {
+	Notify(refID uint, template string, data interface{}) (uint, error)
+}
+
+// Options configure the exports
+type Options struct {
+	// Retention is how long the archive of an export can be downloaded
+	Retention time.Duration
+	// Downloads hands out the download URLs of the archives, there are none if it is nil
+	Downloads download.URLs
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	parts   []Part
+	queue   Queue
+	store   storage.Store
+	mails   Notifier
+	options Options
+	logger  log.Logger
+	mtx     *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Export{})
+}
+
+// exports returns the exports of the user refID, the latest first
+func (s *service) exports(refID uint) ([]Export, error) {
+	es := []Export{}
+	err := s.db.FindOrdered(&es, "id DESC", 0, "ref_id = ?", refID)
+	if err != nil {
+		return nil, err
+	}
+	return es, nil
+}
+
+// export returns the export id of the user refID, or of any user if refID is 0
+func (s *service) export(refID uint, id uint) (Export, error) {
+	e := Export{}
+	var err error
+	if refID == 0 {
+		err = s.db.First(&e, "id = ?", id)
+	} else {
+		err = s.db.First(&e, "id = ? AND ref_id = ?", id, refID)
+	}
+	if s.db.IsNotFound(err) {
+		return Export{}, ErrExportNotExist
+	}
+	if err != nil {
+		return Export{}, err
+	}
+	return e, nil
+}
+
+func (s *service) ExportUserData(refID uint) (Export, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err := s.db.First(&Export{}, "ref_id = ? AND state = ?", refID, Running)
+	if err == nil {
+		return Export{}, ErrExportRunning
+	}
+	if !s.db.IsNotFound(err) {
+		return Export{}, err
+	}
+
+	e := Export{
+		RefID:     refID,
+		State:     Running,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = s.db.Create(&e)
+	if err != nil {
+		return Export{}, err
+	}
+
+	_, err = s.queue.Enqueue(BuildJob, buildPayload{
+		ExportID: e.ID,
+	})
+	if err != nil {
+		s.db.Delete(&Export{ID: e.ID})
+		return Export{}, err
+	}
+	return e, nil
+}
+
+func (s *service) Exports(refID uint, e *[]Export) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	es, err := s.exports(refID)
+	if err != nil {
+		return err
+	}
+
+	*e = append(*e, es...)
+	return nil
+}
+
+func (s *service) Download(refID uint, id uint) ([]byte, error) {
+	s.mtx.Lock()
+	e, err := s.export(refID, id)
+	s.mtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if e.State != Succeeded {
+		return nil, ErrNotReady
+	}
+
+	r, err := s.store.Get(e.Key)
+	if err == storage.ErrObjectNotExist {
+		return nil, ErrExportNotExist
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+func (s *service) DownloadURL(refID uint, id uint) (string, time.Time, error) {
+	if s.options.Downloads == nil {
+		return "", time.Time{}, ErrNoDownloadURLs
+	}
+
+	s.mtx.Lock()
+	e, err := s.export(refID, id)
+	s.mtx.Unlock()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if e.State != Succeeded {
+		return "", time.Time{}, ErrNotReady
+	}
+
+	u, expires := s.options.Downloads.URL(e.Key, fmt.Sprintf("export-%d.tar.gz", e.ID))
+	return u, expires, nil
+}
+
+// put writes the archive of e to the store and returns its size
+func (s *service) put(ctx context.Context, e Export) (int64, error) {
+	tmp, err := ioutil.TempFile("", "kroo-export")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = Archive(ctx, e.RefID, s.parts, tmp)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+
+	return size, s.store.Put(e.Key, tmp, size)
+}
+
+// notify tells the user of e that it is done, the export is kept even if the email can't be sent
+func (s *service) notify(e Export) {
+	if s.mails == nil {
+		return
+	}
+
+	template := mail.ExportReady
+	if e.State == Failed {
+		template = mail.ExportFailed
+	}
+
+	_, err := s.mails.Notify(e.RefID, template, mail.ExportData{
+		ID:      e.ID,
+		Error:   e.Error,
+		Expires: e.ExpiresAt,
+	})
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "the user could not be notified of the export", "export", e.ID, "user", e.RefID, "err", err)
+	}
+}
+
+// Build does not lock the service while the archive is written
+func (s *service) Build(ctx context.Context, id uint) (Export, error) {
+	s.mtx.Lock()
+	e, err := s.export(0, id)
+	s.mtx.Unlock()
+	if err != nil {
+		return Export{}, err
+	}
+	if e.State != Running {
+		return e, nil
+	}
+
+	e.Key = fmt.Sprintf("%d/%d-%s.tar.gz", e.RefID, e.ID, e.CreatedAt.Format("20060102T150405"))
+	size, failed := s.put(ctx, e)
+	if failed != nil && ctx.Err() != nil {
+		// the queue stopped, the export is written again once it is started again
+		return e, failed
+	}
+
+	now := time.Now().UTC()
+	changes := &Export{
+		Key:        e.Key,
+		State:      Succeeded,
+		Size:       size,
+		FinishedAt: now,
+		ExpiresAt:  now.Add(s.options.Retention),
+	}
+	if failed != nil {
+		changes.State = Failed
+		changes.Error = failed.Error()
+	}
+	e.State, e.Size, e.Error, e.FinishedAt, e.ExpiresAt = changes.State, changes.Size, changes.Error, changes.FinishedAt, changes.ExpiresAt
+
+	s.mtx.Lock()
+	err = abstraction.UpdateByID(s.db, &Export{}, e.ID, changes)
+	s.mtx.Unlock()
+	if err != nil {
+		return e, err
+	}
+
+	s.notify(e)
+	return e, failed
+}
+
+func (s *service) Expire(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	es := []Export{}
+	err := s.db.Find(&es, "state <> ? AND expires_at <= ?", Running, now)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range es {
+		if e.State == Succeeded {
+			err = s.store.Delete(e.Key)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.db.Delete(&Export{ID: e.ID})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewService creates an ExportService writing the parts into archives kept in store, the retention defaults
+// to seven days and mails may be nil if the daemon sends no emails
+func NewService(db dbAdapter, parts []Part, q Queue, store storage.Store, mails Notifier, o Options, logger log.Logger) (Service, error) {
+	if o.Retention == 0 {
+		o.Retention = 7 * 24 * time.Hour
+	}
+
+	s := &service{
+		db:      db,
+		parts:   parts,
+		queue:   q,
+		store:   store,
+		mails:   mails,
+		options: o,
+		logger:  logger,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
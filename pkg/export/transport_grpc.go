@@ -0,0 +1,226 @@
+package export
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/export/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC ExportServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.ExportServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		exportUserData: grpctransport.NewServer(
+			endpoints.ExportUserDataEndpoint,
+			DecodeGRPCExportUserDataRequest,
+			EncodeGRPCExportUserDataResponse,
+			options...,
+		),
+
+		exports: grpctransport.NewServer(
+			endpoints.ExportsEndpoint,
+			DecodeGRPCExportsRequest,
+			EncodeGRPCExportsResponse,
+			options...,
+		),
+
+		download: grpctransport.NewServer(
+			endpoints.DownloadEndpoint,
+			DecodeGRPCDownloadRequest,
+			EncodeGRPCDownloadResponse,
+			options...,
+		),
+
+		downloadURL: grpctransport.NewServer(
+			endpoints.DownloadURLEndpoint,
+			DecodeGRPCDownloadURLRequest,
+			EncodeGRPCDownloadURLResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	exportUserData grpctransport.Handler
+	exports        grpctransport.Handler
+	download       grpctransport.Handler
+	downloadURL    grpctransport.Handler
+}
+
+func (s *grpcServer) ExportUserData(ctx oldcontext.Context, req *pb.ExportUserDataRequest) (*pb.ExportUserDataResponse, error) {
+	_, res, err := s.exportUserData.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ExportUserDataResponse), nil
+}
+
+func (s *grpcServer) Exports(ctx oldcontext.Context, req *pb.ExportsRequest) (*pb.ExportsResponse, error) {
+	_, res, err := s.exports.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ExportsResponse), nil
+}
+
+func (s *grpcServer) Download(ctx oldcontext.Context, req *pb.DownloadRequest) (*pb.DownloadResponse, error) {
+	_, res, err := s.download.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DownloadResponse), nil
+}
+
+func (s *grpcServer) DownloadURL(ctx oldcontext.Context, req *pb.DownloadURLRequest) (*pb.DownloadURLResponse, error) {
+	_, res, err := s.downloadURL.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DownloadURLResponse), nil
+}
+
+// ConvertExport converts an Export to its protobuf representation
+func ConvertExport(e Export) *pb.Export {
+	ex := &pb.Export{
+		ID:        uint32(e.ID),
+		Size:      e.Size,
+		State:     e.State,
+		Error:     e.Error,
+		CreatedAt: e.CreatedAt.Unix(),
+	}
+	if !e.FinishedAt.IsZero() {
+		ex.FinishedAt = e.FinishedAt.Unix()
+	}
+	if !e.ExpiresAt.IsZero() {
+		ex.ExpiresAt = e.ExpiresAt.Unix()
+	}
+	return ex
+}
+
+// ConvertPBExport converts a protobuf Export to an Export
+func ConvertPBExport(e *pb.Export) Export {
+	if e == nil {
+		return Export{}
+	}
+
+	ex := Export{
+		ID:        uint(e.ID),
+		Size:      e.Size,
+		State:     e.State,
+		Error:     e.Error,
+		CreatedAt: time.Unix(e.CreatedAt, 0).UTC(),
+	}
+	if e.FinishedAt != 0 {
+		ex.FinishedAt = time.Unix(e.FinishedAt, 0).UTC()
+	}
+	if e.ExpiresAt != 0 {
+		ex.ExpiresAt = time.Unix(e.ExpiresAt, 0).UTC()
+	}
+	return ex
+}
+
+// DecodeGRPCExportUserDataRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ExportUserData request to a messages/export.proto-domain exportuserdata request.
+func DecodeGRPCExportUserDataRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ExportUserDataRequest)
+	return ExportUserDataRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCExportUserDataResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/export.proto-domain exportuserdata response to a gRPC ExportUserData response.
+func EncodeGRPCExportUserDataResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ExportUserDataResponse)
+	if res.Error != nil {
+		return &pb.ExportUserDataResponse{
+			Error: res.Error.Error(),
+		}, nil
+	}
+	return &pb.ExportUserDataResponse{
+		Export: ConvertExport(res.Export),
+	}, nil
+}
+
+// DecodeGRPCExportsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Exports request to a messages/export.proto-domain exports request.
+func DecodeGRPCExportsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ExportsRequest)
+	return ExportsRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCExportsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/export.proto-domain exports response to a gRPC Exports response.
+func EncodeGRPCExportsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ExportsResponse)
+	exports := make([]*pb.Export, len(res.Exports))
+	for i, e := range res.Exports {
+		exports[i] = ConvertExport(e)
+	}
+
+	gRPCRes := &pb.ExportsResponse{
+		Exports: exports,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDownloadRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Download request to a messages/export.proto-domain download request.
+func DecodeGRPCDownloadRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DownloadRequest)
+	return DownloadRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCDownloadResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/export.proto-domain download response to a gRPC Download response.
+func EncodeGRPCDownloadResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DownloadResponse)
+	gRPCRes := &pb.DownloadResponse{
+		Content:     res.Content,
+		ContentType: res.ContentType,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDownloadURLRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC DownloadURL request to a messages/export.proto-domain downloadurl request.
+func DecodeGRPCDownloadURLRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DownloadURLRequest)
+	return DownloadURLRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCDownloadURLResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/export.proto-domain downloadurl response to a gRPC DownloadURL response.
+func EncodeGRPCDownloadURLResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DownloadURLResponse)
+	if res.Error != nil {
+		return &pb.DownloadURLResponse{
+			Error: res.Error.Error(),
+		}, nil
+	}
+	return &pb.DownloadURLResponse{
+		Url:       res.URL,
+		ExpiresAt: res.ExpiresAt.Unix(),
+	}, nil
+}
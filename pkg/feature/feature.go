@@ -0,0 +1,264 @@
+// Package feature turns features on for some users only, so risky changes can be rolled out
+// incrementally. Flags are stored in the database and can be toggled at runtime, every node
+// reloads them periodically.
+package feature
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrFlagNotExist is returned if a flag does not exist
+	ErrFlagNotExist = errors.New("feature flag does not exist")
+
+	// ErrInvalidPercentage is returned if a flag should be turned on for more than 100 percent of the users
+	ErrInvalidPercentage = errors.New("percentage has to be between 0 and 100")
+)
+
+// Flag decides which users a feature is turned on for, it is on for a user if any of its targets matches
+type Flag struct {
+	Name        string `gorm:"primary_key" validate:"required,name"`
+	Description string
+	// Enabled turns the feature on for every user
+	Enabled bool
+	// Percentage of the users the feature is turned on for, a user keeps the feature while the percentage grows
+	Percentage uint `validate:"max=100"`
+	// Users are the ids of the users the feature is turned on for
+	Users pq.Int64Array `sql:"type:integer[]"`
+	// Plans are the plans of the users the feature is turned on for
+	Plans pq.StringArray `sql:"type:text[]"`
+	// UpdatedAt is when the flag was last set, a refresh only loads the flags set since the previous one
+	UpdatedAt time.Time
+}
+
+// TableName sets Flag's database table name
+func (Flag) TableName() string {
+	return "feature_flags"
+}
+
+// bucket returns the number between 0 and 99 the percentage of a flag is compared with for the user id
+func (f Flag) bucket(id uint) uint {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s:%d", f.Name, id)
+	return uint(h.Sum32() % 100)
+}
+
+// PlanFunc returns the name of the plan of a user
+type PlanFunc func(id uint) (string, error)
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Pluck(interface{}, string, interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+// Flags stores the feature flags and answers whether a feature is turned on for a user from a copy in memory
+type Flags struct {
+	db     dbAdapter
+	planOf PlanFunc
+	logger log.Logger
+
+	mtx   sync.RWMutex
+	flags map[string]Flag
+	// refreshed is when the last refresh started
+	refreshed time.Time
+}
+
+// clockSkew is the difference between the clocks of the nodes a refresh tolerates, the flags set within it
+// before the previous refresh are loaded again
+const clockSkew = time.Minute
+
+// Refresh reloads the flags changed since the last refresh from the database, e.g. after they were changed
+// on another node, and drops the removed ones
+func (f *Flags) Refresh() error {
+	start := time.Now().UTC()
+
+	names := []string{}
+	err := f.db.Pluck(&Flag{}, "name", &names)
+	if err != nil {
+		return err
+	}
+
+	f.mtx.RLock()
+	since := f.refreshed
+	f.mtx.RUnlock()
+
+	changed := []Flag{}
+	if since.IsZero() {
+		err = f.db.Find(&changed)
+	} else {
+		err = f.db.Find(&changed, "updated_at >= ?", since.Add(-clockSkew))
+	}
+	if err != nil {
+		return err
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	flags := make(map[string]Flag)
+	for _, name := range names {
+		if flag, ok := f.flags[name]; ok {
+			flags[name] = flag
+		}
+	}
+	for _, flag := range changed {
+		flags[flag.Name] = flag
+	}
+
+	f.flags = flags
+	f.refreshed = start
+	return nil
+}
+
+// Flags returns every flag ordered by name
+func (f *Flags) Flags(out *[]Flag) error {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	for _, flag := range f.flags {
+		*out = append(*out, flag)
+	}
+	sort.Slice(*out, func(a, b int) bool {
+		return (*out)[a].Name < (*out)[b].Name
+	})
+	return nil
+}
+
+// Set stores a flag, a flag with the same name is replaced
+func (f *Flags) Set(flag *Flag) error {
+	if flag.Percentage > 100 {
+		return ErrInvalidPercentage
+	}
+
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	flag.UpdatedAt = time.Now().UTC()
+
+	// the flag may have been stored by another node since the last refresh
+	f.db.Begin()
+	err := f.db.Delete(&Flag{Name: flag.Name})
+	if err != nil && !f.db.IsNotFound(err) {
+		f.db.Rollback()
+		return err
+	}
+
+	err = f.db.Create(flag)
+	if err != nil {
+		f.db.Rollback()
+		return err
+	}
+	f.db.Commit()
+
+	f.flags[flag.Name] = *flag
+	return nil
+}
+
+// Remove deletes the flag name, the feature is turned off for every user
+func (f *Flags) Remove(name string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	if _, ok := f.flags[name]; !ok {
+		return ErrFlagNotExist
+	}
+
+	err := f.db.Delete(&Flag{Name: name})
+	if err != nil {
+		return err
+	}
+
+	delete(f.flags, name)
+	return nil
+}
+
+// Enabled reports whether the feature name is turned on for the user id, features without a flag are off
+func (f *Flags) Enabled(name string, id uint) bool {
+	f.mtx.RLock()
+	flag, ok := f.flags[name]
+	f.mtx.RUnlock()
+	if !ok {
+		return false
+	}
+
+	if flag.Enabled {
+		return true
+	}
+
+	for _, u := range flag.Users {
+		if uint(u) == id {
+			return true
+		}
+	}
+
+	if flag.Percentage > 0 && flag.bucket(id) < flag.Percentage {
+		return true
+	}
+
+	if len(flag.Plans) != 0 && f.planOf != nil {
+		plan, err := f.planOf(id)
+		if err != nil {
+			level.Error(f.logger).Log("flag", name, "user", id, "err", err)
+			return false
+		}
+		for _, p := range flag.Plans {
+			if p == plan {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Run reloads the flags every interval until stop is closed
+func (f *Flags) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			err := f.Refresh()
+			if err != nil {
+				level.Error(f.logger).Log("err", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewFlags returns Flags storing the flags in db, planOf may be nil if flags do not target plans
+func NewFlags(db dbAdapter, planOf PlanFunc, logger log.Logger) (*Flags, error) {
+	err := db.AutoMigrate(&Flag{})
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Flags{
+		db:     db,
+		planOf: planOf,
+		logger: logger,
+	}
+
+	err = f.Refresh()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,190 @@
+package feature_test
+
+import (
+	"errors"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/feature"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/lib/pq"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Feature", func() {
+	var (
+		db    *testutils.MockDB
+		flags *feature.Flags
+		plans map[uint]string
+	)
+
+	BeforeEach(func() {
+		db = testutils.NewMockDB()
+		plans = map[uint]string{}
+		var err error
+		flags, err = feature.NewFlags(db, func(id uint) (string, error) {
+			if id == 99 {
+				return "", errors.New("no plan")
+			}
+			return plans[id], nil
+		}, log.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("NewFlags", func() {
+		It("Should return db errors", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := feature.NewFlags(db, nil, log.NewNopLogger())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Set", func() {
+		It("Should store a flag", func() {
+			err := flags.Set(&feature.Flag{Name: "nftables", Enabled: true})
+			Expect(err).NotTo(HaveOccurred())
+
+			out := []feature.Flag{}
+			flags.Flags(&out)
+			Expect(out).To(HaveLen(1))
+			Expect(out[0].Enabled).To(BeTrue())
+		})
+
+		It("Should replace a flag with the same name", func() {
+			flags.Set(&feature.Flag{Name: "nftables", Enabled: true})
+			err := flags.Set(&feature.Flag{Name: "nftables", Percentage: 10})
+			Expect(err).NotTo(HaveOccurred())
+
+			out := []feature.Flag{}
+			flags.Flags(&out)
+			Expect(out).To(HaveLen(1))
+			Expect(out[0].Enabled).To(BeFalse())
+			Expect(out[0].Percentage).To(BeEquivalentTo(10))
+		})
+
+		It("Should reject percentages above 100", func() {
+			err := flags.Set(&feature.Flag{Name: "nftables", Percentage: 101})
+			Expect(err).To(Equal(feature.ErrInvalidPercentage))
+		})
+
+		It("Should return db errors", func() {
+			db.SetError(1)
+			err := flags.Set(&feature.Flag{Name: "nftables"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Remove", func() {
+		It("Should delete a flag", func() {
+			flags.Set(&feature.Flag{Name: "nftables", Enabled: true})
+			err := flags.Remove("nftables")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags.Enabled("nftables", 1)).To(BeFalse())
+
+			out := []feature.Flag{}
+			flags.Flags(&out)
+			Expect(out).To(BeEmpty())
+		})
+
+		It("Should return an error if the flag does not exist", func() {
+			err := flags.Remove("nftables")
+			Expect(err).To(Equal(feature.ErrFlagNotExist))
+		})
+	})
+
+	Describe("Flags", func() {
+		It("Should order the flags by name", func() {
+			flags.Set(&feature.Flag{Name: "scheduler"})
+			flags.Set(&feature.Flag{Name: "nftables"})
+
+			out := []feature.Flag{}
+			flags.Flags(&out)
+			Expect(out).To(HaveLen(2))
+			Expect(out[0].Name).To(Equal("nftables"))
+			Expect(out[1].Name).To(Equal("scheduler"))
+		})
+	})
+
+	Describe("Refresh", func() {
+		It("Should load flags stored by another node", func() {
+			other, err := feature.NewFlags(db, nil, log.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+			other.Set(&feature.Flag{Name: "nftables", Enabled: true})
+			Expect(flags.Enabled("nftables", 1)).To(BeFalse())
+
+			err = flags.Refresh()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(flags.Enabled("nftables", 1)).To(BeTrue())
+		})
+
+		It("Should drop flags removed by another node", func() {
+			other, err := feature.NewFlags(db, nil, log.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+			flags.Set(&feature.Flag{Name: "nftables", Enabled: true})
+			Expect(other.Refresh()).To(Succeed())
+			Expect(other.Remove("nftables")).To(Succeed())
+
+			Expect(flags.Refresh()).To(Succeed())
+			Expect(flags.Enabled("nftables", 1)).To(BeFalse())
+		})
+	})
+
+	Describe("Enabled", func() {
+		It("Should turn unknown features off", func() {
+			Expect(flags.Enabled("nftables", 1)).To(BeFalse())
+		})
+
+		It("Should turn an enabled feature on for everyone", func() {
+			flags.Set(&feature.Flag{Name: "nftables", Enabled: true})
+			Expect(flags.Enabled("nftables", 1)).To(BeTrue())
+			Expect(flags.Enabled("nftables", 2)).To(BeTrue())
+		})
+
+		It("Should turn a feature on for the targeted users", func() {
+			flags.Set(&feature.Flag{Name: "nftables", Users: pq.Int64Array{2}})
+			Expect(flags.Enabled("nftables", 1)).To(BeFalse())
+			Expect(flags.Enabled("nftables", 2)).To(BeTrue())
+		})
+
+		It("Should turn a feature on for the targeted plans", func() {
+			plans[1] = "0"
+			plans[2] = "2"
+			flags.Set(&feature.Flag{Name: "nftables", Plans: pq.StringArray{"2"}})
+			Expect(flags.Enabled("nftables", 1)).To(BeFalse())
+			Expect(flags.Enabled("nftables", 2)).To(BeTrue())
+		})
+
+		It("Should turn a feature off if the plan can not be found", func() {
+			flags.Set(&feature.Flag{Name: "nftables", Plans: pq.StringArray{""}})
+			Expect(flags.Enabled("nftables", 99)).To(BeFalse())
+		})
+
+		It("Should turn a feature on for a share of the users which keep it while the share grows", func() {
+			count := func() map[uint]bool {
+				on := map[uint]bool{}
+				for id := uint(1); id <= 1000; id++ {
+					if flags.Enabled("scheduler", id) {
+						on[id] = true
+					}
+				}
+				return on
+			}
+
+			flags.Set(&feature.Flag{Name: "scheduler", Percentage: 10})
+			ten := count()
+			Expect(len(ten)).To(BeNumerically("~", 100, 40))
+
+			flags.Set(&feature.Flag{Name: "scheduler", Percentage: 50})
+			fifty := count()
+			Expect(len(fifty)).To(BeNumerically("~", 500, 80))
+			for id := range ten {
+				Expect(fifty).To(HaveKey(id))
+			}
+
+			flags.Set(&feature.Flag{Name: "scheduler", Percentage: 100})
+			Expect(count()).To(HaveLen(1000))
+		})
+	})
+})
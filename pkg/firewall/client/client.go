@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
@@ -10,6 +11,7 @@ import (
 	"google.golang.org/grpc"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
 	"github.com/kontainerooo/kontainer.ooo/pkg/pb"
 )
 
@@ -71,12 +73,90 @@ func New(conn *grpc.ClientConn, logger log.Logger) *firewall.Endpoints {
 		).Endpoint()
 	}
 
+	var RuleCountersEndpoint endpoint.Endpoint
+	{
+		RuleCountersEndpoint = grpctransport.NewClient(
+			conn,
+			"firewall.FirewallService",
+			"RuleCounters",
+			EncodeGRPCRuleCountersRequest,
+			DecodeGRPCRuleCountersResponse,
+			pb.RuleCountersResponse{},
+		).Endpoint()
+	}
+
+	var ApplyPolicyEndpoint endpoint.Endpoint
+	{
+		ApplyPolicyEndpoint = grpctransport.NewClient(
+			conn,
+			"firewall.FirewallService",
+			"ApplyPolicy",
+			EncodeGRPCApplyPolicyRequest,
+			DecodeGRPCApplyPolicyResponse,
+			pb.ApplyPolicyResponse{},
+		).Endpoint()
+	}
+
+	var RemovePolicyEndpoint endpoint.Endpoint
+	{
+		RemovePolicyEndpoint = grpctransport.NewClient(
+			conn,
+			"firewall.FirewallService",
+			"RemovePolicy",
+			EncodeGRPCRemovePolicyRequest,
+			DecodeGRPCRemovePolicyResponse,
+			pb.RemovePolicyResponse{},
+		).Endpoint()
+	}
+
+	var DropsEndpoint endpoint.Endpoint
+	{
+		DropsEndpoint = grpctransport.NewClient(
+			conn,
+			"firewall.FirewallService",
+			"Drops",
+			EncodeGRPCDropsRequest,
+			DecodeGRPCDropsResponse,
+			pb.DropsResponse{},
+		).Endpoint()
+	}
+
+	var BanEndpoint endpoint.Endpoint
+	{
+		BanEndpoint = grpctransport.NewClient(
+			conn,
+			"firewall.FirewallService",
+			"Ban",
+			EncodeGRPCBanRequest,
+			DecodeGRPCBanResponse,
+			pb.BanResponse{},
+		).Endpoint()
+	}
+
+	var UnbanEndpoint endpoint.Endpoint
+	{
+		UnbanEndpoint = grpctransport.NewClient(
+			conn,
+			"firewall.FirewallService",
+			"Unban",
+			EncodeGRPCUnbanRequest,
+			DecodeGRPCUnbanResponse,
+			pb.UnbanResponse{},
+		).Endpoint()
+	}
+
 	return &firewall.Endpoints{
 		InitBridgeEndpoint:      InitBridgeEndpoint,
 		AllowConnectionEndpoint: AllowConnectionEndpoint,
 		BlockConnectionEndpoint: BlockConnectionEndpoint,
 		AllowPortEndpoint:       AllowPortEndpoint,
 		BlockPortEndpoint:       BlockPortEndpoint,
+		RuleCountersEndpoint:    RuleCountersEndpoint,
+		ApplyPolicyEndpoint:     ApplyPolicyEndpoint,
+		RemovePolicyEndpoint:    RemovePolicyEndpoint,
+		DropsEndpoint:           DropsEndpoint,
+		BanEndpoint:             BanEndpoint,
+		UnbanEndpoint:           UnbanEndpoint,
 	}
 }
 
@@ -193,3 +273,155 @@ func DecodeGRPCBlockPortResponse(_ context.Context, grpcResponse interface{}) (i
 		Error: getError(response.Error),
 	}, nil
 }
+
+// EncodeGRPCRuleCountersRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain rulecounters request to a gRPC RuleCounters request.
+func EncodeGRPCRuleCountersRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.RuleCountersRequest{}, nil
+}
+
+// DecodeGRPCRuleCountersResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RuleCounters response to a messages/firewall.proto-domain rulecounters response.
+func DecodeGRPCRuleCountersResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RuleCountersResponse)
+	counters := make([]iptables.RuleCounters, len(response.Counters))
+	for i, c := range response.Counters {
+		counters[i] = iptables.RuleCounters{
+			ID:       c.ID,
+			RuleType: int(c.RuleType),
+			Table:    c.Table,
+			Rule:     c.Rule,
+			Packets:  c.Packets,
+			Bytes:    c.Bytes,
+		}
+	}
+
+	return &firewall.RuleCountersResponse{
+		Counters: counters,
+		Error:    getError(response.Error),
+	}, nil
+}
+
+func convertPolicy(p firewall.Policy) *pb.Policy {
+	public := make([]*pb.PublicPort, len(p.Public))
+	for i, port := range p.Public {
+		public[i] = &pb.PublicPort{
+			Port:     uint32(port.Port),
+			HostPort: uint32(port.HostPort),
+			Protocol: port.Protocol,
+		}
+	}
+
+	return &pb.Policy{
+		Public: public,
+		Egress: p.Egress,
+	}
+}
+
+// EncodeGRPCApplyPolicyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain applypolicy request to a gRPC ApplyPolicy request.
+func EncodeGRPCApplyPolicyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*firewall.ApplyPolicyRequest)
+	return &pb.ApplyPolicyRequest{
+		IP:      string(req.IP),
+		Network: req.Network,
+		Policy:  convertPolicy(req.Policy),
+	}, nil
+}
+
+// DecodeGRPCApplyPolicyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ApplyPolicy response to a messages/firewall.proto-domain applypolicy response.
+func DecodeGRPCApplyPolicyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ApplyPolicyResponse)
+	return &firewall.ApplyPolicyResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemovePolicyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain removepolicy request to a gRPC RemovePolicy request.
+func EncodeGRPCRemovePolicyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*firewall.RemovePolicyRequest)
+	return &pb.RemovePolicyRequest{
+		IP:      string(req.IP),
+		Network: req.Network,
+		Policy:  convertPolicy(req.Policy),
+	}, nil
+}
+
+// DecodeGRPCRemovePolicyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemovePolicy response to a messages/firewall.proto-domain removepolicy response.
+func DecodeGRPCRemovePolicyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemovePolicyResponse)
+	return &firewall.RemovePolicyResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDropsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain drops request to a gRPC Drops request.
+func EncodeGRPCDropsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.DropsRequest{}, nil
+}
+
+// DecodeGRPCDropsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Drops response to a messages/firewall.proto-domain drops response.
+func DecodeGRPCDropsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DropsResponse)
+	drops := make([]firewall.DropStats, len(response.Drops))
+	for i, d := range response.Drops {
+		drops[i] = firewall.DropStats{
+			Address:  d.Address,
+			Peer:     d.Peer,
+			Outgoing: d.Outgoing,
+			Packets:  d.Packets,
+			Ports:    d.Ports,
+			Last:     time.Unix(d.Last, 0).UTC(),
+		}
+	}
+
+	return &firewall.DropsResponse{
+		Drops: drops,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCBanRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain ban request to a gRPC Ban request.
+func EncodeGRPCBanRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*firewall.BanRequest)
+	return &pb.BanRequest{
+		Src:  string(req.Src),
+		Dst:  string(req.Dst),
+		Port: uint32(req.Port),
+	}, nil
+}
+
+// DecodeGRPCBanResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Ban response to a messages/firewall.proto-domain ban response.
+func DecodeGRPCBanResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.BanResponse)
+	return &firewall.BanResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCUnbanRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain unban request to a gRPC Unban request.
+func EncodeGRPCUnbanRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*firewall.UnbanRequest)
+	return &pb.UnbanRequest{
+		Src:  string(req.Src),
+		Dst:  string(req.Dst),
+		Port: uint32(req.Port),
+	}, nil
+}
+
+// DecodeGRPCUnbanResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Unban response to a messages/firewall.proto-domain unban response.
+func DecodeGRPCUnbanResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.UnbanResponse)
+	return &firewall.UnbanResponse{
+		Error: getError(response.Error),
+	}, nil
+}
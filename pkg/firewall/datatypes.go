@@ -1,8 +1,47 @@
 // Package firewall handles the firewall and forwarding configuration
 package firewall
 
+import "github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
+
 type networkInterface struct {
 	ID    uint
 	refid uint
 	name  string
 }
+
+const (
+	// EgressOpen lets an instance open connections to any address, it is the default egress profile
+	EgressOpen = "open"
+
+	// EgressWeb only lets an instance open HTTP and HTTPS connections besides DNS
+	EgressWeb = "web"
+
+	// EgressNone only lets an instance resolve names, it can still answer connections
+	EgressNone = "none"
+)
+
+// PublicPort is a port of an instance published on a port of the host
+type PublicPort struct {
+	Port uint16
+	// HostPort is the port of the host forwarded to Port, it defaults to Port
+	HostPort uint16
+	Protocol string
+}
+
+// Policy is the firewall policy of an instance, usually declared by its KMI
+type Policy struct {
+	// Public are the ports reachable from outside of the host
+	Public []PublicPort
+	// Egress is the name of the egress profile, EgressOpen if it is empty
+	Egress string
+}
+
+// egressProfiles are the ports an instance may connect to per egress profile, DNS is allowed by
+// the DNS chain for every profile and EgressOpen is not restricted at all
+var egressProfiles = map[string][]iptables.EgressAllowRule{
+	EgressWeb: {
+		{Protocol: "tcp", Port: 80},
+		{Protocol: "tcp", Port: 443},
+	},
+	EgressNone: {},
+}
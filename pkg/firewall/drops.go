@@ -0,0 +1,279 @@
+package firewall
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
+)
+
+// maxDropStats is the number of container and peer pairs a DropCollector keeps, the pair which dropped
+// packets the longest time ago is forgotten first
+const maxDropStats = 10000
+
+// pollInterval is how often a log file is checked for new lines once its end was reached
+const pollInterval = time.Second
+
+// containerNet is the network the addresses of the containers are part of
+var containerNet = &net.IPNet{IP: net.IPv4(172, 16, 0, 0), Mask: net.CIDRMask(12, 32)}
+
+// Drop is a packet dropped by a deny rule as it was logged
+type Drop struct {
+	In       string
+	Out      string
+	Src      string
+	Dst      string
+	Protocol string
+	DstPort  uint16
+}
+
+// ParseDrop parses the log line of a dropped packet, the line may start with a header like the one of syslog
+// or /dev/kmsg. ok is false for lines of other messages.
+func ParseDrop(line string) (d Drop, ok bool) {
+	i := strings.Index(line, iptables.DropLogPrefix)
+	if i == -1 {
+		return Drop{}, false
+	}
+
+	for _, f := range strings.Fields(line[i+len(iptables.DropLogPrefix):]) {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "IN":
+			d.In = kv[1]
+		case "OUT":
+			d.Out = kv[1]
+		case "SRC":
+			d.Src = kv[1]
+		case "DST":
+			d.Dst = kv[1]
+		case "PROTO":
+			d.Protocol = strings.ToLower(kv[1])
+		case "DPT":
+			port, err := strconv.ParseUint(kv[1], 10, 16)
+			if err == nil {
+				d.DstPort = uint16(port)
+			}
+		}
+	}
+	return d, d.Src != "" && d.Dst != ""
+}
+
+// DropStats are the packets dropped between a container and a peer
+type DropStats struct {
+	// Address is the address of the container, Peer the address it sent packets to or received them from
+	Address string
+	Peer    string
+	// Outgoing is whether the container sent the packets
+	Outgoing bool
+	Packets  uint64
+	// Ports are the destination ports of the packets and their protocol, e.g. 22/tcp
+	Ports []string
+	Last  time.Time
+}
+
+type dropKey struct {
+	address  string
+	peer     string
+	outgoing bool
+}
+
+// DropCollector aggregates the drops of the firewall log per container and peer
+type DropCollector struct {
+	stats map[dropKey]*DropStats
+	mtx   *sync.Mutex
+}
+
+// Add counts a dropped packet, the container is the destination of the packet if it is part of the container
+// network and the source otherwise
+func (c *DropCollector) Add(d Drop, now time.Time) {
+	key := dropKey{address: d.Src, peer: d.Dst, outgoing: true}
+	if ip := net.ParseIP(d.Dst); ip != nil && containerNet.Contains(ip) {
+		key = dropKey{address: d.Dst, peer: d.Src}
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	st, ok := c.stats[key]
+	if !ok {
+		if len(c.stats) >= maxDropStats {
+			c.forgetOldest()
+		}
+		st = &DropStats{
+			Address:  key.address,
+			Peer:     key.peer,
+			Outgoing: key.outgoing,
+		}
+		c.stats[key] = st
+	}
+
+	st.Packets++
+	st.Last = now.UTC()
+	if d.DstPort != 0 {
+		port := strconv.Itoa(int(d.DstPort)) + "/" + d.Protocol
+		for _, p := range st.Ports {
+			if p == port {
+				return
+			}
+		}
+		st.Ports = append(st.Ports, port)
+		sort.Strings(st.Ports)
+	}
+}
+
+func (c *DropCollector) forgetOldest() {
+	var oldest *dropKey
+	for k, st := range c.stats {
+		if oldest == nil || st.Last.Before(c.stats[*oldest].Last) {
+			k := k
+			oldest = &k
+		}
+	}
+	if oldest != nil {
+		delete(c.stats, *oldest)
+	}
+}
+
+// Read counts the drops of the lines of r until it ends
+func (c *DropCollector) Read(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if d, ok := ParseDrop(s.Text()); ok {
+			c.Add(d, time.Now())
+		}
+	}
+	return s.Err()
+}
+
+// Run counts the drops logged to path until stop is closed, e.g. /dev/kmsg for the kernel log or the file
+// ulogd writes the packets of the NFLOG group to. Only messages logged after Run was called are counted.
+func (c *DropCollector) Run(path string, stop <-chan struct{}) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	regular := info.Mode().IsRegular()
+
+	_, err = f.Seek(0, io.SeekEnd)
+	if err != nil && regular {
+		return err
+	}
+
+	lines := make(chan string)
+	errc := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(f)
+		partial := ""
+		for {
+			s, err := r.ReadString('\n')
+			partial += s
+			switch {
+			case err == io.EOF && regular:
+				// the file is followed like tail -f does
+				select {
+				case <-time.After(pollInterval):
+					continue
+				case <-stop:
+					return
+				}
+			case overwritten(err):
+				// messages of /dev/kmsg were overwritten before they were read
+				partial = ""
+				continue
+			case err != nil:
+				errc <- err
+				return
+			}
+
+			select {
+			case lines <- partial:
+			case <-stop:
+				return
+			}
+			partial = ""
+		}
+	}()
+
+	for {
+		select {
+		case line := <-lines:
+			if d, ok := ParseDrop(line); ok {
+				c.Add(d, time.Now())
+			}
+		case err := <-errc:
+			return err
+		case <-stop:
+			return nil
+		}
+	}
+}
+
+// overwritten returns whether err is the EPIPE /dev/kmsg returns if messages were overwritten before they were read
+func overwritten(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	}
+	return err == syscall.EPIPE
+}
+
+// Stats returns the drops per container and peer ordered by the address of the container and the peer
+func (c *DropCollector) Stats() []DropStats {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	stats := make([]DropStats, 0, len(c.stats))
+	for _, st := range c.stats {
+		s := *st
+		s.Ports = append([]string{}, st.Ports...)
+		stats = append(stats, s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Address != stats[j].Address {
+			return stats[i].Address < stats[j].Address
+		}
+		if stats[i].Peer != stats[j].Peer {
+			return stats[i].Peer < stats[j].Peer
+		}
+		return !stats[i].Outgoing && stats[j].Outgoing
+	})
+	return stats
+}
+
+// Dropped returns the number of packets dropped per container address
+func (c *DropCollector) Dropped() map[string]uint64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	dropped := make(map[string]uint64)
+	for k, st := range c.stats {
+		dropped[k.address] += st.Packets
+	}
+	return dropped
+}
+
+// NewDropCollector returns an empty DropCollector
+func NewDropCollector() *DropCollector {
+	return &DropCollector{
+		stats: make(map[dropKey]*DropStats),
+		mtx:   &sync.Mutex{},
+	}
+}
@@ -0,0 +1,69 @@
+package firewall_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drops", func() {
+	const (
+		inbound  = "6,1042,3400112,-;KROO-DROP:IN=eth0 OUT=br-0815 MAC=00 SRC=203.0.113.7 DST=172.18.0.2 LEN=60 TOS=0x00 PREC=0x00 TTL=51 ID=0 DF PROTO=TCP SPT=51234 DPT=22 WINDOW=29200 RES=0x00 SYN URGP=0"
+		outbound = "Oct 17 12:00:00 node kernel: [ 3400.112] KROO-DROP:IN=br-0815 OUT=eth0 SRC=172.18.0.2 DST=198.51.100.1 LEN=60 PROTO=UDP SPT=40000 DPT=53 LEN=40"
+	)
+
+	Describe("Parse", func() {
+		It("Should parse a logged drop", func() {
+			d, ok := firewall.ParseDrop(inbound)
+			Ω(ok).Should(BeTrue())
+			Ω(d).Should(Equal(firewall.Drop{
+				In:       "eth0",
+				Out:      "br-0815",
+				Src:      "203.0.113.7",
+				Dst:      "172.18.0.2",
+				Protocol: "tcp",
+				DstPort:  22,
+			}))
+		})
+
+		It("Should ignore other messages", func() {
+			_, ok := firewall.ParseDrop("6,1043,3400113,-;br-0815: port 1(veth0) entered forwarding state")
+			Ω(ok).Should(BeFalse())
+		})
+	})
+
+	Describe("Collect", func() {
+		It("Should aggregate the drops per instance and peer", func() {
+			c := firewall.NewDropCollector()
+			err := c.Read(strings.NewReader(strings.Join([]string{inbound, outbound, inbound, "unrelated"}, "\n")))
+			Ω(err).ShouldNot(HaveOccurred())
+
+			stats := c.Stats()
+			Ω(stats).Should(HaveLen(2))
+			Ω(stats[0].Address).Should(Equal("172.18.0.2"))
+			Ω(stats[0].Peer).Should(Equal("198.51.100.1"))
+			Ω(stats[0].Outgoing).Should(BeTrue())
+			Ω(stats[0].Ports).Should(Equal([]string{"53/udp"}))
+			Ω(stats[1].Peer).Should(Equal("203.0.113.7"))
+			Ω(stats[1].Outgoing).Should(BeFalse())
+			Ω(stats[1].Packets).Should(BeEquivalentTo(2))
+			Ω(stats[1].Ports).Should(Equal([]string{"22/tcp"}))
+
+			Ω(c.Dropped()).Should(Equal(map[string]uint64{"172.18.0.2": 3}))
+		})
+
+		It("Should remember the time of the last drop", func() {
+			c := firewall.NewDropCollector()
+			d, _ := firewall.ParseDrop(inbound)
+			now := time.Date(2017, 10, 17, 12, 0, 0, 0, time.UTC)
+
+			c.Add(d, now.Add(-time.Minute))
+			c.Add(d, now)
+
+			Ω(c.Stats()[0].Last).Should(Equal(now))
+		})
+	})
+})
@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
 )
 
 // Endpoints is a struct which collects all endpoints for the firewall service
@@ -14,12 +15,18 @@ type Endpoints struct {
 	BlockConnectionEndpoint endpoint.Endpoint
 	AllowPortEndpoint       endpoint.Endpoint
 	BlockPortEndpoint       endpoint.Endpoint
+	RuleCountersEndpoint    endpoint.Endpoint
+	ApplyPolicyEndpoint     endpoint.Endpoint
+	RemovePolicyEndpoint    endpoint.Endpoint
+	DropsEndpoint           endpoint.Endpoint
+	BanEndpoint             endpoint.Endpoint
+	UnbanEndpoint           endpoint.Endpoint
 }
 
 // InitBridgeRequest is the request struct for the InitBridgeEndpoint
 type InitBridgeRequest struct {
-	IP    abstraction.Inet
-	NetIf string
+	IP    abstraction.Inet `validate:"required,inet"`
+	NetIf string           `validate:"required"`
 }
 
 // InitBridgeResponse is the response struct for the InitBridgeEndpoint
@@ -40,9 +47,9 @@ func MakeInitBridgeEndpoint(s Service) endpoint.Endpoint {
 
 // AllowConnectionRequest is the request struct for the AllowConnectionEndpoint
 type AllowConnectionRequest struct {
-	SrcIP      abstraction.Inet
+	SrcIP      abstraction.Inet `validate:"required,inet"`
 	SrcNetwork string
-	DstIP      abstraction.Inet
+	DstIP      abstraction.Inet `validate:"required,inet"`
 	DstNetwork string
 }
 
@@ -64,9 +71,9 @@ func MakeAllowConnectionEndpoint(s Service) endpoint.Endpoint {
 
 // BlockConnectionRequest is the request struct for the BlockConnectionEndpoint
 type BlockConnectionRequest struct {
-	SrcIP      abstraction.Inet
+	SrcIP      abstraction.Inet `validate:"required,inet"`
 	SrcNetwork string
-	DstIP      abstraction.Inet
+	DstIP      abstraction.Inet `validate:"required,inet"`
 	DstNetwork string
 }
 
@@ -88,12 +95,12 @@ func MakeBlockConnectionEndpoint(s Service) endpoint.Endpoint {
 
 // AllowPortRequest is the request struct for the AllowPortEndpoint
 type AllowPortRequest struct {
-	SrcIP      abstraction.Inet
+	SrcIP      abstraction.Inet `validate:"required,inet"`
 	SrcNetwork string
-	DstIP      abstraction.Inet
+	DstIP      abstraction.Inet `validate:"required,inet"`
 	DstNetwork string
-	Port       uint16
-	Protocol   string
+	Port       uint16 `validate:"required,port"`
+	Protocol   string `validate:"required,protocol"`
 }
 
 // AllowPortResponse is the response struct for the AllowPortEndpoint
@@ -114,12 +121,12 @@ func MakeAllowPortEndpoint(s Service) endpoint.Endpoint {
 
 // BlockPortRequest is the request struct for the BlockPortEndpoint
 type BlockPortRequest struct {
-	SrcIP      abstraction.Inet
+	SrcIP      abstraction.Inet `validate:"required,inet"`
 	SrcNetwork string
-	DstIP      abstraction.Inet
+	DstIP      abstraction.Inet `validate:"required,inet"`
 	DstNetwork string
-	Port       uint16
-	Protocol   string
+	Port       uint16 `validate:"required,port"`
+	Protocol   string `validate:"required,protocol"`
 }
 
 // BlockPortResponse is the response struct for the BlockPortEndpoint
@@ -137,3 +144,135 @@ func MakeBlockPortEndpoint(s Service) endpoint.Endpoint {
 		}, nil
 	}
 }
+
+// RuleCountersRequest is the request struct for the RuleCountersEndpoint
+type RuleCountersRequest struct{}
+
+// RuleCountersResponse is the response struct for the RuleCountersEndpoint
+type RuleCountersResponse struct {
+	Counters []iptables.RuleCounters
+	Error    error
+}
+
+// MakeRuleCountersEndpoint creates a gokit endpoint which invokes RuleCounters
+func MakeRuleCountersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		counters, err := s.RuleCounters()
+		return RuleCountersResponse{
+			Counters: counters,
+			Error:    err,
+		}, nil
+	}
+}
+
+// ApplyPolicyRequest is the request struct for the ApplyPolicyEndpoint
+type ApplyPolicyRequest struct {
+	IP      abstraction.Inet `validate:"required,inet"`
+	Network string           `validate:"required"`
+	Policy  Policy
+}
+
+// ApplyPolicyResponse is the response struct for the ApplyPolicyEndpoint
+type ApplyPolicyResponse struct {
+	Error error
+}
+
+// MakeApplyPolicyEndpoint creates a gokit endpoint which invokes ApplyPolicy
+func MakeApplyPolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ApplyPolicyRequest)
+		err := s.ApplyPolicy(req.IP, req.Network, req.Policy)
+		return ApplyPolicyResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RemovePolicyRequest is the request struct for the RemovePolicyEndpoint
+type RemovePolicyRequest struct {
+	IP      abstraction.Inet `validate:"required,inet"`
+	Network string           `validate:"required"`
+	Policy  Policy
+}
+
+// RemovePolicyResponse is the response struct for the RemovePolicyEndpoint
+type RemovePolicyResponse struct {
+	Error error
+}
+
+// MakeRemovePolicyEndpoint creates a gokit endpoint which invokes RemovePolicy
+func MakeRemovePolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemovePolicyRequest)
+		err := s.RemovePolicy(req.IP, req.Network, req.Policy)
+		return RemovePolicyResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// DropsRequest is the request struct for the DropsEndpoint
+type DropsRequest struct{}
+
+// DropsResponse is the response struct for the DropsEndpoint
+type DropsResponse struct {
+	Drops []DropStats
+	Error error
+}
+
+// MakeDropsEndpoint creates a gokit endpoint which invokes Drops
+func MakeDropsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		drops, err := s.Drops()
+		return DropsResponse{
+			Drops: drops,
+			Error: err,
+		}, nil
+	}
+}
+
+// BanRequest is the request struct for the BanEndpoint
+type BanRequest struct {
+	Src  abstraction.Inet `validate:"required,inet"`
+	Dst  abstraction.Inet `validate:"inet"`
+	Port uint16
+}
+
+// BanResponse is the response struct for the BanEndpoint
+type BanResponse struct {
+	Error error
+}
+
+// MakeBanEndpoint creates a gokit endpoint which invokes Ban
+func MakeBanEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(BanRequest)
+		err := s.Ban(req.Src, req.Dst, req.Port)
+		return BanResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// UnbanRequest is the request struct for the UnbanEndpoint
+type UnbanRequest struct {
+	Src  abstraction.Inet `validate:"required,inet"`
+	Dst  abstraction.Inet `validate:"inet"`
+	Port uint16
+}
+
+// UnbanResponse is the response struct for the UnbanEndpoint
+type UnbanResponse struct {
+	Error error
+}
+
+// MakeUnbanEndpoint creates a gokit endpoint which invokes Unban
+func MakeUnbanEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UnbanRequest)
+		err := s.Unban(req.Src, req.Dst, req.Port)
+		return UnbanResponse{
+			Error: err,
+		}, nil
+	}
+}
@@ -0,0 +1,136 @@
+package firewall
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// Actions of the published FirewallEvents
+const (
+	ActionInitBridge      = "init_bridge"
+	ActionAllowConnection = "allow_connection"
+	ActionBlockConnection = "block_connection"
+	ActionAllowPort       = "allow_port"
+	ActionBlockPort       = "block_port"
+	ActionApplyPolicy     = "apply_policy"
+	ActionRemovePolicy    = "remove_policy"
+	ActionBan             = "ban"
+	ActionUnban           = "unban"
+)
+
+type eventService struct {
+	Service
+	bus    events.Bus
+	logger log.Logger
+}
+
+// publish publishes e if the rule was changed
+func (s *eventService) publish(err error, e events.FirewallEvent) error {
+	if err != nil {
+		return err
+	}
+
+	perr := s.bus.Publish(events.FirewallChanged, e)
+	if perr != nil {
+		level.Error(s.logger).Log("topic", events.FirewallChanged, "action", e.Action, "err", perr)
+	}
+	return nil
+}
+
+func (s *eventService) InitBridge(ip abstraction.Inet, netIf string) error {
+	return s.publish(s.Service.InitBridge(ip, netIf), events.FirewallEvent{
+		Action: ActionInitBridge,
+		SrcIP:  string(ip),
+		SrcNw:  netIf,
+	})
+}
+
+func (s *eventService) AllowConnection(srcIP abstraction.Inet, srcNw string, dstIP abstraction.Inet, dstNw string) error {
+	return s.publish(s.Service.AllowConnection(srcIP, srcNw, dstIP, dstNw), events.FirewallEvent{
+		Action: ActionAllowConnection,
+		SrcIP:  string(srcIP),
+		SrcNw:  srcNw,
+		DstIP:  string(dstIP),
+		DstNw:  dstNw,
+	})
+}
+
+func (s *eventService) BlockConnection(srcIP abstraction.Inet, srcNw string, dstIP abstraction.Inet, dstNw string) error {
+	return s.publish(s.Service.BlockConnection(srcIP, srcNw, dstIP, dstNw), events.FirewallEvent{
+		Action: ActionBlockConnection,
+		SrcIP:  string(srcIP),
+		SrcNw:  srcNw,
+		DstIP:  string(dstIP),
+		DstNw:  dstNw,
+	})
+}
+
+func (s *eventService) AllowPort(srcIP abstraction.Inet, srcNw string, dstIP abstraction.Inet, dstNw string, port uint16, protocol string) error {
+	return s.publish(s.Service.AllowPort(srcIP, srcNw, dstIP, dstNw, port, protocol), events.FirewallEvent{
+		Action:   ActionAllowPort,
+		SrcIP:    string(srcIP),
+		SrcNw:    srcNw,
+		DstIP:    string(dstIP),
+		DstNw:    dstNw,
+		Port:     port,
+		Protocol: protocol,
+	})
+}
+
+func (s *eventService) BlockPort(srcIP abstraction.Inet, srcNw string, dstIP abstraction.Inet, dstNw string, port uint16, protocol string) error {
+	return s.publish(s.Service.BlockPort(srcIP, srcNw, dstIP, dstNw, port, protocol), events.FirewallEvent{
+		Action:   ActionBlockPort,
+		SrcIP:    string(srcIP),
+		SrcNw:    srcNw,
+		DstIP:    string(dstIP),
+		DstNw:    dstNw,
+		Port:     port,
+		Protocol: protocol,
+	})
+}
+
+func (s *eventService) ApplyPolicy(ip abstraction.Inet, nw string, policy Policy) error {
+	return s.publish(s.Service.ApplyPolicy(ip, nw, policy), events.FirewallEvent{
+		Action: ActionApplyPolicy,
+		DstIP:  string(ip),
+		DstNw:  nw,
+	})
+}
+
+func (s *eventService) RemovePolicy(ip abstraction.Inet, nw string, policy Policy) error {
+	return s.publish(s.Service.RemovePolicy(ip, nw, policy), events.FirewallEvent{
+		Action: ActionRemovePolicy,
+		DstIP:  string(ip),
+		DstNw:  nw,
+	})
+}
+
+func (s *eventService) Ban(src abstraction.Inet, dst abstraction.Inet, port uint16) error {
+	return s.publish(s.Service.Ban(src, dst, port), events.FirewallEvent{
+		Action: ActionBan,
+		SrcIP:  string(src),
+		DstIP:  string(dst),
+		Port:   port,
+	})
+}
+
+func (s *eventService) Unban(src abstraction.Inet, dst abstraction.Inet, port uint16) error {
+	return s.publish(s.Service.Unban(src, dst, port), events.FirewallEvent{
+		Action: ActionUnban,
+		SrcIP:  string(src),
+		DstIP:  string(dst),
+		Port:   port,
+	})
+}
+
+// NewEventService returns a Service which publishes a FirewallChanged event once a rule was changed,
+// failing to publish is only logged
+func NewEventService(s Service, bus events.Bus, logger log.Logger) Service {
+	return &eventService{
+		Service: s,
+		bus:     bus,
+		logger:  logger,
+	}
+}
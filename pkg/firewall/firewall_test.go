@@ -13,7 +13,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should create a new service", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, err := firewall.NewService(mockIpt)
+			fws, err := firewall.NewService(mockIpt, firewall.Options{})
 
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(fws).ShouldNot(BeNil())
@@ -24,7 +24,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should set up rules for bridge initialisation", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip, _ := abstraction.NewInet("172.18.0.0/16")
 			err := fws.InitBridge(ip, "br-084d60eeada1")
@@ -37,7 +37,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should allow connection", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip1, _ := abstraction.NewInet("172.18.0.0/16")
 			ip2, _ := abstraction.NewInet("172.18.0.0/16")
@@ -52,7 +52,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should block a connection", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip1, _ := abstraction.NewInet("172.18.0.0/16")
 			ip2, _ := abstraction.NewInet("172.18.0.0/16")
@@ -69,7 +69,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should allow a port", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip1, _ := abstraction.NewInet("172.18.0.0/16")
 			ip2, _ := abstraction.NewInet("172.18.0.0/16")
@@ -82,7 +82,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should error on invalid protocol", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip1, _ := abstraction.NewInet("172.18.0.0/16")
 			ip2, _ := abstraction.NewInet("172.18.0.0/16")
@@ -95,7 +95,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should error on existing rule", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip1, _ := abstraction.NewInet("172.18.0.0/16")
 			ip2, _ := abstraction.NewInet("172.18.0.0/16")
@@ -111,7 +111,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should block a port", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip1, _ := abstraction.NewInet("172.18.0.0/16")
 			ip2, _ := abstraction.NewInet("172.18.0.0/16")
@@ -126,7 +126,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should error on invalid protocol", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip1, _ := abstraction.NewInet("172.18.0.0/16")
 			ip2, _ := abstraction.NewInet("172.18.0.0/16")
@@ -141,7 +141,7 @@ var _ = XDescribe("Firewall", func() {
 		It("Should error non-existing rule", func() {
 			mockIpt, _ := testutils.NewMockIPTService()
 
-			fws, _ := firewall.NewService(mockIpt)
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
 
 			ip1, _ := abstraction.NewInet("172.18.0.0/16")
 			ip2, _ := abstraction.NewInet("172.18.0.0/16")
@@ -151,4 +151,106 @@ var _ = XDescribe("Firewall", func() {
 			Ω(err).Should(HaveOccurred())
 		})
 	})
+
+	Describe("Policies", func() {
+		policy := firewall.Policy{
+			Public: []firewall.PublicPort{
+				{Port: 80, HostPort: 8080, Protocol: "tcp"},
+				{Port: 53, Protocol: "udp"},
+			},
+			Egress: firewall.EgressWeb,
+		}
+
+		It("Should apply and remove a policy", func() {
+			mockIpt, _ := testutils.NewMockIPTService()
+
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
+			before, _ := mockIpt.CountRules()
+
+			ip, _ := abstraction.NewInet("172.18.0.2")
+			err := fws.ApplyPolicy(ip, "br-0815", policy)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			count, _ := mockIpt.CountRules()
+			Ω(count - before).Should(BeEquivalentTo(7))
+
+			err = fws.RemovePolicy(ip, "br-0815", policy)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			count, _ = mockIpt.CountRules()
+			Ω(count).Should(Equal(before))
+		})
+
+		It("Should not create any rule of an invalid policy", func() {
+			mockIpt, _ := testutils.NewMockIPTService()
+
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
+			before, _ := mockIpt.CountRules()
+
+			ip, _ := abstraction.NewInet("172.18.0.2")
+			err := fws.ApplyPolicy(ip, "br-0815", firewall.Policy{
+				Public: policy.Public,
+				Egress: "intranet",
+			})
+			Ω(err).Should(HaveOccurred())
+
+			count, _ := mockIpt.CountRules()
+			Ω(count).Should(Equal(before))
+		})
+
+		It("Should roll back a policy conflicting with existing rules", func() {
+			mockIpt, _ := testutils.NewMockIPTService()
+
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
+
+			ip, _ := abstraction.NewInet("172.18.0.2")
+			fws.ApplyPolicy(ip, "br-0815", firewall.Policy{Egress: firewall.EgressNone})
+			before, _ := mockIpt.CountRules()
+
+			err := fws.ApplyPolicy(ip, "br-0815", firewall.Policy{
+				Public: policy.Public,
+				Egress: firewall.EgressNone,
+			})
+			Ω(err).Should(HaveOccurred())
+
+			count, _ := mockIpt.CountRules()
+			Ω(count).Should(Equal(before))
+		})
+	})
+
+	Describe("Drops", func() {
+		It("Should create the drop chain if drops are logged", func() {
+			mockIpt, _ := testutils.NewMockIPTService()
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
+			before, _ := mockIpt.CountRules()
+
+			mockIpt, _ = testutils.NewMockIPTService()
+			fws, err := firewall.NewService(mockIpt, firewall.Options{
+				LogDrops: firewall.LogDropsNFLog,
+				Drops:    firewall.NewDropCollector(),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			count, _ := mockIpt.CountRules()
+			Ω(count - before).Should(BeEquivalentTo(3))
+
+			drops, err := fws.Drops()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(drops).Should(BeEmpty())
+		})
+
+		It("Should error on an unknown drop log", func() {
+			mockIpt, _ := testutils.NewMockIPTService()
+			_, err := firewall.NewService(mockIpt, firewall.Options{LogDrops: "syslog"})
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should error if drops are not logged", func() {
+			mockIpt, _ := testutils.NewMockIPTService()
+			fws, _ := firewall.NewService(mockIpt, firewall.Options{})
+
+			_, err := fws.Drops()
+			Ω(err).Should(Equal(firewall.ErrDropsNotLogged))
+		})
+	})
 })
@@ -0,0 +1,192 @@
+package iptables
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RuleCounters are the packets and bytes matched by a persisted rule since it was loaded
+type RuleCounters struct {
+	// ID is the ID of the RuleEntry
+	ID       string
+	RuleType int
+	Table    string
+	// Rule is the rule as it was passed to iptables
+	Rule    string
+	Packets uint64
+	Bytes   uint64
+}
+
+// ruleKey identifies a rule independently of the way iptables prints it, iptables appends the prefix
+// length to addresses, adds the implicit protocol matches and orders the options on its own
+type ruleKey struct {
+	table string
+	chain string
+	spec  string
+}
+
+// parseRule returns the key of an appended rule, ok is false for other commands like the creation of chains
+func parseRule(table string, fields []string) (key ruleKey, ok bool) {
+	key.table = table
+	groups := []string{}
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		switch {
+		case f == "-t" && i+1 < len(fields):
+			key.table = fields[i+1]
+			i++
+			continue
+		case f == "-A" && i+1 < len(fields):
+			key.chain = fields[i+1]
+			ok = true
+			i++
+			continue
+		}
+
+		// an option is grouped with its negation and its arguments
+		group := []string{}
+		if f == "!" && i+1 < len(fields) {
+			group = append(group, f)
+			i++
+			f = fields[i]
+		}
+		group = append(group, f)
+		for i+1 < len(fields) && !strings.HasPrefix(fields[i+1], "-") && fields[i+1] != "!" {
+			i++
+			group = append(group, fields[i])
+		}
+
+		switch group[len(group)-1] {
+		case "tcp", "udp":
+			// the protocol matches iptables adds for --dport and --sport
+			if group[0] == "-m" {
+				continue
+			}
+		}
+
+		if len(group) == 2 && (group[0] == "-s" || group[0] == "-d") && !strings.Contains(group[1], "/") {
+			group[1] = hostPrefix(group[1])
+		}
+		if len(group) == 3 && (group[1] == "-s" || group[1] == "-d") && !strings.Contains(group[2], "/") {
+			group[2] = hostPrefix(group[2])
+		}
+		groups = append(groups, strings.Join(group, " "))
+	}
+
+	sort.Strings(groups)
+	key.spec = strings.Join(groups, " ")
+	return key, ok
+}
+
+func hostPrefix(ip string) string {
+	if strings.Contains(ip, ":") {
+		return ip + "/128"
+	}
+	return ip + "/32"
+}
+
+type counters struct {
+	packets uint64
+	bytes   uint64
+}
+
+// parseCounters reads the output of iptables -S -v, the counters of a rule are printed as -c packets bytes
+func parseCounters(table string, output string) (map[ruleKey]counters, error) {
+	res := make(map[ruleKey]counters)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "-A" {
+			continue
+		}
+
+		c := counters{}
+		rule := []string{}
+		for i := 0; i < len(fields); i++ {
+			if fields[i] != "-c" || i+2 >= len(fields) {
+				// iptables quotes strings like log prefixes
+				rule = append(rule, strings.Trim(fields[i], `"`))
+				continue
+			}
+
+			var err error
+			c.packets, err = strconv.ParseUint(fields[i+1], 10, 64)
+			if err == nil {
+				c.bytes, err = strconv.ParseUint(fields[i+2], 10, 64)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid counters in %q", line)
+			}
+			i += 2
+		}
+
+		key, _ := parseRule(table, rule)
+		// identical rules share their counters
+		sum := res[key]
+		sum.packets += c.packets
+		sum.bytes += c.bytes
+		res[key] = sum
+	}
+	return res, nil
+}
+
+func (s *service) GetRuleCounters() ([]RuleCounters, error) {
+	res := []RuleEntry{}
+	err := s.db.Find(&res)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []RuleCounters{}
+	keys := []ruleKey{}
+	tables := make(map[string]map[ruleKey]counters)
+	for _, v := range res {
+		_, cmdStr, err := s.CreateRuleEntryString(v.rule.RuleType, v.rule.Data)
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := parseRule("filter", strings.Fields(cmdStr))
+		if !ok {
+			continue
+		}
+
+		rules = append(rules, RuleCounters{
+			ID:       v.ID,
+			RuleType: v.rule.RuleType,
+			Table:    key.table,
+			Rule:     cmdStr,
+		})
+		keys = append(keys, key)
+		tables[key.table] = nil
+	}
+
+	for table := range tables {
+		out, err := ExecCommand(s.iptPath, "-t", table, "-S", "-v").Output()
+		if err != nil {
+			return nil, err
+		}
+
+		tables[table], err = parseCounters(table, string(out))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// rules which are persisted but not loaded are left out
+	loaded := []RuleCounters{}
+	for i, r := range rules {
+		c, ok := tables[keys[i].table][keys[i]]
+		if !ok {
+			continue
+		}
+		r.Packets, r.Bytes = c.packets, c.bytes
+		loaded = append(loaded, r)
+	}
+
+	sort.Slice(loaded, func(i, j int) bool {
+		return loaded[i].ID < loaded[j].ID
+	})
+	return loaded, nil
+}
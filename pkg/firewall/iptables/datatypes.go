@@ -27,6 +27,19 @@ const (
 	// IptNatChain is the name of the custom chain that is used within the nat table
 	IptNatChain = "KROO-NAT"
 
+	// IptEgressChain is the name of the chain restricting the outgoing traffic of containers
+	IptEgressChain = "KROO-EGRESS"
+
+	// IptDropChain is the name of the chain logging packets before dropping them, deny rules jump to it
+	// instead of dropping packets if drops are logged
+	IptDropChain = "KROO-DROP"
+
+	// IptBanChain is the name of the chain dropping the packets of banned sources
+	IptBanChain = "KROO-BAN"
+
+	// DropLogPrefix prefixes the log messages of dropped packets
+	DropLogPrefix = "KROO-DROP:"
+
 	// CreateChainRuleType specifies a CreateChainRule
 	CreateChainRuleType = iota
 
@@ -70,6 +83,24 @@ const (
 
 	// NatMaskRuleType specifies a rule for masking outgoing traffic
 	NatMaskRuleType = iota
+
+	// PublishPortRuleType specifies a rule forwarding a host port to a container
+	PublishPortRuleType = iota
+
+	// AcceptPublishedPortRuleType specifies a rule accepting the forwarded traffic of a published port
+	AcceptPublishedPortRuleType = iota
+
+	// EgressAllowRuleType specifies a rule exempting a port from the egress restriction of a container
+	EgressAllowRuleType = iota
+
+	// EgressDropRuleType specifies a rule dropping new outgoing connections of a container
+	EgressDropRuleType = iota
+
+	// LogDropRuleType specifies a rule logging the packets of the drop chain
+	LogDropRuleType = iota
+
+	// BanRuleType specifies a rule dropping the packets of a banned source
+	BanRuleType = iota
 )
 
 var (
@@ -77,7 +108,7 @@ var (
 
 	jumpToChainRuleStr = "-t {{.Table}} -A {{.From}} {{if .SrcNetwork}} -i {{.SrcNetwork}} {{end}} {{if .Match}} -m {{.Match}} {{end}} -j {{.To}}"
 
-	isolationRuleStr = fmt.Sprintf("-A %s ! -i {{.SrcNetwork}} -o {{.SrcNetwork}} -j DROP", IptIsolationChain)
+	isolationRuleStr = fmt.Sprintf("-A %s ! -i {{.SrcNetwork}} -o {{.SrcNetwork}} -j {{if .Target}}{{.Target}}{{else}}DROP{{end}}", IptIsolationChain)
 
 	outgoingOutRuleStr = fmt.Sprintf("-A %s -s {{.SrcIP}} ! -d 172.16.0.0/12 -i {{.SrcNetwork}} ! -o {{.SrcNetwork}} -j ACCEPT", IptOutboundChain)
 	outgoingInRuleStr  = fmt.Sprintf("-A %s ! -s 172.16.0.0/12 -d {{.SrcIP}} ! -i {{.SrcNetwork}} -o {{.SrcNetwork}} -j ACCEPT", IptOutboundChain)
@@ -97,6 +128,16 @@ var (
 	natOutStr = fmt.Sprintf("-t nat -A OUTPUT ! -d 127.0.0.0/8 -m addrtype --dst-type LOCAL -j %s", IptNatChain)
 
 	natMaskStr = "-t nat -A POSTROUTING -s {{.SrcIP}} ! -o {{.SrcNetwork}} -j MASQUERADE"
+
+	publishPortStr         = fmt.Sprintf("-t nat -A %s -p {{.Protocol}} --dport {{.HostPort}} -j DNAT --to-destination {{.DstIP}}:{{.DstPort}}", IptNatChain)
+	acceptPublishedPortStr = fmt.Sprintf("-A %s -d {{.DstIP}} ! -i {{.DstNetwork}} -o {{.DstNetwork}} -p {{.Protocol}} --dport {{.DstPort}} -j ACCEPT", IptLinkChain)
+
+	egressAllowStr = fmt.Sprintf("-A %s -s {{.SrcIP}} -p {{.Protocol}} --dport {{.Port}} -j RETURN", IptEgressChain)
+	egressDropStr  = fmt.Sprintf("-A %s -s {{.SrcIP}} ! -d 172.16.0.0/12 -m conntrack --ctstate NEW -j {{if .Target}}{{.Target}}{{else}}DROP{{end}}", IptEgressChain)
+
+	logDropStr = fmt.Sprintf("-A %s -m limit --limit {{.Limit}}/sec -j {{.Target}} {{if eq .Target \"NFLOG\"}}--nflog-group {{.Group}} --nflog-prefix{{else}}--log-prefix{{end}} %s", IptDropChain, DropLogPrefix)
+
+	banStr = fmt.Sprintf("-A %s -s {{.SrcIP}}{{if .DstIP}} -d {{.DstIP}}{{end}}{{if .Port}} -p tcp --dport {{.Port}}{{end}} -j DROP", IptBanChain)
 )
 
 var (
@@ -144,6 +185,24 @@ var (
 
 	// NatMaskRuleTmpl is the template for the nat outgoing mask rule
 	NatMaskRuleTmpl = template.Must(template.New("natMaskRule").Parse(natMaskStr))
+
+	// PublishPortRuleTmpl is the template for the rule forwarding a host port to a container
+	PublishPortRuleTmpl = template.Must(template.New("publishPortRule").Parse(publishPortStr))
+
+	// AcceptPublishedPortRuleTmpl is the template for the rule accepting the traffic of a published port
+	AcceptPublishedPortRuleTmpl = template.Must(template.New("acceptPublishedPortRule").Parse(acceptPublishedPortStr))
+
+	// EgressAllowRuleTmpl is the template for the rule exempting a port from the egress restriction
+	EgressAllowRuleTmpl = template.Must(template.New("egressAllowRule").Parse(egressAllowStr))
+
+	// EgressDropRuleTmpl is the template for the rule dropping new outgoing connections
+	EgressDropRuleTmpl = template.Must(template.New("egressDropRule").Parse(egressDropStr))
+
+	// LogDropRuleTmpl is the template for the rule logging dropped packets
+	LogDropRuleTmpl = template.Must(template.New("logDropRule").Parse(logDropStr))
+
+	// BanRuleTmpl is the template for the rule dropping the packets of a banned source
+	BanRuleTmpl = template.Must(template.New("banRule").Parse(banStr))
 )
 
 // RuleEntry represents a database rule entry
@@ -221,6 +280,7 @@ func (r *Rule) scanBytes(src []byte) error {
 	case IsolationRuleType:
 		r.Data = IsolationRule{
 			SrcNetwork: data.SrcNetwork,
+			Target:     data.Target,
 		}
 	case OutgoingOutRuleType:
 		srcIP, err := abstraction.NewInet(data.SrcIP)
@@ -355,6 +415,68 @@ func (r *Rule) scanBytes(src []byte) error {
 		r.Data = NatOutRule{}
 	case NatMaskRuleType:
 		r.Data = NatMaskRule{}
+	case PublishPortRuleType:
+		dstIP, err := abstraction.NewInet(data.DstIP)
+		if err != nil {
+			return err
+		}
+
+		r.Data = PublishPortRule{
+			DstIP:    dstIP,
+			Protocol: data.Protocol,
+			HostPort: uint16(data.HostPort),
+			DstPort:  uint16(data.DstPort),
+		}
+	case AcceptPublishedPortRuleType:
+		dstIP, err := abstraction.NewInet(data.DstIP)
+		if err != nil {
+			return err
+		}
+
+		r.Data = AcceptPublishedPortRule{
+			DstIP:      dstIP,
+			DstNetwork: data.DstNetwork,
+			Protocol:   data.Protocol,
+			DstPort:    uint16(data.DstPort),
+		}
+	case EgressAllowRuleType:
+		srcIP, err := abstraction.NewInet(data.SrcIP)
+		if err != nil {
+			return err
+		}
+
+		r.Data = EgressAllowRule{
+			SrcIP:    srcIP,
+			Protocol: data.Protocol,
+			Port:     uint16(data.Port),
+		}
+	case EgressDropRuleType:
+		srcIP, err := abstraction.NewInet(data.SrcIP)
+		if err != nil {
+			return err
+		}
+
+		r.Data = EgressDropRule{
+			SrcIP:  srcIP,
+			Target: data.Target,
+		}
+	case LogDropRuleType:
+		r.Data = LogDropRule{
+			Target: data.Target,
+			Group:  uint16(data.Group),
+			Limit:  uint(data.Limit),
+		}
+	case BanRuleType:
+		srcIP, err := abstraction.NewInet(data.SrcIP)
+		if err != nil {
+			return err
+		}
+
+		r.Data = BanRule{
+			SrcIP: srcIP,
+			DstIP: abstraction.Inet(data.DstIP),
+			Port:  uint16(data.Port),
+		}
 	default:
 		return errors.New("pq: cannot convert input src to FrontendArray")
 	}
@@ -372,9 +494,13 @@ type anyRule struct {
 	DstNetwork string
 	Protocol   string
 	DstPort    float64
+	HostPort   float64
 	Port       float64
 	Chain      string
 	Table      string
+	Target     string
+	Group      float64
+	Limit      float64
 }
 
 // CreateChainRule represents rule data for a CreateChainRuleType
@@ -395,6 +521,8 @@ type JumpToChainRule struct {
 // IsolationRule represents rule data for an IsolationRuleType
 type IsolationRule struct {
 	SrcNetwork string
+	// Target is the chain the isolated packets jump to, they are dropped if it is empty
+	Target string
 }
 
 // OutgoingOutRule represents rule data for an OutgoingOutRuleType
@@ -482,3 +610,52 @@ type NatMaskRule struct {
 	SrcIP      abstraction.Inet
 	SrcNetwork string
 }
+
+// PublishPortRule represents rule data for a PublishPortRuleType
+type PublishPortRule struct {
+	DstIP    abstraction.Inet
+	Protocol string
+	HostPort uint16
+	DstPort  uint16
+}
+
+// AcceptPublishedPortRule represents rule data for an AcceptPublishedPortRuleType
+type AcceptPublishedPortRule struct {
+	DstIP      abstraction.Inet
+	DstNetwork string
+	Protocol   string
+	DstPort    uint16
+}
+
+// EgressAllowRule represents rule data for an EgressAllowRuleType
+type EgressAllowRule struct {
+	SrcIP    abstraction.Inet
+	Protocol string
+	Port     uint16
+}
+
+// EgressDropRule represents rule data for an EgressDropRuleType
+type EgressDropRule struct {
+	SrcIP abstraction.Inet
+	// Target is the chain the restricted packets jump to, they are dropped if it is empty
+	Target string
+}
+
+// LogDropRule represents rule data for a LogDropRuleType
+type LogDropRule struct {
+	// Target is LOG to log to the kernel log or NFLOG to pass the packets to a netlink group
+	Target string
+	// Group is the netlink group of NFLOG
+	Group uint16
+	// Limit is the number of packets logged per second
+	Limit uint
+}
+
+// BanRule represents rule data for a BanRuleType
+type BanRule struct {
+	SrcIP abstraction.Inet
+	// DstIP and Port restrict the ban to tcp packets to an address and a port, every packet is dropped
+	// if they are empty
+	DstIP abstraction.Inet
+	Port  uint16
+}
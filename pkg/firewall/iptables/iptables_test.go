@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
@@ -17,12 +18,13 @@ import (
 
 var iptablesIsPresent = 1
 var isRestore = 0
+var iptOutput = ""
 
 func fakeExecCommand(command string, args ...string) *exec.Cmd {
 	cs := []string{"-test.run=TestHelperProcess", "--", command}
 	cs = append(cs, args...)
 	cmd := exec.Command(os.Args[0], cs...)
-	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", fmt.Sprintf("GO_IPT_IS_PRESENT=%d", iptablesIsPresent), fmt.Sprintf("IS_RESTORE=%d", isRestore)}
+	cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1", fmt.Sprintf("GO_IPT_IS_PRESENT=%d", iptablesIsPresent), fmt.Sprintf("IS_RESTORE=%d", isRestore), fmt.Sprintf("IPT_OUTPUT=%s", iptOutput)}
 	return cmd
 }
 
@@ -37,6 +39,8 @@ func TestHelperProcess(t *testing.T) {
 		f.WriteString(string(b))
 	}
 
+	fmt.Print(os.Getenv("IPT_OUTPUT"))
+
 	if os.Getenv("GO_IPT_IS_PRESENT") == "1" {
 		os.Exit(0)
 	} else {
@@ -261,6 +265,82 @@ var _ = Describe("iptables", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 		})
 
+		It("Should create a new PublishPortRule", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			rule := iptables.PublishPortRule{
+				DstIP:    simpleNewInet("172.18.0.2"),
+				Protocol: "tcp",
+				HostPort: 8080,
+				DstPort:  80,
+			}
+			err := ipts.CreateRule(iptables.PublishPortRuleType, rule)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, cmdStr, _ := ipts.CreateRuleEntryString(iptables.PublishPortRuleType, rule)
+			Ω(cmdStr).Should(Equal("-t nat -A KROO-NAT -p tcp --dport 8080 -j DNAT --to-destination 172.18.0.2:80"))
+		})
+
+		It("Should create a new AcceptPublishedPortRule", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			err := ipts.CreateRule(iptables.AcceptPublishedPortRuleType, iptables.AcceptPublishedPortRule{
+				DstIP:      simpleNewInet("172.18.0.2"),
+				DstNetwork: "br-0815",
+				Protocol:   "tcp",
+				DstPort:    80,
+			})
+
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should create new egress rules", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			err := ipts.CreateRule(iptables.EgressAllowRuleType, iptables.EgressAllowRule{
+				SrcIP:    simpleNewInet("172.18.0.2"),
+				Protocol: "tcp",
+				Port:     443,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = ipts.CreateRule(iptables.EgressDropRuleType, iptables.EgressDropRule{
+				SrcIP: simpleNewInet("172.18.0.2"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should create a new LogDropRule", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			err := ipts.CreateRule(iptables.LogDropRuleType, iptables.LogDropRule{
+				Target: "NFLOG",
+				Group:  5,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = ipts.CreateRule(iptables.LogDropRuleType, iptables.LogDropRule{
+				Target: "ULOG",
+			})
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should create a new BanRule", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			err := ipts.CreateRule(iptables.BanRuleType, iptables.BanRule{
+				SrcIP: simpleNewInet("203.0.113.7"),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = ipts.CreateRule(iptables.BanRuleType, iptables.BanRule{
+				SrcIP: simpleNewInet("203.0.113.7"),
+				DstIP: simpleNewInet("172.18.0.2"),
+				Port:  22,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
 		It("Should error on invalid rule", func() {
 			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
 
@@ -376,4 +456,119 @@ var _ = Describe("iptables", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 		})
 	})
+
+	Describe("Export rules", func() {
+		It("Should return all rules", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			ipts.CreateRule(iptables.AllowPortOutRuleType, iptables.AllowPortOutRule{
+				Protocol: "tcp",
+				Port:     uint16(53),
+				Chain:    "INPUT",
+			})
+
+			rules, err := ipts.ExportRules()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(rules).Should(Equal("-A INPUT -p tcp -m tcp --dport 53 -m state --state NEW,ESTABLISHED -j ACCEPT\n"))
+		})
+	})
+
+	Describe("Count rules", func() {
+		It("Should count all rules", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			n, err := ipts.CountRules()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(n).Should(BeZero())
+
+			ipts.CreateRule(iptables.AllowPortOutRuleType, iptables.AllowPortOutRule{
+				Protocol: "tcp",
+				Port:     uint16(53),
+				Chain:    "INPUT",
+			})
+
+			n, err = ipts.CountRules()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(n).Should(BeEquivalentTo(1))
+		})
+	})
+
+	Describe("Rule counters", func() {
+		It("Should return the counters of the loaded rules", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			ipts.CreateRule(iptables.CreateChainRuleType, iptables.CreateChainRule{
+				Name: iptables.IptLinkChain,
+			})
+			ipts.CreateRule(iptables.LinkContainerPortToRuleType, iptables.LinkContainerPortToRule{
+				SrcIP:      simpleNewInet("172.18.0.2"),
+				SrcNetwork: "br-0815",
+				DstIP:      simpleNewInet("172.18.0.3"),
+				DstNetwork: "br-0815",
+				Protocol:   "tcp",
+				DstPort:    uint16(80),
+			})
+			ipts.CreateRule(iptables.AllowPortOutRuleType, iptables.AllowPortOutRule{
+				Protocol: "udp",
+				Port:     uint16(53),
+				Chain:    "OUTPUT",
+			})
+
+			iptOutput = strings.Join([]string{
+				"-P INPUT ACCEPT -c 0 0",
+				"-N KROO-LINK",
+				"-A OUTPUT -p udp -m udp --dport 53 -m state --state NEW,ESTABLISHED -c 12 960 -j ACCEPT",
+				"-A KROO-LINK -s 172.18.0.2/32 -d 172.18.0.3/32 -i br-0815 -o br-0815 -p tcp -m tcp --dport 80 -c 7 420 -j ACCEPT",
+			}, "\n")
+			counters, err := ipts.GetRuleCounters()
+			iptOutput = ""
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(counters).To(HaveLen(2))
+
+			byType := make(map[int]iptables.RuleCounters)
+			for _, c := range counters {
+				Expect(c.Table).To(Equal("filter"))
+				byType[c.RuleType] = c
+			}
+			link := byType[iptables.LinkContainerPortToRuleType]
+			Expect(link.Packets).To(BeEquivalentTo(7))
+			Expect(link.Bytes).To(BeEquivalentTo(420))
+			Expect(link.Rule).To(ContainSubstring("--dport 80"))
+
+			port := byType[iptables.AllowPortOutRuleType]
+			Expect(port.Packets).To(BeEquivalentTo(12))
+			Expect(port.Bytes).To(BeEquivalentTo(960))
+		})
+
+		It("Should leave out rules which are not loaded", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			ipts.CreateRule(iptables.AllowPortOutRuleType, iptables.AllowPortOutRule{
+				Protocol: "tcp",
+				Port:     uint16(53),
+				Chain:    "INPUT",
+			})
+
+			iptOutput = "-A INPUT -p tcp -m tcp --dport 443 -m state --state NEW,ESTABLISHED -c 1 60 -j ACCEPT"
+			counters, err := ipts.GetRuleCounters()
+			iptOutput = ""
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(counters).To(BeEmpty())
+		})
+
+		It("Should error on invalid counters", func() {
+			ipts, _ := iptables.NewService("iptables", "iptables-restore", testutils.NewMockDB())
+
+			ipts.CreateRule(iptables.AllowPortOutRuleType, iptables.AllowPortOutRule{
+				Protocol: "tcp",
+				Port:     uint16(53),
+				Chain:    "INPUT",
+			})
+
+			iptOutput = "-A INPUT -p tcp -m tcp --dport 53 -m state --state NEW,ESTABLISHED -c many 60 -j ACCEPT"
+			_, err := ipts.GetRuleCounters()
+			iptOutput = ""
+			Ω(err).Should(HaveOccurred())
+		})
+	})
 })
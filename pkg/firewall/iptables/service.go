@@ -25,6 +25,15 @@ type Service interface {
 
 	// RestoreRules restores all rules from the database using iptables-restore
 	RestoreRules() error
+
+	// ExportRules returns all rules from the database in the format read by iptables-restore
+	ExportRules() (string, error)
+
+	// CountRules returns the number of rules
+	CountRules() (uint, error)
+
+	// GetRuleCounters returns the packet and byte counters of the persisted rules which are loaded
+	GetRuleCounters() ([]RuleCounters, error)
 }
 
 type dbAdapter interface {
@@ -379,6 +388,132 @@ func (s *service) CreateRuleEntryString(ruleType int, ruleData interface{}) (Rul
 			return RuleEntry{}, "", err
 		}
 
+		cmdStr = buf.String()
+		re.ID = createHash(cmdStr)
+	case PublishPortRuleType:
+		rd, ok := ruleData.(PublishPortRule)
+		if !ok {
+			return RuleEntry{}, "", errInvalidData
+		}
+		rule := Rule{
+			Data:     rd,
+			RuleType: PublishPortRuleType,
+		}
+		re.rule = rule
+		re.setRefs("", "", abstraction.Inet(""), rd.DstIP)
+
+		var buf bytes.Buffer
+		err := PublishPortRuleTmpl.Execute(&buf, rd)
+		if err != nil {
+			return RuleEntry{}, "", err
+		}
+
+		cmdStr = buf.String()
+		re.ID = createHash(cmdStr)
+	case AcceptPublishedPortRuleType:
+		rd, ok := ruleData.(AcceptPublishedPortRule)
+		if !ok {
+			return RuleEntry{}, "", errInvalidData
+		}
+		rule := Rule{
+			Data:     rd,
+			RuleType: AcceptPublishedPortRuleType,
+		}
+		re.rule = rule
+		re.setRefs("", rd.DstNetwork, abstraction.Inet(""), rd.DstIP)
+
+		var buf bytes.Buffer
+		err := AcceptPublishedPortRuleTmpl.Execute(&buf, rd)
+		if err != nil {
+			return RuleEntry{}, "", err
+		}
+
+		cmdStr = buf.String()
+		re.ID = createHash(cmdStr)
+	case EgressAllowRuleType:
+		rd, ok := ruleData.(EgressAllowRule)
+		if !ok {
+			return RuleEntry{}, "", errInvalidData
+		}
+		rule := Rule{
+			Data:     rd,
+			RuleType: EgressAllowRuleType,
+		}
+		re.rule = rule
+		re.setRefs("", "", rd.SrcIP, abstraction.Inet(""))
+
+		var buf bytes.Buffer
+		err := EgressAllowRuleTmpl.Execute(&buf, rd)
+		if err != nil {
+			return RuleEntry{}, "", err
+		}
+
+		cmdStr = buf.String()
+		re.ID = createHash(cmdStr)
+	case EgressDropRuleType:
+		rd, ok := ruleData.(EgressDropRule)
+		if !ok {
+			return RuleEntry{}, "", errInvalidData
+		}
+		rule := Rule{
+			Data:     rd,
+			RuleType: EgressDropRuleType,
+		}
+		re.rule = rule
+		re.setRefs("", "", rd.SrcIP, abstraction.Inet(""))
+
+		var buf bytes.Buffer
+		err := EgressDropRuleTmpl.Execute(&buf, rd)
+		if err != nil {
+			return RuleEntry{}, "", err
+		}
+
+		cmdStr = buf.String()
+		re.ID = createHash(cmdStr)
+	case LogDropRuleType:
+		rd, ok := ruleData.(LogDropRule)
+		if !ok {
+			return RuleEntry{}, "", errInvalidData
+		}
+		if rd.Target != "LOG" && rd.Target != "NFLOG" {
+			return RuleEntry{}, "", errInvalidData
+		}
+		if rd.Limit == 0 {
+			rd.Limit = 10
+		}
+		rule := Rule{
+			Data:     rd,
+			RuleType: LogDropRuleType,
+		}
+		re.rule = rule
+		re.setRefs("", "", abstraction.Inet(""), abstraction.Inet(""))
+
+		var buf bytes.Buffer
+		err := LogDropRuleTmpl.Execute(&buf, rd)
+		if err != nil {
+			return RuleEntry{}, "", err
+		}
+
+		cmdStr = buf.String()
+		re.ID = createHash(cmdStr)
+	case BanRuleType:
+		rd, ok := ruleData.(BanRule)
+		if !ok {
+			return RuleEntry{}, "", errInvalidData
+		}
+		rule := Rule{
+			Data:     rd,
+			RuleType: BanRuleType,
+		}
+		re.rule = rule
+		re.setRefs("", "", rd.SrcIP, rd.DstIP)
+
+		var buf bytes.Buffer
+		err := BanRuleTmpl.Execute(&buf, rd)
+		if err != nil {
+			return RuleEntry{}, "", err
+		}
+
 		cmdStr = buf.String()
 		re.ID = createHash(cmdStr)
 	default:
@@ -425,7 +560,7 @@ func (s *service) RemoveRule(ruleType int, ruleData interface{}) error {
 		return errors.New("Rule cannot be removed (no -A present)")
 	}
 
-	strings.Replace(cmdStr, "-A", "-D", -1)
+	cmdStr = strings.Replace(cmdStr, "-A", "-D", 1)
 
 	err = s.executeIPTableCommand(cmdStr)
 	if err != nil {
@@ -462,6 +597,10 @@ func (s *service) createExportStrings() (string, error) {
 	return restoreStr, nil
 }
 
+func (s *service) ExportRules() (string, error) {
+	return s.createExportStrings()
+}
+
 func (s *service) RestoreRules() error {
 	str, err := s.createExportStrings()
 	if err != nil {
@@ -480,6 +619,15 @@ func (s *service) RestoreRules() error {
 	return nil
 }
 
+func (s *service) CountRules() (uint, error) {
+	res := []RuleEntry{}
+	err := s.db.Find(&res)
+	if err != nil {
+		return 0, err
+	}
+	return uint(len(res)), nil
+}
+
 // ExecCommand is a wrapper around exec.Command used for testing
 var ExecCommand = exec.Command
 
@@ -3,6 +3,7 @@ package firewall
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"sync"
 
@@ -26,13 +27,63 @@ type Service interface {
 
 	// AllowPort sets up a rule to block src from talking to dst on port port
 	BlockPort(srcIP abstraction.Inet, srcNw string, dstIP abstraction.Inet, dstNw string, port uint16, protocol string) error
+
+	// RuleCounters returns the traffic matched by every rule of the firewall
+	RuleCounters() ([]iptables.RuleCounters, error)
+
+	// ApplyPolicy publishes the public ports of the instance with ip in network nw and restricts its
+	// outgoing connections to its egress profile
+	ApplyPolicy(ip abstraction.Inet, nw string, policy Policy) error
+
+	// RemovePolicy removes the rules ApplyPolicy created for the same policy
+	RemovePolicy(ip abstraction.Inet, nw string, policy Policy) error
+
+	// Ban drops the packets of src, only the tcp packets to dst and port are dropped if they are set
+	Ban(src abstraction.Inet, dst abstraction.Inet, port uint16) error
+
+	// Unban removes the rule Ban created for the same arguments
+	Unban(src abstraction.Inet, dst abstraction.Inet, port uint16) error
+
+	// Drops returns the packets the deny rules dropped per container and peer since the daemon started
+	Drops() ([]DropStats, error)
+}
+
+const (
+	// LogDropsKernel logs the dropped packets to the kernel log
+	LogDropsKernel = "log"
+
+	// LogDropsNFLog passes the dropped packets to a netlink group, e.g. for ulogd
+	LogDropsNFLog = "nflog"
+)
+
+// ErrDropsNotLogged occurs if the drops are requested while they are not logged
+var ErrDropsNotLogged = errors.New("dropped packets are not logged")
+
+// Options configure the firewall service
+type Options struct {
+	// LogDrops is LogDropsKernel or LogDropsNFLog to log the packets dropped by the deny rules, nothing is
+	// logged if it is empty
+	LogDrops string
+	// NFLogGroup is the netlink group of LogDropsNFLog
+	NFLogGroup uint16
+	// Drops aggregates the logged drops, it is fed with the log by the caller
+	Drops *DropCollector
 }
 
 type service struct {
 	iptClient iptables.Service
+	options   Options
 	mtx       *sync.Mutex
 }
 
+// denyTarget is the target of the deny rules, they jump to the drop chain if drops are logged
+func (s *service) denyTarget() string {
+	if s.options.LogDrops == "" {
+		return ""
+	}
+	return iptables.IptDropChain
+}
+
 func (s *service) InitBridge(ip abstraction.Inet, netIf string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
@@ -44,6 +95,7 @@ func (s *service) initBridge(ip abstraction.Inet, netIf string) error {
 	// Isolate bridge from other bridges and allow outgoing traffic
 	err := s.iptClient.CreateRule(iptables.IsolationRuleType, iptables.IsolationRule{
 		SrcNetwork: netIf,
+		Target:     s.denyTarget(),
 	})
 	if err != nil {
 		return err
@@ -238,6 +290,185 @@ func (s *service) blockPort(srcIP abstraction.Inet, srcNw string, dstIP abstract
 	return nil
 }
 
+func (s *service) RuleCounters() ([]iptables.RuleCounters, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.iptClient.GetRuleCounters()
+}
+
+// policyRule is a rule the firewall policy of an instance consists of
+type policyRule struct {
+	ruleType int
+	data     interface{}
+}
+
+// policyRules returns the rules of a policy in the order they are created in
+func (s *service) policyRules(ip abstraction.Inet, nw string, policy Policy) ([]policyRule, error) {
+	rules := []policyRule{}
+	for _, p := range policy.Public {
+		if !s.isValidProtocol(p.Protocol) {
+			return nil, errors.New("Not a valid protocol")
+		}
+		if p.Port == 0 {
+			return nil, errors.New("Public ports need a port")
+		}
+
+		hostPort := p.HostPort
+		if hostPort == 0 {
+			hostPort = p.Port
+		}
+
+		rules = append(rules, policyRule{iptables.AcceptPublishedPortRuleType, iptables.AcceptPublishedPortRule{
+			DstIP:      ip,
+			DstNetwork: nw,
+			Protocol:   p.Protocol,
+			DstPort:    p.Port,
+		}}, policyRule{iptables.PublishPortRuleType, iptables.PublishPortRule{
+			DstIP:    ip,
+			Protocol: p.Protocol,
+			HostPort: hostPort,
+			DstPort:  p.Port,
+		}})
+	}
+
+	if policy.Egress == "" || policy.Egress == EgressOpen {
+		return rules, nil
+	}
+
+	allowed, ok := egressProfiles[policy.Egress]
+	if !ok {
+		return nil, fmt.Errorf("Unknown egress profile %s", policy.Egress)
+	}
+	for _, a := range allowed {
+		a.SrcIP = ip
+		rules = append(rules, policyRule{iptables.EgressAllowRuleType, a})
+	}
+	return append(rules, policyRule{iptables.EgressDropRuleType, iptables.EgressDropRule{
+		SrcIP:  ip,
+		Target: s.denyTarget(),
+	}}), nil
+}
+
+func (s *service) ApplyPolicy(ip abstraction.Inet, nw string, policy Policy) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.applyPolicy(ip, nw, policy)
+}
+
+func (s *service) applyPolicy(ip abstraction.Inet, nw string, policy Policy) error {
+	rules, err := s.policyRules(ip, nw, policy)
+	if err != nil {
+		return err
+	}
+
+	for i, r := range rules {
+		err = s.iptClient.CreateRule(r.ruleType, r.data)
+		if err != nil {
+			// a policy is applied completely or not at all
+			for j := i - 1; j >= 0; j-- {
+				s.iptClient.RemoveRule(rules[j].ruleType, rules[j].data)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) RemovePolicy(ip abstraction.Inet, nw string, policy Policy) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removePolicy(ip, nw, policy)
+}
+
+func (s *service) removePolicy(ip abstraction.Inet, nw string, policy Policy) error {
+	rules, err := s.policyRules(ip, nw, policy)
+	if err != nil {
+		return err
+	}
+
+	// every rule is removed even if removing one of them fails
+	var first error
+	for i := len(rules) - 1; i >= 0; i-- {
+		err = s.iptClient.RemoveRule(rules[i].ruleType, rules[i].data)
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (s *service) Ban(src abstraction.Inet, dst abstraction.Inet, port uint16) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.ban(src, dst, port)
+}
+
+func (s *service) ban(src abstraction.Inet, dst abstraction.Inet, port uint16) error {
+	return s.iptClient.CreateRule(iptables.BanRuleType, iptables.BanRule{
+		SrcIP: src,
+		DstIP: dst,
+		Port:  port,
+	})
+}
+
+func (s *service) Unban(src abstraction.Inet, dst abstraction.Inet, port uint16) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.unban(src, dst, port)
+}
+
+func (s *service) unban(src abstraction.Inet, dst abstraction.Inet, port uint16) error {
+	return s.iptClient.RemoveRule(iptables.BanRuleType, iptables.BanRule{
+		SrcIP: src,
+		DstIP: dst,
+		Port:  port,
+	})
+}
+
+func (s *service) Drops() ([]DropStats, error) {
+	if s.options.LogDrops == "" || s.options.Drops == nil {
+		return nil, ErrDropsNotLogged
+	}
+	return s.options.Drops.Stats(), nil
+}
+
+// setUpDropLog creates the drop chain, which logs packets and drops them
+func (s *service) setUpDropLog() error {
+	target := "LOG"
+	switch s.options.LogDrops {
+	case LogDropsKernel:
+	case LogDropsNFLog:
+		target = "NFLOG"
+	default:
+		return fmt.Errorf("Unknown drop log %s", s.options.LogDrops)
+	}
+
+	err := s.iptClient.CreateRule(iptables.CreateChainRuleType, iptables.CreateChainRule{
+		Name: iptables.IptDropChain,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = s.iptClient.CreateRule(iptables.LogDropRuleType, iptables.LogDropRule{
+		Target: target,
+		Group:  s.options.NFLogGroup,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.iptClient.CreateRule(iptables.JumpToChainRuleType, iptables.JumpToChainRule{
+		From: iptables.IptDropChain,
+		To:   "DROP",
+	})
+}
+
 func (s *service) setUpDNS() error {
 	err := s.iptClient.CreateRule(iptables.AllowPortInRuleType, iptables.AllowPortInRule{
 		Protocol: "udp",
@@ -279,9 +510,10 @@ func (s *service) setUpDNS() error {
 }
 
 // NewService creates a new firewall service
-func NewService(ipte iptables.Service) (Service, error) {
+func NewService(ipte iptables.Service, o Options) (Service, error) {
 	s := &service{
 		iptClient: ipte,
+		options:   o,
 		mtx:       &sync.Mutex{},
 	}
 
@@ -289,9 +521,11 @@ func NewService(ipte iptables.Service) (Service, error) {
 		return &service{}, errors.New("Invalid iptable client")
 	}
 
-	// Create predefined chains
+	// Create predefined chains, banned sources are dropped before any other chain accepts their packets
 	chains := []string{
+		iptables.IptBanChain,
 		iptables.IptDNSChain,
+		iptables.IptEgressChain,
 		iptables.IptOutboundChain,
 		iptables.IptLinkChain,
 		iptables.IptIsolationChain,
@@ -320,6 +554,13 @@ func NewService(ipte iptables.Service) (Service, error) {
 			return &service{}, err
 		}
 	}
+	// banned sources may not reach the services of the host either, e.g. the router
+	if err := s.iptClient.CreateRule(iptables.JumpToChainRuleType, iptables.JumpToChainRule{
+		From: "INPUT",
+		To:   iptables.IptBanChain,
+	}); err != nil {
+		return &service{}, err
+	}
 	// Create nat jump
 	if err := s.iptClient.CreateRule(iptables.JumpToChainRuleType, iptables.JumpToChainRule{
 		From:  "PREROUTING",
@@ -330,6 +571,13 @@ func NewService(ipte iptables.Service) (Service, error) {
 		return &service{}, err
 	}
 
+	// the drop chain has to exist before the deny rules jump to it
+	if o.LogDrops != "" {
+		if err := s.setUpDropLog(); err != nil {
+			return &service{}, err
+		}
+	}
+
 	err := s.setUpDNS()
 	if err != nil {
 		return &service{}, err
@@ -6,6 +6,7 @@ import (
 	"github.com/go-kit/kit/log"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
 	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/pb"
 	oldcontext "golang.org/x/net/context"
 )
@@ -47,6 +48,42 @@ func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger)
 			EncodeGRPCBlockPortResponse,
 			options...,
 		),
+		rulecounters: grpctransport.NewServer(
+			endpoints.RuleCountersEndpoint,
+			DecodeGRPCRuleCountersRequest,
+			EncodeGRPCRuleCountersResponse,
+			options...,
+		),
+		applypolicy: grpctransport.NewServer(
+			endpoints.ApplyPolicyEndpoint,
+			DecodeGRPCApplyPolicyRequest,
+			EncodeGRPCApplyPolicyResponse,
+			options...,
+		),
+		removepolicy: grpctransport.NewServer(
+			endpoints.RemovePolicyEndpoint,
+			DecodeGRPCRemovePolicyRequest,
+			EncodeGRPCRemovePolicyResponse,
+			options...,
+		),
+		drops: grpctransport.NewServer(
+			endpoints.DropsEndpoint,
+			DecodeGRPCDropsRequest,
+			EncodeGRPCDropsResponse,
+			options...,
+		),
+		ban: grpctransport.NewServer(
+			endpoints.BanEndpoint,
+			DecodeGRPCBanRequest,
+			EncodeGRPCBanResponse,
+			options...,
+		),
+		unban: grpctransport.NewServer(
+			endpoints.UnbanEndpoint,
+			DecodeGRPCUnbanRequest,
+			EncodeGRPCUnbanResponse,
+			options...,
+		),
 	}
 }
 
@@ -56,6 +93,12 @@ type grpcServer struct {
 	blockconnection grpctransport.Handler
 	allowport       grpctransport.Handler
 	blockport       grpctransport.Handler
+	rulecounters    grpctransport.Handler
+	applypolicy     grpctransport.Handler
+	removepolicy    grpctransport.Handler
+	drops           grpctransport.Handler
+	ban             grpctransport.Handler
+	unban           grpctransport.Handler
 }
 
 func (s *grpcServer) InitBridge(ctx oldcontext.Context, req *pb.InitBridgeRequest) (*pb.InitBridgeResponse, error) {
@@ -98,6 +141,54 @@ func (s *grpcServer) BlockPort(ctx oldcontext.Context, req *pb.BlockPortRequest)
 	return res.(*pb.BlockPortResponse), nil
 }
 
+func (s *grpcServer) RuleCounters(ctx oldcontext.Context, req *pb.RuleCountersRequest) (*pb.RuleCountersResponse, error) {
+	_, res, err := s.rulecounters.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RuleCountersResponse), nil
+}
+
+func (s *grpcServer) ApplyPolicy(ctx oldcontext.Context, req *pb.ApplyPolicyRequest) (*pb.ApplyPolicyResponse, error) {
+	_, res, err := s.applypolicy.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ApplyPolicyResponse), nil
+}
+
+func (s *grpcServer) RemovePolicy(ctx oldcontext.Context, req *pb.RemovePolicyRequest) (*pb.RemovePolicyResponse, error) {
+	_, res, err := s.removepolicy.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemovePolicyResponse), nil
+}
+
+func (s *grpcServer) Drops(ctx oldcontext.Context, req *pb.DropsRequest) (*pb.DropsResponse, error) {
+	_, res, err := s.drops.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DropsResponse), nil
+}
+
+func (s *grpcServer) Ban(ctx oldcontext.Context, req *pb.BanRequest) (*pb.BanResponse, error) {
+	_, res, err := s.ban.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.BanResponse), nil
+}
+
+func (s *grpcServer) Unban(ctx oldcontext.Context, req *pb.UnbanRequest) (*pb.UnbanResponse, error) {
+	_, res, err := s.unban.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.UnbanResponse), nil
+}
+
 // DecodeGRPCInitBridgeRequest is a transport/grpc.DecodeRequestFunc that converts a
 // gRPC InitBridge request to a messages/firewall.proto-domain initbridge request.
 func DecodeGRPCInitBridgeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
@@ -250,3 +341,225 @@ func EncodeGRPCBlockPortResponse(_ context.Context, response interface{}) (inter
 	}
 	return gRPCRes, nil
 }
+
+// DecodeGRPCRuleCountersRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RuleCounters request to a messages/firewall.proto-domain rulecounters request.
+func DecodeGRPCRuleCountersRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return RuleCountersRequest{}, nil
+}
+
+// ConvertRuleCounters converts RuleCounters to their protobuf representation
+func ConvertRuleCounters(c iptables.RuleCounters) *pb.RuleCounters {
+	return &pb.RuleCounters{
+		ID:       c.ID,
+		RuleType: int32(c.RuleType),
+		Table:    c.Table,
+		Rule:     c.Rule,
+		Packets:  c.Packets,
+		Bytes:    c.Bytes,
+	}
+}
+
+// ConvertPBRuleCounters converts protobuf RuleCounters to RuleCounters
+func ConvertPBRuleCounters(c *pb.RuleCounters) iptables.RuleCounters {
+	if c == nil {
+		return iptables.RuleCounters{}
+	}
+
+	return iptables.RuleCounters{
+		ID:       c.ID,
+		RuleType: int(c.RuleType),
+		Table:    c.Table,
+		Rule:     c.Rule,
+		Packets:  c.Packets,
+		Bytes:    c.Bytes,
+	}
+}
+
+// EncodeGRPCRuleCountersResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain rulecounters response to a gRPC RuleCounters response.
+func EncodeGRPCRuleCountersResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RuleCountersResponse)
+	counters := make([]*pb.RuleCounters, len(res.Counters))
+	for i, c := range res.Counters {
+		counters[i] = ConvertRuleCounters(c)
+	}
+
+	gRPCRes := &pb.RuleCountersResponse{
+		Counters: counters,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// ConvertPolicy converts a Policy to its protobuf representation
+func ConvertPolicy(p Policy) *pb.Policy {
+	public := make([]*pb.PublicPort, len(p.Public))
+	for i, port := range p.Public {
+		public[i] = &pb.PublicPort{
+			Port:     uint32(port.Port),
+			HostPort: uint32(port.HostPort),
+			Protocol: port.Protocol,
+		}
+	}
+
+	return &pb.Policy{
+		Public: public,
+		Egress: p.Egress,
+	}
+}
+
+// ConvertPBPolicy converts a protobuf Policy to a Policy
+func ConvertPBPolicy(p *pb.Policy) Policy {
+	if p == nil {
+		return Policy{}
+	}
+
+	public := make([]PublicPort, len(p.Public))
+	for i, port := range p.Public {
+		public[i] = PublicPort{
+			Port:     uint16(port.Port),
+			HostPort: uint16(port.HostPort),
+			Protocol: port.Protocol,
+		}
+	}
+
+	return Policy{
+		Public: public,
+		Egress: p.Egress,
+	}
+}
+
+// DecodeGRPCApplyPolicyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ApplyPolicy request to a messages/firewall.proto-domain applypolicy request.
+func DecodeGRPCApplyPolicyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ApplyPolicyRequest)
+	ip, err := abstraction.NewInet(req.IP)
+	if err != nil {
+		return ApplyPolicyRequest{}, err
+	}
+	return ApplyPolicyRequest{
+		IP:      ip,
+		Network: req.Network,
+		Policy:  ConvertPBPolicy(req.Policy),
+	}, nil
+}
+
+// EncodeGRPCApplyPolicyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain applypolicy response to a gRPC ApplyPolicy response.
+func EncodeGRPCApplyPolicyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ApplyPolicyResponse)
+	gRPCRes := &pb.ApplyPolicyResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemovePolicyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemovePolicy request to a messages/firewall.proto-domain removepolicy request.
+func DecodeGRPCRemovePolicyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemovePolicyRequest)
+	ip, err := abstraction.NewInet(req.IP)
+	if err != nil {
+		return RemovePolicyRequest{}, err
+	}
+	return RemovePolicyRequest{
+		IP:      ip,
+		Network: req.Network,
+		Policy:  ConvertPBPolicy(req.Policy),
+	}, nil
+}
+
+// EncodeGRPCRemovePolicyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain removepolicy response to a gRPC RemovePolicy response.
+func EncodeGRPCRemovePolicyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemovePolicyResponse)
+	gRPCRes := &pb.RemovePolicyResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDropsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Drops request to a messages/firewall.proto-domain drops request.
+func DecodeGRPCDropsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return DropsRequest{}, nil
+}
+
+// ConvertDropStats converts DropStats to their protobuf representation
+func ConvertDropStats(d DropStats) *pb.DropStats {
+	return &pb.DropStats{
+		Address:  d.Address,
+		Peer:     d.Peer,
+		Outgoing: d.Outgoing,
+		Packets:  d.Packets,
+		Ports:    d.Ports,
+		Last:     d.Last.Unix(),
+	}
+}
+
+// EncodeGRPCDropsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain drops response to a gRPC Drops response.
+func EncodeGRPCDropsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DropsResponse)
+	drops := make([]*pb.DropStats, len(res.Drops))
+	for i, d := range res.Drops {
+		drops[i] = ConvertDropStats(d)
+	}
+
+	gRPCRes := &pb.DropsResponse{
+		Drops: drops,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCBanRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Ban request to a messages/firewall.proto-domain ban request.
+func DecodeGRPCBanRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.BanRequest)
+	return BanRequest{
+		Src:  abstraction.Inet(req.Src),
+		Dst:  abstraction.Inet(req.Dst),
+		Port: uint16(req.Port),
+	}, nil
+}
+
+// EncodeGRPCBanResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain ban response to a gRPC Ban response.
+func EncodeGRPCBanResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(BanResponse)
+	gRPCRes := &pb.BanResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCUnbanRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Unban request to a messages/firewall.proto-domain unban request.
+func DecodeGRPCUnbanRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UnbanRequest)
+	return UnbanRequest{
+		Src:  abstraction.Inet(req.Src),
+		Dst:  abstraction.Inet(req.Dst),
+		Port: uint16(req.Port),
+	}, nil
+}
+
+// EncodeGRPCUnbanResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/firewall.proto-domain unban response to a gRPC Unban response.
+func EncodeGRPCUnbanResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(UnbanResponse)
+	gRPCRes := &pb.UnbanResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,435 @@
+// Package gateway provides a RESTful HTTP/JSON transport for the public API, requests are translated
+// to calls of the gRPC services so authentication and permissions are handled by their interceptor
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/kontainerooo/kontainer.ooo/pkg/idempotency"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/login"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ratelimit"
+	"github.com/kontainerooo/kontainer.ooo/pkg/validation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// SpecPath is the path the OpenAPI specification of the served routes is available at
+const SpecPath = "/v1/openapi.json"
+
+// Invoker calls gRPC methods, it is implemented by *grpc.ClientConn
+type Invoker interface {
+	Invoke(ctx oldcontext.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error
+}
+
+// Route maps an HTTP method and path to a gRPC method. Variables in the path are written as {field}
+// and set the request field of the same name, the remaining fields are read from the query string
+// of GET requests or from the JSON body of any other request.
+type Route struct {
+	Method     string
+	Path       string
+	GRPCMethod string
+	Request    proto.Message
+	Response   proto.Message
+	Summary    string
+}
+
+// segments returns the parts of a slash separated path
+func segments(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// variable returns the name of a path variable, ok is false if segment is no variable
+func variable(segment string) (string, bool) {
+	if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+		return segment[1 : len(segment)-1], true
+	}
+	return "", false
+}
+
+// match returns the path variables if path matches the route
+func (r Route) match(method, path string) (map[string]string, bool) {
+	if r.Method != method {
+		return nil, false
+	}
+
+	pattern, parts := segments(r.Path), segments(path)
+	if len(pattern) != len(parts) {
+		return nil, false
+	}
+
+	vars := make(map[string]string)
+	for i, p := range pattern {
+		if name, ok := variable(p); ok {
+			if parts[i] == "" {
+				return nil, false
+			}
+			vars[name] = parts[i]
+		} else if p != parts[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+// fields returns the fields of a message by their protobuf name
+func fields(t reflect.Type) map[string]reflect.StructField {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	fs := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		for _, o := range strings.Split(f.Tag.Get("protobuf"), ",") {
+			if strings.HasPrefix(o, "name=") {
+				fs[strings.TrimPrefix(o, "name=")] = f
+			}
+		}
+	}
+	return fs
+}
+
+// field returns the field name of a message of type t, fields of nested messages are selected with
+// dots, e.g. flag.name
+func field(t reflect.Type, name string) (reflect.StructField, bool) {
+	parts := strings.SplitN(name, ".", 2)
+	f, ok := fields(t)[parts[0]]
+	if !ok || len(parts) == 1 {
+		return f, ok
+	}
+	if f.Type.Kind() != reflect.Ptr || f.Type.Elem().Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	return field(f.Type, parts[1])
+}
+
+// convert returns the JSON value of a string for a field of kind k
+func convert(k reflect.Kind, s string) (interface{}, error) {
+	switch k {
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int32, reflect.Int64:
+		_, err := strconv.ParseInt(s, 10, 64)
+		return json.Number(s), err
+	case reflect.Uint32, reflect.Uint64:
+		_, err := strconv.ParseUint(s, 10, 64)
+		return json.Number(s), err
+	}
+	return s, nil
+}
+
+// setParam sets the field name of a message of type t in values to s. Fields of nested messages
+// and keys of maps are selected with dots, e.g. page.limit or page.filter.name, unknown fields are
+// ignored.
+func setParam(values map[string]interface{}, t reflect.Type, name, s string) error {
+	parts := strings.SplitN(name, ".", 2)
+	f, ok := fields(t)[parts[0]]
+	if !ok {
+		return nil
+	}
+
+	if len(parts) == 1 {
+		val, err := convert(f.Type.Kind(), s)
+		if err != nil {
+			return err
+		}
+		values[parts[0]] = val
+		return nil
+	}
+
+	nested, ok := values[parts[0]].(map[string]interface{})
+	if !ok {
+		nested = make(map[string]interface{})
+	}
+
+	switch {
+	case f.Type.Kind() == reflect.Map:
+		nested[parts[1]] = s
+	case f.Type.Kind() == reflect.Ptr && f.Type.Elem().Kind() == reflect.Struct:
+		err := setParam(nested, f.Type, parts[1], s)
+		if err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	values[parts[0]] = nested
+	return nil
+}
+
+// decode builds the gRPC request of a route from the HTTP request and the path variables
+func (r Route) decode(req *http.Request, vars map[string]string) (proto.Message, error) {
+	values := make(map[string]interface{})
+	params := make(map[string]string)
+
+	if req.Method == http.MethodGet {
+		for k, v := range req.URL.Query() {
+			params[k] = v[0]
+		}
+	} else {
+		err := json.NewDecoder(req.Body).Decode(&values)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	for k, v := range vars {
+		params[k] = v
+	}
+
+	for k, v := range params {
+		err := setParam(values, reflect.TypeOf(r.Request), k, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := reflect.New(reflect.TypeOf(r.Request).Elem()).Interface().(proto.Message)
+	u := jsonpb.Unmarshaler{AllowUnknownFields: true}
+	err = u.Unmarshal(bytes.NewReader(data), msg)
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Server is an http.Handler serving a set of routes
+type Server struct {
+	// MaxBodySize is the size in bytes the bodies of the requests may have, there is no limit if it is 0
+	MaxBodySize int64
+
+	conn       Invoker
+	routes     []Route
+	deprecated map[string]versioning.Deprecation
+	spec       []byte
+	logger     log.Logger
+}
+
+// statusCode maps the code of a gRPC error to an HTTP status code
+func statusCode(err error) int {
+	switch grpc.Code(err) {
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Aborted:
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError writes err in the same form as the error field of the responses, fields are the
+// invalid fields of rejected requests
+func (s *Server) writeError(w http.ResponseWriter, code int, err error, fields ...validation.FieldError) {
+	body := map[string]interface{}{
+		"error": grpc.ErrorDesc(err),
+	}
+	if len(fields) > 0 {
+		body["fields"] = fields
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
+
+// successor returns the path of the route of the same version as r calling the gRPC method linked
+// in the link header of a deprecated method
+func (s *Server) successor(r Route, link string) string {
+	start, end := strings.Index(link, "<"), strings.Index(link, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	method := "/" + strings.TrimPrefix(link[start+1:end], "/")
+
+	v, _ := split(r.Path)
+	for _, o := range s.routes {
+		if ov, _ := split(o.Path); ov == v && o.GRPCMethod == method {
+			return o.Path
+		}
+	}
+	return ""
+}
+
+// fill sets the variables of path to their values in vars
+func fill(path string, vars map[string]string) string {
+	parts := segments(path)
+	for i, p := range parts {
+		if name, ok := variable(p); ok && vars[name] != "" {
+			parts[i] = vars[name]
+		}
+	}
+	return "/" + strings.Join(parts, "/")
+}
+
+// deprecate sets the headers announcing the deprecation of r, or of its gRPC method if the method is
+// deprecated in every version. The variables of the successor are set to the ones of the request.
+func (s *Server) deprecate(w http.ResponseWriter, r Route, vars map[string]string, header metadata.MD) {
+	d, ok := s.deprecated[key(r.Method, r.Path)]
+	if !ok {
+		if len(header[versioning.DeprecationHeader]) == 0 {
+			return
+		}
+		if v := header[versioning.SunsetHeader]; len(v) > 0 {
+			d.Sunset, _ = http.ParseTime(v[0])
+		}
+		if v := header[versioning.LinkHeader]; len(v) > 0 {
+			d.Successor = s.successor(r, v[0])
+		}
+	}
+
+	if d.Successor != "" {
+		d.Successor = fill(d.Successor, vars)
+	}
+	for k, v := range d.Header() {
+		w.Header()[k] = v
+	}
+}
+
+// responseError returns the content of the error field of a response
+func responseError(res proto.Message) string {
+	f := reflect.Indirect(reflect.ValueOf(res)).FieldByName("Error")
+	if f.IsValid() && f.Kind() == reflect.String {
+		return f.String()
+	}
+	return ""
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *http.Request, r Route, vars map[string]string) {
+	if s.MaxBodySize > 0 {
+		if req.ContentLength > s.MaxBodySize {
+			s.writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", s.MaxBodySize))
+			return
+		}
+		req.Body = http.MaxBytesReader(w, req.Body, s.MaxBodySize)
+	}
+
+	msg, err := r.decode(req, vars)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := req.Context()
+	md := metadata.MD{}
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		md.Set("authorization", auth)
+	}
+	if id := logging.RequestID(ctx); id != "" {
+		md.Set(logging.RequestIDHeader, id)
+	}
+	if key := req.Header.Get("Idempotency-Key"); key != "" {
+		md.Set(idempotency.Header, key)
+	}
+	if session := req.Header.Get("X-Impersonate"); session != "" {
+		md.Set(impersonation.MetadataKey, session)
+	}
+	md.Set(login.ClientAddrHeader, req.RemoteAddr)
+	if agent := req.UserAgent(); agent != "" {
+		md.Set(login.ClientAgentHeader, agent)
+	}
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	res := reflect.New(reflect.TypeOf(r.Response).Elem()).Interface().(proto.Message)
+	header := metadata.MD{}
+	err = s.conn.Invoke(ctx, r.GRPCMethod, msg, res, grpc.Header(&header))
+	s.deprecate(w, r, vars, header)
+	if err != nil {
+		level.Warn(s.logger).Log("method", r.GRPCMethod, "request", logging.RequestID(ctx), "err", err)
+		if v := header[ratelimit.RetryAfterHeader]; len(v) > 0 {
+			w.Header().Set("Retry-After", v[0])
+		}
+		var fields validation.Errors
+		if v := header[validation.FieldsHeader]; len(v) > 0 {
+			json.Unmarshal([]byte(v[0]), &fields)
+		}
+		s.writeError(w, statusCode(err), err, fields...)
+		return
+	}
+
+	if v := header[idempotency.ReplayedHeader]; len(v) > 0 {
+		w.Header().Set("Idempotent-Replayed", v[0])
+	}
+
+	code := http.StatusOK
+	if responseError(res) != "" {
+		code = http.StatusBadRequest
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	m := jsonpb.Marshaler{OrigName: true, EmitDefaults: true}
+	err = m.Marshal(w, res)
+	if err != nil {
+		level.Error(s.logger).Log("method", r.GRPCMethod, "request", logging.RequestID(ctx), "err", err)
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	if req.Method == http.MethodGet && req.URL.Path == SpecPath {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(s.spec)
+		return
+	}
+
+	for _, r := range s.routes {
+		vars, ok := r.match(req.Method, req.URL.Path)
+		if ok {
+			s.serve(w, req, r, vars)
+			return
+		}
+	}
+
+	http.NotFound(w, req)
+}
+
+// NewServer returns a Server translating the requests of routes to gRPC calls on conn, the routes
+// are served by every version derived from theirs as well
+func NewServer(conn Invoker, routes []Route, versions []Version, info Info, logger log.Logger) (*Server, error) {
+	spec, err := json.Marshal(NewSpec(routes, versions, info))
+	if err != nil {
+		return nil, err
+	}
+
+	routes, deprecated := Versioned(routes, versions)
+	return &Server{
+		conn:       conn,
+		routes:     routes,
+		deprecated: deprecated,
+		spec:       spec,
+		logger:     logger,
+	}, nil
+}
@@ -0,0 +1,372 @@
+package gateway_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/golang/protobuf/proto"
+	adminPB "github.com/kontainerooo/kontainer.ooo/pkg/admin/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/gateway"
+	"github.com/kontainerooo/kontainer.ooo/pkg/idempotency"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	kmiPB "github.com/kontainerooo/kontainer.ooo/pkg/kmi/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/login"
+	userPB "github.com/kontainerooo/kontainer.ooo/pkg/user/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockInvoker struct {
+	method string
+	args   interface{}
+	md     metadata.MD
+	reply  proto.Message
+	err    error
+}
+
+func (m *mockInvoker) Invoke(ctx oldcontext.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	m.method = method
+	m.args = args
+	m.md, _ = metadata.FromOutgoingContext(ctx)
+	if m.reply != nil {
+		proto.Merge(reply.(proto.Message), m.reply)
+	}
+	return m.err
+}
+
+var variables = regexp.MustCompile("{[^}]*}")
+
+func request(s http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	return w
+}
+
+var _ = Describe("Gateway", func() {
+	info := gateway.Info{
+		Title:   "kontainerooo",
+		Version: "v1",
+	}
+
+	Describe("Routes", func() {
+		It("Should dispatch every route to its gRPC method", func() {
+			inv := &mockInvoker{}
+			s, err := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+			Ω(err).ShouldNot(HaveOccurred())
+
+			for _, r := range gateway.Routes {
+				path := variables.ReplaceAllString(r.Path, "1")
+				w := request(s, r.Method, path, "")
+				Expect(w.Code).To(Equal(http.StatusOK), r.Path)
+				Expect(inv.method).To(Equal(r.GRPCMethod), r.Path)
+			}
+		})
+	})
+
+	Describe("Server", func() {
+		It("Should set request fields from path variables and the query", func() {
+			inv := &mockInvoker{
+				reply: &userPB.GetUserResponse{
+					User: &userPB.User{
+						Username: "user",
+					},
+				},
+			}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "GET", "/v1/users/7?ID=8", "")
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(inv.args.(*userPB.GetUserRequest).ID).To(BeEquivalentTo(7))
+			Expect(w.Body.String()).To(ContainSubstring(`"username":"user"`))
+		})
+
+		It("Should set fields of nested messages from the query", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "GET", "/v1/kmi?page.limit=5&page.sort=-name&page.filter.type=1&page.unknown=1", "")
+			Expect(w.Code).To(Equal(http.StatusOK))
+			page := inv.args.(*kmiPB.KMIRequest).Page
+			Expect(page.Limit).To(BeEquivalentTo(5))
+			Expect(page.Sort).To(Equal("-name"))
+			Expect(page.Filter).To(Equal(map[string]string{"type": "1"}))
+
+			w = request(s, "GET", "/v1/kmi?page.limit=many", "")
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("Should merge path variables into the body", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "PUT", "/v1/users/7/username", `{"ID": 8, "username": "new"}`)
+			Expect(w.Code).To(Equal(http.StatusOK))
+			req := inv.args.(*userPB.ChangeUsernameRequest)
+			Expect(req.ID).To(BeEquivalentTo(7))
+			Expect(req.Username).To(Equal("new"))
+		})
+
+		It("Should merge path variables into nested messages of the body", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "PUT", "/v1/admin/flags/nftables", `{"flag": {"percentage": 10}}`)
+			Expect(w.Code).To(Equal(http.StatusOK))
+			req := inv.args.(*adminPB.SetFlagRequest)
+			Expect(req.Flag.Name).To(Equal("nftables"))
+			Expect(req.Flag.Percentage).To(BeEquivalentTo(10))
+		})
+
+		It("Should forward the authorization header", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			request(s, "DELETE", "/v1/users/7", "")
+			Expect(inv.md["authorization"]).To(Equal([]string{"Bearer token"}))
+		})
+
+		It("Should forward the idempotency key", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			req := httptest.NewRequest("DELETE", "/v1/users/7", nil)
+			req.Header.Set("Idempotency-Key", "retry-1")
+			s.ServeHTTP(httptest.NewRecorder(), req)
+			Expect(inv.md[idempotency.Header]).To(Equal([]string{"retry-1"}))
+		})
+
+		It("Should forward the impersonation session", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			req := httptest.NewRequest("DELETE", "/v1/users/7", nil)
+			req.Header.Set("X-Impersonate", "3")
+			s.ServeHTTP(httptest.NewRecorder(), req)
+			Expect(inv.md[impersonation.MetadataKey]).To(Equal([]string{"3"}))
+		})
+
+		It("Should forward the address and user agent of the client", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			req := httptest.NewRequest("POST", "/v1/auth", strings.NewReader(`{"username": "alice"}`))
+			req.RemoteAddr = "192.0.2.10:51234"
+			req.Header.Set("User-Agent", "Mozilla/5.0")
+			s.ServeHTTP(httptest.NewRecorder(), req)
+			Expect(inv.md[login.ClientAddrHeader]).To(Equal([]string{"192.0.2.10:51234"}))
+			Expect(inv.md[login.ClientAgentHeader]).To(Equal([]string{"Mozilla/5.0"}))
+		})
+
+		It("Should reject malformed requests", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "GET", "/v1/users/abc", "")
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+
+			w = request(s, "POST", "/v1/users", "{")
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(inv.method).To(BeEmpty())
+		})
+
+		It("Should reject bodies exceeding the size", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+			s.MaxBodySize = 32
+
+			w := request(s, "POST", "/v1/users", `{"username": "user", "config": {"email": "user@kontainer.ooo"}}`)
+			Expect(w.Code).To(Equal(http.StatusRequestEntityTooLarge))
+			Expect(inv.method).To(BeEmpty())
+
+			w = request(s, "POST", "/v1/users", `{"username": "user"}`)
+			Expect(w.Code).To(Equal(http.StatusOK))
+		})
+
+		It("Should return 404 for unknown routes", func() {
+			s, _ := gateway.NewServer(&mockInvoker{}, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "GET", "/v1/unknown", "")
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+
+			w = request(s, "PATCH", "/v1/users/7", "")
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("Should map gRPC errors to status codes", func() {
+			inv := &mockInvoker{
+				err: grpc.Errorf(codes.PermissionDenied, "not allowed"),
+			}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "GET", "/v1/users/7", "")
+			Expect(w.Code).To(Equal(http.StatusForbidden))
+			Expect(w.Body.String()).To(ContainSubstring("not allowed"))
+
+			inv.err = grpc.Errorf(codes.Unauthenticated, "no token present")
+			w = request(s, "GET", "/v1/users/7", "")
+			Expect(w.Code).To(Equal(http.StatusUnauthorized))
+
+			inv.err = grpc.Errorf(codes.ResourceExhausted, "rate limit exceeded")
+			w = request(s, "GET", "/v1/users/7", "")
+			Expect(w.Code).To(Equal(http.StatusTooManyRequests))
+
+			inv.err = grpc.Errorf(codes.Aborted, "a request with this idempotency key is in progress")
+			w = request(s, "GET", "/v1/users/7", "")
+			Expect(w.Code).To(Equal(http.StatusConflict))
+		})
+
+		It("Should return 400 if the response contains an error", func() {
+			inv := &mockInvoker{
+				reply: &userPB.DeleteUserResponse{
+					Error: "user does not exist",
+				},
+			}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "DELETE", "/v1/users/7", "")
+			Expect(w.Code).To(Equal(http.StatusBadRequest))
+			Expect(w.Body.String()).To(ContainSubstring("user does not exist"))
+		})
+	})
+
+	Describe("Versions", func() {
+		It("Should serve the routes of the base version", func() {
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "GET", "/v2/users/7", "")
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(inv.method).To(Equal("/user.UserService/GetUser"))
+			Expect(inv.args.(*userPB.GetUserRequest).ID).To(BeEquivalentTo(7))
+			Expect(w.Header().Get("Deprecation")).To(BeEmpty())
+		})
+
+		It("Should not serve removed routes", func() {
+			s, _ := gateway.NewServer(&mockInvoker{}, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "POST", "/v2/users/credentials", "")
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+
+			w = request(s, "POST", "/v1/users/credentials", "")
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Header().Get("Link")).To(Equal(`</v2/auth>; rel="successor-version"`))
+		})
+
+		It("Should prefer the routes of a version over the ones of its base", func() {
+			routes := []gateway.Route{
+				{"GET", "/v1/users/{ID}", "/user.UserService/GetUser", &userPB.GetUserRequest{}, &userPB.GetUserResponse{}, "Get a user"},
+				{"GET", "/v2/users/{ID}", "/admin.AdminService/Users", &adminPB.UsersRequest{}, &adminPB.UsersResponse{}, "Get a user"},
+			}
+			versions := []gateway.Version{
+				{Name: "v1"},
+				{Name: "v2", Base: "v1"},
+			}
+			inv := &mockInvoker{}
+			s, _ := gateway.NewServer(inv, routes, versions, info, log.NewNopLogger())
+
+			request(s, "GET", "/v2/users/7", "")
+			Expect(inv.method).To(Equal("/admin.AdminService/Users"))
+		})
+
+		It("Should announce the deprecation of a version", func() {
+			sunset := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+			versions := []gateway.Version{
+				{Name: "v1", Deprecation: &versioning.Deprecation{Sunset: sunset, Successor: "v2"}},
+				{Name: "v2", Base: "v1"},
+			}
+			s, _ := gateway.NewServer(&mockInvoker{}, gateway.Routes, versions, info, log.NewNopLogger())
+
+			w := request(s, "GET", "/v1/users/7", "")
+			Expect(w.Code).To(Equal(http.StatusOK))
+			Expect(w.Header().Get("Deprecation")).To(Equal("true"))
+			Expect(w.Header().Get("Sunset")).To(Equal("Tue, 01 Jan 2030 00:00:00 GMT"))
+			Expect(w.Header().Get("Link")).To(Equal(`</v2/users/7>; rel="successor-version"`))
+		})
+	})
+
+	Describe("Spec", func() {
+		It("Should describe every route", func() {
+			spec := gateway.NewSpec(gateway.Routes, gateway.Versions, info)
+			Expect(spec.Swagger).To(Equal("2.0"))
+
+			for _, r := range gateway.Routes {
+				op, ok := spec.Paths[r.Path][strings.ToLower(r.Method)]
+				Expect(ok).To(BeTrue(), r.Path)
+				Expect(op.Responses).To(HaveKey("200"))
+			}
+		})
+
+		It("Should describe path and query parameters", func() {
+			spec := gateway.NewSpec(gateway.Routes, gateway.Versions, info)
+
+			op := spec.Paths["/v1/users/{RefID}/usage"]["get"]
+			names := []string{}
+			for _, p := range op.Parameters {
+				names = append(names, p.In+":"+p.Name)
+			}
+			Expect(names).To(Equal([]string{"path:RefID", "query:From", "query:To"}))
+			Expect(op.Security).ToNot(BeEmpty())
+
+			op = spec.Paths["/v1/kmi"]["get"]
+			names = []string{}
+			for _, p := range op.Parameters {
+				names = append(names, p.In+":"+p.Name)
+			}
+			Expect(names).To(Equal([]string{"query:page.cursor", "query:page.limit", "query:page.sort"}))
+
+			op = spec.Paths["/v1/auth"]["post"]
+			Expect(op.Parameters[0].In).To(Equal("body"))
+			Expect(op.Security).To(BeEmpty())
+		})
+
+		It("Should mark the routes of deprecated versions", func() {
+			spec := gateway.NewSpec(gateway.Routes, gateway.Versions, info)
+
+			Expect(spec.Paths["/v1/users/{ID}"]["get"].Deprecated).To(BeTrue())
+			Expect(spec.Paths["/v2/users/{ID}"]["get"].Deprecated).To(BeFalse())
+			Expect(spec.Paths).NotTo(HaveKey("/v2/users/credentials"))
+
+			ids := make(map[string]bool)
+			for _, ops := range spec.Paths {
+				for _, op := range ops {
+					Expect(ids).NotTo(HaveKey(op.OperationID))
+					ids[op.OperationID] = true
+				}
+			}
+		})
+
+		It("Should define the messages", func() {
+			spec := gateway.NewSpec(gateway.Routes, gateway.Versions, info)
+
+			def, ok := spec.Definitions["user.GetUserResponse"]
+			Expect(ok).To(BeTrue())
+			Expect(def.Properties["user"].Ref).To(Equal("#/definitions/user.User"))
+			Expect(spec.Definitions).To(HaveKey("user.User"))
+		})
+
+		It("Should be served", func() {
+			s, _ := gateway.NewServer(&mockInvoker{}, gateway.Routes, gateway.Versions, info, log.NewNopLogger())
+
+			w := request(s, "GET", gateway.SpecPath, "")
+			Expect(w.Code).To(Equal(http.StatusOK))
+
+			spec := gateway.Spec{}
+			err := json.Unmarshal(w.Body.Bytes(), &spec)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(spec.Info.Title).To(Equal("kontainerooo"))
+		})
+	})
+})
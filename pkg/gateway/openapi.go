@@ -0,0 +1,281 @@
+package gateway
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Info describes the API in the OpenAPI specification
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Schema is an OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// Parameter is an OpenAPI parameter object
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Type     string  `json:"type,omitempty"`
+	Format   string  `json:"format,omitempty"`
+	Schema   *Schema `json:"schema,omitempty"`
+}
+
+// Response is an OpenAPI response object
+type Response struct {
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// Operation is an OpenAPI operation object
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+// SecurityScheme is an OpenAPI security scheme object
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+}
+
+// Spec is an OpenAPI 2.0 specification
+type Spec struct {
+	Swagger             string                          `json:"swagger"`
+	Info                Info                            `json:"info"`
+	Consumes            []string                        `json:"consumes"`
+	Produces            []string                        `json:"produces"`
+	SecurityDefinitions map[string]SecurityScheme       `json:"securityDefinitions"`
+	Paths               map[string]map[string]Operation `json:"paths"`
+	Definitions         map[string]*Schema              `json:"definitions"`
+}
+
+var (
+	messageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+	stringer    = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// schema returns the schema of a message field type, messages are added to the definitions of the spec
+func (s *Spec) schema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		if !t.Implements(messageType) {
+			return s.schema(t.Elem())
+		}
+
+		name := proto.MessageName(reflect.New(t.Elem()).Interface().(proto.Message))
+		if _, ok := s.Definitions[name]; !ok {
+			def := &Schema{
+				Type:       "object",
+				Properties: make(map[string]*Schema),
+			}
+			s.Definitions[name] = def
+
+			for n, f := range fields(t) {
+				def.Properties[n] = s.schema(f.Type)
+			}
+		}
+		return &Schema{Ref: "#/definitions/" + name}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int32:
+		// enums are encoded by their names
+		if t.Implements(stringer) {
+			return &Schema{Type: "string"}
+		}
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int64:
+		return &Schema{Type: "string", Format: "int64"}
+	case reflect.Uint64:
+		return &Schema{Type: "string", Format: "uint64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	}
+	return &Schema{Type: "object"}
+}
+
+// parameters returns the path and query parameters of a route, or its body parameter
+func (s *Spec) parameters(r Route) []Parameter {
+	fs := fields(reflect.TypeOf(r.Request))
+	params := []Parameter{}
+
+	inPath := make(map[string]bool)
+	for _, seg := range segments(r.Path) {
+		name, ok := variable(seg)
+		if !ok {
+			continue
+		}
+		inPath[name] = true
+
+		f, _ := field(reflect.TypeOf(r.Request), name)
+		sc := s.schema(f.Type)
+		params = append(params, Parameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Type:     sc.Type,
+			Format:   sc.Format,
+		})
+	}
+
+	if r.Method != "GET" {
+		return append(params, Parameter{
+			Name:     "body",
+			In:       "body",
+			Required: true,
+			Schema:   s.schema(reflect.TypeOf(r.Request)),
+		})
+	}
+
+	names := []string{}
+	for n := range fs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		if inPath[n] {
+			continue
+		}
+		params = append(params, s.queryParameters(n, fs[n].Type)...)
+	}
+	return params
+}
+
+// queryParameters returns the query parameters of a field, the scalar fields of nested messages
+// are named with dots, e.g. page.limit
+func (s *Spec) queryParameters(name string, t reflect.Type) []Parameter {
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		fs := fields(t)
+		names := []string{}
+		for n := range fs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		params := []Parameter{}
+		for _, n := range names {
+			params = append(params, s.queryParameters(name+"."+n, fs[n].Type)...)
+		}
+		return params
+	}
+
+	sc := s.schema(t)
+	if sc.Ref != "" || sc.Type == "object" || sc.Type == "array" {
+		return nil
+	}
+	return []Parameter{{
+		Name:   name,
+		In:     "query",
+		Type:   sc.Type,
+		Format: sc.Format,
+	}}
+}
+
+// NewSpec returns the OpenAPI specification of routes and the routes of versions derived from theirs
+func NewSpec(routes []Route, versions []Version, info Info) *Spec {
+	s := &Spec{
+		Swagger:  "2.0",
+		Info:     info,
+		Consumes: []string{"application/json"},
+		Produces: []string{"application/json"},
+		SecurityDefinitions: map[string]SecurityScheme{
+			"token": {
+				Type:        "apiKey",
+				Name:        "Authorization",
+				In:          "header",
+				Description: "Bearer token issued by the authentication route",
+			},
+		},
+		Paths:       make(map[string]map[string]Operation),
+		Definitions: make(map[string]*Schema),
+	}
+
+	routes, deprecated := Versioned(routes, versions)
+	ids := make(map[string]bool)
+	for _, r := range routes {
+		method := strings.TrimPrefix(r.GRPCMethod, "/")
+		service := strings.Split(method, "/")[0]
+
+		// the routes of the other versions calling the same method are told apart by their version
+		id := strings.Replace(method, "/", "_", -1)
+		if ids[id] {
+			v, _ := split(r.Path)
+			id = v + "_" + id
+		}
+		ids[id] = true
+
+		op := Operation{
+			OperationID: id,
+			Summary:     r.Summary,
+			Tags:        []string{strings.Split(service, ".")[0]},
+			Parameters:  s.parameters(r),
+			Responses: map[string]Response{
+				"200": {
+					Description: "OK",
+					Schema:      s.schema(reflect.TypeOf(r.Response)),
+				},
+				"default": {
+					Description: "Error",
+					Schema: &Schema{
+						Type: "object",
+						Properties: map[string]*Schema{
+							"error": {Type: "string"},
+						},
+					},
+				},
+			},
+		}
+
+		if _, ok := deprecated[key(r.Method, r.Path)]; ok {
+			op.Deprecated = true
+		}
+
+		if r.GRPCMethod != authenticateMethod {
+			op.Security = []map[string][]string{
+				{"token": {}},
+			}
+		}
+
+		if s.Paths[r.Path] == nil {
+			s.Paths[r.Path] = make(map[string]Operation)
+		}
+		s.Paths[r.Path][strings.ToLower(r.Method)] = op
+	}
+	return s
+}
@@ -0,0 +1,300 @@
+package gateway
+
+import (
+	adminPB "github.com/kontainerooo/kontainer.ooo/pkg/admin/pb"
+	agentPB "github.com/kontainerooo/kontainer.ooo/pkg/agent/pb"
+	alertPB "github.com/kontainerooo/kontainer.ooo/pkg/alert/pb"
+	autoscalePB "github.com/kontainerooo/kontainer.ooo/pkg/autoscale/pb"
+	banPB "github.com/kontainerooo/kontainer.ooo/pkg/ban/pb"
+	billingPB "github.com/kontainerooo/kontainer.ooo/pkg/billing/pb"
+	containerPB "github.com/kontainerooo/kontainer.ooo/pkg/container/pb"
+	containerlogPB "github.com/kontainerooo/kontainer.ooo/pkg/containerlog/pb"
+	cronjobPB "github.com/kontainerooo/kontainer.ooo/pkg/cronjob/pb"
+	databasePB "github.com/kontainerooo/kontainer.ooo/pkg/database/pb"
+	deployPB "github.com/kontainerooo/kontainer.ooo/pkg/deploy/pb"
+	dnsPB "github.com/kontainerooo/kontainer.ooo/pkg/dns/pb"
+	exportPB "github.com/kontainerooo/kontainer.ooo/pkg/export/pb"
+	firewallPB "github.com/kontainerooo/kontainer.ooo/pkg/firewall/pb"
+	ktgPB "github.com/kontainerooo/kontainer.ooo/pkg/kentheguru/pb"
+	kmiPB "github.com/kontainerooo/kontainer.ooo/pkg/kmi/pb"
+	modulePB "github.com/kontainerooo/kontainer.ooo/pkg/module/pb"
+	networkPB "github.com/kontainerooo/kontainer.ooo/pkg/network/pb"
+	portsPB "github.com/kontainerooo/kontainer.ooo/pkg/ports/pb"
+	routingPB "github.com/kontainerooo/kontainer.ooo/pkg/routing/pb"
+	sitePB "github.com/kontainerooo/kontainer.ooo/pkg/site/pb"
+	snapshotPB "github.com/kontainerooo/kontainer.ooo/pkg/snapshot/pb"
+	userPB "github.com/kontainerooo/kontainer.ooo/pkg/user/pb"
+	webhookPB "github.com/kontainerooo/kontainer.ooo/pkg/webhook/pb"
+	wireguardPB "github.com/kontainerooo/kontainer.ooo/pkg/wireguard/pb"
+)
+
+// authenticateMethod is the only gRPC method which can be called without a token
+const authenticateMethod = "/kentheguru.KenTheGuruService/Authenticate"
+
+// Routes are the routes of the public API
+var Routes = []Route{
+	// authentication
+	{"POST", "/v1/auth", "/kentheguru.KenTheGuruService/Authenticate", &ktgPB.AuthenticationRequest{}, &ktgPB.AuthenticationResponse{}, "Exchange username and password for a token"},
+
+	// daemon
+	{"POST", "/v1/configuration/reload", "/kentheguru.KenTheGuruService/ReloadConfiguration", &ktgPB.ReloadConfigurationRequest{}, &ktgPB.ReloadConfigurationResponse{}, "Reload the configuration of the daemon"},
+	{"GET", "/v1/health", "/kentheguru.KenTheGuruService/Health", &ktgPB.HealthRequest{}, &ktgPB.HealthResponse{}, "Get the health of the dependencies of the daemon"},
+	{"GET", "/v1/jobs", "/kentheguru.KenTheGuruService/Jobs", &ktgPB.JobsRequest{}, &ktgPB.JobsResponse{}, "List the background jobs of the daemon"},
+	{"POST", "/v1/jobs/{ID}/requeue", "/kentheguru.KenTheGuruService/RequeueJob", &ktgPB.RequeueJobRequest{}, &ktgPB.RequeueJobResponse{}, "Run a dead background job again"},
+
+	// admin service
+	{"GET", "/v1/admin/users", "/admin.AdminService/Users", &adminPB.UsersRequest{}, &adminPB.UsersResponse{}, "List all users with their resource usage"},
+	{"GET", "/v1/admin/containers", "/admin.AdminService/Containers", &adminPB.ContainersRequest{}, &adminPB.ContainersResponse{}, "List the containers of all users per node"},
+	{"GET", "/v1/admin/errors", "/admin.AdminService/Errors", &adminPB.ErrorsRequest{}, &adminPB.ErrorsResponse{}, "List the recent errors of the daemon"},
+	{"GET", "/v1/admin/queue", "/admin.AdminService/QueueDepth", &adminPB.QueueDepthRequest{}, &adminPB.QueueDepthResponse{}, "Count the background jobs per state"},
+	{"POST", "/v1/admin/containers/{containerID}/stop", "/admin.AdminService/StopContainer", &adminPB.StopContainerRequest{}, &adminPB.StopContainerResponse{}, "Force a container of any user to stop"},
+	{"POST", "/v1/admin/containers/{containerID}/reassign", "/admin.AdminService/ReassignNode", &adminPB.ReassignNodeRequest{}, &adminPB.ReassignNodeResponse{}, "Move an instance to another node"},
+	{"POST", "/v1/admin/nodes/{node}/drain", "/admin.AdminService/DrainNode", &adminPB.DrainNodeRequest{}, &adminPB.DrainNodeResponse{}, "Move or stop every instance of a node, notify their owners and stop placing instances on it"},
+	{"DELETE", "/v1/admin/nodes/{node}/drain", "/admin.AdminService/UndrainNode", &adminPB.UndrainNodeRequest{}, &adminPB.UndrainNodeResponse{}, "Place instances on a drained node again"},
+	{"POST", "/v1/admin/nodes/{node}/cordon", "/admin.AdminService/CordonNode", &adminPB.CordonNodeRequest{}, &adminPB.CordonNodeResponse{}, "Stop placing instances on a node, its instances keep running"},
+	{"PUT", "/v1/admin/nodes/{node}/labels", "/admin.AdminService/LabelNode", &adminPB.LabelNodeRequest{}, &adminPB.LabelNodeResponse{}, "Set the labels of a node, empty values remove labels"},
+	{"DELETE", "/v1/admin/nodes/{node}/cordon", "/admin.AdminService/UncordonNode", &adminPB.UncordonNodeRequest{}, &adminPB.UncordonNodeResponse{}, "Place instances on a cordoned node again"},
+	{"GET", "/v1/admin/flags", "/admin.AdminService/Flags", &adminPB.FlagsRequest{}, &adminPB.FlagsResponse{}, "List the feature flags"},
+	{"PUT", "/v1/admin/flags/{flag.name}", "/admin.AdminService/SetFlag", &adminPB.SetFlagRequest{}, &adminPB.SetFlagResponse{}, "Turn a feature on for all, some or no users"},
+	{"DELETE", "/v1/admin/flags/{name}", "/admin.AdminService/RemoveFlag", &adminPB.RemoveFlagRequest{}, &adminPB.RemoveFlagResponse{}, "Remove a feature flag"},
+	{"GET", "/v1/admin/maintenance", "/admin.AdminService/Maintenance", &adminPB.MaintenanceRequest{}, &adminPB.MaintenanceResponse{}, "List the upcoming and active maintenance windows"},
+	{"POST", "/v1/admin/maintenance", "/admin.AdminService/ScheduleMaintenance", &adminPB.ScheduleMaintenanceRequest{}, &adminPB.ScheduleMaintenanceResponse{}, "Schedule a maintenance window in which only reading calls are accepted"},
+	{"DELETE", "/v1/admin/maintenance/{ID}", "/admin.AdminService/CancelMaintenance", &adminPB.CancelMaintenanceRequest{}, &adminPB.CancelMaintenanceResponse{}, "Cancel a maintenance window"},
+	{"POST", "/v1/admin/impersonations", "/admin.AdminService/Impersonate", &adminPB.ImpersonateRequest{}, &adminPB.ImpersonateResponse{}, "Start a session in which an admin acts as a user, the user is notified"},
+	{"DELETE", "/v1/admin/impersonations/{ID}", "/admin.AdminService/StopImpersonation", &adminPB.StopImpersonationRequest{}, &adminPB.StopImpersonationResponse{}, "End an impersonation session"},
+	{"GET", "/v1/admin/impersonations", "/admin.AdminService/Impersonations", &adminPB.ImpersonationsRequest{}, &adminPB.ImpersonationsResponse{}, "List the impersonation sessions which did not end yet"},
+	{"GET", "/v1/admin/impersonations/{ID}/trail", "/admin.AdminService/ImpersonationTrail", &adminPB.ImpersonationTrailRequest{}, &adminPB.ImpersonationTrailResponse{}, "List the calls made in an impersonation session, 0 lists those of every session"},
+
+	// user service
+	{"POST", "/v1/users", "/user.UserService/CreateUser", &userPB.CreateUserRequest{}, &userPB.CreateUserResponse{}, "Create a user"},
+	{"POST", "/v1/users/credentials", "/user.UserService/CheckLoginCredentials", &userPB.CheckLoginCredentialsRequest{}, &userPB.CheckLoginCredentialsResponse{}, "Check the credentials of a user"},
+	{"POST", "/v1/users/password-reset", "/user.UserService/ResetPassword", &userPB.ResetPasswordRequest{}, &userPB.ResetPasswordResponse{}, "Reset the password of a user"},
+	{"GET", "/v1/users/{ID}", "/user.UserService/GetUser", &userPB.GetUserRequest{}, &userPB.GetUserResponse{}, "Get a user"},
+	{"PUT", "/v1/users/{ID}", "/user.UserService/EditUser", &userPB.EditUserRequest{}, &userPB.EditUserResponse{}, "Edit the configuration of a user"},
+	{"PUT", "/v1/users/{ID}/username", "/user.UserService/ChangeUsername", &userPB.ChangeUsernameRequest{}, &userPB.ChangeUsernameResponse{}, "Change the username of a user"},
+	{"DELETE", "/v1/users/{ID}", "/user.UserService/DeleteUser", &userPB.DeleteUserRequest{}, &userPB.DeleteUserResponse{}, "Delete a user"},
+
+	// kmi service
+	{"GET", "/v1/kmi", "/kmi.KMIService/KMI", &kmiPB.KMIRequest{}, &kmiPB.KMIResponse{}, "List all kontainer module images"},
+	{"POST", "/v1/kmi", "/kmi.KMIService/AddKMI", &kmiPB.AddKMIRequest{}, &kmiPB.AddKMIResponse{}, "Add a kontainer module image"},
+	{"GET", "/v1/kmi/ui", "/kmi.KMIService/UIs", &kmiPB.UIsRequest{}, &kmiPB.UIsResponse{}, "List the frontend UIs of the modules and the paths of their entries"},
+	{"GET", "/v1/kmi/{ID}", "/kmi.KMIService/GetKMI", &kmiPB.GetKMIRequest{}, &kmiPB.GetKMIResponse{}, "Get a kontainer module image"},
+	{"DELETE", "/v1/kmi/{ID}", "/kmi.KMIService/RemoveKMI", &kmiPB.RemoveKMIRequest{}, &kmiPB.RemoveKMIResponse{}, "Remove a kontainer module image"},
+
+	// container service
+	{"GET", "/v1/users/{refID}/containers", "/container.ContainerService/Instances", &containerPB.InstancesRequest{}, &containerPB.InstancesResponse{}, "List the containers of a user"},
+	{"POST", "/v1/users/{refID}/containers", "/container.ContainerService/CreateContainer", &containerPB.CreateContainerRequest{}, &containerPB.CreateContainerResponse{}, "Create a container"},
+	{"DELETE", "/v1/users/{refID}/containers/{ID}", "/container.ContainerService/RemoveContainer", &containerPB.RemoveContainerRequest{}, &containerPB.RemoveContainerResponse{}, "Remove a container"},
+	{"POST", "/v1/users/{refID}/containers/{ID}/stop", "/container.ContainerService/StopContainer", &containerPB.StopContainerRequest{}, &containerPB.StopContainerResponse{}, "Stop a container"},
+	{"POST", "/v1/users/{refID}/containers/{ID}/exec", "/container.ContainerService/Execute", &containerPB.ExecuteRequest{}, &containerPB.ExecuteResponse{}, "Execute a command in a container"},
+	{"PUT", "/v1/users/{refID}/containers/{ID}/replicas", "/container.ContainerService/ScaleInstance", &containerPB.ScaleInstanceRequest{}, &containerPB.ScaleInstanceResponse{}, "Scale a container"},
+	{"POST", "/v1/users/{refID}/containers/{ID}/clone", "/container.ContainerService/CloneInstance", &containerPB.CloneInstanceRequest{}, &containerPB.CloneInstanceResponse{}, "Clone a container into a staging copy"},
+	{"PUT", "/v1/users/{refID}/containers/{ID}/pin", "/container.ContainerService/PinInstance", &containerPB.PinInstanceRequest{}, &containerPB.PinInstanceResponse{}, "Pin a container to its node or unpin it"},
+	{"PUT", "/v1/users/{refID}/containers/{ID}/placement", "/container.ContainerService/SetPlacement", &containerPB.SetPlacementRequest{}, &containerPB.SetPlacementResponse{}, "Constrain the nodes a container is placed on"},
+	{"POST", "/v1/users/{refID}/containers/{ID}/export", "/container.ContainerService/ExportInstance", &containerPB.ExportInstanceRequest{}, &containerPB.ExportInstanceResponse{}, "Export the volume of a container and get a signed URL the archive can be downloaded from until it expires"},
+	{"GET", "/v1/users/{refID}/containers/{ID}/checkpoints", "/container.ContainerService/Checkpoints", &containerPB.CheckpointsRequest{}, &containerPB.CheckpointsResponse{}, "List the checkpoints of a container"},
+	{"POST", "/v1/users/{refID}/containers/{ID}/checkpoints", "/container.ContainerService/CheckpointInstance", &containerPB.CheckpointInstanceRequest{}, &containerPB.CheckpointInstanceResponse{}, "Checkpoint a container"},
+	{"POST", "/v1/users/{refID}/containers/{ID}/checkpoints/{name}/restore", "/container.ContainerService/RestoreInstance", &containerPB.RestoreInstanceRequest{}, &containerPB.RestoreInstanceResponse{}, "Restore a container from a checkpoint"},
+	{"DELETE", "/v1/users/{refID}/containers/{ID}/checkpoints/{name}", "/container.ContainerService/RemoveCheckpoint", &containerPB.RemoveCheckpointRequest{}, &containerPB.RemoveCheckpointResponse{}, "Remove a checkpoint of a container"},
+	{"GET", "/v1/users/{refID}/containers/{ID}/env/{key}", "/container.ContainerService/GetEnv", &containerPB.GetEnvRequest{}, &containerPB.GetEnvResponse{}, "Get an environment variable of a container"},
+	{"PUT", "/v1/users/{refID}/containers/{ID}/env/{key}", "/container.ContainerService/SetEnv", &containerPB.SetEnvRequest{}, &containerPB.SetEnvResponse{}, "Set an environment variable of a container"},
+	{"GET", "/v1/users/{refID}/containers/{containerID}/links", "/container.ContainerService/GetLinks", &containerPB.GetLinksRequest{}, &containerPB.GetLinksResponse{}, "List the links of a container"},
+	{"PUT", "/v1/users/{refID}/containers/{containerID}/links", "/container.ContainerService/SetLink", &containerPB.SetLinkRequest{}, &containerPB.SetLinkResponse{}, "Link a container to another one"},
+	{"DELETE", "/v1/users/{refID}/containers/{containerID}/links", "/container.ContainerService/RemoveLink", &containerPB.RemoveLinkRequest{}, &containerPB.RemoveLinkResponse{}, "Remove a link of a container"},
+	{"GET", "/v1/users/{refID}/container-ids/{name}", "/container.ContainerService/IDForName", &containerPB.IDForNameRequest{}, &containerPB.IDForNameResponse{}, "Get the id of a container by its name"},
+	{"GET", "/v1/containers/{containerID}/kmi", "/container.ContainerService/GetContainerKMI", &containerPB.GetContainerKMIRequest{}, &containerPB.GetContainerKMIResponse{}, "Get the kontainer module image of a container"},
+
+	// module service
+	{"GET", "/v1/users/{refID}/modules", "/module.ModuleService/GetModules", &modulePB.GetModulesRequest{}, &modulePB.GetModulesResponse{}, "List the modules of a user"},
+	{"POST", "/v1/users/{refID}/modules", "/module.ModuleService/CreateContainerModule", &modulePB.CreateContainerModuleRequest{}, &modulePB.CreateContainerModuleResponse{}, "Install a module"},
+	{"GET", "/v1/users/{refID}/modules/{containerName}/config", "/module.ModuleService/GetModuleConfig", &modulePB.GetModuleConfigRequest{}, &modulePB.GetModuleConfigResponse{}, "Get the configuration of a module"},
+	{"PUT", "/v1/users/{refID}/modules/{containerName}/public-key", "/module.ModuleService/SetPublicKey", &modulePB.SetPublicKeyRequest{}, &modulePB.SetPublicKeyResponse{}, "Set the public key of a module"},
+	{"GET", "/v1/users/{refID}/modules/{containerName}/files", "/module.ModuleService/GetFiles", &modulePB.GetFilesRequest{}, &modulePB.GetFilesResponse{}, "List the files of a module"},
+	{"GET", "/v1/users/{refID}/modules/{containerName}/file", "/module.ModuleService/GetFile", &modulePB.GetFileRequest{}, &modulePB.GetFileResponse{}, "Download a file of a module"},
+	{"GET", "/v1/users/{refID}/modules/{containerName}/browse", "/module.ModuleService/ListDirectory", &modulePB.ListDirectoryRequest{}, &modulePB.ListDirectoryResponse{}, "Browse a directory of a module"},
+	{"GET", "/v1/users/{refID}/modules/{containerName}/stat", "/module.ModuleService/StatFile", &modulePB.StatFileRequest{}, &modulePB.StatFileResponse{}, "Describe a file of a module"},
+	{"GET", "/v1/users/{refID}/modules/{containerName}/read", "/module.ModuleService/ReadFile", &modulePB.ReadFileRequest{}, &modulePB.ReadFileResponse{}, "Read a small file of a module"},
+	{"PUT", "/v1/users/{refID}/modules/{containerName}/file", "/module.ModuleService/UploadFile", &modulePB.UploadFileRequest{}, &modulePB.UploadFileResponse{}, "Upload a file to a module"},
+	{"DELETE", "/v1/users/{refID}/modules/{containerName}/file", "/module.ModuleService/RemoveFile", &modulePB.RemoveFileRequest{}, &modulePB.RemoveFileResponse{}, "Remove a file of a module"},
+	{"DELETE", "/v1/users/{refID}/modules/{containerName}/directory", "/module.ModuleService/RemoveDirectory", &modulePB.RemoveDirectoryRequest{}, &modulePB.RemoveDirectoryResponse{}, "Remove a directory of a module"},
+	{"POST", "/v1/users/{refID}/modules/{containerName}/commands", "/module.ModuleService/SendCommand", &modulePB.SendCommandRequest{}, &modulePB.SendCommandResponse{}, "Send a command to a module"},
+	{"GET", "/v1/users/{refID}/modules/{containerName}/env/{key}", "/module.ModuleService/GetEnv", &modulePB.GetEnvRequest{}, &modulePB.GetEnvResponse{}, "Get an environment variable of a module"},
+	{"PUT", "/v1/users/{refID}/modules/{containerName}/env/{key}", "/module.ModuleService/SetEnv", &modulePB.SetEnvRequest{}, &modulePB.SetEnvResponse{}, "Set an environment variable of a module"},
+	{"PUT", "/v1/users/{refID}/modules/{containerName}/links", "/module.ModuleService/SetLink", &modulePB.SetLinkRequest{}, &modulePB.SetLinkResponse{}, "Link a module to another one"},
+	{"DELETE", "/v1/users/{refID}/modules/{containerName}/links", "/module.ModuleService/RemoveLink", &modulePB.RemoveLinkRequest{}, &modulePB.RemoveLinkResponse{}, "Remove a link of a module"},
+
+	// routing service
+	{"GET", "/v1/users/{refID}/routing/{name}", "/routing.RoutingService/GetConfig", &routingPB.GetConfigRequest{}, &routingPB.GetConfigResponse{}, "Get a router configuration"},
+	{"PUT", "/v1/users/{refID}/routing/{name}", "/routing.RoutingService/EditConfig", &routingPB.EditConfigRequest{}, &routingPB.EditConfigResponse{}, "Edit a router configuration"},
+	{"DELETE", "/v1/users/{refID}/routing/{name}", "/routing.RoutingService/RemoveConfig", &routingPB.RemoveConfigRequest{}, &routingPB.RemoveConfigResponse{}, "Remove a router configuration"},
+	{"PUT", "/v1/users/{refID}/routing/{name}/listen", "/routing.RoutingService/ChangeListenStatement", &routingPB.ChangeListenStatementRequest{}, &routingPB.ChangeListenStatementResponse{}, "Change the listen statement of a router configuration"},
+	{"PUT", "/v1/users/{refID}/routing/{name}/https", "/routing.RoutingService/SetHTTPSPolicy", &routingPB.SetHTTPSPolicyRequest{}, &routingPB.SetHTTPSPolicyResponse{}, "Set the https redirect and HSTS policy of a router configuration"},
+	{"PUT", "/v1/users/{refID}/routing/{name}/error-pages/{code}", "/routing.RoutingService/SetErrorPage", &routingPB.SetErrorPageRequest{}, &routingPB.SetErrorPageResponse{}, "Set the custom error page of a router configuration for a status code"},
+	{"PUT", "/v1/users/{refID}/routing/{name}/maintenance", "/routing.RoutingService/SetMaintenance", &routingPB.SetMaintenanceRequest{}, &routingPB.SetMaintenanceResponse{}, "Put the instance of a router configuration into maintenance or out of it"},
+	{"POST", "/v1/users/{refID}/routing/{name}/locations", "/routing.RoutingService/AddLocation", &routingPB.AddLocationRequest{}, &routingPB.AddLocationResponse{}, "Add a location to a router configuration"},
+	{"PUT", "/v1/users/{refID}/routing/{name}/locations/{id}/limits", "/routing.RoutingService/SetProxyLimits", &routingPB.SetProxyLimitsRequest{}, &routingPB.SetProxyLimitsResponse{}, "Set the client max body size and timeouts of a location within the limits of the plan"},
+	{"DELETE", "/v1/users/{refID}/routing/{name}/locations/{id}", "/routing.RoutingService/RemoveLocation", &routingPB.RemoveLocationRequest{}, &routingPB.RemoveLocationResponse{}, "Remove a location of a router configuration"},
+	{"POST", "/v1/users/{refID}/routing/{name}/server-names", "/routing.RoutingService/AddServerName", &routingPB.AddServerNameRequest{}, &routingPB.AddServerNameResponse{}, "Add a server name to a router configuration"},
+	{"DELETE", "/v1/users/{refID}/routing/{name}/server-names/{id}", "/routing.RoutingService/RemoveServerName", &routingPB.RemoveServerNameRequest{}, &routingPB.RemoveServerNameResponse{}, "Remove a server name of a router configuration"},
+	{"PUT", "/v1/users/{refID}/routing/{name}/upstreams", "/routing.RoutingService/SetUpstream", &routingPB.SetUpstreamRequest{}, &routingPB.SetUpstreamResponse{}, "Set an upstream of a router configuration"},
+	{"DELETE", "/v1/users/{refID}/routing/{name}/upstreams/{upstream}", "/routing.RoutingService/RemoveUpstream", &routingPB.RemoveUpstreamRequest{}, &routingPB.RemoveUpstreamResponse{}, "Remove an upstream of a router configuration"},
+	{"POST", "/v1/users/{refID}/routing/{name}/upstreams/{upstream}/members", "/routing.RoutingService/AddUpstreamMember", &routingPB.AddUpstreamMemberRequest{}, &routingPB.AddUpstreamMemberResponse{}, "Add a member to an upstream"},
+	{"DELETE", "/v1/users/{refID}/routing/{name}/upstreams/{upstream}/members/{address}", "/routing.RoutingService/RemoveUpstreamMember", &routingPB.RemoveUpstreamMemberRequest{}, &routingPB.RemoveUpstreamMemberResponse{}, "Remove a member of an upstream"},
+	{"PUT", "/v1/users/{refID}/routing/{name}/switches", "/routing.RoutingService/SetSwitch", &routingPB.SetSwitchRequest{}, &routingPB.SetSwitchResponse{}, "Point a blue/green switch of a router configuration at an upstream and set its canary percentage"},
+	{"DELETE", "/v1/users/{refID}/routing/{name}/switches/{switch}", "/routing.RoutingService/RemoveSwitch", &routingPB.RemoveSwitchRequest{}, &routingPB.RemoveSwitchResponse{}, "Remove a blue/green switch of a router configuration"},
+	{"GET", "/v1/users/{refID}/quota", "/routing.RoutingService/Quota", &routingPB.QuotaRequest{}, &routingPB.QuotaResponse{}, "Get the traffic and the traffic quota of a user in the current month"},
+	{"GET", "/v1/users/{refID}/routing/{name}/traffic", "/routing.RoutingService/Traffic", &routingPB.TrafficRequest{}, &routingPB.TrafficResponse{}, "Get the traffic of a router configuration"},
+
+	// dns service
+	{"GET", "/v1/users/{refID}/records", "/dns.DNSService/Records", &dnsPB.RecordsRequest{}, &dnsPB.RecordsResponse{}, "List the DNS records of a user"},
+	{"POST", "/v1/users/{refID}/records", "/dns.DNSService/CreateRecord", &dnsPB.CreateRecordRequest{}, &dnsPB.CreateRecordResponse{}, "Create a DNS record"},
+	{"DELETE", "/v1/users/{refID}/records/{ID}", "/dns.DNSService/RemoveRecord", &dnsPB.RemoveRecordRequest{}, &dnsPB.RemoveRecordResponse{}, "Remove a DNS record"},
+	{"POST", "/v1/users/{refID}/instances/{instance}/records", "/dns.DNSService/CreateInstanceRecords", &dnsPB.CreateInstanceRecordsRequest{}, &dnsPB.CreateInstanceRecordsResponse{}, "Create the DNS records of an instance"},
+	{"DELETE", "/v1/users/{refID}/instances/{instance}/records", "/dns.DNSService/RemoveInstanceRecords", &dnsPB.RemoveInstanceRecordsRequest{}, &dnsPB.RemoveInstanceRecordsResponse{}, "Remove the DNS records of an instance"},
+	{"GET", "/v1/users/{refID}/domains", "/dns.DNSService/CustomDomains", &dnsPB.CustomDomainsRequest{}, &dnsPB.CustomDomainsResponse{}, "List the custom domains of a user"},
+	{"POST", "/v1/users/{refID}/domains", "/dns.DNSService/AddCustomDomain", &dnsPB.AddCustomDomainRequest{}, &dnsPB.AddCustomDomainResponse{}, "Add a custom domain"},
+	{"DELETE", "/v1/users/{refID}/domains/{domain}", "/dns.DNSService/RemoveCustomDomain", &dnsPB.RemoveCustomDomainRequest{}, &dnsPB.RemoveCustomDomainResponse{}, "Remove a custom domain"},
+	{"POST", "/v1/users/{refID}/domains/{domain}/verify", "/dns.DNSService/VerifyCustomDomain", &dnsPB.VerifyCustomDomainRequest{}, &dnsPB.VerifyCustomDomainResponse{}, "Verify the ownership of a custom domain"},
+
+	// webhook service, the webhooks of the platform belong to the user 0
+	{"GET", "/v1/users/{refID}/webhooks", "/webhook.WebhookService/Webhooks", &webhookPB.WebhooksRequest{}, &webhookPB.WebhooksResponse{}, "List the webhooks of a user"},
+	{"POST", "/v1/users/{refID}/webhooks", "/webhook.WebhookService/CreateWebhook", &webhookPB.CreateWebhookRequest{}, &webhookPB.CreateWebhookResponse{}, "Register a webhook for events"},
+	{"DELETE", "/v1/users/{refID}/webhooks/{ID}", "/webhook.WebhookService/RemoveWebhook", &webhookPB.RemoveWebhookRequest{}, &webhookPB.RemoveWebhookResponse{}, "Remove a webhook"},
+	{"GET", "/v1/users/{refID}/webhooks/{ID}/deliveries", "/webhook.WebhookService/Deliveries", &webhookPB.DeliveriesRequest{}, &webhookPB.DeliveriesResponse{}, "List the recent deliveries of a webhook with their response codes"},
+
+	// ports service, the host ports of the users, ports used by the daemon and the router are never handed out
+	{"GET", "/v1/users/{refID}/ports", "/ports.PortService/Reservations", &portsPB.ReservationsRequest{}, &portsPB.ReservationsResponse{}, "List the host ports reserved by a user"},
+	{"POST", "/v1/users/{refID}/ports", "/ports.PortService/AllocatePort", &portsPB.AllocatePortRequest{}, &portsPB.AllocatePortResponse{}, "Reserve the lowest free host port of a range"},
+	{"PUT", "/v1/users/{refID}/ports/{port}", "/ports.PortService/ReservePort", &portsPB.ReservePortRequest{}, &portsPB.ReservePortResponse{}, "Reserve a given host port, fails if the port is in use"},
+	{"DELETE", "/v1/users/{refID}/ports/{port}", "/ports.PortService/ReleasePort", &portsPB.ReleasePortRequest{}, &portsPB.ReleasePortResponse{}, "Release a reserved host port"},
+
+	// database service, only available if managed databases are enabled
+	{"GET", "/v1/users/{refID}/databases", "/database.DatabaseService/Databases", &databasePB.DatabasesRequest{}, &databasePB.DatabasesResponse{}, "List the managed databases of a user with their credentials"},
+	{"POST", "/v1/users/{refID}/databases", "/database.DatabaseService/CreateDatabase", &databasePB.CreateDatabaseRequest{}, &databasePB.CreateDatabaseResponse{}, "Provision a MySQL or PostgreSQL database"},
+	{"DELETE", "/v1/users/{refID}/databases/{name}", "/database.DatabaseService/RemoveDatabase", &databasePB.RemoveDatabaseRequest{}, &databasePB.RemoveDatabaseResponse{}, "Drop a database, its dumps are kept"},
+	{"PUT", "/v1/users/{refID}/databases/{name}/links/{containerName}", "/database.DatabaseService/LinkDatabase", &databasePB.LinkDatabaseRequest{}, &databasePB.LinkDatabaseResponse{}, "Inject the connection info of a database into an instance"},
+	{"DELETE", "/v1/users/{refID}/databases/{name}/links/{containerName}", "/database.DatabaseService/UnlinkDatabase", &databasePB.LinkDatabaseRequest{}, &databasePB.LinkDatabaseResponse{}, "Remove the connection info of a database from an instance"},
+	{"POST", "/v1/users/{refID}/databases/{name}/dumps", "/database.DatabaseService/DumpDatabase", &databasePB.DumpDatabaseRequest{}, &databasePB.DumpDatabaseResponse{}, "Dump a database in the background"},
+	{"GET", "/v1/users/{refID}/databases/{name}/dumps", "/database.DatabaseService/Dumps", &databasePB.DumpsRequest{}, &databasePB.DumpsResponse{}, "List the dumps of a database"},
+
+	// snapshot service, only available if snapshots are enabled
+	{"GET", "/v1/users/{refID}/schedules", "/snapshot.SnapshotService/Schedules", &snapshotPB.SchedulesRequest{}, &snapshotPB.SchedulesResponse{}, "List the snapshot schedules of a user"},
+	{"POST", "/v1/users/{refID}/schedules", "/snapshot.SnapshotService/CreateSchedule", &snapshotPB.CreateScheduleRequest{}, &snapshotPB.CreateScheduleResponse{}, "Schedule snapshots of a volume or database"},
+	{"DELETE", "/v1/users/{refID}/schedules/{ID}", "/snapshot.SnapshotService/RemoveSchedule", &snapshotPB.RemoveScheduleRequest{}, &snapshotPB.RemoveScheduleResponse{}, "Remove a schedule, its snapshots are kept"},
+	{"POST", "/v1/users/{refID}/schedules/{scheduleID}/snapshots", "/snapshot.SnapshotService/TakeSnapshot", &snapshotPB.TakeSnapshotRequest{}, &snapshotPB.TakeSnapshotResponse{}, "Take a snapshot of a schedule in the background"},
+	{"GET", "/v1/users/{refID}/snapshots", "/snapshot.SnapshotService/Snapshots", &snapshotPB.SnapshotsRequest{}, &snapshotPB.SnapshotsResponse{}, "List the snapshots of a user, or of the schedule given as scheduleID"},
+	{"DELETE", "/v1/users/{refID}/snapshots/{ID}", "/snapshot.SnapshotService/RemoveSnapshot", &snapshotPB.RemoveSnapshotRequest{}, &snapshotPB.RemoveSnapshotResponse{}, "Remove a snapshot from its store"},
+	{"POST", "/v1/users/{refID}/snapshots/{ID}/restore", "/snapshot.SnapshotService/RestoreSnapshot", &snapshotPB.RestoreSnapshotRequest{}, &snapshotPB.RestoreSnapshotResponse{}, "Restore a volume snapshot into a new volume in the background"},
+
+	// billing service, only available if billing is enabled
+	{"GET", "/v1/users/{refID}/billing", "/billing.BillingService/Account", &billingPB.AccountRequest{}, &billingPB.AccountResponse{}, "Get the plan and payment status of a user"},
+	{"GET", "/v1/users/{refID}/invoices", "/billing.BillingService/Invoices", &billingPB.InvoicesRequest{}, &billingPB.InvoicesResponse{}, "List the invoices and credit notes of a user"},
+	{"GET", "/v1/users/{refID}/invoices/{ID}", "/billing.BillingService/Document", &billingPB.DocumentRequest{}, &billingPB.DocumentResponse{}, "Download an invoice as base64 encoded pdf, or as json if format is json"},
+	{"GET", "/v1/users/{refID}/invoices/{ID}/url", "/billing.BillingService/DocumentURL", &billingPB.DocumentRequest{}, &billingPB.DocumentURLResponse{}, "Get a signed URL an invoice can be downloaded from as pdf, or as json if format is json, until it expires"},
+	{"POST", "/v1/users/{refID}/invoices/{invoiceID}/credit-notes", "/billing.BillingService/CreateCreditNote", &billingPB.CreateCreditNoteRequest{}, &billingPB.CreateCreditNoteResponse{}, "Correct an invoice with a credit note, admins only"},
+
+	// cron job service, only available if cron jobs are enabled
+	{"GET", "/v1/users/{refID}/cronjobs", "/cronjob.CronJobService/Jobs", &cronjobPB.JobsRequest{}, &cronjobPB.JobsResponse{}, "List the cron jobs of a user"},
+	{"POST", "/v1/users/{refID}/cronjobs", "/cronjob.CronJobService/CreateJob", &cronjobPB.CreateJobRequest{}, &cronjobPB.CreateJobResponse{}, "Run a command inside an instance on a schedule"},
+	{"DELETE", "/v1/users/{refID}/cronjobs/{ID}", "/cronjob.CronJobService/RemoveJob", &cronjobPB.RemoveJobRequest{}, &cronjobPB.RemoveJobResponse{}, "Remove a cron job and its runs"},
+	{"POST", "/v1/users/{refID}/cronjobs/{ID}/runs", "/cronjob.CronJobService/RunJob", &cronjobPB.RunJobRequest{}, &cronjobPB.RunJobResponse{}, "Run a cron job now in the background"},
+	{"GET", "/v1/users/{refID}/cronjobs/{jobID}/runs", "/cronjob.CronJobService/Runs", &cronjobPB.RunsRequest{}, &cronjobPB.RunsResponse{}, "List the last runs of a cron job with their output"},
+
+	// container log service, only available if it is configured, tailing is only available via websocket
+	{"GET", "/v1/users/{refID}/logs", "/containerlog.ContainerLogService/Search", &containerlogPB.SearchRequest{}, &containerlogPB.SearchResponse{}, "Search the lines the instances of a user wrote to their standard output and error"},
+
+	// alert service, only available if alerts are enabled
+	{"GET", "/v1/users/{refID}/alerts/rules", "/alert.AlertService/Rules", &alertPB.RulesRequest{}, &alertPB.RulesResponse{}, "List the alert rules of a user"},
+	{"POST", "/v1/users/{refID}/alerts/rules", "/alert.AlertService/CreateRule", &alertPB.CreateRuleRequest{}, &alertPB.CreateRuleResponse{}, "Alert once a metric of an instance exceeds a threshold for a duration"},
+	{"DELETE", "/v1/users/{refID}/alerts/rules/{ID}", "/alert.AlertService/RemoveRule", &alertPB.RemoveRuleRequest{}, &alertPB.RemoveRuleResponse{}, "Remove an alert rule"},
+	{"GET", "/v1/users/{refID}/alerts/states", "/alert.AlertService/States", &alertPB.StatesRequest{}, &alertPB.StatesResponse{}, "List the instances which exceed the thresholds of the alert rules"},
+	{"GET", "/v1/users/{refID}/alerts", "/alert.AlertService/Alerts", &alertPB.AlertsRequest{}, &alertPB.AlertsResponse{}, "List the alerts which fired or were resolved"},
+
+	// autoscale service, only available if autoscaling is enabled
+	{"GET", "/v1/users/{refID}/autoscaling/policies", "/autoscale.AutoscaleService/Policies", &autoscalePB.PoliciesRequest{}, &autoscalePB.PoliciesResponse{}, "List the autoscaling policies of a user"},
+	{"PUT", "/v1/users/{refID}/instances/{instance}/autoscaling", "/autoscale.AutoscaleService/SetPolicy", &autoscalePB.SetPolicyRequest{}, &autoscalePB.SetPolicyResponse{}, "Scale the replicas of an instance between bounds by its CPU usage and request rate"},
+	{"DELETE", "/v1/users/{refID}/instances/{instance}/autoscaling", "/autoscale.AutoscaleService/RemovePolicy", &autoscalePB.RemovePolicyRequest{}, &autoscalePB.RemovePolicyResponse{}, "Stop scaling an instance, it keeps its replicas"},
+	{"GET", "/v1/users/{refID}/autoscaling/windows", "/autoscale.AutoscaleService/Windows", &autoscalePB.WindowsRequest{}, &autoscalePB.WindowsResponse{}, "List the windows of the autoscaling policies of a user"},
+	{"POST", "/v1/users/{refID}/instances/{instance}/autoscaling/windows", "/autoscale.AutoscaleService/CreateWindow", &autoscalePB.CreateWindowRequest{}, &autoscalePB.CreateWindowResponse{}, "Keep a minimum of replicas of an instance during recurring times"},
+	{"DELETE", "/v1/users/{refID}/autoscaling/windows/{ID}", "/autoscale.AutoscaleService/RemoveWindow", &autoscalePB.RemoveWindowRequest{}, &autoscalePB.RemoveWindowResponse{}, "Remove a window of an autoscaling policy"},
+	{"GET", "/v1/users/{refID}/autoscaling/events", "/autoscale.AutoscaleService/Events", &autoscalePB.EventsRequest{}, &autoscalePB.EventsResponse{}, "List the scalings of the instances of a user"},
+
+	// ban service, only available if bans are enabled
+	{"GET", "/v1/users/{refID}/bans/jails", "/ban.BanService/Jails", &banPB.JailsRequest{}, &banPB.JailsResponse{}, "List the jails of a user"},
+	{"POST", "/v1/users/{refID}/bans/jails", "/ban.BanService/CreateJail", &banPB.CreateJailRequest{}, &banPB.CreateJailResponse{}, "Ban the sources which match the filter of a jail too often in the log of an instance or routing configuration"},
+	{"DELETE", "/v1/users/{refID}/bans/jails/{ID}", "/ban.BanService/RemoveJail", &banPB.RemoveJailRequest{}, &banPB.RemoveJailResponse{}, "Remove a jail and lift its bans"},
+	{"GET", "/v1/users/{refID}/bans", "/ban.BanService/Bans", &banPB.BansRequest{}, &banPB.BansResponse{}, "List the sources the jails of a user banned"},
+	{"DELETE", "/v1/users/{refID}/bans/{ID}", "/ban.BanService/Unban", &banPB.UnbanRequest{}, &banPB.UnbanResponse{}, "Lift a ban before it expires"},
+
+	// wireguard service, only available if the VPN access is enabled
+	{"GET", "/v1/users/{refID}/vpn/peers", "/wireguard.WireGuardService/Peers", &wireguardPB.PeersRequest{}, &wireguardPB.PeersResponse{}, "List the VPN peers of a user"},
+	{"POST", "/v1/users/{refID}/vpn/peers", "/wireguard.WireGuardService/CreatePeer", &wireguardPB.CreatePeerRequest{}, &wireguardPB.CreatePeerResponse{}, "Create a VPN peer connecting to the private network of a user and get its WireGuard configuration"},
+	{"DELETE", "/v1/users/{refID}/vpn/peers/{ID}", "/wireguard.WireGuardService/RemovePeer", &wireguardPB.RemovePeerRequest{}, &wireguardPB.RemovePeerResponse{}, "Remove a VPN peer"},
+	{"POST", "/v1/users/{refID}/vpn/peers/{ID}/rotate", "/wireguard.WireGuardService/RotateKey", &wireguardPB.RotateKeyRequest{}, &wireguardPB.RotateKeyResponse{}, "Replace the key of a VPN peer and get its new configuration"},
+	{"PUT", "/v1/users/{refID}/vpn/peers/{ID}/rules", "/wireguard.WireGuardService/SetRules", &wireguardPB.SetRulesRequest{}, &wireguardPB.SetRulesResponse{}, "Set the instances a VPN peer may reach"},
+
+	// site service, only available if static sites are enabled
+	{"GET", "/v1/users/{refID}/sites", "/site.SiteService/Sites", &sitePB.SitesRequest{}, &sitePB.SitesResponse{}, "List the static sites of a user"},
+	{"POST", "/v1/users/{refID}/sites", "/site.SiteService/CreateSite", &sitePB.CreateSiteRequest{}, &sitePB.CreateSiteResponse{}, "Create a static site, it is empty until a bundle is deployed"},
+	{"GET", "/v1/users/{refID}/sites/{name}", "/site.SiteService/GetSite", &sitePB.GetSiteRequest{}, &sitePB.GetSiteResponse{}, "Get a static site"},
+	{"PUT", "/v1/users/{refID}/sites/{name}", "/site.SiteService/EditSite", &sitePB.EditSiteRequest{}, &sitePB.EditSiteResponse{}, "Set the domains, the cache max age and https of a static site"},
+	{"DELETE", "/v1/users/{refID}/sites/{name}", "/site.SiteService/RemoveSite", &sitePB.RemoveSiteRequest{}, &sitePB.RemoveSiteResponse{}, "Remove a static site with its files and certificate"},
+	{"POST", "/v1/users/{refID}/sites/{name}/deploy", "/site.SiteService/Deploy", &sitePB.DeployRequest{}, &sitePB.DeployResponse{}, "Deploy a gzip compressed tar archive of the files of a static site"},
+
+	// deploy service, only available if deployments from git pushes are enabled
+	{"GET", "/v1/users/{refID}/deployments", "/deploy.DeployService/Deployments", &deployPB.DeploymentsRequest{}, &deployPB.DeploymentsResponse{}, "List the deployments of a user, the query parameter instance selects the ones of an instance"},
+	{"GET", "/v1/users/{refID}/deployments/{ID}/log", "/deploy.DeployService/Log", &deployPB.LogRequest{}, &deployPB.LogResponse{}, "Get the build log of a deployment"},
+	{"GET", "/v1/users/{refID}/deployments/retention", "/deploy.DeployService/GetRetention", &deployPB.GetRetentionRequest{}, &deployPB.GetRetentionResponse{}, "Get the retention of the images built for the instances of a user"},
+	{"PUT", "/v1/users/{refID}/deployments/retention", "/deploy.DeployService/SetRetention", &deployPB.SetRetentionRequest{}, &deployPB.SetRetentionResponse{}, "Set the retention of the images built for the instances of a user"},
+	{"POST", "/v1/users/{refID}/deployments/prune", "/deploy.DeployService/PruneImages", &deployPB.PruneImagesRequest{}, &deployPB.PruneImagesResponse{}, "Remove the images exceeding the retention of a user, or preview them with dry_run"},
+	{"POST", "/v1/users/{refID}/deployments/{ID}/rollback", "/deploy.DeployService/Rollback", &deployPB.RollbackRequest{}, &deployPB.RollbackResponse{}, "Deploy the image and the environment of a succeeded deployment of an instance again"},
+	{"GET", "/v1/users/{refID}/deployments/hooks", "/deploy.DeployService/Hooks", &deployPB.HooksRequest{}, &deployPB.HooksResponse{}, "List the hooks deploying instances from GitHub or GitLab"},
+	{"POST", "/v1/users/{refID}/deployments/hooks", "/deploy.DeployService/CreateHook", &deployPB.CreateHookRequest{}, &deployPB.CreateHookResponse{}, "Create a hook deploying an instance from the pushes to a branch of a repository, the response contains the secret of its webhooks"},
+	{"PUT", "/v1/users/{refID}/deployments/hooks/{ID}", "/deploy.DeployService/EditHook", &deployPB.EditHookRequest{}, &deployPB.EditHookResponse{}, "Enable or disable a hook"},
+	{"DELETE", "/v1/users/{refID}/deployments/hooks/{ID}", "/deploy.DeployService/RemoveHook", &deployPB.RemoveHookRequest{}, &deployPB.RemoveHookResponse{}, "Remove a hook"},
+
+	// export service
+	{"POST", "/v1/users/{refID}/exports", "/export.ExportService/ExportUserData", &exportPB.ExportUserDataRequest{}, &exportPB.ExportUserDataResponse{}, "Export the personal data of a user into an archive in the background, the user is notified once it is done"},
+	{"GET", "/v1/users/{refID}/exports", "/export.ExportService/Exports", &exportPB.ExportsRequest{}, &exportPB.ExportsResponse{}, "List the data exports of a user, latest first"},
+	{"GET", "/v1/users/{refID}/exports/{ID}", "/export.ExportService/Download", &exportPB.DownloadRequest{}, &exportPB.DownloadResponse{}, "Download the archive of a succeeded data export as base64 encoded tar.gz"},
+	{"GET", "/v1/users/{refID}/exports/{ID}/url", "/export.ExportService/DownloadURL", &exportPB.DownloadURLRequest{}, &exportPB.DownloadURLResponse{}, "Get a signed URL the archive of a succeeded data export can be downloaded from until it expires"},
+
+	// agent service, agents send their heartbeats and events themselves
+	{"GET", "/v1/agents", "/agent.AgentService/Agents", &agentPB.AgentsRequest{}, &agentPB.AgentsResponse{}, "List the agents of the worker nodes"},
+	{"DELETE", "/v1/agents/{name}", "/agent.AgentService/RemoveAgent", &agentPB.RemoveAgentRequest{}, &agentPB.RemoveAgentResponse{}, "Remove an agent"},
+
+	// network service, only available if it is configured
+	{"POST", "/v1/users/{RefID}/networks", "/network.NetworkService/CreateNetwork", &networkPB.CreateNetworkRequest{}, &networkPB.CreateNetworkResponse{}, "Create a network"},
+	{"POST", "/v1/users/{RefID}/networks/primary", "/network.NetworkService/CreatePrimaryNetworkForContainer", &networkPB.CreatePrimaryNetworkForContainerRequest{}, &networkPB.CreatePrimaryNetworkForContainerResponse{}, "Create the primary network of a container"},
+	{"DELETE", "/v1/users/{RefID}/networks/{Name}", "/network.NetworkService/RemoveNetworkByName", &networkPB.RemoveNetworkByNameRequest{}, &networkPB.RemoveNetworkByNameResponse{}, "Remove a network"},
+	{"POST", "/v1/users/{RefID}/networks/{Name}/containers", "/network.NetworkService/AddContainerToNetwork", &networkPB.AddContainerToNetworkRequest{}, &networkPB.AddContainerToNetworkResponse{}, "Add a container to a network"},
+	{"DELETE", "/v1/users/{RefID}/networks/{Name}/containers/{ContainerID}", "/network.NetworkService/RemoveContainerFromNetwork", &networkPB.RemoveContainerFromNetworkRequest{}, &networkPB.RemoveContainerFromNetworkResponse{}, "Remove a container from a network"},
+	{"POST", "/v1/users/{RefID}/ports", "/network.NetworkService/ExposePortToContainer", &networkPB.ExposePortToContainerRequest{}, &networkPB.ExposePortToContainerResponse{}, "Expose a port of a container to another one"},
+	{"DELETE", "/v1/users/{RefID}/ports", "/network.NetworkService/RemovePortFromContainer", &networkPB.RemovePortFromContainerRequest{}, &networkPB.RemovePortFromContainerResponse{}, "Remove an exposed port"},
+	{"PUT", "/v1/users/{RefID}/bridge", "/network.NetworkService/CreateBridge", &networkPB.CreateBridgeRequest{}, &networkPB.CreateBridgeResponse{}, "Create the bridge network of a user"},
+	{"DELETE", "/v1/users/{RefID}/bridge", "/network.NetworkService/RemoveBridge", &networkPB.RemoveBridgeRequest{}, &networkPB.RemoveBridgeResponse{}, "Remove the bridge network of a user"},
+	{"POST", "/v1/users/{RefID}/addresses", "/network.NetworkService/AssignIP", &networkPB.AssignIPRequest{}, &networkPB.AssignIPResponse{}, "Assign an address to a container"},
+	{"DELETE", "/v1/users/{RefID}/addresses/{ContainerID}", "/network.NetworkService/ReleaseIP", &networkPB.ReleaseIPRequest{}, &networkPB.ReleaseIPResponse{}, "Release the address of a container"},
+	{"GET", "/v1/users/{RefID}/peerings", "/network.NetworkService/Peerings", &networkPB.PeeringsRequest{}, &networkPB.PeeringsResponse{}, "List the peerings of a user"},
+	{"POST", "/v1/users/{RefID}/peerings", "/network.NetworkService/RequestPeering", &networkPB.RequestPeeringRequest{}, &networkPB.RequestPeeringResponse{}, "Request a peering with a container of another user"},
+	{"POST", "/v1/users/{RefID}/peerings/{ID}/approve", "/network.NetworkService/ApprovePeering", &networkPB.ApprovePeeringRequest{}, &networkPB.ApprovePeeringResponse{}, "Approve a peering"},
+	{"DELETE", "/v1/users/{RefID}/peerings/{ID}", "/network.NetworkService/RevokePeering", &networkPB.RevokePeeringRequest{}, &networkPB.RevokePeeringResponse{}, "Revoke a peering"},
+	{"GET", "/v1/users/{RefID}/usage", "/network.NetworkService/Usage", &networkPB.UsageRequest{}, &networkPB.UsageResponse{}, "Get the network usage of a user"},
+	{"GET", "/v1/users/{RefID}/usage/total", "/network.NetworkService/TotalUsage", &networkPB.TotalUsageRequest{}, &networkPB.TotalUsageResponse{}, "Get the total network usage of a user"},
+	{"GET", "/v1/nodes", "/network.NetworkService/Nodes", &networkPB.NodesRequest{}, &networkPB.NodesResponse{}, "List the nodes"},
+	{"PUT", "/v1/nodes/{Name}", "/network.NetworkService/RegisterNode", &networkPB.RegisterNodeRequest{}, &networkPB.RegisterNodeResponse{}, "Register a node"},
+	{"DELETE", "/v1/nodes/{Name}", "/network.NetworkService/RemoveNode", &networkPB.RemoveNodeRequest{}, &networkPB.RemoveNodeResponse{}, "Remove a node"},
+
+	// firewall service, only available if it is configured
+	{"POST", "/v1/firewall/bridges", "/firewall.FirewallService/InitBridge", &firewallPB.InitBridgeRequest{}, &firewallPB.InitBridgeResponse{}, "Set up the firewall of a bridge"},
+	{"POST", "/v1/firewall/connections", "/firewall.FirewallService/AllowConnection", &firewallPB.AllowConnectionRequest{}, &firewallPB.AllowConnectionResponse{}, "Allow the connection between two addresses"},
+	{"DELETE", "/v1/firewall/connections", "/firewall.FirewallService/BlockConnection", &firewallPB.BlockConnectionRequest{}, &firewallPB.BlockConnectionResponse{}, "Block the connection between two addresses"},
+	{"POST", "/v1/firewall/ports", "/firewall.FirewallService/AllowPort", &firewallPB.AllowPortRequest{}, &firewallPB.AllowPortResponse{}, "Allow the connection to a port"},
+	{"DELETE", "/v1/firewall/ports", "/firewall.FirewallService/BlockPort", &firewallPB.BlockPortRequest{}, &firewallPB.BlockPortResponse{}, "Block the connection to a port"},
+	{"GET", "/v1/firewall/counters", "/firewall.FirewallService/RuleCounters", &firewallPB.RuleCountersRequest{}, &firewallPB.RuleCountersResponse{}, "List the packets and bytes matched by every firewall rule"},
+	{"POST", "/v1/firewall/policies", "/firewall.FirewallService/ApplyPolicy", &firewallPB.ApplyPolicyRequest{}, &firewallPB.ApplyPolicyResponse{}, "Apply the firewall policy of an instance"},
+	{"DELETE", "/v1/firewall/policies", "/firewall.FirewallService/RemovePolicy", &firewallPB.RemovePolicyRequest{}, &firewallPB.RemovePolicyResponse{}, "Remove the firewall policy of an instance"},
+	{"GET", "/v1/firewall/drops", "/firewall.FirewallService/Drops", &firewallPB.DropsRequest{}, &firewallPB.DropsResponse{}, "List the packets dropped by the firewall per instance and peer"},
+	{"POST", "/v1/firewall/bans", "/firewall.FirewallService/Ban", &firewallPB.BanRequest{}, &firewallPB.BanResponse{}, "Drop the packets of a source address"},
+	{"DELETE", "/v1/firewall/bans", "/firewall.FirewallService/Unban", &firewallPB.UnbanRequest{}, &firewallPB.UnbanResponse{}, "Remove the ban of a source address"},
+}
@@ -0,0 +1,126 @@
+package gateway
+
+import (
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
+)
+
+// Version is a version of the REST API, the paths of its routes start with /<Name>. Versions coexist,
+// a client migrates by changing the version in its paths.
+type Version struct {
+	Name string
+
+	// Base is the version this one is derived from, its routes are served by this version as well
+	// unless this version has a route of the same method and path or removes it. A version therefore
+	// only declares its changes.
+	Base string
+
+	// Removed are the routes of Base this version does not serve, e.g. POST /users/credentials, they
+	// are mapped to the path of their successor in this version or to an empty string
+	Removed map[string]string
+
+	// Deprecation is set if the version is deprecated, its Successor is the name of the version
+	// replacing it. The routes of the version answer with the Deprecation, Sunset and Link headers.
+	Deprecation *versioning.Deprecation
+}
+
+// Versions are the versions of the public API
+var Versions = []Version{
+	{
+		Name:        "v1",
+		Deprecation: &versioning.Deprecation{Successor: "v2"},
+	},
+	{
+		Name: "v2",
+		Base: "v1",
+		Removed: map[string]string{
+			// the credentials are checked by authenticating
+			"POST /users/credentials": "/auth",
+		},
+	},
+}
+
+// split returns the version of a path and the path without it
+func split(path string) (string, string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], "/" + parts[1]
+}
+
+// key identifies the route of method and path across versions
+func key(method, path string) string {
+	return method + " " + path
+}
+
+// Versioned returns routes and the routes every version serves from its base, the served routes
+// come first so they are matched before the derived ones. The deprecations of the routes of
+// deprecated versions are returned by method and path, e.g. GET /v1/users/{ID}, the successor of
+// a route is its path in the successor version.
+func Versioned(routes []Route, versions []Version) ([]Route, map[string]versioning.Deprecation) {
+	served := make(map[string]map[string]bool)
+	byVersion := make(map[string][]Route)
+	for _, r := range routes {
+		v, p := split(r.Path)
+		if served[v] == nil {
+			served[v] = make(map[string]bool)
+		}
+		served[v][key(r.Method, p)] = true
+		byVersion[v] = append(byVersion[v], r)
+	}
+
+	all := append([]Route{}, routes...)
+	for _, v := range versions {
+		if v.Base == "" {
+			continue
+		}
+		if served[v.Name] == nil {
+			served[v.Name] = make(map[string]bool)
+		}
+
+		for _, r := range byVersion[v.Base] {
+			_, p := split(r.Path)
+			k := key(r.Method, p)
+			if _, removed := v.Removed[k]; removed || served[v.Name][k] {
+				continue
+			}
+
+			r.Path = "/" + v.Name + p
+			served[v.Name][k] = true
+			byVersion[v.Name] = append(byVersion[v.Name], r)
+			all = append(all, r)
+		}
+	}
+
+	deprecated := make(map[string]versioning.Deprecation)
+	for _, v := range versions {
+		if v.Deprecation == nil {
+			continue
+		}
+
+		var successor *Version
+		for i := range versions {
+			if versions[i].Name == v.Deprecation.Successor {
+				successor = &versions[i]
+			}
+		}
+
+		for _, r := range byVersion[v.Name] {
+			_, p := split(r.Path)
+			k := key(r.Method, p)
+
+			d := versioning.Deprecation{Sunset: v.Deprecation.Sunset}
+			if successor != nil {
+				if served[successor.Name][k] {
+					d.Successor = "/" + successor.Name + p
+				} else if s := successor.Removed[k]; s != "" {
+					d.Successor = "/" + successor.Name + s
+				}
+			}
+			deprecated[key(r.Method, r.Path)] = d
+		}
+	}
+	return all, deprecated
+}
@@ -0,0 +1,209 @@
+// Package health aggregates the checks of the dependencies of the daemon into its liveness and readiness
+package health
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotChecked is the error of checkers which did not run yet
+var ErrNotChecked = errors.New("not checked yet")
+
+// CheckFunc checks a dependency, it returns nil if the dependency is usable
+type CheckFunc func() error
+
+// Status is the result of the last run of a checker
+type Status struct {
+	Name        string     `json:"name"`
+	Healthy     bool       `json:"healthy"`
+	Error       string     `json:"error,omitempty"`
+	LastChecked *time.Time `json:"lastChecked,omitempty"`
+	LastError   *time.Time `json:"lastError,omitempty"`
+}
+
+// Report is the aggregated status of every checker, the daemon is ready if every checker is healthy
+// and live as long as the checkers are run
+type Report struct {
+	Live   bool     `json:"live"`
+	Ready  bool     `json:"ready"`
+	Checks []Status `json:"checks"`
+}
+
+// Registry runs the registered checkers and keeps their results
+type Registry struct {
+	mtx      sync.RWMutex
+	checks   map[string]CheckFunc
+	status   map[string]Status
+	timeout  time.Duration
+	interval time.Duration
+	lastRun  time.Time
+}
+
+// Register adds a checker named name, a checker with the same name is replaced
+func (r *Registry) Register(name string, check CheckFunc) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.checks[name] = check
+	delete(r.status, name)
+}
+
+// run calls check and fails if it does not return within timeout
+func run(check CheckFunc, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- check()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// Check runs every checker concurrently and records their results
+func (r *Registry) Check() {
+	r.mtx.RLock()
+	checks := make(map[string]CheckFunc, len(r.checks))
+	for name, check := range r.checks {
+		checks[name] = check
+	}
+	r.mtx.RUnlock()
+
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+
+			err := run(check, r.timeout)
+			now := time.Now()
+
+			r.mtx.Lock()
+			defer r.mtx.Unlock()
+
+			s := r.status[name]
+			s.Name, s.Healthy, s.Error, s.LastChecked = name, err == nil, "", &now
+			if err != nil {
+				s.Error, s.LastError = err.Error(), &now
+			}
+			r.status[name] = s
+		}(name, check)
+	}
+	wg.Wait()
+
+	r.mtx.Lock()
+	r.lastRun = time.Now()
+	r.mtx.Unlock()
+}
+
+// Run calls Check every interval until stop is closed
+func (r *Registry) Run(interval time.Duration, stop <-chan struct{}) {
+	r.mtx.Lock()
+	r.interval = interval
+	r.mtx.Unlock()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		r.Check()
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Report returns the status of every checker ordered by name. The daemon is not live if the
+// checkers were not run for three intervals, which means Run is stuck.
+func (r *Registry) Report() Report {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	report := Report{
+		Live:   r.interval == 0 || time.Since(r.lastRun) < 3*r.interval,
+		Ready:  true,
+		Checks: []Status{},
+	}
+
+	for name := range r.checks {
+		s, ok := r.status[name]
+		if !ok {
+			s = Status{
+				Name:  name,
+				Error: ErrNotChecked.Error(),
+			}
+		}
+
+		report.Ready = report.Ready && s.Healthy
+		report.Checks = append(report.Checks, s)
+	}
+
+	sort.Slice(report.Checks, func(i, j int) bool {
+		return report.Checks[i].Name < report.Checks[j].Name
+	})
+	return report
+}
+
+// NewRegistry returns a Registry failing checkers which take longer than timeout
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{
+		checks:  make(map[string]CheckFunc),
+		status:  make(map[string]Status),
+		timeout: timeout,
+	}
+}
+
+// Command returns a checker running a command, it fails if the command exits with an error
+func Command(name string, args ...string) CheckFunc {
+	return func() error {
+		out, err := exec.Command(name, args...).CombinedOutput()
+		if err != nil {
+			msg := strings.TrimSpace(string(out))
+			if msg == "" {
+				return err
+			}
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil
+	}
+}
+
+// Path returns a checker which fails if path does not exist
+func Path(path string) CheckFunc {
+	return func() error {
+		_, err := os.Stat(path)
+		return err
+	}
+}
+
+// Result is a checker reporting the outcome of work done elsewhere, e.g. by a background job.
+// It is healthy until a failure is set.
+type Result struct {
+	mtx sync.Mutex
+	err error
+}
+
+// Set records the outcome of the latest run of the work
+func (r *Result) Set(err error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.err = err
+}
+
+// Check returns the error set last
+func (r *Result) Check() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.err
+}
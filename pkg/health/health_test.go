@@ -0,0 +1,108 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/health"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Health", func() {
+	var r *health.Registry
+
+	BeforeEach(func() {
+		r = health.NewRegistry(50 * time.Millisecond)
+	})
+
+	Describe("Registry", func() {
+		It("Should not be ready before the checkers ran", func() {
+			r.Register("database", func() error {
+				return nil
+			})
+
+			report := r.Report()
+			Expect(report.Live).To(BeTrue())
+			Expect(report.Ready).To(BeFalse())
+			Expect(report.Checks).To(HaveLen(1))
+			Expect(report.Checks[0].Error).To(Equal(health.ErrNotChecked.Error()))
+		})
+
+		It("Should aggregate the results of the checkers", func() {
+			database := &health.Result{}
+			r.Register("database", database.Check)
+			r.Register("acme", func() error {
+				return nil
+			})
+
+			r.Check()
+			report := r.Report()
+			Expect(report.Ready).To(BeTrue())
+			Expect(report.Checks[0].Name).To(Equal("acme"))
+			Expect(report.Checks[1].LastChecked).NotTo(BeNil())
+			Expect(report.Checks[1].LastError).To(BeNil())
+
+			database.Set(errors.New("connection refused"))
+			r.Check()
+			report = r.Report()
+			Expect(report.Ready).To(BeFalse())
+			Expect(report.Checks[1].Healthy).To(BeFalse())
+			Expect(report.Checks[1].Error).To(Equal("connection refused"))
+			Expect(report.Checks[1].LastError).NotTo(BeNil())
+
+			database.Set(nil)
+			r.Check()
+			report = r.Report()
+			Expect(report.Ready).To(BeTrue())
+			Expect(report.Checks[1].Error).To(BeEmpty())
+			Expect(report.Checks[1].LastError).NotTo(BeNil())
+		})
+
+		It("Should fail checkers which time out", func() {
+			r.Register("nginx", func() error {
+				time.Sleep(time.Second)
+				return nil
+			})
+
+			r.Check()
+			report := r.Report()
+			Expect(report.Ready).To(BeFalse())
+			Expect(report.Checks[0].Error).To(ContainSubstring("timed out"))
+		})
+
+		It("Should check paths", func() {
+			Expect(health.Path("/")()).To(Succeed())
+			Expect(health.Path("/does/not/exist")()).NotTo(Succeed())
+		})
+	})
+
+	Describe("Handler", func() {
+		It("Should serve readiness", func() {
+			result := &health.Result{}
+			r.Register("iptables", result.Check)
+			h := health.Handler(r)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", health.ReadyPath, nil))
+			Expect(rec.Code).To(Equal(http.StatusServiceUnavailable))
+
+			r.Check()
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", health.ReadyPath, nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+
+			report := health.Report{}
+			Expect(json.Unmarshal(rec.Body.Bytes(), &report)).To(Succeed())
+			Expect(report.Checks[0].Name).To(Equal("iptables"))
+
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", health.LivePath, nil))
+			Expect(rec.Code).To(Equal(http.StatusOK))
+		})
+	})
+})
@@ -0,0 +1,36 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	// LivePath is the path the liveness of the daemon is served at
+	LivePath = "/healthz"
+
+	// ReadyPath is the path the readiness of the daemon is served at
+	ReadyPath = "/readyz"
+)
+
+// Handler serves the report of r at LivePath and ReadyPath, the status code is 503 if the daemon is not live or ready respectively
+func Handler(r *Registry) http.Handler {
+	write := func(w http.ResponseWriter, report Report, ok bool) {
+		w.Header().Set("Content-Type", "application/json")
+		if !ok {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(report)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(LivePath, func(w http.ResponseWriter, req *http.Request) {
+		report := r.Report()
+		write(w, report, report.Live)
+	})
+	mux.HandleFunc(ReadyPath, func(w http.ResponseWriter, req *http.Request) {
+		report := r.Report()
+		write(w, report, report.Ready)
+	})
+	return mux
+}
@@ -0,0 +1,117 @@
+// Package idempotency keeps the results of calls made with an idempotency key, so a client retrying a
+// call after a network failure gets the result of the first call instead of e.g. a second container
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTTL is how long the result of a call is returned for retries with its key
+	DefaultTTL = 24 * time.Hour
+
+	// PendingTTL is how long a key is reserved for a call which did not finish, e.g. because
+	// the node handling it stopped
+	PendingTTL = 10 * time.Minute
+
+	// MaxKeyLength is the maximum length of an idempotency key
+	MaxKeyLength = 255
+)
+
+// Record is what is stored for a key
+type Record struct {
+	// Fingerprint is the hash of the request the key was first used for
+	Fingerprint string `json:"fingerprint"`
+
+	// Done is false while the first call with the key is handled
+	Done bool `json:"done"`
+
+	// Type is the name of the response message
+	Type string `json:"type,omitempty"`
+
+	// Response is the encoded response message
+	Response []byte `json:"response,omitempty"`
+}
+
+// Store keeps the records of the keys
+type Store interface {
+	// Reserve stores r for key if key is not used, otherwise it returns the record stored for it and false
+	Reserve(key string, r Record, ttl time.Duration) (Record, bool, error)
+
+	// Save replaces the record stored for key
+	Save(key string, r Record, ttl time.Duration) error
+
+	// Release removes the record of key so it can be used again
+	Release(key string) error
+}
+
+type entry struct {
+	record  Record
+	expires time.Time
+}
+
+type memoryStore struct {
+	mtx      sync.Mutex
+	entries  map[string]entry
+	reserves int
+}
+
+// sweepInterval is the number of reservations after which expired records are removed
+const sweepInterval = 1000
+
+func (s *memoryStore) sweep(now time.Time) {
+	for key, e := range s.entries {
+		if now.After(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+func (s *memoryStore) Reserve(key string, r Record, ttl time.Duration) (Record, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	s.reserves++
+	if s.reserves%sweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	e, ok := s.entries[key]
+	if ok && !now.After(e.expires) {
+		return e.record, false, nil
+	}
+
+	s.entries[key] = entry{
+		record:  r,
+		expires: now.Add(ttl),
+	}
+	return r, true, nil
+}
+
+func (s *memoryStore) Save(key string, r Record, ttl time.Duration) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.entries[key] = entry{
+		record:  r,
+		expires: time.Now().Add(ttl),
+	}
+	return nil
+}
+
+func (s *memoryStore) Release(key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// NewMemoryStore returns a Store keeping the records in memory, they are not shared between nodes
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[string]entry),
+	}
+}
@@ -0,0 +1,144 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/bart"
+	"github.com/kontainerooo/kontainer.ooo/pkg/idempotency"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	userPB "github.com/kontainerooo/kontainer.ooo/pkg/user/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Idempotency", func() {
+	Describe("MemoryStore", func() {
+		It("Should reserve keys until they are released or expired", func() {
+			s := idempotency.NewMemoryStore()
+
+			r, ok, err := s.Reserve("1", idempotency.Record{Fingerprint: "a"}, time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeTrue())
+
+			r, ok, _ = s.Reserve("1", idempotency.Record{Fingerprint: "b"}, time.Minute)
+			Expect(ok).To(BeFalse())
+			Expect(r.Fingerprint).To(Equal("a"))
+
+			s.Release("1")
+			_, ok, _ = s.Reserve("1", idempotency.Record{Fingerprint: "b"}, time.Minute)
+			Expect(ok).To(BeTrue())
+
+			s.Save("1", idempotency.Record{Fingerprint: "b", Done: true}, -time.Second)
+			_, ok, _ = s.Reserve("1", idempotency.Record{Fingerprint: "c"}, time.Minute)
+			Expect(ok).To(BeTrue())
+		})
+	})
+
+	Describe("RedisStore", func() {
+		It("Should reserve keys with SET NX and return the stored record", func() {
+			url, commands := testutils.FakeRedis("+OK\r\n", "+OK\r\n", "$-1\r\n", "$32\r\n{\"fingerprint\":\"a\",\"done\":false}\r\n")
+			s, err := idempotency.NewRedisStore(url)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			r, ok, err := s.Reserve("1:m:k", idempotency.Record{Fingerprint: "b"}, time.Minute)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ok).To(BeFalse())
+			Expect(r.Fingerprint).To(Equal("a"))
+
+			<-commands
+			<-commands
+			Expect(<-commands).To(Equal([]string{"SET", idempotency.RedisKeyPrefix + "1:m:k", `{"fingerprint":"b","done":false}`, "NX", "PX", "60000"}))
+			Expect(<-commands).To(Equal([]string{"GET", idempotency.RedisKeyPrefix + "1:m:k"}))
+		})
+	})
+
+	Describe("UnaryServerInterceptor", func() {
+		var (
+			interceptor grpc.UnaryServerInterceptor
+			calls       int
+			fail        error
+			info        = &grpc.UnaryServerInfo{FullMethod: "/user.UserService/CreateUser"}
+		)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls++
+			if fail != nil {
+				return nil, fail
+			}
+			return &userPB.CreateUserResponse{ID: uint32(calls)}, nil
+		}
+
+		call := func(key string, req *userPB.CreateUserRequest) (interface{}, error) {
+			ctx := bart.ContextWithCaller(context.Background(), 1)
+			if key != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(idempotency.Header, key))
+			}
+			return interceptor(ctx, req, info, handler)
+		}
+
+		BeforeEach(func() {
+			interceptor = idempotency.UnaryServerInterceptor(idempotency.NewMemoryStore(), time.Hour, []string{"user.UserService/CreateUser"}, log.NewNopLogger())
+			calls, fail = 0, nil
+		})
+
+		It("Should return the first result to retries", func() {
+			req := &userPB.CreateUserRequest{Username: "kroo"}
+
+			res, err := call("k", req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res.(*userPB.CreateUserResponse).ID).To(BeEquivalentTo(1))
+
+			res, err = call("k", req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res.(*userPB.CreateUserResponse).ID).To(BeEquivalentTo(1))
+			Expect(calls).To(Equal(1))
+
+			call("other", req)
+			call("", req)
+			Expect(calls).To(Equal(3))
+		})
+
+		It("Should reject keys used for a different request", func() {
+			call("k", &userPB.CreateUserRequest{Username: "kroo"})
+			_, err := call("k", &userPB.CreateUserRequest{Username: "other"})
+			Expect(grpc.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+
+		It("Should not keep failed calls", func() {
+			fail = errors.New("unavailable")
+			_, err := call("k", &userPB.CreateUserRequest{Username: "kroo"})
+			Expect(err).To(Equal(fail))
+
+			fail = nil
+			_, err = call("k", &userPB.CreateUserRequest{Username: "kroo"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+		})
+
+		It("Should ignore keys of other methods", func() {
+			info.FullMethod = "/user.UserService/GetUser"
+			defer func() { info.FullMethod = "/user.UserService/CreateUser" }()
+
+			call("k", &userPB.CreateUserRequest{Username: "kroo"})
+			call("k", &userPB.CreateUserRequest{Username: "kroo"})
+			Expect(calls).To(Equal(2))
+		})
+
+		It("Should reject keys which are too long", func() {
+			key := make([]byte, idempotency.MaxKeyLength+1)
+			for i := range key {
+				key[i] = 'k'
+			}
+			_, err := call(string(key), &userPB.CreateUserRequest{Username: "kroo"})
+			Expect(grpc.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(calls).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,148 @@
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/golang/protobuf/proto"
+	"github.com/kontainerooo/kontainer.ooo/pkg/bart"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// Header is the gRPC metadata key carrying the idempotency key of a call, the gateway sets it
+	// from the Idempotency-Key header
+	Header = "idempotency-key"
+
+	// ReplayedHeader is set to true in the response metadata if the result of an earlier call is returned
+	ReplayedHeader = "idempotent-replayed"
+)
+
+var (
+	// ErrInProgress occurs if a call is made with the key of a call which is still handled
+	ErrInProgress = errors.New("a request with this idempotency key is in progress")
+
+	// ErrMismatch occurs if a key is used again for a different request
+	ErrMismatch = errors.New("the idempotency key was used for a different request")
+
+	// ErrKeyTooLong occurs if a key is longer than MaxKeyLength
+	ErrKeyTooLong = fmt.Errorf("idempotency keys can't be longer than %d characters", MaxKeyLength)
+)
+
+// fingerprint returns the hash of a request
+func fingerprint(req proto.Message) (string, error) {
+	b := proto.NewBuffer(nil)
+	b.SetDeterministic(true)
+	err := b.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// replay decodes the response stored in r
+func replay(r Record) (proto.Message, error) {
+	t := proto.MessageType(r.Type)
+	if t == nil {
+		return nil, fmt.Errorf("unknown response type %s", r.Type)
+	}
+
+	res := reflect.New(t.Elem()).Interface().(proto.Message)
+	err := proto.Unmarshal(r.Response, res)
+	return res, err
+}
+
+// UnaryServerInterceptor returns the result of the first call for every retry of a call with the same
+// idempotency key, keys are kept per user and method for ttl. Only the given methods, e.g.
+// container.ContainerService/CreateContainer, accept keys. Calls failing with an error are not kept so
+// they can be retried, errors in the response are kept like any other result. If the store fails the
+// call is handled as if it had no key.
+func UnaryServerInterceptor(s Store, ttl time.Duration, methods []string, logger log.Logger) grpc.UnaryServerInterceptor {
+	idempotent := make(map[string]bool)
+	for _, m := range methods {
+		idempotent[m] = true
+	}
+
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		method := strings.TrimPrefix(info.FullMethod, "/")
+		md, _ := metadata.FromIncomingContext(ctx)
+		keys := md[Header]
+		msg, ok := req.(proto.Message)
+		if !idempotent[method] || len(keys) == 0 || keys[0] == "" || !ok {
+			return handler(ctx, req)
+		}
+		if len(keys[0]) > MaxKeyLength {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", ErrKeyTooLong)
+		}
+
+		fp, err := fingerprint(msg)
+		if err != nil {
+			return nil, grpc.Errorf(codes.Internal, "%v", err)
+		}
+
+		id, _ := bart.Caller(ctx)
+		key := fmt.Sprintf("%d:%s:%s", id, method, keys[0])
+
+		r, reserved, err := s.Reserve(key, Record{Fingerprint: fp}, PendingTTL)
+		if err != nil {
+			level.Error(logger).Log("method", method, "err", err)
+			return handler(ctx, req)
+		}
+
+		if !reserved {
+			switch {
+			case r.Fingerprint != fp:
+				return nil, grpc.Errorf(codes.InvalidArgument, "%v", ErrMismatch)
+			case !r.Done:
+				return nil, grpc.Errorf(codes.Aborted, "%v", ErrInProgress)
+			}
+
+			res, err := replay(r)
+			if err != nil {
+				return nil, grpc.Errorf(codes.Internal, "%v", err)
+			}
+			grpc.SetHeader(ctx, metadata.Pairs(ReplayedHeader, "true"))
+			return res, nil
+		}
+
+		res, err := handler(ctx, req)
+		if err != nil {
+			if err := s.Release(key); err != nil {
+				level.Error(logger).Log("method", method, "err", err)
+			}
+			return res, err
+		}
+
+		// if the result can't be kept the key is released, so retries are handled again instead of
+		// failing until the reservation expires
+		err = errors.New("response is no protobuf message")
+		if resMsg, ok := res.(proto.Message); ok {
+			r = Record{
+				Fingerprint: fp,
+				Done:        true,
+				Type:        proto.MessageName(resMsg),
+			}
+			r.Response, err = proto.Marshal(resMsg)
+			if err == nil {
+				err = s.Save(key, r, ttl)
+			}
+		}
+		if err != nil {
+			level.Error(logger).Log("method", method, "err", err)
+			s.Release(key)
+		}
+		return res, nil
+	}
+}
@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/redis"
+)
+
+// RedisKeyPrefix is prepended to the keys of the records in Redis
+const RedisKeyPrefix = "kroo:idempotency:"
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func milliseconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Millisecond), 10)
+}
+
+func (s *redisStore) Reserve(key string, r Record, ttl time.Duration) (Record, bool, error) {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	// the record may expire between SET and GET, the key is free again then
+	for i := 0; i < 2; i++ {
+		reply, err := s.client.Do("SET", RedisKeyPrefix+key, string(value), "NX", "PX", milliseconds(ttl))
+		if err != nil {
+			return Record{}, false, err
+		}
+		if reply != nil {
+			return r, true, nil
+		}
+
+		reply, err = s.client.Do("GET", RedisKeyPrefix+key)
+		if err != nil {
+			return Record{}, false, err
+		}
+		if stored, ok := reply.(string); ok {
+			existing := Record{}
+			err = json.Unmarshal([]byte(stored), &existing)
+			return existing, false, err
+		}
+	}
+	return Record{}, false, errors.New("redis: key could not be reserved")
+}
+
+func (s *redisStore) Save(key string, r Record, ttl time.Duration) error {
+	value, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Do("SET", RedisKeyPrefix+key, string(value), "PX", milliseconds(ttl))
+	return err
+}
+
+func (s *redisStore) Release(key string) error {
+	_, err := s.client.Do("DEL", RedisKeyPrefix+key)
+	return err
+}
+
+// NewRedisStore returns a Store keeping the records in the Redis server at rawurl, which has the
+// form redis://[:password@]host[:port][/db]. The records are shared by every node using the server.
+func NewRedisStore(rawurl string) (Store, error) {
+	c, err := redis.NewClient(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStore{
+		client: c,
+	}, nil
+}
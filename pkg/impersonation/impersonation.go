@@ -0,0 +1,317 @@
+// Package impersonation lets admins act as a user for support purposes. Every impersonation is a
+// time-boxed session the user is notified of, the calls made in a session run with the permissions
+// of the user and are recorded in an audit trail as calls of the admin as the user.
+package impersonation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+)
+
+const (
+	// MetadataKey is the gRPC metadata key of the id of the session a call of an admin is made in
+	MetadataKey = "x-impersonate"
+
+	// DefaultDuration is the duration of sessions which are started without one
+	DefaultDuration = 30 * time.Minute
+
+	// MaxDuration is the longest a session may last, a longer impersonation needs a new session
+	MaxDuration = 4 * time.Hour
+)
+
+// Methods recorded in the audit trail besides the calls made in a session
+const (
+	// Started is recorded when a session starts
+	Started = "impersonation/start"
+	// Stopped is recorded when a session is stopped before it ends
+	Stopped = "impersonation/stop"
+)
+
+var (
+	// ErrInvalidDuration is returned if a session should last longer than MaxDuration
+	ErrInvalidDuration = fmt.Errorf("an impersonation may last at most %s", MaxDuration)
+
+	// ErrNoReason is returned if a session is started without a reason
+	ErrNoReason = errors.New("a reason for the impersonation is required")
+
+	// ErrSelf is returned if an admin tries to impersonate themselves
+	ErrSelf = errors.New("admins can't impersonate themselves")
+
+	// ErrSessionNotExist is returned if a session does not exist, ended already or belongs to another admin
+	ErrSessionNotExist = errors.New("impersonation session does not exist or ended")
+)
+
+// Session is a period in which the admin AdminID may act as the user UserID
+type Session struct {
+	ID      uint `gorm:"primary_key"`
+	AdminID uint
+	UserID  uint
+	Reason  string
+	Start   time.Time
+	End     time.Time
+}
+
+// TableName sets Session's database table name
+func (Session) TableName() string {
+	return "impersonation_sessions"
+}
+
+// Entry is a record of the audit trail, Error is empty if the call succeeded
+type Entry struct {
+	ID        uint `gorm:"primary_key"`
+	SessionID uint
+	AdminID   uint
+	UserID    uint
+	Method    string
+	Error     string
+	Time      time.Time
+}
+
+// TableName sets Entry's database table name
+func (Entry) TableName() string {
+	return "impersonation_audit"
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("admin %d as user %d: %s", e.AdminID, e.UserID, e.Method)
+}
+
+// Notifier sends the email telling a user about an impersonation, it is satisfied by the mail service
+type Notifier interface {
+	Notify(refID uint, template string, data interface{}) (uint, error)
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type sessionKey struct{}
+
+// ContextWithSession returns a copy of ctx carrying the session a call is made in
+func ContextWithSession(ctx context.Context, s Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// FromContext returns the session a call is made in, ok is false if the caller impersonates nobody
+func FromContext(ctx context.Context) (s Session, ok bool) {
+	s, ok = ctx.Value(sessionKey{}).(Session)
+	return s, ok
+}
+
+// Sessions stores the impersonation sessions and their audit trail
+type Sessions struct {
+	db     dbAdapter
+	mails  Notifier
+	logger log.Logger
+
+	mtx sync.Mutex
+}
+
+// activeSession returns the session id if it is active at now, the sessions of every admin are found if
+// adminID is 0
+func (s *Sessions) activeSession(id uint, adminID uint, now time.Time) (Session, error) {
+	session := Session{}
+	var err error
+	if adminID == 0 {
+		err = s.db.First(&session, "id = ? AND \"start\" <= ? AND \"end\" > ?", id, now, now)
+	} else {
+		err = s.db.First(&session, "id = ? AND admin_id = ? AND \"start\" <= ? AND \"end\" > ?", id, adminID, now, now)
+	}
+	if s.db.IsNotFound(err) {
+		return Session{}, ErrSessionNotExist
+	}
+	if err != nil {
+		return Session{}, err
+	}
+	return session, nil
+}
+
+// record stores an entry of the audit trail and logs it
+func (s *Sessions) record(e *Entry) error {
+	logger := log.With(s.logger, "session", e.SessionID, "admin", e.AdminID, "user", e.UserID, "method", e.Method)
+	if e.Error != "" {
+		logger = log.With(logger, "err", e.Error)
+	}
+	level.Info(logger).Log("msg", e.String())
+
+	return s.db.Create(e)
+}
+
+// Start stores a session of the admin AdminID as the user UserID lasting d from now, it lasts DefaultDuration
+// if d is zero. The user is notified, the session starts even if the email can't be sent.
+func (s *Sessions) Start(session *Session, d time.Duration) error {
+	if d == 0 {
+		d = DefaultDuration
+	}
+	if d < 0 || d > MaxDuration {
+		return ErrInvalidDuration
+	}
+	if session.Reason == "" {
+		return ErrNoReason
+	}
+	if session.AdminID == session.UserID {
+		return ErrSelf
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	session.ID = 0
+	session.Start = time.Now()
+	session.End = session.Start.Add(d)
+	err := s.db.Create(session)
+	if err != nil {
+		return err
+	}
+
+	err = s.record(&Entry{
+		SessionID: session.ID,
+		AdminID:   session.AdminID,
+		UserID:    session.UserID,
+		Method:    Started,
+		Time:      session.Start,
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.mails != nil {
+		_, err = s.mails.Notify(session.UserID, mail.Impersonated, mail.ImpersonationData{
+			Reason: session.Reason,
+			Start:  session.Start,
+			End:    session.End,
+		})
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "the user could not be notified of the impersonation", "session", session.ID, "user", session.UserID, "err", err)
+		}
+	}
+	return nil
+}
+
+// Stop ends the session id right away
+func (s *Sessions) Stop(id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	session, err := s.activeSession(id, 0, now)
+	if err != nil {
+		return err
+	}
+
+	s.db.Begin()
+	err = s.db.Where("id = ?", id)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Update(&Session{}, &Session{End: now})
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+
+	return s.record(&Entry{
+		SessionID: session.ID,
+		AdminID:   session.AdminID,
+		UserID:    session.UserID,
+		Method:    Stopped,
+		Time:      now,
+	})
+}
+
+// Active returns the sessions which did not end yet ordered by their start
+func (s *Sessions) Active(out *[]Session) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+	sessions := []Session{}
+	err := s.db.FindOrdered(&sessions, "\"start\"", 0, "\"start\" <= ? AND \"end\" > ?", now, now)
+	if err != nil {
+		return err
+	}
+
+	*out = append(*out, sessions...)
+	return nil
+}
+
+// Resolve returns the session id of the admin adminID if it is active
+func (s *Sessions) Resolve(id uint, adminID uint) (Session, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.activeSession(id, adminID, time.Now())
+}
+
+// Record adds a call made in session to the audit trail, err is the error the call failed with. The call
+// is logged even if it can't be stored, so it is not refused because of it.
+func (s *Sessions) Record(session Session, method string, err error) {
+	e := &Entry{
+		SessionID: session.ID,
+		AdminID:   session.AdminID,
+		UserID:    session.UserID,
+		Method:    method,
+		Time:      time.Now(),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	err = s.record(e)
+	if err != nil {
+		level.Error(s.logger).Log("msg", "the call could not be added to the audit trail", "session", session.ID, "method", method, "err", err)
+	}
+}
+
+// Trail returns the audit trail of the session id ordered by time, or the trail of every session if id is zero
+func (s *Sessions) Trail(id uint, out *[]Entry) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	es := []Entry{}
+	var err error
+	if id == 0 {
+		err = s.db.FindOrdered(&es, "\"time\", id", 0)
+	} else {
+		err = s.db.FindOrdered(&es, "\"time\", id", 0, "session_id = ?", id)
+	}
+	if err != nil {
+		return err
+	}
+
+	*out = append(*out, es...)
+	return nil
+}
+
+// NewSessions returns Sessions stored in db, mails may be nil if the daemon sends no emails
+func NewSessions(db dbAdapter, mails Notifier, logger log.Logger) (*Sessions, error) {
+	err := db.AutoMigrate(&Session{}, &Entry{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Sessions{
+		db:     db,
+		mails:  mails,
+		logger: logger,
+	}, nil
+}
@@ -0,0 +1,13 @@
+package impersonation_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestImpersonation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Impersonation Suite")
+}
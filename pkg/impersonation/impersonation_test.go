@@ -0,0 +1,138 @@
+package impersonation_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type notifier struct {
+	sent map[uint]mail.ImpersonationData
+	err  error
+}
+
+func (n *notifier) Notify(refID uint, template string, data interface{}) (uint, error) {
+	if n.err != nil {
+		return 0, n.err
+	}
+	n.sent[refID] = data.(mail.ImpersonationData)
+	return 1, nil
+}
+
+var _ = Describe("Impersonation", func() {
+	var (
+		db       *testutils.MockDB
+		mails    *notifier
+		sessions *impersonation.Sessions
+	)
+
+	BeforeEach(func() {
+		db = testutils.NewMockDB()
+		mails = &notifier{sent: map[uint]mail.ImpersonationData{}}
+		var err error
+		sessions, err = impersonation.NewSessions(db, mails, log.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("NewSessions", func() {
+		It("Should return db errors", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := impersonation.NewSessions(db, nil, log.NewNopLogger())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Start", func() {
+		It("Should store a session and notify the user", func() {
+			s := &impersonation.Session{AdminID: 1, UserID: 2, Reason: "ticket 42"}
+			Expect(sessions.Start(s, 0)).To(Succeed())
+			Expect(s.ID).NotTo(BeZero())
+			Expect(s.End.Sub(s.Start)).To(Equal(impersonation.DefaultDuration))
+			Expect(mails.sent[2]).To(Equal(mail.ImpersonationData{Reason: "ticket 42", Start: s.Start, End: s.End}))
+
+			out := []impersonation.Session{}
+			Expect(sessions.Active(&out)).To(Succeed())
+			Expect(out).To(HaveLen(1))
+			Expect(out[0].UserID).To(BeEquivalentTo(2))
+		})
+
+		It("Should reject sessions which last too long, lack a reason or impersonate the admin", func() {
+			Expect(sessions.Start(&impersonation.Session{AdminID: 1, UserID: 2, Reason: "ticket 42"}, 5*time.Hour)).To(Equal(impersonation.ErrInvalidDuration))
+			Expect(sessions.Start(&impersonation.Session{AdminID: 1, UserID: 2, Reason: "ticket 42"}, -time.Hour)).To(Equal(impersonation.ErrInvalidDuration))
+			Expect(sessions.Start(&impersonation.Session{AdminID: 1, UserID: 2}, time.Hour)).To(Equal(impersonation.ErrNoReason))
+			Expect(sessions.Start(&impersonation.Session{AdminID: 1, UserID: 1, Reason: "ticket 42"}, time.Hour)).To(Equal(impersonation.ErrSelf))
+		})
+
+		It("Should start sessions even if the user can't be notified", func() {
+			mails.err = errors.New("no email address")
+			s := &impersonation.Session{AdminID: 1, UserID: 2, Reason: "ticket 42"}
+			Expect(sessions.Start(s, time.Hour)).To(Succeed())
+			_, err := sessions.Resolve(s.ID, 1)
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("Should only resolve the active sessions of the admin", func() {
+			s := &impersonation.Session{AdminID: 1, UserID: 2, Reason: "ticket 42"}
+			Expect(sessions.Start(s, time.Hour)).To(Succeed())
+
+			resolved, err := sessions.Resolve(s.ID, 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved.UserID).To(BeEquivalentTo(2))
+
+			_, err = sessions.Resolve(s.ID, 3)
+			Expect(err).To(Equal(impersonation.ErrSessionNotExist))
+
+			Expect(sessions.Stop(s.ID)).To(Succeed())
+			_, err = sessions.Resolve(s.ID, 1)
+			Expect(err).To(Equal(impersonation.ErrSessionNotExist))
+			Expect(sessions.Stop(s.ID)).To(Equal(impersonation.ErrSessionNotExist))
+		})
+	})
+
+	Describe("Trail", func() {
+		It("Should record the calls made in a session as calls of the admin as the user", func() {
+			s := &impersonation.Session{AdminID: 1, UserID: 2, Reason: "ticket 42"}
+			Expect(sessions.Start(s, time.Hour)).To(Succeed())
+			other := &impersonation.Session{AdminID: 3, UserID: 4, Reason: "ticket 43"}
+			Expect(sessions.Start(other, time.Hour)).To(Succeed())
+
+			sessions.Record(*s, "container.ContainerService/Instances", nil)
+			sessions.Record(*s, "container.ContainerService/RemoveContainer", errors.New("not allowed"))
+
+			trail := []impersonation.Entry{}
+			Expect(sessions.Trail(s.ID, &trail)).To(Succeed())
+			Expect(trail).To(HaveLen(3))
+			Expect(trail[0].Method).To(Equal(impersonation.Started))
+			Expect(trail[1].String()).To(Equal("admin 1 as user 2: container.ContainerService/Instances"))
+			Expect(trail[1].Error).To(BeEmpty())
+			Expect(trail[2].Error).To(Equal("not allowed"))
+
+			all := []impersonation.Entry{}
+			Expect(sessions.Trail(0, &all)).To(Succeed())
+			Expect(all).To(HaveLen(4))
+		})
+	})
+
+	Describe("FromContext", func() {
+		It("Should return the session of a call", func() {
+			_, ok := impersonation.FromContext(context.Background())
+			Expect(ok).To(BeFalse())
+
+			s := impersonation.Session{ID: 1, AdminID: 1, UserID: 2}
+			out, ok := impersonation.FromContext(impersonation.ContextWithSession(context.Background(), s))
+			Expect(ok).To(BeTrue())
+			Expect(out).To(Equal(s))
+		})
+	})
+})
@@ -0,0 +1,519 @@
+// Package jobs runs long operations in the background. Jobs are persisted, so they survive restarts
+// of the daemon, and failed jobs are retried with backoff until they are given up as dead.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/retry"
+)
+
+// States of a job
+const (
+	// Pending jobs wait for their RunAt time and a free worker
+	Pending = "pending"
+	// Running jobs are handled by a worker
+	Running = "running"
+	// Succeeded jobs were handled without an error
+	Succeeded = "succeeded"
+	// Dead jobs failed on every attempt, they are only run again if they are requeued
+	Dead = "dead"
+)
+
+var (
+	// ErrUnknownType is returned if a job is enqueued with a type no handler was registered for
+	ErrUnknownType = errors.New("unknown job type")
+
+	// ErrJobNotExist is returned if a job does not exist
+	ErrJobNotExist = errors.New("job does not exist")
+
+	// ErrJobActive is returned if a pending or running job is requeued
+	ErrJobActive = errors.New("job is pending or running")
+)
+
+// Job is a persisted background operation
+type Job struct {
+	ID      uint `gorm:"primary_key"`
+	Type    string
+	Payload string
+	State   string
+	// Attempts is the number of times the job was handled, Limit the number of attempts after which it is dead
+	Attempts  uint
+	Limit     uint
+	LastError string
+	RunAt     time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName sets Job's database table name
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// Decode unmarshals the payload of the job into v
+func (j Job) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// Handler runs a job, if it returns an error the job is retried. ctx is cancelled when the queue stops,
+// a job whose handler returned after that is run again once the queue is started again.
+type Handler func(ctx context.Context, j Job) error
+
+// Options configure the handling of a job type
+type Options struct {
+	// Workers is the number of jobs of the type which run at the same time
+	Workers int
+	// MaxAttempts is the number of attempts after which a job is dead
+	MaxAttempts uint
+	// Backoff is the delay before the first retry, it doubles with every further attempt up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter is the fraction of the delay which is randomized, so jobs which failed together are not retried together
+	Jitter float64
+}
+
+// DefaultOptions run one job at a time and give up after five attempts within about an hour
+var DefaultOptions = Options{
+	Workers:     1,
+	MaxAttempts: 5,
+	Backoff:     time.Minute,
+	MaxBackoff:  30 * time.Minute,
+}
+
+// delay returns the time to wait before the next attempt of a job which failed attempts times
+func (o Options) delay(attempts uint) time.Duration {
+	return retry.Policy{
+		Backoff:    o.Backoff,
+		MaxBackoff: o.MaxBackoff,
+		Jitter:     o.Jitter,
+	}.Delay(int(attempts))
+}
+
+type pool struct {
+	opts Options
+	h    Handler
+	busy int
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+// Queue stores jobs and runs them with the handler registered for their type
+type Queue struct {
+	db      dbAdapter
+	logger  log.Logger
+	metrics *retry.Metrics
+
+	mtx   sync.Mutex
+	pools map[string]*pool
+	wake  chan struct{}
+}
+
+// Register sets the handler and options of a job type, it has to be called before jobs of the type are enqueued
+func (q *Queue) Register(typ string, o Options, h Handler) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	if o.Workers < 1 {
+		o.Workers = 1
+	}
+	if o.MaxAttempts < 1 {
+		o.MaxAttempts = 1
+	}
+	q.pools[typ] = &pool{
+		opts: o,
+		h:    h,
+	}
+}
+
+// SetMetrics records the retries of the jobs with m, the operations are named by the job types
+func (q *Queue) SetMetrics(m *retry.Metrics) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	q.metrics = m
+}
+
+// notify makes Run look for due jobs
+func (q *Queue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue stores a job of type typ with the payload marshalled as JSON, it runs as soon as a worker is free
+func (q *Queue) Enqueue(typ string, payload interface{}) (uint, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	p, ok := q.pools[typ]
+	if !ok {
+		return 0, ErrUnknownType
+	}
+
+	now := time.Now().UTC()
+	j := &Job{
+		Type:      typ,
+		Payload:   string(data),
+		State:     Pending,
+		Limit:     p.opts.MaxAttempts,
+		RunAt:     now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = q.db.Create(j)
+	if err != nil {
+		return 0, err
+	}
+
+	q.notify()
+	return j.ID, nil
+}
+
+// Jobs returns the jobs in state, or all jobs if state is empty, in the order they were enqueued
+func (q *Queue) Jobs(state string, out *[]Job) error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	return q.jobs(state, out)
+}
+
+func (q *Queue) jobs(state string, out *[]Job) error {
+	if state == "" {
+		return q.db.FindOrdered(out, "id", 0)
+	}
+	return q.db.FindOrdered(out, "id", 0, "state = ?", state)
+}
+
+func (q *Queue) job(id uint) (Job, error) {
+	j := Job{}
+	err := q.db.Where("id = ?", id)
+	if err != nil {
+		return j, err
+	}
+
+	err = q.db.First(&j, "id = ?", id)
+	if err != nil || j.ID != id {
+		return Job{}, ErrJobNotExist
+	}
+	return j, nil
+}
+
+// update stores the changed fields of a job, zero values are not stored
+func (q *Queue) update(id uint, changes *Job) error {
+	changes.UpdatedAt = time.Now().UTC()
+
+	q.db.Begin()
+	err := q.db.Where("id = ?", id)
+	if err != nil {
+		q.db.Rollback()
+		return err
+	}
+
+	err = q.db.Update(&Job{}, changes)
+	if err != nil {
+		q.db.Rollback()
+		return err
+	}
+	q.db.Commit()
+	return nil
+}
+
+// Requeue runs a dead or succeeded job again, it is granted the MaxAttempts of its type once more
+func (q *Queue) Requeue(id uint) error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	j, err := q.job(id)
+	if err != nil {
+		return err
+	}
+
+	if j.State == Pending || j.State == Running {
+		return ErrJobActive
+	}
+
+	p, ok := q.pools[j.Type]
+	if !ok {
+		return ErrUnknownType
+	}
+
+	err = q.update(id, &Job{
+		State: Pending,
+		Limit: j.Attempts + p.opts.MaxAttempts,
+		RunAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	q.notify()
+	return nil
+}
+
+// Schedule enqueues a job of type typ right away and then every interval until stop is closed,
+// no job is enqueued while another job of the type is still pending or running
+func (q *Queue) Schedule(typ string, payload interface{}, interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		if !q.active(typ) {
+			_, err := q.Enqueue(typ, payload)
+			if err != nil {
+				level.Error(q.logger).Log("type", typ, "err", err)
+			}
+		}
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// active reports whether a job of type typ is pending or running
+func (q *Queue) active(typ string) bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	active := []Job{}
+	err := q.db.FindOrdered(&active, "id", 1, "type = ? AND state IN (?)", typ, []string{Pending, Running})
+	return err == nil && len(active) > 0
+}
+
+// Prune removes the succeeded jobs which finished before before, dead jobs are kept until they are requeued
+func (q *Queue) Prune(before time.Time) (int, error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	old := []Job{}
+	err := q.db.Find(&old, "state = ? AND updated_at < ?", Succeeded, before)
+	if err != nil || len(old) == 0 {
+		return 0, err
+	}
+
+	err = q.db.Delete(&Job{}, "state = ? AND updated_at < ?", Succeeded, before)
+	if err != nil {
+		return 0, err
+	}
+	return len(old), nil
+}
+
+// PruneJob is the type of the job removing old succeeded jobs
+const PruneJob = "jobs.prune"
+
+// PruneHandler returns the handler of PruneJob, it removes the jobs which succeeded longer than retention ago
+func PruneHandler(q *Queue, retention time.Duration) Handler {
+	return func(ctx context.Context, j Job) error {
+		n, err := q.Prune(time.Now().Add(-retention))
+		if n > 0 {
+			level.Info(q.logger).Log("msg", "pruned succeeded jobs", "jobs", n)
+		}
+		return err
+	}
+}
+
+// recover makes the jobs which were running when the daemon stopped pending again
+func (q *Queue) recover() error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	running := []Job{}
+	err := q.jobs(Running, &running)
+	if err != nil {
+		return err
+	}
+
+	for _, j := range running {
+		err = q.update(j.ID, &Job{State: Pending})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dispatch starts the due jobs of types with a free worker, it returns the time the next pending job is due
+// or the zero time if there is none
+func (q *Queue) dispatch(ctx context.Context, wg *sync.WaitGroup) time.Time {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	now := time.Now().UTC()
+
+	// only the due jobs of registered types with free workers are selected, jobs of unknown types may
+	// belong to a newer version of the daemon, they stay pending
+	for typ, p := range q.pools {
+		free := p.opts.Workers - p.busy
+		if free <= 0 {
+			continue
+		}
+
+		due := []Job{}
+		err := q.db.FindOrdered(&due, "run_at, id", free, "state = ? AND type = ? AND run_at <= ?", Pending, typ, now)
+		if err != nil {
+			level.Error(q.logger).Log("type", typ, "err", err)
+			continue
+		}
+
+		for _, j := range due {
+			err = q.update(j.ID, &Job{State: Running})
+			if err != nil {
+				level.Error(q.logger).Log("job", j.ID, "type", j.Type, "err", err)
+				continue
+			}
+
+			p.busy++
+			wg.Add(1)
+			go func(p *pool, j Job) {
+				defer wg.Done()
+				q.finish(ctx, p, j, q.handle(ctx, p, j))
+			}(p, j)
+		}
+	}
+
+	next := []Job{}
+	err := q.db.FindOrdered(&next, "run_at", 1, "state = ? AND run_at > ?", Pending, now)
+	if err != nil {
+		level.Error(q.logger).Log("err", err)
+		return time.Time{}
+	}
+	if len(next) == 0 {
+		return time.Time{}
+	}
+	return next[0].RunAt
+}
+
+// handle runs the handler of a job, a panic fails the job instead of the daemon
+func (q *Queue) handle(ctx context.Context, p *pool, j Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return p.h(ctx, j)
+}
+
+// finish stores the result of a job
+func (q *Queue) finish(ctx context.Context, p *pool, j Job, err error) {
+	q.mtx.Lock()
+	defer func() {
+		p.busy--
+		q.mtx.Unlock()
+		q.notify()
+	}()
+
+	changes := &Job{}
+	switch {
+	case err == nil:
+		changes.State = Succeeded
+		changes.Attempts = j.Attempts + 1
+		level.Debug(q.logger).Log("job", j.ID, "type", j.Type, "msg", "succeeded")
+	case ctx.Err() != nil:
+		changes.State = Pending
+		level.Info(q.logger).Log("job", j.ID, "type", j.Type, "msg", "interrupted, it runs again after a restart")
+	default:
+		changes.Attempts = j.Attempts + 1
+		changes.LastError = err.Error()
+		if changes.Attempts >= j.Limit {
+			changes.State = Dead
+			q.metrics.Exhausted(j.Type)
+			level.Error(q.logger).Log("job", j.ID, "type", j.Type, "attempts", changes.Attempts, "err", err)
+		} else {
+			changes.State = Pending
+			changes.RunAt = time.Now().UTC().Add(p.opts.delay(changes.Attempts))
+			q.metrics.Retried(j.Type)
+			level.Warn(q.logger).Log("job", j.ID, "type", j.Type, "attempts", changes.Attempts, "retry", changes.RunAt, "err", err)
+		}
+	}
+
+	uerr := q.update(j.ID, changes)
+	if uerr != nil {
+		level.Error(q.logger).Log("job", j.ID, "type", j.Type, "err", uerr)
+	}
+}
+
+// Run starts the due jobs as soon as they are due or a job is enqueued or finished until stop is closed,
+// interval is the longest time between two looks at the stored jobs.
+// Jobs which were running when the daemon stopped are run again. On stop the context of the running
+// jobs is cancelled and Run returns once their handlers returned.
+func (q *Queue) Run(interval time.Duration, stop <-chan struct{}) {
+	err := q.recover()
+	if err != nil {
+		level.Error(q.logger).Log("err", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wg := &sync.WaitGroup{}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	t := time.NewTimer(interval)
+	defer t.Stop()
+
+	for {
+		wait := interval
+		next := q.dispatch(ctx, wg)
+		if !next.IsZero() && time.Until(next) < wait {
+			wait = time.Until(next)
+		}
+
+		if !t.Stop() {
+			select {
+			case <-t.C:
+			default:
+			}
+		}
+		t.Reset(wait)
+
+		select {
+		case <-t.C:
+		case <-q.wake:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewQueue returns a Queue storing its jobs in db
+func NewQueue(db dbAdapter, logger log.Logger) (*Queue, error) {
+	err := db.AutoMigrate(&Job{})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Queue{
+		db:     db,
+		logger: logger,
+		pools:  make(map[string]*pool),
+		wake:   make(chan struct{}, 1),
+	}, nil
+}
@@ -0,0 +1,308 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+	"github.com/kontainerooo/kontainer.ooo/pkg/retry"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Jobs", func() {
+	var (
+		db   *testutils.MockDB
+		q    *jobs.Queue
+		stop chan struct{}
+		done chan struct{}
+		opts = jobs.Options{
+			Workers:     1,
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			MaxBackoff:  5 * time.Millisecond,
+		}
+	)
+
+	run := func() {
+		stop = make(chan struct{})
+		done = make(chan struct{})
+		go func() {
+			defer close(done)
+			q.Run(time.Hour, stop)
+		}()
+	}
+
+	shutdown := func() {
+		close(stop)
+		Eventually(done).Should(BeClosed())
+	}
+
+	job := func(id uint) func() jobs.Job {
+		return func() jobs.Job {
+			js := []jobs.Job{}
+			q.Jobs("", &js)
+			for _, j := range js {
+				if j.ID == id {
+					return j
+				}
+			}
+			return jobs.Job{}
+		}
+	}
+
+	state := func(id uint) func() string {
+		return func() string {
+			return job(id)().State
+		}
+	}
+
+	BeforeEach(func() {
+		db = testutils.NewMockDB()
+		var err error
+		q, err = jobs.NewQueue(db, log.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("Should return the db error", func() {
+		db := testutils.NewMockDB()
+		db.SetError(1)
+		_, err := jobs.NewQueue(db, log.NewNopLogger())
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Should only enqueue registered types", func() {
+		_, err := q.Enqueue("image.pull", nil)
+		Expect(err).To(Equal(jobs.ErrUnknownType))
+	})
+
+	It("Should run a job with its payload", func() {
+		payloads := make(chan string, 1)
+		q.Register("image.pull", opts, func(ctx context.Context, j jobs.Job) error {
+			var image string
+			err := j.Decode(&image)
+			payloads <- image
+			return err
+		})
+
+		id, err := q.Enqueue("image.pull", "nginx")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state(id)()).To(Equal(jobs.Pending))
+
+		run()
+		defer shutdown()
+
+		Eventually(payloads).Should(Receive(Equal("nginx")))
+		Eventually(state(id)).Should(Equal(jobs.Succeeded))
+		Expect(job(id)().Attempts).To(BeEquivalentTo(1))
+	})
+
+	It("Should retry a failed job", func() {
+		fails := 1
+		q.Register("gc", opts, func(ctx context.Context, j jobs.Job) error {
+			if fails > 0 {
+				fails--
+				return errors.New("busy")
+			}
+			return nil
+		})
+
+		run()
+		defer shutdown()
+
+		id, _ := q.Enqueue("gc", nil)
+		Eventually(state(id)).Should(Equal(jobs.Succeeded))
+		Expect(job(id)().Attempts).To(BeEquivalentTo(2))
+		Expect(job(id)().LastError).To(Equal("busy"))
+	})
+
+	It("Should give up a job after its attempts and requeue it", func() {
+		var (
+			mtx   sync.Mutex
+			calls int
+			fail  = true
+		)
+		q.Register("build", opts, func(ctx context.Context, j jobs.Job) error {
+			mtx.Lock()
+			defer mtx.Unlock()
+			calls++
+			if fail {
+				return errors.New("broken")
+			}
+			return nil
+		})
+
+		run()
+		defer shutdown()
+
+		id, _ := q.Enqueue("build", nil)
+		Eventually(state(id)).Should(Equal(jobs.Dead))
+		Expect(job(id)().Attempts).To(BeEquivalentTo(3))
+
+		dead := []jobs.Job{}
+		Expect(q.Jobs(jobs.Dead, &dead)).To(Succeed())
+		Expect(dead).To(HaveLen(1))
+
+		mtx.Lock()
+		Expect(calls).To(Equal(3))
+		fail = false
+		mtx.Unlock()
+
+		Expect(q.Requeue(id)).To(Succeed())
+		Eventually(state(id)).Should(Equal(jobs.Succeeded))
+		Expect(job(id)().Attempts).To(BeEquivalentTo(4))
+	})
+
+	It("Should record the retries of the jobs", func() {
+		provider := metrics.NewPrometheusProvider(metrics.Namespace)
+		q.SetMetrics(retry.NewMetrics(provider))
+		q.Register("build", opts, func(ctx context.Context, j jobs.Job) error {
+			return errors.New("broken")
+		})
+
+		run()
+		defer shutdown()
+
+		id, _ := q.Enqueue("build", nil)
+		Eventually(state(id)).Should(Equal(jobs.Dead))
+
+		rec := httptest.NewRecorder()
+		provider.Handler().ServeHTTP(rec, httptest.NewRequest("GET", metrics.Path, nil))
+		body, _ := ioutil.ReadAll(rec.Body)
+		Expect(string(body)).To(ContainSubstring(`kontainerooo_retries_total{operation="build"} 2`))
+		Expect(string(body)).To(ContainSubstring(`kontainerooo_retries_exhausted_total{operation="build"} 1`))
+	})
+
+	It("Should not requeue active or unknown jobs", func() {
+		q.Register("build", opts, func(ctx context.Context, j jobs.Job) error {
+			return nil
+		})
+
+		id, _ := q.Enqueue("build", nil)
+		Expect(q.Requeue(id)).To(Equal(jobs.ErrJobActive))
+		Expect(q.Requeue(id + 1)).To(Equal(jobs.ErrJobNotExist))
+	})
+
+	It("Should limit the jobs running at the same time", func() {
+		var (
+			mtx          sync.Mutex
+			running, max int
+		)
+		o := opts
+		o.Workers = 2
+		q.Register("migrate", o, func(ctx context.Context, j jobs.Job) error {
+			mtx.Lock()
+			running++
+			if running > max {
+				max = running
+			}
+			mtx.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mtx.Lock()
+			running--
+			mtx.Unlock()
+			return nil
+		})
+
+		ids := []uint{}
+		for i := 0; i < 5; i++ {
+			id, _ := q.Enqueue("migrate", i)
+			ids = append(ids, id)
+		}
+
+		run()
+		defer shutdown()
+
+		for _, id := range ids {
+			Eventually(state(id)).Should(Equal(jobs.Succeeded))
+		}
+		mtx.Lock()
+		defer mtx.Unlock()
+		Expect(max).To(Equal(2))
+	})
+
+	It("Should run interrupted jobs again", func() {
+		started := make(chan struct{}, 1)
+		q.Register("image.pull", opts, func(ctx context.Context, j jobs.Job) error {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		id, _ := q.Enqueue("image.pull", nil)
+		run()
+		Eventually(started).Should(Receive())
+		Expect(state(id)()).To(Equal(jobs.Running))
+		shutdown()
+
+		Expect(state(id)()).To(Equal(jobs.Pending))
+		Expect(job(id)().Attempts).To(BeZero())
+
+		q.Register("image.pull", opts, func(ctx context.Context, j jobs.Job) error {
+			return nil
+		})
+		run()
+		defer shutdown()
+		Eventually(state(id)).Should(Equal(jobs.Succeeded))
+	})
+
+	It("Should enqueue scheduled jobs once at a time", func() {
+		release := make(chan struct{})
+		q.Register("gc", opts, func(ctx context.Context, j jobs.Job) error {
+			<-release
+			return nil
+		})
+
+		run()
+		defer shutdown()
+
+		stopSchedule := make(chan struct{})
+		defer close(stopSchedule)
+		go q.Schedule("gc", nil, time.Millisecond, stopSchedule)
+
+		all := func() []jobs.Job {
+			js := []jobs.Job{}
+			q.Jobs("", &js)
+			return js
+		}
+		Eventually(all).Should(HaveLen(1))
+		Consistently(all, 20*time.Millisecond).Should(HaveLen(1))
+
+		close(release)
+		Eventually(func() int { return len(all()) }).Should(BeNumerically(">=", 2))
+	})
+
+	It("Should prune old succeeded jobs", func() {
+		q.Register(jobs.PruneJob, opts, jobs.PruneHandler(q, time.Millisecond))
+		q.Register("build", opts, func(ctx context.Context, j jobs.Job) error {
+			return errors.New("broken")
+		})
+		q.Register("gc", opts, func(ctx context.Context, j jobs.Job) error {
+			return nil
+		})
+
+		run()
+		defer shutdown()
+
+		dead, _ := q.Enqueue("build", nil)
+		succeeded, _ := q.Enqueue("gc", nil)
+		Eventually(state(dead)).Should(Equal(jobs.Dead))
+		Eventually(state(succeeded)).Should(Equal(jobs.Succeeded))
+
+		time.Sleep(5 * time.Millisecond)
+		prune, _ := q.Enqueue(jobs.PruneJob, nil)
+		Eventually(state(succeeded)).Should(BeEmpty())
+		Expect(state(dead)()).To(Equal(jobs.Dead))
+		Expect(state(prune)()).NotTo(BeEmpty())
+	})
+})
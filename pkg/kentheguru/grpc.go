@@ -0,0 +1,310 @@
+package kentheguru
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/kontainerooo/kontainer.ooo/pkg/bart"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kentheguru/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/login"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ratelimit"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// AuthenticateMethod is the gRPC method exchanging credentials for a token, it is the only one callable without a token
+	AuthenticateMethod = "/kentheguru.KenTheGuruService/Authenticate"
+
+	// TokenLifetime is how long a token issued to gRPC clients is valid
+	TokenLifetime = 24 * time.Hour
+)
+
+func (s *service) Authenticate(ctx oldcontext.Context, req *pb.AuthenticationRequest) (*pb.AuthenticationResponse, error) {
+	claims, err := s.MakeEndpoint()(ctx, loginRequest{
+		CheckLoginCredentialsRequest: user.CheckLoginCredentialsRequest{
+			Username: req.Username,
+			Password: req.Password,
+		},
+		Captcha: req.Captcha,
+	})
+	if e, ok := err.(*login.LockedError); ok {
+		grpc.SetHeader(ctx, metadata.Pairs(ratelimit.RetryAfterHeader, strconv.Itoa(ratelimit.RetryAfterSeconds(e.RetryAfter))))
+		return nil, grpc.Errorf(codes.ResourceExhausted, "%v", e)
+	}
+	if err != nil {
+		return &pb.AuthenticationResponse{
+			Error:           err.Error(),
+			CaptchaRequired: err == login.ErrCaptchaRequired,
+		}, nil
+	}
+
+	c := ws.TokenAuthClaims{
+		Data: claims,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(TokenLifetime).Unix(),
+			Issuer:    "KenTheGuru",
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, c).SignedString(s.SigningKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AuthenticationResponse{
+		Token: token,
+	}, nil
+}
+
+func (s *service) SetReloader(r Reloader) {
+	s.Reloader = r
+}
+
+func (s *service) ReloadConfiguration(ctx oldcontext.Context, req *pb.ReloadConfigurationRequest) (*pb.ReloadConfigurationResponse, error) {
+	if s.Reloader == nil {
+		return &pb.ReloadConfigurationResponse{
+			Error: "reloading is not supported",
+		}, nil
+	}
+
+	changed, ignored, err := s.Reloader.Reload()
+	if err != nil {
+		return &pb.ReloadConfigurationResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	return &pb.ReloadConfigurationResponse{
+		Changed: changed,
+		Ignored: ignored,
+	}, nil
+}
+
+func (s *service) SetHealthReporter(h HealthReporter) {
+	s.HealthReporter = h
+}
+
+// unix returns the unix timestamp of t or zero if t is not set
+func unix(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+func (s *service) Health(ctx oldcontext.Context, req *pb.HealthRequest) (*pb.HealthResponse, error) {
+	if s.HealthReporter == nil {
+		return &pb.HealthResponse{
+			Error: "health checks are not supported",
+		}, nil
+	}
+
+	report := s.HealthReporter.Report()
+	res := &pb.HealthResponse{
+		Live:  report.Live,
+		Ready: report.Ready,
+	}
+	for _, c := range report.Checks {
+		res.Checks = append(res.Checks, &pb.HealthCheck{
+			Name:        c.Name,
+			Healthy:     c.Healthy,
+			Error:       c.Error,
+			LastChecked: unix(c.LastChecked),
+			LastError:   unix(c.LastError),
+		})
+	}
+	return res, nil
+}
+
+func (s *service) SetJobQueue(q JobQueue) {
+	s.JobQueue = q
+}
+
+func (s *service) SetImpersonation(i Impersonation) {
+	s.Impersonation = i
+}
+
+func (s *service) Jobs(ctx oldcontext.Context, req *pb.JobsRequest) (*pb.JobsResponse, error) {
+	if s.JobQueue == nil {
+		return &pb.JobsResponse{
+			Error: "jobs are not supported",
+		}, nil
+	}
+
+	js := []jobs.Job{}
+	err := s.JobQueue.Jobs(req.State, &js)
+	if err != nil {
+		return &pb.JobsResponse{
+			Error: err.Error(),
+		}, nil
+	}
+
+	res := &pb.JobsResponse{}
+	for _, j := range js {
+		res.Jobs = append(res.Jobs, &pb.Job{
+			ID:        uint32(j.ID),
+			Type:      j.Type,
+			Payload:   j.Payload,
+			State:     j.State,
+			Attempts:  uint32(j.Attempts),
+			Limit:     uint32(j.Limit),
+			LastError: j.LastError,
+			RunAt:     unix(&j.RunAt),
+			CreatedAt: unix(&j.CreatedAt),
+			UpdatedAt: unix(&j.UpdatedAt),
+		})
+	}
+	return res, nil
+}
+
+func (s *service) RequeueJob(ctx oldcontext.Context, req *pb.RequeueJobRequest) (*pb.RequeueJobResponse, error) {
+	if s.JobQueue == nil {
+		return &pb.RequeueJobResponse{
+			Error: "jobs are not supported",
+		}, nil
+	}
+
+	err := s.JobQueue.Requeue(uint(req.ID))
+	if err != nil {
+		return &pb.RequeueJobResponse{
+			Error: err.Error(),
+		}, nil
+	}
+	return &pb.RequeueJobResponse{}, nil
+}
+
+// authenticate returns the id of the user whose token is sent in the authorization metadata
+func (s *service) authenticate(ctx oldcontext.Context) (uint, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md["authorization"]) == 0 {
+		return 0, errors.New("no token present")
+	}
+
+	tokenString := strings.TrimPrefix(md["authorization"][0], "Bearer ")
+	token, err := jwt.ParseWithClaims(tokenString, &ws.TokenAuthClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.SigningKey, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	claims, ok := token.Claims.(*ws.TokenAuthClaims)
+	if !ok || !token.Valid {
+		return 0, errors.New("token invalid")
+	}
+
+	data, ok := claims.Data.(map[string]interface{})
+	if !ok {
+		return 0, errors.New("malformed claims")
+	}
+
+	id, ok := data["ID"].(float64)
+	if !ok || id == 0 {
+		return 0, errors.New("id malformed")
+	}
+
+	return uint(id), nil
+}
+
+// impersonate returns the session the admin id makes a call in, ok is false if the call carries no session
+func (s *service) impersonate(ctx oldcontext.Context, id uint) (session impersonation.Session, ok bool, err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if len(md[impersonation.MetadataKey]) == 0 {
+		return session, false, nil
+	}
+
+	if s.Impersonation == nil {
+		return session, false, errors.New("impersonation is not supported")
+	}
+
+	sessionID, err := strconv.ParseUint(md[impersonation.MetadataKey][0], 10, 32)
+	if err != nil {
+		return session, false, errors.New("impersonation session malformed")
+	}
+
+	session, err = s.Impersonation.Resolve(uint(sessionID), id)
+	return session, err == nil, err
+}
+
+// Intercept is a gRPC unary server interceptor, every call but Authenticate needs a valid token
+// and has to be permitted by the bart bus. The id of the caller is added to the context of the call.
+// Calls of an admin in an impersonation session are checked and made as the impersonated user, they
+// are recorded in the audit trail of the session.
+func (s *service) Intercept(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if info.FullMethod == AuthenticateMethod {
+		return handler(ctx, req)
+	}
+
+	id, err := s.authenticate(ctx)
+	if err != nil {
+		return nil, grpc.Errorf(codes.Unauthenticated, "%v", err)
+	}
+
+	session, impersonating, err := s.impersonate(ctx, id)
+	if err != nil {
+		return nil, grpc.Errorf(codes.PermissionDenied, "%v", err)
+	}
+
+	if impersonating {
+		id = session.UserID
+		ctx = impersonation.ContextWithSession(ctx, session)
+		if bart.OwnerOnly(info.FullMethod) {
+			err = errors.New("not allowed while impersonating")
+		}
+	}
+
+	if err == nil {
+		err = s.BartBus.CheckGRPC(info.FullMethod, req, id)
+	}
+	if err != nil {
+		err = grpc.Errorf(codes.PermissionDenied, "%v", err)
+		if impersonating {
+			s.Impersonation.Record(session, strings.TrimPrefix(info.FullMethod, "/"), err)
+		}
+		return nil, err
+	}
+
+	res, err := handler(bart.ContextWithCaller(ctx, id), req)
+	if impersonating {
+		s.Impersonation.Record(session, strings.TrimPrefix(info.FullMethod, "/"), err)
+	}
+	return res, err
+}
+
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+func (t tokenCredentials) GetRequestMetadata(ctx oldcontext.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + t.token,
+	}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return t.secure
+}
+
+// NewTokenCredentials returns credentials sending a token issued by Authenticate with every call,
+// they are meant to be used with grpc.WithPerRPCCredentials
+func NewTokenCredentials(token string, secure bool) credentials.PerRPCCredentials {
+	return tokenCredentials{
+		token:  token,
+		secure: secure,
+	}
+}
@@ -0,0 +1,71 @@
+package kentheguru
+
+import (
+	"context"
+	"net"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/login"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Guard protects the logins against guessing passwords and tells users about logins from new networks
+// and devices, it is satisfied by *login.Guard
+type Guard interface {
+	Check(ctx context.Context, username string, c login.Client, response string) error
+	Fail(username string, c login.Client)
+	Succeed(refID uint, username string, c login.Client)
+}
+
+// loginRequest are the credentials of a login together with the response to the CAPTCHA
+type loginRequest struct {
+	user.CheckLoginCredentialsRequest
+	Captcha string
+}
+
+func (s *service) SetGuard(g Guard) {
+	s.Guard = g
+}
+
+// host returns the host of addr, addr itself if it has no port
+func host(addr string) string {
+	h, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return h
+}
+
+// loginClient returns the origin of a login made over the websocket or the gRPC transport. The address
+// forwarded by the gateway is only used if the call came over the loopback interface, other clients could
+// send any address with it.
+func loginClient(ctx context.Context) login.Client {
+	if c, ok := ws.ClientFromContext(ctx); ok {
+		return login.Client{
+			IP:        host(c.Addr),
+			UserAgent: c.UserAgent,
+		}
+	}
+
+	c := login.Client{}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		c.IP = host(p.Addr.String())
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ip := net.ParseIP(c.IP); ip != nil && ip.IsLoopback() {
+		if v := md[login.ClientAddrHeader]; len(v) > 0 && v[0] != "" {
+			c.IP = host(v[0])
+		}
+		if v := md[login.ClientAgentHeader]; len(v) > 0 {
+			c.UserAgent = v[0]
+			return c
+		}
+	}
+	if v := md["user-agent"]; len(v) > 0 {
+		c.UserAgent = v[0]
+	}
+	return c
+}
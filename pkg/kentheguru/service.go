@@ -1,46 +1,170 @@
 // Package kentheguru is the service which provides access to kontainerooo via a websocket server
+// and guards the access via gRPC
 package kentheguru
 
 import (
 	"context"
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/websocket"
 	"github.com/kontainerooo/kontainer.ooo/pkg/bart"
 	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns"
+	"github.com/kontainerooo/kontainer.ooo/pkg/health"
+	"github.com/kontainerooo/kontainer.ooo/pkg/impersonation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kentheguru/pb"
 	"github.com/kontainerooo/kontainer.ooo/pkg/module"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
 	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+	"github.com/kontainerooo/kontainer.ooo/pkg/validation"
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
 )
 
 // Service is the interface describing the KenTheGuru.Service used for communication with the frontend
 type Service interface {
+	pb.KenTheGuruServiceServer
+
 	StartWebsocketTransport(errorChannel chan error, logger log.Logger, wsAddr string)
+
+	// StopWebsocketTransport stops the websocket transport after the requests in progress finished or ctx is done
+	StopWebsocketTransport(ctx context.Context) error
+
+	// Intercept should be used as the unary interceptor of the gRPC server
+	Intercept(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error)
+
+	// SetReloader enables reloading the configuration of the daemon via gRPC
+	SetReloader(r Reloader)
+
+	// SetHealthReporter enables reporting the health of the daemon via gRPC
+	SetHealthReporter(h HealthReporter)
+
+	// SetJobQueue enables listing and requeueing background jobs via gRPC
+	SetJobQueue(q JobQueue)
+
+	// SetImpersonation lets admins make gRPC calls as a user in the sessions of i
+	SetImpersonation(i Impersonation)
+
+	// SetGuard protects the logins via gRPC and websocket with g
+	SetGuard(g Guard)
+
+	// AddWebsocketMiddleware adds middlewares which run after the permission checks of the bart bus,
+	// it has to be called before the websocket transport is started
+	AddWebsocketMiddleware(m ...*ws.Middleware)
+
+	// AddWebsocketService makes the services of optional features available via the websocket transport,
+	// it has to be called before the websocket transport is started
+	AddWebsocketService(sd ...*ws.ServiceDescription)
+
+	// DeprecateWebsocketProtocol announces the deprecation of a websocket protocol in the handshake of
+	// its connections, it has to be called before the websocket transport is started
+	DeprecateWebsocketProtocol(name string, d versioning.Deprecation)
+
+	// SetRequestTimeout sets the deadline of the requests of the websocket transport, there is none if it
+	// is 0. It has to be called before the websocket transport is started.
+	SetRequestTimeout(d time.Duration)
+
+	// SetRequestLimits limits the size of the requests of the websocket transport per endpoint.
+	// It has to be called before the websocket transport is started.
+	SetRequestLimits(l payload.Limits)
+
+	// Broadcast sends msg to every client of the websocket transport as a message of the method me
+	// of KTG, it is dropped if the transport is not started
+	Broadcast(me ws.ProtoID, msg proto.Message)
+}
+
+// Reloader reloads the configuration of the daemon, it returns the settings which were changed
+// and the ones which only take effect after a restart
+type Reloader interface {
+	Reload() (changed []string, ignored []string, err error)
+}
+
+// HealthReporter reports the health of the dependencies of the daemon
+type HealthReporter interface {
+	Report() health.Report
+}
+
+// JobQueue lists and requeues the background jobs of the daemon
+type JobQueue interface {
+	Jobs(state string, out *[]jobs.Job) error
+	Requeue(id uint) error
+}
+
+// Impersonation resolves the sessions admins impersonate users in and records the calls made in them,
+// it is satisfied by impersonation.Sessions
+type Impersonation interface {
+	Resolve(id uint, adminID uint) (impersonation.Session, error)
+	Record(session impersonation.Session, method string, err error)
 }
 
 type service struct {
 	ProtocolMap        ws.ProtocolMap
+	Deprecated         map[string]versioning.Deprecation
 	WebsocketUpgrader  websocket.Upgrader
 	TokenAuth          ws.Authenticator
 	BartBus            bart.Bus
+	SigningKey         []byte
 	SSLConfig          ws.SSLConfig
 	UserEndpoints      user.Endpoints
 	KMIEndpoints       kmi.Endpoints
 	ContainerEndpoints container.Endpoints
 	RoutingEndpoints   routing.Endpoints
 	ModuleEndpoints    module.Endpoints
+	DNSEndpoints       dns.Endpoints
+	Reloader           Reloader
+	HealthReporter     HealthReporter
+	JobQueue           JobQueue
+	Impersonation      Impersonation
+	Guard              Guard
+	Middleware         []*ws.Middleware
+	Services           []*ws.ServiceDescription
+	RequestTimeout     time.Duration
+	RequestLimits      payload.Limits
+
+	mtx     sync.Mutex
+	wss     *ws.Server
+	stopped bool
+}
+
+func (s *service) AddWebsocketMiddleware(m ...*ws.Middleware) {
+	s.Middleware = append(s.Middleware, m...)
+}
+
+func (s *service) AddWebsocketService(sd ...*ws.ServiceDescription) {
+	s.Services = append(s.Services, sd...)
+}
+
+func (s *service) DeprecateWebsocketProtocol(name string, d versioning.Deprecation) {
+	s.Deprecated[name] = d
+}
+
+func (s *service) SetRequestTimeout(d time.Duration) {
+	s.RequestTimeout = d
+}
+
+func (s *service) SetRequestLimits(l payload.Limits) {
+	s.RequestLimits = l
 }
 
 func (s *service) StartWebsocketTransport(errc chan error, logger log.Logger, wsAddr string) {
 	logger = log.With(logger, "transport", "ws")
-	wss := ws.NewServer(s.ProtocolMap, logger, s.WebsocketUpgrader, s.TokenAuth, s.SSLConfig, s.ErrorHandler, ws.Before(s.BartBus.LostAndFound), ws.Before(s.BartBus.GetOff), ws.After(s.BartBus.GetOn))
+	middleware := append([]*ws.Middleware{ws.Before(s.BartBus.LostAndFound), ws.Before(s.BartBus.GetOff), ws.After(s.BartBus.GetOn)}, s.Middleware...)
+	wss := ws.NewServer(s.ProtocolMap, logger, s.WebsocketUpgrader, s.TokenAuth, s.SSLConfig, s.ErrorHandler, middleware...)
+	wss.Deprecated = s.Deprecated
+	wss.Timeout = s.RequestTimeout
+	wss.Limits = s.RequestLimits
 
 	userService := user.MakeWebsocketService(s.UserEndpoints)
 	wss.RegisterService(userService)
@@ -57,20 +181,63 @@ func (s *service) StartWebsocketTransport(errc chan error, logger log.Logger, ws
 	moduleServer := module.MakeWebsocketService(s.ModuleEndpoints)
 	wss.RegisterService(moduleServer)
 
-	logger.Log("addr", wsAddr)
-	errc <- wss.Serve(wsAddr)
+	dnsServer := dns.MakeWebsocketService(s.DNSEndpoints)
+	wss.RegisterService(dnsServer)
+
+	for _, sd := range s.Services {
+		wss.RegisterService(sd)
+	}
+
+	s.mtx.Lock()
+	if s.stopped {
+		s.mtx.Unlock()
+		return
+	}
+	s.wss = wss
+	s.mtx.Unlock()
+
+	level.Info(logger).Log("addr", wsAddr)
+	err := wss.Serve(wsAddr)
+	if err != nil {
+		errc <- err
+	}
+}
+
+func (s *service) Broadcast(me ws.ProtoID, msg proto.Message) {
+	s.mtx.Lock()
+	wss := s.wss
+	s.mtx.Unlock()
+
+	if wss != nil {
+		wss.Broadcast(ws.ProtoIDFromString("KTG"), me, msg)
+	}
+}
+
+func (s *service) StopWebsocketTransport(ctx context.Context) error {
+	s.mtx.Lock()
+	s.stopped = true
+	wss := s.wss
+	s.mtx.Unlock()
+
+	if wss == nil {
+		return nil
+	}
+	return wss.Shutdown(ctx)
 }
 
 func (s *service) DecodeFunc(_ context.Context, data interface{}) (interface{}, error) {
 	request := &pb.AuthenticationRequest{}
-	err := proto.Unmarshal(data.([]byte), request)
+	err := payload.Unmarshal(data.([]byte), request)
 	if err != nil {
 		return nil, err
 	}
 
-	return user.CheckLoginCredentialsRequest{
-		Username: request.Username,
-		Password: request.Password,
+	return loginRequest{
+		CheckLoginCredentialsRequest: user.CheckLoginCredentialsRequest{
+			Username: request.Username,
+			Password: request.Password,
+		},
+		Captcha: request.Captcha,
 	}, nil
 }
 
@@ -83,20 +250,35 @@ func (s *service) EncodeFunc(_ context.Context, res interface{}) (interface{}, e
 
 func (s *service) MakeEndpoint() endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
-		req, ok := request.(user.CheckLoginCredentialsRequest)
+		req, ok := request.(loginRequest)
 		if !ok {
 			return nil, errors.New("invalid request")
 		}
 
-		res, err := s.UserEndpoints.CheckLoginCredentialsEndpoint(ctx, request)
+		client := loginClient(ctx)
+		if s.Guard != nil {
+			err := s.Guard.Check(ctx, req.Username, client, req.Captcha)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		res, err := s.UserEndpoints.CheckLoginCredentialsEndpoint(ctx, req.CheckLoginCredentialsRequest)
 		if err != nil {
 			return nil, err
 		}
 		response := res.(user.CheckLoginCredentialsResponse)
 		if response.ID == 0 {
+			if s.Guard != nil {
+				s.Guard.Fail(req.Username, client)
+			}
 			return nil, errors.New("not authenticated")
 		}
 
+		if s.Guard != nil {
+			s.Guard.Succeed(response.ID, req.Username, client)
+		}
+
 		return bart.Claims{
 			Username: req.Username,
 			ID:       response.ID,
@@ -124,6 +306,15 @@ func (s *service) ErrorHandler(srv, me *ws.ProtoID, err error, ph ws.ProtocolHan
 		Method:  meString,
 	}
 
+	if errs, ok := err.(validation.Errors); ok {
+		for _, f := range errs {
+			res.Fields = append(res.Fields, &pb.FieldError{
+				Field:   f.Field,
+				Message: f.Message,
+			})
+		}
+	}
+
 	data, _ := ph.Encode(&ktgID, &errID, res)
 	return data
 }
@@ -138,19 +329,23 @@ func NewService(
 	ce container.Endpoints,
 	re routing.Endpoints,
 	me module.Endpoints,
+	de dns.Endpoints,
 ) Service {
 	s := &service{
 		ProtocolMap: ws.ProtocolMap{
 			"v1": ws.BasicHandler{},
 		},
+		Deprecated:         make(map[string]versioning.Deprecation),
 		WebsocketUpgrader:  upgrader,
 		BartBus:            bart.NewBus(signingKey, ue),
+		SigningKey:         []byte(signingKey),
 		SSLConfig:          sslConfig,
 		UserEndpoints:      ue,
 		KMIEndpoints:       ke,
 		ContainerEndpoints: ce,
 		RoutingEndpoints:   re,
 		ModuleEndpoints:    me,
+		DNSEndpoints:       de,
 	}
 
 	s.TokenAuth = ws.NewTokenAuth(
@@ -0,0 +1,181 @@
+package kmi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+// UIDir is the directory of a module bundle containing the frontend assets of the module
+const UIDir = "ui"
+
+// AssetPath is the path the assets of the modules are served below, namespaced by the name of their
+// module, e.g. /ui/php/dashboard.html
+const AssetPath = "/ui/"
+
+// DefaultContentSecurityPolicy only allows the UIs to load their own assets and to call the API of the
+// installation, they may be framed by the panel of the installation only
+const DefaultContentSecurityPolicy = "default-src 'self'; script-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; connect-src 'self'; object-src 'none'; base-uri 'none'; frame-ancestors 'self'"
+
+// assetKey returns the key of an asset of a module in the store
+func assetKey(module, asset string) string {
+	return path.Join(module, asset)
+}
+
+// assetURL returns the path an asset of a module is served at
+func assetURL(module, asset string) string {
+	return AssetPath + assetKey(module, asset)
+}
+
+type assetService struct {
+	Service
+	assets storage.Store
+}
+
+// removeAssets removes the assets of k from the store
+func (s *assetService) removeAssets(k *KMI) {
+	for _, a := range k.Assets {
+		s.assets.Delete(assetKey(k.Name, a))
+	}
+}
+
+// putAssets copies the assets of k from the bundle at p into the store
+func (s *assetService) putAssets(p string, k *KMI) error {
+	kC := NewContent()
+	err := Extract(p, kC)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range k.Assets {
+		data, err := kC.GetFile(path.Join(kC.modulePath, UIDir, a))
+		if err != nil {
+			return err
+		}
+
+		err = s.assets.Put(assetKey(k.Name, a), bytes.NewReader(*data), int64(len(*data)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *assetService) AddKMI(p string) (uint, error) {
+	id, err := s.Service.AddKMI(p)
+	if err != nil {
+		return 0, err
+	}
+
+	k := &KMI{}
+	err = s.Service.GetKMI(id, k)
+	if err == nil {
+		err = s.putAssets(p, k)
+	}
+	if err != nil {
+		// the module is only added with all of its assets
+		s.removeAssets(k)
+		s.Service.RemoveKMI(id)
+		return 0, err
+	}
+	return id, nil
+}
+
+func (s *assetService) RemoveKMI(id uint) error {
+	k := &KMI{}
+	err := s.Service.GetKMI(id, k)
+	if err != nil {
+		return err
+	}
+
+	err = s.Service.RemoveKMI(id)
+	if err != nil {
+		return err
+	}
+
+	s.removeAssets(k)
+	return nil
+}
+
+// NewAssetService returns a Service which keeps the frontend assets of the modules in assets while
+// they are added, it has to wrap a Service reading the bundles from the node
+func NewAssetService(s Service, assets storage.Store) Service {
+	return &assetService{
+		Service: s,
+		assets:  assets,
+	}
+}
+
+// AssetOptions configure how the assets are served
+type AssetOptions struct {
+	// MaxAge is how long browsers may cache the assets, it defaults to an hour
+	MaxAge time.Duration
+	// ContentSecurityPolicy defaults to DefaultContentSecurityPolicy
+	ContentSecurityPolicy string
+}
+
+// AssetHandler serves the assets of the modules kept in assets below AssetPath. Assets are public like
+// the modules themselves, the UIs call the API with the token of the user.
+func AssetHandler(assets storage.Store, o AssetOptions) http.Handler {
+	if o.MaxAge == 0 {
+		o.MaxAge = time.Hour
+	}
+	if o.ContentSecurityPolicy == "" {
+		o.ContentSecurityPolicy = DefaultContentSecurityPolicy
+	}
+	cacheControl := "public, max-age=" + strconv.Itoa(int(o.MaxAge.Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, AssetPath)
+		if !strings.Contains(key, "/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		rc, err := assets.Get(key)
+		if err == storage.ErrObjectNotExist || err == storage.ErrInvalidKey {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+
+		data, err := ioutil.ReadAll(rc)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		contentType := mime.TypeByExtension(path.Ext(key))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		sum := sha256.Sum256(data)
+
+		h := w.Header()
+		h.Set("Content-Type", contentType)
+		h.Set("Cache-Control", cacheControl)
+		h.Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+		h.Set("Content-Security-Policy", o.ContentSecurityPolicy)
+		h.Set("X-Content-Type-Options", "nosniff")
+		http.ServeContent(w, r, key, time.Time{}, bytes.NewReader(data))
+	})
+}
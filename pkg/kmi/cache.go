@@ -0,0 +1,82 @@
+package kmi
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/cache"
+)
+
+// catalogKey is the cache key of the display information of all modules
+const catalogKey = "kmi:catalog"
+
+func kmiKey(id uint) string {
+	return fmt.Sprintf("kmi:%d", id)
+}
+
+type cachedService struct {
+	Service
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+func (c *cachedService) AddKMI(path string) (uint, error) {
+	id, err := c.Service.AddKMI(path)
+	if err != nil {
+		return 0, err
+	}
+
+	c.cache.Delete(catalogKey)
+	return id, nil
+}
+
+func (c *cachedService) RemoveKMI(id uint) error {
+	err := c.Service.RemoveKMI(id)
+	if err != nil {
+		return err
+	}
+
+	c.cache.Delete(kmiKey(id), catalogKey)
+	return nil
+}
+
+func (c *cachedService) GetKMI(id uint, k *KMI) error {
+	found, err := c.cache.Get(kmiKey(id), k)
+	if found && err == nil {
+		return nil
+	}
+
+	err = c.Service.GetKMI(id, k)
+	if err != nil {
+		return err
+	}
+
+	c.cache.Set(kmiKey(id), k, c.ttl)
+	return nil
+}
+
+func (c *cachedService) KMI(out *[]KMDI) error {
+	catalog := []KMDI{}
+	found, err := c.cache.Get(catalogKey, &catalog)
+	if !found || err != nil {
+		catalog = []KMDI{}
+		err = c.Service.KMI(&catalog)
+		if err != nil {
+			return err
+		}
+		c.cache.Set(catalogKey, catalog, c.ttl)
+	}
+
+	*out = append(*out, catalog...)
+	return nil
+}
+
+// NewCachedService returns a Service keeping the modules and the catalog in c for ttl, they are
+// invalidated when modules are added or removed. Failing cache operations fall back to s.
+func NewCachedService(s Service, c cache.Cache, ttl time.Duration) Service {
+	return &cachedService{
+		Service: s,
+		cache:   c,
+		ttl:     ttl,
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 )
 
 // New creates a set of endpoints based on a gRPC connection
@@ -66,11 +67,24 @@ func New(conn *grpc.ClientConn, logger log.Logger) *kmi.Endpoints {
 		).Endpoint()
 	}
 
+	var UIsEndpoint endpoint.Endpoint
+	{
+		UIsEndpoint = grpctransport.NewClient(
+			conn,
+			"kmi.KMIService",
+			"UIs",
+			EncodeGRPCUIsRequest,
+			DecodeGRPCUIsResponse,
+			pb.UIsResponse{},
+		).Endpoint()
+	}
+
 	return &kmi.Endpoints{
 		AddKMIEndpoint:    AddKMIEndpoint,
 		RemoveKMIEndpoint: RemoveKMIEndpoint,
 		GetKMIEndpoint:    GetKMIEndpoint,
 		KMIEndpoint:       KMIEndpoint,
+		UIsEndpoint:       UIsEndpoint,
 	}
 }
 
@@ -112,10 +126,12 @@ func ConvertKMI(k *pb.KMI) *kmi.KMI {
 		ProvisionScript: k.ProvisionScript,
 		Commands:        abstraction.NewJSONFromMap(k.Commands),
 		Environment:     abstraction.NewJSONFromMap(k.Environment),
+		Secrets:         pq.StringArray(k.Secrets),
 		Frontend:        convertFrontendModuleArray(k.Frontend),
 		Imports:         pq.StringArray(k.Imports),
 		Interfaces:      abstraction.NewJSONFromMap(k.Interfaces),
 		Resources:       abstraction.NewJSONFromMap(k.Resources),
+		UI:              abstraction.NewJSONFromMap(k.Ui),
 	}
 }
 
@@ -185,8 +201,11 @@ func DecodeGRPCGetKMIResponse(_ context.Context, grpcResponse interface{}) (inte
 
 // EncodeGRPCKMIRequest is a transport/grpc.EncodeRequestFunc that converts a
 // messages/kmi.proto-domain kmi request to a gRPC KMI request.
-func EncodeGRPCKMIRequest(_ context.Context, _ interface{}) (interface{}, error) {
-	return &pb.KMIRequest{}, nil
+func EncodeGRPCKMIRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*kmi.KMIRequest)
+	return &pb.KMIRequest{
+		Page: paging.EncodePage(req.Page),
+	}, nil
 }
 
 // DecodeGRPCKMIResponse is a transport/grpc.DecodeResponseFunc that converts a
@@ -196,5 +215,30 @@ func DecodeGRPCKMIResponse(_ context.Context, grpcResponse interface{}) (interfa
 	return &kmi.KMIResponse{
 		KMDI:  convertKMDIArray(response.Kmdi),
 		Error: getError(response.Error),
+		Page:  paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCUIsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/kmi.proto-domain uis request to a gRPC UIs request.
+func EncodeGRPCUIsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.UIsRequest{}, nil
+}
+
+// DecodeGRPCUIsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC UIs response to a messages/kmi.proto-domain uis response.
+func DecodeGRPCUIsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.UIsResponse)
+	uis := make([]kmi.ModuleUI, len(response.Uis))
+	for i, u := range response.Uis {
+		uis[i] = kmi.ModuleUI{
+			KMDI:    ConvertKMDI(u.KMDI),
+			Entries: u.Entries,
+		}
+	}
+
+	return &kmi.UIsResponse{
+		UIs:   uis,
+		Error: getError(response.Error),
 	}, nil
 }
@@ -3,7 +3,11 @@ package kmi
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 	"github.com/lib/pq"
@@ -56,6 +60,311 @@ func (f FrontendArray) Value() (driver.Value, error) {
 	return string(b), err
 }
 
+// StackMember is a container a module needs besides its own, like the database of an application.
+// The members are started before the container of the module in the order of their dependencies.
+type StackMember struct {
+	// Name is the name of the member, its container is named after the container of the module and Name
+	Name string
+	// KMI is the name of the module the container of the member is created from
+	KMI string
+	// DependsOn are the names of the members which have to be ready before the member is started
+	DependsOn []string
+	// Ready is a command run in the container of the member until it succeeds, e.g. pg_isready,
+	// the member is ready as soon as its container started if it is empty
+	Ready string
+	// Timeout is the number of seconds the member may take to become ready
+	Timeout int
+	// Links are the interfaces of the member which are linked into the container of the module
+	Links []string
+}
+
+// Stack are the members of a module
+type Stack []*StackMember
+
+// Scan implements the sql.Scanner interface.
+func (s *Stack) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return json.Unmarshal(src, s)
+	case string:
+		return json.Unmarshal([]byte(src), s)
+	case nil:
+		*s = nil
+		return nil
+	}
+
+	return fmt.Errorf("pq: cannot convert %T to Stack", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (s Stack) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+
+	return string(b), err
+}
+
+// Order returns the members in the order they are started in, every member comes after the members
+// it depends on. Unknown dependencies and cycles are errors.
+func (s Stack) Order() ([]*StackMember, error) {
+	members := make(map[string]*StackMember)
+	for _, m := range s {
+		if m.Name == "" || m.KMI == "" {
+			return nil, errors.New("stack members need a name and a kmi")
+		}
+		if members[m.Name] != nil {
+			return nil, fmt.Errorf("stack member %s is defined twice", m.Name)
+		}
+		members[m.Name] = m
+	}
+
+	const (
+		visiting = iota + 1
+		visited
+	)
+	state := make(map[string]int)
+	order := []*StackMember{}
+
+	var visit func(m *StackMember) error
+	visit = func(m *StackMember) error {
+		switch state[m.Name] {
+		case visiting:
+			return fmt.Errorf("stack member %s is part of a dependency cycle", m.Name)
+		case visited:
+			return nil
+		}
+
+		state[m.Name] = visiting
+		for _, d := range m.DependsOn {
+			dep, ok := members[d]
+			if !ok {
+				return fmt.Errorf("stack member %s depends on unknown member %s", m.Name, d)
+			}
+			err := visit(dep)
+			if err != nil {
+				return err
+			}
+		}
+		state[m.Name] = visited
+		order = append(order, m)
+		return nil
+	}
+
+	for _, m := range s {
+		err := visit(m)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// PolicyPort is a port of a module in its firewall policy
+type PolicyPort struct {
+	Port uint16
+	// Protocol is tcp or udp, it defaults to tcp
+	Protocol string
+}
+
+// FirewallPolicy declares which ports of a module are reachable from where and where the module may
+// connect to. The firewall rules are created with the instance and removed with it.
+type FirewallPolicy struct {
+	// Public ports are published on the same port of the host
+	Public []PolicyPort
+	// Internal ports are only reachable from the node and from linked instances, they are never published
+	Internal []PolicyPort
+	// Egress is the egress profile of the firewall service the module is restricted to: open, web or none.
+	// It defaults to open.
+	Egress string
+}
+
+var egressProfiles = map[string]bool{
+	"":     true,
+	"open": true,
+	"web":  true,
+	"none": true,
+}
+
+// Validate sets the default protocol of the ports and returns an error if a port is invalid, declared
+// twice or the egress profile is unknown
+func (f *FirewallPolicy) Validate() error {
+	if !egressProfiles[f.Egress] {
+		return fmt.Errorf("unknown egress profile %s", f.Egress)
+	}
+
+	seen := make(map[PolicyPort]bool)
+	for _, ports := range [][]PolicyPort{f.Public, f.Internal} {
+		for i := range ports {
+			p := &ports[i]
+			if p.Protocol == "" {
+				p.Protocol = "tcp"
+			}
+			if p.Protocol != "tcp" && p.Protocol != "udp" {
+				return fmt.Errorf("port %d has unknown protocol %s", p.Port, p.Protocol)
+			}
+			if p.Port == 0 {
+				return errors.New("firewall policy ports need a port")
+			}
+			if seen[*p] {
+				return fmt.Errorf("port %d/%s is declared twice", p.Port, p.Protocol)
+			}
+			seen[*p] = true
+		}
+	}
+	return nil
+}
+
+// Scan implements the sql.Scanner interface.
+func (f *FirewallPolicy) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return json.Unmarshal(src, f)
+	case string:
+		return json.Unmarshal([]byte(src), f)
+	case nil:
+		*f = FirewallPolicy{}
+		return nil
+	}
+
+	return fmt.Errorf("pq: cannot convert %T to FirewallPolicy", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (f FirewallPolicy) Value() (driver.Value, error) {
+	b, err := json.Marshal(f)
+
+	return string(b), err
+}
+
+// labelKey matches the keys of node labels like ssd or example.com/zone, labelValue their values
+var (
+	labelKey   = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]*[a-zA-Z0-9])?$`)
+	labelValue = regexp.MustCompile(`^[a-zA-Z0-9._-]*$`)
+)
+
+// RuntimeLabelPrefix prefixes the labels the nodes set themselves to report their container runtime and
+// its features, like kontainer.ooo/gpu, operators can not set them
+const RuntimeLabelPrefix = "kontainer.ooo/"
+
+// ValidateLabel returns an error if key or value can not be a label of a node
+func ValidateLabel(key string, value string) error {
+	if !labelKey.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	if !labelValue.MatchString(value) {
+		return fmt.Errorf("invalid value %q of label %s", value, key)
+	}
+	return nil
+}
+
+// Placement constrains the nodes the instances of a module are placed on
+type Placement struct {
+	// Spread keeps the instances of the module a user owns on different nodes, the replicas of
+	// an instance run on its node, so the instances of a spread module can not be scaled
+	Spread bool
+	// Labels are the labels a node needs to have, e.g. ssd: "true"
+	Labels map[string]string
+}
+
+// Validate returns an error if a label of the placement is invalid
+func (p *Placement) Validate() error {
+	for k, v := range p.Labels {
+		err := ValidateLabel(k, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Merge returns the placement satisfying both p and o, labels both require with different values are an error
+func (p Placement) Merge(o Placement) (Placement, error) {
+	m := Placement{
+		Spread: p.Spread || o.Spread,
+		Labels: make(map[string]string),
+	}
+	for k, v := range p.Labels {
+		m.Labels[k] = v
+	}
+	for k, v := range o.Labels {
+		if w, ok := m.Labels[k]; ok && w != v {
+			return Placement{}, fmt.Errorf("label %s is required as %s=%s already", k, k, w)
+		}
+		m.Labels[k] = v
+	}
+	return m, nil
+}
+
+// Missing returns the first required label as key=value which labels lack or have with another value,
+// it is empty if a node with labels satisfies the labels of p
+func (p Placement) Missing(labels map[string]string) string {
+	keys := make([]string, 0, len(p.Labels))
+	for k := range p.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if v, ok := labels[k]; !ok || v != p.Labels[k] {
+			return fmt.Sprintf("%s=%s", k, p.Labels[k])
+		}
+	}
+	return ""
+}
+
+// String returns the placement like spread,ssd=true
+func (p Placement) String() string {
+	cs := []string{}
+	if p.Spread {
+		cs = append(cs, "spread")
+	}
+
+	labels := []string{}
+	for k, v := range p.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(labels)
+	return strings.Join(append(cs, labels...), ",")
+}
+
+// Scan implements the sql.Scanner interface.
+func (p *Placement) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return json.Unmarshal(src, p)
+	case string:
+		return json.Unmarshal([]byte(src), p)
+	case nil:
+		*p = Placement{}
+		return nil
+	}
+
+	return fmt.Errorf("pq: cannot convert %T to Placement", src)
+}
+
+// Value implements the driver.Valuer interface.
+func (p Placement) Value() (driver.Value, error) {
+	b, err := json.Marshal(p)
+
+	return string(b), err
+}
+
+// PlacementError is returned if the placement of an instance is unsatisfiable, Node is empty if no node satisfies it
+type PlacementError struct {
+	Instance string
+	Node     string
+	Reason   string
+}
+
+func (e *PlacementError) Error() string {
+	if e.Node == "" {
+		return fmt.Sprintf("unsatisfiable placement: no node can run instance %s, %s", e.Instance, e.Reason)
+	}
+	return fmt.Sprintf("unsatisfiable placement: node %s can not run instance %s, %s", e.Node, e.Instance, e.Reason)
+}
+
 // The KMI struct is used to represent every information included in a kmi-file
 type KMI struct {
 	KMDI
@@ -66,9 +375,40 @@ type KMI struct {
 	Imports         pq.StringArray   `sql:"type:text[]"`
 	Interfaces      abstraction.JSON `sql:"type:jsonb"`
 	Resources       abstraction.JSON `sql:"type:jsonb"`
+	Routes          abstraction.JSON `sql:"type:jsonb"`
+	// Secrets are the environment variables which hold secrets, their values are kept by the secret backend
+	// and are not copied when an instance is cloned
+	Secrets pq.StringArray `sql:"type:text[]"`
+	// UI maps the names of the frontend UIs of the module, e.g. dashboard or config, to their entry file in ui/
+	UI abstraction.JSON `sql:"type:jsonb"`
+	// Assets are the files of the ui directory of the module, they are served below AssetPath
+	Assets pq.StringArray `sql:"type:text[]"`
+	// Stack are the containers started before the container of the module
+	Stack Stack `sql:"type:jsonb"`
+	// Firewall is the firewall policy of the instances of the module
+	Firewall FirewallPolicy `sql:"type:jsonb"`
+	// Placement constrains the nodes the instances of the module are placed on
+	Placement Placement `sql:"type:jsonb"`
 }
 
 // TableName sets KMI's tablename
 func (KMI) TableName() string {
 	return "kontainer_module_information"
 }
+
+// IsSecret reports whether the environment variable name is flagged as a secret by the module
+func (k KMI) IsSecret(name string) bool {
+	for _, s := range k.Secrets {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ModuleUI lists the frontend UIs of a module
+type ModuleUI struct {
+	KMDI
+	// Entries maps the names of the UIs to the paths their entry files are served at
+	Entries map[string]string
+}
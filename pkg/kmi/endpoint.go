@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 )
 
 // Endpoints is a struct which collects all endpoints for the kmi service
@@ -12,11 +13,12 @@ type Endpoints struct {
 	RemoveKMIEndpoint endpoint.Endpoint
 	GetKMIEndpoint    endpoint.Endpoint
 	KMIEndpoint       endpoint.Endpoint
+	UIsEndpoint       endpoint.Endpoint
 }
 
 // AddKMIRequest is the request struct for the AddKMIEndpoint
 type AddKMIRequest struct {
-	Path string
+	Path string `validate:"required"`
 }
 
 // AddKMIResponse is the response struct for the AddKMIEndpoint
@@ -83,21 +85,56 @@ func MakeGetKMIEndpoint(s Service) endpoint.Endpoint {
 }
 
 // KMIRequest is the request struct for the KMIEndpoint
-type KMIRequest struct{}
+type KMIRequest struct {
+	Page paging.Request
+}
 
 // KMIResponse is the response struct for the KMIEndpoint
 type KMIResponse struct {
 	KMDI  *[]KMDI
 	Error error
+	Page  paging.Response
 }
 
 // MakeKMIEndpoint creates a gokit endpoint which invokes KMI
 func MakeKMIEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(KMIRequest)
 		k := &[]KMDI{}
 		err := s.KMI(k)
+		if err != nil {
+			return KMIResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(k, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
 		return KMIResponse{
-			KMDI:  k,
+			KMDI: k,
+			Page: page,
+		}, nil
+	}
+}
+
+// UIsRequest is the request struct for the UIsEndpoint
+type UIsRequest struct{}
+
+// UIsResponse is the response struct for the UIsEndpoint
+type UIsResponse struct {
+	UIs   []ModuleUI
+	Error error
+}
+
+// MakeUIsEndpoint creates a gokit endpoint which invokes UIs
+func MakeUIsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		uis := []ModuleUI{}
+		err := s.UIs(&uis)
+		return UIsResponse{
+			UIs:   uis,
 			Error: err,
 		}, nil
 	}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/lib/pq"
@@ -103,6 +104,34 @@ func (c *Content) GetFile(p string) (*[]byte, error) {
 	return c.folders.GetFile(p)
 }
 
+// Files returns the paths of the files below the directory dir of the module relative to dir, sorted
+func (c *Content) Files(dir string) []string {
+	folder, err := c.folders.walk(path.Join(c.modulePath, dir), false)
+	if err != nil {
+		return nil
+	}
+
+	files := []string{}
+	folder.collect("", &files)
+	sort.Strings(files)
+	return files
+}
+
+// collect appends the paths of the files of f and its subfolders prefixed by p to files, hidden files
+// like the ._ files of macOS are skipped
+func (f *Folder) collect(p string, files *[]string) {
+	for name := range f.files {
+		if name != "" && !strings.HasPrefix(name, ".") {
+			*files = append(*files, path.Join(p, name))
+		}
+	}
+	for name, folder := range f.folders {
+		if !strings.HasPrefix(name, ".") {
+			folder.collect(path.Join(p, name), files)
+		}
+	}
+}
+
 // NewContent initializes a new Content instance
 func NewContent() *Content {
 	return &Content{
@@ -150,9 +179,15 @@ type moduleJSON struct {
 	ProvisionScript string
 	Frontend        interface{}
 	Env             interface{}
+	Secrets         []string
 	Interfaces      interface{}
 	Cmd             interface{}
 	Resources       interface{}
+	Routes          interface{}
+	UI              interface{}
+	Stack           Stack
+	Firewall        *FirewallPolicy
+	Placement       *Placement
 }
 
 // ChooseSource fills src with outsrc if src is not the expected data kind
@@ -246,6 +281,11 @@ func valueSwitch(restriction map[reflect.Kind]bool) func(reflect.Value) (interfa
 				return nil, fmt.Errorf("unexpected string")
 			}
 			return v.String(), nil
+		case reflect.Bool:
+			if restriction[reflect.Bool] {
+				return nil, fmt.Errorf("unexpected bool")
+			}
+			return v.Bool(), nil
 		case reflect.Float64:
 			if restriction[reflect.Int] {
 				return nil, fmt.Errorf("unexpected number")
@@ -338,6 +378,33 @@ func GetFrontend(src interface{}, dst *FrontendArray) error {
 	return nil
 }
 
+// GetUI fills the UI of a KMI with the entries of src and its Assets with the files of the ui directory
+// of the module, every entry has to be one of the files
+func GetUI(src interface{}, kC *Content, k *KMI) error {
+	onlyStrings := map[reflect.Kind]bool{
+		reflect.Bool:  true,
+		reflect.Int:   true,
+		reflect.Slice: true,
+		reflect.Map:   true,
+	}
+	err := GetStringMap(src, kC, k.UI, "ui", onlyStrings)
+	if err != nil {
+		return err
+	}
+
+	k.Assets = kC.Files(UIDir)
+	assets := make(map[string]bool)
+	for _, a := range k.Assets {
+		assets[a] = true
+	}
+	for name, entry := range k.UI {
+		if !assets[entry.(string)] {
+			return fmt.Errorf("entry %s of ui %s does not exist in %s", entry, name, UIDir)
+		}
+	}
+	return nil
+}
+
 func GetProvisionScript(p string, kc *Content) (string, error) {
 	b, err := kc.GetFile(p)
 	if err != nil {
@@ -379,6 +446,13 @@ func GetData(kC *Content, k *KMI) error {
 		return err
 	}
 
+	for _, name := range m.Secrets {
+		if _, ok := k.Environment[name]; !ok {
+			return fmt.Errorf("secret %s is not an environment variable", name)
+		}
+	}
+	k.Secrets = m.Secrets
+
 	k.Interfaces = make(map[string]interface{})
 	err = GetStringMap(m.Interfaces, kC, k.Interfaces, "interfaces", nil)
 	if err != nil {
@@ -391,6 +465,46 @@ func GetData(kC *Content, k *KMI) error {
 		return err
 	}
 
+	k.Routes = make(map[string]interface{})
+	if m.Routes != nil {
+		err = GetStringMap(m.Routes, kC, k.Routes, "routes", nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	if m.Stack != nil {
+		_, err = m.Stack.Order()
+		if err != nil {
+			return err
+		}
+		k.Stack = m.Stack
+	}
+
+	if m.Firewall != nil {
+		err = m.Firewall.Validate()
+		if err != nil {
+			return err
+		}
+		k.Firewall = *m.Firewall
+	}
+
+	if m.Placement != nil {
+		err = m.Placement.Validate()
+		if err != nil {
+			return err
+		}
+		k.Placement = *m.Placement
+	}
+
+	k.UI = make(map[string]interface{})
+	if m.UI != nil {
+		err = GetUI(m.UI, kC, k)
+		if err != nil {
+			return err
+		}
+	}
+
 	frontend := make(map[string]interface{})
 	err = GetStringMap(m.Frontend, kC, frontend, "frontend", nil)
 	if err != nil {
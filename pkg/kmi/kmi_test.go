@@ -1,10 +1,23 @@
 package kmi_test
 
 import (
+	"archive/tar"
 	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
+	"time"
 
+	"github.com/kontainerooo/kontainer.ooo/pkg/cache"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/retry"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
 	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
 
 	. "github.com/onsi/ginkgo"
@@ -430,3 +443,383 @@ var _ = XDescribe("KMI", func() {
 	})
 
 })
+
+// catalogService serves a fixed catalog and counts the reads
+type catalogService struct {
+	catalog []kmi.KMDI
+	reads   int
+}
+
+func (c *catalogService) AddKMI(path string) (uint, error) {
+	id := uint(len(c.catalog) + 1)
+	c.catalog = append(c.catalog, kmi.KMDI{ID: id, Name: path})
+	return id, nil
+}
+
+func (c *catalogService) RemoveKMI(id uint) error {
+	c.catalog = c.catalog[:0]
+	return nil
+}
+
+func (c *catalogService) GetKMI(id uint, k *kmi.KMI) error {
+	c.reads++
+	for _, kmdi := range c.catalog {
+		if kmdi.ID == id {
+			k.KMDI = kmdi
+			return nil
+		}
+	}
+	return errors.New("not found")
+}
+
+func (c *catalogService) KMI(out *[]kmi.KMDI) error {
+	c.reads++
+	*out = append(*out, c.catalog...)
+	return nil
+}
+
+func (c *catalogService) UIs(out *[]kmi.ModuleUI) error {
+	return nil
+}
+
+var _ = Describe("Cache", func() {
+	It("Should keep the catalog until modules are added or removed", func() {
+		s := &catalogService{}
+		cached := kmi.NewCachedService(s, cache.NewLRU(10), time.Minute)
+		id, _ := cached.AddKMI("web")
+
+		for i := 0; i < 2; i++ {
+			out := []kmi.KMDI{}
+			Ω(cached.KMI(&out)).Should(Succeed())
+			Expect(out).To(HaveLen(1))
+
+			k := &kmi.KMI{}
+			Ω(cached.GetKMI(id, k)).Should(Succeed())
+			Expect(k.Name).To(Equal("web"))
+		}
+		Expect(s.reads).To(Equal(2))
+
+		cached.AddKMI("db")
+		out := []kmi.KMDI{}
+		cached.KMI(&out)
+		Expect(out).To(HaveLen(2))
+
+		Ω(cached.RemoveKMI(id)).Should(Succeed())
+		out = []kmi.KMDI{}
+		cached.KMI(&out)
+		Expect(out).To(BeEmpty())
+		Ω(cached.GetKMI(id, &kmi.KMI{})).ShouldNot(Succeed())
+	})
+})
+
+// bundleService records the content of the bundles it adds
+type bundleService struct {
+	catalogService
+	bundles []string
+}
+
+func (b *bundleService) AddKMI(path string) (uint, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	b.bundles = append(b.bundles, string(data))
+	return b.catalogService.AddKMI(path)
+}
+
+var _ = Describe("Storage", func() {
+	It("Should add the bundles of the artifact store", func() {
+		dir, _ := ioutil.TempDir("", "kroo-bundles")
+		defer os.RemoveAll(dir)
+		store := storage.NewLocalStore(dir)
+		store.Put("php.kmi", strings.NewReader("bundle"), 6)
+
+		s := &bundleService{}
+		bundles := kmi.NewStorageService(s, store, retry.DefaultPolicy)
+
+		_, err := bundles.AddKMI("storage:php.kmi")
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(s.bundles).To(Equal([]string{"bundle"}))
+		_, err = os.Stat(s.catalog[0].Name)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		_, err = bundles.AddKMI("storage:go.kmi")
+		Expect(err).To(Equal(storage.ErrObjectNotExist))
+
+		ioutil.WriteFile(filepath.Join(dir, "local.kmi"), []byte("local"), 0644)
+		_, err = bundles.AddKMI(filepath.Join(dir, "local.kmi"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(s.bundles).To(Equal([]string{"bundle", "local"}))
+	})
+
+	It("Should retry interrupted downloads of bundles", func() {
+		dir, _ := ioutil.TempDir("", "kroo-bundles")
+		defer os.RemoveAll(dir)
+		store := &flakyStore{Store: storage.NewLocalStore(dir), failures: 2}
+		store.Put("php.kmi", strings.NewReader("bundle"), 6)
+
+		s := &bundleService{}
+		bundles := kmi.NewStorageService(s, store, retry.Policy{Attempts: 3, Backoff: time.Millisecond})
+
+		_, err := bundles.AddKMI("storage:php.kmi")
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(s.bundles).To(Equal([]string{"bundle"}))
+		Expect(store.gets).To(Equal(3))
+	})
+})
+
+// flakyStore fails the first downloads like a connection which breaks
+type flakyStore struct {
+	storage.Store
+	failures int
+	gets     int
+}
+
+func (f *flakyStore) Get(key string) (io.ReadCloser, error) {
+	f.gets++
+	if f.gets <= f.failures {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return f.Store.Get(key)
+}
+
+// uiService adds modules shipping the assets of a ui
+type uiService struct {
+	catalogService
+	assets []string
+}
+
+func (u *uiService) GetKMI(id uint, k *kmi.KMI) error {
+	err := u.catalogService.GetKMI(id, k)
+	k.Name = "php"
+	k.Assets = u.assets
+	return err
+}
+
+// writeBundle writes a bundle containing files to a temporary file and returns its path
+func writeBundle(dir string, files map[string]string) string {
+	f, _ := ioutil.TempFile(dir, "bundle")
+	defer f.Close()
+
+	w := tar.NewWriter(f)
+	for name, content := range files {
+		w.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		w.Write([]byte(content))
+	}
+	w.Close()
+	return f.Name()
+}
+
+var _ = Describe("Assets", func() {
+	var (
+		dir    string
+		store  storage.Store
+		bundle string
+	)
+
+	BeforeEach(func() {
+		dir, _ = ioutil.TempDir("", "kroo-assets")
+		store = storage.NewLocalStore(filepath.Join(dir, "store"))
+		bundle = writeBundle(dir, map[string]string{
+			"php/module.json":    "{}",
+			"php/ui/widget.html": "<p>widget</p>",
+			"php/ui/js/app.js":   "app()",
+			"php/ui/._app.js":    "",
+		})
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Should list the files of a directory of the module", func() {
+		kC := kmi.NewContent()
+		Ω(kmi.Extract(bundle, kC)).Should(Succeed())
+		Expect(kC.Files(kmi.UIDir)).To(Equal([]string{"js/app.js", "widget.html"}))
+		Expect(kC.Files("missing")).To(BeEmpty())
+	})
+
+	It("Should keep the assets of the modules in the store", func() {
+		s := &uiService{assets: []string{"js/app.js", "widget.html"}}
+		assets := kmi.NewAssetService(s, store)
+
+		id, err := assets.AddKMI(bundle)
+		Ω(err).ShouldNot(HaveOccurred())
+		rc, err := store.Get("php/widget.html")
+		Ω(err).ShouldNot(HaveOccurred())
+		data, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		Expect(string(data)).To(Equal("<p>widget</p>"))
+
+		Ω(assets.RemoveKMI(id)).Should(Succeed())
+		_, err = store.Get("php/js/app.js")
+		Expect(err).To(Equal(storage.ErrObjectNotExist))
+	})
+
+	It("Should not add modules with missing assets", func() {
+		s := &uiService{assets: []string{"widget.html", "form.html"}}
+		assets := kmi.NewAssetService(s, store)
+
+		_, err := assets.AddKMI(bundle)
+		Ω(err).Should(HaveOccurred())
+		Expect(s.catalog).To(BeEmpty())
+		_, err = store.Get("php/widget.html")
+		Expect(err).To(Equal(storage.ErrObjectNotExist))
+	})
+
+	It("Should serve the assets with cache headers and a content security policy", func() {
+		store.Put("php/widget.html", strings.NewReader("<p>widget</p>"), 13)
+		handler := kmi.AssetHandler(store, kmi.AssetOptions{MaxAge: time.Minute})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/ui/php/widget.html", nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("<p>widget</p>"))
+		Expect(w.Header().Get("Content-Type")).To(HavePrefix("text/html"))
+		Expect(w.Header().Get("Cache-Control")).To(Equal("public, max-age=60"))
+		Expect(w.Header().Get("Content-Security-Policy")).To(Equal(kmi.DefaultContentSecurityPolicy))
+		etag := w.Header().Get("ETag")
+		Expect(etag).NotTo(BeEmpty())
+
+		r := httptest.NewRequest("GET", "/ui/php/widget.html", nil)
+		r.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		Expect(w.Code).To(Equal(http.StatusNotModified))
+
+		for _, p := range []string{"/ui/php/form.html", "/ui/php", "/ui/../php/widget.html"} {
+			w = httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+			Expect(w.Code).To(Equal(http.StatusNotFound))
+		}
+
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/ui/php/widget.html", nil))
+		Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+})
+
+var _ = Describe("Stack", func() {
+	names := func(members []*kmi.StackMember) []string {
+		n := []string{}
+		for _, m := range members {
+			n = append(n, m.Name)
+		}
+		return n
+	}
+
+	It("Should order the members after their dependencies", func() {
+		s := kmi.Stack{
+			{Name: "worker", KMI: "worker", DependsOn: []string{"db", "cache"}},
+			{Name: "cache", KMI: "redis"},
+			{Name: "db", KMI: "postgres"},
+			{Name: "migrate", KMI: "migrate", DependsOn: []string{"db"}},
+		}
+		order, err := s.Order()
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(names(order)).To(Equal([]string{"db", "cache", "worker", "migrate"}))
+	})
+
+	It("Should return an error for unknown dependencies", func() {
+		_, err := kmi.Stack{{Name: "app", KMI: "app", DependsOn: []string{"db"}}}.Order()
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("Should return an error for cycles", func() {
+		_, err := kmi.Stack{
+			{Name: "a", KMI: "a", DependsOn: []string{"b"}},
+			{Name: "b", KMI: "b", DependsOn: []string{"a"}},
+		}.Order()
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("Should return an error for members defined twice", func() {
+		_, err := kmi.Stack{{Name: "db", KMI: "postgres"}, {Name: "db", KMI: "mysql"}}.Order()
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("Should read the stack from the module json", func() {
+		s := kmi.Stack{}
+		Ω(s.Scan(`[{"Name":"db","KMI":"postgres","Ready":"pg_isready","Timeout":30}]`)).Should(Succeed())
+		Expect(s).To(HaveLen(1))
+		Expect(s[0].Ready).To(Equal("pg_isready"))
+		Expect(s[0].Timeout).To(Equal(30))
+	})
+})
+
+var _ = Describe("Firewall policy", func() {
+	It("Should default the protocol of the ports to tcp", func() {
+		f := kmi.FirewallPolicy{
+			Public:   []kmi.PolicyPort{{Port: 80}},
+			Internal: []kmi.PolicyPort{{Port: 9000, Protocol: "udp"}},
+			Egress:   "web",
+		}
+		Ω(f.Validate()).Should(Succeed())
+		Expect(f.Public[0].Protocol).To(Equal("tcp"))
+		Expect(f.Internal[0].Protocol).To(Equal("udp"))
+	})
+
+	It("Should return an error for unknown egress profiles", func() {
+		f := kmi.FirewallPolicy{Egress: "intranet"}
+		Ω(f.Validate()).ShouldNot(Succeed())
+	})
+
+	It("Should return an error for ports which are public and internal", func() {
+		f := kmi.FirewallPolicy{
+			Public:   []kmi.PolicyPort{{Port: 80}},
+			Internal: []kmi.PolicyPort{{Port: 80, Protocol: "tcp"}},
+		}
+		Ω(f.Validate()).ShouldNot(Succeed())
+	})
+
+	It("Should return an error for invalid ports", func() {
+		Ω((&kmi.FirewallPolicy{Public: []kmi.PolicyPort{{Port: 0}}}).Validate()).ShouldNot(Succeed())
+		Ω((&kmi.FirewallPolicy{Public: []kmi.PolicyPort{{Port: 80, Protocol: "sctp"}}}).Validate()).ShouldNot(Succeed())
+	})
+
+	It("Should read the policy from the database", func() {
+		f := kmi.FirewallPolicy{}
+		Ω(f.Scan(`{"Public":[{"Port":443,"Protocol":"tcp"}],"Egress":"none"}`)).Should(Succeed())
+		Expect(f.Public).To(HaveLen(1))
+		Expect(f.Egress).To(Equal("none"))
+	})
+})
+
+var _ = Describe("Placement", func() {
+	It("Should return the first label a node lacks", func() {
+		p := kmi.Placement{Labels: map[string]string{"ssd": "true", "zone": "a"}}
+		Expect(p.Missing(map[string]string{"ssd": "true", "zone": "a", "gpu": "false"})).To(BeEmpty())
+		Expect(p.Missing(map[string]string{"zone": "a"})).To(Equal("ssd=true"))
+		Expect(p.Missing(map[string]string{"ssd": "true", "zone": "b"})).To(Equal("zone=a"))
+		Expect(kmi.Placement{}.Missing(nil)).To(BeEmpty())
+	})
+
+	It("Should merge placements", func() {
+		p, err := kmi.Placement{Labels: map[string]string{"ssd": "true"}}.Merge(kmi.Placement{Spread: true, Labels: map[string]string{"zone": "a"}})
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(p.Spread).To(BeTrue())
+		Expect(p.Labels).To(Equal(map[string]string{"ssd": "true", "zone": "a"}))
+		Expect(p.String()).To(Equal("spread,ssd=true,zone=a"))
+
+		_, err = kmi.Placement{Labels: map[string]string{"ssd": "true"}}.Merge(kmi.Placement{Labels: map[string]string{"ssd": "false"}})
+		Ω(err).Should(HaveOccurred())
+	})
+
+	It("Should return an error for invalid labels", func() {
+		Ω((&kmi.Placement{Labels: map[string]string{"example.com/zone": "eu-1"}}).Validate()).Should(Succeed())
+		Ω((&kmi.Placement{Labels: map[string]string{"-ssd": "true"}}).Validate()).ShouldNot(Succeed())
+		Ω((&kmi.Placement{Labels: map[string]string{"ssd": "very fast"}}).Validate()).ShouldNot(Succeed())
+	})
+
+	It("Should read the placement from the database", func() {
+		p := kmi.Placement{}
+		Ω(p.Scan(`{"Spread":true,"Labels":{"ssd":"true"}}`)).Should(Succeed())
+		Expect(p.Spread).To(BeTrue())
+		Expect(p.Labels).To(HaveKeyWithValue("ssd", "true"))
+	})
+})
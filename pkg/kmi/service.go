@@ -21,6 +21,9 @@ type Service interface {
 
 	// KMI returns display information for all exisiting kontainer modules
 	KMI(*[]KMDI) error
+
+	// UIs returns the frontend UIs of all kontainer modules which ship any
+	UIs(*[]ModuleUI) error
 }
 
 type dbAdapter interface {
@@ -130,6 +133,34 @@ func (s *service) kmi(out *[]KMDI) error {
 	return nil
 }
 
+func (s *service) UIs(out *[]ModuleUI) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	k := []KMI{}
+	err := s.db.Find(&k)
+	if err != nil {
+		return err
+	}
+	for _, kmi := range k {
+		if len(kmi.UI) == 0 {
+			continue
+		}
+
+		ui := ModuleUI{
+			KMDI:    kmi.KMDI,
+			Entries: make(map[string]string),
+		}
+		for name, entry := range kmi.UI {
+			if e, ok := entry.(string); ok {
+				ui.Entries[name] = assetURL(kmi.Name, e)
+			}
+		}
+		*out = append(*out, ui)
+	}
+	return nil
+}
+
 // NewService creates a KMIService with necessary dependencies.
 func NewService(db dbAdapter) (Service, error) {
 	s := &service{
@@ -0,0 +1,78 @@
+package kmi
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/retry"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+type storageService struct {
+	Service
+	bundles storage.Store
+	retry   retry.Policy
+}
+
+// fetch copies the bundle key into a temporary file, downloads which were interrupted are started again
+func (s *storageService) fetch(key string) (string, error) {
+	var file string
+	err := s.retry.Do(context.Background(), "kmi.FetchBundle", func() (err error) {
+		file, err = s.download(key)
+		return err
+	})
+	return file, err
+}
+
+// download copies the bundle key into a temporary file, which is removed if the download fails
+func (s *storageService) download(key string) (string, error) {
+	r, err := s.bundles.Get(key)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	f, err := ioutil.TempFile("", "kroo-bundle")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+func (s *storageService) AddKMI(path string) (uint, error) {
+	if !strings.HasPrefix(path, storage.PathPrefix) {
+		return s.Service.AddKMI(path)
+	}
+
+	file, err := s.fetch(strings.TrimPrefix(path, storage.PathPrefix))
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file)
+
+	return s.Service.AddKMI(file)
+}
+
+// NewStorageService returns a Service which also adds the module bundles kept in bundles,
+// paths like storage:php.kmi are fetched from the store while other paths are read from the node.
+// Fetches failing with a transient error are retried with r.
+func NewStorageService(s Service, bundles storage.Store, r retry.Policy) Service {
+	return &storageService{
+		Service: s,
+		bundles: bundles,
+		retry:   r,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/go-kit/kit/log"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 	oldcontext "golang.org/x/net/context"
 )
 
@@ -40,6 +41,12 @@ func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger)
 			EncodeGRPCKMIResponse,
 			options...,
 		),
+		uis: grpctransport.NewServer(
+			endpoints.UIsEndpoint,
+			DecodeGRPCUIsRequest,
+			EncodeGRPCUIsResponse,
+			options...,
+		),
 	}
 }
 
@@ -48,6 +55,7 @@ type grpcServer struct {
 	removeKMI grpctransport.Handler
 	getKMI    grpctransport.Handler
 	kmi       grpctransport.Handler
+	uis       grpctransport.Handler
 }
 
 func (s *grpcServer) AddKMI(ctx oldcontext.Context, req *pb.AddKMIRequest) (*pb.AddKMIResponse, error) {
@@ -82,6 +90,14 @@ func (s *grpcServer) KMI(ctx oldcontext.Context, req *pb.KMIRequest) (*pb.KMIRes
 	return res.(*pb.KMIResponse), nil
 }
 
+func (s *grpcServer) UIs(ctx oldcontext.Context, req *pb.UIsRequest) (*pb.UIsResponse, error) {
+	_, res, err := s.uis.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.UIsResponse), nil
+}
+
 func convertPBFrontendModule(f *FrontendModule) *pb.FrontendModule {
 	return &pb.FrontendModule{
 		Template:   f.Template,
@@ -113,10 +129,20 @@ func ConvertPBKMI(k *KMI) *pb.KMI {
 		ProvisionScript: k.ProvisionScript,
 		Commands:        k.Commands.ToStringMap(),
 		Environment:     k.Environment.ToStringMap(),
+		Secrets:         k.Secrets,
 		Frontend:        convertPBFrontendModuleArray(k.Frontend),
 		Imports:         k.Imports,
 		Interfaces:      k.Interfaces.ToStringMap(),
 		Resources:       k.Resources.ToStringMap(),
+		Ui:              k.UI.ToStringMap(),
+	}
+}
+
+// ConvertPBModuleUI converts a ModuleUI to its protobuf representation
+func ConvertPBModuleUI(u ModuleUI) *pb.ModuleUI {
+	return &pb.ModuleUI{
+		KMDI:    ConvertPBKMDI(u.KMDI),
+		Entries: u.Entries,
 	}
 }
 
@@ -158,7 +184,10 @@ func DecodeGRPCGetKMIRequest(_ context.Context, grpcReq interface{}) (interface{
 // DecodeGRPCKMIRequest is a transport/grpc.DecodeRequestFunc that converts a
 // gRPC KMI request to a messages/KMI.proto-domain KMI request.
 func DecodeGRPCKMIRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
-	return KMIRequest{}, nil
+	req := grpcReq.(*pb.KMIRequest)
+	return KMIRequest{
+		Page: paging.DecodePage(req.Page),
+	}, nil
 }
 
 // EncodeGRPCAddKMIResponse is a transport/grpc.EncodeRequestFunc that converts a
@@ -203,7 +232,9 @@ func EncodeGRPCGetKMIResponse(_ context.Context, response interface{}) (interfac
 // messages/KMI.proto-domain KMI response to a gRPC KMI response.
 func EncodeGRPCKMIResponse(_ context.Context, response interface{}) (interface{}, error) {
 	res := response.(KMIResponse)
-	gRPCRes := &pb.KMIResponse{}
+	gRPCRes := &pb.KMIResponse{
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
 	if res.KMDI != nil {
 		gRPCRes.Kmdi = convertPBKMDIArray(res.KMDI)
 	}
@@ -212,3 +243,27 @@ func EncodeGRPCKMIResponse(_ context.Context, response interface{}) (interface{}
 	}
 	return gRPCRes, nil
 }
+
+// DecodeGRPCUIsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC UIs request to a messages/KMI.proto-domain UIs request.
+func DecodeGRPCUIsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return UIsRequest{}, nil
+}
+
+// EncodeGRPCUIsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/KMI.proto-domain UIs response to a gRPC UIs response.
+func EncodeGRPCUIsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(UIsResponse)
+	uis := make([]*pb.ModuleUI, len(res.UIs))
+	for i, u := range res.UIs {
+		uis[i] = ConvertPBModuleUI(u)
+	}
+
+	gRPCRes := &pb.UIsResponse{
+		Uis: uis,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
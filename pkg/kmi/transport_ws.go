@@ -3,8 +3,8 @@ package kmi
 import (
 	"context"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
 )
 
@@ -51,7 +51,7 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 // WS AddKMI request to a messages/kmi.proto-domain addkmi request.
 func DecodeWSAddKMIRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.AddKMIRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +63,7 @@ func DecodeWSAddKMIRequest(ctx context.Context, data interface{}) (interface{},
 // WS RemoveKMI request to a messages/kmi.proto-domain removekmi request.
 func DecodeWSRemoveKMIRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveKMIRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -75,7 +75,7 @@ func DecodeWSRemoveKMIRequest(ctx context.Context, data interface{}) (interface{
 // WS GetKMI request to a messages/kmi.proto-domain getkmi request.
 func DecodeWSGetKMIRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetKMIRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +87,7 @@ func DecodeWSGetKMIRequest(ctx context.Context, data interface{}) (interface{},
 // WS KMI request to a messages/kmi.proto-domain kmi request.
 func DecodeWSKMIRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.KMIRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,133 @@
+// Package leader elects one replica of the daemon which runs the singleton background workers
+package leader
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+type worker struct {
+	name string
+	run  func(stop <-chan struct{})
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Elector campaigns for the lock and runs the registered workers while its replica holds it
+type Elector struct {
+	mtx     sync.Mutex
+	lock    Locker
+	leading bool
+	workers []*worker
+	logger  log.Logger
+}
+
+// Go registers a worker which only runs while the replica is the leader, the stop channel given to run
+// is closed when the leadership is lost or the elector is stopped
+func (e *Elector) Go(name string, run func(stop <-chan struct{})) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	w := &worker{
+		name: name,
+		run:  run,
+	}
+	e.workers = append(e.workers, w)
+	if e.leading {
+		e.start(w)
+	}
+}
+
+// Leading reports whether the replica is the leader
+func (e *Elector) Leading() bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return e.leading
+}
+
+func (e *Elector) start(w *worker) {
+	w.stop, w.done = make(chan struct{}), make(chan struct{})
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+		w.run(stop)
+	}(w.stop, w.done)
+}
+
+func (e *Elector) stopWorkers() {
+	for _, w := range e.workers {
+		close(w.stop)
+	}
+	for _, w := range e.workers {
+		<-w.done
+	}
+}
+
+// Campaign tries to become or stay the leader once, the workers are started when the leadership
+// is won and stopped when it is lost
+func (e *Elector) Campaign() {
+	locked, err := e.lock.Lock()
+	if err != nil {
+		level.Error(e.logger).Log("err", err)
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	switch {
+	case locked && !e.leading:
+		level.Info(e.logger).Log("leader", true)
+		e.leading = true
+		for _, w := range e.workers {
+			e.start(w)
+		}
+	case !locked && e.leading:
+		level.Warn(e.logger).Log("leader", false)
+		e.leading = false
+		e.stopWorkers()
+	}
+}
+
+// Run campaigns right away and then every interval until stop is closed, the workers are stopped
+// and the lock is released afterwards
+func (e *Elector) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		e.Campaign()
+
+		select {
+		case <-t.C:
+		case <-stop:
+			e.resign()
+			return
+		}
+	}
+}
+
+func (e *Elector) resign() {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if !e.leading {
+		return
+	}
+	e.leading = false
+	e.stopWorkers()
+
+	err := e.lock.Unlock()
+	if err != nil {
+		level.Error(e.logger).Log("err", err)
+	}
+}
+
+// NewElector returns an Elector campaigning for lock
+func NewElector(lock Locker, logger log.Logger) *Elector {
+	return &Elector{
+		lock:   lock,
+		logger: logger,
+	}
+}
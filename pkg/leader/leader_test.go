@@ -0,0 +1,129 @@
+package leader_test
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/leader"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Leader", func() {
+	Describe("Memory Lock", func() {
+		It("Should be held by one handle at a time", func() {
+			l := leader.NewMemoryLock()
+			a, b := l.Handle(), l.Handle()
+
+			locked, err := a.Lock()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(locked).Should(BeTrue())
+
+			locked, _ = a.Lock()
+			Ω(locked).Should(BeTrue())
+
+			locked, _ = b.Lock()
+			Ω(locked).Should(BeFalse())
+
+			Ω(b.Unlock()).Should(Succeed())
+			locked, _ = a.Lock()
+			Ω(locked).Should(BeTrue())
+
+			Ω(a.Unlock()).Should(Succeed())
+			locked, _ = b.Lock()
+			Ω(locked).Should(BeTrue())
+		})
+
+		It("Should take the lock away on release", func() {
+			l := leader.NewMemoryLock()
+			a := l.Handle()
+			a.Lock()
+
+			l.Release()
+			locked, _ := l.Handle().Lock()
+			Ω(locked).Should(BeTrue())
+			locked, _ = a.Lock()
+			Ω(locked).Should(BeFalse())
+		})
+	})
+
+	Describe("Elector", func() {
+		var (
+			lock    *leader.MemoryLock
+			running int32
+		)
+
+		worker := func(stop <-chan struct{}) {
+			atomic.AddInt32(&running, 1)
+			<-stop
+			atomic.AddInt32(&running, -1)
+		}
+
+		BeforeEach(func() {
+			lock = leader.NewMemoryLock()
+			atomic.StoreInt32(&running, 0)
+		})
+
+		It("Should run the workers only on the leader", func() {
+			a := leader.NewElector(lock.Handle(), log.NewNopLogger())
+			b := leader.NewElector(lock.Handle(), log.NewNopLogger())
+			a.Go("worker", worker)
+			b.Go("worker", worker)
+
+			a.Campaign()
+			b.Campaign()
+			Ω(a.Leading()).Should(BeTrue())
+			Ω(b.Leading()).Should(BeFalse())
+			Eventually(func() int32 { return atomic.LoadInt32(&running) }).Should(BeEquivalentTo(1))
+			Consistently(func() int32 { return atomic.LoadInt32(&running) }, 50*time.Millisecond).Should(BeEquivalentTo(1))
+		})
+
+		It("Should start workers registered while leading", func() {
+			e := leader.NewElector(lock.Handle(), log.NewNopLogger())
+			e.Campaign()
+			e.Go("worker", worker)
+			Eventually(func() int32 { return atomic.LoadInt32(&running) }).Should(BeEquivalentTo(1))
+		})
+
+		It("Should stop the workers when the leadership is lost", func() {
+			a := leader.NewElector(lock.Handle(), log.NewNopLogger())
+			b := leader.NewElector(lock.Handle(), log.NewNopLogger())
+			a.Go("worker", worker)
+			b.Go("worker", worker)
+
+			a.Campaign()
+			Eventually(func() int32 { return atomic.LoadInt32(&running) }).Should(BeEquivalentTo(1))
+
+			lock.Release()
+			b.Campaign()
+			Ω(b.Leading()).Should(BeTrue())
+			a.Campaign()
+			Ω(a.Leading()).Should(BeFalse())
+			Eventually(func() int32 { return atomic.LoadInt32(&running) }).Should(BeEquivalentTo(1))
+		})
+
+		It("Should hand the leadership over once the leader stops", func() {
+			a := leader.NewElector(lock.Handle(), log.NewNopLogger())
+			b := leader.NewElector(lock.Handle(), log.NewNopLogger())
+			a.Go("worker", worker)
+			b.Go("worker", worker)
+
+			stop, done := make(chan struct{}), make(chan struct{})
+			go func() {
+				defer close(done)
+				a.Run(time.Hour, stop)
+			}()
+			Eventually(a.Leading).Should(BeTrue())
+
+			close(stop)
+			<-done
+			Ω(a.Leading()).Should(BeFalse())
+			Ω(atomic.LoadInt32(&running)).Should(BeEquivalentTo(0))
+
+			b.Campaign()
+			Ω(b.Leading()).Should(BeTrue())
+			Eventually(func() int32 { return atomic.LoadInt32(&running) }).Should(BeEquivalentTo(1))
+		})
+	})
+})
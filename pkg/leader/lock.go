@@ -0,0 +1,131 @@
+package leader
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"sync"
+)
+
+// Locker is a lock shared by all replicas of the daemon, only one of them holds it at a time
+type Locker interface {
+	// Lock acquires the lock if it is free or, if the caller holds it already, checks that it still does.
+	// It reports whether the caller holds the lock.
+	Lock() (bool, error)
+
+	// Unlock releases the lock if the caller holds it
+	Unlock() error
+}
+
+// PostgresLock is a Locker using a session level advisory lock of postgres, the lock is held
+// by a dedicated connection so it is released by the database once the replica goes away
+type PostgresLock struct {
+	mtx  sync.Mutex
+	db   *sql.DB
+	key  int64
+	conn *sql.Conn
+}
+
+// Lock implements Locker
+func (l *PostgresLock) Lock() (bool, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	ctx := context.Background()
+	if l.conn != nil {
+		err := l.conn.PingContext(ctx)
+		if err != nil {
+			l.conn.Close()
+			l.conn = nil
+			return false, err
+		}
+		return true, nil
+	}
+
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var locked bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&locked)
+	if err != nil || !locked {
+		conn.Close()
+		return false, err
+	}
+
+	l.conn = conn
+	return true, nil
+}
+
+// Unlock implements Locker
+func (l *PostgresLock) Unlock() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	_, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key)
+	l.conn.Close()
+	l.conn = nil
+	return err
+}
+
+// NewPostgresLock returns a PostgresLock on db, the key of the advisory lock is derived from name
+func NewPostgresLock(db *sql.DB, name string) *PostgresLock {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return &PostgresLock{
+		db:  db,
+		key: int64(h.Sum64()),
+	}
+}
+
+// MemoryLock is a Locker within a single process, it is used with the mock database and in tests
+type MemoryLock struct {
+	mtx    sync.Mutex
+	holder *memoryHandle
+}
+
+type memoryHandle struct {
+	l *MemoryLock
+}
+
+// Handle returns a Locker on l, each handle is a separate contender for the lock
+func (l *MemoryLock) Handle() Locker {
+	return &memoryHandle{l}
+}
+
+// Release takes the lock away from its holder, as if its connection to the database was lost
+func (l *MemoryLock) Release() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.holder = nil
+}
+
+func (h *memoryHandle) Lock() (bool, error) {
+	h.l.mtx.Lock()
+	defer h.l.mtx.Unlock()
+
+	if h.l.holder == nil {
+		h.l.holder = h
+	}
+	return h.l.holder == h, nil
+}
+
+func (h *memoryHandle) Unlock() error {
+	h.l.mtx.Lock()
+	defer h.l.mtx.Unlock()
+
+	if h.l.holder == h {
+		h.l.holder = nil
+	}
+	return nil
+}
+
+// NewMemoryLock returns a free MemoryLock
+func NewMemoryLock() *MemoryLock {
+	return &MemoryLock{}
+}
@@ -0,0 +1,131 @@
+// Package lifecycle shuts the components of the daemon down in dependency order
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"google.golang.org/grpc"
+)
+
+// StopFunc stops a component, it should return once the component stopped or ctx is done
+type StopFunc func(ctx context.Context) error
+
+type component struct {
+	name string
+	stop StopFunc
+}
+
+// Manager stops the registered components in the reverse order of their registration,
+// so components have to be registered after the components they depend on
+type Manager struct {
+	mtx        sync.Mutex
+	components []component
+	logger     log.Logger
+}
+
+// Add registers a component which is stopped by stop
+func (m *Manager) Add(name string, stop StopFunc) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.components = append(m.components, component{name, stop})
+}
+
+// Go runs a background worker in a goroutine, the stop channel given to run is closed on shutdown
+// and the worker counts as stopped once run returned
+func (m *Manager) Go(name string, run func(stop <-chan struct{})) {
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		run(stop)
+	}()
+
+	m.Add(name, func(ctx context.Context) error {
+		close(stop)
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// Shutdown stops every component, once timeout passed the remaining components are stopped with a
+// context which is done already. The returned error names the components which did not stop cleanly.
+func (m *Manager) Shutdown(timeout time.Duration) error {
+	m.mtx.Lock()
+	components := m.components
+	m.components = nil
+	m.mtx.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	level.Info(m.logger).Log("msg", "shutting down", "components", len(components), "timeout", timeout)
+
+	failed := []string{}
+	for i := len(components) - 1; i >= 0; i-- {
+		c := components[i]
+		begin := time.Now()
+
+		err := c.stop(ctx)
+		if err != nil {
+			level.Error(m.logger).Log("msg", "stopping failed", "name", c.name, "err", err)
+			failed = append(failed, c.name)
+			continue
+		}
+		level.Info(m.logger).Log("msg", "stopped", "name", c.name, "took", time.Since(begin))
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%s did not stop cleanly", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// NewManager returns a Manager logging the shutdown progress to logger
+func NewManager(logger log.Logger) *Manager {
+	return &Manager{
+		components: []component{},
+		logger:     logger,
+	}
+}
+
+// HTTPServer stops s after the requests in progress finished
+func HTTPServer(s *http.Server) StopFunc {
+	return s.Shutdown
+}
+
+// GRPCServer stops s after the calls in progress finished, the remaining calls are cancelled once ctx is done
+func GRPCServer(s *grpc.Server) StopFunc {
+	return func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			s.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			s.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Closer stops a component by closing c
+func Closer(c io.Closer) StopFunc {
+	return func(context.Context) error {
+		return c.Close()
+	}
+}
@@ -0,0 +1,85 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/lifecycle"
+	"google.golang.org/grpc"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lifecycle", func() {
+	var lc *lifecycle.Manager
+
+	BeforeEach(func() {
+		lc = lifecycle.NewManager(log.NewNopLogger())
+	})
+
+	It("Should stop components in reverse order", func() {
+		stopped := []string{}
+		for _, name := range []string{"database", "worker", "transport"} {
+			name := name
+			lc.Add(name, func(context.Context) error {
+				stopped = append(stopped, name)
+				return nil
+			})
+		}
+
+		Expect(lc.Shutdown(time.Second)).To(Succeed())
+		Expect(stopped).To(Equal([]string{"transport", "worker", "database"}))
+	})
+
+	It("Should wait for workers to return", func() {
+		flushed := false
+		lc.Go("worker", func(stop <-chan struct{}) {
+			<-stop
+			time.Sleep(10 * time.Millisecond)
+			flushed = true
+		})
+
+		Expect(lc.Shutdown(time.Second)).To(Succeed())
+		Expect(flushed).To(BeTrue())
+	})
+
+	It("Should give up once the timeout passed", func() {
+		lc.Add("database", func(context.Context) error {
+			return nil
+		})
+		lc.Go("worker", func(stop <-chan struct{}) {
+			select {}
+		})
+		lc.Add("transport", func(context.Context) error {
+			return errors.New("fail")
+		})
+
+		begin := time.Now()
+		err := lc.Shutdown(20 * time.Millisecond)
+		Expect(err).To(MatchError("transport, worker did not stop cleanly"))
+		Expect(time.Since(begin)).To(BeNumerically("<", time.Second))
+	})
+
+	It("Should stop servers", func() {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		s := grpc.NewServer()
+		served := make(chan error)
+		go func() {
+			served <- s.Serve(ln)
+		}()
+
+		srv := &http.Server{Addr: "127.0.0.1:0"}
+		lc.Add("gRPC", lifecycle.GRPCServer(s))
+		lc.Add("http", lifecycle.HTTPServer(srv))
+
+		Expect(lc.Shutdown(time.Second)).To(Succeed())
+		Eventually(served).Should(Receive())
+		Expect(srv.ListenAndServe()).To(Equal(http.ErrServerClosed))
+	})
+})
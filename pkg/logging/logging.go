@@ -0,0 +1,185 @@
+// Package logging provides the leveled, structured logging of the daemon, records can be filtered per module
+// and requests are logged with their request id
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// ModuleKeys are the keys whose value names the module a record belongs to, the first one found is used
+var ModuleKeys = []string{"service", "component", "transport"}
+
+// levels are the log levels, ordered from the lowest to the highest
+var levels = []level.Value{
+	level.DebugValue(),
+	level.InfoValue(),
+	level.WarnValue(),
+	level.ErrorValue(),
+}
+
+// ParseLevel returns the log level named name
+func ParseLevel(name string) (level.Value, error) {
+	for _, l := range levels {
+		if l.String() == name {
+			return l, nil
+		}
+	}
+	return nil, fmt.Errorf("log level %s does not exist", name)
+}
+
+// rank returns the position of l in levels
+func rank(l level.Value) int {
+	for i, v := range levels {
+		if v == l {
+			return i
+		}
+	}
+	return -1
+}
+
+// ParseModules parses per module log levels in the form of "routing=debug,network=warn"
+func ParseModules(spec string) (map[string]level.Value, error) {
+	modules := make(map[string]level.Value)
+	for _, m := range strings.Split(spec, ",") {
+		m = strings.TrimSpace(m)
+		if m == "" {
+			continue
+		}
+
+		kv := strings.SplitN(m, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("%q is not in the form of module=level", m)
+		}
+
+		l, err := ParseLevel(kv[1])
+		if err != nil {
+			return nil, err
+		}
+		modules[kv[0]] = l
+	}
+	return modules, nil
+}
+
+// NewLogger returns a logger writing records to w in format, which is either logfmt or json
+func NewLogger(w io.Writer, format string) (log.Logger, error) {
+	switch format {
+	case "logfmt":
+		return log.NewLogfmtLogger(log.NewSyncWriter(w)), nil
+	case "json":
+		return log.NewJSONLogger(log.NewSyncWriter(w)), nil
+	}
+	return nil, fmt.Errorf("log format %s does not exist", format)
+}
+
+// LevelLogger filters log records by their level, the minimum level can be set per module and changed
+// while the logger is used. Records without a level are always logged.
+type LevelLogger struct {
+	mtx     sync.RWMutex
+	next    log.Logger
+	level   int
+	modules map[string]int
+}
+
+// record returns the level and module of a record
+func record(keyvals []interface{}) (level.Value, string) {
+	var (
+		l      level.Value
+		module string
+		found  = len(ModuleKeys)
+	)
+
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == level.Key() {
+			if v, ok := keyvals[i+1].(level.Value); ok {
+				l = v
+			}
+			continue
+		}
+
+		k, ok := keyvals[i].(string)
+		if !ok {
+			continue
+		}
+		for j, mk := range ModuleKeys[:found] {
+			if k == mk {
+				module, found = fmt.Sprint(keyvals[i+1]), j
+				break
+			}
+		}
+	}
+	return l, module
+}
+
+// Log implements log.Logger
+func (l *LevelLogger) Log(keyvals ...interface{}) error {
+	lvl, module := record(keyvals)
+	if lvl != nil {
+		l.mtx.RLock()
+		min, ok := l.modules[module]
+		if !ok {
+			min = l.level
+		}
+		l.mtx.RUnlock()
+
+		if rank(lvl) < min {
+			return nil
+		}
+	}
+	return l.next.Log(keyvals...)
+}
+
+// SetLevel changes the minimum level of logged records
+func (l *LevelLogger) SetLevel(name string) error {
+	lvl, err := ParseLevel(name)
+	if err != nil {
+		return err
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.level = rank(lvl)
+	return nil
+}
+
+// SetModules changes the minimum levels of the records of modules, see ParseModules for the format of spec.
+// Modules which are not part of spec use the level set with SetLevel.
+func (l *LevelLogger) SetModules(spec string) error {
+	parsed, err := ParseModules(spec)
+	if err != nil {
+		return err
+	}
+
+	modules := make(map[string]int)
+	for m, lvl := range parsed {
+		modules[m] = rank(lvl)
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.modules = modules
+	return nil
+}
+
+// NewLevelLogger returns a LevelLogger logging records of at least level name, or the level of their module in modules, to next
+func NewLevelLogger(next log.Logger, name, modules string) (*LevelLogger, error) {
+	l := &LevelLogger{
+		next: next,
+	}
+
+	err := l.SetLevel(name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = l.SetModules(modules)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
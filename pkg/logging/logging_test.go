@@ -0,0 +1,158 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type response struct {
+	Error error
+}
+
+var _ = Describe("Logging", func() {
+	var buf *bytes.Buffer
+
+	BeforeEach(func() {
+		buf = &bytes.Buffer{}
+	})
+
+	Describe("NewLogger", func() {
+		It("Should write JSON", func() {
+			logger, err := logging.NewLogger(buf, "json")
+			Expect(err).NotTo(HaveOccurred())
+			level.Info(logger).Log("msg", "hello")
+			Expect(buf.String()).To(MatchJSON(`{"level":"info","msg":"hello"}`))
+		})
+
+		It("Should reject unknown formats", func() {
+			_, err := logging.NewLogger(buf, "xml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ParseModules", func() {
+		It("Should parse levels per module", func() {
+			modules, err := logging.ParseModules("routing=debug, network=warn")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(modules).To(Equal(map[string]level.Value{
+				"routing": level.DebugValue(),
+				"network": level.WarnValue(),
+			}))
+		})
+
+		It("Should reject malformed modules", func() {
+			_, err := logging.ParseModules("routing")
+			Expect(err).To(HaveOccurred())
+			_, err = logging.ParseModules("routing=loud")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("LevelLogger", func() {
+		It("Should filter records by level and module", func() {
+			l, err := logging.NewLevelLogger(log.NewLogfmtLogger(buf), "info", "routing=debug,network=error")
+			Expect(err).NotTo(HaveOccurred())
+
+			level.Debug(l).Log("msg", "dropped")
+			level.Debug(log.With(l, "service", "routing")).Log("msg", "routing debug")
+			level.Warn(log.With(l, "service", "network")).Log("msg", "dropped")
+			level.Warn(l).Log("msg", "warning")
+			l.Log("msg", "no level")
+
+			Expect(buf.String()).NotTo(ContainSubstring("dropped"))
+			Expect(buf.String()).To(ContainSubstring("routing debug"))
+			Expect(buf.String()).To(ContainSubstring("warning"))
+			Expect(buf.String()).To(ContainSubstring("no level"))
+		})
+
+		It("Should change the levels", func() {
+			l, err := logging.NewLevelLogger(log.NewLogfmtLogger(buf), "info", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(l.SetLevel("error")).To(Succeed())
+			Expect(l.SetModules("acme=debug")).To(Succeed())
+			Expect(l.SetLevel("loud")).NotTo(Succeed())
+
+			level.Warn(l).Log("msg", "dropped")
+			level.Debug(log.With(l, "component", "acme")).Log("msg", "acme debug")
+			Expect(buf.String()).NotTo(ContainSubstring("dropped"))
+			Expect(buf.String()).To(ContainSubstring("acme debug"))
+		})
+	})
+
+	Describe("Recorder", func() {
+		It("Should keep the most recent errors", func() {
+			r := logging.NewRecorder(log.NewLogfmtLogger(buf), 2)
+			logger := log.With(r, "service", "routing")
+
+			level.Error(logger).Log("err", "first")
+			level.Info(logger).Log("msg", "ignored")
+			level.Error(logger).Log("msg", "second")
+			level.Error(logger).Log("msg", "failed", "err", "third")
+
+			entries := r.Entries(0)
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0].Message).To(Equal("third"))
+			Expect(entries[0].Module).To(Equal("routing"))
+			Expect(entries[0].Record).To(Equal("level=error service=routing msg=failed err=third"))
+			Expect(entries[1].Message).To(Equal("second"))
+
+			Expect(r.Entries(1)).To(HaveLen(1))
+			Expect(buf.String()).To(ContainSubstring("msg=ignored"))
+		})
+	})
+
+	Describe("Middleware", func() {
+		It("Should log failed calls with their request id", func() {
+			logger := log.NewLogfmtLogger(buf)
+			e := logging.Middleware(logger, "user", "GetUser")(func(ctx context.Context, request interface{}) (interface{}, error) {
+				return response{Error: errors.New("user not found")}, nil
+			})
+
+			_, err := e(logging.ContextWithRequestID(context.Background(), "abc"), nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buf.String()).To(ContainSubstring("level=warn service=user method=GetUser"))
+			Expect(buf.String()).To(ContainSubstring(`request=abc err="user not found"`))
+		})
+
+		It("Should log successful calls at debug level", func() {
+			logger := log.NewLogfmtLogger(buf)
+			e := logging.Middleware(logger, "user", "GetUser")(func(ctx context.Context, request interface{}) (interface{}, error) {
+				return response{}, nil
+			})
+
+			e(context.Background(), nil)
+			Expect(buf.String()).To(HavePrefix("level=debug"))
+		})
+	})
+
+	Describe("Handler", func() {
+		It("Should keep the request id of the client", func() {
+			var id string
+			h := logging.Handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				id = logging.RequestID(req.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("X-Request-ID", "abc")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			Expect(id).To(Equal("abc"))
+			Expect(rec.Header().Get("X-Request-ID")).To(Equal("abc"))
+
+			rec = httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			Expect(rec.Header().Get("X-Request-ID")).To(HaveLen(16))
+		})
+	})
+})
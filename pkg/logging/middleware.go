@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// responseError returns the error of an endpoint call, the services return most errors in the Error field of their responses
+func responseError(response interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(response))
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	f := v.FieldByName("Error")
+	if !f.IsValid() || f.Type() != errorType || f.IsNil() {
+		return nil
+	}
+	return f.Interface().(error)
+}
+
+// Middleware returns a middleware logging the calls of the endpoint method of service, successful calls
+// are logged at debug and failed ones at warn level
+func Middleware(logger log.Logger, service, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				keyvals := []interface{}{"service", service, "method", method, "took", time.Since(begin)}
+				if id := RequestID(ctx); id != "" {
+					keyvals = append(keyvals, "request", id)
+				}
+
+				if e := responseError(response, err); e != nil {
+					level.Warn(logger).Log(append(keyvals, "err", e)...)
+					return
+				}
+				level.Debug(logger).Log(keyvals...)
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Entry is a log record kept by a Recorder
+type Entry struct {
+	Time   time.Time
+	Module string
+	// Message is the err or, if there is none, the msg value of the record
+	Message string
+	// Record is the whole record in logfmt
+	Record string
+}
+
+// Recorder passes records on to the next logger and keeps the most recent error records,
+// so they can be shown to operators without access to the log output
+type Recorder struct {
+	next log.Logger
+
+	mtx     sync.Mutex
+	entries []Entry
+	size    int
+	start   int
+}
+
+// Log implements log.Logger
+func (r *Recorder) Log(keyvals ...interface{}) error {
+	lvl, module := record(keyvals)
+	if lvl == level.ErrorValue() {
+		r.add(entry(keyvals, module))
+	}
+	return r.next.Log(keyvals...)
+}
+
+// entry converts a record to an Entry
+func entry(keyvals []interface{}, module string) Entry {
+	e := Entry{
+		Time:   time.Now().UTC(),
+		Module: module,
+	}
+
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch keyvals[i] {
+		case "err":
+			e.Message = fmt.Sprint(keyvals[i+1])
+		case "msg":
+			if e.Message == "" {
+				e.Message = fmt.Sprint(keyvals[i+1])
+			}
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	log.NewLogfmtLogger(buf).Log(keyvals...)
+	e.Record = strings.TrimSpace(buf.String())
+	return e
+}
+
+func (r *Recorder) add(e Entry) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if len(r.entries) < r.size {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.start] = e
+	r.start = (r.start + 1) % r.size
+}
+
+// Entries returns up to limit of the recorded entries, the most recent first. All entries are returned if limit is zero.
+func (r *Recorder) Entries(limit int) []Entry {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	n := len(r.entries)
+	if limit <= 0 || limit > n {
+		limit = n
+	}
+
+	entries := make([]Entry, 0, limit)
+	for i := 0; i < limit; i++ {
+		entries = append(entries, r.entries[(r.start+n-1-i)%n])
+	}
+	return entries
+}
+
+// NewRecorder returns a Recorder logging to next which keeps the last size error records
+func NewRecorder(next log.Logger, size int) *Recorder {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Recorder{
+		next: next,
+		size: size,
+	}
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader is the gRPC metadata key and HTTP header carrying the id of a request
+const RequestIDHeader = "x-request-id"
+
+type requestIDKey struct{}
+
+// NewRequestID returns a random id for a request or connection
+func NewRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request id id
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id of ctx, which is empty if it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// UnaryServerInterceptor adds a request id to the context of every gRPC call, the id sent by the caller
+// is used if there is one. The id is returned to the caller in the response header.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var id string
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md[RequestIDHeader]) > 0 {
+			id = md[RequestIDHeader][0]
+		} else {
+			id = NewRequestID()
+		}
+
+		grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, id))
+		return handler(ContextWithRequestID(ctx, id), req)
+	}
+}
+
+// Handler adds a request id to the context of every HTTP request, the id sent in the X-Request-ID header
+// is used if there is one. The id is returned in the X-Request-ID header of the response.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(RequestIDHeader)
+		if id == "" {
+			id = NewRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, req.WithContext(ContextWithRequestID(req.Context(), id)))
+	})
+}
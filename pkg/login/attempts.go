@@ -0,0 +1,131 @@
+package login
+
+import (
+	"sync"
+	"time"
+)
+
+// Policy is how many failed logins lock an account or a source address and for how long. Once there
+// were Threshold failures within Window, the next logins are refused for Delay, which is doubled with
+// every further failure up to MaxDelay. A Threshold of 0 never locks.
+type Policy struct {
+	Threshold int
+	Delay     time.Duration
+	MaxDelay  time.Duration
+	Window    time.Duration
+}
+
+// lockout returns how long failures failed logins lock out
+func (p Policy) lockout(failures int) time.Duration {
+	if p.Threshold <= 0 || failures < p.Threshold {
+		return 0
+	}
+
+	d := p.Delay
+	for i := p.Threshold; i < failures && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
+
+type attempt struct {
+	failures int
+	last     time.Time
+	until    time.Time
+}
+
+// attempts counts the failed logins by key, they are kept in memory and not shared between nodes
+type attempts struct {
+	mtx     sync.Mutex
+	entries map[string]*attempt
+	fails   int
+}
+
+// sweepInterval is the number of failures after which the forgotten entries are removed
+const sweepInterval = 1000
+
+// current returns the entry of key at now, the failures are forgotten once the window passed
+// without any and the entry is not locked anymore
+func (a *attempts) current(key string, p Policy, now time.Time) *attempt {
+	e, ok := a.entries[key]
+	if !ok {
+		return nil
+	}
+	if now.Sub(e.last) > p.Window && !now.Before(e.until) {
+		delete(a.entries, key)
+		return nil
+	}
+	return e
+}
+
+// sweep removes the entries which are forgotten at now
+func (a *attempts) sweep(window time.Duration, now time.Time) {
+	for key, e := range a.entries {
+		if now.Sub(e.last) > window && !now.Before(e.until) {
+			delete(a.entries, key)
+		}
+	}
+}
+
+// locked returns how long key is locked at now, it is 0 if it is not
+func (a *attempts) locked(key string, p Policy, now time.Time) time.Duration {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	e := a.current(key, p, now)
+	if e == nil || !now.Before(e.until) {
+		return 0
+	}
+	return e.until.Sub(now)
+}
+
+// failures returns the number of failures of key which are not forgotten at now
+func (a *attempts) failures(key string, p Policy, now time.Time) int {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	e := a.current(key, p, now)
+	if e == nil {
+		return 0
+	}
+	return e.failures
+}
+
+// fail counts a failed login of key at now and locks it according to p
+func (a *attempts) fail(key string, p Policy, now time.Time) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.fails++
+	if a.fails%sweepInterval == 0 {
+		a.sweep(p.Window, now)
+	}
+
+	e := a.current(key, p, now)
+	if e == nil {
+		e = &attempt{}
+		a.entries[key] = e
+	}
+
+	e.failures++
+	e.last = now
+	if d := p.lockout(e.failures); d > 0 {
+		e.until = now.Add(d)
+	}
+}
+
+// reset forgets the failures of key
+func (a *attempts) reset(key string) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	delete(a.entries, key)
+}
+
+func newAttempts() *attempts {
+	return &attempts{
+		entries: make(map[string]*attempt),
+	}
+}
@@ -0,0 +1,77 @@
+package login
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Captcha verifies the response of a client to a CAPTCHA, ip is the address of the client and empty if it
+// is not known
+type Captcha interface {
+	Verify(ctx context.Context, response string, ip string) error
+}
+
+type siteVerify struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func (s *siteVerify) Verify(ctx context.Context, response string, ip string) error {
+	form := url.Values{
+		"secret":   {s.secret},
+		"response": {response},
+	}
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := s.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha verification returned %s", res.Status)
+	}
+
+	v := siteVerifyResponse{}
+	err = json.NewDecoder(res.Body).Decode(&v)
+	if err != nil {
+		return err
+	}
+	if !v.Success {
+		return ErrCaptchaInvalid
+	}
+	return nil
+}
+
+// NewSiteVerify returns a Captcha verifying the responses at the siteverify endpoint u of reCAPTCHA, hCaptcha
+// or Turnstile, which share the API, with the secret of the site
+func NewSiteVerify(u, secret string, client *http.Client) Captcha {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &siteVerify{
+		url:    u,
+		secret: secret,
+		client: client,
+	}
+}
@@ -0,0 +1,21 @@
+package login
+
+import (
+	"time"
+)
+
+// Device is a network and a client a user logged in from before
+type Device struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	// Network is the network of the addresses logged in from, the /24 of IPv4 and the /48 of IPv6 addresses
+	Network   string
+	UserAgent string
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// TableName sets Device's database table name
+func (Device) TableName() string {
+	return "login_devices"
+}
@@ -0,0 +1,288 @@
+// Package login protects the authentication of the users against guessing passwords and tells them about
+// logins from networks and devices they did not use before. Failed logins are counted per account and per
+// source address, both are locked out for a growing while once they failed too often and logins of accounts
+// or addresses under attack may have to solve a CAPTCHA.
+package login
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+)
+
+const (
+	// ClientAddrHeader is the gRPC metadata key the gateway forwards the address of its clients with,
+	// it is only trusted from peers on the loopback interface
+	ClientAddrHeader = "x-client-addr"
+
+	// ClientAgentHeader is the gRPC metadata key the gateway forwards the user agent of its clients with
+	ClientAgentHeader = "x-client-agent"
+)
+
+var (
+	// ErrCaptchaRequired occurs if a login of an account or source address which failed too often is
+	// made without solving the CAPTCHA
+	ErrCaptchaRequired = errors.New("captcha required")
+
+	// ErrCaptchaInvalid occurs if the response to the CAPTCHA is wrong
+	ErrCaptchaInvalid = errors.New("captcha invalid")
+)
+
+// LockedError is returned for logins of accounts or source addresses which are locked out
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed logins, retry in %s", e.RetryAfter)
+}
+
+// Client is the origin of a login, the fields are empty if they are not known
+type Client struct {
+	IP        string
+	UserAgent string
+}
+
+// Notifier sends the email telling a user about a login from a new network or device, it is satisfied by
+// the mail service
+type Notifier interface {
+	Notify(refID uint, template string, data interface{}) (uint, error)
+}
+
+// Options configure the protection of the logins
+type Options struct {
+	Account Policy
+	Source  Policy
+
+	// CaptchaAfter is the number of failures of an account or a source address after which logins
+	// have to solve a CAPTCHA, it is never required if it is 0
+	CaptchaAfter int
+
+	// Notify sends an email to users logging in from a network or device they did not use before
+	Notify bool
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+// Guard decides whether logins may be attempted and remembers the networks and devices of the users
+type Guard struct {
+	db       dbAdapter
+	captcha  Captcha
+	mails    Notifier
+	options  Options
+	logger   log.Logger
+	accounts *attempts
+	sources  *attempts
+
+	mtx sync.Mutex
+}
+
+// account returns the key of the failures of username, which is not case sensitive
+func account(username string) string {
+	return strings.ToLower(username)
+}
+
+// network returns the network ip is remembered by, ip itself if it is no address
+func network(ip string) string {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return ip
+	}
+
+	if v4 := addr.To4(); v4 != nil {
+		return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+	}
+	return (&net.IPNet{IP: addr.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
+
+// Check returns a *LockedError if username or the address of c is locked out and ErrCaptchaRequired or
+// ErrCaptchaInvalid if the login has to solve a CAPTCHA, response is the response of the client to it
+func (g *Guard) Check(ctx context.Context, username string, c Client, response string) error {
+	now := time.Now()
+
+	wait := g.accounts.locked(account(username), g.options.Account, now)
+	if c.IP != "" {
+		if d := g.sources.locked(c.IP, g.options.Source, now); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 {
+		return &LockedError{
+			RetryAfter: wait,
+		}
+	}
+
+	if g.captcha == nil || g.options.CaptchaAfter <= 0 {
+		return nil
+	}
+
+	failures := g.accounts.failures(account(username), g.options.Account, now)
+	if c.IP != "" {
+		if n := g.sources.failures(c.IP, g.options.Source, now); n > failures {
+			failures = n
+		}
+	}
+	if failures < g.options.CaptchaAfter {
+		return nil
+	}
+
+	if response == "" {
+		return ErrCaptchaRequired
+	}
+	err := g.captcha.Verify(ctx, response, c.IP)
+	if err != nil && err != ErrCaptchaInvalid {
+		level.Error(g.logger).Log("msg", "the captcha could not be verified", "err", err)
+		return ErrCaptchaRequired
+	}
+	return err
+}
+
+// Fail counts a failed login of username from c
+func (g *Guard) Fail(username string, c Client) {
+	now := time.Now()
+	g.accounts.fail(account(username), g.options.Account, now)
+	if c.IP != "" {
+		g.sources.fail(c.IP, g.options.Source, now)
+	}
+	level.Warn(g.logger).Log("msg", "login failed", "username", username, "ip", c.IP)
+}
+
+// Succeed forgets the failures of username and remembers the network and device of c for the user refID,
+// the user is notified if they did not log in from them before. The failures of the address are kept, so
+// logging into an own account does not allow guessing the passwords of others.
+func (g *Guard) Succeed(refID uint, username string, c Client) {
+	g.accounts.reset(account(username))
+	if c.IP == "" {
+		return
+	}
+
+	err := g.remember(refID, c)
+	if err != nil {
+		level.Error(g.logger).Log("msg", "the device of the login could not be stored", "user", refID, "err", err)
+	}
+}
+
+// devices returns the devices of the user refID
+func (g *Guard) devices(refID uint) ([]Device, error) {
+	ds := []Device{}
+	err := g.db.Find(&ds, "ref_id = ?", refID)
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// remember stores the device of c or updates when it was last seen
+func (g *Guard) remember(refID uint, c Client) error {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	now := time.Now().UTC()
+	n := network(c.IP)
+
+	d := Device{}
+	err := g.db.First(&d, "ref_id = ? AND network = ? AND user_agent = ?", refID, n, c.UserAgent)
+	if err == nil {
+		g.db.Begin()
+		err = g.db.Where("id = ?", d.ID)
+		if err != nil {
+			g.db.Rollback()
+			return err
+		}
+
+		err = g.db.Update(&Device{}, &Device{LastSeen: now})
+		if err != nil {
+			g.db.Rollback()
+			return err
+		}
+		g.db.Commit()
+		return nil
+	}
+	if !g.db.IsNotFound(err) {
+		return err
+	}
+
+	// the first login of a user is not news to them
+	err = g.db.First(&Device{}, "ref_id = ?", refID)
+	known := err == nil
+	if err != nil && !g.db.IsNotFound(err) {
+		return err
+	}
+
+	err = g.db.Create(&Device{
+		RefID:     refID,
+		Network:   n,
+		UserAgent: c.UserAgent,
+		FirstSeen: now,
+		LastSeen:  now,
+	})
+	if err != nil {
+		return err
+	}
+
+	if !known || !g.options.Notify || g.mails == nil {
+		return nil
+	}
+
+	_, err = g.mails.Notify(refID, mail.NewLogin, mail.LoginData{
+		IP:        c.IP,
+		UserAgent: c.UserAgent,
+		Time:      now,
+	})
+	if err != nil {
+		level.Warn(g.logger).Log("msg", "the user could not be notified of the login", "user", refID, "err", err)
+	}
+	return nil
+}
+
+// Devices returns the networks and devices the user refID logged in from
+func (g *Guard) Devices(refID uint, out *[]Device) error {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	ds, err := g.devices(refID)
+	if err != nil {
+		return err
+	}
+
+	*out = append(*out, ds...)
+	return nil
+}
+
+// NewGuard returns a Guard keeping the devices in db, the failures are counted in memory on each node.
+// captcha and mails may be nil if no CAPTCHA is configured or the daemon sends no emails.
+func NewGuard(db dbAdapter, captcha Captcha, mails Notifier, o Options, logger log.Logger) (*Guard, error) {
+	g := &Guard{
+		db:       db,
+		captcha:  captcha,
+		mails:    mails,
+		options:  o,
+		logger:   logger,
+		accounts: newAttempts(),
+		sources:  newAttempts(),
+	}
+
+	err := g.db.AutoMigrate(&Device{})
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
@@ -0,0 +1,240 @@
+package login_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/login"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type notification struct {
+	refID    uint
+	template string
+	data     mail.LoginData
+}
+
+type mockNotifier struct {
+	sent []notification
+}
+
+func (n *mockNotifier) Notify(refID uint, template string, data interface{}) (uint, error) {
+	n.sent = append(n.sent, notification{refID: refID, template: template, data: data.(mail.LoginData)})
+	return uint(len(n.sent)), nil
+}
+
+type mockCaptcha struct {
+	err error
+}
+
+func (c *mockCaptcha) Verify(ctx context.Context, response string, ip string) error {
+	return c.err
+}
+
+var _ = Describe("Login", func() {
+	var (
+		ctx     = context.Background()
+		captcha *mockCaptcha
+		mails   *mockNotifier
+		options login.Options
+		g       *login.Guard
+		client  = login.Client{IP: "192.0.2.10", UserAgent: "kroo-cli/1.0"}
+		other   = login.Client{IP: "198.51.100.7", UserAgent: "kroo-cli/1.0"}
+	)
+
+	policy := login.Policy{
+		Threshold: 3,
+		Delay:     time.Minute,
+		MaxDelay:  10 * time.Minute,
+		Window:    time.Hour,
+	}
+
+	BeforeEach(func() {
+		captcha = &mockCaptcha{}
+		mails = &mockNotifier{}
+		options = login.Options{
+			Account: policy,
+			Source:  login.Policy{Threshold: 5, Delay: time.Minute, MaxDelay: time.Hour, Window: time.Hour},
+			Notify:  true,
+		}
+
+		var err error
+		g, err = login.NewGuard(testutils.NewMockDB(), captcha, mails, options, log.NewNopLogger())
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("Create Guard", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := login.NewGuard(db, nil, nil, login.Options{}, log.NewNopLogger())
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Check", func() {
+		It("Should allow logins below the threshold", func() {
+			g.Fail("alice", client)
+			g.Fail("alice", client)
+			Ω(g.Check(ctx, "alice", client, "")).Should(Succeed())
+		})
+
+		It("Should lock an account out once it reached the threshold", func() {
+			for i := 0; i < 3; i++ {
+				g.Fail("alice", client)
+			}
+
+			err := g.Check(ctx, "Alice", other, "")
+			Expect(err).To(BeAssignableToTypeOf(&login.LockedError{}))
+			Expect(err.(*login.LockedError).RetryAfter).To(BeNumerically("~", time.Minute, time.Second))
+		})
+
+		It("Should double the lockout with every further failure up to the maximum", func() {
+			for i := 0; i < 4; i++ {
+				g.Fail("alice", client)
+			}
+			err := g.Check(ctx, "alice", other, "")
+			Expect(err.(*login.LockedError).RetryAfter).To(BeNumerically("~", 2*time.Minute, time.Second))
+
+			for i := 0; i < 10; i++ {
+				g.Fail("alice", client)
+			}
+			err = g.Check(ctx, "alice", other, "")
+			Expect(err.(*login.LockedError).RetryAfter).To(BeNumerically("~", 10*time.Minute, time.Second))
+		})
+
+		It("Should lock a source address out for every account", func() {
+			for _, username := range []string{"alice", "bob", "carol", "dave", "erin"} {
+				g.Fail(username, client)
+			}
+
+			err := g.Check(ctx, "frank", client, "")
+			Expect(err).To(BeAssignableToTypeOf(&login.LockedError{}))
+			Ω(g.Check(ctx, "frank", other, "")).Should(Succeed())
+		})
+
+		Context("With a CAPTCHA", func() {
+			BeforeEach(func() {
+				options.CaptchaAfter = 2
+				var err error
+				g, err = login.NewGuard(testutils.NewMockDB(), captcha, mails, options, log.NewNopLogger())
+				Ω(err).ShouldNot(HaveOccurred())
+
+				g.Fail("alice", client)
+				g.Fail("alice", client)
+			})
+
+			It("Should require it once an account failed too often", func() {
+				Expect(g.Check(ctx, "alice", other, "")).To(Equal(login.ErrCaptchaRequired))
+				Ω(g.Check(ctx, "alice", other, "solved")).Should(Succeed())
+			})
+
+			It("Should not require it for other accounts and addresses", func() {
+				Ω(g.Check(ctx, "bob", other, "")).Should(Succeed())
+			})
+
+			It("Should refuse wrong responses", func() {
+				captcha.err = login.ErrCaptchaInvalid
+				Expect(g.Check(ctx, "alice", client, "wrong")).To(Equal(login.ErrCaptchaInvalid))
+			})
+
+			It("Should still require it if the verification failed", func() {
+				captcha.err = errors.New("connection refused")
+				Expect(g.Check(ctx, "alice", client, "solved")).To(Equal(login.ErrCaptchaRequired))
+			})
+		})
+	})
+
+	Describe("Succeed", func() {
+		It("Should forget the failures of the account but not of the address", func() {
+			for i := 0; i < 4; i++ {
+				g.Fail("alice", client)
+			}
+			g.Succeed(1, "alice", client)
+			Ω(g.Check(ctx, "alice", other, "")).Should(Succeed())
+
+			g.Fail("bob", client)
+			Expect(g.Check(ctx, "bob", client, "")).To(BeAssignableToTypeOf(&login.LockedError{}))
+		})
+
+		It("Should remember the devices without notifying of the first login", func() {
+			g.Succeed(1, "alice", client)
+			g.Succeed(1, "alice", login.Client{IP: "192.0.2.99", UserAgent: client.UserAgent})
+			Expect(mails.sent).To(BeEmpty())
+
+			ds := []login.Device{}
+			Ω(g.Devices(1, &ds)).Should(Succeed())
+			Expect(ds).To(HaveLen(1))
+			Expect(ds[0].Network).To(Equal("192.0.2.0/24"))
+		})
+
+		It("Should notify of logins from new networks and devices", func() {
+			g.Succeed(1, "alice", client)
+			g.Succeed(1, "alice", other)
+			g.Succeed(1, "alice", login.Client{IP: client.IP, UserAgent: "Mozilla/5.0"})
+
+			Expect(mails.sent).To(HaveLen(2))
+			Expect(mails.sent[0].refID).To(Equal(uint(1)))
+			Expect(mails.sent[0].template).To(Equal(mail.NewLogin))
+			Expect(mails.sent[0].data.IP).To(Equal(other.IP))
+			Expect(mails.sent[1].data.UserAgent).To(Equal("Mozilla/5.0"))
+
+			ds := []login.Device{}
+			Ω(g.Devices(1, &ds)).Should(Succeed())
+			Expect(ds).To(HaveLen(3))
+		})
+
+		It("Should not remember logins from unknown addresses", func() {
+			g.Succeed(1, "alice", login.Client{})
+			ds := []login.Device{}
+			Ω(g.Devices(1, &ds)).Should(Succeed())
+			Expect(ds).To(BeEmpty())
+		})
+	})
+
+	Describe("SiteVerify", func() {
+		var (
+			srv  *httptest.Server
+			form map[string]string
+		)
+
+		BeforeEach(func() {
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				form = map[string]string{
+					"secret":   r.PostForm.Get("secret"),
+					"response": r.PostForm.Get("response"),
+					"remoteip": r.PostForm.Get("remoteip"),
+				}
+				if r.PostForm.Get("response") == "solved" {
+					w.Write([]byte(`{"success": true}`))
+					return
+				}
+				w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+			}))
+		})
+
+		AfterEach(func() {
+			srv.Close()
+		})
+
+		It("Should verify the response with the secret and the address", func() {
+			c := login.NewSiteVerify(srv.URL, "s3cret", nil)
+			Ω(c.Verify(ctx, "solved", "192.0.2.10")).Should(Succeed())
+			Expect(form).To(Equal(map[string]string{"secret": "s3cret", "response": "solved", "remoteip": "192.0.2.10"}))
+		})
+
+		It("Should refuse wrong responses", func() {
+			c := login.NewSiteVerify(srv.URL, "s3cret", nil)
+			Expect(c.Verify(ctx, "guess", "")).To(Equal(login.ErrCaptchaInvalid))
+		})
+	})
+})
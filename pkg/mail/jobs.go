@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+// SendJob is the type of the job delivering a rendered message
+const SendJob = "mail.send"
+
+// JobOptions retry failed deliveries for about a day, so outages of the provider do not lose messages
+var JobOptions = jobs.Options{
+	Workers:     2,
+	MaxAttempts: 10,
+	Backoff:     time.Minute,
+	MaxBackoff:  4 * time.Hour,
+}
+
+// SendHandler returns the handler of SendJob, messages the provider rejected are dropped instead of retried
+func SendHandler(s Service, logger log.Logger) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		m := Message{}
+		err := j.Decode(&m)
+		if err != nil {
+			return err
+		}
+
+		err = s.Deliver(m)
+		if IsRejected(err) {
+			level.Warn(logger).Log("msg", "dropped", "job", j.ID, "to", strings.Join(m.To, ","), "err", err)
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,121 @@
+// Package mail sends templated emails to the users through SMTP, SendGrid or Mailgun, messages are
+// sent in the background by the job queue so failed deliveries are retried
+package mail
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Providers
+const (
+	// SMTP sends the messages to an SMTP server
+	SMTP = "smtp"
+	// SendGrid sends the messages with the API of SendGrid
+	SendGrid = "sendgrid"
+	// Mailgun sends the messages with the API of Mailgun
+	Mailgun = "mailgun"
+)
+
+var (
+	// ErrNoRecipient occurs if a message is sent to nobody
+	ErrNoRecipient = errors.New("message has no recipient")
+
+	// ErrTemplateNotExist occurs if a message is rendered from an unknown template
+	ErrTemplateNotExist = errors.New("template does not exist")
+)
+
+// Message is an email with a plain text and an optional HTML body
+type Message struct {
+	From    string   `json:"from"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	HTML    string   `json:"html,omitempty"`
+}
+
+// Provider delivers messages
+type Provider interface {
+	Send(m Message) error
+}
+
+// Rejected is returned by providers for messages which will never be delivered, e.g. to an
+// invalid address, they are not retried
+type Rejected struct {
+	Err error
+}
+
+func (r Rejected) Error() string {
+	return fmt.Sprintf("rejected: %v", r.Err)
+}
+
+// IsRejected reports whether err is Rejected
+func IsRejected(err error) bool {
+	_, ok := err.(Rejected)
+	return ok
+}
+
+// Bytes returns m as a MIME message, HTML messages are sent as multipart/alternative
+func (m Message) Bytes() ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return nil, err
+	}
+	domain := "localhost"
+	if i := strings.LastIndex(m.From, "@"); i >= 0 {
+		domain = strings.Trim(m.From[i+1:], "> ")
+	}
+
+	fmt.Fprintf(buf, "From: %s\r\n", m.From)
+	fmt.Fprintf(buf, "To: %s\r\n", strings.Join(m.To, ", "))
+	fmt.Fprintf(buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(id), domain)
+	fmt.Fprintf(buf, "MIME-Version: 1.0\r\n")
+
+	if m.HTML == "" {
+		fmt.Fprintf(buf, "Content-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+		err = writeQuoted(buf, m.Text)
+		return buf.Bytes(), err
+	}
+
+	w := multipart.NewWriter(buf)
+	fmt.Fprintf(buf, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", w.Boundary())
+	for _, part := range []struct{ typ, body string }{{"text/plain", m.Text}, {"text/html", m.HTML}} {
+		p, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = writeQuoted(p, part.body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = w.Close()
+	return buf.Bytes(), err
+}
+
+func writeQuoted(w io.Writer, s string) error {
+	q := quotedprintable.NewWriter(w)
+	_, err := q.Write([]byte(s))
+	if err != nil {
+		return err
+	}
+	return q.Close()
+}
@@ -0,0 +1,361 @@
+package mail_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/mail"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type job struct {
+	typ     string
+	payload string
+}
+
+type mockQueue struct {
+	jobs []job
+}
+
+func (q *mockQueue) Enqueue(typ string, payload interface{}) (uint, error) {
+	b, _ := json.Marshal(payload)
+	q.jobs = append(q.jobs, job{typ: typ, payload: string(b)})
+	return uint(len(q.jobs)), nil
+}
+
+type mockRecipients map[uint]string
+
+func (r mockRecipients) Email(refID uint) (string, error) {
+	email, ok := r[refID]
+	if !ok {
+		return "", errors.New("user does not exist")
+	}
+	return email, nil
+}
+
+type failingProvider struct {
+	err error
+}
+
+func (p failingProvider) Send(m mail.Message) error {
+	return p.err
+}
+
+// smtpServer accepts one SMTP session, recipients are rejected with 550 if reject is set
+func smtpServer(reject bool) (host string, port int, data chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Ω(err).ShouldNot(HaveOccurred())
+	data = make(chan string, 1)
+
+	go func() {
+		defer l.Close()
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		c := textproto.NewConn(conn)
+		c.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := c.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); {
+			case cmd == "EHLO" || cmd == "HELO":
+				c.PrintfLine("250 localhost")
+			case cmd == "RCPT" && reject:
+				c.PrintfLine("550 no such user")
+			case cmd == "DATA":
+				c.PrintfLine("354 go ahead")
+				b, _ := c.ReadDotBytes()
+				data <- string(b)
+				c.PrintfLine("250 ok")
+			case cmd == "QUIT":
+				c.PrintfLine("221 bye")
+				return
+			default:
+				c.PrintfLine("250 ok")
+			}
+		}
+	}()
+
+	host, p, _ := net.SplitHostPort(l.Addr().String())
+	port, _ = strconv.Atoi(p)
+	return host, port, data
+}
+
+var _ = Describe("Mail", func() {
+	message := mail.Message{
+		From:    "kontainer.ooo <noreply@kontainer.ooo>",
+		To:      []string{"user@example.com"},
+		Subject: "Grüße",
+		Text:    "Hello",
+		HTML:    "<p>Hello</p>",
+	}
+
+	Describe("Message", func() {
+		It("Should encode plain text messages", func() {
+			b, err := mail.Message{From: "a@kontainer.ooo", To: []string{"b@example.com", "c@example.com"}, Subject: "Hi", Text: "Hello"}.Bytes()
+			Ω(err).ShouldNot(HaveOccurred())
+			msg := string(b)
+			Expect(msg).To(ContainSubstring("To: b@example.com, c@example.com\r\n"))
+			Expect(msg).To(ContainSubstring("Message-ID: <"))
+			Expect(msg).To(ContainSubstring("@kontainer.ooo>\r\n"))
+			Expect(msg).To(ContainSubstring("Content-Type: text/plain; charset=utf-8\r\n"))
+			Expect(msg).To(HaveSuffix("\r\n\r\nHello"))
+		})
+
+		It("Should send HTML as an alternative", func() {
+			b, err := message.Bytes()
+			Ω(err).ShouldNot(HaveOccurred())
+			msg := string(b)
+			Expect(msg).To(ContainSubstring("Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n"))
+			Expect(msg).To(ContainSubstring("Content-Type: multipart/alternative; boundary="))
+			Expect(msg).To(ContainSubstring("<p>Hello</p>"))
+		})
+	})
+
+	Describe("Templates", func() {
+		It("Should render the built-in templates", func() {
+			t, err := mail.NewTemplates("")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			m, err := t.Render(mail.PasswordReset, mail.LinkData{Username: "<b>bob</b>", Link: "https://kontainer.ooo/reset?token=a&b"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.Subject).To(Equal("Reset your password"))
+			Expect(m.Text).To(ContainSubstring("Hello <b>bob</b>,"))
+			Expect(m.Text).To(ContainSubstring("https://kontainer.ooo/reset?token=a&b"))
+			Expect(m.HTML).To(ContainSubstring("Hello &lt;b&gt;bob&lt;/b&gt;,"))
+			Expect(m.HTML).To(ContainSubstring(`href="https://kontainer.ooo/reset?token=a&amp;b"`))
+
+			m, err = t.Render(mail.Incident, mail.IncidentData{
+				Title:   "Maintenance",
+				Message: "The database is upgraded.",
+				End:     time.Date(2017, 6, 15, 12, 0, 0, 0, time.UTC),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.Subject).To(Equal("Maintenance"))
+			Expect(m.Text).To(Equal("The database is upgraded.\n\nEnd: 2017-06-15 12:00 UTC\n"))
+
+			m, err = t.Render(mail.NodeDrained, mail.DrainData{
+				Node:      "node1",
+				Instances: []string{"web", "db"},
+				Stopped:   true,
+				Time:      time.Date(2017, 6, 15, 12, 0, 0, 0, time.UTC),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.Subject).To(Equal("Your instances were stopped for maintenance of node1"))
+			Expect(m.Text).To(ContainSubstring("since 2017-06-15 12:00 UTC"))
+			Expect(m.Text).To(ContainSubstring("\n- web\n- db\n"))
+			Expect(m.HTML).To(ContainSubstring("<li>web</li><li>db</li>"))
+
+			m, err = t.Render(mail.NodeFailed, mail.FailureData{
+				Node: "node1",
+				Lost: []string{"db"},
+				Time: time.Date(2017, 6, 15, 12, 0, 0, 0, time.UTC),
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.Subject).To(Equal("Your instances on node1 were lost"))
+			Expect(m.Text).To(Equal("The node node1 failed at 2017-06-15 12:00 UTC.\n\nThe following instances are unavailable until the node is back:\n\n- db\n"))
+		})
+
+		It("Should return the errors of unknown templates and missing data", func() {
+			t, _ := mail.NewTemplates("")
+
+			_, err := t.Render("welcome", nil)
+			Expect(err).To(Equal(mail.ErrTemplateNotExist))
+
+			_, err = t.Render(mail.Verification, map[string]string{"Username": "bob"})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should load overrides and new templates from a directory", func() {
+			dir, _ := ioutil.TempDir("", "kroo-mail")
+			defer os.RemoveAll(dir)
+			ioutil.WriteFile(filepath.Join(dir, "verification.subject"), []byte("Welcome {{.Username}}\n"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "welcome.subject"), []byte("Welcome"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "welcome.txt"), []byte("Hello {{.}}"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "README"), []byte("ignored"), 0644)
+
+			t, err := mail.NewTemplates(dir)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			m, err := t.Render(mail.Verification, mail.LinkData{Username: "bob", Link: "https://kontainer.ooo"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.Subject).To(Equal("Welcome bob"))
+			Expect(m.Text).To(ContainSubstring("please verify your email address"))
+
+			m, err = t.Render("welcome", "bob")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m).To(Equal(mail.Message{Subject: "Welcome", Text: "Hello bob"}))
+
+			ioutil.WriteFile(filepath.Join(dir, "broken.txt"), []byte("{{.Username"), 0644)
+			_, err = mail.NewTemplates(dir)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Providers", func() {
+		It("Should keep the messages in the sandbox", func() {
+			s := mail.NewSandbox(2, nil)
+			for _, subject := range []string{"a", "b", "c"} {
+				Ω(s.Send(mail.Message{Subject: subject})).Should(Succeed())
+			}
+			Expect(s.Messages()).To(Equal([]mail.Message{{Subject: "b"}, {Subject: "c"}}))
+		})
+
+		It("Should send the messages to an SMTP server", func() {
+			host, port, data := smtpServer(false)
+			p := mail.NewSMTPProvider(mail.SMTPOptions{Host: host, Port: port})
+
+			Ω(p.Send(message)).Should(Succeed())
+			// the dot reader of the server converts the line endings
+			Expect(<-data).To(ContainSubstring("To: user@example.com\n"))
+		})
+
+		It("Should reject recipients refused by the SMTP server", func() {
+			host, port, _ := smtpServer(true)
+			p := mail.NewSMTPProvider(mail.SMTPOptions{Host: host, Port: port})
+
+			err := p.Send(message)
+			Expect(mail.IsRejected(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("no such user")))
+		})
+
+		It("Should send the messages with the API of SendGrid", func() {
+			var body map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/v3/mail/send"))
+				Expect(r.Header.Get("Authorization")).To(Equal("Bearer key"))
+				json.NewDecoder(r.Body).Decode(&body)
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+			p := mail.NewSendGridProvider(mail.APIOptions{Endpoint: server.URL, APIKey: "key"})
+
+			Ω(p.Send(message)).Should(Succeed())
+			Expect(body["from"]).To(Equal(map[string]interface{}{"email": "noreply@kontainer.ooo"}))
+			Expect(body["personalizations"]).To(Equal([]interface{}{
+				map[string]interface{}{"to": []interface{}{map[string]interface{}{"email": "user@example.com"}}},
+			}))
+			Expect(body["content"]).To(HaveLen(2))
+		})
+
+		It("Should send the messages with the API of Mailgun", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.URL.Path).To(Equal("/v3/mg.kontainer.ooo/messages"))
+				user, key, _ := r.BasicAuth()
+				Expect(user + ":" + key).To(Equal("api:key"))
+				Expect(r.FormValue("to")).To(Equal("user@example.com"))
+				Expect(r.FormValue("html")).To(Equal("<p>Hello</p>"))
+				if r.FormValue("subject") != "Grüße" {
+					http.Error(w, "invalid subject", http.StatusBadRequest)
+				}
+			}))
+			defer server.Close()
+			p := mail.NewMailgunProvider(mail.APIOptions{Endpoint: server.URL, APIKey: "key", Domain: "mg.kontainer.ooo"})
+
+			Ω(p.Send(message)).Should(Succeed())
+
+			m := message
+			m.Subject = "Hi"
+			err := p.Send(m)
+			Expect(mail.IsRejected(err)).To(BeTrue())
+			Expect(err).To(MatchError(ContainSubstring("400 Bad Request: invalid subject")))
+		})
+
+		It("Should retry server errors and rate limits", func() {
+			status := http.StatusTooManyRequests
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+			p := mail.NewSendGridProvider(mail.APIOptions{Endpoint: server.URL})
+
+			err := p.Send(message)
+			Expect(err).To(HaveOccurred())
+			Expect(mail.IsRejected(err)).To(BeFalse())
+
+			status = http.StatusBadGateway
+			err = p.Send(message)
+			Expect(err).To(HaveOccurred())
+			Expect(mail.IsRejected(err)).To(BeFalse())
+		})
+	})
+
+	Describe("Service", func() {
+		var (
+			sandbox *mail.Sandbox
+			queue   *mockQueue
+			s       mail.Service
+		)
+
+		BeforeEach(func() {
+			sandbox = mail.NewSandbox(0, nil)
+			queue = &mockQueue{}
+			templates, _ := mail.NewTemplates("")
+			s = mail.NewService(sandbox, templates, mockRecipients{1: "bob@example.com", 2: ""}, queue, mail.Options{
+				From: "kontainer.ooo <noreply@kontainer.ooo>",
+			})
+		})
+
+		It("Should render the messages and send them in the background", func() {
+			id, err := s.Notify(1, mail.Verification, mail.LinkData{Username: "bob", Link: "https://kontainer.ooo/verify"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(id).To(Equal(uint(1)))
+			Expect(queue.jobs[0].typ).To(Equal(mail.SendJob))
+			Expect(sandbox.Messages()).To(BeEmpty())
+
+			h := mail.SendHandler(s, log.NewNopLogger())
+			Ω(h(context.Background(), jobs.Job{Payload: queue.jobs[0].payload})).Should(Succeed())
+			m := sandbox.Messages()
+			Expect(m).To(HaveLen(1))
+			Expect(m[0].From).To(Equal("kontainer.ooo <noreply@kontainer.ooo>"))
+			Expect(m[0].To).To(Equal([]string{"bob@example.com"}))
+			Expect(m[0].Subject).To(Equal("Verify your email address"))
+		})
+
+		It("Should return the errors of the recipients and templates", func() {
+			_, err := s.Notify(2, mail.Verification, mail.LinkData{})
+			Expect(err).To(Equal(mail.ErrNoRecipient))
+
+			_, err = s.Notify(3, mail.Verification, mail.LinkData{})
+			Expect(err).To(HaveOccurred())
+
+			_, err = s.Send(nil, mail.Verification, mail.LinkData{})
+			Expect(err).To(Equal(mail.ErrNoRecipient))
+
+			_, err = s.Send([]string{"bob@example.com"}, "welcome", nil)
+			Expect(err).To(Equal(mail.ErrTemplateNotExist))
+			Expect(queue.jobs).To(BeEmpty())
+		})
+
+		It("Should retry failed deliveries but drop rejected ones", func() {
+			templates, _ := mail.NewTemplates("")
+			payload, _ := json.Marshal(message)
+
+			failing := mail.NewService(failingProvider{errors.New("timeout")}, templates, nil, queue, mail.Options{})
+			Expect(mail.SendHandler(failing, log.NewNopLogger())(context.Background(), jobs.Job{Payload: string(payload)})).To(HaveOccurred())
+
+			rejecting := mail.NewService(failingProvider{mail.Rejected{errors.New("invalid address")}}, templates, nil, queue, mail.Options{})
+			Ω(mail.SendHandler(rejecting, log.NewNopLogger())(context.Background(), jobs.Job{Payload: string(payload)})).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,252 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// SMTPOptions configure the SMTP server messages are sent to, STARTTLS is used if the server offers it
+type SMTPOptions struct {
+	Host string
+	Port int
+	// Username and Password authenticate with PLAIN, no authentication is used without a username
+	Username string
+	Password string
+}
+
+type smtpProvider struct {
+	options SMTPOptions
+}
+
+func (p *smtpProvider) Send(m Message) error {
+	msg, err := m.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if p.options.Username != "" {
+		auth = smtp.PlainAuth("", p.options.Username, p.options.Password, p.options.Host)
+	}
+
+	err = smtp.SendMail(net.JoinHostPort(p.options.Host, strconv.Itoa(p.options.Port)), auth, address(m.From), addresses(m.To), msg)
+	if e, ok := err.(*textproto.Error); ok && e.Code >= 500 {
+		return Rejected{err}
+	}
+	return err
+}
+
+// NewSMTPProvider returns a Provider sending the messages to an SMTP server
+func NewSMTPProvider(o SMTPOptions) Provider {
+	return &smtpProvider{
+		options: o,
+	}
+}
+
+// address returns the address of a name-addr like Name <user@example.com>
+func address(s string) string {
+	if i := strings.LastIndex(s, "<"); i >= 0 {
+		return strings.TrimSuffix(s[i+1:], ">")
+	}
+	return s
+}
+
+func addresses(s []string) []string {
+	out := make([]string, len(s))
+	for i, a := range s {
+		out[i] = address(a)
+	}
+	return out
+}
+
+// checkResponse returns the error of an API response, client errors other than rate limits are Rejected
+func checkResponse(provider string, res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+
+	msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	err := fmt.Errorf("%s: %s: %s", provider, res.Status, strings.TrimSpace(string(msg)))
+	if res.StatusCode >= 400 && res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests {
+		return Rejected{err}
+	}
+	return err
+}
+
+// APIOptions configure the account of an API provider
+type APIOptions struct {
+	// Endpoint is the URL of the API, e.g. https://api.eu.mailgun.net, it defaults to the one of the provider
+	Endpoint string
+	APIKey   string
+	// Domain is the sending domain of Mailgun
+	Domain string
+}
+
+type sendGridProvider struct {
+	options APIOptions
+	client  *http.Client
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridMessage struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+func (p *sendGridProvider) Send(m Message) error {
+	personalization := sendGridPersonalization{}
+	for _, to := range m.To {
+		personalization.To = append(personalization.To, sendGridAddress{Email: address(to)})
+	}
+	msg := sendGridMessage{
+		Personalizations: []sendGridPersonalization{personalization},
+		From:             sendGridAddress{Email: address(m.From)},
+		Subject:          m.Subject,
+		Content:          []sendGridContent{{"text/plain", m.Text}},
+	}
+	if m.HTML != "" {
+		msg.Content = append(msg.Content, sendGridContent{"text/html", m.HTML})
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(p.options.Endpoint, "/")+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.options.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return checkResponse(SendGrid, res)
+}
+
+// NewSendGridProvider returns a Provider sending the messages with the v3 API of SendGrid
+func NewSendGridProvider(o APIOptions) Provider {
+	if o.Endpoint == "" {
+		o.Endpoint = "https://api.sendgrid.com"
+	}
+	return &sendGridProvider{
+		options: o,
+		client:  &http.Client{},
+	}
+}
+
+type mailgunProvider struct {
+	options APIOptions
+	client  *http.Client
+}
+
+func (p *mailgunProvider) Send(m Message) error {
+	form := url.Values{
+		"from":    {m.From},
+		"to":      m.To,
+		"subject": {m.Subject},
+		"text":    {m.Text},
+	}
+	if m.HTML != "" {
+		form.Set("html", m.HTML)
+	}
+
+	u := fmt.Sprintf("%s/v3/%s/messages", strings.TrimSuffix(p.options.Endpoint, "/"), url.PathEscape(p.options.Domain))
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("api", p.options.APIKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return checkResponse(Mailgun, res)
+}
+
+// NewMailgunProvider returns a Provider sending the messages with the v3 API of Mailgun
+func NewMailgunProvider(o APIOptions) Provider {
+	if o.Endpoint == "" {
+		o.Endpoint = "https://api.mailgun.net"
+	}
+	return &mailgunProvider{
+		options: o,
+		client:  &http.Client{},
+	}
+}
+
+// Sandbox keeps the messages instead of sending them, it is used for tests and installations
+// which must not send emails
+type Sandbox struct {
+	mtx      sync.Mutex
+	messages []Message
+	limit    int
+	logger   log.Logger
+}
+
+// Send logs m and keeps it, the oldest message is dropped once the limit is reached
+func (s *Sandbox) Send(m Message) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.logger != nil {
+		level.Info(s.logger).Log("msg", "sandboxed", "to", strings.Join(m.To, ","), "subject", m.Subject)
+	}
+	s.messages = append(s.messages, m)
+	if s.limit > 0 && len(s.messages) > s.limit {
+		s.messages = s.messages[1:]
+	}
+	return nil
+}
+
+// Messages returns the kept messages, oldest first
+func (s *Sandbox) Messages() []Message {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return append([]Message{}, s.messages...)
+}
+
+// NewSandbox returns a Sandbox keeping the last limit messages, every message is kept if limit is 0.
+// logger may be nil
+func NewSandbox(limit int, logger log.Logger) *Sandbox {
+	return &Sandbox{
+		limit:  limit,
+		logger: logger,
+	}
+}
@@ -0,0 +1,86 @@
+package mail
+
+// Service sends the templated messages
+type Service interface {
+	// Send renders template for data and sends it to the addresses to in the background, it returns
+	// the ID of the job
+	Send(to []string, template string, data interface{}) (uint, error)
+
+	// Notify sends template to the email address of the user refID in the background
+	Notify(refID uint, template string, data interface{}) (uint, error)
+
+	// Deliver sends m through the provider, messages without a sender are sent from the default one
+	Deliver(m Message) error
+}
+
+// Recipients know the email addresses of the users
+type Recipients interface {
+	Email(refID uint) (string, error)
+}
+
+// Queue runs the deliveries in the background
+type Queue interface {
+	Enqueue(typ string, payload interface{}) (uint, error)
+}
+
+// Options configure the service
+type Options struct {
+	// From is the sender of the messages, e.g. kontainer.ooo <noreply@kontainer.ooo>
+	From string
+}
+
+type service struct {
+	provider   Provider
+	templates  *Templates
+	recipients Recipients
+	queue      Queue
+	options    Options
+}
+
+// Send renders the message right away, so unknown templates and missing data are returned to the caller
+func (s *service) Send(to []string, template string, data interface{}) (uint, error) {
+	if len(to) == 0 {
+		return 0, ErrNoRecipient
+	}
+
+	m, err := s.templates.Render(template, data)
+	if err != nil {
+		return 0, err
+	}
+	m.From = s.options.From
+	m.To = to
+
+	return s.queue.Enqueue(SendJob, m)
+}
+
+func (s *service) Notify(refID uint, template string, data interface{}) (uint, error) {
+	email, err := s.recipients.Email(refID)
+	if err != nil {
+		return 0, err
+	}
+	if email == "" {
+		return 0, ErrNoRecipient
+	}
+	return s.Send([]string{email}, template, data)
+}
+
+func (s *service) Deliver(m Message) error {
+	if len(m.To) == 0 {
+		return ErrNoRecipient
+	}
+	if m.From == "" {
+		m.From = s.options.From
+	}
+	return s.provider.Send(m)
+}
+
+// NewService returns a Service sending the messages rendered from templates through provider
+func NewService(provider Provider, templates *Templates, recipients Recipients, q Queue, o Options) Service {
+	return &service{
+		provider:   provider,
+		templates:  templates,
+		recipients: recipients,
+		queue:      q,
+		options:    o,
+	}
+}
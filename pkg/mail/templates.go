@@ -0,0 +1,394 @@
+package mail
+
+import (
+	"bytes"
+	htmlTemplate "html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	textTemplate "text/template"
+	"time"
+)
+
+// Built-in templates
+const (
+	// Verification asks a user to verify the email address, it is rendered with LinkData
+	Verification = "verification"
+	// PasswordReset sends a link to reset the password, it is rendered with LinkData
+	PasswordReset = "password-reset"
+	// Incident notifies the users of an incident or maintenance, it is rendered with IncidentData
+	Incident = "incident"
+	// CronFailed notifies a user of a failed run of a cron job, it is rendered with CronData
+	CronFailed = "cron-failed"
+	// AlertFiring notifies a user of an alert rule which fired, it is rendered with AlertData
+	AlertFiring = "alert-firing"
+	// AlertResolved notifies a user of a fired alert rule which was resolved, it is rendered with AlertData
+	AlertResolved = "alert-resolved"
+	// NodeDrained notifies a user of instances moved off or stopped on a drained node, it is rendered with DrainData
+	NodeDrained = "node-drained"
+	// NodeFailed notifies a user of instances lost with a failed node, it is rendered with FailureData
+	NodeFailed = "node-failed"
+	// Impersonated notifies a user of an admin acting as them, it is rendered with ImpersonationData
+	Impersonated = "impersonated"
+	// ExportReady tells a user that the export of their data can be downloaded, it is rendered with ExportData
+	ExportReady = "export-ready"
+	// ExportFailed tells a user that the export of their data failed, it is rendered with ExportData
+	ExportFailed = "export-failed"
+	// NewLogin tells a user about a login from a network or device they did not use before, it is rendered
+	// with LoginData
+	NewLogin = "new-login"
+)
+
+// LinkData is the data of the templates sending a link to a user
+type LinkData struct {
+	Username string
+	Link     string
+}
+
+// IncidentData is the data of Incident, Start and End are left out if they are zero
+type IncidentData struct {
+	Title   string
+	Message string
+	Start   time.Time
+	End     time.Time
+}
+
+// CronData is the data of CronFailed, Output is the end of the output of the run
+type CronData struct {
+	Job      string
+	Instance string
+	Command  string
+	Error    string
+	Output   string
+	Time     time.Time
+}
+
+// AlertData is the data of AlertFiring and AlertResolved, Value is the sampled value of the metric
+type AlertData struct {
+	Rule      string
+	Instance  string
+	Metric    string
+	Threshold float64
+	Value     float64
+	Time      time.Time
+}
+
+// DrainData is the data of NodeDrained, Instances are the names of the user's instances on Node
+type DrainData struct {
+	Node      string
+	Instances []string
+	Stopped   bool
+	Time      time.Time
+}
+
+// FailureData is the data of NodeFailed, Rescheduled are the names of the user's instances created again
+// on other nodes and Lost those left on Node, since they are pinned to it or no other node was left
+type FailureData struct {
+	Node        string
+	Rescheduled []string
+	Lost        []string
+	Time        time.Time
+}
+
+// ImpersonationData is the data of Impersonated, the admin may act as the user from Start to End
+type ImpersonationData struct {
+	Reason string
+	Start  time.Time
+	End    time.Time
+}
+
+// ExportData is the data of ExportReady and ExportFailed, the archive of a ready export is removed at Expires
+type ExportData struct {
+	ID      uint
+	Error   string
+	Expires time.Time
+}
+
+// LoginData is the data of NewLogin, UserAgent is empty if the client did not send one
+type LoginData struct {
+	IP        string
+	UserAgent string
+	Time      time.Time
+}
+
+type template struct {
+	subject *textTemplate.Template
+	text    *textTemplate.Template
+	html    *htmlTemplate.Template
+}
+
+// source is the unparsed subject, text and HTML of a template, the HTML is optional
+type source struct {
+	subject, text, html string
+}
+
+var builtin = map[string]source{
+	Verification: {
+		subject: "Verify your email address",
+		text: `Hello {{.Username}},
+
+please verify your email address by opening {{.Link}}
+
+If you did not create an account you can ignore this email.
+`,
+		html: `<p>Hello {{.Username}},</p>
+<p>please verify your email address by opening <a href="{{.Link}}">this link</a>.</p>
+<p>If you did not create an account you can ignore this email.</p>
+`,
+	},
+	PasswordReset: {
+		subject: "Reset your password",
+		text: `Hello {{.Username}},
+
+a new password was requested for your account, you can choose it at {{.Link}}
+
+If you did not request it you can ignore this email, your password is not changed.
+`,
+		html: `<p>Hello {{.Username}},</p>
+<p>a new password was requested for your account, you can choose it <a href="{{.Link}}">here</a>.</p>
+<p>If you did not request it you can ignore this email, your password is not changed.</p>
+`,
+	},
+	Incident: {
+		subject: "{{.Title}}",
+		text: `{{.Message}}
+{{if not .Start.IsZero}}
+Start: {{.Start.UTC.Format "2006-01-02 15:04 MST"}}{{end}}{{if not .End.IsZero}}
+End: {{.End.UTC.Format "2006-01-02 15:04 MST"}}{{end}}
+`,
+		html: `<p>{{.Message}}</p>
+{{if not .Start.IsZero}}<p>Start: {{.Start.UTC.Format "2006-01-02 15:04 MST"}}</p>{{end}}
+{{if not .End.IsZero}}<p>End: {{.End.UTC.Format "2006-01-02 15:04 MST"}}</p>{{end}}
+`,
+	},
+	CronFailed: {
+		subject: "Cron job {{.Job}} failed",
+		text: `The cron job {{.Job}} of the instance {{.Instance}} failed at {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.
+
+Command: {{.Command}}
+Error: {{.Error}}
+{{if .Output}}
+Output:
+{{.Output}}
+{{end}}`,
+		html: `<p>The cron job <b>{{.Job}}</b> of the instance <b>{{.Instance}}</b> failed at {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.</p>
+<p>Command: <code>{{.Command}}</code><br>Error: {{.Error}}</p>
+{{if .Output}}<pre>{{.Output}}</pre>{{end}}
+`,
+	},
+	AlertFiring: {
+		subject: "Alert {{.Rule}} is firing for {{.Instance}}",
+		text: `The {{.Metric}} of the instance {{.Instance}} is {{printf "%.1f" .Value}}, which exceeds the threshold {{printf "%.1f" .Threshold}} of the alert rule {{.Rule}}, since {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.
+`,
+		html: `<p>The {{.Metric}} of the instance <b>{{.Instance}}</b> is {{printf "%.1f" .Value}}, which exceeds the threshold {{printf "%.1f" .Threshold}} of the alert rule <b>{{.Rule}}</b>, since {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.</p>
+`,
+	},
+	AlertResolved: {
+		subject: "Alert {{.Rule}} is resolved for {{.Instance}}",
+		text: `The {{.Metric}} of the instance {{.Instance}} is {{printf "%.1f" .Value}} and within the threshold {{printf "%.1f" .Threshold}} of the alert rule {{.Rule}} again since {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.
+`,
+		html: `<p>The {{.Metric}} of the instance <b>{{.Instance}}</b> is {{printf "%.1f" .Value}} and within the threshold {{printf "%.1f" .Threshold}} of the alert rule <b>{{.Rule}}</b> again since {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.</p>
+`,
+	},
+	NodeDrained: {
+		subject: "{{if .Stopped}}Your instances were stopped{{else}}Your instances were moved{{end}} for maintenance of {{.Node}}",
+		text: `The node {{.Node}} is under maintenance since {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.
+{{if .Stopped}}
+The following instances were stopped and can be started again once the maintenance is over:
+{{else}}
+The following instances were moved to other nodes and created again from their images, files written inside them were not moved:
+{{end}}{{range .Instances}}
+- {{.}}{{end}}
+`,
+		html: `<p>The node <b>{{.Node}}</b> is under maintenance since {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.</p>
+{{if .Stopped}}<p>The following instances were stopped and can be started again once the maintenance is over:</p>
+{{else}}<p>The following instances were moved to other nodes and created again from their images, files written inside them were not moved:</p>
+{{end}}<ul>{{range .Instances}}<li>{{.}}</li>{{end}}</ul>
+`,
+	},
+	NodeFailed: {
+		subject: "Your instances on {{.Node}} were lost",
+		text: `The node {{.Node}} failed at {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.
+{{if .Rescheduled}}
+The following instances were created again on other nodes from their images, files written inside them were lost:
+{{range .Rescheduled}}
+- {{.}}{{end}}
+{{end}}{{if .Lost}}
+The following instances are unavailable until the node is back:
+{{range .Lost}}
+- {{.}}{{end}}
+{{end}}`,
+		html: `<p>The node <b>{{.Node}}</b> failed at {{.Time.UTC.Format "2006-01-02 15:04 MST"}}.</p>
+{{if .Rescheduled}}<p>The following instances were created again on other nodes from their images, files written inside them were lost:</p>
+<ul>{{range .Rescheduled}}<li>{{.}}</li>{{end}}</ul>
+{{end}}{{if .Lost}}<p>The following instances are unavailable until the node is back:</p>
+<ul>{{range .Lost}}<li>{{.}}</li>{{end}}</ul>
+{{end}}`,
+	},
+	Impersonated: {
+		subject: "An admin is acting on your behalf",
+		text: `An admin of the platform may act on your behalf from {{.Start.UTC.Format "2006-01-02 15:04 MST"}} until {{.End.UTC.Format "2006-01-02 15:04 MST"}}.
+
+Reason: {{.Reason}}
+
+Every action taken on your behalf is recorded. If you did not ask for support, please contact us.
+`,
+		html: `<p>An admin of the platform may act on your behalf from {{.Start.UTC.Format "2006-01-02 15:04 MST"}} until {{.End.UTC.Format "2006-01-02 15:04 MST"}}.</p>
+<p>Reason: {{.Reason}}</p>
+<p>Every action taken on your behalf is recorded. If you did not ask for support, please contact us.</p>
+`,
+	},
+	ExportReady: {
+		subject: "Your data export is ready",
+		text: `The export {{.ID}} of your personal data is ready. You can download it until {{.Expires.UTC.Format "2006-01-02 15:04 MST"}}, afterwards it is removed.
+`,
+		html: `<p>The export {{.ID}} of your personal data is ready. You can download it until {{.Expires.UTC.Format "2006-01-02 15:04 MST"}}, afterwards it is removed.</p>
+`,
+	},
+	ExportFailed: {
+		subject: "Your data export failed",
+		text: `The export {{.ID}} of your personal data failed: {{.Error}}
+
+Please request a new export, or contact us if it keeps failing.
+`,
+		html: `<p>The export {{.ID}} of your personal data failed: {{.Error}}</p>
+<p>Please request a new export, or contact us if it keeps failing.</p>
+`,
+	},
+	NewLogin: {
+		subject: "New login to your account",
+		text: `Your account was logged into from a network or device you did not use before.
+
+Address: {{.IP}}
+Device: {{if .UserAgent}}{{.UserAgent}}{{else}}unknown{{end}}
+Time: {{.Time.UTC.Format "2006-01-02 15:04 MST"}}
+
+If this was not you, change your password right away and contact us.
+`,
+		html: `<p>Your account was logged into from a network or device you did not use before.</p>
+<p>Address: {{.IP}}<br>
+Device: {{if .UserAgent}}{{.UserAgent}}{{else}}unknown{{end}}<br>
+Time: {{.Time.UTC.Format "2006-01-02 15:04 MST"}}</p>
+<p>If this was not you, change your password right away and contact us.</p>
+`,
+	},
+}
+
+// Templates render the messages, subjects and plain texts are text templates while HTML bodies
+// are HTML templates, so the data is escaped
+type Templates struct {
+	templates map[string]template
+}
+
+func parse(name string, s source) (template, error) {
+	t := template{}
+	var err error
+	t.subject, err = textTemplate.New(name + ".subject").Option("missingkey=error").Parse(s.subject)
+	if err != nil {
+		return t, err
+	}
+	t.text, err = textTemplate.New(name + ".txt").Option("missingkey=error").Parse(s.text)
+	if err != nil {
+		return t, err
+	}
+	if s.html != "" {
+		t.html, err = htmlTemplate.New(name + ".html").Option("missingkey=error").Parse(s.html)
+	}
+	return t, err
+}
+
+// read returns the content of file, or def if it does not exist
+func read(file, def string) (string, error) {
+	b, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return def, nil
+	}
+	return string(b), err
+}
+
+// NewTemplates returns the built-in templates, dir may contain <name>.subject, <name>.txt and
+// <name>.html files replacing the parts of a built-in template or adding new templates. dir is
+// not read if it is empty
+func NewTemplates(dir string) (*Templates, error) {
+	sources := make(map[string]source)
+	for name, s := range builtin {
+		sources[name] = s
+	}
+
+	if dir != "" {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[string]bool)
+		for _, f := range files {
+			ext := filepath.Ext(f.Name())
+			if !f.IsDir() && (ext == ".subject" || ext == ".txt" || ext == ".html") {
+				names[strings.TrimSuffix(f.Name(), ext)] = true
+			}
+		}
+
+		for name := range names {
+			s := sources[name]
+			base := filepath.Join(dir, name)
+			for _, part := range []struct {
+				ext string
+				dst *string
+			}{{".subject", &s.subject}, {".txt", &s.text}, {".html", &s.html}} {
+				*part.dst, err = read(base+part.ext, *part.dst)
+				if err != nil {
+					return nil, err
+				}
+			}
+			s.subject = strings.TrimSpace(s.subject)
+			sources[name] = s
+		}
+	}
+
+	t := &Templates{
+		templates: make(map[string]template),
+	}
+	for name, s := range sources {
+		var err error
+		t.templates[name], err = parse(name, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Render returns the message of the template name for data, it has no sender or recipients
+func (t *Templates) Render(name string, data interface{}) (Message, error) {
+	m := Message{}
+	tpl, ok := t.templates[name]
+	if !ok {
+		return m, ErrTemplateNotExist
+	}
+
+	buf := &bytes.Buffer{}
+	err := tpl.subject.Execute(buf, data)
+	if err != nil {
+		return m, err
+	}
+	// a subject is a single line
+	m.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+	buf.Reset()
+	err = tpl.text.Execute(buf, data)
+	if err != nil {
+		return m, err
+	}
+	m.Text = buf.String()
+
+	if tpl.html != nil {
+		buf.Reset()
+		err = tpl.html.Execute(buf, data)
+		if err != nil {
+			return m, err
+		}
+		m.HTML = buf.String()
+	}
+	return m, nil
+}
@@ -0,0 +1,246 @@
+// Package maintenance lets admins schedule maintenance windows of the platform. While a window is
+// active calls which change anything are rejected with a notice about the maintenance, calls which
+// only read keep working. Clients are notified when a window is scheduled, starts and ends.
+package maintenance
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// States of a window announced by a Notice
+const (
+	// Scheduled windows start in the future
+	Scheduled = "scheduled"
+	// Started windows are active, mutating calls are rejected
+	Started = "started"
+	// Ended windows are over, which includes started windows which were cancelled
+	Ended = "ended"
+	// Cancelled windows were removed before they started
+	Cancelled = "cancelled"
+)
+
+var (
+	// ErrInvalidWindow is returned if a window should end before it starts or in the past
+	ErrInvalidWindow = errors.New("a maintenance window has to end in the future and after it starts")
+
+	// ErrWindowNotExist is returned if a window does not exist or ended already
+	ErrWindowNotExist = errors.New("maintenance window does not exist")
+)
+
+// Window is a period in which the platform is maintained
+type Window struct {
+	ID      uint `gorm:"primary_key"`
+	Start   time.Time
+	End     time.Time
+	Message string
+}
+
+// TableName sets Window's database table name
+func (Window) TableName() string {
+	return "maintenance_windows"
+}
+
+// active reports whether the window is active at t
+func (w Window) active(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Error is returned for the calls which are rejected during the window
+type Error struct {
+	Window Window
+}
+
+func (e Error) Error() string {
+	msg := fmt.Sprintf("the platform is under maintenance until %s", e.Window.End.UTC().Format(time.RFC3339))
+	if e.Window.Message != "" {
+		msg += ": " + e.Window.Message
+	}
+	return msg
+}
+
+// Notice announces a change of the state of a window
+type Notice struct {
+	Window Window
+	State  string
+}
+
+// Notifier is called with every notice, e.g. to pass it on to the connected clients
+type Notifier func(n Notice)
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+// Mode knows the windows which did not end yet, it reloads them from the database so windows
+// scheduled on another node are known as well
+type Mode struct {
+	db     dbAdapter
+	notify Notifier
+	logger log.Logger
+
+	mtx     sync.RWMutex
+	windows []Window
+	// states are the last announced states of the windows
+	states map[uint]Notice
+}
+
+// Refresh reloads the windows from the database and announces the ones whose state changed
+func (m *Mode) Refresh() error {
+	now := time.Now()
+	windows := []Window{}
+	err := m.db.FindOrdered(&windows, "start", 0, "\"end\" > ?", now)
+	if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	m.windows = windows
+
+	notices := []Notice{}
+	current := make(map[uint]bool)
+	for _, w := range windows {
+		current[w.ID] = true
+		state := Scheduled
+		if w.active(now) {
+			state = Started
+		}
+		if m.states[w.ID].State != state {
+			m.states[w.ID] = Notice{w, state}
+			notices = append(notices, m.states[w.ID])
+		}
+	}
+	for id, n := range m.states {
+		if current[id] {
+			continue
+		}
+		delete(m.states, id)
+		if n.State == Started || !now.Before(n.Window.End) {
+			notices = append(notices, Notice{n.Window, Ended})
+		} else {
+			notices = append(notices, Notice{n.Window, Cancelled})
+		}
+	}
+	m.mtx.Unlock()
+
+	if m.notify != nil {
+		for _, n := range notices {
+			m.notify(n)
+		}
+	}
+	return nil
+}
+
+// Schedule stores a window, a window without a start or starting in the past starts right away
+func (m *Mode) Schedule(w *Window) error {
+	if w.Start.IsZero() {
+		w.Start = time.Now()
+	}
+	if !w.End.After(w.Start) || !w.End.After(time.Now()) {
+		return ErrInvalidWindow
+	}
+
+	err := m.db.Create(w)
+	if err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// Cancel deletes the window id, an active window ends right away
+func (m *Mode) Cancel(id uint) error {
+	m.mtx.RLock()
+	exists := false
+	for _, w := range m.windows {
+		exists = exists || w.ID == id
+	}
+	m.mtx.RUnlock()
+	if !exists {
+		return ErrWindowNotExist
+	}
+
+	err := m.db.Delete(&Window{ID: id})
+	if err != nil {
+		return err
+	}
+	return m.Refresh()
+}
+
+// Windows returns the windows which did not end yet ordered by their start
+func (m *Mode) Windows(out *[]Window) error {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	now := time.Now()
+	for _, w := range m.windows {
+		if now.Before(w.End) {
+			*out = append(*out, w)
+		}
+	}
+	return nil
+}
+
+// Active returns the window which is active now, ok is false if there is none
+func (m *Mode) Active() (w Window, ok bool) {
+	m.mtx.RLock()
+	defer m.mtx.RUnlock()
+
+	now := time.Now()
+	for _, w := range m.windows {
+		if w.active(now) {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+// Run reloads the windows every interval until stop is closed, so windows are announced at most
+// interval after they started or ended
+func (m *Mode) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			err := m.Refresh()
+			if err != nil {
+				level.Error(m.logger).Log("err", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewMode returns a Mode storing the windows in db, notify may be nil if nobody is notified
+func NewMode(db dbAdapter, notify Notifier, logger log.Logger) (*Mode, error) {
+	err := db.AutoMigrate(&Window{})
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Mode{
+		db:     db,
+		notify: notify,
+		logger: logger,
+		states: make(map[uint]Notice),
+	}
+
+	err = m.Refresh()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
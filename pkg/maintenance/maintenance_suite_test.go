@@ -0,0 +1,13 @@
+package maintenance_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestMaintenance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Maintenance Suite")
+}
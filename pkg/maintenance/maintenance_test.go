@@ -0,0 +1,193 @@
+package maintenance_test
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/maintenance"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type recorder struct {
+	mtx     sync.Mutex
+	notices []maintenance.Notice
+}
+
+func (r *recorder) notify(n maintenance.Notice) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.notices = append(r.notices, n)
+}
+
+func (r *recorder) states() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	states := []string{}
+	for _, n := range r.notices {
+		states = append(states, n.State)
+	}
+	return states
+}
+
+var _ = Describe("Maintenance", func() {
+	var (
+		db   *testutils.MockDB
+		rec  *recorder
+		mode *maintenance.Mode
+	)
+
+	BeforeEach(func() {
+		db = testutils.NewMockDB()
+		rec = &recorder{}
+		var err error
+		mode, err = maintenance.NewMode(db, rec.notify, log.NewNopLogger())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("NewMode", func() {
+		It("Should return db errors", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := maintenance.NewMode(db, nil, log.NewNopLogger())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Schedule", func() {
+		It("Should reject windows ending before they start or in the past", func() {
+			now := time.Now()
+			Expect(mode.Schedule(&maintenance.Window{Start: now.Add(time.Hour), End: now})).To(Equal(maintenance.ErrInvalidWindow))
+			Expect(mode.Schedule(&maintenance.Window{Start: now.Add(-time.Hour), End: now.Add(-time.Minute)})).To(Equal(maintenance.ErrInvalidWindow))
+		})
+
+		It("Should announce scheduled windows", func() {
+			Expect(mode.Schedule(&maintenance.Window{Start: time.Now().Add(time.Hour), End: time.Now().Add(2 * time.Hour)})).To(Succeed())
+			_, ok := mode.Active()
+			Expect(ok).To(BeFalse())
+			Expect(rec.states()).To(Equal([]string{maintenance.Scheduled}))
+		})
+
+		It("Should start windows without a start right away", func() {
+			w := &maintenance.Window{End: time.Now().Add(time.Hour), Message: "upgrade"}
+			Expect(mode.Schedule(w)).To(Succeed())
+			active, ok := mode.Active()
+			Expect(ok).To(BeTrue())
+			Expect(active.Message).To(Equal("upgrade"))
+			Expect(rec.states()).To(Equal([]string{maintenance.Started}))
+		})
+	})
+
+	Describe("Cancel", func() {
+		It("Should fail for unknown windows", func() {
+			Expect(mode.Cancel(1)).To(Equal(maintenance.ErrWindowNotExist))
+		})
+
+		It("Should cancel scheduled windows", func() {
+			w := &maintenance.Window{Start: time.Now().Add(time.Hour), End: time.Now().Add(2 * time.Hour)}
+			Expect(mode.Schedule(w)).To(Succeed())
+			Expect(mode.Cancel(w.ID)).To(Succeed())
+			Expect(rec.states()).To(Equal([]string{maintenance.Scheduled, maintenance.Cancelled}))
+
+			out := []maintenance.Window{}
+			Expect(mode.Windows(&out)).To(Succeed())
+			Expect(out).To(BeEmpty())
+		})
+
+		It("Should end active windows", func() {
+			w := &maintenance.Window{End: time.Now().Add(time.Hour)}
+			Expect(mode.Schedule(w)).To(Succeed())
+			Expect(mode.Cancel(w.ID)).To(Succeed())
+			_, ok := mode.Active()
+			Expect(ok).To(BeFalse())
+			Expect(rec.states()).To(Equal([]string{maintenance.Started, maintenance.Ended}))
+		})
+	})
+
+	Describe("Refresh", func() {
+		It("Should load windows scheduled on another node", func() {
+			other, err := maintenance.NewMode(db, nil, log.NewNopLogger())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.Schedule(&maintenance.Window{Start: time.Now().Add(time.Hour), End: time.Now().Add(2 * time.Hour)})).To(Succeed())
+			Expect(other.Schedule(&maintenance.Window{End: time.Now().Add(time.Hour)})).To(Succeed())
+
+			Expect(mode.Refresh()).To(Succeed())
+			out := []maintenance.Window{}
+			Expect(mode.Windows(&out)).To(Succeed())
+			Expect(out).To(HaveLen(2))
+			Expect(out[0].Start.Before(out[1].Start)).To(BeTrue())
+			_, ok := mode.Active()
+			Expect(ok).To(BeTrue())
+		})
+
+		It("Should announce windows once they start", func() {
+			Expect(mode.Schedule(&maintenance.Window{Start: time.Now().Add(50 * time.Millisecond), End: time.Now().Add(time.Hour)})).To(Succeed())
+			time.Sleep(60 * time.Millisecond)
+			Expect(mode.Refresh()).To(Succeed())
+			Expect(mode.Refresh()).To(Succeed())
+			Expect(rec.states()).To(Equal([]string{maintenance.Scheduled, maintenance.Started}))
+		})
+	})
+
+	Describe("Error", func() {
+		It("Should name the end and the message of the window", func() {
+			end := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+			err := maintenance.Error{Window: maintenance.Window{End: end, Message: "upgrade"}}
+			Expect(err.Error()).To(Equal("the platform is under maintenance until 2017-06-01T12:00:00Z: upgrade"))
+		})
+	})
+
+	Describe("UnaryServerInterceptor", func() {
+		var (
+			intercept grpc.UnaryServerInterceptor
+			handler   = func(ctx oldcontext.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			}
+		)
+
+		call := func(method string) error {
+			_, err := intercept(oldcontext.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+			return err
+		}
+
+		BeforeEach(func() {
+			intercept = maintenance.UnaryServerInterceptor(mode, []string{"kmi.KMIService/GetKMI", "admin.AdminService"})
+		})
+
+		It("Should accept every call without an active window", func() {
+			Expect(call("/kmi.KMIService/AddKMI")).To(Succeed())
+		})
+
+		It("Should only accept read only calls during a window", func() {
+			Expect(mode.Schedule(&maintenance.Window{End: time.Now().Add(time.Hour)})).To(Succeed())
+
+			err := call("/kmi.KMIService/AddKMI")
+			Expect(grpc.Code(err)).To(Equal(codes.Unavailable))
+			Expect(grpc.ErrorDesc(err)).To(ContainSubstring("under maintenance"))
+
+			Expect(call("/kmi.KMIService/GetKMI")).To(Succeed())
+			Expect(call("/admin.AdminService/CancelMaintenance")).To(Succeed())
+		})
+	})
+
+	Describe("Websocket", func() {
+		It("Should only accept read only calls during a window", func() {
+			before := maintenance.Websocket(mode, []string{"KMI/ALL", "KTG"})
+			kmi := ws.ProtoIDFromString("KMI")
+
+			Expect(before(kmi, ws.ProtoIDFromString("ADD"), nil, nil)).To(Succeed())
+
+			Expect(mode.Schedule(&maintenance.Window{End: time.Now().Add(time.Hour)})).To(Succeed())
+			Expect(before(kmi, ws.ProtoIDFromString("ADD"), nil, nil)).To(BeAssignableToTypeOf(maintenance.Error{}))
+			Expect(before(kmi, ws.ProtoIDFromString("ALL"), nil, nil)).To(Succeed())
+			Expect(before(ws.ProtoIDFromString("KTG"), ws.ProtoIDFromString("AUT"), nil, nil)).To(Succeed())
+		})
+	})
+})
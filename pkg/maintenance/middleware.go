@@ -0,0 +1,53 @@
+package maintenance
+
+import (
+	"strings"
+
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// allow returns whether a method is one of methods or belongs to one of the services in methods
+func allow(methods []string) func(method string) bool {
+	allowed := make(map[string]bool)
+	for _, m := range methods {
+		allowed[m] = true
+	}
+
+	return func(method string) bool {
+		if allowed[method] {
+			return true
+		}
+		i := strings.LastIndex(method, "/")
+		return i != -1 && allowed[method[:i]]
+	}
+}
+
+// UnaryServerInterceptor rejects every call with codes.Unavailable while a window of m is active,
+// except for the calls of readOnly. readOnly holds methods, e.g. kmi.KMIService/KMI, or whole
+// services, e.g. admin.AdminService.
+func UnaryServerInterceptor(m *Mode, readOnly []string) grpc.UnaryServerInterceptor {
+	allowed := allow(readOnly)
+
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if w, ok := m.Active(); ok && !allowed(strings.TrimPrefix(info.FullMethod, "/")) {
+			return nil, grpc.Errorf(codes.Unavailable, "%v", Error{w})
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Websocket returns a websocket before middleware rejecting every call while a window of m is active,
+// except for the calls of readOnly. readOnly holds methods, e.g. KMI/ALL, or whole services, e.g. KTG.
+func Websocket(m *Mode, readOnly []string) ws.MiddlewareFunc {
+	allowed := allow(readOnly)
+
+	return func(srv, me ws.ProtoID, data *ws.MiddlewareData, session interface{}) error {
+		if w, ok := m.Active(); ok && !allowed(srv.String()+"/"+me.String()) {
+			return Error{w}
+		}
+		return nil
+	}
+}
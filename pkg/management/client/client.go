@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/management"
+	"github.com/kontainerooo/kontainer.ooo/pkg/management/pb"
+)
+
+// getError returns the error of a response, the errors of the management package are returned as they are
+// so they can be compared, e.g. with management.ErrNotFound
+func getError(e string) error {
+	if e == "" {
+		return nil
+	}
+
+	for _, err := range []error{management.ErrNotFound, management.ErrImmutable, management.ErrUnavailable} {
+		if e == err.Error() {
+			return err
+		}
+	}
+	return errors.New(e)
+}
+
+// DecodeGRPCDeleteResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Delete response to a messages/management.proto-domain delete response.
+func DecodeGRPCDeleteResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DeleteResponse)
+	return &management.DeleteResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCUserRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain user request to a gRPC User request.
+func EncodeGRPCUserRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*management.UserRequest)
+	gRPCReq := &pb.UserRequest{
+		User: management.ConvertUser(req.User),
+	}
+	// the password is only sent, it is never returned
+	gRPCReq.User.Password = req.User.Password
+	return gRPCReq, nil
+}
+
+// DecodeGRPCUserResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC User response to a messages/management.proto-domain user response.
+func DecodeGRPCUserResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.UserResponse)
+	return &management.UserResponse{
+		User:  management.ConvertPBUser(response.User),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCModuleRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain module request to a gRPC Module request.
+func EncodeGRPCModuleRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*management.ModuleRequest)
+	gRPCReq := &pb.ModuleRequest{
+		Module: management.ConvertModule(req.Module),
+	}
+	// the path is only sent, it is never returned
+	gRPCReq.Module.Path = req.Module.Path
+	return gRPCReq, nil
+}
+
+// DecodeGRPCModuleResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Module response to a messages/management.proto-domain module response.
+func DecodeGRPCModuleResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ModuleResponse)
+	return &management.ModuleResponse{
+		Module: management.ConvertPBModule(response.Module),
+		Error:  getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCInstanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain instance request to a gRPC Instance request.
+func EncodeGRPCInstanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*management.InstanceRequest)
+	gRPCReq := &pb.InstanceRequest{
+		RefID:    uint32(req.RefID),
+		Instance: management.ConvertInstance(req.Instance),
+	}
+	return gRPCReq, nil
+}
+
+// DecodeGRPCInstanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Instance response to a messages/management.proto-domain instance response.
+func DecodeGRPCInstanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.InstanceResponse)
+	return &management.InstanceResponse{
+		Instance: management.ConvertPBInstance(response.Instance),
+		Error:    getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDomainRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain domain request to a gRPC Domain request.
+func EncodeGRPCDomainRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*management.DomainRequest)
+	gRPCReq := &pb.DomainRequest{
+		RefID:  uint32(req.RefID),
+		Domain: management.ConvertDomain(req.Domain),
+	}
+	return gRPCReq, nil
+}
+
+// DecodeGRPCDomainResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Domain response to a messages/management.proto-domain domain response.
+func DecodeGRPCDomainResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DomainResponse)
+	return &management.DomainResponse{
+		Domain: management.ConvertPBDomain(response.Domain),
+		Error:  getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCPolicyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain policy request to a gRPC Policy request.
+func EncodeGRPCPolicyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*management.PolicyRequest)
+	gRPCReq := &pb.PolicyRequest{
+		RefID:  uint32(req.RefID),
+		Policy: management.ConvertPolicy(req.Policy),
+	}
+	return gRPCReq, nil
+}
+
+// DecodeGRPCPolicyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Policy response to a messages/management.proto-domain policy response.
+func DecodeGRPCPolicyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.PolicyResponse)
+	return &management.PolicyResponse{
+		Policy: management.ConvertPBPolicy(response.Policy),
+		Error:  getError(response.Error),
+	}, nil
+}
@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/management"
+)
+
+// Client is a typed client of the management API for infrastructure as code tools. Every method returns
+// the resource as it is stored afterwards, missing resources fail with management.ErrNotFound.
+type Client struct {
+	e *management.Endpoints
+}
+
+// NewClient returns a Client calling the management API via conn, the token of the caller is sent with
+// the credentials of conn, see kentheguru.NewTokenCredentials
+func NewClient(conn *grpc.ClientConn, logger log.Logger) *Client {
+	return &Client{
+		e: New(conn, logger),
+	}
+}
+
+// call invokes e with req and returns the error of the call or of the response
+func call(ctx context.Context, e endpoint.Endpoint, req interface{}) (interface{}, error) {
+	res, err := e(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if d, ok := res.(*management.DeleteResponse); ok {
+		return d, d.Error
+	}
+	return res, nil
+}
+
+// CreateUser creates a user
+func (c *Client) CreateUser(ctx context.Context, u management.User) (management.User, error) {
+	res, err := call(ctx, c.e.CreateUserEndpoint, &management.UserRequest{User: u})
+	if err != nil {
+		return management.User{}, err
+	}
+
+	r := res.(*management.UserResponse)
+	return r.User, r.Error
+}
+
+// GetUser returns a user
+func (c *Client) GetUser(ctx context.Context, id uint) (management.User, error) {
+	res, err := call(ctx, c.e.GetUserEndpoint, &management.UserRequest{User: management.User{ID: id}})
+	if err != nil {
+		return management.User{}, err
+	}
+
+	r := res.(*management.UserResponse)
+	return r.User, r.Error
+}
+
+// UpdateUser updates a user
+func (c *Client) UpdateUser(ctx context.Context, u management.User) (management.User, error) {
+	res, err := call(ctx, c.e.UpdateUserEndpoint, &management.UserRequest{User: u})
+	if err != nil {
+		return management.User{}, err
+	}
+
+	r := res.(*management.UserResponse)
+	return r.User, r.Error
+}
+
+// DeleteUser removes a user
+func (c *Client) DeleteUser(ctx context.Context, id uint) error {
+	_, err := call(ctx, c.e.DeleteUserEndpoint, &management.UserRequest{User: management.User{ID: id}})
+	return err
+}
+
+// CreateModule creates a module
+func (c *Client) CreateModule(ctx context.Context, m management.Module) (management.Module, error) {
+	res, err := call(ctx, c.e.CreateModuleEndpoint, &management.ModuleRequest{Module: m})
+	if err != nil {
+		return management.Module{}, err
+	}
+
+	r := res.(*management.ModuleResponse)
+	return r.Module, r.Error
+}
+
+// GetModule returns a module
+func (c *Client) GetModule(ctx context.Context, id uint) (management.Module, error) {
+	res, err := call(ctx, c.e.GetModuleEndpoint, &management.ModuleRequest{Module: management.Module{ID: id}})
+	if err != nil {
+		return management.Module{}, err
+	}
+
+	r := res.(*management.ModuleResponse)
+	return r.Module, r.Error
+}
+
+// DeleteModule removes a module
+func (c *Client) DeleteModule(ctx context.Context, id uint) error {
+	_, err := call(ctx, c.e.DeleteModuleEndpoint, &management.ModuleRequest{Module: management.Module{ID: id}})
+	return err
+}
+
+// CreateInstance creates a instance of a user
+func (c *Client) CreateInstance(ctx context.Context, refID uint, i management.Instance) (management.Instance, error) {
+	res, err := call(ctx, c.e.CreateInstanceEndpoint, &management.InstanceRequest{RefID: refID, Instance: i})
+	if err != nil {
+		return management.Instance{}, err
+	}
+
+	r := res.(*management.InstanceResponse)
+	return r.Instance, r.Error
+}
+
+// GetInstance returns a instance of a user
+func (c *Client) GetInstance(ctx context.Context, refID uint, name string) (management.Instance, error) {
+	res, err := call(ctx, c.e.GetInstanceEndpoint, &management.InstanceRequest{RefID: refID, Instance: management.Instance{Name: name}})
+	if err != nil {
+		return management.Instance{}, err
+	}
+
+	r := res.(*management.InstanceResponse)
+	return r.Instance, r.Error
+}
+
+// UpdateInstance updates a instance of a user
+func (c *Client) UpdateInstance(ctx context.Context, refID uint, i management.Instance) (management.Instance, error) {
+	res, err := call(ctx, c.e.UpdateInstanceEndpoint, &management.InstanceRequest{RefID: refID, Instance: i})
+	if err != nil {
+		return management.Instance{}, err
+	}
+
+	r := res.(*management.InstanceResponse)
+	return r.Instance, r.Error
+}
+
+// DeleteInstance removes a instance of a user
+func (c *Client) DeleteInstance(ctx context.Context, refID uint, name string) error {
+	_, err := call(ctx, c.e.DeleteInstanceEndpoint, &management.InstanceRequest{RefID: refID, Instance: management.Instance{Name: name}})
+	return err
+}
+
+// CreateDomain creates a domain of a user
+func (c *Client) CreateDomain(ctx context.Context, refID uint, d management.Domain) (management.Domain, error) {
+	res, err := call(ctx, c.e.CreateDomainEndpoint, &management.DomainRequest{RefID: refID, Domain: d})
+	if err != nil {
+		return management.Domain{}, err
+	}
+
+	r := res.(*management.DomainResponse)
+	return r.Domain, r.Error
+}
+
+// GetDomain returns a domain of a user
+func (c *Client) GetDomain(ctx context.Context, refID uint, name string) (management.Domain, error) {
+	res, err := call(ctx, c.e.GetDomainEndpoint, &management.DomainRequest{RefID: refID, Domain: management.Domain{Name: name}})
+	if err != nil {
+		return management.Domain{}, err
+	}
+
+	r := res.(*management.DomainResponse)
+	return r.Domain, r.Error
+}
+
+// DeleteDomain removes a domain of a user
+func (c *Client) DeleteDomain(ctx context.Context, refID uint, name string) error {
+	_, err := call(ctx, c.e.DeleteDomainEndpoint, &management.DomainRequest{RefID: refID, Domain: management.Domain{Name: name}})
+	return err
+}
+
+// CreatePolicy creates a policy of a user
+func (c *Client) CreatePolicy(ctx context.Context, refID uint, p management.Policy) (management.Policy, error) {
+	res, err := call(ctx, c.e.CreatePolicyEndpoint, &management.PolicyRequest{RefID: refID, Policy: p})
+	if err != nil {
+		return management.Policy{}, err
+	}
+
+	r := res.(*management.PolicyResponse)
+	return r.Policy, r.Error
+}
+
+// GetPolicy returns a policy of a user
+func (c *Client) GetPolicy(ctx context.Context, refID uint, id uint) (management.Policy, error) {
+	res, err := call(ctx, c.e.GetPolicyEndpoint, &management.PolicyRequest{RefID: refID, Policy: management.Policy{ID: id}})
+	if err != nil {
+		return management.Policy{}, err
+	}
+
+	r := res.(*management.PolicyResponse)
+	return r.Policy, r.Error
+}
+
+// DeletePolicy removes a policy of a user
+func (c *Client) DeletePolicy(ctx context.Context, refID uint, id uint) error {
+	_, err := call(ctx, c.e.DeletePolicyEndpoint, &management.PolicyRequest{RefID: refID, Policy: management.Policy{ID: id}})
+	return err
+}
@@ -0,0 +1,62 @@
+package management
+
+import (
+	"time"
+)
+
+// User is a user account. Password is only written, it is never returned.
+type User struct {
+	ID       uint
+	Username string `validate:"required,name"`
+	Email    string `validate:"email"`
+	Password string
+	Admin    bool
+	Phone    string
+	Image    string
+}
+
+// Module is a kontainer module. Path is the kmi file on the daemon's host the module is added
+// from, it is only written and never returned.
+type Module struct {
+	ID          uint
+	Path        string
+	Name        string
+	Version     string
+	Description string
+	Type        int
+}
+
+// Instance is a container instance of a user, it is identified by its name
+type Instance struct {
+	Name     string `validate:"required,name"`
+	KMIID    uint   `validate:"required"`
+	Replicas uint
+	// ContainerID and Node are set by the daemon
+	ContainerID string
+	Node        string
+}
+
+// Domain is a custom domain of a user, it is identified by its name. Token has to be published
+// in the TXT record VerificationRecord until the domain is verified.
+type Domain struct {
+	Name               string `validate:"required"`
+	Token              string
+	VerificationRecord string
+	Verified           bool
+}
+
+// Policy allows the instance Source of a user to connect to the instance Destination on a port
+type Policy struct {
+	ID          uint `gorm:"primary_key"`
+	RefID       uint
+	Source      string `validate:"required,name"`
+	Destination string `validate:"required,name"`
+	Port        uint16 `validate:"required,port"`
+	Protocol    string `validate:"required,oneof=tcp|udp"`
+	CreatedAt   time.Time
+}
+
+// TableName sets Policy's database table name
+func (Policy) TableName() string {
+	return "management_policies"
+}
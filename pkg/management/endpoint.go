@@ -0,0 +1,302 @@
+package management
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// DeleteResponse is the response struct for the endpoints removing a resource
+type DeleteResponse struct {
+	Error error
+}
+
+// UserRequest is the request struct for the endpoints of users, Get and Delete select the user by its id
+type UserRequest struct {
+	User User
+}
+
+// UserResponse is the response struct for the endpoints of users, it contains the user as it is stored
+type UserResponse struct {
+	User  User
+	Error error
+}
+
+// MakeCreateUserEndpoint creates a gokit endpoint which invokes CreateUser
+func MakeCreateUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UserRequest)
+		err := s.CreateUser(&req.User)
+		if err != nil {
+			return UserResponse{
+				Error: err,
+			}, nil
+		}
+		return UserResponse{
+			User: req.User,
+		}, nil
+	}
+}
+
+// MakeGetUserEndpoint creates a gokit endpoint which invokes GetUser
+func MakeGetUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UserRequest)
+		user := User{}
+		err := s.GetUser(req.User.ID, &user)
+		return UserResponse{
+			User:  user,
+			Error: err,
+		}, nil
+	}
+}
+
+// MakeUpdateUserEndpoint creates a gokit endpoint which invokes UpdateUser
+func MakeUpdateUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UserRequest)
+		err := s.UpdateUser(&req.User)
+		if err != nil {
+			return UserResponse{
+				Error: err,
+			}, nil
+		}
+		return UserResponse{
+			User: req.User,
+		}, nil
+	}
+}
+
+// MakeDeleteUserEndpoint creates a gokit endpoint which invokes DeleteUser
+func MakeDeleteUserEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UserRequest)
+		err := s.DeleteUser(req.User.ID)
+		return DeleteResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// ModuleRequest is the request struct for the endpoints of modules, Get and Delete select the module by its id
+type ModuleRequest struct {
+	Module Module
+}
+
+// ModuleResponse is the response struct for the endpoints of modules, it contains the module as it is stored
+type ModuleResponse struct {
+	Module Module
+	Error  error
+}
+
+// MakeCreateModuleEndpoint creates a gokit endpoint which invokes CreateModule
+func MakeCreateModuleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ModuleRequest)
+		err := s.CreateModule(&req.Module)
+		if err != nil {
+			return ModuleResponse{
+				Error: err,
+			}, nil
+		}
+		return ModuleResponse{
+			Module: req.Module,
+		}, nil
+	}
+}
+
+// MakeGetModuleEndpoint creates a gokit endpoint which invokes GetModule
+func MakeGetModuleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ModuleRequest)
+		module := Module{}
+		err := s.GetModule(req.Module.ID, &module)
+		return ModuleResponse{
+			Module: module,
+			Error:  err,
+		}, nil
+	}
+}
+
+// MakeDeleteModuleEndpoint creates a gokit endpoint which invokes DeleteModule
+func MakeDeleteModuleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ModuleRequest)
+		err := s.DeleteModule(req.Module.ID)
+		return DeleteResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// InstanceRequest is the request struct for the endpoints of instances, Get and Delete select the instance by its name
+type InstanceRequest struct {
+	RefID    uint `bart:"ref"`
+	Instance Instance
+}
+
+// InstanceResponse is the response struct for the endpoints of instances, it contains the instance as it is stored
+type InstanceResponse struct {
+	Instance Instance
+	Error    error
+}
+
+// MakeCreateInstanceEndpoint creates a gokit endpoint which invokes CreateInstance
+func MakeCreateInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(InstanceRequest)
+		err := s.CreateInstance(req.RefID, &req.Instance)
+		if err != nil {
+			return InstanceResponse{
+				Error: err,
+			}, nil
+		}
+		return InstanceResponse{
+			Instance: req.Instance,
+		}, nil
+	}
+}
+
+// MakeGetInstanceEndpoint creates a gokit endpoint which invokes GetInstance
+func MakeGetInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(InstanceRequest)
+		instance := Instance{}
+		err := s.GetInstance(req.RefID, req.Instance.Name, &instance)
+		return InstanceResponse{
+			Instance: instance,
+			Error:    err,
+		}, nil
+	}
+}
+
+// MakeUpdateInstanceEndpoint creates a gokit endpoint which invokes UpdateInstance
+func MakeUpdateInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(InstanceRequest)
+		err := s.UpdateInstance(req.RefID, &req.Instance)
+		if err != nil {
+			return InstanceResponse{
+				Error: err,
+			}, nil
+		}
+		return InstanceResponse{
+			Instance: req.Instance,
+		}, nil
+	}
+}
+
+// MakeDeleteInstanceEndpoint creates a gokit endpoint which invokes DeleteInstance
+func MakeDeleteInstanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(InstanceRequest)
+		err := s.DeleteInstance(req.RefID, req.Instance.Name)
+		return DeleteResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// DomainRequest is the request struct for the endpoints of domains, Get and Delete select the domain by its name
+type DomainRequest struct {
+	RefID  uint `bart:"ref"`
+	Domain Domain
+}
+
+// DomainResponse is the response struct for the endpoints of domains, it contains the domain as it is stored
+type DomainResponse struct {
+	Domain Domain
+	Error  error
+}
+
+// MakeCreateDomainEndpoint creates a gokit endpoint which invokes CreateDomain
+func MakeCreateDomainEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DomainRequest)
+		err := s.CreateDomain(req.RefID, &req.Domain)
+		if err != nil {
+			return DomainResponse{
+				Error: err,
+			}, nil
+		}
+		return DomainResponse{
+			Domain: req.Domain,
+		}, nil
+	}
+}
+
+// MakeGetDomainEndpoint creates a gokit endpoint which invokes GetDomain
+func MakeGetDomainEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DomainRequest)
+		domain := Domain{}
+		err := s.GetDomain(req.RefID, req.Domain.Name, &domain)
+		return DomainResponse{
+			Domain: domain,
+			Error:  err,
+		}, nil
+	}
+}
+
+// MakeDeleteDomainEndpoint creates a gokit endpoint which invokes DeleteDomain
+func MakeDeleteDomainEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DomainRequest)
+		err := s.DeleteDomain(req.RefID, req.Domain.Name)
+		return DeleteResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// PolicyRequest is the request struct for the endpoints of policys, Get and Delete select the policy by its id
+type PolicyRequest struct {
+	RefID  uint `bart:"ref"`
+	Policy Policy
+}
+
+// PolicyResponse is the response struct for the endpoints of policys, it contains the policy as it is stored
+type PolicyResponse struct {
+	Policy Policy
+	Error  error
+}
+
+// MakeCreatePolicyEndpoint creates a gokit endpoint which invokes CreatePolicy
+func MakeCreatePolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PolicyRequest)
+		err := s.CreatePolicy(req.RefID, &req.Policy)
+		if err != nil {
+			return PolicyResponse{
+				Error: err,
+			}, nil
+		}
+		return PolicyResponse{
+			Policy: req.Policy,
+		}, nil
+	}
+}
+
+// MakeGetPolicyEndpoint creates a gokit endpoint which invokes GetPolicy
+func MakeGetPolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PolicyRequest)
+		policy := Policy{}
+		err := s.GetPolicy(req.RefID, req.Policy.ID, &policy)
+		return PolicyResponse{
+			Policy: policy,
+			Error:  err,
+		}, nil
+	}
+}
+
+// MakeDeletePolicyEndpoint creates a gokit endpoint which invokes DeletePolicy
+func MakeDeletePolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PolicyRequest)
+		err := s.DeletePolicy(req.RefID, req.Policy.ID)
+		return DeleteResponse{
+			Error: err,
+		}, nil
+	}
+}
@@ -0,0 +1,374 @@
+package management_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/management"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeUsers struct {
+	users map[uint]user.User
+}
+
+func (f *fakeUsers) CreateUser(username string, cfg *user.Config, adr *user.Address) (uint, error) {
+	id := uint(len(f.users) + 1)
+	f.users[id] = user.User{ID: id, Username: username, Config: *cfg}
+	return id, nil
+}
+
+func (f *fakeUsers) EditUser(id uint, cfg *user.Config) error {
+	u := f.users[id]
+	u.Email, u.Phone, u.Image = cfg.Email, cfg.Phone, cfg.Image
+	f.users[id] = u
+	return nil
+}
+
+func (f *fakeUsers) ChangeUsername(id uint, username string) error {
+	u := f.users[id]
+	u.Username = username
+	f.users[id] = u
+	return nil
+}
+
+func (f *fakeUsers) DeleteUser(id uint) error {
+	delete(f.users, id)
+	return nil
+}
+
+func (f *fakeUsers) GetUser(id uint, u *user.User) error {
+	o, ok := f.users[id]
+	if !ok {
+		return testutils.ErrNotFound
+	}
+	*u = o
+	return nil
+}
+
+type fakeModules struct {
+	kmis map[uint]kmi.KMI
+}
+
+func (f *fakeModules) AddKMI(path string) (uint, error) {
+	id := uint(len(f.kmis) + 1)
+	f.kmis[id] = kmi.KMI{KMDI: kmi.KMDI{ID: id, Name: path, Version: "1.0"}}
+	return id, nil
+}
+
+func (f *fakeModules) RemoveKMI(id uint) error {
+	delete(f.kmis, id)
+	return nil
+}
+
+func (f *fakeModules) GetKMI(id uint, k *kmi.KMI) error {
+	o, ok := f.kmis[id]
+	if !ok {
+		return testutils.ErrNotFound
+	}
+	*k = o
+	return nil
+}
+
+type fakeInstances struct {
+	containers []container.Container
+}
+
+func (f *fakeInstances) CreateContainer(ctx context.Context, refID uint, kmiID uint, name string) (string, error) {
+	id := fmt.Sprintf("c%d", len(f.containers)+1)
+	f.containers = append(f.containers, container.Container{RefID: refID, ContainerID: id, ContainerName: name, KMIID: kmiID})
+	return id, nil
+}
+
+func (f *fakeInstances) RemoveContainer(ctx context.Context, refID uint, id string) error {
+	cs := []container.Container{}
+	for _, c := range f.containers {
+		if c.ContainerID != id {
+			cs = append(cs, c)
+		}
+	}
+	f.containers = cs
+	return nil
+}
+
+func (f *fakeInstances) Instances(ctx context.Context, refID uint) []container.Container {
+	cs := []container.Container{}
+	for _, c := range f.containers {
+		if c.RefID == refID {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+func (f *fakeInstances) ScaleInstance(ctx context.Context, refID uint, id string, replicas uint) error {
+	for i, c := range f.containers {
+		if c.ContainerID == id {
+			f.containers[i].Replicas = replicas
+		}
+	}
+	return nil
+}
+
+type fakeDomains struct {
+	verifications []dns.Verification
+}
+
+func (f *fakeDomains) AddCustomDomain(refID uint, domain string, v *dns.Verification) error {
+	*v = dns.Verification{Domain: domain, RefID: refID, Token: "token"}
+	f.verifications = append(f.verifications, *v)
+	return nil
+}
+
+func (f *fakeDomains) RemoveCustomDomain(refID uint, domain string) error {
+	vs := []dns.Verification{}
+	for _, v := range f.verifications {
+		if v.Domain != domain {
+			vs = append(vs, v)
+		}
+	}
+	f.verifications = vs
+	return nil
+}
+
+func (f *fakeDomains) CustomDomains(refID uint, v *[]dns.Verification) error {
+	for _, o := range f.verifications {
+		if o.RefID == refID {
+			*v = append(*v, o)
+		}
+	}
+	return nil
+}
+
+type fakePorts struct {
+	open map[string]bool
+}
+
+func (f *fakePorts) ExposePortToContainer(refid uint, src string, port uint16, protocol string, dst string) error {
+	f.open[fmt.Sprintf("%s:%d/%s->%s", src, port, protocol, dst)] = true
+	return nil
+}
+
+func (f *fakePorts) RemovePortFromContainer(refid uint, src string, port uint16, protocol string, dst string) error {
+	delete(f.open, fmt.Sprintf("%s:%d/%s->%s", src, port, protocol, dst))
+	return nil
+}
+
+var _ = Describe("Management", func() {
+	var (
+		refID     = uint(1)
+		s         management.Service
+		instances *fakeInstances
+		ports     *fakePorts
+	)
+
+	BeforeEach(func() {
+		instances = &fakeInstances{}
+		ports = &fakePorts{open: make(map[string]bool)}
+
+		var err error
+		s, err = management.NewService(testutils.NewMockDB(), management.Backends{
+			Users:     &fakeUsers{users: make(map[uint]user.User)},
+			Modules:   &fakeModules{kmis: make(map[uint]kmi.KMI)},
+			Instances: instances,
+			Domains:   &fakeDomains{},
+			Ports:     ports,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("Create Service", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := management.NewService(db, management.Backends{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Users", func() {
+		It("Should create a user without returning its password", func() {
+			u := &management.User{Username: "alice", Email: "alice@example.com", Password: "secret"}
+			err := s.CreateUser(u)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(u.ID).ToNot(BeZero())
+			Expect(u.Password).To(BeEmpty())
+
+			o := &management.User{}
+			err = s.GetUser(u.ID, o)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(o).To(Equal(u))
+		})
+
+		It("Should update a user", func() {
+			u := &management.User{Username: "alice"}
+			s.CreateUser(u)
+
+			u.Username, u.Phone = "bob", "123"
+			err := s.UpdateUser(u)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(u.Username).To(Equal("bob"))
+			Expect(u.Phone).To(Equal("123"))
+		})
+
+		It("Should not change the fields which can only be set on creation", func() {
+			u := &management.User{Username: "alice"}
+			s.CreateUser(u)
+
+			err := s.UpdateUser(&management.User{ID: u.ID, Username: "alice", Admin: true})
+			Expect(err).To(Equal(management.ErrImmutable))
+
+			err = s.UpdateUser(&management.User{ID: u.ID, Username: "alice", Password: "secret"})
+			Expect(err).To(Equal(management.ErrImmutable))
+		})
+
+		It("Should return ErrNotFound for removed users", func() {
+			u := &management.User{Username: "alice"}
+			s.CreateUser(u)
+
+			err := s.DeleteUser(u.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = s.GetUser(u.ID, &management.User{})
+			Expect(err).To(Equal(management.ErrNotFound))
+
+			err = s.DeleteUser(u.ID)
+			Expect(err).To(Equal(management.ErrNotFound))
+		})
+	})
+
+	Describe("Modules", func() {
+		It("Should add and remove a module", func() {
+			m := &management.Module{Path: "/kmi/web.kmi"}
+			err := s.CreateModule(m)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(m.ID).ToNot(BeZero())
+			Expect(m.Path).To(BeEmpty())
+			Expect(m.Version).To(Equal("1.0"))
+
+			err = s.DeleteModule(m.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = s.GetModule(m.ID, &management.Module{})
+			Expect(err).To(Equal(management.ErrNotFound))
+		})
+	})
+
+	Describe("Instances", func() {
+		It("Should create an instance with its replicas", func() {
+			i := &management.Instance{Name: "web", KMIID: 1, Replicas: 2}
+			err := s.CreateInstance(refID, i)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(i.ContainerID).ToNot(BeEmpty())
+			Expect(i.Replicas).To(Equal(uint(2)))
+
+			err = s.GetInstance(2, "web", &management.Instance{})
+			Expect(err).To(Equal(management.ErrNotFound))
+		})
+
+		It("Should scale an instance", func() {
+			s.CreateInstance(refID, &management.Instance{Name: "web", KMIID: 1})
+
+			i := &management.Instance{Name: "web", KMIID: 1, Replicas: 3}
+			err := s.UpdateInstance(refID, i)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(i.Replicas).To(Equal(uint(3)))
+		})
+
+		It("Should not change the module of an instance", func() {
+			s.CreateInstance(refID, &management.Instance{Name: "web", KMIID: 1})
+
+			err := s.UpdateInstance(refID, &management.Instance{Name: "web", KMIID: 2})
+			Expect(err).To(Equal(management.ErrImmutable))
+		})
+
+		It("Should remove an instance", func() {
+			s.CreateInstance(refID, &management.Instance{Name: "web", KMIID: 1})
+
+			err := s.DeleteInstance(refID, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(instances.containers).To(BeEmpty())
+		})
+	})
+
+	Describe("Domains", func() {
+		It("Should add a domain with its verification", func() {
+			d := &management.Domain{Name: "example.com"}
+			err := s.CreateDomain(refID, d)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(d.Token).To(Equal("token"))
+			Expect(d.VerificationRecord).To(Equal(dns.VerificationName("example.com")))
+			Expect(d.Verified).To(BeFalse())
+
+			err = s.DeleteDomain(refID, "example.com")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = s.GetDomain(refID, "example.com", d)
+			Expect(err).To(Equal(management.ErrNotFound))
+		})
+	})
+
+	Describe("Policies", func() {
+		BeforeEach(func() {
+			s.CreateInstance(refID, &management.Instance{Name: "web", KMIID: 1})
+			s.CreateInstance(refID, &management.Instance{Name: "db", KMIID: 2})
+		})
+
+		It("Should open the port of a policy until it is removed", func() {
+			p := &management.Policy{Source: "web", Destination: "db", Port: 5432, Protocol: "tcp"}
+			err := s.CreatePolicy(refID, p)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(p.ID).ToNot(BeZero())
+			Expect(p.RefID).To(Equal(refID))
+			Expect(ports.open).To(HaveKey("c1:5432/tcp->c2"))
+
+			o := &management.Policy{}
+			err = s.GetPolicy(refID, p.ID, o)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(o).To(Equal(p))
+
+			err = s.GetPolicy(2, p.ID, o)
+			Expect(err).To(Equal(management.ErrNotFound))
+
+			err = s.DeletePolicy(refID, p.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ports.open).To(BeEmpty())
+		})
+
+		It("Should remove the policies of removed instances", func() {
+			p := &management.Policy{Source: "web", Destination: "db", Port: 5432, Protocol: "tcp"}
+			s.CreatePolicy(refID, p)
+			s.DeleteInstance(refID, "db")
+
+			err := s.DeletePolicy(refID, p.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should return ErrNotFound for unknown instances", func() {
+			err := s.CreatePolicy(refID, &management.Policy{Source: "web", Destination: "cache", Port: 6379, Protocol: "tcp"})
+			Expect(err).To(Equal(management.ErrNotFound))
+		})
+	})
+
+	Describe("Unavailable Backends", func() {
+		It("Should return ErrUnavailable", func() {
+			s, _ := management.NewService(testutils.NewMockDB(), management.Backends{})
+
+			err := s.GetUser(1, &management.User{})
+			Expect(err).To(Equal(management.ErrUnavailable))
+
+			err = s.CreateDomain(refID, &management.Domain{Name: "example.com"})
+			Expect(err).To(Equal(management.ErrUnavailable))
+
+			err = s.DeletePolicy(refID, 1)
+			Expect(err).To(Equal(management.ErrUnavailable))
+		})
+	})
+})
@@ -0,0 +1,541 @@
+// Package management provides the stable management API for infrastructure as code tools like a
+// Terraform provider. Every resource can be created, read by its id and removed, and every call
+// returns the resource as it is stored afterwards, so a plan can compare it with the configuration.
+package management
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+)
+
+// Version is the version of the management API, fields are only added within a version
+const Version = "v1"
+
+var (
+	// ErrNotFound occurs if a resource does not exist, clients remove it from their state
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrImmutable occurs if an update changes a field which can only be set on creation
+	ErrImmutable = errors.New("field can only be set on creation")
+
+	// ErrUnavailable occurs if the service a resource is managed by is not enabled
+	ErrUnavailable = errors.New("resource is not available on this daemon")
+)
+
+// Service ManagementService
+type Service interface {
+	// CreateUser creates a user and sets its id
+	CreateUser(u *User) error
+
+	// GetUser returns a user by id
+	GetUser(id uint, u *User) error
+
+	// UpdateUser changes the username, email, phone number and image of a user, empty values are kept
+	UpdateUser(u *User) error
+
+	// DeleteUser removes a user
+	DeleteUser(id uint) error
+
+	// CreateModule adds the kontainer module of the kmi file at m.Path and sets its fields
+	CreateModule(m *Module) error
+
+	// GetModule returns a kontainer module by id
+	GetModule(id uint, m *Module) error
+
+	// DeleteModule removes a kontainer module
+	DeleteModule(id uint) error
+
+	// CreateInstance creates an instance of a user with its replicas
+	CreateInstance(refID uint, i *Instance) error
+
+	// GetInstance returns an instance of a user by name
+	GetInstance(refID uint, name string, i *Instance) error
+
+	// UpdateInstance scales an instance of a user to its replicas
+	UpdateInstance(refID uint, i *Instance) error
+
+	// DeleteInstance removes an instance of a user with its replicas
+	DeleteInstance(refID uint, name string) error
+
+	// CreateDomain adds a custom domain of a user and sets its verification token
+	CreateDomain(refID uint, d *Domain) error
+
+	// GetDomain returns a custom domain of a user by name
+	GetDomain(refID uint, name string, d *Domain) error
+
+	// DeleteDomain removes a custom domain of a user
+	DeleteDomain(refID uint, name string) error
+
+	// CreatePolicy allows an instance of a user to connect to another one on a port and sets its id
+	CreatePolicy(refID uint, p *Policy) error
+
+	// GetPolicy returns a policy of a user by id
+	GetPolicy(refID uint, id uint, p *Policy) error
+
+	// DeletePolicy removes a policy of a user and blocks the port again
+	DeletePolicy(refID uint, id uint) error
+}
+
+// Users manages the users, it is satisfied by the user service
+type Users interface {
+	CreateUser(username string, cfg *user.Config, adr *user.Address) (uint, error)
+	EditUser(id uint, cfg *user.Config) error
+	ChangeUsername(id uint, username string) error
+	DeleteUser(id uint) error
+	GetUser(id uint, u *user.User) error
+}
+
+// Modules manages the kontainer modules, it is satisfied by the kmi service
+type Modules interface {
+	AddKMI(path string) (uint, error)
+	RemoveKMI(id uint) error
+	GetKMI(id uint, k *kmi.KMI) error
+}
+
+// Instances manages the instances, it is satisfied by the container service
+type Instances interface {
+	CreateContainer(ctx context.Context, refID uint, kmiID uint, name string) (string, error)
+	RemoveContainer(ctx context.Context, refID uint, id string) error
+	Instances(ctx context.Context, refID uint) []container.Container
+	ScaleInstance(ctx context.Context, refID uint, id string, replicas uint) error
+}
+
+// Domains manages the custom domains, it is satisfied by the dns service
+type Domains interface {
+	AddCustomDomain(refID uint, domain string, v *dns.Verification) error
+	RemoveCustomDomain(refID uint, domain string) error
+	CustomDomains(refID uint, v *[]dns.Verification) error
+}
+
+// Ports opens the ports between instances, it is satisfied by the network service
+type Ports interface {
+	ExposePortToContainer(refid uint, srcContainerID string, port uint16, protocol string, destContainerID string) error
+	RemovePortFromContainer(refid uint, srcContainerID string, port uint16, protocol string, destContainerID string) error
+}
+
+// Backends are the services the resources are managed by, the resources of a nil backend are not available
+type Backends struct {
+	Users     Users
+	Modules   Modules
+	Instances Instances
+	Domains   Domains
+	Ports     Ports
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db       dbAdapter
+	backends Backends
+	mtx      *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Policy{})
+}
+
+// notFound returns ErrNotFound for the not found errors of the database and err otherwise
+func (s *service) notFound(err error) error {
+	if err != nil && s.db.IsNotFound(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *service) CreateUser(u *User) error {
+	if s.backends.Users == nil {
+		return ErrUnavailable
+	}
+
+	id, err := s.backends.Users.CreateUser(u.Username, &user.Config{
+		Admin:    u.Admin,
+		Email:    u.Email,
+		Password: u.Password,
+		Phone:    u.Phone,
+		Image:    u.Image,
+	}, &user.Address{})
+	if err != nil {
+		return err
+	}
+
+	return s.GetUser(id, u)
+}
+
+func (s *service) GetUser(id uint, u *User) error {
+	if s.backends.Users == nil {
+		return ErrUnavailable
+	}
+
+	o := user.User{}
+	err := s.backends.Users.GetUser(id, &o)
+	if err != nil {
+		return s.notFound(err)
+	}
+
+	*u = User{
+		ID:       o.ID,
+		Username: o.Username,
+		Email:    o.Email,
+		Admin:    o.Admin,
+		Phone:    o.Phone,
+		Image:    o.Image,
+	}
+	return nil
+}
+
+func (s *service) UpdateUser(u *User) error {
+	current := &User{}
+	err := s.GetUser(u.ID, current)
+	if err != nil {
+		return err
+	}
+	// the user service keeps the admin flag if it is unset and would store a new password unhashed
+	if u.Admin != current.Admin || u.Password != "" {
+		return ErrImmutable
+	}
+
+	if u.Username != "" && u.Username != current.Username {
+		err = s.backends.Users.ChangeUsername(u.ID, u.Username)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.backends.Users.EditUser(u.ID, &user.Config{
+		Email: u.Email,
+		Phone: u.Phone,
+		Image: u.Image,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.GetUser(u.ID, u)
+}
+
+func (s *service) DeleteUser(id uint) error {
+	err := s.GetUser(id, &User{})
+	if err != nil {
+		return err
+	}
+
+	return s.backends.Users.DeleteUser(id)
+}
+
+func (s *service) CreateModule(m *Module) error {
+	if s.backends.Modules == nil {
+		return ErrUnavailable
+	}
+
+	id, err := s.backends.Modules.AddKMI(m.Path)
+	if err != nil {
+		return err
+	}
+
+	return s.GetModule(id, m)
+}
+
+func (s *service) GetModule(id uint, m *Module) error {
+	if s.backends.Modules == nil {
+		return ErrUnavailable
+	}
+
+	k := kmi.KMI{}
+	err := s.backends.Modules.GetKMI(id, &k)
+	if err != nil {
+		return s.notFound(err)
+	}
+
+	*m = Module{
+		ID:          k.ID,
+		Name:        k.Name,
+		Version:     k.Version,
+		Description: k.Description,
+		Type:        k.Type,
+	}
+	return nil
+}
+
+func (s *service) DeleteModule(id uint) error {
+	err := s.GetModule(id, &Module{})
+	if err != nil {
+		return err
+	}
+
+	return s.backends.Modules.RemoveKMI(id)
+}
+
+// instance returns the container of an instance of a user, replicas are no instances
+func (s *service) instance(refID uint, name string) (container.Container, error) {
+	if s.backends.Instances == nil {
+		return container.Container{}, ErrUnavailable
+	}
+
+	for _, c := range s.backends.Instances.Instances(context.Background(), refID) {
+		if c.ContainerName == name && c.ReplicaOf == "" {
+			return c, nil
+		}
+	}
+	return container.Container{}, ErrNotFound
+}
+
+func (s *service) CreateInstance(refID uint, i *Instance) error {
+	if s.backends.Instances == nil {
+		return ErrUnavailable
+	}
+
+	id, err := s.backends.Instances.CreateContainer(context.Background(), refID, i.KMIID, i.Name)
+	if err != nil {
+		return err
+	}
+
+	if i.Replicas > 0 {
+		err = s.backends.Instances.ScaleInstance(context.Background(), refID, id, i.Replicas)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.GetInstance(refID, i.Name, i)
+}
+
+func (s *service) GetInstance(refID uint, name string, i *Instance) error {
+	c, err := s.instance(refID, name)
+	if err != nil {
+		return err
+	}
+
+	*i = Instance{
+		Name:        c.ContainerName,
+		KMIID:       c.KMIID,
+		Replicas:    c.Replicas,
+		ContainerID: c.ContainerID,
+		Node:        c.Node,
+	}
+	return nil
+}
+
+func (s *service) UpdateInstance(refID uint, i *Instance) error {
+	c, err := s.instance(refID, i.Name)
+	if err != nil {
+		return err
+	}
+	if i.KMIID != c.KMIID {
+		return ErrImmutable
+	}
+
+	if i.Replicas != c.Replicas {
+		err = s.backends.Instances.ScaleInstance(context.Background(), refID, c.ContainerID, i.Replicas)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.GetInstance(refID, i.Name, i)
+}
+
+func (s *service) DeleteInstance(refID uint, name string) error {
+	c, err := s.instance(refID, name)
+	if err != nil {
+		return err
+	}
+
+	if c.Replicas > 0 {
+		err = s.backends.Instances.ScaleInstance(context.Background(), refID, c.ContainerID, 0)
+		if err != nil {
+			return err
+		}
+	}
+	return s.backends.Instances.RemoveContainer(context.Background(), refID, c.ContainerID)
+}
+
+func (s *service) CreateDomain(refID uint, d *Domain) error {
+	if s.backends.Domains == nil {
+		return ErrUnavailable
+	}
+
+	err := s.backends.Domains.AddCustomDomain(refID, d.Name, &dns.Verification{})
+	if err != nil {
+		return err
+	}
+
+	return s.GetDomain(refID, d.Name, d)
+}
+
+func (s *service) GetDomain(refID uint, name string, d *Domain) error {
+	if s.backends.Domains == nil {
+		return ErrUnavailable
+	}
+
+	vs := []dns.Verification{}
+	err := s.backends.Domains.CustomDomains(refID, &vs)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range vs {
+		if v.Domain == name {
+			*d = Domain{
+				Name:               v.Domain,
+				Token:              v.Token,
+				VerificationRecord: dns.VerificationName(v.Domain),
+				Verified:           v.Verified,
+			}
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (s *service) DeleteDomain(refID uint, name string) error {
+	err := s.GetDomain(refID, name, &Domain{})
+	if err != nil {
+		return err
+	}
+
+	return s.backends.Domains.RemoveCustomDomain(refID, name)
+}
+
+// containerIDs returns the ids of the containers of the source and the destination of p
+func (s *service) containerIDs(refID uint, p Policy) (string, string, error) {
+	src, err := s.instance(refID, p.Source)
+	if err != nil {
+		return "", "", err
+	}
+
+	dst, err := s.instance(refID, p.Destination)
+	if err != nil {
+		return "", "", err
+	}
+	return src.ContainerID, dst.ContainerID, nil
+}
+
+// policy returns a policy of a user
+func (s *service) policy(refID uint, id uint) (Policy, error) {
+	ps := []Policy{}
+	err := s.db.Find(&ps)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	for _, p := range ps {
+		if p.ID == id && p.RefID == refID {
+			return p, nil
+		}
+	}
+	return Policy{}, ErrNotFound
+}
+
+func (s *service) CreatePolicy(refID uint, p *Policy) error {
+	if s.backends.Ports == nil {
+		return ErrUnavailable
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	src, dst, err := s.containerIDs(refID, *p)
+	if err != nil {
+		return err
+	}
+
+	err = s.backends.Ports.ExposePortToContainer(refID, src, p.Port, p.Protocol, dst)
+	if err != nil {
+		return err
+	}
+
+	o := *p
+	o.ID = 0
+	o.RefID = refID
+	o.CreatedAt = time.Now().UTC()
+	err = s.db.Create(&o)
+	if err != nil {
+		return err
+	}
+
+	*p, err = s.policy(refID, o.ID)
+	return err
+}
+
+func (s *service) GetPolicy(refID uint, id uint, p *Policy) error {
+	if s.backends.Ports == nil {
+		return ErrUnavailable
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	o, err := s.policy(refID, id)
+	if err != nil {
+		return err
+	}
+
+	*p = o
+	return nil
+}
+
+func (s *service) DeletePolicy(refID uint, id uint) error {
+	if s.backends.Ports == nil {
+		return ErrUnavailable
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, err := s.policy(refID, id)
+	if err != nil {
+		return err
+	}
+
+	src, dst, err := s.containerIDs(refID, p)
+	// the rules of removed instances were removed with them
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+	if err == nil {
+		err = s.backends.Ports.RemovePortFromContainer(refID, src, p.Port, p.Protocol, dst)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.Delete(&Policy{ID: id})
+}
+
+// NewService creates a ManagementService managing the resources with backends
+func NewService(db dbAdapter, backends Backends) (Service, error) {
+	s := &service{
+		db:       db,
+		backends: backends,
+		mtx:      &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
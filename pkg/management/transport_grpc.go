@@ -0,0 +1,266 @@
+package management
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/management/pb"
+)
+
+// ConvertUser converts a User to its protobuf representation, the password is never returned
+func ConvertUser(u User) *pb.User {
+	return &pb.User{
+		ID:       uint32(u.ID),
+		Username: u.Username,
+		Email:    u.Email,
+		Admin:    u.Admin,
+		Phone:    u.Phone,
+		Image:    u.Image,
+	}
+}
+
+// ConvertPBUser converts a protobuf User to a User
+func ConvertPBUser(u *pb.User) User {
+	if u == nil {
+		return User{}
+	}
+
+	return User{
+		ID:       uint(u.ID),
+		Username: u.Username,
+		Email:    u.Email,
+		Password: u.Password,
+		Admin:    u.Admin,
+		Phone:    u.Phone,
+		Image:    u.Image,
+	}
+}
+
+// ConvertModule converts a Module to its protobuf representation, the path is never returned
+func ConvertModule(m Module) *pb.Module {
+	return &pb.Module{
+		ID:          uint32(m.ID),
+		Name:        m.Name,
+		Version:     m.Version,
+		Description: m.Description,
+		Type:        int32(m.Type),
+	}
+}
+
+// ConvertPBModule converts a protobuf Module to a Module
+func ConvertPBModule(m *pb.Module) Module {
+	if m == nil {
+		return Module{}
+	}
+
+	return Module{
+		ID:          uint(m.ID),
+		Path:        m.Path,
+		Name:        m.Name,
+		Version:     m.Version,
+		Description: m.Description,
+		Type:        int(m.Type),
+	}
+}
+
+// ConvertInstance converts an Instance to its protobuf representation
+func ConvertInstance(i Instance) *pb.Instance {
+	return &pb.Instance{
+		Name:        i.Name,
+		KmiID:       uint32(i.KMIID),
+		Replicas:    uint32(i.Replicas),
+		ContainerID: i.ContainerID,
+		Node:        i.Node,
+	}
+}
+
+// ConvertPBInstance converts a protobuf Instance to an Instance
+func ConvertPBInstance(i *pb.Instance) Instance {
+	if i == nil {
+		return Instance{}
+	}
+
+	return Instance{
+		Name:        i.Name,
+		KMIID:       uint(i.KmiID),
+		Replicas:    uint(i.Replicas),
+		ContainerID: i.ContainerID,
+		Node:        i.Node,
+	}
+}
+
+// ConvertDomain converts a Domain to its protobuf representation
+func ConvertDomain(d Domain) *pb.Domain {
+	return &pb.Domain{
+		Name:               d.Name,
+		Token:              d.Token,
+		VerificationRecord: d.VerificationRecord,
+		Verified:           d.Verified,
+	}
+}
+
+// ConvertPBDomain converts a protobuf Domain to a Domain
+func ConvertPBDomain(d *pb.Domain) Domain {
+	if d == nil {
+		return Domain{}
+	}
+
+	return Domain{
+		Name:               d.Name,
+		Token:              d.Token,
+		VerificationRecord: d.VerificationRecord,
+		Verified:           d.Verified,
+	}
+}
+
+// ConvertPolicy converts a Policy to its protobuf representation
+func ConvertPolicy(p Policy) *pb.Policy {
+	return &pb.Policy{
+		ID:          uint32(p.ID),
+		Source:      p.Source,
+		Destination: p.Destination,
+		Port:        uint32(p.Port),
+		Protocol:    p.Protocol,
+		CreatedAt:   p.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBPolicy converts a protobuf Policy to a Policy
+func ConvertPBPolicy(p *pb.Policy) Policy {
+	if p == nil {
+		return Policy{}
+	}
+
+	return Policy{
+		ID:          uint(p.ID),
+		Source:      p.Source,
+		Destination: p.Destination,
+		Port:        uint16(p.Port),
+		Protocol:    p.Protocol,
+		CreatedAt:   time.Unix(p.CreatedAt, 0).UTC(),
+	}
+}
+
+// EncodeGRPCDeleteResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain delete response to a gRPC Delete response.
+func EncodeGRPCDeleteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DeleteResponse)
+	gRPCRes := &pb.DeleteResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCUserRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC User request to a messages/management.proto-domain user request.
+func DecodeGRPCUserRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UserRequest)
+	return UserRequest{
+		User: ConvertPBUser(req.User),
+	}, nil
+}
+
+// EncodeGRPCUserResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain user response to a gRPC User response.
+func EncodeGRPCUserResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(UserResponse)
+	gRPCRes := &pb.UserResponse{
+		User: ConvertUser(res.User),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCModuleRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Module request to a messages/management.proto-domain module request.
+func DecodeGRPCModuleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ModuleRequest)
+	return ModuleRequest{
+		Module: ConvertPBModule(req.Module),
+	}, nil
+}
+
+// EncodeGRPCModuleResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain module response to a gRPC Module response.
+func EncodeGRPCModuleResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ModuleResponse)
+	gRPCRes := &pb.ModuleResponse{
+		Module: ConvertModule(res.Module),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCInstanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Instance request to a messages/management.proto-domain instance request.
+func DecodeGRPCInstanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.InstanceRequest)
+	return InstanceRequest{
+		RefID:    uint(req.RefID),
+		Instance: ConvertPBInstance(req.Instance),
+	}, nil
+}
+
+// EncodeGRPCInstanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain instance response to a gRPC Instance response.
+func EncodeGRPCInstanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(InstanceResponse)
+	gRPCRes := &pb.InstanceResponse{
+		Instance: ConvertInstance(res.Instance),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDomainRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Domain request to a messages/management.proto-domain domain request.
+func DecodeGRPCDomainRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DomainRequest)
+	return DomainRequest{
+		RefID:  uint(req.RefID),
+		Domain: ConvertPBDomain(req.Domain),
+	}, nil
+}
+
+// EncodeGRPCDomainResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain domain response to a gRPC Domain response.
+func EncodeGRPCDomainResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DomainResponse)
+	gRPCRes := &pb.DomainResponse{
+		Domain: ConvertDomain(res.Domain),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCPolicyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Policy request to a messages/management.proto-domain policy request.
+func DecodeGRPCPolicyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PolicyRequest)
+	return PolicyRequest{
+		RefID:  uint(req.RefID),
+		Policy: ConvertPBPolicy(req.Policy),
+	}, nil
+}
+
+// EncodeGRPCPolicyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/management.proto-domain policy response to a gRPC Policy response.
+func EncodeGRPCPolicyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(PolicyResponse)
+	gRPCRes := &pb.PolicyResponse{
+		Policy: ConvertPolicy(res.Policy),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/metrics"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Instrumenting records the requests, errors and latencies of the endpoints of services
+type Instrumenting struct {
+	requests metrics.Counter
+	errors   metrics.Counter
+	duration metrics.Histogram
+}
+
+// failed reports whether an endpoint call failed, the services return most errors in the Error field of their responses
+func failed(response interface{}, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(response))
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+
+	f := v.FieldByName("Error")
+	return f.IsValid() && f.Type() == errorType && !f.IsNil()
+}
+
+// Middleware returns a middleware recording the calls of the endpoint method of service
+func (i *Instrumenting) Middleware(service, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			defer func(begin time.Time) {
+				labels := []string{"service", service, "method", method}
+				i.requests.With(labels...).Add(1)
+				if failed(response, err) {
+					i.errors.With(labels...).Add(1)
+				}
+				i.duration.With(labels...).Observe(time.Since(begin).Seconds())
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// NewInstrumenting returns an Instrumenting whose metrics are created by p
+func NewInstrumenting(p Provider) *Instrumenting {
+	return &Instrumenting{
+		requests: p.NewCounter("requests_total", "Number of requests handled by the services.", "service", "method"),
+		errors:   p.NewCounter("request_errors_total", "Number of requests which failed.", "service", "method"),
+		duration: p.NewHistogram("request_duration_seconds", "Time spent handling requests.", "service", "method"),
+	}
+}
@@ -0,0 +1,99 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type response struct {
+	Error error
+}
+
+var _ = Describe("Metrics", func() {
+	var p metrics.Provider
+
+	BeforeEach(func() {
+		p = metrics.NewPrometheusProvider(metrics.Namespace)
+	})
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		p.Handler().ServeHTTP(rec, httptest.NewRequest("GET", metrics.Path, nil))
+		body, err := ioutil.ReadAll(rec.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return string(body)
+	}
+
+	Describe("Instrumenting", func() {
+		It("Should count requests and errors", func() {
+			i := metrics.NewInstrumenting(p)
+
+			e := i.Middleware("user", "GetUser")(func(ctx context.Context, request interface{}) (interface{}, error) {
+				if request == nil {
+					return response{Error: errors.New("not found")}, nil
+				}
+				return &response{}, nil
+			})
+
+			_, err := e(context.Background(), 1)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = e(context.Background(), nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			body := scrape()
+			Expect(body).To(ContainSubstring(`kontainerooo_requests_total{method="GetUser",service="user"} 2`))
+			Expect(body).To(ContainSubstring(`kontainerooo_request_errors_total{method="GetUser",service="user"} 1`))
+			Expect(body).To(ContainSubstring(`kontainerooo_request_duration_seconds_count{method="GetUser",service="user"} 2`))
+		})
+
+		It("Should count errors returned by endpoints", func() {
+			i := metrics.NewInstrumenting(p)
+
+			e := i.Middleware("kmi", "AddKMI")(func(ctx context.Context, request interface{}) (interface{}, error) {
+				return nil, errors.New("fail")
+			})
+
+			_, err := e(context.Background(), nil)
+			Expect(err).To(HaveOccurred())
+			Expect(scrape()).To(ContainSubstring(`kontainerooo_request_errors_total{method="AddKMI",service="kmi"} 1`))
+		})
+	})
+
+	Describe("Sampler", func() {
+		It("Should set gauges", func() {
+			s := metrics.NewSampler(p, log.NewNopLogger())
+
+			value := 3.0
+			s.Gauge("running_containers", "Number of running containers.", func() (float64, error) {
+				return value, nil
+			})
+			s.Gauge("users", "Number of users.", func() (float64, error) {
+				return 0, errors.New("fail")
+			})
+
+			s.Sample()
+			Expect(scrape()).To(ContainSubstring("kontainerooo_running_containers 3"))
+
+			value = 5
+			s.Sample()
+			Expect(scrape()).To(ContainSubstring("kontainerooo_running_containers 5"))
+			Expect(scrape()).NotTo(ContainSubstring("kontainerooo_users "))
+		})
+	})
+
+	Describe("Discard", func() {
+		It("Should not serve metrics", func() {
+			rec := httptest.NewRecorder()
+			metrics.NewDiscardProvider().Handler().ServeHTTP(rec, httptest.NewRequest("GET", metrics.Path, nil))
+			Expect(rec.Code).To(Equal(404))
+		})
+	})
+})
@@ -0,0 +1,105 @@
+// Package metrics provides the metrics of the daemon, services record them through a shared Provider
+// and they are exposed in the Prometheus format
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/go-kit/kit/metrics"
+	"github.com/go-kit/kit/metrics/discard"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace is the prefix of the names of all metrics
+const Namespace = "kontainerooo"
+
+// Path is the path metrics are served at
+const Path = "/metrics"
+
+// Provider creates metrics, every metric name may only be used once per Provider
+type Provider interface {
+	NewCounter(name, help string, labels ...string) metrics.Counter
+	NewGauge(name, help string, labels ...string) metrics.Gauge
+	NewHistogram(name, help string, labels ...string) metrics.Histogram
+
+	// Handler serves the metrics created by the Provider
+	Handler() http.Handler
+}
+
+type prometheusProvider struct {
+	namespace string
+	registry  *stdprometheus.Registry
+}
+
+func (p *prometheusProvider) NewCounter(name, help string, labels ...string) metrics.Counter {
+	c := stdprometheus.NewCounterVec(stdprometheus.CounterOpts{
+		Namespace: p.namespace,
+		Name:      name,
+		Help:      help,
+	}, labels)
+	p.registry.MustRegister(c)
+	return kitprometheus.NewCounter(c)
+}
+
+func (p *prometheusProvider) NewGauge(name, help string, labels ...string) metrics.Gauge {
+	g := stdprometheus.NewGaugeVec(stdprometheus.GaugeOpts{
+		Namespace: p.namespace,
+		Name:      name,
+		Help:      help,
+	}, labels)
+	p.registry.MustRegister(g)
+	return kitprometheus.NewGauge(g)
+}
+
+func (p *prometheusProvider) NewHistogram(name, help string, labels ...string) metrics.Histogram {
+	h := stdprometheus.NewHistogramVec(stdprometheus.HistogramOpts{
+		Namespace: p.namespace,
+		Name:      name,
+		Help:      help,
+		Buckets:   stdprometheus.DefBuckets,
+	}, labels)
+	p.registry.MustRegister(h)
+	return kitprometheus.NewHistogram(h)
+}
+
+func (p *prometheusProvider) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// NewPrometheusProvider returns a Provider whose metrics are served in the Prometheus format, the metrics
+// of the go runtime and the process are served as well
+func NewPrometheusProvider(namespace string) Provider {
+	r := stdprometheus.NewRegistry()
+	r.MustRegister(stdprometheus.NewGoCollector())
+	r.MustRegister(stdprometheus.NewProcessCollector(stdprometheus.ProcessCollectorOpts{}))
+
+	return &prometheusProvider{
+		namespace: namespace,
+		registry:  r,
+	}
+}
+
+type discardProvider struct{}
+
+func (discardProvider) NewCounter(name, help string, labels ...string) metrics.Counter {
+	return discard.NewCounter()
+}
+
+func (discardProvider) NewGauge(name, help string, labels ...string) metrics.Gauge {
+	return discard.NewGauge()
+}
+
+func (discardProvider) NewHistogram(name, help string, labels ...string) metrics.Histogram {
+	return discard.NewHistogram()
+}
+
+func (discardProvider) Handler() http.Handler {
+	return http.NotFoundHandler()
+}
+
+// NewDiscardProvider returns a Provider whose metrics are not recorded
+func NewDiscardProvider() Provider {
+	return discardProvider{}
+}
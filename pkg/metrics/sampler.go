@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+)
+
+// SampleFunc returns the current value of a gauge
+type SampleFunc func() (float64, error)
+
+type sample struct {
+	name  string
+	gauge metrics.Gauge
+	f     SampleFunc
+}
+
+// Sampler periodically sets gauges to the state of the services, e.g. the number of running containers
+type Sampler struct {
+	p       Provider
+	samples []sample
+	logger  log.Logger
+}
+
+// Gauge creates a gauge which is set to the value returned by f whenever the Sampler samples
+func (s *Sampler) Gauge(name, help string, f SampleFunc) {
+	s.samples = append(s.samples, sample{
+		name:  name,
+		gauge: s.p.NewGauge(name, help),
+		f:     f,
+	})
+}
+
+// Sample sets all gauges, gauges whose function fails keep their value
+func (s *Sampler) Sample() {
+	for _, smp := range s.samples {
+		v, err := smp.f()
+		if err != nil {
+			level.Error(s.logger).Log("gauge", smp.name, "err", err)
+			continue
+		}
+		smp.gauge.Set(v)
+	}
+}
+
+// Run samples every interval until stop is closed
+func (s *Sampler) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		s.Sample()
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewSampler returns a Sampler creating its gauges with p
+func NewSampler(p Provider, logger log.Logger) *Sampler {
+	return &Sampler{
+		p:       p,
+		samples: []sample{},
+		logger:  logger,
+	}
+}
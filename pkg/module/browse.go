@@ -0,0 +1,182 @@
+package module
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/context"
+)
+
+// MaxReadSize is the size of the largest file ReadFile returns
+var MaxReadSize int64 = 1 << 20
+
+var (
+	// ErrOutsideContainer occurs if a path leaves the root filesystem of a container, e.g. through a symlink
+	ErrOutsideContainer = errors.New("path is outside of the container")
+
+	// ErrFileTooLarge occurs if a file larger than MaxReadSize is read
+	ErrFileTooLarge = errors.New("file is too large to be read")
+
+	// ErrFileNotExist occurs if a path does not exist inside of the container
+	ErrFileNotExist = errors.New("file does not exist")
+
+	// ErrNotDirectory occurs if a file is listed like a directory
+	ErrNotDirectory = errors.New("path is not a directory")
+)
+
+// resolve returns the path of p below the root filesystem coPath. p is taken as absolute path inside
+// of the container, symlinks are followed as long as they stay inside of coPath.
+func resolve(coPath string, p string) (string, error) {
+	root, err := filepath.EvalSymlinks(coPath)
+	if err != nil {
+		return "", err
+	}
+
+	// the errors of the lookup are not returned, they contain the path on the host
+	full, err := filepath.EvalSymlinks(filepath.Join(root, path.Clean("/"+p)))
+	if err != nil {
+		return "", ErrFileNotExist
+	}
+
+	if full != root && !strings.HasPrefix(full, root+string(filepath.Separator)) {
+		return "", ErrOutsideContainer
+	}
+	return full, nil
+}
+
+func fileInfo(f os.FileInfo) FileInfo {
+	return FileInfo{
+		Name:    f.Name(),
+		Size:    f.Size(),
+		Mode:    f.Mode().String(),
+		ModTime: f.ModTime(),
+		Dir:     f.IsDir(),
+	}
+}
+
+func (s *service) ListDirectory(ctx context.Context, refID uint, containerName string, dir string) ([]FileInfo, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.listDirectory(ctx, refID, containerName, dir)
+}
+
+func (s *service) listDirectory(ctx context.Context, refID uint, containerName string, dir string) ([]FileInfo, error) {
+	coPath, err := s.makePath(ctx, refID, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := resolve(coPath, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if !f.IsDir() {
+		return nil, ErrNotDirectory
+	}
+
+	files, err := ioutil.ReadDir(p)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := []FileInfo{}
+	for _, f := range files {
+		infos = append(infos, fileInfo(f))
+	}
+
+	// directories first, like file browsers list them
+	sort.SliceStable(infos, func(i, j int) bool {
+		return infos[i].Dir && !infos[j].Dir
+	})
+	return infos, nil
+}
+
+func (s *service) StatFile(ctx context.Context, refID uint, containerName string, file string) (FileInfo, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.statFile(ctx, refID, containerName, file)
+}
+
+func (s *service) statFile(ctx context.Context, refID uint, containerName string, file string) (FileInfo, error) {
+	coPath, err := s.makePath(ctx, refID, containerName)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	p, err := resolve(coPath, file)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	f, err := os.Stat(p)
+	if err != nil {
+		return FileInfo{}, err
+	}
+
+	info := fileInfo(f)
+	info.Name = path.Base(path.Clean("/" + file))
+	return info, nil
+}
+
+func (s *service) ReadFile(ctx context.Context, refID uint, containerName string, file string) ([]byte, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.readFile(ctx, refID, containerName, file)
+}
+
+func (s *service) readFile(ctx context.Context, refID uint, containerName string, file string) ([]byte, error) {
+	coPath, err := s.makePath(ctx, refID, containerName)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := resolve(coPath, file)
+	if err != nil {
+		return nil, err
+	}
+
+	// opening a named pipe would block, so the file is checked before it is opened
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, errors.New("cannot read a directory")
+	}
+	if !info.Mode().IsRegular() {
+		return nil, errors.New("only regular files can be read")
+	}
+	if info.Size() > MaxReadSize {
+		return nil, ErrFileTooLarge
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	// the file might grow after the stat, so no more than MaxReadSize bytes are read anyway
+	content, err := ioutil.ReadAll(&io.LimitedReader{R: f, N: MaxReadSize + 1})
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > MaxReadSize {
+		return nil, ErrFileTooLarge
+	}
+	return content, nil
+}
@@ -17,6 +17,7 @@ import (
 	kmiPB "github.com/kontainerooo/kontainer.ooo/pkg/kmi/pb"
 	"github.com/kontainerooo/kontainer.ooo/pkg/module"
 	"github.com/kontainerooo/kontainer.ooo/pkg/module/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 )
 
 // New creates a set of endpoints based on a gRPC connection
@@ -93,6 +94,42 @@ func New(conn *grpc.ClientConn, logger log.Logger) *module.Endpoints {
 		).Endpoint()
 	}
 
+	var ListDirectoryEndpoint endpoint.Endpoint
+	{
+		ListDirectoryEndpoint = grpctransport.NewClient(
+			conn,
+			"module.ModuleService",
+			"ListDirectory",
+			EncodeGRPCListDirectoryRequest,
+			DecodeGRPCListDirectoryResponse,
+			pb.ListDirectoryResponse{},
+		).Endpoint()
+	}
+
+	var StatFileEndpoint endpoint.Endpoint
+	{
+		StatFileEndpoint = grpctransport.NewClient(
+			conn,
+			"module.ModuleService",
+			"StatFile",
+			EncodeGRPCStatFileRequest,
+			DecodeGRPCStatFileResponse,
+			pb.StatFileResponse{},
+		).Endpoint()
+	}
+
+	var ReadFileEndpoint endpoint.Endpoint
+	{
+		ReadFileEndpoint = grpctransport.NewClient(
+			conn,
+			"module.ModuleService",
+			"ReadFile",
+			EncodeGRPCReadFileRequest,
+			DecodeGRPCReadFileResponse,
+			pb.ReadFileResponse{},
+		).Endpoint()
+	}
+
 	var UploadFileEndpoint endpoint.Endpoint
 	{
 		UploadFileEndpoint = grpctransport.NewClient(
@@ -196,6 +233,9 @@ func New(conn *grpc.ClientConn, logger log.Logger) *module.Endpoints {
 		RemoveDirectoryEndpoint:       RemoveDirectoryEndpoint,
 		GetFilesEndpoint:              GetFilesEndpoint,
 		GetFileEndpoint:               GetFileEndpoint,
+		ListDirectoryEndpoint:         ListDirectoryEndpoint,
+		StatFileEndpoint:              StatFileEndpoint,
+		ReadFileEndpoint:              ReadFileEndpoint,
 		UploadFileEndpoint:            UploadFileEndpoint,
 		GetModuleConfigEndpoint:       GetModuleConfigEndpoint,
 		SendCommandEndpoint:           SendCommandEndpoint,
@@ -542,6 +582,7 @@ func EncodeGRPCGetModulesRequest(_ context.Context, request interface{}) (interf
 	req := request.(*module.GetModulesRequest)
 	return &pb.GetModulesRequest{
 		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
 	}, nil
 }
 
@@ -564,5 +605,73 @@ func DecodeGRPCGetModulesResponse(_ context.Context, grpcResponse interface{}) (
 	return &module.GetModulesResponse{
 		Error:   getError(response.Error),
 		Modules: pbToModules(response.Modules),
+		Page:    paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCListDirectoryRequest is a transport/grpc.EncodeRequestFunc that converts a
+// module.proto-domain listdirectory request to a gRPC ListDirectory request.
+func EncodeGRPCListDirectoryRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*module.ListDirectoryRequest)
+	return &pb.ListDirectoryRequest{
+		RefID:         uint32(req.RefID),
+		ContainerName: req.ContainerName,
+		Path:          req.Path,
+	}, nil
+}
+
+// DecodeGRPCListDirectoryResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ListDirectory response to a module.proto-domain listdirectory response.
+func DecodeGRPCListDirectoryResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ListDirectoryResponse)
+	files := []module.FileInfo{}
+	for _, f := range response.Files {
+		files = append(files, module.ConvertPBFileInfo(f))
+	}
+	return &module.ListDirectoryResponse{
+		Files: files,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCStatFileRequest is a transport/grpc.EncodeRequestFunc that converts a
+// module.proto-domain statfile request to a gRPC StatFile request.
+func EncodeGRPCStatFileRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*module.StatFileRequest)
+	return &pb.StatFileRequest{
+		RefID:         uint32(req.RefID),
+		ContainerName: req.ContainerName,
+		Path:          req.Path,
+	}, nil
+}
+
+// DecodeGRPCStatFileResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC StatFile response to a module.proto-domain statfile response.
+func DecodeGRPCStatFileResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.StatFileResponse)
+	return &module.StatFileResponse{
+		File:  module.ConvertPBFileInfo(response.File),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCReadFileRequest is a transport/grpc.EncodeRequestFunc that converts a
+// module.proto-domain readfile request to a gRPC ReadFile request.
+func EncodeGRPCReadFileRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*module.ReadFileRequest)
+	return &pb.ReadFileRequest{
+		RefID:         uint32(req.RefID),
+		ContainerName: req.ContainerName,
+		Path:          req.Path,
+	}, nil
+}
+
+// DecodeGRPCReadFileResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ReadFile response to a module.proto-domain readfile response.
+func DecodeGRPCReadFileResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ReadFileResponse)
+	return &module.ReadFileResponse{
+		Content: response.Content,
+		Error:   getError(response.Error),
 	}, nil
 }
@@ -1,10 +1,23 @@
 // Package module is the module service that talks to dashboard templates
 package module
 
-import "github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+)
 
 // Module contains information about a container module
 type Module struct {
 	ContainerName string
 	KMDI          kmi.KMDI
 }
+
+// FileInfo describes a file inside of a container
+type FileInfo struct {
+	Name    string
+	Size    int64
+	Mode    string
+	ModTime time.Time
+	Dir     bool
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 )
 
 // Endpoints is a struct which collects all endpoints for the module service
@@ -15,6 +16,9 @@ type Endpoints struct {
 	RemoveDirectoryEndpoint       endpoint.Endpoint
 	GetFilesEndpoint              endpoint.Endpoint
 	GetFileEndpoint               endpoint.Endpoint
+	ListDirectoryEndpoint         endpoint.Endpoint
+	StatFileEndpoint              endpoint.Endpoint
+	ReadFileEndpoint              endpoint.Endpoint
 	UploadFileEndpoint            endpoint.Endpoint
 	GetModuleConfigEndpoint       endpoint.Endpoint
 	SendCommandEndpoint           endpoint.Endpoint
@@ -28,8 +32,8 @@ type Endpoints struct {
 // CreateContainerModuleRequest is the request struct for the CreateContainerModuleEndpoint
 type CreateContainerModuleRequest struct {
 	RefID uint
-	KmiID uint
-	Name  string
+	KmiID uint   `validate:"required"`
+	Name  string `validate:"required,name"`
 }
 
 // CreateContainerModuleResponse is the response struct for the CreateContainerModuleEndpoint
@@ -41,7 +45,7 @@ type CreateContainerModuleResponse struct {
 func MakeCreateContainerModuleEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(CreateContainerModuleRequest)
-		err := s.CreateContainerModule(req.RefID, req.KmiID, req.Name)
+		err := s.CreateContainerModule(ctx, req.RefID, req.KmiID, req.Name)
 		return CreateContainerModuleResponse{
 			Error: err,
 		}, nil
@@ -50,9 +54,9 @@ func MakeCreateContainerModuleEndpoint(s Service) endpoint.Endpoint {
 
 // SetPublicKeyRequest is the request struct for the SetPublicKeyEndpoint
 type SetPublicKeyRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
-	Key           string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
+	Key           string `validate:"required"`
 }
 
 // SetPublicKeyResponse is the response struct for the SetPublicKeyEndpoint
@@ -64,7 +68,7 @@ type SetPublicKeyResponse struct {
 func MakeSetPublicKeyEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(SetPublicKeyRequest)
-		err := s.SetPublicKey(req.RefID, req.ContainerName, req.Key)
+		err := s.SetPublicKey(ctx, req.RefID, req.ContainerName, req.Key)
 		return SetPublicKeyResponse{
 			Error: err,
 		}, nil
@@ -73,9 +77,9 @@ func MakeSetPublicKeyEndpoint(s Service) endpoint.Endpoint {
 
 // RemoveFileRequest is the request struct for the RemoveFileEndpoint
 type RemoveFileRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
-	Filename      string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
+	Filename      string `validate:"required"`
 }
 
 // RemoveFileResponse is the response struct for the RemoveFileEndpoint
@@ -87,7 +91,7 @@ type RemoveFileResponse struct {
 func MakeRemoveFileEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(RemoveFileRequest)
-		err := s.RemoveFile(req.RefID, req.ContainerName, req.Filename)
+		err := s.RemoveFile(ctx, req.RefID, req.ContainerName, req.Filename)
 		return RemoveFileResponse{
 			Error: err,
 		}, nil
@@ -96,8 +100,8 @@ func MakeRemoveFileEndpoint(s Service) endpoint.Endpoint {
 
 // RemoveDirectoryRequest is the request struct for the RemoveDirectoryEndpoint
 type RemoveDirectoryRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
 	Path          string
 }
 
@@ -110,7 +114,7 @@ type RemoveDirectoryResponse struct {
 func MakeRemoveDirectoryEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(RemoveDirectoryRequest)
-		err := s.RemoveDirectory(req.RefID, req.ContainerName, req.Path)
+		err := s.RemoveDirectory(ctx, req.RefID, req.ContainerName, req.Path)
 		return RemoveDirectoryResponse{
 			Error: err,
 		}, nil
@@ -119,8 +123,8 @@ func MakeRemoveDirectoryEndpoint(s Service) endpoint.Endpoint {
 
 // GetFilesRequest is the request struct for the GetFilesEndpoint
 type GetFilesRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
 	Path          string
 }
 
@@ -134,7 +138,7 @@ type GetFilesResponse struct {
 func MakeGetFilesEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetFilesRequest)
-		files, err := s.GetFiles(req.RefID, req.ContainerName, req.Path)
+		files, err := s.GetFiles(ctx, req.RefID, req.ContainerName, req.Path)
 		return GetFilesResponse{
 			Files: files,
 			Error: err,
@@ -144,8 +148,8 @@ func MakeGetFilesEndpoint(s Service) endpoint.Endpoint {
 
 // GetFileRequest is the request struct for the GetFileEndpoint
 type GetFileRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
 	Path          string
 }
 
@@ -159,7 +163,7 @@ type GetFileResponse struct {
 func MakeGetFileEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetFileRequest)
-		content, err := s.GetFile(req.RefID, req.ContainerName, req.Path)
+		content, err := s.GetFile(ctx, req.RefID, req.ContainerName, req.Path)
 		return GetFileResponse{
 			Content: content,
 			Error:   err,
@@ -169,8 +173,8 @@ func MakeGetFileEndpoint(s Service) endpoint.Endpoint {
 
 // UploadFileRequest is the request struct for the UploadFileEndpoint
 type UploadFileRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
 	Path          string
 	Content       []byte
 	Override      bool
@@ -185,7 +189,7 @@ type UploadFileResponse struct {
 func MakeUploadFileEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(UploadFileRequest)
-		err := s.UploadFile(req.RefID, req.ContainerName, req.Path, req.Content, req.Override)
+		err := s.UploadFile(ctx, req.RefID, req.ContainerName, req.Path, req.Content, req.Override)
 		return UploadFileResponse{
 			Error: err,
 		}, nil
@@ -194,8 +198,8 @@ func MakeUploadFileEndpoint(s Service) endpoint.Endpoint {
 
 // GetModuleConfigRequest is the request struct for the GetModuleConfigEndpoint
 type GetModuleConfigRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
 }
 
 // GetModuleConfigResponse is the response struct for the GetModuleConfigEndpoint
@@ -209,7 +213,7 @@ type GetModuleConfigResponse struct {
 func MakeGetModuleConfigEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetModuleConfigRequest)
-		cKMI, links, err := s.GetModuleConfig(req.RefID, req.ContainerName)
+		cKMI, links, err := s.GetModuleConfig(ctx, req.RefID, req.ContainerName)
 		return GetModuleConfigResponse{
 			ContainerKMI: cKMI,
 			Links:        links,
@@ -220,9 +224,9 @@ func MakeGetModuleConfigEndpoint(s Service) endpoint.Endpoint {
 
 // SendCommandRequest is the request struct for the SendCommandEndpoint
 type SendCommandRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
-	Command       string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
+	Command       string `validate:"required"`
 	Env           map[string]string
 }
 
@@ -236,7 +240,7 @@ type SendCommandResponse struct {
 func MakeSendCommandEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(SendCommandRequest)
-		res, err := s.SendCommand(req.RefID, req.ContainerName, req.Command, req.Env)
+		res, err := s.SendCommand(ctx, req.RefID, req.ContainerName, req.Command, req.Env)
 		return SendCommandResponse{
 			Response: res,
 			Error:    err,
@@ -246,9 +250,9 @@ func MakeSendCommandEndpoint(s Service) endpoint.Endpoint {
 
 // SetEnvRequest is the request struct for the SetEnvEndpoint
 type SetEnvRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
-	Key           string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
+	Key           string `validate:"required"`
 	Value         string
 }
 
@@ -261,7 +265,7 @@ type SetEnvResponse struct {
 func MakeSetEnvEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(SetEnvRequest)
-		err := s.SetEnv(req.RefID, req.ContainerName, req.Key, req.Value)
+		err := s.SetEnv(ctx, req.RefID, req.ContainerName, req.Key, req.Value)
 		return SetEnvResponse{
 			Error: err,
 		}, nil
@@ -270,9 +274,9 @@ func MakeSetEnvEndpoint(s Service) endpoint.Endpoint {
 
 // GetEnvRequest is the request struct for the GetEnvEndpoint
 type GetEnvRequest struct {
-	RefID         uint `bart:"ref"`
-	ContainerName string
-	Key           string
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
+	Key           string `validate:"required"`
 }
 
 // GetEnvResponse is the response struct for the GetEnvEndpoint
@@ -285,7 +289,7 @@ type GetEnvResponse struct {
 func MakeGetEnvEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetEnvRequest)
-		val, err := s.GetEnv(req.RefID, req.ContainerName, req.Key)
+		val, err := s.GetEnv(ctx, req.RefID, req.ContainerName, req.Key)
 		return GetEnvResponse{
 			Value: val,
 			Error: err,
@@ -296,8 +300,8 @@ func MakeGetEnvEndpoint(s Service) endpoint.Endpoint {
 // SetLinkRequest is the request struct for the SetLinkEndpoint
 type SetLinkRequest struct {
 	RefID         uint
-	ContainerName string
-	LinkName      string
+	ContainerName string `validate:"required,name"`
+	LinkName      string `validate:"required,name"`
 	LinkInterface string
 }
 
@@ -310,7 +314,7 @@ type SetLinkResponse struct {
 func MakeSetLinkEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(SetLinkRequest)
-		err := s.SetLink(req.RefID, req.ContainerName, req.LinkName, req.LinkInterface)
+		err := s.SetLink(ctx, req.RefID, req.ContainerName, req.LinkName, req.LinkInterface)
 		return SetLinkResponse{
 			Error: err,
 		}, nil
@@ -320,8 +324,8 @@ func MakeSetLinkEndpoint(s Service) endpoint.Endpoint {
 // RemoveLinkRequest is the request struct for the RemoveLinkEndpoint
 type RemoveLinkRequest struct {
 	RefID         uint
-	ContainerName string
-	LinkName      string
+	ContainerName string `validate:"required,name"`
+	LinkName      string `validate:"required,name"`
 	LinkInterface string
 }
 
@@ -334,7 +338,7 @@ type RemoveLinkResponse struct {
 func MakeRemoveLinkEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(RemoveLinkRequest)
-		err := s.RemoveLink(req.RefID, req.ContainerName, req.LinkName, req.LinkInterface)
+		err := s.RemoveLink(ctx, req.RefID, req.ContainerName, req.LinkName, req.LinkInterface)
 		return RemoveLinkResponse{
 			Error: err,
 		}, nil
@@ -344,21 +348,108 @@ func MakeRemoveLinkEndpoint(s Service) endpoint.Endpoint {
 // GetModulesRequest is the request struct for the GetModulesEndpoint
 type GetModulesRequest struct {
 	RefID uint
+	Page  paging.Request
 }
 
 // GetModulesResponse is the response struct for the GetModulesEndpoint
 type GetModulesResponse struct {
 	Modules []Module
 	Error   error
+	Page    paging.Response
 }
 
 // MakeGetModulesEndpoint creates a gokit endpoint which invokes GetModules
 func MakeGetModulesEndpoint(s Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(GetModulesRequest)
-		mods, err := s.GetModules(req.RefID)
+		mods, err := s.GetModules(ctx, req.RefID)
+		if err != nil {
+			return GetModulesResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&mods, "ContainerName", req.Page)
+		if err != nil {
+			return nil, err
+		}
 		return GetModulesResponse{
 			Modules: mods,
+			Page:    page,
+		}, nil
+	}
+}
+
+// ListDirectoryRequest is the request struct for the ListDirectoryEndpoint
+type ListDirectoryRequest struct {
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
+	Path          string
+}
+
+// ListDirectoryResponse is the response struct for the ListDirectoryEndpoint
+type ListDirectoryResponse struct {
+	Files []FileInfo
+	Error error
+}
+
+// MakeListDirectoryEndpoint creates a gokit endpoint which invokes ListDirectory
+func MakeListDirectoryEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ListDirectoryRequest)
+		files, err := s.ListDirectory(ctx, req.RefID, req.ContainerName, req.Path)
+		return ListDirectoryResponse{
+			Files: files,
+			Error: err,
+		}, nil
+	}
+}
+
+// StatFileRequest is the request struct for the StatFileEndpoint
+type StatFileRequest struct {
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
+	Path          string
+}
+
+// StatFileResponse is the response struct for the StatFileEndpoint
+type StatFileResponse struct {
+	File  FileInfo
+	Error error
+}
+
+// MakeStatFileEndpoint creates a gokit endpoint which invokes StatFile
+func MakeStatFileEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(StatFileRequest)
+		file, err := s.StatFile(ctx, req.RefID, req.ContainerName, req.Path)
+		return StatFileResponse{
+			File:  file,
+			Error: err,
+		}, nil
+	}
+}
+
+// ReadFileRequest is the request struct for the ReadFileEndpoint
+type ReadFileRequest struct {
+	RefID         uint   `bart:"ref"`
+	ContainerName string `validate:"required,name"`
+	Path          string `validate:"required"`
+}
+
+// ReadFileResponse is the response struct for the ReadFileEndpoint
+type ReadFileResponse struct {
+	Content []byte
+	Error   error
+}
+
+// MakeReadFileEndpoint creates a gokit endpoint which invokes ReadFile
+func MakeReadFileEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ReadFileRequest)
+		content, err := s.ReadFile(ctx, req.RefID, req.ContainerName, req.Path)
+		return ReadFileResponse{
+			Content: content,
 			Error:   err,
 		}, nil
 	}
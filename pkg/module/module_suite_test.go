@@ -0,0 +1,13 @@
+package module_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestModule(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Module Suite")
+}
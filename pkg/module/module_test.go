@@ -0,0 +1,218 @@
+package module_test
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+	"github.com/kontainerooo/kontainer.ooo/pkg/module"
+	"github.com/kontainerooo/kontainer.ooo/pkg/util"
+	"golang.org/x/net/context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// runtime records the calls of the module service to the container endpoints
+type runtime struct {
+	calls   []string
+	ready   map[string]int
+	failing string
+}
+
+func (r *runtime) endpoints() *container.Endpoints {
+	return &container.Endpoints{
+		CreateContainerEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(container.CreateContainerRequest)
+			r.calls = append(r.calls, "create "+req.Name)
+			if req.Name == r.failing {
+				return container.CreateContainerResponse{Error: errors.New("create failed")}, nil
+			}
+			return container.CreateContainerResponse{ID: req.Name}, nil
+		},
+		ExecuteEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(container.ExecuteRequest)
+			r.calls = append(r.calls, "ready "+req.ID)
+			if r.ready[req.ID] > 0 {
+				r.ready[req.ID]--
+				return container.ExecuteResponse{Error: errors.New("not ready")}, nil
+			}
+			return container.ExecuteResponse{}, nil
+		},
+		SetLinkEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(container.SetLinkRequest)
+			r.calls = append(r.calls, fmt.Sprintf("link %s %s", req.LinkName, req.LinkInterface))
+			return container.SetLinkResponse{}, nil
+		},
+		RemoveContainerEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(container.RemoveContainerRequest)
+			r.calls = append(r.calls, "remove "+req.ID)
+			return container.RemoveContainerResponse{}, nil
+		},
+	}
+}
+
+func catalog(stack kmi.Stack) *kmi.Endpoints {
+	kmdi := []kmi.KMDI{{ID: 1, Name: "app"}, {ID: 2, Name: "postgres"}, {ID: 3, Name: "redis"}}
+	return &kmi.Endpoints{
+		GetKMIEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return kmi.GetKMIResponse{KMI: &kmi.KMI{Stack: stack}}, nil
+		},
+		KMIEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return kmi.KMIResponse{KMDI: &kmdi}, nil
+		},
+	}
+}
+
+var _ = Describe("Module", func() {
+	var (
+		r     *runtime
+		stack kmi.Stack
+	)
+
+	BeforeEach(func() {
+		util.SetConfig(util.ConfigFile{})
+		module.ReadyInterval = time.Millisecond
+		r = &runtime{ready: map[string]int{}}
+		stack = kmi.Stack{
+			{Name: "cache", KMI: "redis", DependsOn: []string{"db"}, Links: []string{"redis"}},
+			{Name: "db", KMI: "postgres", Ready: "pg_isready", Links: []string{"postgres"}},
+		}
+	})
+
+	Describe("Create Container Module", func() {
+		It("Should start the stack in dependency order and wait until the members are ready", func() {
+			r.ready["web-db"] = 2
+			s, err := module.NewService(r.endpoints(), catalog(stack), log.NewNopLogger())
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = s.CreateContainerModule(context.Background(), 1, 1, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(r.calls).To(Equal([]string{
+				"create web-db", "ready web-db", "ready web-db", "ready web-db",
+				"create web-cache",
+				"create web",
+				"link web-db postgres", "link web-cache redis",
+			}))
+		})
+
+		It("Should remove the started members if a member is not ready in time", func() {
+			stack[1].Timeout = 1
+			r.ready["web-db"] = 1 << 30
+			s, _ := module.NewService(r.endpoints(), catalog(stack), log.NewNopLogger())
+
+			err := s.CreateContainerModule(context.Background(), 1, 1, "web")
+			Ω(err).Should(HaveOccurred())
+			Expect(r.calls).NotTo(ContainElement("create web"))
+			Expect(r.calls[len(r.calls)-1]).To(Equal("remove web-db"))
+		})
+
+		It("Should remove the stack in reverse order if the module container fails", func() {
+			r.failing = "web"
+			s, _ := module.NewService(r.endpoints(), catalog(stack), log.NewNopLogger())
+
+			err := s.CreateContainerModule(context.Background(), 1, 1, "web")
+			Ω(err).Should(HaveOccurred())
+			Expect(r.calls[len(r.calls)-2:]).To(Equal([]string{"remove web-cache", "remove web-db"}))
+		})
+
+		It("Should remove the started members if a member kmi does not exist", func() {
+			stack[0].KMI = "memcached"
+			s, _ := module.NewService(r.endpoints(), catalog(stack), log.NewNopLogger())
+
+			err := s.CreateContainerModule(context.Background(), 1, 1, "web")
+			Ω(err).Should(HaveOccurred())
+			Expect(r.calls).To(Equal([]string{"create web-db", "ready web-db", "remove web-db"}))
+		})
+	})
+})
+
+var _ = Describe("File Browser", func() {
+	var (
+		s      module.Service
+		dir    string
+		rootfs string
+		ctx    = context.Background()
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "module")
+		Ω(err).ShouldNot(HaveOccurred())
+		rootfs = filepath.Join(dir, "1", "web-id", "rootfs")
+		Ω(os.MkdirAll(filepath.Join(rootfs, "etc", "app"), 0755)).Should(Succeed())
+		Ω(ioutil.WriteFile(filepath.Join(rootfs, "etc", "app", "config.yml"), []byte("port: 80"), 0644)).Should(Succeed())
+		Ω(ioutil.WriteFile(filepath.Join(rootfs, "hello"), []byte("hello"), 0644)).Should(Succeed())
+		Ω(ioutil.WriteFile(filepath.Join(dir, "host"), []byte("host"), 0644)).Should(Succeed())
+
+		util.SetConfig(util.ConfigFile{CustomerPath: dir})
+		s, err = module.NewService(&container.Endpoints{
+			IDForNameEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+				req := request.(container.IDForNameRequest)
+				if req.Name != "web" {
+					return container.IDForNameResponse{Error: errors.New("container does not exist")}, nil
+				}
+				return container.IDForNameResponse{ID: "web-id"}, nil
+			},
+		}, nil, log.NewNopLogger())
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Should list directories before files", func() {
+		files, err := s.ListDirectory(ctx, 1, "web", "/")
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(files).To(HaveLen(2))
+		Expect(files[0].Name).To(Equal("etc"))
+		Expect(files[0].Dir).To(BeTrue())
+		Expect(files[1].Name).To(Equal("hello"))
+		Expect(files[1].Size).To(BeEquivalentTo(5))
+	})
+
+	It("Should stat and read files", func() {
+		info, err := s.StatFile(ctx, 1, "web", "etc/app/config.yml")
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(info.Name).To(Equal("config.yml"))
+		Expect(info.Dir).To(BeFalse())
+
+		content, err := s.ReadFile(ctx, 1, "web", "/etc/app/config.yml")
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(string(content)).To(Equal("port: 80"))
+	})
+
+	It("Should keep paths inside of the container", func() {
+		content, err := s.ReadFile(ctx, 1, "web", "../../../host")
+		Ω(err).Should(Equal(module.ErrFileNotExist))
+		Expect(content).To(BeEmpty())
+
+		Ω(os.Symlink(filepath.Join(dir, "host"), filepath.Join(rootfs, "escape"))).Should(Succeed())
+		_, err = s.ReadFile(ctx, 1, "web", "escape")
+		Ω(err).Should(Equal(module.ErrOutsideContainer))
+		_, err = s.StatFile(ctx, 1, "web", "escape")
+		Ω(err).Should(Equal(module.ErrOutsideContainer))
+	})
+
+	It("Should refuse files larger than MaxReadSize", func() {
+		defer func(max int64) { module.MaxReadSize = max }(module.MaxReadSize)
+		module.MaxReadSize = 4
+
+		_, err := s.ReadFile(ctx, 1, "web", "hello")
+		Ω(err).Should(Equal(module.ErrFileTooLarge))
+	})
+
+	It("Should only browse the containers of the user", func() {
+		_, err := s.ListDirectory(ctx, 2, "web", "/")
+		Ω(err).Should(HaveOccurred())
+		_, err = s.ListDirectory(ctx, 1, "db", "/")
+		Ω(err).Should(HaveOccurred())
+	})
+})
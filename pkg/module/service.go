@@ -23,64 +23,74 @@ import (
 type Service interface {
 
 	// CreateContainerModule creates a new container module
-	CreateContainerModule(refID uint, kmidID uint, name string) error
+	CreateContainerModule(ctx context.Context, refID uint, kmidID uint, name string) error
 
 	// SetPublicKey sets a public key for ssh-ing into the container
-	SetPublicKey(refID uint, containerName string, key string) error
+	SetPublicKey(ctx context.Context, refID uint, containerName string, key string) error
 
 	// RemoveFile removes a file from the customer-container-path
-	RemoveFile(refID uint, containerName string, filename string) error
+	RemoveFile(ctx context.Context, refID uint, containerName string, filename string) error
 
 	// RemoveDirectory removes a directory from the customer-container-path
-	RemoveDirectory(refID uint, containerName string, path string) error
+	RemoveDirectory(ctx context.Context, refID uint, containerName string, path string) error
 
 	// GetFiles lists files from the customer-container-path
-	GetFiles(refID uint, containerName string, path string) (map[string]string, error)
+	GetFiles(ctx context.Context, refID uint, containerName string, path string) (map[string]string, error)
 
 	// GetFile gets the contents of a file from the customer-container-path
-	GetFile(refID uint, containerName string, path string) ([]byte, error)
+	GetFile(ctx context.Context, refID uint, containerName string, path string) ([]byte, error)
+
+	// ListDirectory lists the files of a directory inside of a running container, directories first
+	ListDirectory(ctx context.Context, refID uint, containerName string, dir string) ([]FileInfo, error)
+
+	// StatFile describes a file inside of a running container
+	StatFile(ctx context.Context, refID uint, containerName string, file string) (FileInfo, error)
+
+	// ReadFile returns the content of a regular file inside of a running container which is not larger than MaxReadSize
+	ReadFile(ctx context.Context, refID uint, containerName string, file string) ([]byte, error)
 
 	// UploadFile uploads a file in a given container to a given path
-	UploadFile(refID uint, containerName string, filepath string, content []byte, override bool) error
+	UploadFile(ctx context.Context, refID uint, containerName string, filepath string, content []byte, override bool) error
 
 	// GetModuleConfig returns the configuration for the module
-	GetModuleConfig(refID uint, containerName string) (kmi.KMI, map[string][]string, error)
+	GetModuleConfig(ctx context.Context, refID uint, containerName string) (kmi.KMI, map[string][]string, error)
 
 	// SendCommand sends a command to the customer-container, env overrides environment variables
 	// that are already globally defined in the container
-	SendCommand(refID uint, containerName string, command string, env map[string]string) (string, error)
+	SendCommand(ctx context.Context, refID uint, containerName string, command string, env map[string]string) (string, error)
 
 	// SetEnv sets a permanent environment variable in the container
-	SetEnv(refID uint, containerName string, key string, value string) error
+	SetEnv(ctx context.Context, refID uint, containerName string, key string, value string) error
 
 	// GetEnv gets the value of a permanent environment varibale in the container
-	GetEnv(refID uint, containerName string, key string) (string, error)
+	GetEnv(ctx context.Context, refID uint, containerName string, key string) (string, error)
 
 	// SetLink links a container module's interface into a container module
-	SetLink(refID uint, containerName string, linkName string, linkInterface string) error
+	SetLink(ctx context.Context, refID uint, containerName string, linkName string, linkInterface string) error
 
 	// RemoveLink links a container module's interface into a container module
-	RemoveLink(refID uint, containerName string, linkName string, linkInterface string) error
+	RemoveLink(ctx context.Context, refID uint, containerName string, linkName string, linkInterface string) error
 
 	// GetModules returns a user's modules
-	GetModules(refID uint) ([]Module, error)
+	GetModules(ctx context.Context, refID uint) ([]Module, error)
 }
 
 type service struct {
 	container *container.Endpoints
+	kmi       *kmi.Endpoints
 	logger    log.Logger
 	config    util.ConfigFile
 	mtx       *sync.Mutex
 }
 
-func (s *service) makePath(refID uint, containerName string) (string, error) {
+func (s *service) makePath(ctx context.Context, refID uint, containerName string) (string, error) {
 	cuPath := path.Join(s.config.CustomerPath, fmt.Sprintf("%d", refID))
 	_, err := os.Stat(cuPath)
 	if err != nil {
 		return "", errors.New("customer does not exist")
 	}
 
-	containerID, err := s.getContainerIDForName(refID, containerName)
+	containerID, err := s.getContainerIDForName(ctx, refID, containerName)
 	if err != nil {
 		return "", err
 	}
@@ -93,43 +103,69 @@ func (s *service) makePath(refID uint, containerName string) (string, error) {
 
 	return coPath, nil
 }
-func (s *service) CreateContainerModule(refID uint, kmidID uint, name string) error {
+func (s *service) CreateContainerModule(ctx context.Context, refID uint, kmidID uint, name string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.createContainerModule(refID, kmidID, name)
+	return s.createContainerModule(ctx, refID, kmidID, name)
+}
+
+func (s *service) createContainerModule(ctx context.Context, refID uint, kmidID uint, name string) error {
+	stack, err := s.stack(ctx, kmidID)
+	if err != nil {
+		return err
+	}
+
+	started, err := s.startStack(ctx, refID, name, stack)
+	if err != nil {
+		return err
+	}
+
+	id, err := s.createContainer(ctx, refID, kmidID, name)
+	if err == nil {
+		err = s.linkStack(ctx, refID, id, started)
+		if err != nil {
+			started = append(started, member{id: id, container: name})
+		}
+	}
+	if err != nil {
+		s.removeStack(refID, started)
+		return err
+	}
+
+	return nil
 }
 
-func (s *service) createContainerModule(refID uint, kmidID uint, name string) error {
-	res, err := s.container.CreateContainerEndpoint(context.Background(), container.CreateContainerRequest{
+func (s *service) createContainer(ctx context.Context, refID uint, kmidID uint, name string) (string, error) {
+	res, err := s.container.CreateContainerEndpoint(ctx, container.CreateContainerRequest{
 		RefID: refID,
 		KmiID: kmidID,
 		Name:  name,
 	})
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	errRes, ok := res.(container.CreateContainerResponse)
 	if !ok {
-		return errors.New("service returned unexpected response")
+		return "", errors.New("service returned unexpected response")
 	}
 	if errRes.Error != nil {
-		return errRes.Error
+		return "", errRes.Error
 	}
 
-	return nil
+	return errRes.ID, nil
 }
 
-func (s *service) SetPublicKey(refID uint, containerName string, key string) error {
+func (s *service) SetPublicKey(ctx context.Context, refID uint, containerName string, key string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.setPublicKey(refID, containerName, key)
+	return s.setPublicKey(ctx, refID, containerName, key)
 }
 
-func (s *service) setPublicKey(refID uint, containerName string, key string) error {
-	coPath, err := s.makePath(refID, containerName)
+func (s *service) setPublicKey(ctx context.Context, refID uint, containerName string, key string) error {
+	coPath, err := s.makePath(ctx, refID, containerName)
 	if err != nil {
 		return err
 	}
@@ -142,15 +178,15 @@ func (s *service) setPublicKey(refID uint, containerName string, key string) err
 	return nil
 }
 
-func (s *service) RemoveFile(refID uint, containerName string, filename string) error {
+func (s *service) RemoveFile(ctx context.Context, refID uint, containerName string, filename string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.removeFile(refID, containerName, filename)
+	return s.removeFile(ctx, refID, containerName, filename)
 }
 
-func (s *service) removeFile(refID uint, containerName string, filename string) error {
-	coPath, err := s.makePath(refID, containerName)
+func (s *service) removeFile(ctx context.Context, refID uint, containerName string, filename string) error {
+	coPath, err := s.makePath(ctx, refID, containerName)
 	if err != nil {
 		return err
 	}
@@ -167,15 +203,15 @@ func (s *service) removeFile(refID uint, containerName string, filename string)
 	return nil
 }
 
-func (s *service) RemoveDirectory(refID uint, containerName string, dir string) error {
+func (s *service) RemoveDirectory(ctx context.Context, refID uint, containerName string, dir string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.removeDirectory(refID, containerName, dir)
+	return s.removeDirectory(ctx, refID, containerName, dir)
 }
 
-func (s *service) removeDirectory(refID uint, containerName string, dir string) error {
-	coPath, err := s.makePath(refID, containerName)
+func (s *service) removeDirectory(ctx context.Context, refID uint, containerName string, dir string) error {
+	coPath, err := s.makePath(ctx, refID, containerName)
 	if err != nil {
 		return err
 	}
@@ -196,17 +232,17 @@ func (s *service) removeDirectory(refID uint, containerName string, dir string)
 	return nil
 }
 
-func (s *service) GetFiles(refID uint, containerName string, dir string) (map[string]string, error) {
+func (s *service) GetFiles(ctx context.Context, refID uint, containerName string, dir string) (map[string]string, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.getFiles(refID, containerName, dir)
+	return s.getFiles(ctx, refID, containerName, dir)
 }
 
-func (s *service) getFiles(refID uint, containerName string, dir string) (map[string]string, error) {
+func (s *service) getFiles(ctx context.Context, refID uint, containerName string, dir string) (map[string]string, error) {
 	flist := make(map[string]string)
 
-	coPath, err := s.makePath(refID, containerName)
+	coPath, err := s.makePath(ctx, refID, containerName)
 	if err != nil {
 		return flist, err
 	}
@@ -232,15 +268,15 @@ func (s *service) getFiles(refID uint, containerName string, dir string) (map[st
 	return flist, nil
 }
 
-func (s *service) GetFile(refID uint, containerName string, filepath string) ([]byte, error) {
+func (s *service) GetFile(ctx context.Context, refID uint, containerName string, filepath string) ([]byte, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.getFile(refID, containerName, filepath)
+	return s.getFile(ctx, refID, containerName, filepath)
 }
 
-func (s *service) getFile(refID uint, containerName string, filepath string) ([]byte, error) {
-	coPath, err := s.makePath(refID, containerName)
+func (s *service) getFile(ctx context.Context, refID uint, containerName string, filepath string) ([]byte, error) {
+	coPath, err := s.makePath(ctx, refID, containerName)
 	if err != nil {
 		return []byte{}, err
 	}
@@ -266,15 +302,15 @@ func (s *service) getFile(refID uint, containerName string, filepath string) ([]
 	return content, err
 }
 
-func (s *service) UploadFile(refID uint, containerName string, fpath string, content []byte, override bool) error {
+func (s *service) UploadFile(ctx context.Context, refID uint, containerName string, fpath string, content []byte, override bool) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.uploadFile(refID, containerName, fpath, content, override)
+	return s.uploadFile(ctx, refID, containerName, fpath, content, override)
 }
 
-func (s *service) uploadFile(refID uint, containerName string, fpath string, content []byte, override bool) error {
-	coPath, err := s.makePath(refID, containerName)
+func (s *service) uploadFile(ctx context.Context, refID uint, containerName string, fpath string, content []byte, override bool) error {
+	coPath, err := s.makePath(ctx, refID, containerName)
 	if err != nil {
 		return err
 	}
@@ -316,20 +352,20 @@ func (s *service) uploadFile(refID uint, containerName string, fpath string, con
 	return nil
 }
 
-func (s *service) GetModuleConfig(refID uint, containerName string) (kmi.KMI, map[string][]string, error) {
+func (s *service) GetModuleConfig(ctx context.Context, refID uint, containerName string) (kmi.KMI, map[string][]string, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.getModuleConfig(refID, containerName)
+	return s.getModuleConfig(ctx, refID, containerName)
 }
 
-func (s *service) getModuleConfig(refID uint, containerName string) (kmi.KMI, map[string][]string, error) {
-	id, err := s.getContainerIDForName(refID, containerName)
+func (s *service) getModuleConfig(ctx context.Context, refID uint, containerName string) (kmi.KMI, map[string][]string, error) {
+	id, err := s.getContainerIDForName(ctx, refID, containerName)
 	if err != nil {
 		return kmi.KMI{}, make(map[string][]string), err
 	}
 
-	res, err := s.container.GetContainerKMIEndpoint(context.Background(), container.GetContainerKMIRequest{
+	res, err := s.container.GetContainerKMIEndpoint(ctx, container.GetContainerKMIRequest{
 		ContainerID: id,
 	})
 	if err != nil {
@@ -341,7 +377,7 @@ func (s *service) getModuleConfig(refID uint, containerName string) (kmi.KMI, ma
 		return kmi.KMI{}, make(map[string][]string), errors.New("service returned unexpected response")
 	}
 
-	res, err = s.container.GetLinksEndpoint(context.Background(), container.GetLinksRequest{
+	res, err = s.container.GetLinksEndpoint(ctx, container.GetLinksRequest{
 		RefID:       refID,
 		ContainerID: id,
 	})
@@ -357,20 +393,20 @@ func (s *service) getModuleConfig(refID uint, containerName string) (kmi.KMI, ma
 	return containerKMI.ContainerKMI, links.Links, nil
 }
 
-func (s *service) SendCommand(refID uint, containerName string, command string, env map[string]string) (string, error) {
+func (s *service) SendCommand(ctx context.Context, refID uint, containerName string, command string, env map[string]string) (string, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.sendCommand(refID, containerName, command, env)
+	return s.sendCommand(ctx, refID, containerName, command, env)
 }
 
-func (s *service) sendCommand(refID uint, containerName string, command string, env map[string]string) (string, error) {
-	id, err := s.getContainerIDForName(refID, containerName)
+func (s *service) sendCommand(ctx context.Context, refID uint, containerName string, command string, env map[string]string) (string, error) {
+	id, err := s.getContainerIDForName(ctx, refID, containerName)
 	if err != nil {
 		return "", err
 	}
 
-	res, err := s.container.GetContainerKMIEndpoint(context.Background(), container.GetContainerKMIRequest{
+	res, err := s.container.GetContainerKMIEndpoint(ctx, container.GetContainerKMIRequest{
 		ContainerID: id,
 	})
 	if err != nil {
@@ -389,7 +425,7 @@ func (s *service) sendCommand(refID uint, containerName string, command string,
 		return "", fmt.Errorf("Command %s not found", command)
 	}
 
-	res, err = s.container.ExecuteEndpoint(context.Background(), container.ExecuteRequest{
+	res, err = s.container.ExecuteEndpoint(ctx, container.ExecuteRequest{
 		RefID: refID,
 		ID:    id,
 		CMD:   cmdString,
@@ -411,20 +447,20 @@ func (s *service) sendCommand(refID uint, containerName string, command string,
 	return execRes.Response, nil
 }
 
-func (s *service) SetEnv(refID uint, containerName string, key string, value string) error {
+func (s *service) SetEnv(ctx context.Context, refID uint, containerName string, key string, value string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.setEnv(refID, containerName, key, value)
+	return s.setEnv(ctx, refID, containerName, key, value)
 }
 
-func (s *service) setEnv(refID uint, containerName string, key string, value string) error {
-	id, err := s.getContainerIDForName(refID, containerName)
+func (s *service) setEnv(ctx context.Context, refID uint, containerName string, key string, value string) error {
+	id, err := s.getContainerIDForName(ctx, refID, containerName)
 	if err != nil {
 		return err
 	}
 
-	_, err = s.container.SetEnvEndpoint(context.Background(), container.SetEnvRequest{
+	_, err = s.container.SetEnvEndpoint(ctx, container.SetEnvRequest{
 		RefID: refID,
 		ID:    id,
 		Key:   key,
@@ -437,20 +473,20 @@ func (s *service) setEnv(refID uint, containerName string, key string, value str
 	return nil
 }
 
-func (s *service) GetEnv(refID uint, containerName string, key string) (string, error) {
+func (s *service) GetEnv(ctx context.Context, refID uint, containerName string, key string) (string, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.getEnv(refID, containerName, key)
+	return s.getEnv(ctx, refID, containerName, key)
 }
 
-func (s *service) getEnv(refID uint, containerName string, key string) (string, error) {
-	id, err := s.getContainerIDForName(refID, containerName)
+func (s *service) getEnv(ctx context.Context, refID uint, containerName string, key string) (string, error) {
+	id, err := s.getContainerIDForName(ctx, refID, containerName)
 	if err != nil {
 		return "", err
 	}
 
-	res, err := s.container.GetEnvEndpoint(context.Background(), container.GetEnvRequest{
+	res, err := s.container.GetEnvEndpoint(ctx, container.GetEnvRequest{
 		RefID: refID,
 		ID:    id,
 		Key:   key,
@@ -471,8 +507,8 @@ func (s *service) getEnv(refID uint, containerName string, key string) (string,
 	return val.Value, nil
 }
 
-func (s *service) getContainerIDForName(refID uint, containerName string) (string, error) {
-	res, err := s.container.IDForNameEndpoint(context.Background(), container.IDForNameRequest{
+func (s *service) getContainerIDForName(ctx context.Context, refID uint, containerName string) (string, error) {
+	res, err := s.container.IDForNameEndpoint(ctx, container.IDForNameRequest{
 		RefID: refID,
 		Name:  containerName,
 	})
@@ -492,8 +528,8 @@ func (s *service) getContainerIDForName(refID uint, containerName string) (strin
 	return cnt.ID, nil
 }
 
-func (s *service) getKMI(containerID string) (kmi.KMI, error) {
-	res, err := s.container.GetContainerKMIEndpoint(context.Background(), container.GetContainerKMIRequest{
+func (s *service) getKMI(ctx context.Context, containerID string) (kmi.KMI, error) {
+	res, err := s.container.GetContainerKMIEndpoint(ctx, container.GetContainerKMIRequest{
 		ContainerID: containerID,
 	})
 	if err != nil {
@@ -508,25 +544,25 @@ func (s *service) getKMI(containerID string) (kmi.KMI, error) {
 	return containerKMI.ContainerKMI, nil
 }
 
-func (s *service) SetLink(refID uint, containerName string, linkName string, linkInterface string) error {
+func (s *service) SetLink(ctx context.Context, refID uint, containerName string, linkName string, linkInterface string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.setLink(refID, containerName, linkName, linkInterface)
+	return s.setLink(ctx, refID, containerName, linkName, linkInterface)
 }
 
-func (s *service) setLink(refID uint, containerName string, linkName string, linkInterface string) error {
-	srcID, err := s.getContainerIDForName(refID, containerName)
+func (s *service) setLink(ctx context.Context, refID uint, containerName string, linkName string, linkInterface string) error {
+	srcID, err := s.getContainerIDForName(ctx, refID, containerName)
 	if err != nil {
 		return err
 	}
 
-	dstID, err := s.getContainerIDForName(refID, linkName)
+	dstID, err := s.getContainerIDForName(ctx, refID, linkName)
 	if err != nil {
 		return err
 	}
 
-	res, err := s.container.SetLinkEndpoint(context.Background(), container.SetLinkRequest{
+	res, err := s.container.SetLinkEndpoint(ctx, container.SetLinkRequest{
 		RefID:         refID,
 		ContainerID:   srcID,
 		LinkID:        dstID,
@@ -549,25 +585,25 @@ func (s *service) setLink(refID uint, containerName string, linkName string, lin
 	return nil
 }
 
-func (s *service) RemoveLink(refID uint, containerName string, linkName string, linkInterface string) error {
+func (s *service) RemoveLink(ctx context.Context, refID uint, containerName string, linkName string, linkInterface string) error {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.removeLink(refID, containerName, linkName, linkInterface)
+	return s.removeLink(ctx, refID, containerName, linkName, linkInterface)
 }
 
-func (s *service) removeLink(refID uint, containerName string, linkName string, linkInterface string) error {
-	srcID, err := s.getContainerIDForName(refID, containerName)
+func (s *service) removeLink(ctx context.Context, refID uint, containerName string, linkName string, linkInterface string) error {
+	srcID, err := s.getContainerIDForName(ctx, refID, containerName)
 	if err != nil {
 		return err
 	}
 
-	dstID, err := s.getContainerIDForName(refID, linkName)
+	dstID, err := s.getContainerIDForName(ctx, refID, linkName)
 	if err != nil {
 		return err
 	}
 
-	res, err := s.container.RemoveLinkEndpoint(context.Background(), container.RemoveLinkRequest{
+	res, err := s.container.RemoveLinkEndpoint(ctx, container.RemoveLinkRequest{
 		RefID:         refID,
 		ContainerID:   srcID,
 		LinkID:        dstID,
@@ -590,15 +626,15 @@ func (s *service) removeLink(refID uint, containerName string, linkName string,
 	return nil
 }
 
-func (s *service) GetModules(refID uint) ([]Module, error) {
+func (s *service) GetModules(ctx context.Context, refID uint) ([]Module, error) {
 	s.mtx.Lock()
 	defer s.mtx.Unlock()
 
-	return s.getModules(refID)
+	return s.getModules(ctx, refID)
 }
 
-func (s *service) getModules(refID uint) ([]Module, error) {
-	res, err := s.container.InstancesEndpoint(context.Background(), container.InstancesRequest{
+func (s *service) getModules(ctx context.Context, refID uint) ([]Module, error) {
+	res, err := s.container.InstancesEndpoint(ctx, container.InstancesRequest{
 		RefID: refID,
 	})
 	if err != nil {
@@ -621,8 +657,8 @@ func (s *service) getModules(refID uint) ([]Module, error) {
 	return mods, nil
 }
 
-// NewService creates a new module service
-func NewService(ce *container.Endpoints, l log.Logger) (Service, error) {
+// NewService creates a new module service, the stacks of multi-container modules are read through ke
+func NewService(ce *container.Endpoints, ke *kmi.Endpoints, l log.Logger) (Service, error) {
 	conf, err := util.GetConfig()
 	if err != nil {
 		return &service{}, err
@@ -630,6 +666,7 @@ func NewService(ce *container.Endpoints, l log.Logger) (Service, error) {
 
 	s := &service{
 		container: ce,
+		kmi:       ke,
 		logger:    l,
 		config:    conf,
 		mtx:       &sync.Mutex{},
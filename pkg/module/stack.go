@@ -0,0 +1,202 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/container"
+	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
+)
+
+var (
+	// DefaultReadyTimeout is the time a stack member may take to become ready if its kmi sets no timeout
+	DefaultReadyTimeout = 60 * time.Second
+
+	// ReadyInterval is the time between two runs of the ready command of a stack member
+	ReadyInterval = time.Second
+)
+
+// member is a started container of a stack member
+type member struct {
+	*kmi.StackMember
+	id        string
+	container string
+}
+
+// stack returns the members of the stack of a kmi in the order they are started in
+func (s *service) stack(ctx context.Context, kmiID uint) ([]*kmi.StackMember, error) {
+	if s.kmi == nil {
+		return nil, nil
+	}
+
+	k, err := s.getTemplate(ctx, kmiID)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.Stack.Order()
+}
+
+func (s *service) getTemplate(ctx context.Context, kmiID uint) (*kmi.KMI, error) {
+	res, err := s.kmi.GetKMIEndpoint(ctx, kmi.GetKMIRequest{
+		ID: kmiID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	k, ok := res.(kmi.GetKMIResponse)
+	if !ok {
+		return nil, errors.New("service returned unexpected response")
+	}
+	if k.Error != nil {
+		return nil, k.Error
+	}
+
+	return k.KMI, nil
+}
+
+// kmiForName returns the id of the latest kmi with the given name
+func (s *service) kmiForName(ctx context.Context, name string) (uint, error) {
+	res, err := s.kmi.KMIEndpoint(ctx, kmi.KMIRequest{})
+	if err != nil {
+		return 0, err
+	}
+
+	list, ok := res.(kmi.KMIResponse)
+	if !ok {
+		return 0, errors.New("service returned unexpected response")
+	}
+	if list.Error != nil {
+		return 0, list.Error
+	}
+
+	var id uint
+	if list.KMDI != nil {
+		for _, k := range *list.KMDI {
+			if k.Name == name && k.ID > id {
+				id = k.ID
+			}
+		}
+	}
+	if id == 0 {
+		return 0, fmt.Errorf("kmi %s does not exist", name)
+	}
+
+	return id, nil
+}
+
+// startStack starts the members one after another, every member has to be ready before the next one is
+// started. The containers which were started are removed again if a member fails.
+func (s *service) startStack(ctx context.Context, refID uint, name string, stack []*kmi.StackMember) ([]member, error) {
+	started := []member{}
+	for _, m := range stack {
+		kmiID, err := s.kmiForName(ctx, m.KMI)
+		if err == nil {
+			started, err = s.startMember(ctx, refID, name, kmiID, m, started)
+		}
+		if err != nil {
+			s.removeStack(refID, started)
+			return nil, fmt.Errorf("stack member %s: %v", m.Name, err)
+		}
+	}
+
+	return started, nil
+}
+
+func (s *service) startMember(ctx context.Context, refID uint, name string, kmiID uint, m *kmi.StackMember, started []member) ([]member, error) {
+	containerName := fmt.Sprintf("%s-%s", name, m.Name)
+	id, err := s.createContainer(ctx, refID, kmiID, containerName)
+	if err != nil {
+		return started, err
+	}
+	started = append(started, member{m, id, containerName})
+
+	return started, s.waitReady(ctx, refID, id, m)
+}
+
+// waitReady runs the ready command of a member until it succeeds or the timeout of the member passed
+func (s *service) waitReady(ctx context.Context, refID uint, id string, m *kmi.StackMember) error {
+	if m.Ready == "" {
+		return nil
+	}
+
+	timeout := DefaultReadyTimeout
+	if m.Timeout > 0 {
+		timeout = time.Duration(m.Timeout) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		res, err := s.container.ExecuteEndpoint(ctx, container.ExecuteRequest{
+			RefID: refID,
+			ID:    id,
+			CMD:   m.Ready,
+		})
+		if err == nil {
+			exec, ok := res.(container.ExecuteResponse)
+			if ok && exec.Error == nil {
+				return nil
+			}
+		}
+
+		select {
+		case <-time.After(ReadyInterval):
+		case <-ctx.Done():
+			return fmt.Errorf("not ready within %s", timeout)
+		}
+	}
+}
+
+// linkStack links the interfaces of the members into the container of the module
+func (s *service) linkStack(ctx context.Context, refID uint, id string, started []member) error {
+	for _, m := range started {
+		for _, iface := range m.Links {
+			res, err := s.container.SetLinkEndpoint(ctx, container.SetLinkRequest{
+				RefID:         refID,
+				ContainerID:   id,
+				LinkID:        m.id,
+				LinkName:      m.container,
+				LinkInterface: iface,
+			})
+			if err != nil {
+				return err
+			}
+
+			errRes, ok := res.(container.SetLinkResponse)
+			if !ok {
+				return errors.New("service returned unexpected response")
+			}
+			if errRes.Error != nil {
+				return fmt.Errorf("stack member %s: %v", m.Name, errRes.Error)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeStack removes the started containers in reverse order, the request context might already
+// be done when a member failed to become ready
+func (s *service) removeStack(refID uint, started []member) {
+	for i := len(started) - 1; i >= 0; i-- {
+		m := started[i]
+		res, err := s.container.RemoveContainerEndpoint(context.Background(), container.RemoveContainerRequest{
+			RefID: refID,
+			ID:    m.id,
+		})
+		if err == nil {
+			if errRes, ok := res.(container.RemoveContainerResponse); ok {
+				err = errRes.Error
+			}
+		}
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "could not remove stack member", "container", m.container, "err", err)
+		}
+	}
+}
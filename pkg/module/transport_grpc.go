@@ -3,12 +3,14 @@ package module
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
 	"github.com/kontainerooo/kontainer.ooo/pkg/kmi"
 	kmiPB "github.com/kontainerooo/kontainer.ooo/pkg/kmi/pb"
 	modulePB "github.com/kontainerooo/kontainer.ooo/pkg/module/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 	oldcontext "golang.org/x/net/context"
 )
 
@@ -55,6 +57,24 @@ func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger)
 			EncodeGRPCGetFileResponse,
 			options...,
 		),
+		listdirectory: grpctransport.NewServer(
+			endpoints.ListDirectoryEndpoint,
+			DecodeGRPCListDirectoryRequest,
+			EncodeGRPCListDirectoryResponse,
+			options...,
+		),
+		statfile: grpctransport.NewServer(
+			endpoints.StatFileEndpoint,
+			DecodeGRPCStatFileRequest,
+			EncodeGRPCStatFileResponse,
+			options...,
+		),
+		readfile: grpctransport.NewServer(
+			endpoints.ReadFileEndpoint,
+			DecodeGRPCReadFileRequest,
+			EncodeGRPCReadFileResponse,
+			options...,
+		),
 		uploadfile: grpctransport.NewServer(
 			endpoints.UploadFileEndpoint,
 			DecodeGRPCUploadFileRequest,
@@ -113,6 +133,9 @@ type grpcServer struct {
 	removedirectory       grpctransport.Handler
 	getfiles              grpctransport.Handler
 	getfile               grpctransport.Handler
+	listdirectory         grpctransport.Handler
+	statfile              grpctransport.Handler
+	readfile              grpctransport.Handler
 	uploadfile            grpctransport.Handler
 	getmoduleconfig       grpctransport.Handler
 	sendcommand           grpctransport.Handler
@@ -208,6 +231,30 @@ func (s *grpcServer) GetFile(ctx oldcontext.Context, req *modulePB.GetFileReques
 	return res.(*modulePB.GetFileResponse), nil
 }
 
+func (s *grpcServer) ListDirectory(ctx oldcontext.Context, req *modulePB.ListDirectoryRequest) (*modulePB.ListDirectoryResponse, error) {
+	_, res, err := s.listdirectory.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*modulePB.ListDirectoryResponse), nil
+}
+
+func (s *grpcServer) StatFile(ctx oldcontext.Context, req *modulePB.StatFileRequest) (*modulePB.StatFileResponse, error) {
+	_, res, err := s.statfile.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*modulePB.StatFileResponse), nil
+}
+
+func (s *grpcServer) ReadFile(ctx oldcontext.Context, req *modulePB.ReadFileRequest) (*modulePB.ReadFileResponse, error) {
+	_, res, err := s.readfile.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*modulePB.ReadFileResponse), nil
+}
+
 func (s *grpcServer) UploadFile(ctx oldcontext.Context, req *modulePB.UploadFileRequest) (*modulePB.UploadFileResponse, error) {
 	_, res, err := s.uploadfile.ServeGRPC(ctx, req)
 	if err != nil {
@@ -426,6 +473,7 @@ func DecodeGRPCGetModulesRequest(_ context.Context, grpcReq interface{}) (interf
 	req := grpcReq.(*modulePB.GetModulesRequest)
 	return GetModulesRequest{
 		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
 	}, nil
 }
 
@@ -607,7 +655,110 @@ func toPBModules(mods []Module) []*modulePB.Module {
 func EncodeGRPCGetModulesResponse(_ context.Context, response interface{}) (interface{}, error) {
 	res := response.(GetModulesResponse)
 	gRPCRes := &modulePB.GetModulesResponse{
-		Modules: toPBModules(res.Modules),
+		Modules:  toPBModules(res.Modules),
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCListDirectoryRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ListDirectory request to a module.proto-domain listdirectory request.
+func DecodeGRPCListDirectoryRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*modulePB.ListDirectoryRequest)
+	return ListDirectoryRequest{
+		RefID:         uint(req.RefID),
+		ContainerName: req.ContainerName,
+		Path:          req.Path,
+	}, nil
+}
+
+// DecodeGRPCStatFileRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC StatFile request to a module.proto-domain statfile request.
+func DecodeGRPCStatFileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*modulePB.StatFileRequest)
+	return StatFileRequest{
+		RefID:         uint(req.RefID),
+		ContainerName: req.ContainerName,
+		Path:          req.Path,
+	}, nil
+}
+
+// DecodeGRPCReadFileRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ReadFile request to a module.proto-domain readfile request.
+func DecodeGRPCReadFileRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*modulePB.ReadFileRequest)
+	return ReadFileRequest{
+		RefID:         uint(req.RefID),
+		ContainerName: req.ContainerName,
+		Path:          req.Path,
+	}, nil
+}
+
+// ConvertFileInfo converts a FileInfo to its protobuf representation
+func ConvertFileInfo(f FileInfo) *modulePB.FileInfo {
+	return &modulePB.FileInfo{
+		Name:    f.Name,
+		Size:    f.Size,
+		Mode:    f.Mode,
+		ModTime: f.ModTime.Unix(),
+		Dir:     f.Dir,
+	}
+}
+
+// ConvertPBFileInfo converts the protobuf representation of a FileInfo
+func ConvertPBFileInfo(f *modulePB.FileInfo) FileInfo {
+	if f == nil {
+		return FileInfo{}
+	}
+	return FileInfo{
+		Name:    f.Name,
+		Size:    f.Size,
+		Mode:    f.Mode,
+		ModTime: time.Unix(f.ModTime, 0).UTC(),
+		Dir:     f.Dir,
+	}
+}
+
+// EncodeGRPCListDirectoryResponse is a transport/grpc.EncodeRequestFunc that converts a
+// module.proto-domain listdirectory response to a gRPC ListDirectory response.
+func EncodeGRPCListDirectoryResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ListDirectoryResponse)
+	files := []*modulePB.FileInfo{}
+	for _, f := range res.Files {
+		files = append(files, ConvertFileInfo(f))
+	}
+
+	gRPCRes := &modulePB.ListDirectoryResponse{
+		Files: files,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// EncodeGRPCStatFileResponse is a transport/grpc.EncodeRequestFunc that converts a
+// module.proto-domain statfile response to a gRPC StatFile response.
+func EncodeGRPCStatFileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(StatFileResponse)
+	gRPCRes := &modulePB.StatFileResponse{
+		File: ConvertFileInfo(res.File),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// EncodeGRPCReadFileResponse is a transport/grpc.EncodeRequestFunc that converts a
+// module.proto-domain readfile response to a gRPC ReadFile response.
+func EncodeGRPCReadFileResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ReadFileResponse)
+	gRPCRes := &modulePB.ReadFileResponse{
+		Content: res.Content,
 	}
 	if res.Error != nil {
 		gRPCRes.Error = res.Error.Error()
@@ -3,8 +3,8 @@ package module
 import (
 	"context"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/kontainerooo/kontainer.ooo/pkg/module/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
 )
 
@@ -60,6 +60,30 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 		EncodeGRPCGetFileResponse,
 	))
 
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"ListDirectory",
+		ws.ProtoIDFromString("LSD"),
+		endpoints.ListDirectoryEndpoint,
+		DecodeWSListDirectoryRequest,
+		EncodeGRPCListDirectoryResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"StatFile",
+		ws.ProtoIDFromString("STF"),
+		endpoints.StatFileEndpoint,
+		DecodeWSStatFileRequest,
+		EncodeGRPCStatFileResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"ReadFile",
+		ws.ProtoIDFromString("RDF"),
+		endpoints.ReadFileEndpoint,
+		DecodeWSReadFileRequest,
+		EncodeGRPCReadFileResponse,
+	))
+
 	service.AddEndpoint(ws.NewServiceEndpoint(
 		"UploadFile",
 		ws.ProtoIDFromString("ULF"),
@@ -131,7 +155,7 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 // WS CreateContainerModule request to a module.proto-domain createcontainermodule request.
 func DecodeWSCreateContainerModuleRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.CreateContainerModuleRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -143,7 +167,7 @@ func DecodeWSCreateContainerModuleRequest(ctx context.Context, data interface{})
 // WS SetPublicKey request to a module.proto-domain setpublickey request.
 func DecodeWSSetPublicKeyRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.SetPublicKeyRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -155,7 +179,7 @@ func DecodeWSSetPublicKeyRequest(ctx context.Context, data interface{}) (interfa
 // WS RemoveFile request to a module.proto-domain removefile request.
 func DecodeWSRemoveFileRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveFileRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -167,7 +191,7 @@ func DecodeWSRemoveFileRequest(ctx context.Context, data interface{}) (interface
 // WS RemoveDirectory request to a module.proto-domain removedirectory request.
 func DecodeWSRemoveDirectoryRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveDirectoryRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -179,7 +203,7 @@ func DecodeWSRemoveDirectoryRequest(ctx context.Context, data interface{}) (inte
 // WS GetFiles request to a module.proto-domain getfiles request.
 func DecodeWSGetFilesRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetFilesRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +215,7 @@ func DecodeWSGetFilesRequest(ctx context.Context, data interface{}) (interface{}
 // WS GetFile request to a module.proto-domain getfile request.
 func DecodeWSGetFileRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetFileRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -203,7 +227,7 @@ func DecodeWSGetFileRequest(ctx context.Context, data interface{}) (interface{},
 // WS UploadFile request to a module.proto-domain uploadfile request.
 func DecodeWSUploadFileRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.UploadFileRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -215,7 +239,7 @@ func DecodeWSUploadFileRequest(ctx context.Context, data interface{}) (interface
 // WS GetModuleConfig request to a module.proto-domain getmoduleconfig request.
 func DecodeWSGetModuleConfigRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetModuleConfigRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +251,7 @@ func DecodeWSGetModuleConfigRequest(ctx context.Context, data interface{}) (inte
 // WS SendCommand request to a module.proto-domain sendcommand request.
 func DecodeWSSendCommandRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.SendCommandRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -239,7 +263,7 @@ func DecodeWSSendCommandRequest(ctx context.Context, data interface{}) (interfac
 // WS SetEnv request to a module.proto-domain setenv request.
 func DecodeWSSetEnvRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.SetEnvRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -251,7 +275,7 @@ func DecodeWSSetEnvRequest(ctx context.Context, data interface{}) (interface{},
 // WS GetEnv request to a module.proto-domain getenv request.
 func DecodeWSGetEnvRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetEnvRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -263,7 +287,7 @@ func DecodeWSGetEnvRequest(ctx context.Context, data interface{}) (interface{},
 // WS SetLink request to a module.proto-domain setlink request.
 func DecodeWSSetLinkRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.SetLinkRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -275,7 +299,7 @@ func DecodeWSSetLinkRequest(ctx context.Context, data interface{}) (interface{},
 // WS RemoveLink request to a module.proto-domain removelink request.
 func DecodeWSRemoveLinkRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveLinkRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -287,10 +311,46 @@ func DecodeWSRemoveLinkRequest(ctx context.Context, data interface{}) (interface
 // WS GetModules request to a module.proto-domain getmodules request.
 func DecodeWSGetModulesRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetModulesRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
 
 	return DecodeGRPCGetModulesRequest(ctx, req)
 }
+
+// DecodeWSListDirectoryRequest is a websocket.DecodeRequestFunc that converts a
+// WS ListDirectory request to a module.proto-domain listdirectory request.
+func DecodeWSListDirectoryRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.ListDirectoryRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCListDirectoryRequest(ctx, req)
+}
+
+// DecodeWSStatFileRequest is a websocket.DecodeRequestFunc that converts a
+// WS StatFile request to a module.proto-domain statfile request.
+func DecodeWSStatFileRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.StatFileRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCStatFileRequest(ctx, req)
+}
+
+// DecodeWSReadFileRequest is a websocket.DecodeRequestFunc that converts a
+// WS ReadFile request to a module.proto-domain readfile request.
+func DecodeWSReadFileRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.ReadFileRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCReadFileRequest(ctx, req)
+}
@@ -0,0 +1,62 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+)
+
+// BridgeDriver creates and removes the bridge interfaces of users
+type BridgeDriver interface {
+	// Create creates a bridge interface with the address gw in the subnet n
+	Create(name string, n *net.IPNet, gw net.IP) error
+
+	// Remove removes a bridge interface
+	Remove(name string) error
+}
+
+// BridgeName returns the name of the bridge interface of a user
+func BridgeName(refid uint) string {
+	return fmt.Sprintf("kroo%d", refid)
+}
+
+type ipBridge struct{}
+
+func (ipBridge) run(args ...string) error {
+	out, err := exec.Command("ip", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ip %v: %s", args, out)
+	}
+	return nil
+}
+
+func (b ipBridge) Create(name string, n *net.IPNet, gw net.IP) error {
+	ones, _ := n.Mask.Size()
+
+	err := b.run("link", "add", "name", name, "type", "bridge")
+	if err != nil {
+		return err
+	}
+
+	err = b.run("addr", "add", fmt.Sprintf("%s/%d", gw, ones), "dev", name)
+	if err != nil {
+		b.Remove(name)
+		return err
+	}
+
+	err = b.run("link", "set", name, "up")
+	if err != nil {
+		b.Remove(name)
+		return err
+	}
+	return nil
+}
+
+func (b ipBridge) Remove(name string) error {
+	return b.run("link", "delete", name, "type", "bridge")
+}
+
+// NewIPBridgeDriver returns a BridgeDriver using iproute2
+func NewIPBridgeDriver() BridgeDriver {
+	return ipBridge{}
+}
@@ -3,13 +3,16 @@ package client
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/go-kit/kit/log"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
 	"google.golang.org/grpc"
 
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 	"github.com/kontainerooo/kontainer.ooo/pkg/pb"
 )
 
@@ -92,6 +95,158 @@ func New(conn *grpc.ClientConn, logger log.Logger) *network.Endpoints {
 			pb.RemovePortFromContainerResponse{},
 		).Endpoint()
 	}
+	var CreateBridgeEndpoint endpoint.Endpoint
+	{
+		CreateBridgeEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"CreateBridge",
+			EncodeGRPCCreateBridgeRequest,
+			DecodeGRPCCreateBridgeResponse,
+			pb.CreateBridgeResponse{},
+		).Endpoint()
+	}
+	var RemoveBridgeEndpoint endpoint.Endpoint
+	{
+		RemoveBridgeEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"RemoveBridge",
+			EncodeGRPCRemoveBridgeRequest,
+			DecodeGRPCRemoveBridgeResponse,
+			pb.RemoveBridgeResponse{},
+		).Endpoint()
+	}
+	var AssignIPEndpoint endpoint.Endpoint
+	{
+		AssignIPEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"AssignIP",
+			EncodeGRPCAssignIPRequest,
+			DecodeGRPCAssignIPResponse,
+			pb.AssignIPResponse{},
+		).Endpoint()
+	}
+	var ReleaseIPEndpoint endpoint.Endpoint
+	{
+		ReleaseIPEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"ReleaseIP",
+			EncodeGRPCReleaseIPRequest,
+			DecodeGRPCReleaseIPResponse,
+			pb.ReleaseIPResponse{},
+		).Endpoint()
+	}
+
+	var RequestPeeringEndpoint endpoint.Endpoint
+	{
+		RequestPeeringEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"RequestPeering",
+			EncodeGRPCRequestPeeringRequest,
+			DecodeGRPCRequestPeeringResponse,
+			pb.RequestPeeringResponse{},
+		).Endpoint()
+	}
+
+	var ApprovePeeringEndpoint endpoint.Endpoint
+	{
+		ApprovePeeringEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"ApprovePeering",
+			EncodeGRPCApprovePeeringRequest,
+			DecodeGRPCApprovePeeringResponse,
+			pb.ApprovePeeringResponse{},
+		).Endpoint()
+	}
+
+	var RevokePeeringEndpoint endpoint.Endpoint
+	{
+		RevokePeeringEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"RevokePeering",
+			EncodeGRPCRevokePeeringRequest,
+			DecodeGRPCRevokePeeringResponse,
+			pb.RevokePeeringResponse{},
+		).Endpoint()
+	}
+
+	var PeeringsEndpoint endpoint.Endpoint
+	{
+		PeeringsEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"Peerings",
+			EncodeGRPCPeeringsRequest,
+			DecodeGRPCPeeringsResponse,
+			pb.PeeringsResponse{},
+		).Endpoint()
+	}
+
+	var RegisterNodeEndpoint endpoint.Endpoint
+	{
+		RegisterNodeEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"RegisterNode",
+			EncodeGRPCRegisterNodeRequest,
+			DecodeGRPCRegisterNodeResponse,
+			pb.RegisterNodeResponse{},
+		).Endpoint()
+	}
+
+	var RemoveNodeEndpoint endpoint.Endpoint
+	{
+		RemoveNodeEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"RemoveNode",
+			EncodeGRPCRemoveNodeRequest,
+			DecodeGRPCRemoveNodeResponse,
+			pb.RemoveNodeResponse{},
+		).Endpoint()
+	}
+
+	var NodesEndpoint endpoint.Endpoint
+	{
+		NodesEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"Nodes",
+			EncodeGRPCNodesRequest,
+			DecodeGRPCNodesResponse,
+			pb.NodesResponse{},
+		).Endpoint()
+	}
+
+	var UsageEndpoint endpoint.Endpoint
+	{
+		UsageEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"Usage",
+			EncodeGRPCUsageRequest,
+			DecodeGRPCUsageResponse,
+			pb.UsageResponse{},
+		).Endpoint()
+	}
+
+	var TotalUsageEndpoint endpoint.Endpoint
+	{
+		TotalUsageEndpoint = grpctransport.NewClient(
+			conn,
+			"networkService",
+			"TotalUsage",
+			EncodeGRPCTotalUsageRequest,
+			DecodeGRPCTotalUsageResponse,
+			pb.TotalUsageResponse{},
+		).Endpoint()
+	}
 
 	return &network.Endpoints{
 		CreatePrimaryNetworkForContainerEndpoint: CreatePrimaryNetworkForContainerEndpoint,
@@ -101,6 +256,19 @@ func New(conn *grpc.ClientConn, logger log.Logger) *network.Endpoints {
 		RemoveContainerFromNetworkEndpoint:       RemoveContainerFromNetworkEndpoint,
 		ExposePortToContainerEndpoint:            ExposePortToContainerEndpoint,
 		RemovePortFromContainerEndpoint:          RemovePortFromContainerEndpoint,
+		CreateBridgeEndpoint:                     CreateBridgeEndpoint,
+		RemoveBridgeEndpoint:                     RemoveBridgeEndpoint,
+		AssignIPEndpoint:                         AssignIPEndpoint,
+		ReleaseIPEndpoint:                        ReleaseIPEndpoint,
+		RequestPeeringEndpoint:                   RequestPeeringEndpoint,
+		ApprovePeeringEndpoint:                   ApprovePeeringEndpoint,
+		RevokePeeringEndpoint:                    RevokePeeringEndpoint,
+		PeeringsEndpoint:                         PeeringsEndpoint,
+		RegisterNodeEndpoint:                     RegisterNodeEndpoint,
+		RemoveNodeEndpoint:                       RemoveNodeEndpoint,
+		NodesEndpoint:                            NodesEndpoint,
+		UsageEndpoint:                            UsageEndpoint,
+		TotalUsageEndpoint:                       TotalUsageEndpoint,
 	}
 }
 
@@ -259,3 +427,277 @@ func DecodeGRPCRemovePortFromContainerResponse(_ context.Context, grpcResponse i
 		Error: getError(response.Error),
 	}, nil
 }
+
+// EncodeGRPCCreateBridgeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain createbridge request to a gRPC CreateBridge request.
+func EncodeGRPCCreateBridgeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.CreateBridgeRequest)
+	return &pb.CreateBridgeRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCCreateBridgeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateBridge response to a messages/network.proto-domain createbridge response.
+func DecodeGRPCCreateBridgeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateBridgeResponse)
+	return &network.CreateBridgeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveBridgeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain removebridge request to a gRPC RemoveBridge request.
+func EncodeGRPCRemoveBridgeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.RemoveBridgeRequest)
+	return &pb.RemoveBridgeRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCRemoveBridgeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveBridge response to a messages/network.proto-domain removebridge response.
+func DecodeGRPCRemoveBridgeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveBridgeResponse)
+	return &network.RemoveBridgeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCAssignIPRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain assignip request to a gRPC AssignIP request.
+func EncodeGRPCAssignIPRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.AssignIPRequest)
+	return &pb.AssignIPRequest{
+		RefID:       uint32(req.RefID),
+		ContainerID: req.ContainerID,
+	}, nil
+}
+
+// DecodeGRPCAssignIPResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC AssignIP response to a messages/network.proto-domain assignip response.
+func DecodeGRPCAssignIPResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.AssignIPResponse)
+	return &network.AssignIPResponse{
+		IP:    abstraction.Inet(response.IP),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCReleaseIPRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain releaseip request to a gRPC ReleaseIP request.
+func EncodeGRPCReleaseIPRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.ReleaseIPRequest)
+	return &pb.ReleaseIPRequest{
+		RefID:       uint32(req.RefID),
+		ContainerID: req.ContainerID,
+	}, nil
+}
+
+// DecodeGRPCReleaseIPResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ReleaseIP response to a messages/network.proto-domain releaseip response.
+func DecodeGRPCReleaseIPResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ReleaseIPResponse)
+	return &network.ReleaseIPResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRequestPeeringRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain requestpeering request to a gRPC RequestPeering request.
+func EncodeGRPCRequestPeeringRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.RequestPeeringRequest)
+	return &pb.RequestPeeringRequest{
+		RefID:           uint32(req.RefID),
+		ContainerID:     req.ContainerID,
+		PeerRefID:       uint32(req.PeerRefID),
+		PeerContainerID: req.PeerContainerID,
+	}, nil
+}
+
+// DecodeGRPCRequestPeeringResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RequestPeering response to a messages/network.proto-domain requestpeering response.
+func DecodeGRPCRequestPeeringResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RequestPeeringResponse)
+	return &network.RequestPeeringResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCApprovePeeringRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain approvepeering request to a gRPC ApprovePeering request.
+func EncodeGRPCApprovePeeringRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.ApprovePeeringRequest)
+	return &pb.ApprovePeeringRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCApprovePeeringResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ApprovePeering response to a messages/network.proto-domain approvepeering response.
+func DecodeGRPCApprovePeeringResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ApprovePeeringResponse)
+	return &network.ApprovePeeringResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRevokePeeringRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain revokepeering request to a gRPC RevokePeering request.
+func EncodeGRPCRevokePeeringRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.RevokePeeringRequest)
+	return &pb.RevokePeeringRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRevokePeeringResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RevokePeering response to a messages/network.proto-domain revokepeering response.
+func DecodeGRPCRevokePeeringResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RevokePeeringResponse)
+	return &network.RevokePeeringResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCPeeringsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain peerings request to a gRPC Peerings request.
+func EncodeGRPCPeeringsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.PeeringsRequest)
+	return &pb.PeeringsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCPeeringsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Peerings response to a messages/network.proto-domain peerings response.
+func DecodeGRPCPeeringsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.PeeringsResponse)
+	peerings := make([]network.Peering, len(response.Peerings))
+	for i, p := range response.Peerings {
+		peerings[i] = network.ConvertPBPeering(p)
+	}
+	return &network.PeeringsResponse{
+		Peerings: peerings,
+		Error:    getError(response.Error),
+		Page:     paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCRegisterNodeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain registernode request to a gRPC RegisterNode request.
+func EncodeGRPCRegisterNodeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.RegisterNodeRequest)
+	return &pb.RegisterNodeRequest{
+		Name:    req.Name,
+		Address: string(req.Address),
+	}, nil
+}
+
+// DecodeGRPCRegisterNodeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RegisterNode response to a messages/network.proto-domain registernode response.
+func DecodeGRPCRegisterNodeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RegisterNodeResponse)
+	return &network.RegisterNodeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveNodeRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain removenode request to a gRPC RemoveNode request.
+func EncodeGRPCRemoveNodeRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.RemoveNodeRequest)
+	return &pb.RemoveNodeRequest{
+		Name: req.Name,
+	}, nil
+}
+
+// DecodeGRPCRemoveNodeResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveNode response to a messages/network.proto-domain removenode response.
+func DecodeGRPCRemoveNodeResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveNodeResponse)
+	return &network.RemoveNodeResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCNodesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain nodes request to a gRPC Nodes request.
+func EncodeGRPCNodesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	return &pb.NodesRequest{}, nil
+}
+
+// DecodeGRPCNodesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Nodes response to a messages/network.proto-domain nodes response.
+func DecodeGRPCNodesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.NodesResponse)
+	nodes := make([]network.Node, len(response.Nodes))
+	for i, n := range response.Nodes {
+		nodes[i] = network.Node{
+			Name:    n.Name,
+			Address: abstraction.Inet(n.Address),
+		}
+	}
+	return &network.NodesResponse{
+		Nodes: nodes,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCUsageRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain usage request to a gRPC Usage request.
+func EncodeGRPCUsageRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.UsageRequest)
+	return &pb.UsageRequest{
+		RefID: uint32(req.RefID),
+		From:  req.From.Unix(),
+		To:    req.To.Unix(),
+	}, nil
+}
+
+// DecodeGRPCUsageResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Usage response to a messages/network.proto-domain usage response.
+func DecodeGRPCUsageResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.UsageResponse)
+	usage := make([]network.Usage, len(response.Usage))
+	for i, u := range response.Usage {
+		usage[i] = network.Usage{
+			ContainerID: u.ContainerID,
+			Period:      time.Unix(u.Period, 0),
+			BytesIn:     u.BytesIn,
+			BytesOut:    u.BytesOut,
+		}
+	}
+	return &network.UsageResponse{
+		Usage: usage,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCTotalUsageRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain totalusage request to a gRPC TotalUsage request.
+func EncodeGRPCTotalUsageRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*network.TotalUsageRequest)
+	return &pb.TotalUsageRequest{
+		RefID: uint32(req.RefID),
+		From:  req.From.Unix(),
+		To:    req.To.Unix(),
+	}, nil
+}
+
+// DecodeGRPCTotalUsageResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC TotalUsage response to a messages/network.proto-domain totalusage response.
+func DecodeGRPCTotalUsageResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.TotalUsageResponse)
+	return &network.TotalUsageResponse{
+		Total: network.Bytes{
+			In:  response.BytesIn,
+			Out: response.BytesOut,
+		},
+		Error: getError(response.Error),
+	}, nil
+}
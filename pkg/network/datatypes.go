@@ -1,7 +1,11 @@
 // Package network handles container networks and interconnections
 package network
 
-import "github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
 
 // Networks stores the networks belonging to a user
 type Networks struct {
@@ -21,7 +25,7 @@ type Containers struct {
 
 // Config describes configuration options for Networks
 type Config struct {
-	Name   string
+	Name   string `validate:"required,name"`
 	Driver string
 }
 
@@ -34,3 +38,72 @@ type exposeData struct {
 	SrcNetwork string
 	Protocol   string
 }
+
+// Bridge is the isolated bridge network of a user
+type Bridge struct {
+	RefID   uint `gorm:"primary_key"`
+	Name    string
+	Subnet  abstraction.Inet
+	Gateway abstraction.Inet
+}
+
+// Assignment is an address of a user's bridge network assigned to a container
+type Assignment struct {
+	IP          abstraction.Inet `gorm:"primary_key"`
+	RefID       uint
+	ContainerID string
+}
+
+// Peering connects a container of a user to a container of another user, the container
+// of the requesting user joins the bridge network of the peer once the peer approved it
+type Peering struct {
+	ID              uint `gorm:"primary_key"`
+	RefID           uint
+	ContainerID     string
+	PeerRefID       uint
+	PeerContainerID string
+	Approved        bool
+
+	// Address is the address of ContainerID inside of the bridge network of the peer
+	Address abstraction.Inet
+
+	// PeerAddress is the address of PeerContainerID inside of its bridge network
+	PeerAddress abstraction.Inet
+}
+
+// involves reports whether refid is one of the users of a peering
+func (p Peering) involves(refid uint) bool {
+	return p.RefID == refid || p.PeerRefID == refid
+}
+
+// connects reports whether a peering is between the containers a and b
+func (p Peering) connects(a string, b string) bool {
+	return (p.ContainerID == a && p.PeerContainerID == b) || (p.ContainerID == b && p.PeerContainerID == a)
+}
+
+// Node is a host running containers, nodes exchange the overlay traffic of users on Address
+type Node struct {
+	Name    string `gorm:"primary_key"`
+	Address abstraction.Inet
+}
+
+// OverlayMember records that the bridge of a user exists on a node
+type OverlayMember struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	Node  string
+}
+
+// Usage is the traffic of a container during one minute
+type Usage struct {
+	RefID       uint      `gorm:"primary_key"`
+	ContainerID string    `gorm:"primary_key"`
+	Period      time.Time `gorm:"primary_key"`
+	BytesIn     uint64
+	BytesOut    uint64
+}
+
+// TableName sets Usage's database table name
+func (Usage) TableName() string {
+	return "network_usage"
+}
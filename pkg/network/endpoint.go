@@ -2,8 +2,11 @@ package network
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 )
 
 // Endpoints is a struct which collects all endpoints for the network service
@@ -15,13 +18,26 @@ type Endpoints struct {
 	RemoveContainerFromNetworkEndpoint       endpoint.Endpoint
 	ExposePortToContainerEndpoint            endpoint.Endpoint
 	RemovePortFromContainerEndpoint          endpoint.Endpoint
+	CreateBridgeEndpoint                     endpoint.Endpoint
+	RemoveBridgeEndpoint                     endpoint.Endpoint
+	AssignIPEndpoint                         endpoint.Endpoint
+	ReleaseIPEndpoint                        endpoint.Endpoint
+	RequestPeeringEndpoint                   endpoint.Endpoint
+	ApprovePeeringEndpoint                   endpoint.Endpoint
+	RevokePeeringEndpoint                    endpoint.Endpoint
+	PeeringsEndpoint                         endpoint.Endpoint
+	RegisterNodeEndpoint                     endpoint.Endpoint
+	RemoveNodeEndpoint                       endpoint.Endpoint
+	NodesEndpoint                            endpoint.Endpoint
+	UsageEndpoint                            endpoint.Endpoint
+	TotalUsageEndpoint                       endpoint.Endpoint
 }
 
 // CreatePrimaryNetworkForContainerRequest is the request struct for the CreatePrimaryNetworkForContainerEndpoint
 type CreatePrimaryNetworkForContainerRequest struct {
-	RefID       uint `bart:"ref"`
-	Config      *Config
-	ContainerID string
+	RefID       uint    `bart:"ref"`
+	Config      *Config `validate:"required"`
+	ContainerID string  `validate:"required"`
 }
 
 // CreatePrimaryNetworkForContainerResponse is the response struct for the CreatePrimaryNetworkForContainerEndpoint
@@ -42,8 +58,8 @@ func MakeCreatePrimaryNetworkForContainerEndpoint(s Service) endpoint.Endpoint {
 
 // CreateNetworkRequest is the request struct for the CreateNetworkEndpoint
 type CreateNetworkRequest struct {
-	RefID  uint `bart:"ref"`
-	Config *Config
+	RefID  uint    `bart:"ref"`
+	Config *Config `validate:"required"`
 }
 
 // CreateNetworkResponse is the response struct for the CreateNetworkEndpoint
@@ -64,8 +80,8 @@ func MakeCreateNetworkEndpoint(s Service) endpoint.Endpoint {
 
 // RemoveNetworkByNameRequest is the request struct for the RemoveNetworkByNameEndpoint
 type RemoveNetworkByNameRequest struct {
-	RefID uint `bart:"ref"`
-	Name  string
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required,name"`
 }
 
 // RemoveNetworkByNameResponse is the response struct for the RemoveNetworkByNameEndpoint
@@ -86,9 +102,9 @@ func MakeRemoveNetworkByNameEndpoint(s Service) endpoint.Endpoint {
 
 // AddContainerToNetworkRequest is the request struct for the AddContainerToNetworkEndpoint
 type AddContainerToNetworkRequest struct {
-	RefID       uint `bart:"ref"`
-	Name        string
-	ContainerID string
+	RefID       uint   `bart:"ref"`
+	Name        string `validate:"required,name"`
+	ContainerID string `validate:"required"`
 }
 
 // AddContainerToNetworkResponse is the response struct for the AddContainerToNetworkEndpoint
@@ -109,9 +125,9 @@ func MakeAddContainerToNetworkEndpoint(s Service) endpoint.Endpoint {
 
 // RemoveContainerFromNetworkRequest is the request struct for the RemoveContainerFromNetworkEndpoint
 type RemoveContainerFromNetworkRequest struct {
-	RefID       uint `bart:"ref"`
-	Name        string
-	ContainerID string
+	RefID       uint   `bart:"ref"`
+	Name        string `validate:"required,name"`
+	ContainerID string `validate:"required"`
 }
 
 // RemoveContainerFromNetworkResponse is the response struct for the RemoveContainerFromNetworkEndpoint
@@ -132,11 +148,11 @@ func MakeRemoveContainerFromNetworkEndpoint(s Service) endpoint.Endpoint {
 
 // ExposePortToContainerRequest is the request struct for the ExposePortToContainerEndpoint
 type ExposePortToContainerRequest struct {
-	RefID          uint `bart:"ref"`
-	SrcContainerID string
-	Port           uint16
-	Protocol       string
-	DstContainerID string
+	RefID          uint   `bart:"ref"`
+	SrcContainerID string `validate:"required"`
+	Port           uint16 `validate:"required,port"`
+	Protocol       string `validate:"required,protocol"`
+	DstContainerID string `validate:"required"`
 }
 
 // ExposePortToContainerResponse is the response struct for the ExposePortToContainerEndpoint
@@ -157,11 +173,11 @@ func MakeExposePortToContainerEndpoint(s Service) endpoint.Endpoint {
 
 // RemovePortFromContainerRequest is the request struct for the RemovePortFromContainerEndpoint
 type RemovePortFromContainerRequest struct {
-	RefID          uint `bart:"ref"`
-	SrcContainerID string
-	Port           uint16
-	Protocol       string
-	DstContainerID string
+	RefID          uint   `bart:"ref"`
+	SrcContainerID string `validate:"required"`
+	Port           uint16 `validate:"required,port"`
+	Protocol       string `validate:"required,protocol"`
+	DstContainerID string `validate:"required"`
 }
 
 // RemovePortFromContainerResponse is the response struct for the RemovePortFromContainerEndpoint
@@ -179,3 +195,309 @@ func MakeRemovePortFromContainerEndpoint(s Service) endpoint.Endpoint {
 		}, nil
 	}
 }
+
+// CreateBridgeRequest is the request struct for the CreateBridgeEndpoint
+type CreateBridgeRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// CreateBridgeResponse is the response struct for the CreateBridgeEndpoint
+type CreateBridgeResponse struct {
+	Error error
+}
+
+// MakeCreateBridgeEndpoint creates a gokit endpoint which invokes CreateBridge
+func MakeCreateBridgeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateBridgeRequest)
+		err := s.CreateBridge(req.RefID)
+		return CreateBridgeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RemoveBridgeRequest is the request struct for the RemoveBridgeEndpoint
+type RemoveBridgeRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// RemoveBridgeResponse is the response struct for the RemoveBridgeEndpoint
+type RemoveBridgeResponse struct {
+	Error error
+}
+
+// MakeRemoveBridgeEndpoint creates a gokit endpoint which invokes RemoveBridge
+func MakeRemoveBridgeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveBridgeRequest)
+		err := s.RemoveBridge(req.RefID)
+		return RemoveBridgeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// AssignIPRequest is the request struct for the AssignIPEndpoint
+type AssignIPRequest struct {
+	RefID       uint   `bart:"ref"`
+	ContainerID string `validate:"required"`
+}
+
+// AssignIPResponse is the response struct for the AssignIPEndpoint
+type AssignIPResponse struct {
+	IP    abstraction.Inet
+	Error error
+}
+
+// MakeAssignIPEndpoint creates a gokit endpoint which invokes AssignIP
+func MakeAssignIPEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AssignIPRequest)
+		ip, err := s.AssignIP(req.RefID, req.ContainerID)
+		return AssignIPResponse{
+			IP:    ip,
+			Error: err,
+		}, nil
+	}
+}
+
+// ReleaseIPRequest is the request struct for the ReleaseIPEndpoint
+type ReleaseIPRequest struct {
+	RefID       uint   `bart:"ref"`
+	ContainerID string `validate:"required"`
+}
+
+// ReleaseIPResponse is the response struct for the ReleaseIPEndpoint
+type ReleaseIPResponse struct {
+	Error error
+}
+
+// MakeReleaseIPEndpoint creates a gokit endpoint which invokes ReleaseIP
+func MakeReleaseIPEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ReleaseIPRequest)
+		err := s.ReleaseIP(req.RefID, req.ContainerID)
+		return ReleaseIPResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RequestPeeringRequest is the request struct for the RequestPeeringEndpoint
+type RequestPeeringRequest struct {
+	RefID           uint   `bart:"ref"`
+	ContainerID     string `validate:"required"`
+	PeerRefID       uint   `validate:"required"`
+	PeerContainerID string `validate:"required"`
+}
+
+// RequestPeeringResponse is the response struct for the RequestPeeringEndpoint
+type RequestPeeringResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeRequestPeeringEndpoint creates a gokit endpoint which invokes RequestPeering
+func MakeRequestPeeringEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RequestPeeringRequest)
+		id, err := s.RequestPeering(req.RefID, req.ContainerID, req.PeerRefID, req.PeerContainerID)
+		return RequestPeeringResponse{
+			ID:    id,
+			Error: err,
+		}, nil
+	}
+}
+
+// ApprovePeeringRequest is the request struct for the ApprovePeeringEndpoint
+type ApprovePeeringRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// ApprovePeeringResponse is the response struct for the ApprovePeeringEndpoint
+type ApprovePeeringResponse struct {
+	Error error
+}
+
+// MakeApprovePeeringEndpoint creates a gokit endpoint which invokes ApprovePeering
+func MakeApprovePeeringEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ApprovePeeringRequest)
+		err := s.ApprovePeering(req.RefID, req.ID)
+		return ApprovePeeringResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RevokePeeringRequest is the request struct for the RevokePeeringEndpoint
+type RevokePeeringRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RevokePeeringResponse is the response struct for the RevokePeeringEndpoint
+type RevokePeeringResponse struct {
+	Error error
+}
+
+// MakeRevokePeeringEndpoint creates a gokit endpoint which invokes RevokePeering
+func MakeRevokePeeringEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RevokePeeringRequest)
+		err := s.RevokePeering(req.RefID, req.ID)
+		return RevokePeeringResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// PeeringsRequest is the request struct for the PeeringsEndpoint
+type PeeringsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// PeeringsResponse is the response struct for the PeeringsEndpoint
+type PeeringsResponse struct {
+	Peerings []Peering
+	Error    error
+	Page     paging.Response
+}
+
+// MakePeeringsEndpoint creates a gokit endpoint which invokes Peerings
+func MakePeeringsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PeeringsRequest)
+		peerings, err := s.Peerings(req.RefID)
+		if err != nil {
+			return PeeringsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&peerings, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return PeeringsResponse{
+			Peerings: peerings,
+			Page:     page,
+		}, nil
+	}
+}
+
+// RegisterNodeRequest is the request struct for the RegisterNodeEndpoint
+type RegisterNodeRequest struct {
+	Name    string           `validate:"required,name"`
+	Address abstraction.Inet `validate:"required,inet"`
+}
+
+// RegisterNodeResponse is the response struct for the RegisterNodeEndpoint
+type RegisterNodeResponse struct {
+	Error error
+}
+
+// MakeRegisterNodeEndpoint creates a gokit endpoint which invokes RegisterNode
+func MakeRegisterNodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RegisterNodeRequest)
+		err := s.RegisterNode(req.Name, req.Address)
+		return RegisterNodeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// RemoveNodeRequest is the request struct for the RemoveNodeEndpoint
+type RemoveNodeRequest struct {
+	Name string `validate:"required,name"`
+}
+
+// RemoveNodeResponse is the response struct for the RemoveNodeEndpoint
+type RemoveNodeResponse struct {
+	Error error
+}
+
+// MakeRemoveNodeEndpoint creates a gokit endpoint which invokes RemoveNode
+func MakeRemoveNodeEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveNodeRequest)
+		err := s.RemoveNode(req.Name)
+		return RemoveNodeResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// NodesRequest is the request struct for the NodesEndpoint
+type NodesRequest struct{}
+
+// NodesResponse is the response struct for the NodesEndpoint
+type NodesResponse struct {
+	Nodes []Node
+	Error error
+}
+
+// MakeNodesEndpoint creates a gokit endpoint which invokes Nodes
+func MakeNodesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		nodes, err := s.Nodes()
+		return NodesResponse{
+			Nodes: nodes,
+			Error: err,
+		}, nil
+	}
+}
+
+// UsageRequest is the request struct for the UsageEndpoint
+type UsageRequest struct {
+	RefID uint `bart:"ref"`
+	From  time.Time
+	To    time.Time
+}
+
+// UsageResponse is the response struct for the UsageEndpoint
+type UsageResponse struct {
+	Usage []Usage
+	Error error
+}
+
+// MakeUsageEndpoint creates a gokit endpoint which invokes Usage
+func MakeUsageEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UsageRequest)
+		usage, err := s.Usage(req.RefID, req.From, req.To)
+		return UsageResponse{
+			Usage: usage,
+			Error: err,
+		}, nil
+	}
+}
+
+// TotalUsageRequest is the request struct for the TotalUsageEndpoint
+type TotalUsageRequest struct {
+	RefID uint `bart:"ref"`
+	From  time.Time
+	To    time.Time
+}
+
+// TotalUsageResponse is the response struct for the TotalUsageEndpoint
+type TotalUsageResponse struct {
+	Total Bytes
+	Error error
+}
+
+// MakeTotalUsageEndpoint creates a gokit endpoint which invokes TotalUsage
+func MakeTotalUsageEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(TotalUsageRequest)
+		total, err := s.TotalUsage(req.RefID, req.From, req.To)
+		return TotalUsageResponse{
+			Total: total,
+			Error: err,
+		}, nil
+	}
+}
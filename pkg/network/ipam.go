@@ -0,0 +1,392 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+)
+
+// ErrPoolExhausted occurs when every subnet of the pool or every address of a subnet is in use
+var ErrPoolExhausted = errors.New("No free addresses left")
+
+// Pool is the address range the subnets of user bridges are allocated from
+type Pool struct {
+	Network *net.IPNet
+
+	// Prefix is the prefix length of the subnet every user gets
+	Prefix int
+}
+
+// NewPool returns a Pool of the IPv4 network cidr divided into subnets with the given prefix length
+func NewPool(cidr string, prefix int) (*Pool, error) {
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.IP.To4() == nil {
+		return nil, errors.New("Pool has to be an IPv4 network")
+	}
+
+	ones, _ := n.Mask.Size()
+	if prefix < ones || prefix > 30 {
+		return nil, fmt.Errorf("Prefix has to be between %d and 30", ones)
+	}
+
+	return &Pool{
+		Network: n,
+		Prefix:  prefix,
+	}, nil
+}
+
+// size returns the number of subnets in the pool
+func (p *Pool) size() int {
+	ones, _ := p.Network.Mask.Size()
+	return 1 << uint(p.Prefix-ones)
+}
+
+// subnet returns the n-th subnet of the pool
+func (p *Pool) subnet(n int) *net.IPNet {
+	ip := p.Network.IP.To4()
+	offset := uint32(n) << uint(32-p.Prefix)
+	base := uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+	base += offset
+
+	return &net.IPNet{
+		IP:   net.IPv4(byte(base>>24), byte(base>>16), byte(base>>8), byte(base)).To4(),
+		Mask: net.CIDRMask(p.Prefix, 32),
+	}
+}
+
+// nextIP returns the address following ip
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// gateway returns the first host address of a subnet, which is assigned to the bridge itself
+func gateway(n *net.IPNet) net.IP {
+	return nextIP(n.IP.To4())
+}
+
+// hosts returns the addresses of a subnet which can be assigned to containers,
+// the network address, the gateway and the broadcast address are left out
+func hosts(n *net.IPNet) []net.IP {
+	ips := []net.IP{}
+	for ip := nextIP(gateway(n)); n.Contains(ip); ip = nextIP(ip) {
+		ips = append(ips, ip)
+	}
+
+	if len(ips) != 0 {
+		ips = ips[:len(ips)-1]
+	}
+	return ips
+}
+
+// getBridge returns the bridge network of a user
+func (s *service) getBridge(refid uint) (Bridge, error) {
+	b := Bridge{}
+	err := s.db.Where("ref_id = ?", refid)
+	if err != nil {
+		return b, err
+	}
+
+	err = s.db.First(&b, "ref_id = ?", refid)
+	if err != nil || b.RefID != refid || b.Name == "" {
+		return Bridge{}, ErrBridgeNotExist
+	}
+	return b, nil
+}
+
+// getAssignments returns every address assigned in the bridge network of a user, the rows
+// are filtered again since the conditions are not applied by every dbAdapter
+func (s *service) getAssignments(refid uint) ([]Assignment, error) {
+	as := []Assignment{}
+	err := s.db.Find(&as, "ref_id = ?", refid)
+	if err != nil {
+		return nil, err
+	}
+
+	assignments := []Assignment{}
+	for _, a := range as {
+		if a.RefID == refid {
+			assignments = append(assignments, a)
+		}
+	}
+	return assignments, nil
+}
+
+// freeSubnet returns the first subnet of the pool which is not used by a bridge
+func (s *service) freeSubnet() (*net.IPNet, error) {
+	bs := []Bridge{}
+	err := s.db.Find(&bs)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	for _, b := range bs {
+		used[string(b.Subnet)] = true
+	}
+
+	for i := 0; i < s.pool.size(); i++ {
+		n := s.pool.subnet(i)
+		if !used[string(abstraction.NewInetFromIP(n.IP, n))] {
+			return n, nil
+		}
+	}
+	return nil, ErrPoolExhausted
+}
+
+func (s *service) CreateBridge(refid uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	_, err := s.createBridge(refid)
+	return err
+}
+
+func (s *service) createBridge(refid uint) (Bridge, error) {
+	if s.pool == nil {
+		return Bridge{}, ErrNoPool
+	}
+
+	if b, err := s.getBridge(refid); err == nil {
+		ok, err := s.isLocal(refid)
+		if err != nil || ok {
+			return Bridge{}, ErrNetworkAlreadyExists
+		}
+		return b, s.join(b)
+	}
+
+	n, err := s.freeSubnet()
+	if err != nil {
+		return Bridge{}, err
+	}
+
+	gw := gateway(n)
+	b := Bridge{
+		RefID:   refid,
+		Name:    BridgeName(refid),
+		Subnet:  abstraction.NewInetFromIP(n.IP, n),
+		Gateway: abstraction.NewInetFromIP(gw, n),
+	}
+
+	err = s.bridges.Create(b.Name, n, gw)
+	if err != nil {
+		return Bridge{}, err
+	}
+
+	err = s.initBridge(b)
+	if err != nil {
+		s.bridges.Remove(b.Name)
+		return Bridge{}, err
+	}
+
+	err = s.db.Create(&b)
+	if err != nil {
+		s.bridges.Remove(b.Name)
+		return Bridge{}, err
+	}
+
+	if s.overlay != nil {
+		err = s.attach(b)
+		if err != nil {
+			return Bridge{}, err
+		}
+	}
+
+	return b, nil
+}
+
+// initBridge sets up the firewall rules of a bridge interface
+func (s *service) initBridge(b Bridge) error {
+	if s.fwClient == nil {
+		return nil
+	}
+
+	res, err := s.fwClient.InitBridgeEndpoint(context.Background(), firewall.InitBridgeRequest{
+		IP:    b.Subnet,
+		NetIf: b.Name,
+	})
+	if err == nil {
+		err = res.(firewall.InitBridgeResponse).Error
+	}
+	return err
+}
+
+// isLocal reports whether the bridge interface of a user exists on this node,
+// without an overlay every bridge is local
+func (s *service) isLocal(refid uint) (bool, error) {
+	if s.overlay == nil {
+		return true, nil
+	}
+	return s.isMember(refid)
+}
+
+func (s *service) Bridge(refid uint) (Bridge, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.getBridge(refid)
+}
+
+func (s *service) RemoveBridge(refid uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeBridge(refid)
+}
+
+func (s *service) removeBridge(refid uint) error {
+	ps, err := s.getPeerings(refid)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range ps {
+		err = s.removePeering(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	b, err := s.getBridge(refid)
+	if err != nil {
+		return err
+	}
+
+	as, err := s.getAssignments(refid)
+	if err != nil {
+		return err
+	}
+
+	local, err := s.isLocal(refid)
+	if err != nil {
+		return err
+	}
+
+	if local {
+		if s.overlay != nil {
+			err = s.leave(refid)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.bridges.Remove(b.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.db.Begin()
+	for _, a := range as {
+		err = s.db.Delete(&Assignment{IP: a.IP})
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+	}
+
+	err = s.db.Delete(&Bridge{RefID: refid})
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+
+	return nil
+}
+
+func (s *service) AssignIP(refid uint, containerID string) (abstraction.Inet, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.assignIP(refid, containerID)
+}
+
+func (s *service) assignIP(refid uint, containerID string) (abstraction.Inet, error) {
+	b, err := s.getBridge(refid)
+	if err == ErrBridgeNotExist {
+		b, err = s.createBridge(refid)
+	} else if err == nil {
+		var ok bool
+		ok, err = s.isLocal(refid)
+		if err == nil && !ok {
+			err = s.join(b)
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	_, n, err := b.Subnet.ParseCIDR()
+	if err != nil {
+		return "", err
+	}
+
+	as, err := s.getAssignments(refid)
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool)
+	for _, a := range as {
+		if a.ContainerID == containerID {
+			return a.IP, nil
+		}
+		used[a.IP.IP().String()] = true
+	}
+
+	for _, ip := range hosts(n) {
+		if used[ip.String()] {
+			continue
+		}
+
+		a := Assignment{
+			IP:          abstraction.NewInetFromIP(ip, n),
+			RefID:       refid,
+			ContainerID: containerID,
+		}
+
+		err = s.db.Create(&a)
+		if err != nil {
+			return "", err
+		}
+		return a.IP, nil
+	}
+	return "", ErrPoolExhausted
+}
+
+func (s *service) ReleaseIP(refid uint, containerID string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.releaseIP(refid, containerID)
+}
+
+func (s *service) releaseIP(refid uint, containerID string) error {
+	as, err := s.getAssignments(refid)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range as {
+		if a.ContainerID == containerID {
+			return s.db.Delete(&Assignment{IP: a.IP})
+		}
+	}
+	return errors.New("Container has no address")
+}
@@ -0,0 +1,353 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// AccountingChain is the iptables chain counting the traffic of container addresses
+const AccountingChain = "KROO-ACCOUNTING"
+
+// Bytes are the cumulative traffic counters of an address
+type Bytes struct {
+	In  uint64
+	Out uint64
+}
+
+// Counter counts the traffic of container addresses
+type Counter interface {
+	// Track starts counting the traffic of ip
+	Track(ip net.IP) error
+
+	// Untrack stops counting the traffic of ip
+	Untrack(ip net.IP) error
+
+	// Read returns the counters of every tracked address
+	Read() (map[string]Bytes, error)
+}
+
+type iptablesCounter struct{}
+
+func (iptablesCounter) run(args ...string) ([]byte, error) {
+	out, err := exec.Command("iptables", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("iptables %v: %s", args, out)
+	}
+	return out, nil
+}
+
+// init creates the accounting chain and jumps to it from the FORWARD chain
+func (c iptablesCounter) init() error {
+	if _, err := c.run("-n", "-L", AccountingChain); err == nil {
+		return nil
+	}
+
+	_, err := c.run("-N", AccountingChain)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.run("-I", "FORWARD", "-j", AccountingChain)
+	return err
+}
+
+func (c iptablesCounter) Track(ip net.IP) error {
+	_, err := c.run("-A", AccountingChain, "-d", ip.String(), "-j", "RETURN")
+	if err != nil {
+		return err
+	}
+
+	_, err = c.run("-A", AccountingChain, "-s", ip.String(), "-j", "RETURN")
+	return err
+}
+
+func (c iptablesCounter) Untrack(ip net.IP) error {
+	_, err := c.run("-D", AccountingChain, "-d", ip.String(), "-j", "RETURN")
+	if err != nil {
+		return err
+	}
+
+	_, err = c.run("-D", AccountingChain, "-s", ip.String(), "-j", "RETURN")
+	return err
+}
+
+func (c iptablesCounter) Read() (map[string]Bytes, error) {
+	out, err := c.run("-n", "-v", "-x", "-L", AccountingChain)
+	if err != nil {
+		return nil, err
+	}
+
+	counters := make(map[string]Bytes)
+	for _, line := range strings.Split(string(out), "\n") {
+		// pkts bytes target prot opt in out source destination
+		f := strings.Fields(line)
+		if len(f) != 9 || f[2] != "RETURN" {
+			continue
+		}
+
+		n, err := strconv.ParseUint(f[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if f[7] == "0.0.0.0/0" {
+			b := counters[f[8]]
+			b.In = n
+			counters[f[8]] = b
+		} else {
+			b := counters[f[7]]
+			b.Out = n
+			counters[f[7]] = b
+		}
+	}
+	return counters, nil
+}
+
+// NewIPTablesCounter returns a Counter using an iptables chain
+func NewIPTablesCounter() (Counter, error) {
+	c := iptablesCounter{}
+	return c, c.init()
+}
+
+// Meter samples the traffic counters of all assigned addresses and records the usage
+// of every container. Traffic is accounted to the user owning the network of an address.
+type Meter struct {
+	s       Service
+	counter Counter
+	logger  log.Logger
+
+	mtx     sync.Mutex
+	tracked map[string]Assignment
+	last    map[string]Bytes
+}
+
+// delta returns the traffic since the last sample, counters which went backwards were reset
+func delta(last Bytes, now Bytes) Bytes {
+	d := now
+	if now.In >= last.In {
+		d.In = now.In - last.In
+	}
+	if now.Out >= last.Out {
+		d.Out = now.Out - last.Out
+	}
+	return d
+}
+
+// sync tracks new assignments and stops tracking released ones
+func (m *Meter) sync() error {
+	as, err := m.s.Assignments()
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]Assignment)
+	for _, a := range as {
+		ip := a.IP.IP().String()
+		current[ip] = a
+
+		if _, ok := m.tracked[ip]; ok {
+			continue
+		}
+
+		err = m.counter.Track(a.IP.IP())
+		if err != nil {
+			level.Error(m.logger).Log("ip", ip, "err", err)
+			continue
+		}
+		m.tracked[ip] = a
+	}
+
+	for ip := range m.tracked {
+		if _, ok := current[ip]; ok {
+			continue
+		}
+
+		err = m.counter.Untrack(net.ParseIP(ip))
+		if err != nil {
+			level.Error(m.logger).Log("ip", ip, "err", err)
+		}
+		delete(m.tracked, ip)
+		delete(m.last, ip)
+	}
+	return nil
+}
+
+// Collect records the traffic of every tracked address since the last call
+func (m *Meter) Collect(now time.Time) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	counters, err := m.counter.Read()
+	if err != nil {
+		level.Error(m.logger).Log("err", err)
+		return
+	}
+
+	period := now.Truncate(time.Minute)
+	for ip, a := range m.tracked {
+		c, ok := counters[ip]
+		if !ok {
+			continue
+		}
+
+		last, known := m.last[ip]
+		m.last[ip] = c
+		if !known {
+			continue
+		}
+
+		d := delta(last, c)
+		if d.In == 0 && d.Out == 0 {
+			continue
+		}
+
+		err = m.s.RecordUsage(&Usage{
+			RefID:       a.RefID,
+			ContainerID: a.ContainerID,
+			Period:      period,
+			BytesIn:     d.In,
+			BytesOut:    d.Out,
+		})
+		if err != nil {
+			level.Error(m.logger).Log("container", a.ContainerID, "err", err)
+		}
+	}
+
+	err = m.sync()
+	if err != nil {
+		level.Error(m.logger).Log("err", err)
+	}
+}
+
+// Run calls Collect every interval until stop is closed
+func (m *Meter) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		m.Collect(time.Now())
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewMeter returns a Meter recording the usage of the addresses of s
+func NewMeter(s Service, c Counter, logger log.Logger) *Meter {
+	return &Meter{
+		s:       s,
+		counter: c,
+		logger:  logger,
+		tracked: make(map[string]Assignment),
+		last:    make(map[string]Bytes),
+	}
+}
+
+func (s *service) Assignments() ([]Assignment, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	as := []Assignment{}
+	err := s.db.Find(&as)
+	if err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+func (s *service) RecordUsage(u *Usage) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.recordUsage(u)
+}
+
+func (s *service) recordUsage(u *Usage) error {
+	stored := Usage{}
+	err := s.db.Where("ref_id = ? AND container_id = ? AND period = ?", u.RefID, u.ContainerID, u.Period)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.First(&stored, "ref_id = ? AND container_id = ? AND period = ?", u.RefID, u.ContainerID, u.Period)
+	if err != nil || stored.ContainerID != u.ContainerID || !stored.Period.Equal(u.Period) {
+		return s.db.Create(u)
+	}
+
+	s.db.Begin()
+	err = s.db.Where("ref_id = ? AND container_id = ? AND period = ?", u.RefID, u.ContainerID, u.Period)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Update(&Usage{}, &Usage{
+		BytesIn:  stored.BytesIn + u.BytesIn,
+		BytesOut: stored.BytesOut + u.BytesOut,
+	})
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+
+	return nil
+}
+
+func (s *service) Usage(refid uint, from time.Time, to time.Time) ([]Usage, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.usage(refid, from, to)
+}
+
+// usage returns the samples of a user between from and to, the rows are filtered again
+// since the conditions are not applied by every dbAdapter
+func (s *service) usage(refid uint, from time.Time, to time.Time) ([]Usage, error) {
+	if to.Before(from) {
+		return nil, errors.New("end of the period is before its start")
+	}
+
+	us := []Usage{}
+	err := s.db.Find(&us, "ref_id = ? AND period >= ? AND period < ?", refid, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := []Usage{}
+	for _, u := range us {
+		if u.RefID == refid && !u.Period.Before(from) && u.Period.Before(to) {
+			usage = append(usage, u)
+		}
+	}
+	return usage, nil
+}
+
+func (s *service) TotalUsage(refid uint, from time.Time, to time.Time) (Bytes, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	us, err := s.usage(refid, from, to)
+	if err != nil {
+		return Bytes{}, err
+	}
+
+	total := Bytes{}
+	for _, u := range us {
+		total.In += u.BytesIn
+		total.Out += u.BytesOut
+	}
+	return total, nil
+}
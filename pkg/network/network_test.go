@@ -1,9 +1,473 @@
 package network_test
 
 import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/go-kit/kit/log"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/network"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
 	. "github.com/onsi/ginkgo"
-	_ "github.com/onsi/gomega"
+	. "github.com/onsi/gomega"
 )
 
+type mockBridges struct {
+	bridges map[string]string
+	err     error
+}
+
+func (m *mockBridges) Create(name string, n *net.IPNet, gw net.IP) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.bridges[name] = gw.String()
+	return nil
+}
+
+func (m *mockBridges) Remove(name string) error {
+	delete(m.bridges, name)
+	return nil
+}
+
+func newMockBridges() *mockBridges {
+	return &mockBridges{
+		bridges: make(map[string]string),
+	}
+}
+
+type mockOverlay struct {
+	attached map[uint32]string
+	peers    map[uint32][]string
+}
+
+func (m *mockOverlay) Attach(bridge string, vni uint32, local net.IP) error {
+	m.attached[vni] = bridge
+	return nil
+}
+
+func (m *mockOverlay) Detach(vni uint32) error {
+	delete(m.attached, vni)
+	delete(m.peers, vni)
+	return nil
+}
+
+func (m *mockOverlay) SetPeers(vni uint32, remotes []net.IP) error {
+	m.peers[vni] = []string{}
+	for _, r := range remotes {
+		m.peers[vni] = append(m.peers[vni], r.String())
+	}
+	return nil
+}
+
+func newMockOverlay() *mockOverlay {
+	return &mockOverlay{
+		attached: make(map[uint32]string),
+		peers:    make(map[uint32][]string),
+	}
+}
+
+type mockCounter struct {
+	counters map[string]network.Bytes
+}
+
+func (m *mockCounter) Track(ip net.IP) error {
+	m.counters[ip.String()] = network.Bytes{}
+	return nil
+}
+
+func (m *mockCounter) Untrack(ip net.IP) error {
+	delete(m.counters, ip.String())
+	return nil
+}
+
+func (m *mockCounter) Read() (map[string]network.Bytes, error) {
+	counters := make(map[string]network.Bytes)
+	for ip, b := range m.counters {
+		counters[ip] = b
+	}
+	return counters, nil
+}
+
+type mockFirewall struct {
+	rules map[[2]abstraction.Inet]string
+}
+
+func (m *mockFirewall) endpoints() *firewall.Endpoints {
+	return &firewall.Endpoints{
+		InitBridgeEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			return firewall.InitBridgeResponse{}, nil
+		},
+		AllowConnectionEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(firewall.AllowConnectionRequest)
+			m.rules[[2]abstraction.Inet{req.SrcIP, req.DstIP}] = req.DstNetwork
+			return firewall.AllowConnectionResponse{}, nil
+		},
+		BlockConnectionEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(firewall.BlockConnectionRequest)
+			delete(m.rules, [2]abstraction.Inet{req.SrcIP, req.DstIP})
+			return firewall.BlockConnectionResponse{}, nil
+		},
+	}
+}
+
+func newMockFirewall() *mockFirewall {
+	return &mockFirewall{
+		rules: make(map[[2]abstraction.Inet]string),
+	}
+}
+
 var _ = XDescribe("Network", func() {
 })
+
+var _ = Describe("Bridges", func() {
+	Describe("Pool", func() {
+		It("Should parse a pool", func() {
+			p, err := network.NewPool("10.0.0.0/16", 24)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(p.Network.String()).To(Equal("10.0.0.0/16"))
+			Expect(p.Prefix).To(Equal(24))
+		})
+
+		It("Should reject invalid pools", func() {
+			_, err := network.NewPool("10.0.0.0", 24)
+			Ω(err).Should(HaveOccurred())
+
+			_, err = network.NewPool("fd00::/64", 120)
+			Ω(err).Should(HaveOccurred())
+
+			_, err = network.NewPool("10.0.0.0/16", 8)
+			Ω(err).Should(HaveOccurred())
+
+			_, err = network.NewPool("10.0.0.0/16", 31)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Create Bridge", func() {
+		It("Should allocate a unique subnet per user", func() {
+			b := newMockBridges()
+			p, _ := network.NewPool("10.0.0.0/16", 24)
+			s, err := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), nil, p, b, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = s.CreateBridge(1)
+			Ω(err).ShouldNot(HaveOccurred())
+			err = s.CreateBridge(2)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Expect(b.bridges).To(Equal(map[string]string{
+				network.BridgeName(1): "10.0.0.1",
+				network.BridgeName(2): "10.0.1.1",
+			}))
+
+			bridge, err := s.Bridge(2)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(bridge.Subnet).To(BeEquivalentTo("10.0.1.0/24"))
+
+			_, err = s.Bridge(3)
+			Ω(err).Should(Equal(network.ErrBridgeNotExist))
+		})
+
+		It("Should not create a second bridge for a user", func() {
+			p, _ := network.NewPool("10.0.0.0/16", 24)
+			s, _ := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), nil, p, newMockBridges(), nil)
+			s.CreateBridge(1)
+			err := s.CreateBridge(1)
+			Ω(err).Should(Equal(network.ErrNetworkAlreadyExists))
+		})
+
+		It("Should return an error if the pool is exhausted", func() {
+			p, _ := network.NewPool("10.0.0.0/24", 25)
+			s, _ := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), nil, p, newMockBridges(), nil)
+			Ω(s.CreateBridge(1)).ShouldNot(HaveOccurred())
+			Ω(s.CreateBridge(2)).ShouldNot(HaveOccurred())
+			Ω(s.CreateBridge(3)).Should(Equal(network.ErrPoolExhausted))
+		})
+
+		It("Should return an error without a pool", func() {
+			s, _ := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), nil, nil, newMockBridges(), nil)
+			Ω(s.CreateBridge(1)).Should(Equal(network.ErrNoPool))
+		})
+
+		It("Should not store the bridge if it can not be created", func() {
+			b := newMockBridges()
+			b.err = errors.New("bridge")
+			p, _ := network.NewPool("10.0.0.0/16", 24)
+			s, _ := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), nil, p, b, nil)
+			Ω(s.CreateBridge(1)).Should(Equal(b.err))
+			Ω(s.RemoveBridge(1)).Should(Equal(network.ErrBridgeNotExist))
+		})
+	})
+
+	Describe("Assign IP", func() {
+		b := newMockBridges()
+		p, _ := network.NewPool("10.0.0.0/16", 29)
+		s, _ := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), nil, p, b, nil)
+
+		It("Should create the bridge and skip the gateway", func() {
+			ip, err := s.AssignIP(1, "a")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ip).To(BeEquivalentTo("10.0.0.2/29"))
+			Expect(b.bridges).To(HaveKey(network.BridgeName(1)))
+		})
+
+		It("Should return the same address for a container", func() {
+			ip, err := s.AssignIP(1, "a")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ip).To(BeEquivalentTo("10.0.0.2/29"))
+		})
+
+		It("Should assign addresses up to the broadcast address", func() {
+			for i, c := range []string{"b", "c", "d", "e"} {
+				ip, err := s.AssignIP(1, c)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(ip.IP().To4()[3]).To(BeEquivalentTo(3 + i))
+			}
+
+			_, err := s.AssignIP(1, "f")
+			Ω(err).Should(Equal(network.ErrPoolExhausted))
+		})
+
+		It("Should reuse released addresses", func() {
+			err := s.ReleaseIP(1, "c")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			ip, err := s.AssignIP(1, "f")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ip).To(BeEquivalentTo("10.0.0.4/29"))
+		})
+
+		It("Should return an error if a container has no address", func() {
+			err := s.ReleaseIP(1, "c")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should release everything when the bridge is removed", func() {
+			err := s.RemoveBridge(1)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(b.bridges).To(BeEmpty())
+
+			ip, err := s.AssignIP(2, "a")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ip).To(BeEquivalentTo("10.0.0.2/29"))
+		})
+	})
+
+	Describe("Peering", func() {
+		b := newMockBridges()
+		fw := newMockFirewall()
+		p, _ := network.NewPool("10.0.0.0/16", 24)
+		s, _ := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), fw.endpoints(), p, b, nil)
+		var id uint
+
+		It("Should not peer containers of the same user", func() {
+			_, err := s.RequestPeering(1, "a", 1, "b")
+			Ω(err).Should(Equal(network.ErrPeeringSelf))
+		})
+
+		It("Should request a peering", func() {
+			var err error
+			id, err = s.RequestPeering(1, "a", 2, "b")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(id).ToNot(BeZero())
+
+			_, err = s.RequestPeering(2, "b", 1, "a")
+			Ω(err).Should(Equal(network.ErrPeeringAlreadyExists))
+		})
+
+		It("Should not connect anything before the peer approved", func() {
+			ps, err := s.Peerings(2)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ps).To(HaveLen(1))
+			Expect(ps[0].Approved).To(BeFalse())
+			Expect(fw.rules).To(BeEmpty())
+		})
+
+		It("Should only let the peer approve", func() {
+			Ω(s.ApprovePeering(1, id)).Should(Equal(network.ErrNotPeer))
+			Ω(s.ApprovePeering(3, id)).Should(Equal(network.ErrPeeringNotExist))
+		})
+
+		It("Should connect both containers in the network of the peer", func() {
+			err := s.ApprovePeering(2, id)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			ps, _ := s.Peerings(1)
+			Expect(ps).To(HaveLen(1))
+			Expect(ps[0].Approved).To(BeTrue())
+			Expect(ps[0].PeerAddress).To(BeEquivalentTo("10.0.0.2/24"))
+			Expect(ps[0].Address).To(BeEquivalentTo("10.0.0.3/24"))
+			Expect(b.bridges).To(HaveKey(network.BridgeName(2)))
+			Expect(fw.rules).To(Equal(map[[2]abstraction.Inet]string{
+				{"10.0.0.3/24", "10.0.0.2/24"}: network.BridgeName(2),
+				{"10.0.0.2/24", "10.0.0.3/24"}: network.BridgeName(2),
+			}))
+		})
+
+		It("Should tear everything down when a peering is revoked", func() {
+			err := s.RevokePeering(1, id)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(fw.rules).To(BeEmpty())
+
+			ps, _ := s.Peerings(2)
+			Expect(ps).To(BeEmpty())
+
+			ip, _ := s.AssignIP(2, "c")
+			Expect(ip).To(BeEquivalentTo("10.0.0.3/24"))
+		})
+
+		It("Should revoke peerings when a bridge is removed", func() {
+			id, _ = s.RequestPeering(2, "b", 3, "d")
+			Ω(s.ApprovePeering(3, id)).ShouldNot(HaveOccurred())
+			Expect(fw.rules).To(HaveLen(2))
+
+			err := s.RemoveBridge(2)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(fw.rules).To(BeEmpty())
+
+			ps, _ := s.Peerings(3)
+			Expect(ps).To(BeEmpty())
+		})
+	})
+
+	Describe("Overlay", func() {
+		db := testutils.NewMockDB()
+		p, _ := network.NewPool("10.0.0.0/16", 24)
+		ba, bb := newMockBridges(), newMockBridges()
+		oa, ob := newMockOverlay(), newMockOverlay()
+		sa, _ := network.NewService(abstraction.NewDCLI(), db, nil, p, ba, &network.Overlay{
+			Node:    "a",
+			Address: net.ParseIP("192.0.2.1"),
+			Driver:  oa,
+		})
+		sb, _ := network.NewService(abstraction.NewDCLI(), db, nil, p, bb, &network.Overlay{
+			Node:    "b",
+			Address: net.ParseIP("192.0.2.2"),
+			Driver:  ob,
+		})
+		vni := network.VNI(1)
+
+		It("Should register the local node", func() {
+			Ω(sa.SyncOverlay()).ShouldNot(HaveOccurred())
+			Ω(sb.SyncOverlay()).ShouldNot(HaveOccurred())
+
+			nodes, err := sa.Nodes()
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(nodes).To(ConsistOf(
+				network.Node{Name: "a", Address: "192.0.2.1"},
+				network.Node{Name: "b", Address: "192.0.2.2"},
+			))
+		})
+
+		It("Should attach a new bridge to the overlay", func() {
+			ip, err := sa.AssignIP(1, "x")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ip).To(BeEquivalentTo("10.0.0.2/24"))
+			Expect(oa.attached).To(Equal(map[uint32]string{vni: network.BridgeName(1)}))
+			Expect(oa.peers[vni]).To(BeEmpty())
+		})
+
+		It("Should join the bridge on another node", func() {
+			ip, err := sb.AssignIP(1, "y")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ip).To(BeEquivalentTo("10.0.0.3/24"))
+			Expect(bb.bridges).To(Equal(map[string]string{network.BridgeName(1): "10.0.0.1"}))
+			Expect(ob.peers[vni]).To(Equal([]string{"192.0.2.1"}))
+		})
+
+		It("Should update the peers of existing members", func() {
+			Ω(sa.SyncOverlay()).ShouldNot(HaveOccurred())
+			Expect(oa.peers[vni]).To(Equal([]string{"192.0.2.2"}))
+		})
+
+		It("Should not send traffic to removed nodes", func() {
+			Ω(sa.RemoveNode("b")).ShouldNot(HaveOccurred())
+			Ω(sa.RemoveNode("b")).Should(Equal(network.ErrNodeNotExist))
+			Ω(sa.SyncOverlay()).ShouldNot(HaveOccurred())
+			Expect(oa.peers[vni]).To(BeEmpty())
+			Ω(sb.SyncOverlay()).ShouldNot(HaveOccurred())
+		})
+
+		It("Should remove the bridge on every node", func() {
+			Ω(sa.RemoveBridge(1)).ShouldNot(HaveOccurred())
+			Expect(oa.attached).To(BeEmpty())
+			Expect(ba.bridges).To(BeEmpty())
+
+			Ω(sb.SyncOverlay()).ShouldNot(HaveOccurred())
+			Expect(ob.attached).To(BeEmpty())
+			Expect(bb.bridges).To(BeEmpty())
+		})
+
+		It("Should return an error without an overlay", func() {
+			s, _ := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), nil, p, newMockBridges(), nil)
+			Ω(s.SyncOverlay()).Should(Equal(network.ErrNoOverlay))
+		})
+	})
+
+	Describe("Metering", func() {
+		p, _ := network.NewPool("10.0.0.0/16", 24)
+		s, _ := network.NewService(abstraction.NewDCLI(), testutils.NewMockDB(), nil, p, newMockBridges(), nil)
+		c := &mockCounter{
+			counters: make(map[string]network.Bytes),
+		}
+		m := network.NewMeter(s, c, log.NewNopLogger())
+		start := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+		It("Should track assigned addresses", func() {
+			s.AssignIP(1, "a")
+			s.AssignIP(1, "b")
+			m.Collect(start)
+			Expect(c.counters).To(HaveLen(2))
+		})
+
+		It("Should record the traffic since the last sample", func() {
+			m.Collect(start.Add(10 * time.Second))
+			c.counters["10.0.0.2"] = network.Bytes{In: 100, Out: 10}
+			m.Collect(start.Add(20 * time.Second))
+			c.counters["10.0.0.2"] = network.Bytes{In: 150, Out: 30}
+			m.Collect(start.Add(30 * time.Second))
+
+			us, err := s.Usage(1, start, start.Add(time.Hour))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(us).To(Equal([]network.Usage{
+				{RefID: 1, ContainerID: "a", Period: start, BytesIn: 150, BytesOut: 30},
+			}))
+		})
+
+		It("Should treat counters which went backwards as reset", func() {
+			c.counters["10.0.0.2"] = network.Bytes{In: 20, Out: 5}
+			m.Collect(start.Add(time.Minute))
+
+			total, err := s.TotalUsage(1, start, start.Add(time.Hour))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(total).To(Equal(network.Bytes{In: 170, Out: 35}))
+		})
+
+		It("Should stop tracking released addresses", func() {
+			s.ReleaseIP(1, "b")
+			m.Collect(start.Add(2 * time.Minute))
+			Expect(c.counters).To(HaveLen(1))
+			Expect(c.counters).To(HaveKey("10.0.0.2"))
+		})
+
+		It("Should only return usage of the period", func() {
+			us, err := s.Usage(1, start.Add(time.Minute), start.Add(time.Hour))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(us).To(HaveLen(1))
+			Expect(us[0].Period).To(Equal(start.Add(time.Minute)))
+
+			us, err = s.Usage(2, start, start.Add(time.Hour))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(us).To(BeEmpty())
+
+			_, err = s.Usage(1, start.Add(time.Hour), start)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+})
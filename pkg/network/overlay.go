@@ -0,0 +1,446 @@
+package network
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// VNIBase is added to the id of a user to get the VXLAN network identifier of their overlay
+const VNIBase = 4096
+
+// VXLANPort is the UDP port VXLAN traffic is exchanged on, it has to be reachable between all nodes
+const VXLANPort = 4789
+
+var (
+	// ErrNodeNotExist occurs when a node is not registered
+	ErrNodeNotExist = errors.New("Node does not exist")
+
+	// ErrNoOverlay occurs when nodes are managed without an overlay configured
+	ErrNoOverlay = errors.New("No overlay configured")
+)
+
+// VNI returns the VXLAN network identifier of a user's overlay
+func VNI(refid uint) uint32 {
+	return uint32(VNIBase + refid)
+}
+
+// OverlayDriver attaches VXLAN interfaces to the bridges of users, so a bridge which
+// exists on several nodes forms a single layer 2 network
+type OverlayDriver interface {
+	// Attach creates the VXLAN interface vni sending from local and adds it to bridge
+	Attach(bridge string, vni uint32, local net.IP) error
+
+	// Detach removes the VXLAN interface vni
+	Detach(vni uint32) error
+
+	// SetPeers replaces the nodes unknown traffic of vni is flooded to
+	SetPeers(vni uint32, remotes []net.IP) error
+}
+
+// Overlay configures the node a network service runs on, bridges of a user are connected
+// with every other node hosting containers of the same user. Since addresses are assigned
+// from the shared database, the bridges on all nodes share the subnet and the gateway address.
+type Overlay struct {
+	// Node is the unique name of this node
+	Node string
+
+	// Address is the address other nodes reach this node on
+	Address net.IP
+
+	Driver OverlayDriver
+}
+
+type ipOverlay struct {
+	ipBridge
+}
+
+func vxlanName(vni uint32) string {
+	return fmt.Sprintf("vxlan%d", vni)
+}
+
+func (o ipOverlay) bridge(args ...string) ([]byte, error) {
+	out, err := exec.Command("bridge", args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("bridge %v: %s", args, out)
+	}
+	return out, nil
+}
+
+func (o ipOverlay) Attach(bridge string, vni uint32, local net.IP) error {
+	name := vxlanName(vni)
+
+	err := o.run("link", "add", name, "type", "vxlan", "id", fmt.Sprint(vni), "local", local.String(), "dstport", fmt.Sprint(VXLANPort))
+	if err != nil {
+		return err
+	}
+
+	err = o.run("link", "set", name, "master", bridge)
+	if err != nil {
+		o.Detach(vni)
+		return err
+	}
+
+	err = o.run("link", "set", name, "up")
+	if err != nil {
+		o.Detach(vni)
+		return err
+	}
+	return nil
+}
+
+func (o ipOverlay) Detach(vni uint32) error {
+	return o.run("link", "delete", vxlanName(vni))
+}
+
+// peers returns the remotes of the flooding entries of a VXLAN interface
+func (o ipOverlay) peers(name string) ([]string, error) {
+	out, err := o.bridge("fdb", "show", "dev", name)
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) >= 3 && f[0] == "00:00:00:00:00:00" && f[1] == "dst" {
+			remotes = append(remotes, f[2])
+		}
+	}
+	return remotes, nil
+}
+
+func (o ipOverlay) SetPeers(vni uint32, remotes []net.IP) error {
+	name := vxlanName(vni)
+
+	current, err := o.peers(name)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool)
+	for _, r := range remotes {
+		wanted[r.String()] = true
+	}
+
+	for _, r := range current {
+		if wanted[r] {
+			delete(wanted, r)
+			continue
+		}
+
+		_, err = o.bridge("fdb", "del", "00:00:00:00:00:00", "dev", name, "dst", r)
+		if err != nil {
+			return err
+		}
+	}
+
+	for r := range wanted {
+		_, err = o.bridge("fdb", "append", "00:00:00:00:00:00", "dev", name, "dst", r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewIPOverlayDriver returns an OverlayDriver using iproute2
+func NewIPOverlayDriver() OverlayDriver {
+	return ipOverlay{}
+}
+
+// getMembers returns the nodes hosting the bridge of a user
+func (s *service) getMembers(refid uint) ([]OverlayMember, error) {
+	ms := []OverlayMember{}
+	err := s.db.Find(&ms, "ref_id = ?", refid)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []OverlayMember{}
+	for _, m := range ms {
+		if m.RefID == refid {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+// isMember reports whether the bridge of a user exists on this node
+func (s *service) isMember(refid uint) (bool, error) {
+	ms, err := s.getMembers(refid)
+	if err != nil {
+		return false, err
+	}
+
+	for _, m := range ms {
+		if m.Node == s.overlay.Node {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// join creates the local bridge of a user whose bridge already exists on other nodes
+func (s *service) join(b Bridge) error {
+	_, n, err := b.Subnet.ParseCIDR()
+	if err != nil {
+		return err
+	}
+
+	err = s.bridges.Create(b.Name, n, b.Gateway.IP())
+	if err != nil {
+		return err
+	}
+
+	err = s.initBridge(b)
+	if err != nil {
+		s.bridges.Remove(b.Name)
+		return err
+	}
+
+	return s.attach(b)
+}
+
+// attach connects the local bridge of a user to the overlay and registers this node as member
+func (s *service) attach(b Bridge) error {
+	err := s.overlay.Driver.Attach(b.Name, VNI(b.RefID), s.overlay.Address)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Create(&OverlayMember{
+		RefID: b.RefID,
+		Node:  s.overlay.Node,
+	})
+	if err != nil {
+		s.overlay.Driver.Detach(VNI(b.RefID))
+		return err
+	}
+
+	return s.setPeers(b.RefID)
+}
+
+// leave detaches the local bridge of a user from their overlay
+func (s *service) leave(refid uint) error {
+	ms, err := s.getMembers(refid)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range ms {
+		if m.Node == s.overlay.Node {
+			return s.detach(m)
+		}
+	}
+	return nil
+}
+
+// detach removes the local bridge of a user from the overlay
+func (s *service) detach(m OverlayMember) error {
+	err := s.overlay.Driver.Detach(VNI(m.RefID))
+	if err != nil {
+		return err
+	}
+
+	return s.db.Delete(&OverlayMember{ID: m.ID})
+}
+
+// setPeers floods the traffic of a user's overlay to every other node hosting their bridge
+func (s *service) setPeers(refid uint) error {
+	ms, err := s.getMembers(refid)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := s.getNodes()
+	if err != nil {
+		return err
+	}
+
+	addresses := make(map[string]net.IP)
+	for _, n := range nodes {
+		addresses[n.Name] = n.Address.IP()
+	}
+
+	remotes := []net.IP{}
+	for _, m := range ms {
+		ip, ok := addresses[m.Node]
+		if m.Node == s.overlay.Node || !ok {
+			continue
+		}
+		remotes = append(remotes, ip)
+	}
+
+	return s.overlay.Driver.SetPeers(VNI(refid), remotes)
+}
+
+func (s *service) getNodes() ([]Node, error) {
+	nodes := []Node{}
+	err := s.db.Find(&nodes)
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+func (s *service) RegisterNode(name string, address abstraction.Inet) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.registerNode(name, address)
+}
+
+func (s *service) registerNode(name string, address abstraction.Inet) error {
+	if address.IP() == nil {
+		return errors.New("Not a valid IP Address")
+	}
+
+	nodes, err := s.getNodes()
+	if err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		if n.Name != name {
+			continue
+		}
+		if n.Address == address {
+			return nil
+		}
+
+		s.db.Begin()
+		err = s.db.Where("name = ?", name)
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+
+		err = s.db.Update(&Node{}, &Node{Address: address})
+		if err != nil {
+			s.db.Rollback()
+			return err
+		}
+		s.db.Commit()
+		return nil
+	}
+
+	return s.db.Create(&Node{
+		Name:    name,
+		Address: address,
+	})
+}
+
+func (s *service) RemoveNode(name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeNode(name)
+}
+
+func (s *service) removeNode(name string) error {
+	nodes, err := s.getNodes()
+	if err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		if n.Name == name {
+			return s.db.Delete(&Node{Name: name})
+		}
+	}
+	return ErrNodeNotExist
+}
+
+func (s *service) Nodes() ([]Node, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.getNodes()
+}
+
+func (s *service) SyncOverlay() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.syncOverlay()
+}
+
+// syncOverlay updates the peers of every local overlay and removes local bridges whose user
+// bridge was removed on another node
+func (s *service) syncOverlay() error {
+	if s.overlay == nil {
+		return ErrNoOverlay
+	}
+
+	err := s.registerNode(s.overlay.Node, abstraction.NewInetFromIP(s.overlay.Address, nil))
+	if err != nil {
+		return err
+	}
+
+	ms := []OverlayMember{}
+	err = s.db.Find(&ms, "node = ?", s.overlay.Node)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range ms {
+		if m.Node != s.overlay.Node {
+			continue
+		}
+
+		b, err := s.getBridge(m.RefID)
+		if err == ErrBridgeNotExist {
+			err = s.detach(m)
+			if err == nil {
+				err = s.bridges.Remove(BridgeName(m.RefID))
+			}
+		} else if err == nil {
+			err = s.setPeers(b.RefID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OverlaySync keeps the overlays of a node up to date with the bridges on other nodes
+type OverlaySync struct {
+	s      Service
+	logger log.Logger
+}
+
+// Run calls SyncOverlay every interval until stop is closed
+func (o *OverlaySync) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		err := o.s.SyncOverlay()
+		if err != nil {
+			level.Error(o.logger).Log("overlay", "sync", "err", err)
+		}
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewOverlaySync returns an OverlaySync for the overlays of s
+func NewOverlaySync(s Service, logger log.Logger) *OverlaySync {
+	return &OverlaySync{
+		s:      s,
+		logger: logger,
+	}
+}
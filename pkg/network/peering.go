@@ -0,0 +1,280 @@
+package network
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+)
+
+var (
+	// ErrPeeringNotExist occurs when a peering does not exist or does not involve the user
+	ErrPeeringNotExist = errors.New("Peering does not exist")
+
+	// ErrPeeringAlreadyExists occurs when two containers are already peered
+	ErrPeeringAlreadyExists = errors.New("Peering already exists")
+
+	// ErrPeeringSelf occurs when a user tries to peer with themself
+	ErrPeeringSelf = errors.New("Containers of the same user do not need a peering")
+
+	// ErrNotPeer occurs when the requesting user tries to approve their own peering
+	ErrNotPeer = errors.New("Only the peer can approve a peering")
+)
+
+// getPeering returns a peering which involves the user refid
+func (s *service) getPeering(refid uint, id uint) (Peering, error) {
+	p := Peering{}
+	err := s.db.Where("id = ?", id)
+	if err != nil {
+		return p, err
+	}
+
+	err = s.db.First(&p, "id = ?", id)
+	if err != nil || p.ID != id || !p.involves(refid) {
+		return Peering{}, ErrPeeringNotExist
+	}
+	return p, nil
+}
+
+// getPeerings returns every peering which involves the user refid
+func (s *service) getPeerings(refid uint) ([]Peering, error) {
+	ps := []Peering{}
+	err := s.db.Find(&ps, "ref_id = ? OR peer_ref_id = ?", refid, refid)
+	if err != nil {
+		return nil, err
+	}
+
+	peerings := []Peering{}
+	for _, p := range ps {
+		if p.involves(refid) {
+			peerings = append(peerings, p)
+		}
+	}
+	return peerings, nil
+}
+
+// connections returns the firewall rules of a peering, both containers may reach
+// each other inside of the bridge network of the peer
+func (s *service) connections(p Peering) [][2]abstraction.Inet {
+	return [][2]abstraction.Inet{
+		{p.Address, p.PeerAddress},
+		{p.PeerAddress, p.Address},
+	}
+}
+
+// link connects the container of the requesting user to the bridge network of the peer
+// and allows the traffic between both containers
+func (s *service) link(p *Peering) error {
+	peer, err := s.getBridge(p.PeerRefID)
+	if err == ErrBridgeNotExist {
+		peer, err = s.createBridge(p.PeerRefID)
+	}
+	if err != nil {
+		return err
+	}
+
+	p.PeerAddress, err = s.assignIP(p.PeerRefID, p.PeerContainerID)
+	if err != nil {
+		return err
+	}
+
+	p.Address, err = s.assignIP(p.PeerRefID, p.ContainerID)
+	if err != nil {
+		return err
+	}
+
+	err = s.dcli.NetworkConnect()
+	if err != nil {
+		s.releaseIP(p.PeerRefID, p.ContainerID)
+		return err
+	}
+
+	if s.fwClient == nil {
+		return nil
+	}
+
+	for _, c := range s.connections(*p) {
+		var res interface{}
+		res, err = s.fwClient.AllowConnectionEndpoint(context.Background(), firewall.AllowConnectionRequest{
+			SrcIP:      c[0],
+			SrcNetwork: peer.Name,
+			DstIP:      c[1],
+			DstNetwork: peer.Name,
+		})
+		if err == nil {
+			err = res.(firewall.AllowConnectionResponse).Error
+		}
+		if err != nil {
+			s.unlink(*p)
+			return err
+		}
+	}
+	return nil
+}
+
+// unlink removes the firewall rules of a peering and disconnects the container of the
+// requesting user from the bridge network of the peer, every step is tried even if
+// a previous one failed so that nothing is left behind
+func (s *service) unlink(p Peering) error {
+	var result error
+
+	peer, err := s.getBridge(p.PeerRefID)
+	if err != nil {
+		return err
+	}
+
+	if s.fwClient != nil {
+		for _, c := range s.connections(p) {
+			res, err := s.fwClient.BlockConnectionEndpoint(context.Background(), firewall.BlockConnectionRequest{
+				SrcIP:      c[0],
+				SrcNetwork: peer.Name,
+				DstIP:      c[1],
+				DstNetwork: peer.Name,
+			})
+			if err == nil {
+				err = res.(firewall.BlockConnectionResponse).Error
+			}
+			if err != nil && result == nil {
+				result = err
+			}
+		}
+	}
+
+	err = s.dcli.NetworkDisconnect()
+	if err != nil && result == nil {
+		result = err
+	}
+
+	err = s.releaseIP(p.PeerRefID, p.ContainerID)
+	if err != nil && result == nil {
+		result = err
+	}
+
+	return result
+}
+
+func (s *service) RequestPeering(refid uint, containerID string, peerRefID uint, peerContainerID string) (uint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.requestPeering(refid, containerID, peerRefID, peerContainerID)
+}
+
+func (s *service) requestPeering(refid uint, containerID string, peerRefID uint, peerContainerID string) (uint, error) {
+	if refid == peerRefID {
+		return 0, ErrPeeringSelf
+	}
+
+	if s.pool == nil {
+		return 0, ErrNoPool
+	}
+
+	ps, err := s.getPeerings(refid)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, p := range ps {
+		if p.connects(containerID, peerContainerID) {
+			return 0, ErrPeeringAlreadyExists
+		}
+	}
+
+	p := &Peering{
+		RefID:           refid,
+		ContainerID:     containerID,
+		PeerRefID:       peerRefID,
+		PeerContainerID: peerContainerID,
+	}
+
+	err = s.db.Create(p)
+	if err != nil {
+		return 0, err
+	}
+	return p.ID, nil
+}
+
+func (s *service) ApprovePeering(refid uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.approvePeering(refid, id)
+}
+
+func (s *service) approvePeering(refid uint, id uint) error {
+	p, err := s.getPeering(refid, id)
+	if err != nil {
+		return err
+	}
+
+	if p.PeerRefID != refid {
+		return ErrNotPeer
+	}
+
+	if p.Approved {
+		return nil
+	}
+
+	err = s.link(&p)
+	if err != nil {
+		return err
+	}
+
+	s.db.Begin()
+	err = s.db.Where("id = ?", id)
+	if err != nil {
+		s.db.Rollback()
+		s.unlink(p)
+		return err
+	}
+
+	err = s.db.Update(&Peering{}, &Peering{
+		Approved:    true,
+		Address:     p.Address,
+		PeerAddress: p.PeerAddress,
+	})
+	if err != nil {
+		s.db.Rollback()
+		s.unlink(p)
+		return err
+	}
+	s.db.Commit()
+
+	return nil
+}
+
+func (s *service) RevokePeering(refid uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.revokePeering(refid, id)
+}
+
+func (s *service) revokePeering(refid uint, id uint) error {
+	p, err := s.getPeering(refid, id)
+	if err != nil {
+		return err
+	}
+
+	return s.removePeering(p)
+}
+
+// removePeering tears down an approved peering and deletes it
+func (s *service) removePeering(p Peering) error {
+	if p.Approved {
+		err := s.unlink(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.Delete(&Peering{ID: p.ID})
+}
+
+func (s *service) Peerings(refid uint) ([]Peering, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.getPeerings(refid)
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
@@ -17,6 +18,12 @@ var (
 
 	// ErrNetworkAlreadyExists occurs when a network already exists
 	ErrNetworkAlreadyExists = errors.New("Network already exists")
+
+	// ErrBridgeNotExist occurs when a user has no bridge network
+	ErrBridgeNotExist = errors.New("Bridge does not exist")
+
+	// ErrNoPool occurs when bridges are used without an address pool
+	ErrNoPool = errors.New("No address pool configured")
 )
 
 // Service NetworkService
@@ -41,6 +48,57 @@ type Service interface {
 
 	// RemovePortFromContainer removes an exposed port from a container
 	RemovePortFromContainer(refid uint, srcContainerID string, port uint16, protocol string, destContainerID string) error
+
+	// CreateBridge allocates a subnet for a user and creates their bridge network
+	CreateBridge(refid uint) error
+
+	// Bridge returns the bridge network of a user
+	Bridge(refid uint) (Bridge, error)
+
+	// RemoveBridge revokes the peerings of a user, removes their bridge network and releases its subnet and addresses
+	RemoveBridge(refid uint) error
+
+	// AssignIP assigns a free address of a user's bridge network to a container, the bridge is created if necessary
+	AssignIP(refid uint, containerID string) (abstraction.Inet, error)
+
+	// ReleaseIP releases the address assigned to a container
+	ReleaseIP(refid uint, containerID string) error
+
+	// RequestPeering asks another user to peer one of their containers with a container of refid
+	RequestPeering(refid uint, containerID string, peerRefID uint, peerContainerID string) (uint, error)
+
+	// ApprovePeering approves a peering requested by another user and connects both containers
+	ApprovePeering(refid uint, id uint) error
+
+	// RevokePeering disconnects the containers of a peering and removes it, both users can revoke a peering
+	RevokePeering(refid uint, id uint) error
+
+	// Peerings returns every peering requested by or from a user
+	Peerings(refid uint) ([]Peering, error)
+
+	// RegisterNode adds a node or updates its address
+	RegisterNode(name string, address abstraction.Inet) error
+
+	// RemoveNode removes a node, no overlay traffic is sent to it anymore
+	RemoveNode(name string) error
+
+	// Nodes returns every registered node
+	Nodes() ([]Node, error)
+
+	// SyncOverlay registers the local node and updates the overlays of the bridges on it
+	SyncOverlay() error
+
+	// Assignments returns every assigned address
+	Assignments() ([]Assignment, error)
+
+	// RecordUsage stores the traffic of a container during a minute, it is merged with already stored traffic of that minute
+	RecordUsage(u *Usage) error
+
+	// Usage returns the traffic of the containers of a user between from and to
+	Usage(refid uint, from time.Time, to time.Time) ([]Usage, error)
+
+	// TotalUsage returns the sum of the traffic of a user between from and to
+	TotalUsage(refid uint, from time.Time, to time.Time) (Bytes, error)
 }
 
 type dbAdapter interface {
@@ -51,12 +109,16 @@ type dbAdapter interface {
 	Find(interface{}, ...interface{}) error
 	Create(interface{}) error
 	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
 }
 
 type service struct {
 	db       dbAdapter
 	dcli     abstraction.DCli
 	fwClient *firewall.Endpoints
+	pool     *Pool
+	bridges  BridgeDriver
+	overlay  *Overlay
 	logger   log.Logger
 	mtx      *sync.Mutex
 }
@@ -69,7 +131,7 @@ func (s *service) InitializeDatabases() error {
 }
 
 func (s *service) initializeDatabases() error {
-	return s.db.AutoMigrate(&Networks{}, &Containers{})
+	return s.db.AutoMigrate(&Networks{}, &Containers{}, &Bridge{}, &Assignment{}, &Peering{}, &Node{}, &OverlayMember{}, &Usage{})
 }
 
 func (s *service) getNetworkByName(refid uint, name string) (Networks, error) {
@@ -473,12 +535,16 @@ func (s *service) removePortFromContainer(refid uint, srcContainerID string, por
 	return nil
 }
 
-// NewService creates a new network service
-func NewService(dcli abstraction.DCli, db dbAdapter, fw *firewall.Endpoints) (Service, error) {
+// NewService creates a new network service, user bridges get their subnets from pool and are created using b.
+// If o is not nil the bridges of a user are connected across all nodes hosting their containers.
+func NewService(dcli abstraction.DCli, db dbAdapter, fw *firewall.Endpoints, pool *Pool, b BridgeDriver, o *Overlay) (Service, error) {
 	s := &service{
 		dcli:     dcli,
 		db:       db,
 		fwClient: fw,
+		pool:     pool,
+		bridges:  b,
+		overlay:  o,
 		mtx:      &sync.Mutex{},
 	}
 
@@ -0,0 +1,58 @@
+package network
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Throttler limits the bandwidth of the bridge networks of users
+type Throttler interface {
+	// Throttle limits the traffic of the bridge network of a user to rate in the format of tc, e.g. 1mbit
+	Throttle(refid uint, rate string) error
+
+	// Unthrottle removes the limit of the bridge network of a user, it succeeds if it is not throttled
+	Unthrottle(refid uint) error
+}
+
+// tcThrottler shapes the traffic the host sends into the bridge of a user with a token bucket
+// and polices the traffic the containers send, both directions are limited to the same rate
+type tcThrottler struct{}
+
+func (tcThrottler) run(args ...string) error {
+	out, err := exec.Command("tc", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("tc %v: %s", args, out)
+	}
+	return nil
+}
+
+func (t tcThrottler) Throttle(refid uint, rate string) error {
+	dev := BridgeName(refid)
+
+	err := t.run("qdisc", "replace", "dev", dev, "root", "tbf", "rate", rate, "burst", "64kb", "latency", "400ms")
+	if err != nil {
+		return err
+	}
+
+	err = t.run("qdisc", "replace", "dev", dev, "handle", "ffff:", "ingress")
+	if err != nil {
+		return err
+	}
+
+	return t.run("filter", "replace", "dev", dev, "parent", "ffff:", "protocol", "all", "prio", "1",
+		"u32", "match", "u32", "0", "0", "police", "rate", rate, "burst", "64kb", "drop", "flowid", ":1")
+}
+
+func (t tcThrottler) Unthrottle(refid uint) error {
+	dev := BridgeName(refid)
+
+	// deleting a qdisc removes its filters, tc fails for qdiscs which do not exist
+	t.run("qdisc", "del", "dev", dev, "root")
+	t.run("qdisc", "del", "dev", dev, "ingress")
+	return nil
+}
+
+// NewTCThrottler returns a Throttler using tc of iproute2
+func NewTCThrottler() Throttler {
+	return tcThrottler{}
+}
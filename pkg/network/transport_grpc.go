@@ -2,10 +2,13 @@ package network
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 	"github.com/kontainerooo/kontainer.ooo/pkg/network/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
 	oldcontext "golang.org/x/net/context"
 )
 
@@ -57,6 +60,97 @@ func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger)
 			EncodeGRPCRemovePortFromContainerResponse,
 			options...,
 		),
+
+		createbridge: grpctransport.NewServer(
+			endpoints.CreateBridgeEndpoint,
+			DecodeGRPCCreateBridgeRequest,
+			EncodeGRPCCreateBridgeResponse,
+			options...,
+		),
+
+		removebridge: grpctransport.NewServer(
+			endpoints.RemoveBridgeEndpoint,
+			DecodeGRPCRemoveBridgeRequest,
+			EncodeGRPCRemoveBridgeResponse,
+			options...,
+		),
+
+		assignip: grpctransport.NewServer(
+			endpoints.AssignIPEndpoint,
+			DecodeGRPCAssignIPRequest,
+			EncodeGRPCAssignIPResponse,
+			options...,
+		),
+
+		releaseip: grpctransport.NewServer(
+			endpoints.ReleaseIPEndpoint,
+			DecodeGRPCReleaseIPRequest,
+			EncodeGRPCReleaseIPResponse,
+			options...,
+		),
+
+		requestpeering: grpctransport.NewServer(
+			endpoints.RequestPeeringEndpoint,
+			DecodeGRPCRequestPeeringRequest,
+			EncodeGRPCRequestPeeringResponse,
+			options...,
+		),
+
+		approvepeering: grpctransport.NewServer(
+			endpoints.ApprovePeeringEndpoint,
+			DecodeGRPCApprovePeeringRequest,
+			EncodeGRPCApprovePeeringResponse,
+			options...,
+		),
+
+		revokepeering: grpctransport.NewServer(
+			endpoints.RevokePeeringEndpoint,
+			DecodeGRPCRevokePeeringRequest,
+			EncodeGRPCRevokePeeringResponse,
+			options...,
+		),
+
+		peerings: grpctransport.NewServer(
+			endpoints.PeeringsEndpoint,
+			DecodeGRPCPeeringsRequest,
+			EncodeGRPCPeeringsResponse,
+			options...,
+		),
+
+		registernode: grpctransport.NewServer(
+			endpoints.RegisterNodeEndpoint,
+			DecodeGRPCRegisterNodeRequest,
+			EncodeGRPCRegisterNodeResponse,
+			options...,
+		),
+
+		removenode: grpctransport.NewServer(
+			endpoints.RemoveNodeEndpoint,
+			DecodeGRPCRemoveNodeRequest,
+			EncodeGRPCRemoveNodeResponse,
+			options...,
+		),
+
+		nodes: grpctransport.NewServer(
+			endpoints.NodesEndpoint,
+			DecodeGRPCNodesRequest,
+			EncodeGRPCNodesResponse,
+			options...,
+		),
+
+		usage: grpctransport.NewServer(
+			endpoints.UsageEndpoint,
+			DecodeGRPCUsageRequest,
+			EncodeGRPCUsageResponse,
+			options...,
+		),
+
+		totalusage: grpctransport.NewServer(
+			endpoints.TotalUsageEndpoint,
+			DecodeGRPCTotalUsageRequest,
+			EncodeGRPCTotalUsageResponse,
+			options...,
+		),
 	}
 }
 
@@ -68,6 +162,19 @@ type grpcServer struct {
 	removecontainerfromnetwork       grpctransport.Handler
 	exposeporttocontainer            grpctransport.Handler
 	removeportfromcontainer          grpctransport.Handler
+	createbridge                     grpctransport.Handler
+	removebridge                     grpctransport.Handler
+	assignip                         grpctransport.Handler
+	releaseip                        grpctransport.Handler
+	requestpeering                   grpctransport.Handler
+	approvepeering                   grpctransport.Handler
+	revokepeering                    grpctransport.Handler
+	peerings                         grpctransport.Handler
+	registernode                     grpctransport.Handler
+	removenode                       grpctransport.Handler
+	nodes                            grpctransport.Handler
+	usage                            grpctransport.Handler
+	totalusage                       grpctransport.Handler
 }
 
 func (s *grpcServer) CreatePrimaryNetworkForContainer(ctx oldcontext.Context, req *pb.CreatePrimaryNetworkForContainerRequest) (*pb.CreatePrimaryNetworkForContainerResponse, error) {
@@ -126,6 +233,110 @@ func (s *grpcServer) RemovePortFromContainer(ctx oldcontext.Context, req *pb.Rem
 	return res.(*pb.RemovePortFromContainerResponse), nil
 }
 
+func (s *grpcServer) CreateBridge(ctx oldcontext.Context, req *pb.CreateBridgeRequest) (*pb.CreateBridgeResponse, error) {
+	_, res, err := s.createbridge.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateBridgeResponse), nil
+}
+
+func (s *grpcServer) RemoveBridge(ctx oldcontext.Context, req *pb.RemoveBridgeRequest) (*pb.RemoveBridgeResponse, error) {
+	_, res, err := s.removebridge.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveBridgeResponse), nil
+}
+
+func (s *grpcServer) AssignIP(ctx oldcontext.Context, req *pb.AssignIPRequest) (*pb.AssignIPResponse, error) {
+	_, res, err := s.assignip.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.AssignIPResponse), nil
+}
+
+func (s *grpcServer) ReleaseIP(ctx oldcontext.Context, req *pb.ReleaseIPRequest) (*pb.ReleaseIPResponse, error) {
+	_, res, err := s.releaseip.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ReleaseIPResponse), nil
+}
+
+func (s *grpcServer) RequestPeering(ctx oldcontext.Context, req *pb.RequestPeeringRequest) (*pb.RequestPeeringResponse, error) {
+	_, res, err := s.requestpeering.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RequestPeeringResponse), nil
+}
+
+func (s *grpcServer) ApprovePeering(ctx oldcontext.Context, req *pb.ApprovePeeringRequest) (*pb.ApprovePeeringResponse, error) {
+	_, res, err := s.approvepeering.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ApprovePeeringResponse), nil
+}
+
+func (s *grpcServer) RevokePeering(ctx oldcontext.Context, req *pb.RevokePeeringRequest) (*pb.RevokePeeringResponse, error) {
+	_, res, err := s.revokepeering.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RevokePeeringResponse), nil
+}
+
+func (s *grpcServer) Peerings(ctx oldcontext.Context, req *pb.PeeringsRequest) (*pb.PeeringsResponse, error) {
+	_, res, err := s.peerings.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.PeeringsResponse), nil
+}
+
+func (s *grpcServer) RegisterNode(ctx oldcontext.Context, req *pb.RegisterNodeRequest) (*pb.RegisterNodeResponse, error) {
+	_, res, err := s.registernode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RegisterNodeResponse), nil
+}
+
+func (s *grpcServer) RemoveNode(ctx oldcontext.Context, req *pb.RemoveNodeRequest) (*pb.RemoveNodeResponse, error) {
+	_, res, err := s.removenode.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveNodeResponse), nil
+}
+
+func (s *grpcServer) Nodes(ctx oldcontext.Context, req *pb.NodesRequest) (*pb.NodesResponse, error) {
+	_, res, err := s.nodes.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.NodesResponse), nil
+}
+
+func (s *grpcServer) Usage(ctx oldcontext.Context, req *pb.UsageRequest) (*pb.UsageResponse, error) {
+	_, res, err := s.usage.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.UsageResponse), nil
+}
+
+func (s *grpcServer) TotalUsage(ctx oldcontext.Context, req *pb.TotalUsageRequest) (*pb.TotalUsageResponse, error) {
+	_, res, err := s.totalusage.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TotalUsageResponse), nil
+}
+
 func nwConfigToPBConfig(c Config) *pb.NetworkConfig {
 	return &pb.NetworkConfig{
 		Driver: c.Driver,
@@ -295,3 +506,336 @@ func EncodeGRPCRemovePortFromContainerResponse(_ context.Context, response inter
 	}
 	return gRPCRes, nil
 }
+
+// DecodeGRPCCreateBridgeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateBridge request to a messages/network.proto-domain createbridge request.
+func DecodeGRPCCreateBridgeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateBridgeRequest)
+	return CreateBridgeRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCCreateBridgeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain createbridge response to a gRPC CreateBridge response.
+func EncodeGRPCCreateBridgeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateBridgeResponse)
+	gRPCRes := &pb.CreateBridgeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveBridgeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveBridge request to a messages/network.proto-domain removebridge request.
+func DecodeGRPCRemoveBridgeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveBridgeRequest)
+	return RemoveBridgeRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCRemoveBridgeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain removebridge response to a gRPC RemoveBridge response.
+func EncodeGRPCRemoveBridgeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveBridgeResponse)
+	gRPCRes := &pb.RemoveBridgeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCAssignIPRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC AssignIP request to a messages/network.proto-domain assignip request.
+func DecodeGRPCAssignIPRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AssignIPRequest)
+	return AssignIPRequest{
+		RefID:       uint(req.RefID),
+		ContainerID: req.ContainerID,
+	}, nil
+}
+
+// EncodeGRPCAssignIPResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain assignip response to a gRPC AssignIP response.
+func EncodeGRPCAssignIPResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(AssignIPResponse)
+	gRPCRes := &pb.AssignIPResponse{
+		IP: string(res.IP),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCReleaseIPRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ReleaseIP request to a messages/network.proto-domain releaseip request.
+func DecodeGRPCReleaseIPRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ReleaseIPRequest)
+	return ReleaseIPRequest{
+		RefID:       uint(req.RefID),
+		ContainerID: req.ContainerID,
+	}, nil
+}
+
+// EncodeGRPCReleaseIPResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain releaseip response to a gRPC ReleaseIP response.
+func EncodeGRPCReleaseIPResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ReleaseIPResponse)
+	gRPCRes := &pb.ReleaseIPResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRequestPeeringRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RequestPeering request to a messages/network.proto-domain requestpeering request.
+func DecodeGRPCRequestPeeringRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RequestPeeringRequest)
+	return RequestPeeringRequest{
+		RefID:           uint(req.RefID),
+		ContainerID:     req.ContainerID,
+		PeerRefID:       uint(req.PeerRefID),
+		PeerContainerID: req.PeerContainerID,
+	}, nil
+}
+
+// EncodeGRPCRequestPeeringResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain requestpeering response to a gRPC RequestPeering response.
+func EncodeGRPCRequestPeeringResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RequestPeeringResponse)
+	gRPCRes := &pb.RequestPeeringResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCApprovePeeringRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ApprovePeering request to a messages/network.proto-domain approvepeering request.
+func DecodeGRPCApprovePeeringRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ApprovePeeringRequest)
+	return ApprovePeeringRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCApprovePeeringResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain approvepeering response to a gRPC ApprovePeering response.
+func EncodeGRPCApprovePeeringResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ApprovePeeringResponse)
+	gRPCRes := &pb.ApprovePeeringResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRevokePeeringRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RevokePeering request to a messages/network.proto-domain revokepeering request.
+func DecodeGRPCRevokePeeringRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RevokePeeringRequest)
+	return RevokePeeringRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRevokePeeringResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain revokepeering response to a gRPC RevokePeering response.
+func EncodeGRPCRevokePeeringResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RevokePeeringResponse)
+	gRPCRes := &pb.RevokePeeringResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCPeeringsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Peerings request to a messages/network.proto-domain peerings request.
+func DecodeGRPCPeeringsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PeeringsRequest)
+	return PeeringsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCPeeringsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain peerings response to a gRPC Peerings response.
+func EncodeGRPCPeeringsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(PeeringsResponse)
+	gRPCRes := &pb.PeeringsResponse{
+		Peerings: make([]*pb.Peering, len(res.Peerings)),
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	for i, p := range res.Peerings {
+		gRPCRes.Peerings[i] = ConvertPeering(&p)
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// ConvertPeering converts a Peering to its protobuf representation
+func ConvertPeering(p *Peering) *pb.Peering {
+	return &pb.Peering{
+		ID:              uint32(p.ID),
+		RefID:           uint32(p.RefID),
+		ContainerID:     p.ContainerID,
+		PeerRefID:       uint32(p.PeerRefID),
+		PeerContainerID: p.PeerContainerID,
+		Approved:        p.Approved,
+		Address:         string(p.Address),
+		PeerAddress:     string(p.PeerAddress),
+	}
+}
+
+// ConvertPBPeering converts a protobuf Peering to a Peering
+func ConvertPBPeering(p *pb.Peering) Peering {
+	if p == nil {
+		return Peering{}
+	}
+
+	return Peering{
+		ID:              uint(p.ID),
+		RefID:           uint(p.RefID),
+		ContainerID:     p.ContainerID,
+		PeerRefID:       uint(p.PeerRefID),
+		PeerContainerID: p.PeerContainerID,
+		Approved:        p.Approved,
+		Address:         abstraction.Inet(p.Address),
+		PeerAddress:     abstraction.Inet(p.PeerAddress),
+	}
+}
+
+// DecodeGRPCRegisterNodeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RegisterNode request to a messages/network.proto-domain registernode request.
+func DecodeGRPCRegisterNodeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RegisterNodeRequest)
+	return RegisterNodeRequest{
+		Name:    req.Name,
+		Address: abstraction.Inet(req.Address),
+	}, nil
+}
+
+// EncodeGRPCRegisterNodeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain registernode response to a gRPC RegisterNode response.
+func EncodeGRPCRegisterNodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RegisterNodeResponse)
+	gRPCRes := &pb.RegisterNodeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveNodeRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveNode request to a messages/network.proto-domain removenode request.
+func DecodeGRPCRemoveNodeRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveNodeRequest)
+	return RemoveNodeRequest{
+		Name: req.Name,
+	}, nil
+}
+
+// EncodeGRPCRemoveNodeResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain removenode response to a gRPC RemoveNode response.
+func EncodeGRPCRemoveNodeResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveNodeResponse)
+	gRPCRes := &pb.RemoveNodeResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCNodesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Nodes request to a messages/network.proto-domain nodes request.
+func DecodeGRPCNodesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	return NodesRequest{}, nil
+}
+
+// EncodeGRPCNodesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain nodes response to a gRPC Nodes response.
+func EncodeGRPCNodesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(NodesResponse)
+	gRPCRes := &pb.NodesResponse{
+		Nodes: make([]*pb.Node, len(res.Nodes)),
+	}
+	for i, n := range res.Nodes {
+		gRPCRes.Nodes[i] = &pb.Node{
+			Name:    n.Name,
+			Address: string(n.Address),
+		}
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCUsageRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Usage request to a messages/network.proto-domain usage request.
+func DecodeGRPCUsageRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.UsageRequest)
+	return UsageRequest{
+		RefID: uint(req.RefID),
+		From:  time.Unix(req.From, 0),
+		To:    time.Unix(req.To, 0),
+	}, nil
+}
+
+// EncodeGRPCUsageResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain usage response to a gRPC Usage response.
+func EncodeGRPCUsageResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(UsageResponse)
+	gRPCRes := &pb.UsageResponse{
+		Usage: make([]*pb.Usage, len(res.Usage)),
+	}
+	for i, u := range res.Usage {
+		gRPCRes.Usage[i] = &pb.Usage{
+			ContainerID: u.ContainerID,
+			Period:      u.Period.Unix(),
+			BytesIn:     u.BytesIn,
+			BytesOut:    u.BytesOut,
+		}
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCTotalUsageRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC TotalUsage request to a messages/network.proto-domain totalusage request.
+func DecodeGRPCTotalUsageRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.TotalUsageRequest)
+	return TotalUsageRequest{
+		RefID: uint(req.RefID),
+		From:  time.Unix(req.From, 0),
+		To:    time.Unix(req.To, 0),
+	}, nil
+}
+
+// EncodeGRPCTotalUsageResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/network.proto-domain totalusage response to a gRPC TotalUsage response.
+func EncodeGRPCTotalUsageResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(TotalUsageResponse)
+	gRPCRes := &pb.TotalUsageResponse{
+		BytesIn:  res.Total.In,
+		BytesOut: res.Total.Out,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
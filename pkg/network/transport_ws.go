@@ -3,8 +3,8 @@ package network
 import (
 	"context"
 
-	"github.com/golang/protobuf/proto"
 	"github.com/kontainerooo/kontainer.ooo/pkg/network/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
 )
 
@@ -75,7 +75,7 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 // WS CreatePrimaryNetworkForContainer request to a messages/network.proto-domain createprimarynetworkforcontainer request.
 func DecodeWSCreatePrimaryNetworkForContainerRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.CreatePrimaryNetworkForContainerRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +87,7 @@ func DecodeWSCreatePrimaryNetworkForContainerRequest(ctx context.Context, data i
 // WS CreateNetwork request to a messages/network.proto-domain createnetwork request.
 func DecodeWSCreateNetworkRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.CreateNetworkRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -99,7 +99,7 @@ func DecodeWSCreateNetworkRequest(ctx context.Context, data interface{}) (interf
 // WS RemoveNetworkByName request to a messages/network.proto-domain removenetworkbyname request.
 func DecodeWSRemoveNetworkByNameRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveNetworkByNameRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -111,7 +111,7 @@ func DecodeWSRemoveNetworkByNameRequest(ctx context.Context, data interface{}) (
 // WS AddContainerToNetwork request to a messages/network.proto-domain addcontainertonetwork request.
 func DecodeWSAddContainerToNetworkRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.AddContainerToNetworkRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +123,7 @@ func DecodeWSAddContainerToNetworkRequest(ctx context.Context, data interface{})
 // WS RemoveContainerFromNetwork request to a messages/network.proto-domain removecontainerfromnetwork request.
 func DecodeWSRemoveContainerFromNetworkRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveContainerFromNetworkRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +135,7 @@ func DecodeWSRemoveContainerFromNetworkRequest(ctx context.Context, data interfa
 // WS ExposePortToContainer request to a messages/network.proto-domain exposeporttocontainer request.
 func DecodeWSExposePortToContainerRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.ExposePortToContainerRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +147,7 @@ func DecodeWSExposePortToContainerRequest(ctx context.Context, data interface{})
 // WS RemovePortFromContainer request to a messages/network.proto-domain removeportfromcontainer request.
 func DecodeWSRemovePortFromContainerRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemovePortFromContainerRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
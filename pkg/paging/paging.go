@@ -0,0 +1,321 @@
+// Package paging filters, sorts and pages the results of list endpoints. Pages are continued with
+// an opaque cursor holding the position of the last returned item, so a page does not skip or
+// repeat items if items before it were added or removed in the meantime.
+package paging
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/validation"
+)
+
+// MaxLimit is the maximum number of items of a page, it is used if a request has no limit
+const MaxLimit = 1000
+
+// ErrInvalidList occurs if the items are not a pointer to a slice of structs
+var ErrInvalidList = errors.New("items have to be a pointer to a slice of structs")
+
+// ErrInvalidCursor occurs if a cursor is malformed or was returned for a different sort order
+var ErrInvalidCursor = validation.Errors{{Field: "Page.Cursor", Message: "is not a valid cursor"}}
+
+// Request selects a page of a list
+type Request struct {
+	// Limit is the number of items of the page, at most MaxLimit
+	Limit uint
+
+	// Cursor is the NextCursor of the previous page, the first page is returned if it is empty
+	Cursor string
+
+	// Sort is the field the items are sorted by, e.g. name or kmdi.name, prefixed with - to sort in
+	// descending order. Items with the same value are sorted by their key.
+	Sort string
+
+	// Filter are the fields and the values the items have to have, e.g. running=true
+	Filter map[string]string
+}
+
+// Response describes the returned page
+type Response struct {
+	// NextCursor selects the next page, it is empty on the last one
+	NextCursor string
+
+	// Total is the number of items matching the filter
+	Total uint
+}
+
+// unknownField returns the error for a field to sort or filter by which does not exist
+func unknownField(setting, name string) error {
+	return validation.Errors{{Field: setting, Message: fmt.Sprintf("has the unknown field %s", name)}}
+}
+
+// cursor is the position of the last item of a page
+type cursor struct {
+	Sort  string          `json:"s"`
+	Value json.RawMessage `json:"v"`
+	Key   json.RawMessage `json:"k"`
+}
+
+// normalize makes names of fields comparable, so Go, camel and snake case names match
+func normalize(name string) string {
+	return strings.ToLower(strings.Replace(name, "_", "", -1))
+}
+
+// indirect returns the type t points to
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldPath returns the indices and the type of the field name in t, name may contain dots to
+// select nested fields
+func fieldPath(t reflect.Type, name string) ([]int, reflect.Type, bool) {
+	path := []int{}
+	for _, part := range strings.Split(name, ".") {
+		t = indirect(t)
+		if t.Kind() != reflect.Struct {
+			return nil, nil, false
+		}
+
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath == "" && normalize(f.Name) == normalize(part) {
+				path, t, found = append(path, i), f.Type, true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, false
+		}
+	}
+	return path, indirect(t), true
+}
+
+// field returns the field at path of the item v, it is invalid if a pointer on the way is nil
+func field(v reflect.Value, path []int) reflect.Value {
+	for _, i := range path {
+		v = reflect.Indirect(v)
+		if !v.IsValid() {
+			return v
+		}
+		v = v.Field(i)
+	}
+	return reflect.Indirect(v)
+}
+
+// compare returns -1, 0 or 1 if a is less than, equal to or greater than b
+func compare(a, b reflect.Value) int {
+	if !a.IsValid() || !b.IsValid() {
+		switch {
+		case a.IsValid():
+			return 1
+		case b.IsValid():
+			return -1
+		}
+		return 0
+	}
+
+	less, greater := false, false
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less, greater = a.Int() < b.Int(), a.Int() > b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		less, greater = a.Uint() < b.Uint(), a.Uint() > b.Uint()
+	case reflect.Float32, reflect.Float64:
+		less, greater = a.Float() < b.Float(), a.Float() > b.Float()
+	case reflect.Bool:
+		less, greater = !a.Bool() && b.Bool(), a.Bool() && !b.Bool()
+	case reflect.String:
+		less, greater = a.String() < b.String(), a.String() > b.String()
+	default:
+		if ta, ok := a.Interface().(time.Time); ok {
+			tb := b.Interface().(time.Time)
+			less, greater = ta.Before(tb), ta.After(tb)
+		} else {
+			sa, sb := fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface())
+			less, greater = sa < sb, sa > sb
+		}
+	}
+
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+// matches reports whether the value of a field is the filter value
+func matches(v reflect.Value, value string) bool {
+	if !v.IsValid() {
+		return value == ""
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.Format(time.RFC3339) == value
+	}
+	return strings.EqualFold(fmt.Sprint(v.Interface()), value)
+}
+
+// decodeCursor returns the sort value and the key of the item a cursor points to
+func decodeCursor(s, sortBy string, sortType, keyType reflect.Type) (reflect.Value, reflect.Value, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, ErrInvalidCursor
+	}
+
+	c := cursor{}
+	err = json.Unmarshal(raw, &c)
+	if err != nil || c.Sort != sortBy {
+		return reflect.Value{}, reflect.Value{}, ErrInvalidCursor
+	}
+
+	value, err := decodeValue(c.Value, sortType)
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, ErrInvalidCursor
+	}
+	key, err := decodeValue(c.Key, keyType)
+	if err != nil {
+		return reflect.Value{}, reflect.Value{}, ErrInvalidCursor
+	}
+	return value, key, nil
+}
+
+// decodeValue decodes a value of a cursor, null is the value of a field behind a nil pointer
+func decodeValue(raw json.RawMessage, t reflect.Type) (reflect.Value, error) {
+	if string(raw) == "null" {
+		return reflect.Value{}, nil
+	}
+
+	v := reflect.New(t)
+	err := json.Unmarshal(raw, v.Interface())
+	return v.Elem(), err
+}
+
+// encodeValue encodes a value of a cursor
+func encodeValue(v reflect.Value) json.RawMessage {
+	if !v.IsValid() {
+		return json.RawMessage("null")
+	}
+	raw, _ := json.Marshal(v.Interface())
+	return raw
+}
+
+// encodeCursor returns a cursor pointing to the item with the sort value and key
+func encodeCursor(sortBy string, value, key reflect.Value) string {
+	raw, _ := json.Marshal(cursor{
+		Sort:  sortBy,
+		Value: encodeValue(value),
+		Key:   encodeValue(key),
+	})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Apply replaces the items, a pointer to a slice of structs or pointers to structs, with the page
+// r selects. key is the field which identifies an item, it is used to sort items with the same
+// sort value and as default sort order. Invalid requests are rejected with validation.Errors for
+// the Page field of the request.
+func Apply(items interface{}, key string, r Request) (Response, error) {
+	list := reflect.ValueOf(items)
+	if list.Kind() != reflect.Ptr || list.Elem().Kind() != reflect.Slice {
+		return Response{}, ErrInvalidList
+	}
+	list = list.Elem()
+	elem := list.Type().Elem()
+
+	keyPath, keyType, ok := fieldPath(elem, key)
+	if !ok {
+		return Response{}, fmt.Errorf("unknown key %s", key)
+	}
+
+	sortBy, desc := strings.TrimPrefix(r.Sort, "-"), strings.HasPrefix(r.Sort, "-")
+	if sortBy == "" {
+		sortBy = key
+	}
+	sortPath, sortType, ok := fieldPath(elem, sortBy)
+	if !ok {
+		return Response{}, unknownField("Page.Sort", sortBy)
+	}
+
+	filters := make(map[string][]int)
+	for f := range r.Filter {
+		path, _, ok := fieldPath(elem, f)
+		if !ok {
+			return Response{}, unknownField("Page.Filter", f)
+		}
+		filters[f] = path
+	}
+
+	filtered := reflect.MakeSlice(list.Type(), 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		item, match := list.Index(i), true
+		for f, path := range filters {
+			if !matches(field(item, path), r.Filter[f]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			filtered = reflect.Append(filtered, item)
+		}
+	}
+
+	// order compares the sort values and keys of two items with respect to the sort direction
+	order := func(av, ak, bv, bk reflect.Value) int {
+		c := compare(av, bv)
+		if c == 0 {
+			c = compare(ak, bk)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}
+
+	sort.SliceStable(filtered.Interface(), func(i, j int) bool {
+		a, b := filtered.Index(i), filtered.Index(j)
+		return order(field(a, sortPath), field(a, keyPath), field(b, sortPath), field(b, keyPath)) < 0
+	})
+
+	start := 0
+	if r.Cursor != "" {
+		value, k, err := decodeCursor(r.Cursor, r.Sort, sortType, keyType)
+		if err != nil {
+			return Response{}, err
+		}
+
+		start = sort.Search(filtered.Len(), func(i int) bool {
+			item := filtered.Index(i)
+			return order(field(item, sortPath), field(item, keyPath), value, k) > 0
+		})
+	}
+
+	limit := int(r.Limit)
+	if limit == 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+	end := start + limit
+	if end > filtered.Len() {
+		end = filtered.Len()
+	}
+
+	res := Response{
+		Total: uint(filtered.Len()),
+	}
+	if end < filtered.Len() {
+		last := filtered.Index(end - 1)
+		res.NextCursor = encodeCursor(r.Sort, field(last, sortPath), field(last, keyPath))
+	}
+
+	list.Set(filtered.Slice(start, end))
+	return res, nil
+}
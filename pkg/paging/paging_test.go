@@ -0,0 +1,177 @@
+package paging_test
+
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging/pb"
+	"github.com/kontainerooo/kontainer.ooo/pkg/validation"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type image struct {
+	Name string
+}
+
+type item struct {
+	ID      uint
+	Name    string
+	Running bool
+	Created time.Time
+	Image   *image
+}
+
+func ids(items []item) []uint {
+	res := []uint{}
+	for _, i := range items {
+		res = append(res, i.ID)
+	}
+	return res
+}
+
+var _ = Describe("Paging", func() {
+	var items []item
+	created := time.Date(2017, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	BeforeEach(func() {
+		items = []item{
+			{ID: 3, Name: "b", Running: true, Created: created, Image: &image{Name: "nginx"}},
+			{ID: 1, Name: "c", Running: false, Created: created.Add(time.Hour)},
+			{ID: 4, Name: "a", Running: true, Created: created.Add(2 * time.Hour), Image: &image{Name: "redis"}},
+			{ID: 2, Name: "b", Running: false, Created: created.Add(3 * time.Hour), Image: &image{Name: "nginx"}},
+		}
+	})
+
+	Describe("Apply", func() {
+		It("Should sort by the key by default", func() {
+			res, err := paging.Apply(&items, "ID", paging.Request{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ids(items)).To(Equal([]uint{1, 2, 3, 4}))
+			Expect(res).To(Equal(paging.Response{Total: 4}))
+		})
+
+		It("Should sort by a field and then by the key", func() {
+			_, err := paging.Apply(&items, "ID", paging.Request{Sort: "name"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ids(items)).To(Equal([]uint{4, 2, 3, 1}))
+		})
+
+		It("Should sort in descending order", func() {
+			_, err := paging.Apply(&items, "ID", paging.Request{Sort: "-created"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ids(items)).To(Equal([]uint{2, 4, 1, 3}))
+		})
+
+		It("Should sort by fields of nested structs", func() {
+			_, err := paging.Apply(&items, "ID", paging.Request{Sort: "image.name"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ids(items)).To(Equal([]uint{1, 2, 3, 4}))
+		})
+
+		It("Should filter", func() {
+			res, err := paging.Apply(&items, "ID", paging.Request{
+				Filter: map[string]string{
+					"running":    "true",
+					"image.name": "NGINX",
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ids(items)).To(Equal([]uint{3}))
+			Expect(res.Total).To(BeEquivalentTo(1))
+		})
+
+		It("Should filter by time", func() {
+			_, err := paging.Apply(&items, "ID", paging.Request{
+				Filter: map[string]string{
+					"Created": created.Format(time.RFC3339),
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ids(items)).To(Equal([]uint{3}))
+		})
+
+		It("Should continue at the cursor", func() {
+			all := items
+			req := paging.Request{Limit: 2, Sort: "-name"}
+			res, err := paging.Apply(&items, "ID", req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ids(items)).To(Equal([]uint{1, 3}))
+			Expect(res.NextCursor).ToNot(BeEmpty())
+			Expect(res.Total).To(BeEquivalentTo(4))
+
+			// an item inserted before the cursor does not move the next page
+			items = append(all, item{ID: 5, Name: "d"})
+			req.Cursor = res.NextCursor
+			res, err = paging.Apply(&items, "ID", req)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ids(items)).To(Equal([]uint{2, 4}))
+			Expect(res.NextCursor).To(BeEmpty())
+		})
+
+		It("Should limit pages to MaxLimit", func() {
+			many := make([]item, paging.MaxLimit+1)
+			for i := range many {
+				many[i].ID = uint(i)
+			}
+
+			res, err := paging.Apply(&many, "ID", paging.Request{Limit: paging.MaxLimit + 1})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(many).To(HaveLen(paging.MaxLimit))
+			Expect(res.NextCursor).ToNot(BeEmpty())
+		})
+
+		It("Should accept slices of pointers", func() {
+			ptrs := []*item{&items[0], &items[1]}
+			_, err := paging.Apply(&ptrs, "ID", paging.Request{})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(ptrs[0].ID).To(BeEquivalentTo(1))
+		})
+
+		It("Should reject unknown fields", func() {
+			_, err := paging.Apply(&items, "ID", paging.Request{Sort: "size"})
+			Expect(err).To(Equal(validation.Errors{{Field: "Page.Sort", Message: "has the unknown field size"}}))
+
+			_, err = paging.Apply(&items, "ID", paging.Request{Filter: map[string]string{"size": "1"}})
+			Expect(err).To(Equal(validation.Errors{{Field: "Page.Filter", Message: "has the unknown field size"}}))
+		})
+
+		It("Should reject invalid cursors", func() {
+			_, err := paging.Apply(&items, "ID", paging.Request{Cursor: "invalid"})
+			Expect(err).To(Equal(paging.ErrInvalidCursor))
+
+			res, err := paging.Apply(&items, "ID", paging.Request{Limit: 1})
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = paging.Apply(&items, "ID", paging.Request{Sort: "name", Cursor: res.NextCursor})
+			Expect(err).To(Equal(paging.ErrInvalidCursor))
+		})
+
+		It("Should reject items which are no slice", func() {
+			_, err := paging.Apply(items, "ID", paging.Request{})
+			Expect(err).To(Equal(paging.ErrInvalidList))
+		})
+	})
+
+	Describe("Protobuf", func() {
+		It("Should convert pages", func() {
+			r := paging.Request{
+				Limit:  10,
+				Cursor: "cursor",
+				Sort:   "-name",
+				Filter: map[string]string{"running": "true"},
+			}
+			Expect(paging.DecodePage(paging.EncodePage(r))).To(Equal(r))
+			Expect(paging.DecodePage(nil)).To(Equal(paging.Request{}))
+		})
+
+		It("Should convert page infos", func() {
+			r := paging.Response{
+				NextCursor: "cursor",
+				Total:      3,
+			}
+			Expect(paging.DecodePageInfo(paging.EncodePageInfo(r))).To(Equal(r))
+			Expect(paging.DecodePageInfo(&pb.PageInfo{})).To(Equal(paging.Response{}))
+		})
+	})
+})
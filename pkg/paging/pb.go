@@ -0,0 +1,45 @@
+package paging
+
+import "github.com/kontainerooo/kontainer.ooo/pkg/paging/pb"
+
+// DecodePage converts the page of a gRPC request, nil selects the first page
+func DecodePage(p *pb.Page) Request {
+	if p == nil {
+		return Request{}
+	}
+	return Request{
+		Limit:  uint(p.Limit),
+		Cursor: p.Cursor,
+		Sort:   p.Sort,
+		Filter: p.Filter,
+	}
+}
+
+// EncodePage converts a Request for a gRPC request
+func EncodePage(r Request) *pb.Page {
+	return &pb.Page{
+		Limit:  uint32(r.Limit),
+		Cursor: r.Cursor,
+		Sort:   r.Sort,
+		Filter: r.Filter,
+	}
+}
+
+// EncodePageInfo converts a Response for a gRPC response
+func EncodePageInfo(r Response) *pb.PageInfo {
+	return &pb.PageInfo{
+		NextCursor: r.NextCursor,
+		Total:      uint32(r.Total),
+	}
+}
+
+// DecodePageInfo converts the page info of a gRPC response
+func DecodePageInfo(p *pb.PageInfo) Response {
+	if p == nil {
+		return Response{}
+	}
+	return Response{
+		NextCursor: p.NextCursor,
+		Total:      uint(p.Total),
+	}
+}
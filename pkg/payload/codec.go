@@ -0,0 +1,33 @@
+package payload
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Codec is the protobuf codec of the gRPC server checking the requests before they are decoded,
+// it is meant to be used with grpc.CustomCodec
+type Codec struct{}
+
+// Marshal encodes v, which has to be a proto.Message
+func (Codec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is no proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal checks data and decodes it into v, which has to be a proto.Message
+func (Codec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("%T is no proto.Message", v)
+	}
+	return Unmarshal(data, m)
+}
+
+func (Codec) String() string {
+	return "proto"
+}
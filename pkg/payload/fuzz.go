@@ -0,0 +1,35 @@
+// +build gofuzz
+
+package payload
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// fuzzMessage has a field of every kind Check distinguishes
+type fuzzMessage struct {
+	Name   string            `protobuf:"bytes,1,opt,name=name,proto3"`
+	Child  *fuzzMessage      `protobuf:"bytes,2,opt,name=child,proto3"`
+	Tags   []string          `protobuf:"bytes,3,rep,name=tags,proto3"`
+	Ports  []uint32          `protobuf:"varint,4,rep,packed,name=ports,proto3"`
+	Labels map[string]string `protobuf:"bytes,5,rep,name=labels,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Data   []byte            `protobuf:"bytes,6,opt,name=data,proto3"`
+	Count  uint64            `protobuf:"fixed64,7,opt,name=count,proto3"`
+}
+
+func (m *fuzzMessage) Reset()         { *m = fuzzMessage{} }
+func (m *fuzzMessage) String() string { return proto.CompactTextString(m) }
+func (*fuzzMessage) ProtoMessage()    {}
+
+// Fuzz is the entry point of go-fuzz for Check, run it with
+// go-fuzz-build github.com/kontainerooo/kontainer.ooo/pkg/payload && go-fuzz. Messages passing Check
+// have to decode.
+func Fuzz(data []byte) int {
+	if Check(data, &fuzzMessage{}) != nil {
+		return 0
+	}
+	if err := proto.Unmarshal(data, &fuzzMessage{}); err != nil {
+		panic(err)
+	}
+	return 1
+}
@@ -0,0 +1,80 @@
+package payload
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Limits are the sizes in bytes requests may have. Methods are named like gRPC methods, e.g.
+// site.SiteService/Deploy, or like websocket endpoints, e.g. CNT/CRE.
+type Limits struct {
+	// Max is the size of the requests of methods without their own
+	Max     int
+	Methods map[string]int
+}
+
+// Limit returns the size requests to method may have, 0 does not limit them
+func (l Limits) Limit(method string) int {
+	if m, ok := l.Methods[method]; ok {
+		return m
+	}
+	return l.Max
+}
+
+// Largest returns the size of the largest request any method allows
+func (l Limits) Largest() int {
+	max := l.Max
+	for _, m := range l.Methods {
+		if m > max {
+			max = m
+		}
+	}
+	return max
+}
+
+// SizeError is returned for requests exceeding the size of their method
+type SizeError struct {
+	Method string
+	Size   int
+	Limit  int
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("request of %d bytes exceeds the limit of %d bytes of %s", e.Size, e.Limit, e.Method)
+}
+
+// Allow returns a *SizeError if a request to method may not have size bytes
+func (l Limits) Allow(method string, size int) error {
+	limit := l.Limit(method)
+	if limit > 0 && size > limit {
+		return &SizeError{
+			Method: method,
+			Size:   size,
+			Limit:  limit,
+		}
+	}
+	return nil
+}
+
+// UnaryServerInterceptor rejects the gRPC calls exceeding the size of their method with codes.InvalidArgument.
+// The server only receives messages up to the largest size, so the size of the other methods is checked
+// once the message is decoded.
+func UnaryServerInterceptor(l Limits) grpc.UnaryServerInterceptor {
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		m, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		err := l.Allow(strings.TrimPrefix(info.FullMethod, "/"), proto.Size(m))
+		if err != nil {
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		return handler(ctx, req)
+	}
+}
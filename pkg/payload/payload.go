@@ -0,0 +1,353 @@
+// Package payload guards the decoding of the requests. Requests are limited in size per gRPC method and
+// websocket endpoint, and protobuf messages are checked in their wire format before they are decoded:
+// messages may not be nested deeper than MaxDepth, fields which are not repeated may not be sent twice and
+// strings have to be valid UTF-8. The fields are known from the struct tags of the generated messages,
+// fields unknown to them are skipped.
+package payload
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// MaxDepth is how deep messages may be nested in a request
+const MaxDepth = 32
+
+// maxFieldNumber is the largest field number protobuf allows
+const maxFieldNumber = 1<<29 - 1
+
+// wire types of protobuf
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var (
+	// ErrMalformed occurs if a message is no valid protobuf wire format
+	ErrMalformed = errors.New("malformed message")
+
+	// ErrTooDeep occurs if messages are nested deeper than MaxDepth
+	ErrTooDeep = fmt.Errorf("messages nested deeper than %d levels", MaxDepth)
+
+	// ErrDuplicateField occurs if a field which is not repeated is sent more than once, or more than one
+	// field of a oneof is sent
+	ErrDuplicateField = errors.New("field sent more than once")
+
+	// ErrWireType occurs if a field is sent with the wire type of another type
+	ErrWireType = errors.New("field sent with the wrong wire type")
+
+	// ErrInvalidUTF8 occurs if a string is not valid UTF-8
+	ErrInvalidUTF8 = errors.New("string is not valid UTF-8")
+)
+
+// Error is a field of a message which was rejected
+type Error struct {
+	Field string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+type kind int
+
+const (
+	scalar kind = iota
+	text
+	message
+)
+
+type field struct {
+	name     string
+	wireType uint64
+	repeated bool
+	kind     kind
+	// oneof is the name of the oneof of the field, at most one of its fields may be sent
+	oneof string
+	msg   *descriptor
+}
+
+// descriptor are the fields of a message by their numbers
+type descriptor struct {
+	fields map[uint64]*field
+}
+
+var (
+	mtx         sync.Mutex
+	descriptors = make(map[reflect.Type]*descriptor)
+)
+
+// wireTypes are the wire types of the encodings in the struct tags of the generated messages
+var wireTypes = map[string]uint64{
+	"varint":   wireVarint,
+	"zigzag32": wireVarint,
+	"zigzag64": wireVarint,
+	"fixed64":  wireFixed64,
+	"fixed32":  wireFixed32,
+	"bytes":    wireBytes,
+}
+
+// newField returns the field described by the protobuf struct tag of a field of the Go type t, ok is false
+// if the tag can't be used, e.g. for groups
+func newField(tag string, t reflect.Type) (uint64, *field, bool) {
+	parts := strings.Split(tag, ",")
+	if len(parts) < 3 {
+		return 0, nil, false
+	}
+
+	wt, ok := wireTypes[parts[0]]
+	if !ok {
+		return 0, nil, false
+	}
+	num, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, nil, false
+	}
+
+	f := &field{
+		name:     parts[1],
+		wireType: wt,
+		repeated: parts[2] == "rep",
+	}
+	for _, p := range parts[3:] {
+		if strings.HasPrefix(p, "name=") {
+			f.name = strings.TrimPrefix(p, "name=")
+		}
+	}
+
+	if t.Kind() == reflect.Map {
+		f.kind = message
+		return num, f, true
+	}
+	if f.repeated && t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	switch {
+	case t.Kind() == reflect.String:
+		f.kind = text
+	case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct:
+		f.kind = message
+		f.msg = describe(t.Elem())
+	}
+	return num, f, true
+}
+
+// mapEntry returns the descriptor of the entries of the map field sf
+func mapEntry(sf reflect.StructField) *descriptor {
+	d := &descriptor{
+		fields: make(map[uint64]*field),
+	}
+	if num, f, ok := newField(sf.Tag.Get("protobuf_key"), sf.Type.Key()); ok {
+		d.fields[num] = f
+	}
+	if num, f, ok := newField(sf.Tag.Get("protobuf_val"), sf.Type.Elem()); ok {
+		d.fields[num] = f
+	}
+	return d
+}
+
+type oneofWrappers interface {
+	XXX_OneofWrappers() []interface{}
+}
+
+// describe returns the descriptor of the generated message struct t, mtx has to be held
+func describe(t reflect.Type) *descriptor {
+	if d, ok := descriptors[t]; ok {
+		return d
+	}
+
+	d := &descriptor{
+		fields: make(map[uint64]*field),
+	}
+	// messages may contain themselves
+	descriptors[t] = d
+
+	var wrappers []interface{}
+	if w, ok := reflect.New(t).Interface().(oneofWrappers); ok {
+		wrappers = w.XXX_OneofWrappers()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if group := sf.Tag.Get("protobuf_oneof"); group != "" {
+			for _, w := range wrappers {
+				wt := reflect.TypeOf(w)
+				if !wt.Implements(sf.Type) || wt.Elem().NumField() != 1 {
+					continue
+				}
+				wf := wt.Elem().Field(0)
+				if num, f, ok := newField(wf.Tag.Get("protobuf"), wf.Type); ok {
+					f.oneof = group
+					d.fields[num] = f
+				}
+			}
+			continue
+		}
+
+		num, f, ok := newField(sf.Tag.Get("protobuf"), sf.Type)
+		if !ok {
+			continue
+		}
+		if sf.Type.Kind() == reflect.Map {
+			f.msg = mapEntry(sf)
+		}
+		d.fields[num] = f
+	}
+	return d
+}
+
+// packed checks the packed values of a repeated scalar field
+func packed(b []byte, f *field) error {
+	switch f.wireType {
+	case wireVarint:
+		for len(b) > 0 {
+			_, n := binary.Uvarint(b)
+			if n <= 0 {
+				return ErrMalformed
+			}
+			b = b[n:]
+		}
+	case wireFixed64:
+		if len(b)%8 != 0 {
+			return ErrMalformed
+		}
+	case wireFixed32:
+		if len(b)%4 != 0 {
+			return ErrMalformed
+		}
+	default:
+		return ErrWireType
+	}
+	return nil
+}
+
+// check walks the fields of the message b described by d, which is nested depth levels deep
+func check(b []byte, d *descriptor, depth int, path string) error {
+	if depth > MaxDepth {
+		return &Error{Field: strings.TrimSuffix(path, "."), Err: ErrTooDeep}
+	}
+
+	seen := make(map[uint64]bool)
+	oneofs := make(map[string]uint64)
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return ErrMalformed
+		}
+		b = b[n:]
+
+		num, wt := key>>3, key&7
+		if num == 0 || num > maxFieldNumber {
+			return ErrMalformed
+		}
+
+		var value []byte
+		switch wt {
+		case wireVarint:
+			_, n = binary.Uvarint(b)
+			if n <= 0 {
+				return ErrMalformed
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return ErrMalformed
+			}
+			b = b[8:]
+		case wireFixed32:
+			if len(b) < 4 {
+				return ErrMalformed
+			}
+			b = b[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || l > uint64(len(b)-n) {
+				return ErrMalformed
+			}
+			value = b[n : n+int(l)]
+			b = b[n+int(l):]
+		default:
+			// groups are deprecated and not used by any message
+			return ErrMalformed
+		}
+
+		f, ok := d.fields[num]
+		if !ok {
+			continue
+		}
+		name := path + f.name
+
+		if wt != f.wireType {
+			if !f.repeated || f.kind != scalar || wt != wireBytes {
+				return &Error{Field: name, Err: ErrWireType}
+			}
+			if err := packed(value, f); err != nil {
+				return &Error{Field: name, Err: err}
+			}
+			continue
+		}
+
+		if !f.repeated {
+			if seen[num] {
+				return &Error{Field: name, Err: ErrDuplicateField}
+			}
+			seen[num] = true
+		}
+		if f.oneof != "" {
+			if other, ok := oneofs[f.oneof]; ok && other != num {
+				return &Error{Field: name, Err: ErrDuplicateField}
+			}
+			oneofs[f.oneof] = num
+		}
+
+		switch f.kind {
+		case text:
+			if !utf8.Valid(value) {
+				return &Error{Field: name, Err: ErrInvalidUTF8}
+			}
+		case message:
+			if f.msg == nil {
+				continue
+			}
+			err := check(value, f.msg, depth+1, name+".")
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Check checks the wire format b of the message m before it is decoded, m is only used to learn its fields
+func Check(b []byte, m proto.Message) error {
+	t := reflect.TypeOf(m)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%T is no generated message", m)
+	}
+
+	mtx.Lock()
+	d := describe(t.Elem())
+	mtx.Unlock()
+
+	return check(b, d, 1, "")
+}
+
+// Unmarshal decodes b into m once it passed Check
+func Unmarshal(b []byte, m proto.Message) error {
+	err := Check(b, m)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(b, m)
+}
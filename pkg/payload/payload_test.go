@@ -0,0 +1,244 @@
+package payload_test
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type Tree struct {
+	Name   string            `protobuf:"bytes,1,opt,name=name,proto3"`
+	Child  *Tree             `protobuf:"bytes,2,opt,name=child,proto3"`
+	Tags   []string          `protobuf:"bytes,3,rep,name=tags,proto3"`
+	Ports  []uint32          `protobuf:"varint,4,rep,packed,name=ports,proto3"`
+	Labels map[string]string `protobuf:"bytes,5,rep,name=labels,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Data   []byte            `protobuf:"bytes,6,opt,name=data,proto3"`
+	Count  uint32            `protobuf:"varint,7,opt,name=count,proto3"`
+	// Source is a oneof of Path and URL
+	Source isTreeSource `protobuf_oneof:"source"`
+}
+
+func (m *Tree) Reset()         { *m = Tree{} }
+func (m *Tree) String() string { return proto.CompactTextString(m) }
+func (*Tree) ProtoMessage()    {}
+
+func (*Tree) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*TreePath)(nil),
+		(*TreeURL)(nil),
+	}
+}
+
+type isTreeSource interface {
+	isTreeSource()
+}
+
+type TreePath struct {
+	Path string `protobuf:"bytes,8,opt,name=path,proto3,oneof"`
+}
+
+type TreeURL struct {
+	URL string `protobuf:"bytes,9,opt,name=url,proto3,oneof"`
+}
+
+func (*TreePath) isTreeSource() {}
+func (*TreeURL) isTreeSource()  {}
+
+func uvarint(v uint64) []byte {
+	b := make([]byte, binary.MaxVarintLen64)
+	return b[:binary.PutUvarint(b, v)]
+}
+
+func key(num, wt uint64) []byte {
+	return uvarint(num<<3 | wt)
+}
+
+func varint(num, v uint64) []byte {
+	return append(key(num, 0), uvarint(v)...)
+}
+
+func bytes(num uint64, parts ...[]byte) []byte {
+	b := []byte{}
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return append(append(key(num, 2), uvarint(uint64(len(b)))...), b...)
+}
+
+func str(num uint64, s string) []byte {
+	return bytes(num, []byte(s))
+}
+
+func join(parts ...[]byte) []byte {
+	b := []byte{}
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// nested returns a message with depth levels of children
+func nested(depth int) []byte {
+	b := str(1, "leaf")
+	for i := 1; i < depth; i++ {
+		b = bytes(2, b)
+	}
+	return b
+}
+
+var _ = Describe("Payload", func() {
+	Describe("Check", func() {
+		It("Should accept valid messages", func() {
+			m := &Tree{
+				Name:   "root",
+				Child:  &Tree{Name: "child", Tags: []string{"a", "b"}},
+				Ports:  []uint32{80, 443},
+				Labels: map[string]string{"tier": "web"},
+				Data:   []byte{0xff, 0xfe},
+				Count:  3,
+				Source: &TreeURL{URL: "https://kontainer.ooo"},
+			}
+			b, err := proto.Marshal(m)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			out := &Tree{}
+			Ω(payload.Unmarshal(b, out)).Should(Succeed())
+			Expect(proto.Equal(m, out)).To(BeTrue())
+		})
+
+		It("Should accept unpacked repeated scalars and unknown fields", func() {
+			b := join(varint(4, 80), varint(4, 443), str(100, "future"), varint(100, 1))
+			Ω(payload.Check(b, &Tree{})).Should(Succeed())
+		})
+
+		It("Should reject messages nested too deep", func() {
+			Ω(payload.Check(nested(payload.MaxDepth), &Tree{})).Should(Succeed())
+
+			err := payload.Check(nested(payload.MaxDepth+1), &Tree{})
+			Expect(err).To(BeAssignableToTypeOf(&payload.Error{}))
+			Expect(err.(*payload.Error).Err).To(Equal(payload.ErrTooDeep))
+		})
+
+		It("Should reject fields sent twice", func() {
+			err := payload.Check(join(str(1, "a"), str(1, "b")), &Tree{})
+			Expect(err).To(MatchError("name: field sent more than once"))
+
+			err = payload.Check(bytes(2, varint(7, 1), varint(7, 2)), &Tree{})
+			Expect(err).To(MatchError("child.count: field sent more than once"))
+
+			Ω(payload.Check(join(str(3, "a"), str(3, "b")), &Tree{})).Should(Succeed())
+		})
+
+		It("Should reject more than one field of a oneof", func() {
+			err := payload.Check(join(str(8, "/srv"), str(9, "https://kontainer.ooo")), &Tree{})
+			Expect(err).To(MatchError("url: field sent more than once"))
+		})
+
+		It("Should reject strings which are not UTF-8", func() {
+			err := payload.Check(str(1, "\xff\xfe"), &Tree{})
+			Expect(err).To(MatchError("name: string is not valid UTF-8"))
+
+			err = payload.Check(bytes(5, str(1, "tier"), str(2, "\xc3\x28")), &Tree{})
+			Expect(err).To(MatchError("labels.value: string is not valid UTF-8"))
+
+			Ω(payload.Check(str(6, "\xff\xfe"), &Tree{})).Should(Succeed())
+		})
+
+		It("Should reject fields with the wrong wire type", func() {
+			err := payload.Check(varint(1, 1), &Tree{})
+			Expect(err).To(MatchError("name: field sent with the wrong wire type"))
+		})
+
+		It("Should reject malformed messages", func() {
+			for _, b := range [][]byte{
+				{0x0a, 0x05, 'a'},
+				{0x38},
+				{0x38, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+				{0x00, 0x01},
+				{0x0b},
+				bytes(4, []byte{0x80}),
+			} {
+				Ω(payload.Check(b, &Tree{})).ShouldNot(Succeed())
+			}
+		})
+
+		It("Should survive random and mutated messages", func() {
+			r := rand.New(rand.NewSource(742))
+			valid, _ := proto.Marshal(&Tree{Name: "root", Child: &Tree{Name: "child", Ports: []uint32{1, 2}}, Labels: map[string]string{"a": "b"}})
+
+			for i := 0; i < 20000; i++ {
+				var b []byte
+				if i%2 == 0 {
+					b = make([]byte, r.Intn(64))
+					r.Read(b)
+				} else {
+					b = append([]byte{}, valid...)
+					for j := 0; j <= r.Intn(4); j++ {
+						b[r.Intn(len(b))] = byte(r.Intn(256))
+					}
+				}
+
+				Expect(func() {
+					if payload.Check(b, &Tree{}) == nil {
+						Ω(proto.Unmarshal(b, &Tree{})).Should(Succeed())
+					}
+				}).NotTo(Panic())
+			}
+		})
+	})
+
+	Describe("Limits", func() {
+		l := payload.Limits{
+			Max:     100,
+			Methods: map[string]int{"site.SiteService/Deploy": 1000, "USR/CRT": 10},
+		}
+
+		It("Should limit the methods by their own size or the default", func() {
+			Ω(l.Allow("site.SiteService/Deploy", 500)).Should(Succeed())
+			Ω(l.Allow("USR/CRT", 11)).ShouldNot(Succeed())
+			Expect(l.Allow("user.UserService/CreateUser", 101)).To(MatchError("request of 101 bytes exceeds the limit of 100 bytes of user.UserService/CreateUser"))
+			Expect(l.Largest()).To(Equal(1000))
+		})
+
+		It("Should not limit without a size", func() {
+			Ω(payload.Limits{}.Allow("USR/CRT", 1<<30)).Should(Succeed())
+		})
+
+		It("Should reject gRPC calls exceeding their size", func() {
+			intercept := payload.UnaryServerInterceptor(l)
+			handler := func(ctx oldcontext.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			}
+
+			res, err := intercept(oldcontext.Background(), &Tree{Name: "small"}, &grpc.UnaryServerInfo{FullMethod: "/user.UserService/CreateUser"}, handler)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res).To(Equal("ok"))
+
+			_, err = intercept(oldcontext.Background(), &Tree{Data: make([]byte, 200)}, &grpc.UnaryServerInfo{FullMethod: "/user.UserService/CreateUser"}, handler)
+			Expect(grpc.Code(err)).To(Equal(codes.InvalidArgument))
+		})
+	})
+
+	Describe("Codec", func() {
+		It("Should check the requests before decoding them", func() {
+			c := payload.Codec{}
+			b, err := c.Marshal(&Tree{Name: "root"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			out := &Tree{}
+			Ω(c.Unmarshal(b, out)).Should(Succeed())
+			Expect(out.Name).To(Equal("root"))
+
+			Ω(c.Unmarshal(join(b, b), &Tree{})).ShouldNot(Succeed())
+			Ω(c.Unmarshal(b, "no message")).ShouldNot(Succeed())
+		})
+	})
+})
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ports"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ports/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *ports.Endpoints {
+
+	var AllocatePortEndpoint endpoint.Endpoint
+	{
+		AllocatePortEndpoint = grpctransport.NewClient(
+			conn,
+			"ports.PortService",
+			"AllocatePort",
+			EncodeGRPCAllocatePortRequest,
+			DecodeGRPCAllocatePortResponse,
+			pb.AllocatePortResponse{},
+		).Endpoint()
+	}
+
+	var ReservePortEndpoint endpoint.Endpoint
+	{
+		ReservePortEndpoint = grpctransport.NewClient(
+			conn,
+			"ports.PortService",
+			"ReservePort",
+			EncodeGRPCReservePortRequest,
+			DecodeGRPCReservePortResponse,
+			pb.ReservePortResponse{},
+		).Endpoint()
+	}
+
+	var ReleasePortEndpoint endpoint.Endpoint
+	{
+		ReleasePortEndpoint = grpctransport.NewClient(
+			conn,
+			"ports.PortService",
+			"ReleasePort",
+			EncodeGRPCReleasePortRequest,
+			DecodeGRPCReleasePortResponse,
+			pb.ReleasePortResponse{},
+		).Endpoint()
+	}
+
+	var ReservationsEndpoint endpoint.Endpoint
+	{
+		ReservationsEndpoint = grpctransport.NewClient(
+			conn,
+			"ports.PortService",
+			"Reservations",
+			EncodeGRPCReservationsRequest,
+			DecodeGRPCReservationsResponse,
+			pb.ReservationsResponse{},
+		).Endpoint()
+	}
+
+	return &ports.Endpoints{
+		AllocatePortEndpoint: AllocatePortEndpoint,
+		ReservePortEndpoint:  ReservePortEndpoint,
+		ReleasePortEndpoint:  ReleasePortEndpoint,
+		ReservationsEndpoint: ReservationsEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCAllocatePortRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ports.proto-domain allocateport request to a gRPC AllocatePort request.
+func EncodeGRPCAllocatePortRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ports.AllocatePortRequest)
+	return &pb.AllocatePortRequest{
+		RefID:    uint32(req.RefID),
+		From:     uint32(req.Range.From),
+		To:       uint32(req.Range.To),
+		Protocol: req.Protocol,
+		Owner:    req.Owner,
+	}, nil
+}
+
+// DecodeGRPCAllocatePortResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC AllocatePort response to a messages/ports.proto-domain allocateport response.
+func DecodeGRPCAllocatePortResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.AllocatePortResponse)
+	return &ports.AllocatePortResponse{
+		Port:  uint16(response.Port),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCReservePortRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ports.proto-domain reserveport request to a gRPC ReservePort request.
+func EncodeGRPCReservePortRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ports.ReservePortRequest)
+	return &pb.ReservePortRequest{
+		RefID:    uint32(req.RefID),
+		Port:     uint32(req.Port),
+		Protocol: req.Protocol,
+		Owner:    req.Owner,
+	}, nil
+}
+
+// DecodeGRPCReservePortResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ReservePort response to a messages/ports.proto-domain reserveport response.
+func DecodeGRPCReservePortResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ReservePortResponse)
+	return &ports.ReservePortResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCReleasePortRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ports.proto-domain releaseport request to a gRPC ReleasePort request.
+func EncodeGRPCReleasePortRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ports.ReleasePortRequest)
+	return &pb.ReleasePortRequest{
+		RefID:    uint32(req.RefID),
+		Port:     uint32(req.Port),
+		Protocol: req.Protocol,
+	}, nil
+}
+
+// DecodeGRPCReleasePortResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC ReleasePort response to a messages/ports.proto-domain releaseport response.
+func DecodeGRPCReleasePortResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ReleasePortResponse)
+	return &ports.ReleasePortResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCReservationsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ports.proto-domain reservations request to a gRPC Reservations request.
+func EncodeGRPCReservationsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*ports.ReservationsRequest)
+	return &pb.ReservationsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCReservationsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Reservations response to a messages/ports.proto-domain reservations response.
+func DecodeGRPCReservationsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.ReservationsResponse)
+	rs := make([]ports.Reservation, len(response.Reservations))
+	for i, r := range response.Reservations {
+		rs[i] = ports.ConvertPBReservation(r)
+	}
+
+	return &ports.ReservationsResponse{
+		Reservations: rs,
+		Error:        getError(response.Error),
+		Page:         paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
@@ -0,0 +1,45 @@
+package ports
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reservation is a host port reserved for a user
+type Reservation struct {
+	ID       uint `gorm:"primary_key"`
+	RefID    uint
+	Port     uint16
+	Protocol string
+	// Owner names what uses the port, e.g. an instance or a routing configuration
+	Owner     string
+	CreatedAt time.Time
+}
+
+// TableName sets Reservation's database table name
+func (Reservation) TableName() string {
+	return "port_reservations"
+}
+
+// Range is an inclusive range of ports
+type Range struct {
+	From uint16
+	To   uint16
+}
+
+// Contains returns whether port is part of the range
+func (r Range) Contains(port uint16) bool {
+	return port >= r.From && port <= r.To
+}
+
+// ConflictError occurs if a port is reserved or used already
+type ConflictError struct {
+	Port     uint16
+	Protocol string
+	// Owner is what uses the port, ports of other users are not described further
+	Owner string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("port %d/%s is already in use by %s", e.Port, e.Protocol, e.Owner)
+}
@@ -0,0 +1,125 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the ports service
+type Endpoints struct {
+	AllocatePortEndpoint endpoint.Endpoint
+	ReservePortEndpoint  endpoint.Endpoint
+	ReleasePortEndpoint  endpoint.Endpoint
+	ReservationsEndpoint endpoint.Endpoint
+}
+
+// AllocatePortRequest is the request struct for the AllocatePortEndpoint
+type AllocatePortRequest struct {
+	RefID    uint `bart:"ref"`
+	Range    Range
+	Protocol string
+	Owner    string `validate:"required"`
+}
+
+// AllocatePortResponse is the response struct for the AllocatePortEndpoint
+type AllocatePortResponse struct {
+	Port  uint16
+	Error error
+}
+
+// MakeAllocatePortEndpoint creates a gokit endpoint which invokes AllocatePort
+func MakeAllocatePortEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AllocatePortRequest)
+		port, err := s.AllocatePort(req.RefID, req.Range, req.Protocol, req.Owner)
+		return AllocatePortResponse{
+			Port:  port,
+			Error: err,
+		}, nil
+	}
+}
+
+// ReservePortRequest is the request struct for the ReservePortEndpoint
+type ReservePortRequest struct {
+	RefID    uint   `bart:"ref"`
+	Port     uint16 `validate:"required"`
+	Protocol string
+	Owner    string `validate:"required"`
+}
+
+// ReservePortResponse is the response struct for the ReservePortEndpoint
+type ReservePortResponse struct {
+	Error error
+}
+
+// MakeReservePortEndpoint creates a gokit endpoint which invokes ReservePort
+func MakeReservePortEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ReservePortRequest)
+		err := s.ReservePort(req.RefID, req.Port, req.Protocol, req.Owner)
+		return ReservePortResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// ReleasePortRequest is the request struct for the ReleasePortEndpoint
+type ReleasePortRequest struct {
+	RefID    uint   `bart:"ref"`
+	Port     uint16 `validate:"required"`
+	Protocol string
+}
+
+// ReleasePortResponse is the response struct for the ReleasePortEndpoint
+type ReleasePortResponse struct {
+	Error error
+}
+
+// MakeReleasePortEndpoint creates a gokit endpoint which invokes ReleasePort
+func MakeReleasePortEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ReleasePortRequest)
+		err := s.ReleasePort(req.RefID, req.Port, req.Protocol)
+		return ReleasePortResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// ReservationsRequest is the request struct for the ReservationsEndpoint
+type ReservationsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// ReservationsResponse is the response struct for the ReservationsEndpoint
+type ReservationsResponse struct {
+	Reservations []Reservation
+	Error        error
+	Page         paging.Response
+}
+
+// MakeReservationsEndpoint creates a gokit endpoint which invokes Reservations
+func MakeReservationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(ReservationsRequest)
+		rs := []Reservation{}
+		err := s.Reservations(req.RefID, &rs)
+		if err != nil {
+			return ReservationsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&rs, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return ReservationsResponse{
+			Reservations: rs,
+			Page:         page,
+		}, nil
+	}
+}
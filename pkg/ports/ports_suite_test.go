@@ -0,0 +1,13 @@
+package ports_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPorts(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Ports Suite")
+}
@@ -0,0 +1,172 @@
+package ports_test
+
+import (
+	"github.com/kontainerooo/kontainer.ooo/pkg/ports"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ports", func() {
+	var (
+		refID  = uint(1)
+		rng    = ports.Range{From: 20000, To: 20009}
+		router []ports.Reservation
+		s      ports.Service
+	)
+
+	BeforeEach(func() {
+		router = []ports.Reservation{}
+		daemon, err := ports.Listeners(":8082", "", "127.0.0.1:20001")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		s, err = ports.NewService(testutils.NewMockDB(), rng, daemon, ports.SourceFunc(func() ([]ports.Reservation, error) {
+			return router, nil
+		}))
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("Create Service", func() {
+		It("Should create service", func() {
+			s, err := ports.NewService(testutils.NewMockDB(), rng)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s).ToNot(BeZero())
+		})
+
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := ports.NewService(db, rng)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should reject privileged and empty ranges", func() {
+			_, err := ports.NewService(testutils.NewMockDB(), ports.Range{From: 80, To: 20000})
+			Ω(err).Should(Equal(ports.ErrInvalidRange))
+
+			_, err = ports.NewService(testutils.NewMockDB(), ports.Range{From: 20001, To: 20000})
+			Ω(err).Should(Equal(ports.ErrInvalidRange))
+		})
+
+		It("Should reject invalid listen addresses", func() {
+			_, err := ports.Listeners("localhost")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("AllocatePort", func() {
+		It("Should allocate the lowest free port", func() {
+			port, err := s.AllocatePort(refID, ports.Range{}, "", "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(port).To(BeEquivalentTo(20000))
+
+			// 20001 is used by the daemon
+			port, err = s.AllocatePort(refID, ports.Range{}, ports.TCP, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(port).To(BeEquivalentTo(20002))
+
+			port, err = s.AllocatePort(2, ports.Range{}, ports.UDP, "dns")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(port).To(BeEquivalentTo(20000))
+		})
+
+		It("Should skip the ports of the sources", func() {
+			router = append(router, ports.Reservation{Port: 20000, Protocol: ports.TCP, Owner: "the router"})
+
+			port, err := s.AllocatePort(refID, ports.Range{}, ports.TCP, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(port).To(BeEquivalentTo(20002))
+		})
+
+		It("Should allocate from a part of the range", func() {
+			port, err := s.AllocatePort(refID, ports.Range{From: 20005, To: 20006}, ports.TCP, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(port).To(BeEquivalentTo(20005))
+
+			port, err = s.AllocatePort(refID, ports.Range{From: 20005, To: 20006}, ports.TCP, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(port).To(BeEquivalentTo(20006))
+
+			_, err = s.AllocatePort(refID, ports.Range{From: 20005, To: 20006}, ports.TCP, "web")
+			Ω(err).Should(Equal(ports.ErrNoFreePort))
+		})
+
+		It("Should reject ranges outside of the range of the service", func() {
+			_, err := s.AllocatePort(refID, ports.Range{From: 19999, To: 20005}, ports.TCP, "web")
+			Ω(err).Should(Equal(ports.ErrInvalidRange))
+
+			_, err = s.AllocatePort(refID, ports.Range{From: 20005, To: 20004}, ports.TCP, "web")
+			Ω(err).Should(Equal(ports.ErrInvalidRange))
+		})
+
+		It("Should reject unknown protocols", func() {
+			_, err := s.AllocatePort(refID, ports.Range{}, "sctp", "web")
+			Ω(err).Should(Equal(ports.ErrInvalidProtocol))
+		})
+	})
+
+	Describe("ReservePort", func() {
+		It("Should reserve a port", func() {
+			Ω(s.ReservePort(refID, 30000, ports.TCP, "web")).Should(Succeed())
+
+			rs := []ports.Reservation{}
+			Ω(s.Reservations(refID, &rs)).Should(Succeed())
+			Expect(rs).To(HaveLen(1))
+			Expect(rs[0].Port).To(BeEquivalentTo(30000))
+			Expect(rs[0].Protocol).To(Equal(ports.TCP))
+			Expect(rs[0].Owner).To(Equal("web"))
+		})
+
+		It("Should reject ports in use", func() {
+			Ω(s.ReservePort(refID, 30000, ports.TCP, "web")).Should(Succeed())
+
+			err := s.ReservePort(refID, 30000, ports.TCP, "api")
+			Ω(err).Should(Equal(&ports.ConflictError{Port: 30000, Protocol: ports.TCP, Owner: "web"}))
+			Expect(err.Error()).To(Equal("port 30000/tcp is already in use by web"))
+
+			err = s.ReservePort(2, 30000, ports.TCP, "web")
+			Expect(err.Error()).To(Equal("port 30000/tcp is already in use by another user"))
+
+			err = s.ReservePort(2, 8082, ports.TCP, "web")
+			Expect(err.Error()).To(Equal("port 8082/tcp is already in use by kontainer.ooo"))
+
+			Ω(s.ReservePort(2, 30000, ports.UDP, "web")).Should(Succeed())
+		})
+
+		It("Should reject ports of the sources", func() {
+			router = append(router, ports.Reservation{Port: 8443, Protocol: ports.TCP, Owner: "the router"})
+
+			err := s.ReservePort(refID, 8443, ports.TCP, "web")
+			Expect(err).To(BeAssignableToTypeOf(&ports.ConflictError{}))
+			Expect(err.Error()).To(Equal("port 8443/tcp is already in use by the router"))
+		})
+
+		It("Should reject privileged ports", func() {
+			err := s.ReservePort(refID, 443, ports.TCP, "web")
+			Ω(err).Should(Equal(ports.ErrPrivilegedPort))
+		})
+	})
+
+	Describe("ReleasePort", func() {
+		It("Should only release ports of the user", func() {
+			port, _ := s.AllocatePort(refID, ports.Range{}, ports.TCP, "web")
+
+			err := s.ReleasePort(2, port, ports.TCP)
+			Ω(err).Should(Equal(ports.ErrNotReserved))
+
+			err = s.ReleasePort(refID, port, ports.UDP)
+			Ω(err).Should(Equal(ports.ErrNotReserved))
+
+			Ω(s.ReleasePort(refID, port, ports.TCP)).Should(Succeed())
+
+			rs := []ports.Reservation{}
+			s.Reservations(refID, &rs)
+			Expect(rs).To(BeEmpty())
+
+			next, err := s.AllocatePort(2, ports.Range{}, ports.TCP, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(next).To(Equal(port))
+		})
+	})
+})
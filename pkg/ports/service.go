@@ -0,0 +1,337 @@
+// Package ports allocates the host ports of the users and keeps track of the ports used by other services,
+// so two services never listen on the same host port
+package ports
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+const (
+	// TCP is the protocol of ports which are reserved without protocol
+	TCP = "tcp"
+
+	// UDP ports are reserved independently of the TCP ports with the same number
+	UDP = "udp"
+)
+
+// MinPort is the lowest port users may reserve, lower ports are reserved for the system
+const MinPort = 1024
+
+// SystemOwner is the owner of the ports the daemon listens on
+const SystemOwner = "kontainer.ooo"
+
+var (
+	// ErrInvalidProtocol occurs if a protocol is neither TCP nor UDP
+	ErrInvalidProtocol = errors.New("protocol has to be tcp or udp")
+
+	// ErrInvalidRange occurs if a range is empty or not part of the range of the service
+	ErrInvalidRange = errors.New("invalid port range")
+
+	// ErrPrivilegedPort occurs if a port below MinPort is reserved
+	ErrPrivilegedPort = fmt.Errorf("ports below %d are reserved for the system", MinPort)
+
+	// ErrNoFreePort occurs if every port of a range is in use
+	ErrNoFreePort = errors.New("no free port in range")
+
+	// ErrNotReserved occurs if a port which is not reserved by the user is released
+	ErrNotReserved = errors.New("port is not reserved")
+)
+
+// Service PortService
+type Service interface {
+	// AllocatePort reserves the lowest free port of r for owner and returns it, r has to be part of
+	// the range of the service and defaults to it if it is empty
+	AllocatePort(refID uint, r Range, protocol string, owner string) (uint16, error)
+
+	// ReservePort reserves a given port for owner, it returns a ConflictError if the port is in use
+	ReservePort(refID uint, port uint16, protocol string, owner string) error
+
+	// ReleasePort releases a port reserved by a user
+	ReleasePort(refID uint, port uint16, protocol string) error
+
+	// Reservations returns the ports reserved by a user
+	Reservations(refID uint, r *[]Reservation) error
+}
+
+// Source reports the host ports another service uses without reserving them, e.g. the listen
+// statements of the routing configurations
+type Source interface {
+	// Ports returns the ports in use, the RefID of a port is 0 if it is used by the system
+	Ports() ([]Reservation, error)
+}
+
+// SourceFunc is a function implementing Source
+type SourceFunc func() ([]Reservation, error)
+
+// Ports calls f
+func (f SourceFunc) Ports() ([]Reservation, error) {
+	return f()
+}
+
+// Listeners returns a Source of the TCP ports of the addresses the daemon listens on,
+// empty addresses are skipped
+func Listeners(addrs ...string) (Source, error) {
+	used := []Reservation{}
+	for _, addr := range addrs {
+		if addr == "" {
+			continue
+		}
+
+		_, p, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		port, err := strconv.ParseUint(p, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in %s", addr)
+		}
+
+		used = append(used, Reservation{
+			Port:     uint16(port),
+			Protocol: TCP,
+			Owner:    SystemOwner,
+		})
+	}
+
+	return SourceFunc(func() ([]Reservation, error) {
+		return used, nil
+	}), nil
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	rng     Range
+	sources []Source
+	mtx     *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Reservation{})
+}
+
+func protocol(p string) (string, error) {
+	switch p {
+	case "", TCP:
+		return TCP, nil
+	case UDP:
+		return UDP, nil
+	}
+	return "", ErrInvalidProtocol
+}
+
+func key(port uint16, protocol string) string {
+	return fmt.Sprintf("%d/%s", port, protocol)
+}
+
+func (s *service) reservations() ([]Reservation, error) {
+	rs := []Reservation{}
+	err := s.db.Find(&rs)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// used returns every port in use by its port and protocol
+func (s *service) used() (map[string]Reservation, error) {
+	rs, err := s.reservations()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, src := range s.sources {
+		ps, err := src.Ports()
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, ps...)
+	}
+
+	used := make(map[string]Reservation)
+	for _, r := range rs {
+		used[key(r.Port, r.Protocol)] = r
+	}
+	return used, nil
+}
+
+// conflict describes the user of a port to refID, the ports of other users are not described further
+func conflict(refID uint, r Reservation) *ConflictError {
+	owner := r.Owner
+	if r.RefID != refID && r.RefID != 0 {
+		owner = "another user"
+	}
+
+	return &ConflictError{
+		Port:     r.Port,
+		Protocol: r.Protocol,
+		Owner:    owner,
+	}
+}
+
+func (s *service) reserve(refID uint, port uint16, protocol string, owner string) error {
+	return s.db.Create(&Reservation{
+		RefID:     refID,
+		Port:      port,
+		Protocol:  protocol,
+		Owner:     owner,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+func (s *service) AllocatePort(refID uint, r Range, protocol string, owner string) (uint16, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.allocatePort(refID, r, protocol, owner)
+}
+
+func (s *service) allocatePort(refID uint, r Range, proto string, owner string) (uint16, error) {
+	proto, err := protocol(proto)
+	if err != nil {
+		return 0, err
+	}
+
+	if r == (Range{}) {
+		r = s.rng
+	}
+	if r.From > r.To || !s.rng.Contains(r.From) || !s.rng.Contains(r.To) {
+		return 0, ErrInvalidRange
+	}
+
+	used, err := s.used()
+	if err != nil {
+		return 0, err
+	}
+
+	for port := int(r.From); port <= int(r.To); port++ {
+		if _, ok := used[key(uint16(port), proto)]; ok {
+			continue
+		}
+
+		err = s.reserve(refID, uint16(port), proto, owner)
+		if err != nil {
+			return 0, err
+		}
+		return uint16(port), nil
+	}
+	return 0, ErrNoFreePort
+}
+
+func (s *service) ReservePort(refID uint, port uint16, protocol string, owner string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.reservePort(refID, port, protocol, owner)
+}
+
+func (s *service) reservePort(refID uint, port uint16, proto string, owner string) error {
+	proto, err := protocol(proto)
+	if err != nil {
+		return err
+	}
+
+	if port < MinPort {
+		return ErrPrivilegedPort
+	}
+
+	used, err := s.used()
+	if err != nil {
+		return err
+	}
+
+	if r, ok := used[key(port, proto)]; ok {
+		return conflict(refID, r)
+	}
+	return s.reserve(refID, port, proto, owner)
+}
+
+func (s *service) ReleasePort(refID uint, port uint16, protocol string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.releasePort(refID, port, protocol)
+}
+
+func (s *service) releasePort(refID uint, port uint16, proto string) error {
+	proto, err := protocol(proto)
+	if err != nil {
+		return err
+	}
+
+	rs, err := s.reservations()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range rs {
+		if r.RefID == refID && r.Port == port && r.Protocol == proto {
+			return s.db.Delete(&Reservation{ID: r.ID})
+		}
+	}
+	return ErrNotReserved
+}
+
+func (s *service) Reservations(refID uint, r *[]Reservation) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rs, err := s.reservations()
+	if err != nil {
+		return err
+	}
+
+	for _, res := range rs {
+		if res.RefID == refID {
+			*r = append(*r, res)
+		}
+	}
+	sort.Slice(*r, func(i, j int) bool {
+		return (*r)[i].ID < (*r)[j].ID
+	})
+	return nil
+}
+
+// NewService creates a PortService allocating ports of rng, the ports reported by sources are never
+// allocated or reserved
+func NewService(db dbAdapter, rng Range, sources ...Source) (Service, error) {
+	if rng.From < MinPort || rng.From > rng.To {
+		return nil, ErrInvalidRange
+	}
+
+	s := &service{
+		db:      db,
+		rng:     rng,
+		sources: sources,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,240 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ports/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// errPortRange occurs if a port of a request does not fit into 16 bits
+var errPortRange = errors.New("port has to be between 0 and 65535")
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC PortServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.PortServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		allocatePort: grpctransport.NewServer(
+			endpoints.AllocatePortEndpoint,
+			DecodeGRPCAllocatePortRequest,
+			EncodeGRPCAllocatePortResponse,
+			options...,
+		),
+
+		reservePort: grpctransport.NewServer(
+			endpoints.ReservePortEndpoint,
+			DecodeGRPCReservePortRequest,
+			EncodeGRPCReservePortResponse,
+			options...,
+		),
+
+		releasePort: grpctransport.NewServer(
+			endpoints.ReleasePortEndpoint,
+			DecodeGRPCReleasePortRequest,
+			EncodeGRPCReleasePortResponse,
+			options...,
+		),
+
+		reservations: grpctransport.NewServer(
+			endpoints.ReservationsEndpoint,
+			DecodeGRPCReservationsRequest,
+			EncodeGRPCReservationsResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	allocatePort grpctransport.Handler
+	reservePort  grpctransport.Handler
+	releasePort  grpctransport.Handler
+	reservations grpctransport.Handler
+}
+
+func (s *grpcServer) AllocatePort(ctx oldcontext.Context, req *pb.AllocatePortRequest) (*pb.AllocatePortResponse, error) {
+	_, res, err := s.allocatePort.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.AllocatePortResponse), nil
+}
+
+func (s *grpcServer) ReservePort(ctx oldcontext.Context, req *pb.ReservePortRequest) (*pb.ReservePortResponse, error) {
+	_, res, err := s.reservePort.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ReservePortResponse), nil
+}
+
+func (s *grpcServer) ReleasePort(ctx oldcontext.Context, req *pb.ReleasePortRequest) (*pb.ReleasePortResponse, error) {
+	_, res, err := s.releasePort.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ReleasePortResponse), nil
+}
+
+func (s *grpcServer) Reservations(ctx oldcontext.Context, req *pb.ReservationsRequest) (*pb.ReservationsResponse, error) {
+	_, res, err := s.reservations.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.ReservationsResponse), nil
+}
+
+func convertPort(ports ...uint32) ([]uint16, error) {
+	converted := make([]uint16, len(ports))
+	for i, p := range ports {
+		if p > 65535 {
+			return nil, errPortRange
+		}
+		converted[i] = uint16(p)
+	}
+	return converted, nil
+}
+
+// ConvertReservation converts a Reservation to its protobuf representation
+func ConvertReservation(r Reservation) *pb.Reservation {
+	return &pb.Reservation{
+		ID:        uint32(r.ID),
+		Port:      uint32(r.Port),
+		Protocol:  r.Protocol,
+		Owner:     r.Owner,
+		CreatedAt: r.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBReservation converts a protobuf Reservation to a Reservation
+func ConvertPBReservation(r *pb.Reservation) Reservation {
+	if r == nil {
+		return Reservation{}
+	}
+
+	return Reservation{
+		ID:        uint(r.ID),
+		Port:      uint16(r.Port),
+		Protocol:  r.Protocol,
+		Owner:     r.Owner,
+		CreatedAt: time.Unix(r.CreatedAt, 0).UTC(),
+	}
+}
+
+// DecodeGRPCAllocatePortRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC AllocatePort request to a messages/ports.proto-domain allocateport request.
+func DecodeGRPCAllocatePortRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AllocatePortRequest)
+	rng, err := convertPort(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+	return AllocatePortRequest{
+		RefID:    uint(req.RefID),
+		Range:    Range{From: rng[0], To: rng[1]},
+		Protocol: req.Protocol,
+		Owner:    req.Owner,
+	}, nil
+}
+
+// EncodeGRPCAllocatePortResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ports.proto-domain allocateport response to a gRPC AllocatePort response.
+func EncodeGRPCAllocatePortResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(AllocatePortResponse)
+	gRPCRes := &pb.AllocatePortResponse{
+		Port: uint32(res.Port),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCReservePortRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ReservePort request to a messages/ports.proto-domain reserveport request.
+func DecodeGRPCReservePortRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ReservePortRequest)
+	port, err := convertPort(req.Port)
+	if err != nil {
+		return nil, err
+	}
+	return ReservePortRequest{
+		RefID:    uint(req.RefID),
+		Port:     port[0],
+		Protocol: req.Protocol,
+		Owner:    req.Owner,
+	}, nil
+}
+
+// EncodeGRPCReservePortResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ports.proto-domain reserveport response to a gRPC ReservePort response.
+func EncodeGRPCReservePortResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ReservePortResponse)
+	gRPCRes := &pb.ReservePortResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCReleasePortRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC ReleasePort request to a messages/ports.proto-domain releaseport request.
+func DecodeGRPCReleasePortRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ReleasePortRequest)
+	port, err := convertPort(req.Port)
+	if err != nil {
+		return nil, err
+	}
+	return ReleasePortRequest{
+		RefID:    uint(req.RefID),
+		Port:     port[0],
+		Protocol: req.Protocol,
+	}, nil
+}
+
+// EncodeGRPCReleasePortResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ports.proto-domain releaseport response to a gRPC ReleasePort response.
+func EncodeGRPCReleasePortResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ReleasePortResponse)
+	gRPCRes := &pb.ReleasePortResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCReservationsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Reservations request to a messages/ports.proto-domain reservations request.
+func DecodeGRPCReservationsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.ReservationsRequest)
+	return ReservationsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCReservationsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/ports.proto-domain reservations response to a gRPC Reservations response.
+func EncodeGRPCReservationsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(ReservationsResponse)
+	rs := make([]*pb.Reservation, len(res.Reservations))
+	for i, r := range res.Reservations {
+		rs[i] = ConvertReservation(r)
+	}
+
+	gRPCRes := &pb.ReservationsResponse{
+		Reservations: rs,
+		PageInfo:     paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
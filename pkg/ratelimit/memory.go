@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// refill adds the tokens for the time since the last request, up to the size of the bucket
+func (b *bucket) refill(l Limit, now time.Time) {
+	if now.After(b.last) {
+		b.tokens = math.Min(float64(l.Burst), b.tokens+now.Sub(b.last).Seconds()*l.Rate)
+		b.last = now
+	}
+}
+
+// take takes a token if there is one, it returns how long it takes until there is one otherwise
+func (b *bucket) take(l Limit) Result {
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{
+			Allowed:   true,
+			Remaining: int(b.tokens),
+		}
+	}
+
+	return Result{
+		RetryAfter: time.Duration(math.Ceil((1 - b.tokens) / l.Rate * float64(time.Second))),
+	}
+}
+
+type memoryStore struct {
+	mtx     sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+// sweepInterval is the number of takes after which full buckets are removed
+const sweepInterval = 10000
+
+// sweep removes the buckets which are full again, they are the same as new ones
+func (s *memoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate >= float64(b.limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func (s *memoryStore) Take(key string, l Limit, now time.Time) (Result, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.takes++
+	if s.takes%sweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{
+			tokens: float64(l.Burst),
+			last:   now,
+		}
+		s.buckets[key] = b
+	}
+
+	b.limit = l
+	b.refill(l, now)
+	return b.take(l), nil
+}
+
+// NewMemoryStore returns a Store keeping the buckets in memory, they are not shared between nodes
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+	}
+}
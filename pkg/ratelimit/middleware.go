@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/bart"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// RetryAfterHeader is the gRPC metadata key carrying the seconds until a limited call may be
+// made again, the gateway returns it as the Retry-After header of 429 responses
+const RetryAfterHeader = "retry-after"
+
+// RetryAfterSeconds returns the whole seconds of the Retry-After value for a call which may be made
+// again after d, it is at least 1
+func RetryAfterSeconds(d time.Duration) int {
+	return int(math.Max(1, math.Ceil(d.Seconds())))
+}
+
+// UnaryServerInterceptor limits the gRPC calls of the users authenticated by the interceptors before it,
+// limited calls fail with codes.ResourceExhausted. REST calls are limited as well since the gateway
+// forwards them to the gRPC services.
+func UnaryServerInterceptor(l *Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		id, ok := bart.Caller(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		err := l.Allow(id, strings.TrimPrefix(info.FullMethod, "/"))
+		if e, ok := err.(*Error); ok {
+			grpc.SetHeader(ctx, metadata.Pairs(RetryAfterHeader, strconv.Itoa(RetryAfterSeconds(e.RetryAfter))))
+			return nil, grpc.Errorf(codes.ResourceExhausted, "%v", e)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Websocket returns a websocket before middleware limiting the calls of the user of a session,
+// calls of connections without a session are not limited
+func Websocket(l *Limiter) ws.MiddlewareFunc {
+	return func(srv, me ws.ProtoID, data *ws.MiddlewareData, session interface{}) error {
+		sessionMap, ok := session.(map[interface{}]interface{})
+		if !ok {
+			return nil
+		}
+
+		id, ok := sessionMap["ID"].(float64)
+		if !ok {
+			return nil
+		}
+
+		return l.Allow(uint(id), srv.String()+"/"+me.String())
+	}
+}
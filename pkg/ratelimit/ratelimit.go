@@ -0,0 +1,206 @@
+// Package ratelimit limits the requests users make with token buckets, one per user and one per
+// user and method for the methods their plan limits separately
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const (
+	// DefaultPlan is used for users without a plan or with a plan which is not configured
+	DefaultPlan = "default"
+
+	// Unlimited is the plan of users who are never limited, like admins
+	Unlimited = "unlimited"
+
+	// PlanTTL is how long the plan of a user is cached
+	PlanTTL = time.Minute
+)
+
+// Limit is a token bucket which holds up to Burst tokens and is refilled with Rate tokens per second,
+// every request takes one token. A Rate of 0 does not limit the requests.
+type Limit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// unlimited reports whether l does not limit any request
+func (l Limit) unlimited() bool {
+	return l.Rate <= 0
+}
+
+// burst returns the size of the bucket, which holds at least one token
+func (l Limit) burst() int {
+	if l.Burst < 1 {
+		return 1
+	}
+	return l.Burst
+}
+
+// Plan are the limits of a group of users. Limit applies to all requests of a user, Methods
+// limits the requests to single methods in addition. Methods are named like gRPC methods,
+// e.g. container.ContainerService/CreateContainer, or like websocket endpoints, e.g. CNT/CRE.
+type Plan struct {
+	Limit   `yaml:",inline"`
+	Methods map[string]Limit `yaml:"methods"`
+}
+
+// Result is the state of a bucket after a request took a token from it
+type Result struct {
+	Allowed bool
+
+	// Remaining is the number of whole tokens left in the bucket
+	Remaining int
+
+	// RetryAfter is the time until the next token is added if the request was not allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the token buckets
+type Store interface {
+	// Take takes a token from the bucket key, which is created with l if it does not exist
+	Take(key string, l Limit, now time.Time) (Result, error)
+}
+
+// Error is returned for requests exceeding a limit
+type Error struct {
+	// Method is empty if the limit of all requests of the user was exceeded
+	Method     string
+	Limit      Limit
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Method == "" {
+		return fmt.Sprintf("rate limit of %g requests per second exceeded, retry in %s", e.Limit.Rate, e.RetryAfter)
+	}
+	return fmt.Sprintf("rate limit of %g requests per second exceeded for %s, retry in %s", e.Limit.Rate, e.Method, e.RetryAfter)
+}
+
+// PlanFunc returns the name of the plan of a user
+type PlanFunc func(id uint) (string, error)
+
+type cachedPlan struct {
+	name    string
+	expires time.Time
+}
+
+// Limiter decides whether users may make a request
+type Limiter struct {
+	store  Store
+	planOf PlanFunc
+	logger log.Logger
+
+	mtx   sync.Mutex
+	plans map[string]Plan
+	cache map[uint]cachedPlan
+}
+
+// SetPlans replaces the configured plans
+func (l *Limiter) SetPlans(plans map[string]Plan) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.plans = plans
+}
+
+// plan returns the plan of the user id
+func (l *Limiter) plan(id uint) (Plan, bool, error) {
+	now := time.Now()
+
+	l.mtx.Lock()
+	c, ok := l.cache[id]
+	l.mtx.Unlock()
+
+	if !ok || now.After(c.expires) {
+		name, err := l.planOf(id)
+		if err != nil {
+			return Plan{}, false, err
+		}
+
+		c = cachedPlan{
+			name:    name,
+			expires: now.Add(PlanTTL),
+		}
+		l.mtx.Lock()
+		l.cache[id] = c
+		l.mtx.Unlock()
+	}
+
+	if c.name == Unlimited {
+		return Plan{}, false, nil
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	p, ok := l.plans[c.name]
+	if !ok {
+		p, ok = l.plans[DefaultPlan]
+	}
+	return p, ok, nil
+}
+
+// take takes a token from the bucket key and returns an Error if there was none
+func (l *Limiter) take(key, method string, limit Limit, now time.Time) error {
+	if limit.unlimited() {
+		return nil
+	}
+
+	res, err := l.store.Take(key, Limit{Rate: limit.Rate, Burst: limit.burst()}, now)
+	if err != nil {
+		return err
+	}
+
+	if !res.Allowed {
+		return &Error{
+			Method:     method,
+			Limit:      limit,
+			RetryAfter: res.RetryAfter,
+		}
+	}
+	return nil
+}
+
+// Allow returns an *Error if the user id may not call method right now. If the plan of the user
+// or the buckets can't be read the request is allowed, so an unavailable store does not take
+// the platform down with it.
+func (l *Limiter) Allow(id uint, method string) error {
+	p, ok, err := l.plan(id)
+	if err != nil {
+		level.Error(l.logger).Log("user", id, "err", err)
+		return nil
+	}
+	if !ok {
+		return nil
+	}
+
+	// the method is checked first so requests it rejects don't use up the limit of all requests
+	now := time.Now()
+	if m, ok := p.Methods[method]; ok {
+		err = l.take(fmt.Sprintf("%d:%s", id, method), method, m, now)
+	}
+	if err == nil {
+		err = l.take(fmt.Sprintf("%d", id), "", p.Limit, now)
+	}
+
+	if _, limited := err.(*Error); err != nil && !limited {
+		level.Error(l.logger).Log("user", id, "method", method, "err", err)
+		return nil
+	}
+	return err
+}
+
+// NewLimiter returns a Limiter which keeps its buckets in s and limits users by the plans
+func NewLimiter(s Store, plans map[string]Plan, planOf PlanFunc, logger log.Logger) *Limiter {
+	return &Limiter{
+		store:  s,
+		planOf: planOf,
+		logger: logger,
+		plans:  plans,
+		cache:  make(map[uint]cachedPlan),
+	}
+}
@@ -0,0 +1,189 @@
+package ratelimit_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/bart"
+	"github.com/kontainerooo/kontainer.ooo/pkg/ratelimit"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ratelimit", func() {
+	now := time.Date(2017, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	Describe("MemoryStore", func() {
+		It("Should take tokens until the bucket is empty and refill it", func() {
+			s := ratelimit.NewMemoryStore()
+			l := ratelimit.Limit{Rate: 2, Burst: 3}
+
+			for i := 2; i >= 0; i-- {
+				res, err := s.Take("1", l, now)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(res.Allowed).To(BeTrue())
+				Expect(res.Remaining).To(Equal(i))
+			}
+
+			res, _ := s.Take("1", l, now)
+			Expect(res.Allowed).To(BeFalse())
+			Expect(res.RetryAfter).To(Equal(500 * time.Millisecond))
+
+			res, _ = s.Take("2", l, now)
+			Expect(res.Allowed).To(BeTrue())
+
+			res, _ = s.Take("1", l, now.Add(500*time.Millisecond))
+			Expect(res.Allowed).To(BeTrue())
+
+			res, _ = s.Take("1", l, now.Add(time.Hour))
+			Expect(res.Allowed).To(BeTrue())
+			Expect(res.Remaining).To(Equal(2))
+		})
+	})
+
+	Describe("RedisStore", func() {
+		It("Should take tokens with a script", func() {
+			url, commands := testutils.FakeRedis("+OK\r\n", "+OK\r\n", "*3\r\n:0\r\n:0\r\n:1500\r\n")
+			s, err := ratelimit.NewRedisStore(url)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			res, err := s.Take("1:CNT/CRE", ratelimit.Limit{Rate: 0.5, Burst: 2}, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(res.Allowed).To(BeFalse())
+			Expect(res.RetryAfter).To(Equal(1500 * time.Millisecond))
+
+			Expect(<-commands).To(Equal([]string{"AUTH", "secret"}))
+			Expect(<-commands).To(Equal([]string{"SELECT", "2"}))
+			eval := <-commands
+			Expect(eval[0]).To(Equal("EVAL"))
+			Expect(eval[2:]).To(Equal([]string{"1", ratelimit.RedisKeyPrefix + "1:CNT/CRE", "0.5", "2", "1496318400000"}))
+		})
+
+		It("Should return error replies", func() {
+			url, _ := testutils.FakeRedis("+OK\r\n", "+OK\r\n", "-ERR script failed\r\n")
+			s, _ := ratelimit.NewRedisStore(url)
+
+			_, err := s.Take("1", ratelimit.Limit{Rate: 1, Burst: 1}, now)
+			Ω(err).Should(MatchError("ERR script failed"))
+		})
+
+		It("Should only accept redis urls", func() {
+			_, err := ratelimit.NewRedisStore("http://localhost")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Limiter", func() {
+		var (
+			plans map[string]ratelimit.Plan
+			users map[uint]string
+			l     *ratelimit.Limiter
+		)
+
+		BeforeEach(func() {
+			plans = map[string]ratelimit.Plan{
+				ratelimit.DefaultPlan: {
+					Limit: ratelimit.Limit{Rate: 0.001, Burst: 3},
+					Methods: map[string]ratelimit.Limit{
+						"CNT/CRE": {Rate: 0.001, Burst: 1},
+					},
+				},
+				"1": {
+					Limit: ratelimit.Limit{Rate: 0.001, Burst: 5},
+				},
+			}
+			users = map[uint]string{
+				1: ratelimit.DefaultPlan,
+				2: "1",
+				3: ratelimit.Unlimited,
+				4: "2",
+			}
+			l = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), plans, func(id uint) (string, error) {
+				p, ok := users[id]
+				if !ok {
+					return "", errors.New("user does not exist")
+				}
+				return p, nil
+			}, log.NewNopLogger())
+		})
+
+		allowed := func(id uint, method string, n int) int {
+			for i := 0; i < n; i++ {
+				if l.Allow(id, method) != nil {
+					return i
+				}
+			}
+			return n
+		}
+
+		It("Should limit the requests by the plan of the user", func() {
+			Expect(allowed(1, "USR/GET", 10)).To(Equal(3))
+			Expect(allowed(2, "USR/GET", 10)).To(Equal(5))
+			Expect(allowed(3, "USR/GET", 10)).To(Equal(10))
+		})
+
+		It("Should limit methods in addition", func() {
+			Expect(allowed(1, "CNT/CRE", 10)).To(Equal(1))
+			Expect(allowed(1, "USR/GET", 10)).To(Equal(2))
+
+			err := l.Allow(1, "CNT/CRE")
+			e, ok := err.(*ratelimit.Error)
+			Expect(ok).To(BeTrue())
+			Expect(e.Method).To(Equal("CNT/CRE"))
+			Expect(e.RetryAfter).To(BeNumerically(">", time.Minute))
+		})
+
+		It("Should use the default plan for unknown plans and allow requests if the plan is unknown", func() {
+			Expect(allowed(4, "USR/GET", 10)).To(Equal(3))
+			Expect(allowed(5, "USR/GET", 10)).To(Equal(10))
+		})
+
+		It("Should replace the plans", func() {
+			l.SetPlans(map[string]ratelimit.Plan{})
+			Expect(allowed(1, "USR/GET", 10)).To(Equal(10))
+		})
+
+		It("Should limit gRPC calls of authenticated users", func() {
+			i := ratelimit.UnaryServerInterceptor(l)
+			info := &grpc.UnaryServerInfo{FullMethod: "/container.ContainerService/CreateContainer"}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			}
+
+			ctx := bart.ContextWithCaller(context.Background(), 1)
+			for n := 0; n < 3; n++ {
+				res, err := i(ctx, nil, info, handler)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(res).To(Equal("ok"))
+			}
+
+			_, err := i(ctx, nil, info, handler)
+			Expect(grpc.Code(err)).To(Equal(codes.ResourceExhausted))
+			Expect(grpc.ErrorDesc(err)).To(ContainSubstring("rate limit"))
+
+			_, err = i(context.Background(), nil, info, handler)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should limit websocket calls of sessions", func() {
+			m := ratelimit.Websocket(l)
+			session := map[interface{}]interface{}{"ID": float64(1)}
+			srv, me := ws.ProtoIDFromString("CNT"), ws.ProtoIDFromString("CRE")
+
+			Ω(m(srv, me, &ws.MiddlewareData{}, session)).Should(Succeed())
+			err := m(srv, me, &ws.MiddlewareData{}, session)
+			Ω(err).Should(HaveOccurred())
+			Expect(strings.Contains(err.Error(), "CNT/CRE")).To(BeTrue())
+
+			Ω(m(srv, me, &ws.MiddlewareData{}, nil)).Should(Succeed())
+		})
+	})
+})
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/redis"
+)
+
+// RedisKeyPrefix is prepended to the keys of the buckets in Redis
+const RedisKeyPrefix = "kroo:ratelimit:"
+
+// takeScript takes a token from the bucket in KEYS[1] atomically, ARGV are the rate, the size of
+// the bucket and the current time in milliseconds. Buckets expire once they would be full again.
+const takeScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(bucket[1]) or burst
+local last = tonumber(bucket[2]) or now
+if now > last then
+  tokens = math.min(burst, tokens + (now - last) / 1000 * rate)
+  last = now
+end
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "last", tostring(last))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, math.floor(tokens), wait}
+`
+
+type redisStore struct {
+	client *redis.Client
+}
+
+func (s *redisStore) Take(key string, l Limit, now time.Time) (Result, error) {
+	reply, err := s.client.Do(
+		"EVAL", takeScript, "1", RedisKeyPrefix+key,
+		strconv.FormatFloat(l.Rate, 'f', -1, 64),
+		strconv.Itoa(l.Burst),
+		strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
+	)
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 3 {
+		return Result{}, errors.New("redis: unexpected reply to the rate limit script")
+	}
+
+	ints := make([]int64, len(values))
+	for i, v := range values {
+		ints[i], ok = v.(int64)
+		if !ok {
+			return Result{}, errors.New("redis: unexpected reply to the rate limit script")
+		}
+	}
+
+	return Result{
+		Allowed:    ints[0] == 1,
+		Remaining:  int(ints[1]),
+		RetryAfter: time.Duration(ints[2]) * time.Millisecond,
+	}, nil
+}
+
+// NewRedisStore returns a Store keeping the buckets in the Redis server at rawurl, which has the
+// form redis://[:password@]host[:port][/db]. The buckets are shared by every node using the server.
+func NewRedisStore(rawurl string) (Store, error) {
+	c, err := redis.NewClient(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return &redisStore{
+		client: c,
+	}, nil
+}
@@ -0,0 +1,177 @@
+// Package redis is a minimal client of the Redis serialization protocol for the few commands the
+// daemon needs to share state between nodes
+package redis
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error is an error reply of Redis
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// Client is a single connection to a Redis server which is opened on the first command and
+// opened again after it failed, commands are sent one at a time
+type Client struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+
+	mtx  sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// Do sends a command and returns its reply, which is a string, an int64, a []interface{} of
+// replies or nil. Error replies are returned as Error.
+func (c *Client) Do(args ...string) (interface{}, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.do(args...)
+}
+
+// do sends a command and reads its reply, the connection is closed on errors other than error
+// replies so the next command reconnects
+func (c *Client) do(args ...string) (interface{}, error) {
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+		if err != nil {
+			return nil, err
+		}
+		c.conn, c.r = conn, bufio.NewReader(conn)
+
+		if c.password != "" {
+			_, err = c.do("AUTH", c.password)
+		}
+		if err == nil && c.db != 0 {
+			_, err = c.do("SELECT", strconv.Itoa(c.db))
+		}
+		if err != nil {
+			c.close()
+			return nil, err
+		}
+	}
+
+	c.conn.SetDeadline(time.Now().Add(c.timeout))
+
+	cmd := fmt.Sprintf("*%d\r\n", len(args))
+	for _, a := range args {
+		cmd += fmt.Sprintf("$%d\r\n%s\r\n", len(a), a)
+	}
+
+	_, err := io.WriteString(c.conn, cmd)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+
+	reply, err := c.read()
+	if _, ok := err.(Error); err != nil && !ok {
+		c.close()
+	}
+	return reply, err
+}
+
+// Close closes the connection, the next command opens a new one
+func (c *Client) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.close()
+	return nil
+}
+
+func (c *Client) close() {
+	if c.conn != nil {
+		c.conn.Close()
+		c.conn, c.r = nil, nil
+	}
+}
+
+// read reads a reply in the Redis serialization protocol
+func (c *Client) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, errors.New("redis: malformed reply")
+	}
+	typ, line := line[0], line[1:len(line)-2]
+
+	switch typ {
+	case '+':
+		return line, nil
+	case '-':
+		return nil, Error(line)
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		_, err = io.ReadFull(c.r, buf)
+		if err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line)
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			values[i], err = c.read()
+			if err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", typ)
+}
+
+// NewClient returns a Client of the Redis server at rawurl, which has the form
+// redis://[:password@]host[:port][/db]
+func NewClient(rawurl string) (*Client, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("%s is no redis url", rawurl)
+	}
+
+	c := &Client{
+		addr:    u.Host,
+		timeout: time.Second,
+	}
+	if u.Port() == "" {
+		c.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		c.password, _ = u.User.Password()
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		c.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf("invalid database %s", db)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,45 @@
+package redis_test
+
+import (
+	"github.com/kontainerooo/kontainer.ooo/pkg/redis"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redis", func() {
+	It("Should authenticate, select the database and decode the replies", func() {
+		url, commands := testutils.FakeRedis("+OK\r\n", "+OK\r\n", "*4\r\n+OK\r\n:7\r\n$5\r\nhello\r\n$-1\r\n")
+		c, err := redis.NewClient(url)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		reply, err := c.Do("GET", "key")
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(reply).To(Equal([]interface{}{"OK", int64(7), "hello", nil}))
+
+		Expect(<-commands).To(Equal([]string{"AUTH", "secret"}))
+		Expect(<-commands).To(Equal([]string{"SELECT", "2"}))
+		Expect(<-commands).To(Equal([]string{"GET", "key"}))
+	})
+
+	It("Should return error replies and keep the connection", func() {
+		url, _ := testutils.FakeRedis("+OK\r\n", "+OK\r\n", "-ERR wrong type\r\n", "$2\r\nok\r\n")
+		c, _ := redis.NewClient(url)
+
+		_, err := c.Do("GET", "key")
+		Ω(err).Should(Equal(redis.Error("ERR wrong type")))
+
+		reply, err := c.Do("GET", "key")
+		Ω(err).ShouldNot(HaveOccurred())
+		Expect(reply).To(Equal("ok"))
+	})
+
+	It("Should only accept redis urls", func() {
+		_, err := redis.NewClient("http://localhost")
+		Ω(err).Should(HaveOccurred())
+
+		_, err = redis.NewClient("redis://localhost/db")
+		Ω(err).Should(HaveOccurred())
+	})
+})
@@ -0,0 +1,529 @@
+// Package registry provides a read-only pull-through cache of a Docker registry. The blobs and manifests
+// pulled through it are kept on disk, so pulls of the same images by the docker daemons of the nodes are
+// answered locally instead of by the upstream registry.
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/go-kit/kit/metrics"
+	kmetrics "github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+// maxManifest limits the size of the manifests, they are read into memory
+const maxManifest = 4 << 20
+
+var (
+	nameExp   = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagExp    = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	digestExp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// Options configures a Cache
+type Options struct {
+	// Upstream is the URL of the registry the cache pulls through, e.g. https://registry-1.docker.io
+	Upstream string
+	// Username and Password are the credentials for the upstream registry, pulls are anonymous without them
+	Username string
+	Password string
+	// Secrets keeps the Password, a plaintext Password is put into it when the cache is created and only taken
+	// out of it to authenticate with the upstream registry. It defaults to secret.Plaintext.
+	Secrets secret.Backend
+	// Root is the directory the blobs and manifests are kept in
+	Root string
+	// Quota is the number of bytes the blobs may take up, the least recently pulled ones are removed beyond it
+	Quota int64
+	// ManifestTTL is how long the manifest of a tag is served without asking the upstream registry for it again,
+	// manifests pulled by digest never change
+	ManifestTTL time.Duration
+	// Unused is how long blobs and manifests are kept after they were pulled the last time
+	Unused time.Duration
+}
+
+// manifest is a manifest pulled from the upstream registry
+type manifest struct {
+	MediaType string
+	Digest    string
+	Body      []byte
+	Fetched   time.Time
+}
+
+// upstreamError is an answer of the upstream registry other than 200, it is passed on to the client
+type upstreamError struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+func (e *upstreamError) Error() string {
+	return fmt.Sprintf("upstream registry answered with %d", e.status)
+}
+
+// Cache is a pull-through cache of a registry, it serves the pull endpoints of the registry API. Blobs are
+// kept by their digest, the least recently pulled ones are removed if the cache exceeds its quota.
+type Cache struct {
+	options  Options
+	upstream *upstream
+	logger   log.Logger
+
+	mtx  sync.Mutex
+	size int64
+
+	hits      metrics.Counter
+	misses    metrics.Counter
+	served    metrics.Counter
+	evictions metrics.Counter
+}
+
+// countingWriter counts the bytes written to a response
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+func digestOf(b []byte) string {
+	h := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+// writeError answers with an error in the format of the registry API
+func writeError(w http.ResponseWriter, status int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{
+			"code":    code,
+			"message": message,
+		}},
+	})
+}
+
+// writeUpstreamError passes an error of the upstream registry on, its challenges are dropped since
+// the clients do not authenticate with the cache
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	e, ok := err.(*upstreamError)
+	if !ok {
+		writeError(w, http.StatusBadGateway, "UNAVAILABLE", err.Error())
+		return
+	}
+	if e.contentType != "" {
+		w.Header().Set("Content-Type", e.contentType)
+	}
+	w.WriteHeader(e.status)
+	w.Write(e.body)
+}
+
+func readUpstreamError(res *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1<<16))
+	return &upstreamError{
+		status:      res.StatusCode,
+		contentType: res.Header.Get("Content-Type"),
+		body:        body,
+	}
+}
+
+// parsePath splits the path of a pull into the name of the repository, manifests or blobs and the reference
+func parsePath(path string) (name string, kind string, ref string, ok bool) {
+	path = strings.TrimPrefix(path, "/v2/")
+	for _, k := range []string{"manifests", "blobs"} {
+		i := strings.LastIndex(path, "/"+k+"/")
+		if i > 0 {
+			name, kind, ref = path[:i], k, path[i+len(k)+2:]
+			break
+		}
+	}
+
+	switch {
+	case kind == "" || !nameExp.MatchString(name):
+		return "", "", "", false
+	case kind == "blobs":
+		return name, kind, ref, digestExp.MatchString(ref)
+	default:
+		return name, kind, ref, digestExp.MatchString(ref) || tagExp.MatchString(ref)
+	}
+}
+
+// accepts checks whether mediaType is one of the media types of the Accept headers
+func accepts(header []string, mediaType string) bool {
+	if len(header) == 0 {
+		return true
+	}
+	for _, h := range header {
+		for _, t := range strings.Split(h, ",") {
+			t = strings.TrimSpace(strings.SplitN(t, ";", 2)[0])
+			if t == mediaType || t == "*/*" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Cache) blobPath(digest string) string {
+	return filepath.Join(c.options.Root, "blobs", "sha256", strings.TrimPrefix(digest, "sha256:"))
+}
+
+func (c *Cache) manifestPath(name string, ref string) string {
+	h := sha256.Sum256([]byte(name + "@" + ref))
+	return filepath.Join(c.options.Root, "manifests", hex.EncodeToString(h[:]))
+}
+
+// touch marks a file as pulled now
+func touch(path string) {
+	now := time.Now()
+	os.Chtimes(path, now, now)
+}
+
+// ServeHTTP serves the pulls of manifests and blobs, the other requests of the registry API are rejected
+func (c *Cache) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+	if r.Method != "GET" && r.Method != "HEAD" {
+		writeError(w, http.StatusMethodNotAllowed, "UNSUPPORTED", "the registry cache is read-only")
+		return
+	}
+
+	if r.URL.Path == "/v2/" || r.URL.Path == "/v2" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+		return
+	}
+
+	name, kind, ref, ok := parsePath(r.URL.Path)
+	if !ok {
+		writeError(w, http.StatusNotFound, "NAME_INVALID", "invalid repository name or reference")
+		return
+	}
+
+	if kind == "manifests" {
+		c.serveManifest(w, r, name, ref)
+	} else {
+		c.serveBlob(w, r, name, ref)
+	}
+}
+
+func (c *Cache) loadManifest(name string, ref string) (manifest, bool) {
+	m := manifest{}
+	b, err := ioutil.ReadFile(c.manifestPath(name, ref))
+	if err != nil {
+		return m, false
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err == nil
+}
+
+func (c *Cache) storeManifest(name string, ref string, m manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Join(c.options.Root, "tmp"), "manifest")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.manifestPath(name, ref))
+}
+
+// fetchManifest pulls a manifest from the upstream registry and stores it under its reference and its digest
+func (c *Cache) fetchManifest(r *http.Request, name string, ref string) (manifest, error) {
+	header := http.Header{}
+	for _, a := range r.Header["Accept"] {
+		header.Add("Accept", a)
+	}
+
+	res, err := c.upstream.do(r.Context(), "GET", name, fmt.Sprintf("/v2/%s/manifests/%s", name, ref), header)
+	if err != nil {
+		return manifest{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return manifest{}, readUpstreamError(res)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(res.Body, maxManifest+1))
+	if err != nil {
+		return manifest{}, err
+	}
+	if len(body) > maxManifest {
+		return manifest{}, fmt.Errorf("manifest %s of %s exceeds %d bytes", ref, name, maxManifest)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		return manifest{}, fmt.Errorf("manifest %s of %s: %v", ref, name, err)
+	}
+
+	m := manifest{
+		MediaType: mediaType,
+		Digest:    digestOf(body),
+		Body:      body,
+		Fetched:   time.Now().UTC(),
+	}
+	if digestExp.MatchString(ref) && m.Digest != ref {
+		return manifest{}, fmt.Errorf("manifest %s of %s has the digest %s", ref, name, m.Digest)
+	}
+
+	err = c.storeManifest(name, ref, m)
+	if err == nil && ref != m.Digest {
+		err = c.storeManifest(name, m.Digest, m)
+	}
+	if err != nil {
+		level.Error(c.logger).Log("name", name, "manifest", ref, "err", err)
+	}
+	return m, nil
+}
+
+// serveManifest answers with the cached manifest unless the manifest of a tag is older than ManifestTTL.
+// Stale manifests are served if the upstream registry is not available.
+func (c *Cache) serveManifest(w http.ResponseWriter, r *http.Request, name string, ref string) {
+	cached, found := c.loadManifest(name, ref)
+	found = found && accepts(r.Header["Accept"], cached.MediaType)
+
+	m, source := cached, "cache"
+	if !found || (!digestExp.MatchString(ref) && time.Since(cached.Fetched) >= c.options.ManifestTTL) {
+		fetched, err := c.fetchManifest(r, name, ref)
+		e, answered := err.(*upstreamError)
+		switch {
+		case err == nil:
+			m, source = fetched, "upstream"
+		case found && (!answered || e.status >= http.StatusInternalServerError):
+			level.Warn(c.logger).Log("name", name, "manifest", ref, "msg", "serving stale manifest", "err", err)
+		default:
+			c.misses.With("kind", "manifest").Add(1)
+			writeUpstreamError(w, err)
+			return
+		}
+	}
+
+	if source == "cache" {
+		c.hits.With("kind", "manifest").Add(1)
+		touch(c.manifestPath(name, ref))
+	} else {
+		c.misses.With("kind", "manifest").Add(1)
+	}
+
+	w.Header().Set("Content-Type", m.MediaType)
+	w.Header().Set("Docker-Content-Digest", m.Digest)
+	w.Header().Set("Content-Length", strconv.Itoa(len(m.Body)))
+	if r.Method == "HEAD" {
+		return
+	}
+	w.Write(m.Body)
+	c.served.With("source", source).Add(float64(len(m.Body)))
+}
+
+// serveBlob answers with the cached blob, blobs which are not cached yet are streamed to the client while
+// they are pulled from the upstream registry
+func (c *Cache) serveBlob(w http.ResponseWriter, r *http.Request, name string, digest string) {
+	path := c.blobPath(digest)
+	f, err := os.Open(path)
+	if err == nil {
+		defer f.Close()
+		touch(path)
+		c.hits.With("kind", "blob").Add(1)
+
+		cw := &countingWriter{ResponseWriter: w}
+		cw.Header().Set("Content-Type", "application/octet-stream")
+		cw.Header().Set("Docker-Content-Digest", digest)
+		http.ServeContent(cw, r, "", time.Time{}, f)
+		c.served.With("source", "cache").Add(float64(cw.n))
+		return
+	}
+	c.misses.With("kind", "blob").Add(1)
+
+	res, err := c.upstream.do(r.Context(), r.Method, name, fmt.Sprintf("/v2/%s/blobs/%s", name, digest), nil)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		writeUpstreamError(w, readUpstreamError(res))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Docker-Content-Digest", digest)
+	if res.ContentLength >= 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(res.ContentLength, 10))
+	}
+	if r.Method == "HEAD" {
+		return
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Join(c.options.Root, "tmp"), "blob")
+	if err != nil {
+		level.Error(c.logger).Log("name", name, "blob", digest, "err", err)
+		n, _ := io.Copy(w, res.Body)
+		c.served.With("source", "upstream").Add(float64(n))
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	cw := &countingWriter{ResponseWriter: w}
+	_, err = io.Copy(io.MultiWriter(tmp, h, cw), res.Body)
+	c.served.With("source", "upstream").Add(float64(cw.n))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return
+	}
+
+	// the client checks the digest as well, the blob is only not cached
+	if "sha256:"+hex.EncodeToString(h.Sum(nil)) != digest {
+		level.Error(c.logger).Log("name", name, "blob", digest, "err", "digest mismatch")
+		return
+	}
+
+	err = c.store(tmp.Name(), digest)
+	if err != nil {
+		level.Error(c.logger).Log("name", name, "blob", digest, "err", err)
+	}
+}
+
+// store moves a pulled blob into the cache and removes the least recently pulled blobs exceeding the quota
+func (c *Cache) store(tmp string, digest string) error {
+	fi, err := os.Stat(tmp)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	path := c.blobPath(digest)
+	_, err = os.Stat(path)
+	if err == nil {
+		// it was pulled by another client meanwhile
+		return nil
+	}
+
+	err = os.Rename(tmp, path)
+	if err != nil {
+		return err
+	}
+	c.size += fi.Size()
+	_, err = c.evict(digest)
+	return err
+}
+
+// evict removes the least recently pulled blobs until the cache fits its quota, keep is not removed
+func (c *Cache) evict(keep string) (removed int, err error) {
+	if c.options.Quota <= 0 || c.size <= c.options.Quota {
+		return 0, nil
+	}
+
+	blobs, err := c.blobs()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, b := range blobs {
+		if c.size <= c.options.Quota {
+			break
+		}
+		if "sha256:"+b.Name() == keep {
+			continue
+		}
+
+		err = os.Remove(c.blobPath("sha256:" + b.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		c.size -= b.Size()
+		c.evictions.With("reason", "quota").Add(1)
+		removed++
+	}
+	return removed, nil
+}
+
+// Size returns the number of bytes the cached blobs take up
+func (c *Cache) Size() int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.size
+}
+
+// NewCache returns a Cache of the registry Upstream, the blobs already kept in Root are served as well
+func NewCache(o Options, p kmetrics.Provider, logger log.Logger) (*Cache, error) {
+	if o.Secrets == nil {
+		o.Secrets = secret.Plaintext
+	}
+
+	var err error
+	if o.Password != "" && !secret.IsSealed(o.Password) {
+		o.Password, err = o.Secrets.Put(0, "registry", o.Password)
+		if err != nil {
+			return nil, fmt.Errorf("registry password: %v", err)
+		}
+	}
+
+	u, err := newUpstream(o.Upstream, o.Username, o.Password, o.Secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	// pulls interrupted by a restart leave temporary files behind
+	err = os.RemoveAll(filepath.Join(o.Root, "tmp"))
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{"tmp", "manifests", filepath.Join("blobs", "sha256")} {
+		err = os.MkdirAll(filepath.Join(o.Root, dir), 0700)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c := &Cache{
+		options:   o,
+		upstream:  u,
+		logger:    logger,
+		hits:      p.NewCounter("registry_cache_hits_total", "Number of pulls answered by the registry cache.", "kind"),
+		misses:    p.NewCounter("registry_cache_misses_total", "Number of pulls the registry cache passed to the upstream registry.", "kind"),
+		served:    p.NewCounter("registry_cache_served_bytes_total", "Number of bytes the registry cache answered pulls with.", "source"),
+		evictions: p.NewCounter("registry_cache_evictions_total", "Number of blobs removed from the registry cache.", "reason"),
+	}
+
+	blobs, err := c.blobs()
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range blobs {
+		c.size += b.Size()
+	}
+	return c, nil
+}
@@ -0,0 +1,71 @@
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// blobs returns the cached blobs, the least recently pulled first
+func (c *Cache) blobs() ([]os.FileInfo, error) {
+	blobs, err := ioutil.ReadDir(filepath.Join(c.options.Root, "blobs", "sha256"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(blobs, func(i, j int) bool {
+		return blobs[i].ModTime().Before(blobs[j].ModTime())
+	})
+	return blobs, nil
+}
+
+// removeUnused removes the files of dir which were not modified since before
+func removeUnused(dir string, before time.Time) (removed int, freed int64, err error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, f := range files {
+		if !f.ModTime().Before(before) {
+			continue
+		}
+		err = os.Remove(filepath.Join(dir, f.Name()))
+		if err != nil && !os.IsNotExist(err) {
+			return removed, freed, err
+		}
+		removed++
+		freed += f.Size()
+	}
+	return removed, freed, nil
+}
+
+// GC removes the blobs and manifests which were not pulled for Unused and the least recently pulled blobs
+// exceeding the quota. It returns the number of removed blobs.
+func (c *Cache) GC() (int, error) {
+	before := time.Now().Add(-c.options.Unused)
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	removed, freed, err := removeUnused(filepath.Join(c.options.Root, "blobs", "sha256"), before)
+	c.size -= freed
+	c.evictions.With("reason", "unused").Add(float64(removed))
+	if err != nil {
+		return removed, err
+	}
+
+	_, _, err = removeUnused(filepath.Join(c.options.Root, "manifests"), before)
+	if err != nil {
+		return removed, err
+	}
+
+	// pulls which did not finish for that long are abandoned
+	_, _, err = removeUnused(filepath.Join(c.options.Root, "tmp"), before)
+	if err != nil {
+		return removed, err
+	}
+	evicted, err := c.evict("")
+	return removed + evicted, err
+}
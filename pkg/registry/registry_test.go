@@ -0,0 +1,286 @@
+package registry_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+	"github.com/kontainerooo/kontainer.ooo/pkg/registry"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const manifestType = "application/vnd.docker.distribution.manifest.v2+json"
+
+func digest(content string) string {
+	h := sha256.Sum256([]byte(content))
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+// mockRegistry serves the blobs and manifests of library/alpine to clients with a token of its token service
+type mockRegistry struct {
+	mtx       sync.Mutex
+	blobs     map[string]string
+	manifests map[string]string
+	requests  map[string]int
+	tokens    int
+	password  string
+	down      bool
+	server    *httptest.Server
+}
+
+func (m *mockRegistry) pulls(path string) int {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	return m.requests[path]
+}
+
+func (m *mockRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if r.URL.Path == "/token" {
+		m.tokens++
+		_, m.password, _ = r.BasicAuth()
+		fmt.Fprint(w, `{"token":"t","expires_in":300}`)
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer t" {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry.test",scope="repository:library/alpine:pull"`, m.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	m.requests[r.URL.Path]++
+	if m.down {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	parts := strings.Split(r.URL.Path, "/")
+	ref := parts[len(parts)-1]
+	if manifest, ok := m.manifests[ref]; ok && strings.Contains(r.URL.Path, "/manifests/") {
+		w.Header().Set("Content-Type", manifestType)
+		fmt.Fprint(w, manifest)
+		return
+	}
+	if blob, ok := m.blobs[ref]; ok && strings.Contains(r.URL.Path, "/blobs/") {
+		fmt.Fprint(w, blob)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, `{"errors":[{"code":"MANIFEST_UNKNOWN"}]}`)
+}
+
+var _ = Describe("Cache", func() {
+	var (
+		root     string
+		upstream *mockRegistry
+		options  registry.Options
+		c        *registry.Cache
+	)
+
+	blobA, blobB := "layer-a", "layer-b"
+
+	BeforeEach(func() {
+		var err error
+		root, err = ioutil.TempDir("", "registry")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		upstream = &mockRegistry{
+			blobs: map[string]string{
+				digest(blobA): blobA,
+				digest(blobB): blobB,
+			},
+			manifests: map[string]string{
+				"3.18": `{"schemaVersion":2}`,
+			},
+			requests: map[string]int{},
+		}
+		upstream.server = httptest.NewServer(upstream)
+
+		options = registry.Options{
+			Upstream:    upstream.server.URL,
+			Root:        root,
+			ManifestTTL: time.Hour,
+			Unused:      time.Hour,
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		c, err = registry.NewCache(options, metrics.NewDiscardProvider(), log.NewNopLogger())
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		upstream.server.Close()
+		os.RemoveAll(root)
+	})
+
+	pull := func(method string, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Accept", manifestType)
+		rec := httptest.NewRecorder()
+		c.ServeHTTP(rec, req)
+		return rec
+	}
+
+	It("Should answer the version check of the registry API", func() {
+		rec := pull("GET", "/v2/")
+		Ω(rec.Code).Should(Equal(http.StatusOK))
+		Ω(rec.Header().Get("Docker-Distribution-API-Version")).Should(Equal("registry/2.0"))
+	})
+
+	It("Should reject pushes", func() {
+		Ω(pull("PUT", "/v2/library/alpine/manifests/3.18").Code).Should(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("Should pull blobs through the cache", func() {
+		path := "/v2/library/alpine/blobs/" + digest(blobA)
+		for i := 0; i < 2; i++ {
+			rec := pull("GET", path)
+			Ω(rec.Code).Should(Equal(http.StatusOK))
+			Ω(rec.Body.String()).Should(Equal(blobA))
+			Ω(rec.Header().Get("Docker-Content-Digest")).Should(Equal(digest(blobA)))
+		}
+		Ω(upstream.pulls(path)).Should(Equal(1))
+		Ω(upstream.tokens).Should(Equal(1))
+		Ω(c.Size()).Should(Equal(int64(len(blobA))))
+
+		By("keeping the blobs across restarts")
+		c, err := registry.NewCache(options, metrics.NewDiscardProvider(), log.NewNopLogger())
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(c.Size()).Should(Equal(int64(len(blobA))))
+	})
+
+	It("Should not cache blobs whose digest does not match", func() {
+		upstream.blobs[digest(blobA)] = "corrupted"
+		path := "/v2/library/alpine/blobs/" + digest(blobA)
+		pull("GET", path)
+		pull("GET", path)
+		Ω(upstream.pulls(path)).Should(Equal(2))
+		Ω(c.Size()).Should(BeZero())
+	})
+
+	It("Should pass the errors of the upstream registry on", func() {
+		rec := pull("GET", "/v2/library/alpine/manifests/missing")
+		Ω(rec.Code).Should(Equal(http.StatusNotFound))
+		Ω(rec.Body.String()).Should(ContainSubstring("MANIFEST_UNKNOWN"))
+		Ω(rec.Header().Get("WWW-Authenticate")).Should(BeEmpty())
+
+		Ω(pull("GET", "/v2/library/Alpine/manifests/3.18").Code).Should(Equal(http.StatusNotFound))
+	})
+
+	It("Should cache the manifests of tags", func() {
+		for i := 0; i < 2; i++ {
+			rec := pull("GET", "/v2/library/alpine/manifests/3.18")
+			Ω(rec.Code).Should(Equal(http.StatusOK))
+			Ω(rec.Header().Get("Content-Type")).Should(Equal(manifestType))
+			Ω(rec.Header().Get("Docker-Content-Digest")).Should(Equal(digest(`{"schemaVersion":2}`)))
+		}
+		Ω(upstream.pulls("/v2/library/alpine/manifests/3.18")).Should(Equal(1))
+
+		By("caching them under their digest as well")
+		rec := pull("GET", "/v2/library/alpine/manifests/"+digest(`{"schemaVersion":2}`))
+		Ω(rec.Code).Should(Equal(http.StatusOK))
+		Ω(rec.Body.String()).Should(Equal(`{"schemaVersion":2}`))
+	})
+
+	Context("with expired manifests", func() {
+		BeforeEach(func() {
+			options.ManifestTTL = 0
+		})
+
+		It("Should serve stale manifests while the upstream registry is down", func() {
+			Ω(pull("GET", "/v2/library/alpine/manifests/3.18").Code).Should(Equal(http.StatusOK))
+
+			upstream.mtx.Lock()
+			upstream.down = true
+			upstream.mtx.Unlock()
+
+			rec := pull("GET", "/v2/library/alpine/manifests/3.18")
+			Ω(rec.Code).Should(Equal(http.StatusOK))
+			Ω(rec.Body.String()).Should(Equal(`{"schemaVersion":2}`))
+			Ω(upstream.pulls("/v2/library/alpine/manifests/3.18")).Should(Equal(2))
+		})
+	})
+
+	Context("with a quota", func() {
+		BeforeEach(func() {
+			options.Quota = int64(len(blobA) + len(blobB) - 1)
+		})
+
+		It("Should remove the least recently pulled blobs", func() {
+			pathA, pathB := "/v2/library/alpine/blobs/"+digest(blobA), "/v2/library/alpine/blobs/"+digest(blobB)
+			pull("GET", pathA)
+			pull("GET", pathB)
+			Ω(c.Size()).Should(Equal(int64(len(blobB))))
+
+			pull("GET", pathB)
+			pull("GET", pathA)
+			Ω(upstream.pulls(pathA)).Should(Equal(2))
+			Ω(upstream.pulls(pathB)).Should(Equal(1))
+		})
+	})
+
+	Context("with unused blobs", func() {
+		BeforeEach(func() {
+			options.Unused = 100 * time.Millisecond
+		})
+
+		It("Should remove them", func() {
+			pull("GET", "/v2/library/alpine/blobs/"+digest(blobA))
+			time.Sleep(200 * time.Millisecond)
+			pull("GET", "/v2/library/alpine/blobs/"+digest(blobB))
+
+			removed, err := c.GC()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(removed).Should(Equal(1))
+			Ω(c.Size()).Should(Equal(int64(len(blobB))))
+		})
+	})
+
+	Context("with a password", func() {
+		BeforeEach(func() {
+			options.Username = "user"
+			options.Password = "pass"
+		})
+
+		It("Should authenticate with it", func() {
+			Ω(pull("GET", "/v2/library/alpine/blobs/"+digest(blobA)).Code).Should(Equal(http.StatusOK))
+			Ω(upstream.password).Should(Equal("pass"))
+		})
+	})
+
+	Context("with a sealed password", func() {
+		BeforeEach(func() {
+			keyring, err := secret.NewKeyring(map[string]string{"a": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", secret.DataKeySize)))}, "a")
+			Ω(err).ShouldNot(HaveOccurred())
+			options.Secrets = secret.NewStore(keyring)
+
+			options.Username = "user"
+			options.Password, err = options.Secrets.Put(0, "registry", "pass")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should authenticate with its plaintext", func() {
+			Ω(pull("GET", "/v2/library/alpine/blobs/"+digest(blobA)).Code).Should(Equal(http.StatusOK))
+			Ω(upstream.password).Should(Equal("pass"))
+		})
+	})
+})
@@ -0,0 +1,219 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+// token is a bearer token of the upstream registry
+type token struct {
+	value   string
+	expires time.Time
+}
+
+// upstream is the registry the cache pulls through, it authenticates with the token service of the registry
+// if it answers with a bearer challenge. The password is sealed by secrets.
+type upstream struct {
+	url      *url.URL
+	username string
+	password string
+	secrets  secret.Backend
+	client   *http.Client
+
+	mtx    sync.Mutex
+	tokens map[string]token
+}
+
+// challenge parses the parameters of a WWW-Authenticate header, e.g.
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/alpine:pull"
+func challenge(header string) (scheme string, params map[string]string) {
+	params = map[string]string{}
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	scheme = strings.ToLower(parts[0])
+	if len(parts) == 1 {
+		return scheme, params
+	}
+
+	rest := parts[1]
+	for rest != "" {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = strings.TrimSpace(rest[eq+1:])
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			end := strings.Index(rest, ",")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+		params[key] = value
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return scheme, params
+}
+
+// token requests a bearer token for scope from the realm of a challenge, unless the challenge names the scope itself
+func (u *upstream) token(ctx context.Context, params map[string]string, scope string) (string, error) {
+	key := scope
+	if params["scope"] != "" {
+		scope = params["scope"]
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid realm %q", params["realm"])
+	}
+	q := realm.Query()
+	if params["service"] != "" {
+		q.Set("service", params["service"])
+	}
+	q.Set("scope", scope)
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if u.username != "" {
+		password, err := u.secrets.Get(0, u.password)
+		if err != nil {
+			return "", fmt.Errorf("registry password: %v", err)
+		}
+		req.SetBasicAuth(u.username, password)
+	}
+
+	res, err := u.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token service answered with %s", res.Status)
+	}
+
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}{}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return "", err
+	}
+	if body.Token == "" {
+		body.Token = body.AccessToken
+	}
+	// tokens without expiry are valid for 60 seconds, they are renewed a few seconds early
+	if body.ExpiresIn < 60 {
+		body.ExpiresIn = 60
+	}
+
+	u.mtx.Lock()
+	u.tokens[key] = token{
+		value:   body.Token,
+		expires: time.Now().Add(time.Duration(body.ExpiresIn-10) * time.Second),
+	}
+	u.mtx.Unlock()
+	return body.Token, nil
+}
+
+// do sends a request for path of the repository name to the upstream registry, it is sent again with
+// credentials if the registry asks for them
+func (u *upstream) do(ctx context.Context, method string, name string, path string, header http.Header) (*http.Response, error) {
+	scope := fmt.Sprintf("repository:%s:pull", name)
+	target := u.url.ResolveReference(&url.URL{Path: path}).String()
+
+	send := func(authorization string) (*http.Response, error) {
+		req, err := http.NewRequest(method, target, nil)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		return u.client.Do(req.WithContext(ctx))
+	}
+
+	// tokens are reused until they expire
+	u.mtx.Lock()
+	t, ok := u.tokens[scope]
+	u.mtx.Unlock()
+	authorization := ""
+	if ok && time.Now().Before(t.expires) {
+		authorization = "Bearer " + t.value
+	}
+
+	res, err := send(authorization)
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	scheme, params := challenge(res.Header.Get("WWW-Authenticate"))
+	switch {
+	case scheme == "bearer":
+		res.Body.Close()
+		t, err := u.token(ctx, params, scope)
+		if err != nil {
+			return nil, err
+		}
+		return send("Bearer " + t)
+	case scheme == "basic" && u.username != "":
+		res.Body.Close()
+		password, err := u.secrets.Get(0, u.password)
+		if err != nil {
+			return nil, fmt.Errorf("registry password: %v", err)
+		}
+		return send("Basic " + base64.StdEncoding.EncodeToString([]byte(u.username+":"+password)))
+	}
+	return res, nil
+}
+
+func newUpstream(rawurl string, username string, password string, secrets secret.Backend) (*upstream, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("upstream %q is no http or https URL", rawurl)
+	}
+
+	return &upstream{
+		url:      u,
+		username: username,
+		password: password,
+		secrets:  secrets,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:                 http.ProxyFromEnvironment,
+				ResponseHeaderTimeout: time.Minute,
+				TLSHandshakeTimeout:   10 * time.Second,
+				IdleConnTimeout:       90 * time.Second,
+				MaxIdleConnsPerHost:   16,
+			},
+		},
+		tokens: map[string]token{},
+	}, nil
+}
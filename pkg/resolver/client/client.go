@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/resolver"
+	"github.com/kontainerooo/kontainer.ooo/pkg/resolver/pb"
+)
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCRecordsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/resolver.proto-domain records request to a gRPC Records request.
+func EncodeGRPCRecordsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*resolver.RecordsRequest)
+	return &pb.RecordsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCRecordsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Records response to a messages/resolver.proto-domain records response.
+func DecodeGRPCRecordsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RecordsResponse)
+	records := make([]resolver.Record, len(response.Records))
+	for i, r := range response.Records {
+		records[i] = resolver.ConvertPBRecord(r)
+	}
+
+	return &resolver.RecordsResponse{
+		Records: records,
+		Error:   getError(response.Error),
+		Page:    paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCLookupRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/resolver.proto-domain lookup request to a gRPC Lookup request.
+func EncodeGRPCLookupRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*resolver.LookupRequest)
+	return &pb.LookupRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// DecodeGRPCLookupResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Lookup response to a messages/resolver.proto-domain lookup response.
+func DecodeGRPCLookupResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.LookupResponse)
+	return &resolver.LookupResponse{
+		Answer: resolver.Answer{
+			Name:        response.Name,
+			Instance:    response.Instance,
+			ContainerID: response.ContainerID,
+			Addresses:   resolver.ConvertPBAddresses(response.Addresses),
+		},
+		Error: getError(response.Error),
+	}, nil
+}
@@ -0,0 +1,31 @@
+package resolver
+
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Record points the name of an instance to its container inside of the bridge network of its user
+type Record struct {
+	ID          uint `gorm:"primary_key"`
+	RefID       uint
+	Name        string
+	ContainerID string
+	CreatedAt   time.Time
+}
+
+// TableName sets Record's database table name
+func (Record) TableName() string {
+	return "resolver_records"
+}
+
+// Answer is the result of looking a name up in the network of a user
+type Answer struct {
+	// Name is the fully qualified name which was looked up
+	Name        string
+	Instance    string
+	ContainerID string
+	// Addresses are the addresses of the container, it has none while it is not attached to the network
+	Addresses []abstraction.Inet
+}
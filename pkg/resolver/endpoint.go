@@ -0,0 +1,71 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// RecordsRequest is the request struct for the RecordsEndpoint
+type RecordsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// RecordsResponse is the response struct for the RecordsEndpoint
+type RecordsResponse struct {
+	Records []Record
+	Error   error
+	Page    paging.Response
+}
+
+// MakeRecordsEndpoint creates a gokit endpoint which invokes Records
+func MakeRecordsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RecordsRequest)
+		records := []Record{}
+		// a user without a reference has no network
+		if req.RefID != 0 {
+			err := s.Records(req.RefID, &records)
+			if err != nil {
+				return RecordsResponse{
+					Error: err,
+				}, nil
+			}
+		}
+
+		page, err := paging.Apply(&records, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return RecordsResponse{
+			Records: records,
+			Page:    page,
+		}, nil
+	}
+}
+
+// LookupRequest is the request struct for the LookupEndpoint
+type LookupRequest struct {
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required"`
+}
+
+// LookupResponse is the response struct for the LookupEndpoint
+type LookupResponse struct {
+	Answer Answer
+	Error  error
+}
+
+// MakeLookupEndpoint creates a gokit endpoint which invokes Lookup
+func MakeLookupEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(LookupRequest)
+		answer, err := s.Lookup(req.RefID, req.Name)
+		return LookupResponse{
+			Answer: answer,
+			Error:  err,
+		}, nil
+	}
+}
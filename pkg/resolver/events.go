@@ -0,0 +1,66 @@
+package resolver
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// EventGroup is the group the resolver services of all nodes subscribe to events with, the records are
+// shared by the nodes, so they are only changed once
+const EventGroup = "resolver"
+
+// Subscribe points the names of instances to their containers once they are created and removes the records
+// of removed containers and deleted users. Replicas are not resolved by the name of their instance.
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	containers, err := bus.Subscribe(events.ContainerEvents, EventGroup, func(e events.Event) error {
+		if e.Topic != events.ContainerCreated && e.Topic != events.ContainerRemoved {
+			return nil
+		}
+
+		c := events.ContainerEvent{}
+		err := e.Decode(&c)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+		if c.Replica {
+			return nil
+		}
+
+		if e.Topic == events.ContainerCreated {
+			err = s.SetRecord(c.RefID, c.Name, c.ContainerID)
+		} else {
+			err = s.RemoveRecord(c.RefID, c.ContainerID)
+		}
+		if err == ErrInvalidName {
+			level.Error(logger).Log("instance", c.Name, "err", err)
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := bus.Subscribe(events.UserDeleted, EventGroup, func(e events.Event) error {
+		u := events.UserEvent{}
+		err := e.Decode(&u)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+
+		err = s.RemoveRecords(u.ID)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", u.ID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		containers.Unsubscribe()
+		return nil, err
+	}
+
+	return []events.Subscription{containers, deleted}, nil
+}
@@ -0,0 +1,198 @@
+package resolver_test
+
+import (
+	"net"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/resolver"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"golang.org/x/net/dns/dnsmessage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// mockNetwork assigns the containers web and db of user 1 and the loopback address to user 1
+type mockNetwork struct{}
+
+func (mockNetwork) Address(refID uint, containerID string) (net.IP, bool, error) {
+	if refID != 1 {
+		return nil, false, nil
+	}
+	switch containerID {
+	case "web":
+		return net.ParseIP("10.0.1.2"), true, nil
+	case "db":
+		return net.ParseIP("10.0.1.3"), true, nil
+	}
+	return nil, false, nil
+}
+
+func (mockNetwork) Owner(ip net.IP) (uint, bool, error) {
+	if ip.IsLoopback() {
+		return 1, true, nil
+	}
+	return 0, false, nil
+}
+
+// query sends a query for name to addr and returns the response
+func query(addr net.Addr, name string) (dnsmessage.Header, []dnsmessage.Resource) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: 42, RecursionDesired: true})
+	b.StartQuestions()
+	b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(name),
+		Type:  dnsmessage.TypeA,
+		Class: dnsmessage.ClassINET,
+	})
+	msg, err := b.Finish()
+	Expect(err).NotTo(HaveOccurred())
+
+	conn, err := net.Dial("udp", addr.String())
+	Expect(err).NotTo(HaveOccurred())
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	_, err = conn.Write(msg)
+	Expect(err).NotTo(HaveOccurred())
+	buf := make([]byte, 512)
+	n, err := conn.Read(buf)
+	Expect(err).NotTo(HaveOccurred())
+
+	res := dnsmessage.Message{}
+	Expect(res.Unpack(buf[:n])).To(Succeed())
+	Expect(res.Header.ID).To(BeEquivalentTo(42))
+	return res.Header, res.Answers
+}
+
+var _ = Describe("Resolver", func() {
+	var s resolver.Service
+
+	BeforeEach(func() {
+		var err error
+		s, err = resolver.NewService(testutils.NewMockDB(), mockNetwork{}, "internal")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	Describe("Records", func() {
+		It("Should point the name of an instance to its container", func() {
+			Expect(s.SetRecord(1, "db.myproject", "db")).To(Succeed())
+
+			a, err := s.Lookup(1, "DB.myproject.internal.")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.Name).To(Equal("db.myproject.internal"))
+			Expect(a.ContainerID).To(Equal("db"))
+			Expect(a.Addresses).To(Equal([]abstraction.Inet{"10.0.1.3"}))
+
+			a, err = s.Lookup(1, "db.myproject")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(a.ContainerID).To(Equal("db"))
+		})
+
+		It("Should scope the names to the network of a user", func() {
+			Expect(s.SetRecord(1, "web", "web")).To(Succeed())
+
+			_, err := s.Lookup(2, "web.internal")
+			Expect(err).To(Equal(resolver.ErrNotFound))
+
+			_, err = s.Lookup(1, "internal")
+			Expect(err).To(Equal(resolver.ErrInvalidName))
+		})
+
+		It("Should keep the record of a moved instance once its old container is removed", func() {
+			Expect(s.SetRecord(1, "web", "old")).To(Succeed())
+			Expect(s.SetRecord(1, "web", "web")).To(Succeed())
+			Expect(s.RemoveRecord(1, "old")).To(Succeed())
+
+			rs := []resolver.Record{}
+			Expect(s.Records(1, &rs)).To(Succeed())
+			Expect(rs).To(HaveLen(1))
+			Expect(rs[0].ContainerID).To(Equal("web"))
+
+			Expect(s.RemoveRecord(1, "web")).To(Succeed())
+			_, err := s.Lookup(1, "web")
+			Expect(err).To(Equal(resolver.ErrNotFound))
+		})
+
+		It("Should remove the records of a user", func() {
+			Expect(s.SetRecord(1, "web", "web")).To(Succeed())
+			Expect(s.SetRecord(1, "db", "db")).To(Succeed())
+			Expect(s.SetRecord(2, "web", "other")).To(Succeed())
+			Expect(s.RemoveRecords(1)).To(Succeed())
+
+			rs := []resolver.Record{}
+			Expect(s.Records(1, &rs)).To(Succeed())
+			Expect(rs).To(BeEmpty())
+			Expect(s.Records(2, &rs)).To(Succeed())
+			Expect(rs).To(HaveLen(1))
+		})
+	})
+
+	Describe("Server", func() {
+		var server *resolver.Server
+		var addr net.Addr
+
+		serve := func(o resolver.ServerOptions) {
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			addr = conn.LocalAddr()
+
+			server = resolver.NewServer(s, mockNetwork{}, o, log.NewNopLogger())
+			go server.Serve(conn)
+		}
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Should answer the queries for the instances of the querying user", func() {
+			Expect(s.SetRecord(1, "web", "web")).To(Succeed())
+			serve(resolver.ServerOptions{Domain: "internal", TTL: 5 * time.Second})
+
+			h, answers := query(addr, "web.internal.")
+			Expect(h.RCode).To(Equal(dnsmessage.RCodeSuccess))
+			Expect(h.Authoritative).To(BeTrue())
+			Expect(answers).To(HaveLen(1))
+			Expect(answers[0].Header.TTL).To(BeEquivalentTo(5))
+			Expect(answers[0].Body.(*dnsmessage.AResource).A).To(Equal([4]byte{10, 0, 1, 2}))
+
+			h, answers = query(addr, "db.internal.")
+			Expect(h.RCode).To(Equal(dnsmessage.RCodeNameError))
+			Expect(answers).To(BeEmpty())
+		})
+
+		It("Should forward other names to the upstream resolver", func() {
+			serve(resolver.ServerOptions{Domain: "internal"})
+			h, _ := query(addr, "kontainer.ooo.")
+			Expect(h.RCode).To(Equal(dnsmessage.RCodeRefused))
+			server.Close()
+
+			upstream, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+			defer upstream.Close()
+			go func() {
+				buf := make([]byte, 512)
+				n, from, err := upstream.ReadFrom(buf)
+				if err != nil {
+					return
+				}
+				m := dnsmessage.Message{}
+				m.Unpack(buf[:n])
+				m.Header.Response = true
+				m.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: m.Questions[0].Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET},
+					Body:   &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}},
+				}}
+				res, _ := m.Pack()
+				upstream.WriteTo(res, from)
+			}()
+
+			serve(resolver.ServerOptions{Domain: "internal", Upstream: upstream.LocalAddr().String()})
+			h, answers := query(addr, "kontainer.ooo.")
+			Expect(h.RCode).To(Equal(dnsmessage.RCodeSuccess))
+			Expect(answers).To(HaveLen(1))
+			Expect(answers[0].Body.(*dnsmessage.AResource).A).To(Equal([4]byte{192, 0, 2, 1}))
+		})
+	})
+})
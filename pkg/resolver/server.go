@@ -0,0 +1,233 @@
+package resolver
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ErrClosed is returned by Serve once the server was closed
+var ErrClosed = errors.New("resolver closed")
+
+// ServerOptions configure how a Server answers queries
+type ServerOptions struct {
+	// Domain is the domain the names of the instances are served under
+	Domain string
+	// Upstream is the resolver queries for names outside of Domain are forwarded to, they are refused if it is empty
+	Upstream string
+	// TTL is how long the answers may be cached
+	TTL time.Duration
+	// Timeout is how long to wait for an answer of Upstream
+	Timeout time.Duration
+}
+
+// Server answers the DNS queries of the containers of a node, names are resolved in the network of the user
+// the address a query was sent from is assigned to
+type Server struct {
+	s       Service
+	network Network
+	options ServerOptions
+	logger  log.Logger
+
+	mtx    sync.Mutex
+	conns  map[net.PacketConn]bool
+	closed bool
+}
+
+// Serve answers the queries read from conn until the server is closed
+func (v *Server) Serve(conn net.PacketConn) error {
+	v.mtx.Lock()
+	if v.closed {
+		v.mtx.Unlock()
+		return ErrClosed
+	}
+	v.conns[conn] = true
+	v.mtx.Unlock()
+
+	buf := make([]byte, 4096)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			v.mtx.Lock()
+			closed := v.closed
+			v.mtx.Unlock()
+			if closed {
+				return ErrClosed
+			}
+			return err
+		}
+
+		query := make([]byte, n)
+		copy(query, buf[:n])
+		go v.handle(conn, addr, query)
+	}
+}
+
+// Close stops serving queries
+func (v *Server) Close() error {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	v.closed = true
+	for conn := range v.conns {
+		conn.Close()
+	}
+	return nil
+}
+
+func (v *Server) handle(conn net.PacketConn, addr net.Addr, query []byte) {
+	var p dnsmessage.Parser
+	h, err := p.Start(query)
+	if err != nil {
+		return
+	}
+	q, err := p.Question()
+	if err != nil {
+		v.reply(conn, addr, h, nil, dnsmessage.RCodeFormatError, nil)
+		return
+	}
+
+	name := strings.ToLower(q.Name.String())
+	if !strings.HasSuffix(name, "."+v.options.Domain+".") {
+		v.forward(conn, addr, h, q, query)
+		return
+	}
+
+	src, ok := addr.(*net.UDPAddr)
+	if !ok {
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeRefused, nil)
+		return
+	}
+	refID, ok, err := v.network.Owner(src.IP)
+	if err != nil {
+		level.Error(v.logger).Log("src", src.IP, "err", err)
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeServerFailure, nil)
+		return
+	}
+	// addresses outside of the bridge networks belong to no user whose instances could be resolved
+	if !ok {
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeRefused, nil)
+		return
+	}
+
+	a, err := v.s.Lookup(refID, name)
+	switch err {
+	case nil:
+	case ErrNotFound, ErrInvalidName:
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeNameError, nil)
+		return
+	default:
+		level.Error(v.logger).Log("name", name, "user", refID, "err", err)
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeServerFailure, nil)
+		return
+	}
+
+	// the bridge networks are IPv4 only, other types are answered without records
+	ips := []net.IP{}
+	if q.Type == dnsmessage.TypeA || q.Type == dnsmessage.TypeALL {
+		for _, address := range a.Addresses {
+			ip := net.ParseIP(string(address)).To4()
+			if ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	v.reply(conn, addr, h, &q, dnsmessage.RCodeSuccess, ips)
+}
+
+// reply answers a query with the A records of ips
+func (v *Server) reply(conn net.PacketConn, addr net.Addr, h dnsmessage.Header, q *dnsmessage.Question, code dnsmessage.RCode, ips []net.IP) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:                 h.ID,
+		Response:           true,
+		OpCode:             h.OpCode,
+		Authoritative:      code == dnsmessage.RCodeSuccess || code == dnsmessage.RCodeNameError,
+		RecursionDesired:   h.RecursionDesired,
+		RecursionAvailable: v.options.Upstream != "",
+		RCode:              code,
+	})
+	b.EnableCompression()
+
+	err := b.StartQuestions()
+	if err == nil && q != nil {
+		err = b.Question(*q)
+	}
+	if err == nil {
+		err = b.StartAnswers()
+	}
+	for _, ip := range ips {
+		if err != nil {
+			break
+		}
+		a := dnsmessage.AResource{}
+		copy(a.A[:], ip)
+		err = b.AResource(dnsmessage.ResourceHeader{
+			Name:  q.Name,
+			Type:  dnsmessage.TypeA,
+			Class: dnsmessage.ClassINET,
+			TTL:   uint32(v.options.TTL / time.Second),
+		}, a)
+	}
+
+	var msg []byte
+	if err == nil {
+		msg, err = b.Finish()
+	}
+	if err != nil {
+		level.Error(v.logger).Log("query", h.ID, "err", err)
+		return
+	}
+	conn.WriteTo(msg, addr)
+}
+
+// forward relays a query to the upstream resolver and its answer back
+func (v *Server) forward(conn net.PacketConn, addr net.Addr, h dnsmessage.Header, q dnsmessage.Question, query []byte) {
+	if v.options.Upstream == "" {
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeRefused, nil)
+		return
+	}
+
+	up, err := net.DialTimeout("udp", v.options.Upstream, v.options.Timeout)
+	if err != nil {
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeServerFailure, nil)
+		return
+	}
+	defer up.Close()
+
+	up.SetDeadline(time.Now().Add(v.options.Timeout))
+	_, err = up.Write(query)
+	if err != nil {
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeServerFailure, nil)
+		return
+	}
+
+	buf := make([]byte, 4096)
+	n, err := up.Read(buf)
+	if err != nil {
+		v.reply(conn, addr, h, &q, dnsmessage.RCodeServerFailure, nil)
+		return
+	}
+	conn.WriteTo(buf[:n], addr)
+}
+
+// NewServer returns a Server resolving the names of the instances with s
+func NewServer(s Service, nw Network, o ServerOptions, logger log.Logger) *Server {
+	o.Domain = strings.ToLower(strings.Trim(o.Domain, "."))
+	if o.Timeout == 0 {
+		o.Timeout = 5 * time.Second
+	}
+
+	return &Server{
+		s:       s,
+		network: nw,
+		options: o,
+		logger:  logger,
+		conns:   make(map[net.PacketConn]bool),
+	}
+}
@@ -0,0 +1,236 @@
+// Package resolver lets the containers of a user resolve their sibling instances by name, e.g. db.myproject.internal.
+// Names are scoped to the bridge network of a user: the records are kept up to date by the container
+// lifecycle events and every node answers the queries of its containers on the gateways of their networks.
+package resolver
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+var (
+	// ErrInvalidName occurs if a name is not part of the domain of the networks
+	ErrInvalidName = errors.New("name is not part of the internal domain")
+
+	// ErrNotFound occurs if no instance of a user has the name
+	ErrNotFound = errors.New("name does not exist")
+)
+
+// Service ResolverService
+type Service interface {
+	// SetRecord points the name of an instance to its container, a record pointing to another container is replaced
+	SetRecord(refID uint, name string, containerID string) error
+
+	// RemoveRecord removes the record pointing to a container
+	RemoveRecord(refID uint, containerID string) error
+
+	// RemoveRecords removes every record of a user
+	RemoveRecords(refID uint) error
+
+	// Records returns the records of a user
+	Records(refID uint, r *[]Record) error
+
+	// Lookup resolves a name in the network of a user, the domain may be left out
+	Lookup(refID uint, name string) (Answer, error)
+}
+
+// Network resolves the addresses of the bridge networks of the users
+type Network interface {
+	// Address returns the address of a container in the bridge network of its user, ok is false if it has none
+	Address(refID uint, containerID string) (ip net.IP, ok bool, err error)
+
+	// Owner returns the user whose bridge network ip is assigned in, ok is false if it is not assigned
+	Owner(ip net.IP) (refID uint, ok bool, err error)
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	network Network
+	domain  string
+	mtx     *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Record{})
+}
+
+// records returns the records of a user
+func (s *service) records(refID uint) ([]Record, error) {
+	rs := []Record{}
+	err := s.db.Find(&rs, "ref_id = ?", refID)
+	if err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+// record returns the record of an instance of a user, ok is false if it has none
+func (s *service) record(refID uint, instance string) (r Record, ok bool, err error) {
+	err = s.db.First(&r, "ref_id = ? AND name = ?", refID, instance)
+	if s.db.IsNotFound(err) {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+	return r, true, nil
+}
+
+// instance returns the instance name of a fully qualified or relative name, names are case-insensitive like
+// DNS names and may contain dots, so db.myproject.internal is the name of the instance db.myproject
+func (s *service) instance(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	name = strings.TrimSuffix(name, "."+s.domain)
+	if name == "" || name == s.domain {
+		return "", ErrInvalidName
+	}
+	return name, nil
+}
+
+func (s *service) SetRecord(refID uint, name string, containerID string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setRecord(refID, name, containerID)
+}
+
+func (s *service) setRecord(refID uint, name string, containerID string) error {
+	name, err := s.instance(name)
+	if err != nil {
+		return err
+	}
+
+	r, ok, err := s.record(refID, name)
+	if err != nil {
+		return err
+	}
+	if ok && r.ContainerID == containerID {
+		return nil
+	}
+
+	err = s.db.Delete(&Record{}, "ref_id = ? AND name = ?", refID, name)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Create(&Record{
+		RefID:       refID,
+		Name:        name,
+		ContainerID: containerID,
+	})
+}
+
+func (s *service) RemoveRecord(refID uint, containerID string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeRecord(refID, containerID)
+}
+
+func (s *service) removeRecord(refID uint, containerID string) error {
+	// the record of an instance moved to another container points to the new one already and is kept
+	return s.db.Delete(&Record{}, "ref_id = ? AND container_id = ?", refID, containerID)
+}
+
+func (s *service) RemoveRecords(refID uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeRecords(refID)
+}
+
+func (s *service) removeRecords(refID uint) error {
+	return s.db.Delete(&Record{}, "ref_id = ?", refID)
+}
+
+func (s *service) Records(refID uint, r *[]Record) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.getRecords(refID, r)
+}
+
+func (s *service) getRecords(refID uint, r *[]Record) error {
+	rs, err := s.records(refID)
+	if err != nil {
+		return err
+	}
+
+	*r = rs
+	return nil
+}
+
+func (s *service) Lookup(refID uint, name string) (Answer, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.lookup(refID, name)
+}
+
+func (s *service) lookup(refID uint, name string) (Answer, error) {
+	instance, err := s.instance(name)
+	if err != nil {
+		return Answer{}, err
+	}
+
+	r, ok, err := s.record(refID, instance)
+	if err != nil {
+		return Answer{}, err
+	}
+	if !ok {
+		return Answer{}, ErrNotFound
+	}
+
+	a := Answer{
+		Name:        instance + "." + s.domain,
+		Instance:    instance,
+		ContainerID: r.ContainerID,
+		Addresses:   []abstraction.Inet{},
+	}
+
+	ip, ok, err := s.network.Address(refID, r.ContainerID)
+	if err != nil {
+		return Answer{}, err
+	}
+	if ok {
+		a.Addresses = append(a.Addresses, abstraction.Inet(ip.String()))
+	}
+	return a, nil
+}
+
+// NewService creates a ResolverService serving the names of the instances under domain
+func NewService(db dbAdapter, nw Network, domain string) (Service, error) {
+	s := &service{
+		db:      db,
+		network: nw,
+		domain:  strings.ToLower(strings.Trim(domain, ".")),
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,107 @@
+package resolver
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/resolver/pb"
+)
+
+// ConvertRecord converts a Record to its protobuf representation
+func ConvertRecord(r Record) *pb.Record {
+	return &pb.Record{
+		ID:          uint32(r.ID),
+		Name:        r.Name,
+		ContainerID: r.ContainerID,
+		CreatedAt:   r.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBRecord converts a protobuf Record to a Record
+func ConvertPBRecord(r *pb.Record) Record {
+	if r == nil {
+		return Record{}
+	}
+
+	return Record{
+		ID:          uint(r.ID),
+		Name:        r.Name,
+		ContainerID: r.ContainerID,
+		CreatedAt:   time.Unix(r.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertAddresses converts the addresses of an Answer to strings
+func ConvertAddresses(as []abstraction.Inet) []string {
+	addresses := make([]string, len(as))
+	for i, a := range as {
+		addresses[i] = string(a)
+	}
+	return addresses
+}
+
+// ConvertPBAddresses converts the addresses of a protobuf LookupResponse to Inets
+func ConvertPBAddresses(as []string) []abstraction.Inet {
+	addresses := make([]abstraction.Inet, len(as))
+	for i, a := range as {
+		addresses[i] = abstraction.Inet(a)
+	}
+	return addresses
+}
+
+// DecodeGRPCRecordsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Records request to a messages/resolver.proto-domain records request.
+func DecodeGRPCRecordsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RecordsRequest)
+	return RecordsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCRecordsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/resolver.proto-domain records response to a gRPC Records response.
+func EncodeGRPCRecordsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RecordsResponse)
+	records := make([]*pb.Record, len(res.Records))
+	for i, r := range res.Records {
+		records[i] = ConvertRecord(r)
+	}
+
+	gRPCRes := &pb.RecordsResponse{
+		Records:  records,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCLookupRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Lookup request to a messages/resolver.proto-domain lookup request.
+func DecodeGRPCLookupRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.LookupRequest)
+	return LookupRequest{
+		RefID: uint(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// EncodeGRPCLookupResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/resolver.proto-domain lookup response to a gRPC Lookup response.
+func EncodeGRPCLookupResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(LookupResponse)
+	gRPCRes := &pb.LookupResponse{
+		Name:        res.Answer.Name,
+		Instance:    res.Answer.Instance,
+		ContainerID: res.Answer.ContainerID,
+		Addresses:   ConvertAddresses(res.Answer.Addresses),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
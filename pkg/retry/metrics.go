@@ -0,0 +1,34 @@
+package retry
+
+import (
+	"github.com/go-kit/kit/metrics"
+	kmetrics "github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+)
+
+// Metrics records the retries of operations, a nil Metrics records nothing
+type Metrics struct {
+	retries   metrics.Counter
+	exhausted metrics.Counter
+}
+
+// Retried records that op failed and is attempted again
+func (m *Metrics) Retried(op string) {
+	if m != nil {
+		m.retries.With("operation", op).Add(1)
+	}
+}
+
+// Exhausted records that op failed on its last attempt
+func (m *Metrics) Exhausted(op string) {
+	if m != nil {
+		m.exhausted.With("operation", op).Add(1)
+	}
+}
+
+// NewMetrics returns a Metrics whose metrics are created by p
+func NewMetrics(p kmetrics.Provider) *Metrics {
+	return &Metrics{
+		retries:   p.NewCounter("retries_total", "Number of retries of operations which failed with a transient error.", "operation"),
+		exhausted: p.NewCounter("retries_exhausted_total", "Number of operations which failed on their last attempt.", "operation"),
+	}
+}
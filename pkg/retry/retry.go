@@ -0,0 +1,99 @@
+// Package retry retries operations which failed with a transient error, like a database which is
+// restarting or an object storage dropping a download. The delay between the attempts grows
+// exponentially and is jittered, so callers failing at the same time do not retry in lockstep.
+package retry
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"math/rand"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Policy decides whether and when a failed operation is attempted again
+type Policy struct {
+	// Attempts is the number of attempts including the first one, an operation is attempted once if it is less than 1
+	Attempts int
+	// Backoff is the delay before the first retry, it doubles with every further retry up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Jitter is the fraction of a delay which is randomized, e.g. 0.2 waits between 80% and 120% of it
+	Jitter float64
+	// Retryable reports whether an error is transient, Transient is used if it is nil
+	Retryable func(error) bool
+	// Metrics records the retries, nothing is recorded if it is nil
+	Metrics *Metrics
+}
+
+// DefaultPolicy makes up to five attempts within about ten seconds
+var DefaultPolicy = Policy{
+	Attempts:   5,
+	Backoff:    500 * time.Millisecond,
+	MaxBackoff: 5 * time.Second,
+	Jitter:     0.2,
+}
+
+// Transient reports whether err shows that a backend is briefly unreachable or overloaded
+func Transient(err error) bool {
+	if err == driver.ErrBadConn || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	switch grpc.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+func (p Policy) retryable(err error) bool {
+	if p.Retryable == nil {
+		return Transient(err)
+	}
+	return p.Retryable(err)
+}
+
+// Delay returns the time to wait before the retry with the given number, the first retry is number 1
+func (p Policy) Delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		d += time.Duration(float64(d) * p.Jitter * (2*rand.Float64() - 1))
+	}
+	return d
+}
+
+// Do calls f until it succeeds, fails with an error which is not retryable or used up the attempts of the
+// policy and returns its last error. The retries of op stop once ctx is done.
+func (p Policy) Do(ctx context.Context, op string, f func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := f()
+		if err == nil || !p.retryable(err) {
+			return err
+		}
+		if attempt >= p.Attempts {
+			p.Metrics.Exhausted(op)
+			return err
+		}
+
+		p.Metrics.Retried(op)
+		select {
+		case <-time.After(p.Delay(attempt)):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
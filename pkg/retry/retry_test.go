@@ -0,0 +1,117 @@
+package retry_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io/ioutil"
+	"net/http/httptest"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/metrics"
+	"github.com/kontainerooo/kontainer.ooo/pkg/retry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Retry", func() {
+	policy := retry.Policy{
+		Attempts:   3,
+		Backoff:    time.Millisecond,
+		MaxBackoff: 4 * time.Millisecond,
+	}
+
+	failing := func(calls *int, errs ...error) func() error {
+		return func() error {
+			*calls++
+			if *calls <= len(errs) {
+				return errs[*calls-1]
+			}
+			return nil
+		}
+	}
+
+	Describe("Do", func() {
+		It("Should retry transient errors until the operation succeeds", func() {
+			calls := 0
+			err := policy.Do(context.Background(), "test", failing(&calls, driver.ErrBadConn, grpc.Errorf(codes.Unavailable, "down")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(calls).To(Equal(3))
+		})
+
+		It("Should return the last error once the attempts are used up", func() {
+			calls := 0
+			err := policy.Do(context.Background(), "test", failing(&calls, driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn))
+			Expect(err).To(Equal(driver.ErrBadConn))
+			Expect(calls).To(Equal(3))
+		})
+
+		It("Should not retry errors which are not transient", func() {
+			calls := 0
+			err := policy.Do(context.Background(), "test", failing(&calls, errors.New("invalid")))
+			Expect(err).To(HaveOccurred())
+			Expect(calls).To(Equal(1))
+		})
+
+		It("Should classify the errors with Retryable", func() {
+			p := policy
+			p.Retryable = func(err error) bool {
+				return err.Error() == "busy"
+			}
+
+			calls := 0
+			err := p.Do(context.Background(), "test", failing(&calls, errors.New("busy"), driver.ErrBadConn))
+			Expect(err).To(Equal(driver.ErrBadConn))
+			Expect(calls).To(Equal(2))
+		})
+
+		It("Should stop retrying once the context is done", func() {
+			p := policy
+			p.Backoff = time.Minute
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			calls := 0
+			err := p.Do(ctx, "test", failing(&calls, driver.ErrBadConn))
+			Expect(err).To(Equal(driver.ErrBadConn))
+			Expect(calls).To(Equal(1))
+		})
+
+		It("Should record the retries", func() {
+			provider := metrics.NewPrometheusProvider(metrics.Namespace)
+			p := policy
+			p.Metrics = retry.NewMetrics(provider)
+
+			calls := 0
+			p.Do(context.Background(), "db.connect", failing(&calls, driver.ErrBadConn, driver.ErrBadConn, driver.ErrBadConn))
+
+			rec := httptest.NewRecorder()
+			provider.Handler().ServeHTTP(rec, httptest.NewRequest("GET", metrics.Path, nil))
+			body, _ := ioutil.ReadAll(rec.Body)
+			Expect(string(body)).To(ContainSubstring(`kontainerooo_retries_total{operation="db.connect"} 2`))
+			Expect(string(body)).To(ContainSubstring(`kontainerooo_retries_exhausted_total{operation="db.connect"} 1`))
+		})
+	})
+
+	Describe("Delay", func() {
+		It("Should double the delay up to the maximum", func() {
+			p := retry.Policy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+			Expect(p.Delay(1)).To(Equal(time.Second))
+			Expect(p.Delay(2)).To(Equal(2 * time.Second))
+			Expect(p.Delay(3)).To(Equal(4 * time.Second))
+			Expect(p.Delay(4)).To(Equal(5 * time.Second))
+			Expect(p.Delay(10)).To(Equal(5 * time.Second))
+		})
+
+		It("Should jitter the delay", func() {
+			p := retry.Policy{Backoff: time.Second, MaxBackoff: time.Second, Jitter: 0.2}
+			for i := 0; i < 100; i++ {
+				Expect(p.Delay(1)).To(BeNumerically("~", time.Second, 200*time.Millisecond))
+			}
+		})
+	})
+})
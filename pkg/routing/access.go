@@ -0,0 +1,44 @@
+package routing
+
+import (
+	"github.com/GehirnInc/crypt/sha512_crypt"
+)
+
+// HashCredentials replaces the plain text passwords of all credentials with a crypt(3)
+// compatible SHA-512 hash, which nginx can verify using its auth_basic_user_file
+func (a *AccessOptions) HashCredentials() error {
+	if a == nil {
+		return nil
+	}
+
+	crypter := sha512_crypt.New()
+	for _, c := range a.Credentials {
+		if c == nil || c.Password == "" {
+			continue
+		}
+
+		hash, err := crypter.Generate([]byte(c.Password), nil)
+		if err != nil {
+			return err
+		}
+
+		c.Hash = hash
+		c.Password = ""
+	}
+	return nil
+}
+
+// HashCredentials hashes the credentials of every LocationRule
+func (l LocationRules) HashCredentials() error {
+	for _, r := range l {
+		if r == nil {
+			continue
+		}
+
+		err := r.Access.HashCredentials()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,333 @@
+package acme_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing/acme"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/lib/pq"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const testPath = "acmetest"
+
+type mockIssuer struct {
+	validFor time.Duration
+	err      error
+	calls    int
+	domains  []string
+}
+
+func (m *mockIssuer) Obtain(domains []string) ([]byte, []byte, error) {
+	m.calls++
+	m.domains = domains
+	if m.err != nil {
+		return nil, nil, m.err
+	}
+	return selfSigned(domains, m.validFor)
+}
+
+func selfSigned(domains []string, validFor time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domains[0]},
+		DNSNames:     domains,
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(validFor),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}), nil
+}
+
+var _ = Describe("Acme", func() {
+	var (
+		refID = uint(1)
+		name  = "name"
+		day   = 24 * time.Hour
+	)
+
+	BeforeEach(func() {
+		err := os.RemoveAll(testPath)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(testPath)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("FileStore", func() {
+		It("Should store and return certificates", func() {
+			s, err := acme.NewFileStore(testPath)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			cert, key, _ := selfSigned([]string{"a.kontainer.ooo"}, 90*day)
+			c, err := s.Put(refID, name, cert, key)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(c.Domains).To(ConsistOf("a.kontainer.ooo"))
+
+			info, err := os.Stat(c.KeyPath)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(BeEquivalentTo(0600))
+
+			c, err = s.Get(refID, name)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(c.ExpiresWithin(30 * day)).To(BeFalse())
+			Expect(c.ExpiresWithin(91 * day)).To(BeTrue())
+		})
+
+		It("Should return an error if no certificate exists", func() {
+			s, _ := acme.NewFileStore(testPath)
+			_, err := s.Get(refID, name)
+			Ω(err).Should(BeEquivalentTo(acme.ErrNoCertificate))
+		})
+
+		It("Should remove certificates", func() {
+			s, _ := acme.NewFileStore(testPath)
+			cert, key, _ := selfSigned([]string{"a.kontainer.ooo"}, 90*day)
+			s.Put(refID, name, cert, key)
+
+			err := s.Remove(refID, name)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			_, err = s.Get(refID, name)
+			Ω(err).Should(BeEquivalentTo(acme.ErrNoCertificate))
+		})
+	})
+
+	Describe("EventStore", func() {
+		It("Should publish issued and renewed certificates", func() {
+			bus := events.NewMemoryBus("node1", time.Second, log.NewNopLogger())
+			defer bus.Close()
+
+			topics := make(chan string, 2)
+			bus.Subscribe(events.CertificateEvents, "", func(e events.Event) error {
+				c := events.CertificateEvent{}
+				Ω(e.Decode(&c)).Should(Succeed())
+				Expect(c.Domains).To(ConsistOf("a.kontainer.ooo"))
+				topics <- e.Topic
+				return nil
+			})
+
+			fs, _ := acme.NewFileStore(testPath)
+			s := acme.NewEventStore(fs, bus, log.NewNopLogger())
+
+			cert, key, _ := selfSigned([]string{"a.kontainer.ooo"}, 90*day)
+			_, err := s.Put(refID, name, cert, key)
+			Ω(err).ShouldNot(HaveOccurred())
+			Eventually(topics).Should(Receive(Equal(events.CertificateIssued)))
+
+			_, err = s.Put(refID, name, cert, key)
+			Ω(err).ShouldNot(HaveOccurred())
+			Eventually(topics).Should(Receive(Equal(events.CertificateRenewed)))
+		})
+	})
+
+	Describe("DomainPolicy", func() {
+		It("Should allow subdomains of the base domain", func() {
+			p := acme.NewDomainPolicy("kontainer.ooo", nil)
+			Ω(p(refID, "a.kontainer.ooo")).ShouldNot(HaveOccurred())
+			Ω(p(refID, "kontainer.ooo.evil.com")).Should(HaveOccurred())
+		})
+
+		It("Should allow verified custom domains", func() {
+			p := acme.NewDomainPolicy("kontainer.ooo", func(id uint, domain string) bool {
+				return id == refID && domain == "custom.com"
+			})
+			Ω(p(refID, "custom.com")).ShouldNot(HaveOccurred())
+			Ω(p(2, "custom.com")).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Wildcard", func() {
+		It("Should cover the zone and its direct subdomains", func() {
+			Expect(acme.CoveredByWildcard("kontainer.ooo", "kontainer.ooo")).To(BeTrue())
+			Expect(acme.CoveredByWildcard("kontainer.ooo", "a.kontainer.ooo")).To(BeTrue())
+			Expect(acme.CoveredByWildcard("kontainer.ooo", "b.a.kontainer.ooo")).To(BeFalse())
+			Expect(acme.CoveredByWildcard("kontainer.ooo", "evilkontainer.ooo")).To(BeFalse())
+			Expect(acme.CoveredByWildcard("", "a.kontainer.ooo")).To(BeFalse())
+		})
+
+		It("Should share the challenge name with the base domain", func() {
+			Expect(acme.ChallengeName("*.kontainer.ooo")).To(BeEquivalentTo("_acme-challenge.kontainer.ooo"))
+			Expect(acme.ChallengeName("kontainer.ooo")).To(BeEquivalentTo("_acme-challenge.kontainer.ooo"))
+		})
+	})
+
+	Describe("Service", func() {
+		var (
+			r      routing.Service
+			store  acme.Store
+			issuer *mockIssuer
+			alerts int
+			s      acme.Service
+		)
+
+		BeforeEach(func() {
+			r, _ = routing.NewService(testutils.NewMockDB())
+			r.CreateRouterConfig(&routing.RouterConfig{
+				RefID:      refID,
+				Name:       name,
+				ServerName: pq.StringArray{"a.kontainer.ooo"},
+			})
+
+			store, _ = acme.NewFileStore(testPath)
+			issuer = &mockIssuer{validFor: 90 * day}
+			alerts = 0
+			s = acme.NewService(r, issuer, store, acme.NewDomainPolicy("kontainer.ooo", nil), func(*acme.Certificate, error) {
+				alerts++
+			}, acme.DefaultOptions)
+		})
+
+		It("Should obtain a certificate and wire it into the config", func() {
+			err := s.Manage(refID, name)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			c, err := s.Certificate(refID, name)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := routing.RouterConfig{}
+			r.GetRouterConfig(refID, name, &conf)
+			Expect(conf.SSLSettings.ACME).To(BeTrue())
+			Expect(conf.SSLSettings.Certificate).To(BeEquivalentTo(c.CertPath))
+			Expect(conf.SSLSettings.CertificateKey).To(BeEquivalentTo(c.KeyPath))
+		})
+
+		It("Should refuse domains not allowed by the policy", func() {
+			r.AddServerName(refID, name, "custom.com")
+			err := s.Manage(refID, name)
+			Ω(err).Should(HaveOccurred())
+			Expect(issuer.calls).To(BeZero())
+		})
+
+		It("Should only renew certificates which are about to expire", func() {
+			s.Manage(refID, name)
+			err := s.Renew()
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(issuer.calls).To(BeEquivalentTo(1))
+
+			issuer.validFor = 10 * day
+			s.Manage(refID, name)
+			err = s.Renew()
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(issuer.calls).To(BeEquivalentTo(3))
+		})
+
+		Context("With a wildcard certificate", func() {
+			var reloads int
+
+			BeforeEach(func() {
+				r.CreateRouterConfig(&routing.RouterConfig{
+					RefID:      refID,
+					Name:       "other",
+					ServerName: pq.StringArray{"b.kontainer.ooo"},
+				})
+
+				reloads = 0
+				opts := acme.DefaultOptions
+				opts.Wildcard = "kontainer.ooo"
+				opts.Reload = func() error {
+					reloads++
+					return nil
+				}
+				s = acme.NewService(r, issuer, store, acme.NewDomainPolicy("kontainer.ooo", nil), func(*acme.Certificate, error) {
+					alerts++
+				}, opts)
+			})
+
+			It("Should share one certificate between covered configs", func() {
+				err := s.Manage(refID, name)
+				Ω(err).ShouldNot(HaveOccurred())
+				err = s.Manage(refID, "other")
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(issuer.calls).To(BeEquivalentTo(1))
+				Expect(issuer.domains).To(ConsistOf("*.kontainer.ooo", "kontainer.ooo"))
+				Expect(reloads).To(BeEquivalentTo(2))
+
+				a, b := routing.RouterConfig{}, routing.RouterConfig{}
+				r.GetRouterConfig(refID, name, &a)
+				r.GetRouterConfig(refID, "other", &b)
+				Expect(a.SSLSettings.Certificate).ToNot(BeEmpty())
+				Expect(a.SSLSettings.Certificate).To(BeEquivalentTo(b.SSLSettings.Certificate))
+			})
+
+			It("Should use an own certificate for domains not covered", func() {
+				r.AddServerName(refID, name, "b.a.kontainer.ooo")
+				err := s.Manage(refID, name)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(issuer.domains).To(ConsistOf("a.kontainer.ooo", "b.a.kontainer.ooo"))
+			})
+
+			It("Should renew the wildcard certificate and reload the router", func() {
+				err := s.Renew()
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(issuer.calls).To(BeEquivalentTo(1))
+				Expect(reloads).To(BeEquivalentTo(1))
+
+				err = s.Renew()
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(issuer.calls).To(BeEquivalentTo(1))
+				Expect(reloads).To(BeEquivalentTo(1))
+			})
+
+			It("Should renew a wildcard certificate which is about to expire", func() {
+				issuer.validFor = 10 * day
+				s.Renew()
+
+				err := s.Renew()
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(issuer.calls).To(BeEquivalentTo(2))
+				Expect(reloads).To(BeEquivalentTo(2))
+			})
+		})
+
+		It("Should alert if a renewal fails shortly before expiry", func() {
+			issuer.validFor = 20 * day
+			s.Manage(refID, name)
+
+			issuer.err = errors.New("rate limited")
+			err := s.Renew()
+			Ω(err).Should(HaveOccurred())
+			Expect(alerts).To(BeZero())
+
+			issuer.err = nil
+			issuer.validFor = 10 * day
+			s.Manage(refID, name)
+
+			issuer.err = errors.New("rate limited")
+			err = s.Renew()
+			Ω(err).Should(HaveOccurred())
+			Expect(alerts).To(BeEquivalentTo(1))
+		})
+	})
+})
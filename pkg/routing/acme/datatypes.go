@@ -0,0 +1,69 @@
+// Package acme obtains and renews certificates for routed domains using the ACME protocol
+package acme
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	// LetsEncrypt is the directory url of the Let's Encrypt production environment
+	LetsEncrypt = "https://acme-v02.api.letsencrypt.org/directory"
+
+	// LetsEncryptStaging is the directory url of the Let's Encrypt staging environment
+	LetsEncryptStaging = "https://acme-staging-v02.api.letsencrypt.org/directory"
+)
+
+// Webroot is the directory http-01 challenge responses are written to,
+// the generated router configurations serve /.well-known/acme-challenge/ from it
+var Webroot = "/var/lib/kontainerooo/acme"
+
+// Propagation is the time waited for published dns-01 challenge records to reach every name server
+var Propagation = 30 * time.Second
+
+// ChallengeTTL is the TTL of dns-01 challenge records
+var ChallengeTTL uint = 60
+
+// WildcardName is the name the wildcard certificate is stored under, it belongs to no user
+const WildcardName = "wildcard"
+
+// ChallengeName returns the name of the TXT record of a domain's dns-01 challenge,
+// a wildcard domain shares it with its base domain
+func ChallengeName(domain string) string {
+	return "_acme-challenge." + strings.TrimPrefix(domain, "*.")
+}
+
+// WildcardDomains returns the domains of the wildcard certificate of zone
+func WildcardDomains(zone string) []string {
+	return []string{"*." + zone, zone}
+}
+
+// CoveredByWildcard checks whether the wildcard certificate of zone is valid for domain,
+// which is the case for the zone itself and its direct subdomains
+func CoveredByWildcard(zone string, domain string) bool {
+	if zone == "" {
+		return false
+	}
+
+	if domain == zone {
+		return true
+	}
+
+	label := strings.TrimSuffix(domain, "."+zone)
+	return label != domain && label != "" && !strings.Contains(label, ".")
+}
+
+// Certificate describes a certificate stored for a routing configuration
+type Certificate struct {
+	RefID    uint
+	Name     string
+	Domains  []string
+	CertPath string
+	KeyPath  string
+	NotAfter time.Time
+}
+
+// ExpiresWithin returns true if the certificate expires within the given duration
+func (c *Certificate) ExpiresWithin(d time.Duration) bool {
+	return time.Now().Add(d).After(c.NotAfter)
+}
@@ -0,0 +1,47 @@
+package acme
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+type eventStore struct {
+	Store
+	bus    events.Bus
+	logger log.Logger
+}
+
+func (s *eventStore) Put(refID uint, name string, cert, key []byte) (*Certificate, error) {
+	_, err := s.Store.Get(refID, name)
+	topic := events.CertificateRenewed
+	if err == ErrNoCertificate {
+		topic = events.CertificateIssued
+	}
+
+	c, err := s.Store.Put(refID, name, cert, key)
+	if err != nil {
+		return c, err
+	}
+
+	err = s.bus.Publish(topic, events.CertificateEvent{
+		RefID:    c.RefID,
+		Name:     c.Name,
+		Domains:  c.Domains,
+		NotAfter: c.NotAfter,
+	})
+	if err != nil {
+		level.Error(s.logger).Log("topic", topic, "config", name, "err", err)
+	}
+	return c, nil
+}
+
+// NewEventStore returns a Store which publishes an event once a certificate was stored, it is
+// issued if the configuration had no certificate before and renewed otherwise. Failing to publish is only logged.
+func NewEventStore(s Store, bus events.Bus, logger log.Logger) Store {
+	return &eventStore{
+		Store:  s,
+		bus:    bus,
+		logger: logger,
+	}
+}
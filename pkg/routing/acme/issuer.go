@@ -0,0 +1,380 @@
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/dns"
+	"github.com/kontainerooo/kontainer.ooo/pkg/retry"
+	"golang.org/x/crypto/acme"
+)
+
+// Issuer obtains certificates from a certificate authority
+type Issuer interface {
+	// Obtain returns a pem encoded certificate chain and key valid for all domains
+	Obtain(domains []string) (cert []byte, key []byte, err error)
+}
+
+// ContactIssuer is an Issuer whose account contact can be changed while it is used,
+// the issuers of NewIssuer and NewDNSIssuer implement it
+type ContactIssuer interface {
+	Issuer
+
+	// SetEmail changes the contact of the account, the account is updated with the next certificate obtained
+	SetEmail(email string)
+}
+
+type issuer struct {
+	client   *acme.Client
+	mtx      sync.Mutex
+	email    string
+	changed  bool
+	webroot  string
+	provider dns.Provider
+	zone     string
+	timeout  time.Duration
+	retry    retry.Policy
+}
+
+// retryable reports whether a call to the directory failed because the certificate authority
+// is overloaded or unreachable
+func retryable(err error) bool {
+	if e, ok := err.(*acme.Error); ok {
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+	}
+	return retry.Transient(err)
+}
+
+func (i *issuer) SetEmail(email string) {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+	i.changed = i.changed || email != i.email
+	i.email = email
+}
+
+func (i *issuer) register(ctx context.Context) error {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	acct := &acme.Account{
+		Contact: []string{},
+	}
+	if i.email != "" {
+		acct.Contact = []string{"mailto:" + i.email}
+	}
+
+	err := i.retry.Do(ctx, "acme.Register", func() error {
+		_, err := i.client.Register(ctx, acct, acme.AcceptTOS)
+		return err
+	})
+	if err == acme.ErrAccountAlreadyExists {
+		if !i.changed {
+			return nil
+		}
+		err = i.retry.Do(ctx, "acme.UpdateReg", func() error {
+			_, err := i.client.UpdateReg(ctx, acct)
+			return err
+		})
+	}
+	if err == nil {
+		i.changed = false
+	}
+	return err
+}
+
+func (i *issuer) Obtain(domains []string) ([]byte, []byte, error) {
+	if len(domains) == 0 {
+		return nil, nil, errors.New("no domains given")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), i.timeout)
+	defer cancel()
+
+	err := i.register(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var order *acme.Order
+	err = i.retry.Do(ctx, "acme.AuthorizeOrder", func() (err error) {
+		order, err = i.client.AuthorizeOrder(ctx, acme.DomainIDs(domains...))
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pending := []*acme.Authorization{}
+	for _, url := range order.AuthzURLs {
+		var authz *acme.Authorization
+		err := i.retry.Do(ctx, "acme.GetAuthorization", func() (err error) {
+			authz, err = i.client.GetAuthorization(ctx, url)
+			return err
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if authz.Status == acme.StatusValid {
+			continue
+		}
+
+		if i.provider != nil && i.inZone(authz.Identifier.Value) {
+			pending = append(pending, authz)
+			continue
+		}
+
+		err = i.authorize(ctx, authz)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(pending) != 0 {
+		err = i.authorizeDNS(ctx, pending)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	uri := order.URI
+	err = i.retry.Do(ctx, "acme.WaitOrder", func() (err error) {
+		order, err = i.client.WaitOrder(ctx, uri)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var der [][]byte
+	err = i.retry.Do(ctx, "acme.CreateOrderCert", func() (err error) {
+		der, _, err = i.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cert []byte
+	for _, b := range der {
+		cert = append(cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: b})...)
+	}
+
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, keyPEM, nil
+}
+
+// inZone checks whether the records of domain can be published using the dns provider
+func (i *issuer) inZone(domain string) bool {
+	return domain == i.zone || strings.HasSuffix(domain, "."+i.zone)
+}
+
+func challenge(authz *acme.Authorization, typ string) *acme.Challenge {
+	for _, c := range authz.Challenges {
+		if c.Type == typ {
+			return c
+		}
+	}
+	return nil
+}
+
+func (i *issuer) authorize(ctx context.Context, authz *acme.Authorization) error {
+	chal := challenge(authz, "http-01")
+	if chal == nil {
+		return errors.New("no http-01 challenge offered for " + authz.Identifier.Value)
+	}
+
+	res, err := i.client.HTTP01ChallengeResponse(chal.Token)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(i.webroot, i.client.HTTP01ChallengePath(chal.Token))
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(path, []byte(res), 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	err = i.accept(ctx, chal)
+	if err != nil {
+		return err
+	}
+	return i.wait(ctx, authz)
+}
+
+// accept tells the certificate authority that chal is ready to be validated
+func (i *issuer) accept(ctx context.Context, chal *acme.Challenge) error {
+	return i.retry.Do(ctx, "acme.Accept", func() error {
+		_, err := i.client.Accept(ctx, chal)
+		return err
+	})
+}
+
+// wait waits until authz is valid
+func (i *issuer) wait(ctx context.Context, authz *acme.Authorization) error {
+	return i.retry.Do(ctx, "acme.WaitAuthorization", func() error {
+		_, err := i.client.WaitAuthorization(ctx, authz.URI)
+		return err
+	})
+}
+
+// authorizeDNS solves the dns-01 challenges of all authorizations at once, a wildcard and
+// its base domain share the name of their challenge record, so its values are published together
+func (i *issuer) authorizeDNS(ctx context.Context, authzs []*acme.Authorization) error {
+	records := make(map[string][]string)
+	chals := make([]*acme.Challenge, len(authzs))
+	for n, authz := range authzs {
+		chal := challenge(authz, "dns-01")
+		if chal == nil {
+			return errors.New("no dns-01 challenge offered for " + authz.Identifier.Value)
+		}
+		chals[n] = chal
+
+		value, err := i.client.DNS01ChallengeRecord(chal.Token)
+		if err != nil {
+			return err
+		}
+
+		name := ChallengeName(authz.Identifier.Value)
+		records[name] = append(records[name], value)
+	}
+
+	for name, values := range records {
+		err := i.provider.SetRecords(name, dns.TXT, ChallengeTTL, values)
+		defer i.provider.SetRecords(name, dns.TXT, ChallengeTTL, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	select {
+	case <-time.After(Propagation):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for _, chal := range chals {
+		err := i.accept(ctx, chal)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, authz := range authzs {
+		err := i.wait(ctx, authz)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	b, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}), nil
+}
+
+func accountKey(s Store) (crypto.Signer, error) {
+	b, err := s.AccountKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if b != nil {
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return nil, errors.New("invalid account key")
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err = encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, s.SetAccountKey(b)
+}
+
+// NewIssuer returns an Issuer talking to the acme directory at url, solving
+// http-01 challenges by writing to webroot. The account key is kept in the Store.
+// Calls failing because the directory is overloaded or unreachable are retried with r, unless it
+// classifies the errors itself.
+func NewIssuer(url, email, webroot string, s Store, r retry.Policy) (Issuer, error) {
+	key, err := accountKey(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Retryable == nil {
+		r.Retryable = retryable
+	}
+	return &issuer{
+		client: &acme.Client{
+			Key:          key,
+			DirectoryURL: url,
+		},
+		email:   email,
+		webroot: webroot,
+		timeout: 5 * time.Minute,
+		retry:   r,
+	}, nil
+}
+
+// NewDNSIssuer returns an Issuer like NewIssuer, which solves dns-01 challenges for zone and
+// its subdomains by publishing their records with p. Only dns-01 challenges allow wildcard domains.
+func NewDNSIssuer(url, email, webroot string, p dns.Provider, zone string, s Store, r retry.Policy) (Issuer, error) {
+	i, err := NewIssuer(url, email, webroot, s, r)
+	if err != nil {
+		return nil, err
+	}
+
+	i.(*issuer).provider = p
+	i.(*issuer).zone = strings.TrimPrefix(zone, "*.")
+	return i, nil
+}
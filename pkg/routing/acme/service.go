@@ -0,0 +1,352 @@
+package acme
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
+
+// The Service interface describes the functions necessary for the acme subsystem
+type Service interface {
+	// Manage obtains a certificate for every server name of a configuration and enables it
+	Manage(refID uint, name string) error
+
+	// Unmanage removes the certificate of a configuration and disables it
+	Unmanage(refID uint, name string) error
+
+	// Renew renews every managed certificate which is about to expire
+	Renew() error
+
+	// Certificate returns the certificate stored for a configuration
+	Certificate(refID uint, name string) (*Certificate, error)
+}
+
+// HostPolicy decides whether a certificate may be requested for a domain
+type HostPolicy func(refID uint, domain string) error
+
+// AlertFunc is called if a certificate could not be renewed and is about to expire
+type AlertFunc func(c *Certificate, err error)
+
+// NewDomainPolicy returns a HostPolicy allowing every subdomain of base and
+// every custom domain for which verified returns true
+func NewDomainPolicy(base string, verified func(refID uint, domain string) bool) HostPolicy {
+	suffix := "." + strings.TrimPrefix(base, ".")
+	return func(refID uint, domain string) error {
+		if base != "" && strings.HasSuffix(domain, suffix) {
+			return nil
+		}
+
+		if verified != nil && verified(refID, domain) {
+			return nil
+		}
+
+		return fmt.Errorf("domain %s is not verified for user %d", domain, refID)
+	}
+}
+
+// Options configures when certificates are renewed and alerts are sent
+type Options struct {
+	// RenewBefore is the time before expiry a certificate is renewed
+	RenewBefore time.Duration
+
+	// AlertBefore is the time before expiry a failed renewal is alerted
+	AlertBefore time.Duration
+
+	// Wildcard is the zone a wildcard certificate is kept for, configurations whose server
+	// names are all covered by it share it. It requires an Issuer solving dns-01 challenges.
+	Wildcard string
+
+	// Reload is called after certificate files changed, so the router uses the new ones
+	Reload func() error
+}
+
+// DefaultOptions renews certificates 30 days and alerts 14 days before they expire
+var DefaultOptions = Options{
+	RenewBefore: 30 * 24 * time.Hour,
+	AlertBefore: 14 * 24 * time.Hour,
+}
+
+type service struct {
+	routing routing.Service
+	issuer  Issuer
+	store   Store
+	policy  HostPolicy
+	alert   AlertFunc
+	opts    Options
+	mtx     *sync.Mutex
+}
+
+func (s *service) Manage(refID uint, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.manage(refID, name)
+}
+
+func (s *service) manage(refID uint, name string) error {
+	conf := routing.RouterConfig{}
+	err := s.routing.GetRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+
+	err = s.obtain(&conf)
+	if err != nil {
+		return err
+	}
+
+	return s.reload()
+}
+
+func (s *service) reload() error {
+	if s.opts.Reload == nil {
+		return nil
+	}
+	return s.opts.Reload()
+}
+
+// covered checks whether the wildcard certificate is valid for every server name of conf
+func (s *service) covered(conf *routing.RouterConfig) bool {
+	for _, domain := range conf.ServerName {
+		if !CoveredByWildcard(s.opts.Wildcard, domain) {
+			return false
+		}
+	}
+	return true
+}
+
+// wildcard returns the wildcard certificate, it is obtained if it is missing or about to expire
+func (s *service) wildcard() (*Certificate, error) {
+	c, err := s.store.Get(0, WildcardName)
+	if err == nil && !c.ExpiresWithin(s.opts.RenewBefore) {
+		return c, nil
+	}
+
+	cert, key, err := s.issuer.Obtain(WildcardDomains(s.opts.Wildcard))
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.Put(0, WildcardName, cert, key)
+}
+
+func (s *service) obtain(conf *routing.RouterConfig) error {
+	if len(conf.ServerName) == 0 {
+		return fmt.Errorf("config %s of user %d has no server name", conf.Name, conf.RefID)
+	}
+
+	for _, domain := range conf.ServerName {
+		err := s.policy(conf.RefID, domain)
+		if err != nil {
+			return err
+		}
+	}
+
+	var c *Certificate
+	if s.opts.Wildcard != "" && s.covered(conf) {
+		var err error
+		c, err = s.wildcard()
+		if err != nil {
+			return err
+		}
+	} else {
+		cert, key, err := s.issuer.Obtain(conf.ServerName)
+		if err != nil {
+			return err
+		}
+
+		c, err = s.store.Put(conf.RefID, conf.Name, cert, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	ssl := conf.SSLSettings
+	ssl.ACME = true
+	ssl.Certificate = c.CertPath
+	ssl.CertificateKey = c.KeyPath
+
+	return s.routing.EditRouterConfig(conf.RefID, conf.Name, &routing.RouterConfig{
+		SSLSettings: ssl,
+	})
+}
+
+func (s *service) Unmanage(refID uint, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.unmanage(refID, name)
+}
+
+func (s *service) unmanage(refID uint, name string) error {
+	conf := routing.RouterConfig{}
+	err := s.routing.GetRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+
+	ssl := conf.SSLSettings
+	ssl.ACME = false
+	ssl.Certificate = ""
+	ssl.CertificateKey = ""
+
+	err = s.routing.EditRouterConfig(refID, name, &routing.RouterConfig{
+		SSLSettings: ssl,
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.store.Remove(refID, name)
+}
+
+func (s *service) Renew() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.renew()
+}
+
+// renewWildcard renews the wildcard certificate if it is about to expire and reports whether it changed
+func (s *service) renewWildcard() (bool, error) {
+	c, err := s.store.Get(0, WildcardName)
+	if err == nil && !c.ExpiresWithin(s.opts.RenewBefore) {
+		return false, nil
+	}
+
+	_, err = s.wildcard()
+	if err == nil {
+		return true, nil
+	}
+
+	if c == nil {
+		c = &Certificate{
+			Name:    WildcardName,
+			Domains: WildcardDomains(s.opts.Wildcard),
+		}
+	}
+
+	if c.ExpiresWithin(s.opts.AlertBefore) {
+		s.alert(c, err)
+	}
+	return false, err
+}
+
+func (s *service) renew() error {
+	confs := make([]routing.RouterConfig, 0)
+	s.routing.Configurations(&confs)
+
+	failed, changed := 0, false
+	if s.opts.Wildcard != "" {
+		renewed, err := s.renewWildcard()
+		if err != nil {
+			failed++
+		}
+		changed = renewed
+	}
+
+	for i := range confs {
+		conf := &confs[i]
+		if !conf.SSLSettings.ACME {
+			continue
+		}
+
+		c, err := s.store.Get(conf.RefID, conf.Name)
+		if s.opts.Wildcard != "" && s.covered(conf) {
+			// the wildcard certificate was renewed above, only configurations not using it yet are changed
+			w, err := s.store.Get(0, WildcardName)
+			if err != nil || conf.SSLSettings.Certificate == w.CertPath {
+				continue
+			}
+		} else if err == nil && !c.ExpiresWithin(s.opts.RenewBefore) {
+			continue
+		}
+
+		err = s.obtain(conf)
+		if err == nil {
+			changed = true
+			continue
+		}
+		failed++
+
+		if c == nil {
+			c = &Certificate{
+				RefID:   conf.RefID,
+				Name:    conf.Name,
+				Domains: conf.ServerName,
+			}
+		}
+
+		if c.ExpiresWithin(s.opts.AlertBefore) {
+			s.alert(c, err)
+		}
+	}
+
+	if changed {
+		err := s.reload()
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d certificates could not be renewed", failed)
+	}
+	return nil
+}
+
+func (s *service) Certificate(refID uint, name string) (*Certificate, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.store.Get(refID, name)
+}
+
+// LogAlert returns an AlertFunc which writes to a logger
+func LogAlert(logger log.Logger) AlertFunc {
+	return func(c *Certificate, err error) {
+		level.Error(logger).Log(
+			"alert", "certificate renewal failed",
+			"ref", c.RefID,
+			"name", c.Name,
+			"domains", strings.Join(c.Domains, ","),
+			"expires", c.NotAfter,
+			"err", err,
+		)
+	}
+}
+
+// RenewJob is the type of the job renewing the managed certificates
+const RenewJob = "acme.renew"
+
+// RenewHandler returns the handler of RenewJob, report is called with the result of every renewal if it is set
+func RenewHandler(s Service, report func(error)) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		err := s.Renew()
+		if report != nil {
+			report(err)
+		}
+		return err
+	}
+}
+
+// NewService creates an acme Service with necessary dependencies.
+// The routing service should be a writing service, so certificate changes end up in the router.
+func NewService(r routing.Service, i Issuer, s Store, p HostPolicy, a AlertFunc, o Options) Service {
+	return &service{
+		routing: r,
+		issuer:  i,
+		store:   s,
+		policy:  p,
+		alert:   a,
+		opts:    o,
+		mtx:     &sync.Mutex{},
+	}
+}
@@ -0,0 +1,152 @@
+package acme
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// ErrNoCertificate is returned, if no certificate is stored for a configuration
+	ErrNoCertificate = errors.New("no certificate stored")
+
+	// ErrInvalidCertificate is returned, if a stored certificate can not be parsed
+	ErrInvalidCertificate = errors.New("invalid certificate")
+)
+
+const (
+	certFile       = "fullchain.pem"
+	keyFile        = "privkey.pem"
+	accountKeyFile = "account.key"
+)
+
+// Store persists certificates and keys
+type Store interface {
+	// Put writes a pem encoded certificate chain and key for a configuration
+	Put(refID uint, name string, cert, key []byte) (*Certificate, error)
+
+	// Get returns the certificate stored for a configuration
+	Get(refID uint, name string) (*Certificate, error)
+
+	// Remove deletes the certificate stored for a configuration
+	Remove(refID uint, name string) error
+
+	// AccountKey returns the pem encoded acme account key, nil if there is none
+	AccountKey() ([]byte, error)
+
+	// SetAccountKey stores the pem encoded acme account key
+	SetAccountKey(key []byte) error
+}
+
+type fileStore struct {
+	path string
+}
+
+func (f *fileStore) dir(refID uint, name string) string {
+	return filepath.Join(f.path, fmt.Sprintf("%d_%s", refID, name))
+}
+
+func (f *fileStore) Put(refID uint, name string, cert, key []byte) (*Certificate, error) {
+	dir := f.dir(refID, name)
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeFile(filepath.Join(dir, keyFile), key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = writeFile(filepath.Join(dir, certFile), cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.Get(refID, name)
+}
+
+func (f *fileStore) Get(refID uint, name string) (*Certificate, error) {
+	dir := f.dir(refID, name)
+	certPath := filepath.Join(dir, certFile)
+
+	b, err := ioutil.ReadFile(certPath)
+	if os.IsNotExist(err) {
+		return nil, ErrNoCertificate
+	} else if err != nil {
+		return nil, err
+	}
+
+	leaf, err := parseLeaf(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Certificate{
+		RefID:    refID,
+		Name:     name,
+		Domains:  leaf.DNSNames,
+		CertPath: certPath,
+		KeyPath:  filepath.Join(dir, keyFile),
+		NotAfter: leaf.NotAfter,
+	}, nil
+}
+
+func (f *fileStore) Remove(refID uint, name string) error {
+	return os.RemoveAll(f.dir(refID, name))
+}
+
+func (f *fileStore) AccountKey() ([]byte, error) {
+	b, err := ioutil.ReadFile(filepath.Join(f.path, accountKeyFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (f *fileStore) SetAccountKey(key []byte) error {
+	return writeFile(filepath.Join(f.path, accountKeyFile), key)
+}
+
+// writeFile writes to a temporary file first, so a certificate is never half written
+func writeFile(path string, b []byte) error {
+	tmp := path + ".tmp"
+	err := ioutil.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func parseLeaf(b []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, ErrInvalidCertificate
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// NewFileStore returns a Store which keeps certificates below path,
+// only readable by the user running krood
+func NewFileStore(path string) (Store, error) {
+	err := os.MkdirAll(path, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	} else if !dir.IsDir() {
+		return nil, errors.New("path is no dir")
+	}
+
+	return &fileStore{
+		path: path,
+	}, nil
+}
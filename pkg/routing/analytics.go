@@ -0,0 +1,436 @@
+package routing
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// AnalyticsLogFormat is the nginx log_format of the access logs used for traffic analytics,
+// $kroo_location is set to the index of the location rule which handled the request
+const AnalyticsLogFormat = "$msec $status $bytes_sent $request_time $kroo_location"
+
+// ErrAccessEntry is returned if a line of an analytics access log is malformed
+var ErrAccessEntry = errors.New("malformed access log entry")
+
+// Traffic is the aggregated traffic of a location of a configuration during one minute
+type Traffic struct {
+	RefID      uint      `gorm:"primary_key"`
+	Name       string    `gorm:"primary_key"`
+	Location   string    `gorm:"primary_key"`
+	Period     time.Time `gorm:"primary_key"`
+	Requests   uint
+	Status1xx  uint
+	Status2xx  uint
+	Status3xx  uint
+	Status4xx  uint
+	Status5xx  uint
+	BytesSent  uint64
+	LatencyP50 float64
+	LatencyP90 float64
+	LatencyP99 float64
+}
+
+// TableName sets Traffic's database table name
+func (Traffic) TableName() string {
+	return "routing_traffic"
+}
+
+// TrafficRate is the current number of requests per second of a location
+type TrafficRate struct {
+	Location string
+	Rate     float64
+}
+
+// AnalyticsLogName returns the file name of the configuration's analytics access log
+func (r RouterConfig) AnalyticsLogName() string {
+	return fmt.Sprintf("%d_%s.analytics.log", r.RefID, r.Name)
+}
+
+// AnalyticsLogFormatName returns the name of the configuration's analytics log_format,
+// it has to be unique since every configuration is included in the same http block
+func (r RouterConfig) AnalyticsLogFormatName() string {
+	return fmt.Sprintf("analytics_%d_%s", r.RefID, r.Name)
+}
+
+// AccessEntry is a parsed line of an analytics access log
+type AccessEntry struct {
+	Time      time.Time
+	Status    int
+	BytesSent uint64
+	Latency   float64
+	// Location is the index of the location rule, -1 if no rule matched
+	Location int
+}
+
+// ParseAccessEntry parses a line written in AnalyticsLogFormat
+func ParseAccessEntry(line string) (AccessEntry, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 5 {
+		return AccessEntry{}, ErrAccessEntry
+	}
+
+	msec, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return AccessEntry{}, ErrAccessEntry
+	}
+
+	status, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return AccessEntry{}, ErrAccessEntry
+	}
+
+	bytes, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return AccessEntry{}, ErrAccessEntry
+	}
+
+	latency, err := strconv.ParseFloat(fields[3], 64)
+	if err != nil {
+		return AccessEntry{}, ErrAccessEntry
+	}
+
+	location := -1
+	if fields[4] != "-" {
+		location, err = strconv.Atoi(fields[4])
+		if err != nil {
+			return AccessEntry{}, ErrAccessEntry
+		}
+	}
+
+	sec, frac := math.Modf(msec)
+	return AccessEntry{
+		Time:      time.Unix(int64(sec), int64(frac*1e9)).UTC(),
+		Status:    status,
+		BytesSent: bytes,
+		Latency:   latency,
+		Location:  location,
+	}, nil
+}
+
+// percentile returns the p-th percentile of sorted values using the nearest rank method
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+type trafficKey struct {
+	refID    uint
+	name     string
+	location string
+	period   time.Time
+}
+
+type trafficBucket struct {
+	traffic   Traffic
+	latencies []float64
+}
+
+func (b *trafficBucket) add(e AccessEntry) {
+	b.traffic.Requests++
+	switch e.Status / 100 {
+	case 1:
+		b.traffic.Status1xx++
+	case 2:
+		b.traffic.Status2xx++
+	case 3:
+		b.traffic.Status3xx++
+	case 4:
+		b.traffic.Status4xx++
+	case 5:
+		b.traffic.Status5xx++
+	}
+	b.traffic.BytesSent += e.BytesSent
+	b.latencies = append(b.latencies, e.Latency)
+}
+
+func (b *trafficBucket) result() *Traffic {
+	sort.Float64s(b.latencies)
+	t := b.traffic
+	t.LatencyP50 = percentile(b.latencies, 0.5)
+	t.LatencyP90 = percentile(b.latencies, 0.9)
+	t.LatencyP99 = percentile(b.latencies, 0.99)
+	return &t
+}
+
+// Collector reads the analytics access logs the router writes for every configuration,
+// aggregates them per location and minute and publishes the current request rates
+type Collector struct {
+	s      Service
+	dir    string
+	logger log.Logger
+
+	mtx         sync.Mutex
+	offsets     map[string]int64
+	buckets     map[trafficKey]*trafficBucket
+	last        time.Time
+	rates       map[IDRequest]float64
+	subscribers map[IDRequest][]chan []TrafficRate
+}
+
+// read returns the complete lines which were appended to a log since the last call.
+// Logs which already exist on the first call are read from their end, so a restart does
+// not count requests twice, a log which shrank is expected to be rotated and read from its start.
+func (c *Collector) read(path string) ([]string, error) {
+	offset, known := c.offsets[path]
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		c.offsets[path] = 0
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if !known {
+		c.offsets[path] = info.Size()
+		return nil, nil
+	}
+
+	if info.Size() < offset {
+		offset = 0
+	}
+
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []string{}
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if err == io.EOF {
+			// an incomplete line is read again once nginx finished writing it
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		offset += int64(len(line))
+		lines = append(lines, strings.TrimSpace(line))
+	}
+
+	c.offsets[path] = offset
+	return lines, nil
+}
+
+// Collect reads new log entries of all configurations, stores every completed minute and
+// publishes the request rates since the last call to the subscribers
+func (c *Collector) Collect(now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	elapsed := now.Sub(c.last).Seconds()
+	c.last = now
+
+	var confs []RouterConfig
+	c.s.Configurations(&confs)
+
+	rates := make(map[IDRequest]float64)
+	for _, conf := range confs {
+		path := filepath.Join(c.dir, conf.AnalyticsLogName())
+		_, known := c.offsets[path]
+		lines, err := c.read(path)
+		if err != nil {
+			level.Error(c.logger).Log("config", conf.AnalyticsLogName(), "err", err)
+			continue
+		}
+
+		requests := make(map[string]uint)
+		total := 0
+		for _, line := range lines {
+			e, err := ParseAccessEntry(line)
+			if err != nil {
+				continue
+			}
+
+			total++
+			location := ""
+			if e.Location >= 0 && e.Location < len(conf.LocationRules) && conf.LocationRules[e.Location] != nil {
+				location = conf.LocationRules[e.Location].Location
+			}
+			requests[location]++
+
+			key := trafficKey{conf.RefID, conf.Name, location, e.Time.Truncate(time.Minute)}
+			b, ok := c.buckets[key]
+			if !ok {
+				b = &trafficBucket{
+					traffic: Traffic{
+						RefID:    conf.RefID,
+						Name:     conf.Name,
+						Location: location,
+						Period:   key.period,
+					},
+				}
+				c.buckets[key] = b
+			}
+			b.add(e)
+		}
+
+		if known && elapsed > 0 {
+			rates[IDRequest{conf.RefID, conf.Name}] = float64(total) / elapsed
+		}
+		c.publish(conf, requests, elapsed)
+	}
+	c.rates = rates
+
+	c.record(now.Truncate(time.Minute))
+}
+
+// Rate returns the requests per second a configuration received between the last two calls of Collect,
+// it is false if the log of the configuration was not read twice yet
+func (c *Collector) Rate(refID uint, name string) (float64, bool) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	r, ok := c.rates[IDRequest{refID, name}]
+	return r, ok
+}
+
+// record stores the traffic of the buckets of periods before end, or of every bucket if end is zero
+func (c *Collector) record(end time.Time) {
+	for key, b := range c.buckets {
+		if !end.IsZero() && !key.period.Before(end) {
+			continue
+		}
+
+		err := c.s.RecordTraffic(b.result())
+		if err != nil {
+			level.Error(c.logger).Log("config", key.name, "location", key.location, "err", err)
+			continue
+		}
+		delete(c.buckets, key)
+	}
+}
+
+func (c *Collector) publish(conf RouterConfig, requests map[string]uint, elapsed float64) {
+	subscribers := c.subscribers[IDRequest{conf.RefID, conf.Name}]
+	if len(subscribers) == 0 || elapsed <= 0 {
+		return
+	}
+
+	rates := []TrafficRate{}
+	for _, l := range conf.LocationRules {
+		if l == nil {
+			continue
+		}
+		rates = append(rates, TrafficRate{
+			Location: l.Location,
+			Rate:     float64(requests[l.Location]) / elapsed,
+		})
+	}
+	if requests[""] != 0 {
+		rates = append(rates, TrafficRate{
+			Rate: float64(requests[""]) / elapsed,
+		})
+	}
+
+	for _, s := range subscribers {
+		// a slow subscriber only gets the latest rates
+		select {
+		case <-s:
+		default:
+		}
+		s <- rates
+	}
+}
+
+// Subscribe returns a channel which receives the request rates of a configuration
+// on every Collect and a function to cancel the subscription
+func (c *Collector) Subscribe(refID uint, name string) (<-chan []TrafficRate, func()) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	id := IDRequest{refID, name}
+	s := make(chan []TrafficRate, 1)
+	c.subscribers[id] = append(c.subscribers[id], s)
+
+	var once sync.Once
+	return s, func() {
+		once.Do(func() {
+			c.mtx.Lock()
+			defer c.mtx.Unlock()
+
+			subscribers := c.subscribers[id]
+			for i, sub := range subscribers {
+				if sub == s {
+					c.subscribers[id] = append(subscribers[:i], subscribers[i+1:]...)
+					break
+				}
+			}
+			if len(c.subscribers[id]) == 0 {
+				delete(c.subscribers, id)
+			}
+			close(s)
+		})
+	}
+}
+
+// Flush collects the new entries and stores the traffic of every period, including the current one.
+// Traffic recorded for a period which was already stored is merged into it.
+func (c *Collector) Flush() {
+	c.Collect(time.Now())
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.record(time.Time{})
+}
+
+// Run calls Collect every interval until stop is closed, the buffered traffic is flushed before it returns
+func (c *Collector) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		c.Collect(time.Now())
+
+		select {
+		case <-t.C:
+		case <-stop:
+			c.Flush()
+			return
+		}
+	}
+}
+
+// NewCollector returns a Collector for the analytics access logs in dir
+func NewCollector(s Service, dir string, logger log.Logger) *Collector {
+	return &Collector{
+		s:           s,
+		dir:         dir,
+		logger:      logger,
+		offsets:     make(map[string]int64),
+		buckets:     make(map[trafficKey]*trafficBucket),
+		last:        time.Now(),
+		rates:       make(map[IDRequest]float64),
+		subscribers: make(map[IDRequest][]chan []TrafficRate),
+	}
+}
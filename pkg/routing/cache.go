@@ -0,0 +1,151 @@
+package routing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/cache"
+)
+
+// configurationsKey is the cache key of all configurations
+const configurationsKey = "routing:configurations"
+
+func configKey(refID uint, name string) string {
+	return fmt.Sprintf("routing:%d:%s", refID, name)
+}
+
+type cachedService struct {
+	Service
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// invalidate removes the cached configuration name of refID and all configurations if err is nil
+// and returns err
+func (c *cachedService) invalidate(refID uint, name string, err error) error {
+	if err == nil {
+		c.cache.Delete(configKey(refID, name), configurationsKey)
+	}
+	return err
+}
+
+func (c *cachedService) CreateRouterConfig(r *RouterConfig) error {
+	return c.invalidate(r.RefID, r.Name, c.Service.CreateRouterConfig(r))
+}
+
+func (c *cachedService) EditRouterConfig(refID uint, name string, r *RouterConfig) error {
+	err := c.Service.EditRouterConfig(refID, name, r)
+	if err == nil && r.Name != "" && r.Name != name {
+		c.cache.Delete(configKey(refID, r.Name))
+	}
+	return c.invalidate(refID, name, err)
+}
+
+func (c *cachedService) GetRouterConfig(refID uint, name string, r *RouterConfig) error {
+	found, err := c.cache.Get(configKey(refID, name), r)
+	if found && err == nil {
+		return nil
+	}
+
+	err = c.Service.GetRouterConfig(refID, name, r)
+	if err != nil {
+		return err
+	}
+
+	c.cache.Set(configKey(refID, name), r, c.ttl)
+	return nil
+}
+
+func (c *cachedService) RemoveRouterConfig(refID uint, name string) error {
+	return c.invalidate(refID, name, c.Service.RemoveRouterConfig(refID, name))
+}
+
+func (c *cachedService) AddLocationRule(refID uint, name string, lr *LocationRule) error {
+	return c.invalidate(refID, name, c.Service.AddLocationRule(refID, name, lr))
+}
+
+func (c *cachedService) RemoveLocationRule(refID uint, name string, lid int) error {
+	return c.invalidate(refID, name, c.Service.RemoveLocationRule(refID, name, lid))
+}
+
+func (c *cachedService) ChangeListenStatement(refID uint, name string, ls *ListenStatement) error {
+	return c.invalidate(refID, name, c.Service.ChangeListenStatement(refID, name, ls))
+}
+
+func (c *cachedService) AddServerName(refID uint, name string, sn string) error {
+	return c.invalidate(refID, name, c.Service.AddServerName(refID, name, sn))
+}
+
+func (c *cachedService) RemoveServerName(refID uint, name string, id int) error {
+	return c.invalidate(refID, name, c.Service.RemoveServerName(refID, name, id))
+}
+
+func (c *cachedService) Configurations(r *[]RouterConfig) {
+	found, err := c.cache.Get(configurationsKey, r)
+	if found && err == nil {
+		return
+	}
+
+	*r = []RouterConfig{}
+	c.Service.Configurations(r)
+	c.cache.Set(configurationsKey, r, c.ttl)
+}
+
+func (c *cachedService) SetUpstream(refID uint, name string, u *Upstream) error {
+	return c.invalidate(refID, name, c.Service.SetUpstream(refID, name, u))
+}
+
+func (c *cachedService) RemoveUpstream(refID uint, name string, upstream string) error {
+	return c.invalidate(refID, name, c.Service.RemoveUpstream(refID, name, upstream))
+}
+
+func (c *cachedService) AddUpstreamMember(refID uint, name string, upstream string, m *UpstreamMember) error {
+	return c.invalidate(refID, name, c.Service.AddUpstreamMember(refID, name, upstream, m))
+}
+
+func (c *cachedService) RemoveUpstreamMember(refID uint, name string, upstream string, address string) error {
+	return c.invalidate(refID, name, c.Service.RemoveUpstreamMember(refID, name, upstream, address))
+}
+
+func (c *cachedService) SetUpstreamMemberDown(refID uint, name string, upstream string, address string, down bool) error {
+	return c.invalidate(refID, name, c.Service.SetUpstreamMemberDown(refID, name, upstream, address, down))
+}
+
+func (c *cachedService) SetHTTPSPolicy(refID uint, name string, p *HTTPSPolicy) error {
+	return c.invalidate(refID, name, c.Service.SetHTTPSPolicy(refID, name, p))
+}
+
+func (c *cachedService) SetSwitch(refID uint, name string, sw *Switch) error {
+	return c.invalidate(refID, name, c.Service.SetSwitch(refID, name, sw))
+}
+
+func (c *cachedService) RemoveSwitch(refID uint, name string, sw string) error {
+	return c.invalidate(refID, name, c.Service.RemoveSwitch(refID, name, sw))
+}
+
+func (c *cachedService) SetProxyLimits(refID uint, name string, lid int, l *ProxyLimits) error {
+	return c.invalidate(refID, name, c.Service.SetProxyLimits(refID, name, lid, l))
+}
+
+func (c *cachedService) SetErrorPage(refID uint, name string, code int, page string) error {
+	return c.invalidate(refID, name, c.Service.SetErrorPage(refID, name, code, page))
+}
+
+func (c *cachedService) SetMaintenance(refID uint, name string, maintenance bool) error {
+	return c.invalidate(refID, name, c.Service.SetMaintenance(refID, name, maintenance))
+}
+
+func (c *cachedService) SetOverQuota(refID uint, name string, over bool) error {
+	return c.invalidate(refID, name, c.Service.SetOverQuota(refID, name, over))
+}
+
+// NewCachedService returns a Service keeping the configurations of the router in c for ttl, a
+// configuration is invalidated whenever it is changed. The traffic is not cached. Failing cache
+// operations fall back to s.
+func NewCachedService(s Service, c cache.Cache, ttl time.Duration) Service {
+	return &cachedService{
+		Service: s,
+		cache:   c,
+		ttl:     ttl,
+	}
+}
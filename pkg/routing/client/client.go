@@ -136,6 +136,150 @@ func New(conn *grpc.ClientConn, logger log.Logger) *routing.Endpoints {
 		).Endpoint()
 	}
 
+	var SetUpstreamEndpoint endpoint.Endpoint
+	{
+		SetUpstreamEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"SetUpstream",
+			EncodeGRPCSetUpstreamRequest,
+			DecodeGRPCSetUpstreamResponse,
+			pb.SetUpstreamResponse{},
+		).Endpoint()
+	}
+
+	var RemoveUpstreamEndpoint endpoint.Endpoint
+	{
+		RemoveUpstreamEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"RemoveUpstream",
+			EncodeGRPCRemoveUpstreamRequest,
+			DecodeGRPCRemoveUpstreamResponse,
+			pb.RemoveUpstreamResponse{},
+		).Endpoint()
+	}
+
+	var AddUpstreamMemberEndpoint endpoint.Endpoint
+	{
+		AddUpstreamMemberEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"AddUpstreamMember",
+			EncodeGRPCAddUpstreamMemberRequest,
+			DecodeGRPCAddUpstreamMemberResponse,
+			pb.AddUpstreamMemberResponse{},
+		).Endpoint()
+	}
+
+	var RemoveUpstreamMemberEndpoint endpoint.Endpoint
+	{
+		RemoveUpstreamMemberEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"RemoveUpstreamMember",
+			EncodeGRPCRemoveUpstreamMemberRequest,
+			DecodeGRPCRemoveUpstreamMemberResponse,
+			pb.RemoveUpstreamMemberResponse{},
+		).Endpoint()
+	}
+
+	var TrafficEndpoint endpoint.Endpoint
+	{
+		TrafficEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"Traffic",
+			EncodeGRPCTrafficRequest,
+			DecodeGRPCTrafficResponse,
+			pb.TrafficResponse{},
+		).Endpoint()
+	}
+
+	var SetHTTPSPolicyEndpoint endpoint.Endpoint
+	{
+		SetHTTPSPolicyEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"SetHTTPSPolicy",
+			EncodeGRPCSetHTTPSPolicyRequest,
+			DecodeGRPCSetHTTPSPolicyResponse,
+			pb.SetHTTPSPolicyResponse{},
+		).Endpoint()
+	}
+
+	var SetErrorPageEndpoint endpoint.Endpoint
+	{
+		SetErrorPageEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"SetErrorPage",
+			EncodeGRPCSetErrorPageRequest,
+			DecodeGRPCSetErrorPageResponse,
+			pb.SetErrorPageResponse{},
+		).Endpoint()
+	}
+
+	var SetMaintenanceEndpoint endpoint.Endpoint
+	{
+		SetMaintenanceEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"SetMaintenance",
+			EncodeGRPCSetMaintenanceRequest,
+			DecodeGRPCSetMaintenanceResponse,
+			pb.SetMaintenanceResponse{},
+		).Endpoint()
+	}
+
+	var SetProxyLimitsEndpoint endpoint.Endpoint
+	{
+		SetProxyLimitsEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"SetProxyLimits",
+			EncodeGRPCSetProxyLimitsRequest,
+			DecodeGRPCSetProxyLimitsResponse,
+			pb.SetProxyLimitsResponse{},
+		).Endpoint()
+	}
+
+	var SetSwitchEndpoint endpoint.Endpoint
+	{
+		SetSwitchEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"SetSwitch",
+			EncodeGRPCSetSwitchRequest,
+			DecodeGRPCSetSwitchResponse,
+			pb.SetSwitchResponse{},
+		).Endpoint()
+	}
+
+	var RemoveSwitchEndpoint endpoint.Endpoint
+	{
+		RemoveSwitchEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"RemoveSwitch",
+			EncodeGRPCRemoveSwitchRequest,
+			DecodeGRPCRemoveSwitchResponse,
+			pb.RemoveSwitchResponse{},
+		).Endpoint()
+	}
+
+	var QuotaEndpoint endpoint.Endpoint
+	{
+		QuotaEndpoint = grpctransport.NewClient(
+			conn,
+			"routing.RoutingService",
+			"Quota",
+			EncodeGRPCQuotaRequest,
+			DecodeGRPCQuotaResponse,
+			pb.QuotaResponse{},
+		).Endpoint()
+	}
+
 	return &routing.Endpoints{
 		CreateConfigEndpoint:          CreateConfigEndpoint,
 		EditConfigEndpoint:            EditConfigEndpoint,
@@ -147,6 +291,18 @@ func New(conn *grpc.ClientConn, logger log.Logger) *routing.Endpoints {
 		AddServerNameEndpoint:         AddServerNameEndpoint,
 		RemoveServerNameEndpoint:      RemoveServerNameEndpoint,
 		ConfigurationsEndpoint:        ConfigurationsEndpoint,
+		SetUpstreamEndpoint:           SetUpstreamEndpoint,
+		RemoveUpstreamEndpoint:        RemoveUpstreamEndpoint,
+		AddUpstreamMemberEndpoint:     AddUpstreamMemberEndpoint,
+		RemoveUpstreamMemberEndpoint:  RemoveUpstreamMemberEndpoint,
+		TrafficEndpoint:               TrafficEndpoint,
+		SetHTTPSPolicyEndpoint:        SetHTTPSPolicyEndpoint,
+		SetErrorPageEndpoint:          SetErrorPageEndpoint,
+		SetMaintenanceEndpoint:        SetMaintenanceEndpoint,
+		SetProxyLimitsEndpoint:        SetProxyLimitsEndpoint,
+		SetSwitchEndpoint:             SetSwitchEndpoint,
+		RemoveSwitchEndpoint:          RemoveSwitchEndpoint,
+		QuotaEndpoint:                 QuotaEndpoint,
 	}
 }
 
@@ -356,3 +512,256 @@ func DecodeGRPCConfigurationsResponse(_ context.Context, grpcResponse interface{
 		Configurations: convertPBConfigs(response.Configurations),
 	}, nil
 }
+
+// EncodeGRPCSetUpstreamRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain setupstream request to a gRPC SetUpstream request.
+func EncodeGRPCSetUpstreamRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.SetUpstreamRequest)
+	return &pb.SetUpstreamRequest{
+		RefID:    uint32(req.RefID),
+		Name:     req.Name,
+		Upstream: routing.ConvertUpstream(req.Upstream),
+	}, nil
+}
+
+// DecodeGRPCSetUpstreamResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetUpstream response to a messages/routing.proto-domain setupstream response.
+func DecodeGRPCSetUpstreamResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetUpstreamResponse)
+	return &routing.SetUpstreamResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveUpstreamRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain removeupstream request to a gRPC RemoveUpstream request.
+func EncodeGRPCRemoveUpstreamRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.RemoveUpstreamRequest)
+	return &pb.RemoveUpstreamRequest{
+		RefID:    uint32(req.RefID),
+		Name:     req.Name,
+		Upstream: req.Upstream,
+	}, nil
+}
+
+// DecodeGRPCRemoveUpstreamResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveUpstream response to a messages/routing.proto-domain removeupstream response.
+func DecodeGRPCRemoveUpstreamResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveUpstreamResponse)
+	return &routing.RemoveUpstreamResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCAddUpstreamMemberRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain addupstreammember request to a gRPC AddUpstreamMember request.
+func EncodeGRPCAddUpstreamMemberRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.AddUpstreamMemberRequest)
+	return &pb.AddUpstreamMemberRequest{
+		RefID:    uint32(req.RefID),
+		Name:     req.Name,
+		Upstream: req.Upstream,
+		Member:   routing.ConvertUpstreamMember(req.Member),
+	}, nil
+}
+
+// DecodeGRPCAddUpstreamMemberResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC AddUpstreamMember response to a messages/routing.proto-domain addupstreammember response.
+func DecodeGRPCAddUpstreamMemberResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.AddUpstreamMemberResponse)
+	return &routing.AddUpstreamMemberResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveUpstreamMemberRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain removeupstreammember request to a gRPC RemoveUpstreamMember request.
+func EncodeGRPCRemoveUpstreamMemberRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.RemoveUpstreamMemberRequest)
+	return &pb.RemoveUpstreamMemberRequest{
+		RefID:    uint32(req.RefID),
+		Name:     req.Name,
+		Upstream: req.Upstream,
+		Address:  req.Address,
+	}, nil
+}
+
+// DecodeGRPCRemoveUpstreamMemberResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveUpstreamMember response to a messages/routing.proto-domain removeupstreammember response.
+func DecodeGRPCRemoveUpstreamMemberResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveUpstreamMemberResponse)
+	return &routing.RemoveUpstreamMemberResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCTrafficRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain traffic request to a gRPC Traffic request.
+func EncodeGRPCTrafficRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.TrafficRequest)
+	return &pb.TrafficRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Name,
+		From:  req.From.Unix(),
+		To:    req.To.Unix(),
+	}, nil
+}
+
+// DecodeGRPCTrafficResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Traffic response to a messages/routing.proto-domain traffic response.
+func DecodeGRPCTrafficResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.TrafficResponse)
+	return &routing.TrafficResponse{
+		Traffic: routing.ConvertPBTraffic(response.Traffic),
+		Error:   getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetHTTPSPolicyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain sethttpspolicy request to a gRPC SetHTTPSPolicy request.
+func EncodeGRPCSetHTTPSPolicyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.SetHTTPSPolicyRequest)
+	return &pb.SetHTTPSPolicyRequest{
+		RefID:  uint32(req.RefID),
+		Name:   req.Name,
+		Policy: routing.ConvertHTTPSPolicy(req.Policy),
+	}, nil
+}
+
+// DecodeGRPCSetHTTPSPolicyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetHTTPSPolicy response to a messages/routing.proto-domain sethttpspolicy response.
+func DecodeGRPCSetHTTPSPolicyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetHTTPSPolicyResponse)
+	return &routing.SetHTTPSPolicyResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetErrorPageRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain seterrorpage request to a gRPC SetErrorPage request.
+func EncodeGRPCSetErrorPageRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.SetErrorPageRequest)
+	return &pb.SetErrorPageRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Name,
+		Code:  uint32(req.Code),
+		Page:  req.Page,
+	}, nil
+}
+
+// DecodeGRPCSetErrorPageResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetErrorPage response to a messages/routing.proto-domain seterrorpage response.
+func DecodeGRPCSetErrorPageResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetErrorPageResponse)
+	return &routing.SetErrorPageResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetMaintenanceRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain setmaintenance request to a gRPC SetMaintenance request.
+func EncodeGRPCSetMaintenanceRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.SetMaintenanceRequest)
+	return &pb.SetMaintenanceRequest{
+		RefID:       uint32(req.RefID),
+		Name:        req.Name,
+		Maintenance: req.Maintenance,
+	}, nil
+}
+
+// DecodeGRPCSetMaintenanceResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetMaintenance response to a messages/routing.proto-domain setmaintenance response.
+func DecodeGRPCSetMaintenanceResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetMaintenanceResponse)
+	return &routing.SetMaintenanceResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetProxyLimitsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain setproxylimits request to a gRPC SetProxyLimits request.
+func EncodeGRPCSetProxyLimitsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.SetProxyLimitsRequest)
+	var limits *pb.ProxyLimits
+	if req.Limits != nil {
+		limits = &pb.ProxyLimits{
+			ClientMaxBodySize: req.Limits.ClientMaxBodySize,
+			ReadTimeout:       uint32(req.Limits.ReadTimeout),
+			SendTimeout:       uint32(req.Limits.SendTimeout),
+		}
+	}
+	return &pb.SetProxyLimitsRequest{
+		RefID:  uint32(req.RefID),
+		Name:   req.Name,
+		Id:     int32(req.LID),
+		Limits: limits,
+	}, nil
+}
+
+// DecodeGRPCSetProxyLimitsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetProxyLimits response to a messages/routing.proto-domain setproxylimits response.
+func DecodeGRPCSetProxyLimitsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetProxyLimitsResponse)
+	return &routing.SetProxyLimitsResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetSwitchRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain setswitch request to a gRPC SetSwitch request.
+func EncodeGRPCSetSwitchRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.SetSwitchRequest)
+	return &pb.SetSwitchRequest{
+		RefID:  uint32(req.RefID),
+		Name:   req.Name,
+		Switch: routing.ConvertSwitch(req.Switch),
+	}, nil
+}
+
+// DecodeGRPCSetSwitchResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetSwitch response to a messages/routing.proto-domain setswitch response.
+func DecodeGRPCSetSwitchResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetSwitchResponse)
+	return &routing.SetSwitchResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveSwitchRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain removeswitch request to a gRPC RemoveSwitch request.
+func EncodeGRPCRemoveSwitchRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.RemoveSwitchRequest)
+	return &pb.RemoveSwitchRequest{
+		RefID:  uint32(req.RefID),
+		Name:   req.Name,
+		Switch: req.Switch,
+	}, nil
+}
+
+// DecodeGRPCRemoveSwitchResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveSwitch response to a messages/routing.proto-domain removeswitch response.
+func DecodeGRPCRemoveSwitchResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveSwitchResponse)
+	return &routing.RemoveSwitchResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCQuotaRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain quota request to a gRPC Quota request.
+func EncodeGRPCQuotaRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*routing.QuotaRequest)
+	return &pb.QuotaRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCQuotaResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Quota response to a messages/routing.proto-domain quota response.
+func DecodeGRPCQuotaResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.QuotaResponse)
+	return &routing.QuotaResponse{
+		Quota: routing.ConvertPBQuota(response.Quota),
+		Error: getError(response.Error),
+	}, nil
+}
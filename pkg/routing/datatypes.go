@@ -3,7 +3,10 @@ package routing
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 	"github.com/lib/pq"
@@ -13,9 +16,10 @@ type inet string
 
 // ListenStatement combines an ipAddress with a port and a keyword
 type ListenStatement struct {
-	IPAddress abstraction.Inet `sql:"type:inet"`
-	Port      uint16
+	IPAddress abstraction.Inet `sql:"type:inet" validate:"inet"`
+	Port      uint16           `validate:"port"`
 	Keyword   string
+	HTTP2     bool
 }
 
 // Scan implements the sql.Scanner interface.
@@ -90,12 +94,192 @@ type SSLSettings struct {
 	PreferServerCiphers bool
 	Certificate         string
 	CertificateKey      string
+	ACME                bool
+}
+
+// HSTSMinPreloadAge is the minimum max-age in seconds browsers require to preload a HSTS policy
+const HSTSMinPreloadAge = 31536000
+
+// HTTPSRedirect redirects plain http requests to the https listen statement of a configuration
+type HTTPSRedirect struct {
+	// Port is the port plain http requests are accepted on, traefik uses its plain http entry point instead
+	Port uint16 `validate:"port"`
+	// HTTPSPort is the port clients are redirected to, 443 if it is not set
+	HTTPSPort uint16
+}
+
+// HSTS describes the Strict-Transport-Security header of a configuration
+type HSTS struct {
+	MaxAge            uint
+	IncludeSubdomains bool
+	Preload           bool
+}
+
+// Header returns the value of the Strict-Transport-Security header
+func (h HSTS) Header() string {
+	v := fmt.Sprintf("max-age=%d", h.MaxAge)
+	if h.IncludeSubdomains {
+		v += "; includeSubDomains"
+	}
+	if h.Preload {
+		v += "; preload"
+	}
+	return v
+}
+
+// HTTPSPolicy combines the redirect of plain http requests and the HSTS header of a configuration
+type HTTPSPolicy struct {
+	Redirect *HTTPSRedirect `json:",omitempty"`
+	HSTS     *HSTS          `json:",omitempty"`
+}
+
+// RedirectTarget returns the scheme and authority plain http requests are redirected to, $host is
+// the host the request was sent to
+func (p HTTPSPolicy) RedirectTarget() string {
+	if p.Redirect == nil || p.Redirect.HTTPSPort == 0 || p.Redirect.HTTPSPort == 443 {
+		return "https://$host"
+	}
+	return fmt.Sprintf("https://$host:%d", p.Redirect.HTTPSPort)
+}
+
+// Scan implements the sql.Scanner interface.
+func (p *HTTPSPolicy) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return p.scanBytes(src)
+	case string:
+		return p.scanBytes([]byte(src))
+	case nil:
+		*p = HTTPSPolicy{}
+		return nil
+	}
+
+	return fmt.Errorf("pq: cannot convert %T to HTTPSPolicy", src)
+}
+
+func (p *HTTPSPolicy) scanBytes(src []byte) error {
+	return json.Unmarshal(src, p)
+}
+
+// Value implements the driver.Valuer interface.
+func (p HTTPSPolicy) Value() (driver.Value, error) {
+	if p == (HTTPSPolicy{}) {
+		return nil, nil
+	}
+	b, err := json.Marshal(p)
+
+	return string(b), err
+}
+
+// MaxErrorPageSize is the maximum size of a custom error page in bytes
+const MaxErrorPageSize = 64 << 10
+
+// ErrorPageCodes are the status codes custom error pages can be set for
+var ErrorPageCodes = []int{502, 503, 509}
+
+// DefaultQuotaPage is served with 509 if the traffic quota of a user is exhausted and no custom page is set
+const DefaultQuotaPage = "<html><head><title>509 Bandwidth Limit Exceeded</title></head><body><h1>Bandwidth Limit Exceeded</h1><p>The traffic quota of this site is exhausted for this month.</p></body></html>"
+
+// ErrorPages holds the custom error pages of a configuration and whether its instance is in maintenance
+type ErrorPages struct {
+	// BadGateway is the html page served if the upstream can not be reached
+	BadGateway string `json:",omitempty"`
+	// Unavailable is the html page served if the upstream is unavailable or the instance is in maintenance
+	Unavailable string `json:",omitempty"`
+	// QuotaExceeded is the html page served if the traffic quota of the user is exhausted
+	QuotaExceeded string `json:",omitempty"`
+	// Maintenance answers every request but the acme challenges with 503
+	Maintenance bool `json:",omitempty"`
+	// OverQuota answers every request but the acme challenges with 509, it is set by the QuotaEnforcer
+	OverQuota bool `json:",omitempty"`
+}
+
+// Page returns the page served with a status code, it is empty if there is none
+func (e ErrorPages) Page(code int) string {
+	switch code {
+	case 502:
+		return e.BadGateway
+	case 503:
+		return e.Unavailable
+	case 509:
+		if e.QuotaExceeded == "" && e.OverQuota {
+			return DefaultQuotaPage
+		}
+		return e.QuotaExceeded
+	}
+	return ""
+}
+
+// Scan implements the sql.Scanner interface.
+func (e *ErrorPages) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return e.scanBytes(src)
+	case string:
+		return e.scanBytes([]byte(src))
+	case nil:
+		*e = ErrorPages{}
+		return nil
+	}
+
+	return fmt.Errorf("pq: cannot convert %T to ErrorPages", src)
+}
+
+func (e *ErrorPages) scanBytes(src []byte) error {
+	return json.Unmarshal(src, e)
+}
+
+// Value implements the driver.Valuer interface.
+func (e ErrorPages) Value() (driver.Value, error) {
+	if e == (ErrorPages{}) {
+		return nil, nil
+	}
+	b, err := json.Marshal(e)
+
+	return string(b), err
+}
+
+// ProxyOptions describes how a location is proxied to an instance
+type ProxyOptions struct {
+	Upstream          string
+	Websocket         bool
+	GRPC              bool
+	ClientMaxBodySize string
+	ConnectTimeout    uint
+	ReadTimeout       uint
+	SendTimeout       uint
+}
+
+// ProxyLimits are the client max body size and the read and send timeouts in seconds of a proxied location,
+// zero values use the defaults of the router
+type ProxyLimits struct {
+	ClientMaxBodySize string
+	ReadTimeout       uint
+	SendTimeout       uint
+}
+
+// Credential is a user which may access a location protected by basic auth.
+// Password is only used to pass a plain text password to the service, it is never stored.
+type Credential struct {
+	Username string
+	Password string `json:"-"`
+	Hash     string
+}
+
+// AccessOptions restricts the access to a location by basic auth credentials and/or
+// a list of allowed IP addresses and networks
+type AccessOptions struct {
+	Realm       string
+	Credentials []*Credential
+	Allow       []string
 }
 
 // LocationRule is a struct which combines a single location (URL path) with a set of rules
 type LocationRule struct {
 	Location string
 	Rules    map[string][]string
+	Proxy    *ProxyOptions  `json:",omitempty"`
+	Access   *AccessOptions `json:",omitempty"`
 }
 
 // Scan implements the sql.Scanner interface.
@@ -161,6 +345,191 @@ func (l LocationRules) Value() (driver.Value, error) {
 	return string(b), err
 }
 
+// Balancing algorithms an Upstream can use, an empty algorithm means round robin
+const (
+	RoundRobin = ""
+	LeastConn  = "least_conn"
+	IPHash     = "ip_hash"
+	Random     = "random"
+)
+
+// UpstreamMember is a single server an Upstream balances requests to
+type UpstreamMember struct {
+	Address     string `validate:"required"`
+	Weight      uint
+	MaxFails    uint
+	FailTimeout uint
+	Down        bool
+}
+
+// Upstream is a named group of servers, e.g. the replicas of an instance
+type Upstream struct {
+	Name      string `validate:"required,name"`
+	Algorithm string
+	Members   []*UpstreamMember
+}
+
+// Member returns the index of the member with the given address, -1 if there is none
+func (u *Upstream) Member(address string) int {
+	for i, m := range u.Members {
+		if m.Address == address {
+			return i
+		}
+	}
+	return -1
+}
+
+// Upstreams is an array of Upstreams
+type Upstreams []*Upstream
+
+// Scan implements the sql.Scanner interface.
+func (u *Upstreams) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return u.scanBytes(src)
+	case string:
+		return u.scanBytes([]byte(src))
+	case nil:
+		*u = nil
+		return nil
+	}
+
+	return fmt.Errorf("pq: cannot convert %T to Upstreams", src)
+}
+
+func (u *Upstreams) scanBytes(src []byte) error {
+	return json.Unmarshal(src, u)
+}
+
+// Value implements the driver.Valuer interface.
+func (u Upstreams) Value() (driver.Value, error) {
+	if u == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(u)
+
+	return string(b), err
+}
+
+// Get returns the index of the upstream with the given name, -1 if there is none
+func (u Upstreams) Get(name string) int {
+	for i, up := range u {
+		if up.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Colors of the upstreams of a Switch
+const (
+	Blue  = "blue"
+	Green = "green"
+)
+
+// Switch points the locations proxying to its name at the blue or the green upstream of a configuration,
+// e.g. the replica sets of two releases of an instance. Canary is the percentage of the requests sent to
+// the inactive upstream, clients are split by their address and user agent, so they stay on one upstream.
+type Switch struct {
+	Name   string `validate:"required,name"`
+	Blue   string `validate:"required,name"`
+	Green  string `validate:"required,name"`
+	Active string `validate:"oneof=blue|green"`
+	Canary uint   `validate:"max=100"`
+}
+
+// ActiveUpstream returns the name of the upstream the switch points at
+func (s Switch) ActiveUpstream() string {
+	if s.Active == Green {
+		return s.Green
+	}
+	return s.Blue
+}
+
+// InactiveUpstream returns the name of the upstream the canary requests are sent to
+func (s Switch) InactiveUpstream() string {
+	if s.Active == Green {
+		return s.Blue
+	}
+	return s.Green
+}
+
+// Split returns whether the requests are split between both upstreams
+func (s Switch) Split() bool {
+	return s.Canary > 0 && s.Canary < 100
+}
+
+// Validate checks whether the switch points at two upstreams of u and does not shadow one
+func (s Switch) Validate(u Upstreams) error {
+	if s.Active != Blue && s.Active != Green {
+		return fmt.Errorf("active upstream has to be %s or %s", Blue, Green)
+	}
+	if s.Canary > 100 {
+		return errors.New("canary is no percentage")
+	}
+	if u.Get(s.Name) != -1 {
+		return fmt.Errorf("switch %s has the name of an upstream", s.Name)
+	}
+	for _, up := range []string{s.Blue, s.Green} {
+		if u.Get(up) == -1 {
+			return fmt.Errorf("upstream %s does not exist", up)
+		}
+	}
+	return nil
+}
+
+// Switches is an array of Switches
+type Switches []*Switch
+
+// Scan implements the sql.Scanner interface.
+func (s *Switches) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case []byte:
+		return s.scanBytes(src)
+	case string:
+		return s.scanBytes([]byte(src))
+	case nil:
+		*s = nil
+		return nil
+	}
+
+	return fmt.Errorf("pq: cannot convert %T to Switches", src)
+}
+
+func (s *Switches) scanBytes(src []byte) error {
+	return json.Unmarshal(src, s)
+}
+
+// Value implements the driver.Valuer interface.
+func (s Switches) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(s)
+
+	return string(b), err
+}
+
+// Get returns the index of the switch with the given name, -1 if there is none
+func (s Switches) Get(name string) int {
+	for i, sw := range s {
+		if sw.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Uses returns the name of the first switch pointing at the upstream, an empty string if there is none
+func (s Switches) Uses(upstream string) string {
+	for _, sw := range s {
+		if sw.Blue == upstream || sw.Green == upstream {
+			return sw.Name
+		}
+	}
+	return ""
+}
+
 // The RouterConfig struct represents the collected information needed to configurate an http router
 type RouterConfig struct {
 	RefID           uint             `gorm:"primary_key"`
@@ -172,9 +541,82 @@ type RouterConfig struct {
 	RootPath        string
 	SSLSettings     SSLSettings   `sql:"type:jsonb"`
 	LocationRules   LocationRules `sql:"type:jsonb[]"`
+	Upstreams       Upstreams     `sql:"type:jsonb"`
+	HTTPS           *HTTPSPolicy  `sql:"type:jsonb"`
+	ErrorPages      *ErrorPages   `sql:"type:jsonb"`
+	Switches        Switches      `sql:"type:jsonb"`
 }
 
 // TableName sets RouterConfig's database table name
 func (RouterConfig) TableName() string {
 	return "routing"
 }
+
+// UpstreamName returns the router wide unique name of an upstream of the configuration
+func (r RouterConfig) UpstreamName(name string) string {
+	return fmt.Sprintf("%d_%s_%s", r.RefID, r.Name, name)
+}
+
+// SwitchVariable returns the nginx variable the clients of a switch of the configuration are split into
+func (r RouterConfig) SwitchVariable(name string) string {
+	return "kroo_" + strings.Map(func(c rune) rune {
+		if c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c) {
+			return c
+		}
+		return '_'
+	}, r.UpstreamName(name))
+}
+
+// ProxyTarget returns the upstream name if the proxy options refer to an upstream
+// of the configuration, the upstream or variable of a switch if they refer to a switch,
+// otherwise the address itself
+func (r RouterConfig) ProxyTarget(p *ProxyOptions) string {
+	if i := r.Switches.Get(p.Upstream); i != -1 {
+		s := r.Switches[i]
+		switch {
+		case s.Split():
+			return "$" + r.SwitchVariable(s.Name)
+		case s.Canary == 100:
+			return r.UpstreamName(s.InactiveUpstream())
+		}
+		return r.UpstreamName(s.ActiveUpstream())
+	}
+
+	if r.Upstreams.Get(p.Upstream) != -1 {
+		return r.UpstreamName(p.Upstream)
+	}
+	return p.Upstream
+}
+
+// HSTSHeader returns the Strict-Transport-Security header of the configuration, without a HSTS policy
+// browsers are told to forget the ones they saw before
+func (r RouterConfig) HSTSHeader() string {
+	if r.HTTPS == nil || r.HTTPS.HSTS == nil {
+		return "max-age=0; includeSubDomains"
+	}
+	return r.HTTPS.HSTS.Header()
+}
+
+// ErrorPageCodes returns the status codes the configuration has custom error pages for
+func (r RouterConfig) ErrorPageCodes() []int {
+	codes := []int{}
+	if r.ErrorPages == nil {
+		return codes
+	}
+	for _, code := range ErrorPageCodes {
+		if r.ErrorPages.Page(code) != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// InMaintenance returns whether the instance of the configuration is in maintenance
+func (r RouterConfig) InMaintenance() bool {
+	return r.ErrorPages != nil && r.ErrorPages.Maintenance
+}
+
+// OverQuota returns whether the traffic quota of the user of the configuration is exhausted
+func (r RouterConfig) OverQuota() bool {
+	return r.ErrorPages != nil && r.ErrorPages.OverQuota
+}
@@ -2,8 +2,10 @@ package routing
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
 )
 
 // Endpoints is a struct which collects all endpoints for the routing service
@@ -18,17 +20,30 @@ type Endpoints struct {
 	AddServerNameEndpoint         endpoint.Endpoint
 	RemoveServerNameEndpoint      endpoint.Endpoint
 	ConfigurationsEndpoint        endpoint.Endpoint
+	SetUpstreamEndpoint           endpoint.Endpoint
+	RemoveUpstreamEndpoint        endpoint.Endpoint
+	AddUpstreamMemberEndpoint     endpoint.Endpoint
+	RemoveUpstreamMemberEndpoint  endpoint.Endpoint
+	TrafficEndpoint               endpoint.Endpoint
+	TrafficRateEndpoint           endpoint.Endpoint
+	SetHTTPSPolicyEndpoint        endpoint.Endpoint
+	SetErrorPageEndpoint          endpoint.Endpoint
+	SetMaintenanceEndpoint        endpoint.Endpoint
+	SetProxyLimitsEndpoint        endpoint.Endpoint
+	SetSwitchEndpoint             endpoint.Endpoint
+	RemoveSwitchEndpoint          endpoint.Endpoint
+	QuotaEndpoint                 endpoint.Endpoint
 }
 
 // IDRequest combines a RefID and a name
 type IDRequest struct {
 	RefID uint
-	Name  string
+	Name  string `validate:"required,name"`
 }
 
 // CreateConfigRequest is the request struct for the CreateConfigEndpoint
 type CreateConfigRequest struct {
-	Config *RouterConfig
+	Config *RouterConfig `validate:"required"`
 }
 
 // CreateConfigResponse is the response struct for the CreateConfigEndpoint
@@ -48,7 +63,7 @@ func MakeCreateConfigEndpoint(s Service) endpoint.Endpoint {
 // EditConfigRequest is the request struct for the EditConfigEndpoint
 type EditConfigRequest struct {
 	IDRequest
-	Config *RouterConfig
+	Config *RouterConfig `validate:"required"`
 }
 
 // EditConfigResponse is the response struct for the EditConfigEndpoint
@@ -111,7 +126,7 @@ func MakeRemoveConfigEndpoint(s Service) endpoint.Endpoint {
 // AddLocationRequest is the request struct for the AddLocationEndpoint
 type AddLocationRequest struct {
 	IDRequest
-	Location *LocationRule
+	Location *LocationRule `validate:"required"`
 }
 
 // AddLocationResponse is the response struct for the AddLocationEndpoint
@@ -151,7 +166,7 @@ func MakeRemoveLocationEndpoint(s Service) endpoint.Endpoint {
 // ChangeListenStatementRequest is the request struct for the ChangeListenStatementEndpoint
 type ChangeListenStatementRequest struct {
 	IDRequest
-	ListenStatement *ListenStatement
+	ListenStatement *ListenStatement `validate:"required"`
 }
 
 // ChangeListenStatementResponse is the response struct for the ChangeListenStatementEndpoint
@@ -171,7 +186,7 @@ func MakeChangeListenStatementEndpoint(s Service) endpoint.Endpoint {
 // AddServerNameRequest is the request struct for the AddServerNameEndpoint
 type AddServerNameRequest struct {
 	IDRequest
-	ServerName string
+	ServerName string `validate:"required"`
 }
 
 // AddServerNameResponse is the response struct for the AddServerNameEndpoint
@@ -226,3 +241,297 @@ func MakeConfigurationsEndpoint(s Service) endpoint.Endpoint {
 		}, nil
 	}
 }
+
+// SetUpstreamRequest is the request struct for the SetUpstreamEndpoint
+type SetUpstreamRequest struct {
+	IDRequest
+	Upstream *Upstream `validate:"required"`
+}
+
+// SetUpstreamResponse is the response struct for the SetUpstreamEndpoint
+type SetUpstreamResponse struct {
+	Error error
+}
+
+// MakeSetUpstreamEndpoint creates a gokit endpoint which invokes SetUpstream
+func MakeSetUpstreamEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetUpstreamRequest)
+		err := s.SetUpstream(req.RefID, req.Name, req.Upstream)
+		return SetUpstreamResponse{err}, nil
+	}
+}
+
+// RemoveUpstreamRequest is the request struct for the RemoveUpstreamEndpoint
+type RemoveUpstreamRequest struct {
+	IDRequest
+	Upstream string `validate:"required,name"`
+}
+
+// RemoveUpstreamResponse is the response struct for the RemoveUpstreamEndpoint
+type RemoveUpstreamResponse struct {
+	Error error
+}
+
+// MakeRemoveUpstreamEndpoint creates a gokit endpoint which invokes RemoveUpstream
+func MakeRemoveUpstreamEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveUpstreamRequest)
+		err := s.RemoveUpstream(req.RefID, req.Name, req.Upstream)
+		return RemoveUpstreamResponse{err}, nil
+	}
+}
+
+// AddUpstreamMemberRequest is the request struct for the AddUpstreamMemberEndpoint
+type AddUpstreamMemberRequest struct {
+	IDRequest
+	Upstream string          `validate:"required,name"`
+	Member   *UpstreamMember `validate:"required"`
+}
+
+// AddUpstreamMemberResponse is the response struct for the AddUpstreamMemberEndpoint
+type AddUpstreamMemberResponse struct {
+	Error error
+}
+
+// MakeAddUpstreamMemberEndpoint creates a gokit endpoint which invokes AddUpstreamMember
+func MakeAddUpstreamMemberEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AddUpstreamMemberRequest)
+		err := s.AddUpstreamMember(req.RefID, req.Name, req.Upstream, req.Member)
+		return AddUpstreamMemberResponse{err}, nil
+	}
+}
+
+// RemoveUpstreamMemberRequest is the request struct for the RemoveUpstreamMemberEndpoint
+type RemoveUpstreamMemberRequest struct {
+	IDRequest
+	Upstream string `validate:"required,name"`
+	Address  string `validate:"required"`
+}
+
+// RemoveUpstreamMemberResponse is the response struct for the RemoveUpstreamMemberEndpoint
+type RemoveUpstreamMemberResponse struct {
+	Error error
+}
+
+// MakeRemoveUpstreamMemberEndpoint creates a gokit endpoint which invokes RemoveUpstreamMember
+func MakeRemoveUpstreamMemberEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveUpstreamMemberRequest)
+		err := s.RemoveUpstreamMember(req.RefID, req.Name, req.Upstream, req.Address)
+		return RemoveUpstreamMemberResponse{err}, nil
+	}
+}
+
+// SetHTTPSPolicyRequest is the request struct for the SetHTTPSPolicyEndpoint
+type SetHTTPSPolicyRequest struct {
+	IDRequest
+	Policy *HTTPSPolicy
+}
+
+// SetHTTPSPolicyResponse is the response struct for the SetHTTPSPolicyEndpoint
+type SetHTTPSPolicyResponse struct {
+	Error error
+}
+
+// MakeSetHTTPSPolicyEndpoint creates a gokit endpoint which invokes SetHTTPSPolicy
+func MakeSetHTTPSPolicyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetHTTPSPolicyRequest)
+		err := s.SetHTTPSPolicy(req.RefID, req.Name, req.Policy)
+		return SetHTTPSPolicyResponse{err}, nil
+	}
+}
+
+// SetErrorPageRequest is the request struct for the SetErrorPageEndpoint
+type SetErrorPageRequest struct {
+	IDRequest
+	Code int `validate:"oneof=502|503|509"`
+	Page string
+}
+
+// SetErrorPageResponse is the response struct for the SetErrorPageEndpoint
+type SetErrorPageResponse struct {
+	Error error
+}
+
+// MakeSetErrorPageEndpoint creates a gokit endpoint which invokes SetErrorPage
+func MakeSetErrorPageEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetErrorPageRequest)
+		err := s.SetErrorPage(req.RefID, req.Name, req.Code, req.Page)
+		return SetErrorPageResponse{err}, nil
+	}
+}
+
+// SetMaintenanceRequest is the request struct for the SetMaintenanceEndpoint
+type SetMaintenanceRequest struct {
+	IDRequest
+	Maintenance bool
+}
+
+// SetMaintenanceResponse is the response struct for the SetMaintenanceEndpoint
+type SetMaintenanceResponse struct {
+	Error error
+}
+
+// MakeSetMaintenanceEndpoint creates a gokit endpoint which invokes SetMaintenance
+func MakeSetMaintenanceEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetMaintenanceRequest)
+		err := s.SetMaintenance(req.RefID, req.Name, req.Maintenance)
+		return SetMaintenanceResponse{err}, nil
+	}
+}
+
+// SetSwitchRequest is the request struct for the SetSwitchEndpoint
+type SetSwitchRequest struct {
+	IDRequest
+	Switch *Switch `validate:"required"`
+}
+
+// SetSwitchResponse is the response struct for the SetSwitchEndpoint
+type SetSwitchResponse struct {
+	Error error
+}
+
+// MakeSetSwitchEndpoint creates a gokit endpoint which invokes SetSwitch
+func MakeSetSwitchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetSwitchRequest)
+		err := s.SetSwitch(req.RefID, req.Name, req.Switch)
+		return SetSwitchResponse{err}, nil
+	}
+}
+
+// RemoveSwitchRequest is the request struct for the RemoveSwitchEndpoint
+type RemoveSwitchRequest struct {
+	IDRequest
+	Switch string `validate:"required,name"`
+}
+
+// RemoveSwitchResponse is the response struct for the RemoveSwitchEndpoint
+type RemoveSwitchResponse struct {
+	Error error
+}
+
+// MakeRemoveSwitchEndpoint creates a gokit endpoint which invokes RemoveSwitch
+func MakeRemoveSwitchEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveSwitchRequest)
+		err := s.RemoveSwitch(req.RefID, req.Name, req.Switch)
+		return RemoveSwitchResponse{err}, nil
+	}
+}
+
+// SetProxyLimitsRequest is the request struct for the SetProxyLimitsEndpoint
+type SetProxyLimitsRequest struct {
+	IDRequest
+	LID    int
+	Limits *ProxyLimits
+}
+
+// SetProxyLimitsResponse is the response struct for the SetProxyLimitsEndpoint
+type SetProxyLimitsResponse struct {
+	Error error
+}
+
+// MakeSetProxyLimitsEndpoint creates a gokit endpoint which invokes SetProxyLimits
+func MakeSetProxyLimitsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetProxyLimitsRequest)
+		err := s.SetProxyLimits(req.RefID, req.Name, req.LID, req.Limits)
+		return SetProxyLimitsResponse{err}, nil
+	}
+}
+
+// QuotaRequest is the request struct for the QuotaEndpoint
+type QuotaRequest struct {
+	RefID uint
+}
+
+// QuotaResponse is the response struct for the QuotaEndpoint
+type QuotaResponse struct {
+	Quota Quota
+	Error error
+}
+
+// MakeQuotaEndpoint creates a gokit endpoint which returns the traffic quota of a user in the current month
+func MakeQuotaEndpoint(q *QuotaEnforcer) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(QuotaRequest)
+		quota, err := q.Quota(req.RefID, time.Now())
+		return QuotaResponse{
+			Quota: quota,
+			Error: err,
+		}, nil
+	}
+}
+
+// TrafficRequest is the request struct for the TrafficEndpoint
+type TrafficRequest struct {
+	IDRequest
+	From time.Time
+	To   time.Time
+}
+
+// TrafficResponse is the response struct for the TrafficEndpoint
+type TrafficResponse struct {
+	Traffic []Traffic
+	Error   error
+}
+
+// MakeTrafficEndpoint creates a gokit endpoint which invokes Traffic
+func MakeTrafficEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(TrafficRequest)
+		traffic := []Traffic{}
+		err := s.Traffic(req.RefID, req.Name, req.From, req.To, &traffic)
+		return TrafficResponse{
+			Traffic: traffic,
+			Error:   err,
+		}, nil
+	}
+}
+
+// TrafficRateRequest is the request struct for the TrafficRateEndpoint
+type TrafficRateRequest struct {
+	IDRequest
+}
+
+// TrafficRateResponse is the response struct for the TrafficRateEndpoint
+type TrafficRateResponse struct {
+	Rates []TrafficRate
+	Error error
+}
+
+// MakeTrafficRateEndpoint creates a gokit endpoint which streams the request rates a Collector
+// publishes for a configuration, it can only be used with the websocket transport
+func MakeTrafficRateEndpoint(c *Collector) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(TrafficRateRequest)
+		rates, cancel := c.Subscribe(req.RefID, req.Name)
+
+		responses := make(chan interface{})
+		stop := make(chan struct{})
+		go func() {
+			defer close(responses)
+			for r := range rates {
+				select {
+				case responses <- TrafficRateResponse{Rates: r}:
+				case <-stop:
+					return
+				}
+			}
+		}()
+
+		return &ws.Stream{
+			C: responses,
+			Stop: func() {
+				close(stop)
+				cancel()
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,83 @@
+package routing
+
+import (
+	"net"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// DialFunc opens a connection to an address, it is used to probe upstream members
+type DialFunc func(network, address string, timeout time.Duration) (net.Conn, error)
+
+// HealthCheck probes the members of every upstream and marks unreachable ones as down,
+// so the router stops balancing requests to them until they are reachable again
+type HealthCheck struct {
+	s       Service
+	dial    DialFunc
+	timeout time.Duration
+	logger  log.Logger
+}
+
+func (h *HealthCheck) healthy(address string) bool {
+	conn, err := h.dial("tcp", address, h.timeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Check probes all upstream members once and updates those whose state changed
+func (h *HealthCheck) Check() {
+	var confs []RouterConfig
+	h.s.Configurations(&confs)
+
+	for _, c := range confs {
+		for _, u := range c.Upstreams {
+			for _, m := range u.Members {
+				down := !h.healthy(m.Address)
+				if down == m.Down {
+					continue
+				}
+
+				err := h.s.SetUpstreamMemberDown(c.RefID, c.Name, u.Name, m.Address, down)
+				if err != nil {
+					level.Error(h.logger).Log("upstream", c.UpstreamName(u.Name), "member", m.Address, "err", err)
+				}
+			}
+		}
+	}
+}
+
+// Run calls Check every interval until stop is closed
+func (h *HealthCheck) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		h.Check()
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewHealthCheck returns a HealthCheck for the upstreams of s. The routing service should be
+// a writing service, so state changes end up in the router. If dial is nil net.DialTimeout is used.
+func NewHealthCheck(s Service, dial DialFunc, timeout time.Duration, logger log.Logger) *HealthCheck {
+	if dial == nil {
+		dial = net.DialTimeout
+	}
+
+	return &HealthCheck{
+		s:       s,
+		dial:    dial,
+		timeout: timeout,
+		logger:  logger,
+	}
+}
@@ -0,0 +1,41 @@
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// LocationRulesFromKMI converts the routes section of a KMI, mapping locations to
+// their proxy options, into LocationRules
+func LocationRulesFromKMI(routes abstraction.JSON) (LocationRules, error) {
+	locations := make([]string, 0, len(routes))
+	for l := range routes {
+		locations = append(locations, l)
+	}
+	sort.Strings(locations)
+
+	lr := make(LocationRules, len(locations))
+	for i, l := range locations {
+		b, err := json.Marshal(routes[l])
+		if err != nil {
+			return nil, err
+		}
+
+		opts := &ProxyOptions{}
+		err = json.Unmarshal(b, opts)
+		if err != nil {
+			return nil, fmt.Errorf("route %s malformatted: %s", l, err)
+		}
+
+		lr[i] = &LocationRule{
+			Location: l,
+			Rules:    make(map[string][]string),
+			Proxy:    opts,
+		}
+	}
+
+	return lr, nil
+}
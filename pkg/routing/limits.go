@@ -0,0 +1,212 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Plans of users which are not limited by the configured plans
+const (
+	// DefaultPlan is used for users whose plan is not configured
+	DefaultPlan = "default"
+	// Unlimited is the plan of users who are never limited, like admins
+	Unlimited = "unlimited"
+)
+
+var (
+	// ErrBodySizeLimit occurs if the client max body size of a location exceeds the plan of its user
+	ErrBodySizeLimit = errors.New("client max body size exceeds the limit of the plan")
+
+	// ErrTimeoutLimit occurs if a timeout of a location exceeds the plan of its user
+	ErrTimeoutLimit = errors.New("timeout exceeds the limit of the plan")
+)
+
+// Plan limits the proxy options users set for the locations of their configurations and their monthly traffic
+type Plan struct {
+	// MaxBodySize is the largest client max body size in the format of nginx, e.g. 100m,
+	// an empty size does not limit it
+	MaxBodySize string `yaml:"maxBodySize"`
+	// MaxTimeout is the number of seconds the connect, read and send timeouts may be set to, 0 does not limit them
+	MaxTimeout uint `yaml:"maxTimeout"`
+	// MonthlyTraffic is the traffic quota of a calendar month like MaxBodySize, e.g. 500g,
+	// an empty quota does not limit the traffic
+	MonthlyTraffic string `yaml:"monthlyTraffic"`
+	// Exhausted is QuotaPage or QuotaThrottle, it defaults to QuotaPage
+	Exhausted string `yaml:"exhausted"`
+	// ThrottleRate is the rate in the format of tc the traffic is throttled to with QuotaThrottle, e.g. 1mbit
+	ThrottleRate string `yaml:"throttleRate"`
+}
+
+// PlanFunc returns the name of the plan of a user
+type PlanFunc func(refID uint) (string, error)
+
+// ParseSize returns the number of bytes of a size in the format of nginx, a number optionally
+// followed by k, m or g
+func ParseSize(size string) (uint64, error) {
+	s, unit := size, uint64(1)
+	if s != "" {
+		switch strings.ToLower(s[len(s)-1:]) {
+		case "k":
+			unit = 1 << 10
+		case "m":
+			unit = 1 << 20
+		case "g":
+			unit = 1 << 30
+		}
+	}
+	if unit != 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s is no valid size", size)
+	}
+	return n * unit, nil
+}
+
+// allows checks the proxy options of a location against the plan
+func (p Plan) allows(o *ProxyOptions) error {
+	if o == nil {
+		return nil
+	}
+
+	if p.MaxBodySize != "" && o.ClientMaxBodySize != "" {
+		max, err := ParseSize(p.MaxBodySize)
+		if err != nil {
+			return err
+		}
+
+		size, err := ParseSize(o.ClientMaxBodySize)
+		if err != nil {
+			return err
+		}
+
+		// nginx does not check the body size for 0
+		if size == 0 || size > max {
+			return ErrBodySizeLimit
+		}
+	}
+
+	if p.MaxTimeout != 0 && (o.ConnectTimeout > p.MaxTimeout || o.ReadTimeout > p.MaxTimeout || o.SendTimeout > p.MaxTimeout) {
+		return ErrTimeoutLimit
+	}
+	return nil
+}
+
+// LimitedService is a Service which limits the proxy options of the locations to the plan of their user
+type LimitedService interface {
+	Service
+
+	// SetPlans replaces the configured plans, locations exceeding a new limit are kept
+	SetPlans(plans map[string]Plan)
+
+	// Plan returns the plan of a user
+	Plan(refID uint) (Plan, error)
+}
+
+type limitedService struct {
+	Service
+	mtx    *sync.RWMutex
+	planOf PlanFunc
+	plans  map[string]Plan
+}
+
+func (l *limitedService) SetPlans(plans map[string]Plan) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	l.plans = plans
+}
+
+// Plan returns the plan of a user, users are not limited if neither their plan nor the default plan is configured
+func (l *limitedService) Plan(refID uint) (Plan, error) {
+	name, err := l.planOf(refID)
+	if err != nil {
+		return Plan{}, err
+	}
+	if name == Unlimited {
+		return Plan{}, nil
+	}
+
+	l.mtx.RLock()
+	defer l.mtx.RUnlock()
+
+	p, ok := l.plans[name]
+	if !ok {
+		p = l.plans[DefaultPlan]
+	}
+	return p, nil
+}
+
+// check checks the proxy options of locations against the plan of refID
+func (l *limitedService) check(refID uint, locations ...*LocationRule) error {
+	p, err := l.Plan(refID)
+	if err != nil {
+		return err
+	}
+
+	for _, lr := range locations {
+		if lr == nil {
+			continue
+		}
+
+		err = p.allows(lr.Proxy)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *limitedService) CreateRouterConfig(r *RouterConfig) error {
+	err := l.check(r.RefID, r.LocationRules...)
+	if err != nil {
+		return err
+	}
+	return l.Service.CreateRouterConfig(r)
+}
+
+func (l *limitedService) EditRouterConfig(refID uint, name string, r *RouterConfig) error {
+	err := l.check(refID, r.LocationRules...)
+	if err != nil {
+		return err
+	}
+	return l.Service.EditRouterConfig(refID, name, r)
+}
+
+func (l *limitedService) AddLocationRule(refID uint, name string, lr *LocationRule) error {
+	err := l.check(refID, lr)
+	if err != nil {
+		return err
+	}
+	return l.Service.AddLocationRule(refID, name, lr)
+}
+
+func (l *limitedService) SetProxyLimits(refID uint, name string, lid int, pl *ProxyLimits) error {
+	if pl != nil {
+		err := l.check(refID, &LocationRule{Proxy: &ProxyOptions{
+			ClientMaxBodySize: pl.ClientMaxBodySize,
+			ReadTimeout:       pl.ReadTimeout,
+			SendTimeout:       pl.SendTimeout,
+		}})
+		if err != nil {
+			return err
+		}
+	}
+	return l.Service.SetProxyLimits(refID, name, lid, pl)
+}
+
+// NewLimitedService returns a LimitedService wrapping s, the plans are looked up by planOf and users whose plan is
+// not configured get the DefaultPlan
+func NewLimitedService(s Service, planOf PlanFunc, plans map[string]Plan) LimitedService {
+	return &limitedService{
+		Service: s,
+		mtx:     &sync.RWMutex{},
+		planOf:  planOf,
+		plans:   plans,
+	}
+}
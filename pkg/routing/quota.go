@@ -0,0 +1,225 @@
+package routing
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Behaviors of a plan once the monthly traffic of a user is exhausted
+const (
+	// QuotaPage answers the requests to the configurations of the user with 509 and the quota exceeded page
+	QuotaPage = "page"
+	// QuotaThrottle throttles the traffic of the containers of the user to the throttle rate of the plan
+	QuotaThrottle = "throttle"
+)
+
+// TrafficFunc returns the traffic of a user between from and to in bytes, which did not pass the router
+type TrafficFunc func(refID uint, from time.Time, to time.Time) (uint64, error)
+
+// Throttler limits the bandwidth of the containers of a user
+type Throttler interface {
+	// Throttle limits the traffic of the containers of a user to rate in the format of tc, e.g. 1mbit
+	Throttle(refID uint, rate string) error
+
+	// Unthrottle removes the limit of the containers of a user, it succeeds if they are not throttled
+	Unthrottle(refID uint) error
+}
+
+// Quota is the traffic of a user in the current month and the quota of the user's plan
+type Quota struct {
+	// Period is the first day of the month in UTC
+	Period time.Time
+	// Proxied is the number of bytes the router sent for the configurations of the user
+	Proxied uint64
+	// Network is the number of bytes the containers of the user sent and received without the router
+	Network uint64
+	// Limit is the monthly traffic in bytes, 0 does not limit it
+	Limit uint64
+	// Behavior is QuotaPage or QuotaThrottle and Rate the rate the traffic is throttled to
+	Behavior  string
+	Rate      string
+	Exhausted bool
+}
+
+// Used returns the traffic of the month in bytes
+func (q Quota) Used() uint64 {
+	return q.Proxied + q.Network
+}
+
+// month returns the first day of the month of t in UTC
+func month(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// QuotaEnforcer measures the monthly traffic of the users with configurations and enforces the quotas
+// of their plans. The traffic is the one of the traffic analytics and of a TrafficFunc, e.g. the iptables
+// counters of the network metering. Quotas are lifted once a new month starts or the plan changes.
+type QuotaEnforcer struct {
+	s         Service
+	plan      func(refID uint) (Plan, error)
+	network   TrafficFunc
+	throttler Throttler
+	logger    log.Logger
+
+	mtx       sync.Mutex
+	throttled map[uint]bool
+}
+
+// quota returns the quota of a user with the configurations confs
+func (q *QuotaEnforcer) quota(refID uint, confs []RouterConfig, now time.Time) (Quota, error) {
+	p, err := q.plan(refID)
+	if err != nil {
+		return Quota{}, err
+	}
+
+	quota := Quota{
+		Period:   month(now),
+		Behavior: p.Exhausted,
+		Rate:     p.ThrottleRate,
+	}
+	if quota.Behavior == "" {
+		quota.Behavior = QuotaPage
+	}
+
+	if p.MonthlyTraffic != "" {
+		quota.Limit, err = ParseSize(p.MonthlyTraffic)
+		if err != nil {
+			return Quota{}, err
+		}
+	}
+
+	to := quota.Period.AddDate(0, 1, 0)
+	for _, c := range confs {
+		traffic := []Traffic{}
+		err = q.s.Traffic(refID, c.Name, quota.Period, to, &traffic)
+		if err != nil {
+			return Quota{}, err
+		}
+
+		// the rows are filtered again since the conditions are not applied by every dbAdapter
+		for _, t := range traffic {
+			if t.RefID == refID && t.Name == c.Name && !t.Period.Before(quota.Period) && t.Period.Before(to) {
+				quota.Proxied += t.BytesSent
+			}
+		}
+	}
+
+	if q.network != nil {
+		quota.Network, err = q.network(refID, quota.Period, to)
+		if err != nil {
+			return Quota{}, err
+		}
+	}
+
+	quota.Exhausted = quota.Limit != 0 && quota.Used() >= quota.Limit
+	return quota, nil
+}
+
+// Quota returns the quota of a user in the month of now
+func (q *QuotaEnforcer) Quota(refID uint, now time.Time) (Quota, error) {
+	var all []RouterConfig
+	q.s.Configurations(&all)
+
+	confs := []RouterConfig{}
+	for _, c := range all {
+		if c.RefID == refID {
+			confs = append(confs, c)
+		}
+	}
+	return q.quota(refID, confs, now)
+}
+
+// apply answers the requests to the configurations with 509 or throttles the containers of a user
+// if the quota is exhausted and lifts the measure otherwise
+func (q *QuotaEnforcer) apply(refID uint, confs []RouterConfig, quota Quota) {
+	page := quota.Exhausted && quota.Behavior == QuotaPage
+	for _, c := range confs {
+		if c.OverQuota() == page {
+			continue
+		}
+
+		err := q.s.SetOverQuota(c.RefID, c.Name, page)
+		if err != nil {
+			level.Error(q.logger).Log("config", c.Name, "refID", refID, "err", err)
+		}
+	}
+
+	if q.throttler == nil {
+		return
+	}
+
+	// users who are not known yet are unthrottled as well, they might have been throttled before a restart
+	throttle := quota.Exhausted && quota.Behavior == QuotaThrottle
+	if throttled, ok := q.throttled[refID]; ok && throttled == throttle {
+		return
+	}
+
+	var err error
+	if throttle {
+		err = q.throttler.Throttle(refID, quota.Rate)
+	} else {
+		err = q.throttler.Unthrottle(refID)
+	}
+	if err != nil {
+		level.Error(q.logger).Log("refID", refID, "throttle", throttle, "err", err)
+		return
+	}
+	q.throttled[refID] = throttle
+}
+
+// Enforce checks the quotas of every user with configurations in the month of now
+func (q *QuotaEnforcer) Enforce(now time.Time) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	var all []RouterConfig
+	q.s.Configurations(&all)
+
+	users := make(map[uint][]RouterConfig)
+	for _, c := range all {
+		users[c.RefID] = append(users[c.RefID], c)
+	}
+
+	for refID, confs := range users {
+		quota, err := q.quota(refID, confs, now)
+		if err != nil {
+			level.Error(q.logger).Log("refID", refID, "err", err)
+			continue
+		}
+		q.apply(refID, confs, quota)
+	}
+}
+
+// Run calls Enforce every interval until stop is closed
+func (q *QuotaEnforcer) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		q.Enforce(time.Now())
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewQuotaEnforcer returns a QuotaEnforcer for the configurations of s, plan returns the plan of a user.
+// The routing service should be a writing service like for the HealthCheck. network and throttler are
+// optional, without a throttler QuotaThrottle does not limit the traffic.
+func NewQuotaEnforcer(s Service, plan func(refID uint) (Plan, error), network TrafficFunc, throttler Throttler, logger log.Logger) *QuotaEnforcer {
+	return &QuotaEnforcer{
+		s:         s,
+		plan:      plan,
+		network:   network,
+		throttler: throttler,
+		logger:    logger,
+		throttled: make(map[uint]bool),
+	}
+}
@@ -1,6 +1,18 @@
 package routing_test
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/cache"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
 	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
 
@@ -167,6 +179,30 @@ var _ = Describe("Routing", func() {
 			Expect(conf.LocationRules).To(HaveLen(1))
 		})
 
+		It("Should store credentials hashed", func() {
+			refID, name := uint(2), "test"
+			routingService.CreateRouterConfig(&routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+			})
+
+			lr := &routing.LocationRule{
+				Location: "/admin",
+				Access: &routing.AccessOptions{
+					Credentials: []*routing.Credential{
+						&routing.Credential{Username: "admin", Password: "secret"},
+					},
+				},
+			}
+			err := routingService.AddLocationRule(refID, name, lr)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			cred := lr.Access.Credentials[0]
+			Expect(cred.Password).To(BeEmpty())
+			Expect(cred.Hash).To(HavePrefix("$6$"))
+			Expect(cred.Hash).NotTo(ContainSubstring("secret"))
+		})
+
 		It("Should return error if ID does not exist", func() {
 			err := routingService.AddLocationRule(28, "", &routing.LocationRule{})
 			Ω(err).Should(BeEquivalentTo(testutils.ErrNotFound))
@@ -245,6 +281,250 @@ var _ = Describe("Routing", func() {
 		})
 	})
 
+	Describe("HTTPS Policy", func() {
+		db := testutils.NewMockDB()
+		routingService, _ := routing.NewService(db)
+		It("Should set the https policy", func() {
+			refID, name := uint(1), "test"
+			routingService.CreateRouterConfig(&routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+			})
+
+			err := routingService.SetHTTPSPolicy(refID, name, &routing.HTTPSPolicy{
+				Redirect: &routing.HTTPSRedirect{Port: 8080},
+				HSTS:     &routing.HSTS{MaxAge: 600, IncludeSubdomains: true},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.HTTPS.Redirect.Port).To(BeEquivalentTo(8080))
+			Expect(conf.HSTSHeader()).To(Equal("max-age=600; includeSubDomains"))
+			Expect(conf.HTTPS.RedirectTarget()).To(Equal("https://$host"))
+		})
+
+		It("Should return error on db failure", func() {
+			db.SetError(1)
+			err := routingService.SetHTTPSPolicy(1, "test", nil)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Error Pages", func() {
+		db := testutils.NewMockDB()
+		routingService, _ := routing.NewService(db)
+		It("Should set error pages and maintenance", func() {
+			refID, name := uint(1), "test"
+			routingService.CreateRouterConfig(&routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+			})
+
+			err := routingService.SetErrorPage(refID, name, 502, "<h1>down</h1>")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = routingService.SetMaintenance(refID, name, true)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.ErrorPages.BadGateway).To(Equal("<h1>down</h1>"))
+			Expect(conf.ErrorPageCodes()).To(Equal([]int{502}))
+			Expect(conf.InMaintenance()).To(BeTrue())
+		})
+
+		It("Should return an error for other status codes", func() {
+			err := routingService.SetErrorPage(1, "test", 404, "<h1>not found</h1>")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should return an error if a page is too large", func() {
+			err := routingService.SetErrorPage(1, "test", 503, strings.Repeat("a", routing.MaxErrorPageSize+1))
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should return error on db failure", func() {
+			db.SetError(1)
+			err := routingService.SetMaintenance(1, "test", true)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Proxy Limits", func() {
+		db := testutils.NewMockDB()
+		routingService, _ := routing.NewService(db)
+		It("Should set the limits of a location", func() {
+			refID, name := uint(1), "test"
+			routingService.CreateRouterConfig(&routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{
+						Location: "/",
+						Proxy:    &routing.ProxyOptions{Upstream: "web", ConnectTimeout: 5},
+					},
+				},
+			})
+
+			err := routingService.SetProxyLimits(refID, name, 0, &routing.ProxyLimits{
+				ClientMaxBodySize: "100m",
+				ReadTimeout:       300,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.LocationRules[0].Proxy.Upstream).To(Equal("web"))
+			Expect(conf.LocationRules[0].Proxy.ConnectTimeout).To(BeEquivalentTo(5))
+			Expect(conf.LocationRules[0].Proxy.ClientMaxBodySize).To(Equal("100m"))
+			Expect(conf.LocationRules[0].Proxy.ReadTimeout).To(BeEquivalentTo(300))
+		})
+
+		It("Should return an error if the location does not exist", func() {
+			err := routingService.SetProxyLimits(1, "test", 1, nil)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Limited Service", func() {
+		plans := map[string]routing.Plan{
+			routing.DefaultPlan: routing.Plan{MaxBodySize: "10m", MaxTimeout: 60},
+			"2":                 routing.Plan{MaxBodySize: "1g", MaxTimeout: 600},
+		}
+		planOf := func(refID uint) (string, error) {
+			switch refID {
+			case 2:
+				return "2", nil
+			case 3:
+				return routing.Unlimited, nil
+			}
+			return routing.DefaultPlan, nil
+		}
+
+		newService := func() routing.LimitedService {
+			s, _ := routing.NewService(testutils.NewMockDB())
+			return routing.NewLimitedService(s, planOf, plans)
+		}
+
+		location := func(size string, timeout uint) *routing.LocationRule {
+			return &routing.LocationRule{
+				Location: "/",
+				Proxy:    &routing.ProxyOptions{Upstream: "web", ClientMaxBodySize: size, ReadTimeout: timeout},
+			}
+		}
+
+		It("Should limit locations to the plan of the user", func() {
+			s := newService()
+			err := s.CreateRouterConfig(&routing.RouterConfig{RefID: 1, Name: "test", LocationRules: routing.LocationRules{location("100m", 0)}})
+			Ω(err).Should(BeEquivalentTo(routing.ErrBodySizeLimit))
+
+			err = s.CreateRouterConfig(&routing.RouterConfig{RefID: 2, Name: "test", LocationRules: routing.LocationRules{location("100m", 0)}})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = s.AddLocationRule(2, "test", location("", 900))
+			Ω(err).Should(BeEquivalentTo(routing.ErrTimeoutLimit))
+
+			err = s.SetProxyLimits(2, "test", 0, &routing.ProxyLimits{ClientMaxBodySize: "0"})
+			Ω(err).Should(BeEquivalentTo(routing.ErrBodySizeLimit))
+
+			err = s.CreateRouterConfig(&routing.RouterConfig{RefID: 3, Name: "test", LocationRules: routing.LocationRules{location("0", 3600)}})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should apply new plans", func() {
+			s := newService()
+			s.SetPlans(map[string]routing.Plan{})
+			err := s.CreateRouterConfig(&routing.RouterConfig{RefID: 1, Name: "test", LocationRules: routing.LocationRules{location("100m", 3600)}})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should parse sizes", func() {
+			size, err := routing.ParseSize("10M")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(size).To(BeEquivalentTo(10 << 20))
+
+			_, err = routing.ParseSize("10mb")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Quota Enforcer", func() {
+		now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+		plans := map[string]routing.Plan{
+			routing.DefaultPlan: routing.Plan{MonthlyTraffic: "1k"},
+			"2":                 routing.Plan{MonthlyTraffic: "1k", Exhausted: routing.QuotaThrottle, ThrottleRate: "1mbit"},
+		}
+		planOf := func(refID uint) (string, error) {
+			if refID == 2 {
+				return "2", nil
+			}
+			return routing.DefaultPlan, nil
+		}
+		network := func(refID uint, from time.Time, to time.Time) (uint64, error) {
+			return 500, nil
+		}
+
+		var (
+			s         routing.LimitedService
+			throttler *mockThrottler
+			q         *routing.QuotaEnforcer
+		)
+
+		BeforeEach(func() {
+			rs, _ := routing.NewService(testutils.NewMockDB())
+			s = routing.NewLimitedService(rs, planOf, plans)
+			throttler = &mockThrottler{rates: make(map[uint]string)}
+			q = routing.NewQuotaEnforcer(s, s.Plan, network, throttler, log.NewNopLogger())
+
+			for _, refID := range []uint{1, 2} {
+				s.CreateRouterConfig(&routing.RouterConfig{RefID: refID, Name: "test"})
+				s.RecordTraffic(&routing.Traffic{RefID: refID, Name: "test", Location: "0", Period: now.Add(-time.Hour), BytesSent: 600})
+				s.RecordTraffic(&routing.Traffic{RefID: refID, Name: "test", Location: "0", Period: now.AddDate(0, -1, 0), BytesSent: 600})
+			}
+		})
+
+		It("Should report the traffic of the month", func() {
+			quota, err := q.Quota(1, now)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(quota.Period).To(Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)))
+			Expect(quota.Proxied).To(BeEquivalentTo(600))
+			Expect(quota.Network).To(BeEquivalentTo(500))
+			Expect(quota.Limit).To(BeEquivalentTo(1024))
+			Expect(quota.Used()).To(BeEquivalentTo(1100))
+			Expect(quota.Exhausted).To(BeTrue())
+		})
+
+		It("Should answer with 509 and keep it on edits", func() {
+			q.Enforce(now)
+
+			conf := &routing.RouterConfig{}
+			s.GetRouterConfig(1, "test", conf)
+			Expect(conf.OverQuota()).To(BeTrue())
+			Expect(conf.ErrorPageCodes()).To(Equal([]int{509}))
+			Expect(conf.ErrorPages.Page(509)).To(Equal(routing.DefaultQuotaPage))
+
+			err := s.EditRouterConfig(1, "test", &routing.RouterConfig{ErrorPages: &routing.ErrorPages{QuotaExceeded: "<h1>over</h1>"}})
+			Ω(err).ShouldNot(HaveOccurred())
+			s.GetRouterConfig(1, "test", conf)
+			Expect(conf.OverQuota()).To(BeTrue())
+			Expect(conf.ErrorPages.Page(509)).To(Equal("<h1>over</h1>"))
+		})
+
+		It("Should throttle users whose plan throttles them", func() {
+			q.Enforce(now)
+			Expect(throttler.rates).To(Equal(map[uint]string{2: "1mbit"}))
+
+			conf := &routing.RouterConfig{}
+			s.GetRouterConfig(2, "test", conf)
+			Expect(conf.OverQuota()).To(BeFalse())
+
+			s.SetPlans(map[string]routing.Plan{})
+			q.Enforce(now)
+			Expect(throttler.rates).To(BeEmpty())
+		})
+	})
+
 	Describe("AddServerName", func() {
 		db := testutils.NewMockDB()
 		routingService, _ := routing.NewService(db)
@@ -319,4 +599,428 @@ var _ = Describe("Routing", func() {
 			Expect(conf).To(HaveLen(1))
 		})
 	})
+
+	Describe("Cache", func() {
+		It("Should keep configurations until they are changed", func() {
+			db := testutils.NewMockDB()
+			routingService, _ := routing.NewService(db)
+			routingService = routing.NewCachedService(routingService, cache.NewLRU(10), time.Minute)
+			routingService.CreateRouterConfig(&routing.RouterConfig{
+				RefID: 1,
+				Name:  "test",
+			})
+			Ω(routingService.GetRouterConfig(1, "test", &routing.RouterConfig{})).Should(Succeed())
+
+			db.SetError(1)
+			conf := &routing.RouterConfig{}
+			Ω(routingService.GetRouterConfig(1, "test", conf)).Should(Succeed())
+			Expect(conf.Name).To(Equal("test"))
+			db.SetError(0)
+
+			Ω(routingService.ChangeListenStatement(1, "test", &routing.ListenStatement{Port: 8080})).Should(Succeed())
+			conf = &routing.RouterConfig{}
+			Ω(routingService.GetRouterConfig(1, "test", conf)).Should(Succeed())
+			Expect(conf.ListenStatement.Port).To(BeEquivalentTo(8080))
+
+			confs := []routing.RouterConfig{}
+			routingService.Configurations(&confs)
+			Expect(confs).To(HaveLen(1))
+
+			Ω(routingService.RemoveRouterConfig(1, "test")).Should(Succeed())
+			confs = []routing.RouterConfig{}
+			routingService.Configurations(&confs)
+			Expect(confs).To(BeEmpty())
+		})
+	})
+
+	Describe("Upstreams", func() {
+		db := testutils.NewMockDB()
+		routingService, _ := routing.NewService(db)
+		refID, name := uint(1), "test"
+		routingService.CreateRouterConfig(&routing.RouterConfig{
+			RefID: refID,
+			Name:  name,
+		})
+
+		It("Should set an upstream", func() {
+			err := routingService.SetUpstream(refID, name, &routing.Upstream{
+				Name:      "web",
+				Algorithm: routing.LeastConn,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.Upstreams).To(HaveLen(1))
+			Expect(conf.Upstreams[0].Algorithm).To(Equal(routing.LeastConn))
+		})
+
+		It("Should add and update upstream members", func() {
+			err := routingService.AddUpstreamMember(refID, name, "web", &routing.UpstreamMember{
+				Address: "10.0.0.2:80",
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = routingService.AddUpstreamMember(refID, name, "web", &routing.UpstreamMember{
+				Address: "10.0.0.3:80",
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = routingService.SetUpstreamMemberDown(refID, name, "web", "10.0.0.3:80", true)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.Upstreams[0].Members).To(HaveLen(2))
+			Expect(conf.Upstreams[0].Members[1].Down).To(BeTrue())
+		})
+
+		It("Should remove upstream members", func() {
+			err := routingService.RemoveUpstreamMember(refID, name, "web", "10.0.0.2:80")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.Upstreams[0].Members).To(HaveLen(1))
+		})
+
+		It("Should return an error if the upstream or member does not exist", func() {
+			err := routingService.AddUpstreamMember(refID, name, "api", &routing.UpstreamMember{
+				Address: "10.0.0.2:80",
+			})
+			Ω(err).Should(HaveOccurred())
+
+			err = routingService.RemoveUpstreamMember(refID, name, "web", "10.0.0.9:80")
+			Ω(err).Should(HaveOccurred())
+
+			err = routingService.RemoveUpstream(refID, name, "api")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should remove an upstream", func() {
+			err := routingService.RemoveUpstream(refID, name, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.Upstreams).To(HaveLen(0))
+		})
+
+		It("Should return error if ID does not exist", func() {
+			err := routingService.SetUpstream(28, "", &routing.Upstream{Name: "web"})
+			Ω(err).Should(BeEquivalentTo(testutils.ErrNotFound))
+		})
+
+		It("Should return error on db failure", func() {
+			db.SetError(2)
+			err := routingService.SetUpstream(refID, name, &routing.Upstream{Name: "web"})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Switches", func() {
+		db := testutils.NewMockDB()
+		routingService, _ := routing.NewService(db)
+		refID, name := uint(1), "test"
+		routingService.CreateRouterConfig(&routing.RouterConfig{
+			RefID: refID,
+			Name:  name,
+			Upstreams: routing.Upstreams{
+				&routing.Upstream{Name: "web-blue"},
+				&routing.Upstream{Name: "web-green"},
+			},
+		})
+
+		It("Should set a switch", func() {
+			err := routingService.SetSwitch(refID, name, &routing.Switch{
+				Name:   "web",
+				Blue:   "web-blue",
+				Green:  "web-green",
+				Active: routing.Blue,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.Switches).To(HaveLen(1))
+			Expect(conf.ProxyTarget(&routing.ProxyOptions{Upstream: "web"})).To(Equal("1_test_web-blue"))
+		})
+
+		It("Should switch to the other replica set and split requests", func() {
+			err := routingService.SetSwitch(refID, name, &routing.Switch{
+				Name:   "web",
+				Blue:   "web-blue",
+				Green:  "web-green",
+				Active: routing.Green,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.Switches).To(HaveLen(1))
+			Expect(conf.ProxyTarget(&routing.ProxyOptions{Upstream: "web"})).To(Equal("1_test_web-green"))
+
+			err = routingService.SetSwitch(refID, name, &routing.Switch{
+				Name:   "web",
+				Blue:   "web-blue",
+				Green:  "web-green",
+				Active: routing.Green,
+				Canary: 10,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.Switches[0].Split()).To(BeTrue())
+			Expect(conf.ProxyTarget(&routing.ProxyOptions{Upstream: "web"})).To(Equal("$" + conf.SwitchVariable("web")))
+		})
+
+		It("Should return an error if a switch is invalid", func() {
+			err := routingService.SetSwitch(refID, name, &routing.Switch{
+				Name:   "web",
+				Blue:   "web-blue",
+				Green:  "api",
+				Active: routing.Blue,
+			})
+			Ω(err).Should(HaveOccurred())
+
+			err = routingService.SetSwitch(refID, name, &routing.Switch{
+				Name:   "web",
+				Blue:   "web-blue",
+				Green:  "web-green",
+				Active: "red",
+			})
+			Ω(err).Should(HaveOccurred())
+
+			err = routingService.SetSwitch(refID, name, &routing.Switch{
+				Name:   "web",
+				Blue:   "web-blue",
+				Green:  "web-green",
+				Active: routing.Blue,
+				Canary: 101,
+			})
+			Ω(err).Should(HaveOccurred())
+
+			err = routingService.SetSwitch(refID, name, &routing.Switch{
+				Name:   "web-blue",
+				Blue:   "web-blue",
+				Green:  "web-green",
+				Active: routing.Blue,
+			})
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should not remove an upstream used by a switch", func() {
+			err := routingService.RemoveUpstream(refID, name, "web-blue")
+			Ω(err).Should(HaveOccurred())
+
+			err = routingService.SetUpstream(refID, name, &routing.Upstream{Name: "web"})
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should remove a switch", func() {
+			err := routingService.RemoveSwitch(refID, name, "api")
+			Ω(err).Should(HaveOccurred())
+
+			err = routingService.RemoveSwitch(refID, name, "web")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should return error if ID does not exist", func() {
+			err := routingService.RemoveSwitch(28, "", "web")
+			Ω(err).Should(BeEquivalentTo(testutils.ErrNotFound))
+		})
+	})
+
+	Describe("HealthCheck", func() {
+		It("Should mark unreachable members as down and reachable ones as up", func() {
+			routingService, _ := routing.NewService(testutils.NewMockDB())
+			refID, name := uint(1), "test"
+			routingService.CreateRouterConfig(&routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				Upstreams: routing.Upstreams{
+					&routing.Upstream{
+						Name: "web",
+						Members: []*routing.UpstreamMember{
+							&routing.UpstreamMember{Address: "10.0.0.2:80", Down: true},
+							&routing.UpstreamMember{Address: "10.0.0.3:80"},
+						},
+					},
+				},
+			})
+
+			dial := func(network, address string, timeout time.Duration) (net.Conn, error) {
+				if address == "10.0.0.3:80" {
+					return nil, errors.New("connection refused")
+				}
+				c, _ := net.Pipe()
+				return c, nil
+			}
+
+			routing.NewHealthCheck(routingService, dial, time.Second, log.NewNopLogger()).Check()
+
+			conf := &routing.RouterConfig{}
+			routingService.GetRouterConfig(refID, name, conf)
+			Expect(conf.Upstreams[0].Members[0].Down).To(BeFalse())
+			Expect(conf.Upstreams[0].Members[1].Down).To(BeTrue())
+		})
+	})
+
+	Describe("Traffic", func() {
+		It("Should parse analytics access log entries", func() {
+			e, err := routing.ParseAccessEntry("1500000000.250 404 512 0.020 1")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(e.Time).To(BeTemporally("==", time.Unix(1500000000, 250000000)))
+			Expect(e.Status).To(BeEquivalentTo(404))
+			Expect(e.BytesSent).To(BeEquivalentTo(512))
+			Expect(e.Latency).To(BeEquivalentTo(0.02))
+			Expect(e.Location).To(BeEquivalentTo(1))
+
+			e, err = routing.ParseAccessEntry("1500000000.250 200 512 0.020 -")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(e.Location).To(BeEquivalentTo(-1))
+		})
+
+		It("Should return an error if an entry is malformed", func() {
+			_, err := routing.ParseAccessEntry("GET / 200")
+			Ω(err).Should(BeEquivalentTo(routing.ErrAccessEntry))
+		})
+
+		It("Should aggregate access logs per location and minute", func() {
+			dir, err := ioutil.TempDir("", "analytics")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			routingService, _ := routing.NewService(testutils.NewMockDB())
+			conf := &routing.RouterConfig{
+				RefID: 1,
+				Name:  "test",
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{Location: "/"},
+					&routing.LocationRule{Location: "/api"},
+				},
+			}
+			routingService.CreateRouterConfig(conf)
+
+			period := time.Unix(1500000000, 0).Truncate(time.Minute)
+			c := routing.NewCollector(routingService, dir, log.NewNopLogger())
+			rates, cancel := c.Subscribe(1, "test")
+			defer cancel()
+			c.Collect(period)
+			_, ok := c.Rate(1, "test")
+			Expect(ok).To(BeFalse())
+
+			lines := ""
+			for i, l := range []string{"200 100 0.010 0", "200 100 0.020 0", "503 50 1.000 0", "301 10 0.001 1", "404 10 0.001 -"} {
+				lines += fmt.Sprintf("%d.000 %s\n", period.Unix()+int64(i), l)
+			}
+			err = ioutil.WriteFile(filepath.Join(dir, conf.AnalyticsLogName()), []byte(lines+"1500000000.000 200"), 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			c.Collect(period.Add(90 * time.Second))
+			Expect(<-rates).To(ContainElement(routing.TrafficRate{Location: "/", Rate: 3.0 / 90}))
+			rate, ok := c.Rate(1, "test")
+			Expect(ok).To(BeTrue())
+			Expect(rate).To(Equal(5.0 / 90))
+
+			traffic := []routing.Traffic{}
+			err = routingService.Traffic(1, "test", period, period.Add(time.Minute), &traffic)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(traffic).To(HaveLen(3))
+
+			for _, t := range traffic {
+				if t.Location != "/" {
+					continue
+				}
+				Expect(t.Requests).To(BeEquivalentTo(3))
+				Expect(t.Status2xx).To(BeEquivalentTo(2))
+				Expect(t.Status5xx).To(BeEquivalentTo(1))
+				Expect(t.BytesSent).To(BeEquivalentTo(250))
+				Expect(t.LatencyP50).To(BeEquivalentTo(0.02))
+				Expect(t.LatencyP99).To(BeEquivalentTo(1))
+			}
+		})
+
+		It("Should flush the traffic of the current period", func() {
+			dir, err := ioutil.TempDir("", "analytics")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			routingService, _ := routing.NewService(testutils.NewMockDB())
+			conf := &routing.RouterConfig{
+				RefID: 1,
+				Name:  "test",
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{Location: "/"},
+				},
+			}
+			routingService.CreateRouterConfig(conf)
+
+			now := time.Now()
+			c := routing.NewCollector(routingService, dir, log.NewNopLogger())
+			c.Collect(now)
+			err = ioutil.WriteFile(filepath.Join(dir, conf.AnalyticsLogName()), []byte(fmt.Sprintf("%d.000 200 100 0.010 0\n", now.Unix())), 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			c.Collect(now)
+			traffic := []routing.Traffic{}
+			err = routingService.Traffic(1, "test", now.Truncate(time.Minute), now.Add(time.Minute), &traffic)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(traffic).To(BeEmpty())
+
+			c.Flush()
+			err = routingService.Traffic(1, "test", now.Truncate(time.Minute), now.Add(time.Minute), &traffic)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(traffic).To(HaveLen(1))
+			Expect(traffic[0].Requests).To(BeEquivalentTo(1))
+		})
+
+		It("Should return an error if the period ends before it starts", func() {
+			routingService, _ := routing.NewService(testutils.NewMockDB())
+			now := time.Now()
+			err := routingService.Traffic(1, "test", now, now.Add(-time.Minute), &[]routing.Traffic{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("LocationRulesFromKMI", func() {
+		It("Should convert kmi routes to location rules", func() {
+			lr, err := routing.LocationRulesFromKMI(abstraction.JSON{
+				"/ws": map[string]interface{}{
+					"Websocket":   true,
+					"ReadTimeout": 3600,
+				},
+				"/": map[string]interface{}{
+					"ClientMaxBodySize": "10m",
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(lr).To(HaveLen(2))
+			Expect(lr[0].Location).To(BeEquivalentTo("/"))
+			Expect(lr[0].Proxy.ClientMaxBodySize).To(BeEquivalentTo("10m"))
+			Expect(lr[1].Proxy.Websocket).To(BeTrue())
+			Expect(lr[1].Proxy.ReadTimeout).To(BeEquivalentTo(3600))
+		})
+
+		It("Should return an error if a route is malformatted", func() {
+			_, err := routing.LocationRulesFromKMI(abstraction.JSON{
+				"/": "websocket",
+			})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
 })
+
+type mockThrottler struct {
+	rates map[uint]string
+}
+
+func (t *mockThrottler) Throttle(refID uint, rate string) error {
+	t.rates[refID] = rate
+	return nil
+}
+
+func (t *mockThrottler) Unthrottle(refID uint) error {
+	delete(t.rates, refID)
+	return nil
+}
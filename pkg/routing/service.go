@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 )
@@ -39,6 +40,49 @@ type Service interface {
 
 	// Configuration returns all Configurations
 	Configurations(r *[]RouterConfig)
+
+	// SetUpstream adds an upstream to a configuration or replaces the one with the same name
+	SetUpstream(refID uint, name string, u *Upstream) error
+
+	// RemoveUpstream removes an upstream by its name from a configuration
+	RemoveUpstream(refID uint, name string, upstream string) error
+
+	// AddUpstreamMember adds a member to an upstream of a configuration
+	AddUpstreamMember(refID uint, name string, upstream string, m *UpstreamMember) error
+
+	// RemoveUpstreamMember removes a member by its address from an upstream of a configuration
+	RemoveUpstreamMember(refID uint, name string, upstream string, address string) error
+
+	// SetUpstreamMemberDown marks a member of an upstream as down or up again
+	SetUpstreamMemberDown(refID uint, name string, upstream string, address string, down bool) error
+
+	// SetSwitch adds a blue/green switch to a configuration or replaces the one with the same name,
+	// replacing it cuts the traffic over instantly
+	SetSwitch(refID uint, name string, sw *Switch) error
+
+	// RemoveSwitch removes a switch by its name from a configuration
+	RemoveSwitch(refID uint, name string, sw string) error
+
+	// SetHTTPSPolicy sets the redirect of plain http requests and the HSTS header of a configuration, nil removes both
+	SetHTTPSPolicy(refID uint, name string, p *HTTPSPolicy) error
+
+	// SetErrorPage sets the html page a configuration serves with a status code of ErrorPageCodes, an empty page removes it
+	SetErrorPage(refID uint, name string, code int, page string) error
+
+	// SetMaintenance puts the instance of a configuration into maintenance, requests are answered with 503 until it is turned off
+	SetMaintenance(refID uint, name string, maintenance bool) error
+
+	// SetOverQuota answers the requests of a configuration with 509 while the traffic quota of its user is exhausted
+	SetOverQuota(refID uint, name string, over bool) error
+
+	// SetProxyLimits sets the client max body size and timeouts of the location lid of a configuration
+	SetProxyLimits(refID uint, name string, lid int, l *ProxyLimits) error
+
+	// RecordTraffic stores the traffic of a location during a minute, it is merged with already stored traffic of that minute
+	RecordTraffic(t *Traffic) error
+
+	// Traffic returns the stored traffic of a configuration between from and to
+	Traffic(refID uint, name string, from time.Time, to time.Time, t *[]Traffic) error
 }
 
 type dbAdapter interface {
@@ -60,7 +104,7 @@ type service struct {
 }
 
 func (s service) InitializeDatabases() error {
-	return s.db.AutoMigrate(&RouterConfig{})
+	return s.db.AutoMigrate(&RouterConfig{}, &Traffic{})
 }
 
 func (s *service) CreateRouterConfig(r *RouterConfig) error {
@@ -77,7 +121,12 @@ func (s *service) createRouterConfig(r *RouterConfig) error {
 		return fmt.Errorf("config with name %s for user %d already exists", r.Name, r.RefID)
 	}
 
-	err := s.db.Create(r)
+	err := r.LocationRules.HashCredentials()
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Create(r)
 	if err != nil {
 		return err
 	}
@@ -97,8 +146,26 @@ func (s *service) editRouterConfig(refID uint, name string, r *RouterConfig) err
 		return errors.New("can not change reference id")
 	}
 
+	err := r.LocationRules.HashCredentials()
+	if err != nil {
+		return err
+	}
+
+	if r.ErrorPages != nil {
+		// the quota is only lifted by the QuotaEnforcer
+		stored := RouterConfig{}
+		err = s.getRouterConfig(refID, name, &stored)
+		if err != nil {
+			return err
+		}
+
+		pages := *r.ErrorPages
+		pages.OverQuota = stored.OverQuota()
+		r.ErrorPages = &pages
+	}
+
 	s.db.Begin()
-	err := s.db.Where("RefID = ? AND Name = ?", refID, name)
+	err = s.db.Where("RefID = ? AND Name = ?", refID, name)
 	if err != nil {
 		s.db.Rollback()
 		return err
@@ -164,6 +231,13 @@ func (s *service) AddLocationRule(refID uint, name string, lr *LocationRule) err
 }
 
 func (s *service) addLocationRule(refID uint, name string, lr *LocationRule) error {
+	if lr != nil {
+		err := lr.Access.HashCredentials()
+		if err != nil {
+			return err
+		}
+	}
+
 	s.db.Begin()
 
 	err := s.db.AppendToArray(&RouterConfig{
@@ -281,6 +355,461 @@ func (s *service) configurations(r *[]RouterConfig) {
 	s.db.Find(r)
 }
 
+func (s *service) changeUpstreams(refID uint, name string, fn func(u *Upstreams) error) error {
+	conf := RouterConfig{}
+	err := s.getRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+
+	err = fn(&conf.Upstreams)
+	if err != nil {
+		return err
+	}
+
+	s.db.Begin()
+	err = s.db.Where("RefID = ? AND Name = ?", refID, name)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Update(&RouterConfig{}, &RouterConfig{
+		Upstreams: conf.Upstreams,
+	})
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	s.db.Commit()
+	return nil
+}
+
+func (s *service) SetUpstream(refID uint, name string, u *Upstream) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setUpstream(refID, name, u)
+}
+
+func (s *service) setUpstream(refID uint, name string, u *Upstream) error {
+	if u == nil || u.Name == "" {
+		return errors.New("upstream has no name")
+	}
+
+	conf := RouterConfig{}
+	err := s.getRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+	if conf.Switches.Get(u.Name) != -1 {
+		return fmt.Errorf("upstream %s has the name of a switch", u.Name)
+	}
+
+	return s.changeUpstreams(refID, name, func(ups *Upstreams) error {
+		i := ups.Get(u.Name)
+		if i == -1 {
+			*ups = append(*ups, u)
+		} else {
+			(*ups)[i] = u
+		}
+		return nil
+	})
+}
+
+func (s *service) RemoveUpstream(refID uint, name string, upstream string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeUpstream(refID, name, upstream)
+}
+
+func (s *service) removeUpstream(refID uint, name string, upstream string) error {
+	conf := RouterConfig{}
+	err := s.getRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+	if sw := conf.Switches.Uses(upstream); sw != "" {
+		return fmt.Errorf("upstream %s is used by switch %s", upstream, sw)
+	}
+
+	return s.changeUpstreams(refID, name, func(ups *Upstreams) error {
+		i := ups.Get(upstream)
+		if i == -1 {
+			return fmt.Errorf("upstream %s does not exist", upstream)
+		}
+		*ups = append((*ups)[:i], (*ups)[i+1:]...)
+		return nil
+	})
+}
+
+func (s *service) AddUpstreamMember(refID uint, name string, upstream string, m *UpstreamMember) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.addUpstreamMember(refID, name, upstream, m)
+}
+
+func (s *service) addUpstreamMember(refID uint, name string, upstream string, m *UpstreamMember) error {
+	if m == nil || m.Address == "" {
+		return errors.New("upstream member has no address")
+	}
+
+	return s.changeUpstreams(refID, name, func(ups *Upstreams) error {
+		i := ups.Get(upstream)
+		if i == -1 {
+			return fmt.Errorf("upstream %s does not exist", upstream)
+		}
+
+		u := (*ups)[i]
+		j := u.Member(m.Address)
+		if j == -1 {
+			u.Members = append(u.Members, m)
+		} else {
+			u.Members[j] = m
+		}
+		return nil
+	})
+}
+
+func (s *service) RemoveUpstreamMember(refID uint, name string, upstream string, address string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeUpstreamMember(refID, name, upstream, address)
+}
+
+func (s *service) removeUpstreamMember(refID uint, name string, upstream string, address string) error {
+	return s.changeUpstreams(refID, name, func(ups *Upstreams) error {
+		i := ups.Get(upstream)
+		if i == -1 {
+			return fmt.Errorf("upstream %s does not exist", upstream)
+		}
+
+		u := (*ups)[i]
+		j := u.Member(address)
+		if j == -1 {
+			return fmt.Errorf("upstream %s has no member %s", upstream, address)
+		}
+		u.Members = append(u.Members[:j], u.Members[j+1:]...)
+		return nil
+	})
+}
+
+func (s *service) SetUpstreamMemberDown(refID uint, name string, upstream string, address string, down bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setUpstreamMemberDown(refID, name, upstream, address, down)
+}
+
+func (s *service) setUpstreamMemberDown(refID uint, name string, upstream string, address string, down bool) error {
+	return s.changeUpstreams(refID, name, func(ups *Upstreams) error {
+		i := ups.Get(upstream)
+		if i == -1 {
+			return fmt.Errorf("upstream %s does not exist", upstream)
+		}
+
+		u := (*ups)[i]
+		j := u.Member(address)
+		if j == -1 {
+			return fmt.Errorf("upstream %s has no member %s", upstream, address)
+		}
+		u.Members[j].Down = down
+		return nil
+	})
+}
+
+func (s *service) SetHTTPSPolicy(refID uint, name string, p *HTTPSPolicy) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setHTTPSPolicy(refID, name, p)
+}
+
+func (s *service) setHTTPSPolicy(refID uint, name string, p *HTTPSPolicy) error {
+	// an empty policy is stored as null, a nil one would be skipped by the update
+	if p == nil {
+		p = &HTTPSPolicy{}
+	}
+
+	err := s.db.Where("RefID = ? AND Name = ?", refID, name)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(&RouterConfig{}, &RouterConfig{
+		HTTPS: p,
+	})
+}
+
+func (s *service) changeErrorPages(refID uint, name string, fn func(e *ErrorPages) error) error {
+	conf := RouterConfig{}
+	err := s.getRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+
+	pages := ErrorPages{}
+	if conf.ErrorPages != nil {
+		pages = *conf.ErrorPages
+	}
+
+	err = fn(&pages)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Where("RefID = ? AND Name = ?", refID, name)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(&RouterConfig{}, &RouterConfig{
+		ErrorPages: &pages,
+	})
+}
+
+func (s *service) SetSwitch(refID uint, name string, sw *Switch) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setSwitch(refID, name, sw)
+}
+
+func (s *service) setSwitch(refID uint, name string, sw *Switch) error {
+	if sw == nil || sw.Name == "" {
+		return errors.New("switch has no name")
+	}
+
+	conf := RouterConfig{}
+	err := s.getRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+
+	err = sw.Validate(conf.Upstreams)
+	if err != nil {
+		return err
+	}
+
+	switches := append(Switches{}, conf.Switches...)
+	i := switches.Get(sw.Name)
+	if i == -1 {
+		switches = append(switches, sw)
+	} else {
+		switches[i] = sw
+	}
+
+	err = s.db.Where("RefID = ? AND Name = ?", refID, name)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(&RouterConfig{}, &RouterConfig{
+		Switches: switches,
+	})
+}
+
+func (s *service) RemoveSwitch(refID uint, name string, sw string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeSwitch(refID, name, sw)
+}
+
+func (s *service) removeSwitch(refID uint, name string, sw string) error {
+	conf := RouterConfig{}
+	err := s.getRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+
+	i := conf.Switches.Get(sw)
+	if i == -1 {
+		return fmt.Errorf("switch %s does not exist", sw)
+	}
+	switches := append(append(Switches{}, conf.Switches[:i]...), conf.Switches[i+1:]...)
+
+	err = s.db.Where("RefID = ? AND Name = ?", refID, name)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(&RouterConfig{}, &RouterConfig{
+		Switches: switches,
+	})
+}
+
+func (s *service) SetProxyLimits(refID uint, name string, lid int, l *ProxyLimits) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setProxyLimits(refID, name, lid, l)
+}
+
+func (s *service) setProxyLimits(refID uint, name string, lid int, l *ProxyLimits) error {
+	if l == nil {
+		l = &ProxyLimits{}
+	}
+
+	conf := RouterConfig{}
+	err := s.getRouterConfig(refID, name, &conf)
+	if err != nil {
+		return err
+	}
+
+	if lid < 0 || lid >= len(conf.LocationRules) || conf.LocationRules[lid] == nil {
+		return fmt.Errorf("location %d does not exist", lid)
+	}
+
+	lr := *conf.LocationRules[lid]
+	proxy := ProxyOptions{}
+	if lr.Proxy != nil {
+		proxy = *lr.Proxy
+	}
+	proxy.ClientMaxBodySize = l.ClientMaxBodySize
+	proxy.ReadTimeout = l.ReadTimeout
+	proxy.SendTimeout = l.SendTimeout
+	lr.Proxy = &proxy
+
+	rules := append(LocationRules{}, conf.LocationRules...)
+	rules[lid] = &lr
+
+	err = s.db.Where("RefID = ? AND Name = ?", refID, name)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(&RouterConfig{}, &RouterConfig{
+		LocationRules: rules,
+	})
+}
+
+func (s *service) SetErrorPage(refID uint, name string, code int, page string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setErrorPage(refID, name, code, page)
+}
+
+func (s *service) setErrorPage(refID uint, name string, code int, page string) error {
+	if len(page) > MaxErrorPageSize {
+		return fmt.Errorf("error page exceeds %d bytes", MaxErrorPageSize)
+	}
+
+	return s.changeErrorPages(refID, name, func(e *ErrorPages) error {
+		switch code {
+		case 502:
+			e.BadGateway = page
+		case 503:
+			e.Unavailable = page
+		case 509:
+			e.QuotaExceeded = page
+		default:
+			return fmt.Errorf("no error page can be set for status %d", code)
+		}
+		return nil
+	})
+}
+
+func (s *service) SetMaintenance(refID uint, name string, maintenance bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setMaintenance(refID, name, maintenance)
+}
+
+func (s *service) setMaintenance(refID uint, name string, maintenance bool) error {
+	return s.changeErrorPages(refID, name, func(e *ErrorPages) error {
+		e.Maintenance = maintenance
+		return nil
+	})
+}
+
+func (s *service) SetOverQuota(refID uint, name string, over bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.setOverQuota(refID, name, over)
+}
+
+func (s *service) setOverQuota(refID uint, name string, over bool) error {
+	return s.changeErrorPages(refID, name, func(e *ErrorPages) error {
+		e.OverQuota = over
+		return nil
+	})
+}
+
+func (s *service) RecordTraffic(t *Traffic) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.recordTraffic(t)
+}
+
+func (s *service) recordTraffic(t *Traffic) error {
+	stored := Traffic{}
+	err := s.db.First(&stored, "ref_id = ? AND name = ? AND location = ? AND period = ?", t.RefID, t.Name, t.Location, t.Period)
+	if err != nil {
+		if s.db.IsNotFound(err) {
+			return s.db.Create(t)
+		}
+		return err
+	}
+
+	// percentiles can not be merged exactly, they are weighted by the number of requests
+	weight := func(a, b float64) float64 {
+		return (a*float64(stored.Requests) + b*float64(t.Requests)) / float64(stored.Requests+t.Requests)
+	}
+
+	merged := &Traffic{
+		Requests:   stored.Requests + t.Requests,
+		Status1xx:  stored.Status1xx + t.Status1xx,
+		Status2xx:  stored.Status2xx + t.Status2xx,
+		Status3xx:  stored.Status3xx + t.Status3xx,
+		Status4xx:  stored.Status4xx + t.Status4xx,
+		Status5xx:  stored.Status5xx + t.Status5xx,
+		BytesSent:  stored.BytesSent + t.BytesSent,
+		LatencyP50: weight(stored.LatencyP50, t.LatencyP50),
+		LatencyP90: weight(stored.LatencyP90, t.LatencyP90),
+		LatencyP99: weight(stored.LatencyP99, t.LatencyP99),
+	}
+
+	s.db.Begin()
+	err = s.db.Where("ref_id = ? AND name = ? AND location = ? AND period = ?", t.RefID, t.Name, t.Location, t.Period)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Update(&Traffic{}, merged)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+
+	return nil
+}
+
+func (s *service) Traffic(refID uint, name string, from time.Time, to time.Time, t *[]Traffic) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.traffic(refID, name, from, to, t)
+}
+
+func (s *service) traffic(refID uint, name string, from time.Time, to time.Time, t *[]Traffic) error {
+	if to.Before(from) {
+		return errors.New("end of the period is before its start")
+	}
+
+	return s.db.Find(t, "ref_id = ? AND name = ? AND period >= ? AND period < ?", refID, name, from, to)
+}
+
 // NewService creates a UserService with necessary dependencies.
 func NewService(db dbAdapter) (Service, error) {
 	s := &service{
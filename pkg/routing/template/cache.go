@@ -105,6 +105,7 @@ func (c *cache) changeConf(r *routing.RouterConfig, edit bool) *routing.RouterCo
 			}
 
 			conf.SSLSettings.PreferServerCiphers = ssl.PreferServerCiphers
+			conf.SSLSettings.ACME = ssl.ACME
 		}
 
 		if len(r.LocationRules) != 0 {
@@ -115,6 +116,22 @@ func (c *cache) changeConf(r *routing.RouterConfig, edit bool) *routing.RouterCo
 			conf.RootPath = r.RootPath
 		}
 
+		if r.Upstreams != nil {
+			conf.Upstreams = r.Upstreams
+		}
+
+		if r.HTTPS != nil {
+			conf.HTTPS = r.HTTPS
+		}
+
+		if r.ErrorPages != nil {
+			conf.ErrorPages = r.ErrorPages
+		}
+
+		if r.Switches != nil {
+			conf.Switches = r.Switches
+		}
+
 	} else {
 		c.m[r.RefID][r.Name] = r
 	}
@@ -1,6 +1,19 @@
 package template_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing/template"
@@ -10,6 +23,32 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// writeCertificate writes a self-signed certificate for domains valid between notBefore and notAfter and its key to dir
+func writeCertificate(dir string, domains []string, notBefore, notAfter time.Time) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: domains[0]},
+		DNSNames:     domains,
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	b, err := x509.MarshalECPrivateKey(key)
+	Ω(err).ShouldNot(HaveOccurred())
+
+	cert, certKey := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	err = ioutil.WriteFile(cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	Ω(err).ShouldNot(HaveOccurred())
+	err = ioutil.WriteFile(certKey, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: b}), 0600)
+	Ω(err).ShouldNot(HaveOccurred())
+	return cert, certKey
+}
+
 var _ = Describe("Check", func() {
 	Describe("Listen Statement", func() {
 		It("Should validate a Listen Statement", func() {
@@ -96,23 +135,159 @@ var _ = Describe("Check", func() {
 		})
 	})
 
-	XDescribe("LocationRule", func() {
+	Describe("LocationRule", func() {
 		It("Should validate a location rule", func() {
-			_ = template.NewCheck(template.Nginx)
+			c := template.NewCheck(template.Nginx)
+			err := c.LocationRule(&routing.LocationRule{
+				Location: "/",
+				Proxy: &routing.ProxyOptions{
+					Upstream:          "127.0.0.1:8080",
+					Websocket:         true,
+					ClientMaxBodySize: "10m",
+					ReadTimeout:       3600,
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
 		})
 
-		It("Should return an error if ", func() {
-			_ = template.NewCheck(template.Nginx)
+		It("Should return an error if websocket and grpc are both enabled", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.LocationRule(&routing.LocationRule{
+				Location: "/",
+				Proxy: &routing.ProxyOptions{
+					Websocket: true,
+					GRPC:      true,
+				},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrProxyProtocol))
+		})
+
+		It("Should return an error if the client max body size is malformatted", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.LocationRule(&routing.LocationRule{
+				Location: "/",
+				Proxy: &routing.ProxyOptions{
+					ClientMaxBodySize: "10 megabytes",
+				},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrBodySize))
+		})
+	})
+
+	Describe("Access", func() {
+		It("Should validate access options", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Access(&routing.AccessOptions{
+				Realm: "Admin area",
+				Credentials: []*routing.Credential{
+					&routing.Credential{Username: "admin", Password: "secret"},
+				},
+				Allow: []string{"10.0.0.1", "192.168.0.0/16", "::1"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should return an error if the realm contains quotes", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Access(&routing.AccessOptions{Realm: `a"b`})
+			Ω(err).Should(BeEquivalentTo(template.ErrRealm))
+		})
+
+		It("Should return an error if the realm ends with a backslash", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Access(&routing.AccessOptions{Realm: `admin\`})
+			Ω(err).Should(BeEquivalentTo(template.ErrRealm))
+		})
+
+		It("Should return an error if a credential is invalid", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Access(&routing.AccessOptions{
+				Credentials: []*routing.Credential{
+					&routing.Credential{Username: "ad:min", Password: "secret"},
+				},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrCredential))
+
+			err = c.Access(&routing.AccessOptions{
+				Credentials: []*routing.Credential{
+					&routing.Credential{Username: "admin"},
+				},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrCredential))
+
+			err = c.Access(&routing.AccessOptions{
+				Credentials: []*routing.Credential{
+					&routing.Credential{Username: "admin", Hash: "$6$salt$hash\nroot:$6$salt$hash"},
+				},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrCredential))
+		})
+
+		It("Should return an error if an allowed address is invalid", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Access(&routing.AccessOptions{Allow: []string{"10.0.0.1; deny all"}})
+			Ω(err).Should(BeEquivalentTo(template.ErrAllow))
 		})
 	})
 
-	XDescribe("LocationRules", func() {
+	Describe("LocationRules", func() {
 		It("Should validate LocationRules", func() {
-			_ = template.NewCheck(template.Nginx)
+			c := template.NewCheck(template.Nginx)
+			err := c.LocationRules(&routing.LocationRules{
+				&routing.LocationRule{Location: "/"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
 		})
 
-		It("Should return an error if ", func() {
-			_ = template.NewCheck(template.Nginx)
+		It("Should return an error if a rule is invalid", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.LocationRules(&routing.LocationRules{
+				&routing.LocationRule{Location: "/"},
+				&routing.LocationRule{
+					Location: "/api",
+					Proxy: &routing.ProxyOptions{
+						ClientMaxBodySize: "-1",
+					},
+				},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrBodySize))
+		})
+	})
+
+	Describe("Upstream", func() {
+		It("Should validate an upstream", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Upstream(&routing.Upstream{
+				Name:      "web",
+				Algorithm: routing.IPHash,
+				Members: []*routing.UpstreamMember{
+					&routing.UpstreamMember{Address: "10.0.0.2:80"},
+				},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should return an error if the name is invalid", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Upstream(&routing.Upstream{Name: "web app"})
+			Ω(err).Should(BeEquivalentTo(template.ErrUpstreamName))
+		})
+
+		It("Should return an error if the algorithm is not supported", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Upstream(&routing.Upstream{Name: "web", Algorithm: "fastest"})
+			Ω(err).Should(BeEquivalentTo(template.ErrAlgorithm))
+		})
+
+		It("Should return an error if a member has no address", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.Upstream(&routing.Upstream{
+				Name: "web",
+				Members: []*routing.UpstreamMember{
+					&routing.UpstreamMember{},
+				},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrNoMemberAddress))
 		})
 	})
 
@@ -212,6 +387,34 @@ var _ = Describe("Check", func() {
 		})
 	})
 
+	It("Should return an error if grpc is proxied without http2", func() {
+		c := template.NewCheck(template.Nginx)
+		conf := &routing.RouterConfig{
+			RefID: 1,
+			Name:  "name",
+			ListenStatement: &routing.ListenStatement{
+				IPAddress: abstraction.Inet("127.0.0.1"),
+				Port:      1337,
+				Keyword:   "ssl",
+			},
+			ServerName: pq.StringArray{"domain.com"},
+			LocationRules: routing.LocationRules{
+				&routing.LocationRule{
+					Location: "/",
+					Proxy: &routing.ProxyOptions{
+						GRPC: true,
+					},
+				},
+			},
+		}
+		err := c.Config(conf, false)
+		Ω(err).Should(BeEquivalentTo(template.ErrGRPCWithoutHTTP2))
+
+		conf.ListenStatement.HTTP2 = true
+		err = c.Config(conf, false)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
 	XIt("Should return an error if the SSLSettings aren't properly set", func() {
 		c := template.NewCheck(template.Nginx)
 		err := c.Config(&routing.RouterConfig{
@@ -239,4 +442,174 @@ var _ = Describe("Check", func() {
 		}, false)
 		Ω(err).Should(BeEquivalentTo(template.ErrNoName))
 	})
+
+	Describe("HTTPS Policy", func() {
+		var (
+			dir  string
+			conf *routing.RouterConfig
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "kroo-check")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf = &routing.RouterConfig{
+				RefID:           1,
+				Name:            "name",
+				ListenStatement: &routing.ListenStatement{Port: 8443, Keyword: "ssl"},
+				ServerName:      pq.StringArray{"domain.com"},
+			}
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("Should validate a redirect to an ssl listen statement", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.HTTPSPolicy(&routing.HTTPSPolicy{
+				Redirect: &routing.HTTPSRedirect{Port: 8080},
+			}, conf)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should return an error if the listen statement does not use ssl", func() {
+			c := template.NewCheck(template.Nginx)
+			conf.ListenStatement.Keyword = ""
+			err := c.HTTPSPolicy(&routing.HTTPSPolicy{
+				Redirect: &routing.HTTPSRedirect{Port: 8080},
+			}, conf)
+			Ω(err).Should(BeEquivalentTo(template.ErrRedirectWithoutSSL))
+		})
+
+		It("Should return an error if the redirect uses the port of the listen statement", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.HTTPSPolicy(&routing.HTTPSPolicy{
+				Redirect: &routing.HTTPSRedirect{Port: 8443},
+			}, conf)
+			Ω(err).Should(BeEquivalentTo(template.ErrRedirectPort))
+		})
+
+		It("Should return an error if a preloaded policy is too short", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.HTTPSPolicy(&routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: 600, IncludeSubdomains: true, Preload: true},
+			}, conf)
+			Ω(err).Should(BeEquivalentTo(template.ErrHSTSPreload))
+		})
+
+		It("Should refuse HSTS without a certificate", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.HTTPSPolicy(&routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: 600},
+			}, conf)
+			Ω(err).Should(BeEquivalentTo(template.ErrNoValidCertificate))
+
+			conf.SSLSettings.Certificate = filepath.Join(dir, "missing.pem")
+			conf.SSLSettings.CertificateKey = filepath.Join(dir, "missing.key")
+			err = c.HTTPSPolicy(&routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: 600},
+			}, conf)
+			Ω(err).Should(BeEquivalentTo(template.ErrNoValidCertificate))
+		})
+
+		It("Should refuse HSTS if the certificate expired or does not cover a server name", func() {
+			c := template.NewCheck(template.Nginx)
+			conf.SSLSettings.Certificate, conf.SSLSettings.CertificateKey = writeCertificate(dir, []string{"domain.com"}, time.Now().Add(-2*time.Hour), time.Now().Add(-time.Hour))
+			err := c.HTTPSPolicy(&routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: 600},
+			}, conf)
+			Ω(err).Should(BeEquivalentTo(template.ErrNoValidCertificate))
+
+			conf.SSLSettings.Certificate, conf.SSLSettings.CertificateKey = writeCertificate(dir, []string{"other.com"}, time.Now(), time.Now().Add(time.Hour))
+			err = c.HTTPSPolicy(&routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: 600},
+			}, conf)
+			Ω(err).Should(BeEquivalentTo(template.ErrNoValidCertificate))
+		})
+
+		It("Should allow HSTS with a valid certificate or to clear it", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.HTTPSPolicy(&routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: 0},
+			}, conf)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf.SSLSettings.Certificate, conf.SSLSettings.CertificateKey = writeCertificate(dir, []string{"domain.com"}, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+			err = c.HTTPSPolicy(&routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: routing.HSTSMinPreloadAge, IncludeSubdomains: true, Preload: true},
+			}, conf)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Error Pages", func() {
+		It("Should validate error pages", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.ErrorPages(&routing.ErrorPages{BadGateway: "<h1>down</h1>", Maintenance: true})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should return an error if a page is too large", func() {
+			c := template.NewCheck(template.Nginx)
+			err := c.ErrorPages(&routing.ErrorPages{Unavailable: strings.Repeat("a", routing.MaxErrorPageSize+1)})
+			Ω(err).Should(BeEquivalentTo(template.ErrErrorPageSize))
+		})
+
+		It("Should return an error if the router can not serve pages", func() {
+			c := template.NewCheck(template.Traefik)
+			err := c.ErrorPages(&routing.ErrorPages{Maintenance: true})
+			Ω(err).Should(BeEquivalentTo(template.ErrNotSupported))
+
+			err = c.ErrorPages(&routing.ErrorPages{})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
+	Describe("Traefik", func() {
+		It("Should validate a proxied location", func() {
+			c := template.NewCheck(template.Traefik)
+			err := c.LocationRule(&routing.LocationRule{
+				Location: "^~ /api",
+				Access:   &routing.AccessOptions{Allow: []string{"10.0.0.0/8"}},
+				Proxy:    &routing.ProxyOptions{Upstream: "web"},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should return an error if a location is not proxied", func() {
+			c := template.NewCheck(template.Traefik)
+			err := c.LocationRule(&routing.LocationRule{
+				Location: "/",
+				Rules:    map[string][]string{"index": []string{"index.html"}},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrNotSupported))
+		})
+
+		It("Should return an error if a location is a regular expression", func() {
+			c := template.NewCheck(template.Traefik)
+			err := c.LocationRule(&routing.LocationRule{
+				Location: `~ \.php$`,
+				Proxy:    &routing.ProxyOptions{Upstream: "web"},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrNotSupported))
+		})
+
+		It("Should return an error if a location uses basic auth", func() {
+			c := template.NewCheck(template.Traefik)
+			err := c.Access(&routing.AccessOptions{
+				Credentials: []*routing.Credential{
+					&routing.Credential{Username: "admin", Password: "secret"},
+				},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrNotSupported))
+		})
+
+		It("Should return an error if an upstream does not use round robin", func() {
+			c := template.NewCheck(template.Traefik)
+			err := c.Upstream(&routing.Upstream{Name: "web", Algorithm: routing.LeastConn})
+			Ω(err).Should(BeEquivalentTo(template.ErrAlgorithm))
+		})
+	})
 })
@@ -0,0 +1,201 @@
+package template
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
+
+// Provider generates the configuration files of a router
+type Provider interface {
+	// Extension returns the file extension of the configuration files
+	Extension() string
+
+	// Render writes the configuration file of c to wr
+	Render(wr io.Writer, c *routing.RouterConfig) error
+}
+
+// Snippets are the named parts of a router's template which can be overridden per installation
+var Snippets = map[Router][]string{
+	Nginx: {"logging", "security_headers", "server", "location"},
+}
+
+// sample is rendered to validate templates, it should make every part of a template execute
+var sample = &routing.RouterConfig{
+	RefID:           1,
+	Name:            "sample",
+	ListenStatement: &routing.ListenStatement{IPAddress: "127.0.0.1", Port: 443, Keyword: "ssl", HTTP2: true},
+	ServerName:      []string{"sample.kontainer.ooo"},
+	AccessLog:       routing.Log{Path: "/var/log/nginx/access.log", Keyword: "combined"},
+	ErrorLog:        routing.Log{Path: "/var/log/nginx/error.log", Keyword: "warn"},
+	RootPath:        "/var/www",
+	SSLSettings:     routing.SSLSettings{Protocols: []string{"TLSv1.2"}, Certificate: "sample.crt", CertificateKey: "sample.key", ACME: true},
+	LocationRules: routing.LocationRules{
+		&routing.LocationRule{
+			Location: "/",
+			Rules:    map[string][]string{"index": []string{"index.html"}},
+			Access: &routing.AccessOptions{
+				Credentials: []*routing.Credential{&routing.Credential{Username: "sample", Hash: "$6$sample"}},
+				Allow:       []string{"127.0.0.1"},
+			},
+			Proxy: &routing.ProxyOptions{Upstream: "web", Websocket: true, ReadTimeout: 60},
+		},
+		&routing.LocationRule{
+			Location: "/canary",
+			Proxy:    &routing.ProxyOptions{Upstream: "live"},
+		},
+	},
+	Upstreams: routing.Upstreams{
+		&routing.Upstream{
+			Name:    "web",
+			Members: []*routing.UpstreamMember{&routing.UpstreamMember{Address: "127.0.0.1:8080"}},
+		},
+		&routing.Upstream{
+			Name:    "next",
+			Members: []*routing.UpstreamMember{&routing.UpstreamMember{Address: "127.0.0.1:8081"}},
+		},
+	},
+	Switches: routing.Switches{
+		&routing.Switch{Name: "live", Blue: "web", Green: "next", Active: routing.Blue, Canary: 10},
+	},
+	HTTPS: &routing.HTTPSPolicy{
+		Redirect: &routing.HTTPSRedirect{Port: 8080},
+		HSTS:     &routing.HSTS{MaxAge: routing.HSTSMinPreloadAge, IncludeSubdomains: true, Preload: true},
+	},
+	ErrorPages: &routing.ErrorPages{BadGateway: "<h1>502</h1>", Unavailable: "<h1>503</h1>", Maintenance: true},
+}
+
+func isSnippet(r Router, name string) bool {
+	for _, s := range Snippets[r] {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// override replaces snippets of t with the ones defined in the *.tmpl files of dir.
+// Everything in these files has to be inside a define action of a known snippet.
+func override(t *template.Template, r Router, funcs template.FuncMap, dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	} else if !info.IsDir() {
+		return fmt.Errorf("template override path %s is no dir", dir)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		name := filepath.Base(f)
+		o, err := template.New(name).Funcs(funcs).ParseFiles(f)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range o.Templates() {
+			if d.Tree == nil {
+				continue
+			}
+
+			if d.Name() == name {
+				if strings.TrimSpace(d.Tree.Root.String()) != "" {
+					return fmt.Errorf("%s: snippets have to be defined using define actions", f)
+				}
+				continue
+			}
+
+			if !isSnippet(r, d.Name()) {
+				return fmt.Errorf("%s: unknown snippet %s", f, d.Name())
+			}
+
+			_, err = t.AddParseTree(d.Name(), d.Tree)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type templateProvider struct {
+	template  *template.Template
+	name      string
+	extension string
+}
+
+func (p *templateProvider) Extension() string {
+	return p.extension
+}
+
+func (p *templateProvider) Render(wr io.Writer, c *routing.RouterConfig) error {
+	return p.template.ExecuteTemplate(wr, p.name, c)
+}
+
+// newTemplateProvider parses the template file name and applies the overrides
+func newTemplateProvider(r Router, name string, extension string, funcs template.FuncMap, overrides []string) (*templateProvider, error) {
+	t, err := template.New("routing").Funcs(funcs).ParseFiles(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range overrides {
+		err = override(t, r, funcs, dir)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &templateProvider{
+		template:  t,
+		name:      name,
+		extension: extension,
+	}, nil
+}
+
+// NewProvider returns the Provider of a router, the result is validated by rendering a sample configuration.
+// Overrides are only supported by routers whose configuration is template-driven.
+func NewProvider(r Router, funcs template.FuncMap, overrides ...string) (Provider, error) {
+	var (
+		p   Provider
+		err error
+	)
+
+	switch r {
+	case Nginx:
+		p, err = newTemplateProvider(r, "nginx.conf", "conf", funcs, overrides)
+	case Traefik:
+		if len(overrides) != 0 {
+			return nil, fmt.Errorf("Router %s does not support template overrides", router[r])
+		}
+		p = &traefikProvider{}
+	default:
+		return nil, fmt.Errorf("Router with id %d does not exist", r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.Render(ioutil.Discard, sample)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Validate checks whether the configuration of a router combined with the snippets of the override directories renders
+func Validate(r Router, overrides ...string) error {
+	w := &writer{}
+	_, err := NewProvider(r, w.funcs(), overrides...)
+	return err
+}
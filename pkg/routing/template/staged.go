@@ -0,0 +1,328 @@
+package template
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
+
+// releasesDir is the directory inside of the path of a staged writer the generations of the configurations
+// are rendered to, the file names inside of it never match the configuration files a router includes
+const releasesDir = ".releases"
+
+// keepGenerations is the number of generations kept per configuration, the current and the previous one
+const keepGenerations = 2
+
+var (
+	// ErrValidation is returned, if the router rejected a staged configuration
+	ErrValidation = errors.New("configuration rejected by router")
+
+	// ErrUnhealthy is returned, if no member of an upstream of a staged configuration is reachable
+	ErrUnhealthy = errors.New("upstream not reachable")
+)
+
+// stagedProvider renders like its Provider, the files of the generations get an extension no router includes
+type stagedProvider struct {
+	Provider
+}
+
+func (stagedProvider) Extension() string {
+	return "staged"
+}
+
+// StageOptions configure how a staged writer applies configurations
+type StageOptions struct {
+	// Validate checks the configuration files the router includes, e.g. nginx -t
+	Validate func() error
+	// Reload makes the router load the changed configuration files
+	Reload func() error
+	// Dial probes the upstream members of an applied configuration, no members are probed if it is nil
+	Dial routing.DialFunc
+	// Timeout is the timeout of a probe
+	Timeout time.Duration
+}
+
+// DefaultStageOptions returns the StageOptions of a router, nginx validates its configuration with
+// nginx -t and is reloaded, traefik watches its files itself. Upstream members are probed with net.DialTimeout.
+func DefaultStageOptions(r Router) StageOptions {
+	o := StageOptions{
+		Dial:    net.DialTimeout,
+		Timeout: 2 * time.Second,
+	}
+
+	if r == Nginx {
+		o.Validate = func() error {
+			out, err := exec.Command("nginx", "-t").CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("%s", out)
+			}
+			return nil
+		}
+		o.Reload = func() error {
+			return Reload(r)
+		}
+	}
+	return o
+}
+
+// stagedWriter applies every configuration transactionally. A configuration file is a symlink to the file of
+// its current generation. A change is rendered to a new generation and the symlink is swapped atomically. If the
+// router rejects the new generation or its upstreams are not reachable after the reload, the symlink is swapped
+// back to the previous generation. The router only reads its files on reloads, so it never sees a rejected one.
+type stagedWriter struct {
+	mtx       *sync.Mutex
+	router    Router
+	path      string
+	overrides []string
+	live      *writer
+	opts      StageOptions
+}
+
+func (s *stagedWriter) CreatePath(refID uint, name string) string {
+	return s.live.CreatePath(refID, name)
+}
+
+// releases returns the directory of the generations of a configuration
+func (s *stagedWriter) releases(refID uint, name string) string {
+	return fmt.Sprintf("%s/%s/%d_%s", s.path, releasesDir, refID, name)
+}
+
+// current returns the file the configuration file links to, a regular file written before
+// configurations were staged becomes the first generation
+func (s *stagedWriter) current(refID uint, name string) (string, error) {
+	link := s.CreatePath(refID, name)
+	info, err := os.Lstat(link)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		return os.Readlink(link)
+	}
+
+	dir := filepath.Join(s.releases(refID, name), "0")
+	err = os.MkdirAll(dir, os.ModeDir|0755)
+	if err != nil {
+		return "", err
+	}
+
+	target := filepath.Join(dir, fmt.Sprintf("%d_%s.%s", refID, name, stagedProvider{}.Extension()))
+	err = os.Rename(link, target)
+	if err != nil {
+		return "", err
+	}
+
+	return target, s.swap(link, target)
+}
+
+// swap points link to target atomically, an empty target removes link
+func (s *stagedWriter) swap(link, target string) error {
+	if target == "" {
+		return os.Remove(link)
+	}
+
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	err := os.Symlink(target, tmp)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
+
+// healthy checks whether a member of every upstream of c, which is not marked as down, is reachable
+func (s *stagedWriter) healthy(c *routing.RouterConfig) error {
+	if s.opts.Dial == nil {
+		return nil
+	}
+
+	for _, u := range c.Upstreams {
+		if u == nil {
+			continue
+		}
+
+		probed, reachable := false, false
+		for _, m := range u.Members {
+			if m == nil || m.Down {
+				continue
+			}
+
+			probed = true
+			conn, err := s.opts.Dial("tcp", m.Address, s.opts.Timeout)
+			if err == nil {
+				conn.Close()
+				reachable = true
+				break
+			}
+		}
+
+		if probed && !reachable {
+			return ErrUnhealthy
+		}
+	}
+	return nil
+}
+
+func (s *stagedWriter) reload() error {
+	if s.opts.Reload == nil {
+		return nil
+	}
+	return s.opts.Reload()
+}
+
+// rollback points link back to previous, reloads the router if it loaded the rejected generation and removes it
+func (s *stagedWriter) rollback(link, previous, generation string, reloaded bool, cause error) error {
+	err := s.swap(link, previous)
+	if err != nil {
+		return fmt.Errorf("%v, rollback failed: %v", cause, err)
+	}
+
+	if reloaded {
+		err = s.reload()
+		if err != nil {
+			return fmt.Errorf("%v, reload after rollback failed: %v", cause, err)
+		}
+	}
+
+	os.RemoveAll(generation)
+	return cause
+}
+
+// prune removes all but the last keepGenerations generations of a configuration
+func (s *stagedWriter) prune(refID uint, name string) error {
+	dirs, err := ioutil.ReadDir(s.releases(refID, name))
+	if err != nil {
+		return err
+	}
+
+	// generations are named by the nanoseconds they were created at, the adopted generation is 0
+	names := make([]string, 0, len(dirs))
+	for _, d := range dirs {
+		names = append(names, d.Name())
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return len(names[i]) < len(names[j]) || (len(names[i]) == len(names[j]) && names[i] < names[j])
+	})
+
+	for len(names) > keepGenerations {
+		err = os.RemoveAll(filepath.Join(s.releases(refID, name), names[0]))
+		if err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func (s *stagedWriter) CreateFile(c *routing.RouterConfig) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	link := s.CreatePath(c.RefID, c.Name)
+	previous, err := s.current(c.RefID, c.Name)
+	if err != nil {
+		return err
+	}
+
+	generation := filepath.Join(s.releases(c.RefID, c.Name), fmt.Sprint(time.Now().UnixNano()))
+	err = os.MkdirAll(generation, os.ModeDir|0755)
+	if err != nil {
+		return err
+	}
+
+	w, err := newWriter(s.router, generation, s.path, s.overrides...)
+	if err != nil {
+		os.RemoveAll(generation)
+		return err
+	}
+	w.provider = stagedProvider{w.provider}
+
+	err = w.CreateFile(c)
+	if err != nil {
+		os.RemoveAll(generation)
+		return err
+	}
+
+	err = s.swap(link, w.CreatePath(c.RefID, c.Name))
+	if err != nil {
+		os.RemoveAll(generation)
+		return err
+	}
+
+	if s.opts.Validate != nil {
+		err = s.opts.Validate()
+		if err != nil {
+			return s.rollback(link, previous, generation, false, fmt.Errorf("%v: %v", ErrValidation, err))
+		}
+	}
+
+	err = s.reload()
+	if err != nil {
+		return s.rollback(link, previous, generation, true, err)
+	}
+
+	err = s.healthy(c)
+	if err != nil {
+		return s.rollback(link, previous, generation, true, err)
+	}
+
+	return s.prune(c.RefID, c.Name)
+}
+
+func (s *stagedWriter) RemoveFile(refID uint, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// files written before configurations were staged
+	err := s.live.RemoveFile(refID, name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	err = os.RemoveAll(s.releases(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return s.reload()
+}
+
+// NewStagedWriter returns a Writer which applies configurations transactionally, see NewWriter for overrides
+func NewStagedWriter(r Router, p string, o StageOptions, overrides ...string) (Writer, error) {
+	// the configuration files link to the absolute paths of their generations
+	p, err := filepath.Abs(p)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := newWriter(r, p, p, overrides...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Join(p, releasesDir), os.ModeDir|0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stagedWriter{
+		mtx:       &sync.Mutex{},
+		router:    r,
+		path:      p,
+		overrides: overrides,
+		live:      w,
+		opts:      o,
+	}, nil
+}
@@ -0,0 +1,164 @@
+package template_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing/template"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Staged Writer", func() {
+	var (
+		reloads   int
+		invalid   bool
+		reachable bool
+		opts      template.StageOptions
+	)
+
+	refID, name := uint(1), "test"
+	config := func(root string) *routing.RouterConfig {
+		return &routing.RouterConfig{
+			RefID:    refID,
+			Name:     name,
+			RootPath: root,
+			Upstreams: routing.Upstreams{
+				&routing.Upstream{
+					Name:    "web",
+					Members: []*routing.UpstreamMember{&routing.UpstreamMember{Address: "10.0.0.2:80"}},
+				},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		err := os.Mkdir(testPath, os.ModeDir|os.ModePerm)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		reloads, invalid, reachable = 0, false, true
+		opts = template.StageOptions{
+			Validate: func() error {
+				if invalid {
+					return errors.New("nginx: [emerg] unknown directive")
+				}
+				return nil
+			},
+			Reload: func() error {
+				reloads++
+				return nil
+			},
+			Dial: func(network, address string, timeout time.Duration) (net.Conn, error) {
+				if !reachable {
+					return nil, errors.New("connection refused")
+				}
+				client, server := net.Pipe()
+				server.Close()
+				return client, nil
+			},
+		}
+	})
+
+	AfterEach(func() {
+		err := os.RemoveAll(testPath)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("Should link the configuration to its current generation", func() {
+		w, err := template.NewStagedWriter(template.Nginx, testPath, opts)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		err = w.CreateFile(config("/var/www/v1"))
+		Ω(err).ShouldNot(HaveOccurred())
+		err = w.CreateFile(config("/var/www/v2"))
+		Ω(err).ShouldNot(HaveOccurred())
+		err = w.CreateFile(config("/var/www/v3"))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(reloads).Should(Equal(3))
+
+		info, err := os.Lstat(w.CreatePath(refID, name))
+		Ω(err).ShouldNot(HaveOccurred())
+		Ω(info.Mode() & os.ModeSymlink).ShouldNot(BeZero())
+
+		b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+		Ω(string(b)).Should(ContainSubstring("root /var/www/v3;"))
+
+		generations, _ := filepath.Glob(testPath + "/.releases/1_test/*")
+		Ω(generations).Should(HaveLen(2))
+	})
+
+	It("Should roll back a configuration the router rejects", func() {
+		w, _ := template.NewStagedWriter(template.Nginx, testPath, opts)
+		w.CreateFile(config("/var/www/v1"))
+
+		invalid = true
+		err := w.CreateFile(config("/var/www/v2"))
+		Ω(err).Should(HaveOccurred())
+		Ω(err.Error()).Should(ContainSubstring(template.ErrValidation.Error()))
+		Ω(reloads).Should(Equal(1))
+
+		b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+		Ω(string(b)).Should(ContainSubstring("root /var/www/v1;"))
+	})
+
+	It("Should remove a new configuration the router rejects", func() {
+		w, _ := template.NewStagedWriter(template.Nginx, testPath, opts)
+
+		invalid = true
+		err := w.CreateFile(config("/var/www/v1"))
+		Ω(err).Should(HaveOccurred())
+
+		_, err = os.Lstat(w.CreatePath(refID, name))
+		Ω(os.IsNotExist(err)).Should(BeTrue())
+	})
+
+	It("Should roll back a configuration whose upstreams are not reachable", func() {
+		w, _ := template.NewStagedWriter(template.Nginx, testPath, opts)
+		w.CreateFile(config("/var/www/v1"))
+
+		reachable = false
+		err := w.CreateFile(config("/var/www/v2"))
+		Ω(err).Should(BeEquivalentTo(template.ErrUnhealthy))
+		Ω(reloads).Should(Equal(3))
+
+		b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+		Ω(string(b)).Should(ContainSubstring("root /var/www/v1;"))
+
+		c := config("/var/www/v3")
+		c.Upstreams[0].Members[0].Down = true
+		err = w.CreateFile(c)
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	It("Should adopt a configuration written before it was staged", func() {
+		plain, _ := template.NewWriter(template.Nginx, testPath)
+		plain.CreateFile(config("/var/www/v1"))
+
+		w, _ := template.NewStagedWriter(template.Nginx, testPath, opts)
+		invalid = true
+		err := w.CreateFile(config("/var/www/v2"))
+		Ω(err).Should(HaveOccurred())
+
+		b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+		Ω(string(b)).Should(ContainSubstring("root /var/www/v1;"))
+	})
+
+	It("Should remove a configuration and its generations", func() {
+		w, _ := template.NewStagedWriter(template.Nginx, testPath, opts)
+		w.CreateFile(config("/var/www/v1"))
+
+		err := w.RemoveFile(refID, name)
+		Ω(err).ShouldNot(HaveOccurred())
+
+		_, err = os.Lstat(w.CreatePath(refID, name))
+		Ω(os.IsNotExist(err)).Should(BeTrue())
+		_, err = os.Stat(testPath + "/.releases/1_test")
+		Ω(os.IsNotExist(err)).Should(BeTrue())
+	})
+})
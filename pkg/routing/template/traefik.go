@@ -0,0 +1,365 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
+
+var (
+	// TraefikEntryPoint is the entry point plain http routes are attached to
+	TraefikEntryPoint = "web"
+
+	// TraefikSecureEntryPoint is the entry point routes using tls are attached to
+	TraefikSecureEntryPoint = "websecure"
+)
+
+type traefikConfig struct {
+	HTTP traefikHTTP `json:"http"`
+	TLS  *traefikTLS `json:"tls,omitempty"`
+}
+
+type traefikHTTP struct {
+	Routers           map[string]*traefikRouter           `json:"routers,omitempty"`
+	Services          map[string]*traefikService          `json:"services,omitempty"`
+	Middlewares       map[string]*traefikMiddleware       `json:"middlewares,omitempty"`
+	ServersTransports map[string]*traefikServersTransport `json:"serversTransports,omitempty"`
+}
+
+type traefikRouter struct {
+	Rule        string    `json:"rule"`
+	Service     string    `json:"service"`
+	EntryPoints []string  `json:"entryPoints,omitempty"`
+	Middlewares []string  `json:"middlewares,omitempty"`
+	TLS         *struct{} `json:"tls,omitempty"`
+}
+
+type traefikService struct {
+	LoadBalancer *traefikLoadBalancer `json:"loadBalancer,omitempty"`
+	Weighted     *traefikWeighted     `json:"weighted,omitempty"`
+}
+
+type traefikWeighted struct {
+	Services []traefikWeightedService `json:"services"`
+}
+
+type traefikWeightedService struct {
+	Name   string `json:"name"`
+	Weight uint   `json:"weight"`
+}
+
+type traefikLoadBalancer struct {
+	Servers          []traefikServer `json:"servers"`
+	ServersTransport string          `json:"serversTransport,omitempty"`
+}
+
+type traefikServer struct {
+	URL string `json:"url"`
+}
+
+type traefikMiddleware struct {
+	IPWhiteList    *traefikIPWhiteList    `json:"ipWhiteList,omitempty"`
+	Buffering      *traefikBuffering      `json:"buffering,omitempty"`
+	RedirectScheme *traefikRedirectScheme `json:"redirectScheme,omitempty"`
+	Headers        *traefikHeaders        `json:"headers,omitempty"`
+}
+
+type traefikIPWhiteList struct {
+	SourceRange []string `json:"sourceRange"`
+}
+
+type traefikBuffering struct {
+	MaxRequestBodyBytes int64 `json:"maxRequestBodyBytes"`
+}
+
+type traefikRedirectScheme struct {
+	Scheme    string `json:"scheme"`
+	Port      string `json:"port,omitempty"`
+	Permanent bool   `json:"permanent"`
+}
+
+type traefikHeaders struct {
+	STSSeconds           uint `json:"stsSeconds"`
+	STSIncludeSubdomains bool `json:"stsIncludeSubdomains,omitempty"`
+	STSPreload           bool `json:"stsPreload,omitempty"`
+}
+
+type traefikServersTransport struct {
+	ForwardingTimeouts traefikForwardingTimeouts `json:"forwardingTimeouts"`
+}
+
+type traefikForwardingTimeouts struct {
+	DialTimeout           string `json:"dialTimeout,omitempty"`
+	ResponseHeaderTimeout string `json:"responseHeaderTimeout,omitempty"`
+}
+
+type traefikTLS struct {
+	Certificates []traefikCertificate `json:"certificates"`
+}
+
+type traefikCertificate struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+// traefikProvider generates dynamic configuration files for traefik's file provider. They are
+// written as JSON, which traefik reads as YAML, so the files use the yml extension.
+type traefikProvider struct{}
+
+func (p *traefikProvider) Extension() string {
+	return "yml"
+}
+
+// size converts an nginx size like 10m to bytes
+func size(s string) int64 {
+	if s == "" {
+		return 0
+	}
+
+	unit := int64(1)
+	switch s[len(s)-1] {
+	case 'k', 'K':
+		unit = 1 << 10
+	case 'm', 'M':
+		unit = 1 << 20
+	case 'g', 'G':
+		unit = 1 << 30
+	}
+	if unit != 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n * unit
+}
+
+// rule converts the server names of c and a location to a traefik rule, exact locations
+// (= /path) match their path, every other location matches its path as prefix
+func (p *traefikProvider) rule(c *routing.RouterConfig, location string) string {
+	parts := []string{}
+
+	if len(c.ServerName) != 0 {
+		hosts := make([]string, len(c.ServerName))
+		for i, h := range c.ServerName {
+			hosts[i] = fmt.Sprintf("`%s`", h)
+		}
+		parts = append(parts, fmt.Sprintf("Host(%s)", strings.Join(hosts, ", ")))
+	}
+
+	fields := strings.Fields(location)
+	switch {
+	case len(fields) == 2 && fields[0] == "=":
+		parts = append(parts, fmt.Sprintf("Path(`%s`)", fields[1]))
+	case len(fields) == 2 && fields[0] == "^~":
+		parts = append(parts, fmt.Sprintf("PathPrefix(`%s`)", fields[1]))
+	case len(fields) == 1:
+		parts = append(parts, fmt.Sprintf("PathPrefix(`%s`)", fields[0]))
+	default:
+		parts = append(parts, "PathPrefix(`/`)")
+	}
+
+	return strings.Join(parts, " && ")
+}
+
+// servers returns the servers a location proxies to, members of an upstream which are down are left out
+func (p *traefikProvider) servers(c *routing.RouterConfig, proxy *routing.ProxyOptions) []traefikServer {
+	scheme := "http"
+	if proxy.GRPC {
+		scheme = "h2c"
+	}
+
+	servers := []traefikServer{}
+	i := c.Upstreams.Get(proxy.Upstream)
+	if i == -1 {
+		return append(servers, traefikServer{
+			URL: fmt.Sprintf("%s://%s", scheme, proxy.Upstream),
+		})
+	}
+
+	for _, m := range c.Upstreams[i].Members {
+		if m == nil || m.Down {
+			continue
+		}
+		servers = append(servers, traefikServer{
+			URL: fmt.Sprintf("%s://%s", scheme, m.Address),
+		})
+	}
+	return servers
+}
+
+// split returns the service of a location proxying to a switch, it balances to the upstream the switch points at
+// or splits the requests between both upstreams by weight
+func (p *traefikProvider) split(conf traefikConfig, name string, c *routing.RouterConfig, s *routing.Switch, proxy *routing.ProxyOptions, transport string) *traefikService {
+	balancer := func(upstream string) *traefikLoadBalancer {
+		options := *proxy
+		options.Upstream = upstream
+		return &traefikLoadBalancer{
+			Servers:          p.servers(c, &options),
+			ServersTransport: transport,
+		}
+	}
+
+	switch {
+	case s.Canary == 0:
+		return &traefikService{LoadBalancer: balancer(s.ActiveUpstream())}
+	case s.Canary == 100:
+		return &traefikService{LoadBalancer: balancer(s.InactiveUpstream())}
+	}
+
+	active, inactive := name+"-"+s.Active, name+"-"+routing.Blue
+	if s.Active == routing.Blue {
+		inactive = name + "-" + routing.Green
+	}
+	conf.HTTP.Services[active] = &traefikService{LoadBalancer: balancer(s.ActiveUpstream())}
+	conf.HTTP.Services[inactive] = &traefikService{LoadBalancer: balancer(s.InactiveUpstream())}
+
+	return &traefikService{
+		Weighted: &traefikWeighted{
+			Services: []traefikWeightedService{
+				traefikWeightedService{Name: active, Weight: 100 - s.Canary},
+				traefikWeightedService{Name: inactive, Weight: s.Canary},
+			},
+		},
+	}
+}
+
+func (p *traefikProvider) Render(wr io.Writer, c *routing.RouterConfig) error {
+	conf := traefikConfig{
+		HTTP: traefikHTTP{
+			Routers:           make(map[string]*traefikRouter),
+			Services:          make(map[string]*traefikService),
+			Middlewares:       make(map[string]*traefikMiddleware),
+			ServersTransports: make(map[string]*traefikServersTransport),
+		},
+	}
+
+	secure := c.SSLSettings.Certificate != "" || (c.ListenStatement != nil && c.ListenStatement.Keyword == "ssl")
+	if c.SSLSettings.Certificate != "" {
+		conf.TLS = &traefikTLS{
+			Certificates: []traefikCertificate{
+				traefikCertificate{
+					CertFile: c.SSLSettings.Certificate,
+					KeyFile:  c.SSLSettings.CertificateKey,
+				},
+			},
+		}
+	}
+
+	var hsts string
+	if secure && c.HTTPS != nil {
+		if c.HTTPS.HSTS != nil {
+			hsts = fmt.Sprintf("%d_%s-hsts", c.RefID, c.Name)
+			conf.HTTP.Middlewares[hsts] = &traefikMiddleware{
+				Headers: &traefikHeaders{
+					STSSeconds:           c.HTTPS.HSTS.MaxAge,
+					STSIncludeSubdomains: c.HTTPS.HSTS.IncludeSubdomains,
+					STSPreload:           c.HTTPS.HSTS.Preload,
+				},
+			}
+		}
+
+		// every request to the server names on the plain http entry point is redirected, the router
+		// never reaches its service
+		if c.HTTPS.Redirect != nil {
+			name := fmt.Sprintf("%d_%s_redirect", c.RefID, c.Name)
+			redirect := &traefikRedirectScheme{
+				Scheme:    "https",
+				Permanent: true,
+			}
+			if c.HTTPS.Redirect.HTTPSPort != 0 && c.HTTPS.Redirect.HTTPSPort != 443 {
+				redirect.Port = strconv.Itoa(int(c.HTTPS.Redirect.HTTPSPort))
+			}
+
+			conf.HTTP.Middlewares[name] = &traefikMiddleware{
+				RedirectScheme: redirect,
+			}
+			conf.HTTP.Routers[name] = &traefikRouter{
+				Rule:        p.rule(c, ""),
+				Service:     "noop@internal",
+				EntryPoints: []string{TraefikEntryPoint},
+				Middlewares: []string{name},
+			}
+		}
+	}
+
+	for i, l := range c.LocationRules {
+		// traefik can not serve files, only proxied locations are routed
+		if l == nil || l.Proxy == nil || l.Proxy.Upstream == "" {
+			continue
+		}
+
+		name := fmt.Sprintf("%d_%s_%d", c.RefID, c.Name, i)
+		router := &traefikRouter{
+			Rule:        p.rule(c, l.Location),
+			Service:     name,
+			EntryPoints: []string{TraefikEntryPoint},
+		}
+		if secure {
+			router.EntryPoints = []string{TraefikSecureEntryPoint}
+			router.TLS = &struct{}{}
+		}
+
+		if hsts != "" {
+			router.Middlewares = append(router.Middlewares, hsts)
+		}
+
+		balancer := &traefikLoadBalancer{
+			Servers: p.servers(c, l.Proxy),
+		}
+		service := &traefikService{
+			LoadBalancer: balancer,
+		}
+
+		if l.Access != nil && len(l.Access.Allow) != 0 {
+			conf.HTTP.Middlewares[name+"-allow"] = &traefikMiddleware{
+				IPWhiteList: &traefikIPWhiteList{
+					SourceRange: l.Access.Allow,
+				},
+			}
+			router.Middlewares = append(router.Middlewares, name+"-allow")
+		}
+
+		if l.Proxy.ClientMaxBodySize != "" {
+			conf.HTTP.Middlewares[name+"-body"] = &traefikMiddleware{
+				Buffering: &traefikBuffering{
+					MaxRequestBodyBytes: size(l.Proxy.ClientMaxBodySize),
+				},
+			}
+			router.Middlewares = append(router.Middlewares, name+"-body")
+		}
+
+		if l.Proxy.ConnectTimeout != 0 || l.Proxy.ReadTimeout != 0 {
+			timeouts := traefikForwardingTimeouts{}
+			if l.Proxy.ConnectTimeout != 0 {
+				timeouts.DialTimeout = fmt.Sprintf("%ds", l.Proxy.ConnectTimeout)
+			}
+			if l.Proxy.ReadTimeout != 0 {
+				timeouts.ResponseHeaderTimeout = fmt.Sprintf("%ds", l.Proxy.ReadTimeout)
+			}
+
+			conf.HTTP.ServersTransports[name] = &traefikServersTransport{
+				ForwardingTimeouts: timeouts,
+			}
+			balancer.ServersTransport = name
+		}
+
+		if s := c.Switches.Get(l.Proxy.Upstream); s != -1 {
+			service = p.split(conf, name, c, c.Switches[s], l.Proxy, balancer.ServersTransport)
+		}
+
+		conf.HTTP.Routers[name] = router
+		conf.HTTP.Services[name] = service
+	}
+
+	e := json.NewEncoder(wr)
+	e.SetEscapeHTML(false)
+	e.SetIndent("", "  ")
+	return e.Encode(conf)
+}
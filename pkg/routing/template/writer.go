@@ -1,13 +1,18 @@
 package template
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"text/template"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing/acme"
 )
 
 // Router is an enum of possible Routers
@@ -16,10 +21,37 @@ type Router uint
 const (
 	// Nginx router
 	Nginx Router = iota
+
+	// Traefik router, configured using its file provider
+	Traefik
 )
 
 var router = [...]string{
-	"nginx.conf",
+	"nginx",
+	"traefik",
+}
+
+// ParseRouter returns the Router with the given name, an empty name selects Nginx
+func ParseRouter(name string) (Router, error) {
+	if name == "" {
+		return Nginx, nil
+	}
+
+	for i, n := range router {
+		if n == name {
+			return Router(i), nil
+		}
+	}
+	return 0, fmt.Errorf("Router %s does not exist", name)
+}
+
+// Reload makes a router load changed configuration and certificate files,
+// traefik watches the files of its file provider itself
+func Reload(r Router) error {
+	if r == Nginx {
+		return exec.Command("nginx", "-s", "reload").Run()
+	}
+	return nil
 }
 
 // Writer is
@@ -30,25 +62,121 @@ type Writer interface {
 }
 
 type writer struct {
-	template *template.Template
+	provider Provider
 	path     string
-	name     string
+	// logs is the directory of the analytics access logs, it stays the same for staged configurations
+	logs string
 }
 
 func (w writer) CreatePath(refID uint, name string) string {
-	return fmt.Sprintf("%s/%d_%s.conf", w.path, refID, name)
+	return fmt.Sprintf("%s/%d_%s.%s", w.path, refID, name, w.provider.Extension())
+}
+
+func (w writer) analyticsLogPath(c *routing.RouterConfig) string {
+	return fmt.Sprintf("%s/%s", w.logs, c.AnalyticsLogName())
+}
+
+func (w writer) htpasswdPath(c *routing.RouterConfig, i int) string {
+	return fmt.Sprintf("%s/%d_%s_%d.htpasswd", w.path, c.RefID, c.Name, i)
+}
+
+// writeHtpasswd writes a user file for every location protected by basic auth and
+// removes the files of locations which are not protected anymore
+func (w writer) writeHtpasswd(c *routing.RouterConfig) error {
+	err := w.removeHtpasswd(c.RefID, c.Name)
+	if err != nil {
+		return err
+	}
+
+	for i, l := range c.LocationRules {
+		if l == nil || l.Access == nil || len(l.Access.Credentials) == 0 {
+			continue
+		}
+
+		var b bytes.Buffer
+		for _, cred := range l.Access.Credentials {
+			fmt.Fprintf(&b, "%s:%s\n", cred.Username, cred.Hash)
+		}
+
+		err = ioutil.WriteFile(w.htpasswdPath(c, i), b.Bytes(), 0640)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w writer) removeHtpasswd(refID uint, name string) error {
+	files, err := filepath.Glob(fmt.Sprintf("%s/%d_%s_*.htpasswd", w.path, refID, name))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		err = os.Remove(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w writer) errorPagePath(c *routing.RouterConfig, code int) string {
+	return fmt.Sprintf("%s/%d_%s_%d.html", w.path, c.RefID, c.Name, code)
+}
+
+// writeErrorPages writes the custom error pages of a configuration and
+// removes the ones which are not set anymore
+func (w writer) writeErrorPages(c *routing.RouterConfig) error {
+	err := w.removeErrorPages(c.RefID, c.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, code := range c.ErrorPageCodes() {
+		err = ioutil.WriteFile(w.errorPagePath(c, code), []byte(c.ErrorPages.Page(code)), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w writer) removeErrorPages(refID uint, name string) error {
+	files, err := filepath.Glob(fmt.Sprintf("%s/%d_%s_*.html", w.path, refID, name))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		err = os.Remove(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (w writer) CreateFile(c *routing.RouterConfig) error {
 	path := w.CreatePath(c.RefID, c.Name)
 
+	err := w.writeHtpasswd(c)
+	if err != nil {
+		return err
+	}
+
+	err = w.writeErrorPages(c)
+	if err != nil {
+		return err
+	}
+
 	f, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer f.Close()
 
-	err = w.template.ExecuteTemplate(f, w.name, c)
+	err = w.provider.Render(f, c)
 	if err != nil {
 		return err
 	}
@@ -57,20 +185,44 @@ func (w writer) CreateFile(c *routing.RouterConfig) error {
 }
 
 func (w writer) RemoveFile(refID uint, name string) error {
+	err := w.removeHtpasswd(refID, name)
+	if err != nil {
+		return err
+	}
+
+	err = w.removeErrorPages(refID, name)
+	if err != nil {
+		return err
+	}
+
 	return os.Remove(w.CreatePath(refID, name))
 }
 
-// NewWriter returns a new writer
-func NewWriter(r Router, p string) (Writer, error) {
-	t := template.New("routing").Funcs(template.FuncMap{
-		"join": strings.Join,
-	})
+func (w *writer) funcs() template.FuncMap {
+	return template.FuncMap{
+		"join":            strings.Join,
+		"acmeWebroot":     func() string { return acme.Webroot },
+		"htpasswd":        w.htpasswdPath,
+		"errorPage":       w.errorPagePath,
+		"analyticsLog":    w.analyticsLogPath,
+		"analyticsFormat": func() string { return routing.AnalyticsLogFormat },
+	}
+}
+
+// NewWriter returns a new writer, the snippets of the router's template are overridden by the ones
+// defined in the *.tmpl files of the override directories, later directories take precedence
+func NewWriter(r Router, p string, overrides ...string) (Writer, error) {
+	return newWriter(r, p, p, overrides...)
+}
 
-	if !(int(r) < len(router)) {
-		return nil, fmt.Errorf("Router with id %d does not exist", r)
+// newWriter returns a writer writing to p whose analytics access logs are written to logs
+func newWriter(r Router, p string, logs string, overrides ...string) (*writer, error) {
+	w := &writer{
+		path: p,
+		logs: logs,
 	}
-	name := router[r]
-	t, err := t.ParseFiles(name)
+
+	provider, err := NewProvider(r, w.funcs(), overrides...)
 	if err != nil {
 		return nil, err
 	}
@@ -82,9 +234,6 @@ func NewWriter(r Router, p string) (Writer, error) {
 		return nil, errors.New("path is no dir")
 	}
 
-	return &writer{
-		template: t,
-		path:     p,
-		name:     name,
-	}, nil
+	w.provider = provider
+	return w, nil
 }
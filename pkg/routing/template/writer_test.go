@@ -3,8 +3,10 @@ package template_test
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing/acme"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing/template"
 
 	. "github.com/onsi/ginkgo"
@@ -37,6 +39,89 @@ var _ = Describe("Writer", func() {
 		})
 	})
 
+	Describe("Parse Router", func() {
+		It("Should return the router with the given name", func() {
+			r, err := template.ParseRouter("traefik")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(r).Should(BeEquivalentTo(template.Traefik))
+		})
+
+		It("Should default to nginx", func() {
+			r, err := template.ParseRouter("")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(r).Should(BeEquivalentTo(template.Nginx))
+		})
+
+		It("Should return an error if the router does not exist", func() {
+			_, err := template.ParseRouter("apache")
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Overrides", func() {
+		var overridePath string
+
+		BeforeEach(func() {
+			var err error
+			overridePath, err = ioutil.TempDir("", "kroo-overrides")
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(overridePath)
+		})
+
+		writeOverride := func(content string) {
+			err := ioutil.WriteFile(filepath.Join(overridePath, "override.tmpl"), []byte(content), 0644)
+			Ω(err).ShouldNot(HaveOccurred())
+		}
+
+		It("Should replace snippets of the template", func() {
+			writeOverride(`{{define "security_headers"}}add_header X-Frame-Options "DENY";{{end}}`)
+
+			dir, _ := ioutil.TempDir("", "kroo-routing")
+			defer os.RemoveAll(dir)
+
+			w, err := template.NewWriter(template.Nginx, dir, overridePath)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = w.CreateFile(&routing.RouterConfig{RefID: 1, Name: "test"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(1, "test"))
+			Ω(string(b)).Should(ContainSubstring(`add_header X-Frame-Options "DENY";`))
+			Ω(string(b)).ShouldNot(ContainSubstring("SAMEORIGIN"))
+		})
+
+		It("Should return an error if an unknown snippet is defined", func() {
+			writeOverride(`{{define "headers"}}{{end}}`)
+			err := template.Validate(template.Nginx, overridePath)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should return an error if there is text outside of a define action", func() {
+			writeOverride(`add_header X-Frame-Options "DENY";`)
+			err := template.Validate(template.Nginx, overridePath)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should return an error if a snippet does not render", func() {
+			writeOverride(`{{define "location"}}{{.Missing}}{{end}}`)
+			err := template.Validate(template.Nginx, overridePath)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should return an error if the override path does not exist", func() {
+			err := template.Validate(template.Nginx, "-")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should validate the default template", func() {
+			err := template.Validate(template.Nginx)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Describe("Create File", func() {
 		BeforeEach(func() {
 			err := os.Mkdir(testPath, os.ModeDir|os.ModePerm)
@@ -64,6 +149,262 @@ var _ = Describe("Writer", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 			Ω(b).ShouldNot(BeEmpty())
 		})
+
+		It("Should serve acme challenges if certificates are managed", func() {
+			w, _ := template.NewWriter(template.Nginx, testPath)
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				SSLSettings: routing.SSLSettings{
+					ACME: true,
+				},
+			}
+
+			err := w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("/.well-known/acme-challenge/"))
+			Ω(string(b)).Should(ContainSubstring(acme.Webroot))
+		})
+
+		It("Should redirect plain http and send the HSTS header", func() {
+			w, _ := template.NewWriter(template.Nginx, testPath)
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID:           refID,
+				Name:            name,
+				ListenStatement: &routing.ListenStatement{IPAddress: "127.0.0.1", Port: 8443, Keyword: "ssl"},
+				ServerName:      []string{"example.com"},
+				SSLSettings:     routing.SSLSettings{Certificate: "cert.pem", CertificateKey: "key.pem"},
+				HTTPS: &routing.HTTPSPolicy{
+					Redirect: &routing.HTTPSRedirect{Port: 8080, HTTPSPort: 8443},
+					HSTS:     &routing.HSTS{MaxAge: 600, IncludeSubdomains: true},
+				},
+			}
+
+			err := w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("add_header Strict-Transport-Security \"max-age=600; includeSubDomains\";"))
+			Ω(string(b)).Should(ContainSubstring("listen 127.0.0.1:8080;"))
+			Ω(string(b)).Should(ContainSubstring("return 301 https://$host:8443$request_uri;"))
+		})
+
+		It("Should write an analytics access log", func() {
+			w, _ := template.NewWriter(template.Nginx, testPath)
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{Location: "/"},
+				},
+			}
+
+			err := w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("log_format analytics_1_test '" + routing.AnalyticsLogFormat + "';"))
+			Ω(string(b)).Should(ContainSubstring("access_log " + testPath + "/1_test.analytics.log analytics_1_test;"))
+			Ω(string(b)).Should(ContainSubstring("set $kroo_location 0;"))
+		})
+
+		It("Should write proxy options of a location", func() {
+			w, _ := template.NewWriter(template.Nginx, testPath)
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{
+						Location: "/",
+						Proxy: &routing.ProxyOptions{
+							Upstream:          "127.0.0.1:8080",
+							Websocket:         true,
+							ClientMaxBodySize: "10m",
+							ReadTimeout:       3600,
+						},
+					},
+				},
+			}
+
+			err := w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("proxy_pass http://127.0.0.1:8080;"))
+			Ω(string(b)).Should(ContainSubstring("proxy_set_header Upgrade $http_upgrade;"))
+			Ω(string(b)).Should(ContainSubstring("client_max_body_size 10m;"))
+			Ω(string(b)).Should(ContainSubstring("proxy_read_timeout 3600s;"))
+		})
+
+		It("Should write upstreams and proxy to them", func() {
+			w, _ := template.NewWriter(template.Nginx, testPath)
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				Upstreams: routing.Upstreams{
+					&routing.Upstream{
+						Name:      "web",
+						Algorithm: routing.LeastConn,
+						Members: []*routing.UpstreamMember{
+							&routing.UpstreamMember{Address: "10.0.0.2:80", Weight: 2},
+							&routing.UpstreamMember{Address: "10.0.0.3:80", Down: true},
+						},
+					},
+				},
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{
+						Location: "/",
+						Proxy: &routing.ProxyOptions{
+							Upstream: "web",
+						},
+					},
+				},
+			}
+
+			err := w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("upstream 1_test_web {"))
+			Ω(string(b)).Should(ContainSubstring("least_conn;"))
+			Ω(string(b)).Should(ContainSubstring("server 10.0.0.2:80 weight=2;"))
+			Ω(string(b)).Should(ContainSubstring("server 10.0.0.3:80 down;"))
+			Ω(string(b)).Should(ContainSubstring("proxy_pass http://1_test_web;"))
+		})
+
+		It("Should split requests between blue and green upstreams", func() {
+			w, _ := template.NewWriter(template.Nginx, testPath)
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				Upstreams: routing.Upstreams{
+					&routing.Upstream{Name: "web-blue"},
+					&routing.Upstream{Name: "web-green"},
+				},
+				Switches: routing.Switches{
+					&routing.Switch{Name: "web", Blue: "web-blue", Green: "web-green", Active: routing.Blue, Canary: 20},
+				},
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{
+						Location: "/",
+						Proxy: &routing.ProxyOptions{
+							Upstream: "web",
+						},
+					},
+				},
+			}
+
+			err := w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("split_clients \"${remote_addr}${http_user_agent}\" $kroo_1_test_web {"))
+			Ω(string(b)).Should(ContainSubstring("20% 1_test_web-green;"))
+			Ω(string(b)).Should(ContainSubstring("* 1_test_web-blue;"))
+			Ω(string(b)).Should(ContainSubstring("proxy_pass http://$kroo_1_test_web;"))
+
+			c.Switches[0].Canary = 0
+			err = w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ = ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).ShouldNot(ContainSubstring("split_clients"))
+			Ω(string(b)).Should(ContainSubstring("proxy_pass http://1_test_web-blue;"))
+		})
+
+		It("Should protect a location with basic auth and an allowlist", func() {
+			w, _ := template.NewWriter(template.Nginx, testPath)
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{
+						Location: "/admin",
+						Access: &routing.AccessOptions{
+							Credentials: []*routing.Credential{
+								&routing.Credential{Username: "admin", Hash: "$6$salt$hash"},
+							},
+							Allow: []string{"10.0.0.0/8"},
+						},
+					},
+				},
+			}
+
+			err := w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring(`auth_basic "Restricted";`))
+			Ω(string(b)).Should(ContainSubstring("auth_basic_user_file " + testPath + "/1_test_0.htpasswd;"))
+			Ω(string(b)).Should(ContainSubstring("allow 10.0.0.0/8;"))
+			Ω(string(b)).Should(ContainSubstring("deny all;"))
+
+			b, err = ioutil.ReadFile(testPath + "/1_test_0.htpasswd")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(Equal("admin:$6$salt$hash\n"))
+
+			err = w.RemoveFile(refID, name)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = os.Stat(testPath + "/1_test_0.htpasswd")
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
+
+		It("Should serve custom error pages and answer with 503 in maintenance", func() {
+			w, _ := template.NewWriter(template.Nginx, testPath)
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				ErrorPages: &routing.ErrorPages{
+					BadGateway:  "<h1>down</h1>",
+					Maintenance: true,
+				},
+			}
+
+			err := w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("error_page 502 /.kroo-errors/502.html;"))
+			Ω(string(b)).Should(ContainSubstring("alias " + testPath + "/1_test_502.html;"))
+			Ω(string(b)).ShouldNot(ContainSubstring("error_page 503"))
+			Ω(string(b)).Should(ContainSubstring("return 503;"))
+
+			b, err = ioutil.ReadFile(testPath + "/1_test_502.html")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(Equal("<h1>down</h1>"))
+
+			c.ErrorPages = &routing.ErrorPages{Unavailable: "<h1>maintenance</h1>"}
+			err = w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ = ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).ShouldNot(ContainSubstring("return 503;"))
+			_, err = os.Stat(testPath + "/1_test_502.html")
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+
+			err = w.RemoveFile(refID, name)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = os.Stat(testPath + "/1_test_503.html")
+			Ω(os.IsNotExist(err)).Should(BeTrue())
+		})
 	})
 
 	Describe("RemoveFile", func() {
@@ -77,6 +418,141 @@ var _ = Describe("Writer", func() {
 			Ω(err).ShouldNot(HaveOccurred())
 		})
 
+		It("Should write traefik dynamic configuration", func() {
+			w, err := template.NewWriter(template.Traefik, testPath)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID:      refID,
+				Name:       name,
+				ServerName: []string{"example.com"},
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{
+						Location: "/",
+						Access:   &routing.AccessOptions{Allow: []string{"10.0.0.0/8"}},
+						Proxy:    &routing.ProxyOptions{Upstream: "web", ClientMaxBodySize: "10m", ReadTimeout: 60},
+					},
+					&routing.LocationRule{
+						Location: "= /health",
+						Proxy:    &routing.ProxyOptions{Upstream: "127.0.0.1:8080"},
+					},
+				},
+				Upstreams: routing.Upstreams{
+					&routing.Upstream{
+						Name: "web",
+						Members: []*routing.UpstreamMember{
+							&routing.UpstreamMember{Address: "10.0.0.2:80"},
+							&routing.UpstreamMember{Address: "10.0.0.3:80", Down: true},
+						},
+					},
+				},
+			}
+
+			err = w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(w.CreatePath(refID, name)).Should(HaveSuffix(".yml"))
+
+			b, err := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(b)).Should(ContainSubstring("\"rule\": \"Host(`example.com`) && PathPrefix(`/`)\""))
+			Ω(string(b)).Should(ContainSubstring("\"rule\": \"Host(`example.com`) && Path(`/health`)\""))
+			Ω(string(b)).Should(ContainSubstring("\"url\": \"http://10.0.0.2:80\""))
+			Ω(string(b)).ShouldNot(ContainSubstring("10.0.0.3:80"))
+			Ω(string(b)).Should(ContainSubstring("\"url\": \"http://127.0.0.1:8080\""))
+			Ω(string(b)).Should(ContainSubstring("\"maxRequestBodyBytes\": 10485760"))
+			Ω(string(b)).Should(ContainSubstring("\"responseHeaderTimeout\": \"60s\""))
+			Ω(string(b)).Should(ContainSubstring("\"1_test_0-allow\""))
+		})
+
+		It("Should redirect plain http and send the HSTS header with traefik", func() {
+			w, err := template.NewWriter(template.Traefik, testPath)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID:           refID,
+				Name:            name,
+				ListenStatement: &routing.ListenStatement{Port: 8443, Keyword: "ssl"},
+				ServerName:      []string{"example.com"},
+				SSLSettings:     routing.SSLSettings{Certificate: "cert.pem", CertificateKey: "key.pem"},
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{
+						Location: "/",
+						Proxy:    &routing.ProxyOptions{Upstream: "127.0.0.1:8080"},
+					},
+				},
+				HTTPS: &routing.HTTPSPolicy{
+					Redirect: &routing.HTTPSRedirect{},
+					HSTS:     &routing.HSTS{MaxAge: 600},
+				},
+			}
+
+			err = w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("\"1_test_redirect\""))
+			Ω(string(b)).Should(ContainSubstring("\"scheme\": \"https\""))
+			Ω(string(b)).Should(ContainSubstring("\"stsSeconds\": 600"))
+			Ω(string(b)).Should(ContainSubstring("\"1_test-hsts\""))
+		})
+
+		It("Should split requests between blue and green upstreams with traefik", func() {
+			w, err := template.NewWriter(template.Traefik, testPath)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			refID, name := uint(1), "test"
+			c := &routing.RouterConfig{
+				RefID:      refID,
+				Name:       name,
+				ServerName: []string{"example.com"},
+				Upstreams: routing.Upstreams{
+					&routing.Upstream{
+						Name:    "web-blue",
+						Members: []*routing.UpstreamMember{&routing.UpstreamMember{Address: "10.0.0.2:80"}},
+					},
+					&routing.Upstream{
+						Name:    "web-green",
+						Members: []*routing.UpstreamMember{&routing.UpstreamMember{Address: "10.0.0.3:80"}},
+					},
+				},
+				Switches: routing.Switches{
+					&routing.Switch{Name: "web", Blue: "web-blue", Green: "web-green", Active: routing.Blue, Canary: 20},
+				},
+				LocationRules: routing.LocationRules{
+					&routing.LocationRule{
+						Location: "/",
+						Proxy:    &routing.ProxyOptions{Upstream: "web"},
+					},
+				},
+			}
+
+			err = w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).Should(ContainSubstring("\"weighted\""))
+			Ω(string(b)).Should(ContainSubstring("\"1_test_0-blue\""))
+			Ω(string(b)).Should(ContainSubstring("\"weight\": 80"))
+			Ω(string(b)).Should(ContainSubstring("\"weight\": 20"))
+			Ω(string(b)).Should(ContainSubstring("\"url\": \"http://10.0.0.3:80\""))
+
+			c.Switches[0].Canary = 100
+			err = w.CreateFile(c)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ = ioutil.ReadFile(w.CreatePath(refID, name))
+			Ω(string(b)).ShouldNot(ContainSubstring("\"weighted\""))
+			Ω(string(b)).ShouldNot(ContainSubstring("10.0.0.2:80"))
+			Ω(string(b)).Should(ContainSubstring("\"url\": \"http://10.0.0.3:80\""))
+		})
+
+		It("Should not allow template overrides for traefik", func() {
+			_, err := template.NewWriter(template.Traefik, testPath, testPath)
+			Ω(err).Should(HaveOccurred())
+		})
+
 		It("Should remove a file", func() {
 			w, _ := template.NewWriter(template.Nginx, testPath)
 
@@ -1,6 +1,10 @@
 package template
 
-import "github.com/kontainerooo/kontainer.ooo/pkg/routing"
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+)
 
 type writingService struct {
 	s     routing.Service
@@ -30,6 +34,20 @@ func (w *writingService) CreateRouterConfig(r *routing.RouterConfig) error {
 	return nil
 }
 
+// checkHTTPS checks the https policy of a stored configuration with change applied, so a change can not
+// leave a configuration redirecting to or announcing HSTS for a server which can not serve https
+func (w *writingService) checkHTTPS(refID uint, name string, change func(r *routing.RouterConfig)) error {
+	conf, err := w.mem.GetConf(refID, name)
+	if err != nil {
+		// configurations which do not exist are reported by the underlying service
+		return nil
+	}
+
+	r := *conf
+	change(&r)
+	return w.check.HTTPSPolicy(r.HTTPS, &r)
+}
+
 func (w *writingService) EditRouterConfig(refID uint, name string, r *routing.RouterConfig) error {
 	var err error
 	err = w.check.Config(r, true)
@@ -37,12 +55,39 @@ func (w *writingService) EditRouterConfig(refID uint, name string, r *routing.Ro
 		return err
 	}
 
+	err = w.checkHTTPS(refID, name, func(c *routing.RouterConfig) {
+		if r.HTTPS != nil {
+			c.HTTPS = r.HTTPS
+		}
+		if r.ListenStatement != nil {
+			c.ListenStatement = r.ListenStatement
+		}
+		if len(r.ServerName) != 0 {
+			c.ServerName = r.ServerName
+		}
+		if r.SSLSettings.Certificate != "" {
+			c.SSLSettings.Certificate = r.SSLSettings.Certificate
+		}
+		if r.SSLSettings.CertificateKey != "" {
+			c.SSLSettings.CertificateKey = r.SSLSettings.CertificateKey
+		}
+	})
+	if err != nil {
+		return err
+	}
+
 	err = w.s.EditRouterConfig(refID, name, r)
 	if err != nil {
 		return err
 	}
 
-	err = w.w.CreateFile(w.mem.EditConf(r))
+	// r only holds the changed fields, the edited configuration is read again
+	if r.Name != "" && r.Name != name {
+		w.mem.RemoveConf(refID, name)
+		name = r.Name
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
 	if err != nil {
 		return err
 	}
@@ -112,6 +157,13 @@ func (w *writingService) ChangeListenStatement(refID uint, name string, ls *rout
 		return err
 	}
 
+	err = w.checkHTTPS(refID, name, func(c *routing.RouterConfig) {
+		c.ListenStatement = ls
+	})
+	if err != nil {
+		return err
+	}
+
 	err = w.s.ChangeListenStatement(refID, name, ls)
 	if err != nil {
 		return err
@@ -132,6 +184,13 @@ func (w *writingService) AddServerName(refID uint, name string, sn string) error
 		return err
 	}
 
+	err = w.checkHTTPS(refID, name, func(c *routing.RouterConfig) {
+		c.ServerName = append(append([]string{}, c.ServerName...), sn)
+	})
+	if err != nil {
+		return err
+	}
+
 	err = w.s.AddServerName(refID, name, sn)
 	if err != nil {
 		return err
@@ -159,13 +218,279 @@ func (w *writingService) RemoveServerName(refID uint, name string, id int) error
 	return nil
 }
 
+func (w *writingService) SetUpstream(refID uint, name string, u *routing.Upstream) error {
+	var err error
+	err = w.check.Upstream(u)
+	if err != nil {
+		return err
+	}
+
+	err = w.s.SetUpstream(refID, name, u)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) RemoveUpstream(refID uint, name string, upstream string) error {
+	var err error
+	err = w.s.RemoveUpstream(refID, name, upstream)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) AddUpstreamMember(refID uint, name string, upstream string, m *routing.UpstreamMember) error {
+	var err error
+	err = w.check.UpstreamMember(m)
+	if err != nil {
+		return err
+	}
+
+	err = w.s.AddUpstreamMember(refID, name, upstream, m)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) RemoveUpstreamMember(refID uint, name string, upstream string, address string) error {
+	var err error
+	err = w.s.RemoveUpstreamMember(refID, name, upstream, address)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) SetUpstreamMemberDown(refID uint, name string, upstream string, address string, down bool) error {
+	var err error
+	err = w.s.SetUpstreamMemberDown(refID, name, upstream, address, down)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) SetHTTPSPolicy(refID uint, name string, p *routing.HTTPSPolicy) error {
+	conf, err := w.mem.GetConf(refID, name)
+	if err != nil {
+		return err
+	}
+
+	err = w.check.HTTPSPolicy(p, conf)
+	if err != nil {
+		return err
+	}
+
+	err = w.s.SetHTTPSPolicy(refID, name, p)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) SetSwitch(refID uint, name string, sw *routing.Switch) error {
+	err := w.s.SetSwitch(refID, name, sw)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) RemoveSwitch(refID uint, name string, sw string) error {
+	err := w.s.RemoveSwitch(refID, name, sw)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) SetProxyLimits(refID uint, name string, lid int, l *routing.ProxyLimits) error {
+	if l != nil && l.ClientMaxBodySize != "" && !sizeRegex.MatchString(l.ClientMaxBodySize) {
+		return ErrBodySize
+	}
+
+	err := w.s.SetProxyLimits(refID, name, lid, l)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkErrorPages checks the error pages of a stored configuration with change applied
+func (w *writingService) checkErrorPages(refID uint, name string, change func(e *routing.ErrorPages)) error {
+	conf, err := w.mem.GetConf(refID, name)
+	if err != nil {
+		// configurations which do not exist are reported by the underlying service
+		return nil
+	}
+
+	var e routing.ErrorPages
+	if conf.ErrorPages != nil {
+		e = *conf.ErrorPages
+	}
+	change(&e)
+	return w.check.ErrorPages(&e)
+}
+
+func (w *writingService) SetErrorPage(refID uint, name string, code int, page string) error {
+	err := w.checkErrorPages(refID, name, func(e *routing.ErrorPages) {
+		switch code {
+		case 502:
+			e.BadGateway = page
+		case 503:
+			e.Unavailable = page
+		case 509:
+			e.QuotaExceeded = page
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = w.s.SetErrorPage(refID, name, code, page)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) SetMaintenance(refID uint, name string, maintenance bool) error {
+	err := w.checkErrorPages(refID, name, func(e *routing.ErrorPages) {
+		e.Maintenance = maintenance
+	})
+	if err != nil {
+		return err
+	}
+
+	err = w.s.SetMaintenance(refID, name, maintenance)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (w *writingService) SetOverQuota(refID uint, name string, over bool) error {
+	err := w.checkErrorPages(refID, name, func(e *routing.ErrorPages) {
+		e.OverQuota = over
+	})
+	if err != nil {
+		return err
+	}
+
+	err = w.s.SetOverQuota(refID, name, over)
+	if err != nil {
+		return err
+	}
+
+	err = w.w.CreateFile(w.mem.UpdateConf(refID, name))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (w *writingService) Configurations(r *[]routing.RouterConfig) {
 	w.s.Configurations(r)
 }
 
-// NewWritingService creates a writingService with necessary dependencies.
-func NewWritingService(s routing.Service, r Router, p string) (routing.Service, error) {
-	w, err := NewWriter(r, p)
+func (w *writingService) RecordTraffic(t *routing.Traffic) error {
+	return w.s.RecordTraffic(t)
+}
+
+func (w *writingService) Traffic(refID uint, name string, from time.Time, to time.Time, t *[]routing.Traffic) error {
+	return w.s.Traffic(refID, name, from, to, t)
+}
+
+// NewWritingService creates a writingService with necessary dependencies, see NewWriter for overrides.
+func NewWritingService(s routing.Service, r Router, p string, overrides ...string) (routing.Service, error) {
+	w, err := NewWriter(r, p, overrides...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &writingService{
+		s:     s,
+		w:     w,
+		r:     r,
+		mem:   NewCache(s.GetRouterConfig),
+		check: NewCheck(r),
+	}, nil
+}
+
+// NewStagedWritingService creates a writingService which applies every configuration transactionally,
+// see NewStagedWriter
+func NewStagedWritingService(s routing.Service, r Router, p string, o StageOptions, overrides ...string) (routing.Service, error) {
+	w, err := NewStagedWriter(r, p, o, overrides...)
 	if err != nil {
 		return nil, err
 	}
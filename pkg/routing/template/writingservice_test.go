@@ -1,7 +1,10 @@
 package template_test
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
+	"time"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
@@ -435,6 +438,152 @@ var _ = Describe("Writingservice", func() {
 		})
 	})
 
+	Describe("SetHTTPSPolicy", func() {
+		var (
+			dir  string
+			conf *routing.RouterConfig
+		)
+
+		BeforeEach(func() {
+			err := os.Mkdir(testPath, os.ModeDir|os.ModePerm)
+			Ω(err).ShouldNot(HaveOccurred())
+			dir, err = ioutil.TempDir("", "kroo-https")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf = &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				ListenStatement: &routing.ListenStatement{
+					IPAddress: abstraction.Inet("127.0.0.1"),
+					Port:      1337,
+					Keyword:   "ssl",
+				},
+				ServerName: pq.StringArray{"domain.com"},
+			}
+		})
+
+		AfterEach(func() {
+			err := os.RemoveAll(testPath)
+			Ω(err).ShouldNot(HaveOccurred())
+			os.RemoveAll(dir)
+		})
+
+		It("Should refuse HSTS before a valid certificate exists", func() {
+			db := testutils.NewMockDB()
+			s, _ := routing.NewService(db)
+			w, _ := template.NewWritingService(s, template.Nginx, testPath)
+			w.CreateRouterConfig(conf)
+
+			err := w.SetHTTPSPolicy(refID, name, &routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: 600},
+			})
+			Ω(err).Should(BeEquivalentTo(template.ErrNoValidCertificate))
+
+			err = w.SetHTTPSPolicy(refID, name, &routing.HTTPSPolicy{
+				Redirect: &routing.HTTPSRedirect{Port: 8080},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should refuse server names the certificate does not cover while HSTS is enabled", func() {
+			db := testutils.NewMockDB()
+			s, _ := routing.NewService(db)
+			w, _ := template.NewWritingService(s, template.Nginx, testPath)
+			w.CreateRouterConfig(conf)
+
+			cert, key := writeCertificate(dir, []string{"domain.com"}, time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+			err := w.EditRouterConfig(refID, name, &routing.RouterConfig{
+				SSLSettings: routing.SSLSettings{Certificate: cert, CertificateKey: key},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = w.SetHTTPSPolicy(refID, name, &routing.HTTPSPolicy{
+				HSTS: &routing.HSTS{MaxAge: 600},
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = w.AddServerName(refID, name, "domain2.com")
+			Ω(err).Should(BeEquivalentTo(template.ErrNoValidCertificate))
+		})
+
+		It("Should return an error if the config does not exist", func() {
+			db := testutils.NewMockDB()
+			s, _ := routing.NewService(db)
+			w, _ := template.NewWritingService(s, template.Nginx, testPath)
+
+			err := w.SetHTTPSPolicy(refID, "missing", nil)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Error Pages", func() {
+		var conf *routing.RouterConfig
+
+		BeforeEach(func() {
+			err := os.Mkdir(testPath, os.ModeDir|os.ModePerm)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			conf = &routing.RouterConfig{
+				RefID: refID,
+				Name:  name,
+				ListenStatement: &routing.ListenStatement{
+					IPAddress: abstraction.Inet("127.0.0.1"),
+					Port:      1337,
+				},
+				ServerName: pq.StringArray{"domain.com"},
+			}
+		})
+
+		AfterEach(func() {
+			err := os.RemoveAll(testPath)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should write error pages and maintenance", func() {
+			db := testutils.NewMockDB()
+			s, _ := routing.NewService(db)
+			w, _ := template.NewWritingService(s, template.Nginx, testPath)
+			w.CreateRouterConfig(conf)
+
+			err := w.SetErrorPage(refID, name, 503, "<h1>maintenance</h1>")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = w.SetMaintenance(refID, name, true)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(fmt.Sprintf("%s/%d_%s.conf", testPath, refID, name))
+			Ω(string(b)).Should(ContainSubstring("error_page 503 /.kroo-errors/503.html;"))
+			Ω(string(b)).Should(ContainSubstring("return 503;"))
+		})
+
+		It("Should answer with 509 while the quota is exhausted", func() {
+			db := testutils.NewMockDB()
+			s, _ := routing.NewService(db)
+			w, _ := template.NewWritingService(s, template.Nginx, testPath)
+			w.CreateRouterConfig(conf)
+
+			err := w.SetOverQuota(refID, name, true)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			b, _ := ioutil.ReadFile(fmt.Sprintf("%s/%d_%s.conf", testPath, refID, name))
+			Ω(string(b)).Should(ContainSubstring("error_page 509 /.kroo-errors/509.html;"))
+			Ω(string(b)).Should(ContainSubstring("return 509;"))
+
+			page, _ := ioutil.ReadFile(fmt.Sprintf("%s/%d_%s_509.html", testPath, refID, name))
+			Ω(string(page)).Should(Equal(routing.DefaultQuotaPage))
+		})
+
+		It("Should refuse maintenance with traefik", func() {
+			db := testutils.NewMockDB()
+			s, _ := routing.NewService(db)
+			w, _ := template.NewWritingService(s, template.Traefik, testPath)
+			w.CreateRouterConfig(conf)
+
+			err := w.SetMaintenance(refID, name, true)
+			Ω(err).Should(BeEquivalentTo(template.ErrNotSupported))
+		})
+	})
+
 	Describe("Configurations", func() {
 		BeforeEach(func() {
 			err := os.Mkdir(testPath, os.ModeDir|os.ModePerm)
@@ -3,6 +3,7 @@ package routing
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	grpctransport "github.com/go-kit/kit/transport/grpc"
@@ -88,6 +89,90 @@ func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger)
 			EncodeGRPCConfigurationsResponse,
 			options...,
 		),
+
+		setUpstream: grpctransport.NewServer(
+			endpoints.SetUpstreamEndpoint,
+			DecodeGRPCSetUpstreamRequest,
+			EncodeGRPCSetUpstreamResponse,
+			options...,
+		),
+
+		removeUpstream: grpctransport.NewServer(
+			endpoints.RemoveUpstreamEndpoint,
+			DecodeGRPCRemoveUpstreamRequest,
+			EncodeGRPCRemoveUpstreamResponse,
+			options...,
+		),
+
+		addUpstreamMember: grpctransport.NewServer(
+			endpoints.AddUpstreamMemberEndpoint,
+			DecodeGRPCAddUpstreamMemberRequest,
+			EncodeGRPCAddUpstreamMemberResponse,
+			options...,
+		),
+
+		removeUpstreamMember: grpctransport.NewServer(
+			endpoints.RemoveUpstreamMemberEndpoint,
+			DecodeGRPCRemoveUpstreamMemberRequest,
+			EncodeGRPCRemoveUpstreamMemberResponse,
+			options...,
+		),
+
+		traffic: grpctransport.NewServer(
+			endpoints.TrafficEndpoint,
+			DecodeGRPCTrafficRequest,
+			EncodeGRPCTrafficResponse,
+			options...,
+		),
+
+		setHTTPSPolicy: grpctransport.NewServer(
+			endpoints.SetHTTPSPolicyEndpoint,
+			DecodeGRPCSetHTTPSPolicyRequest,
+			EncodeGRPCSetHTTPSPolicyResponse,
+			options...,
+		),
+
+		setErrorPage: grpctransport.NewServer(
+			endpoints.SetErrorPageEndpoint,
+			DecodeGRPCSetErrorPageRequest,
+			EncodeGRPCSetErrorPageResponse,
+			options...,
+		),
+
+		setMaintenance: grpctransport.NewServer(
+			endpoints.SetMaintenanceEndpoint,
+			DecodeGRPCSetMaintenanceRequest,
+			EncodeGRPCSetMaintenanceResponse,
+			options...,
+		),
+
+		setProxyLimits: grpctransport.NewServer(
+			endpoints.SetProxyLimitsEndpoint,
+			DecodeGRPCSetProxyLimitsRequest,
+			EncodeGRPCSetProxyLimitsResponse,
+			options...,
+		),
+
+		setSwitch: grpctransport.NewServer(
+			endpoints.SetSwitchEndpoint,
+			DecodeGRPCSetSwitchRequest,
+			EncodeGRPCSetSwitchResponse,
+			options...,
+		),
+
+		removeSwitch: grpctransport.NewServer(
+			endpoints.RemoveSwitchEndpoint,
+			DecodeGRPCRemoveSwitchRequest,
+			EncodeGRPCRemoveSwitchResponse,
+			options...,
+		),
+
+		quota: grpctransport.NewServer(
+			endpoints.QuotaEndpoint,
+			DecodeGRPCQuotaRequest,
+			EncodeGRPCQuotaResponse,
+			options...,
+		),
 	}
 }
 
@@ -102,6 +187,18 @@ type grpcServer struct {
 	addServerName         grpctransport.Handler
 	removeServerName      grpctransport.Handler
 	configurations        grpctransport.Handler
+	setUpstream           grpctransport.Handler
+	removeUpstream        grpctransport.Handler
+	addUpstreamMember     grpctransport.Handler
+	removeUpstreamMember  grpctransport.Handler
+	traffic               grpctransport.Handler
+	setHTTPSPolicy        grpctransport.Handler
+	setErrorPage          grpctransport.Handler
+	setMaintenance        grpctransport.Handler
+	setProxyLimits        grpctransport.Handler
+	setSwitch             grpctransport.Handler
+	removeSwitch          grpctransport.Handler
+	quota                 grpctransport.Handler
 }
 
 func (s *grpcServer) CreateConfig(ctx oldcontext.Context, req *pb.CreateConfigRequest) (*pb.CreateConfigResponse, error) {
@@ -184,6 +281,102 @@ func (s *grpcServer) Configurations(ctx oldcontext.Context, req *pb.Configuratio
 	return res.(*pb.ConfigurationsResponse), nil
 }
 
+func (s *grpcServer) SetUpstream(ctx oldcontext.Context, req *pb.SetUpstreamRequest) (*pb.SetUpstreamResponse, error) {
+	_, res, err := s.setUpstream.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetUpstreamResponse), nil
+}
+
+func (s *grpcServer) RemoveUpstream(ctx oldcontext.Context, req *pb.RemoveUpstreamRequest) (*pb.RemoveUpstreamResponse, error) {
+	_, res, err := s.removeUpstream.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveUpstreamResponse), nil
+}
+
+func (s *grpcServer) AddUpstreamMember(ctx oldcontext.Context, req *pb.AddUpstreamMemberRequest) (*pb.AddUpstreamMemberResponse, error) {
+	_, res, err := s.addUpstreamMember.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.AddUpstreamMemberResponse), nil
+}
+
+func (s *grpcServer) RemoveUpstreamMember(ctx oldcontext.Context, req *pb.RemoveUpstreamMemberRequest) (*pb.RemoveUpstreamMemberResponse, error) {
+	_, res, err := s.removeUpstreamMember.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveUpstreamMemberResponse), nil
+}
+
+func (s *grpcServer) Traffic(ctx oldcontext.Context, req *pb.TrafficRequest) (*pb.TrafficResponse, error) {
+	_, res, err := s.traffic.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TrafficResponse), nil
+}
+
+func (s *grpcServer) SetHTTPSPolicy(ctx oldcontext.Context, req *pb.SetHTTPSPolicyRequest) (*pb.SetHTTPSPolicyResponse, error) {
+	_, res, err := s.setHTTPSPolicy.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetHTTPSPolicyResponse), nil
+}
+
+func (s *grpcServer) SetErrorPage(ctx oldcontext.Context, req *pb.SetErrorPageRequest) (*pb.SetErrorPageResponse, error) {
+	_, res, err := s.setErrorPage.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetErrorPageResponse), nil
+}
+
+func (s *grpcServer) SetMaintenance(ctx oldcontext.Context, req *pb.SetMaintenanceRequest) (*pb.SetMaintenanceResponse, error) {
+	_, res, err := s.setMaintenance.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetMaintenanceResponse), nil
+}
+
+func (s *grpcServer) SetProxyLimits(ctx oldcontext.Context, req *pb.SetProxyLimitsRequest) (*pb.SetProxyLimitsResponse, error) {
+	_, res, err := s.setProxyLimits.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetProxyLimitsResponse), nil
+}
+
+func (s *grpcServer) SetSwitch(ctx oldcontext.Context, req *pb.SetSwitchRequest) (*pb.SetSwitchResponse, error) {
+	_, res, err := s.setSwitch.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetSwitchResponse), nil
+}
+
+func (s *grpcServer) RemoveSwitch(ctx oldcontext.Context, req *pb.RemoveSwitchRequest) (*pb.RemoveSwitchResponse, error) {
+	_, res, err := s.removeSwitch.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveSwitchResponse), nil
+}
+
+func (s *grpcServer) Quota(ctx oldcontext.Context, req *pb.QuotaRequest) (*pb.QuotaResponse, error) {
+	_, res, err := s.quota.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.QuotaResponse), nil
+}
+
 func convertPBRules(r map[string]string) map[string][]string {
 	m := make(map[string][]string)
 	for k, v := range r {
@@ -192,10 +385,46 @@ func convertPBRules(r map[string]string) map[string][]string {
 	return m
 }
 
+func convertPBProxyOptions(p *pb.ProxyOptions) *ProxyOptions {
+	if p == nil {
+		return nil
+	}
+	return &ProxyOptions{
+		Upstream:          p.Upstream,
+		Websocket:         p.Websocket,
+		GRPC:              p.Grpc,
+		ClientMaxBodySize: p.ClientMaxBodySize,
+		ConnectTimeout:    uint(p.ConnectTimeout),
+		ReadTimeout:       uint(p.ReadTimeout),
+		SendTimeout:       uint(p.SendTimeout),
+	}
+}
+
+func convertPBAccessOptions(a *pb.AccessOptions) *AccessOptions {
+	if a == nil {
+		return nil
+	}
+	credentials := make([]*Credential, len(a.Credentials))
+	for i, c := range a.Credentials {
+		credentials[i] = &Credential{
+			Username: c.Username,
+			Password: c.Password,
+			Hash:     c.Hash,
+		}
+	}
+	return &AccessOptions{
+		Realm:       a.Realm,
+		Credentials: credentials,
+		Allow:       a.Allow,
+	}
+}
+
 func convertPBLocation(l *pb.Location) *LocationRule {
 	return &LocationRule{
 		Location: l.Location,
 		Rules:    convertPBRules(l.Rules),
+		Proxy:    convertPBProxyOptions(l.Proxy),
+		Access:   convertPBAccessOptions(l.Access),
 	}
 }
 
@@ -213,6 +442,7 @@ func convertPBListenStatement(l *pb.ListenStatement) *ListenStatement {
 		IPAddress: ip,
 		Keyword:   l.Keyword,
 		Port:      uint16(l.Port),
+		HTTP2:     l.Http2,
 	}
 }
 
@@ -231,6 +461,100 @@ func convertPBSSLSettings(s *pb.SSLSettings) SSLSettings {
 		Certificate:         s.Certificate,
 		CertificateKey:      s.CertificateKey,
 		Curve:               s.Curve,
+		ACME:                s.Acme,
+	}
+}
+
+func convertPBUpstreamMember(m *pb.UpstreamMember) *UpstreamMember {
+	if m == nil {
+		return nil
+	}
+	return &UpstreamMember{
+		Address:     m.Address,
+		Weight:      uint(m.Weight),
+		MaxFails:    uint(m.MaxFails),
+		FailTimeout: uint(m.FailTimeout),
+		Down:        m.Down,
+	}
+}
+
+func convertPBUpstream(u *pb.Upstream) *Upstream {
+	if u == nil {
+		return nil
+	}
+	members := make([]*UpstreamMember, len(u.Members))
+	for i, m := range u.Members {
+		members[i] = convertPBUpstreamMember(m)
+	}
+	return &Upstream{
+		Name:      u.Name,
+		Algorithm: u.Algorithm,
+		Members:   members,
+	}
+}
+
+func convertPBUpstreams(u []*pb.Upstream) Upstreams {
+	ups := make(Upstreams, len(u))
+	for i, up := range u {
+		ups[i] = convertPBUpstream(up)
+	}
+	return ups
+}
+
+// ConvertPBSwitch convert *pb.Switch to *Switch
+func ConvertPBSwitch(s *pb.Switch) *Switch {
+	if s == nil {
+		return nil
+	}
+	return &Switch{
+		Name:   s.Name,
+		Blue:   s.Blue,
+		Green:  s.Green,
+		Active: s.Active,
+		Canary: uint(s.Canary),
+	}
+}
+
+func convertPBSwitches(s []*pb.Switch) Switches {
+	switches := make(Switches, len(s))
+	for i, sw := range s {
+		switches[i] = ConvertPBSwitch(sw)
+	}
+	return switches
+}
+
+// ConvertPBHTTPSPolicy convert *pb.HTTPSPolicy to *HTTPSPolicy
+func ConvertPBHTTPSPolicy(p *pb.HTTPSPolicy) *HTTPSPolicy {
+	if p == nil {
+		return nil
+	}
+	policy := &HTTPSPolicy{}
+	if p.Redirect != nil {
+		policy.Redirect = &HTTPSRedirect{
+			Port:      uint16(p.Redirect.Port),
+			HTTPSPort: uint16(p.Redirect.HttpsPort),
+		}
+	}
+	if p.Hsts != nil {
+		policy.HSTS = &HSTS{
+			MaxAge:            uint(p.Hsts.MaxAge),
+			IncludeSubdomains: p.Hsts.IncludeSubdomains,
+			Preload:           p.Hsts.Preload,
+		}
+	}
+	return policy
+}
+
+func convertPBErrorPages(e *pb.ErrorPages) *ErrorPages {
+	if e == nil {
+		return nil
+	}
+	return &ErrorPages{
+		BadGateway:    e.BadGateway,
+		Unavailable:   e.Unavailable,
+		QuotaExceeded: e.QuotaExceeded,
+		Maintenance:   e.Maintenance,
+		OverQuota:     e.OverQuota,
 	}
 }
 
@@ -246,6 +570,10 @@ func ConvertPBConfig(c *pb.RouterConfig) *RouterConfig {
 		RootPath:        c.RootPath,
 		SSLSettings:     convertPBSSLSettings(c.SSLSettings),
 		LocationRules:   convertPBLocations(c.LocationRules),
+		Upstreams:       convertPBUpstreams(c.Upstreams),
+		HTTPS:           ConvertPBHTTPSPolicy(c.Https),
+		ErrorPages:      convertPBErrorPages(c.ErrorPages),
+		Switches:        convertPBSwitches(c.Switches),
 	}
 }
 
@@ -257,11 +585,47 @@ func convertRules(r map[string][]string) map[string]string {
 	return m
 }
 
+func convertProxyOptions(p *ProxyOptions) *pb.ProxyOptions {
+	if p == nil {
+		return nil
+	}
+	return &pb.ProxyOptions{
+		Upstream:          p.Upstream,
+		Websocket:         p.Websocket,
+		Grpc:              p.GRPC,
+		ClientMaxBodySize: p.ClientMaxBodySize,
+		ConnectTimeout:    uint32(p.ConnectTimeout),
+		ReadTimeout:       uint32(p.ReadTimeout),
+		SendTimeout:       uint32(p.SendTimeout),
+	}
+}
+
+func convertAccessOptions(a *AccessOptions) *pb.AccessOptions {
+	if a == nil {
+		return nil
+	}
+	credentials := make([]*pb.Credential, len(a.Credentials))
+	for i, c := range a.Credentials {
+		credentials[i] = &pb.Credential{
+			Username: c.Username,
+			Password: c.Password,
+			Hash:     c.Hash,
+		}
+	}
+	return &pb.AccessOptions{
+		Realm:       a.Realm,
+		Credentials: credentials,
+		Allow:       a.Allow,
+	}
+}
+
 // ConvertLocation convert *Location to *pb.Location
 func ConvertLocation(l *LocationRule) *pb.Location {
 	return &pb.Location{
 		Location: l.Location,
 		Rules:    convertRules(l.Rules),
+		Proxy:    convertProxyOptions(l.Proxy),
+		Access:   convertAccessOptions(l.Access),
 	}
 }
 
@@ -279,6 +643,7 @@ func ConvertListenStatement(l *ListenStatement) *pb.ListenStatement {
 		IPAddress: string(l.IPAddress),
 		Keyword:   l.Keyword,
 		Port:      uint32(l.Port),
+		Http2:     l.HTTP2,
 	}
 }
 
@@ -297,6 +662,187 @@ func convertSSLSettings(s SSLSettings) *pb.SSLSettings {
 		Certificate:         s.Certificate,
 		CertificateKey:      s.CertificateKey,
 		Curve:               s.Curve,
+		Acme:                s.ACME,
+	}
+}
+
+// ConvertUpstreamMember convert *UpstreamMember to *pb.UpstreamMember
+func ConvertUpstreamMember(m *UpstreamMember) *pb.UpstreamMember {
+	if m == nil {
+		return nil
+	}
+	return &pb.UpstreamMember{
+		Address:     m.Address,
+		Weight:      uint32(m.Weight),
+		MaxFails:    uint32(m.MaxFails),
+		FailTimeout: uint32(m.FailTimeout),
+		Down:        m.Down,
+	}
+}
+
+// ConvertUpstream convert *Upstream to *pb.Upstream
+func ConvertUpstream(u *Upstream) *pb.Upstream {
+	if u == nil {
+		return nil
+	}
+	members := make([]*pb.UpstreamMember, len(u.Members))
+	for i, m := range u.Members {
+		members[i] = ConvertUpstreamMember(m)
+	}
+	return &pb.Upstream{
+		Name:      u.Name,
+		Algorithm: u.Algorithm,
+		Members:   members,
+	}
+}
+
+func convertUpstreams(u Upstreams) []*pb.Upstream {
+	ups := make([]*pb.Upstream, len(u))
+	for i, up := range u {
+		ups[i] = ConvertUpstream(up)
+	}
+	return ups
+}
+
+func convertTraffic(t []Traffic) []*pb.Traffic {
+	traffic := make([]*pb.Traffic, len(t))
+	for i, tr := range t {
+		traffic[i] = &pb.Traffic{
+			Location:   tr.Location,
+			Period:     tr.Period.Unix(),
+			Requests:   uint32(tr.Requests),
+			Status1Xx:  uint32(tr.Status1xx),
+			Status2Xx:  uint32(tr.Status2xx),
+			Status3Xx:  uint32(tr.Status3xx),
+			Status4Xx:  uint32(tr.Status4xx),
+			Status5Xx:  uint32(tr.Status5xx),
+			BytesSent:  tr.BytesSent,
+			LatencyP50: tr.LatencyP50,
+			LatencyP90: tr.LatencyP90,
+			LatencyP99: tr.LatencyP99,
+		}
+	}
+	return traffic
+}
+
+// ConvertPBTraffic converts gRPC traffic statistics to messages/routing.proto-domain Traffic
+func ConvertPBTraffic(t []*pb.Traffic) []Traffic {
+	traffic := make([]Traffic, 0, len(t))
+	for _, tr := range t {
+		if tr == nil {
+			continue
+		}
+		traffic = append(traffic, Traffic{
+			Location:   tr.Location,
+			Period:     time.Unix(tr.Period, 0),
+			Requests:   uint(tr.Requests),
+			Status1xx:  uint(tr.Status1Xx),
+			Status2xx:  uint(tr.Status2Xx),
+			Status3xx:  uint(tr.Status3Xx),
+			Status4xx:  uint(tr.Status4Xx),
+			Status5xx:  uint(tr.Status5Xx),
+			BytesSent:  tr.BytesSent,
+			LatencyP50: tr.LatencyP50,
+			LatencyP90: tr.LatencyP90,
+			LatencyP99: tr.LatencyP99,
+		})
+	}
+	return traffic
+}
+
+func convertQuota(q Quota) *pb.Quota {
+	return &pb.Quota{
+		Period:    q.Period.Unix(),
+		Proxied:   q.Proxied,
+		Network:   q.Network,
+		Limit:     q.Limit,
+		Behavior:  q.Behavior,
+		Rate:      q.Rate,
+		Exhausted: q.Exhausted,
+	}
+}
+
+// ConvertPBQuota converts a gRPC quota to a messages/routing.proto-domain Quota
+func ConvertPBQuota(q *pb.Quota) Quota {
+	if q == nil {
+		return Quota{}
+	}
+	return Quota{
+		Period:    time.Unix(q.Period, 0).UTC(),
+		Proxied:   q.Proxied,
+		Network:   q.Network,
+		Limit:     q.Limit,
+		Behavior:  q.Behavior,
+		Rate:      q.Rate,
+		Exhausted: q.Exhausted,
+	}
+}
+
+func convertTrafficRates(r []TrafficRate) []*pb.TrafficRate {
+	rates := make([]*pb.TrafficRate, len(r))
+	for i, rate := range r {
+		rates[i] = &pb.TrafficRate{
+			Location: rate.Location,
+			Rate:     rate.Rate,
+		}
+	}
+	return rates
+}
+
+// ConvertSwitch convert *Switch to *pb.Switch
+func ConvertSwitch(s *Switch) *pb.Switch {
+	if s == nil {
+		return nil
+	}
+	return &pb.Switch{
+		Name:   s.Name,
+		Blue:   s.Blue,
+		Green:  s.Green,
+		Active: s.Active,
+		Canary: uint32(s.Canary),
+	}
+}
+
+func convertSwitches(s Switches) []*pb.Switch {
+	switches := make([]*pb.Switch, len(s))
+	for i, sw := range s {
+		switches[i] = ConvertSwitch(sw)
+	}
+	return switches
+}
+
+// ConvertHTTPSPolicy convert *HTTPSPolicy to *pb.HTTPSPolicy
+func ConvertHTTPSPolicy(p *HTTPSPolicy) *pb.HTTPSPolicy {
+	if p == nil {
+		return nil
+	}
+	policy := &pb.HTTPSPolicy{}
+	if p.Redirect != nil {
+		policy.Redirect = &pb.HTTPSRedirect{
+			Port:      uint32(p.Redirect.Port),
+			HttpsPort: uint32(p.Redirect.HTTPSPort),
+		}
+	}
+	if p.HSTS != nil {
+		policy.Hsts = &pb.HSTS{
+			MaxAge:            uint32(p.HSTS.MaxAge),
+			IncludeSubdomains: p.HSTS.IncludeSubdomains,
+			Preload:           p.HSTS.Preload,
+		}
+	}
+	return policy
+}
+
+func convertErrorPages(e *ErrorPages) *pb.ErrorPages {
+	if e == nil {
+		return nil
+	}
+	return &pb.ErrorPages{
+		BadGateway:    e.BadGateway,
+		Unavailable:   e.Unavailable,
+		QuotaExceeded: e.QuotaExceeded,
+		Maintenance:   e.Maintenance,
+		OverQuota:     e.OverQuota,
 	}
 }
 
@@ -312,6 +858,10 @@ func ConvertConfiguration(c RouterConfig) *pb.RouterConfig {
 		RootPath:        c.RootPath,
 		SSLSettings:     convertSSLSettings(c.SSLSettings),
 		LocationRules:   convertLocations(c.LocationRules),
+		Upstreams:       convertUpstreams(c.Upstreams),
+		Https:           ConvertHTTPSPolicy(c.HTTPS),
+		ErrorPages:      convertErrorPages(c.ErrorPages),
+		Switches:        convertSwitches(c.Switches),
 	}
 }
 
@@ -551,3 +1101,304 @@ func EncodeGRPCConfigurationsResponse(_ context.Context, response interface{}) (
 	}
 	return gRPCRes, nil
 }
+
+// DecodeGRPCSetUpstreamRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetUpstream request to a messages/routing.proto-domain setupstream request.
+func DecodeGRPCSetUpstreamRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetUpstreamRequest)
+	return SetUpstreamRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Upstream: convertPBUpstream(req.Upstream),
+	}, nil
+}
+
+// EncodeGRPCSetUpstreamResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain setupstream response to a gRPC SetUpstream response.
+func EncodeGRPCSetUpstreamResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetUpstreamResponse)
+	gRPCRes := &pb.SetUpstreamResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveUpstreamRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveUpstream request to a messages/routing.proto-domain removeupstream request.
+func DecodeGRPCRemoveUpstreamRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveUpstreamRequest)
+	return RemoveUpstreamRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Upstream: req.Upstream,
+	}, nil
+}
+
+// EncodeGRPCRemoveUpstreamResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain removeupstream response to a gRPC RemoveUpstream response.
+func EncodeGRPCRemoveUpstreamResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveUpstreamResponse)
+	gRPCRes := &pb.RemoveUpstreamResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCAddUpstreamMemberRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC AddUpstreamMember request to a messages/routing.proto-domain addupstreammember request.
+func DecodeGRPCAddUpstreamMemberRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AddUpstreamMemberRequest)
+	return AddUpstreamMemberRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Upstream: req.Upstream,
+		Member:   convertPBUpstreamMember(req.Member),
+	}, nil
+}
+
+// EncodeGRPCAddUpstreamMemberResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain addupstreammember response to a gRPC AddUpstreamMember response.
+func EncodeGRPCAddUpstreamMemberResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(AddUpstreamMemberResponse)
+	gRPCRes := &pb.AddUpstreamMemberResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveUpstreamMemberRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveUpstreamMember request to a messages/routing.proto-domain removeupstreammember request.
+func DecodeGRPCRemoveUpstreamMemberRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveUpstreamMemberRequest)
+	return RemoveUpstreamMemberRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Upstream: req.Upstream,
+		Address:  req.Address,
+	}, nil
+}
+
+// EncodeGRPCRemoveUpstreamMemberResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain removeupstreammember response to a gRPC RemoveUpstreamMember response.
+func EncodeGRPCRemoveUpstreamMemberResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveUpstreamMemberResponse)
+	gRPCRes := &pb.RemoveUpstreamMemberResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCTrafficRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Traffic request to a messages/routing.proto-domain traffic request.
+func DecodeGRPCTrafficRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.TrafficRequest)
+	return TrafficRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		From: time.Unix(req.From, 0),
+		To:   time.Unix(req.To, 0),
+	}, nil
+}
+
+// EncodeGRPCTrafficResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain traffic response to a gRPC Traffic response.
+func EncodeGRPCTrafficResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(TrafficResponse)
+	gRPCRes := &pb.TrafficResponse{
+		Traffic: convertTraffic(res.Traffic),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetHTTPSPolicyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetHTTPSPolicy request to a messages/routing.proto-domain sethttpspolicy request.
+func DecodeGRPCSetHTTPSPolicyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetHTTPSPolicyRequest)
+	return SetHTTPSPolicyRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Policy: ConvertPBHTTPSPolicy(req.Policy),
+	}, nil
+}
+
+// EncodeGRPCSetHTTPSPolicyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain sethttpspolicy response to a gRPC SetHTTPSPolicy response.
+func EncodeGRPCSetHTTPSPolicyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetHTTPSPolicyResponse)
+	gRPCRes := &pb.SetHTTPSPolicyResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetErrorPageRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetErrorPage request to a messages/routing.proto-domain seterrorpage request.
+func DecodeGRPCSetErrorPageRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetErrorPageRequest)
+	return SetErrorPageRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Code: int(req.Code),
+		Page: req.Page,
+	}, nil
+}
+
+// EncodeGRPCSetErrorPageResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain seterrorpage response to a gRPC SetErrorPage response.
+func EncodeGRPCSetErrorPageResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetErrorPageResponse)
+	gRPCRes := &pb.SetErrorPageResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetMaintenanceRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetMaintenance request to a messages/routing.proto-domain setmaintenance request.
+func DecodeGRPCSetMaintenanceRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetMaintenanceRequest)
+	return SetMaintenanceRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Maintenance: req.Maintenance,
+	}, nil
+}
+
+// EncodeGRPCSetMaintenanceResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain setmaintenance response to a gRPC SetMaintenance response.
+func EncodeGRPCSetMaintenanceResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetMaintenanceResponse)
+	gRPCRes := &pb.SetMaintenanceResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetProxyLimitsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetProxyLimits request to a messages/routing.proto-domain setproxylimits request.
+func DecodeGRPCSetProxyLimitsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetProxyLimitsRequest)
+	var limits *ProxyLimits
+	if req.Limits != nil {
+		limits = &ProxyLimits{
+			ClientMaxBodySize: req.Limits.ClientMaxBodySize,
+			ReadTimeout:       uint(req.Limits.ReadTimeout),
+			SendTimeout:       uint(req.Limits.SendTimeout),
+		}
+	}
+	return SetProxyLimitsRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		LID:    int(req.Id),
+		Limits: limits,
+	}, nil
+}
+
+// EncodeGRPCSetProxyLimitsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain setproxylimits response to a gRPC SetProxyLimits response.
+func EncodeGRPCSetProxyLimitsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetProxyLimitsResponse)
+	gRPCRes := &pb.SetProxyLimitsResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetSwitchRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetSwitch request to a messages/routing.proto-domain setswitch request.
+func DecodeGRPCSetSwitchRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetSwitchRequest)
+	return SetSwitchRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Switch: ConvertPBSwitch(req.Switch),
+	}, nil
+}
+
+// EncodeGRPCSetSwitchResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain setswitch response to a gRPC SetSwitch response.
+func EncodeGRPCSetSwitchResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetSwitchResponse)
+	gRPCRes := &pb.SetSwitchResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveSwitchRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveSwitch request to a messages/routing.proto-domain removeswitch request.
+func DecodeGRPCRemoveSwitchRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveSwitchRequest)
+	return RemoveSwitchRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+		Switch: req.Switch,
+	}, nil
+}
+
+// EncodeGRPCRemoveSwitchResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain removeswitch response to a gRPC RemoveSwitch response.
+func EncodeGRPCRemoveSwitchResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveSwitchResponse)
+	gRPCRes := &pb.RemoveSwitchResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCQuotaRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Quota request to a messages/routing.proto-domain quota request.
+func DecodeGRPCQuotaRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.QuotaRequest)
+	return QuotaRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCQuotaResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/routing.proto-domain quota response to a gRPC Quota response.
+func EncodeGRPCQuotaResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(QuotaResponse)
+	gRPCRes := &pb.QuotaResponse{
+		Quota: convertQuota(res.Quota),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -3,7 +3,7 @@ package routing
 import (
 	"context"
 
-	"github.com/golang/protobuf/proto"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
 	"github.com/kontainerooo/kontainer.ooo/pkg/routing/pb"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
 )
@@ -92,6 +92,110 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 		EncodeGRPCConfigurationsResponse,
 	))
 
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"SetUpstream",
+		ws.ProtoIDFromString("SUP"),
+		endpoints.SetUpstreamEndpoint,
+		DecodeWSSetUpstreamRequest,
+		EncodeGRPCSetUpstreamResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"RemoveUpstream",
+		ws.ProtoIDFromString("RUP"),
+		endpoints.RemoveUpstreamEndpoint,
+		DecodeWSRemoveUpstreamRequest,
+		EncodeGRPCRemoveUpstreamResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"AddUpstreamMember",
+		ws.ProtoIDFromString("AUM"),
+		endpoints.AddUpstreamMemberEndpoint,
+		DecodeWSAddUpstreamMemberRequest,
+		EncodeGRPCAddUpstreamMemberResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"RemoveUpstreamMember",
+		ws.ProtoIDFromString("RUM"),
+		endpoints.RemoveUpstreamMemberEndpoint,
+		DecodeWSRemoveUpstreamMemberRequest,
+		EncodeGRPCRemoveUpstreamMemberResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"Traffic",
+		ws.ProtoIDFromString("TRF"),
+		endpoints.TrafficEndpoint,
+		DecodeWSTrafficRequest,
+		EncodeGRPCTrafficResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"TrafficRate",
+		ws.ProtoIDFromString("TRR"),
+		endpoints.TrafficRateEndpoint,
+		DecodeWSTrafficRateRequest,
+		EncodeWSTrafficRateResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"SetHTTPSPolicy",
+		ws.ProtoIDFromString("SHP"),
+		endpoints.SetHTTPSPolicyEndpoint,
+		DecodeWSSetHTTPSPolicyRequest,
+		EncodeGRPCSetHTTPSPolicyResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"SetErrorPage",
+		ws.ProtoIDFromString("SEP"),
+		endpoints.SetErrorPageEndpoint,
+		DecodeWSSetErrorPageRequest,
+		EncodeGRPCSetErrorPageResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"SetMaintenance",
+		ws.ProtoIDFromString("SMT"),
+		endpoints.SetMaintenanceEndpoint,
+		DecodeWSSetMaintenanceRequest,
+		EncodeGRPCSetMaintenanceResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"SetProxyLimits",
+		ws.ProtoIDFromString("SPL"),
+		endpoints.SetProxyLimitsEndpoint,
+		DecodeWSSetProxyLimitsRequest,
+		EncodeGRPCSetProxyLimitsResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"SetSwitch",
+		ws.ProtoIDFromString("SSW"),
+		endpoints.SetSwitchEndpoint,
+		DecodeWSSetSwitchRequest,
+		EncodeGRPCSetSwitchResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"RemoveSwitch",
+		ws.ProtoIDFromString("RSW"),
+		endpoints.RemoveSwitchEndpoint,
+		DecodeWSRemoveSwitchRequest,
+		EncodeGRPCRemoveSwitchResponse,
+	))
+
+	service.AddEndpoint(ws.NewServiceEndpoint(
+		"Quota",
+		ws.ProtoIDFromString("QTA"),
+		endpoints.QuotaEndpoint,
+		DecodeWSQuotaRequest,
+		EncodeGRPCQuotaResponse,
+	))
+
 	return service
 }
 
@@ -99,7 +203,7 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 // WS CreateConfig request to a messages/routing.proto-domain createconfig request.
 func DecodeWSCreateConfigRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.CreateConfigRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -111,7 +215,7 @@ func DecodeWSCreateConfigRequest(ctx context.Context, data interface{}) (interfa
 // WS EditConfig request to a messages/routing.proto-domain editconfig request.
 func DecodeWSEditConfigRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.EditConfigRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -123,7 +227,7 @@ func DecodeWSEditConfigRequest(ctx context.Context, data interface{}) (interface
 // WS GetConfig request to a messages/routing.proto-domain getconfig request.
 func DecodeWSGetConfigRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetConfigRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +239,7 @@ func DecodeWSGetConfigRequest(ctx context.Context, data interface{}) (interface{
 // WS RemoveConfig request to a messages/routing.proto-domain removeconfig request.
 func DecodeWSRemoveConfigRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveConfigRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -147,7 +251,7 @@ func DecodeWSRemoveConfigRequest(ctx context.Context, data interface{}) (interfa
 // WS AddLocation request to a messages/routing.proto-domain addlocation request.
 func DecodeWSAddLocationRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.AddLocationRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +263,7 @@ func DecodeWSAddLocationRequest(ctx context.Context, data interface{}) (interfac
 // WS RemoveLocation request to a messages/routing.proto-domain removelocation request.
 func DecodeWSRemoveLocationRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveLocationRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +275,7 @@ func DecodeWSRemoveLocationRequest(ctx context.Context, data interface{}) (inter
 // WS ChangeListenStatement request to a messages/routing.proto-domain changelistenstatement request.
 func DecodeWSChangeListenStatementRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.ChangeListenStatementRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -183,7 +287,7 @@ func DecodeWSChangeListenStatementRequest(ctx context.Context, data interface{})
 // WS AddServerName request to a messages/routing.proto-domain addservername request.
 func DecodeWSAddServerNameRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.AddServerNameRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -195,7 +299,7 @@ func DecodeWSAddServerNameRequest(ctx context.Context, data interface{}) (interf
 // WS RemoveServerName request to a messages/routing.proto-domain removeservername request.
 func DecodeWSRemoveServerNameRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.RemoveServerNameRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -207,10 +311,184 @@ func DecodeWSRemoveServerNameRequest(ctx context.Context, data interface{}) (int
 // WS Configurations request to a messages/routing.proto-domain configurations request.
 func DecodeWSConfigurationsRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.ConfigurationsRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
 
 	return DecodeGRPCConfigurationsRequest(ctx, req)
 }
+
+// DecodeWSSetUpstreamRequest is a websocket.DecodeRequestFunc that converts a
+// WS SetUpstream request to a messages/routing.proto-domain setupstream request.
+func DecodeWSSetUpstreamRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.SetUpstreamRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCSetUpstreamRequest(ctx, req)
+}
+
+// DecodeWSRemoveUpstreamRequest is a websocket.DecodeRequestFunc that converts a
+// WS RemoveUpstream request to a messages/routing.proto-domain removeupstream request.
+func DecodeWSRemoveUpstreamRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.RemoveUpstreamRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCRemoveUpstreamRequest(ctx, req)
+}
+
+// DecodeWSAddUpstreamMemberRequest is a websocket.DecodeRequestFunc that converts a
+// WS AddUpstreamMember request to a messages/routing.proto-domain addupstreammember request.
+func DecodeWSAddUpstreamMemberRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.AddUpstreamMemberRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCAddUpstreamMemberRequest(ctx, req)
+}
+
+// DecodeWSRemoveUpstreamMemberRequest is a websocket.DecodeRequestFunc that converts a
+// WS RemoveUpstreamMember request to a messages/routing.proto-domain removeupstreammember request.
+func DecodeWSRemoveUpstreamMemberRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.RemoveUpstreamMemberRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCRemoveUpstreamMemberRequest(ctx, req)
+}
+
+// DecodeWSTrafficRequest is a websocket.DecodeRequestFunc that converts a
+// WS Traffic request to a messages/routing.proto-domain traffic request.
+func DecodeWSTrafficRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.TrafficRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCTrafficRequest(ctx, req)
+}
+
+// DecodeWSTrafficRateRequest is a websocket.DecodeRequestFunc that converts a
+// WS TrafficRate request to a messages/routing.proto-domain trafficrate request.
+func DecodeWSTrafficRateRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.TrafficRateRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return TrafficRateRequest{
+		IDRequest: IDRequest{
+			RefID: uint(req.RefID),
+			Name:  req.Name,
+		},
+	}, nil
+}
+
+// EncodeWSTrafficRateResponse is a websocket.EncodeResponseFunc that converts a
+// messages/routing.proto-domain trafficrate response to a WS TrafficRate response.
+func EncodeWSTrafficRateResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(TrafficRateResponse)
+	wsRes := &pb.TrafficRateResponse{
+		Rates: convertTrafficRates(res.Rates),
+	}
+	if res.Error != nil {
+		wsRes.Error = res.Error.Error()
+	}
+	return wsRes, nil
+}
+
+// DecodeWSSetHTTPSPolicyRequest is a websocket.DecodeRequestFunc that converts a
+// WS SetHTTPSPolicy request to a messages/routing.proto-domain sethttpspolicy request.
+func DecodeWSSetHTTPSPolicyRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.SetHTTPSPolicyRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCSetHTTPSPolicyRequest(ctx, req)
+}
+
+// DecodeWSSetErrorPageRequest is a websocket.DecodeRequestFunc that converts a
+// WS SetErrorPage request to a messages/routing.proto-domain seterrorpage request.
+func DecodeWSSetErrorPageRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.SetErrorPageRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCSetErrorPageRequest(ctx, req)
+}
+
+// DecodeWSSetMaintenanceRequest is a websocket.DecodeRequestFunc that converts a
+// WS SetMaintenance request to a messages/routing.proto-domain setmaintenance request.
+func DecodeWSSetMaintenanceRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.SetMaintenanceRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCSetMaintenanceRequest(ctx, req)
+}
+
+// DecodeWSSetProxyLimitsRequest is a websocket.DecodeRequestFunc that converts a
+// WS SetProxyLimits request to a messages/routing.proto-domain setproxylimits request.
+func DecodeWSSetProxyLimitsRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.SetProxyLimitsRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCSetProxyLimitsRequest(ctx, req)
+}
+
+// DecodeWSSetSwitchRequest is a websocket.DecodeRequestFunc that converts a
+// WS SetSwitch request to a messages/routing.proto-domain setswitch request.
+func DecodeWSSetSwitchRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.SetSwitchRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCSetSwitchRequest(ctx, req)
+}
+
+// DecodeWSRemoveSwitchRequest is a websocket.DecodeRequestFunc that converts a
+// WS RemoveSwitch request to a messages/routing.proto-domain removeswitch request.
+func DecodeWSRemoveSwitchRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.RemoveSwitchRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCRemoveSwitchRequest(ctx, req)
+}
+
+// DecodeWSQuotaRequest is a websocket.DecodeRequestFunc that converts a
+// WS Quota request to a messages/routing.proto-domain quota request.
+func DecodeWSQuotaRequest(ctx context.Context, data interface{}) (interface{}, error) {
+	req := &pb.QuotaRequest{}
+	err := payload.Unmarshal(data.([]byte), req)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeGRPCQuotaRequest(ctx, req)
+}
@@ -0,0 +1,177 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AWSOptions configure the key of AWS KMS the data keys are encrypted with
+type AWSOptions struct {
+	// Endpoint is the URL of the KMS, e.g. https://kms.eu-central-1.amazonaws.com
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	// KeyID is the id, ARN or alias of the symmetric key new data keys are encrypted with
+	KeyID string
+}
+
+type awsKMS struct {
+	options AWSOptions
+	client  *http.Client
+	now     func() time.Time
+}
+
+// awsError is the body of the responses of failed calls
+type awsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// sign adds the AWS signature version 4 of req with the body body to its headers
+func (k *awsKMS) sign(req *http.Request, body []byte) {
+	now := k.now().UTC()
+	date := now.Format("20060102")
+	stamp := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", stamp)
+
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	for key, v := range req.Header {
+		key = strings.ToLower(key)
+		if strings.HasPrefix(key, "x-amz-") || key == "content-type" {
+			headers[key] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for key := range headers {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := ""
+	for _, key := range names {
+		canonicalHeaders += key + ":" + headers[key] + "\n"
+	}
+	signed := strings.Join(names, ";")
+
+	payload := sha256.Sum256(body)
+	canonical := strings.Join([]string{
+		req.Method,
+		"/",
+		"",
+		canonicalHeaders,
+		signed,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonical))
+
+	scope := strings.Join([]string{date, k.options.Region, "kms", "aws4_request"}, "/")
+	toSign := strings.Join([]string{"AWS4-HMAC-SHA256", stamp, scope, hex.EncodeToString(sum[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+k.options.SecretKey), date)
+	key = hmacSHA256(key, k.options.Region)
+	key = hmacSHA256(key, "kms")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		k.options.AccessKey, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign)),
+	))
+}
+
+// call sends the JSON encoded in to the action of the KMS API and decodes the response into out
+func (k *awsKMS) call(action string, in interface{}, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", strings.TrimSuffix(k.options.Endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	k.sign(req, body)
+
+	res, err := k.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		e := awsError{}
+		json.Unmarshal(b, &e)
+		// the type is prefixed with the namespace of the error in some responses
+		if t := e.Type[strings.LastIndex(e.Type, "#")+1:]; t == "NotFoundException" {
+			return ErrUnknownKey
+		}
+		return fmt.Errorf("kms %s failed with status %d: %s %s", action, res.StatusCode, e.Type, e.Message)
+	}
+	return json.Unmarshal(b, out)
+}
+
+func (k *awsKMS) KeyID() string {
+	return k.options.KeyID
+}
+
+func (k *awsKMS) Encrypt(key []byte) ([]byte, error) {
+	out := struct {
+		CiphertextBlob []byte
+	}{}
+	err := k.call("Encrypt", struct {
+		KeyId     string
+		Plaintext []byte
+	}{k.options.KeyID, key}, &out)
+	return out.CiphertextBlob, err
+}
+
+func (k *awsKMS) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	out := struct {
+		Plaintext []byte
+	}{}
+	err := k.call("Decrypt", struct {
+		KeyId          string
+		CiphertextBlob []byte
+	}{keyID, ciphertext}, &out)
+	return out.Plaintext, err
+}
+
+// NewAWSKMS returns a KMS encrypting the data keys with a key of AWS KMS, client defaults to one with
+// a timeout of 10 seconds
+func NewAWSKMS(o AWSOptions, client *http.Client) KMS {
+	if client == nil {
+		client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	return &awsKMS{
+		options: o,
+		client:  client,
+		now:     time.Now,
+	}
+}
@@ -0,0 +1,48 @@
+package secret
+
+import "strings"
+
+// Backend keeps the secrets of the users for the services, which store the values it returns in their place
+type Backend interface {
+	// Put keeps value as a secret of the user refID, kind groups the secrets of a sort, e.g. webhooks.
+	// It returns the value the service stores instead of the secret.
+	Put(refID uint, kind string, value string) (string, error)
+
+	// Get returns the secret of a value returned by Put for the user refID, plaintext values are returned as they are
+	Get(refID uint, value string) (string, error)
+
+	// Delete removes the secret of a value returned by Put for the user refID once the service does not store it anymore
+	Delete(refID uint, value string) error
+
+	// Migrate keeps value with the current master key or in the backend if it is not already, plaintext values
+	// included. It reports whether value changed.
+	Migrate(refID uint, kind string, value string) (string, bool, error)
+}
+
+// Plaintext is the Backend keeping the secrets in plaintext, it is used if no other backend is configured
+var Plaintext Backend = (*Store)(nil)
+
+// IsSealed reports whether value is kept by a backend instead of being a plaintext secret
+func IsSealed(value string) bool {
+	return IsEncrypted(value) || strings.HasPrefix(value, VaultPrefix)
+}
+
+// Put encrypts value, the secrets of all users share the master keys
+func (s *Store) Put(refID uint, kind string, value string) (string, error) {
+	return s.Encrypt(value)
+}
+
+// Get decrypts value
+func (s *Store) Get(refID uint, value string) (string, error) {
+	return s.Decrypt(value)
+}
+
+// Delete does nothing, the encrypted secrets are only kept by the services
+func (s *Store) Delete(refID uint, value string) error {
+	return nil
+}
+
+// Migrate encrypts value with the current master key
+func (s *Store) Migrate(refID uint, kind string, value string) (string, bool, error) {
+	return s.Rotate(value)
+}
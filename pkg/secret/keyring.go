@@ -0,0 +1,58 @@
+package secret
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// Keyring is a KMS keeping the master keys itself, they are given in the configuration
+type Keyring struct {
+	keys    map[string][]byte
+	current string
+}
+
+// KeyID returns the id of the current master key
+func (k *Keyring) KeyID() string {
+	return k.current
+}
+
+// Encrypt encrypts key with the current master key
+func (k *Keyring) Encrypt(key []byte) ([]byte, error) {
+	return seal(k.keys[k.current], key)
+}
+
+// Decrypt decrypts a data key encrypted with the master key keyID
+func (k *Keyring) Decrypt(keyID string, ciphertext []byte) ([]byte, error) {
+	master, ok := k.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return open(master, ciphertext)
+}
+
+// NewKeyring returns a Keyring of the base64 encoded master keys by their id, new data keys are encrypted
+// with the key current. Every key has to be DataKeySize bytes long, e.g. generated with openssl rand -base64 32.
+func NewKeyring(keys map[string]string, current string) (*Keyring, error) {
+	k := &Keyring{
+		keys:    make(map[string][]byte),
+		current: current,
+	}
+
+	for id, encoded := range keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("master key %s: %v", id, err)
+		}
+		if len(key) != DataKeySize {
+			return nil, fmt.Errorf("master key %s has %d bytes instead of %d", id, len(key), DataKeySize)
+		}
+		k.keys[id] = key
+	}
+
+	if current != "" {
+		if _, ok := k.keys[current]; !ok {
+			return nil, fmt.Errorf("the current master key %s is unknown", current)
+		}
+	}
+	return k, nil
+}
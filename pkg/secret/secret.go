@@ -0,0 +1,270 @@
+// Package secret keeps the secrets of the services, like the secrets of webhooks. The built-in Store encrypts them
+// for the database with envelope encryption: every secret is encrypted with its own AES-256-GCM data key, which is
+// encrypted with a master key of a KMS. The Vault backend keeps them in HashiCorp Vault instead.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Prefix starts every encrypted secret, values without it are plaintext kept from before the encryption was enabled
+const Prefix = "kroo:v1:"
+
+// DataKeySize is the size in bytes of the data keys and the master keys
+const DataKeySize = 32
+
+const (
+	// Local encrypts the data keys with the master keys of a Keyring
+	Local = "local"
+	// AWS encrypts the data keys with a key of AWS KMS
+	AWS = "aws"
+)
+
+var (
+	// ErrMalformed is returned if an encrypted secret can not be parsed
+	ErrMalformed = errors.New("malformed secret")
+
+	// ErrUnknownKey is returned if a secret was encrypted with a master key the KMS does not have
+	ErrUnknownKey = errors.New("unknown master key")
+
+	// ErrNotFound is returned if the secret a value stands in for does not exist in its backend anymore
+	ErrNotFound = errors.New("the secret does not exist")
+
+	// ErrNoKMS is returned if an encrypted secret should be decrypted without a KMS
+	ErrNoKMS = errors.New("the secret is encrypted but no master key is configured")
+)
+
+// KMS encrypts the data keys of the secrets with its master keys
+type KMS interface {
+	// KeyID returns the id of the master key new data keys are encrypted with
+	KeyID() string
+
+	// Encrypt encrypts a data key with the master key KeyID
+	Encrypt(key []byte) ([]byte, error)
+
+	// Decrypt decrypts a data key encrypted with the master key keyID, it returns ErrUnknownKey
+	// if it does not have the key
+	Decrypt(keyID string, ciphertext []byte) ([]byte, error)
+}
+
+// Store encrypts and decrypts secrets. A nil Store keeps the secrets in plaintext.
+type Store struct {
+	kms      KMS
+	previous []KMS
+}
+
+// IsEncrypted reports whether value is an encrypted secret
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, Prefix)
+}
+
+// seal encrypts plaintext with key using AES-256-GCM, the nonce is prepended to the ciphertext
+func seal(key []byte, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a ciphertext of seal
+func open(key []byte, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, ErrMalformed
+	}
+	return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+}
+
+var encoding = base64.RawURLEncoding
+
+// parse splits an encrypted secret into the id of its master key, its encrypted data key and its ciphertext
+func parse(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, Prefix), ".")
+	if !IsEncrypted(value) || len(parts) != 3 {
+		return "", nil, nil, ErrMalformed
+	}
+
+	decoded := make([][]byte, len(parts))
+	for i, p := range parts {
+		b, err := encoding.DecodeString(p)
+		if err != nil {
+			return "", nil, nil, ErrMalformed
+		}
+		decoded[i] = b
+	}
+	return string(decoded[0]), decoded[1], decoded[2], nil
+}
+
+// KeyID returns the id of the master key value was encrypted with, it is empty for plaintext values
+func KeyID(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", nil
+	}
+
+	id, _, _, err := parse(value)
+	return id, err
+}
+
+// Encrypt encrypts plaintext with a new data key, which is encrypted with the current master key
+func (s *Store) Encrypt(plaintext string) (string, error) {
+	if s == nil {
+		return plaintext, nil
+	}
+
+	key := make([]byte, DataKeySize)
+	_, err := io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(key, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	encryptedKey, err := s.kms.Encrypt(key)
+	if err != nil {
+		return "", err
+	}
+
+	return Prefix + strings.Join([]string{
+		encoding.EncodeToString([]byte(s.kms.KeyID())),
+		encoding.EncodeToString(encryptedKey),
+		encoding.EncodeToString(ciphertext),
+	}, "."), nil
+}
+
+// Decrypt returns the plaintext of value, plaintext values are returned as they are
+func (s *Store) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if s == nil {
+		return "", ErrNoKMS
+	}
+
+	keyID, encryptedKey, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	// the previous KMS keep the master keys secrets were encrypted with before the KMS was changed
+	var key []byte
+	err = ErrUnknownKey
+	for _, kms := range append(s.previous, s.kms) {
+		key, err = kms.Decrypt(keyID, encryptedKey)
+		if err != ErrUnknownKey {
+			break
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(key, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// Rotate encrypts value with the current master key unless it is already, plaintext values are encrypted.
+// It reports whether value was encrypted again.
+func (s *Store) Rotate(value string) (string, bool, error) {
+	if s == nil {
+		return value, false, nil
+	}
+
+	keyID, err := KeyID(value)
+	if err != nil {
+		return "", false, err
+	}
+	if IsEncrypted(value) && keyID == s.kms.KeyID() {
+		return value, false, nil
+	}
+
+	plaintext, err := s.Decrypt(value)
+	if err != nil {
+		return "", false, err
+	}
+
+	encrypted, err := s.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return encrypted, true, nil
+}
+
+// NewStore returns a Store encrypting the data keys of new secrets with kms. The previous KMS are only used
+// to decrypt the secrets encrypted before kms replaced them, until they were rotated.
+func NewStore(kms KMS, previous ...KMS) *Store {
+	return &Store{
+		kms:      kms,
+		previous: previous,
+	}
+}
+
+// Options configure the store of an installation
+type Options struct {
+	// KMS is Local or AWS
+	KMS string
+
+	// Keys are the base64 encoded master keys of the Keyring by their id, Current is the one of new secrets.
+	// With AWS they only decrypt the secrets encrypted before AWS KMS was used.
+	Keys    map[string]string
+	Current string
+
+	// AWS configure the key of the AWS KMS
+	AWS AWSOptions
+}
+
+// New returns the Store of the KMS configured in o
+func New(o Options) (*Store, error) {
+	switch o.KMS {
+	case Local:
+		k, err := NewKeyring(o.Keys, o.Current)
+		if err != nil {
+			return nil, err
+		}
+		if o.Current == "" {
+			return nil, errors.New("the current master key is required")
+		}
+		return NewStore(k), nil
+	case AWS:
+		k, err := NewKeyring(o.Keys, "")
+		if err != nil {
+			return nil, err
+		}
+		if o.AWS.Endpoint == "" {
+			o.AWS.Endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", o.AWS.Region)
+		}
+		return NewStore(NewAWSKMS(o.AWS, nil), k), nil
+	}
+	return nil, fmt.Errorf("unknown kms %q", o.KMS)
+}
@@ -0,0 +1,363 @@
+package secret_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func key(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), secret.DataKeySize)))
+}
+
+func keyring(keys map[string]string, current string) *secret.Keyring {
+	k, err := secret.NewKeyring(keys, current)
+	Expect(err).NotTo(HaveOccurred())
+	return k
+}
+
+var _ = Describe("Secret", func() {
+	Describe("NewKeyring", func() {
+		It("Should validate the master keys", func() {
+			_, err := secret.NewKeyring(map[string]string{"a": key('a')}, "a")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = secret.NewKeyring(map[string]string{"a": base64.StdEncoding.EncodeToString([]byte("short"))}, "a")
+			Expect(err).To(MatchError("master key a has 5 bytes instead of 32"))
+
+			_, err = secret.NewKeyring(map[string]string{"a": "%"}, "a")
+			Expect(err).To(HaveOccurred())
+
+			_, err = secret.NewKeyring(map[string]string{"a": key('a')}, "b")
+			Expect(err).To(MatchError("the current master key b is unknown"))
+		})
+	})
+
+	Describe("Store", func() {
+		var s *secret.Store
+
+		BeforeEach(func() {
+			s = secret.NewStore(keyring(map[string]string{"a": key('a')}, "a"))
+		})
+
+		It("Should encrypt and decrypt secrets", func() {
+			v, err := s.Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.IsEncrypted(v)).To(BeTrue())
+			Expect(v).NotTo(ContainSubstring("hunter2"))
+			Expect(secret.KeyID(v)).To(Equal("a"))
+
+			other, err := s.Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other).NotTo(Equal(v))
+
+			Expect(s.Decrypt(v)).To(Equal("hunter2"))
+		})
+
+		It("Should return plaintext values as they are", func() {
+			Expect(s.Decrypt("hunter2")).To(Equal("hunter2"))
+		})
+
+		It("Should reject malformed and tampered secrets", func() {
+			_, err := s.Decrypt(secret.Prefix + "a.b")
+			Expect(err).To(Equal(secret.ErrMalformed))
+
+			v, err := s.Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			parts := strings.Split(v, ".")
+			parts[2] = base64.RawURLEncoding.EncodeToString([]byte("tampered ciphertext"))
+			_, err = s.Decrypt(strings.Join(parts, "."))
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should keep secrets in plaintext without a store", func() {
+			var plain *secret.Store
+			Expect(plain.Encrypt("hunter2")).To(Equal("hunter2"))
+			Expect(plain.Decrypt("hunter2")).To(Equal("hunter2"))
+
+			v, err := s.Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = plain.Decrypt(v)
+			Expect(err).To(Equal(secret.ErrNoKMS))
+		})
+
+		It("Should rotate secrets to the current master key", func() {
+			old, err := s.Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+
+			s = secret.NewStore(keyring(map[string]string{"a": key('a'), "b": key('b')}, "b"))
+			Expect(s.Decrypt(old)).To(Equal("hunter2"))
+
+			rotated, changed, err := s.Rotate(old)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(secret.KeyID(rotated)).To(Equal("b"))
+			Expect(s.Decrypt(rotated)).To(Equal("hunter2"))
+
+			again, changed, err := s.Rotate(rotated)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(again).To(Equal(rotated))
+
+			encrypted, changed, err := s.Rotate("plaintext")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(s.Decrypt(encrypted)).To(Equal("plaintext"))
+
+			s = secret.NewStore(keyring(map[string]string{"b": key('b')}, "b"))
+			_, err = s.Decrypt(old)
+			Expect(err).To(Equal(secret.ErrUnknownKey))
+		})
+
+		It("Should decrypt with the previous KMS", func() {
+			local := keyring(map[string]string{"a": key('a')}, "a")
+			old, err := secret.NewStore(local).Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+
+			s = secret.NewStore(keyring(map[string]string{"b": key('b')}, "b"), local)
+			Expect(s.Decrypt(old)).To(Equal("hunter2"))
+		})
+	})
+
+	Describe("New", func() {
+		It("Should return the store of the configured KMS", func() {
+			s, err := secret.New(secret.Options{
+				KMS:     secret.Local,
+				Keys:    map[string]string{"a": key('a')},
+				Current: "a",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			v, err := s.Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.KeyID(v)).To(Equal("a"))
+
+			_, err = secret.New(secret.Options{KMS: secret.Local, Keys: map[string]string{"a": key('a')}})
+			Expect(err).To(MatchError("the current master key is required"))
+
+			_, err = secret.New(secret.Options{KMS: "vault"})
+			Expect(err).To(MatchError(`unknown kms "vault"`))
+		})
+	})
+
+	Describe("AWS KMS", func() {
+		var (
+			server *httptest.Server
+			calls  []string
+		)
+
+		BeforeEach(func() {
+			calls = []string{}
+			// the fake KMS "encrypts" by prefixing the data key with the id of the master key
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(r.Header.Get("Content-Type")).To(Equal("application/x-amz-json-1.1"))
+				Expect(r.Header.Get("Authorization")).To(HavePrefix("AWS4-HMAC-SHA256 Credential=access/"))
+				Expect(r.Header.Get("Authorization")).To(ContainSubstring("/eu-central-1/kms/aws4_request"))
+
+				body, _ := ioutil.ReadAll(r.Body)
+				in := map[string]interface{}{}
+				Expect(json.Unmarshal(body, &in)).To(Succeed())
+				target := r.Header.Get("X-Amz-Target")
+				calls = append(calls, target)
+
+				switch target {
+				case "TrentService.Encrypt":
+					plaintext, _ := base64.StdEncoding.DecodeString(in["Plaintext"].(string))
+					json.NewEncoder(w).Encode(map[string][]byte{
+						"CiphertextBlob": append([]byte(in["KeyId"].(string)+":"), plaintext...),
+					})
+				case "TrentService.Decrypt":
+					blob, _ := base64.StdEncoding.DecodeString(in["CiphertextBlob"].(string))
+					prefix := in["KeyId"].(string) + ":"
+					if !strings.HasPrefix(string(blob), prefix) {
+						w.WriteHeader(http.StatusBadRequest)
+						w.Write([]byte(`{"__type": "NotFoundException", "message": "key not found"}`))
+						return
+					}
+					json.NewEncoder(w).Encode(map[string][]byte{
+						"Plaintext": blob[len(prefix):],
+					})
+				}
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Should encrypt the data keys with the key of the KMS", func() {
+			kms := secret.NewAWSKMS(secret.AWSOptions{
+				Endpoint:  server.URL,
+				Region:    "eu-central-1",
+				AccessKey: "access",
+				SecretKey: "secret",
+				KeyID:     "alias/kroo",
+			}, nil)
+			s := secret.NewStore(kms)
+
+			v, err := s.Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(secret.KeyID(v)).To(Equal("alias/kroo"))
+			Expect(s.Decrypt(v)).To(Equal("hunter2"))
+			Expect(calls).To(Equal([]string{"TrentService.Encrypt", "TrentService.Decrypt"}))
+
+			_, err = kms.Decrypt("alias/other", []byte("alias/kroo:key"))
+			Expect(err).To(Equal(secret.ErrUnknownKey))
+		})
+	})
+
+	Describe("Vault", func() {
+		var (
+			server *httptest.Server
+			kv     map[string]string
+			token  string
+			logins int
+		)
+
+		BeforeEach(func() {
+			kv = map[string]string{}
+			token = ""
+			logins = 0
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+
+				if r.URL.Path == "/v1/auth/approle/login" {
+					in := map[string]string{}
+					Expect(json.NewDecoder(r.Body).Decode(&in)).To(Succeed())
+					Expect(in).To(Equal(map[string]string{"role_id": "role", "secret_id": "id"}))
+					logins++
+					token = fmt.Sprintf("token-%d", logins)
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600},
+					})
+					return
+				}
+				if r.Header.Get("X-Vault-Token") != token {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+
+				p := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+				switch {
+				case r.Method == "POST" && strings.HasPrefix(p, "data/"):
+					in := map[string]map[string]string{}
+					Expect(json.NewDecoder(r.Body).Decode(&in)).To(Succeed())
+					kv[strings.TrimPrefix(p, "data/")] = in["data"]["value"]
+				case r.Method == "GET" && strings.HasPrefix(p, "data/"):
+					v, ok := kv[strings.TrimPrefix(p, "data/")]
+					if !ok {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+					json.NewEncoder(w).Encode(map[string]interface{}{
+						"data": map[string]interface{}{"data": map[string]string{"value": v}},
+					})
+				case r.Method == "DELETE" && strings.HasPrefix(p, "metadata/"):
+					delete(kv, strings.TrimPrefix(p, "metadata/"))
+					w.WriteHeader(http.StatusNoContent)
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+				}
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Should keep the secrets of every user in their path", func() {
+			v := secret.NewVault(secret.VaultOptions{
+				Address:  server.URL,
+				Prefix:   "kroo",
+				RoleID:   "role",
+				SecretID: "id",
+			}, nil, nil)
+
+			ref, err := v.Put(3, "webhooks", "hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ref).To(HavePrefix(secret.VaultPrefix + "kroo/users/3/webhooks/"))
+			Expect(secret.IsSealed(ref)).To(BeTrue())
+			Expect(kv).To(HaveLen(1))
+
+			Expect(v.Get(3, ref)).To(Equal("hunter2"))
+			Expect(v.Get(3, "plaintext")).To(Equal("plaintext"))
+
+			_, err = v.Get(4, ref)
+			Expect(err).To(Equal(secret.ErrForeign))
+			Expect(v.Delete(4, ref)).To(Equal(secret.ErrForeign))
+			_, err = v.Get(3, secret.VaultPrefix+"kroo/users/3/../4/webhooks/a")
+			Expect(err).To(Equal(secret.ErrMalformed))
+
+			Expect(v.Delete(3, ref)).To(Succeed())
+			Expect(kv).To(BeEmpty())
+			_, err = v.Get(3, ref)
+			Expect(err).To(Equal(secret.ErrNotFound))
+			Expect(logins).To(Equal(1))
+		})
+
+		It("Should log in again once the token was revoked", func() {
+			v := secret.NewVault(secret.VaultOptions{
+				Address:  server.URL,
+				RoleID:   "role",
+				SecretID: "id",
+			}, nil, nil)
+
+			_, err := v.Put(3, "webhooks", "hunter2")
+			Expect(err).NotTo(HaveOccurred())
+
+			token = "revoked"
+			ref, err := v.Put(3, "webhooks", "hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v.Get(3, ref)).To(Equal("hunter2"))
+			Expect(logins).To(Equal(2))
+		})
+
+		It("Should not log in with a token", func() {
+			token = "static"
+			v := secret.NewVault(secret.VaultOptions{
+				Address: server.URL,
+				Token:   "static",
+			}, nil, nil)
+
+			_, err := v.Put(3, "webhooks", "hunter2")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(logins).To(Equal(0))
+
+			token = "other"
+			_, err = v.Put(3, "webhooks", "hunter2")
+			Expect(err).To(Equal(secret.ErrVaultDenied))
+		})
+
+		It("Should migrate the secrets of the previous store", func() {
+			token = "static"
+			store := secret.NewStore(keyring(map[string]string{"a": key('a')}, "a"))
+			old, err := store.Encrypt("hunter2")
+			Expect(err).NotTo(HaveOccurred())
+
+			v := secret.NewVault(secret.VaultOptions{
+				Address: server.URL,
+				Token:   "static",
+			}, store, nil)
+			Expect(v.Get(3, old)).To(Equal("hunter2"))
+
+			ref, changed, err := v.Migrate(3, "webhooks", old)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(v.Get(3, ref)).To(Equal("hunter2"))
+
+			again, changed, err := v.Migrate(3, "webhooks", ref)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(again).To(Equal(ref))
+		})
+	})
+})
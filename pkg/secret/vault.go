@@ -0,0 +1,301 @@
+package secret
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultPrefix starts the values standing in for the secrets kept in Vault, it is followed by their path in the mount
+const VaultPrefix = "vault:"
+
+var (
+	// ErrVaultDenied is returned if Vault denies an operation with the token of the backend
+	ErrVaultDenied = errors.New("permission denied by vault")
+
+	// ErrForeign is returned if a value stands in for a secret of another user
+	ErrForeign = errors.New("the secret belongs to another user")
+)
+
+// VaultOptions configure the KV version 2 secrets engine of HashiCorp Vault the secrets are kept in
+type VaultOptions struct {
+	// Address is the URL of Vault, e.g. https://vault:8200
+	Address string
+	// Namespace is the Vault Enterprise namespace of the mount, it is empty without namespaces
+	Namespace string
+	// Mount is the path of the secrets engine, the secrets of user 3 are kept at <Mount>/data/<Prefix>/users/3/
+	Mount  string
+	Prefix string
+
+	// Token authenticates the backend, otherwise it logs in with the AppRole RoleID and SecretID at AppRole,
+	// the path of the AppRole auth method
+	Token    string
+	RoleID   string
+	SecretID string
+	AppRole  string
+}
+
+type vault struct {
+	options  VaultOptions
+	previous *Store
+	client   *http.Client
+	now      func() time.Time
+
+	mtx     *sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// vaultError is the body of the responses of failed requests
+type vaultError struct {
+	Errors []string `json:"errors"`
+}
+
+// login returns the token of the backend, AppRole tokens are renewed by logging in again shortly before they expire
+func (v *vault) login() (string, error) {
+	if v.options.Token != "" {
+		return v.options.Token, nil
+	}
+
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+	if v.token != "" && (v.expires.IsZero() || v.now().Before(v.expires)) {
+		return v.token, nil
+	}
+
+	out := struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+		} `json:"auth"`
+	}{}
+	err := v.do("POST", path.Join("auth", v.options.AppRole, "login"), "", map[string]string{
+		"role_id":   v.options.RoleID,
+		"secret_id": v.options.SecretID,
+	}, &out)
+	if err != nil {
+		return "", fmt.Errorf("vault approle login: %v", err)
+	}
+
+	v.token = out.Auth.ClientToken
+	v.expires = time.Time{}
+	if out.Auth.LeaseDuration > 0 {
+		// the token is renewed once 90% of its lease passed
+		v.expires = v.now().Add(time.Duration(out.Auth.LeaseDuration) * time.Second * 9 / 10)
+	}
+	return v.token, nil
+}
+
+// forget drops an AppRole token Vault denied, e.g. because it was revoked, so the next call logs in again
+func (v *vault) forget(token string) {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+	if v.token == token {
+		v.token = ""
+	}
+}
+
+// do sends the JSON encoded in to the path of the Vault API and decodes the response into out if it is not nil
+func (v *vault) do(method string, p string, token string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(v.options.Address, "/")+"/v1/"+p, body)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if v.options.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.options.Namespace)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	res, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusForbidden:
+		return ErrVaultDenied
+	case res.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case res.StatusCode >= 300:
+		e := vaultError{}
+		json.Unmarshal(b, &e)
+		return fmt.Errorf("vault %s %s failed with status %d: %s", method, p, res.StatusCode, strings.Join(e.Errors, ", "))
+	}
+
+	if out == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
+
+// call sends a request with the token of the backend, a denied AppRole token is replaced once
+func (v *vault) call(method string, p string, in interface{}, out interface{}) error {
+	token, err := v.login()
+	if err != nil {
+		return err
+	}
+
+	err = v.do(method, p, token, in, out)
+	if err == ErrVaultDenied && v.options.Token == "" {
+		v.forget(token)
+		token, err = v.login()
+		if err != nil {
+			return err
+		}
+		err = v.do(method, p, token, in, out)
+	}
+	return err
+}
+
+// userPath returns the path the secrets of the user refID are kept in
+func (v *vault) userPath(refID uint) string {
+	return path.Join(v.options.Prefix, "users", fmt.Sprintf("%d", refID))
+}
+
+// secretPath returns the path of the secret of a value returned by Put for the user refID
+func (v *vault) secretPath(refID uint, value string) (string, error) {
+	p := strings.TrimPrefix(value, VaultPrefix)
+	if p == "" || p == value || path.Clean("/"+p) != "/"+p {
+		return "", ErrMalformed
+	}
+	if !strings.HasPrefix(p, v.userPath(refID)+"/") {
+		return "", ErrForeign
+	}
+	return p, nil
+}
+
+func (v *vault) Put(refID uint, kind string, value string) (string, error) {
+	id := make([]byte, 16)
+	_, err := io.ReadFull(rand.Reader, id)
+	if err != nil {
+		return "", err
+	}
+
+	p := path.Join(v.userPath(refID), kind, hex.EncodeToString(id))
+	err = v.call("POST", path.Join(v.options.Mount, "data", p), map[string]interface{}{
+		"data": map[string]string{
+			"value": value,
+		},
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return VaultPrefix + p, nil
+}
+
+func (v *vault) Get(refID uint, value string) (string, error) {
+	if !strings.HasPrefix(value, VaultPrefix) {
+		return v.previous.Decrypt(value)
+	}
+
+	p, err := v.secretPath(refID, value)
+	if err != nil {
+		return "", err
+	}
+
+	out := struct {
+		Data struct {
+			Data struct {
+				Value *string `json:"value"`
+			} `json:"data"`
+		} `json:"data"`
+	}{}
+	err = v.call("GET", path.Join(v.options.Mount, "data", p), nil, &out)
+	if err != nil {
+		return "", err
+	}
+	if out.Data.Data.Value == nil {
+		return "", ErrNotFound
+	}
+	return *out.Data.Data.Value, nil
+}
+
+func (v *vault) Delete(refID uint, value string) error {
+	if !strings.HasPrefix(value, VaultPrefix) {
+		return nil
+	}
+
+	p, err := v.secretPath(refID, value)
+	if err != nil {
+		return err
+	}
+
+	// the metadata is removed with every version of the secret
+	err = v.call("DELETE", path.Join(v.options.Mount, "metadata", p), nil, nil)
+	if err == ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+func (v *vault) Migrate(refID uint, kind string, value string) (string, bool, error) {
+	if strings.HasPrefix(value, VaultPrefix) {
+		return value, false, nil
+	}
+
+	plaintext, err := v.previous.Decrypt(value)
+	if err != nil {
+		return "", false, err
+	}
+
+	p, err := v.Put(refID, kind, plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return p, true, nil
+}
+
+// NewVault returns a Backend keeping the secrets in Vault, every user has its own path. previous decrypts the
+// secrets encrypted before Vault was used, until they were migrated, it may be nil if there are none. Mount
+// defaults to secret, AppRole to approle and client to one with a timeout of 10 seconds.
+func NewVault(o VaultOptions, previous *Store, client *http.Client) Backend {
+	if o.Mount == "" {
+		o.Mount = "secret"
+	}
+	if o.AppRole == "" {
+		o.AppRole = "approle"
+	}
+	if client == nil {
+		client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+
+	return &vault{
+		options:  o,
+		previous: previous,
+		client:   client,
+		now:      time.Now,
+		mtx:      &sync.Mutex{},
+	}
+}
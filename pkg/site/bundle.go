@@ -0,0 +1,83 @@
+package site
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrInvalidBundle occurs if a bundle is no gzip compressed tar archive or contains files outside of the site
+	ErrInvalidBundle = errors.New("bundle is no gzip compressed tar archive of the site")
+
+	// ErrBundleTooLarge occurs if a bundle or the files it contains exceed the maximum size
+	ErrBundleTooLarge = errors.New("bundle is too large")
+)
+
+// extract unpacks the gzip compressed tar archive r to dir. Only regular files and directories are extracted,
+// links and devices are skipped, and the files may take max bytes in total.
+func extract(r io.Reader, dir string, max int64) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return ErrInvalidBundle
+	}
+	defer gz.Close()
+
+	written := int64(0)
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return ErrInvalidBundle
+		}
+
+		for _, e := range strings.Split(h.Name, "/") {
+			if e == ".." {
+				return ErrInvalidBundle
+			}
+		}
+
+		// the names are relative to the root of the site, archives of ./ are common
+		name := path.Clean("/" + h.Name)
+		if name == "/" {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch h.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+			if err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			written += h.Size
+			if written > max {
+				return ErrBundleTooLarge
+			}
+
+			err = os.MkdirAll(filepath.Dir(target), 0755)
+			if err != nil {
+				return err
+			}
+
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.CopyN(f, tr, h.Size)
+			f.Close()
+			if err != nil {
+				return ErrInvalidBundle
+			}
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/site"
+	"github.com/kontainerooo/kontainer.ooo/pkg/site/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *site.Endpoints {
+
+	var CreateSiteEndpoint endpoint.Endpoint
+	{
+		CreateSiteEndpoint = grpctransport.NewClient(
+			conn,
+			"site.SiteService",
+			"CreateSite",
+			EncodeGRPCCreateSiteRequest,
+			DecodeGRPCCreateSiteResponse,
+			pb.CreateSiteResponse{},
+		).Endpoint()
+	}
+
+	var EditSiteEndpoint endpoint.Endpoint
+	{
+		EditSiteEndpoint = grpctransport.NewClient(
+			conn,
+			"site.SiteService",
+			"EditSite",
+			EncodeGRPCEditSiteRequest,
+			DecodeGRPCEditSiteResponse,
+			pb.EditSiteResponse{},
+		).Endpoint()
+	}
+
+	var GetSiteEndpoint endpoint.Endpoint
+	{
+		GetSiteEndpoint = grpctransport.NewClient(
+			conn,
+			"site.SiteService",
+			"GetSite",
+			EncodeGRPCGetSiteRequest,
+			DecodeGRPCGetSiteResponse,
+			pb.GetSiteResponse{},
+		).Endpoint()
+	}
+
+	var SitesEndpoint endpoint.Endpoint
+	{
+		SitesEndpoint = grpctransport.NewClient(
+			conn,
+			"site.SiteService",
+			"Sites",
+			EncodeGRPCSitesRequest,
+			DecodeGRPCSitesResponse,
+			pb.SitesResponse{},
+		).Endpoint()
+	}
+
+	var RemoveSiteEndpoint endpoint.Endpoint
+	{
+		RemoveSiteEndpoint = grpctransport.NewClient(
+			conn,
+			"site.SiteService",
+			"RemoveSite",
+			EncodeGRPCRemoveSiteRequest,
+			DecodeGRPCRemoveSiteResponse,
+			pb.RemoveSiteResponse{},
+		).Endpoint()
+	}
+
+	var DeployEndpoint endpoint.Endpoint
+	{
+		DeployEndpoint = grpctransport.NewClient(
+			conn,
+			"site.SiteService",
+			"Deploy",
+			EncodeGRPCDeployRequest,
+			DecodeGRPCDeployResponse,
+			pb.DeployResponse{},
+		).Endpoint()
+	}
+
+	return &site.Endpoints{
+		CreateSiteEndpoint: CreateSiteEndpoint,
+		EditSiteEndpoint:   EditSiteEndpoint,
+		GetSiteEndpoint:    GetSiteEndpoint,
+		SitesEndpoint:      SitesEndpoint,
+		RemoveSiteEndpoint: RemoveSiteEndpoint,
+		DeployEndpoint:     DeployEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreateSiteRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain create site request to a gRPC CreateSite request.
+func EncodeGRPCCreateSiteRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*site.CreateSiteRequest)
+	return &pb.CreateSiteRequest{
+		RefID:   uint32(req.RefID),
+		Name:    req.Name,
+		Domains: req.Domains,
+		MaxAge:  uint32(req.MaxAge),
+		Tls:     req.TLS,
+	}, nil
+}
+
+// DecodeGRPCCreateSiteResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateSite response to a messages/site.proto-domain create site response.
+func DecodeGRPCCreateSiteResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateSiteResponse)
+	return &site.CreateSiteResponse{
+		Site:  site.ConvertPBSite(response.Site),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCEditSiteRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain edit site request to a gRPC EditSite request.
+func EncodeGRPCEditSiteRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*site.EditSiteRequest)
+	return &pb.EditSiteRequest{
+		RefID:   uint32(req.RefID),
+		Name:    req.Name,
+		Domains: req.Domains,
+		MaxAge:  uint32(req.MaxAge),
+		Tls:     req.TLS,
+	}, nil
+}
+
+// DecodeGRPCEditSiteResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC EditSite response to a messages/site.proto-domain edit site response.
+func DecodeGRPCEditSiteResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.EditSiteResponse)
+	return &site.EditSiteResponse{
+		Site:  site.ConvertPBSite(response.Site),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCGetSiteRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain get site request to a gRPC GetSite request.
+func EncodeGRPCGetSiteRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*site.GetSiteRequest)
+	return &pb.GetSiteRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// DecodeGRPCGetSiteResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC GetSite response to a messages/site.proto-domain get site response.
+func DecodeGRPCGetSiteResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.GetSiteResponse)
+	return &site.GetSiteResponse{
+		Site:  site.ConvertPBSite(response.Site),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSitesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain sites request to a gRPC Sites request.
+func EncodeGRPCSitesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*site.SitesRequest)
+	return &pb.SitesRequest{
+		RefID: uint32(req.RefID),
+	}, nil
+}
+
+// DecodeGRPCSitesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Sites response to a messages/site.proto-domain sites response.
+func DecodeGRPCSitesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SitesResponse)
+	sites := make([]site.Site, len(response.Sites))
+	for i, s := range response.Sites {
+		sites[i] = site.ConvertPBSite(s)
+	}
+
+	return &site.SitesResponse{
+		Sites: sites,
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveSiteRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain remove site request to a gRPC RemoveSite request.
+func EncodeGRPCRemoveSiteRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*site.RemoveSiteRequest)
+	return &pb.RemoveSiteRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// DecodeGRPCRemoveSiteResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveSite response to a messages/site.proto-domain remove site response.
+func DecodeGRPCRemoveSiteResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveSiteResponse)
+	return &site.RemoveSiteResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCDeployRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain deploy request to a gRPC Deploy request.
+func EncodeGRPCDeployRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*site.DeployRequest)
+	return &pb.DeployRequest{
+		RefID:  uint32(req.RefID),
+		Name:   req.Name,
+		Bundle: req.Bundle,
+	}, nil
+}
+
+// DecodeGRPCDeployResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Deploy response to a messages/site.proto-domain deploy response.
+func DecodeGRPCDeployResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DeployResponse)
+	return &site.DeployResponse{
+		Version: uint(response.Version),
+		Error:   getError(response.Error),
+	}, nil
+}
@@ -0,0 +1,30 @@
+package site
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Site is a static site of a user, its files are served by the router without a container
+type Site struct {
+	ID      uint `gorm:"primary_key"`
+	RefID   uint
+	Name    string
+	Domains pq.StringArray `sql:"type:text[]"`
+	// MaxAge is the number of seconds browsers may cache the assets of the site, html documents are revalidated
+	MaxAge uint
+	// TLS serves the site over https with a certificate obtained for its domains
+	TLS bool
+	// Version is the number of the deployed bundle, it is 0 until a bundle was deployed
+	Version uint
+	// Size is the number of bytes of the deployed bundle
+	Size       int64
+	DeployedAt time.Time
+	CreatedAt  time.Time
+}
+
+// TableName sets Site's database table name
+func (Site) TableName() string {
+	return "sites"
+}
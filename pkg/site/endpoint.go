@@ -0,0 +1,181 @@
+package site
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Endpoints is a struct which collects all endpoints for the site service
+type Endpoints struct {
+	CreateSiteEndpoint endpoint.Endpoint
+	EditSiteEndpoint   endpoint.Endpoint
+	GetSiteEndpoint    endpoint.Endpoint
+	SitesEndpoint      endpoint.Endpoint
+	RemoveSiteEndpoint endpoint.Endpoint
+	DeployEndpoint     endpoint.Endpoint
+}
+
+// CreateSiteRequest is the request struct for the CreateSiteEndpoint
+type CreateSiteRequest struct {
+	RefID   uint     `bart:"ref"`
+	Name    string   `validate:"required"`
+	Domains []string `validate:"required"`
+	MaxAge  uint
+	TLS     bool
+}
+
+// CreateSiteResponse is the response struct for the CreateSiteEndpoint
+type CreateSiteResponse struct {
+	Site  Site
+	Error error
+}
+
+// MakeCreateSiteEndpoint creates a gokit endpoint which invokes CreateSite
+func MakeCreateSiteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateSiteRequest)
+		site := &Site{
+			RefID:   req.RefID,
+			Name:    req.Name,
+			Domains: req.Domains,
+			MaxAge:  req.MaxAge,
+			TLS:     req.TLS,
+		}
+		err := s.CreateSite(site)
+		return CreateSiteResponse{
+			Site:  *site,
+			Error: err,
+		}, nil
+	}
+}
+
+// EditSiteRequest is the request struct for the EditSiteEndpoint
+type EditSiteRequest struct {
+	RefID   uint     `bart:"ref"`
+	Name    string   `validate:"required"`
+	Domains []string `validate:"required"`
+	MaxAge  uint
+	TLS     bool
+}
+
+// EditSiteResponse is the response struct for the EditSiteEndpoint
+type EditSiteResponse struct {
+	Site  Site
+	Error error
+}
+
+// MakeEditSiteEndpoint creates a gokit endpoint which invokes EditSite
+func MakeEditSiteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(EditSiteRequest)
+		site := &Site{
+			Domains: req.Domains,
+			MaxAge:  req.MaxAge,
+			TLS:     req.TLS,
+		}
+		err := s.EditSite(req.RefID, req.Name, site)
+		return EditSiteResponse{
+			Site:  *site,
+			Error: err,
+		}, nil
+	}
+}
+
+// GetSiteRequest is the request struct for the GetSiteEndpoint
+type GetSiteRequest struct {
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required"`
+}
+
+// GetSiteResponse is the response struct for the GetSiteEndpoint
+type GetSiteResponse struct {
+	Site  Site
+	Error error
+}
+
+// MakeGetSiteEndpoint creates a gokit endpoint which invokes GetSite
+func MakeGetSiteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetSiteRequest)
+		site := Site{}
+		err := s.GetSite(req.RefID, req.Name, &site)
+		return GetSiteResponse{
+			Site:  site,
+			Error: err,
+		}, nil
+	}
+}
+
+// SitesRequest is the request struct for the SitesEndpoint
+type SitesRequest struct {
+	RefID uint `bart:"ref"`
+}
+
+// SitesResponse is the response struct for the SitesEndpoint
+type SitesResponse struct {
+	Sites []Site
+	Error error
+}
+
+// MakeSitesEndpoint creates a gokit endpoint which invokes Sites
+func MakeSitesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SitesRequest)
+		sites := []Site{}
+		err := s.Sites(req.RefID, &sites)
+		return SitesResponse{
+			Sites: sites,
+			Error: err,
+		}, nil
+	}
+}
+
+// RemoveSiteRequest is the request struct for the RemoveSiteEndpoint
+type RemoveSiteRequest struct {
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required"`
+}
+
+// RemoveSiteResponse is the response struct for the RemoveSiteEndpoint
+type RemoveSiteResponse struct {
+	Error error
+}
+
+// MakeRemoveSiteEndpoint creates a gokit endpoint which invokes RemoveSite
+func MakeRemoveSiteEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveSiteRequest)
+		err := s.RemoveSite(req.RefID, req.Name)
+		return RemoveSiteResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// DeployRequest is the request struct for the DeployEndpoint
+type DeployRequest struct {
+	RefID uint   `bart:"ref"`
+	Name  string `validate:"required"`
+	// Bundle is a gzip compressed tar archive of the files of the site
+	Bundle []byte `validate:"required"`
+}
+
+// DeployResponse is the response struct for the DeployEndpoint
+type DeployResponse struct {
+	Version uint
+	Error   error
+}
+
+// MakeDeployEndpoint creates a gokit endpoint which invokes Deploy
+func MakeDeployEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DeployRequest)
+		version, err := s.Deploy(req.RefID, req.Name, bytes.NewReader(req.Bundle))
+		return DeployResponse{
+			Version: version,
+			Error:   err,
+		}, nil
+	}
+}
@@ -0,0 +1,34 @@
+package site
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// EventGroup is the group the site services of all nodes subscribe to events with, the sites are shared
+// by the nodes, so they are only removed once. The other nodes remove their files on their next sync.
+const EventGroup = "site"
+
+// Subscribe removes the sites of deleted users
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	deleted, err := bus.Subscribe(events.UserDeleted, EventGroup, func(e events.Event) error {
+		u := events.UserEvent{}
+		err := e.Decode(&u)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+
+		err = s.RemoveSites(u.ID)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", u.ID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []events.Subscription{deleted}, nil
+}
@@ -0,0 +1,721 @@
+// Package site hosts static sites without containers. The bundle of a site is kept in the artifact store,
+// every node extracts the deployed bundle below its site root and the router serves the files of the site
+// with cache headers under its domains, over https if the site has a certificate.
+package site
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+// currentDir is the link inside of the directory of a site to the directory of the deployed version
+const currentDir = "current"
+
+// assetLocation matches the files browsers may cache for the max age of a site, other files are revalidated
+const assetLocation = `~* \.(css|js|mjs|map|json|png|jpe?g|gif|svg|webp|avif|ico|woff2?|ttf|otf|eot|mp3|mp4|webm|pdf|txt)$`
+
+var (
+	// ErrInvalidName occurs if the name of a site is no lowercase name of letters, digits and dashes
+	ErrInvalidName = errors.New("invalid site name")
+
+	// ErrInvalidDomain occurs if a domain of a site is no valid host name
+	ErrInvalidDomain = errors.New("invalid domain")
+
+	// ErrNoDomain occurs if a site has no domain
+	ErrNoDomain = errors.New("site has no domain")
+
+	// ErrSiteExists occurs if a user has a site with the name already
+	ErrSiteExists = errors.New("site already exists")
+
+	// ErrSiteNotExist occurs if a user has no site with the name
+	ErrSiteNotExist = errors.New("site does not exist")
+
+	// ErrNoCertificates occurs if a site should be served over https, but no certificates are issued
+	ErrNoCertificates = errors.New("certificates are not enabled")
+
+	nameRegex   = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	domainRegex = regexp.MustCompile(`^(\*\.)?([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+)
+
+// Service SiteService
+type Service interface {
+	// CreateSite creates a site and the router configuration serving it, the site is empty until a bundle is deployed
+	CreateSite(s *Site) error
+
+	// EditSite replaces the domains, the max age and whether the site is served over https
+	EditSite(refID uint, name string, s *Site) error
+
+	// GetSite returns a site of a user
+	GetSite(refID uint, name string, s *Site) error
+
+	// Sites returns the sites of a user
+	Sites(refID uint, s *[]Site) error
+
+	// RemoveSite removes a site with its router configuration, its certificate and its bundle
+	RemoveSite(refID uint, name string) error
+
+	// RemoveSites removes every site of a user
+	RemoveSites(refID uint) error
+
+	// Deploy stores a gzip compressed tar archive of the files of a site as its next version and serves it,
+	// the previous version is removed once the new one is served
+	Deploy(refID uint, name string, bundle io.Reader) (uint, error)
+
+	// Sync extracts the deployed versions missing on the node and removes the files of removed sites and versions
+	Sync() error
+}
+
+// Certificates obtains the certificates of router configurations, e.g. the acme service
+type Certificates interface {
+	// Manage obtains a certificate for the server names of a configuration and enables it
+	Manage(refID uint, name string) error
+
+	// Unmanage removes the certificate of a configuration and disables it
+	Unmanage(refID uint, name string) error
+}
+
+// Options configure where the sites are extracted to and how they are served
+type Options struct {
+	// Root is the directory the bundles are extracted to, the router has to be able to read it
+	Root string
+	// Address is the address the router serves the sites on
+	Address abstraction.Inet
+	// MaxSize is the number of bytes a bundle and the files it contains may have
+	MaxSize int64
+	// MaxAge is the number of seconds assets may be cached if a site does not set it
+	MaxAge uint
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	routing routing.Service
+	store   storage.Store
+	certs   Certificates
+	opts    Options
+	mtx     *sync.Mutex
+}
+
+// ConfigName returns the name of the router configuration of a site
+func ConfigName(name string) string {
+	return "site-" + name
+}
+
+// bundleKey returns the key of a version of a site in the store
+func bundleKey(refID uint, name string, version uint) string {
+	return fmt.Sprintf("%d/%s/%d.tar.gz", refID, name, version)
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Site{})
+}
+
+// dir returns the directory of a site on the node
+func (s *service) dir(refID uint, name string) string {
+	return filepath.Join(s.opts.Root, strconv.FormatUint(uint64(refID), 10), name)
+}
+
+// sites returns the sites of a user or of every user if all is true, the rows are filtered again since the
+// conditions are not applied by every dbAdapter
+func (s *service) sites(refID uint, all bool) ([]Site, error) {
+	rows := []Site{}
+	var err error
+	if all {
+		err = s.db.Find(&rows)
+	} else {
+		err = s.db.Find(&rows, "ref_id = ?", refID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sites := []Site{}
+	for _, r := range rows {
+		if all || r.RefID == refID {
+			sites = append(sites, r)
+		}
+	}
+	return sites, nil
+}
+
+func (s *service) getSite(refID uint, name string) (Site, error) {
+	sites, err := s.sites(refID, false)
+	if err != nil {
+		return Site{}, err
+	}
+
+	for _, site := range sites {
+		if site.Name == name {
+			return site, nil
+		}
+	}
+	return Site{}, ErrSiteNotExist
+}
+
+// save replaces the row of a site, an update would not store settings which were turned off
+func (s *service) save(site *Site) error {
+	s.db.Begin()
+	err := s.db.Delete(&Site{ID: site.ID})
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+
+	err = s.db.Create(site)
+	if err != nil {
+		s.db.Rollback()
+		return err
+	}
+	s.db.Commit()
+	return nil
+}
+
+// check validates and normalizes the settings of a site
+func (s *service) check(site *Site) error {
+	if len(site.Domains) == 0 {
+		return ErrNoDomain
+	}
+
+	for i, d := range site.Domains {
+		d = strings.ToLower(strings.TrimSuffix(d, "."))
+		if !domainRegex.MatchString(d) {
+			return ErrInvalidDomain
+		}
+		site.Domains[i] = d
+	}
+
+	if site.MaxAge == 0 {
+		site.MaxAge = s.opts.MaxAge
+	}
+
+	if site.TLS && s.certs == nil {
+		return ErrNoCertificates
+	}
+	return nil
+}
+
+// routerConfig returns the router configuration of a site served over http
+func (s *service) routerConfig(site *Site) *routing.RouterConfig {
+	return &routing.RouterConfig{
+		RefID: site.RefID,
+		Name:  ConfigName(site.Name),
+		ListenStatement: &routing.ListenStatement{
+			IPAddress: s.opts.Address,
+			Port:      80,
+		},
+		ServerName: site.Domains,
+		RootPath:   filepath.Join(s.dir(site.RefID, site.Name), currentDir),
+		SSLSettings: routing.SSLSettings{
+			ACME: site.TLS,
+		},
+		LocationRules: routing.LocationRules{
+			&routing.LocationRule{
+				Location: assetLocation,
+				Rules: map[string][]string{
+					"try_files": []string{"$uri", "=404"},
+					"expires":   []string{fmt.Sprintf("%ds", site.MaxAge)},
+				},
+			},
+			&routing.LocationRule{
+				Location: "/",
+				Rules: map[string][]string{
+					"try_files": []string{"$uri", "$uri/", "$uri.html", "=404"},
+					"expires":   []string{"-1"},
+				},
+			},
+		},
+	}
+}
+
+// serve writes the router configuration of a site. A certificate is obtained over the plain http configuration
+// first if a site should be served over https and its stored configuration has none for its domains, the site
+// is served over https and plain http requests are redirected afterwards.
+func (s *service) serve(site *Site, create bool) error {
+	name := ConfigName(site.Name)
+	conf := s.routerConfig(site)
+	https := &routing.ListenStatement{
+		IPAddress: s.opts.Address,
+		Port:      443,
+		Keyword:   "ssl",
+		HTTP2:     true,
+	}
+
+	if create {
+		err := s.routing.CreateRouterConfig(conf)
+		if err != nil {
+			return err
+		}
+	} else {
+		stored := routing.RouterConfig{}
+		err := s.routing.GetRouterConfig(site.RefID, name, &stored)
+		if err != nil {
+			return err
+		}
+
+		if !site.TLS && stored.SSLSettings.ACME && s.certs != nil {
+			err = s.certs.Unmanage(site.RefID, name)
+			if err != nil {
+				return err
+			}
+		}
+
+		if site.TLS && stored.SSLSettings.Certificate != "" && sameDomains(stored.ServerName, site.Domains) {
+			conf.SSLSettings = stored.SSLSettings
+			conf.ListenStatement = https
+		}
+
+		err = s.routing.EditRouterConfig(site.RefID, name, conf)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !site.TLS {
+		return s.routing.SetHTTPSPolicy(site.RefID, name, nil)
+	}
+
+	if conf.ListenStatement != https {
+		err := s.certs.Manage(site.RefID, name)
+		if err != nil {
+			return err
+		}
+
+		err = s.routing.EditRouterConfig(site.RefID, name, &routing.RouterConfig{
+			ListenStatement: https,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.routing.SetHTTPSPolicy(site.RefID, name, &routing.HTTPSPolicy{
+		Redirect: &routing.HTTPSRedirect{
+			Port: 80,
+		},
+	})
+}
+
+// sameDomains checks whether a and b contain the same domains in the same order
+func sameDomains(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *service) CreateSite(site *Site) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.createSite(site)
+}
+
+func (s *service) createSite(site *Site) error {
+	if !nameRegex.MatchString(site.Name) {
+		return ErrInvalidName
+	}
+
+	err := s.check(site)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.getSite(site.RefID, site.Name)
+	if err == nil {
+		return ErrSiteExists
+	}
+	if err != ErrSiteNotExist {
+		return err
+	}
+
+	site.ID = 0
+	site.Version = 0
+	site.Size = 0
+
+	err = os.MkdirAll(s.dir(site.RefID, site.Name), 0755)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Create(site)
+	if err != nil {
+		return err
+	}
+
+	err = s.serve(site, true)
+	if err != nil {
+		s.db.Delete(&Site{ID: site.ID})
+		s.routing.RemoveRouterConfig(site.RefID, ConfigName(site.Name))
+		return err
+	}
+	return nil
+}
+
+func (s *service) EditSite(refID uint, name string, site *Site) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.editSite(refID, name, site)
+}
+
+func (s *service) editSite(refID uint, name string, site *Site) error {
+	stored, err := s.getSite(refID, name)
+	if err != nil {
+		return err
+	}
+
+	edited := stored
+	edited.Domains = site.Domains
+	edited.MaxAge = site.MaxAge
+	edited.TLS = site.TLS
+	err = s.check(&edited)
+	if err != nil {
+		return err
+	}
+
+	err = s.serve(&edited, false)
+	if err != nil {
+		return err
+	}
+
+	err = s.save(&edited)
+	if err != nil {
+		return err
+	}
+
+	*site = edited
+	return nil
+}
+
+func (s *service) GetSite(refID uint, name string, site *Site) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	stored, err := s.getSite(refID, name)
+	if err != nil {
+		return err
+	}
+
+	*site = stored
+	return nil
+}
+
+func (s *service) Sites(refID uint, sites *[]Site) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ss, err := s.sites(refID, false)
+	if err != nil {
+		return err
+	}
+
+	*sites = ss
+	return nil
+}
+
+func (s *service) RemoveSite(refID uint, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	site, err := s.getSite(refID, name)
+	if err != nil {
+		return err
+	}
+	return s.removeSite(site)
+}
+
+func (s *service) removeSite(site Site) error {
+	if site.TLS && s.certs != nil {
+		err := s.certs.Unmanage(site.RefID, ConfigName(site.Name))
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.routing.RemoveRouterConfig(site.RefID, ConfigName(site.Name))
+	if err != nil {
+		return err
+	}
+
+	if site.Version != 0 {
+		err = s.store.Delete(bundleKey(site.RefID, site.Name, site.Version))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.RemoveAll(s.dir(site.RefID, site.Name))
+	if err != nil {
+		return err
+	}
+
+	return s.db.Delete(&Site{ID: site.ID})
+}
+
+func (s *service) RemoveSites(refID uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	sites, err := s.sites(refID, false)
+	if err != nil {
+		return err
+	}
+
+	for _, site := range sites {
+		err = s.removeSite(site)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// swap points the current link of a site to the directory of version atomically
+func (s *service) swap(refID uint, name string, version uint) error {
+	link := filepath.Join(s.dir(refID, name), currentDir)
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+
+	err := os.Symlink(strconv.FormatUint(uint64(version), 10), tmp)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
+
+// unpack extracts the bundle of a version of a site, a partially extracted version is removed
+func (s *service) unpack(refID uint, name string, version uint, bundle io.Reader) error {
+	dir := filepath.Join(s.dir(refID, name), strconv.FormatUint(uint64(version), 10))
+	err := os.RemoveAll(dir)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	err = extract(bundle, dir, s.opts.MaxSize)
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+// prune removes the files of the versions of a site besides keep
+func (s *service) prune(refID uint, name string, keep uint) error {
+	entries, err := ioutil.ReadDir(s.dir(refID, name))
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		if e.Name() == currentDir || e.Name() == strconv.FormatUint(uint64(keep), 10) {
+			continue
+		}
+
+		err = os.RemoveAll(filepath.Join(s.dir(refID, name), e.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) Deploy(refID uint, name string, bundle io.Reader) (uint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.deploy(refID, name, bundle)
+}
+
+func (s *service) deploy(refID uint, name string, bundle io.Reader) (uint, error) {
+	site, err := s.getSite(refID, name)
+	if err != nil {
+		return 0, err
+	}
+
+	err = os.MkdirAll(s.dir(refID, name), 0755)
+	if err != nil {
+		return 0, err
+	}
+
+	// the bundle is buffered, it is extracted before it is stored, so invalid bundles are never stored
+	tmp, err := ioutil.TempFile(s.dir(refID, name), ".bundle")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(bundle, s.opts.MaxSize+1))
+	if err != nil {
+		return 0, err
+	}
+	if size > s.opts.MaxSize {
+		return 0, ErrBundleTooLarge
+	}
+
+	version := site.Version + 1
+	_, err = tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	err = s.unpack(refID, name, version, tmp)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tmp.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	err = s.store.Put(bundleKey(refID, name, version), tmp, size)
+	if err != nil {
+		return 0, err
+	}
+
+	previous := site.Version
+	site.Version = version
+	site.Size = size
+	site.DeployedAt = time.Now()
+	err = s.save(&site)
+	if err != nil {
+		s.store.Delete(bundleKey(refID, name, version))
+		return 0, err
+	}
+
+	err = s.swap(refID, name, version)
+	if err != nil {
+		return 0, err
+	}
+
+	if previous != 0 {
+		err = s.store.Delete(bundleKey(refID, name, previous))
+		if err != nil {
+			return 0, err
+		}
+	}
+	return version, s.prune(refID, name, version)
+}
+
+func (s *service) Sync() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.sync()
+}
+
+// fetch extracts the deployed version of a site from the store
+func (s *service) fetch(site Site) error {
+	r, err := s.store.Get(bundleKey(site.RefID, site.Name, site.Version))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return s.unpack(site.RefID, site.Name, site.Version, r)
+}
+
+func (s *service) sync() error {
+	sites, err := s.sites(0, true)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool)
+	for _, site := range sites {
+		dir := s.dir(site.RefID, site.Name)
+		known[dir] = true
+		if site.Version == 0 {
+			continue
+		}
+
+		version := filepath.Join(dir, strconv.FormatUint(uint64(site.Version), 10))
+		if _, err = os.Stat(version); os.IsNotExist(err) {
+			err = s.fetch(site)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = s.swap(site.RefID, site.Name, site.Version)
+		if err != nil {
+			return err
+		}
+
+		err = s.prune(site.RefID, site.Name, site.Version)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the directories of sites removed on other nodes
+	dirs, err := filepath.Glob(filepath.Join(s.opts.Root, "*", "*"))
+	if err != nil {
+		return err
+	}
+	for _, dir := range dirs {
+		if !known[dir] {
+			err = os.RemoveAll(dir)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NewService creates a SiteService serving the sites with r, the bundles are kept in store. certs is optional,
+// without it sites can not be served over https.
+func NewService(db dbAdapter, r routing.Service, store storage.Store, certs Certificates, o Options) (Service, error) {
+	s := &service{
+		db:      db,
+		routing: r,
+		store:   store,
+		certs:   certs,
+		opts:    o,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
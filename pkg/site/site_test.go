@@ -0,0 +1,230 @@
+package site_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/routing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/site"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// bundle returns a gzip compressed tar archive of files
+func bundle(files map[string]string) *bytes.Buffer {
+	b := &bytes.Buffer{}
+	gz := gzip.NewWriter(b)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		err := tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tw.Write([]byte(content))
+		Expect(err).NotTo(HaveOccurred())
+	}
+	Expect(tw.Close()).To(Succeed())
+	Expect(gz.Close()).To(Succeed())
+	return b
+}
+
+// mockCertificates issues a certificate for every configuration like the acme service
+type mockCertificates struct {
+	routing routing.Service
+	managed map[string]bool
+}
+
+func (m *mockCertificates) Manage(refID uint, name string) error {
+	m.managed[name] = true
+	return m.routing.EditRouterConfig(refID, name, &routing.RouterConfig{
+		SSLSettings: routing.SSLSettings{
+			ACME:           true,
+			Certificate:    "/certs/" + name + ".pem",
+			CertificateKey: "/certs/" + name + ".key",
+		},
+	})
+}
+
+func (m *mockCertificates) Unmanage(refID uint, name string) error {
+	delete(m.managed, name)
+	return nil
+}
+
+var _ = Describe("Site", func() {
+	var (
+		dir   string
+		r     routing.Service
+		store storage.Store
+		certs *mockCertificates
+		s     site.Service
+	)
+
+	opts := func() site.Options {
+		return site.Options{
+			Root:    filepath.Join(dir, "sites"),
+			Address: "0.0.0.0",
+			MaxSize: 1 << 20,
+			MaxAge:  3600,
+		}
+	}
+
+	read := func(name string) string {
+		b, err := ioutil.ReadFile(filepath.Join(dir, "sites", "1", "blog", "current", name))
+		Expect(err).NotTo(HaveOccurred())
+		return string(b)
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "kroo-site")
+		Expect(err).NotTo(HaveOccurred())
+
+		r, err = routing.NewService(testutils.NewMockDB())
+		Expect(err).NotTo(HaveOccurred())
+		store = storage.NewLocalStore(filepath.Join(dir, "store"))
+		certs = &mockCertificates{routing: r, managed: make(map[string]bool)}
+
+		s, err = site.NewService(testutils.NewMockDB(), r, store, certs, opts())
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("Create", func() {
+		It("Should serve a site from its current version with cache headers", func() {
+			err := s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"Blog.Example.com"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			conf := routing.RouterConfig{}
+			Expect(r.GetRouterConfig(1, site.ConfigName("blog"), &conf)).To(Succeed())
+			Expect(conf.ServerName).To(ConsistOf("blog.example.com"))
+			Expect(conf.RootPath).To(Equal(filepath.Join(dir, "sites", "1", "blog", "current")))
+			Expect(conf.ListenStatement.Port).To(BeEquivalentTo(80))
+			Expect(conf.LocationRules).To(HaveLen(2))
+			Expect(conf.LocationRules[0].Rules["expires"]).To(Equal([]string{"3600s"}))
+			Expect(conf.LocationRules[1].Rules["expires"]).To(Equal([]string{"-1"}))
+		})
+
+		It("Should reject invalid names and domains", func() {
+			Expect(s.CreateSite(&site.Site{RefID: 1, Name: "My Blog", Domains: []string{"example.com"}})).To(Equal(site.ErrInvalidName))
+			Expect(s.CreateSite(&site.Site{RefID: 1, Name: "blog"})).To(Equal(site.ErrNoDomain))
+			Expect(s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"exa mple.com"}})).To(Equal(site.ErrInvalidDomain))
+
+			Expect(s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"example.com"}})).To(Succeed())
+			Expect(s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"example.org"}})).To(Equal(site.ErrSiteExists))
+		})
+
+		It("Should obtain a certificate and redirect to https", func() {
+			err := s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"example.com"}, TLS: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(certs.managed).To(HaveKey(site.ConfigName("blog")))
+
+			conf := routing.RouterConfig{}
+			Expect(r.GetRouterConfig(1, site.ConfigName("blog"), &conf)).To(Succeed())
+			Expect(conf.ListenStatement.Port).To(BeEquivalentTo(443))
+			Expect(conf.ListenStatement.Keyword).To(Equal("ssl"))
+			Expect(conf.SSLSettings.Certificate).To(Equal("/certs/site-blog.pem"))
+			Expect(conf.HTTPS.Redirect.Port).To(BeEquivalentTo(80))
+		})
+
+		It("Should refuse https without certificates", func() {
+			s, err := site.NewService(testutils.NewMockDB(), r, store, nil, opts())
+			Expect(err).NotTo(HaveOccurred())
+
+			err = s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"example.com"}, TLS: true})
+			Expect(err).To(Equal(site.ErrNoCertificates))
+		})
+	})
+
+	Describe("Deploy", func() {
+		BeforeEach(func() {
+			Expect(s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"example.com"}})).To(Succeed())
+		})
+
+		It("Should store and serve a bundle and remove the previous version", func() {
+			version, err := s.Deploy(1, "blog", bundle(map[string]string{"./index.html": "v1", "css/main.css": "body{}"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(BeEquivalentTo(1))
+			Expect(read("index.html")).To(Equal("v1"))
+			Expect(read("css/main.css")).To(Equal("body{}"))
+
+			version, err = s.Deploy(1, "blog", bundle(map[string]string{"index.html": "v2"}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(BeEquivalentTo(2))
+			Expect(read("index.html")).To(Equal("v2"))
+
+			_, err = os.Stat(filepath.Join(dir, "sites", "1", "blog", "1"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+			_, err = store.Get("1/blog/1.tar.gz")
+			Expect(err).To(Equal(storage.ErrObjectNotExist))
+			_, err = store.Get("1/blog/2.tar.gz")
+			Expect(err).NotTo(HaveOccurred())
+
+			stored := site.Site{}
+			Expect(s.GetSite(1, "blog", &stored)).To(Succeed())
+			Expect(stored.Version).To(BeEquivalentTo(2))
+		})
+
+		It("Should reject bundles leaving the site or exceeding the maximum size", func() {
+			_, err := s.Deploy(1, "blog", bundle(map[string]string{"../../escape.html": "x"}))
+			Expect(err).To(Equal(site.ErrInvalidBundle))
+
+			_, err = s.Deploy(1, "blog", bytes.NewBufferString("no archive"))
+			Expect(err).To(Equal(site.ErrInvalidBundle))
+
+			_, err = s.Deploy(1, "blog", bundle(map[string]string{"big.bin": string(make([]byte, 2<<20))}))
+			Expect(err).To(Equal(site.ErrBundleTooLarge))
+
+			_, err = os.Stat(filepath.Join(dir, "escape.html"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+
+			stored := site.Site{}
+			Expect(s.GetSite(1, "blog", &stored)).To(Succeed())
+			Expect(stored.Version).To(BeZero())
+		})
+	})
+
+	It("Should extract missing versions and remove the files of removed sites on sync", func() {
+		Expect(s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"example.com"}})).To(Succeed())
+		_, err := s.Deploy(1, "blog", bundle(map[string]string{"index.html": "synced"}))
+		Expect(err).NotTo(HaveOccurred())
+
+		// another node only shares the database and the store
+		Expect(os.RemoveAll(filepath.Join(dir, "sites"))).To(Succeed())
+		stale := filepath.Join(dir, "sites", "2", "old")
+		Expect(os.MkdirAll(stale, 0755)).To(Succeed())
+
+		Expect(s.Sync()).To(Succeed())
+		Expect(read("index.html")).To(Equal("synced"))
+		_, err = os.Stat(stale)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+	})
+
+	It("Should remove a site with its configuration, certificate and bundle", func() {
+		Expect(s.CreateSite(&site.Site{RefID: 1, Name: "blog", Domains: []string{"example.com"}, TLS: true})).To(Succeed())
+		_, err := s.Deploy(1, "blog", bundle(map[string]string{"index.html": "bye"}))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(s.RemoveSite(1, "blog")).To(Succeed())
+		Expect(certs.managed).To(BeEmpty())
+		Expect(r.GetRouterConfig(1, site.ConfigName("blog"), &routing.RouterConfig{})).NotTo(Succeed())
+		_, err = store.Get("1/blog/1.tar.gz")
+		Expect(err).To(Equal(storage.ErrObjectNotExist))
+		_, err = os.Stat(filepath.Join(dir, "sites", "1", "blog"))
+		Expect(os.IsNotExist(err)).To(BeTrue())
+
+		Expect(s.GetSite(1, "blog", &site.Site{})).To(Equal(site.ErrSiteNotExist))
+	})
+})
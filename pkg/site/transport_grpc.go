@@ -0,0 +1,306 @@
+package site
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/site/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC SiteServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.SiteServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createSite: grpctransport.NewServer(
+			endpoints.CreateSiteEndpoint,
+			DecodeGRPCCreateSiteRequest,
+			EncodeGRPCCreateSiteResponse,
+			options...,
+		),
+
+		editSite: grpctransport.NewServer(
+			endpoints.EditSiteEndpoint,
+			DecodeGRPCEditSiteRequest,
+			EncodeGRPCEditSiteResponse,
+			options...,
+		),
+
+		getSite: grpctransport.NewServer(
+			endpoints.GetSiteEndpoint,
+			DecodeGRPCGetSiteRequest,
+			EncodeGRPCGetSiteResponse,
+			options...,
+		),
+
+		sites: grpctransport.NewServer(
+			endpoints.SitesEndpoint,
+			DecodeGRPCSitesRequest,
+			EncodeGRPCSitesResponse,
+			options...,
+		),
+
+		removeSite: grpctransport.NewServer(
+			endpoints.RemoveSiteEndpoint,
+			DecodeGRPCRemoveSiteRequest,
+			EncodeGRPCRemoveSiteResponse,
+			options...,
+		),
+
+		deploy: grpctransport.NewServer(
+			endpoints.DeployEndpoint,
+			DecodeGRPCDeployRequest,
+			EncodeGRPCDeployResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createSite grpctransport.Handler
+	editSite   grpctransport.Handler
+	getSite    grpctransport.Handler
+	sites      grpctransport.Handler
+	removeSite grpctransport.Handler
+	deploy     grpctransport.Handler
+}
+
+func (s *grpcServer) CreateSite(ctx oldcontext.Context, req *pb.CreateSiteRequest) (*pb.CreateSiteResponse, error) {
+	_, res, err := s.createSite.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateSiteResponse), nil
+}
+
+func (s *grpcServer) EditSite(ctx oldcontext.Context, req *pb.EditSiteRequest) (*pb.EditSiteResponse, error) {
+	_, res, err := s.editSite.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.EditSiteResponse), nil
+}
+
+func (s *grpcServer) GetSite(ctx oldcontext.Context, req *pb.GetSiteRequest) (*pb.GetSiteResponse, error) {
+	_, res, err := s.getSite.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.GetSiteResponse), nil
+}
+
+func (s *grpcServer) Sites(ctx oldcontext.Context, req *pb.SitesRequest) (*pb.SitesResponse, error) {
+	_, res, err := s.sites.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SitesResponse), nil
+}
+
+func (s *grpcServer) RemoveSite(ctx oldcontext.Context, req *pb.RemoveSiteRequest) (*pb.RemoveSiteResponse, error) {
+	_, res, err := s.removeSite.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveSiteResponse), nil
+}
+
+func (s *grpcServer) Deploy(ctx oldcontext.Context, req *pb.DeployRequest) (*pb.DeployResponse, error) {
+	_, res, err := s.deploy.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DeployResponse), nil
+}
+
+// ConvertSite converts a Site to its protobuf representation
+func ConvertSite(s Site) *pb.Site {
+	site := &pb.Site{
+		ID:        uint32(s.ID),
+		Name:      s.Name,
+		Domains:   s.Domains,
+		MaxAge:    uint32(s.MaxAge),
+		Tls:       s.TLS,
+		Version:   uint32(s.Version),
+		Size:      s.Size,
+		CreatedAt: s.CreatedAt.Unix(),
+	}
+	if !s.DeployedAt.IsZero() {
+		site.DeployedAt = s.DeployedAt.Unix()
+	}
+	return site
+}
+
+// ConvertPBSite converts a protobuf Site to a Site
+func ConvertPBSite(s *pb.Site) Site {
+	if s == nil {
+		return Site{}
+	}
+
+	site := Site{
+		ID:        uint(s.ID),
+		Name:      s.Name,
+		Domains:   s.Domains,
+		MaxAge:    uint(s.MaxAge),
+		TLS:       s.Tls,
+		Version:   uint(s.Version),
+		Size:      s.Size,
+		CreatedAt: time.Unix(s.CreatedAt, 0).UTC(),
+	}
+	if s.DeployedAt != 0 {
+		site.DeployedAt = time.Unix(s.DeployedAt, 0).UTC()
+	}
+	return site
+}
+
+// DecodeGRPCCreateSiteRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateSite request to a messages/site.proto-domain create site request.
+func DecodeGRPCCreateSiteRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateSiteRequest)
+	return CreateSiteRequest{
+		RefID:   uint(req.RefID),
+		Name:    req.Name,
+		Domains: req.Domains,
+		MaxAge:  uint(req.MaxAge),
+		TLS:     req.Tls,
+	}, nil
+}
+
+// EncodeGRPCCreateSiteResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain create site response to a gRPC CreateSite response.
+func EncodeGRPCCreateSiteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateSiteResponse)
+	gRPCRes := &pb.CreateSiteResponse{
+		Site: ConvertSite(res.Site),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCEditSiteRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC EditSite request to a messages/site.proto-domain edit site request.
+func DecodeGRPCEditSiteRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.EditSiteRequest)
+	return EditSiteRequest{
+		RefID:   uint(req.RefID),
+		Name:    req.Name,
+		Domains: req.Domains,
+		MaxAge:  uint(req.MaxAge),
+		TLS:     req.Tls,
+	}, nil
+}
+
+// EncodeGRPCEditSiteResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain edit site response to a gRPC EditSite response.
+func EncodeGRPCEditSiteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(EditSiteResponse)
+	gRPCRes := &pb.EditSiteResponse{
+		Site: ConvertSite(res.Site),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCGetSiteRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC GetSite request to a messages/site.proto-domain get site request.
+func DecodeGRPCGetSiteRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.GetSiteRequest)
+	return GetSiteRequest{
+		RefID: uint(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// EncodeGRPCGetSiteResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain get site response to a gRPC GetSite response.
+func EncodeGRPCGetSiteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(GetSiteResponse)
+	gRPCRes := &pb.GetSiteResponse{
+		Site: ConvertSite(res.Site),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSitesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Sites request to a messages/site.proto-domain sites request.
+func DecodeGRPCSitesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SitesRequest)
+	return SitesRequest{
+		RefID: uint(req.RefID),
+	}, nil
+}
+
+// EncodeGRPCSitesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain sites response to a gRPC Sites response.
+func EncodeGRPCSitesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SitesResponse)
+	sites := make([]*pb.Site, len(res.Sites))
+	for i, s := range res.Sites {
+		sites[i] = ConvertSite(s)
+	}
+
+	gRPCRes := &pb.SitesResponse{
+		Sites: sites,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveSiteRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveSite request to a messages/site.proto-domain remove site request.
+func DecodeGRPCRemoveSiteRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveSiteRequest)
+	return RemoveSiteRequest{
+		RefID: uint(req.RefID),
+		Name:  req.Name,
+	}, nil
+}
+
+// EncodeGRPCRemoveSiteResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain remove site response to a gRPC RemoveSite response.
+func EncodeGRPCRemoveSiteResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveSiteResponse)
+	gRPCRes := &pb.RemoveSiteResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDeployRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Deploy request to a messages/site.proto-domain deploy request.
+func DecodeGRPCDeployRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DeployRequest)
+	return DeployRequest{
+		RefID:  uint(req.RefID),
+		Name:   req.Name,
+		Bundle: req.Bundle,
+	}, nil
+}
+
+// EncodeGRPCDeployResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/site.proto-domain deploy response to a gRPC Deploy response.
+func EncodeGRPCDeployResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DeployResponse)
+	gRPCRes := &pb.DeployResponse{
+		Version: uint32(res.Version),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
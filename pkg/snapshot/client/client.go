@@ -0,0 +1,275 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/snapshot"
+	"github.com/kontainerooo/kontainer.ooo/pkg/snapshot/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *snapshot.Endpoints {
+
+	var CreateScheduleEndpoint endpoint.Endpoint
+	{
+		CreateScheduleEndpoint = grpctransport.NewClient(
+			conn,
+			"snapshot.SnapshotService",
+			"CreateSchedule",
+			EncodeGRPCCreateScheduleRequest,
+			DecodeGRPCCreateScheduleResponse,
+			pb.CreateScheduleResponse{},
+		).Endpoint()
+	}
+
+	var RemoveScheduleEndpoint endpoint.Endpoint
+	{
+		RemoveScheduleEndpoint = grpctransport.NewClient(
+			conn,
+			"snapshot.SnapshotService",
+			"RemoveSchedule",
+			EncodeGRPCRemoveScheduleRequest,
+			DecodeGRPCRemoveScheduleResponse,
+			pb.RemoveScheduleResponse{},
+		).Endpoint()
+	}
+
+	var SchedulesEndpoint endpoint.Endpoint
+	{
+		SchedulesEndpoint = grpctransport.NewClient(
+			conn,
+			"snapshot.SnapshotService",
+			"Schedules",
+			EncodeGRPCSchedulesRequest,
+			DecodeGRPCSchedulesResponse,
+			pb.SchedulesResponse{},
+		).Endpoint()
+	}
+
+	var SnapshotsEndpoint endpoint.Endpoint
+	{
+		SnapshotsEndpoint = grpctransport.NewClient(
+			conn,
+			"snapshot.SnapshotService",
+			"Snapshots",
+			EncodeGRPCSnapshotsRequest,
+			DecodeGRPCSnapshotsResponse,
+			pb.SnapshotsResponse{},
+		).Endpoint()
+	}
+
+	var RemoveSnapshotEndpoint endpoint.Endpoint
+	{
+		RemoveSnapshotEndpoint = grpctransport.NewClient(
+			conn,
+			"snapshot.SnapshotService",
+			"RemoveSnapshot",
+			EncodeGRPCRemoveSnapshotRequest,
+			DecodeGRPCRemoveSnapshotResponse,
+			pb.RemoveSnapshotResponse{},
+		).Endpoint()
+	}
+
+	var TakeSnapshotEndpoint endpoint.Endpoint
+	{
+		TakeSnapshotEndpoint = grpctransport.NewClient(
+			conn,
+			"snapshot.SnapshotService",
+			"TakeSnapshot",
+			EncodeGRPCTakeSnapshotRequest,
+			DecodeGRPCTakeSnapshotResponse,
+			pb.TakeSnapshotResponse{},
+		).Endpoint()
+	}
+
+	var RestoreSnapshotEndpoint endpoint.Endpoint
+	{
+		RestoreSnapshotEndpoint = grpctransport.NewClient(
+			conn,
+			"snapshot.SnapshotService",
+			"RestoreSnapshot",
+			EncodeGRPCRestoreSnapshotRequest,
+			DecodeGRPCRestoreSnapshotResponse,
+			pb.RestoreSnapshotResponse{},
+		).Endpoint()
+	}
+
+	return &snapshot.Endpoints{
+		CreateScheduleEndpoint:  CreateScheduleEndpoint,
+		RemoveScheduleEndpoint:  RemoveScheduleEndpoint,
+		SchedulesEndpoint:       SchedulesEndpoint,
+		SnapshotsEndpoint:       SnapshotsEndpoint,
+		RemoveSnapshotEndpoint:  RemoveSnapshotEndpoint,
+		TakeSnapshotEndpoint:    TakeSnapshotEndpoint,
+		RestoreSnapshotEndpoint: RestoreSnapshotEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreateScheduleRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain createschedule request to a gRPC CreateSchedule request.
+func EncodeGRPCCreateScheduleRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*snapshot.CreateScheduleRequest)
+	return &pb.CreateScheduleRequest{
+		RefID:     uint32(req.RefID),
+		Name:      req.Schedule.Name,
+		Kind:      req.Schedule.Kind,
+		Source:    req.Schedule.Source,
+		Cron:      req.Schedule.Cron,
+		Retention: uint32(req.Schedule.Retention),
+		Target:    req.Schedule.Target,
+	}, nil
+}
+
+// DecodeGRPCCreateScheduleResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateSchedule response to a messages/snapshot.proto-domain createschedule response.
+func DecodeGRPCCreateScheduleResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateScheduleResponse)
+	return &snapshot.CreateScheduleResponse{
+		ID:    uint(response.ID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveScheduleRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain removeschedule request to a gRPC RemoveSchedule request.
+func EncodeGRPCRemoveScheduleRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*snapshot.RemoveScheduleRequest)
+	return &pb.RemoveScheduleRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveScheduleResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveSchedule response to a messages/snapshot.proto-domain removeschedule response.
+func DecodeGRPCRemoveScheduleResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveScheduleResponse)
+	return &snapshot.RemoveScheduleResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSchedulesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain schedules request to a gRPC Schedules request.
+func EncodeGRPCSchedulesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*snapshot.SchedulesRequest)
+	return &pb.SchedulesRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCSchedulesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Schedules response to a messages/snapshot.proto-domain schedules response.
+func DecodeGRPCSchedulesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SchedulesResponse)
+	schedules := make([]snapshot.Schedule, len(response.Schedules))
+	for i, s := range response.Schedules {
+		schedules[i] = snapshot.ConvertPBSchedule(s)
+	}
+
+	return &snapshot.SchedulesResponse{
+		Schedules: schedules,
+		Error:     getError(response.Error),
+		Page:      paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCSnapshotsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain snapshots request to a gRPC Snapshots request.
+func EncodeGRPCSnapshotsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*snapshot.SnapshotsRequest)
+	return &pb.SnapshotsRequest{
+		RefID:      uint32(req.RefID),
+		ScheduleID: uint32(req.ScheduleID),
+	}, nil
+}
+
+// DecodeGRPCSnapshotsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Snapshots response to a messages/snapshot.proto-domain snapshots response.
+func DecodeGRPCSnapshotsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SnapshotsResponse)
+	snapshots := make([]snapshot.Snapshot, len(response.Snapshots))
+	for i, s := range response.Snapshots {
+		snapshots[i] = snapshot.ConvertPBSnapshot(s)
+	}
+
+	return &snapshot.SnapshotsResponse{
+		Snapshots: snapshots,
+		Error:     getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveSnapshotRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain removesnapshot request to a gRPC RemoveSnapshot request.
+func EncodeGRPCRemoveSnapshotRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*snapshot.RemoveSnapshotRequest)
+	return &pb.RemoveSnapshotRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveSnapshotResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveSnapshot response to a messages/snapshot.proto-domain removesnapshot response.
+func DecodeGRPCRemoveSnapshotResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveSnapshotResponse)
+	return &snapshot.RemoveSnapshotResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCTakeSnapshotRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain takesnapshot request to a gRPC TakeSnapshot request.
+func EncodeGRPCTakeSnapshotRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*snapshot.TakeSnapshotRequest)
+	return &pb.TakeSnapshotRequest{
+		RefID:      uint32(req.RefID),
+		ScheduleID: uint32(req.ScheduleID),
+	}, nil
+}
+
+// DecodeGRPCTakeSnapshotResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC TakeSnapshot response to a messages/snapshot.proto-domain takesnapshot response.
+func DecodeGRPCTakeSnapshotResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.TakeSnapshotResponse)
+	return &snapshot.TakeSnapshotResponse{
+		JobID: uint(response.JobID),
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRestoreSnapshotRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain restoresnapshot request to a gRPC RestoreSnapshot request.
+func EncodeGRPCRestoreSnapshotRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*snapshot.RestoreSnapshotRequest)
+	return &pb.RestoreSnapshotRequest{
+		RefID:  uint32(req.RefID),
+		ID:     uint32(req.ID),
+		Volume: req.Volume,
+	}, nil
+}
+
+// DecodeGRPCRestoreSnapshotResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RestoreSnapshot response to a messages/snapshot.proto-domain restoresnapshot response.
+func DecodeGRPCRestoreSnapshotResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RestoreSnapshotResponse)
+	return &snapshot.RestoreSnapshotResponse{
+		JobID: uint(response.JobID),
+		Error: getError(response.Error),
+	}, nil
+}
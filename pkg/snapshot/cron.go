@@ -0,0 +1,157 @@
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCron occurs if a cron expression can not be parsed
+var ErrCron = errors.New("invalid cron expression")
+
+// shortcuts are the predefined cron expressions
+var shortcuts = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// Cron is a parsed cron expression, every field is a set of the values it matches
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAll and dowAll are set if the day fields are *, a day matches if either of
+	// the restricted day fields matches as with the cron daemon
+	domAll, dowAll bool
+}
+
+// field parses one field of a cron expression, it supports *, values, ranges, lists and steps
+func field(f string, min, max uint) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(f, ",") {
+		step := uint(1)
+		if i := strings.Index(part, "/"); i != -1 {
+			n, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || n == 0 {
+				return 0, fmt.Errorf("%s: %v", f, ErrCron)
+			}
+			step = uint(n)
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			bounds := strings.SplitN(part, "-", 2)
+			n, err := strconv.ParseUint(bounds[0], 10, 8)
+			if err != nil {
+				return 0, fmt.Errorf("%s: %v", f, ErrCron)
+			}
+			lo, hi = uint(n), uint(n)
+			if len(bounds) == 2 {
+				n, err = strconv.ParseUint(bounds[1], 10, 8)
+				if err != nil {
+					return 0, fmt.Errorf("%s: %v", f, ErrCron)
+				}
+				hi = uint(n)
+			} else if step != 1 {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%s: %v", f, ErrCron)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// ParseCron parses a cron expression of the fields minute, hour, day of month, month and day of week,
+// or one of @hourly, @daily, @weekly, @monthly and @yearly. Sunday is 0 or 7.
+func ParseCron(expr string) (Cron, error) {
+	if s, ok := shortcuts[strings.TrimSpace(expr)]; ok {
+		expr = s
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return Cron{}, ErrCron
+	}
+
+	c := Cron{
+		domAll: fields[2] == "*",
+		dowAll: fields[4] == "*",
+	}
+	var err error
+	c.minute, err = field(fields[0], 0, 59)
+	if err != nil {
+		return Cron{}, err
+	}
+	c.hour, err = field(fields[1], 0, 23)
+	if err != nil {
+		return Cron{}, err
+	}
+	c.dom, err = field(fields[2], 1, 31)
+	if err != nil {
+		return Cron{}, err
+	}
+	c.month, err = field(fields[3], 1, 12)
+	if err != nil {
+		return Cron{}, err
+	}
+	c.dow, err = field(fields[4], 0, 7)
+	if err != nil {
+		return Cron{}, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+func (c Cron) day(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAll && c.dowAll:
+		return true
+	case c.domAll:
+		return dow
+	case c.dowAll:
+		return dom
+	}
+	return dom || dow
+}
+
+// Next returns the first time after t the expression matches, in UTC. It returns the
+// zero time if the expression matches no time within five years, e.g. for 0 0 31 2 *.
+func (c Cron) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	end := t.AddDate(5, 0, 0)
+
+	for t.Before(end) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.day(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,52 @@
+package snapshot
+
+import (
+	"time"
+)
+
+// Schedule takes snapshots of a volume or managed database of a user
+type Schedule struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	Name  string `validate:"required,name"`
+	// Kind is what is snapshotted, the volume of an instance or a managed database
+	Kind string `validate:"required,oneof=volume|database"`
+	// Source is the name of the instance or database
+	Source string `validate:"required,name"`
+	// Cron is the five field cron expression of the snapshots in UTC, e.g. 0 3 * * *
+	Cron string `validate:"required"`
+	// Retention is the number of snapshots kept, older ones are removed
+	Retention uint
+	// Target is the store the snapshots are written to, local or s3
+	Target    string
+	LastRun   time.Time
+	CreatedAt time.Time
+}
+
+// TableName sets Schedule's database table name
+func (Schedule) TableName() string {
+	return "snapshot_schedules"
+}
+
+// Snapshot is a run of a schedule
+type Snapshot struct {
+	ID         uint `gorm:"primary_key"`
+	ScheduleID uint
+	RefID      uint
+	Kind       string
+	Source     string
+	Target     string
+	// Key is the name of the snapshot in its store
+	Key   string
+	Size  int64
+	State string
+	Error string
+	// CreatedAt is when the snapshot was started, FinishedAt when it succeeded or failed
+	CreatedAt  time.Time
+	FinishedAt time.Time
+}
+
+// TableName sets Snapshot's database table name
+func (Snapshot) TableName() string {
+	return "snapshots"
+}
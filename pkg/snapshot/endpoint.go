@@ -0,0 +1,201 @@
+package snapshot
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the snapshot service
+type Endpoints struct {
+	CreateScheduleEndpoint  endpoint.Endpoint
+	RemoveScheduleEndpoint  endpoint.Endpoint
+	SchedulesEndpoint       endpoint.Endpoint
+	SnapshotsEndpoint       endpoint.Endpoint
+	RemoveSnapshotEndpoint  endpoint.Endpoint
+	TakeSnapshotEndpoint    endpoint.Endpoint
+	RestoreSnapshotEndpoint endpoint.Endpoint
+}
+
+// CreateScheduleRequest is the request struct for the CreateScheduleEndpoint
+type CreateScheduleRequest struct {
+	RefID    uint      `bart:"ref"`
+	Schedule *Schedule `validate:"required"`
+}
+
+// CreateScheduleResponse is the response struct for the CreateScheduleEndpoint
+type CreateScheduleResponse struct {
+	ID    uint
+	Error error
+}
+
+// MakeCreateScheduleEndpoint creates a gokit endpoint which invokes CreateSchedule
+func MakeCreateScheduleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateScheduleRequest)
+		err := s.CreateSchedule(req.RefID, req.Schedule)
+		if err != nil {
+			return CreateScheduleResponse{
+				Error: err,
+			}, nil
+		}
+		return CreateScheduleResponse{
+			ID: req.Schedule.ID,
+		}, nil
+	}
+}
+
+// RemoveScheduleRequest is the request struct for the RemoveScheduleEndpoint
+type RemoveScheduleRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemoveScheduleResponse is the response struct for the RemoveScheduleEndpoint
+type RemoveScheduleResponse struct {
+	Error error
+}
+
+// MakeRemoveScheduleEndpoint creates a gokit endpoint which invokes RemoveSchedule
+func MakeRemoveScheduleEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveScheduleRequest)
+		err := s.RemoveSchedule(req.RefID, req.ID)
+		return RemoveScheduleResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// SchedulesRequest is the request struct for the SchedulesEndpoint
+type SchedulesRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// SchedulesResponse is the response struct for the SchedulesEndpoint
+type SchedulesResponse struct {
+	Schedules []Schedule
+	Error     error
+	Page      paging.Response
+}
+
+// MakeSchedulesEndpoint creates a gokit endpoint which invokes Schedules
+func MakeSchedulesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SchedulesRequest)
+		schedules := []Schedule{}
+		err := s.Schedules(req.RefID, &schedules)
+		if err != nil {
+			return SchedulesResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&schedules, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return SchedulesResponse{
+			Schedules: schedules,
+			Page:      page,
+		}, nil
+	}
+}
+
+// SnapshotsRequest is the request struct for the SnapshotsEndpoint
+type SnapshotsRequest struct {
+	RefID      uint `bart:"ref"`
+	ScheduleID uint
+}
+
+// SnapshotsResponse is the response struct for the SnapshotsEndpoint
+type SnapshotsResponse struct {
+	Snapshots []Snapshot
+	Error     error
+}
+
+// MakeSnapshotsEndpoint creates a gokit endpoint which invokes Snapshots
+func MakeSnapshotsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SnapshotsRequest)
+		snapshots := []Snapshot{}
+		err := s.Snapshots(req.RefID, req.ScheduleID, &snapshots)
+		return SnapshotsResponse{
+			Snapshots: snapshots,
+			Error:     err,
+		}, nil
+	}
+}
+
+// RemoveSnapshotRequest is the request struct for the RemoveSnapshotEndpoint
+type RemoveSnapshotRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemoveSnapshotResponse is the response struct for the RemoveSnapshotEndpoint
+type RemoveSnapshotResponse struct {
+	Error error
+}
+
+// MakeRemoveSnapshotEndpoint creates a gokit endpoint which invokes RemoveSnapshot
+func MakeRemoveSnapshotEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveSnapshotRequest)
+		err := s.RemoveSnapshot(req.RefID, req.ID)
+		return RemoveSnapshotResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// TakeSnapshotRequest is the request struct for the TakeSnapshotEndpoint
+type TakeSnapshotRequest struct {
+	RefID      uint `bart:"ref"`
+	ScheduleID uint
+}
+
+// TakeSnapshotResponse is the response struct for the TakeSnapshotEndpoint
+type TakeSnapshotResponse struct {
+	JobID uint
+	Error error
+}
+
+// MakeTakeSnapshotEndpoint creates a gokit endpoint which invokes TakeSnapshot
+func MakeTakeSnapshotEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(TakeSnapshotRequest)
+		id, err := s.TakeSnapshot(req.RefID, req.ScheduleID)
+		return TakeSnapshotResponse{
+			JobID: id,
+			Error: err,
+		}, nil
+	}
+}
+
+// RestoreSnapshotRequest is the request struct for the RestoreSnapshotEndpoint
+type RestoreSnapshotRequest struct {
+	RefID  uint `bart:"ref"`
+	ID     uint
+	Volume string `validate:"required,name"`
+}
+
+// RestoreSnapshotResponse is the response struct for the RestoreSnapshotEndpoint
+type RestoreSnapshotResponse struct {
+	JobID uint
+	Error error
+}
+
+// MakeRestoreSnapshotEndpoint creates a gokit endpoint which invokes RestoreSnapshot
+func MakeRestoreSnapshotEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RestoreSnapshotRequest)
+		id, err := s.RestoreSnapshot(req.RefID, req.ID, req.Volume)
+		return RestoreSnapshotResponse{
+			JobID: id,
+			Error: err,
+		}, nil
+	}
+}
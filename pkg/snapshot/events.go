@@ -0,0 +1,77 @@
+package snapshot
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+type eventService struct {
+	Service
+	bus    events.Bus
+	logger log.Logger
+}
+
+func (s *eventService) publish(topic string, e events.SnapshotEvent) {
+	err := s.bus.Publish(topic, e)
+	if err != nil {
+		level.Error(s.logger).Log("topic", topic, "snapshot", e.SnapshotID, "err", err)
+	}
+}
+
+func (s *eventService) Snapshot(scheduleID uint) (Snapshot, error) {
+	sn, err := s.Service.Snapshot(scheduleID)
+	if sn.ID == 0 {
+		return sn, err
+	}
+
+	e := events.SnapshotEvent{
+		RefID:      sn.RefID,
+		ScheduleID: sn.ScheduleID,
+		SnapshotID: sn.ID,
+		Kind:       sn.Kind,
+		Source:     sn.Source,
+	}
+	if sn.State != Succeeded {
+		e.Error = sn.Error
+		s.publish(events.SnapshotFailed, e)
+		return sn, err
+	}
+
+	s.publish(events.SnapshotSucceeded, e)
+	return sn, err
+}
+
+func (s *eventService) Restore(id uint, volume string) (Snapshot, error) {
+	sn, err := s.Service.Restore(id, volume)
+	if sn.ID == 0 {
+		return sn, err
+	}
+
+	e := events.SnapshotEvent{
+		RefID:      sn.RefID,
+		ScheduleID: sn.ScheduleID,
+		SnapshotID: sn.ID,
+		Kind:       sn.Kind,
+		Source:     sn.Source,
+		Volume:     volume,
+	}
+	if err != nil {
+		e.Error = err.Error()
+		s.publish(events.SnapshotFailed, e)
+		return sn, err
+	}
+
+	s.publish(events.SnapshotRestored, e)
+	return sn, nil
+}
+
+// NewEventService returns a Service which publishes an event once a snapshot succeeded, failed or was
+// restored, failing to publish is only logged
+func NewEventService(s Service, bus events.Bus, logger log.Logger) Service {
+	return &eventService{
+		Service: s,
+		bus:     bus,
+		logger:  logger,
+	}
+}
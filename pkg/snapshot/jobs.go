@@ -0,0 +1,71 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+const (
+	// DueJob is the type of the job enqueueing the snapshots of the schedules which are due
+	DueJob = "snapshot.due"
+	// SnapshotJob is the type of the job taking a snapshot of a schedule
+	SnapshotJob = "snapshot.take"
+	// RestoreJob is the type of the job restoring a snapshot into a new volume
+	RestoreJob = "snapshot.restore"
+)
+
+// JobOptions run one snapshot at a time, failed snapshots are not retried but taken again at the next
+// time of their schedule, so every failure is only reported once
+var JobOptions = jobs.Options{
+	Workers:     1,
+	MaxAttempts: 1,
+}
+
+type snapshotPayload struct {
+	ScheduleID uint `json:"scheduleID"`
+}
+
+type restorePayload struct {
+	SnapshotID uint   `json:"snapshotID"`
+	Volume     string `json:"volume"`
+}
+
+// DueHandler returns the handler of DueJob
+func DueHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		return s.Due(time.Now())
+	}
+}
+
+// SnapshotHandler returns the handler of SnapshotJob, schedules removed in the meantime are skipped
+func SnapshotHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		p := snapshotPayload{}
+		err := j.Decode(&p)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Snapshot(p.ScheduleID)
+		if err == ErrScheduleNotExist {
+			return nil
+		}
+		return err
+	}
+}
+
+// RestoreHandler returns the handler of RestoreJob
+func RestoreHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		p := restorePayload{}
+		err := j.Decode(&p)
+		if err != nil {
+			return err
+		}
+
+		_, err = s.Restore(p.SnapshotID, p.Volume)
+		return err
+	}
+}
@@ -0,0 +1,681 @@
+// Package snapshot takes scheduled snapshots of the volumes and managed databases of the users,
+// keeps them in a local or S3 compatible store and restores volume snapshots into new volumes
+package snapshot
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/backup"
+	"github.com/kontainerooo/kontainer.ooo/pkg/database"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+)
+
+const (
+	// Volume snapshots are archives of the volume of an instance
+	Volume = "volume"
+	// Database snapshots are gzipped SQL dumps of a managed database
+	Database = "database"
+)
+
+// States of a snapshot
+const (
+	Running   = "running"
+	Succeeded = "succeeded"
+	Failed    = "failed"
+)
+
+// volumePart is the name of the part of the volume in the archives of volume snapshots
+const volumePart = "volume"
+
+var (
+	// ErrKind occurs if a schedule is created for a kind of source which is not available
+	ErrKind = errors.New("kind has to be volume or database")
+
+	// ErrTarget occurs if a schedule is created with a store which is not configured
+	ErrTarget = errors.New("target is not available")
+
+	// ErrSourceNotExist occurs if the instance or database of a schedule does not exist
+	ErrSourceNotExist = errors.New("source does not exist")
+
+	// ErrScheduleExists occurs if a user has a schedule of the same name
+	ErrScheduleExists = errors.New("schedule exists already")
+
+	// ErrScheduleNotExist occurs if a schedule does not exist
+	ErrScheduleNotExist = errors.New("schedule does not exist")
+
+	// ErrSnapshotNotExist occurs if a snapshot does not exist
+	ErrSnapshotNotExist = errors.New("snapshot does not exist")
+
+	// ErrSnapshotRunning occurs if a snapshot which is still written is removed
+	ErrSnapshotRunning = errors.New("snapshot is running")
+
+	// ErrRestore occurs if a snapshot which failed or is not of a volume is restored
+	ErrRestore = errors.New("only succeeded volume snapshots can be restored")
+
+	// ErrVolumeExists occurs if a snapshot is restored into an existing volume
+	ErrVolumeExists = errors.New("volume exists already")
+)
+
+// Service SnapshotService
+type Service interface {
+	// CreateSchedule creates a schedule of a user, the retention defaults to the one of the options
+	// and the target to the local store
+	CreateSchedule(refID uint, s *Schedule) error
+
+	// RemoveSchedule removes a schedule, its snapshots are kept
+	RemoveSchedule(refID uint, id uint) error
+
+	// Schedules returns the schedules of a user
+	Schedules(refID uint, s *[]Schedule) error
+
+	// Snapshots returns the snapshots of a schedule, or of every schedule if scheduleID is 0, the latest first
+	Snapshots(refID uint, scheduleID uint, s *[]Snapshot) error
+
+	// RemoveSnapshot removes a snapshot from its store
+	RemoveSnapshot(refID uint, id uint) error
+
+	// TakeSnapshot takes a snapshot of a schedule in the background and returns the ID of the job
+	TakeSnapshot(refID uint, scheduleID uint) (uint, error)
+
+	// RestoreSnapshot restores a volume snapshot into the new volume of a user in the background
+	// and returns the ID of the job
+	RestoreSnapshot(refID uint, id uint, volume string) (uint, error)
+
+	// Due enqueues a snapshot of every schedule whose cron expression matched since its last run
+	Due(now time.Time) error
+
+	// Snapshot takes a snapshot of a schedule and removes the snapshots exceeding its retention.
+	// The snapshot is returned even if it failed, it has no ID if it was never started.
+	Snapshot(scheduleID uint) (Snapshot, error)
+
+	// Restore restores a snapshot into the directory of volume in the restore path
+	Restore(id uint, volume string) (Snapshot, error)
+}
+
+// Volumes returns the directories of the volumes of the instances, it is implemented by the daemon
+type Volumes interface {
+	Volume(refID uint, name string) (string, error)
+}
+
+// Databases exports the managed databases, it is satisfied by the database service
+type Databases interface {
+	Databases(refID uint, d *[]database.Database) error
+	Export(refID uint, name string, w io.Writer) error
+}
+
+// Queue runs the snapshots in the background, it is the job queue of the daemon
+type Queue interface {
+	Enqueue(typ string, payload interface{}) (uint, error)
+}
+
+// Options configure the snapshots
+type Options struct {
+	// Node is written into the archives of volume snapshots
+	Node string
+	// RestorePath is the directory volumes are restored into, in a directory per user
+	RestorePath string
+	// Retention is the number of snapshots kept by schedules without one
+	Retention uint
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db        dbAdapter
+	volumes   Volumes
+	databases Databases
+	queue     Queue
+	stores    map[string]storage.Store
+	options   Options
+	mtx       *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Schedule{}, &Snapshot{})
+}
+
+// schedule returns the schedule id of a user, or of any user if refID is 0
+func (s *service) schedule(refID uint, id uint) (Schedule, error) {
+	sc := Schedule{}
+	var err error
+	if refID == 0 {
+		err = s.db.First(&sc, "id = ?", id)
+	} else {
+		err = s.db.First(&sc, "id = ? AND ref_id = ?", id, refID)
+	}
+	if s.db.IsNotFound(err) {
+		return Schedule{}, ErrScheduleNotExist
+	}
+	if err != nil {
+		return Schedule{}, err
+	}
+	return sc, nil
+}
+
+// snapshots returns the snapshots matching the non-zero arguments, the latest first
+func (s *service) snapshots(refID uint, scheduleID uint) ([]Snapshot, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if refID != 0 {
+		conditions = append(conditions, "ref_id = ?")
+		args = append(args, refID)
+	}
+	if scheduleID != 0 {
+		conditions = append(conditions, "schedule_id = ?")
+		args = append(args, scheduleID)
+	}
+
+	where := []interface{}{}
+	if len(conditions) != 0 {
+		where = append([]interface{}{strings.Join(conditions, " AND ")}, args...)
+	}
+
+	ss := []Snapshot{}
+	err := s.db.FindOrdered(&ss, "id DESC", 0, where...)
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// snapshot returns the snapshot id of a user, or of any user if refID is 0
+func (s *service) snapshot(refID uint, id uint) (Snapshot, error) {
+	sn := Snapshot{}
+	var err error
+	if refID == 0 {
+		err = s.db.First(&sn, "id = ?", id)
+	} else {
+		err = s.db.First(&sn, "id = ? AND ref_id = ?", id, refID)
+	}
+	if s.db.IsNotFound(err) {
+		return Snapshot{}, ErrSnapshotNotExist
+	}
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return sn, nil
+}
+
+// source checks that the source of sc exists
+func (s *service) source(sc Schedule) error {
+	switch sc.Kind {
+	case Volume:
+		_, err := s.volumes.Volume(sc.RefID, sc.Source)
+		if err != nil {
+			return ErrSourceNotExist
+		}
+		return nil
+	case Database:
+		if s.databases == nil {
+			return ErrKind
+		}
+
+		ds := []database.Database{}
+		err := s.databases.Databases(sc.RefID, &ds)
+		if err != nil {
+			return err
+		}
+		for _, d := range ds {
+			if d.Name == sc.Source {
+				return nil
+			}
+		}
+		return ErrSourceNotExist
+	}
+	return ErrKind
+}
+
+func (s *service) CreateSchedule(refID uint, sc *Schedule) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.createSchedule(refID, sc)
+}
+
+func (s *service) createSchedule(refID uint, sc *Schedule) error {
+	sc.ID = 0
+	sc.RefID = refID
+	if sc.Target == "" {
+		sc.Target = storage.Local
+	}
+	if s.stores[sc.Target] == nil {
+		return ErrTarget
+	}
+	if sc.Retention == 0 {
+		sc.Retention = s.options.Retention
+	}
+
+	_, err := ParseCron(sc.Cron)
+	if err != nil {
+		return err
+	}
+
+	err = s.source(*sc)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.First(&Schedule{}, "ref_id = ? AND name = ?", refID, sc.Name)
+	if err == nil {
+		return ErrScheduleExists
+	}
+	if !s.db.IsNotFound(err) {
+		return err
+	}
+
+	// the first snapshot is taken the next time the expression matches
+	sc.CreatedAt = time.Now().UTC()
+	sc.LastRun = sc.CreatedAt
+	return s.db.Create(sc)
+}
+
+func (s *service) RemoveSchedule(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeSchedule(refID, id)
+}
+
+func (s *service) removeSchedule(refID uint, id uint) error {
+	_, err := s.schedule(refID, id)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Delete(&Schedule{ID: id})
+}
+
+func (s *service) Schedules(refID uint, sc *[]Schedule) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.Find(sc, "ref_id = ?", refID)
+}
+
+func (s *service) Snapshots(refID uint, scheduleID uint, sn *[]Snapshot) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if scheduleID != 0 {
+		_, err := s.schedule(refID, scheduleID)
+		if err != nil {
+			return err
+		}
+	}
+
+	ss, err := s.snapshots(refID, scheduleID)
+	if err != nil {
+		return err
+	}
+
+	*sn = append(*sn, ss...)
+	return nil
+}
+
+func (s *service) RemoveSnapshot(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	sn, err := s.snapshot(refID, id)
+	if err != nil {
+		return err
+	}
+	if sn.State == Running {
+		return ErrSnapshotRunning
+	}
+
+	return s.remove(sn)
+}
+
+// remove deletes sn from its store and the database
+func (s *service) remove(sn Snapshot) error {
+	if sn.State == Succeeded && s.stores[sn.Target] != nil {
+		err := s.stores[sn.Target].Delete(sn.Key)
+		if err != nil {
+			return err
+		}
+	}
+
+	return s.db.Delete(&Snapshot{ID: sn.ID})
+}
+
+func (s *service) TakeSnapshot(refID uint, scheduleID uint) (uint, error) {
+	s.mtx.Lock()
+	sc, err := s.schedule(refID, scheduleID)
+	s.mtx.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	return s.queue.Enqueue(SnapshotJob, snapshotPayload{
+		ScheduleID: sc.ID,
+	})
+}
+
+// volumeDir returns the directory a volume of a user is restored into
+func (s *service) volumeDir(refID uint, volume string) string {
+	return filepath.Join(s.options.RestorePath, fmt.Sprintf("%d", refID), volume)
+}
+
+func (s *service) RestoreSnapshot(refID uint, id uint, volume string) (uint, error) {
+	s.mtx.Lock()
+	sn, err := s.snapshot(refID, id)
+	s.mtx.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	if sn.Kind != Volume || sn.State != Succeeded {
+		return 0, ErrRestore
+	}
+
+	_, err = os.Stat(s.volumeDir(refID, volume))
+	if err == nil {
+		return 0, ErrVolumeExists
+	}
+
+	return s.queue.Enqueue(RestoreJob, restorePayload{
+		SnapshotID: id,
+		Volume:     volume,
+	})
+}
+
+func (s *service) Due(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// the cron expressions are evaluated here, so every schedule is a candidate
+	ss := []Schedule{}
+	err := s.db.Find(&ss)
+	if err != nil {
+		return err
+	}
+
+	for _, sc := range ss {
+		c, err := ParseCron(sc.Cron)
+		if err != nil {
+			continue
+		}
+
+		next := c.Next(sc.LastRun)
+		if next.IsZero() || next.After(now) {
+			continue
+		}
+
+		_, err = s.queue.Enqueue(SnapshotJob, snapshotPayload{
+			ScheduleID: sc.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = abstraction.UpdateByID(s.db, &Schedule{}, sc.ID, &Schedule{LastRun: now.UTC()})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write writes the snapshot of sc into a temporary file in dir and returns its name
+func (s *service) write(sc Schedule, dir string) (string, error) {
+	file := filepath.Join(dir, "snapshot")
+
+	if sc.Kind == Volume {
+		root, err := s.volumes.Volume(sc.RefID, sc.Source)
+		if err != nil {
+			return "", err
+		}
+
+		_, err = backup.Create(file, s.options.Node, []backup.Part{backup.Directory(volumePart, root)})
+		return file, err
+	}
+
+	if s.databases == nil {
+		return "", ErrKind
+	}
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+	err = s.databases.Export(sc.RefID, sc.Source, gz)
+	if err != nil {
+		return "", err
+	}
+
+	err = gz.Close()
+	if err != nil {
+		return "", err
+	}
+	return file, f.Close()
+}
+
+// put writes the snapshot of sc to its store
+func (s *service) put(sc Schedule, key string) (int64, error) {
+	store := s.stores[sc.Target]
+	if store == nil {
+		return 0, ErrTarget
+	}
+
+	dir, err := ioutil.TempDir("", "kroo-snapshot")
+	if err != nil {
+		return 0, err
+	}
+	defer os.RemoveAll(dir)
+
+	file, err := s.write(sc, dir)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), store.Put(key, f, info.Size())
+}
+
+// Snapshot does not lock the service while the snapshot is written
+func (s *service) Snapshot(scheduleID uint) (Snapshot, error) {
+	s.mtx.Lock()
+	sc, err := s.schedule(0, scheduleID)
+	if err != nil {
+		s.mtx.Unlock()
+		return Snapshot{}, err
+	}
+
+	now := time.Now().UTC()
+	ext := ".tar.gz"
+	if sc.Kind == Database {
+		ext = ".sql.gz"
+	}
+	sn := Snapshot{
+		ScheduleID: sc.ID,
+		RefID:      sc.RefID,
+		Kind:       sc.Kind,
+		Source:     sc.Source,
+		Target:     sc.Target,
+		Key:        fmt.Sprintf("%d/%s/%s%s", sc.RefID, sc.Name, now.Format("20060102T150405"), ext),
+		State:      Running,
+		CreatedAt:  now,
+	}
+	err = s.db.Create(&sn)
+	s.mtx.Unlock()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	size, failed := s.put(sc, sn.Key)
+
+	changes := &Snapshot{
+		State:      Succeeded,
+		Size:       size,
+		FinishedAt: time.Now().UTC(),
+	}
+	if failed != nil {
+		changes.State = Failed
+		changes.Error = failed.Error()
+	}
+	sn.State, sn.Size, sn.Error, sn.FinishedAt = changes.State, changes.Size, changes.Error, changes.FinishedAt
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err = abstraction.UpdateByID(s.db, &Snapshot{}, sn.ID, changes)
+	if err != nil {
+		return sn, err
+	}
+
+	err = s.prune(sc)
+	if failed != nil {
+		return sn, failed
+	}
+	return sn, err
+}
+
+// prune removes the succeeded and the failed snapshots of sc exceeding its retention,
+// so failing snapshots do not remove the ones which can be restored
+func (s *service) prune(sc Schedule) error {
+	ss, err := s.snapshots(sc.RefID, sc.ID)
+	if err != nil {
+		return err
+	}
+
+	kept := map[string]uint{}
+	for _, sn := range ss {
+		if sn.State == Running {
+			continue
+		}
+
+		kept[sn.State]++
+		if kept[sn.State] <= sc.Retention {
+			continue
+		}
+
+		err = s.remove(sn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore does not lock the service while the snapshot is restored
+func (s *service) Restore(id uint, volume string) (Snapshot, error) {
+	s.mtx.Lock()
+	sn, err := s.snapshot(0, id)
+	s.mtx.Unlock()
+	if err != nil {
+		return sn, err
+	}
+	if sn.Kind != Volume || sn.State != Succeeded {
+		return sn, ErrRestore
+	}
+
+	store := s.stores[sn.Target]
+	if store == nil {
+		return sn, ErrTarget
+	}
+
+	target := s.volumeDir(sn.RefID, volume)
+	_, err = os.Stat(target)
+	if err == nil {
+		return sn, ErrVolumeExists
+	}
+
+	tmp, err := ioutil.TempFile("", "kroo-restore")
+	if err != nil {
+		return sn, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	r, err := store.Get(sn.Key)
+	if err != nil {
+		return sn, err
+	}
+	_, err = io.Copy(tmp, r)
+	r.Close()
+	if err != nil {
+		return sn, err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return sn, err
+	}
+
+	err = os.MkdirAll(target, 0755)
+	if err != nil {
+		return sn, err
+	}
+
+	_, err = backup.Restore(tmp.Name(), []backup.Part{backup.Directory(volumePart, target)})
+	if err != nil {
+		os.RemoveAll(target)
+		return sn, err
+	}
+	return sn, nil
+}
+
+// NewService creates a SnapshotService, databases may be nil if managed databases are disabled
+// and stores maps the targets, storage.Local and storage.S3, to the configured Stores
+func NewService(db dbAdapter, volumes Volumes, databases Databases, q Queue, stores map[string]storage.Store, o Options) (Service, error) {
+	if o.Retention == 0 {
+		o.Retention = 7
+	}
+
+	s := &service{
+		db:        db,
+		volumes:   volumes,
+		databases: databases,
+		queue:     q,
+		stores:    stores,
+		options:   o,
+		mtx:       &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
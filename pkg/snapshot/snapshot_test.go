@@ -0,0 +1,397 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/database"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/snapshot"
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockVolumes struct {
+	dirs map[string]string
+}
+
+func (v *mockVolumes) Volume(refID uint, name string) (string, error) {
+	dir, ok := v.dirs[name]
+	if !ok {
+		return "", errors.New("container does not exist")
+	}
+	return dir, nil
+}
+
+type mockDatabases struct {
+	err error
+}
+
+func (d *mockDatabases) Databases(refID uint, ds *[]database.Database) error {
+	*ds = append(*ds, database.Database{Name: "app"})
+	return nil
+}
+
+func (d *mockDatabases) Export(refID uint, name string, w io.Writer) error {
+	if d.err != nil {
+		return d.err
+	}
+	_, err := fmt.Fprintf(w, "dump of %s", name)
+	return err
+}
+
+type job struct {
+	typ     string
+	payload string
+}
+
+type mockQueue struct {
+	jobs []job
+}
+
+func (q *mockQueue) Enqueue(typ string, payload interface{}) (uint, error) {
+	b, _ := json.Marshal(payload)
+	q.jobs = append(q.jobs, job{typ: typ, payload: string(b)})
+	return uint(len(q.jobs)), nil
+}
+
+type recorder struct {
+	mtx    sync.Mutex
+	events []events.Event
+}
+
+func (r *recorder) handle(e events.Event) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *recorder) topics() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	ts := []string{}
+	for _, e := range r.events {
+		ts = append(ts, e.Topic)
+	}
+	return ts
+}
+
+func read(s storage.Store, key string) string {
+	r, err := s.Get(key)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	Ω(err).ShouldNot(HaveOccurred())
+	return string(b)
+}
+
+var _ = Describe("Snapshot", func() {
+	Describe("Cron", func() {
+		start := time.Date(2017, 6, 15, 10, 30, 0, 0, time.UTC)
+
+		next := func(expr string, t time.Time) time.Time {
+			c, err := snapshot.ParseCron(expr)
+			Ω(err).ShouldNot(HaveOccurred())
+			return c.Next(t)
+		}
+
+		It("Should find the next time of an expression", func() {
+			Expect(next("* * * * *", start)).To(Equal(start.Add(time.Minute)))
+			Expect(next("0 3 * * *", start)).To(Equal(time.Date(2017, 6, 16, 3, 0, 0, 0, time.UTC)))
+			Expect(next("*/20 * * * *", start)).To(Equal(time.Date(2017, 6, 15, 10, 40, 0, 0, time.UTC)))
+			Expect(next("15,45 9-11 * * *", start)).To(Equal(time.Date(2017, 6, 15, 10, 45, 0, 0, time.UTC)))
+			Expect(next("0 0 1 1 *", start)).To(Equal(time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)))
+			Expect(next("@monthly", start)).To(Equal(time.Date(2017, 7, 1, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("Should match either restricted day field", func() {
+			// the 15th of June 2017 is a Thursday
+			Expect(next("0 0 * * 0", start)).To(Equal(time.Date(2017, 6, 18, 0, 0, 0, 0, time.UTC)))
+			Expect(next("0 0 * * 7", start)).To(Equal(time.Date(2017, 6, 18, 0, 0, 0, 0, time.UTC)))
+			Expect(next("0 0 20 * 6", start)).To(Equal(time.Date(2017, 6, 17, 0, 0, 0, 0, time.UTC)))
+		})
+
+		It("Should return the zero time for impossible dates", func() {
+			Expect(next("0 0 31 2 *", start).IsZero()).To(BeTrue())
+		})
+
+		It("Should reject invalid expressions", func() {
+			for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+				_, err := snapshot.ParseCron(expr)
+				Expect(err).To(HaveOccurred(), expr)
+			}
+		})
+	})
+
+	Describe("Service", func() {
+		var (
+			refID     = uint(1)
+			dir       string
+			volume    string
+			volumes   *mockVolumes
+			databases *mockDatabases
+			queue     *mockQueue
+			store     storage.Store
+			s         snapshot.Service
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "kroo-snapshot")
+			Ω(err).ShouldNot(HaveOccurred())
+
+			volume = filepath.Join(dir, "volume")
+			os.MkdirAll(filepath.Join(volume, "etc"), 0755)
+			ioutil.WriteFile(filepath.Join(volume, "etc", "app.conf"), []byte("port=80"), 0644)
+
+			volumes = &mockVolumes{dirs: map[string]string{"web": volume}}
+			databases = &mockDatabases{}
+			queue = &mockQueue{}
+			store = storage.NewLocalStore(filepath.Join(dir, "store"))
+			s, err = snapshot.NewService(testutils.NewMockDB(), volumes, databases, queue, map[string]storage.Store{
+				storage.Local: store,
+			}, snapshot.Options{
+				Node:        "node1",
+				RestorePath: filepath.Join(dir, "restored"),
+				Retention:   2,
+			})
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		Describe("Create Service", func() {
+			It("Should return db error", func() {
+				db := testutils.NewMockDB()
+				db.SetError(1)
+				_, err := snapshot.NewService(db, volumes, nil, queue, nil, snapshot.Options{})
+				Ω(err).Should(HaveOccurred())
+			})
+		})
+
+		Describe("CreateSchedule", func() {
+			It("Should create a schedule with the default retention and target", func() {
+				sc := &snapshot.Schedule{Name: "daily", Kind: snapshot.Volume, Source: "web", Cron: "0 3 * * *"}
+				Ω(s.CreateSchedule(refID, sc)).Should(Succeed())
+				Expect(sc.ID).ToNot(BeZero())
+				Expect(sc.Retention).To(Equal(uint(2)))
+				Expect(sc.Target).To(Equal(storage.Local))
+
+				ss := []snapshot.Schedule{}
+				Ω(s.Schedules(refID, &ss)).Should(Succeed())
+				Expect(ss).To(HaveLen(1))
+
+				ss = []snapshot.Schedule{}
+				s.Schedules(2, &ss)
+				Expect(ss).To(BeEmpty())
+			})
+
+			It("Should reject invalid schedules", func() {
+				Expect(s.CreateSchedule(refID, &snapshot.Schedule{Name: "a", Kind: snapshot.Volume, Source: "web", Cron: "* * *"})).To(Equal(snapshot.ErrCron))
+				Expect(s.CreateSchedule(refID, &snapshot.Schedule{Name: "a", Kind: snapshot.Volume, Source: "web", Cron: "@daily", Target: storage.S3})).To(Equal(snapshot.ErrTarget))
+				Expect(s.CreateSchedule(refID, &snapshot.Schedule{Name: "a", Kind: snapshot.Volume, Source: "db", Cron: "@daily"})).To(Equal(snapshot.ErrSourceNotExist))
+				Expect(s.CreateSchedule(refID, &snapshot.Schedule{Name: "a", Kind: snapshot.Database, Source: "web", Cron: "@daily"})).To(Equal(snapshot.ErrSourceNotExist))
+				Expect(s.CreateSchedule(refID, &snapshot.Schedule{Name: "a", Kind: "disk", Source: "web", Cron: "@daily"})).To(Equal(snapshot.ErrKind))
+
+				Ω(s.CreateSchedule(refID, &snapshot.Schedule{Name: "a", Kind: snapshot.Database, Source: "app", Cron: "@daily"})).Should(Succeed())
+				Expect(s.CreateSchedule(refID, &snapshot.Schedule{Name: "a", Kind: snapshot.Volume, Source: "web", Cron: "@daily"})).To(Equal(snapshot.ErrScheduleExists))
+			})
+
+			It("Should not snapshot databases if managed databases are disabled", func() {
+				s, _ := snapshot.NewService(testutils.NewMockDB(), volumes, nil, queue, map[string]storage.Store{
+					storage.Local: store,
+				}, snapshot.Options{})
+				Expect(s.CreateSchedule(refID, &snapshot.Schedule{Name: "a", Kind: snapshot.Database, Source: "app", Cron: "@daily"})).To(Equal(snapshot.ErrKind))
+			})
+		})
+
+		Describe("RemoveSchedule", func() {
+			It("Should only remove the schedules of the user", func() {
+				sc := &snapshot.Schedule{Name: "daily", Kind: snapshot.Volume, Source: "web", Cron: "@daily"}
+				s.CreateSchedule(refID, sc)
+
+				Expect(s.RemoveSchedule(2, sc.ID)).To(Equal(snapshot.ErrScheduleNotExist))
+				Ω(s.RemoveSchedule(refID, sc.ID)).Should(Succeed())
+
+				ss := []snapshot.Schedule{}
+				s.Schedules(refID, &ss)
+				Expect(ss).To(BeEmpty())
+			})
+		})
+
+		Describe("Due", func() {
+			It("Should enqueue the schedules whose expression matched since their last run", func() {
+				sc := &snapshot.Schedule{Name: "hourly", Kind: snapshot.Volume, Source: "web", Cron: "@hourly"}
+				s.CreateSchedule(refID, sc)
+
+				Ω(s.Due(time.Now())).Should(Succeed())
+				Expect(queue.jobs).To(BeEmpty())
+
+				later := time.Now().Add(2 * time.Hour)
+				Ω(s.Due(later)).Should(Succeed())
+				Expect(queue.jobs).To(Equal([]job{{typ: snapshot.SnapshotJob, payload: fmt.Sprintf(`{"scheduleID":%d}`, sc.ID)}}))
+
+				Ω(s.Due(later.Add(time.Minute))).Should(Succeed())
+				Expect(queue.jobs).To(HaveLen(1))
+			})
+		})
+
+		Describe("Snapshot", func() {
+			It("Should snapshot a volume and restore it into a new volume", func() {
+				sc := &snapshot.Schedule{Name: "daily", Kind: snapshot.Volume, Source: "web", Cron: "@daily"}
+				s.CreateSchedule(refID, sc)
+
+				id, err := s.TakeSnapshot(refID, sc.ID)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(id).To(Equal(uint(1)))
+
+				handler := snapshot.SnapshotHandler(s)
+				Ω(handler(context.Background(), jobs.Job{Payload: queue.jobs[0].payload})).Should(Succeed())
+
+				ss := []snapshot.Snapshot{}
+				Ω(s.Snapshots(refID, sc.ID, &ss)).Should(Succeed())
+				Expect(ss).To(HaveLen(1))
+				Expect(ss[0].State).To(Equal(snapshot.Succeeded))
+				Expect(ss[0].Key).To(HavePrefix("1/daily/"))
+				Expect(ss[0].Key).To(HaveSuffix(".tar.gz"))
+				Expect(ss[0].Size).To(BeNumerically(">", 0))
+
+				_, err = s.RestoreSnapshot(refID, ss[0].ID, "web-restored")
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(queue.jobs[1].typ).To(Equal(snapshot.RestoreJob))
+
+				restore := snapshot.RestoreHandler(s)
+				Ω(restore(context.Background(), jobs.Job{Payload: queue.jobs[1].payload})).Should(Succeed())
+
+				b, err := ioutil.ReadFile(filepath.Join(dir, "restored", "1", "web-restored", "etc", "app.conf"))
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(string(b)).To(Equal("port=80"))
+
+				_, err = s.RestoreSnapshot(refID, ss[0].ID, "web-restored")
+				Expect(err).To(Equal(snapshot.ErrVolumeExists))
+				_, err = s.RestoreSnapshot(2, ss[0].ID, "web-restored")
+				Expect(err).To(Equal(snapshot.ErrSnapshotNotExist))
+			})
+
+			It("Should snapshot a database as gzipped dump", func() {
+				sc := &snapshot.Schedule{Name: "db", Kind: snapshot.Database, Source: "app", Cron: "@daily"}
+				s.CreateSchedule(refID, sc)
+
+				sn, err := s.Snapshot(sc.ID)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(sn.Key).To(HaveSuffix(".sql.gz"))
+
+				gz, err := gzip.NewReader(bytes.NewBufferString(read(store, sn.Key)))
+				Ω(err).ShouldNot(HaveOccurred())
+				b, _ := ioutil.ReadAll(gz)
+				Expect(string(b)).To(Equal("dump of app"))
+
+				_, err = s.RestoreSnapshot(refID, sn.ID, "app")
+				Expect(err).To(Equal(snapshot.ErrRestore))
+			})
+
+			It("Should keep the restorable snapshots if snapshots fail", func() {
+				sc := &snapshot.Schedule{Name: "db", Kind: snapshot.Database, Source: "app", Cron: "@daily", Retention: 1}
+				s.CreateSchedule(refID, sc)
+
+				first, err := s.Snapshot(sc.ID)
+				Ω(err).ShouldNot(HaveOccurred())
+
+				databases.err = errors.New("connection refused")
+				for i := 0; i < 3; i++ {
+					sn, err := s.Snapshot(sc.ID)
+					Expect(err).To(MatchError("connection refused"))
+					Expect(sn.State).To(Equal(snapshot.Failed))
+					Expect(sn.Error).To(Equal("connection refused"))
+				}
+
+				ss := []snapshot.Snapshot{}
+				s.Snapshots(refID, 0, &ss)
+				Expect(ss).To(HaveLen(2))
+				Expect(ss[0].State).To(Equal(snapshot.Failed))
+				Expect(ss[1].ID).To(Equal(first.ID))
+			})
+
+			It("Should remove the snapshots exceeding the retention from the store", func() {
+				sc := &snapshot.Schedule{Name: "db", Kind: snapshot.Database, Source: "app", Cron: "@daily", Retention: 1}
+				s.CreateSchedule(refID, sc)
+
+				first, _ := s.Snapshot(sc.ID)
+				// keys have a precision of a second
+				time.Sleep(time.Second)
+				second, _ := s.Snapshot(sc.ID)
+
+				_, err := store.Get(first.Key)
+				Expect(err).To(Equal(storage.ErrObjectNotExist))
+
+				Ω(s.RemoveSnapshot(refID, second.ID)).Should(Succeed())
+				_, err = store.Get(second.Key)
+				Expect(err).To(Equal(storage.ErrObjectNotExist))
+
+				ss := []snapshot.Snapshot{}
+				s.Snapshots(refID, sc.ID, &ss)
+				Expect(ss).To(BeEmpty())
+			})
+
+			It("Should skip removed schedules", func() {
+				handler := snapshot.SnapshotHandler(s)
+				Ω(handler(context.Background(), jobs.Job{Payload: `{"scheduleID":42}`})).Should(Succeed())
+			})
+		})
+
+		Describe("Events", func() {
+			It("Should publish the results of snapshots and restores", func() {
+				logger := log.NewNopLogger()
+				bus := events.NewMemoryBus("node1", time.Millisecond, logger)
+				defer bus.Close()
+				rec := &recorder{}
+				bus.Subscribe(events.SnapshotEvents, "", rec.handle)
+
+				s = snapshot.NewEventService(s, bus, logger)
+				sc := &snapshot.Schedule{Name: "daily", Kind: snapshot.Volume, Source: "web", Cron: "@daily"}
+				s.CreateSchedule(refID, sc)
+
+				sn, err := s.Snapshot(sc.ID)
+				Ω(err).ShouldNot(HaveOccurred())
+				_, err = s.Restore(sn.ID, "copy")
+				Ω(err).ShouldNot(HaveOccurred())
+				_, err = s.Restore(sn.ID, "copy")
+				Expect(err).To(Equal(snapshot.ErrVolumeExists))
+
+				Eventually(rec.topics).Should(Equal([]string{events.SnapshotSucceeded, events.SnapshotRestored, events.SnapshotFailed}))
+
+				e := events.SnapshotEvent{}
+				rec.events[2].Decode(&e)
+				Expect(e).To(Equal(events.SnapshotEvent{
+					RefID:      refID,
+					ScheduleID: sc.ID,
+					SnapshotID: sn.ID,
+					Kind:       snapshot.Volume,
+					Source:     "web",
+					Volume:     "copy",
+					Error:      snapshot.ErrVolumeExists.Error(),
+				}))
+			})
+		})
+	})
+})
@@ -0,0 +1,390 @@
+package snapshot
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/snapshot/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC SnapshotServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.SnapshotServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createSchedule: grpctransport.NewServer(
+			endpoints.CreateScheduleEndpoint,
+			DecodeGRPCCreateScheduleRequest,
+			EncodeGRPCCreateScheduleResponse,
+			options...,
+		),
+
+		removeSchedule: grpctransport.NewServer(
+			endpoints.RemoveScheduleEndpoint,
+			DecodeGRPCRemoveScheduleRequest,
+			EncodeGRPCRemoveScheduleResponse,
+			options...,
+		),
+
+		schedules: grpctransport.NewServer(
+			endpoints.SchedulesEndpoint,
+			DecodeGRPCSchedulesRequest,
+			EncodeGRPCSchedulesResponse,
+			options...,
+		),
+
+		snapshots: grpctransport.NewServer(
+			endpoints.SnapshotsEndpoint,
+			DecodeGRPCSnapshotsRequest,
+			EncodeGRPCSnapshotsResponse,
+			options...,
+		),
+
+		removeSnapshot: grpctransport.NewServer(
+			endpoints.RemoveSnapshotEndpoint,
+			DecodeGRPCRemoveSnapshotRequest,
+			EncodeGRPCRemoveSnapshotResponse,
+			options...,
+		),
+
+		takeSnapshot: grpctransport.NewServer(
+			endpoints.TakeSnapshotEndpoint,
+			DecodeGRPCTakeSnapshotRequest,
+			EncodeGRPCTakeSnapshotResponse,
+			options...,
+		),
+
+		restoreSnapshot: grpctransport.NewServer(
+			endpoints.RestoreSnapshotEndpoint,
+			DecodeGRPCRestoreSnapshotRequest,
+			EncodeGRPCRestoreSnapshotResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createSchedule  grpctransport.Handler
+	removeSchedule  grpctransport.Handler
+	schedules       grpctransport.Handler
+	snapshots       grpctransport.Handler
+	removeSnapshot  grpctransport.Handler
+	takeSnapshot    grpctransport.Handler
+	restoreSnapshot grpctransport.Handler
+}
+
+func (s *grpcServer) CreateSchedule(ctx oldcontext.Context, req *pb.CreateScheduleRequest) (*pb.CreateScheduleResponse, error) {
+	_, res, err := s.createSchedule.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateScheduleResponse), nil
+}
+
+func (s *grpcServer) RemoveSchedule(ctx oldcontext.Context, req *pb.RemoveScheduleRequest) (*pb.RemoveScheduleResponse, error) {
+	_, res, err := s.removeSchedule.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveScheduleResponse), nil
+}
+
+func (s *grpcServer) Schedules(ctx oldcontext.Context, req *pb.SchedulesRequest) (*pb.SchedulesResponse, error) {
+	_, res, err := s.schedules.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SchedulesResponse), nil
+}
+
+func (s *grpcServer) Snapshots(ctx oldcontext.Context, req *pb.SnapshotsRequest) (*pb.SnapshotsResponse, error) {
+	_, res, err := s.snapshots.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SnapshotsResponse), nil
+}
+
+func (s *grpcServer) RemoveSnapshot(ctx oldcontext.Context, req *pb.RemoveSnapshotRequest) (*pb.RemoveSnapshotResponse, error) {
+	_, res, err := s.removeSnapshot.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveSnapshotResponse), nil
+}
+
+func (s *grpcServer) TakeSnapshot(ctx oldcontext.Context, req *pb.TakeSnapshotRequest) (*pb.TakeSnapshotResponse, error) {
+	_, res, err := s.takeSnapshot.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.TakeSnapshotResponse), nil
+}
+
+func (s *grpcServer) RestoreSnapshot(ctx oldcontext.Context, req *pb.RestoreSnapshotRequest) (*pb.RestoreSnapshotResponse, error) {
+	_, res, err := s.restoreSnapshot.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RestoreSnapshotResponse), nil
+}
+
+// ConvertSchedule converts a Schedule to its protobuf representation
+func ConvertSchedule(s Schedule) *pb.Schedule {
+	return &pb.Schedule{
+		ID:        uint32(s.ID),
+		Name:      s.Name,
+		Kind:      s.Kind,
+		Source:    s.Source,
+		Cron:      s.Cron,
+		Retention: uint32(s.Retention),
+		Target:    s.Target,
+		LastRun:   s.LastRun.Unix(),
+		CreatedAt: s.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBSchedule converts a protobuf Schedule to a Schedule
+func ConvertPBSchedule(s *pb.Schedule) Schedule {
+	if s == nil {
+		return Schedule{}
+	}
+
+	return Schedule{
+		ID:        uint(s.ID),
+		Name:      s.Name,
+		Kind:      s.Kind,
+		Source:    s.Source,
+		Cron:      s.Cron,
+		Retention: uint(s.Retention),
+		Target:    s.Target,
+		LastRun:   time.Unix(s.LastRun, 0).UTC(),
+		CreatedAt: time.Unix(s.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertSnapshot converts a Snapshot to its protobuf representation
+func ConvertSnapshot(s Snapshot) *pb.Snapshot {
+	sn := &pb.Snapshot{
+		ID:         uint32(s.ID),
+		ScheduleID: uint32(s.ScheduleID),
+		Kind:       s.Kind,
+		Source:     s.Source,
+		Target:     s.Target,
+		Key:        s.Key,
+		Size:       s.Size,
+		State:      s.State,
+		Error:      s.Error,
+		CreatedAt:  s.CreatedAt.Unix(),
+	}
+	if !s.FinishedAt.IsZero() {
+		sn.FinishedAt = s.FinishedAt.Unix()
+	}
+	return sn
+}
+
+// ConvertPBSnapshot converts a protobuf Snapshot to a Snapshot
+func ConvertPBSnapshot(s *pb.Snapshot) Snapshot {
+	if s == nil {
+		return Snapshot{}
+	}
+
+	sn := Snapshot{
+		ID:         uint(s.ID),
+		ScheduleID: uint(s.ScheduleID),
+		Kind:       s.Kind,
+		Source:     s.Source,
+		Target:     s.Target,
+		Key:        s.Key,
+		Size:       s.Size,
+		State:      s.State,
+		Error:      s.Error,
+		CreatedAt:  time.Unix(s.CreatedAt, 0).UTC(),
+	}
+	if s.FinishedAt != 0 {
+		sn.FinishedAt = time.Unix(s.FinishedAt, 0).UTC()
+	}
+	return sn
+}
+
+// DecodeGRPCCreateScheduleRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateSchedule request to a messages/snapshot.proto-domain createschedule request.
+func DecodeGRPCCreateScheduleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateScheduleRequest)
+	return CreateScheduleRequest{
+		RefID: uint(req.RefID),
+		Schedule: &Schedule{
+			Name:      req.Name,
+			Kind:      req.Kind,
+			Source:    req.Source,
+			Cron:      req.Cron,
+			Retention: uint(req.Retention),
+			Target:    req.Target,
+		},
+	}, nil
+}
+
+// EncodeGRPCCreateScheduleResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain createschedule response to a gRPC CreateSchedule response.
+func EncodeGRPCCreateScheduleResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateScheduleResponse)
+	gRPCRes := &pb.CreateScheduleResponse{
+		ID: uint32(res.ID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveScheduleRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveSchedule request to a messages/snapshot.proto-domain removeschedule request.
+func DecodeGRPCRemoveScheduleRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveScheduleRequest)
+	return RemoveScheduleRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveScheduleResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain removeschedule response to a gRPC RemoveSchedule response.
+func EncodeGRPCRemoveScheduleResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveScheduleResponse)
+	gRPCRes := &pb.RemoveScheduleResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSchedulesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Schedules request to a messages/snapshot.proto-domain schedules request.
+func DecodeGRPCSchedulesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SchedulesRequest)
+	return SchedulesRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCSchedulesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain schedules response to a gRPC Schedules response.
+func EncodeGRPCSchedulesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SchedulesResponse)
+	schedules := make([]*pb.Schedule, len(res.Schedules))
+	for i, s := range res.Schedules {
+		schedules[i] = ConvertSchedule(s)
+	}
+
+	gRPCRes := &pb.SchedulesResponse{
+		Schedules: schedules,
+		PageInfo:  paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSnapshotsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Snapshots request to a messages/snapshot.proto-domain snapshots request.
+func DecodeGRPCSnapshotsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SnapshotsRequest)
+	return SnapshotsRequest{
+		RefID:      uint(req.RefID),
+		ScheduleID: uint(req.ScheduleID),
+	}, nil
+}
+
+// EncodeGRPCSnapshotsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain snapshots response to a gRPC Snapshots response.
+func EncodeGRPCSnapshotsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SnapshotsResponse)
+	snapshots := make([]*pb.Snapshot, len(res.Snapshots))
+	for i, s := range res.Snapshots {
+		snapshots[i] = ConvertSnapshot(s)
+	}
+
+	gRPCRes := &pb.SnapshotsResponse{
+		Snapshots: snapshots,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveSnapshotRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveSnapshot request to a messages/snapshot.proto-domain removesnapshot request.
+func DecodeGRPCRemoveSnapshotRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveSnapshotRequest)
+	return RemoveSnapshotRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveSnapshotResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain removesnapshot response to a gRPC RemoveSnapshot response.
+func EncodeGRPCRemoveSnapshotResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveSnapshotResponse)
+	gRPCRes := &pb.RemoveSnapshotResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCTakeSnapshotRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC TakeSnapshot request to a messages/snapshot.proto-domain takesnapshot request.
+func DecodeGRPCTakeSnapshotRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.TakeSnapshotRequest)
+	return TakeSnapshotRequest{
+		RefID:      uint(req.RefID),
+		ScheduleID: uint(req.ScheduleID),
+	}, nil
+}
+
+// EncodeGRPCTakeSnapshotResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain takesnapshot response to a gRPC TakeSnapshot response.
+func EncodeGRPCTakeSnapshotResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(TakeSnapshotResponse)
+	gRPCRes := &pb.TakeSnapshotResponse{
+		JobID: uint32(res.JobID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRestoreSnapshotRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RestoreSnapshot request to a messages/snapshot.proto-domain restoresnapshot request.
+func DecodeGRPCRestoreSnapshotRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RestoreSnapshotRequest)
+	return RestoreSnapshotRequest{
+		RefID:  uint(req.RefID),
+		ID:     uint(req.ID),
+		Volume: req.Volume,
+	}, nil
+}
+
+// EncodeGRPCRestoreSnapshotResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/snapshot.proto-domain restoresnapshot response to a gRPC RestoreSnapshot response.
+func EncodeGRPCRestoreSnapshotResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RestoreSnapshotResponse)
+	gRPCRes := &pb.RestoreSnapshotResponse{
+		JobID: uint32(res.JobID),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,370 @@
+// Package sshgateway bridges SSH sessions of the users to their containers. Users log in with one of
+// their keys and the name of a container as user name, e.g. ssh web@kontainer.ooo, since the host name
+// a client connected to is not part of the SSH protocol. Every session is recorded for audits. Git pushes
+// to the repository of the container, e.g. git push web@kontainer.ooo:web.git, are handed to a Receiver.
+package sshgateway
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrClosed is returned by Serve once the gateway was closed
+var ErrClosed = errors.New("gateway closed")
+
+// Containers looks up the containers of the users and runs commands in them, it is satisfied by
+// the container service
+type Containers interface {
+	// IDForName returns the ID of the container name of the user refID
+	IDForName(ctx context.Context, refID uint, name string) (string, error)
+
+	// Attach runs cmd in a container with its standard streams connected to stdin, stdout and stderr
+	Attach(ctx context.Context, refID uint, id string, cmd []string, env map[string]string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+}
+
+// Receiver receives the git pushes to the containers of the users, it is satisfied by the deploy service
+type Receiver interface {
+	// Receive runs git receive-pack for the repository of the container name with the streams of a push
+	// and returns its exit code
+	Receive(ctx context.Context, refID uint, name string, stdin io.Reader, stdout, stderr io.Writer) (int, error)
+}
+
+// Gateway accepts SSH connections and attaches their sessions to the containers of the users
+type Gateway struct {
+	config     *ssh.ServerConfig
+	containers Containers
+	receiver   Receiver
+	recordings string
+	logger     log.Logger
+
+	mtx       sync.Mutex
+	closed    bool
+	listeners map[net.Listener]bool
+	conns     map[net.Conn]bool
+}
+
+// authenticate maps a key to its user and the user name of the connection to a container of the user
+func (g *Gateway) authenticate(keys KeyStore) func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+	return func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		refID, err := keys.User(key)
+		if err != nil {
+			return nil, err
+		}
+
+		id, err := g.containers.IDForName(context.Background(), refID, meta.User())
+		if err != nil || id == "" {
+			return nil, fmt.Errorf("user %d has no container %s", refID, meta.User())
+		}
+
+		return &ssh.Permissions{
+			Extensions: map[string]string{
+				"refID":     strconv.FormatUint(uint64(refID), 10),
+				"container": id,
+			},
+		}, nil
+	}
+}
+
+// SetReceiver hands the git pushes to r, they are refused if no receiver is set
+func (g *Gateway) SetReceiver(r Receiver) {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+	g.receiver = r
+}
+
+// Serve accepts connections on l until the gateway is closed
+func (g *Gateway) Serve(l net.Listener) error {
+	g.mtx.Lock()
+	if g.closed {
+		g.mtx.Unlock()
+		return ErrClosed
+	}
+	g.listeners[l] = true
+	g.mtx.Unlock()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			g.mtx.Lock()
+			closed := g.closed
+			g.mtx.Unlock()
+			if closed {
+				return ErrClosed
+			}
+			return err
+		}
+
+		go g.handle(c)
+	}
+}
+
+// Close stops accepting connections and closes the open ones
+func (g *Gateway) Close() error {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	g.closed = true
+	for l := range g.listeners {
+		l.Close()
+	}
+	for c := range g.conns {
+		c.Close()
+	}
+	return nil
+}
+
+func (g *Gateway) track(c net.Conn, open bool) bool {
+	g.mtx.Lock()
+	defer g.mtx.Unlock()
+
+	if open {
+		if g.closed {
+			return false
+		}
+		g.conns[c] = true
+	} else {
+		delete(g.conns, c)
+	}
+	return true
+}
+
+func (g *Gateway) handle(c net.Conn) {
+	if !g.track(c, true) {
+		c.Close()
+		return
+	}
+	defer g.track(c, false)
+	defer c.Close()
+
+	conn, chans, reqs, err := ssh.NewServerConn(c, g.config)
+	if err != nil {
+		level.Debug(g.logger).Log("remote", c.RemoteAddr(), "err", err)
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	// the commands of the sessions are killed once the client disconnected
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for nc := range chans {
+		if nc.ChannelType() != "session" {
+			nc.Reject(ssh.UnknownChannelType, "only sessions are supported")
+			continue
+		}
+
+		ch, chReqs, err := nc.Accept()
+		if err != nil {
+			level.Error(g.logger).Log("remote", c.RemoteAddr(), "err", err)
+			continue
+		}
+		go g.session(ctx, conn, ch, chReqs)
+	}
+}
+
+// session handles the requests of a session channel, the session runs the first shell or exec request.
+// Pseudo terminals are refused since the commands are run without one.
+func (g *Gateway) session(ctx context.Context, conn *ssh.ServerConn, ch ssh.Channel, reqs <-chan *ssh.Request) {
+	refID, _ := strconv.ParseUint(conn.Permissions.Extensions["refID"], 10, 64)
+	id := conn.Permissions.Extensions["container"]
+
+	env := make(map[string]string)
+	started := false
+	for req := range reqs {
+		var cmd []string
+		push := false
+		switch req.Type {
+		case "env":
+			var p struct {
+				Name  string
+				Value string
+			}
+			err := ssh.Unmarshal(req.Payload, &p)
+			if err == nil {
+				env[p.Name] = p.Value
+			}
+			req.Reply(err == nil, nil)
+			continue
+		case "shell":
+			cmd = []string{"/bin/sh", "-i"}
+		case "exec":
+			var p struct {
+				Command string
+			}
+			if ssh.Unmarshal(req.Payload, &p) != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			cmd = []string{"/bin/sh", "-c", p.Command}
+			push = isPush(p.Command)
+		default:
+			req.Reply(false, nil)
+			continue
+		}
+
+		if started {
+			req.Reply(false, nil)
+			continue
+		}
+		started = true
+		req.Reply(true, nil)
+
+		go func(env map[string]string) {
+			var code int
+			if push {
+				code = g.receive(ctx, uint(refID), conn.User(), cmd[len(cmd)-1], ch)
+			} else {
+				code = g.run(ctx, uint(refID), conn.User(), id, cmd, env, ch)
+			}
+			ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(code)}))
+			ch.Close()
+		}(env)
+		env = make(map[string]string)
+	}
+}
+
+// run attaches cmd to the container id and records the session, it returns the exit code of cmd
+func (g *Gateway) run(ctx context.Context, refID uint, name, id string, cmd []string, env map[string]string, ch ssh.Channel) int {
+	logger := log.With(g.logger, "user", refID, "container", name)
+
+	rec, err := record(g.recordings, refID, name, cmd)
+	if err != nil {
+		level.Error(logger).Log("recording", "start", "err", err)
+		fmt.Fprintln(ch.Stderr(), "the session could not be recorded")
+		return 255
+	}
+	level.Info(logger).Log("session", "start", "command", cmd[len(cmd)-1])
+
+	/* The command reads from a pipe so it does not wait for the client to close its input once
+	 *  it exited, which exec.Cmd does for other readers. */
+	stdin, w, err := os.Pipe()
+	if err != nil {
+		rec.close(255)
+		level.Error(logger).Log("err", err)
+		return 255
+	}
+	go func() {
+		io.Copy(w, io.TeeReader(ch, rec.stream("stdin")))
+		w.Close()
+	}()
+
+	code, err := g.containers.Attach(ctx, refID, id, cmd, env, stdin,
+		io.MultiWriter(ch, rec.stream("stdout")),
+		io.MultiWriter(ch.Stderr(), rec.stream("stderr")))
+	stdin.Close()
+	w.Close()
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		fmt.Fprintln(ch.Stderr(), err)
+		code = 255
+	}
+
+	err = rec.close(code)
+	if err != nil {
+		level.Error(logger).Log("recording", "close", "err", err)
+	}
+	level.Info(logger).Log("session", "end", "exit", code)
+	return code
+}
+
+// isPush reports whether cmd is the command git runs to push to a repository
+func isPush(cmd string) bool {
+	return strings.HasPrefix(cmd, "git-receive-pack ") || strings.HasPrefix(cmd, "git receive-pack ")
+}
+
+// receive hands a push to the repository of the container name to the receiver, the repository has to be
+// named after the container. Pushes are not recorded since they only carry the pushed objects.
+func (g *Gateway) receive(ctx context.Context, refID uint, name, cmd string, ch ssh.Channel) int {
+	logger := log.With(g.logger, "user", refID, "container", name)
+
+	repo := strings.Trim(cmd[strings.LastIndex(cmd, " ")+1:], `'"`)
+	if strings.TrimSuffix(path.Base(repo), ".git") != name {
+		fmt.Fprintf(ch.Stderr(), "the repository of %s is %s.git\n", name, name)
+		return 128
+	}
+
+	g.mtx.Lock()
+	r := g.receiver
+	g.mtx.Unlock()
+	if r == nil {
+		fmt.Fprintln(ch.Stderr(), "git pushes are not supported")
+		return 128
+	}
+
+	level.Info(logger).Log("push", "start")
+	code, err := r.Receive(ctx, refID, name, ch, ch, ch.Stderr())
+	if err != nil {
+		level.Error(logger).Log("err", err)
+		fmt.Fprintln(ch.Stderr(), err)
+		code = 255
+	}
+	level.Info(logger).Log("push", "end", "exit", code)
+	return code
+}
+
+// NewGateway returns a Gateway identifying itself with hostKey, authenticating the users with keys and
+// recording the sessions in the directory recordings
+func NewGateway(hostKey ssh.Signer, keys KeyStore, containers Containers, recordings string, logger log.Logger) *Gateway {
+	g := &Gateway{
+		containers: containers,
+		recordings: recordings,
+		logger:     logger,
+		listeners:  make(map[net.Listener]bool),
+		conns:      make(map[net.Conn]bool),
+	}
+
+	g.config = &ssh.ServerConfig{
+		PublicKeyCallback: g.authenticate(keys),
+	}
+	g.config.AddHostKey(hostKey)
+	return g
+}
+
+// HostKey loads the private key at path, an ed25519 key is generated and written to path if there is none
+func HostKey(path string) (ssh.Signer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err == nil {
+		return ssh.ParsePrivateKey(b)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := ssh.MarshalPrivateKey(key, "kontainer.ooo")
+	if err != nil {
+		return nil, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromKey(key)
+}
@@ -0,0 +1,61 @@
+package sshgateway
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strconv"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrUnknownKey is returned if a key does not belong to any user
+var ErrUnknownKey = errors.New("unknown key")
+
+// KeyStore returns the users the public keys belong to
+type KeyStore interface {
+	// User returns the ID of the user the key belongs to
+	User(key ssh.PublicKey) (uint, error)
+}
+
+// AuthorizedKeys is a KeyStore reading a file in the authorized_keys format, the comment of a key
+// is the ID of its user. The file is read again for every login so changes apply right away.
+type AuthorizedKeys struct {
+	path string
+}
+
+// User implements KeyStore
+func (a *AuthorizedKeys) User(key ssh.PublicKey) (uint, error) {
+	b, err := ioutil.ReadFile(a.path)
+	if err != nil {
+		return 0, err
+	}
+
+	marshaled := key.Marshal()
+	for len(b) > 0 {
+		k, comment, _, rest, err := ssh.ParseAuthorizedKey(b)
+		if err != nil {
+			// there are no keys left, only comments and empty lines
+			break
+		}
+		b = rest
+
+		if !bytes.Equal(k.Marshal(), marshaled) {
+			continue
+		}
+
+		refID, err := strconv.ParseUint(comment, 10, 64)
+		if err != nil {
+			return 0, ErrUnknownKey
+		}
+		return uint(refID), nil
+	}
+	return 0, ErrUnknownKey
+}
+
+// NewAuthorizedKeys returns an AuthorizedKeys reading the file at path
+func NewAuthorizedKeys(path string) *AuthorizedKeys {
+	return &AuthorizedKeys{
+		path: path,
+	}
+}
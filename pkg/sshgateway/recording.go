@@ -0,0 +1,97 @@
+package sshgateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Frame is a line of a recording, the first frame of a recording describes the session
+// and the following ones hold the data of a stream of the session
+type Frame struct {
+	// Time is the number of seconds since the session started
+	Time      float64    `json:"time"`
+	Stream    string     `json:"stream,omitempty"`
+	Data      string     `json:"data,omitempty"`
+	User      uint       `json:"user,omitempty"`
+	Container string     `json:"container,omitempty"`
+	Command   string     `json:"command,omitempty"`
+	Start     *time.Time `json:"start,omitempty"`
+	// Exit is the exit code of the command, it is set in the last frame
+	Exit *int `json:"exit,omitempty"`
+}
+
+// recording writes the frames of a session as JSON lines
+type recording struct {
+	mtx   sync.Mutex
+	f     *os.File
+	enc   *json.Encoder
+	start time.Time
+}
+
+func (r *recording) write(f Frame) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	f.Time = time.Since(r.start).Seconds()
+	r.enc.Encode(f)
+}
+
+// stream returns a writer recording everything written to it as stream
+func (r *recording) stream(stream string) io.Writer {
+	return streamWriter{r, stream}
+}
+
+func (r *recording) close(exit int) error {
+	r.write(Frame{
+		Exit: &exit,
+	})
+	return r.f.Close()
+}
+
+type streamWriter struct {
+	r      *recording
+	stream string
+}
+
+func (w streamWriter) Write(p []byte) (int, error) {
+	w.r.write(Frame{
+		Stream: w.stream,
+		Data:   string(p),
+	})
+	return len(p), nil
+}
+
+// record starts the recording of a session in dir/<user>/<container>-<start>.jsonl
+func record(dir string, refID uint, container string, cmd []string) (*recording, error) {
+	start := time.Now().UTC()
+	userDir := path.Join(dir, fmt.Sprintf("%d", refID))
+	err := os.MkdirAll(userDir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s-%s.jsonl", container, start.Format("20060102T150405.000000000"))
+	f, err := os.OpenFile(path.Join(userDir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &recording{
+		f:     f,
+		enc:   json.NewEncoder(f),
+		start: start,
+	}
+	r.write(Frame{
+		User:      refID,
+		Container: container,
+		Command:   strings.Join(cmd, " "),
+		Start:     &start,
+	})
+	return r, nil
+}
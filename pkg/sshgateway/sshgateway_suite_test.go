@@ -0,0 +1,13 @@
+package sshgateway_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSshgateway(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Sshgateway Suite")
+}
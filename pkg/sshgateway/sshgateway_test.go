@@ -0,0 +1,335 @@
+package sshgateway_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshgateway"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+)
+
+// containers runs a few fixed commands instead of attaching to real containers
+type containers struct {
+	mtx sync.Mutex
+	env map[string]string
+}
+
+func (c *containers) IDForName(ctx context.Context, refID uint, name string) (string, error) {
+	if refID == 1 && name == "web" {
+		return "c1", nil
+	}
+	return "", errors.New("container does not exist")
+}
+
+func (c *containers) Attach(ctx context.Context, refID uint, id string, cmd []string, env map[string]string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	c.mtx.Lock()
+	c.env = env
+	c.mtx.Unlock()
+
+	if cmd[1] == "-i" {
+		_, err := io.Copy(stdout, stdin)
+		return 0, err
+	}
+
+	switch cmd[2] {
+	case "echo hello":
+		fmt.Fprintln(stdout, "hello")
+		return 0, nil
+	case "fail":
+		fmt.Fprintln(stderr, "failed")
+		return 3, nil
+	}
+	return -1, errors.New("unknown command")
+}
+
+// receiver answers pushes with the pushed data and the name of the container
+type receiver struct{}
+
+func (receiver) Receive(ctx context.Context, refID uint, name string, stdin io.Reader, stdout, stderr io.Writer) (int, error) {
+	_, err := io.Copy(stdout, stdin)
+	fmt.Fprintf(stderr, "deployed %s of user %d\n", name, refID)
+	return 0, err
+}
+
+func newKey() (ssh.Signer, ssh.PublicKey) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	Ω(err).ShouldNot(HaveOccurred())
+	signer, err := ssh.NewSignerFromKey(priv)
+	Ω(err).ShouldNot(HaveOccurred())
+	key, err := ssh.NewPublicKey(pub)
+	Ω(err).ShouldNot(HaveOccurred())
+	return signer, key
+}
+
+func authorizedKey(key ssh.PublicKey, comment string) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))) + " " + comment + "\n"
+}
+
+func readFrames(dir string) []sshgateway.Frame {
+	files, err := filepath.Glob(filepath.Join(dir, "1", "web-*.jsonl"))
+	Ω(err).ShouldNot(HaveOccurred())
+	Ω(files).Should(HaveLen(1))
+
+	f, err := os.Open(files[0])
+	Ω(err).ShouldNot(HaveOccurred())
+	defer f.Close()
+
+	frames := []sshgateway.Frame{}
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		frame := sshgateway.Frame{}
+		Ω(json.Unmarshal(s.Bytes(), &frame)).Should(Succeed())
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+var _ = Describe("Sshgateway", func() {
+	var (
+		dir     string
+		keys    string
+		cs      *containers
+		gateway *sshgateway.Gateway
+		addr    string
+		user    ssh.Signer
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "sshgateway")
+		Ω(err).ShouldNot(HaveOccurred())
+
+		var key ssh.PublicKey
+		user, key = newKey()
+		_, other := newKey()
+		keys = filepath.Join(dir, "authorized_keys")
+		Ω(ioutil.WriteFile(keys, []byte("# users\n"+authorizedKey(other, "2")+authorizedKey(key, "1")), 0600)).Should(Succeed())
+
+		hostKey, err := sshgateway.HostKey(filepath.Join(dir, "host_key"))
+		Ω(err).ShouldNot(HaveOccurred())
+
+		cs = &containers{}
+		gateway = sshgateway.NewGateway(hostKey, sshgateway.NewAuthorizedKeys(keys), cs, filepath.Join(dir, "recordings"), log.NewNopLogger())
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		Ω(err).ShouldNot(HaveOccurred())
+		addr = l.Addr().String()
+		go gateway.Serve(l)
+	})
+
+	AfterEach(func() {
+		gateway.Close()
+		os.RemoveAll(dir)
+	})
+
+	dial := func(name string, signer ssh.Signer) (*ssh.Client, error) {
+		return ssh.Dial("tcp", addr, &ssh.ClientConfig{
+			User:            name,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+	}
+
+	Describe("Authentication", func() {
+		It("Should reject unknown keys", func() {
+			unknown, _ := newKey()
+			_, err := dial("web", unknown)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should reject containers of other users", func() {
+			_, err := dial("db", user)
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should accept the containers of the user", func() {
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			client.Close()
+		})
+	})
+
+	Describe("Sessions", func() {
+		It("Should run commands in the container", func() {
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer client.Close()
+
+			session, err := client.NewSession()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(session.Setenv("GREETING", "hi")).Should(Succeed())
+
+			out, err := session.Output("echo hello")
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(string(out)).Should(Equal("hello\n"))
+
+			cs.mtx.Lock()
+			Ω(cs.env).Should(HaveKeyWithValue("GREETING", "hi"))
+			cs.mtx.Unlock()
+		})
+
+		It("Should return the exit code of commands", func() {
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer client.Close()
+
+			session, err := client.NewSession()
+			Ω(err).ShouldNot(HaveOccurred())
+			stderr := &bytes.Buffer{}
+			session.Stderr = stderr
+
+			err = session.Run("fail")
+			Ω(err).Should(BeAssignableToTypeOf(&ssh.ExitError{}))
+			Ω(err.(*ssh.ExitError).ExitStatus()).Should(Equal(3))
+			Ω(stderr.String()).Should(Equal("failed\n"))
+		})
+
+		It("Should attach shells to the input of the client", func() {
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer client.Close()
+
+			session, err := client.NewSession()
+			Ω(err).ShouldNot(HaveOccurred())
+			session.Stdin = strings.NewReader("ls\n")
+			stdout := &bytes.Buffer{}
+			session.Stdout = stdout
+
+			Ω(session.Shell()).Should(Succeed())
+			Ω(session.Wait()).Should(Succeed())
+			Ω(stdout.String()).Should(Equal("ls\n"))
+		})
+
+		It("Should record the sessions", func() {
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer client.Close()
+
+			session, err := client.NewSession()
+			Ω(err).ShouldNot(HaveOccurred())
+			session.Run("fail")
+
+			frames := readFrames(filepath.Join(dir, "recordings"))
+			Ω(len(frames)).Should(BeNumerically(">=", 3))
+			Ω(frames[0].User).Should(BeEquivalentTo(1))
+			Ω(frames[0].Container).Should(Equal("web"))
+			Ω(frames[0].Command).Should(Equal("/bin/sh -c fail"))
+			Ω(frames[0].Start).ShouldNot(BeNil())
+			Ω(frames).Should(ContainElement(And(
+				WithTransform(func(f sshgateway.Frame) string { return f.Stream }, Equal("stderr")),
+				WithTransform(func(f sshgateway.Frame) string { return f.Data }, Equal("failed\n")),
+			)))
+
+			last := frames[len(frames)-1]
+			Ω(last.Exit).ShouldNot(BeNil())
+			Ω(*last.Exit).Should(Equal(3))
+		})
+
+		It("Should refuse pseudo terminals", func() {
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer client.Close()
+
+			session, err := client.NewSession()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(session.RequestPty("xterm", 24, 80, ssh.TerminalModes{})).ShouldNot(Succeed())
+		})
+	})
+
+	Describe("Pushes", func() {
+		It("Should hand pushes to the receiver", func() {
+			gateway.SetReceiver(receiver{})
+
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer client.Close()
+
+			session, err := client.NewSession()
+			Ω(err).ShouldNot(HaveOccurred())
+			session.Stdin = strings.NewReader("objects")
+			stdout := &bytes.Buffer{}
+			session.Stdout = stdout
+			stderr := &bytes.Buffer{}
+			session.Stderr = stderr
+
+			Ω(session.Run("git-receive-pack '/web.git'")).Should(Succeed())
+			Ω(stdout.String()).Should(Equal("objects"))
+			Ω(stderr.String()).Should(Equal("deployed web of user 1\n"))
+		})
+
+		It("Should only accept pushes to the repository of the container", func() {
+			gateway.SetReceiver(receiver{})
+
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer client.Close()
+
+			session, err := client.NewSession()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = session.Run("git-receive-pack 'db.git'")
+			Ω(err).Should(BeAssignableToTypeOf(&ssh.ExitError{}))
+			Ω(err.(*ssh.ExitError).ExitStatus()).Should(Equal(128))
+		})
+
+		It("Should refuse pushes without a receiver", func() {
+			client, err := dial("web", user)
+			Ω(err).ShouldNot(HaveOccurred())
+			defer client.Close()
+
+			session, err := client.NewSession()
+			Ω(err).ShouldNot(HaveOccurred())
+
+			err = session.Run("git-receive-pack 'web.git'")
+			Ω(err).Should(BeAssignableToTypeOf(&ssh.ExitError{}))
+			Ω(err.(*ssh.ExitError).ExitStatus()).Should(Equal(128))
+		})
+	})
+
+	Describe("Host Key", func() {
+		It("Should keep the generated key", func() {
+			path := filepath.Join(dir, "keys", "host_key")
+			first, err := sshgateway.HostKey(path)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			second, err := sshgateway.HostKey(path)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(second.PublicKey().Marshal()).Should(Equal(first.PublicKey().Marshal()))
+		})
+	})
+
+	Describe("Authorized Keys", func() {
+		It("Should return the user of a key", func() {
+			_, key := newKey()
+			Ω(ioutil.WriteFile(keys, []byte(authorizedKey(key, "42")), 0600)).Should(Succeed())
+
+			refID, err := sshgateway.NewAuthorizedKeys(keys).User(key)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(refID).Should(BeEquivalentTo(42))
+		})
+
+		It("Should not accept keys without a user", func() {
+			_, key := newKey()
+			Ω(ioutil.WriteFile(keys, []byte(authorizedKey(key, "alice")), 0600)).Should(Succeed())
+
+			_, err := sshgateway.NewAuthorizedKeys(keys).User(key)
+			Ω(err).Should(Equal(sshgateway.ErrUnknownKey))
+		})
+	})
+})
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshkey"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshkey/pb"
+)
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCAddKeyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/sshkey.proto-domain addkey request to a gRPC AddKey request.
+func EncodeGRPCAddKeyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*sshkey.AddKeyRequest)
+	return &pb.AddKeyRequest{
+		RefID: uint32(req.RefID),
+		Key:   req.Key.Key,
+		Name:  req.Key.Name,
+	}, nil
+}
+
+// DecodeGRPCAddKeyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC AddKey response to a messages/sshkey.proto-domain addkey response.
+func DecodeGRPCAddKeyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.AddKeyResponse)
+	return &sshkey.AddKeyResponse{
+		ID:          uint(response.ID),
+		Fingerprint: response.Fingerprint,
+		Error:       getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveKeyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/sshkey.proto-domain removekey request to a gRPC RemoveKey request.
+func EncodeGRPCRemoveKeyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*sshkey.RemoveKeyRequest)
+	return &pb.RemoveKeyRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveKeyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveKey response to a messages/sshkey.proto-domain removekey response.
+func DecodeGRPCRemoveKeyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveKeyResponse)
+	return &sshkey.RemoveKeyResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCKeysRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/sshkey.proto-domain keys request to a gRPC Keys request.
+func EncodeGRPCKeysRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*sshkey.KeysRequest)
+	return &pb.KeysRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCKeysResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Keys response to a messages/sshkey.proto-domain keys response.
+func DecodeGRPCKeysResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.KeysResponse)
+	keys := make([]sshkey.Key, len(response.Keys))
+	for i, k := range response.Keys {
+		keys[i] = sshkey.ConvertPBKey(k)
+	}
+
+	return &sshkey.KeysResponse{
+		Keys:  keys,
+		Error: getError(response.Error),
+		Page:  paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
@@ -0,0 +1,22 @@
+package sshkey
+
+import "time"
+
+// Key is a public key a user logs in to the SSH gateway and to their containers with
+type Key struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	Name  string
+	// Type is the algorithm of the key, e.g. ssh-ed25519
+	Type string
+	// Fingerprint is the SHA256 fingerprint of the key, a key can only be added once
+	Fingerprint string `gorm:"unique_index"`
+	// Key is the key in the authorized_keys format without options and comment
+	Key       string `validate:"required"`
+	CreatedAt time.Time
+}
+
+// TableName sets Key's database table name
+func (Key) TableName() string {
+	return "ssh_keys"
+}
@@ -0,0 +1,96 @@
+package sshkey
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// AddKeyRequest is the request struct for the AddKeyEndpoint
+type AddKeyRequest struct {
+	RefID uint `bart:"ref"`
+	Key   *Key `validate:"required"`
+}
+
+// AddKeyResponse is the response struct for the AddKeyEndpoint
+type AddKeyResponse struct {
+	ID          uint
+	Fingerprint string
+	Error       error
+}
+
+// MakeAddKeyEndpoint creates a gokit endpoint which invokes AddKey
+func MakeAddKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(AddKeyRequest)
+		err := s.AddKey(req.RefID, req.Key)
+		if err != nil {
+			return AddKeyResponse{
+				Error: err,
+			}, nil
+		}
+		return AddKeyResponse{
+			ID:          req.Key.ID,
+			Fingerprint: req.Key.Fingerprint,
+		}, nil
+	}
+}
+
+// RemoveKeyRequest is the request struct for the RemoveKeyEndpoint
+type RemoveKeyRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemoveKeyResponse is the response struct for the RemoveKeyEndpoint
+type RemoveKeyResponse struct {
+	Error error
+}
+
+// MakeRemoveKeyEndpoint creates a gokit endpoint which invokes RemoveKey
+func MakeRemoveKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveKeyRequest)
+		err := s.RemoveKey(req.RefID, req.ID)
+		return RemoveKeyResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// KeysRequest is the request struct for the KeysEndpoint
+type KeysRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// KeysResponse is the response struct for the KeysEndpoint
+type KeysResponse struct {
+	Keys  []Key
+	Error error
+	Page  paging.Response
+}
+
+// MakeKeysEndpoint creates a gokit endpoint which invokes Keys
+func MakeKeysEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(KeysRequest)
+		keys := []Key{}
+		err := s.Keys(req.RefID, &keys)
+		if err != nil {
+			return KeysResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&keys, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return KeysResponse{
+			Keys: keys,
+			Page: page,
+		}, nil
+	}
+}
@@ -0,0 +1,33 @@
+package sshkey
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// EventGroup is the group the services of all nodes subscribe to events with
+const EventGroup = "sshkey"
+
+// Subscribe writes the keys of a user into a container once it was created
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	sub, err := bus.Subscribe(events.ContainerCreated, EventGroup, func(e events.Event) error {
+		c := events.ContainerEvent{}
+		err := e.Decode(&c)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+
+		err = s.Inject(c.RefID)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", c.RefID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []events.Subscription{sub}, nil
+}
@@ -0,0 +1,330 @@
+// Package sshkey manages the public keys users log in to the SSH gateway and to their containers with
+package sshkey
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	// ErrInvalidKey occurs if a key is not a line of an authorized_keys file
+	ErrInvalidKey = errors.New("key is not in the authorized_keys format")
+
+	// ErrKeyType occurs if the algorithm of a key is not supported
+	ErrKeyType = errors.New("key type is not supported")
+
+	// ErrWeakKey occurs if a RSA key is shorter than MinRSABits
+	ErrWeakKey = fmt.Errorf("rsa keys need at least %d bits", MinRSABits)
+
+	// ErrKeyExists occurs if a key was added already, by the same or another user
+	ErrKeyExists = errors.New("key exists already")
+
+	// ErrKeyNotExist occurs if a key does not exist
+	ErrKeyNotExist = errors.New("key does not exist")
+)
+
+// MinRSABits is the minimum length of RSA keys
+const MinRSABits = 2048
+
+// Types are the supported key algorithms, DSA keys are not supported
+var Types = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoSKED25519,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoSKECDSA256,
+	ssh.KeyAlgoRSA,
+}
+
+// SSHInterface is the name of the interface KMIs declare SSH support with, the keys of the users are
+// written into their containers which have it
+const SSHInterface = "ssh"
+
+// Service SSHKeyService
+type Service interface {
+	// AddKey adds a key of a user, k.Key is a line of an authorized_keys file.
+	// The name defaults to the comment of the key.
+	AddKey(refID uint, k *Key) error
+
+	// RemoveKey removes a key of a user
+	RemoveKey(refID uint, id uint) error
+
+	// Keys returns the keys of a user
+	Keys(refID uint, k *[]Key) error
+
+	// User returns the user a key belongs to, it is the key store of the SSH gateway
+	User(key ssh.PublicKey) (uint, error)
+
+	// Inject writes the keys of a user into their containers which support SSH
+	Inject(refID uint) error
+}
+
+// Containers are the containers of the users the keys are written into
+type Containers interface {
+	// SSHContainers returns the names of the containers of a user whose KMI has the SSHInterface
+	SSHContainers(refID uint) ([]string, error)
+
+	// SetKeys replaces the keys of a container with authorizedKeys, which is in the authorized_keys format
+	SetKeys(refID uint, name string, authorizedKeys string) error
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db         dbAdapter
+	containers Containers
+	mtx        *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Key{})
+}
+
+// Parse parses a line of an authorized_keys file and checks that its type is supported,
+// it returns the key and its comment
+func Parse(line string) (ssh.PublicKey, string, error) {
+	key, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+	if err != nil {
+		return nil, "", ErrInvalidKey
+	}
+
+	supported := false
+	for _, t := range Types {
+		if key.Type() == t {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return nil, "", ErrKeyType
+	}
+
+	if key.Type() == ssh.KeyAlgoRSA {
+		rsaKey, ok := key.(ssh.CryptoPublicKey).CryptoPublicKey().(*rsa.PublicKey)
+		if !ok || rsaKey.N.BitLen() < MinRSABits {
+			return nil, "", ErrWeakKey
+		}
+	}
+
+	return key, comment, nil
+}
+
+func (s *service) keys() ([]Key, error) {
+	ks := []Key{}
+	err := s.db.Find(&ks)
+	if err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+func (s *service) userKeys(refID uint) ([]Key, error) {
+	all, err := s.keys()
+	if err != nil {
+		return nil, err
+	}
+
+	ks := []Key{}
+	for _, k := range all {
+		if k.RefID == refID {
+			ks = append(ks, k)
+		}
+	}
+	sort.Slice(ks, func(i, j int) bool {
+		return ks[i].ID < ks[j].ID
+	})
+	return ks, nil
+}
+
+func (s *service) AddKey(refID uint, k *Key) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.addKey(refID, k)
+}
+
+func (s *service) addKey(refID uint, k *Key) error {
+	key, comment, err := Parse(k.Key)
+	if err != nil {
+		return err
+	}
+
+	fingerprint := ssh.FingerprintSHA256(key)
+	all, err := s.keys()
+	if err != nil {
+		return err
+	}
+	for _, other := range all {
+		if other.Fingerprint == fingerprint {
+			return ErrKeyExists
+		}
+	}
+
+	name := k.Name
+	if name == "" {
+		name = comment
+	}
+
+	added := &Key{
+		RefID:       refID,
+		Name:        name,
+		Type:        key.Type(),
+		Fingerprint: fingerprint,
+		Key:         strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))),
+		CreatedAt:   time.Now().UTC(),
+	}
+	err = s.db.Create(added)
+	if err != nil {
+		return err
+	}
+
+	*k = *added
+	return s.inject(refID)
+}
+
+func (s *service) RemoveKey(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeKey(refID, id)
+}
+
+func (s *service) removeKey(refID uint, id uint) error {
+	ks, err := s.userKeys(refID)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	for _, k := range ks {
+		if k.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrKeyNotExist
+	}
+
+	err = s.db.Delete(&Key{ID: id})
+	if err != nil {
+		return err
+	}
+	return s.inject(refID)
+}
+
+func (s *service) Keys(refID uint, k *[]Key) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ks, err := s.userKeys(refID)
+	if err != nil {
+		return err
+	}
+
+	*k = append(*k, ks...)
+	return nil
+}
+
+func (s *service) User(key ssh.PublicKey) (uint, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	fingerprint := ssh.FingerprintSHA256(key)
+	ks, err := s.keys()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, k := range ks {
+		if k.Fingerprint == fingerprint {
+			return k.RefID, nil
+		}
+	}
+	return 0, ErrKeyNotExist
+}
+
+func (s *service) Inject(refID uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.inject(refID)
+}
+
+// authorizedKeys returns the keys of a user in the authorized_keys format, it starts with a comment
+// so containers of users without keys get a file replacing the previous one as well
+func authorizedKeys(refID uint, ks []Key) string {
+	lines := []string{fmt.Sprintf("# keys of the user %d, managed by kontainer.ooo", refID)}
+	for _, k := range ks {
+		lines = append(lines, fmt.Sprintf("%s %s", k.Key, k.Name))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (s *service) inject(refID uint) error {
+	if s.containers == nil {
+		return nil
+	}
+
+	names, err := s.containers.SSHContainers(refID)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	ks, err := s.userKeys(refID)
+	if err != nil {
+		return err
+	}
+
+	content := authorizedKeys(refID, ks)
+	for _, name := range names {
+		err = s.containers.SetKeys(refID, name, content)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewService creates a SSHKeyService, the keys are not written into containers if containers is nil
+func NewService(db dbAdapter, containers Containers) (Service, error) {
+	s := &service{
+		db:         db,
+		containers: containers,
+		mtx:        &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,13 @@
+package sshkey_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSSHKey(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SSHKey Suite")
+}
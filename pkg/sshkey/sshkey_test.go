@@ -0,0 +1,229 @@
+package sshkey_test
+
+import (
+	"crypto/dsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshkey"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"golang.org/x/crypto/ssh"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockContainers struct {
+	mtx        sync.Mutex
+	containers map[uint][]string
+	keys       map[string]string
+}
+
+func (c *mockContainers) SSHContainers(refID uint) ([]string, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.containers[refID], nil
+}
+
+func (c *mockContainers) SetKeys(refID uint, name string, authorizedKeys string) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.keys[fmt.Sprintf("%d/%s", refID, name)] = authorizedKeys
+	return nil
+}
+
+func (c *mockContainers) get(key string) string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.keys[key]
+}
+
+func authorizedKey(key interface{}, comment string) (ssh.PublicKey, string) {
+	pub, err := ssh.NewPublicKey(key)
+	Ω(err).ShouldNot(HaveOccurred())
+	line := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub)))
+	if comment != "" {
+		line = fmt.Sprintf("%s %s", line, comment)
+	}
+	return pub, line
+}
+
+func ed25519Key(comment string) (ssh.PublicKey, string) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	Ω(err).ShouldNot(HaveOccurred())
+	return authorizedKey(pub, comment)
+}
+
+var _ = Describe("SSHKey", func() {
+	var (
+		refID      = uint(1)
+		containers *mockContainers
+		s          sshkey.Service
+	)
+
+	BeforeEach(func() {
+		containers = &mockContainers{
+			containers: map[uint][]string{refID: []string{"web"}},
+			keys:       make(map[string]string),
+		}
+		s, _ = sshkey.NewService(testutils.NewMockDB(), containers)
+	})
+
+	Describe("Create Service", func() {
+		It("Should create service", func() {
+			s, err := sshkey.NewService(testutils.NewMockDB(), nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s).ToNot(BeZero())
+		})
+
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := sshkey.NewService(db, nil)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("AddKey", func() {
+		It("Should add a key named after its comment", func() {
+			pub, line := ed25519Key("user@laptop")
+			k := &sshkey.Key{Key: line}
+			err := s.AddKey(refID, k)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(k.ID).ToNot(BeZero())
+			Expect(k.Name).To(Equal("user@laptop"))
+			Expect(k.Type).To(Equal(ssh.KeyAlgoED25519))
+			Expect(k.Fingerprint).To(Equal(ssh.FingerprintSHA256(pub)))
+
+			ks := []sshkey.Key{}
+			Ω(s.Keys(refID, &ks)).Should(Succeed())
+			Expect(ks).To(HaveLen(1))
+			Expect(ks[0].Name).To(Equal("user@laptop"))
+
+			ks = []sshkey.Key{}
+			Ω(s.Keys(2, &ks)).Should(Succeed())
+			Expect(ks).To(BeEmpty())
+		})
+
+		It("Should keep a given name", func() {
+			_, line := ed25519Key("user@laptop")
+			k := &sshkey.Key{Key: line, Name: "laptop"}
+			Ω(s.AddKey(refID, k)).Should(Succeed())
+			Expect(k.Name).To(Equal("laptop"))
+		})
+
+		It("Should reject keys which were added already", func() {
+			_, line := ed25519Key("")
+			Ω(s.AddKey(refID, &sshkey.Key{Key: line})).Should(Succeed())
+
+			err := s.AddKey(refID, &sshkey.Key{Key: line})
+			Ω(err).Should(Equal(sshkey.ErrKeyExists))
+
+			err = s.AddKey(2, &sshkey.Key{Key: line + " other comment"})
+			Ω(err).Should(Equal(sshkey.ErrKeyExists))
+		})
+
+		It("Should validate the key", func() {
+			err := s.AddKey(refID, &sshkey.Key{Key: "ssh-ed25519 invalid"})
+			Ω(err).Should(Equal(sshkey.ErrInvalidKey))
+
+			weak, err := rsa.GenerateKey(rand.Reader, 1024)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, line := authorizedKey(&weak.PublicKey, "")
+			err = s.AddKey(refID, &sshkey.Key{Key: line})
+			Ω(err).Should(Equal(sshkey.ErrWeakKey))
+
+			strong, err := rsa.GenerateKey(rand.Reader, sshkey.MinRSABits)
+			Ω(err).ShouldNot(HaveOccurred())
+			_, line = authorizedKey(&strong.PublicKey, "")
+			Ω(s.AddKey(refID, &sshkey.Key{Key: line})).Should(Succeed())
+
+			params := dsa.Parameters{}
+			Ω(dsa.GenerateParameters(&params, rand.Reader, dsa.L1024N160)).Should(Succeed())
+			dsaKey := &dsa.PrivateKey{PublicKey: dsa.PublicKey{Parameters: params}}
+			Ω(dsa.GenerateKey(dsaKey, rand.Reader)).Should(Succeed())
+			_, line = authorizedKey(&dsaKey.PublicKey, "")
+			err = s.AddKey(refID, &sshkey.Key{Key: line})
+			Ω(err).Should(Equal(sshkey.ErrKeyType))
+		})
+	})
+
+	Describe("RemoveKey", func() {
+		It("Should only remove keys of the user", func() {
+			_, line := ed25519Key("")
+			k := &sshkey.Key{Key: line}
+			s.AddKey(refID, k)
+
+			err := s.RemoveKey(2, k.ID)
+			Ω(err).Should(Equal(sshkey.ErrKeyNotExist))
+
+			err = s.RemoveKey(refID, k.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			ks := []sshkey.Key{}
+			s.Keys(refID, &ks)
+			Expect(ks).To(BeEmpty())
+		})
+	})
+
+	Describe("User", func() {
+		It("Should return the user a key belongs to", func() {
+			pub, line := ed25519Key("")
+			s.AddKey(2, &sshkey.Key{Key: line})
+
+			id, err := s.User(pub)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(id).To(BeEquivalentTo(2))
+
+			unknown, _ := ed25519Key("")
+			_, err = s.User(unknown)
+			Ω(err).Should(Equal(sshkey.ErrKeyNotExist))
+		})
+	})
+
+	Describe("Inject", func() {
+		It("Should write the keys into the containers with SSH support", func() {
+			_, line := ed25519Key("")
+			k := &sshkey.Key{Key: line, Name: "laptop"}
+			Ω(s.AddKey(refID, k)).Should(Succeed())
+
+			lines := strings.Split(strings.TrimSpace(containers.get("1/web")), "\n")
+			Expect(lines).To(HaveLen(2))
+			Expect(lines[0]).To(HavePrefix("#"))
+			Expect(lines[1]).To(Equal(k.Key + " laptop"))
+
+			Ω(s.RemoveKey(refID, k.ID)).Should(Succeed())
+			lines = strings.Split(strings.TrimSpace(containers.get("1/web")), "\n")
+			Expect(lines).To(HaveLen(1))
+		})
+
+		It("Should write the keys into created containers", func() {
+			_, line := ed25519Key("")
+			s.AddKey(refID, &sshkey.Key{Key: line})
+
+			bus := events.NewMemoryBus("node1", time.Second, log.NewNopLogger())
+			defer bus.Close()
+			_, err := sshkey.Subscribe(s, bus, log.NewNopLogger())
+			Ω(err).ShouldNot(HaveOccurred())
+
+			containers.mtx.Lock()
+			containers.containers[refID] = append(containers.containers[refID], "db")
+			containers.mtx.Unlock()
+
+			bus.Publish(events.ContainerCreated, events.ContainerEvent{RefID: refID, Name: "db"})
+			Eventually(func() string {
+				return containers.get("1/db")
+			}).Should(ContainSubstring(line))
+		})
+	})
+})
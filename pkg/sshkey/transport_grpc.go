@@ -0,0 +1,114 @@
+package sshkey
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/sshkey/pb"
+)
+
+// ConvertKey converts a Key to its protobuf representation
+func ConvertKey(k Key) *pb.Key {
+	return &pb.Key{
+		ID:          uint32(k.ID),
+		Name:        k.Name,
+		Type:        k.Type,
+		Fingerprint: k.Fingerprint,
+		Key:         k.Key,
+		CreatedAt:   k.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBKey converts a protobuf Key to a Key
+func ConvertPBKey(k *pb.Key) Key {
+	if k == nil {
+		return Key{}
+	}
+
+	return Key{
+		ID:          uint(k.ID),
+		Name:        k.Name,
+		Type:        k.Type,
+		Fingerprint: k.Fingerprint,
+		Key:         k.Key,
+		CreatedAt:   time.Unix(k.CreatedAt, 0).UTC(),
+	}
+}
+
+// DecodeGRPCAddKeyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC AddKey request to a messages/sshkey.proto-domain addkey request.
+func DecodeGRPCAddKeyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.AddKeyRequest)
+	return AddKeyRequest{
+		RefID: uint(req.RefID),
+		Key: &Key{
+			Name: req.Name,
+			Key:  req.Key,
+		},
+	}, nil
+}
+
+// EncodeGRPCAddKeyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/sshkey.proto-domain addkey response to a gRPC AddKey response.
+func EncodeGRPCAddKeyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(AddKeyResponse)
+	gRPCRes := &pb.AddKeyResponse{
+		ID:          uint32(res.ID),
+		Fingerprint: res.Fingerprint,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveKeyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveKey request to a messages/sshkey.proto-domain removekey request.
+func DecodeGRPCRemoveKeyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveKeyRequest)
+	return RemoveKeyRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveKeyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/sshkey.proto-domain removekey response to a gRPC RemoveKey response.
+func EncodeGRPCRemoveKeyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveKeyResponse)
+	gRPCRes := &pb.RemoveKeyResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCKeysRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Keys request to a messages/sshkey.proto-domain keys request.
+func DecodeGRPCKeysRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.KeysRequest)
+	return KeysRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCKeysResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/sshkey.proto-domain keys response to a gRPC Keys response.
+func EncodeGRPCKeysResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(KeysResponse)
+	keys := make([]*pb.Key, len(res.Keys))
+	for i, k := range res.Keys {
+		keys[i] = ConvertKey(k)
+	}
+
+	gRPCRes := &pb.KeysResponse{
+		Keys:     keys,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+type localStore struct {
+	dir string
+}
+
+func (s *localStore) Put(key string, r io.Reader, size int64) error {
+	err := checkKey(key)
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(s.dir, filepath.FromSlash(key))
+	err = os.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".put")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		return err
+	}
+	if size >= 0 && n != size {
+		return fmt.Errorf("%s: wrote %d of %d bytes", key, n, size)
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+func (s *localStore) Get(key string) (io.ReadCloser, error) {
+	err := checkKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotExist
+	}
+	return f, err
+}
+
+func (s *localStore) Delete(key string) error {
+	err := checkKey(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(filepath.Join(s.dir, filepath.FromSlash(key)))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// NewLocalStore returns a Store keeping the objects as files below dir, objects are written
+// to a temporary file first so readers never see a partial object
+func NewLocalStore(dir string) Store {
+	return &localStore{
+		dir: dir,
+	}
+}
@@ -0,0 +1,371 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// AES256 encrypts the objects with keys managed by the object storage
+	AES256 = "AES256"
+	// KMS encrypts the objects with a key of the key management service of the object storage
+	KMS = "aws:kms"
+)
+
+const (
+	// MinPartSize is the smallest part of a multipart upload accepted by S3
+	MinPartSize = 5 << 20
+	// DefaultPartSize is the part size used if none is configured
+	DefaultPartSize = 16 << 20
+)
+
+// S3Options configure the bucket of an S3 store
+type S3Options struct {
+	// Endpoint is the URL of the object storage, e.g. https://s3.eu-central-1.amazonaws.com
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+
+	// Encryption is the server-side encryption of the objects, AES256, KMS or empty for none
+	Encryption string
+	// KMSKeyID is the key used with KMS, the default key of the bucket is used if it is empty
+	KMSKeyID string
+
+	// PartSize is the size of the parts objects larger than it are uploaded in, it is at least
+	// MinPartSize and defaults to DefaultPartSize
+	PartSize int64
+}
+
+type s3Store struct {
+	options S3Options
+	client  *http.Client
+	now     func() time.Time
+}
+
+// escape encodes a path or query element as required by the canonical request of signature version 4
+func escape(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+	}
+	return strings.Join(segments, "/")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery returns the sorted query of a canonical request
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, k := range keys {
+		for _, v := range q[k] {
+			pairs = append(pairs, strings.Replace(escape(k), "/", "%2F", -1)+"="+strings.Replace(escape(v), "/", "%2F", -1))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// sign adds the AWS signature version 4 of req to its headers, the host and every x-amz header
+// are signed but the payload is not, so uploads are streamed
+func (s *s3Store) sign(req *http.Request) {
+	now := s.now().UTC()
+	date := now.Format("20060102")
+	stamp := now.Format("20060102T150405Z")
+
+	req.Header.Set("X-Amz-Date", stamp)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+
+	headers := map[string]string{
+		"host": req.URL.Host,
+	}
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-amz-") {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	canonicalHeaders := ""
+	for _, k := range names {
+		canonicalHeaders += k + ":" + headers[k] + "\n"
+	}
+	signed := strings.Join(names, ";")
+
+	canonical := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders,
+		signed,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	sum := sha256.Sum256([]byte(canonical))
+
+	scope := strings.Join([]string{date, s.options.Region, "s3", "aws4_request"}, "/")
+	toSign := strings.Join([]string{"AWS4-HMAC-SHA256", stamp, scope, hex.EncodeToString(sum[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.options.SecretKey), date)
+	key = hmacSHA256(key, s.options.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.options.AccessKey, scope, signed, hex.EncodeToString(hmacSHA256(key, toSign)),
+	))
+}
+
+// do sends a signed request for key, the bucket is addressed in the path so any endpoint works
+func (s *s3Store) do(method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	err := checkKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(strings.TrimSuffix(s.options.Endpoint, "/"))
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/" + s.options.Bucket + "/" + key
+	u.RawPath = escape(u.Path)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	s.sign(req)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound && query == nil {
+		res.Body.Close()
+		return nil, ErrObjectNotExist
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		defer res.Body.Close()
+		msg, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("s3: %s %s: %s: %s", method, key, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return res, nil
+}
+
+// encryption returns the headers of the server-side encryption of new objects
+func (s *s3Store) encryption() http.Header {
+	h := http.Header{}
+	if s.options.Encryption != "" {
+		h.Set("X-Amz-Server-Side-Encryption", s.options.Encryption)
+	}
+	if s.options.Encryption == KMS && s.options.KMSKeyID != "" {
+		h.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", s.options.KMSKeyID)
+	}
+	return h
+}
+
+func (s *s3Store) partSize() int64 {
+	if s.options.PartSize < MinPartSize {
+		if s.options.PartSize == 0 {
+			return DefaultPartSize
+		}
+		return MinPartSize
+	}
+	return s.options.PartSize
+}
+
+func (s *s3Store) put(key string, r io.Reader, size int64) error {
+	res, err := s.do(http.MethodPut, key, nil, s.encryption(), r, size)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// Put uploads objects of a known size up to the part size at once, larger ones and streams are
+// uploaded in parts so only one part is buffered
+func (s *s3Store) Put(key string, r io.Reader, size int64) error {
+	partSize := s.partSize()
+	if size >= 0 && size <= partSize {
+		return s.put(key, r, size)
+	}
+
+	buf := make([]byte, partSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		if size >= 0 && int64(n) != size {
+			return fmt.Errorf("%s: read %d of %d bytes", key, n, size)
+		}
+		return s.put(key, bytes.NewReader(buf[:n]), int64(n))
+	}
+	if err != nil {
+		return err
+	}
+
+	// the first part is read into buf again, copying it onto itself
+	return s.multipart(key, io.MultiReader(bytes.NewReader(buf[:n]), r), size, buf)
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int
+	ETag       string
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type s3Error struct {
+	XMLName xml.Name
+	Code    string
+	Message string
+}
+
+// multipart uploads r in parts of the length of buf, the upload is aborted if a part fails
+func (s *s3Store) multipart(key string, r io.Reader, size int64, buf []byte) error {
+	res, err := s.do(http.MethodPost, key, url.Values{"uploads": {""}}, s.encryption(), nil, 0)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	initiated := initiateMultipartUploadResult{}
+	err = xml.NewDecoder(res.Body).Decode(&initiated)
+	if err != nil {
+		return err
+	}
+	uploadID := initiated.UploadID
+
+	err = s.upload(key, uploadID, r, size, buf)
+	if err != nil {
+		res, abortErr := s.do(http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil, 0)
+		if abortErr == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *s3Store) upload(key, uploadID string, r io.Reader, size int64, buf []byte) error {
+	complete := completeMultipartUpload{}
+	var total int64
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(r, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		total += int64(n)
+
+		res, err := s.do(http.MethodPut, key, url.Values{
+			"partNumber": {strconv.Itoa(number)},
+			"uploadId":   {uploadID},
+		}, nil, bytes.NewReader(buf[:n]), int64(n))
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		complete.Parts = append(complete.Parts, completedPart{
+			PartNumber: number,
+			ETag:       res.Header.Get("ETag"),
+		})
+
+		if n < len(buf) {
+			break
+		}
+	}
+	if size >= 0 && total != size {
+		return fmt.Errorf("%s: read %d of %d bytes", key, total, size)
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	res, err := s.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, nil, bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// completing an upload may fail after the status has been sent, the error is in the body then
+	result := s3Error{}
+	err = xml.NewDecoder(res.Body).Decode(&result)
+	if err == nil && result.XMLName.Local == "Error" {
+		return fmt.Errorf("s3: complete %s: %s: %s", key, result.Code, result.Message)
+	}
+	return nil
+}
+
+func (s *s3Store) Get(key string) (io.ReadCloser, error) {
+	res, err := s.do(http.MethodGet, key, nil, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (s *s3Store) Delete(key string) error {
+	res, err := s.do(http.MethodDelete, key, nil, nil, nil, 0)
+	if err == ErrObjectNotExist {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// NewS3Store returns a Store keeping the objects in a bucket of an S3 compatible object storage
+func NewS3Store(o S3Options) Store {
+	return &s3Store{
+		options: o,
+		client:  &http.Client{},
+		now:     time.Now,
+	}
+}
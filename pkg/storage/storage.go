@@ -0,0 +1,139 @@
+// Package storage keeps the artifacts of an installation, like module bundles, backups, snapshots, invoices,
+// sites and data exports, on the local disk or in an S3 compatible object storage
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	// Local keeps the objects in a directory of the node
+	Local = "local"
+	// S3 keeps the objects in a bucket of an S3 compatible object storage
+	S3 = "s3"
+)
+
+const (
+	// Bundles is the prefix of the module bundles
+	Bundles = "bundles"
+	// Backups is the prefix of the backup archives
+	Backups = "backups"
+	// Snapshots is the prefix of the snapshots of the users
+	Snapshots = "snapshots"
+	// Invoices is the prefix of the invoices and credit notes of the users
+	Invoices = "invoices"
+	// Assets is the prefix of the frontend assets of the modules
+	Assets = "assets"
+	// Sites is the prefix of the bundles of the static sites
+	Sites = "sites"
+	// Exports is the prefix of the archives of the personal data of the users
+	Exports = "exports"
+	// ContainerExports is the prefix of the archives of the volumes of exported instances
+	ContainerExports = "container-exports"
+)
+
+// PathPrefix marks paths which are keys of the artifact store instead of files of the node,
+// e.g. storage:php.kmi
+const PathPrefix = "storage:"
+
+var (
+	// ErrObjectNotExist occurs if an object is not in its store
+	ErrObjectNotExist = errors.New("object does not exist")
+
+	// ErrInvalidKey occurs for keys which would leave the store
+	ErrInvalidKey = errors.New("invalid key")
+)
+
+// Store keeps objects under slash separated keys
+type Store interface {
+	// Put stores the content of r as key, an existing object is replaced. size is the length of r,
+	// a negative size streams r until io.EOF
+	Put(key string, r io.Reader, size int64) error
+
+	// Get returns the content of key
+	Get(key string) (io.ReadCloser, error)
+
+	// Delete removes key, removing a missing key is no error
+	Delete(key string) error
+}
+
+// checkKey makes sure key is a relative slash separated path without . and .. elements
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+type prefixStore struct {
+	store  Store
+	prefix string
+}
+
+func (p *prefixStore) key(key string) (string, error) {
+	err := checkKey(key)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(p.prefix, key), nil
+}
+
+func (p *prefixStore) Put(key string, r io.Reader, size int64) error {
+	key, err := p.key(key)
+	if err != nil {
+		return err
+	}
+	return p.store.Put(key, r, size)
+}
+
+func (p *prefixStore) Get(key string) (io.ReadCloser, error) {
+	key, err := p.key(key)
+	if err != nil {
+		return nil, err
+	}
+	return p.store.Get(key)
+}
+
+func (p *prefixStore) Delete(key string) error {
+	key, err := p.key(key)
+	if err != nil {
+		return err
+	}
+	return p.store.Delete(key)
+}
+
+// Prefix returns a Store keeping its objects below prefix in s, so the artifacts of several
+// services share one store
+func Prefix(s Store, prefix string) Store {
+	return &prefixStore{
+		store:  s,
+		prefix: prefix,
+	}
+}
+
+// Options configure the store of an installation
+type Options struct {
+	// Backend is Local or S3
+	Backend string
+
+	// LocalPath is the directory of the Local backend
+	LocalPath string
+
+	// S3 configure the bucket of the S3 backend
+	S3 S3Options
+}
+
+// New returns the Store of the backend configured in o
+func New(o Options) (Store, error) {
+	switch o.Backend {
+	case Local:
+		return NewLocalStore(o.LocalPath), nil
+	case S3:
+		return NewS3Store(o.S3), nil
+	}
+	return nil, fmt.Errorf("unknown storage backend %q", o.Backend)
+}
@@ -0,0 +1,289 @@
+package storage_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/storage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func read(s storage.Store, key string) string {
+	r, err := s.Get(key)
+	Ω(err).ShouldNot(HaveOccurred())
+	defer r.Close()
+
+	b, err := ioutil.ReadAll(r)
+	Ω(err).ShouldNot(HaveOccurred())
+	return string(b)
+}
+
+// onlyReader hides the other interfaces of a reader, so its size is unknown to the http client
+type onlyReader struct {
+	io.Reader
+}
+
+// mockS3 is a bucket supporting single and multipart uploads
+type mockS3 struct {
+	mtx     sync.Mutex
+	objects map[string]string
+	parts   map[string]map[string]string
+	headers []http.Header
+	queries []string
+	uploads int
+}
+
+func (m *mockS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	m.headers = append(m.headers, r.Header)
+	m.queries = append(m.queries, r.Method+" "+r.URL.RawQuery)
+	q := r.URL.Query()
+	b, _ := ioutil.ReadAll(r.Body)
+
+	_, initiate := q["uploads"]
+
+	switch {
+	case r.Method == http.MethodPost && initiate:
+		m.uploads++
+		id := fmt.Sprintf("upload%d", m.uploads)
+		m.parts[id] = make(map[string]string)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case r.Method == http.MethodPut && q.Get("uploadId") != "":
+		m.parts[q.Get("uploadId")][q.Get("partNumber")] = string(b)
+		w.Header().Set("ETag", `"`+q.Get("partNumber")+`"`)
+	case r.Method == http.MethodPost && q.Get("uploadId") != "":
+		parts := m.parts[q.Get("uploadId")]
+		numbers := []string{}
+		for n := range parts {
+			numbers = append(numbers, n)
+		}
+		sort.Strings(numbers)
+		o := ""
+		for _, n := range numbers {
+			Expect(string(b)).To(ContainSubstring(fmt.Sprintf(`<PartNumber>%s</PartNumber><ETag>&#34;%s&#34;</ETag>`, n, n)))
+			o += parts[n]
+		}
+		m.objects[r.URL.Path] = o
+		delete(m.parts, q.Get("uploadId"))
+		io.WriteString(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+	case r.Method == http.MethodDelete && q.Get("uploadId") != "":
+		delete(m.parts, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		m.objects[r.URL.Path] = string(b)
+	case r.Method == http.MethodGet:
+		o, ok := m.objects[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, o)
+	case r.Method == http.MethodDelete:
+		if _, ok := m.objects[r.URL.Path]; !ok {
+			http.NotFound(w, r)
+			return
+		}
+		delete(m.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+var _ = Describe("Storage", func() {
+	Describe("Local Store", func() {
+		It("Should put, get and delete objects", func() {
+			dir, _ := ioutil.TempDir("", "kroo-store")
+			defer os.RemoveAll(dir)
+			s := storage.NewLocalStore(dir)
+
+			Ω(s.Put("1/daily/a.tar.gz", strings.NewReader("data"), 4)).Should(Succeed())
+			Expect(read(s, "1/daily/a.tar.gz")).To(Equal("data"))
+
+			Ω(s.Delete("1/daily/a.tar.gz")).Should(Succeed())
+			_, err := s.Get("1/daily/a.tar.gz")
+			Expect(err).To(Equal(storage.ErrObjectNotExist))
+			Ω(s.Delete("1/daily/a.tar.gz")).Should(Succeed())
+		})
+
+		It("Should stream objects of an unknown size", func() {
+			dir, _ := ioutil.TempDir("", "kroo-store")
+			defer os.RemoveAll(dir)
+			s := storage.NewLocalStore(dir)
+
+			Ω(s.Put("a", strings.NewReader("streamed"), -1)).Should(Succeed())
+			Expect(read(s, "a")).To(Equal("streamed"))
+		})
+
+		It("Should reject short writes and keys leaving the store", func() {
+			dir, _ := ioutil.TempDir("", "kroo-store")
+			defer os.RemoveAll(dir)
+			s := storage.NewLocalStore(dir)
+
+			Expect(s.Put("a", strings.NewReader("da"), 4)).To(HaveOccurred())
+			_, err := s.Get("a")
+			Expect(err).To(Equal(storage.ErrObjectNotExist))
+
+			for _, key := range []string{"", "/etc/passwd", "../a", "a/../../b"} {
+				Expect(s.Put(key, strings.NewReader(""), 0)).To(Equal(storage.ErrInvalidKey), key)
+			}
+		})
+	})
+
+	Describe("Prefix", func() {
+		It("Should keep the objects below the prefix", func() {
+			dir, _ := ioutil.TempDir("", "kroo-store")
+			defer os.RemoveAll(dir)
+			s := storage.NewLocalStore(dir)
+			p := storage.Prefix(s, storage.Bundles)
+
+			Ω(p.Put("php.kmi", strings.NewReader("kmi"), 3)).Should(Succeed())
+			Expect(read(s, "bundles/php.kmi")).To(Equal("kmi"))
+			Expect(read(p, "php.kmi")).To(Equal("kmi"))
+
+			Expect(p.Put("../backups/a", strings.NewReader(""), 0)).To(Equal(storage.ErrInvalidKey))
+			Ω(p.Delete("php.kmi")).Should(Succeed())
+			_, err := s.Get("bundles/php.kmi")
+			Expect(err).To(Equal(storage.ErrObjectNotExist))
+		})
+	})
+
+	Describe("New", func() {
+		It("Should return the store of the backend", func() {
+			s, err := storage.New(storage.Options{Backend: storage.Local, LocalPath: "/tmp"})
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s).NotTo(BeNil())
+
+			_, err = storage.New(storage.Options{Backend: "ftp"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("S3 Store", func() {
+		var (
+			server *httptest.Server
+			bucket *mockS3
+		)
+
+		BeforeEach(func() {
+			bucket = &mockS3{
+				objects: make(map[string]string),
+				parts:   make(map[string]map[string]string),
+			}
+			server = httptest.NewServer(bucket)
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Should store the objects in the bucket with signed requests", func() {
+			s := storage.NewS3Store(storage.S3Options{
+				Endpoint:  server.URL,
+				Region:    "eu-central-1",
+				Bucket:    "snapshots",
+				AccessKey: "AKID",
+				SecretKey: "secret",
+			})
+
+			Ω(s.Put("1/daily/a.tar.gz", strings.NewReader("data"), 4)).Should(Succeed())
+			Expect(bucket.objects).To(HaveKeyWithValue("/snapshots/1/daily/a.tar.gz", "data"))
+			Expect(read(s, "1/daily/a.tar.gz")).To(Equal("data"))
+
+			Ω(s.Delete("1/daily/a.tar.gz")).Should(Succeed())
+			Ω(s.Delete("1/daily/a.tar.gz")).Should(Succeed())
+			_, err := s.Get("1/daily/a.tar.gz")
+			Expect(err).To(Equal(storage.ErrObjectNotExist))
+
+			for _, h := range bucket.headers {
+				a := h.Get("Authorization")
+				Expect(a).To(HavePrefix("AWS4-HMAC-SHA256 Credential=AKID/"))
+				Expect(a).To(ContainSubstring("/eu-central-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="))
+			}
+		})
+
+		It("Should request the server-side encryption of new objects", func() {
+			s := storage.NewS3Store(storage.S3Options{
+				Endpoint:   server.URL,
+				Bucket:     "artifacts",
+				Encryption: storage.KMS,
+				KMSKeyID:   "key1",
+			})
+
+			Ω(s.Put("a", strings.NewReader("data"), 4)).Should(Succeed())
+			h := bucket.headers[0]
+			Expect(h.Get("X-Amz-Server-Side-Encryption")).To(Equal("aws:kms"))
+			Expect(h.Get("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id")).To(Equal("key1"))
+			Expect(h.Get("Authorization")).To(ContainSubstring("SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-server-side-encryption;x-amz-server-side-encryption-aws-kms-key-id,"))
+
+			read(s, "a")
+			Expect(bucket.headers[1].Get("X-Amz-Server-Side-Encryption")).To(BeEmpty())
+		})
+
+		It("Should upload large objects and streams in parts", func() {
+			s := storage.NewS3Store(storage.S3Options{
+				Endpoint:   server.URL,
+				Bucket:     "artifacts",
+				Encryption: storage.AES256,
+				PartSize:   storage.MinPartSize,
+			})
+
+			data := bytes.Repeat([]byte("0123456789"), storage.MinPartSize/4)
+			Ω(s.Put("backups/a.tar", onlyReader{bytes.NewReader(data)}, -1)).Should(Succeed())
+			Expect(bucket.objects["/artifacts/backups/a.tar"]).To(Equal(string(data)))
+			Expect(bucket.queries).To(Equal([]string{
+				"POST uploads=",
+				"PUT partNumber=1&uploadId=upload1",
+				"PUT partNumber=2&uploadId=upload1",
+				"PUT partNumber=3&uploadId=upload1",
+				"POST uploadId=upload1",
+			}))
+			Expect(bucket.headers[0].Get("X-Amz-Server-Side-Encryption")).To(Equal("AES256"))
+
+			bucket.queries = nil
+			Ω(s.Put("backups/b.tar", bytes.NewReader(data), int64(len(data)))).Should(Succeed())
+			Expect(bucket.objects["/artifacts/backups/b.tar"]).To(Equal(string(data)))
+			Expect(bucket.queries).To(HaveLen(5))
+		})
+
+		It("Should upload small streams at once", func() {
+			s := storage.NewS3Store(storage.S3Options{Endpoint: server.URL, Bucket: "artifacts"})
+
+			Ω(s.Put("a", onlyReader{strings.NewReader("small")}, -1)).Should(Succeed())
+			Ω(s.Put("empty", onlyReader{strings.NewReader("")}, -1)).Should(Succeed())
+			Expect(bucket.objects).To(Equal(map[string]string{"/artifacts/a": "small", "/artifacts/empty": ""}))
+			Expect(bucket.queries).To(Equal([]string{"PUT ", "PUT "}))
+		})
+
+		It("Should abort failed multipart uploads", func() {
+			s := storage.NewS3Store(storage.S3Options{Endpoint: server.URL, Bucket: "artifacts", PartSize: storage.MinPartSize})
+
+			data := bytes.Repeat([]byte("0"), storage.MinPartSize+1)
+			err := s.Put("a", bytes.NewReader(data), int64(len(data)+1))
+			Expect(err).To(MatchError(ContainSubstring("read 5242881 of 5242882 bytes")))
+			Expect(bucket.queries[len(bucket.queries)-1]).To(Equal("DELETE uploadId=upload1"))
+			Expect(bucket.parts).To(BeEmpty())
+			Expect(bucket.objects).To(BeEmpty())
+		})
+
+		It("Should return the errors of the object storage", func() {
+			server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "AccessDenied", http.StatusForbidden)
+			})
+			s := storage.NewS3Store(storage.S3Options{Endpoint: server.URL, Bucket: "snapshots"})
+
+			err := s.Put("a", strings.NewReader("data"), 4)
+			Expect(err).To(MatchError(ContainSubstring("403 Forbidden: AccessDenied")))
+		})
+	})
+})
@@ -0,0 +1,297 @@
+package testutils
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
+)
+
+// ErrChaos is returned by the calls a Scenario lets fail
+var ErrChaos = errors.New("chaos: injected fault")
+
+// Fault is the kind of fault injected into a call
+type Fault int
+
+const (
+	// NoFault lets the call through
+	NoFault Fault = iota
+	// DelayFault delays the call
+	DelayFault
+	// ErrorFault fails the call without invoking the wrapped dependency
+	ErrorFault
+	// PartialFault invokes the wrapped dependency but fails the call anyway,
+	// like a write which was applied before the connection broke
+	PartialFault
+)
+
+// Injection is a fault a Scenario injected into a call
+type Injection struct {
+	Call  int
+	Op    string
+	Fault Fault
+	Delay time.Duration
+}
+
+// Scenario decides which calls of the chaos wrappers fail. The decisions only depend on the seed
+// and the order of the calls, so a scenario which broke the code under test can be replayed.
+type Scenario struct {
+	// ErrorRate, PartialRate and DelayRate are the probabilities of the faults of every call
+	ErrorRate   float64
+	PartialRate float64
+	DelayRate   float64
+	// MaxDelay is the longest delay a call is delayed by
+	MaxDelay time.Duration
+
+	mtx        sync.Mutex
+	rand       *rand.Rand
+	ops        map[string]bool
+	calls      int
+	injections []Injection
+}
+
+// Only restricts the faults to the given operations, e.g. "db.Create" or "iptables.CreateRule"
+func (s *Scenario) Only(ops ...string) *Scenario {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.ops = make(map[string]bool)
+	for _, op := range ops {
+		s.ops[op] = true
+	}
+	return s
+}
+
+// Injections returns the faults injected so far in the order of the calls
+func (s *Scenario) Injections() []Injection {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return append([]Injection{}, s.injections...)
+}
+
+// decide draws the fault of the next call to op, every call draws the same numbers
+// so restricting the operations does not change the faults of the remaining ones
+func (s *Scenario) decide(op string) Injection {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.calls++
+	f, d := s.rand.Float64(), s.rand.Float64()
+	in := Injection{
+		Call: s.calls,
+		Op:   op,
+	}
+	if s.ops != nil && !s.ops[op] {
+		return in
+	}
+
+	switch {
+	case f < s.ErrorRate:
+		in.Fault = ErrorFault
+	case f < s.ErrorRate+s.PartialRate:
+		in.Fault = PartialFault
+	case f < s.ErrorRate+s.PartialRate+s.DelayRate:
+		in.Fault = DelayFault
+		in.Delay = time.Duration(d * float64(s.MaxDelay))
+	default:
+		return in
+	}
+
+	s.injections = append(s.injections, in)
+	return in
+}
+
+// run calls f under the fault drawn for op
+func (s *Scenario) run(op string, f func() error) error {
+	in := s.decide(op)
+	switch in.Fault {
+	case ErrorFault:
+		return ErrChaos
+	case PartialFault:
+		f()
+		return ErrChaos
+	case DelayFault:
+		time.Sleep(in.Delay)
+	}
+	return f()
+}
+
+// NewScenario returns a Scenario drawing its faults from seed, it injects no faults until the rates are set
+func NewScenario(seed int64) *Scenario {
+	return &Scenario{
+		rand: rand.New(rand.NewSource(seed)),
+	}
+}
+
+// ChaosDB injects the faults of a scenario into the calls of a database
+type ChaosDB struct {
+	abstraction.DB
+	s *Scenario
+}
+
+// Where injects faults into the wrapped Where
+func (c *ChaosDB) Where(query interface{}, args ...interface{}) error {
+	return c.s.run("db.Where", func() error {
+		return c.DB.Where(query, args...)
+	})
+}
+
+// First injects faults into the wrapped First
+func (c *ChaosDB) First(out interface{}, where ...interface{}) error {
+	return c.s.run("db.First", func() error {
+		return c.DB.First(out, where...)
+	})
+}
+
+// Find injects faults into the wrapped Find
+func (c *ChaosDB) Find(out interface{}, where ...interface{}) error {
+	return c.s.run("db.Find", func() error {
+		return c.DB.Find(out, where...)
+	})
+}
+
+// Create injects faults into the wrapped Create
+func (c *ChaosDB) Create(value interface{}) error {
+	return c.s.run("db.Create", func() error {
+		return c.DB.Create(value)
+	})
+}
+
+// Delete injects faults into the wrapped Delete
+func (c *ChaosDB) Delete(value interface{}, where ...interface{}) error {
+	return c.s.run("db.Delete", func() error {
+		return c.DB.Delete(value, where...)
+	})
+}
+
+// Update injects faults into the wrapped Update
+func (c *ChaosDB) Update(model interface{}, attrs ...interface{}) error {
+	return c.s.run("db.Update", func() error {
+		return c.DB.Update(model, attrs...)
+	})
+}
+
+// FindOrdered injects faults into the wrapped FindOrdered
+func (c *ChaosDB) FindOrdered(out interface{}, order string, limit int, where ...interface{}) error {
+	return c.s.run("db.FindOrdered", func() error {
+		return c.DB.FindOrdered(out, order, limit, where...)
+	})
+}
+
+// Sum injects faults into the wrapped Sum
+func (c *ChaosDB) Sum(model interface{}, column string, out *int64, where ...interface{}) error {
+	return c.s.run("db.Sum", func() error {
+		return c.DB.Sum(model, column, out, where...)
+	})
+}
+
+// Pluck injects faults into the wrapped Pluck
+func (c *ChaosDB) Pluck(model interface{}, column string, out interface{}, where ...interface{}) error {
+	return c.s.run("db.Pluck", func() error {
+		return c.DB.Pluck(model, column, out, where...)
+	})
+}
+
+// AppendToArray injects faults into the wrapped AppendToArray
+func (c *ChaosDB) AppendToArray(query interface{}, target string, values interface{}) error {
+	return c.s.run("db.AppendToArray", func() error {
+		return c.DB.AppendToArray(query, target, values)
+	})
+}
+
+// RemoveFromArray injects faults into the wrapped RemoveFromArray
+func (c *ChaosDB) RemoveFromArray(query interface{}, target string, index int) error {
+	return c.s.run("db.RemoveFromArray", func() error {
+		return c.DB.RemoveFromArray(query, target, index)
+	})
+}
+
+// WithContext binds c to ctx, the faults are still injected
+func (c *ChaosDB) WithContext(ctx context.Context) abstraction.DB {
+	return abstraction.NewContextDB(c, ctx)
+}
+
+// NewChaosDB wraps db, AutoMigrate and the transactions are not affected by s
+func NewChaosDB(db abstraction.DB, s *Scenario) *ChaosDB {
+	return &ChaosDB{
+		DB: db,
+		s:  s,
+	}
+}
+
+// ChaosDCli injects the faults of a scenario into the calls of a docker client
+type ChaosDCli struct {
+	d abstraction.DCli
+	s *Scenario
+}
+
+// NetworkCreate injects faults into the wrapped NetworkCreate
+func (c *ChaosDCli) NetworkCreate() error {
+	return c.s.run("docker.NetworkCreate", c.d.NetworkCreate)
+}
+
+// NetworkRemove injects faults into the wrapped NetworkRemove
+func (c *ChaosDCli) NetworkRemove() error {
+	return c.s.run("docker.NetworkRemove", c.d.NetworkRemove)
+}
+
+// NetworkConnect injects faults into the wrapped NetworkConnect
+func (c *ChaosDCli) NetworkConnect() error {
+	return c.s.run("docker.NetworkConnect", c.d.NetworkConnect)
+}
+
+// NetworkDisconnect injects faults into the wrapped NetworkDisconnect
+func (c *ChaosDCli) NetworkDisconnect() error {
+	return c.s.run("docker.NetworkDisconnect", c.d.NetworkDisconnect)
+}
+
+// NetworkInspect injects faults into the wrapped NetworkInspect
+func (c *ChaosDCli) NetworkInspect() error {
+	return c.s.run("docker.NetworkInspect", c.d.NetworkInspect)
+}
+
+// NewChaosDCli wraps d
+func NewChaosDCli(d abstraction.DCli, s *Scenario) *ChaosDCli {
+	return &ChaosDCli{
+		d: d,
+		s: s,
+	}
+}
+
+// ChaosIPT injects the faults of a scenario into the calls of an iptables executor
+type ChaosIPT struct {
+	iptables.Service
+	s *Scenario
+}
+
+// CreateRule injects faults into the wrapped CreateRule
+func (c *ChaosIPT) CreateRule(ruleType int, ruleData interface{}) error {
+	return c.s.run("iptables.CreateRule", func() error {
+		return c.Service.CreateRule(ruleType, ruleData)
+	})
+}
+
+// RemoveRule injects faults into the wrapped RemoveRule
+func (c *ChaosIPT) RemoveRule(ruleType int, ruleData interface{}) error {
+	return c.s.run("iptables.RemoveRule", func() error {
+		return c.Service.RemoveRule(ruleType, ruleData)
+	})
+}
+
+// RestoreRules injects faults into the wrapped RestoreRules
+func (c *ChaosIPT) RestoreRules() error {
+	return c.s.run("iptables.RestoreRules", c.Service.RestoreRules)
+}
+
+// NewChaosIPT wraps i, the rule entries are still built by i without faults
+func NewChaosIPT(i iptables.Service, s *Scenario) *ChaosIPT {
+	return &ChaosIPT{
+		Service: i,
+		s:       s,
+	}
+}
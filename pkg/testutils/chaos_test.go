@@ -0,0 +1,157 @@
+package testutils_test
+
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type item struct {
+	ID   uint
+	Name string
+}
+
+var _ = Describe("Chaos", func() {
+	newDB := func(s *testutils.Scenario) *testutils.ChaosDB {
+		db := testutils.NewMockDB()
+		db.AutoMigrate(&item{})
+		return testutils.NewChaosDB(db, s)
+	}
+
+	Describe("Scenario", func() {
+		It("Should inject the same faults for the same seed", func() {
+			run := func() ([]testutils.Injection, []bool) {
+				s := testutils.NewScenario(42)
+				s.ErrorRate, s.PartialRate = 0.2, 0.1
+				db := newDB(s)
+
+				failed := []bool{}
+				for i := 0; i < 50; i++ {
+					failed = append(failed, db.Create(&item{Name: "kroo"}) != nil)
+				}
+				return s.Injections(), failed
+			}
+
+			injections, failed := run()
+			Ω(injections).ShouldNot(BeEmpty())
+			Ω(len(injections)).Should(BeNumerically("<", 50))
+
+			again, failedAgain := run()
+			Ω(again).Should(Equal(injections))
+			Ω(failedAgain).Should(Equal(failed))
+		})
+
+		It("Should inject no faults without rates", func() {
+			s := testutils.NewScenario(1)
+			db := newDB(s)
+			for i := 0; i < 20; i++ {
+				Ω(db.Create(&item{Name: "kroo"})).Should(Succeed())
+			}
+			Ω(s.Injections()).Should(BeEmpty())
+		})
+
+		It("Should only inject faults into the given operations", func() {
+			s := testutils.NewScenario(1).Only("db.Create")
+			s.ErrorRate = 1
+			db := newDB(s)
+
+			Ω(db.Create(&item{Name: "kroo"})).Should(MatchError(testutils.ErrChaos))
+			Ω(db.Find(&[]item{})).Should(Succeed())
+
+			injections := s.Injections()
+			Ω(injections).Should(HaveLen(1))
+			Ω(injections[0].Op).Should(Equal("db.Create"))
+			Ω(injections[0].Call).Should(Equal(1))
+		})
+
+		It("Should delay calls", func() {
+			s := testutils.NewScenario(1)
+			s.DelayRate, s.MaxDelay = 1, 20*time.Millisecond
+			db := newDB(s)
+
+			Ω(db.Create(&item{Name: "kroo"})).Should(Succeed())
+			injections := s.Injections()
+			Ω(injections).Should(HaveLen(1))
+			Ω(injections[0].Fault).Should(Equal(testutils.DelayFault))
+			Ω(injections[0].Delay).Should(BeNumerically("<=", 20*time.Millisecond))
+		})
+	})
+
+	Describe("DB", func() {
+		It("Should not reach the database on errors", func() {
+			s := testutils.NewScenario(1).Only("db.Create")
+			s.ErrorRate = 1
+			db := newDB(s)
+
+			Ω(db.Create(&item{Name: "kroo"})).Should(MatchError(testutils.ErrChaos))
+			items := []item{}
+			Ω(db.Find(&items)).Should(Succeed())
+			Ω(items).Should(BeEmpty())
+		})
+
+		It("Should apply partial failures", func() {
+			s := testutils.NewScenario(1).Only("db.Create")
+			s.PartialRate = 1
+			db := newDB(s)
+
+			Ω(db.Create(&item{Name: "kroo"})).Should(MatchError(testutils.ErrChaos))
+			items := []item{}
+			Ω(db.Find(&items)).Should(Succeed())
+			Ω(items).Should(HaveLen(1))
+		})
+	})
+
+	Describe("Docker Client", func() {
+		It("Should fail the calls of the scenario", func() {
+			s := testutils.NewScenario(7)
+			s.ErrorRate = 0.5
+			d := testutils.NewChaosDCli(abstraction.NewDCLI(), s)
+
+			failed := 0
+			for i := 0; i < 20; i++ {
+				if d.NetworkCreate() != nil {
+					failed++
+				}
+			}
+			Ω(failed).Should(Equal(len(s.Injections())))
+			Ω(failed).Should(BeNumerically(">", 0))
+			Ω(failed).Should(BeNumerically("<", 20))
+		})
+	})
+
+	Describe("IPTables", func() {
+		rule := iptables.CreateChainRule{
+			Name:  "KROO-TEST",
+			Table: "nat",
+		}
+
+		It("Should not create rules on errors", func() {
+			m, err := testutils.NewMockIPTService()
+			Ω(err).ShouldNot(HaveOccurred())
+			s := testutils.NewScenario(1)
+			s.ErrorRate = 1
+			ipt := testutils.NewChaosIPT(m, s)
+
+			Ω(ipt.CreateRule(iptables.CreateChainRuleType, rule)).Should(MatchError(testutils.ErrChaos))
+			n, err := ipt.CountRules()
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(n).Should(BeZero())
+		})
+
+		It("Should create rules on partial failures", func() {
+			m, err := testutils.NewMockIPTService()
+			Ω(err).ShouldNot(HaveOccurred())
+			s := testutils.NewScenario(1)
+			s.PartialRate = 1
+			ipt := testutils.NewChaosIPT(m, s)
+
+			Ω(ipt.CreateRule(iptables.CreateChainRuleType, rule)).Should(MatchError(testutils.ErrChaos))
+			n, _ := ipt.CountRules()
+			Ω(n).Should(BeEquivalentTo(1))
+		})
+	})
+})
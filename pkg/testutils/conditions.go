@@ -0,0 +1,254 @@
+package testutils
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// conditionRegExp matches a part of an inline condition like "ref_id = ?" or "state IN (?)", reserved
+// words like "end" may be quoted
+var conditionRegExp = regexp.MustCompile(`^\s*"?(\w+)"?\s*(=|<>|!=|<=|>=|<|>|IN|NOT IN)\s*\(?\?\)?\s*$`)
+
+// condition is a comparison of a column with an argument
+type condition struct {
+	field    string
+	operator string
+	arg      interface{}
+}
+
+// column returns the field name of a column name
+func (t *table) column(c string) string {
+	if c == gorm.ToDBName(c) {
+		if f, ok := t.columns[c]; ok {
+			return f
+		}
+	}
+	return c
+}
+
+// conditions parses the inline conditions where into groups of conditions joined by AND, the groups are joined
+// by OR. Like in SQL AND binds tighter than OR, parentheses are not supported.
+func (t *table) conditions(where []interface{}) ([][]condition, error) {
+	if len(where) == 0 {
+		return nil, nil
+	}
+
+	query, ok := where[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("unsupported condition %v", where[0])
+	}
+
+	groups := [][]condition{}
+	i := 0
+	for _, alternative := range strings.Split(query, " OR ") {
+		cs := []condition{}
+		for _, part := range strings.Split(alternative, " AND ") {
+			m := conditionRegExp.FindStringSubmatch(part)
+			if m == nil {
+				return nil, fmt.Errorf("unsupported condition %s", part)
+			}
+			i++
+			if i >= len(where) {
+				return nil, fmt.Errorf("missing argument of %s", part)
+			}
+
+			field := t.column(m[1])
+			if _, found := t.ref.FieldByName(field); !found {
+				return nil, fmt.Errorf("%s is not a column of %s", m[1], t.Name)
+			}
+			cs = append(cs, condition{field, m[2], where[i]})
+		}
+		groups = append(groups, cs)
+	}
+	return groups, nil
+}
+
+// compare returns -1, 0 or 1 if a is less than, equal to or greater than b, a and b of different kinds are
+// never equal
+func compare(a, b reflect.Value) (int, bool) {
+	if ta, ok := a.Interface().(time.Time); ok {
+		tb, ok := b.Interface().(time.Time)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case ta.Before(tb):
+			return -1, true
+		case ta.After(tb):
+			return 1, true
+		}
+		return 0, true
+	}
+
+	switch a.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch b.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return order(float64(a.Uint()), float64(b.Uint())), true
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return order(float64(a.Uint()), float64(b.Int())), true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch b.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return order(float64(a.Int()), float64(b.Uint())), true
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return order(float64(a.Int()), float64(b.Int())), true
+		}
+	case reflect.Float32, reflect.Float64:
+		switch b.Kind() {
+		case reflect.Float32, reflect.Float64:
+			return order(a.Float(), b.Float()), true
+		}
+	case reflect.String:
+		if b.Kind() == reflect.String {
+			return strings.Compare(a.String(), b.String()), true
+		}
+	case reflect.Bool:
+		if b.Kind() == reflect.Bool && a.Bool() == b.Bool() {
+			return 0, true
+		}
+	}
+	return 0, false
+}
+
+func order(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// matches reports whether row fulfills c
+func (c condition) matches(row reflect.Value) bool {
+	v := row.FieldByName(c.field)
+
+	if c.operator == "IN" || c.operator == "NOT IN" {
+		in := false
+		args := reflect.ValueOf(c.arg)
+		for i := 0; i < args.Len(); i++ {
+			if o, ok := compare(v, args.Index(i)); ok && o == 0 {
+				in = true
+				break
+			}
+		}
+		return in == (c.operator == "IN")
+	}
+
+	o, ok := compare(v, reflect.ValueOf(c.arg))
+	if !ok {
+		return c.operator == "<>" || c.operator == "!="
+	}
+
+	switch c.operator {
+	case "=":
+		return o == 0
+	case "<>", "!=":
+		return o != 0
+	case "<":
+		return o < 0
+	case "<=":
+		return o <= 0
+	case ">":
+		return o > 0
+	}
+	return o >= 0
+}
+
+// filter returns the rows matching the inline conditions where, all rows if there are none
+func (t *table) filter(where []interface{}) ([]interface{}, error) {
+	groups, err := t.conditions(where)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := []interface{}{}
+	for _, row := range t.rows {
+		if len(groups) == 0 || matchesAny(groups, reflect.ValueOf(row).Elem()) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// matchesAny reports whether row fulfills every condition of one of groups
+func matchesAny(groups [][]condition, row reflect.Value) bool {
+	for _, cs := range groups {
+		match := true
+		for _, c := range cs {
+			if !c.matches(row) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// sort sorts rows by the columns of o, like "run_at, id" or "time DESC"
+func (t *table) sort(rows []interface{}, o string) error {
+	type key struct {
+		field string
+		desc  bool
+	}
+
+	keys := []key{}
+	for _, part := range strings.Split(o, ",") {
+		s := strings.Fields(part)
+		if len(s) == 0 || len(s) > 2 {
+			return fmt.Errorf("unsupported order %s", o)
+		}
+
+		k := key{field: t.column(strings.Trim(s[0], `"`))}
+		if _, found := t.ref.FieldByName(k.field); !found {
+			return fmt.Errorf("%s is not a column of %s", s[0], t.Name)
+		}
+		if len(s) == 2 {
+			k.desc = strings.ToUpper(s[1]) == "DESC"
+		}
+		keys = append(keys, k)
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := reflect.ValueOf(rows[i]).Elem(), reflect.ValueOf(rows[j]).Elem()
+		for _, k := range keys {
+			o, _ := compare(a.FieldByName(k.field), b.FieldByName(k.field))
+			if o == 0 {
+				continue
+			}
+			return (o < 0) != k.desc
+		}
+		return false
+	})
+	return nil
+}
+
+// value returns column of row, octet_length(column) is the length of a string column
+func (t *table) value(row interface{}, column string) (reflect.Value, error) {
+	length := false
+	if strings.HasPrefix(column, "octet_length(") && strings.HasSuffix(column, ")") {
+		length = true
+		column = strings.TrimSuffix(strings.TrimPrefix(column, "octet_length("), ")")
+	}
+
+	v := reflect.ValueOf(row).Elem().FieldByName(t.column(column))
+	if !v.IsValid() {
+		return RNil, fmt.Errorf("%s is not a column of %s", column, t.Name)
+	}
+	if length {
+		return reflect.ValueOf(int64(len(v.String()))), nil
+	}
+	return v, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"os/exec"
+	"sort"
 
 	"github.com/kontainerooo/kontainer.ooo/pkg/firewall/iptables"
 )
@@ -71,6 +72,30 @@ func (m *MockIPTService) RestoreRules() error {
 	return nil
 }
 
+// ExportRules is not mocked
+func (m *MockIPTService) ExportRules() (string, error) {
+	return "", nil
+}
+
+// CountRules returns the number of created rules
+func (m *MockIPTService) CountRules() (uint, error) {
+	return uint(len(m.rules)), nil
+}
+
+// GetRuleCounters returns zero counters for the created rules
+func (m *MockIPTService) GetRuleCounters() ([]iptables.RuleCounters, error) {
+	counters := []iptables.RuleCounters{}
+	for id := range m.rules {
+		counters = append(counters, iptables.RuleCounters{
+			ID: id,
+		})
+	}
+	sort.Slice(counters, func(i, j int) bool {
+		return counters[i].ID < counters[j].ID
+	})
+	return counters, nil
+}
+
 // NewMockIPTService creates a new MockIPTServicet
 func NewMockIPTService() (*MockIPTService, error) {
 	db := NewMockDB()
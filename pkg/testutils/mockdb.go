@@ -1,10 +1,13 @@
 package testutils
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
 )
 
 var (
@@ -146,6 +149,11 @@ func (m *MockDB) GetValue() interface{} {
 	return m.value
 }
 
+// WithContext returns the mockDB bound to ctx, its calls fail once ctx is done
+func (m *MockDB) WithContext(ctx context.Context) abstraction.DB {
+	return abstraction.NewContextDB(m, ctx)
+}
+
 // GetAffectedRows returns 0
 func (m *MockDB) GetAffectedRows() int64 {
 	return 0
@@ -170,9 +178,10 @@ func (m *MockDB) Where(query interface{}, args ...interface{}) error {
 		return ErrDBFailure
 	}
 
-	// reset value and multiValue
+	// reset value, multiValue and the query state of a previous call
 	m.value = RNil
 	m.multiValue = nil
+	m.isQuery = false
 
 	// split query at "AND"
 	and := strings.Split(query.(string), " AND ")
@@ -258,7 +267,24 @@ func (m *MockDB) First(out interface{}, where ...interface{}) error {
 	ref := reflect.TypeOf(out).Elem()
 	name := ref.String()
 
+	// inline conditions are evaluated unless a query of Where is pending
+	if len(where) > 0 && !m.isQuery {
+		t, ok := m.tables[name]
+		if !ok {
+			return ErrTypeMismatch
+		}
+		rows, err := t.filter(where)
+		if err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return ErrNotFound
+		}
+		return merge(reflect.ValueOf(out).Elem(), reflect.ValueOf(rows[0]).Elem(), true, 0)
+	}
+
 	if m.multiValue == nil || len(m.multiValue) == 0 {
+		m.isQuery = false
 		return ErrNotFound
 	}
 
@@ -291,10 +317,11 @@ func (m *MockDB) Find(out interface{}, where ...interface{}) error {
 	for _, t := range m.tables {
 		if ref == reflect.SliceOf(t.getRef()) {
 			if !m.isQuery {
-				err := t.all(out)
+				rows, err := t.filter(where)
 				if err != nil {
 					return err
 				}
+				appendRows(out, rows)
 				return nil
 			}
 			if reflect.TypeOf(out).Elem().Kind() == reflect.Slice {
@@ -344,12 +371,161 @@ func (m *MockDB) Delete(value interface{}, where ...interface{}) error {
 		}
 	}
 
+	// inline conditions delete every matching row, like the database does
+	if len(where) > 0 {
+		t := m.tables[name]
+		rows, err := t.filter(where)
+		if err != nil {
+			return err
+		}
+
+		deleted := make(map[interface{}]bool)
+		for _, row := range rows {
+			match := true
+			for k, fv := range ids {
+				if fv.Interface() != reflect.ValueOf(row).Elem().FieldByName(k).Interface() {
+					match = false
+					break
+				}
+			}
+			deleted[row] = match
+		}
+
+		kept := []interface{}{}
+		for _, row := range t.rows {
+			if !deleted[row] {
+				kept = append(kept, row)
+			}
+		}
+		t.rows = kept
+		return nil
+	}
+
 	if len(ids) != 0 {
 		return m.tables[name].delete(ids)
 	}
 	return ErrDBFailure
 }
 
+// FindOrdered mocks gorm.DBs Order, Limit and Find functions
+func (m *MockDB) FindOrdered(out interface{}, order string, limit int, where ...interface{}) error {
+	if m.produceError() {
+		return ErrDBFailure
+	}
+
+	t, err := m.tableOf(reflect.TypeOf(out).Elem().Elem())
+	if err != nil {
+		return err
+	}
+
+	rows, err := t.filter(where)
+	if err != nil {
+		return err
+	}
+	err = t.sort(rows, order)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+
+	appendRows(out, rows)
+	return nil
+}
+
+// Sum mocks gorm.DBs Select of the SUM of a column
+func (m *MockDB) Sum(model interface{}, column string, out *int64, where ...interface{}) error {
+	if m.produceError() {
+		return ErrDBFailure
+	}
+
+	t, err := m.tableOf(reflect.TypeOf(model).Elem())
+	if err != nil {
+		return err
+	}
+
+	rows, err := t.filter(where)
+	if err != nil {
+		return err
+	}
+
+	sum := int64(0)
+	for _, row := range rows {
+		v, err := t.value(row, column)
+		if err != nil {
+			return err
+		}
+		switch v.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			sum += int64(v.Uint())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			sum += v.Int()
+		case reflect.Float32, reflect.Float64:
+			sum += int64(v.Float())
+		default:
+			return ErrTypeMismatch
+		}
+	}
+
+	*out = sum
+	return nil
+}
+
+// Pluck mocks gorm.DBs Pluck function, "DISTINCT column" plucks every value once
+func (m *MockDB) Pluck(model interface{}, column string, out interface{}, where ...interface{}) error {
+	if m.produceError() {
+		return ErrDBFailure
+	}
+
+	t, err := m.tableOf(reflect.TypeOf(model).Elem())
+	if err != nil {
+		return err
+	}
+
+	rows, err := t.filter(where)
+	if err != nil {
+		return err
+	}
+
+	distinct := strings.HasPrefix(column, "DISTINCT ")
+	column = strings.TrimPrefix(column, "DISTINCT ")
+
+	seen := make(map[interface{}]bool)
+	outVal := reflect.ValueOf(out).Elem()
+	for _, row := range rows {
+		v, err := t.value(row, column)
+		if err != nil {
+			return err
+		}
+		if distinct {
+			if seen[v.Interface()] {
+				continue
+			}
+			seen[v.Interface()] = true
+		}
+		outVal.Set(reflect.Append(outVal, v.Convert(outVal.Type().Elem())))
+	}
+	return nil
+}
+
+// tableOf returns the table of the type ref
+func (m *MockDB) tableOf(ref reflect.Type) (*table, error) {
+	t, ok := m.tables[ref.String()]
+	if !ok {
+		return nil, ErrTypeMismatch
+	}
+	return t, nil
+}
+
+// appendRows appends rows to the slice out
+func appendRows(out interface{}, rows []interface{}) {
+	outVal := reflect.ValueOf(out).Elem()
+	for _, row := range rows {
+		outVal.Set(reflect.Append(outVal, reflect.ValueOf(row).Elem()))
+	}
+}
+
 // Update mocks gorm.DBs Update function
 func (m *MockDB) Update(model interface{}, attrs ...interface{}) error {
 	if m.produceError() {
@@ -377,6 +553,7 @@ func (m *MockDB) Update(model interface{}, attrs ...interface{}) error {
 		}
 
 	}
+	m.isQuery = false
 	return nil
 }
 
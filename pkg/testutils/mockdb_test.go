@@ -0,0 +1,85 @@
+package testutils_test
+
+import (
+	"context"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MockDB", func() {
+	Describe("WithContext", func() {
+		It("Should run the calls while the context is not done", func() {
+			db := testutils.NewMockDB()
+			db.AutoMigrate(&item{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			bound := db.WithContext(ctx)
+			Ω(bound.Create(&item{Name: "kroo"})).Should(Succeed())
+
+			cancel()
+			Ω(bound.Create(&item{Name: "ooo"})).Should(MatchError(context.Canceled))
+			Ω(bound.Find(&[]item{})).Should(MatchError(context.Canceled))
+
+			items := []item{}
+			Ω(db.Find(&items)).Should(Succeed())
+			Expect(items).To(HaveLen(1))
+		})
+	})
+
+	Describe("Inline conditions", func() {
+		var db *testutils.MockDB
+		BeforeEach(func() {
+			db = testutils.NewMockDB()
+			db.AutoMigrate(&item{})
+			for _, name := range []string{"kroo", "ooo", "kroo", "mock"} {
+				db.Create(&item{Name: name})
+			}
+		})
+
+		It("Should find the matching rows", func() {
+			items := []item{}
+			Ω(db.Find(&items, "name = ? AND id > ?", "kroo", 1)).Should(Succeed())
+			Expect(items).To(Equal([]item{{ID: 3, Name: "kroo"}}))
+
+			i := item{}
+			Ω(db.First(&i, "name IN (?)", []string{"mock", "ooo"})).Should(Succeed())
+			Expect(i.ID).To(BeEquivalentTo(2))
+			Ω(db.First(&i, "name = ?", "none")).ShouldNot(Succeed())
+		})
+
+		It("Should join the conditions by OR with AND binding tighter", func() {
+			items := []item{}
+			Ω(db.Find(&items, "name = ? OR name = ? AND id > ?", "mock", "kroo", 1)).Should(Succeed())
+			Expect(items).To(Equal([]item{{ID: 3, Name: "kroo"}, {ID: 4, Name: "mock"}}))
+
+			Ω(db.Find(&items, "name = ? OR id = ?", "ooo")).ShouldNot(Succeed())
+		})
+
+		It("Should order and limit the rows", func() {
+			items := []item{}
+			Ω(db.FindOrdered(&items, "name DESC, id", 3, "id <> ?", 2)).Should(Succeed())
+			Expect(items).To(Equal([]item{{ID: 4, Name: "mock"}, {ID: 1, Name: "kroo"}, {ID: 3, Name: "kroo"}}))
+		})
+
+		It("Should sum and pluck the column", func() {
+			var sum int64
+			Ω(db.Sum(&item{}, "id", &sum, "name = ?", "kroo")).Should(Succeed())
+			Expect(sum).To(BeEquivalentTo(4))
+			Ω(db.Sum(&item{}, "octet_length(name)", &sum)).Should(Succeed())
+			Expect(sum).To(BeEquivalentTo(15))
+
+			names := []string{}
+			Ω(db.Pluck(&item{}, "DISTINCT name", &names, "id < ?", 4)).Should(Succeed())
+			Expect(names).To(Equal([]string{"kroo", "ooo"}))
+		})
+
+		It("Should delete every matching row", func() {
+			Ω(db.Delete(&item{}, "name = ?", "kroo")).Should(Succeed())
+			items := []item{}
+			Ω(db.Find(&items)).Should(Succeed())
+			Expect(items).To(Equal([]item{{ID: 2, Name: "ooo"}, {ID: 4, Name: "mock"}}))
+		})
+	})
+})
@@ -0,0 +1,46 @@
+package testutils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+)
+
+// FakeRedis accepts one connection and answers every command with the next reply, it returns the
+// url of the server and a channel receiving the arguments of every command
+func FakeRedis(replies ...string) (string, chan []string) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	commands := make(chan []string, len(replies))
+
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for _, reply := range replies {
+			var n int
+			_, err := fmt.Fscanf(r, "*%d\r\n", &n)
+			if err != nil {
+				return
+			}
+
+			args := make([]string, n)
+			for i := range args {
+				var l int
+				fmt.Fscanf(r, "$%d\r\n", &l)
+				buf := make([]byte, l+2)
+				io.ReadFull(r, buf)
+				args[i] = string(buf[:l])
+			}
+			commands <- args
+			conn.Write([]byte(reply))
+		}
+	}()
+
+	return "redis://:secret@" + ln.Addr().String() + "/2", commands
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"time"
 
 	"github.com/jinzhu/gorm"
 )
@@ -49,14 +50,6 @@ func (t *table) copy() *table {
 	return nt
 }
 
-func (t *table) all(out interface{}) error {
-	outVal := reflect.ValueOf(out).Elem()
-	for _, row := range t.rows {
-		outVal.Set(reflect.Append(outVal, reflect.ValueOf(row).Elem()))
-	}
-	return nil
-}
-
 func (t *table) checkForField(f string) bool {
 	if f == gorm.ToDBName(f) {
 		f = t.columns[f]
@@ -113,6 +106,11 @@ func (t *table) find(field string, value interface{}) (reflect.Value, error) {
 			if bool(v) == value {
 				result = reflect.Append(result, reflect.ValueOf(row))
 			}
+		} else if t, ok := value.(time.Time); ok {
+			v, ok := reflect.ValueOf(row).Elem().FieldByName(field).Interface().(time.Time)
+			if ok && v.Equal(t) {
+				result = reflect.Append(result, reflect.ValueOf(row))
+			}
 		} else {
 			v := reflect.ValueOf(row).Elem().FieldByName(field).String()
 			if v == value {
@@ -251,7 +249,7 @@ func getPrimaryKeys(ref reflect.Type, prime, idx *[]string, m map[string]string)
 			t = t.Elem()
 		}
 
-		if t.Kind() == reflect.Struct {
+		if t.Kind() == reflect.Struct && t != timeType {
 			getPrimaryKeys(t, prime, idx, m)
 			continue
 		}
@@ -4,13 +4,16 @@ import (
 	"errors"
 	"reflect"
 	"regexp"
+	"time"
 )
 
+var timeType = reflect.TypeOf(time.Time{})
+
 func merge(dst, src reflect.Value, overwriteID bool, depth int) error {
 	if depth > 7 {
 		return errors.New("too deep")
 	}
-	idRegexp := regexp.MustCompile("ID")
+	idRegexp := regexp.MustCompile("^ID$")
 
 	if isZero(src) {
 		return nil
@@ -32,7 +35,7 @@ func merge(dst, src reflect.Value, overwriteID bool, depth int) error {
 			field = dst.Field(i)
 		}
 
-		if field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Elem().Kind() == reflect.Struct) {
+		if field.Type() != timeType && (field.Kind() == reflect.Struct || (field.Kind() == reflect.Ptr && field.Elem().Kind() == reflect.Struct)) {
 			merge(field, src.Field(i), overwriteID, depth+1)
 		} else if field.CanSet() {
 			srcVal := src.Field(i)
@@ -63,6 +66,10 @@ func isZero(v reflect.Value) bool {
 		return v.Float() == 0
 	case reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
 		return v.IsNil()
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return v.Interface().(time.Time).IsZero()
+		}
 	}
 	return false
 }
@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+// db records a span for every database operation, tagged with the model it concerns. Once bound
+// to a context the spans are children of the span of that context, otherwise they start new traces.
+type db struct {
+	abstraction.DB
+	tracer opentracing.Tracer
+	ctx    context.Context
+}
+
+// model returns the name of the type of a model, slices are named by their elements
+func model(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+func (d *db) trace(operation string, value interface{}, f func() error) error {
+	opts := []opentracing.StartSpanOption{}
+	if d.ctx != nil {
+		if parent := opentracing.SpanFromContext(d.ctx); parent != nil {
+			opts = append(opts, opentracing.ChildOf(parent.Context()))
+		}
+	}
+
+	span := d.tracer.StartSpan("db."+operation, opts...)
+	ext.DBType.Set(span, "sql")
+	ext.Component.Set(span, "gorm")
+	span.SetTag("db.model", model(value))
+
+	err := f()
+	if err != nil && d.IsNotFound(err) {
+		span.Finish()
+		return err
+	}
+	Finish(span, err)
+	return err
+}
+
+func (d *db) AppendToArray(query interface{}, target string, values interface{}) error {
+	return d.trace("AppendToArray", query, func() error {
+		return d.DB.AppendToArray(query, target, values)
+	})
+}
+
+func (d *db) RemoveFromArray(query interface{}, target string, index int) error {
+	return d.trace("RemoveFromArray", query, func() error {
+		return d.DB.RemoveFromArray(query, target, index)
+	})
+}
+
+func (d *db) AutoMigrate(values ...interface{}) error {
+	return d.trace("AutoMigrate", nil, func() error {
+		return d.DB.AutoMigrate(values...)
+	})
+}
+
+func (d *db) First(out interface{}, where ...interface{}) error {
+	return d.trace("First", out, func() error {
+		return d.DB.First(out, where...)
+	})
+}
+
+func (d *db) Find(out interface{}, where ...interface{}) error {
+	return d.trace("Find", out, func() error {
+		return d.DB.Find(out, where...)
+	})
+}
+
+func (d *db) Create(value interface{}) error {
+	return d.trace("Create", value, func() error {
+		return d.DB.Create(value)
+	})
+}
+
+func (d *db) Delete(value interface{}, where ...interface{}) error {
+	return d.trace("Delete", value, func() error {
+		return d.DB.Delete(value, where...)
+	})
+}
+
+func (d *db) Update(value interface{}, attrs ...interface{}) error {
+	return d.trace("Update", value, func() error {
+		return d.DB.Update(value, attrs...)
+	})
+}
+
+func (d *db) FindOrdered(out interface{}, order string, limit int, where ...interface{}) error {
+	return d.trace("FindOrdered", out, func() error {
+		return d.DB.FindOrdered(out, order, limit, where...)
+	})
+}
+
+func (d *db) Sum(model interface{}, column string, out *int64, where ...interface{}) error {
+	return d.trace("Sum", model, func() error {
+		return d.DB.Sum(model, column, out, where...)
+	})
+}
+
+func (d *db) Pluck(model interface{}, column string, out interface{}, where ...interface{}) error {
+	return d.trace("Pluck", model, func() error {
+		return d.DB.Pluck(model, column, out, where...)
+	})
+}
+
+func (d *db) WithContext(ctx context.Context) abstraction.DB {
+	return abstraction.NewContextDB(&db{
+		DB:     d.DB.WithContext(ctx),
+		tracer: d.tracer,
+		ctx:    ctx,
+	}, ctx)
+}
+
+// NewDB returns a DB recording a span for every operation of next
+func NewDB(next abstraction.DB, tracer opentracing.Tracer) abstraction.DB {
+	return &db{
+		DB:     next,
+		tracer: tracer,
+	}
+}
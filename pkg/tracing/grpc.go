@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"strings"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataCarrier carries span contexts in gRPC metadata
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Set(key, val string) {
+	key = strings.ToLower(key)
+	c[key] = append(c[key], val)
+}
+
+func (c metadataCarrier) ForeachKey(handler func(key, val string) error) error {
+	for k, vs := range c {
+		for _, v := range vs {
+			err := handler(k, v)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// UnaryServerInterceptor starts a span for every gRPC call, it continues the trace of the caller if the
+// call carries one
+func UnaryServerInterceptor(tracer opentracing.Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			md = metadata.MD{}
+		}
+
+		opts := []opentracing.StartSpanOption{ext.SpanKindRPCServer}
+		parent, err := tracer.Extract(opentracing.HTTPHeaders, metadataCarrier(md))
+		if err == nil {
+			opts = append(opts, opentracing.ChildOf(parent))
+		}
+
+		span := tracer.StartSpan(info.FullMethod, opts...)
+		ext.Component.Set(span, "grpc")
+		defer func() {
+			Finish(span, err)
+		}()
+
+		return handler(opentracing.ContextWithSpan(ctx, span), req)
+	}
+}
+
+// UnaryClientInterceptor sends the span of the context of a call along with it
+func UnaryClientInterceptor(tracer opentracing.Tracer) grpc.UnaryClientInterceptor {
+	return func(ctx oldcontext.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+		span, ctx := StartSpan(ctx, tracer, method, ext.SpanKindRPCClient)
+		ext.Component.Set(span, "grpc")
+		defer func() {
+			Finish(span, err)
+		}()
+
+		md, ok := metadata.FromOutgoingContext(ctx)
+		if ok {
+			md = md.Copy()
+		} else {
+			md = metadata.MD{}
+		}
+
+		err = tracer.Inject(span.Context(), opentracing.HTTPHeaders, metadataCarrier(md))
+		if err != nil {
+			return err
+		}
+
+		return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+	}
+}
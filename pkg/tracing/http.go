@@ -0,0 +1,44 @@
+package tracing
+
+import (
+	"net/http"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Handler starts a span for every request to next, it continues the trace of the caller if the
+// request carries one in its headers
+func Handler(tracer opentracing.Tracer, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		opts := []opentracing.StartSpanOption{ext.SpanKindRPCServer}
+		parent, err := tracer.Extract(opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(req.Header))
+		if err == nil {
+			opts = append(opts, opentracing.ChildOf(parent))
+		}
+
+		span := tracer.StartSpan(req.Method+" "+req.URL.Path, opts...)
+		defer span.Finish()
+		ext.Component.Set(span, "http")
+		ext.HTTPMethod.Set(span, req.Method)
+		ext.HTTPUrl.Set(span, req.URL.String())
+
+		rec := &statusRecorder{w, http.StatusOK}
+		next.ServeHTTP(rec, req.WithContext(opentracing.ContextWithSpan(req.Context(), span)))
+
+		ext.HTTPStatusCode.Set(span, uint16(rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			ext.Error.Set(span, true)
+		}
+	})
+}
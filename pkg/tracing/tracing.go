@@ -0,0 +1,71 @@
+// Package tracing traces requests through the transports, endpoints and database operations of the daemon
+// using OpenTracing, spans are reported to Jaeger
+package tracing
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/go-kit/kit/endpoint"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	jaeger "github.com/uber/jaeger-client-go"
+	jaegercfg "github.com/uber/jaeger-client-go/config"
+)
+
+// New returns a tracer reporting to the Jaeger agent at agent, or to the Jaeger collector at collector if it is set.
+// sampleRate is the fraction of traces which are reported. The closer flushes the spans which were not reported yet.
+func New(serviceName, agent, collector string, sampleRate float64) (opentracing.Tracer, io.Closer, error) {
+	cfg := jaegercfg.Configuration{
+		ServiceName: serviceName,
+		Sampler: &jaegercfg.SamplerConfig{
+			Type:  jaeger.SamplerTypeProbabilistic,
+			Param: sampleRate,
+		},
+		Reporter: &jaegercfg.ReporterConfig{
+			LocalAgentHostPort: agent,
+			CollectorEndpoint:  collector,
+		},
+	}
+	return cfg.NewTracer()
+}
+
+// NewNoop returns a tracer which does not record anything
+func NewNoop() (opentracing.Tracer, io.Closer) {
+	return opentracing.NoopTracer{}, ioutil.NopCloser(nil)
+}
+
+// StartSpan starts a span which is a child of the span of ctx if it has one
+func StartSpan(ctx context.Context, tracer opentracing.Tracer, operationName string, opts ...opentracing.StartSpanOption) (opentracing.Span, context.Context) {
+	if parent := opentracing.SpanFromContext(ctx); parent != nil {
+		opts = append(opts, opentracing.ChildOf(parent.Context()))
+	}
+
+	span := tracer.StartSpan(operationName, opts...)
+	return span, opentracing.ContextWithSpan(ctx, span)
+}
+
+// Finish marks the span as failed if err is not nil and finishes it
+func Finish(span opentracing.Span, err error) {
+	if err != nil {
+		ext.Error.Set(span, true)
+		span.LogKV("error", err.Error())
+	}
+	span.Finish()
+}
+
+// Middleware returns a middleware tracing the calls of the endpoint method of service
+func Middleware(tracer opentracing.Tracer, service, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+			span, ctx := StartSpan(ctx, tracer, service+"."+method)
+			span.SetTag("service", service)
+			defer func() {
+				Finish(span, err)
+			}()
+
+			return next(ctx, request)
+		}
+	}
+}
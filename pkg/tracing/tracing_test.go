@@ -0,0 +1,132 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/tracing"
+	"github.com/kontainerooo/kontainer.ooo/pkg/user"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tracing", func() {
+	var tracer *mocktracer.MockTracer
+
+	BeforeEach(func() {
+		tracer = mocktracer.New()
+	})
+
+	Describe("Middleware", func() {
+		It("Should start a child span of the span of the context", func() {
+			parent := tracer.StartSpan("parent")
+			ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+			e := tracing.Middleware(tracer, "user", "GetUser")(func(ctx context.Context, request interface{}) (interface{}, error) {
+				Expect(opentracing.SpanFromContext(ctx)).NotTo(Equal(parent))
+				return nil, errors.New("fail")
+			})
+			_, err := e(ctx, nil)
+			Expect(err).To(HaveOccurred())
+			parent.Finish()
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[0].OperationName).To(Equal("user.GetUser"))
+			Expect(spans[0].ParentID).To(Equal(parent.(*mocktracer.MockSpan).SpanContext.SpanID))
+			Expect(spans[0].Tag("error")).To(BeTrue())
+		})
+	})
+
+	Describe("gRPC", func() {
+		It("Should continue the trace of the client", func() {
+			var md metadata.MD
+			invoker := func(ctx oldcontext.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				md, _ = metadata.FromOutgoingContext(ctx)
+				return nil
+			}
+
+			ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", "Bearer token"))
+			err := tracing.UnaryClientInterceptor(tracer)(ctx, "/user.UserService/GetUser", nil, nil, nil, invoker)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(md["authorization"]).To(Equal([]string{"Bearer token"}))
+
+			var span opentracing.Span
+			handler := func(ctx oldcontext.Context, req interface{}) (interface{}, error) {
+				span = opentracing.SpanFromContext(ctx)
+				return nil, nil
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: "/user.UserService/GetUser"}
+			_, err = tracing.UnaryServerInterceptor(tracer)(metadata.NewIncomingContext(context.Background(), md), nil, info, handler)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(span).NotTo(BeNil())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(2))
+			Expect(spans[1].OperationName).To(Equal("/user.UserService/GetUser"))
+			Expect(spans[1].ParentID).To(Equal(spans[0].SpanContext.SpanID))
+			Expect(spans[1].SpanContext.TraceID).To(Equal(spans[0].SpanContext.TraceID))
+		})
+	})
+
+	Describe("HTTP", func() {
+		It("Should trace requests", func() {
+			var span opentracing.Span
+			h := tracing.Handler(tracer, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				span = opentracing.SpanFromContext(req.Context())
+				w.WriteHeader(http.StatusNotFound)
+			}))
+
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/users/1", nil))
+			Expect(span).NotTo(BeNil())
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(1))
+			Expect(spans[0].OperationName).To(Equal("GET /v1/users/1"))
+			Expect(spans[0].Tag("http.status_code")).To(BeEquivalentTo(http.StatusNotFound))
+		})
+	})
+
+	Describe("DB", func() {
+		It("Should record database operations", func() {
+			db := tracing.NewDB(testutils.NewMockDB(), tracer)
+			Expect(db.AutoMigrate(&user.User{})).To(Succeed())
+			Expect(db.Create(&user.User{ID: 1, Username: "kroo"})).To(Succeed())
+
+			users := []user.User{}
+			Expect(db.Find(&users)).To(Succeed())
+			Expect(users).To(HaveLen(1))
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(3))
+			Expect(spans[1].OperationName).To(Equal("db.Create"))
+			Expect(spans[2].OperationName).To(Equal("db.Find"))
+			Expect(spans[2].Tag("db.model")).To(Equal("user.User"))
+		})
+
+		It("Should record operations of a bound database as children of the span of the context", func() {
+			parent := tracer.StartSpan("parent")
+			ctx := opentracing.ContextWithSpan(context.Background(), parent)
+
+			db := tracing.NewDB(testutils.NewMockDB(), tracer).WithContext(ctx)
+			Expect(db.AutoMigrate(&user.User{})).To(Succeed())
+			Expect(db.Create(&user.User{ID: 1, Username: "kroo"})).To(Succeed())
+			parent.Finish()
+
+			spans := tracer.FinishedSpans()
+			Expect(spans).To(HaveLen(3))
+			Expect(spans[1].OperationName).To(Equal("db.Create"))
+			Expect(spans[1].ParentID).To(Equal(parent.(*mocktracer.MockSpan).SpanContext.SpanID))
+		})
+	})
+})
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/usage"
+	"github.com/kontainerooo/kontainer.ooo/pkg/usage/pb"
+)
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCQueryRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/usage.proto-domain query request to a gRPC Query request.
+func EncodeGRPCQueryRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*usage.QueryRequest)
+	gRPCReq := &pb.QueryRequest{
+		RefID:      uint32(req.RefID),
+		Instance:   req.Query.Instance,
+		Resolution: req.Query.Resolution,
+	}
+	if !req.Query.From.IsZero() {
+		gRPCReq.From = req.Query.From.Unix()
+	}
+	if !req.Query.To.IsZero() {
+		gRPCReq.To = req.Query.To.Unix()
+	}
+	return gRPCReq, nil
+}
+
+// DecodeGRPCQueryResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Query response to a messages/usage.proto-domain query response.
+func DecodeGRPCQueryResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.QueryResponse)
+	return &usage.QueryResponse{
+		Points:     usage.ConvertPBPoints(response.Points, response.Resolution),
+		Resolution: response.Resolution,
+		Error:      getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRecommendationsRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/usage.proto-domain recommendations request to a gRPC Recommendations request.
+func EncodeGRPCRecommendationsRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*usage.RecommendationsRequest)
+	return &pb.RecommendationsRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCRecommendationsResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Recommendations response to a messages/usage.proto-domain recommendations response.
+func DecodeGRPCRecommendationsResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RecommendationsResponse)
+	recommendations := make([]usage.Recommendation, len(response.Recommendations))
+	for i, r := range response.Recommendations {
+		recommendations[i] = usage.ConvertPBRecommendation(r)
+	}
+
+	return &usage.RecommendationsResponse{
+		Recommendations: recommendations,
+		Error:           getError(response.Error),
+		Page:            paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
@@ -0,0 +1,104 @@
+package usage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// StatsFunc returns the resource usage of the instances running on this node
+type StatsFunc func() ([]Stats, error)
+
+type previous struct {
+	cpu     uint64
+	started string
+	time    time.Time
+}
+
+// Collector records the resource usage of the instances on this node. The CPU usage is derived
+// from the previous sample of an instance, so it has to collect regularly.
+type Collector struct {
+	s      Service
+	stats  StatsFunc
+	logger log.Logger
+
+	mtx  sync.Mutex
+	last map[series]*previous
+}
+
+// Collect records a raw point of every running instance, an instance is recorded from its second
+// sample on and from the second sample after it restarted
+func (c *Collector) Collect(now time.Time) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	stats, err := c.stats()
+	if err != nil {
+		level.Error(c.logger).Log("err", err)
+		return
+	}
+
+	running := make(map[series]bool)
+	points := []Point{}
+	for _, st := range stats {
+		key := series{st.RefID, st.Instance}
+		running[key] = true
+
+		p, ok := c.last[key]
+		if ok && p.started == st.Started && now.After(p.time) && st.CPU >= p.cpu {
+			points = append(points, Point{
+				RefID:       st.RefID,
+				Instance:    st.Instance,
+				Time:        now,
+				CPU:         float64(st.CPU-p.cpu) / float64(now.Sub(p.time).Nanoseconds()) * 100,
+				Memory:      st.Memory,
+				MemoryLimit: st.MemoryLimit,
+			})
+		}
+		c.last[key] = &previous{st.CPU, st.Started, now}
+	}
+
+	// stopped instances are forgotten
+	for key := range c.last {
+		if !running[key] {
+			delete(c.last, key)
+		}
+	}
+
+	if len(points) == 0 {
+		return
+	}
+
+	err = c.s.Record(points)
+	if err != nil {
+		level.Error(c.logger).Log("points", len(points), "err", err)
+	}
+}
+
+// Run calls Collect every interval until stop is closed
+func (c *Collector) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		c.Collect(time.Now())
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewCollector returns a Collector recording the usage stats returns
+func NewCollector(s Service, stats StatsFunc, logger log.Logger) *Collector {
+	return &Collector{
+		s:      s,
+		stats:  stats,
+		logger: logger,
+		last:   make(map[series]*previous),
+	}
+}
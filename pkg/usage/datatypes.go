@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"time"
+)
+
+// Point is the resource usage of an instance at a resolution. Raw points are single samples,
+// the points of the rollups aggregate the points of the next finer resolution within their step.
+type Point struct {
+	ID         uint `gorm:"primary_key"`
+	RefID      uint
+	Instance   string
+	Resolution string
+	// Time is when a raw point was sampled or the start of the step of a rollup
+	Time time.Time
+	// CPU is the mean CPU usage in percent of a core and CPUMax its maximum within the step
+	CPU    float64
+	CPUMax float64
+	// Memory is the mean memory usage and MemoryMax its maximum within the step in bytes
+	Memory    uint64
+	MemoryMax uint64
+	// MemoryLimit is the most memory the instance may use in bytes
+	MemoryLimit uint64
+}
+
+// TableName sets Point's database table name
+func (Point) TableName() string {
+	return "usage_points"
+}
+
+// Query selects the points of a user
+type Query struct {
+	// Instance is the name of the instance, it is required
+	Instance string `validate:"required,name"`
+	// Resolution is Raw, FiveMinutes or Hour, the finest resolution which is kept for the
+	// whole time range is used if it is empty
+	Resolution string `validate:"oneof=raw|5m|1h"`
+	// From and To select the points in [From, To), the last hour is selected if From is zero
+	// and To defaults to now
+	From time.Time
+	To   time.Time
+}
+
+// Stats is the resource usage of a running instance
+type Stats struct {
+	RefID    uint
+	Instance string
+	// CPU is the CPU time the instance used since it was started in nanoseconds
+	CPU uint64
+	// Memory is the memory the instance uses and MemoryLimit the most it may use in bytes
+	Memory      uint64
+	MemoryLimit uint64
+	// Started identifies the start of the instance, it changes once the instance restarts
+	Started string
+}
+
+// Recommendation suggests limits for an instance derived from its hourly usage within a period,
+// the peaks plus the headroom of the options rounded up
+type Recommendation struct {
+	ID       uint `gorm:"primary_key"`
+	RefID    uint
+	Instance string
+	// From and To are the period of the usage, Points the number of hourly points within it
+	From   time.Time
+	To     time.Time
+	Points uint
+	// CPU is the mean CPU usage and CPUPeak the 95th percentile of its hourly maximum in percent of a core
+	CPU     float64
+	CPUPeak float64
+	// Memory is the mean memory usage and MemoryPeak its maximum in bytes
+	Memory     uint64
+	MemoryPeak uint64
+	// CPULimit and MemoryLimit are the current limits in cores and bytes, they are 0 if they are unlimited
+	CPULimit    float64
+	MemoryLimit uint64
+	// SuggestedCPU and SuggestedMemory are the suggested limits in cores and bytes
+	SuggestedCPU    float64
+	SuggestedMemory uint64
+	// SavedCPU and SavedMemory are the cores and bytes the suggested limits free, they are negative if the
+	// instance needs more than its limits and 0 if the resource is unlimited
+	SavedCPU    float64
+	SavedMemory int64
+	// Savings is the monthly price of the saved resources in the smallest unit of the currency, 0 if no prices are set
+	Savings   int64
+	CreatedAt time.Time
+}
+
+// TableName sets Recommendation's database table name
+func (Recommendation) TableName() string {
+	return "usage_recommendations"
+}
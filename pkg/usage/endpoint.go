@@ -0,0 +1,71 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// QueryRequest is the request struct for the QueryEndpoint
+type QueryRequest struct {
+	RefID uint `bart:"ref"`
+	Query Query
+}
+
+// QueryResponse is the response struct for the QueryEndpoint
+type QueryResponse struct {
+	Points     []Point
+	Resolution string
+	Error      error
+}
+
+// MakeQueryEndpoint creates a gokit endpoint which invokes Query
+func MakeQueryEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(QueryRequest)
+		points := []Point{}
+		resolution, err := s.Query(req.RefID, req.Query, &points)
+		return QueryResponse{
+			Points:     points,
+			Resolution: resolution,
+			Error:      err,
+		}, nil
+	}
+}
+
+// RecommendationsRequest is the request struct for the RecommendationsEndpoint
+type RecommendationsRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// RecommendationsResponse is the response struct for the RecommendationsEndpoint
+type RecommendationsResponse struct {
+	Recommendations []Recommendation
+	Error           error
+	Page            paging.Response
+}
+
+// MakeRecommendationsEndpoint creates a gokit endpoint which invokes Recommendations
+func MakeRecommendationsEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RecommendationsRequest)
+		recommendations := []Recommendation{}
+		err := s.Recommendations(req.RefID, &recommendations)
+		if err != nil {
+			return RecommendationsResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&recommendations, "Instance", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return RecommendationsResponse{
+			Recommendations: recommendations,
+			Page:            page,
+		}, nil
+	}
+}
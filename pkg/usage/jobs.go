@@ -0,0 +1,29 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+const (
+	// DownsampleJob is the type of the job rolling the points up and removing the points beyond their retention
+	DownsampleJob = "usage.downsample"
+	// RecommendJob is the type of the job deriving the recommendations from the usage
+	RecommendJob = "usage.recommend"
+)
+
+// DownsampleHandler returns the handler of DownsampleJob
+func DownsampleHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		return s.Downsample(time.Now())
+	}
+}
+
+// RecommendHandler returns the handler of RecommendJob
+func RecommendHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		return s.Recommend(time.Now())
+	}
+}
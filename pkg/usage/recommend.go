@@ -0,0 +1,144 @@
+package usage
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// MinPoints is the number of hourly points an instance needs for a recommendation, a day of usage
+const MinPoints = 24
+
+// The suggested limits are rounded up to steps and at least one step
+const (
+	cpuStep    = 0.1
+	memoryStep = 64 << 20
+)
+
+// unlimited is the memory limit from which on the memory is not limited, cgroup v1 reports the
+// largest page aligned number instead of 0
+const unlimited = 1 << 60
+
+const gib = 1 << 30
+
+// ceil rounds v up to a multiple of step, it is at least step
+func ceil(v, step float64) float64 {
+	return math.Max(math.Ceil(v/step-1e-9), 1) * step
+}
+
+// percentile returns the p-th percentile of vs by the nearest rank
+func percentile(vs []float64, p float64) float64 {
+	if len(vs) == 0 {
+		return 0
+	}
+
+	sorted := append([]float64{}, vs...)
+	sort.Float64s(sorted)
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// recommend returns the recommendation for the hourly points ps of an instance, the oldest first
+func (s *service) recommend(ps []Point, from, to time.Time) Recommendation {
+	r := Recommendation{
+		RefID:    ps[0].RefID,
+		Instance: ps[0].Instance,
+		From:     from,
+		To:       to,
+		Points:   uint(len(ps)),
+		CPULimit: s.options.CPULimit,
+	}
+
+	var cpu float64
+	var memory uint64
+	peaks := make([]float64, len(ps))
+	for i, p := range ps {
+		cpu += p.CPU
+		memory += p.Memory
+		peaks[i] = p.CPUMax
+		if p.MemoryMax > r.MemoryPeak {
+			r.MemoryPeak = p.MemoryMax
+		}
+	}
+	r.CPU = cpu / float64(len(ps))
+	r.Memory = memory / uint64(len(ps))
+	r.CPUPeak = percentile(peaks, 95)
+
+	// the latest limit applies
+	if limit := ps[len(ps)-1].MemoryLimit; limit < unlimited {
+		r.MemoryLimit = limit
+	}
+
+	headroom := 1 + s.options.Headroom
+	r.SuggestedCPU = math.Round(ceil(r.CPUPeak/100*headroom, cpuStep)*10) / 10
+	r.SuggestedMemory = uint64(ceil(float64(r.MemoryPeak)*headroom, memoryStep))
+
+	if r.CPULimit > 0 {
+		r.SavedCPU = math.Round((r.CPULimit-r.SuggestedCPU)*10) / 10
+	}
+	if r.MemoryLimit > 0 {
+		r.SavedMemory = int64(r.MemoryLimit) - int64(r.SuggestedMemory)
+	}
+	r.Savings = int64(math.Round(r.SavedCPU*float64(s.options.CPUPrice) + float64(r.SavedMemory)/gib*float64(s.options.MemoryPrice)))
+	return r
+}
+
+func (s *service) Recommend(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now = now.UTC()
+	from := now.Add(-s.options.Period)
+
+	ps, err := s.points("resolution = ? AND \"time\" >= ? AND \"time\" < ?", Hour, from, now)
+	if err != nil {
+		return err
+	}
+
+	keys := []series{}
+	points := make(map[series][]Point)
+	for _, p := range ps {
+		key := series{p.RefID, p.Instance}
+		if _, ok := points[key]; !ok {
+			keys = append(keys, key)
+		}
+		points[key] = append(points[key], p)
+	}
+
+	// the recommendations are replaced as a whole, instances without enough usage lose theirs
+	err = s.db.Delete(&Recommendation{}, "created_at <= ?", now)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if len(points[key]) < MinPoints {
+			continue
+		}
+
+		r := s.recommend(points[key], from, now)
+		r.CreatedAt = now
+		err = s.db.Create(&r)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) Recommendations(refID uint, r *[]Recommendation) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	rs := []Recommendation{}
+	err := s.db.FindOrdered(&rs, "instance", 0, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	*r = append(*r, rs...)
+	return nil
+}
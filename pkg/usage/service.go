@@ -0,0 +1,343 @@
+// Package usage keeps the history of the resource usage of the instances, it rolls the sampled
+// points up into coarser resolutions, answers range queries for charts and suggests limits for
+// the instances from their usage
+package usage
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Resolutions of the points
+const (
+	Raw         = "raw"
+	FiveMinutes = "5m"
+	Hour        = "1h"
+)
+
+var (
+	// ErrTimeRange occurs if the end of a query is before its start
+	ErrTimeRange = errors.New("the end of the time range is before its start")
+
+	// ErrResolution occurs if a query asks for an unknown resolution
+	ErrResolution = errors.New("unknown resolution")
+)
+
+// rollup aggregates the points of source into points of resolution, one per step
+type rollup struct {
+	resolution string
+	source     string
+	step       time.Duration
+}
+
+// rollups are applied in order, so a rollup can aggregate the points of the previous one
+var rollups = []rollup{
+	{FiveMinutes, Raw, 5 * time.Minute},
+	{Hour, FiveMinutes, time.Hour},
+}
+
+// Service UsageService
+type Service interface {
+	// Record stores the raw points of the instances
+	Record(points []Point) error
+
+	// Query returns the points of an instance of a user within the time range, the oldest first,
+	// and the resolution they have
+	Query(refID uint, q Query, p *[]Point) (string, error)
+
+	// Downsample rolls the complete steps up into the coarser resolutions and removes
+	// the points which are older than the retention of their resolution
+	Downsample(now time.Time) error
+
+	// RemoveInstance removes the points and the recommendation of an instance
+	RemoveInstance(refID uint, instance string) error
+
+	// Recommend replaces the recommendations with ones derived from the hourly points within the period
+	// of the options before now, instances with less than MinPoints points get none
+	Recommend(now time.Time) error
+
+	// Recommendations returns the recommendations for the instances of a user
+	Recommendations(refID uint, r *[]Recommendation) error
+}
+
+// Options configure how long the points of every resolution are kept and how the recommendations are derived
+type Options struct {
+	Raw         time.Duration
+	FiveMinutes time.Duration
+	Hour        time.Duration
+	// Period is the usage the recommendations are derived from, Headroom the share of the peaks added
+	// to the suggested limits, e.g. 0.2
+	Period   time.Duration
+	Headroom float64
+	// CPULimit is the number of cores every instance may use, 0 if it is unlimited
+	CPULimit float64
+	// CPUPrice and MemoryPrice are the monthly prices of a core and a GiB of memory in the smallest
+	// unit of the currency, the savings are 0 without them
+	CPUPrice    uint64
+	MemoryPrice uint64
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type series struct {
+	refID    uint
+	instance string
+}
+
+type step struct {
+	series
+	start time.Time
+}
+
+type service struct {
+	db        dbAdapter
+	retention map[string]time.Duration
+	options   Options
+	mtx       *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Point{}, &Recommendation{})
+}
+
+// points returns the points matching the inline conditions where, the oldest first
+func (s *service) points(where ...interface{}) ([]Point, error) {
+	ps := []Point{}
+	err := s.db.FindOrdered(&ps, "\"time\", id", 0, where...)
+	if err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+func (s *service) Record(points []Point) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for i := range points {
+		p := points[i]
+		p.ID = 0
+		p.Resolution = Raw
+		p.CPUMax, p.MemoryMax = p.CPU, p.Memory
+		if p.Time.IsZero() {
+			p.Time = time.Now()
+		}
+		p.Time = p.Time.UTC()
+
+		err := s.db.Create(&p)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolution returns the finest resolution which is kept from from on
+func (s *service) resolution(from, now time.Time) string {
+	for _, r := range []string{Raw, FiveMinutes} {
+		if !from.Before(now.Add(-s.retention[r])) {
+			return r
+		}
+	}
+	return Hour
+}
+
+func (s *service) Query(refID uint, q Query, p *[]Point) (string, error) {
+	now := time.Now()
+	if q.To.IsZero() {
+		q.To = now
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-time.Hour)
+	}
+	if q.To.Before(q.From) {
+		return "", ErrTimeRange
+	}
+
+	resolution := q.Resolution
+	if resolution == "" {
+		resolution = s.resolution(q.From, now)
+	}
+	if _, ok := s.retention[resolution]; !ok {
+		return "", ErrResolution
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ps, err := s.points("ref_id = ? AND instance = ? AND resolution = ? AND \"time\" >= ? AND \"time\" < ?",
+		refID, q.Instance, resolution, q.From.UTC(), q.To.UTC())
+	if err != nil {
+		return "", err
+	}
+
+	*p = append(*p, ps...)
+	return resolution, nil
+}
+
+// aggregate returns the point of a step of resolution aggregating ps
+func aggregate(st step, resolution string, ps []Point) Point {
+	a := Point{
+		RefID:      st.refID,
+		Instance:   st.instance,
+		Resolution: resolution,
+		Time:       st.start,
+	}
+
+	var cpu float64
+	var memory uint64
+	for _, p := range ps {
+		cpu += p.CPU
+		memory += p.Memory
+		if p.CPUMax > a.CPUMax {
+			a.CPUMax = p.CPUMax
+		}
+		if p.MemoryMax > a.MemoryMax {
+			a.MemoryMax = p.MemoryMax
+		}
+		// the points are sorted, so the latest limit is kept
+		a.MemoryLimit = p.MemoryLimit
+	}
+	a.CPU = cpu / float64(len(ps))
+	a.Memory = memory / uint64(len(ps))
+	return a
+}
+
+// rollup aggregates the steps of r which ended before now and were not rolled up yet
+func (s *service) rollup(r rollup, now time.Time) error {
+	// the points of the steps which ended before now
+	ps, err := s.points("resolution = ? AND \"time\" < ?", r.source, now.Truncate(r.step))
+	if err != nil || len(ps) == 0 {
+		return err
+	}
+
+	// only the steps from the oldest point on can conflict with the steps which were rolled up
+	done, err := s.points("resolution = ? AND \"time\" >= ?", r.resolution, ps[0].Time.Truncate(r.step))
+	if err != nil {
+		return err
+	}
+
+	// end is the end of the last step which was rolled up per series
+	end := make(map[series]time.Time)
+	for _, p := range done {
+		e := p.Time.Add(r.step)
+		if e.After(end[series{p.RefID, p.Instance}]) {
+			end[series{p.RefID, p.Instance}] = e
+		}
+	}
+
+	steps := []step{}
+	points := make(map[step][]Point)
+	for _, p := range ps {
+		st := step{series{p.RefID, p.Instance}, p.Time.Truncate(r.step)}
+		if st.start.Before(end[st.series]) {
+			continue
+		}
+
+		if _, ok := points[st]; !ok {
+			steps = append(steps, st)
+		}
+		points[st] = append(points[st], p)
+	}
+
+	for _, st := range steps {
+		a := aggregate(st, r.resolution, points[st])
+		err = s.db.Create(&a)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) Downsample(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now = now.UTC()
+	for _, r := range rollups {
+		err := s.rollup(r, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	resolutions := []string{}
+	for resolution, retention := range s.retention {
+		resolutions = append(resolutions, resolution)
+
+		err := s.db.Delete(&Point{}, "resolution = ? AND \"time\" < ?", resolution, now.Add(-retention))
+		if err != nil {
+			return err
+		}
+	}
+	return s.db.Delete(&Point{}, "resolution NOT IN (?)", resolutions)
+}
+
+func (s *service) RemoveInstance(refID uint, instance string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	err := s.db.Delete(&Point{}, "ref_id = ? AND instance = ?", refID, instance)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&Recommendation{}, "ref_id = ? AND instance = ?", refID, instance)
+}
+
+// NewService creates a UsageService, the raw points are kept for a day, the five minute
+// rollups for a week and the hourly rollups for 90 days unless the options say otherwise.
+// The recommendations are derived from two weeks of usage with a headroom of 20% by default.
+func NewService(db dbAdapter, o Options) (Service, error) {
+	if o.Raw == 0 {
+		o.Raw = 24 * time.Hour
+	}
+	if o.FiveMinutes == 0 {
+		o.FiveMinutes = 7 * 24 * time.Hour
+	}
+	if o.Hour == 0 {
+		o.Hour = 90 * 24 * time.Hour
+	}
+	if o.Period == 0 {
+		o.Period = 14 * 24 * time.Hour
+	}
+	if o.Headroom == 0 {
+		o.Headroom = 0.2
+	}
+
+	s := &service{
+		db: db,
+		retention: map[string]time.Duration{
+			Raw:         o.Raw,
+			FiveMinutes: o.FiveMinutes,
+			Hour:        o.Hour,
+		},
+		options: o,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
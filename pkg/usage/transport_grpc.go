@@ -0,0 +1,155 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/usage/pb"
+)
+
+// ConvertPoints converts Points to their protobuf representation
+func ConvertPoints(ps []Point) []*pb.Point {
+	points := make([]*pb.Point, len(ps))
+	for i, p := range ps {
+		points[i] = &pb.Point{
+			Time:        p.Time.Unix(),
+			Cpu:         p.CPU,
+			CpuMax:      p.CPUMax,
+			Memory:      p.Memory,
+			MemoryMax:   p.MemoryMax,
+			MemoryLimit: p.MemoryLimit,
+		}
+	}
+	return points
+}
+
+// ConvertPBPoints converts protobuf Points of a resolution to Points
+func ConvertPBPoints(ps []*pb.Point, resolution string) []Point {
+	points := make([]Point, len(ps))
+	for i, p := range ps {
+		points[i] = Point{
+			Resolution:  resolution,
+			Time:        time.Unix(p.Time, 0).UTC(),
+			CPU:         p.Cpu,
+			CPUMax:      p.CpuMax,
+			Memory:      p.Memory,
+			MemoryMax:   p.MemoryMax,
+			MemoryLimit: p.MemoryLimit,
+		}
+	}
+	return points
+}
+
+// DecodeGRPCQueryRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Query request to a messages/usage.proto-domain query request.
+func DecodeGRPCQueryRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.QueryRequest)
+	q := Query{
+		Instance:   req.Instance,
+		Resolution: req.Resolution,
+	}
+	// an unset bound gets its default
+	if req.From != 0 {
+		q.From = time.Unix(req.From, 0)
+	}
+	if req.To != 0 {
+		q.To = time.Unix(req.To, 0)
+	}
+
+	return QueryRequest{
+		RefID: uint(req.RefID),
+		Query: q,
+	}, nil
+}
+
+// EncodeGRPCQueryResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/usage.proto-domain query response to a gRPC Query response.
+func EncodeGRPCQueryResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(QueryResponse)
+	gRPCRes := &pb.QueryResponse{
+		Points:     ConvertPoints(res.Points),
+		Resolution: res.Resolution,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// ConvertRecommendation converts a Recommendation to its protobuf representation
+func ConvertRecommendation(r Recommendation) *pb.Recommendation {
+	return &pb.Recommendation{
+		Instance:        r.Instance,
+		From:            r.From.Unix(),
+		To:              r.To.Unix(),
+		Points:          uint32(r.Points),
+		Cpu:             r.CPU,
+		CpuPeak:         r.CPUPeak,
+		Memory:          r.Memory,
+		MemoryPeak:      r.MemoryPeak,
+		CpuLimit:        r.CPULimit,
+		MemoryLimit:     r.MemoryLimit,
+		SuggestedCpu:    r.SuggestedCPU,
+		SuggestedMemory: r.SuggestedMemory,
+		SavedCpu:        r.SavedCPU,
+		SavedMemory:     r.SavedMemory,
+		Savings:         r.Savings,
+		CreatedAt:       r.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBRecommendation converts a protobuf Recommendation to a Recommendation
+func ConvertPBRecommendation(r *pb.Recommendation) Recommendation {
+	if r == nil {
+		return Recommendation{}
+	}
+
+	return Recommendation{
+		Instance:        r.Instance,
+		From:            time.Unix(r.From, 0).UTC(),
+		To:              time.Unix(r.To, 0).UTC(),
+		Points:          uint(r.Points),
+		CPU:             r.Cpu,
+		CPUPeak:         r.CpuPeak,
+		Memory:          r.Memory,
+		MemoryPeak:      r.MemoryPeak,
+		CPULimit:        r.CpuLimit,
+		MemoryLimit:     r.MemoryLimit,
+		SuggestedCPU:    r.SuggestedCpu,
+		SuggestedMemory: r.SuggestedMemory,
+		SavedCPU:        r.SavedCpu,
+		SavedMemory:     r.SavedMemory,
+		Savings:         r.Savings,
+		CreatedAt:       time.Unix(r.CreatedAt, 0).UTC(),
+	}
+}
+
+// DecodeGRPCRecommendationsRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Recommendations request to a messages/usage.proto-domain recommendations request.
+func DecodeGRPCRecommendationsRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RecommendationsRequest)
+	return RecommendationsRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCRecommendationsResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/usage.proto-domain recommendations response to a gRPC Recommendations response.
+func EncodeGRPCRecommendationsResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RecommendationsResponse)
+	recommendations := make([]*pb.Recommendation, len(res.Recommendations))
+	for i, r := range res.Recommendations {
+		recommendations[i] = ConvertRecommendation(r)
+	}
+
+	gRPCRes := &pb.RecommendationsResponse{
+		Recommendations: recommendations,
+		PageInfo:        paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,237 @@
+package usage_test
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/usage"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func cpus(ps []usage.Point) []float64 {
+	cs := []float64{}
+	for _, p := range ps {
+		cs = append(cs, p.CPU)
+	}
+	return cs
+}
+
+var _ = Describe("Usage", func() {
+	var (
+		refID = uint(1)
+		s     usage.Service
+		// start is aligned to the steps of every resolution
+		start = time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	)
+
+	BeforeEach(func() {
+		var err error
+		s, err = usage.NewService(testutils.NewMockDB(), usage.Options{})
+		Ω(err).ShouldNot(HaveOccurred())
+	})
+
+	Describe("Create Service", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := usage.NewService(db, usage.Options{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Query", func() {
+		It("Should return the points of an instance within the time range", func() {
+			s.Record([]usage.Point{
+				{RefID: refID, Instance: "web", Time: start, CPU: 1},
+				{RefID: refID, Instance: "web", Time: start.Add(time.Minute), CPU: 2},
+				{RefID: refID, Instance: "db", Time: start.Add(time.Minute), CPU: 3},
+				{RefID: 2, Instance: "web", Time: start.Add(time.Minute), CPU: 4},
+				{RefID: refID, Instance: "web", Time: start.Add(2 * time.Minute), CPU: 5},
+			})
+
+			points := []usage.Point{}
+			resolution, err := s.Query(refID, usage.Query{
+				Instance: "web",
+				From:     start,
+				To:       start.Add(2 * time.Minute),
+			}, &points)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(resolution).To(Equal(usage.Raw))
+			Expect(cpus(points)).To(Equal([]float64{1, 2}))
+			Expect(points[0].CPUMax).To(Equal(1.0))
+		})
+
+		It("Should choose the resolution which is kept for the time range", func() {
+			points := []usage.Point{}
+			resolution, err := s.Query(refID, usage.Query{Instance: "web", From: time.Now().Add(-48 * time.Hour)}, &points)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(resolution).To(Equal(usage.FiveMinutes))
+
+			resolution, _ = s.Query(refID, usage.Query{Instance: "web", From: time.Now().Add(-30 * 24 * time.Hour)}, &points)
+			Expect(resolution).To(Equal(usage.Hour))
+		})
+
+		It("Should reject invalid queries", func() {
+			points := []usage.Point{}
+			_, err := s.Query(refID, usage.Query{Instance: "web", From: start, To: start.Add(-time.Minute)}, &points)
+			Expect(err).To(Equal(usage.ErrTimeRange))
+
+			_, err = s.Query(refID, usage.Query{Instance: "web", Resolution: "1d"}, &points)
+			Expect(err).To(Equal(usage.ErrResolution))
+		})
+	})
+
+	Describe("Downsample", func() {
+		It("Should roll the complete steps up", func() {
+			s.Record([]usage.Point{
+				{RefID: refID, Instance: "web", Time: start, CPU: 10, Memory: 100, MemoryLimit: 1000},
+				{RefID: refID, Instance: "web", Time: start.Add(time.Minute), CPU: 30, Memory: 300, MemoryLimit: 1000},
+				{RefID: refID, Instance: "web", Time: start.Add(5 * time.Minute), CPU: 50, Memory: 500, MemoryLimit: 2000},
+				{RefID: refID, Instance: "web", Time: start.Add(time.Hour), CPU: 70},
+			})
+
+			Ω(s.Downsample(start.Add(time.Hour + time.Minute))).Should(Succeed())
+
+			points := []usage.Point{}
+			s.Query(refID, usage.Query{Instance: "web", Resolution: usage.FiveMinutes, From: start, To: start.Add(2 * time.Hour)}, &points)
+			Expect(cpus(points)).To(Equal([]float64{20, 50}))
+			Expect(points[0].CPUMax).To(Equal(30.0))
+			Expect(points[0].Memory).To(Equal(uint64(200)))
+			Expect(points[0].MemoryMax).To(Equal(uint64(300)))
+			Expect(points[1].MemoryLimit).To(Equal(uint64(2000)))
+
+			points = []usage.Point{}
+			s.Query(refID, usage.Query{Instance: "web", Resolution: usage.Hour, From: start, To: start.Add(2 * time.Hour)}, &points)
+			Expect(cpus(points)).To(Equal([]float64{35}))
+			Expect(points[0].CPUMax).To(Equal(50.0))
+
+			// the rolled up steps are not aggregated again
+			Ω(s.Downsample(start.Add(time.Hour + 2*time.Minute))).Should(Succeed())
+			points = []usage.Point{}
+			s.Query(refID, usage.Query{Instance: "web", Resolution: usage.FiveMinutes, From: start, To: start.Add(2 * time.Hour)}, &points)
+			Expect(points).To(HaveLen(2))
+		})
+
+		It("Should remove the points beyond their retention", func() {
+			s.Record([]usage.Point{
+				{RefID: refID, Instance: "web", Time: start, CPU: 10},
+			})
+
+			Ω(s.Downsample(start.Add(25 * time.Hour))).Should(Succeed())
+
+			points := []usage.Point{}
+			s.Query(refID, usage.Query{Instance: "web", Resolution: usage.Raw, From: start, To: start.Add(time.Hour)}, &points)
+			Expect(points).To(BeEmpty())
+
+			s.Query(refID, usage.Query{Instance: "web", Resolution: usage.FiveMinutes, From: start, To: start.Add(time.Hour)}, &points)
+			Expect(cpus(points)).To(Equal([]float64{10}))
+		})
+
+		It("Should remove the points of an instance", func() {
+			s.Record([]usage.Point{
+				{RefID: refID, Instance: "web", Time: start, CPU: 10},
+				{RefID: refID, Instance: "db", Time: start, CPU: 20},
+			})
+			Ω(s.RemoveInstance(refID, "web")).Should(Succeed())
+
+			points := []usage.Point{}
+			s.Query(refID, usage.Query{Instance: "web", From: start}, &points)
+			Expect(points).To(BeEmpty())
+			s.Query(refID, usage.Query{Instance: "db", From: start}, &points)
+			Expect(points).To(HaveLen(1))
+		})
+	})
+
+	Describe("Recommend", func() {
+		It("Should suggest limits from the hourly usage", func() {
+			s, _ = usage.NewService(testutils.NewMockDB(), usage.Options{
+				CPULimit:    1,
+				CPUPrice:    1000,
+				MemoryPrice: 500,
+			})
+			now := time.Now().UTC().Truncate(time.Hour)
+			from := now.Add(-30 * time.Hour)
+
+			points := []usage.Point{}
+			for i := 0; i < 30; i++ {
+				p := usage.Point{RefID: refID, Instance: "web", Time: from.Add(time.Duration(i) * time.Hour), CPU: 20, Memory: 200 << 20, MemoryLimit: 1 << 30}
+				if i%2 == 1 {
+					p.CPU = 40
+				}
+				switch i {
+				case 5:
+					p.CPU = 90
+				case 6:
+					p.Memory = 300 << 20
+				}
+				points = append(points, p)
+
+				if i < 10 {
+					points = append(points, usage.Point{RefID: refID, Instance: "db", Time: p.Time, CPU: 10})
+				}
+				if i < 24 {
+					points = append(points, usage.Point{RefID: 2, Instance: "cache", Time: p.Time, CPU: 5, Memory: 10 << 20})
+				}
+			}
+			Ω(s.Record(points)).Should(Succeed())
+			Ω(s.Downsample(now)).Should(Succeed())
+			Ω(s.Recommend(now)).Should(Succeed())
+
+			rs := []usage.Recommendation{}
+			Ω(s.Recommendations(refID, &rs)).Should(Succeed())
+			Expect(rs).To(HaveLen(1))
+			r := rs[0]
+			Expect(r.Instance).To(Equal("web"))
+			Expect(r.Points).To(BeEquivalentTo(30))
+			Expect(r.CPUPeak).To(Equal(40.0))
+			Expect(r.MemoryPeak).To(BeEquivalentTo(300 << 20))
+			Expect(r.SuggestedCPU).To(Equal(0.5))
+			Expect(r.SuggestedMemory).To(BeEquivalentTo(384 << 20))
+			Expect(r.SavedCPU).To(Equal(0.5))
+			Expect(r.SavedMemory).To(BeEquivalentTo(640 << 20))
+			Expect(r.Savings).To(BeEquivalentTo(813))
+
+			rs = []usage.Recommendation{}
+			s.Recommendations(2, &rs)
+			Expect(rs).To(HaveLen(1))
+			Expect(rs[0].SuggestedCPU).To(Equal(0.1))
+			Expect(rs[0].SuggestedMemory).To(BeEquivalentTo(64 << 20))
+			Expect(rs[0].SavedMemory).To(BeZero())
+
+			// recommendations are replaced and removed with their instance
+			Ω(s.Recommend(now)).Should(Succeed())
+			Ω(s.RemoveInstance(refID, "web")).Should(Succeed())
+			rs = []usage.Recommendation{}
+			s.Recommendations(refID, &rs)
+			Expect(rs).To(BeEmpty())
+			s.Recommendations(2, &rs)
+			Expect(rs).To(HaveLen(1))
+		})
+	})
+
+	Describe("Collector", func() {
+		It("Should record the usage from the second sample on", func() {
+			stats := []usage.Stats{
+				{RefID: refID, Instance: "web", CPU: 0, Memory: 50, MemoryLimit: 100, Started: "1"},
+			}
+			c := usage.NewCollector(s, func() ([]usage.Stats, error) {
+				return stats, nil
+			}, log.NewNopLogger())
+
+			c.Collect(start)
+			stats[0].CPU = uint64(15 * time.Second)
+			c.Collect(start.Add(30 * time.Second))
+			// a restart resets the CPU time
+			stats[0].CPU, stats[0].Started = 0, "2"
+			c.Collect(start.Add(time.Minute))
+
+			points := []usage.Point{}
+			s.Query(refID, usage.Query{Instance: "web", From: start, To: start.Add(time.Hour)}, &points)
+			Expect(cpus(points)).To(Equal([]float64{50}))
+			Expect(points[0].Memory).To(Equal(uint64(50)))
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/cache"
+)
+
+func userKey(id uint) string {
+	return fmt.Sprintf("user:%d", id)
+}
+
+type cachedService struct {
+	Service
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// invalidate removes the cached user id if err is nil and returns err
+func (c *cachedService) invalidate(id uint, err error) error {
+	if err == nil {
+		c.cache.Delete(userKey(id))
+	}
+	return err
+}
+
+func (c *cachedService) EditUser(id uint, cfg *Config) error {
+	return c.invalidate(id, c.Service.EditUser(id, cfg))
+}
+
+func (c *cachedService) ChangeUsername(id uint, username string) error {
+	return c.invalidate(id, c.Service.ChangeUsername(id, username))
+}
+
+func (c *cachedService) DeleteUser(id uint) error {
+	return c.invalidate(id, c.Service.DeleteUser(id))
+}
+
+func (c *cachedService) GetUser(id uint, user *User) error {
+	found, err := c.cache.Get(userKey(id), user)
+	if found && err == nil {
+		return nil
+	}
+
+	err = c.Service.GetUser(id, user)
+	if err != nil {
+		return err
+	}
+
+	c.cache.Set(userKey(id), user, c.ttl)
+	return nil
+}
+
+// NewCachedService returns a Service keeping users in c for ttl, e.g. for the permission checks of
+// every call, a user is invalidated when it is edited, renamed or deleted. Failing cache operations
+// fall back to s.
+func NewCachedService(s Service, c cache.Cache, ttl time.Duration) Service {
+	return &cachedService{
+		Service: s,
+		cache:   c,
+		ttl:     ttl,
+	}
+}
@@ -24,7 +24,7 @@ func (a Address) String() string {
 // The Config struct represents a users general information
 type Config struct {
 	Admin     bool
-	Email     string
+	Email     string `validate:"email"`
 	Password  string
 	Salt      string
 	Image     string
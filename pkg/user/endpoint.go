@@ -19,8 +19,8 @@ type Endpoints struct {
 
 // CreateUserRequest is the request struct for the CreateUserEndpoint
 type CreateUserRequest struct {
-	Username string
-	Cfg      *Config
+	Username string  `validate:"required,name"`
+	Cfg      *Config `validate:"required"`
 	Adr      *Address
 }
 
@@ -44,8 +44,8 @@ func MakeCreateUserEndpoint(s Service) endpoint.Endpoint {
 
 // EditUserRequest is the request struct for the EditUserEndpoint
 type EditUserRequest struct {
-	ID  uint `bart:"ref"`
-	Cfg *Config
+	ID  uint    `bart:"ref"`
+	Cfg *Config `validate:"required"`
 }
 
 // EditUserResponse is the response struct for the EditUserEndpoint
@@ -66,8 +66,8 @@ func MakeEditUserEndpoint(s Service) endpoint.Endpoint {
 
 // ChangeUsernameRequest is the request struct for the ChangeUsernameEndpoint
 type ChangeUsernameRequest struct {
-	ID       uint `bart:"ref"`
-	Username string
+	ID       uint   `bart:"ref"`
+	Username string `validate:"required,name"`
 }
 
 // ChangeUsernameResponse is the response struct for the ChangeUsernameResponse
@@ -109,7 +109,7 @@ func MakeDeleteUserEndpoint(s Service) endpoint.Endpoint {
 
 // ResetPasswordRequest is the request struct for the ResetPasswordEndpoint
 type ResetPasswordRequest struct {
-	Email string
+	Email string `validate:"required,email"`
 }
 
 // ResetPasswordResponse is the response struct for the ResetPasswordEndpoint
@@ -154,8 +154,8 @@ func MakeGetUserEndpoint(s Service) endpoint.Endpoint {
 
 // CheckLoginCredentialsRequest is the request struct for the CheckLoginCredentialsEndpoint
 type CheckLoginCredentialsRequest struct {
-	Username string
-	Password string
+	Username string `validate:"required"`
+	Password string `validate:"required"`
 }
 
 // CheckLoginCredentialsResponse is the response struct for the CheckLoginCredentialsEndpoint
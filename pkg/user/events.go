@@ -0,0 +1,50 @@
+package user
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+type eventService struct {
+	Service
+	bus    events.Bus
+	logger log.Logger
+}
+
+func (s *eventService) publish(topic string, e events.UserEvent) {
+	err := s.bus.Publish(topic, e)
+	if err != nil {
+		level.Error(s.logger).Log("topic", topic, "user", e.ID, "err", err)
+	}
+}
+
+func (s *eventService) CreateUser(username string, cfg *Config, adr *Address) (uint, error) {
+	id, err := s.Service.CreateUser(username, cfg, adr)
+	if err != nil {
+		return id, err
+	}
+
+	s.publish(events.UserCreated, events.UserEvent{ID: id, Username: username})
+	return id, nil
+}
+
+func (s *eventService) DeleteUser(id uint) error {
+	err := s.Service.DeleteUser(id)
+	if err != nil {
+		return err
+	}
+
+	s.publish(events.UserDeleted, events.UserEvent{ID: id})
+	return nil
+}
+
+// NewEventService returns a Service which publishes an event once a user was created or deleted,
+// failing to publish is only logged
+func NewEventService(s Service, bus events.Bus, logger log.Logger) Service {
+	return &eventService{
+		Service: s,
+		bus:     bus,
+		logger:  logger,
+	}
+}
@@ -0,0 +1,29 @@
+package user
+
+// DeleteHook is called before a user is deleted, an error aborts the deletion
+type DeleteHook func(id uint) error
+
+type hookedService struct {
+	Service
+	onDelete []DeleteHook
+}
+
+func (h *hookedService) DeleteUser(id uint) error {
+	for _, hook := range h.onDelete {
+		err := hook(id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return h.Service.DeleteUser(id)
+}
+
+// NewHookedService returns a Service which releases the resources other services
+// hold for a user by calling hooks before the user is deleted
+func NewHookedService(s Service, hooks ...DeleteHook) Service {
+	return &hookedService{
+		Service:  s,
+		onDelete: hooks,
+	}
+}
@@ -34,6 +34,9 @@ type Service interface {
 	// CheckLoginCredentials is used to check the login credentials of a user
 	CheckLoginCredentials(username string, password string) uint
 
+	// CountUsers returns the number of users
+	CountUsers() (uint, error)
+
 	getDB() abstraction.DBAdapter
 }
 
@@ -42,6 +45,7 @@ type dbAdapter interface {
 	AutoMigrate(...interface{}) error
 	Where(interface{}, ...interface{}) error
 	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
 	Create(interface{}) error
 	Delete(interface{}, ...interface{}) error
 	Update(interface{}, ...interface{}) error
@@ -175,6 +179,15 @@ func (s *service) CheckLoginCredentials(username string, password string) uint {
 	return 0
 }
 
+func (s *service) CountUsers() (uint, error) {
+	users := []User{}
+	err := s.db.Find(&users)
+	if err != nil {
+		return 0, err
+	}
+	return uint(len(users)), nil
+}
+
 // NewService creates a UserService with necessary dependencies.
 func NewService(db dbAdapter, bcryptCost int) (Service, error) {
 	s := &service{
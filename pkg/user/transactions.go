@@ -101,6 +101,13 @@ func (t *transactionBasedService) GetUser(id uint, user *User) error {
 	return nil
 }
 
+func (t *transactionBasedService) CountUsers() (uint, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	return t.s.CountUsers()
+}
+
 func (t *transactionBasedService) getDB() abstraction.DBAdapter {
 	return t.db
 }
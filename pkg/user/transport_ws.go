@@ -3,7 +3,7 @@ package user
 import (
 	"context"
 
-	"github.com/golang/protobuf/proto"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
 	"github.com/kontainerooo/kontainer.ooo/pkg/user/pb"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
 )
@@ -67,7 +67,7 @@ func MakeWebsocketService(endpoints Endpoints) *ws.ServiceDescription {
 // WS CreateUser request to a messages/user.proto-domain createuser request.
 func DecodeWSCreateUserRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.CreateUserRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -79,7 +79,7 @@ func DecodeWSCreateUserRequest(ctx context.Context, data interface{}) (interface
 // WS EditUser request to a messages/user.proto-domain edituser request.
 func DecodeWSEditUserRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.EditUserRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -91,7 +91,7 @@ func DecodeWSEditUserRequest(ctx context.Context, data interface{}) (interface{}
 // WS ChangeUsername request to a messages/user.proto-domain changeusername request.
 func DecodeWSChangeUsernameRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.ChangeUsernameRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -103,7 +103,7 @@ func DecodeWSChangeUsernameRequest(ctx context.Context, data interface{}) (inter
 // WS DeleteUser request to a messages/user.proto-domain deleteuser request.
 func DecodeWSDeleteUserRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.DeleteUserRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -115,7 +115,7 @@ func DecodeWSDeleteUserRequest(ctx context.Context, data interface{}) (interface
 // WS ResetPassword request to a messages/user.proto-domain resetpassword request.
 func DecodeWSResetPasswordRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.ResetPasswordRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -127,7 +127,7 @@ func DecodeWSResetPasswordRequest(ctx context.Context, data interface{}) (interf
 // WS GetUser request to a messages/user.proto-domain getuser request.
 func DecodeWSGetUserRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.GetUserRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -139,7 +139,7 @@ func DecodeWSGetUserRequest(ctx context.Context, data interface{}) (interface{},
 // WS CheckLoginCredentials request to a messages/user.proto-domain CheckLoginCredentials request.
 func DecodeWSCheckLoginCredentialsRequest(ctx context.Context, data interface{}) (interface{}, error) {
 	req := &pb.CheckLoginCredentialsRequest{}
-	err := proto.Unmarshal(data.([]byte), req)
+	err := payload.Unmarshal(data.([]byte), req)
 	if err != nil {
 		return nil, err
 	}
@@ -2,9 +2,12 @@ package user_test
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/kontainerooo/kontainer.ooo/pkg/cache"
 	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
 	"github.com/kontainerooo/kontainer.ooo/pkg/user"
 
@@ -12,6 +15,30 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+// usersService keeps users in a map and counts the lookups
+type usersService struct {
+	user.Service
+	users map[uint]user.User
+	reads int
+}
+
+func (s *usersService) GetUser(id uint, u *user.User) error {
+	s.reads++
+	found, ok := s.users[id]
+	if !ok {
+		return testutils.ErrNotFound
+	}
+	*u = found
+	return nil
+}
+
+func (s *usersService) ChangeUsername(id uint, username string) error {
+	u := s.users[id]
+	u.Username = username
+	s.users[id] = u
+	return nil
+}
+
 var _ = Describe("User", func() {
 	Describe("Create Service", func() {
 		It("Should create service", func() {
@@ -136,6 +163,39 @@ var _ = Describe("User", func() {
 		})
 	})
 
+	Describe("Delete Hooks", func() {
+		db := testutils.NewMockDB()
+		userService, _ := user.NewService(db, bcrypt.MinCost)
+		called := []uint{}
+		hookErr := errors.New("hook")
+		fail := false
+		userService = user.NewHookedService(user.NewTransactionBasedService(userService), func(id uint) error {
+			called = append(called, id)
+			if fail {
+				return hookErr
+			}
+			return nil
+		})
+
+		It("Should call hooks before deleting the user", func() {
+			id, _ := userService.CreateUser("username", &user.Config{}, &user.Address{})
+			err := userService.DeleteUser(id)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(called).To(Equal([]uint{id}))
+		})
+
+		It("Should not delete the user if a hook fails", func() {
+			id, _ := userService.CreateUser("username2", &user.Config{}, &user.Address{})
+			fail = true
+			err := userService.DeleteUser(id)
+			Ω(err).Should(Equal(hookErr))
+
+			u := &user.User{}
+			err = userService.GetUser(id, u)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+	})
+
 	Describe("GetUser", func() {
 		db := testutils.NewMockDB()
 		userService, _ := user.NewService(db, bcrypt.MinCost)
@@ -157,6 +217,32 @@ var _ = Describe("User", func() {
 		})
 	})
 
+	Describe("Cache", func() {
+		s := &usersService{users: map[uint]user.User{1: {ID: 1, Username: "username"}}}
+		userService := user.NewCachedService(s, cache.NewLRU(10), time.Minute)
+
+		It("Should keep users until they are changed", func() {
+			for i := 0; i < 2; i++ {
+				u := &user.User{}
+				Ω(userService.GetUser(1, u)).Should(Succeed())
+				Expect(u.Username).To(Equal("username"))
+			}
+			Expect(s.reads).To(Equal(1))
+
+			Ω(userService.ChangeUsername(1, "renamed")).Should(Succeed())
+			u := &user.User{}
+			Ω(userService.GetUser(1, u)).Should(Succeed())
+			Expect(u.Username).To(Equal("renamed"))
+		})
+
+		It("Should not keep failed lookups", func() {
+			s.reads = 0
+			Ω(userService.GetUser(2, &user.User{})).ShouldNot(Succeed())
+			Ω(userService.GetUser(2, &user.User{})).ShouldNot(Succeed())
+			Expect(s.reads).To(Equal(2))
+		})
+	})
+
 	XDescribe("CheckLoginCredentials", func() {
 
 	})
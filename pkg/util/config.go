@@ -15,6 +15,39 @@ type ConfigFile struct {
 	CustomerPath         string
 	NetNSPath            string
 	StandardPathVariable string
+	BaseDomain           string
+	ACMEDirectory        string
+	ACMEEmail            string
+	ACMEWildcard         bool
+	CertificatePath      string
+	AnalyticsPath        string
+	RoutingTemplatePath  string
+	Router               string
+	PublicIPv4           string
+	PublicIPv6           string
+	DNSTarget            string
+	PowerDNSURL          string
+	PowerDNSAPIKey       string
+	NetworkPool          string
+	NetworkPrefix        int
+	NodeName             string
+	NodeAddress          string
+	NetworkMetering      bool
+	Firewall             bool
+	Checkpoints          bool
+	CRIUPath             string
+	CheckpointRestart    bool
+	MemoryLimit          int64
+	SwapLimit            int64
+	CPULimit             float64
+	PidsLimit            int64
+	ReservedCPU          float64
+	ReservedMemory       int64
+	CPUOvercommit        float64
+	MemoryOvercommit     float64
+	GRPCCertFile         string
+	GRPCKeyFile          string
+	GatewayAddr          string
 }
 
 var configLoaded = false
@@ -34,8 +67,15 @@ func GetConfig() (ConfigFile, error) {
 			return ConfigFile{}, err
 		}
 
+		configLoaded = true
 		return conf, nil
 	}
 
 	return conf, nil
 }
+
+// SetConfig replaces the config returned by GetConfig, it is used by the daemon to pass on its configuration
+func SetConfig(c ConfigFile) {
+	conf = c
+	configLoaded = true
+}
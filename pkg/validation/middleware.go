@@ -0,0 +1,44 @@
+package validation
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-kit/kit/endpoint"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// FieldsHeader is the gRPC metadata key carrying the invalid fields of a rejected request as JSON,
+// the gateway returns them in the fields of the error response
+const FieldsHeader = "invalid-fields"
+
+// Middleware returns an endpoint middleware rejecting requests with invalid fields with Errors
+// before the endpoint is called
+func Middleware() endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			err := Validate(request)
+			if err != nil {
+				return nil, err
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// UnaryServerInterceptor turns Errors returned by the endpoints into codes.InvalidArgument errors,
+// the invalid fields are sent in the FieldsHeader
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		res, err := handler(ctx, req)
+		if errs, ok := err.(Errors); ok {
+			fields, _ := json.Marshal(errs)
+			grpc.SetHeader(ctx, metadata.Pairs(FieldsHeader, string(fields)))
+			return nil, grpc.Errorf(codes.InvalidArgument, "%v", errs)
+		}
+		return res, err
+	}
+}
@@ -0,0 +1,277 @@
+// Package validation checks the fields of requests against the rules given in their validate tags,
+// e.g. `validate:"required,name"`. Every invalid field is reported so clients can highlight all of them.
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+)
+
+// Tag is the name of the struct tag holding the rules of a field
+const Tag = "validate"
+
+// FieldError is an invalid field, Field is the path of the field in the request, e.g. Config.Email
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Field, e.Message)
+}
+
+// Errors are all invalid fields of a request
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	s := make([]string, len(e))
+	for i, f := range e {
+		s[i] = f.Error()
+	}
+	return strings.Join(s, ", ")
+}
+
+// Func checks a value against a rule with the parameter given after = in the tag, it returns
+// why the value is invalid or an empty string if it is valid. Rules are not called for zero
+// values, which are only rejected by required.
+type Func func(v reflect.Value, param string) string
+
+var (
+	nameRegexp   = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	domainRegexp = regexp.MustCompile(`^(\*\.)?([a-zA-Z0-9_]([a-zA-Z0-9_-]{0,61}[a-zA-Z0-9])?\.)+[a-zA-Z][a-zA-Z0-9-]{0,61}[a-zA-Z0-9]$`)
+)
+
+// MaxNameLength is the maximum length of names of containers, modules, networks and nodes
+const MaxNameLength = 63
+
+// length returns the length of strings, slices and maps or the value of numbers
+func length(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// bound returns a rule comparing the length or value of a field with its parameter
+func bound(min bool) Func {
+	return func(v reflect.Value, param string) string {
+		n, err := strconv.ParseFloat(param, 64)
+		l, ok := length(v)
+		if err != nil || !ok {
+			return "can't be checked"
+		}
+
+		unit := ""
+		if v.Kind() == reflect.String {
+			unit = " characters"
+		} else if v.Kind() == reflect.Slice || v.Kind() == reflect.Map || v.Kind() == reflect.Array {
+			unit = " entries"
+		}
+
+		if min && l < n {
+			return fmt.Sprintf("has to be at least %s%s", param, unit)
+		}
+		if !min && l > n {
+			return fmt.Sprintf("can't be more than %s%s", param, unit)
+		}
+		return ""
+	}
+}
+
+// stringRule returns a rule for string fields
+func stringRule(valid func(s, param string) bool, message string) Func {
+	return func(v reflect.Value, param string) string {
+		if v.Kind() != reflect.String || !valid(v.String(), param) {
+			return message
+		}
+		return ""
+	}
+}
+
+var (
+	mtx   sync.RWMutex
+	rules = map[string]Func{
+		"min": bound(true),
+		"max": bound(false),
+		"name": stringRule(func(s, _ string) bool {
+			return len(s) <= MaxNameLength && nameRegexp.MatchString(s)
+		}, "is not a valid name"),
+		"domain": stringRule(func(s, _ string) bool {
+			return len(s) <= 253 && domainRegexp.MatchString(s)
+		}, "is not a valid domain"),
+		"inet": stringRule(func(s, _ string) bool {
+			i := abstraction.Inet(s)
+			if strings.Contains(s, "/") {
+				_, _, err := i.ParseCIDR()
+				return err == nil
+			}
+			return i.IP() != nil
+		}, "is not a valid IP address"),
+		"email": stringRule(func(s, _ string) bool {
+			a, err := mail.ParseAddress(s)
+			return err == nil && a.Address == s
+		}, "is not a valid email address"),
+		"url": stringRule(func(s, _ string) bool {
+			u, err := url.Parse(s)
+			return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+		}, "is not a valid http or https URL"),
+		"protocol": stringRule(func(s, _ string) bool {
+			return s == "tcp" || s == "udp"
+		}, "has to be tcp or udp"),
+		"oneof": func(v reflect.Value, param string) string {
+			options := strings.Split(param, "|")
+			for _, o := range options {
+				if fmt.Sprint(v.Interface()) == o {
+					return ""
+				}
+			}
+			return "has to be one of " + strings.Join(options, ", ")
+		},
+		"port": func(v reflect.Value, _ string) string {
+			n, ok := length(v)
+			if !ok || v.Kind() == reflect.String || n < 1 || n > 65535 {
+				return "is not a valid port"
+			}
+			return ""
+		},
+	}
+)
+
+// Register adds a rule which can be used in the validate tags as name
+func Register(name string, f Func) {
+	mtx.Lock()
+	defer mtx.Unlock()
+	rules[name] = f
+}
+
+func rule(name string) (Func, bool) {
+	mtx.RLock()
+	defer mtx.RUnlock()
+	f, ok := rules[name]
+	return f, ok
+}
+
+// each reports whether a rule applies to the elements of slices instead of the slice itself
+func each(name string) bool {
+	return name != "min" && name != "max"
+}
+
+// check checks v against the rules of tag and adds the errors to errs
+func check(v reflect.Value, path, tag string, errs *Errors) {
+	for _, r := range strings.Split(tag, ",") {
+		name, param := r, ""
+		if i := strings.Index(r, "="); i != -1 {
+			name, param = r[:i], r[i+1:]
+		}
+		if name == "" {
+			continue
+		}
+
+		if name == "required" {
+			if isZero(v) {
+				*errs = append(*errs, FieldError{path, "is required"})
+				return
+			}
+			continue
+		}
+
+		f, ok := rule(name)
+		if !ok {
+			*errs = append(*errs, FieldError{path, fmt.Sprintf("has the unknown rule %s", name)})
+			continue
+		}
+
+		values, paths := []reflect.Value{reflect.Indirect(v)}, []string{path}
+		if v.Kind() == reflect.Slice && each(name) {
+			values, paths = nil, nil
+			for i := 0; i < v.Len(); i++ {
+				values = append(values, v.Index(i))
+				paths = append(paths, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+
+		for i, e := range values {
+			if !e.IsValid() || isZero(e) {
+				continue
+			}
+			if msg := f(e, param); msg != "" {
+				*errs = append(*errs, FieldError{paths[i], msg})
+			}
+		}
+	}
+}
+
+func isZero(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+		return v.IsNil() || (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return reflect.DeepEqual(v.Interface(), reflect.Zero(v.Type()).Interface())
+}
+
+// walk checks the fields of the struct v and of the structs it contains
+func walk(v reflect.Value, path string, errs *Errors) {
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		p := f.Name
+		if path != "" {
+			p = path + "." + f.Name
+		}
+		if f.Anonymous {
+			p = path
+		}
+
+		fv := v.Field(i)
+		if tag, ok := f.Tag.Lookup(Tag); ok {
+			check(fv, p, tag, errs)
+		}
+
+		switch fv.Kind() {
+		case reflect.Struct, reflect.Ptr:
+			walk(fv, p, errs)
+		case reflect.Slice:
+			if fv.Type().Elem().Kind() == reflect.Struct || fv.Type().Elem().Kind() == reflect.Ptr {
+				for j := 0; j < fv.Len(); j++ {
+					walk(fv.Index(j), fmt.Sprintf("%s[%d]", p, j), errs)
+				}
+			}
+		}
+	}
+}
+
+// Validate checks the fields of the struct v, it returns Errors if any of them are invalid
+func Validate(v interface{}) error {
+	errs := Errors{}
+	walk(reflect.ValueOf(v), "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
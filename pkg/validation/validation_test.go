@@ -0,0 +1,181 @@
+package validation_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/validation"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type listen struct {
+	IP   abstraction.Inet `validate:"inet"`
+	Port uint16           `validate:"port"`
+}
+
+type embedded struct {
+	Name string `validate:"required,name"`
+}
+
+type request struct {
+	embedded
+	ID       string   `validate:"required"`
+	Email    string   `validate:"email"`
+	Protocol string   `validate:"protocol"`
+	Domains  []string `validate:"domain"`
+	Tags     []string `validate:"max=2"`
+	Type     string   `validate:"oneof=A|AAAA"`
+	URL      string   `validate:"url"`
+	Listen   *listen  `validate:"required"`
+	Members  []listen
+	Address  abstraction.Inet `validate:"required,inet"`
+}
+
+type Public struct {
+	Name string `validate:"required,name"`
+}
+
+type withPublic struct {
+	Public
+	Count int `validate:"min=1,max=3"`
+}
+
+func valid() *request {
+	return &request{
+		ID:       "abc",
+		Email:    "kroo@example.com",
+		Protocol: "tcp",
+		Domains:  []string{"example.com", "*.example.com"},
+		Tags:     []string{"a"},
+		Type:     "AAAA",
+		URL:      "https://example.com/hook",
+		Listen:   &listen{IP: "10.0.0.1", Port: 80},
+		Members:  []listen{{IP: "10.0.0.0/24"}},
+		Address:  "::1",
+	}
+}
+
+func fields(err error) []string {
+	errs, ok := err.(validation.Errors)
+	Expect(ok).To(BeTrue())
+	f := []string{}
+	for _, e := range errs {
+		f = append(f, e.Field)
+	}
+	return f
+}
+
+var _ = Describe("Validation", func() {
+	Describe("Validate", func() {
+		It("Should accept valid requests", func() {
+			Expect(validation.Validate(valid())).To(BeNil())
+		})
+
+		It("Should return every invalid field", func() {
+			r := valid()
+			r.ID = ""
+			r.Email = "kroo"
+			r.Protocol = "icmp"
+			r.Domains = []string{"example.com", "-example"}
+			r.Tags = []string{"a", "b", "c"}
+			r.Type = "MX"
+			r.URL = "ftp://example.com"
+			r.Listen.Port = 0
+			r.Listen.IP = "300.0.0.1"
+			r.Members = []listen{{IP: "nope"}}
+			r.Address = ""
+
+			err := validation.Validate(r)
+			Expect(fields(err)).To(Equal([]string{
+				"ID", "Email", "Protocol", "Domains[1]", "Tags", "Type", "URL", "Listen.IP", "Members[0].IP", "Address",
+			}))
+		})
+
+		It("Should reject missing structs", func() {
+			r := valid()
+			r.Listen = nil
+			Expect(fields(validation.Validate(r))).To(Equal([]string{"Listen"}))
+		})
+
+		It("Should check embedded structs under the path of their parent", func() {
+			err := validation.Validate(withPublic{Public: Public{Name: "-bad"}, Count: 4})
+			Expect(fields(err)).To(Equal([]string{"Name", "Count"}))
+			Expect(err.Error()).To(Equal("Name is not a valid name, Count can't be more than 3"))
+
+			Expect(validation.Validate(withPublic{Public: Public{Name: "web-1.prod"}, Count: 2})).To(BeNil())
+		})
+
+		It("Should reject names which are too long", func() {
+			name := make([]byte, validation.MaxNameLength+1)
+			for i := range name {
+				name[i] = 'a'
+			}
+			Expect(validation.Validate(Public{Name: string(name)})).ToNot(BeNil())
+		})
+
+		It("Should report unknown rules", func() {
+			err := validation.Validate(struct {
+				A string `validate:"unknown"`
+			}{})
+			Expect(fields(err)).To(Equal([]string{"A"}))
+		})
+
+		It("Should use registered rules", func() {
+			validation.Register("even", func(v reflect.Value, _ string) string {
+				if v.Int()%2 != 0 {
+					return "has to be even"
+				}
+				return ""
+			})
+
+			type even struct {
+				N int `validate:"even"`
+			}
+			Expect(validation.Validate(even{N: 2})).To(BeNil())
+			Expect(validation.Validate(even{N: 3})).To(Equal(validation.Errors{{Field: "N", Message: "has to be even"}}))
+		})
+	})
+
+	Describe("Middleware", func() {
+		It("Should not call the endpoint with invalid requests", func() {
+			called := false
+			e := validation.Middleware()(func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return "ok", nil
+			})
+
+			_, err := e(context.Background(), Public{})
+			Expect(fields(err)).To(Equal([]string{"Name"}))
+			Expect(called).To(BeFalse())
+
+			res, err := e(context.Background(), Public{Name: "web"})
+			Expect(err).To(BeNil())
+			Expect(res).To(Equal("ok"))
+		})
+	})
+
+	Describe("UnaryServerInterceptor", func() {
+		It("Should turn Errors into invalid argument errors", func() {
+			i := validation.UnaryServerInterceptor()
+			info := &grpc.UnaryServerInfo{FullMethod: "/user.UserService/CreateUser"}
+
+			_, err := i(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, validation.Errors{{Field: "Username", Message: "is required"}}
+			})
+			Expect(grpc.Code(err)).To(Equal(codes.InvalidArgument))
+			Expect(grpc.ErrorDesc(err)).To(Equal("Username is required"))
+
+			other := errors.New("other")
+			_, err = i(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, other
+			})
+			Expect(err).To(Equal(other))
+		})
+	})
+})
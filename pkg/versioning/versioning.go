@@ -0,0 +1,111 @@
+// Package versioning announces deprecated parts of the API to their clients, so they can migrate
+// before the parts are removed. Deprecated gRPC methods answer with the deprecation, sunset and link
+// headers, the gateway passes them on as the Deprecation, Sunset and Link HTTP headers and deprecated
+// websocket protocols send them with the handshake.
+package versioning
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// DeprecationHeader is set to true for deprecated calls
+	DeprecationHeader = "deprecation"
+
+	// SunsetHeader is the HTTP date after which a deprecated call is removed
+	SunsetHeader = "sunset"
+
+	// LinkHeader links the successor of a deprecated call
+	LinkHeader = "link"
+)
+
+// Deprecation describes a deprecated method, route or protocol
+type Deprecation struct {
+	// Sunset is when it is removed, it is not announced if it is zero
+	Sunset time.Time
+
+	// Successor replaces it, e.g. a method, a path or a protocol, there is none if it is empty
+	Successor string
+}
+
+// Pairs returns the header keys and values announcing d, in the form metadata.Pairs takes them
+func (d Deprecation) Pairs() []string {
+	pairs := []string{DeprecationHeader, "true"}
+	if !d.Sunset.IsZero() {
+		pairs = append(pairs, SunsetHeader, d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Successor != "" {
+		pairs = append(pairs, LinkHeader, fmt.Sprintf("<%s>; rel=\"successor-version\"", d.Successor))
+	}
+	return pairs
+}
+
+// Header returns the HTTP headers announcing d
+func (d Deprecation) Header() http.Header {
+	h := http.Header{}
+	pairs := d.Pairs()
+	for i := 0; i < len(pairs); i += 2 {
+		h.Set(pairs[i], pairs[i+1])
+	}
+	return h
+}
+
+// Methods maps gRPC methods, e.g. user.UserService/CheckLoginCredentials, to their deprecation
+type Methods map[string]Deprecation
+
+// UnaryServerInterceptor announces the deprecation of the given methods in the response headers of
+// their calls, the calls are handled as usual
+func UnaryServerInterceptor(methods Methods, logger log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx oldcontext.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		d, ok := methods[strings.TrimPrefix(info.FullMethod, "/")]
+		if ok {
+			level.Debug(logger).Log("msg", "deprecated method called", "method", info.FullMethod)
+			grpc.SetHeader(ctx, metadata.Pairs(d.Pairs()...))
+		}
+		return handler(ctx, req)
+	}
+}
+
+// UnaryClientInterceptor logs a warning the first time a deprecated method is called, so the users
+// of a client learn about it before the method is removed
+func UnaryClientInterceptor(logger log.Logger) grpc.UnaryClientInterceptor {
+	var (
+		mtx    sync.Mutex
+		warned = make(map[string]bool)
+	)
+
+	return func(ctx oldcontext.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		header := metadata.MD{}
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		if len(header[DeprecationHeader]) == 0 {
+			return err
+		}
+
+		mtx.Lock()
+		first := !warned[method]
+		warned[method] = true
+		mtx.Unlock()
+
+		if first {
+			keyvals := []interface{}{"msg", "the method is deprecated", "method", method}
+			if v := header[SunsetHeader]; len(v) > 0 {
+				keyvals = append(keyvals, "sunset", v[0])
+			}
+			if v := header[LinkHeader]; len(v) > 0 {
+				keyvals = append(keyvals, "successor", v[0])
+			}
+			level.Warn(logger).Log(keyvals...)
+		}
+		return err
+	}
+}
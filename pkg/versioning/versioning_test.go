@@ -0,0 +1,89 @@
+package versioning_test
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
+	oldcontext "golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// invoker answers every call with header
+func invoker(header metadata.MD) grpc.UnaryInvoker {
+	return func(ctx oldcontext.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		for _, o := range opts {
+			if h, ok := o.(grpc.HeaderCallOption); ok {
+				*h.HeaderAddr = header
+			}
+		}
+		return nil
+	}
+}
+
+var _ = Describe("Versioning", func() {
+	Describe("Deprecation", func() {
+		It("Should announce the deprecation", func() {
+			h := versioning.Deprecation{}.Header()
+			Expect(h.Get("Deprecation")).To(Equal("true"))
+			Expect(h.Get("Sunset")).To(BeEmpty())
+			Expect(h.Get("Link")).To(BeEmpty())
+		})
+
+		It("Should announce the sunset and the successor", func() {
+			h := versioning.Deprecation{
+				Sunset:    time.Date(2030, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600)),
+				Successor: "/v2/auth",
+			}.Header()
+			Expect(h.Get("Sunset")).To(Equal("Tue, 01 Jan 2030 11:00:00 GMT"))
+			Expect(h.Get("Link")).To(Equal(`</v2/auth>; rel="successor-version"`))
+		})
+	})
+
+	Describe("UnaryServerInterceptor", func() {
+		It("Should handle the calls of every method", func() {
+			i := versioning.UnaryServerInterceptor(versioning.Methods{
+				"user.UserService/CheckLoginCredentials": {},
+			}, log.NewNopLogger())
+
+			handled := 0
+			handler := func(ctx oldcontext.Context, req interface{}) (interface{}, error) {
+				handled++
+				return req, nil
+			}
+
+			for _, m := range []string{"/user.UserService/CheckLoginCredentials", "/user.UserService/GetUser"} {
+				res, err := i(oldcontext.Background(), "req", &grpc.UnaryServerInfo{FullMethod: m}, handler)
+				Ω(err).ShouldNot(HaveOccurred())
+				Expect(res).To(Equal("req"))
+			}
+			Expect(handled).To(Equal(2))
+		})
+	})
+
+	Describe("UnaryClientInterceptor", func() {
+		It("Should warn once per deprecated method", func() {
+			warnings := 0
+			logger := log.LoggerFunc(func(keyvals ...interface{}) error {
+				warnings++
+				return nil
+			})
+			i := versioning.UnaryClientInterceptor(logger)
+
+			deprecated := invoker(metadata.Pairs(versioning.Deprecation{Successor: "kentheguru.KenTheGuruService/Authenticate"}.Pairs()...))
+			for n := 0; n < 2; n++ {
+				err := i(oldcontext.Background(), "/user.UserService/CheckLoginCredentials", nil, nil, nil, deprecated)
+				Ω(err).ShouldNot(HaveOccurred())
+			}
+			Expect(warnings).To(Equal(1))
+
+			err := i(oldcontext.Background(), "/user.UserService/GetUser", nil, nil, nil, invoker(metadata.MD{}))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(warnings).To(Equal(1))
+		})
+	})
+})
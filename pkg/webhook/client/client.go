@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/webhook"
+	"github.com/kontainerooo/kontainer.ooo/pkg/webhook/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *webhook.Endpoints {
+
+	var CreateWebhookEndpoint endpoint.Endpoint
+	{
+		CreateWebhookEndpoint = grpctransport.NewClient(
+			conn,
+			"webhook.WebhookService",
+			"CreateWebhook",
+			EncodeGRPCCreateWebhookRequest,
+			DecodeGRPCCreateWebhookResponse,
+			pb.CreateWebhookResponse{},
+		).Endpoint()
+	}
+
+	var RemoveWebhookEndpoint endpoint.Endpoint
+	{
+		RemoveWebhookEndpoint = grpctransport.NewClient(
+			conn,
+			"webhook.WebhookService",
+			"RemoveWebhook",
+			EncodeGRPCRemoveWebhookRequest,
+			DecodeGRPCRemoveWebhookResponse,
+			pb.RemoveWebhookResponse{},
+		).Endpoint()
+	}
+
+	var WebhooksEndpoint endpoint.Endpoint
+	{
+		WebhooksEndpoint = grpctransport.NewClient(
+			conn,
+			"webhook.WebhookService",
+			"Webhooks",
+			EncodeGRPCWebhooksRequest,
+			DecodeGRPCWebhooksResponse,
+			pb.WebhooksResponse{},
+		).Endpoint()
+	}
+
+	var DeliveriesEndpoint endpoint.Endpoint
+	{
+		DeliveriesEndpoint = grpctransport.NewClient(
+			conn,
+			"webhook.WebhookService",
+			"Deliveries",
+			EncodeGRPCDeliveriesRequest,
+			DecodeGRPCDeliveriesResponse,
+			pb.DeliveriesResponse{},
+		).Endpoint()
+	}
+
+	return &webhook.Endpoints{
+		CreateWebhookEndpoint: CreateWebhookEndpoint,
+		RemoveWebhookEndpoint: RemoveWebhookEndpoint,
+		WebhooksEndpoint:      WebhooksEndpoint,
+		DeliveriesEndpoint:    DeliveriesEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreateWebhookRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/webhook.proto-domain createwebhook request to a gRPC CreateWebhook request.
+func EncodeGRPCCreateWebhookRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*webhook.CreateWebhookRequest)
+	return &pb.CreateWebhookRequest{
+		RefID:  uint32(req.RefID),
+		Url:    req.Webhook.URL,
+		Events: req.Webhook.Events,
+		Secret: req.Webhook.Secret,
+	}, nil
+}
+
+// DecodeGRPCCreateWebhookResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreateWebhook response to a messages/webhook.proto-domain createwebhook response.
+func DecodeGRPCCreateWebhookResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreateWebhookResponse)
+	return &webhook.CreateWebhookResponse{
+		ID:     uint(response.ID),
+		Secret: response.Secret,
+		Error:  getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemoveWebhookRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/webhook.proto-domain removewebhook request to a gRPC RemoveWebhook request.
+func EncodeGRPCRemoveWebhookRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*webhook.RemoveWebhookRequest)
+	return &pb.RemoveWebhookRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemoveWebhookResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemoveWebhook response to a messages/webhook.proto-domain removewebhook response.
+func DecodeGRPCRemoveWebhookResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemoveWebhookResponse)
+	return &webhook.RemoveWebhookResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCWebhooksRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/webhook.proto-domain webhooks request to a gRPC Webhooks request.
+func EncodeGRPCWebhooksRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*webhook.WebhooksRequest)
+	return &pb.WebhooksRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCWebhooksResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Webhooks response to a messages/webhook.proto-domain webhooks response.
+func DecodeGRPCWebhooksResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.WebhooksResponse)
+	webhooks := make([]webhook.Webhook, len(response.Webhooks))
+	for i, w := range response.Webhooks {
+		webhooks[i] = webhook.ConvertPBWebhook(w)
+	}
+
+	return &webhook.WebhooksResponse{
+		Webhooks: webhooks,
+		Error:    getError(response.Error),
+		Page:     paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCDeliveriesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/webhook.proto-domain deliveries request to a gRPC Deliveries request.
+func EncodeGRPCDeliveriesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*webhook.DeliveriesRequest)
+	return &pb.DeliveriesRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCDeliveriesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Deliveries response to a messages/webhook.proto-domain deliveries response.
+func DecodeGRPCDeliveriesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.DeliveriesResponse)
+	deliveries := make([]webhook.Delivery, len(response.Deliveries))
+	for i, d := range response.Deliveries {
+		deliveries[i] = webhook.ConvertPBDelivery(d)
+	}
+
+	return &webhook.DeliveriesResponse{
+		Deliveries: deliveries,
+		Error:      getError(response.Error),
+		Page:       paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
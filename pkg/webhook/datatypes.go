@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Webhook is an URL the events of a user, or of the whole platform if RefID is 0, are posted to
+type Webhook struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	URL   string `validate:"required,url"`
+	// Secret is the key the bodies of the deliveries are signed with, it is only returned once the webhook was created
+	Secret string
+	// Events are the topics the webhook is called for, they may contain the wildcards of event subscriptions
+	Events    pq.StringArray `sql:"type:text[]" validate:"required"`
+	CreatedAt time.Time
+}
+
+// TableName sets Webhook's database table name
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Delivery is an attempt to post an event to a webhook
+type Delivery struct {
+	ID        uint `gorm:"primary_key"`
+	WebhookID uint
+	RefID     uint
+	EventID   string
+	Topic     string
+	// Attempt is the number of the attempt to deliver the event, starting at 1
+	Attempt uint
+	// StatusCode is the status of the response, it is 0 if no response was received
+	StatusCode int
+	Error      string
+	// Duration is the time until the response was received
+	Duration  time.Duration
+	CreatedAt time.Time
+}
+
+// TableName sets Delivery's database table name
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
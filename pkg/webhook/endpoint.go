@@ -0,0 +1,141 @@
+package webhook
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the webhook service
+type Endpoints struct {
+	CreateWebhookEndpoint endpoint.Endpoint
+	RemoveWebhookEndpoint endpoint.Endpoint
+	WebhooksEndpoint      endpoint.Endpoint
+	DeliveriesEndpoint    endpoint.Endpoint
+}
+
+// CreateWebhookRequest is the request struct for the CreateWebhookEndpoint
+type CreateWebhookRequest struct {
+	RefID   uint     `bart:"ref"`
+	Webhook *Webhook `validate:"required"`
+}
+
+// CreateWebhookResponse is the response struct for the CreateWebhookEndpoint
+type CreateWebhookResponse struct {
+	ID     uint
+	Secret string
+	Error  error
+}
+
+// MakeCreateWebhookEndpoint creates a gokit endpoint which invokes CreateWebhook
+func MakeCreateWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreateWebhookRequest)
+		err := s.CreateWebhook(req.RefID, req.Webhook)
+		if err != nil {
+			return CreateWebhookResponse{
+				Error: err,
+			}, nil
+		}
+		return CreateWebhookResponse{
+			ID:     req.Webhook.ID,
+			Secret: req.Webhook.Secret,
+		}, nil
+	}
+}
+
+// RemoveWebhookRequest is the request struct for the RemoveWebhookEndpoint
+type RemoveWebhookRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemoveWebhookResponse is the response struct for the RemoveWebhookEndpoint
+type RemoveWebhookResponse struct {
+	Error error
+}
+
+// MakeRemoveWebhookEndpoint creates a gokit endpoint which invokes RemoveWebhook
+func MakeRemoveWebhookEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemoveWebhookRequest)
+		err := s.RemoveWebhook(req.RefID, req.ID)
+		return RemoveWebhookResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// WebhooksRequest is the request struct for the WebhooksEndpoint
+type WebhooksRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// WebhooksResponse is the response struct for the WebhooksEndpoint
+type WebhooksResponse struct {
+	Webhooks []Webhook
+	Error    error
+	Page     paging.Response
+}
+
+// MakeWebhooksEndpoint creates a gokit endpoint which invokes Webhooks
+func MakeWebhooksEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(WebhooksRequest)
+		webhooks := []Webhook{}
+		err := s.Webhooks(req.RefID, &webhooks)
+		if err != nil {
+			return WebhooksResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&webhooks, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return WebhooksResponse{
+			Webhooks: webhooks,
+			Page:     page,
+		}, nil
+	}
+}
+
+// DeliveriesRequest is the request struct for the DeliveriesEndpoint
+type DeliveriesRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+	Page  paging.Request
+}
+
+// DeliveriesResponse is the response struct for the DeliveriesEndpoint
+type DeliveriesResponse struct {
+	Deliveries []Delivery
+	Error      error
+	Page       paging.Response
+}
+
+// MakeDeliveriesEndpoint creates a gokit endpoint which invokes Deliveries
+func MakeDeliveriesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(DeliveriesRequest)
+		deliveries := []Delivery{}
+		err := s.Deliveries(req.RefID, req.ID, &deliveries)
+		if err != nil {
+			return DeliveriesResponse{
+				Error: err,
+			}, nil
+		}
+
+		page, err := paging.Apply(&deliveries, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return DeliveriesResponse{
+			Deliveries: deliveries,
+			Page:       page,
+		}, nil
+	}
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+)
+
+// EventGroup is the group the webhook services of all nodes subscribe to events with,
+// so every event is only dispatched once
+const EventGroup = "webhook"
+
+// DeliverJob is the type of the job posting an event to a webhook
+const DeliverJob = "webhook.deliver"
+
+// DeliverOptions run a few deliveries at a time and retry a failing webhook for about a day
+var DeliverOptions = jobs.Options{
+	Workers:     4,
+	MaxAttempts: 10,
+	Backoff:     30 * time.Second,
+	MaxBackoff:  6 * time.Hour,
+	Jitter:      0.2,
+}
+
+type deliverPayload struct {
+	WebhookID uint         `json:"webhookID"`
+	Event     events.Event `json:"event"`
+}
+
+// DeliverHandler returns the handler of DeliverJob
+func DeliverHandler(s Service) jobs.Handler {
+	return func(ctx context.Context, j jobs.Job) error {
+		p := deliverPayload{}
+		err := j.Decode(&p)
+		if err != nil {
+			return err
+		}
+
+		return s.Deliver(ctx, p.WebhookID, p.Event, j.Attempts+1)
+	}
+}
+
+// Subscribe dispatches every event published on the bus to the webhooks called for it.
+// An event whose dispatch failed is delivered again, so webhooks may receive it twice.
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	sub, err := bus.Subscribe(">", EventGroup, func(e events.Event) error {
+		err := s.Dispatch(e)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "topic", e.Topic, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []events.Subscription{sub}, nil
+}
@@ -0,0 +1,45 @@
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+// RotateSecrets migrates the secret of every webhook to the current master key or backend of secrets, including
+// those kept in plaintext before. It returns the number of secrets which changed.
+func RotateSecrets(db abstraction.DB, secrets secret.Backend) (int, error) {
+	ws := []Webhook{}
+	err := db.Find(&ws)
+	if err != nil {
+		return 0, err
+	}
+
+	rotated := 0
+	for _, w := range ws {
+		encrypted, changed, err := secrets.Migrate(w.RefID, "webhooks", w.Secret)
+		if err != nil {
+			return rotated, fmt.Errorf("webhook %d: %v", w.ID, err)
+		}
+		if !changed {
+			continue
+		}
+
+		db.Begin()
+		err = db.Where("id = ?", w.ID)
+		if err != nil {
+			db.Rollback()
+			return rotated, err
+		}
+
+		err = db.Update(&Webhook{}, &Webhook{Secret: encrypted})
+		if err != nil {
+			db.Rollback()
+			return rotated, err
+		}
+		db.Commit()
+		rotated++
+	}
+	return rotated, nil
+}
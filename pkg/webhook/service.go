@@ -0,0 +1,474 @@
+// Package webhook posts the events of the platform to the URLs users and admins registered for them
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+)
+
+var (
+	// ErrInvalidURL occurs if a webhook's URL is not an absolute http or https URL
+	ErrInvalidURL = errors.New("url has to be an absolute http or https url")
+
+	// ErrNoEvents occurs if a webhook is created without events
+	ErrNoEvents = errors.New("webhook has no events")
+
+	// ErrInvalidEvent occurs if an event of a webhook is not a valid topic
+	ErrInvalidEvent = errors.New("event is not a valid topic")
+
+	// ErrWebhookNotExist occurs if a webhook does not exist
+	ErrWebhookNotExist = errors.New("webhook does not exist")
+)
+
+// Headers of a delivery
+const (
+	// SignatureHeader contains "sha256=" followed by the hex encoded HMAC-SHA256 of the body keyed with the webhook's secret
+	SignatureHeader = "X-Kroo-Signature"
+	// EventHeader contains the topic of the event
+	EventHeader = "X-Kroo-Event"
+	// DeliveryHeader contains the id of the event, it is the same for every attempt to deliver it
+	DeliveryHeader = "X-Kroo-Delivery"
+)
+
+// MaxDeliveries is the number of deliveries kept per webhook, older ones are removed
+const MaxDeliveries = 100
+
+// UserEvents are the topics webhooks of users are called for, if the event concerns the user.
+// Webhooks of the platform are called for every event.
+var UserEvents = []string{events.ContainerEvents, events.CertificateEvents, events.SnapshotEvents, events.AccountEvents, events.CronEvents, events.AlertEvents, events.ScaleEvents}
+
+// Service WebhookService
+type Service interface {
+	// CreateWebhook registers a webhook of a user, or of the platform if refID is 0.
+	// A secret is generated if the webhook has none.
+	CreateWebhook(refID uint, w *Webhook) error
+
+	// RemoveWebhook removes a webhook and its deliveries
+	RemoveWebhook(refID uint, id uint) error
+
+	// Webhooks returns the webhooks of a user without their secrets
+	Webhooks(refID uint, w *[]Webhook) error
+
+	// Deliveries returns the recent deliveries of a webhook, the newest first
+	Deliveries(refID uint, id uint, d *[]Delivery) error
+
+	// Dispatch enqueues a delivery of an event to every webhook which is called for it
+	Dispatch(e events.Event) error
+
+	// Deliver posts an event to a webhook and records the attempt, it returns an error if the
+	// webhook did not respond with a 2xx status
+	Deliver(ctx context.Context, id uint, e events.Event, attempt uint) error
+}
+
+// Queue runs the deliveries in the background, it is the job queue of the daemon
+type Queue interface {
+	Enqueue(typ string, payload interface{}) (uint, error)
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	FindOrdered(interface{}, string, int, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db      dbAdapter
+	queue   Queue
+	client  *http.Client
+	secrets secret.Backend
+	mtx     *sync.Mutex
+}
+
+// Sign returns the value of the SignatureHeader of a body
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Webhook{}, &Delivery{})
+}
+
+// validTopic reports whether a topic pattern can be subscribed to
+func validTopic(t string) bool {
+	tokens := strings.Split(t, ".")
+	for i, token := range tokens {
+		if token == "" || strings.ContainsAny(token, " \t") {
+			return false
+		}
+		if strings.Contains(token, ">") && (token != ">" || i != len(tokens)-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *service) CreateWebhook(refID uint, w *Webhook) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.createWebhook(refID, w)
+}
+
+func (s *service) createWebhook(refID uint, w *Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+
+	if len(w.Events) == 0 {
+		return ErrNoEvents
+	}
+	for _, e := range w.Events {
+		if !validTopic(e) {
+			return ErrInvalidEvent
+		}
+	}
+
+	if w.Secret == "" {
+		w.Secret, err = newSecret()
+		if err != nil {
+			return err
+		}
+	}
+
+	encrypted, err := s.secrets.Put(refID, "webhooks", w.Secret)
+	if err != nil {
+		return err
+	}
+
+	hook := &Webhook{
+		RefID:     refID,
+		URL:       w.URL,
+		Secret:    encrypted,
+		Events:    w.Events,
+		CreatedAt: time.Now().UTC(),
+	}
+	err = s.db.Create(hook)
+	if err != nil {
+		return err
+	}
+
+	plaintext := w.Secret
+	*w = *hook
+	w.Secret = plaintext
+	return nil
+}
+
+func (s *service) getWebhook(refID uint, id uint) (Webhook, error) {
+	w := Webhook{}
+	err := s.db.First(&w, "id = ? AND ref_id = ?", id, refID)
+	if s.db.IsNotFound(err) {
+		return Webhook{}, ErrWebhookNotExist
+	}
+	if err != nil {
+		return Webhook{}, err
+	}
+	return w, nil
+}
+
+// deliveries returns the deliveries of a webhook, the latest first
+func (s *service) deliveries(id uint) ([]Delivery, error) {
+	ds := []Delivery{}
+	err := s.db.FindOrdered(&ds, "id DESC", 0, "webhook_id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+func (s *service) RemoveWebhook(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.removeWebhook(refID, id)
+}
+
+func (s *service) removeWebhook(refID uint, id uint) error {
+	w, err := s.getWebhook(refID, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.Delete(&Webhook{ID: id})
+	if err != nil {
+		return err
+	}
+
+	err = s.secrets.Delete(refID, w.Secret)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Delete(&Delivery{}, "webhook_id = ?", id)
+}
+
+func (s *service) Webhooks(refID uint, w *[]Webhook) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ws := []Webhook{}
+	err := s.db.FindOrdered(&ws, "id", 0, "ref_id = ?", refID)
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range ws {
+		hook.Secret = ""
+		*w = append(*w, hook)
+	}
+	return nil
+}
+
+func (s *service) Deliveries(refID uint, id uint, d *[]Delivery) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	_, err := s.getWebhook(refID, id)
+	if err != nil {
+		return err
+	}
+
+	ds, err := s.deliveries(id)
+	if err != nil {
+		return err
+	}
+
+	*d = ds
+	return nil
+}
+
+// owner returns the user an event concerns, ok is false if it concerns no single user
+func owner(e events.Event) (uint, bool) {
+	p := struct {
+		RefID uint `json:"refID"`
+	}{}
+	err := e.Decode(&p)
+	if err != nil || p.RefID == 0 {
+		return 0, false
+	}
+	return p.RefID, true
+}
+
+// calledFor reports whether a webhook is called for an event
+func calledFor(w Webhook, e events.Event) bool {
+	if w.RefID != 0 {
+		refID, ok := owner(e)
+		if !ok || refID != w.RefID {
+			return false
+		}
+
+		user := false
+		for _, t := range UserEvents {
+			if events.Match(t, e.Topic) {
+				user = true
+				break
+			}
+		}
+		if !user {
+			return false
+		}
+	}
+
+	for _, t := range w.Events {
+		if events.Match(t, e.Topic) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) Dispatch(e events.Event) error {
+	// the webhooks of the platform and of the user the event concerns, the events they are called for
+	// may contain wildcards, so they are matched afterwards
+	refIDs := []uint{0}
+	if refID, ok := owner(e); ok {
+		refIDs = append(refIDs, refID)
+	}
+
+	ws := []Webhook{}
+	s.mtx.Lock()
+	err := s.db.FindOrdered(&ws, "id", 0, "ref_id IN (?)", refIDs)
+	s.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, w := range ws {
+		if !calledFor(w, e) {
+			continue
+		}
+
+		_, err = s.queue.Enqueue(DeliverJob, deliverPayload{
+			WebhookID: w.ID,
+			Event:     e,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) Deliver(ctx context.Context, id uint, e events.Event, attempt uint) error {
+	w := &Webhook{}
+	s.mtx.Lock()
+	err := s.db.First(w, "id = ?", id)
+	s.mtx.Unlock()
+	// the webhook was removed after the event was dispatched
+	if s.db.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	d := &Delivery{
+		WebhookID: w.ID,
+		RefID:     w.RefID,
+		EventID:   e.ID,
+		Topic:     e.Topic,
+		Attempt:   attempt,
+	}
+	start := time.Now()
+	d.StatusCode, err = s.post(ctx, w, e, body)
+	d.Duration = time.Since(start)
+	if err == nil && (d.StatusCode < 200 || d.StatusCode > 299) {
+		err = fmt.Errorf("webhook responded with status %d", d.StatusCode)
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+
+	// an interrupted delivery is attempted again after a restart without counting as attempt
+	if ctx.Err() != nil {
+		return err
+	}
+
+	s.mtx.Lock()
+	rerr := s.record(d)
+	s.mtx.Unlock()
+	if rerr != nil && err == nil {
+		return rerr
+	}
+	return err
+}
+
+func (s *service) post(ctx context.Context, w *Webhook, e events.Event, body []byte) (int, error) {
+	key, err := s.secrets.Get(w.RefID, w.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "kontainerooo-webhook")
+	req.Header.Set(SignatureHeader, Sign(key, body))
+	req.Header.Set(EventHeader, e.Topic)
+	req.Header.Set(DeliveryHeader, e.ID)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// the body is drained, so the connection is reused
+	io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+	return res.StatusCode, nil
+}
+
+// record stores a delivery and removes the oldest ones of its webhook beyond MaxDeliveries
+func (s *service) record(d *Delivery) error {
+	d.CreatedAt = time.Now().UTC()
+	err := s.db.Create(d)
+	if err != nil {
+		return err
+	}
+
+	// the oldest delivery which is kept, the ones before it are removed
+	kept := []Delivery{}
+	err = s.db.FindOrdered(&kept, "id DESC", MaxDeliveries, "webhook_id = ?", d.WebhookID)
+	if err != nil || len(kept) < MaxDeliveries {
+		return err
+	}
+	return s.db.Delete(&Delivery{}, "webhook_id = ? AND id < ?", d.WebhookID, kept[len(kept)-1].ID)
+}
+
+// NewService returns a new WebhookService, deliveries are enqueued in q and posted with client.
+// A client with a timeout of ten seconds is used if client is nil. The secrets of the webhooks are
+// kept by secrets, they are kept in plaintext if it is nil.
+func NewService(db dbAdapter, q Queue, client *http.Client, secrets secret.Backend) (Service, error) {
+	if client == nil {
+		client = &http.Client{
+			Timeout: 10 * time.Second,
+		}
+	}
+	if secrets == nil {
+		secrets = secret.Plaintext
+	}
+
+	s := &service{
+		db:      db,
+		queue:   q,
+		client:  client,
+		secrets: secrets,
+		mtx:     &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
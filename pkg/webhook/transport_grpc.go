@@ -0,0 +1,252 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/webhook/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC WebhookServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.WebhookServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createWebhook: grpctransport.NewServer(
+			endpoints.CreateWebhookEndpoint,
+			DecodeGRPCCreateWebhookRequest,
+			EncodeGRPCCreateWebhookResponse,
+			options...,
+		),
+
+		removeWebhook: grpctransport.NewServer(
+			endpoints.RemoveWebhookEndpoint,
+			DecodeGRPCRemoveWebhookRequest,
+			EncodeGRPCRemoveWebhookResponse,
+			options...,
+		),
+
+		webhooks: grpctransport.NewServer(
+			endpoints.WebhooksEndpoint,
+			DecodeGRPCWebhooksRequest,
+			EncodeGRPCWebhooksResponse,
+			options...,
+		),
+
+		deliveries: grpctransport.NewServer(
+			endpoints.DeliveriesEndpoint,
+			DecodeGRPCDeliveriesRequest,
+			EncodeGRPCDeliveriesResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createWebhook grpctransport.Handler
+	removeWebhook grpctransport.Handler
+	webhooks      grpctransport.Handler
+	deliveries    grpctransport.Handler
+}
+
+func (s *grpcServer) CreateWebhook(ctx oldcontext.Context, req *pb.CreateWebhookRequest) (*pb.CreateWebhookResponse, error) {
+	_, res, err := s.createWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreateWebhookResponse), nil
+}
+
+func (s *grpcServer) RemoveWebhook(ctx oldcontext.Context, req *pb.RemoveWebhookRequest) (*pb.RemoveWebhookResponse, error) {
+	_, res, err := s.removeWebhook.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemoveWebhookResponse), nil
+}
+
+func (s *grpcServer) Webhooks(ctx oldcontext.Context, req *pb.WebhooksRequest) (*pb.WebhooksResponse, error) {
+	_, res, err := s.webhooks.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.WebhooksResponse), nil
+}
+
+func (s *grpcServer) Deliveries(ctx oldcontext.Context, req *pb.DeliveriesRequest) (*pb.DeliveriesResponse, error) {
+	_, res, err := s.deliveries.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.DeliveriesResponse), nil
+}
+
+// ConvertWebhook converts a Webhook to its protobuf representation
+func ConvertWebhook(w Webhook) *pb.Webhook {
+	return &pb.Webhook{
+		ID:        uint32(w.ID),
+		Url:       w.URL,
+		Events:    w.Events,
+		CreatedAt: w.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBWebhook converts a protobuf Webhook to a Webhook
+func ConvertPBWebhook(w *pb.Webhook) Webhook {
+	if w == nil {
+		return Webhook{}
+	}
+
+	return Webhook{
+		ID:        uint(w.ID),
+		URL:       w.Url,
+		Events:    w.Events,
+		CreatedAt: time.Unix(w.CreatedAt, 0).UTC(),
+	}
+}
+
+// ConvertDelivery converts a Delivery to its protobuf representation
+func ConvertDelivery(d Delivery) *pb.Delivery {
+	return &pb.Delivery{
+		ID:         uint32(d.ID),
+		EventID:    d.EventID,
+		Topic:      d.Topic,
+		Attempt:    uint32(d.Attempt),
+		StatusCode: int32(d.StatusCode),
+		Error:      d.Error,
+		Duration:   int64(d.Duration / time.Millisecond),
+		Time:       d.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBDelivery converts a protobuf Delivery to a Delivery
+func ConvertPBDelivery(d *pb.Delivery) Delivery {
+	if d == nil {
+		return Delivery{}
+	}
+
+	return Delivery{
+		ID:         uint(d.ID),
+		EventID:    d.EventID,
+		Topic:      d.Topic,
+		Attempt:    uint(d.Attempt),
+		StatusCode: int(d.StatusCode),
+		Error:      d.Error,
+		Duration:   time.Duration(d.Duration) * time.Millisecond,
+		CreatedAt:  time.Unix(d.Time, 0).UTC(),
+	}
+}
+
+// DecodeGRPCCreateWebhookRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreateWebhook request to a messages/webhook.proto-domain createwebhook request.
+func DecodeGRPCCreateWebhookRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreateWebhookRequest)
+	return CreateWebhookRequest{
+		RefID: uint(req.RefID),
+		Webhook: &Webhook{
+			URL:    req.Url,
+			Events: req.Events,
+			Secret: req.Secret,
+		},
+	}, nil
+}
+
+// EncodeGRPCCreateWebhookResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/webhook.proto-domain createwebhook response to a gRPC CreateWebhook response.
+func EncodeGRPCCreateWebhookResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreateWebhookResponse)
+	gRPCRes := &pb.CreateWebhookResponse{
+		ID:     uint32(res.ID),
+		Secret: res.Secret,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemoveWebhookRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemoveWebhook request to a messages/webhook.proto-domain removewebhook request.
+func DecodeGRPCRemoveWebhookRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemoveWebhookRequest)
+	return RemoveWebhookRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemoveWebhookResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/webhook.proto-domain removewebhook response to a gRPC RemoveWebhook response.
+func EncodeGRPCRemoveWebhookResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemoveWebhookResponse)
+	gRPCRes := &pb.RemoveWebhookResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCWebhooksRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Webhooks request to a messages/webhook.proto-domain webhooks request.
+func DecodeGRPCWebhooksRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.WebhooksRequest)
+	return WebhooksRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCWebhooksResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/webhook.proto-domain webhooks response to a gRPC Webhooks response.
+func EncodeGRPCWebhooksResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(WebhooksResponse)
+	webhooks := make([]*pb.Webhook, len(res.Webhooks))
+	for i, w := range res.Webhooks {
+		webhooks[i] = ConvertWebhook(w)
+	}
+
+	gRPCRes := &pb.WebhooksResponse{
+		Webhooks: webhooks,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCDeliveriesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Deliveries request to a messages/webhook.proto-domain deliveries request.
+func DecodeGRPCDeliveriesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.DeliveriesRequest)
+	return DeliveriesRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCDeliveriesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/webhook.proto-domain deliveries response to a gRPC Deliveries response.
+func EncodeGRPCDeliveriesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(DeliveriesResponse)
+	deliveries := make([]*pb.Delivery, len(res.Deliveries))
+	for i, d := range res.Deliveries {
+		deliveries[i] = ConvertDelivery(d)
+	}
+
+	gRPCRes := &pb.DeliveriesResponse{
+		Deliveries: deliveries,
+		PageInfo:   paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,319 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+	"github.com/kontainerooo/kontainer.ooo/pkg/jobs"
+	"github.com/kontainerooo/kontainer.ooo/pkg/secret"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/webhook"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type mockQueue struct {
+	mtx      sync.Mutex
+	webhooks []uint
+}
+
+func (q *mockQueue) Enqueue(typ string, payload interface{}) (uint, error) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	data, _ := json.Marshal(payload)
+	p := struct {
+		WebhookID uint `json:"webhookID"`
+	}{}
+	json.Unmarshal(data, &p)
+	q.webhooks = append(q.webhooks, p.WebhookID)
+	return uint(len(q.webhooks)), nil
+}
+
+type request struct {
+	body      []byte
+	signature string
+	topic     string
+	delivery  string
+}
+
+var _ = Describe("Webhook", func() {
+	var (
+		refID = uint(1)
+		queue *mockQueue
+		s     webhook.Service
+	)
+
+	event := func(topic string, data interface{}) events.Event {
+		b, _ := json.Marshal(data)
+		return events.Event{
+			ID:    "event1",
+			Topic: topic,
+			Time:  time.Now().UTC(),
+			Data:  b,
+		}
+	}
+
+	BeforeEach(func() {
+		queue = &mockQueue{}
+		s, _ = webhook.NewService(testutils.NewMockDB(), queue, nil, nil)
+	})
+
+	Describe("Create Service", func() {
+		It("Should create service", func() {
+			s, err := webhook.NewService(testutils.NewMockDB(), queue, nil, nil)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(s).ToNot(BeZero())
+		})
+
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := webhook.NewService(db, queue, nil, nil)
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("CreateWebhook", func() {
+		It("Should create a webhook with a generated secret", func() {
+			w := &webhook.Webhook{
+				URL:    "https://example.com/hook",
+				Events: []string{events.ContainerCreated},
+			}
+			err := s.CreateWebhook(refID, w)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(w.ID).ToNot(BeZero())
+			Expect(w.Secret).To(HaveLen(64))
+
+			ws := []webhook.Webhook{}
+			Ω(s.Webhooks(refID, &ws)).Should(Succeed())
+			Expect(ws).To(HaveLen(1))
+			Expect(ws[0].URL).To(Equal("https://example.com/hook"))
+			Expect(ws[0].Secret).To(BeEmpty())
+
+			ws = []webhook.Webhook{}
+			Ω(s.Webhooks(2, &ws)).Should(Succeed())
+			Expect(ws).To(BeEmpty())
+		})
+
+		It("Should keep a given secret", func() {
+			w := &webhook.Webhook{
+				URL:    "http://example.com",
+				Events: []string{">"},
+				Secret: "secret",
+			}
+			Ω(s.CreateWebhook(refID, w)).Should(Succeed())
+			Expect(w.Secret).To(Equal("secret"))
+		})
+
+		It("Should validate the url and events", func() {
+			err := s.CreateWebhook(refID, &webhook.Webhook{URL: "ftp://example.com", Events: []string{">"}})
+			Ω(err).Should(Equal(webhook.ErrInvalidURL))
+
+			err = s.CreateWebhook(refID, &webhook.Webhook{URL: "/hook", Events: []string{">"}})
+			Ω(err).Should(Equal(webhook.ErrInvalidURL))
+
+			err = s.CreateWebhook(refID, &webhook.Webhook{URL: "https://example.com"})
+			Ω(err).Should(Equal(webhook.ErrNoEvents))
+
+			err = s.CreateWebhook(refID, &webhook.Webhook{URL: "https://example.com", Events: []string{"container.>.created"}})
+			Ω(err).Should(Equal(webhook.ErrInvalidEvent))
+
+			err = s.CreateWebhook(refID, &webhook.Webhook{URL: "https://example.com", Events: []string{"container..created"}})
+			Ω(err).Should(Equal(webhook.ErrInvalidEvent))
+		})
+	})
+
+	Describe("RemoveWebhook", func() {
+		It("Should only remove webhooks of the user", func() {
+			w := &webhook.Webhook{URL: "https://example.com", Events: []string{">"}}
+			s.CreateWebhook(refID, w)
+
+			err := s.RemoveWebhook(2, w.ID)
+			Ω(err).Should(Equal(webhook.ErrWebhookNotExist))
+
+			err = s.RemoveWebhook(refID, w.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			ws := []webhook.Webhook{}
+			s.Webhooks(refID, &ws)
+			Expect(ws).To(BeEmpty())
+		})
+	})
+
+	Describe("Dispatch", func() {
+		It("Should enqueue deliveries to the webhooks called for an event", func() {
+			user := &webhook.Webhook{URL: "https://example.com/user", Events: []string{">"}}
+			s.CreateWebhook(refID, user)
+			other := &webhook.Webhook{URL: "https://example.com/other", Events: []string{events.ContainerEvents}}
+			s.CreateWebhook(2, other)
+			platform := &webhook.Webhook{URL: "https://example.com/platform", Events: []string{"container.created", "user.*"}}
+			s.CreateWebhook(0, platform)
+
+			created := event(events.ContainerCreated, events.ContainerEvent{RefID: refID, Name: "web"})
+			Ω(s.Dispatch(created)).Should(Succeed())
+			Expect(queue.webhooks).To(ConsistOf(user.ID, platform.ID))
+
+			queue.webhooks = nil
+			Ω(s.Dispatch(event(events.UserCreated, events.UserEvent{ID: refID}))).Should(Succeed())
+			Expect(queue.webhooks).To(ConsistOf(platform.ID))
+
+			queue.webhooks = nil
+			Ω(s.Dispatch(event(events.StopContainer, events.ContainerCommand{RefID: refID}))).Should(Succeed())
+			Expect(queue.webhooks).To(BeEmpty())
+		})
+	})
+
+	Describe("Deliver", func() {
+		var (
+			mtx      sync.Mutex
+			requests []request
+			status   int
+			server   *httptest.Server
+		)
+
+		BeforeEach(func() {
+			requests = nil
+			status = http.StatusOK
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				mtx.Lock()
+				defer mtx.Unlock()
+				requests = append(requests, request{
+					body:      body,
+					signature: r.Header.Get(webhook.SignatureHeader),
+					topic:     r.Header.Get(webhook.EventHeader),
+					delivery:  r.Header.Get(webhook.DeliveryHeader),
+				})
+				w.WriteHeader(status)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		received := func() []request {
+			mtx.Lock()
+			defer mtx.Unlock()
+			return append([]request{}, requests...)
+		}
+
+		It("Should post signed events and log the deliveries", func() {
+			w := &webhook.Webhook{URL: server.URL, Events: []string{">"}, Secret: "secret"}
+			s.CreateWebhook(refID, w)
+			e := event(events.ContainerCreated, events.ContainerEvent{RefID: refID, Name: "web"})
+
+			status = http.StatusInternalServerError
+			err := s.Deliver(context.Background(), w.ID, e, 1)
+			Ω(err).Should(HaveOccurred())
+
+			status = http.StatusNoContent
+			err = s.Deliver(context.Background(), w.ID, e, 2)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			rs := received()
+			Expect(rs).To(HaveLen(2))
+			Expect(rs[1].signature).To(Equal(webhook.Sign("secret", rs[1].body)))
+			Expect(rs[1].topic).To(Equal(events.ContainerCreated))
+			Expect(rs[1].delivery).To(Equal("event1"))
+
+			posted := events.Event{}
+			Ω(json.Unmarshal(rs[1].body, &posted)).Should(Succeed())
+			Expect(posted.Topic).To(Equal(events.ContainerCreated))
+
+			ds := []webhook.Delivery{}
+			Ω(s.Deliveries(refID, w.ID, &ds)).Should(Succeed())
+			Expect(ds).To(HaveLen(2))
+			Expect(ds[0].Attempt).To(BeEquivalentTo(2))
+			Expect(ds[0].StatusCode).To(Equal(http.StatusNoContent))
+			Expect(ds[0].Error).To(BeEmpty())
+			Expect(ds[1].StatusCode).To(Equal(http.StatusInternalServerError))
+			Expect(ds[1].Error).ToNot(BeEmpty())
+
+			Ω(s.Deliveries(2, w.ID, &ds)).Should(Equal(webhook.ErrWebhookNotExist))
+		})
+
+		It("Should sign with the decrypted secret and rotate it", func() {
+			keys, err := secret.NewKeyring(map[string]string{"a": base64.StdEncoding.EncodeToString(make([]byte, secret.DataKeySize))}, "a")
+			Ω(err).ShouldNot(HaveOccurred())
+			db := testutils.NewMockDB()
+			s, _ = webhook.NewService(db, queue, nil, secret.NewStore(keys))
+
+			w := &webhook.Webhook{URL: server.URL, Events: []string{">"}, Secret: "secret"}
+			Ω(s.CreateWebhook(refID, w)).Should(Succeed())
+			Expect(w.Secret).To(Equal("secret"))
+
+			stored := []webhook.Webhook{}
+			Ω(db.Find(&stored)).Should(Succeed())
+			Expect(secret.IsEncrypted(stored[0].Secret)).To(BeTrue())
+
+			Ω(s.Deliver(context.Background(), w.ID, event(events.ContainerCreated, events.ContainerEvent{RefID: refID}), 1)).Should(Succeed())
+			rs := received()
+			Expect(rs).To(HaveLen(1))
+			Expect(rs[0].signature).To(Equal(webhook.Sign("secret", rs[0].body)))
+
+			keys, err = secret.NewKeyring(map[string]string{
+				"a": base64.StdEncoding.EncodeToString(make([]byte, secret.DataKeySize)),
+				"b": base64.StdEncoding.EncodeToString([]byte(strings.Repeat("b", secret.DataKeySize))),
+			}, "b")
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(webhook.RotateSecrets(db, secret.NewStore(keys))).To(Equal(1))
+
+			stored = []webhook.Webhook{}
+			Ω(db.Find(&stored)).Should(Succeed())
+			Expect(secret.KeyID(stored[0].Secret)).To(Equal("b"))
+			Expect(secret.NewStore(keys).Decrypt(stored[0].Secret)).To(Equal("secret"))
+		})
+
+		It("Should drop deliveries to removed webhooks", func() {
+			w := &webhook.Webhook{URL: server.URL, Events: []string{">"}}
+			s.CreateWebhook(refID, w)
+			s.RemoveWebhook(refID, w.ID)
+
+			err := s.Deliver(context.Background(), w.ID, event(events.ContainerCreated, events.ContainerEvent{RefID: refID}), 1)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(received()).To(BeEmpty())
+		})
+
+		It("Should deliver published events through the job queue", func() {
+			q, err := jobs.NewQueue(testutils.NewMockDB(), log.NewNopLogger())
+			Ω(err).ShouldNot(HaveOccurred())
+			s, _ = webhook.NewService(testutils.NewMockDB(), q, nil, nil)
+			q.Register(webhook.DeliverJob, webhook.DeliverOptions, webhook.DeliverHandler(s))
+
+			bus := events.NewMemoryBus("node1", time.Second, log.NewNopLogger())
+			defer bus.Close()
+			_, err = webhook.Subscribe(s, bus, log.NewNopLogger())
+			Ω(err).ShouldNot(HaveOccurred())
+
+			stop := make(chan struct{})
+			done := make(chan struct{})
+			go func() {
+				q.Run(time.Second, stop)
+				close(done)
+			}()
+			defer func() {
+				close(stop)
+				<-done
+			}()
+
+			w := &webhook.Webhook{URL: server.URL, Events: []string{events.CertificateEvents}}
+			s.CreateWebhook(refID, w)
+
+			bus.Publish(events.CertificateRenewed, events.CertificateEvent{RefID: refID, Name: "web"})
+			Eventually(received).Should(HaveLen(1))
+			Expect(received()[0].topic).To(Equal(events.CertificateRenewed))
+		})
+	})
+})
@@ -0,0 +1,16 @@
+// +build gofuzz
+
+package websocket
+
+// Fuzz is the entry point of go-fuzz for the frames decoded by the BasicHandler, run it with
+// go-fuzz-build github.com/kontainerooo/kontainer.ooo/pkg/websocket && go-fuzz
+func Fuzz(data []byte) int {
+	srv, me, req, err := BasicHandler{}.Decode(data)
+	if err != nil {
+		return 0
+	}
+	if srv == nil || me == nil || len(req.([]byte)) != len(data)-6 {
+		panic("frame decoded partially")
+	}
+	return 1
+}
@@ -63,7 +63,12 @@ func (h BasicHandler) Decode(message []byte) (*ProtoID, *ProtoID, interface{}, e
 func (h BasicHandler) Encode(service *ProtoID, method *ProtoID, data interface{}) ([]byte, error) {
 	var message []byte
 
-	pb, err := proto.Marshal(data.(proto.Message))
+	m, ok := data.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is no proto.Message", data)
+	}
+
+	pb, err := proto.Marshal(m)
 	if err != nil {
 		return nil, err
 	}
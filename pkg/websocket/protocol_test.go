@@ -0,0 +1,50 @@
+package websocket_test
+
+import (
+	"math/rand"
+
+	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Protocol", func() {
+	Describe("BasicHandler", func() {
+		h := ws.BasicHandler{}
+
+		It("Should split a message into its service, method and request", func() {
+			srv, me, data, err := h.Decode([]byte("USRCRTdata"))
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(srv.String()).To(Equal("USR"))
+			Expect(me.String()).To(Equal("CRT"))
+			Expect(data).To(Equal([]byte("data")))
+		})
+
+		It("Should reject messages without a service and method", func() {
+			_, _, _, err := h.Decode([]byte("USRCR"))
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should refuse to encode values which are no protobuf messages", func() {
+			srv, me := ws.ProtoIDFromString("USR"), ws.ProtoIDFromString("CRT")
+			_, err := h.Encode(&srv, &me, "data")
+			Ω(err).Should(HaveOccurred())
+		})
+
+		It("Should survive random frames", func() {
+			r := rand.New(rand.NewSource(742))
+			for i := 0; i < 10000; i++ {
+				frame := make([]byte, r.Intn(32))
+				r.Read(frame)
+
+				Expect(func() {
+					_, _, data, err := h.Decode(frame)
+					if err == nil {
+						Expect(data).To(HaveLen(len(frame) - 6))
+					}
+				}).NotTo(Panic())
+			}
+		})
+	})
+})
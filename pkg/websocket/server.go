@@ -1,13 +1,20 @@
 package websocket
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"runtime/debug"
 	"sync"
+	"time"
 
 	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
 	"github.com/gorilla/websocket"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
 )
 
 // The ErrorHandler is used to encode errors which occur during server side processing of requests
@@ -79,6 +86,18 @@ type Server struct {
 	// There is no need to define Subprotocols, since this will be filled with the help of the ProtocolMap
 	Upgrader websocket.Upgrader
 
+	// Deprecated maps the names of deprecated protocols to their deprecation, it is announced in
+	// the headers of the handshake of the connections using them
+	Deprecated map[string]versioning.Deprecation
+
+	// Timeout is the deadline of every request, the requests of a connection are cancelled once it
+	// is closed as well. There is no deadline if it is zero, streams are not bound by it.
+	Timeout time.Duration
+
+	// Limits are the sizes of the requests of the endpoints, which are named like SRV/MET. Messages larger
+	// than the largest size close the connection.
+	Limits payload.Limits
+
 	auth     Authenticator
 	errh     ErrorHandler
 	ssl      SSLConfig
@@ -86,6 +105,14 @@ type Server struct {
 	before   []*Middleware
 	after    []*Middleware
 	mtx      *sync.Mutex
+
+	// connMtx guards the fields used to shut the server down
+	connMtx  sync.Mutex
+	servers  []*http.Server
+	conns    map[*websocket.Conn]struct{}
+	requests sync.WaitGroup
+	closing  bool
+	done     chan struct{}
 }
 
 // RegisterService adds the given ServiceDescription to the Server's map of services
@@ -109,12 +136,35 @@ func (s *Server) GetService(name ProtoID) (*ServiceDescription, error) {
 	return sd, nil
 }
 
+// listen serves srv until the server is shut down, in which case nil is returned
+func (s *Server) listen(srv *http.Server, tls bool) error {
+	s.connMtx.Lock()
+	if s.closing {
+		s.connMtx.Unlock()
+		return nil
+	}
+	s.servers = append(s.servers, srv)
+	s.connMtx.Unlock()
+
+	var err error
+	if tls {
+		err = srv.ListenAndServeTLS(s.ssl.Certificate, s.ssl.Key)
+	} else {
+		err = srv.ListenAndServe()
+	}
+
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
 // Serve starts the http(s) transport for the websocket, listening on addr
 func (s *Server) Serve(addr string) error {
 	var serving bool
 
 	if !s.ssl.Only {
-		err := http.ListenAndServe(addr, s)
+		err := s.listen(&http.Server{Addr: addr, Handler: s}, false)
 		if err != nil {
 			return err
 		}
@@ -122,7 +172,7 @@ func (s *Server) Serve(addr string) error {
 	}
 
 	if s.ssl.Certificate != "" && s.ssl.Key != "" && s.ssl.Addr != "" {
-		return http.ListenAndServeTLS(s.ssl.Addr, s.ssl.Certificate, s.ssl.Key, s)
+		return s.listen(&http.Server{Addr: s.ssl.Addr, Handler: s}, true)
 	}
 
 	if !serving {
@@ -132,6 +182,17 @@ func (s *Server) Serve(addr string) error {
 	return nil
 }
 
+// protocol returns the name of the protocol a connection requested by r uses, it is the first
+// requested protocol the server supports
+func (s *Server) protocol(r *http.Request) string {
+	for _, p := range websocket.Subprotocols(r) {
+		if _, ok := s.Protocols[p]; ok {
+			return p
+		}
+	}
+	return "default"
+}
+
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var (
 		session interface{}
@@ -145,18 +206,151 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	conn, err := s.Upgrader.Upgrade(w, r, nil)
+	var header http.Header
+	if d, ok := s.Deprecated[s.protocol(r)]; ok {
+		header = d.Header()
+	}
+
+	conn, err := s.Upgrader.Upgrade(w, r, header)
 	if err != nil {
-		s.Logger.Log("err", err)
+		level.Warn(s.Logger).Log("err", err)
 		return
 	}
 
-	s.Logger.Log("conn", conn.RemoteAddr())
-	go s.handleConnection(conn, session)
+	s.connMtx.Lock()
+	if s.closing {
+		s.connMtx.Unlock()
+		conn.Close()
+		return
+	}
+	s.conns[conn] = struct{}{}
+	s.connMtx.Unlock()
+
+	logger := log.With(s.Logger, "conn", logging.NewRequestID())
+	level.Info(logger).Log("msg", "connection opened", "addr", conn.RemoteAddr())
+	go s.handleConnection(conn, session, Client{Addr: r.RemoteAddr, UserAgent: r.UserAgent()}, logger)
+}
+
+// Shutdown stops accepting connections, waits for the requests in progress and closes the open connections.
+// Streams are ended right away. If ctx is done before the requests finished the connections are closed anyway.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.connMtx.Lock()
+	if s.closing {
+		s.connMtx.Unlock()
+		return nil
+	}
+	s.closing = true
+	close(s.done)
+	servers := s.servers
+	s.connMtx.Unlock()
+
+	for _, srv := range servers {
+		err := srv.Shutdown(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		s.requests.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	s.connMtx.Lock()
+	defer s.connMtx.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return err
 }
 
-func (s *Server) handleConnection(conn *websocket.Conn, session interface{}) {
-	defer conn.Close()
+// Broadcast sends v as a message of the service srv and method me to every open connection, it is
+// encoded by the protocol of each connection
+func (s *Server) Broadcast(srv, me ProtoID, v interface{}) {
+	s.connMtx.Lock()
+	conns := make([]*websocket.Conn, 0, len(s.conns))
+	for conn := range s.conns {
+		conns = append(conns, conn)
+	}
+	s.connMtx.Unlock()
+
+	for _, conn := range conns {
+		protocolName := conn.Subprotocol()
+		if protocolName == "" {
+			protocolName = "default"
+		}
+		protocolHandler, ok := s.Protocols[protocolName]
+		if !ok || protocolHandler == nil {
+			continue
+		}
+
+		message, err := protocolHandler.Encode(&srv, &me, v)
+		if err != nil {
+			level.Error(s.Logger).Log("err", err)
+			continue
+		}
+
+		s.mtx.Lock()
+		err = conn.WriteMessage(websocket.BinaryMessage, message)
+		s.mtx.Unlock()
+		if err != nil {
+			level.Error(s.Logger).Log("err", err)
+		}
+	}
+}
+
+// requestContext returns the context of a request of the connection with the context conn
+func (s *Server) requestContext(conn context.Context) (context.Context, context.CancelFunc) {
+	if s.Timeout > 0 {
+		return context.WithTimeout(conn, s.Timeout)
+	}
+	return context.WithCancel(conn)
+}
+
+// ErrInternal is returned for requests which panicked
+var ErrInternal = errors.New("internal error")
+
+// safely calls f and turns a panic into ErrInternal, so a malformed request does not crash the server
+func safely(logger log.Logger, f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			level.Error(logger).Log("msg", "request panicked", "panic", r, "stack", string(debug.Stack()))
+			err = ErrInternal
+		}
+	}()
+	return f()
+}
+
+// Client is the remote end of a connection, Addr is the host and port it connected from
+type Client struct {
+	Addr      string
+	UserAgent string
+}
+
+type clientKey struct{}
+
+// ClientFromContext returns the client of the connection a request was made over
+func ClientFromContext(ctx context.Context) (c Client, ok bool) {
+	c, ok = ctx.Value(clientKey{}).(Client)
+	return c, ok
+}
+
+func (s *Server) handleConnection(conn *websocket.Conn, session interface{}, client Client, logger log.Logger) {
+	defer func() {
+		s.connMtx.Lock()
+		delete(s.conns, conn)
+		s.connMtx.Unlock()
+		conn.Close()
+		level.Info(logger).Log("msg", "connection closed")
+	}()
 
 	protocolName := conn.Subprotocol()
 	if protocolName == "" {
@@ -169,6 +363,18 @@ func (s *Server) handleConnection(conn *websocket.Conn, session interface{}) {
 		return
 	}
 
+	if max := s.Limits.Largest(); max > 0 {
+		// the service and method ids precede the request
+		conn.SetReadLimit(int64(max) + 6)
+	}
+
+	closed := make(chan struct{})
+	defer close(closed)
+
+	// the operations of abandoned requests stop once the client disconnected
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), clientKey{}, client))
+	defer cancel()
+
 	for {
 		// check if a write error occured to stop handler
 		messageType, request, err := conn.ReadMessage()
@@ -176,15 +382,37 @@ func (s *Server) handleConnection(conn *websocket.Conn, session interface{}) {
 			return
 		}
 
+		s.connMtx.Lock()
+		if s.closing {
+			s.connMtx.Unlock()
+			return
+		}
+		s.requests.Add(1)
+		s.connMtx.Unlock()
+
 		go func() {
+			defer s.requests.Done()
 			defer s.mtx.Unlock()
 
-			srv, me, data, err := protocolHandler.Decode(request)
+			ctx, cancel := s.requestContext(ctx)
+			defer cancel()
+
+			var (
+				srv, me *ProtoID
+				data    interface{}
+			)
+			err := safely(logger, func() (err error) {
+				srv, me, data, err = protocolHandler.Decode(request)
+				if b, ok := data.([]byte); ok && err == nil {
+					err = s.Limits.Allow(srv.String()+"/"+me.String(), len(b))
+				}
+				return err
+			})
 			if err != nil {
 				s.mtx.Lock()
 				err = conn.WriteMessage(messageType, s.errh(srv, me, err, protocolHandler))
 				if err != nil {
-					s.Logger.Log("error", err)
+					level.Error(logger).Log("err", err)
 					return
 				}
 				return
@@ -195,7 +423,7 @@ func (s *Server) handleConnection(conn *websocket.Conn, session interface{}) {
 				s.mtx.Lock()
 				err = conn.WriteMessage(messageType, s.errh(srv, me, err, protocolHandler))
 				if err != nil {
-					s.Logger.Log("error", err)
+					level.Error(logger).Log("err", err)
 					return
 				}
 				return
@@ -206,18 +434,22 @@ func (s *Server) handleConnection(conn *websocket.Conn, session interface{}) {
 				s.mtx.Lock()
 				err = conn.WriteMessage(messageType, s.errh(srv, me, err, protocolHandler))
 				if err != nil {
-					s.Logger.Log("error", err)
+					level.Error(logger).Log("err", err)
 					return
 				}
 				return
 			}
 
-			res, err := handler(data)
+			var res interface{}
+			err = safely(logger, func() (err error) {
+				res, err = handler(ctx, data)
+				return err
+			})
 			if err != nil {
 				s.mtx.Lock()
 				err = conn.WriteMessage(messageType, s.errh(srv, me, err, protocolHandler))
 				if err != nil {
-					s.Logger.Log("error", err)
+					level.Error(logger).Log("err", err)
 					return
 				}
 				return
@@ -229,19 +461,26 @@ func (s *Server) handleConnection(conn *websocket.Conn, session interface{}) {
 					s.mtx.Lock()
 					err = conn.WriteMessage(messageType, s.errh(srv, me, err, protocolHandler))
 					if err != nil {
-						s.Logger.Log("error", err)
+						level.Error(logger).Log("err", err)
 						return
 					}
 					return
 				}
 			}
 
+			if stream, ok := res.(*Stream); ok {
+				s.stream(conn, messageType, srv, me, stream, protocolHandler, closed, logger)
+				// the deferred Unlock expects the lock to be held
+				s.mtx.Lock()
+				return
+			}
+
 			response, err := protocolHandler.Encode(srv, me, res)
 			if err != nil {
 				s.mtx.Lock()
 				err = conn.WriteMessage(messageType, s.errh(srv, me, err, protocolHandler))
 				if err != nil {
-					s.Logger.Log("error", err)
+					level.Error(logger).Log("err", err)
 					return
 				}
 				return
@@ -250,13 +489,55 @@ func (s *Server) handleConnection(conn *websocket.Conn, session interface{}) {
 			s.mtx.Lock()
 			err = conn.WriteMessage(messageType, response)
 			if err != nil {
-				s.Logger.Log("error", err)
+				level.Error(logger).Log("err", err)
 				return
 			}
 		}()
 	}
 }
 
+// stream writes every value of a Stream to the connection until the stream ends or the connection is closed
+func (s *Server) stream(conn *websocket.Conn, messageType int, srv, me *ProtoID, stream *Stream, protocolHandler ProtocolHandler, closed <-chan struct{}, logger log.Logger) {
+	defer stream.Stop()
+
+	for {
+		var (
+			v  interface{}
+			ok bool
+		)
+
+		select {
+		case v, ok = <-stream.C:
+			if !ok {
+				return
+			}
+		case <-closed:
+			return
+		case <-s.done:
+			return
+		}
+
+		var message []byte
+		if err, isErr := v.(error); isErr {
+			message = s.errh(srv, me, err, protocolHandler)
+		} else {
+			var err error
+			message, err = protocolHandler.Encode(srv, me, v)
+			if err != nil {
+				message = s.errh(srv, me, err, protocolHandler)
+			}
+		}
+
+		s.mtx.Lock()
+		err := conn.WriteMessage(messageType, message)
+		s.mtx.Unlock()
+		if err != nil {
+			level.Error(logger).Log("err", err)
+			return
+		}
+	}
+}
+
 // NewServer returns a pointer to a Server instance, given its dependencies
 func NewServer(
 	pm ProtocolMap,
@@ -287,7 +568,7 @@ func NewServer(
 	}
 
 	if upgrader.CheckOrigin == nil {
-		logger.Log("caution", "no CheckOrigin function provided, every connection will be accepted")
+		level.Warn(logger).Log("msg", "no CheckOrigin function provided, every connection will be accepted")
 		upgrader.CheckOrigin = func(r *http.Request) bool {
 			return true
 		}
@@ -316,6 +597,8 @@ func NewServer(
 		before:    before,
 		after:     after,
 		mtx:       &sync.Mutex{},
+		conns:     make(map[*websocket.Conn]struct{}),
+		done:      make(chan struct{}),
 	}
 
 	if auth != nil {
@@ -1,13 +1,17 @@
 package websocket_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/gorilla/websocket"
+	"github.com/kontainerooo/kontainer.ooo/pkg/payload"
+	"github.com/kontainerooo/kontainer.ooo/pkg/versioning"
 	ws "github.com/kontainerooo/kontainer.ooo/pkg/websocket"
 
 	. "github.com/onsi/ginkgo"
@@ -140,6 +144,54 @@ var _ = Describe("Server", func() {
 				Ω(err).ShouldNot(HaveOccurred())
 			})
 
+			It("Should announce the deprecation of a protocol in the handshake", func() {
+				wsServer := ws.NewServer(protocolMap, log.NewNopLogger(), websocket.Upgrader{}, testAuth{}, ws.SSLConfig{}, errh)
+				wsServer.Deprecated = map[string]versioning.Deprecation{
+					"default": {Successor: "v2"},
+				}
+				httpServer := httptest.NewServer(wsServer)
+				defer httpServer.Close()
+
+				dialer := websocket.Dialer{}
+				url := fmt.Sprintf("ws://%s", strings.Split(httpServer.URL, "//")[1])
+				c, res, err := dialer.Dial(url, http.Header{})
+				Ω(err).ShouldNot(HaveOccurred())
+				defer c.Close()
+				Expect(res.Header.Get("Deprecation")).To(Equal("true"))
+				Expect(res.Header.Get("Link")).To(Equal(`<v2>; rel="successor-version"`))
+			})
+
+			It("Should close connections on shutdown", func() {
+				wsServer := ws.NewServer(protocolMap, log.NewNopLogger(), websocket.Upgrader{}, testAuth{}, ws.SSLConfig{}, errh)
+				sd, _ := ws.NewServiceDescription("test", ws.ProtoIDFromString("TST"))
+				sd.AddEndpoint(ws.NewServiceEndpoint("test", ws.ProtoIDFromString("TST"), makeTestEndpoint(), decodeTest, encodeTest))
+				wsServer.RegisterService(sd)
+				httpServer := httptest.NewServer(wsServer)
+				defer httpServer.Close()
+
+				dialer := websocket.Dialer{}
+				url := fmt.Sprintf("ws://%s", strings.Split(httpServer.URL, "//")[1])
+				c, _, err := dialer.Dial(url, http.Header{})
+				Ω(err).ShouldNot(HaveOccurred())
+
+				c.WriteMessage(websocket.TextMessage, []byte("TST TST test"))
+				_, _, err = c.ReadMessage()
+				Ω(err).ShouldNot(HaveOccurred())
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				Ω(wsServer.Shutdown(ctx)).Should(Succeed())
+
+				_, _, err = c.ReadMessage()
+				Ω(err).Should(HaveOccurred())
+
+				c, _, err = dialer.Dial(url, http.Header{})
+				if err == nil {
+					_, _, err = c.ReadMessage()
+				}
+				Ω(err).Should(HaveOccurred())
+			})
+
 			Context("Connection Handling", func() {
 				var (
 					testMsg    = []byte("TST TST test")
@@ -217,10 +269,122 @@ var _ = Describe("Server", func() {
 						Ω(string(msg)).Should(ContainSubstring(errProtocolEncode.Error()))
 					})
 
+					It("Should answer requests which panic with an error and keep the connection", func() {
+						connection.WriteMessage(websocket.TextMessage, []byte("TST"))
+						_, msg, _ := connection.ReadMessage()
+						Ω(string(msg)).Should(ContainSubstring(ws.ErrInternal.Error()))
+
+						connection.WriteMessage(websocket.TextMessage, testMsg)
+						_, msg, err := connection.ReadMessage()
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(msg).Should(BeEquivalentTo(testMsg))
+					})
+
 					XContext("Middleware", func() {})
 				})
 			})
 
+			Context("Limits", func() {
+				var (
+					connection *websocket.Conn
+					httpServer *httptest.Server
+				)
+
+				BeforeEach(func() {
+					wsServer := ws.NewServer(ws.ProtocolMap{"default": ws.BasicHandler{}}, log.NewNopLogger(), websocket.Upgrader{}, testAuth{}, ws.SSLConfig{}, errh)
+					wsServer.Limits = payload.Limits{
+						Max:     64,
+						Methods: map[string]int{"TST/TST": 4},
+					}
+
+					sd, _ := ws.NewServiceDescription("test", ws.ProtoIDFromString("TST"))
+					sd.AddEndpoint(ws.NewServiceEndpoint("test", ws.ProtoIDFromString("TST"), makeTestEndpoint(), nil, nil))
+					wsServer.RegisterService(sd)
+
+					httpServer = httptest.NewServer(wsServer)
+					url := fmt.Sprintf("ws://%s", strings.Split(httpServer.URL, "//")[1])
+					connection, _, _ = (&websocket.Dialer{}).Dial(url, http.Header{})
+				})
+
+				AfterEach(func() {
+					connection.Close()
+					httpServer.Close()
+				})
+
+				It("Should reject requests exceeding the size of their endpoint", func() {
+					connection.WriteMessage(websocket.BinaryMessage, []byte("TSTTST12345"))
+					_, msg, err := connection.ReadMessage()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(msg)).Should(ContainSubstring("request of 5 bytes exceeds the limit of 4 bytes of TST/TST"))
+				})
+
+				It("Should close connections sending messages larger than every limit", func() {
+					connection.WriteMessage(websocket.BinaryMessage, append([]byte("TSTTST"), make([]byte, 100)...))
+					_, _, err := connection.ReadMessage()
+					Ω(err).Should(HaveOccurred())
+				})
+			})
+
+			Context("Broadcast", func() {
+				It("Should send a message to every connection", func() {
+					wsServer := ws.NewServer(protocolMap, log.NewNopLogger(), websocket.Upgrader{}, testAuth{}, ws.SSLConfig{}, errh)
+					httpServer := httptest.NewServer(wsServer)
+					defer httpServer.Close()
+
+					dialer := websocket.Dialer{}
+					url := fmt.Sprintf("ws://%s", strings.Split(httpServer.URL, "//")[1])
+					a, _, err := dialer.Dial(url, http.Header{})
+					Ω(err).ShouldNot(HaveOccurred())
+					defer a.Close()
+					b, _, err := dialer.Dial(url, http.Header{})
+					Ω(err).ShouldNot(HaveOccurred())
+					defer b.Close()
+
+					// the connections are registered once their upgrade was handled
+					a.WriteMessage(websocket.TextMessage, []byte("BLA BLA bla"))
+					a.ReadMessage()
+					b.WriteMessage(websocket.TextMessage, []byte("BLA BLA bla"))
+					b.ReadMessage()
+
+					wsServer.Broadcast(ws.ProtoIDFromString("TST"), ws.ProtoIDFromString("NOT"), response{res: "notice"})
+					for _, c := range []*websocket.Conn{a, b} {
+						_, msg, err := c.ReadMessage()
+						Ω(err).ShouldNot(HaveOccurred())
+						Ω(string(msg)).Should(Equal("TST NOT notice"))
+					}
+				})
+			})
+
+			Context("Stream", func() {
+				It("Should write every value of a Stream and stop it afterwards", func() {
+					wsServer := ws.NewServer(protocolMap, log.NewNopLogger(), websocket.Upgrader{}, testAuth{}, ws.SSLConfig{}, errh)
+
+					se, stopped := makeStreamEndpoint("a", "b")
+					sd, _ := ws.NewServiceDescription("test", ws.ProtoIDFromString("TST"))
+					sd.AddEndpoint(ws.NewServiceEndpoint("test", ws.ProtoIDFromString("TST"), se, decodeTest, encodeTest))
+					wsServer.RegisterService(sd)
+
+					httpServer := httptest.NewServer(wsServer)
+					defer httpServer.Close()
+
+					dialer := websocket.Dialer{}
+					url := fmt.Sprintf("ws://%s", strings.Split(httpServer.URL, "//")[1])
+					connection, _, _ := dialer.Dial(url, http.Header{})
+					defer connection.Close()
+
+					connection.WriteMessage(websocket.TextMessage, []byte("TST TST stream"))
+					_, msg, err := connection.ReadMessage()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(msg)).Should(Equal("TST TST a"))
+
+					_, msg, err = connection.ReadMessage()
+					Ω(err).ShouldNot(HaveOccurred())
+					Ω(string(msg)).Should(Equal("TST TST b"))
+
+					Eventually(stopped).Should(BeClosed())
+				})
+			})
+
 			Context("Error Handling", func() {
 				XIt("Should return an error if the requested protocol does not exist", func() {
 				})
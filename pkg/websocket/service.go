@@ -6,6 +6,9 @@ import (
 	"fmt"
 
 	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/logging"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
 )
 
 var (
@@ -20,14 +23,55 @@ var (
 )
 
 // EndpointHandler is a function with calls an Endpoint with the decoded message
-// and returns the encoded response or an error
-type EndpointHandler func(message interface{}) (response interface{}, err error)
+// and returns the encoded response or an error, the Endpoint is cancelled with ctx
+type EndpointHandler func(ctx context.Context, message interface{}) (response interface{}, err error)
 
 // StdDencode is a standard de/encode function which is used in an endpoint is no function was provided
 var StdDencode = func(_ context.Context, i interface{}) (interface{}, error) {
 	return i, nil
 }
 
+// Stream is returned by an endpoint to push a sequence of responses to a client.
+// Every value received from C is encoded and written to the connection until C is
+// closed or the connection ends, Stop is called afterwards to release the producer.
+type Stream struct {
+	C    <-chan interface{}
+	Stop func()
+}
+
+// encode returns a Stream which yields the values of s converted by enc,
+// errors of enc are passed on as values
+func (s *Stream) encode(ctx context.Context, enc EncodeResponseFunc) *Stream {
+	c := make(chan interface{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(c)
+		for v := range s.C {
+			res, err := enc(ctx, v)
+			if err != nil {
+				res = err
+			}
+
+			select {
+			case c <- res:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return &Stream{
+		C: c,
+		Stop: func() {
+			close(done)
+			if s.Stop != nil {
+				s.Stop()
+			}
+		},
+	}
+}
+
 // ServiceEndpoint is a struct type containing every value/function needed for an Endpoint in a Service
 type ServiceEndpoint struct {
 	// Name is the Name of the ServiceEndpoint
@@ -116,8 +160,20 @@ func (s *ServiceDescription) GetEndpointHandler(name ProtoID, before []*Middlewa
 		return nil, fmt.Errorf("Service Endpoint %s does not exist", name)
 	}
 
-	return func(message interface{}) (interface{}, error) {
-		ctx := context.Background()
+	return func(ctx context.Context, message interface{}) (res interface{}, err error) {
+		// websocket messages do not carry a trace, so every call starts a new one with the global tracer
+		span := opentracing.GlobalTracer().StartSpan(fmt.Sprintf("ws %s/%s", s.Name, e.Name), ext.SpanKindRPCServer)
+		ext.Component.Set(span, "websocket")
+		defer func() {
+			if err != nil {
+				ext.Error.Set(span, true)
+				span.LogKV("error", err.Error())
+			}
+			span.Finish()
+		}()
+
+		ctx = opentracing.ContextWithSpan(ctx, span)
+		ctx = logging.ContextWithRequestID(ctx, logging.NewRequestID())
 		req, err := e.Dec(ctx, message)
 		if err != nil {
 			return nil, err
@@ -132,11 +188,15 @@ func (s *ServiceDescription) GetEndpointHandler(name ProtoID, before []*Middlewa
 			}
 		}
 
-		res, err := e.E(ctx, req)
+		res, err = e.E(ctx, req)
 		if err != nil {
 			return nil, err
 		}
 
+		if stream, ok := res.(*Stream); ok {
+			return stream.encode(ctx, e.Enc), nil
+		}
+
 		return e.Enc(ctx, res)
 	}, nil
 }
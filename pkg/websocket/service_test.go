@@ -130,7 +130,7 @@ var _ = Describe("Service", func() {
 
 					val := 0
 					req := request{val}
-					res, err := eh(req)
+					res, err := eh(context.Background(), req)
 
 					Ω(err).ShouldNot(HaveOccurred())
 					Ω(res.(response).res).Should(BeEquivalentTo(val))
@@ -145,7 +145,7 @@ var _ = Describe("Service", func() {
 
 					val := true
 					req := request{val}
-					_, err := eh(req)
+					_, err := eh(context.Background(), req)
 
 					Ω(err).Should(BeEquivalentTo(errDecode))
 				})
@@ -159,7 +159,7 @@ var _ = Describe("Service", func() {
 
 					val := errEndpoint
 					req := request{val}
-					_, err := eh(req)
+					_, err := eh(context.Background(), req)
 
 					Ω(err).Should(BeEquivalentTo(errEndpoint))
 				})
@@ -173,12 +173,56 @@ var _ = Describe("Service", func() {
 
 					val := uint64(1)
 					req := request{val}
-					_, err := eh(req)
+					_, err := eh(context.Background(), req)
 
 					Ω(err).Should(BeEquivalentTo(errEncode))
 				})
 			})
 
+			Context("Context", func() {
+				It("Should pass the context of the request to the endpoint", func() {
+					protoID := ws.ProtoIDFromString("TST")
+					sd, _ := ws.NewServiceDescription("name", protoID)
+					e, _ := ws.NewServiceEndpoint("name", protoID, func(ctx context.Context, req interface{}) (interface{}, error) {
+						return nil, ctx.Err()
+					}, decodeTest, encodeTest)
+					sd.AddEndpoint(e)
+					eh, _ := sd.GetEndpointHandler(protoID, nil, nil)
+
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+
+					_, err := eh(ctx, request{0})
+					Ω(err).Should(Equal(context.Canceled))
+				})
+			})
+
+			Context("Stream", func() {
+				It("Should encode every value of a Stream", func() {
+					protoID := ws.ProtoIDFromString("TST")
+					sd, _ := ws.NewServiceDescription("name", protoID)
+					se, stopped := makeStreamEndpoint("a", uint64(1), "b")
+					e, _ := ws.NewServiceEndpoint("name", protoID, se, decodeTest, encodeTest)
+					sd.AddEndpoint(e)
+					eh, _ := sd.GetEndpointHandler(protoID, nil, nil)
+
+					res, err := eh(context.Background(), request{"stream"})
+					Ω(err).ShouldNot(HaveOccurred())
+
+					stream, ok := res.(*ws.Stream)
+					Ω(ok).Should(BeTrue())
+
+					values := []interface{}{}
+					for v := range stream.C {
+						values = append(values, v)
+					}
+					Ω(values).Should(Equal([]interface{}{response{"a"}, errEncode, response{"b"}}))
+
+					stream.Stop()
+					Eventually(stopped).Should(BeClosed())
+				})
+			})
+
 			Context("Error Handling", func() {
 				It("Should return an error if the requested endpoint does not exist", func() {
 					sd, _ := ws.NewServiceDescription("name", ws.ProtoIDFromString("TST"))
@@ -112,6 +112,24 @@ func makeTestEndpoint() endpoint.Endpoint {
 	}
 }
 
+func makeStreamEndpoint(values ...interface{}) (endpoint.Endpoint, chan struct{}) {
+	stopped := make(chan struct{})
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		c := make(chan interface{}, len(values))
+		for _, v := range values {
+			c <- v
+		}
+		close(c)
+
+		return &ws.Stream{
+			C: c,
+			Stop: func() {
+				close(stopped)
+			},
+		}, nil
+	}, stopped
+}
+
 func decodeTest(ctx context.Context, req interface{}) (interface{}, error) {
 	reqStruct := req.(request)
 	switch reqStruct.req.(type) {
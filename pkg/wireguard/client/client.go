@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"google.golang.org/grpc"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/wireguard"
+	"github.com/kontainerooo/kontainer.ooo/pkg/wireguard/pb"
+)
+
+// New creates a set of endpoints based on a gRPC connection
+func New(conn *grpc.ClientConn, logger log.Logger) *wireguard.Endpoints {
+
+	var CreatePeerEndpoint endpoint.Endpoint
+	{
+		CreatePeerEndpoint = grpctransport.NewClient(
+			conn,
+			"wireguard.WireGuardService",
+			"CreatePeer",
+			EncodeGRPCCreatePeerRequest,
+			DecodeGRPCCreatePeerResponse,
+			pb.CreatePeerResponse{},
+		).Endpoint()
+	}
+
+	var RemovePeerEndpoint endpoint.Endpoint
+	{
+		RemovePeerEndpoint = grpctransport.NewClient(
+			conn,
+			"wireguard.WireGuardService",
+			"RemovePeer",
+			EncodeGRPCRemovePeerRequest,
+			DecodeGRPCRemovePeerResponse,
+			pb.RemovePeerResponse{},
+		).Endpoint()
+	}
+
+	var PeersEndpoint endpoint.Endpoint
+	{
+		PeersEndpoint = grpctransport.NewClient(
+			conn,
+			"wireguard.WireGuardService",
+			"Peers",
+			EncodeGRPCPeersRequest,
+			DecodeGRPCPeersResponse,
+			pb.PeersResponse{},
+		).Endpoint()
+	}
+
+	var RotateKeyEndpoint endpoint.Endpoint
+	{
+		RotateKeyEndpoint = grpctransport.NewClient(
+			conn,
+			"wireguard.WireGuardService",
+			"RotateKey",
+			EncodeGRPCRotateKeyRequest,
+			DecodeGRPCRotateKeyResponse,
+			pb.RotateKeyResponse{},
+		).Endpoint()
+	}
+
+	var SetRulesEndpoint endpoint.Endpoint
+	{
+		SetRulesEndpoint = grpctransport.NewClient(
+			conn,
+			"wireguard.WireGuardService",
+			"SetRules",
+			EncodeGRPCSetRulesRequest,
+			DecodeGRPCSetRulesResponse,
+			pb.SetRulesResponse{},
+		).Endpoint()
+	}
+
+	return &wireguard.Endpoints{
+		CreatePeerEndpoint: CreatePeerEndpoint,
+		RemovePeerEndpoint: RemovePeerEndpoint,
+		PeersEndpoint:      PeersEndpoint,
+		RotateKeyEndpoint:  RotateKeyEndpoint,
+		SetRulesEndpoint:   SetRulesEndpoint,
+	}
+}
+
+func getError(e string) error {
+	if e != "" {
+		return errors.New(e)
+	}
+	return nil
+}
+
+// EncodeGRPCCreatePeerRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain createpeer request to a gRPC CreatePeer request.
+func EncodeGRPCCreatePeerRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*wireguard.CreatePeerRequest)
+	return &pb.CreatePeerRequest{
+		RefID: uint32(req.RefID),
+		Name:  req.Peer.Name,
+		Rules: req.Peer.Rules,
+	}, nil
+}
+
+// DecodeGRPCCreatePeerResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC CreatePeer response to a messages/wireguard.proto-domain createpeer response.
+func DecodeGRPCCreatePeerResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.CreatePeerResponse)
+	return &wireguard.CreatePeerResponse{
+		ID:     uint(response.ID),
+		Config: response.Config,
+		Error:  getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCRemovePeerRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain removepeer request to a gRPC RemovePeer request.
+func EncodeGRPCRemovePeerRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*wireguard.RemovePeerRequest)
+	return &pb.RemovePeerRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRemovePeerResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RemovePeer response to a messages/wireguard.proto-domain removepeer response.
+func DecodeGRPCRemovePeerResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RemovePeerResponse)
+	return &wireguard.RemovePeerResponse{
+		Error: getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCPeersRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain peers request to a gRPC Peers request.
+func EncodeGRPCPeersRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*wireguard.PeersRequest)
+	return &pb.PeersRequest{
+		RefID: uint32(req.RefID),
+		Page:  paging.EncodePage(req.Page),
+	}, nil
+}
+
+// DecodeGRPCPeersResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC Peers response to a messages/wireguard.proto-domain peers response.
+func DecodeGRPCPeersResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.PeersResponse)
+	peers := make([]wireguard.Peer, len(response.Peers))
+	for i, p := range response.Peers {
+		peers[i] = wireguard.ConvertPBPeer(p)
+	}
+
+	return &wireguard.PeersResponse{
+		Peers: peers,
+		Error: getError(response.Error),
+		Page:  paging.DecodePageInfo(response.PageInfo),
+	}, nil
+}
+
+// EncodeGRPCRotateKeyRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain rotatekey request to a gRPC RotateKey request.
+func EncodeGRPCRotateKeyRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*wireguard.RotateKeyRequest)
+	return &pb.RotateKeyRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+	}, nil
+}
+
+// DecodeGRPCRotateKeyResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC RotateKey response to a messages/wireguard.proto-domain rotatekey response.
+func DecodeGRPCRotateKeyResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.RotateKeyResponse)
+	return &wireguard.RotateKeyResponse{
+		Config: response.Config,
+		Error:  getError(response.Error),
+	}, nil
+}
+
+// EncodeGRPCSetRulesRequest is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain setrules request to a gRPC SetRules request.
+func EncodeGRPCSetRulesRequest(_ context.Context, request interface{}) (interface{}, error) {
+	req := request.(*wireguard.SetRulesRequest)
+	return &pb.SetRulesRequest{
+		RefID: uint32(req.RefID),
+		ID:    uint32(req.ID),
+		Rules: req.Rules,
+	}, nil
+}
+
+// DecodeGRPCSetRulesResponse is a transport/grpc.DecodeResponseFunc that converts a
+// gRPC SetRules response to a messages/wireguard.proto-domain setrules response.
+func DecodeGRPCSetRulesResponse(_ context.Context, grpcResponse interface{}) (interface{}, error) {
+	response := grpcResponse.(*pb.SetRulesResponse)
+	return &wireguard.SetRulesResponse{
+		Error: getError(response.Error),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package wireguard
+
+import (
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/lib/pq"
+)
+
+// States of a peer
+const (
+	// Active peers may connect
+	Active = "active"
+	// Suspended peers belong to a suspended user, they are removed from the interface until the user is resumed
+	Suspended = "suspended"
+)
+
+// AllInstances is the rule allowing a peer to reach the whole bridge network of its user
+const AllInstances = "*"
+
+// Peer is a device of a user connecting to the bridge network of the user over the interface of a node
+type Peer struct {
+	ID    uint `gorm:"primary_key"`
+	RefID uint
+	Name  string `validate:"required,name"`
+	// PublicKey is the key of the peer, its private key is only part of the configuration returned once
+	PublicKey string
+	// Address is the address of the peer inside of the tunnel
+	Address abstraction.Inet
+	// Node is the name of the node whose interface the peer connects to, Endpoint and ServerKey are the
+	// address and the public key of that interface
+	Node      string
+	Endpoint  string
+	ServerKey string
+	// Rules are the instances the peer may reach, * allows the whole bridge network
+	Rules        pq.StringArray `sql:"type:text[]"`
+	Status       string
+	KeyCreatedAt time.Time
+	CreatedAt    time.Time
+}
+
+// TableName sets Peer's database table name
+func (Peer) TableName() string {
+	return "wireguard_peers"
+}
+
+// Grant is a connection from a peer to its bridge network allowed in the firewall of a node
+type Grant struct {
+	ID     uint `gorm:"primary_key"`
+	PeerID uint
+	Node   string
+	Src    abstraction.Inet
+	Dst    abstraction.Inet
+	// DstNetwork is the bridge interface of the user
+	DstNetwork string
+}
+
+// TableName sets Grant's database table name
+func (Grant) TableName() string {
+	return "wireguard_grants"
+}
@@ -0,0 +1,84 @@
+package wireguard
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// Device configures the WireGuard interface of a node
+type Device interface {
+	// Up creates the interface with the private key in keyFile, listening on port with the address gw in
+	// the network n, unless it exists already
+	Up(name string, keyFile string, port uint16, n *net.IPNet, gw net.IP) error
+
+	// Peers returns the public keys of the peers of the interface
+	Peers(name string) ([]string, error)
+
+	// SetPeer adds a peer to the interface or updates the address it may use
+	SetPeer(name string, publicKey string, address net.IP) error
+
+	// RemovePeer removes a peer from the interface
+	RemovePeer(name string, publicKey string) error
+}
+
+type wgDevice struct{}
+
+func (wgDevice) run(cmd string, args ...string) (string, error) {
+	out, err := exec.Command(cmd, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s %v: %s", cmd, args, out)
+	}
+	return string(out), nil
+}
+
+func (d wgDevice) Up(name string, keyFile string, port uint16, n *net.IPNet, gw net.IP) error {
+	_, err := d.run("ip", "link", "show", "dev", name)
+	if err == nil {
+		return nil
+	}
+
+	_, err = d.run("ip", "link", "add", "dev", name, "type", "wireguard")
+	if err != nil {
+		return err
+	}
+
+	ones, _ := n.Mask.Size()
+	steps := [][]string{
+		{"ip", "addr", "add", fmt.Sprintf("%s/%d", gw, ones), "dev", name},
+		{"wg", "set", name, "listen-port", fmt.Sprintf("%d", port), "private-key", keyFile},
+		{"ip", "link", "set", name, "up"},
+	}
+	for _, step := range steps {
+		_, err = d.run(step[0], step[1:]...)
+		if err != nil {
+			d.run("ip", "link", "delete", "dev", name)
+			return err
+		}
+	}
+	return nil
+}
+
+func (d wgDevice) Peers(name string) ([]string, error) {
+	out, err := d.run("wg", "show", name, "peers")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+func (d wgDevice) SetPeer(name string, publicKey string, address net.IP) error {
+	_, err := d.run("wg", "set", name, "peer", publicKey, "allowed-ips", fmt.Sprintf("%s/32", address))
+	return err
+}
+
+func (d wgDevice) RemovePeer(name string, publicKey string) error {
+	_, err := d.run("wg", "set", name, "peer", publicKey, "remove")
+	return err
+}
+
+// NewWGDevice returns a Device using iproute2 and wireguard-tools
+func NewWGDevice() Device {
+	return wgDevice{}
+}
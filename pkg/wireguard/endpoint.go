@@ -0,0 +1,155 @@
+package wireguard
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+)
+
+// Endpoints is a struct which collects all endpoints for the wireguard service
+type Endpoints struct {
+	CreatePeerEndpoint endpoint.Endpoint
+	RemovePeerEndpoint endpoint.Endpoint
+	PeersEndpoint      endpoint.Endpoint
+	RotateKeyEndpoint  endpoint.Endpoint
+	SetRulesEndpoint   endpoint.Endpoint
+}
+
+// CreatePeerRequest is the request struct for the CreatePeerEndpoint
+type CreatePeerRequest struct {
+	RefID uint  `bart:"ref"`
+	Peer  *Peer `validate:"required"`
+}
+
+// CreatePeerResponse is the response struct for the CreatePeerEndpoint
+type CreatePeerResponse struct {
+	ID     uint
+	Config string
+	Error  error
+}
+
+// MakeCreatePeerEndpoint creates a gokit endpoint which invokes CreatePeer
+func MakeCreatePeerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CreatePeerRequest)
+		config, err := s.CreatePeer(req.RefID, req.Peer)
+		if err != nil {
+			return CreatePeerResponse{
+				Error: err,
+			}, nil
+		}
+		return CreatePeerResponse{
+			ID:     req.Peer.ID,
+			Config: config,
+		}, nil
+	}
+}
+
+// RemovePeerRequest is the request struct for the RemovePeerEndpoint
+type RemovePeerRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RemovePeerResponse is the response struct for the RemovePeerEndpoint
+type RemovePeerResponse struct {
+	Error error
+}
+
+// MakeRemovePeerEndpoint creates a gokit endpoint which invokes RemovePeer
+func MakeRemovePeerEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RemovePeerRequest)
+		err := s.RemovePeer(req.RefID, req.ID)
+		return RemovePeerResponse{
+			Error: err,
+		}, nil
+	}
+}
+
+// PeersRequest is the request struct for the PeersEndpoint
+type PeersRequest struct {
+	RefID uint `bart:"ref"`
+	Page  paging.Request
+}
+
+// PeersResponse is the response struct for the PeersEndpoint
+type PeersResponse struct {
+	Peers []Peer
+	Error error
+	Page  paging.Response
+}
+
+// MakePeersEndpoint creates a gokit endpoint which invokes Peers
+func MakePeersEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PeersRequest)
+		peers := []Peer{}
+		// a user without a reference would get the peers of every user
+		if req.RefID != 0 {
+			err := s.Peers(req.RefID, &peers)
+			if err != nil {
+				return PeersResponse{
+					Error: err,
+				}, nil
+			}
+		}
+
+		page, err := paging.Apply(&peers, "ID", req.Page)
+		if err != nil {
+			return nil, err
+		}
+		return PeersResponse{
+			Peers: peers,
+			Page:  page,
+		}, nil
+	}
+}
+
+// RotateKeyRequest is the request struct for the RotateKeyEndpoint
+type RotateKeyRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+}
+
+// RotateKeyResponse is the response struct for the RotateKeyEndpoint
+type RotateKeyResponse struct {
+	Config string
+	Error  error
+}
+
+// MakeRotateKeyEndpoint creates a gokit endpoint which invokes RotateKey
+func MakeRotateKeyEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RotateKeyRequest)
+		config, err := s.RotateKey(req.RefID, req.ID)
+		return RotateKeyResponse{
+			Config: config,
+			Error:  err,
+		}, nil
+	}
+}
+
+// SetRulesRequest is the request struct for the SetRulesEndpoint
+type SetRulesRequest struct {
+	RefID uint `bart:"ref"`
+	ID    uint
+	Rules []string `validate:"required"`
+}
+
+// SetRulesResponse is the response struct for the SetRulesEndpoint
+type SetRulesResponse struct {
+	Error error
+}
+
+// MakeSetRulesEndpoint creates a gokit endpoint which invokes SetRules
+func MakeSetRulesEndpoint(s Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(SetRulesRequest)
+		err := s.SetRules(req.RefID, req.ID, req.Rules)
+		return SetRulesResponse{
+			Error: err,
+		}, nil
+	}
+}
@@ -0,0 +1,64 @@
+package wireguard
+
+import (
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/kontainerooo/kontainer.ooo/pkg/events"
+)
+
+// EventGroup is the group the services of all nodes subscribe to events with
+const EventGroup = "wireguard"
+
+// Subscribe disconnects the peers of suspended users until they are resumed and removes the peers of deleted users,
+// the nodes of the peers tear their connections down on their next sync
+func Subscribe(s Service, bus events.Bus, logger log.Logger) ([]events.Subscription, error) {
+	account := func(suspended bool) events.Handler {
+		return func(e events.Event) error {
+			a := events.AccountEvent{}
+			err := e.Decode(&a)
+			if err != nil {
+				level.Error(logger).Log("event", e.ID, "err", err)
+				return nil
+			}
+
+			err = s.Suspend(a.RefID, suspended)
+			if err != nil {
+				level.Error(logger).Log("event", e.ID, "user", a.RefID, "err", err)
+			}
+			return err
+		}
+	}
+
+	suspended, err := bus.Subscribe(events.AccountSuspended, EventGroup, account(true))
+	if err != nil {
+		return nil, err
+	}
+
+	resumed, err := bus.Subscribe(events.AccountResumed, EventGroup, account(false))
+	if err != nil {
+		suspended.Unsubscribe()
+		return nil, err
+	}
+
+	deleted, err := bus.Subscribe(events.UserDeleted, EventGroup, func(e events.Event) error {
+		u := events.UserEvent{}
+		err := e.Decode(&u)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "err", err)
+			return nil
+		}
+
+		err = s.RemovePeers(u.ID)
+		if err != nil {
+			level.Error(logger).Log("event", e.ID, "user", u.ID, "err", err)
+		}
+		return err
+	})
+	if err != nil {
+		suspended.Unsubscribe()
+		resumed.Unsubscribe()
+		return nil, err
+	}
+
+	return []events.Subscription{suspended, resumed, deleted}, nil
+}
@@ -0,0 +1,71 @@
+package wireguard
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/curve25519"
+)
+
+// ErrKey occurs if a key is not a base64 encoded curve25519 key
+var ErrKey = errors.New("invalid key")
+
+// GenerateKey returns a new private key and its public key, both base64 encoded like wg genkey and wg pubkey print them
+func GenerateKey() (private string, public string, err error) {
+	var key [32]byte
+	_, err = rand.Read(key[:])
+	if err != nil {
+		return "", "", err
+	}
+
+	// clamp the key the way curve25519 expects it
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+
+	public, err = PublicKey(base64.StdEncoding.EncodeToString(key[:]))
+	if err != nil {
+		return "", "", err
+	}
+	return base64.StdEncoding.EncodeToString(key[:]), public, nil
+}
+
+// PublicKey returns the public key of a base64 encoded private key
+func PublicKey(private string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(private))
+	if err != nil || len(key) != 32 {
+		return "", ErrKey
+	}
+
+	var priv, pub [32]byte
+	copy(priv[:], key)
+	curve25519.ScalarBaseMult(&pub, &priv)
+	return base64.StdEncoding.EncodeToString(pub[:]), nil
+}
+
+// LoadKey returns the public key of the private key in path, a private key is generated and written to path if it does not exist
+func LoadKey(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		private, public, err := GenerateKey()
+		if err != nil {
+			return "", err
+		}
+
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+		if err != nil {
+			return "", err
+		}
+		return public, ioutil.WriteFile(path, []byte(private+"\n"), 0600)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return PublicKey(string(data))
+}
@@ -0,0 +1,598 @@
+// Package wireguard gives the devices of users VPN access to their isolated bridge networks, e.g. to debug
+// instances which publish no ports. Every node serves the peers created on it over its WireGuard interface and
+// allows them to reach the instances their rules name in its firewall.
+package wireguard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+)
+
+var (
+	// ErrPeerExists occurs if a user has a peer of the same name
+	ErrPeerExists = errors.New("peer exists already")
+
+	// ErrPeerNotExist occurs if a peer does not exist
+	ErrPeerNotExist = errors.New("peer does not exist")
+
+	// ErrLimit occurs if a user has as many peers as allowed
+	ErrLimit = errors.New("no more peers are allowed")
+
+	// ErrRule occurs if a rule is neither * nor the name of an instance
+	ErrRule = errors.New("rules have to be * or the name of an instance")
+
+	// ErrNoNetwork occurs if a user has no bridge network to connect to
+	ErrNoNetwork = errors.New("user has no private network")
+
+	// ErrPoolExhausted occurs if every address of the pool is assigned to a peer
+	ErrPoolExhausted = errors.New("no tunnel address is available")
+)
+
+// ruleRegexp matches the names of instances
+var ruleRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// Service WireGuardService
+type Service interface {
+	// CreatePeer creates a peer of a user and returns its configuration, which is the only copy of its private key
+	CreatePeer(refID uint, p *Peer) (string, error)
+
+	// RemovePeer removes a peer, it is disconnected on the next sync of its node
+	RemovePeer(refID uint, id uint) error
+
+	// RemovePeers removes every peer of a user
+	RemovePeers(refID uint) error
+
+	// Peers returns the peers of a user
+	Peers(refID uint, p *[]Peer) error
+
+	// RotateKey replaces the key of a peer and returns its new configuration, the old key stops working on the
+	// next sync of its node
+	RotateKey(refID uint, id uint) (string, error)
+
+	// SetRules sets the instances a peer may reach
+	SetRules(refID uint, id uint, rules []string) error
+
+	// Suspend disconnects the peers of a suspended user, or reconnects them once the user was resumed
+	Suspend(refID uint, suspended bool) error
+
+	// Sync brings the interface and the firewall of this node in line with its peers. Peers which were removed or
+	// suspended or whose key is older than the maximum key age are disconnected.
+	Sync(now time.Time) error
+}
+
+// Network resolves the bridge networks of the users and the addresses of their instances
+type Network interface {
+	// Bridge returns the subnet and the interface of the bridge network of a user, or ErrNoNetwork
+	Bridge(refID uint) (*net.IPNet, string, error)
+
+	// Address returns the address of an instance in the bridge network of its user, ok is false if it has none
+	Address(refID uint, instance string) (ip net.IP, ok bool, err error)
+}
+
+// Options configure the interface of this node and the peers
+type Options struct {
+	// Node is the name of this node
+	Node string
+	// Interface is the name of the WireGuard interface
+	Interface string
+	// Port is the port the interface listens on, Endpoint the host the peers connect to
+	Port     uint16
+	Endpoint string
+	// Pool is the network the addresses inside of the tunnel are assigned from, the interface takes the first
+	Pool *net.IPNet
+	// KeyFile is the private key of the interface, PublicKey its public key
+	KeyFile   string
+	PublicKey string
+	// MaxPeers is the number of peers a user may have
+	MaxPeers uint
+	// MaxKeyAge is how long a key is valid before it has to be rotated, keys never expire if it is 0
+	MaxKeyAge time.Duration
+}
+
+type dbAdapter interface {
+	abstraction.DBAdapter
+	AutoMigrate(...interface{}) error
+	Where(interface{}, ...interface{}) error
+	First(interface{}, ...interface{}) error
+	Find(interface{}, ...interface{}) error
+	Pluck(interface{}, string, interface{}, ...interface{}) error
+	Create(interface{}) error
+	Delete(interface{}, ...interface{}) error
+	Update(interface{}, ...interface{}) error
+}
+
+type service struct {
+	db       dbAdapter
+	firewall *firewall.Endpoints
+	device   Device
+	network  Network
+	options  Options
+	mtx      *sync.Mutex
+}
+
+func (s *service) InitializeDatabases() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.initializeDatabases()
+}
+
+func (s *service) initializeDatabases() error {
+	return s.db.AutoMigrate(&Peer{}, &Grant{})
+}
+
+// peers returns the peers of a user, or of every user if refID is 0
+func (s *service) peers(refID uint) ([]Peer, error) {
+	ps := []Peer{}
+	var err error
+	if refID == 0 {
+		err = s.db.Find(&ps)
+	} else {
+		err = s.db.Find(&ps, "ref_id = ?", refID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// peer returns a peer of a user
+func (s *service) peer(refID uint, id uint) (Peer, error) {
+	p := Peer{}
+	err := s.db.First(&p, "id = ? AND ref_id = ?", id, refID)
+	if s.db.IsNotFound(err) {
+		return Peer{}, ErrPeerNotExist
+	}
+	if err != nil {
+		return Peer{}, err
+	}
+	return p, nil
+}
+
+// gateway returns the address of the interface, the first of the pool
+func (s *service) gateway() net.IP {
+	return next(s.options.Pool.IP.Mask(s.options.Pool.Mask))
+}
+
+// next returns the address following ip
+func next(ip net.IP) net.IP {
+	n := make(net.IP, len(ip))
+	copy(n, ip)
+	for i := len(n) - 1; i >= 0; i-- {
+		n[i]++
+		if n[i] != 0 {
+			break
+		}
+	}
+	return n
+}
+
+// allocate returns the lowest address of the pool which is neither the interface's nor assigned to a peer
+func (s *service) allocate() (abstraction.Inet, error) {
+	addresses := []abstraction.Inet{}
+	err := s.db.Pluck(&Peer{}, "address", &addresses)
+	if err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool)
+	for _, a := range addresses {
+		used[string(a)] = true
+	}
+
+	pool := s.options.Pool
+	for ip := next(s.gateway()); pool.Contains(ip); ip = next(ip) {
+		// the broadcast address of the pool is not assigned
+		if !pool.Contains(next(ip)) {
+			break
+		}
+		if !used[ip.String()] {
+			return abstraction.Inet(ip.String()), nil
+		}
+	}
+	return "", ErrPoolExhausted
+}
+
+// validRules returns whether every rule is * or the name of an instance
+func validRules(rules []string) bool {
+	for _, r := range rules {
+		if r != AllInstances && !ruleRegexp.MatchString(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// config returns the configuration of a peer with its private key
+func (s *service) config(p Peer, private string) (string, error) {
+	subnet, _, err := s.network.Bridge(p.RefID)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[Interface]\nPrivateKey = %s\nAddress = %s/32\n\n", private, p.Address)
+	fmt.Fprintf(&buf, "[Peer]\nPublicKey = %s\nEndpoint = %s\nAllowedIPs = %s\nPersistentKeepalive = 25\n", p.ServerKey, p.Endpoint, subnet)
+	return buf.String(), nil
+}
+
+func (s *service) CreatePeer(refID uint, p *Peer) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p.ID = 0
+	p.RefID = refID
+
+	if len(p.Rules) == 0 {
+		p.Rules = []string{AllInstances}
+	}
+	if !validRules(p.Rules) {
+		return "", ErrRule
+	}
+
+	_, _, err := s.network.Bridge(refID)
+	if err != nil {
+		return "", err
+	}
+
+	ps, err := s.peers(refID)
+	if err != nil {
+		return "", err
+	}
+
+	for _, o := range ps {
+		if o.Name == p.Name {
+			return "", ErrPeerExists
+		}
+	}
+	if uint(len(ps)) >= s.options.MaxPeers {
+		return "", ErrLimit
+	}
+
+	p.Address, err = s.allocate()
+	if err != nil {
+		return "", err
+	}
+
+	private, public, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now().UTC()
+	p.PublicKey = public
+	p.Node = s.options.Node
+	p.Endpoint = net.JoinHostPort(s.options.Endpoint, fmt.Sprintf("%d", s.options.Port))
+	p.ServerKey = s.options.PublicKey
+	p.Status = Active
+	p.KeyCreatedAt = now
+	p.CreatedAt = now
+
+	err = s.db.Create(p)
+	if err != nil {
+		return "", err
+	}
+	return s.config(*p, private)
+}
+
+func (s *service) RemovePeer(refID uint, id uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	_, err := s.peer(refID, id)
+	if err != nil {
+		return err
+	}
+	return s.db.Delete(&Peer{ID: id})
+}
+
+func (s *service) RemovePeers(refID uint) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	return s.db.Delete(&Peer{}, "ref_id = ?", refID)
+}
+
+func (s *service) Peers(refID uint, p *[]Peer) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ps, err := s.peers(refID)
+	if err != nil {
+		return err
+	}
+
+	*p = append(*p, ps...)
+	return nil
+}
+
+func (s *service) RotateKey(refID uint, id uint) (string, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	p, err := s.peer(refID, id)
+	if err != nil {
+		return "", err
+	}
+
+	private, public, err := GenerateKey()
+	if err != nil {
+		return "", err
+	}
+
+	p.PublicKey = public
+	p.KeyCreatedAt = time.Now().UTC()
+	err = abstraction.UpdateByID(s.db, &Peer{}, id, &Peer{
+		PublicKey:    p.PublicKey,
+		KeyCreatedAt: p.KeyCreatedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return s.config(p, private)
+}
+
+func (s *service) SetRules(refID uint, id uint, rules []string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// an empty list would not be stored, peers without rules are removed instead
+	if len(rules) == 0 || !validRules(rules) {
+		return ErrRule
+	}
+
+	_, err := s.peer(refID, id)
+	if err != nil {
+		return err
+	}
+	return abstraction.UpdateByID(s.db, &Peer{}, id, &Peer{Rules: rules})
+}
+
+func (s *service) Suspend(refID uint, suspended bool) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	status := Active
+	if suspended {
+		status = Suspended
+	}
+
+	ps := []Peer{}
+	err := s.db.Find(&ps, "ref_id = ? AND status <> ?", refID, status)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range ps {
+		err = abstraction.UpdateByID(s.db, &Peer{}, p.ID, &Peer{Status: status})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connected returns whether a peer may be connected to this node at now
+func (s *service) connected(p Peer, now time.Time) bool {
+	if p.Node != s.options.Node || p.Status != Active {
+		return false
+	}
+	return s.options.MaxKeyAge == 0 || p.KeyCreatedAt.Add(s.options.MaxKeyAge).After(now)
+}
+
+// grants returns the connections the rules of a peer allow in the firewall of this node
+func (s *service) grants(p Peer) ([]Grant, error) {
+	subnet, iface, err := s.network.Bridge(p.RefID)
+	if err == ErrNoNetwork {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	gs := []Grant{}
+	seen := make(map[abstraction.Inet]bool)
+	for _, r := range p.Rules {
+		dst := abstraction.Inet(subnet.String())
+		if r != AllInstances {
+			ip, ok, err := s.network.Address(p.RefID, r)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			dst = abstraction.Inet(ip.String())
+		}
+
+		if seen[dst] {
+			continue
+		}
+		seen[dst] = true
+
+		gs = append(gs, Grant{
+			PeerID:     p.ID,
+			Node:       s.options.Node,
+			Src:        p.Address,
+			Dst:        dst,
+			DstNetwork: iface,
+		})
+	}
+	return gs, nil
+}
+
+// key identifies the firewall rules of a grant
+func (g Grant) key() string {
+	return fmt.Sprintf("%s>%s@%s", g.Src, g.Dst, g.DstNetwork)
+}
+
+// firewallRequest allows or blocks the connection of a grant
+func (s *service) firewallRequest(g Grant, allow bool) error {
+	if allow {
+		res, err := s.firewall.AllowConnectionEndpoint(context.Background(), firewall.AllowConnectionRequest{
+			SrcIP:      g.Src,
+			SrcNetwork: s.options.Interface,
+			DstIP:      g.Dst,
+			DstNetwork: g.DstNetwork,
+		})
+		if err != nil {
+			return err
+		}
+		return res.(firewall.AllowConnectionResponse).Error
+	}
+
+	res, err := s.firewall.BlockConnectionEndpoint(context.Background(), firewall.BlockConnectionRequest{
+		SrcIP:      g.Src,
+		SrcNetwork: s.options.Interface,
+		DstIP:      g.Dst,
+		DstNetwork: g.DstNetwork,
+	})
+	if err != nil {
+		return err
+	}
+	return res.(firewall.BlockConnectionResponse).Error
+}
+
+// syncDevice removes the peers which may not be connected from the interface and adds the others
+func (s *service) syncDevice(connected []Peer) error {
+	err := s.device.Up(s.options.Interface, s.options.KeyFile, s.options.Port, s.options.Pool, s.gateway())
+	if err != nil {
+		return err
+	}
+
+	keys, err := s.device.Peers(s.options.Interface)
+	if err != nil {
+		return err
+	}
+
+	want := make(map[string]bool)
+	for _, p := range connected {
+		want[p.PublicKey] = true
+	}
+	for _, key := range keys {
+		if !want[key] {
+			err = s.device.RemovePeer(s.options.Interface, key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, p := range connected {
+		err = s.device.SetPeer(s.options.Interface, p.PublicKey, net.ParseIP(string(p.Address)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncFirewall blocks the stored grants of this node which are not wanted anymore and allows the missing ones
+func (s *service) syncFirewall(connected []Peer) error {
+	want := make(map[string]Grant)
+	for _, p := range connected {
+		gs, err := s.grants(p)
+		if err != nil {
+			return err
+		}
+		for _, g := range gs {
+			want[g.key()] = g
+		}
+	}
+
+	stored := []Grant{}
+	err := s.db.Find(&stored, "node = ?", s.options.Node)
+	if err != nil {
+		return err
+	}
+
+	have := make(map[string]bool)
+	for _, g := range stored {
+		if _, ok := want[g.key()]; ok {
+			have[g.key()] = true
+			continue
+		}
+
+		err = s.firewallRequest(g, false)
+		if err != nil {
+			return err
+		}
+		err = s.db.Delete(&Grant{ID: g.ID})
+		if err != nil {
+			return err
+		}
+	}
+
+	for key, g := range want {
+		if have[key] {
+			continue
+		}
+
+		err = s.firewallRequest(g, true)
+		if err != nil {
+			return err
+		}
+		err = s.db.Create(&g)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) Sync(now time.Time) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ps := []Peer{}
+	err := s.db.Find(&ps, "node = ? AND status = ?", s.options.Node, Active)
+	if err != nil {
+		return err
+	}
+
+	connected := []Peer{}
+	for _, p := range ps {
+		if s.connected(p, now) {
+			connected = append(connected, p)
+		}
+	}
+
+	// the firewall is synced first, so a disconnected peer cannot reach the bridge even if its key lingers
+	err = s.syncFirewall(connected)
+	if err != nil {
+		return err
+	}
+	return s.syncDevice(connected)
+}
+
+// NewService creates a WireGuardService, fw is the firewall of this node
+func NewService(db dbAdapter, fw *firewall.Endpoints, d Device, nw Network, o Options) (Service, error) {
+	if o.MaxPeers == 0 {
+		o.MaxPeers = 5
+	}
+
+	s := &service{
+		db:       db,
+		firewall: fw,
+		device:   d,
+		network:  nw,
+		options:  o,
+		mtx:      &sync.Mutex{},
+	}
+
+	err := s.InitializeDatabases()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,41 @@
+package wireguard
+
+import (
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+// Syncer keeps the interface and the firewall of a node up to date with its peers
+type Syncer struct {
+	s      Service
+	logger log.Logger
+}
+
+// Run calls Sync every interval until stop is closed
+func (y *Syncer) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		err := y.s.Sync(time.Now())
+		if err != nil {
+			level.Error(y.logger).Log("wireguard", "sync", "err", err)
+		}
+
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// NewSyncer returns a Syncer for the peers of s
+func NewSyncer(s Service, logger log.Logger) *Syncer {
+	return &Syncer{
+		s:      s,
+		logger: logger,
+	}
+}
@@ -0,0 +1,263 @@
+package wireguard
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/kontainerooo/kontainer.ooo/pkg/abstraction"
+	"github.com/kontainerooo/kontainer.ooo/pkg/paging"
+	"github.com/kontainerooo/kontainer.ooo/pkg/wireguard/pb"
+	oldcontext "golang.org/x/net/context"
+)
+
+// MakeGRPCServer makes a set of Endpoints available as a gRPC WireGuardServiceServer
+func MakeGRPCServer(ctx context.Context, endpoints Endpoints, logger log.Logger) pb.WireGuardServiceServer {
+	options := []grpctransport.ServerOption{
+		grpctransport.ServerErrorLogger(logger),
+	}
+
+	return &grpcServer{
+		createPeer: grpctransport.NewServer(
+			endpoints.CreatePeerEndpoint,
+			DecodeGRPCCreatePeerRequest,
+			EncodeGRPCCreatePeerResponse,
+			options...,
+		),
+
+		removePeer: grpctransport.NewServer(
+			endpoints.RemovePeerEndpoint,
+			DecodeGRPCRemovePeerRequest,
+			EncodeGRPCRemovePeerResponse,
+			options...,
+		),
+
+		peers: grpctransport.NewServer(
+			endpoints.PeersEndpoint,
+			DecodeGRPCPeersRequest,
+			EncodeGRPCPeersResponse,
+			options...,
+		),
+
+		rotateKey: grpctransport.NewServer(
+			endpoints.RotateKeyEndpoint,
+			DecodeGRPCRotateKeyRequest,
+			EncodeGRPCRotateKeyResponse,
+			options...,
+		),
+
+		setRules: grpctransport.NewServer(
+			endpoints.SetRulesEndpoint,
+			DecodeGRPCSetRulesRequest,
+			EncodeGRPCSetRulesResponse,
+			options...,
+		),
+	}
+}
+
+type grpcServer struct {
+	createPeer grpctransport.Handler
+	removePeer grpctransport.Handler
+	peers      grpctransport.Handler
+	rotateKey  grpctransport.Handler
+	setRules   grpctransport.Handler
+}
+
+func (s *grpcServer) CreatePeer(ctx oldcontext.Context, req *pb.CreatePeerRequest) (*pb.CreatePeerResponse, error) {
+	_, res, err := s.createPeer.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.CreatePeerResponse), nil
+}
+
+func (s *grpcServer) RemovePeer(ctx oldcontext.Context, req *pb.RemovePeerRequest) (*pb.RemovePeerResponse, error) {
+	_, res, err := s.removePeer.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RemovePeerResponse), nil
+}
+
+func (s *grpcServer) Peers(ctx oldcontext.Context, req *pb.PeersRequest) (*pb.PeersResponse, error) {
+	_, res, err := s.peers.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.PeersResponse), nil
+}
+
+func (s *grpcServer) RotateKey(ctx oldcontext.Context, req *pb.RotateKeyRequest) (*pb.RotateKeyResponse, error) {
+	_, res, err := s.rotateKey.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.RotateKeyResponse), nil
+}
+
+func (s *grpcServer) SetRules(ctx oldcontext.Context, req *pb.SetRulesRequest) (*pb.SetRulesResponse, error) {
+	_, res, err := s.setRules.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return res.(*pb.SetRulesResponse), nil
+}
+
+// ConvertPeer converts a Peer to its protobuf representation
+func ConvertPeer(p Peer) *pb.Peer {
+	return &pb.Peer{
+		ID:           uint32(p.ID),
+		Name:         p.Name,
+		PublicKey:    p.PublicKey,
+		Address:      string(p.Address),
+		Node:         p.Node,
+		Endpoint:     p.Endpoint,
+		Rules:        p.Rules,
+		Status:       p.Status,
+		KeyCreatedAt: p.KeyCreatedAt.Unix(),
+		CreatedAt:    p.CreatedAt.Unix(),
+	}
+}
+
+// ConvertPBPeer converts a protobuf Peer to a Peer
+func ConvertPBPeer(p *pb.Peer) Peer {
+	if p == nil {
+		return Peer{}
+	}
+
+	return Peer{
+		ID:           uint(p.ID),
+		Name:         p.Name,
+		PublicKey:    p.PublicKey,
+		Address:      abstraction.Inet(p.Address),
+		Node:         p.Node,
+		Endpoint:     p.Endpoint,
+		Rules:        p.Rules,
+		Status:       p.Status,
+		KeyCreatedAt: time.Unix(p.KeyCreatedAt, 0).UTC(),
+		CreatedAt:    time.Unix(p.CreatedAt, 0).UTC(),
+	}
+}
+
+// DecodeGRPCCreatePeerRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC CreatePeer request to a messages/wireguard.proto-domain createpeer request.
+func DecodeGRPCCreatePeerRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.CreatePeerRequest)
+	return CreatePeerRequest{
+		RefID: uint(req.RefID),
+		Peer: &Peer{
+			Name:  req.Name,
+			Rules: req.Rules,
+		},
+	}, nil
+}
+
+// EncodeGRPCCreatePeerResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain createpeer response to a gRPC CreatePeer response.
+func EncodeGRPCCreatePeerResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(CreatePeerResponse)
+	gRPCRes := &pb.CreatePeerResponse{
+		ID:     uint32(res.ID),
+		Config: res.Config,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRemovePeerRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RemovePeer request to a messages/wireguard.proto-domain removepeer request.
+func DecodeGRPCRemovePeerRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RemovePeerRequest)
+	return RemovePeerRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRemovePeerResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain removepeer response to a gRPC RemovePeer response.
+func EncodeGRPCRemovePeerResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RemovePeerResponse)
+	gRPCRes := &pb.RemovePeerResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCPeersRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC Peers request to a messages/wireguard.proto-domain peers request.
+func DecodeGRPCPeersRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.PeersRequest)
+	return PeersRequest{
+		RefID: uint(req.RefID),
+		Page:  paging.DecodePage(req.Page),
+	}, nil
+}
+
+// EncodeGRPCPeersResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain peers response to a gRPC Peers response.
+func EncodeGRPCPeersResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(PeersResponse)
+	peers := make([]*pb.Peer, len(res.Peers))
+	for i, p := range res.Peers {
+		peers[i] = ConvertPeer(p)
+	}
+
+	gRPCRes := &pb.PeersResponse{
+		Peers:    peers,
+		PageInfo: paging.EncodePageInfo(res.Page),
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCRotateKeyRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC RotateKey request to a messages/wireguard.proto-domain rotatekey request.
+func DecodeGRPCRotateKeyRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.RotateKeyRequest)
+	return RotateKeyRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+	}, nil
+}
+
+// EncodeGRPCRotateKeyResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain rotatekey response to a gRPC RotateKey response.
+func EncodeGRPCRotateKeyResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(RotateKeyResponse)
+	gRPCRes := &pb.RotateKeyResponse{
+		Config: res.Config,
+	}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
+
+// DecodeGRPCSetRulesRequest is a transport/grpc.DecodeRequestFunc that converts a
+// gRPC SetRules request to a messages/wireguard.proto-domain setrules request.
+func DecodeGRPCSetRulesRequest(_ context.Context, grpcReq interface{}) (interface{}, error) {
+	req := grpcReq.(*pb.SetRulesRequest)
+	return SetRulesRequest{
+		RefID: uint(req.RefID),
+		ID:    uint(req.ID),
+		Rules: req.Rules,
+	}, nil
+}
+
+// EncodeGRPCSetRulesResponse is a transport/grpc.EncodeRequestFunc that converts a
+// messages/wireguard.proto-domain setrules response to a gRPC SetRules response.
+func EncodeGRPCSetRulesResponse(_ context.Context, response interface{}) (interface{}, error) {
+	res := response.(SetRulesResponse)
+	gRPCRes := &pb.SetRulesResponse{}
+	if res.Error != nil {
+		gRPCRes.Error = res.Error.Error()
+	}
+	return gRPCRes, nil
+}
@@ -0,0 +1,13 @@
+package wireguard_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestWireguard(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Wireguard Suite")
+}
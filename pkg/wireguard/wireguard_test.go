@@ -0,0 +1,296 @@
+package wireguard_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kontainerooo/kontainer.ooo/pkg/firewall"
+	"github.com/kontainerooo/kontainer.ooo/pkg/testutils"
+	"github.com/kontainerooo/kontainer.ooo/pkg/wireguard"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// mockFirewall records the connections which are allowed
+type mockFirewall struct {
+	allowed map[string]bool
+}
+
+func (m *mockFirewall) endpoints() *firewall.Endpoints {
+	return &firewall.Endpoints{
+		AllowConnectionEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(firewall.AllowConnectionRequest)
+			m.allowed[string(req.SrcIP)+"@"+req.SrcNetwork+">"+string(req.DstIP)+"@"+req.DstNetwork] = true
+			return firewall.AllowConnectionResponse{}, nil
+		},
+		BlockConnectionEndpoint: func(ctx context.Context, request interface{}) (interface{}, error) {
+			req := request.(firewall.BlockConnectionRequest)
+			delete(m.allowed, string(req.SrcIP)+"@"+req.SrcNetwork+">"+string(req.DstIP)+"@"+req.DstNetwork)
+			return firewall.BlockConnectionResponse{}, nil
+		},
+	}
+}
+
+// mockDevice records the peers of the interfaces
+type mockDevice struct {
+	up    bool
+	peers map[string]string
+}
+
+func (m *mockDevice) Up(name string, keyFile string, port uint16, n *net.IPNet, gw net.IP) error {
+	m.up = true
+	return nil
+}
+
+func (m *mockDevice) Peers(name string) ([]string, error) {
+	keys := []string{}
+	for key := range m.peers {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (m *mockDevice) SetPeer(name string, publicKey string, address net.IP) error {
+	m.peers[publicKey] = address.String()
+	return nil
+}
+
+func (m *mockDevice) RemovePeer(name string, publicKey string) error {
+	delete(m.peers, publicKey)
+	return nil
+}
+
+// mockNetwork gives user 1 a bridge network with the instance web
+type mockNetwork struct{}
+
+func (mockNetwork) Bridge(refID uint) (*net.IPNet, string, error) {
+	if refID != 1 {
+		return nil, "", wireguard.ErrNoNetwork
+	}
+	_, n, _ := net.ParseCIDR("10.0.1.0/24")
+	return n, "kroo1", nil
+}
+
+func (mockNetwork) Address(refID uint, instance string) (net.IP, bool, error) {
+	if refID != 1 || instance != "web" {
+		return nil, false, nil
+	}
+	return net.ParseIP("10.0.1.2"), true, nil
+}
+
+var _ = Describe("Wireguard", func() {
+	var (
+		refID  = uint(1)
+		now    = time.Now()
+		fw     *mockFirewall
+		device *mockDevice
+		db     *testutils.MockDB
+		s      wireguard.Service
+	)
+
+	newService := func(node string) wireguard.Service {
+		_, pool, _ := net.ParseCIDR("10.99.0.0/29")
+		s, err := wireguard.NewService(db, fw.endpoints(), device, mockNetwork{}, wireguard.Options{
+			Node:      node,
+			Interface: "kroowg0",
+			Port:      51820,
+			Endpoint:  "vpn.kontainer.ooo",
+			Pool:      pool,
+			PublicKey: "server",
+			MaxPeers:  2,
+			MaxKeyAge: 24 * time.Hour,
+		})
+		Ω(err).ShouldNot(HaveOccurred())
+		return s
+	}
+
+	BeforeEach(func() {
+		fw = &mockFirewall{allowed: make(map[string]bool)}
+		device = &mockDevice{peers: make(map[string]string)}
+		db = testutils.NewMockDB()
+		s = newService("node1")
+	})
+
+	Describe("Create Service", func() {
+		It("Should return db error", func() {
+			db := testutils.NewMockDB()
+			db.SetError(1)
+			_, err := wireguard.NewService(db, nil, nil, nil, wireguard.Options{})
+			Ω(err).Should(HaveOccurred())
+		})
+	})
+
+	Describe("Keys", func() {
+		It("Should derive the public key of a private key", func() {
+			private, public, err := wireguard.GenerateKey()
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(wireguard.PublicKey(private)).To(Equal(public))
+
+			_, err = wireguard.PublicKey("key")
+			Expect(err).To(Equal(wireguard.ErrKey))
+		})
+
+		It("Should generate the key of the interface once", func() {
+			dir, err := ioutil.TempDir("", "wireguard")
+			Ω(err).ShouldNot(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			path := filepath.Join(dir, "private.key")
+			public, err := wireguard.LoadKey(path)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(wireguard.LoadKey(path)).To(Equal(public))
+
+			info, err := os.Stat(path)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+	})
+
+	Describe("Peers", func() {
+		It("Should create a peer and return its configuration", func() {
+			p := &wireguard.Peer{Name: "laptop"}
+			config, err := s.CreatePeer(refID, p)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(p.Address).To(BeEquivalentTo("10.99.0.2"))
+			Expect(p.Rules).To(ConsistOf(wireguard.AllInstances))
+			Expect(p.Status).To(Equal(wireguard.Active))
+
+			Expect(config).To(ContainSubstring("Address = 10.99.0.2/32"))
+			Expect(config).To(ContainSubstring("PublicKey = server"))
+			Expect(config).To(ContainSubstring("Endpoint = vpn.kontainer.ooo:51820"))
+			Expect(config).To(ContainSubstring("AllowedIPs = 10.0.1.0/24"))
+
+			ps := []wireguard.Peer{}
+			Ω(s.Peers(refID, &ps)).Should(Succeed())
+			Expect(ps).To(HaveLen(1))
+			Expect(config).NotTo(ContainSubstring(ps[0].PublicKey))
+		})
+
+		It("Should refuse duplicate names, invalid rules, users without a network and too many peers", func() {
+			_, err := s.CreatePeer(refID, &wireguard.Peer{Name: "a", Rules: []string{"web; rm"}})
+			Expect(err).To(Equal(wireguard.ErrRule))
+			_, err = s.CreatePeer(2, &wireguard.Peer{Name: "a"})
+			Expect(err).To(Equal(wireguard.ErrNoNetwork))
+
+			_, err = s.CreatePeer(refID, &wireguard.Peer{Name: "a"})
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = s.CreatePeer(refID, &wireguard.Peer{Name: "a"})
+			Expect(err).To(Equal(wireguard.ErrPeerExists))
+			_, err = s.CreatePeer(refID, &wireguard.Peer{Name: "b"})
+			Ω(err).ShouldNot(HaveOccurred())
+			_, err = s.CreatePeer(refID, &wireguard.Peer{Name: "c"})
+			Expect(err).To(Equal(wireguard.ErrLimit))
+		})
+
+		It("Should only remove peers of the user", func() {
+			p := &wireguard.Peer{Name: "laptop"}
+			_, err := s.CreatePeer(refID, p)
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Expect(s.RemovePeer(2, p.ID)).To(Equal(wireguard.ErrPeerNotExist))
+			Ω(s.RemovePeer(refID, p.ID)).Should(Succeed())
+			Expect(s.RemovePeer(refID, p.ID)).To(Equal(wireguard.ErrPeerNotExist))
+		})
+	})
+
+	Describe("Sync", func() {
+		var p *wireguard.Peer
+
+		BeforeEach(func() {
+			p = &wireguard.Peer{Name: "laptop"}
+			_, err := s.CreatePeer(refID, p)
+			Ω(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should connect a peer to the bridge network", func() {
+			Ω(s.Sync(now)).Should(Succeed())
+			Expect(device.up).To(BeTrue())
+			Expect(device.peers).To(Equal(map[string]string{p.PublicKey: "10.99.0.2"}))
+			Expect(fw.allowed).To(Equal(map[string]bool{"10.99.0.2@kroowg0>10.0.1.0/24@kroo1": true}))
+		})
+
+		It("Should restrict a peer to the instances of its rules", func() {
+			Ω(s.SetRules(refID, p.ID, []string{"web", "db"})).Should(Succeed())
+			Expect(s.SetRules(refID, p.ID, []string{})).To(Equal(wireguard.ErrRule))
+			Expect(s.SetRules(2, p.ID, []string{"web"})).To(Equal(wireguard.ErrPeerNotExist))
+
+			Ω(s.Sync(now)).Should(Succeed())
+			Expect(fw.allowed).To(Equal(map[string]bool{"10.99.0.2@kroowg0>10.0.1.2@kroo1": true}))
+
+			Ω(s.SetRules(refID, p.ID, []string{wireguard.AllInstances})).Should(Succeed())
+			Ω(s.Sync(now)).Should(Succeed())
+			Expect(fw.allowed).To(Equal(map[string]bool{"10.99.0.2@kroowg0>10.0.1.0/24@kroo1": true}))
+		})
+
+		It("Should replace the key of a peer", func() {
+			old := p.PublicKey
+			config, err := s.RotateKey(refID, p.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Expect(config).To(ContainSubstring("PrivateKey = "))
+
+			ps := []wireguard.Peer{}
+			Ω(s.Peers(refID, &ps)).Should(Succeed())
+			Expect(ps[0].PublicKey).NotTo(Equal(old))
+
+			Ω(s.Sync(now)).Should(Succeed())
+			Expect(device.peers).To(Equal(map[string]string{ps[0].PublicKey: "10.99.0.2"}))
+		})
+
+		It("Should disconnect peers with expired keys until they are rotated", func() {
+			Ω(s.Sync(now.Add(25 * time.Hour))).Should(Succeed())
+			Expect(device.peers).To(BeEmpty())
+			Expect(fw.allowed).To(BeEmpty())
+
+			_, err := s.RotateKey(refID, p.ID)
+			Ω(err).ShouldNot(HaveOccurred())
+			Ω(s.Sync(time.Now())).Should(Succeed())
+			Expect(device.peers).To(HaveLen(1))
+		})
+
+		It("Should disconnect the peers of suspended users until they are resumed", func() {
+			Ω(s.Sync(now)).Should(Succeed())
+
+			Ω(s.Suspend(refID, true)).Should(Succeed())
+			Ω(s.Sync(now)).Should(Succeed())
+			Expect(device.peers).To(BeEmpty())
+			Expect(fw.allowed).To(BeEmpty())
+
+			Ω(s.Suspend(refID, false)).Should(Succeed())
+			Ω(s.Sync(now)).Should(Succeed())
+			Expect(device.peers).To(HaveLen(1))
+			Expect(fw.allowed).To(HaveLen(1))
+		})
+
+		It("Should disconnect removed peers", func() {
+			Ω(s.Sync(now)).Should(Succeed())
+
+			Ω(s.RemovePeers(refID)).Should(Succeed())
+			Ω(s.Sync(now)).Should(Succeed())
+			Expect(device.peers).To(BeEmpty())
+			Expect(fw.allowed).To(BeEmpty())
+		})
+
+		It("Should only connect the peers of its node", func() {
+			// the mock database drops its tables when they are migrated, so the nodes are started first
+			db = testutils.NewMockDB()
+			other := newService("node2")
+			s = newService("node1")
+			_, err := s.CreatePeer(refID, &wireguard.Peer{Name: "laptop"})
+			Ω(err).ShouldNot(HaveOccurred())
+
+			Ω(other.Sync(now)).Should(Succeed())
+			Expect(device.peers).To(BeEmpty())
+			Expect(fw.allowed).To(BeEmpty())
+
+			ps := []wireguard.Peer{}
+			Ω(other.Peers(refID, &ps)).Should(Succeed())
+			Expect(strings.HasPrefix(ps[0].Endpoint, "vpn.kontainer.ooo")).To(BeTrue())
+		})
+	})
+})